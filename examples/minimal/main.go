@@ -3,16 +3,13 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log"
 
 	_ "github.com/mattn/go-sqlite3"
 
-	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus"
 	"github.com/nexus-db/nexus/pkg/core/schema"
-	"github.com/nexus-db/nexus/pkg/dialects"
-	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
 	"github.com/nexus-db/nexus/pkg/query"
 )
 
@@ -43,34 +40,25 @@ func main() {
 	}
 	fmt.Println("✓ Schema validated successfully")
 
-	// 3. Connect to SQLite
-	db, err := sql.Open("sqlite3", ":memory:")
+	// 3. Connect to SQLite, with the schema
+	client, err := nexus.Open(ctx, nexus.Config{URL: "sqlite::memory:", Schema: s})
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
+	defer client.Close()
 
-	dialect := sqlite.New()
-	conn := dialects.NewConnection(db, dialect)
-
-	// 4. Generate and apply migrations
-	engine := migration.NewEngine(conn)
-	if err := engine.Init(ctx); err != nil {
-		log.Fatal(err)
-	}
-
-	// Create tables manually (in production, use migration files)
+	// 4. Create tables (in production, use migration files and client.Migrate.Up)
 	for _, model := range s.GetModels() {
-		sql := dialect.CreateTableSQL(model)
+		sql := client.Conn.Dialect.CreateTableSQL(model)
 		fmt.Printf("Creating table: %s\n", model.Name)
-		if _, err := conn.Exec(ctx, sql); err != nil {
+		if _, err := client.Conn.Exec(ctx, sql); err != nil {
 			log.Fatal(err)
 		}
 	}
 	fmt.Println("✓ Tables created successfully")
 
 	// 5. Use query builder
-	users := query.New(conn, "User")
+	users := client.Model("User")
 
 	// Insert a user
 	_, err = users.Insert(map[string]interface{}{
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/bench"
+)
+
+// BenchOptions configures a benchmark run.
+type BenchOptions struct {
+	// Workload is a JSON profiler capture or a YAML workload spec.
+	Workload string
+	// Target is the database to run against (default from config).
+	Target      string
+	Concurrency int
+	Duration    time.Duration
+	Iterations  int
+	AllowWrites bool
+	// OutputFormat is "text" or "json".
+	OutputFormat string
+}
+
+// DefaultBenchOptions returns sensible defaults.
+func DefaultBenchOptions() BenchOptions {
+	defaults := bench.DefaultOptions()
+	return BenchOptions{
+		Concurrency:  defaults.Concurrency,
+		Duration:     defaults.Duration,
+		OutputFormat: "text",
+	}
+}
+
+// Bench replays a workload against the target database and prints the
+// throughput and latency percentiles of each query pattern. Ctrl+C stops
+// the run early and prints what was measured.
+func Bench(opts BenchOptions) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	workload, err := bench.LoadWorkload(opts.Workload)
+	if err != nil {
+		return err
+	}
+
+	conn, err := connectURL(config, opts.Target)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, stop := signal.NotifyContext(commandContext(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if opts.OutputFormat != "json" {
+		until := fmt.Sprintf("for %s", opts.Duration)
+		if opts.Iterations > 0 {
+			until = fmt.Sprintf("for %d statements", opts.Iterations)
+		}
+		fmt.Printf("▶ Running %d statement(s) of %s on %s with %d worker(s) %s\n",
+			len(workload.Queries), opts.Workload, conn.Dialect.Name(), opts.Concurrency, until)
+	}
+
+	report, err := bench.Run(ctx, conn, workload, bench.Options{
+		Concurrency: opts.Concurrency,
+		Duration:    opts.Duration,
+		Iterations:  opts.Iterations,
+		AllowWrites: opts.AllowWrites,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.OutputFormat == "json" {
+		out, err := json.MarshalIndent(benchReportJSON(report), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(out))
+		return nil
+	}
+	if report.Skipped > 0 {
+		fmt.Printf("⚠ Skipped %d write statement(s); use --allow-writes to run them\n", report.Skipped)
+	}
+	fmt.Println(report.String())
+	return nil
+}
+
+// benchReportJSON converts a report for JSON output, with durations in
+// milliseconds.
+func benchReportJSON(r *bench.Report) map[string]interface{} {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	patterns := make([]map[string]interface{}, 0, len(r.Patterns))
+	for _, p := range r.Patterns {
+		entry := map[string]interface{}{
+			"pattern":    p.Pattern,
+			"count":      p.Count,
+			"errors":     p.Errors,
+			"throughput": p.Throughput,
+			"mean_ms":    ms(p.Mean),
+			"p50_ms":     ms(p.P50),
+			"p95_ms":     ms(p.P95),
+			"p99_ms":     ms(p.P99),
+			"max_ms":     ms(p.Max),
+		}
+		if p.Baseline > 0 {
+			entry["baseline_ms"] = ms(p.Baseline)
+		}
+		if p.FirstError != "" {
+			entry["first_error"] = p.FirstError
+		}
+		patterns = append(patterns, entry)
+	}
+	return map[string]interface{}{
+		"dialect":     r.Dialect,
+		"concurrency": r.Concurrency,
+		"duration_ms": ms(r.Elapsed),
+		"statements":  r.Total,
+		"errors":      r.Errors,
+		"skipped":     r.Skipped,
+		"throughput":  r.Throughput,
+		"patterns":    patterns,
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+// completionTimeout bounds how long completing table names waits for the
+// database, so that the shell stays responsive.
+const completionTimeout = 2 * time.Second
+
+// CompleteMigrations returns the IDs of the migrations of migrations/
+// starting with prefix, for shell completion. Each is followed by a tab
+// and the name of the migration, which shells show as its description.
+func CompleteMigrations(prefix string) []string {
+	files, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".sql") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSuffix(f.Name(), ".sql"), "_", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		if id := parts[0] + "_" + parts[1]; strings.HasPrefix(id, prefix) {
+			ids = append(ids, id+"\t"+parts[2])
+		}
+	}
+	return ids
+}
+
+// CompleteSeedEnvs returns the environments of seeds/, its directories,
+// starting with prefix.
+func CompleteSeedEnvs(prefix string) []string {
+	entries, err := os.ReadDir(seedsDir)
+	if err != nil {
+		return nil
+	}
+	var envs []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && strings.HasPrefix(entry.Name(), prefix) {
+			envs = append(envs, entry.Name())
+		}
+	}
+	return envs
+}
+
+// CompleteTables returns the tables of the database starting with prefix,
+// introspected, leaving out those of Nexus. It gives up, returning none,
+// when the database doesn't answer in time.
+func CompleteTables(prefix string) []string {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil
+	}
+	dialect, err := getDialect(config.Database.Dialect)
+	if err != nil {
+		return nil
+	}
+	introspector, ok := dialect.(migration.Introspector)
+	if !ok {
+		return nil
+	}
+	db, err := openDB(config.Database, dialect)
+	if err != nil {
+		return nil
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(commandContext(), completionTimeout)
+	defer cancel()
+	tables, err := introspector.IntrospectTables(ctx, db)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, table := range tables {
+		if strings.HasPrefix(table, prefix) && !strings.HasPrefix(table, "_nexus_") {
+			names = append(names, table)
+		}
+	}
+	return names
+}
+
+// CompleteEnvironments returns the environments of the "env" blocks of
+// nexus.json starting with prefix.
+func CompleteEnvironments(prefix string) []string {
+	return configKeys(func(raw rawConfig) map[string]json.RawMessage { return raw.Env }, prefix)
+}
+
+// CompleteDatabases returns the names of the "databases" of nexus.json
+// starting with prefix.
+func CompleteDatabases(prefix string) []string {
+	return configKeys(func(raw rawConfig) map[string]json.RawMessage { return raw.Databases }, prefix)
+}
+
+// rawConfig is the part of nexus.json completion reads as written, before
+// environments are applied.
+type rawConfig struct {
+	Env       map[string]json.RawMessage `json:"env"`
+	Databases map[string]json.RawMessage `json:"databases"`
+}
+
+// configKeys returns the sorted keys of the object of nexus.json that
+// field picks.
+func configKeys(field func(rawConfig) map[string]json.RawMessage, prefix string) []string {
+	data, err := os.ReadFile(configFileName)
+	if err != nil {
+		return nil
+	}
+	var raw rawConfig
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	var keys []string
+	for key := range field(raw) {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// selection is the environment and database the commands use, set from
+// the --environment and --db flags.
+var selection struct {
+	environment string
+	database    string
+}
+
+// SelectConfig makes LoadConfig apply the "env" block of environment
+// (default $NEXUS_ENV) over the config, and use the database of the
+// "databases" map named database instead of "database" (overridden by
+// $NEXUS_DATABASE_URL).
+func SelectConfig(environment, database string) {
+	selection.environment = environment
+	selection.database = database
+}
+
+// selectedEnvironment returns the environment selected with --environment,
+// or else $NEXUS_ENV.
+func selectedEnvironment() string {
+	if selection.environment != "" {
+		return selection.environment
+	}
+	return os.Getenv("NEXUS_ENV")
+}
+
+// LoadConfigFile reads a config file. String values may refer to
+// environment variables as ${NAME}, or ${NAME:-default} to use default when
+// the variable is unset or empty. The block of the selected environment,
+// such as
+//
+//	"env": {"production": {"database": {"url": "${DATABASE_URL}"}}}
+//
+// is merged over the rest of the file, object by object.
+func LoadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+
+	environment := selectedEnvironment()
+	envs, _ := raw["env"].(map[string]interface{})
+	delete(raw, "env")
+	if environment != "" {
+		block, ok := envs[environment].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unknown environment %q (%s defines %s)", environment, path, names(envs))
+		}
+		mergeConfig(raw, block)
+	}
+
+	unset := make(map[string][]string)
+	raw = interpolate(raw, "", unset).(map[string]interface{})
+
+	data, err = json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+
+	// Databases fail when they are connected to, so that commands that
+	// don't connect, and databases not used, need no variables set
+	config.Database.unset = unsetError(unset, "database")
+	for name, db := range config.Databases {
+		db.unset = unsetError(unset, "databases."+name)
+		config.Databases[name] = db
+	}
+	for setting := range unset {
+		if setting != "database" && !strings.HasPrefix(setting, "database.") && !strings.HasPrefix(setting, "databases.") {
+			return nil, unsetError(unset, setting)
+		}
+	}
+
+	if selection.database != "" {
+		db, ok := config.Databases[selection.database]
+		if !ok {
+			return nil, fmt.Errorf("unknown database %q (%s defines %s)", selection.database, path, names(config.Databases))
+		}
+		config.Database = db
+	} else if url := os.Getenv("NEXUS_DATABASE_URL"); url != "" {
+		config.Database = overrideURL(config.Database, url)
+	}
+
+	if err := config.Logging.validate(); err != nil {
+		return nil, fmt.Errorf("logging: %w", err)
+	}
+	return &config, nil
+}
+
+// overrideURL returns db connecting to url instead, as set by
+// $NEXUS_DATABASE_URL. The dialect is that of url when it tells, as
+// postgres://... does, and the configured one otherwise.
+func overrideURL(db DatabaseConfig, url string) DatabaseConfig {
+	db.URL = url
+	if dialect, dsn, err := dialects.ParseURL(url); err == nil {
+		db.Dialect, db.URL = dialect, dsn
+	}
+	db.unset = nil
+	return db
+}
+
+// mergeConfig merges src into dst: objects are merged key by key, and
+// other values replace those of dst.
+func mergeConfig(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if obj, ok := value.(map[string]interface{}); ok {
+			if existing, ok := dst[key].(map[string]interface{}); ok {
+				mergeConfig(existing, obj)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate replaces the environment variable references of the strings
+// of v, recording the variables that are unset, with no default, by the
+// path of the value they are in.
+func interpolate(v interface{}, path string, unset map[string][]string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return envReference.ReplaceAllStringFunc(v, func(ref string) string {
+			m := envReference.FindStringSubmatch(ref)
+			if value := os.Getenv(m[1]); value != "" {
+				return value
+			}
+			if m[2] == "" {
+				unset[path] = append(unset[path], m[1])
+			}
+			return m[3]
+		})
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = interpolate(value, joinPath(path, key), unset)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = interpolate(value, fmt.Sprintf("%s[%d]", path, i), unset)
+		}
+	}
+	return v
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// unsetError reports the unset environment variables of the settings
+// under prefix, or returns nil if there are none.
+func unsetError(unset map[string][]string, prefix string) error {
+	var paths []string
+	for path := range unset {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	sort.Strings(paths)
+	return fmt.Errorf("%s: environment variable %s is not set", paths[0], unset[paths[0]][0])
+}
+
+// names lists the keys of m for error messages.
+func names[V any](m map[string]V) string {
+	if len(m) == 0 {
+		return "none"
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,855 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// ConsoleOptions configures nexus console.
+type ConsoleOptions struct {
+	Command string // Run this command and exit instead of reading commands
+	History string // File the commands are kept in across sessions; empty keeps none
+}
+
+// DefaultConsoleOptions returns the default console options.
+func DefaultConsoleOptions() ConsoleOptions {
+	opts := ConsoleOptions{}
+	if home, err := os.UserHomeDir(); err == nil {
+		opts.History = filepath.Join(home, ".nexus_history")
+	}
+	return opts
+}
+
+// Console runs an interactive shell on the configured database, with the
+// schema loaded for query builder expressions.
+func Console(opts ConsoleOptions) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	conn, err := connect(config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+
+	ctx := commandContext()
+	sh := NewShell(conn, s, stdout)
+	if opts.Command != "" {
+		if err := sh.Exec(ctx, opts.Command); !errors.Is(err, errQuit) {
+			return err
+		}
+		return nil
+	}
+
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		sh.Prompt = true
+		fmt.Printf("Nexus console (%s). Type \\? for help, \\q to quit.\n", conn.Dialect.Name())
+	}
+	if opts.History != "" {
+		sh.LoadHistory(opts.History)
+	}
+	return sh.Run(ctx, os.Stdin)
+}
+
+// errQuit ends Run.
+var errQuit = errors.New("quit")
+
+// maxHistory is how many commands the history keeps.
+const maxHistory = 500
+
+// Shell reads commands and prints their results: SQL statements ending
+// with a semicolon, query builder expressions such as
+// users.select(id, email).where(active = true).limit(5), and backslash
+// commands such as \d users.
+type Shell struct {
+	Prompt bool // Print prompts, for terminals
+
+	conn     *dialects.Connection
+	schema   *schema.Schema // Nil without a schema
+	out      io.Writer
+	history  []string
+	file     string // History file, if any
+	expanded bool   // Print a record per block instead of a table
+	timing   bool   // Print how long commands take
+}
+
+// NewShell returns a shell on conn that prints to out. s resolves the
+// model names of expressions and may be nil.
+func NewShell(conn *dialects.Connection, s *schema.Schema, out io.Writer) *Shell {
+	return &Shell{conn: conn, schema: s, out: out}
+}
+
+// LoadHistory reads the history of earlier sessions from file, and keeps
+// the commands of this one there.
+func (sh *Shell) LoadHistory(file string) {
+	sh.file = file
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			sh.history = append(sh.history, strings.ReplaceAll(line, "\\n", "\n"))
+		}
+	}
+	sh.history = sh.history[max(0, len(sh.history)-maxHistory):]
+}
+
+// remember adds a command to the history.
+func (sh *Shell) remember(command string) {
+	if n := len(sh.history); n > 0 && sh.history[n-1] == command {
+		return
+	}
+	sh.history = append(sh.history, command)
+	if sh.file == "" {
+		return
+	}
+	f, err := os.OpenFile(sh.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, strings.ReplaceAll(command, "\n", "\\n"))
+}
+
+// Run reads commands from in until it ends or \q. SQL statements may span
+// lines up to their semicolon. !! runs the last command again and !n the
+// nth of \history. Errors are printed, not returned.
+func (sh *Shell) Run(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var pending strings.Builder
+	prompt := func() {
+		if !sh.Prompt {
+			return
+		}
+		if pending.Len() == 0 {
+			fmt.Fprint(sh.out, "nexus> ")
+		} else {
+			fmt.Fprint(sh.out, "    -> ")
+		}
+	}
+
+	for prompt(); scanner.Scan(); prompt() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		var command string
+		switch {
+		case pending.Len() > 0:
+			pending.WriteString(line + "\n")
+			if !strings.HasSuffix(trimmed, ";") {
+				continue
+			}
+			command = strings.TrimSpace(pending.String())
+			pending.Reset()
+		case trimmed == "":
+			continue
+		case strings.HasPrefix(trimmed, "!"):
+			recalled, err := sh.recall(trimmed)
+			if err != nil {
+				fmt.Fprintf(sh.out, "ERROR: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(sh.out, recalled)
+			command = recalled
+		case isShellCommand(trimmed) || strings.HasSuffix(trimmed, ";"):
+			command = trimmed
+		default:
+			pending.WriteString(line + "\n")
+			continue
+		}
+
+		sh.remember(command)
+		if err := sh.Exec(ctx, command); err != nil {
+			if errors.Is(err, errQuit) {
+				return nil
+			}
+			fmt.Fprintf(sh.out, "ERROR: %v\n", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// recall returns the command of a !! or !n history reference.
+func (sh *Shell) recall(ref string) (string, error) {
+	if len(sh.history) == 0 {
+		return "", fmt.Errorf("history is empty")
+	}
+	if ref == "!!" {
+		return sh.history[len(sh.history)-1], nil
+	}
+	n, err := strconv.Atoi(ref[1:])
+	if err != nil || n < 1 || n > len(sh.history) {
+		return "", fmt.Errorf("no command %s in history", ref)
+	}
+	return sh.history[n-1], nil
+}
+
+// expressionStart matches the start of a query builder expression: a
+// table or model name followed by a method.
+var expressionStart = regexp.MustCompile(`^[A-Za-z_]\w*\s*\.\s*[A-Za-z_]\w*`)
+
+// isShellCommand reports whether a line is a complete command without a
+// semicolon: a backslash command, an expression or a way out.
+func isShellCommand(line string) bool {
+	switch strings.ToLower(line) {
+	case "exit", "quit", "help":
+		return true
+	}
+	return strings.HasPrefix(line, `\`) || expressionStart.MatchString(line)
+}
+
+// Exec runs one command and prints its result.
+func (sh *Shell) Exec(ctx context.Context, command string) error {
+	command = strings.TrimSpace(command)
+	switch strings.ToLower(command) {
+	case "exit", "quit":
+		return errQuit
+	case "help":
+		return sh.meta(ctx, `\?`)
+	}
+
+	start := time.Now()
+	var err error
+	switch {
+	case strings.HasPrefix(command, `\`):
+		err = sh.meta(ctx, command)
+	case expressionStart.MatchString(command):
+		err = sh.expression(ctx, strings.TrimSuffix(command, ";"))
+	default:
+		err = sh.sql(ctx, strings.TrimSuffix(command, ";"))
+	}
+	if err == nil && sh.timing {
+		fmt.Fprintf(sh.out, "Time: %s\n", formatElapsed(time.Since(start)))
+	}
+	return err
+}
+
+// meta runs a backslash command.
+func (sh *Shell) meta(ctx context.Context, command string) error {
+	name, arg, _ := strings.Cut(command, " ")
+	arg = strings.TrimSpace(strings.TrimSuffix(arg, ";"))
+	switch name {
+	case `\q`:
+		return errQuit
+	case `\?`, `\h`:
+		fmt.Fprint(sh.out, shellHelp)
+	case `\d`, `\dt`:
+		if arg == "" {
+			return sh.listTables(ctx)
+		}
+		return sh.describe(ctx, arg)
+	case `\x`:
+		sh.expanded = !sh.expanded
+		fmt.Fprintf(sh.out, "Expanded display is %s.\n", onOff(sh.expanded))
+	case `\timing`:
+		sh.timing = !sh.timing
+		fmt.Fprintf(sh.out, "Timing is %s.\n", onOff(sh.timing))
+	case `\history`, `\s`:
+		for i, c := range sh.history {
+			fmt.Fprintf(sh.out, "%4d  %s\n", i+1, strings.ReplaceAll(c, "\n", "\n      "))
+		}
+	default:
+		return fmt.Errorf(`unknown command %s; type \? for help`, name)
+	}
+	return nil
+}
+
+const shellHelp = `Commands:
+  SELECT ...;                     Run SQL, up to a semicolon
+  users.where(age > 18).limit(5)  Query a table or model with the query builder
+  \d                              List tables
+  \d <table|model>                Describe a table: columns, indexes, foreign keys
+  \x                              Toggle expanded display
+  \timing                         Toggle command timing
+  \history                        List earlier commands; !! runs the last, !n the nth
+  \q                              Quit
+
+Expression methods:
+  select(col, ...)                Columns to return (default all)
+  where(col op value, ...)        Conditions; op is =, !=, <, <=, >, >=, like, in or is [not] null.
+                                  where("raw SQL") adds a condition as is
+  orderBy(col [asc|desc])         Sort; may be repeated
+  limit(n), offset(n)
+  first(), count(), sql()         Return one row, count the rows, or print the SQL without running it
+`
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// sql runs a SQL statement, printing its rows or the rows it changed.
+func (sh *Shell) sql(ctx context.Context, stmt string) error {
+	if !returnsRows(stmt) {
+		result, err := sh.conn.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err == nil && n > 0 {
+			fmt.Fprintf(sh.out, "OK, %d row(s) affected\n", n)
+		} else {
+			fmt.Fprintln(sh.out, "OK")
+		}
+		return nil
+	}
+	return sh.query(ctx, stmt)
+}
+
+// query runs a query and prints its rows.
+func (sh *Shell) query(ctx context.Context, stmt string, args ...interface{}) error {
+	rows, err := sh.conn.Query(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var records [][]string
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		record := make([]string, len(columns))
+		for i, v := range values {
+			record[i] = formatCell(v)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	sh.printRecords(columns, records)
+	return nil
+}
+
+// returnsRows reports whether a statement returns rows to print.
+func returnsRows(stmt string) bool {
+	words := strings.Fields(strings.ToUpper(stmt))
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "SELECT", "WITH", "PRAGMA", "SHOW", "EXPLAIN", "VALUES", "DESCRIBE", "DESC", "TABLE":
+		return true
+	}
+	for _, w := range words {
+		if w == "RETURNING" || w == "OUTPUT" {
+			return true
+		}
+	}
+	return false
+}
+
+// formatCell formats a value of a row for display.
+func formatCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return strings.ReplaceAll(string(v), "\n", `\n`)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04:05")
+	case string:
+		return strings.ReplaceAll(v, "\n", `\n`)
+	}
+	return fmt.Sprint(v)
+}
+
+// printRecords prints rows as a table, or a block per row when expanded.
+func (sh *Shell) printRecords(columns []string, records [][]string) {
+	if sh.expanded {
+		width := 0
+		for _, c := range columns {
+			width = max(width, utf8.RuneCountInString(c))
+		}
+		for i, record := range records {
+			fmt.Fprintf(sh.out, "-[ RECORD %d ]%s\n", i+1, strings.Repeat("-", width))
+			for j, c := range columns {
+				fmt.Fprintf(sh.out, "%s | %s\n", pad(c, width), record[j])
+			}
+		}
+	} else {
+		widths := make([]int, len(columns))
+		for i, c := range columns {
+			widths[i] = utf8.RuneCountInString(c)
+		}
+		for _, record := range records {
+			for i, v := range record {
+				widths[i] = max(widths[i], utf8.RuneCountInString(v))
+			}
+		}
+		line := func(cells []string) {
+			parts := make([]string, len(cells))
+			for i, c := range cells {
+				parts[i] = pad(c, widths[i])
+			}
+			fmt.Fprintln(sh.out, strings.TrimRight(" "+strings.Join(parts, " | "), " "))
+		}
+		line(columns)
+		rules := make([]string, len(columns))
+		for i, w := range widths {
+			rules[i] = strings.Repeat("-", w+2)
+		}
+		fmt.Fprintln(sh.out, strings.Join(rules, "+"))
+		for _, record := range records {
+			line(record)
+		}
+	}
+	if len(records) == 1 {
+		fmt.Fprintln(sh.out, "(1 row)")
+	} else {
+		fmt.Fprintf(sh.out, "(%d rows)\n", len(records))
+	}
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+}
+
+// table returns the table of a model name of the schema, or name itself.
+func (sh *Shell) table(name string) string {
+	if sh.schema == nil {
+		return name
+	}
+	if m, ok := sh.schema.Models[name]; ok {
+		return m.Table()
+	}
+	for _, m := range sh.schema.Models {
+		if strings.EqualFold(m.Name, name) {
+			return m.Table()
+		}
+	}
+	return name
+}
+
+// introspector returns the introspector of the dialect.
+func (sh *Shell) introspector() (migration.Introspector, error) {
+	introspector, ok := sh.conn.Dialect.(migration.Introspector)
+	if !ok {
+		return nil, fmt.Errorf("dialect %s does not support introspection", sh.conn.Dialect.Name())
+	}
+	return introspector, nil
+}
+
+// listTables prints the tables of the database and their models.
+func (sh *Shell) listTables(ctx context.Context) error {
+	introspector, err := sh.introspector()
+	if err != nil {
+		return err
+	}
+	tables, err := introspector.IntrospectTables(ctx, sh.conn.DB)
+	if err != nil {
+		return err
+	}
+	models := make(map[string]string)
+	if sh.schema != nil {
+		for _, m := range sh.schema.Models {
+			models[m.Table()] = m.Name
+		}
+	}
+	sort.Strings(tables)
+	var records [][]string
+	for _, t := range tables {
+		if !strings.HasPrefix(t, "_nexus_") {
+			records = append(records, []string{t, models[t]})
+		}
+	}
+	sh.printRecords([]string{"table", "model"}, records)
+	return nil
+}
+
+// describe prints the columns, indexes and foreign keys of a table.
+func (sh *Shell) describe(ctx context.Context, name string) error {
+	introspector, err := sh.introspector()
+	if err != nil {
+		return err
+	}
+	table := sh.table(name)
+	columns, err := introspector.IntrospectColumns(ctx, sh.conn.DB, table)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("table %s not found", table)
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Position < columns[j].Position })
+
+	fmt.Fprintf(sh.out, "Table %q\n", table)
+	var records [][]string
+	for _, c := range columns {
+		var key []string
+		if c.IsPrimaryKey {
+			key = append(key, "PK")
+		}
+		if c.IsUnique {
+			key = append(key, "UNIQUE")
+		}
+		if c.AutoInc {
+			key = append(key, "AUTO")
+		}
+		nullable := "not null"
+		if c.Nullable {
+			nullable = "null"
+		}
+		records = append(records, []string{c.Name, c.Type, nullable, c.Default, strings.Join(key, " ")})
+	}
+	sh.printRecords([]string{"column", "type", "nullable", "default", "key"}, records)
+
+	indexes, err := introspector.IntrospectIndexes(ctx, sh.conn.DB, table)
+	if err != nil {
+		return err
+	}
+	if len(indexes) > 0 {
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+		fmt.Fprintln(sh.out, "Indexes:")
+		for _, idx := range indexes {
+			unique := ""
+			if idx.Unique {
+				unique = " UNIQUE"
+			}
+			fmt.Fprintf(sh.out, "    %s (%s)%s\n", idx.Name, strings.Join(idx.Columns, ", "), unique)
+		}
+	}
+	fks, err := introspector.IntrospectForeignKeys(ctx, sh.conn.DB, table)
+	if err != nil {
+		return err
+	}
+	if len(fks) > 0 {
+		fmt.Fprintln(sh.out, "Foreign keys:")
+		for _, fk := range fks {
+			fmt.Fprintf(sh.out, "    (%s) -> %s(%s)", strings.Join(fk.Columns, ", "), fk.RefTable, strings.Join(fk.RefColumns, ", "))
+			if fk.OnDelete != "" && fk.OnDelete != "NO ACTION" {
+				fmt.Fprintf(sh.out, " ON DELETE %s", fk.OnDelete)
+			}
+			fmt.Fprintln(sh.out)
+		}
+	}
+	return nil
+}
+
+// expressionCall is a method of an expression with its arguments.
+type expressionCall struct {
+	name string
+	args []string
+}
+
+// expression runs a query builder expression.
+func (sh *Shell) expression(ctx context.Context, expr string) error {
+	name, calls, err := parseExpression(expr)
+	if err != nil {
+		return err
+	}
+
+	var (
+		columns    []string
+		conditions []query.Condition
+		orders     []query.OrderBy
+		limit      = -1
+		offset     = -1
+		terminal   = "all"
+	)
+	for _, call := range calls {
+		switch strings.ToLower(call.name) {
+		case "select":
+			columns = append(columns, call.args...)
+		case "where":
+			for _, arg := range call.args {
+				cond, err := parseCondition(arg)
+				if err != nil {
+					return err
+				}
+				conditions = append(conditions, cond)
+			}
+		case "orderby", "order":
+			for _, arg := range call.args {
+				fields := strings.Fields(arg)
+				if len(fields) == 0 || len(fields) > 2 {
+					return fmt.Errorf("invalid order %q (expected column [asc|desc])", arg)
+				}
+				order := query.OrderBy{Column: fields[0]}
+				if len(fields) == 2 {
+					switch strings.ToLower(fields[1]) {
+					case "asc":
+					case "desc":
+						order.Direction = query.Desc
+					default:
+						return fmt.Errorf("invalid order direction %q", fields[1])
+					}
+				}
+				orders = append(orders, order)
+			}
+		case "limit", "offset":
+			if len(call.args) != 1 {
+				return fmt.Errorf("%s takes one number", call.name)
+			}
+			n, err := strconv.Atoi(call.args[0])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid %s %q", call.name, call.args[0])
+			}
+			if strings.EqualFold(call.name, "limit") {
+				limit = n
+			} else {
+				offset = n
+			}
+		case "all", "first", "count", "sql":
+			terminal = strings.ToLower(call.name)
+		default:
+			return fmt.Errorf(`unknown method %s; type \? for help`, call.name)
+		}
+	}
+
+	sb := query.New(sh.conn, sh.table(name)).Select(columns...).Where(conditions...)
+	for _, o := range orders {
+		sb.OrderBy(o.Column, o.Direction)
+	}
+	if terminal == "first" {
+		limit = 1
+	}
+	if limit >= 0 {
+		sb.Limit(limit)
+	}
+	if offset >= 0 {
+		sb.Offset(offset)
+	}
+
+	switch terminal {
+	case "count":
+		n, err := sb.Count(ctx)
+		if err != nil {
+			return err
+		}
+		sh.printRecords([]string{"count"}, [][]string{{strconv.FormatInt(n, 10)}})
+		return nil
+	case "sql":
+		stmt, args := sb.Build()
+		fmt.Fprintln(sh.out, stmt)
+		if len(args) > 0 {
+			fmt.Fprintf(sh.out, "-- args: %v\n", args)
+		}
+		return nil
+	}
+	stmt, args := sb.Build()
+	return sh.query(ctx, stmt, args...)
+}
+
+// parseExpression splits name.method(args).method(args) into the name
+// and the calls. Arguments are split at top-level commas.
+func parseExpression(expr string) (string, []expressionCall, error) {
+	name, rest, _ := strings.Cut(expr, ".")
+	name = strings.TrimSpace(name)
+	var calls []expressionCall
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		end := strings.IndexAny(rest, ".(")
+		if end < 0 {
+			end = len(rest)
+		}
+		call := expressionCall{name: strings.TrimSpace(rest[:end])}
+		rest = strings.TrimSpace(rest[end:])
+		if strings.HasPrefix(rest, "(") {
+			inner, after, err := splitParens(rest)
+			if err != nil {
+				return "", nil, err
+			}
+			call.args = splitArgs(inner)
+			rest = strings.TrimSpace(after)
+		}
+		calls = append(calls, call)
+		if rest == "" {
+			break
+		}
+		if !strings.HasPrefix(rest, ".") {
+			return "", nil, fmt.Errorf("unexpected %q in expression", rest)
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+	return name, calls, nil
+}
+
+// splitParens returns what is inside the parentheses s starts with, and
+// what follows them, skipping quoted strings.
+func splitParens(s string) (string, string, error) {
+	depth := 0
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], s[i+1:], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("unbalanced parentheses in %q", s)
+}
+
+// splitArgs splits s at the commas outside quotes and parentheses.
+func splitArgs(s string) []string {
+	var args []string
+	depth, start := 0, 0
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(args) > 0 {
+		args = append(args, last)
+	}
+	return args
+}
+
+// conditionPattern matches "column op value" conditions.
+var conditionPattern = regexp.MustCompile(`(?i)^([A-Za-z_][\w.]*)\s*(==|=|!=|<>|<=|>=|<|>|\blike\b|\bin\b|\bis\s+not\s+null\b|\bis\s+null\b)\s*(.*)$`)
+
+// parseCondition parses a where argument: column op value, or a quoted
+// string of raw SQL.
+func parseCondition(arg string) (query.Condition, error) {
+	if len(arg) >= 2 && (arg[0] == '"' || arg[0] == '\'') && arg[len(arg)-1] == arg[0] {
+		raw, err := parseValue(arg)
+		if err != nil {
+			return query.Condition{}, err
+		}
+		return query.RawSQL(raw.(string)), nil
+	}
+	m := conditionPattern.FindStringSubmatch(arg)
+	if m == nil {
+		return query.Condition{}, fmt.Errorf("invalid condition %q (expected column op value)", arg)
+	}
+	column, op, rest := m[1], strings.ToLower(strings.Join(strings.Fields(m[2]), " ")), strings.TrimSpace(m[3])
+	switch op {
+	case "is null", "is not null":
+		if rest != "" {
+			return query.Condition{}, fmt.Errorf("unexpected %q after %s", rest, op)
+		}
+		if op == "is null" {
+			return query.IsNull(column), nil
+		}
+		return query.IsNotNull(column), nil
+	case "in":
+		if len(rest) < 2 || !(rest[0] == '(' && rest[len(rest)-1] == ')' || rest[0] == '[' && rest[len(rest)-1] == ']') {
+			return query.Condition{}, fmt.Errorf("in takes a list, e.g. %s in (1, 2)", column)
+		}
+		var values []interface{}
+		for _, item := range splitArgs(rest[1 : len(rest)-1]) {
+			v, err := parseValue(item)
+			if err != nil {
+				return query.Condition{}, err
+			}
+			values = append(values, v)
+		}
+		return query.In(column, values...), nil
+	}
+
+	value, err := parseValue(rest)
+	if err != nil {
+		return query.Condition{}, err
+	}
+	switch op {
+	case "=", "==":
+		if value == nil {
+			return query.IsNull(column), nil
+		}
+		return query.Eq(column, value), nil
+	case "!=", "<>":
+		if value == nil {
+			return query.IsNotNull(column), nil
+		}
+		return query.Neq(column, value), nil
+	case "<":
+		return query.Lt(column, value), nil
+	case "<=":
+		return query.Lte(column, value), nil
+	case ">":
+		return query.Gt(column, value), nil
+	case ">=":
+		return query.Gte(column, value), nil
+	}
+	pattern, ok := value.(string)
+	if !ok {
+		return query.Condition{}, fmt.Errorf("like takes a string pattern")
+	}
+	return query.Like(column, pattern), nil
+}
+
+// parseValue parses a literal of an expression: a quoted string, a
+// number, true, false or null.
+func parseValue(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strconv.Unquote(s)
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid value %q (quote strings)", s)
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// DBPullOptions configures schema introspection.
+type DBPullOptions struct {
+	Output string // Schema file to write; defaults to the configured schema path
+	Force  bool   // Overwrite an existing schema file
+	Print  bool   // Print the schema to stdout instead of writing a file
+}
+
+// DefaultDBPullOptions returns the default pull options.
+func DefaultDBPullOptions() DBPullOptions {
+	return DBPullOptions{}
+}
+
+// DBPull introspects the database and writes a schema.nexus describing it.
+func DBPull(opts DBPullOptions) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	output := opts.Output
+	if output == "" {
+		output = config.Schema.Path
+	}
+	if info, err := os.Stat(output); err == nil && info.IsDir() {
+		output = filepath.Join(output, "schema"+schema.SchemaExt)
+	}
+
+	if !opts.Print && !opts.Force {
+		if _, err := os.Stat(output); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite or --print to write to stdout)", output)
+		}
+	}
+
+	conn, err := connect(config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	introspector, ok := conn.Dialect.(migration.Introspector)
+	if !ok {
+		return fmt.Errorf("dialect %s does not support introspection", conn.Dialect.Name())
+	}
+
+	snapshot, err := migration.IntrospectDatabase(commandContext(), conn.DB, introspector)
+	if err != nil {
+		return fmt.Errorf("introspecting database: %w", err)
+	}
+
+	s, warnings := migration.SchemaFromSnapshot(snapshot)
+
+	content := fmt.Sprintf("// Nexus Schema File\n// Generated by 'nexus db pull' from the %s database\n\n", conn.Dialect.Name())
+	content += schema.Format(s)
+
+	if opts.Print {
+		fmt.Fprint(stdout, content)
+	} else {
+		if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Wrote %s (%d models)\n", output, len(s.GetModels()))
+	}
+
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", w)
+	}
+
+	return nil
+}
+
+// DBPartitionsOptions configures partition maintenance.
+type DBPartitionsOptions struct {
+	Ahead  int  // Partitions to keep ahead of the current one
+	DryRun bool // Print the statements instead of running them
+}
+
+// DefaultDBPartitionsOptions returns the default partition options.
+func DefaultDBPartitionsOptions() DBPartitionsOptions {
+	return DBPartitionsOptions{Ahead: migration.DefaultPartitionOptions().Ahead}
+}
+
+// DBPartitionsEnsure creates the upcoming partitions of range partitioned
+// tables. Run it on a schedule so inserts never fall into the default
+// partition.
+func DBPartitionsEnsure(opts DBPartitionsOptions) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	s, err := loadTransferSchema(config)
+	if err != nil {
+		return err
+	}
+
+	conn, err := connect(config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	popts := migration.DefaultPartitionOptions()
+	popts.Ahead = opts.Ahead
+	popts.DryRun = opts.DryRun
+	created, err := migration.EnsurePartitions(commandContext(), conn, s, popts)
+	for _, c := range created {
+		if opts.DryRun {
+			fmt.Printf("%s;\n", c.SQL)
+		} else {
+			fmt.Printf("✓ Created partition %s of %s\n", c.Partition, c.Table)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(created) == 0 {
+		fmt.Println("All partitions exist")
+	}
+	return nil
+}
@@ -3,7 +3,10 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -15,6 +18,7 @@ import (
 
 	"github.com/nexus-db/nexus/internal/codegen"
 	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
 )
 
 // DevOptions configures the dev mode behavior.
@@ -22,6 +26,17 @@ type DevOptions struct {
 	NoGen    bool          // Disable automatic code generation
 	Poll     bool          // Use polling instead of OS events
 	Interval time.Duration // Debounce/poll interval
+	JSON     bool          // Print diagnostics as JSON lines; logs go to stderr
+	Apply    bool          // Apply new migrations and changed seeds to the database
+	DBPush   bool          // AutoMigrate schema edits to the database
+	SeedEnv  string        // Environment of the seeds Apply runs
+	Build    bool          // Check the generated code with BuildCmd after each generation
+	BuildCmd string        // Build command; defaults to dev.build of the config, then go build ./...
+	Watch    []string      // Globs of more files to regenerate on, added to dev.watch of the config
+	Ignore   []string      // Globs of files and directories not to react to, added to dev.ignore
+
+	log nexuslog.Logger // Set by Dev from the logging config
+	db  *devDB          // Set by Dev with Apply or DBPush
 }
 
 // DefaultDevOptions returns the default dev mode options.
@@ -30,6 +45,7 @@ func DefaultDevOptions() DevOptions {
 		NoGen:    false,
 		Poll:     false,
 		Interval: 500 * time.Millisecond,
+		SeedEnv:  "dev",
 	}
 }
 
@@ -47,16 +63,40 @@ func Dev(opts DevOptions) error {
 		return fmt.Errorf("resolving schema path: %w", err)
 	}
 
-	// Check schema file exists
+	// Check schema file or directory exists
 	if _, err := os.Stat(absSchemaPath); os.IsNotExist(err) {
-		return fmt.Errorf("schema file not found: %s", schemaPath)
+		return fmt.Errorf("schema not found: %s", schemaPath)
 	}
 
+	// Build with the command of the config unless another is given
+	if opts.BuildCmd == "" {
+		opts.BuildCmd = config.Dev.Build
+	}
+	if opts.BuildCmd != "" {
+		opts.Build = true
+	} else if opts.Build {
+		opts.BuildCmd = defaultBuildCommand
+	}
+
+	opts.Watch = append(append([]string{}, config.Dev.Watch...), opts.Watch...)
+	opts.Ignore = append(append([]string{}, config.Dev.Ignore...), opts.Ignore...)
+
 	// Print startup banner
-	printDevBanner(schemaPath, config.Output.Dir)
+	out := opts.logOutput()
+	opts.log = config.Logging.logger(nexuslog.SubsystemDev, out)
+	printDevBanner(out, schemaPath, config.Output.Dir, opts)
+
+	if opts.Apply || opts.DBPush {
+		db, err := openDevDB(config, opts)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		opts.db = db
+	}
 
 	// Create context with cancellation
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(commandContext())
 	defer cancel()
 
 	// Handle signals for graceful shutdown
@@ -65,18 +105,26 @@ func Dev(opts DevOptions) error {
 
 	go func() {
 		<-sigChan
-		fmt.Println("\n\n👋 Stopping dev mode...")
+		fmt.Fprintln(out, "\n\n👋 Stopping dev mode...")
 		cancel()
 	}()
 
-	// Run initial generation
+	// Run initial generation, then bring the database up to date
+	var genErr error
 	if !opts.NoGen {
-		if err := runGeneration(config); err != nil {
-			fmt.Printf("[%s] ❌ Error: %v\n", timestamp(), err)
-		}
+		genErr = generate(config, opts)
+	}
+	if opts.Apply {
+		opts.db.migrate()
+	}
+	if opts.DBPush && genErr == nil {
+		opts.db.push()
+	}
+	if opts.Apply {
+		opts.db.seed()
 	}
 
-	fmt.Printf("[%s] Watching for changes...\n", timestamp())
+	opts.log.Info("Watching for changes...")
 
 	// Start watching
 	if opts.Poll {
@@ -93,8 +141,11 @@ func watchWithFsnotify(ctx context.Context, schemaPath string, config *Config, o
 	}
 	defer watcher.Close()
 
-	// Watch the directory containing the schema file
-	schemaDir := filepath.Dir(schemaPath)
+	// Watch the schema directory, or the directory containing the schema file
+	schemaDir := schemaPath
+	if info, err := os.Stat(schemaPath); err == nil && !info.IsDir() {
+		schemaDir = filepath.Dir(schemaPath)
+	}
 	if err := watcher.Add(schemaDir); err != nil {
 		return fmt.Errorf("watching directory: %w", err)
 	}
@@ -105,12 +156,32 @@ func watchWithFsnotify(ctx context.Context, schemaPath string, config *Config, o
 	if configDir != schemaDir {
 		if err := watcher.Add(configDir); err != nil {
 			// Non-fatal, just skip config watching
-			fmt.Printf("[%s] ⚠ Could not watch config file\n", timestamp())
+			opts.log.Warn("Could not watch config file", nexuslog.Err(err))
+		}
+	}
+
+	// And the migrations and seeds, and the directories of the watch globs
+	watching := map[string]bool{schemaDir: true, configDir: true}
+	addDir := func(dir string) {
+		if watching[dir] {
+			return
+		}
+		watching[dir] = true
+		if err := watcher.Add(dir); err != nil {
+			opts.log.Warn("Could not watch directory", nexuslog.F("dir", dir), nexuslog.Err(err))
+		}
+	}
+	if opts.Apply {
+		for _, dir := range devDirs() {
+			addDir(dir)
 		}
 	}
+	for _, dir := range opts.watchDirs() {
+		addDir(dir)
+	}
 
-	// Debouncer
-	var debounceTimer *time.Timer
+	// Debouncer, per kind of change so that one doesn't swallow another
+	debounceTimers := make(map[devChange]*time.Timer)
 	var debounceMu sync.Mutex
 
 	for {
@@ -123,18 +194,34 @@ func watchWithFsnotify(ctx context.Context, schemaPath string, config *Config, o
 				return nil
 			}
 
+			// Watch directories created under the watch globs, recursively
+			if event.Has(fsnotify.Create) && len(opts.Watch) > 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if dir, _ := filepath.Abs(event.Name); opts.underWatchRoot(dir) {
+						filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+							if err == nil && d.IsDir() && opts.underWatchRoot(p) {
+								addDir(p)
+							}
+							return nil
+						})
+					}
+					continue
+				}
+			}
+
 			// Only react to write/create events on relevant files
-			if !isRelevantEvent(event, schemaPath, configPath) {
+			kind, ok := isRelevantEvent(event, schemaPath, configPath, opts)
+			if !ok {
 				continue
 			}
 
 			// Debounce rapid changes
 			debounceMu.Lock()
-			if debounceTimer != nil {
-				debounceTimer.Stop()
+			if timer := debounceTimers[kind]; timer != nil {
+				timer.Stop()
 			}
-			debounceTimer = time.AfterFunc(opts.Interval, func() {
-				handleChange(event.Name, config, opts)
+			debounceTimers[kind] = time.AfterFunc(opts.Interval, func() {
+				handleChange(kind, event.Name, config, opts)
 			})
 			debounceMu.Unlock()
 
@@ -142,7 +229,7 @@ func watchWithFsnotify(ctx context.Context, schemaPath string, config *Config, o
 			if !ok {
 				return nil
 			}
-			fmt.Printf("[%s] ⚠ Watcher error: %v\n", timestamp(), err)
+			opts.log.Warn("Watcher error", nexuslog.Err(err))
 		}
 	}
 }
@@ -154,16 +241,14 @@ func watchWithPolling(ctx context.Context, schemaPath string, config *Config, op
 
 	// Track last modification times
 	lastMod := make(map[string]time.Time)
-	files := []string{schemaPath}
-
-	// Add config file to watch list
 	configPath, _ := filepath.Abs(configFileName)
-	if configPath != "" {
-		files = append(files, configPath)
+	files, err := polledFiles(schemaPath, configPath, opts)
+	if err != nil {
+		return err
 	}
 
 	// Initialize last modification times
-	for _, file := range files {
+	for file := range files {
 		if info, err := os.Stat(file); err == nil {
 			lastMod[file] = info.ModTime()
 		}
@@ -175,7 +260,12 @@ func watchWithPolling(ctx context.Context, schemaPath string, config *Config, op
 			return nil
 
 		case <-ticker.C:
-			for _, file := range files {
+			// Listed again each time, so that new files are seen
+			if current, err := polledFiles(schemaPath, configPath, opts); err == nil {
+				files = current
+			}
+			changed := make(map[devChange]string)
+			for file, kind := range files {
 				info, err := os.Stat(file)
 				if err != nil {
 					continue
@@ -183,57 +273,156 @@ func watchWithPolling(ctx context.Context, schemaPath string, config *Config, op
 
 				if !info.ModTime().Equal(lastMod[file]) {
 					lastMod[file] = info.ModTime()
-					handleChange(file, config, opts)
+					changed[kind] = file
+				}
+			}
+			for _, kind := range []devChange{schemaChange, migrationChange, seedChange} {
+				if file, ok := changed[kind]; ok {
+					handleChange(kind, file, config, opts)
 				}
 			}
 		}
 	}
 }
 
-// isRelevantEvent checks if the file system event is relevant.
-func isRelevantEvent(event fsnotify.Event, schemaPath, configPath string) bool {
+// polledFiles returns the files polling watches, with their kind of change.
+func polledFiles(schemaPath, configPath string, opts DevOptions) (map[string]devChange, error) {
+	schemaFiles, err := schema.SchemaFiles(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]devChange)
+	for _, file := range schemaFiles {
+		files[file] = schemaChange
+	}
+	if configPath != "" {
+		files[configPath] = schemaChange
+	}
+	if opts.Apply {
+		for _, dir := range devDirs() {
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				path := filepath.Join(dir, entry.Name())
+				if kind, ok := classifyPath(path, schemaPath, configPath, opts); ok && !entry.IsDir() {
+					files[path] = kind
+				}
+			}
+		}
+	}
+	for _, file := range opts.watchedFiles() {
+		if kind, ok := classifyPath(file, schemaPath, configPath, opts); ok {
+			files[file] = kind
+		}
+	}
+	for file := range files {
+		if opts.ignored(file) {
+			delete(files, file)
+		}
+	}
+	return files, nil
+}
+
+// isRelevantEvent checks if the file system event is relevant, and returns
+// the kind of change it is.
+func isRelevantEvent(event fsnotify.Event, schemaPath, configPath string, opts DevOptions) (devChange, bool) {
 	// Only care about write and create operations
 	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
-		return false
+		return 0, false
 	}
 
-	// Check if it's a relevant file
 	absPath, _ := filepath.Abs(event.Name)
-	if absPath == schemaPath {
-		return true
-	}
-	if absPath == configPath {
-		return true
-	}
+	return classifyPath(absPath, schemaPath, configPath, opts)
+}
 
-	// Check for .nexus extension
-	if filepath.Ext(event.Name) == ".nexus" {
-		return true
+// handleChange processes a file change event.
+func handleChange(kind devChange, filename string, config *Config, opts DevOptions) {
+	opts.log.Info("Change detected", nexuslog.F("file", filepath.Base(filename)))
+
+	switch kind {
+	case migrationChange:
+		opts.db.migrate()
+	case seedChange:
+		opts.db.seed()
+	default:
+		var err error
+		if opts.NoGen {
+			opts.log.Info("⏭ Generation disabled (--no-gen)")
+		} else {
+			err = generate(config, opts)
+		}
+		if opts.DBPush && err == nil {
+			opts.db.push()
+		}
 	}
 
-	return false
+	opts.log.Info("Watching for changes...")
 }
 
-// handleChange processes a file change event.
-func handleChange(filename string, config *Config, opts DevOptions) {
-	basename := filepath.Base(filename)
-	fmt.Printf("[%s] Change detected: %s\n", timestamp(), basename)
+// devEvent is a line of `nexus dev --json` output. Diagnostics is empty
+// when the schema is valid and the generated code builds, so editors can
+// clear earlier problems.
+type devEvent struct {
+	Time        string              `json:"time"`
+	OK          bool                `json:"ok"`
+	Diagnostics []schema.Diagnostic `json:"diagnostics"`
+}
+
+// generate runs the code generation and, with Build, checks the generated
+// code, then reports both. It returns the error of the generation.
+func generate(config *Config, opts DevOptions) error {
+	err := runGeneration(config, opts.log)
+	var build []schema.Diagnostic
+	if err == nil && opts.Build {
+		build = runBuild(opts.BuildCmd)
+	}
+	reportGeneration(err, build, opts)
+	return err
+}
 
-	if opts.NoGen {
-		fmt.Printf("[%s] ⏭ Generation disabled (--no-gen)\n", timestamp())
-		fmt.Printf("[%s] Watching for changes...\n", timestamp())
+// reportGeneration prints the result of a generation run and the errors of
+// the build that followed it.
+func reportGeneration(err error, build []schema.Diagnostic, opts DevOptions) {
+	if err != nil {
+		opts.log.Error("Generation failed", nexuslog.Err(err))
+	}
+	if len(build) > 0 {
+		opts.log.Error("Build failed", nexuslog.F("command", opts.BuildCmd), nexuslog.F("errors", len(build)))
+		for _, d := range build {
+			if pos := buildPosition(d); pos != "" {
+				opts.log.Error(pos + ": " + d.Message)
+			} else {
+				opts.log.Error(d.Message)
+			}
+		}
+	} else if err == nil && opts.Build {
+		opts.log.Info("✓ Build passed", nexuslog.F("command", opts.BuildCmd))
+	}
+	if !opts.JSON {
 		return
 	}
 
-	if err := runGeneration(config); err != nil {
-		fmt.Printf("[%s] ❌ Error: %v\n", timestamp(), err)
+	event := devEvent{
+		Time:        time.Now().Format(time.RFC3339),
+		OK:          err == nil && len(build) == 0,
+		Diagnostics: append(schema.Diagnostics(err), build...),
 	}
+	if event.Diagnostics == nil {
+		event.Diagnostics = []schema.Diagnostic{}
+	}
+	line, _ := json.Marshal(event)
+	fmt.Fprintln(stdout, string(line))
+}
 
-	fmt.Printf("[%s] Watching for changes...\n", timestamp())
+// logOutput returns where dev mode logs go: stderr when stdout carries JSON.
+func (o DevOptions) logOutput() io.Writer {
+	if o.JSON {
+		return os.Stderr
+	}
+	return os.Stdout
 }
 
 // runGeneration runs the code generation pipeline.
-func runGeneration(config *Config) error {
+func runGeneration(config *Config, log nexuslog.Logger) error {
 	// Parse schema
 	s, err := schema.ParseFile(config.Schema.Path)
 	if err != nil {
@@ -244,7 +433,7 @@ func runGeneration(config *Config) error {
 	if err := s.Validate(); err != nil {
 		return fmt.Errorf("validating schema: %w", err)
 	}
-	fmt.Printf("[%s] ✓ Schema validated\n", timestamp())
+	log.Info("✓ Schema validated")
 
 	// Generate code
 	gen := codegen.NewGenerator(s, config.Output.Package, config.Output.Dir)
@@ -252,22 +441,29 @@ func runGeneration(config *Config) error {
 		return fmt.Errorf("generating code: %w", err)
 	}
 
-	fmt.Printf("[%s] ✓ Generated code in %s/\n", timestamp(), config.Output.Dir)
-	fmt.Printf("           - models.go (struct definitions)\n")
-	fmt.Printf("           - queries.go (query methods)\n")
+	log.Info("✓ Generated code", nexuslog.F("dir", config.Output.Dir+"/"), nexuslog.F("files", "models.go,queries.go"))
 
 	return nil
 }
 
 // printDevBanner prints the startup banner.
-func printDevBanner(schemaPath, outputDir string) {
-	fmt.Println()
-	fmt.Println("🚀 Nexus Dev Mode")
-	fmt.Printf("   Watching: %s\n", schemaPath)
-	fmt.Printf("   Output:   %s/\n", outputDir)
-	fmt.Println()
-	fmt.Println("   Press Ctrl+C to stop")
-	fmt.Println()
+func printDevBanner(out io.Writer, schemaPath, outputDir string, opts DevOptions) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "🚀 Nexus Dev Mode")
+	fmt.Fprintf(out, "   Watching: %s\n", schemaPath)
+	if opts.Apply {
+		fmt.Fprintf(out, "             %s/, %s/ (seeds for %s)\n", migrationsDir, seedsDir, opts.SeedEnv)
+	}
+	fmt.Fprintf(out, "   Output:   %s/\n", outputDir)
+	if opts.Build {
+		fmt.Fprintf(out, "   Build:    %s\n", opts.BuildCmd)
+	}
+	if opts.DBPush {
+		fmt.Fprintln(out, "   Database: schema edits pushed with auto-migration")
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "   Press Ctrl+C to stop")
+	fmt.Fprintln(out)
 }
 
 // timestamp returns the current time formatted for logging.
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// defaultBuildCommand checks the generated code when dev mode builds
+// without a command configured.
+const defaultBuildCommand = "go build ./..."
+
+// buildErrorLine matches the errors of go build and go vet:
+// file.go:line[:column]: message.
+var buildErrorLine = regexp.MustCompile(`^(?:vet: )?(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+
+// runBuild runs command with the shell and returns the errors it printed
+// as diagnostics, none if it succeeded. Output without file positions is
+// returned as one diagnostic.
+func runBuild(command string) []schema.Diagnostic {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	diags := parseBuildOutput(string(out))
+	if len(diags) == 0 {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		diags = []schema.Diagnostic{{Severity: schema.SeverityError, Code: "BUILD", Message: msg}}
+	}
+	return diags
+}
+
+// parseBuildOutput returns the errors with a file position in the output
+// of a build.
+func parseBuildOutput(out string) []schema.Diagnostic {
+	var diags []schema.Diagnostic
+	for _, line := range strings.Split(out, "\n") {
+		m := buildErrorLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		d := schema.Diagnostic{File: m[1], Severity: schema.SeverityError, Code: "BUILD", Message: m[4]}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Column, _ = strconv.Atoi(m[3])
+		d.EndLine = d.Line
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// buildPosition formats where a build error is, as the compiler does.
+func buildPosition(d schema.Diagnostic) string {
+	if d.File == "" {
+		return ""
+	}
+	pos := d.File + ":" + strconv.Itoa(d.Line)
+	if d.Column > 0 {
+		pos += ":" + strconv.Itoa(d.Column)
+	}
+	return pos
+}
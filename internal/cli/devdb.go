@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/core/seed"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// devChange is the kind of file a dev mode change is to.
+type devChange int
+
+const (
+	schemaChange    devChange = iota // Schema or config file
+	migrationChange                  // File of migrations/
+	seedChange                       // File of seeds/ or an environment of it
+)
+
+// classifyPath returns the kind of change to path, an absolute path, and
+// whether dev mode reacts to it. Files matching the watch globs regenerate
+// code, unless they are migrations or seeds applied with Apply.
+func classifyPath(path, schemaPath, configPath string, opts DevOptions) (devChange, bool) {
+	if opts.ignored(path) {
+		return 0, false
+	}
+	if path == schemaPath || path == configPath || filepath.Ext(path) == ".nexus" {
+		return schemaChange, true
+	}
+	if kind, ok := classifyApplied(path, opts); ok {
+		return kind, true
+	}
+	if opts.watched(path) {
+		return schemaChange, true
+	}
+	return 0, false
+}
+
+// classifyApplied returns the kind of change to a migration or seed file
+// with Apply.
+func classifyApplied(path string, opts DevOptions) (devChange, bool) {
+	if !opts.Apply {
+		return 0, false
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if dir, _ := filepath.Abs(migrationsDir); filepath.Dir(path) == dir && ext == ".sql" {
+		return migrationChange, true
+	}
+	seeds, _ := filepath.Abs(seedsDir)
+	if rel, err := filepath.Rel(seeds, path); err == nil && !strings.HasPrefix(rel, "..") &&
+		strings.Count(rel, string(filepath.Separator)) <= 1 {
+		switch ext {
+		case ".sql", ".csv", ".json", ".yaml", ".yml":
+			return seedChange, true
+		}
+	}
+	return 0, false
+}
+
+// devDirs returns the directories of migrations and seeds that exist, for
+// the watcher: migrations/, seeds/ and its environments.
+func devDirs() []string {
+	var dirs []string
+	for _, dir := range []string{migrationsDir, seedsDir} {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			abs, _ := filepath.Abs(dir)
+			dirs = append(dirs, abs)
+		}
+	}
+	entries, _ := os.ReadDir(seedsDir)
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			abs, _ := filepath.Abs(filepath.Join(seedsDir, entry.Name()))
+			dirs = append(dirs, abs)
+		}
+	}
+	return dirs
+}
+
+// devDB applies migrations, seeds and schema edits to the database of dev
+// mode as they change, one at a time.
+type devDB struct {
+	mu     sync.Mutex
+	conn   *dialects.Connection
+	config *Config
+	opts   DevOptions
+}
+
+// openDevDB connects to the database of config for --apply and --db-push.
+func openDevDB(config *Config, opts DevOptions) (*devDB, error) {
+	if opts.DBPush && !config.Migrations.Auto {
+		return nil, fmt.Errorf(`--db-push applies schema edits without migrations; enable it for development with "migrations": {"auto": true}, e.g. in "env": {"development": ...} of %s`, configFileName)
+	}
+	conn, err := connect(config)
+	if err != nil {
+		return nil, err
+	}
+	return &devDB{conn: conn, config: config, opts: opts}, nil
+}
+
+// Close closes the connection to the database.
+func (d *devDB) Close() error {
+	return d.conn.Close()
+}
+
+// migrate applies the pending migrations of migrations/.
+func (d *devDB) migrate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	log := d.opts.log
+	ctx := commandContext()
+
+	engine, lockOpts, err := migrationEngine(d.config, d.conn)
+	if err != nil {
+		log.Error("Applying migrations failed", nexuslog.Err(err))
+		return
+	}
+	// Gated migrations of the seed environment, such as fixtures, run too
+	if engine.Environment() == "" {
+		engine.WithEnvironment(d.opts.SeedEnv)
+	}
+	engine.OnMigration(func(e migration.MigrationEvent) {
+		log.Info("✓ Applied migration", nexuslog.F("migration", e.Migration.ID+"_"+e.Migration.Name), nexuslog.F("duration", formatElapsed(e.Elapsed)))
+	})
+	err = func() error {
+		if err := engine.Init(ctx); err != nil {
+			return fmt.Errorf("initializing migrations table: %w", err)
+		}
+		if err := engine.AcquireLock(ctx, lockOpts); err != nil {
+			return fmt.Errorf("acquiring lock: %w", err)
+		}
+		defer engine.ReleaseLock(ctx)
+		if err := engine.LoadFromDir(migrationsDir); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("loading migrations: %w", err)
+		}
+		_, err := engine.Up(ctx)
+		return err
+	}()
+	if err != nil {
+		log.Error("Applying migrations failed", nexuslog.Err(err))
+	}
+}
+
+// seed runs the seeds of seeds/ that are pending or changed since they ran.
+func (d *devDB) seed() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	log := d.opts.log
+	ctx := commandContext()
+
+	engine := seed.NewEngine(d.conn).WithDriftPolicy(seed.DriftRerun).
+		WithLogger(d.config.Logging.engineLogger(nexuslog.SubsystemSeed))
+	if s, err := schema.ParseFile(d.config.Schema.Path); err == nil {
+		engine.WithSchema(s)
+	}
+	if err := engine.Init(ctx); err != nil {
+		log.Error("Running seeds failed", nexuslog.Err(fmt.Errorf("initializing seeds table: %w", err)))
+		return
+	}
+	if err := engine.LoadFromDir(seedsDir); err != nil {
+		if !os.IsNotExist(err) {
+			log.Error("Running seeds failed", nexuslog.Err(fmt.Errorf("loading seeds: %w", err)))
+		}
+		return
+	}
+
+	report, err := engine.RunReport(ctx, d.opts.SeedEnv)
+	for _, s := range report.Applied {
+		log.Info("✓ Ran seed", nexuslog.F("seed", seedLabel(s.Name, s.Env)))
+	}
+	for _, f := range report.Failed {
+		log.Error("Seed failed", nexuslog.F("seed", seedLabel(f.Seed.Name, f.Seed.Env)), nexuslog.Err(f.Err))
+	}
+	if err != nil && len(report.Failed) == 0 {
+		log.Error("Running seeds failed", nexuslog.Err(err))
+	}
+}
+
+// push applies the additive changes of the schema to the database, as
+// nexus migrate auto does.
+func (d *devDB) push() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	log := d.opts.log
+
+	s, err := schema.ParseFile(d.config.Schema.Path)
+	if err == nil {
+		err = s.Validate()
+	}
+	if err != nil {
+		log.Error("Pushing schema failed", nexuslog.Err(err))
+		return
+	}
+	engine := migration.NewEngine(d.conn).WithLogger(d.config.Logging.engineLogger(nexuslog.SubsystemMigrate))
+	result, err := engine.AutoMigrate(commandContext(), s)
+	if err != nil {
+		log.Error("Pushing schema failed", nexuslog.Err(err))
+		return
+	}
+	for _, desc := range migration.DescribeChanges(result.Applied) {
+		log.Info("✓ Pushed", nexuslog.F("change", desc))
+	}
+	for _, desc := range migration.DescribeChanges(result.Skipped) {
+		log.Warn("Skipped destructive change; write a migration for it", nexuslog.F("change", desc))
+	}
+}
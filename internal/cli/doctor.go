@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/doctor"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// ExitError is an error with the exit status the command should end with.
+// Its message has already been printed.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// DBDoctorOptions configures db doctor.
+type DBDoctorOptions struct {
+	JSON bool // Print the report as JSON
+}
+
+// DefaultDBDoctorOptions returns the default doctor options.
+func DefaultDBDoctorOptions() DBDoctorOptions {
+	return DBDoctorOptions{}
+}
+
+// DBDoctor checks the health of the configured database and prints the
+// findings, most urgent first. It fails with an *ExitError of status 1 on
+// warnings and 2 on critical findings, for CI and monitoring.
+func DBDoctor(opts DBDoctorOptions) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	dialect, err := getDialect(config.Database.Dialect)
+	if err != nil {
+		return err
+	}
+	// Not connect: a database that is down is a finding, not an error
+	db, err := openDB(config.Database, dialect)
+	if err != nil {
+		return &ExitError{Code: 2, Err: fmt.Errorf("connecting to database: %w", err)}
+	}
+	conn := dialects.NewConnection(db, dialect)
+	defer conn.Close()
+
+	dopts := doctor.DefaultOptions()
+	engine, _, err := migrationEngine(config, conn)
+	if err != nil {
+		return err
+	}
+	if err := engine.LoadFromDir(migrationsDir); err == nil {
+		dopts.Engine = engine
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+
+	report := doctor.Run(commandContext(), conn, dopts)
+	if opts.JSON {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(out))
+	} else {
+		printDoctorReport(report)
+	}
+
+	if code := report.ExitCode(); code != 0 {
+		return &ExitError{Code: code, Err: fmt.Errorf("database doctor found %d critical problem(s) and %d warning(s)",
+			report.Count(doctor.SeverityCritical), report.Count(doctor.SeverityWarning))}
+	}
+	return nil
+}
+
+// printDoctorReport prints the findings of a report and the largest tables.
+func printDoctorReport(report *doctor.Report) {
+	fmt.Printf("Database: %s", report.Dialect)
+	if report.Latency > 0 {
+		fmt.Printf(" (ping %s)", formatElapsed(report.Latency))
+	}
+	fmt.Println()
+	if report.Stats != nil {
+		fmt.Printf("Size: %s", doctor.FormatBytes(report.Stats.Bytes))
+		if report.Stats.Reclaimable > 0 {
+			fmt.Printf(", %s reclaimable", doctor.FormatBytes(report.Stats.Reclaimable))
+		}
+		fmt.Println()
+	}
+	fmt.Println(strings.Repeat("-", 60))
+
+	if len(report.Findings) == 0 {
+		fmt.Println("✓ No problems found")
+	}
+	for _, f := range report.Findings {
+		prefix := "ℹ️  INFO"
+		switch f.Severity {
+		case doctor.SeverityCritical:
+			prefix = "❌ CRITICAL"
+		case doctor.SeverityWarning:
+			prefix = "⚠️  WARNING"
+		}
+		fmt.Printf("%s [%s]: %s\n", prefix, f.Check, f.Message)
+		if f.Suggestion != "" {
+			fmt.Printf("     → %s\n", f.Suggestion)
+		}
+	}
+
+	if report.Stats != nil && len(report.Stats.Tables) > 0 {
+		fmt.Println(strings.Repeat("-", 60))
+		fmt.Println("Largest tables:")
+		for _, t := range report.Stats.Tables[:min(len(report.Stats.Tables), 10)] {
+			line := fmt.Sprintf("  %-30s %10s", t.Name, doctor.FormatBytes(t.Bytes))
+			if t.Rows > 0 {
+				line += fmt.Sprintf("  ~%d rows", t.Rows)
+			}
+			if t.Reclaimable > 0 {
+				line += fmt.Sprintf("  %s reclaimable", doctor.FormatBytes(t.Reclaimable))
+			}
+			fmt.Println(line)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/bulk"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/core/transfer"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// DBDumpOptions configures a data dump.
+type DBDumpOptions struct {
+	Format  string   // sql, csv or json
+	Tables  []string // Tables to dump; empty dumps all
+	Output  string   // File (or directory for CSV); empty writes to stdout
+	Dialect string   // Dialect of SQL dumps; defaults to the configured one
+
+	Anonymize bool // Replace @pii fields with fake values
+}
+
+// DefaultDBDumpOptions returns the default dump options.
+func DefaultDBDumpOptions() DBDumpOptions {
+	return DBDumpOptions{Format: "sql"}
+}
+
+// DBDump writes the data of the database as SQL, CSV or JSON.
+func DBDump(opts DBDumpOptions) error {
+	format, err := transfer.ParseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	s, err := loadTransferSchema(config)
+	if err != nil {
+		return err
+	}
+
+	conn, err := connect(config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := commandContext()
+	topts := transfer.Options{Tables: opts.Tables, Progress: progressBar(os.Stderr)}
+	models, err := transfer.Models(s, topts)
+	if err != nil {
+		return err
+	}
+	if opts.Anonymize {
+		if topts.Masked, err = transfer.MaskedColumns(s, transfer.SampleOptions{}); err != nil {
+			return err
+		}
+		if len(topts.Masked) == 0 {
+			fmt.Fprintln(os.Stderr, "⚠ No columns are masked: mark personal data with @pii")
+		}
+	}
+
+	if format == transfer.FormatCSV && (len(models) > 1 || isDir(opts.Output)) {
+		// A file per table
+		dir := opts.Output
+		if dir == "" {
+			dir = "dump"
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		for _, model := range models {
+			path := filepath.Join(dir, model.Table()+".csv")
+			if err := writeFile(path, func(w io.Writer) error {
+				return transfer.DumpCSV(ctx, conn, model, w, topts)
+			}); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(os.Stderr, "✓ Dumped %d table(s) to %s\n", len(models), dir)
+		return nil
+	}
+
+	dump := func(w io.Writer) error {
+		switch format {
+		case transfer.FormatCSV:
+			if len(models) == 0 {
+				return fmt.Errorf("no tables to dump")
+			}
+			return transfer.DumpCSV(ctx, conn, models[0], w, topts)
+		case transfer.FormatJSON:
+			return transfer.DumpJSON(ctx, conn, s, w, topts)
+		}
+		dialect := conn.Dialect
+		if opts.Dialect != "" {
+			if dialect, err = getDialect(opts.Dialect); err != nil {
+				return err
+			}
+		}
+		return transfer.DumpSQL(ctx, conn, s, dialect, w, topts)
+	}
+
+	if opts.Output == "" {
+		return dump(stdout)
+	}
+	if err := writeFile(opts.Output, dump); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✓ Dumped %d table(s) to %s\n", len(models), opts.Output)
+	return nil
+}
+
+// DBLoadOptions configures a data load.
+type DBLoadOptions struct {
+	Path   string   // Dump file, or a directory of .csv, .json and .sql files
+	Tables []string // Tables to load from JSON dumps; empty loads all
+	Method string   // Bulk method: auto, copy, load-data or insert
+}
+
+// DefaultDBLoadOptions returns the default load options.
+func DefaultDBLoadOptions() DBLoadOptions {
+	return DBLoadOptions{Method: string(bulk.MethodAuto)}
+}
+
+// DBLoad loads a dump into the database in one transaction.
+func DBLoad(opts DBLoadOptions) error {
+	method, err := bulk.ParseMethod(opts.Method)
+	if err != nil {
+		return err
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	s, err := loadTransferSchema(config)
+	if err != nil {
+		return err
+	}
+
+	files, err := dumpFiles(s, opts.Path)
+	if err != nil {
+		return err
+	}
+
+	conn, err := connect(config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := commandContext()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	topts := transfer.Options{Tables: opts.Tables, Method: method, Progress: progressBar(os.Stderr)}
+	var rows, statements int64
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		var n int64
+		switch filepath.Ext(path) {
+		case ".csv":
+			n, err = transfer.LoadCSV(ctx, tx, csvModel(s, path), f, topts)
+			rows += n
+		case ".json":
+			n, err = transfer.LoadJSON(ctx, tx, s, f, topts)
+			rows += n
+		default:
+			n, err = transfer.LoadSQL(ctx, tx, f, topts)
+			statements += n
+		}
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if statements > 0 {
+		fmt.Printf("✓ Ran %d statement(s)\n", statements)
+	}
+	if rows > 0 || statements == 0 {
+		fmt.Printf("✓ Loaded %d row(s)\n", rows)
+	}
+	return nil
+}
+
+// DBCopyOptions configures a copy between databases.
+type DBCopyOptions struct {
+	From   string   // Source database URL; defaults to the configured database
+	To     string   // Target database URL
+	Tables []string // Tables to copy; empty copies all
+}
+
+// DefaultDBCopyOptions returns the default copy options.
+func DefaultDBCopyOptions() DBCopyOptions {
+	return DBCopyOptions{}
+}
+
+// DBCopy creates the schema in another database and copies the rows over.
+func DBCopy(opts DBCopyOptions) error {
+	if opts.To == "" {
+		return fmt.Errorf("--to is required")
+	}
+	if opts.To == opts.From {
+		return fmt.Errorf("--from and --to are the same database")
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	s, err := loadTransferSchema(config)
+	if err != nil {
+		return err
+	}
+
+	from, err := connectURL(config, opts.From)
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	defer from.Close()
+	to, err := connectURL(config, opts.To)
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	defer to.Close()
+
+	ctx := commandContext()
+	topts := transfer.Options{Tables: opts.Tables, Progress: progressBar(os.Stderr)}
+	created, err := transfer.CreateTables(ctx, to, s, topts)
+	if err != nil {
+		return err
+	}
+	if len(created) > 0 {
+		fmt.Printf("✓ Created %d table(s) in the %s database\n", len(created), to.Dialect.Name())
+	}
+
+	rows, err := transfer.Copy(ctx, from, to, s, topts)
+	if err != nil {
+		return fmt.Errorf("copying rows (nothing was copied): %w", err)
+	}
+	fmt.Printf("✓ Copied %d row(s) from %s to %s\n", rows, from.Dialect.Name(), to.Dialect.Name())
+	return nil
+}
+
+// DBSampleOptions configures sampling data into another database.
+type DBSampleOptions struct {
+	From      string   // Source database; defaults to the configured database
+	To        string   // Target database
+	Rows      int      // Rows per table
+	Tables    []string // Tables to sample; empty samples all
+	Anonymize []string // Columns to mask besides @pii fields
+	Clear     bool     // Delete the rows of the sampled tables in the target first
+}
+
+// DefaultDBSampleOptions returns the default sample options.
+func DefaultDBSampleOptions() DBSampleOptions {
+	return DBSampleOptions{Rows: 1000}
+}
+
+// DBSample copies a referentially consistent subset of the rows of one
+// database to another, masking personal data.
+func DBSample(opts DBSampleOptions) error {
+	if opts.To == "" {
+		return fmt.Errorf("--to is required")
+	}
+	if opts.To == opts.From {
+		return fmt.Errorf("--from and --to are the same database")
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	s, err := loadTransferSchema(config)
+	if err != nil {
+		return err
+	}
+
+	sopts := transfer.SampleOptions{
+		Options:   transfer.Options{Tables: opts.Tables, Progress: progressBar(os.Stderr)},
+		Rows:      opts.Rows,
+		Anonymize: opts.Anonymize,
+		Clear:     opts.Clear,
+	}
+	masked, err := transfer.MaskedColumns(s, sopts)
+	if err != nil {
+		return err
+	}
+
+	from, err := connectURL(config, opts.From)
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	defer from.Close()
+	to, err := connectURL(config, opts.To)
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	defer to.Close()
+
+	ctx := commandContext()
+	// Parents of sampled rows may come from any table
+	created, err := transfer.CreateTables(ctx, to, s, transfer.Options{})
+	if err != nil {
+		return err
+	}
+	if len(created) > 0 {
+		fmt.Printf("✓ Created %d table(s) in the %s database\n", len(created), to.Dialect.Name())
+	}
+
+	columns := make([]string, 0, len(masked))
+	for column := range masked {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	if len(columns) > 0 {
+		fmt.Printf("Masking %s\n", strings.Join(columns, ", "))
+	} else {
+		fmt.Println("⚠ No columns are masked: mark personal data with @pii or use --anonymize")
+	}
+
+	rows, err := transfer.Sample(ctx, from, to, s, sopts)
+	if err != nil {
+		return fmt.Errorf("sampling rows (nothing was copied): %w", err)
+	}
+	fmt.Printf("✓ Sampled %d row(s) from %s to %s\n", rows, from.Dialect.Name(), to.Dialect.Name())
+	return nil
+}
+
+// connectURL connects to a database given as a name from the databases
+// of the config, a URL of the form dialect:dsn such as sqlite:./dev.db or
+// mysql:user:pass@tcp(host)/db, a postgres:// or mysql:// URL, or a SQLite
+// file. An empty URL is the configured database.
+func connectURL(config *Config, url string) (*dialects.Connection, error) {
+	if url == "" {
+		return connect(config)
+	}
+	if db, ok := config.Databases[url]; ok {
+		return connect(&Config{Database: db})
+	}
+	dialect, dsn, err := dialects.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return connect(&Config{Database: DatabaseConfig{Dialect: dialect, URL: dsn}})
+}
+
+// dumpFiles returns the files to load from path: the file itself, or the
+// .sql and .json files of a directory followed by its .csv files with
+// parent tables first.
+func dumpFiles(s *schema.Schema, path string) ([]string, error) {
+	if !isDir(path) {
+		switch filepath.Ext(path) {
+		case ".sql", ".json":
+		case ".csv":
+			if csvModel(s, path) == nil {
+				return nil, fmt.Errorf("%s: no model for table %q", path, csvTable(path))
+			}
+		default:
+			return nil, fmt.Errorf("%s: expected a .sql, .csv or .json file", path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	csvFiles := make(map[string]string) // Table -> file
+	for _, e := range entries {
+		file := filepath.Join(path, e.Name())
+		switch filepath.Ext(e.Name()) {
+		case ".sql", ".json":
+			files = append(files, file)
+		case ".csv":
+			model := csvModel(s, file)
+			if model == nil {
+				return nil, fmt.Errorf("%s: no model for table %q", file, csvTable(file))
+			}
+			csvFiles[model.Table()] = file
+		}
+	}
+	sort.Strings(files)
+	for _, model := range transfer.TableOrder(s) {
+		if file, ok := csvFiles[model.Table()]; ok {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql, .csv or .json files in %s", path)
+	}
+	return files, nil
+}
+
+// csvTable returns the table a CSV file is named after.
+func csvTable(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+func csvModel(s *schema.Schema, path string) *schema.Model {
+	return transfer.FindModel(s, csvTable(path))
+}
+
+// loadTransferSchema parses the schema dumps and loads convert values with.
+func loadTransferSchema(config *Config) (*schema.Schema, error) {
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("validating schema: %w", err)
+	}
+	return s, nil
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// progressBar draws a line per table on w as rows are dumped or loaded.
+func progressBar(w io.Writer) transfer.Progress {
+	const width = 30
+	return func(table string, done, total int64) {
+		if total < 0 {
+			fmt.Fprintf(w, "\r  %-24s %d rows", table, done)
+			return
+		}
+		filled := width
+		if total > 0 {
+			filled = int(done * width / total)
+		}
+		fmt.Fprintf(w, "\r  %-24s [%s%s] %d/%d", table,
+			strings.Repeat("█", filled), strings.Repeat("░", width-filled), done, total)
+		if done == total {
+			fmt.Fprintln(w)
+		}
+	}
+}
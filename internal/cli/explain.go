@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// ExplainOptions configures explain.
+type ExplainOptions struct {
+	// Check is a YAML plans file of tracked queries whose plans are
+	// checked, instead of explaining one query.
+	Check   string
+	Analyze bool // Run the query to report actual timings
+}
+
+// DefaultExplainOptions returns the default explain options.
+func DefaultExplainOptions() ExplainOptions {
+	return ExplainOptions{}
+}
+
+// Explain prints the plan of a query, or with opts.Check checks the plans
+// of the tracked queries of a plans file and fails if any degraded, such
+// as an index no longer being used.
+func Explain(sql string, opts ExplainOptions) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	var tracked []query.TrackedQuery
+	if opts.Check != "" {
+		if tracked, err = query.LoadPlanChecks(opts.Check); err != nil {
+			return err
+		}
+	} else if strings.TrimSpace(sql) == "" {
+		return fmt.Errorf("give a query to explain, or --check with a plans file")
+	}
+
+	conn, err := connect(config)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	defer conn.Close()
+	ctx := commandContext()
+
+	if opts.Check == "" {
+		plan, err := query.NewRawQuery(conn, sql).Explain(ctx, query.ExplainOptions{Analyze: opts.Analyze, Format: query.ExplainFormatText})
+		if err != nil {
+			return err
+		}
+		fmt.Println(plan.Raw)
+		if len(plan.UsedIndexes) > 0 {
+			fmt.Printf("\nIndexes: %s\n", strings.Join(plan.UsedIndexes, ", "))
+		}
+		for _, w := range plan.Warnings {
+			fmt.Printf("⚠ %s\n", w)
+		}
+		return nil
+	}
+
+	results, err := query.CheckPlans(ctx, conn, tracked)
+	if err != nil {
+		return err
+	}
+	degraded := 0
+	for _, r := range results {
+		if len(r.Problems) == 0 {
+			fmt.Printf("✓ %s\n", r.Query.Name)
+			continue
+		}
+		degraded++
+		fmt.Printf("✗ %s\n", r.Query.Name)
+		for _, p := range r.Problems {
+			fmt.Printf("    %s\n", p)
+		}
+		for _, line := range strings.Split(r.Plan.Raw, "\n") {
+			fmt.Printf("    │ %s\n", line)
+		}
+	}
+	if degraded > 0 {
+		return &ExitError{Code: 1, Err: fmt.Errorf("%d of %d query plans degraded", degraded, len(results))}
+	}
+	fmt.Printf("\nAll %d query plans OK\n", len(results))
+	return nil
+}
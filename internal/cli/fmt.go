@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// Fmt formats .nexus files in place. paths may name files or directories;
+// without paths the configured schema is formatted. With check, nothing is
+// written: files that are not formatted are listed and an error is
+// returned, for use in CI.
+func Fmt(paths []string, check bool) error {
+	if len(paths) == 0 {
+		config, err := LoadConfig()
+		if err != nil {
+			return err
+		}
+		paths = []string{config.Schema.Path}
+	}
+
+	var files []string
+	for _, path := range paths {
+		found, err := schema.SchemaFiles(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+
+	var unformatted, failed int
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := schema.FormatSource(string(data))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:\n%v\n", file, err)
+			failed++
+			continue
+		}
+		if formatted == string(data) {
+			continue
+		}
+
+		unformatted++
+		if check {
+			fmt.Println(file)
+			continue
+		}
+		if err := os.WriteFile(file, []byte(formatted), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Formatted %s\n", file)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be parsed", failed)
+	}
+	if check && unformatted > 0 {
+		return fmt.Errorf("%d file(s) need formatting; run 'nexus fmt'", unformatted)
+	}
+	return nil
+}
@@ -2,8 +2,12 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/internal/studio"
+	"github.com/nexus-db/nexus/pkg/core/migration"
 	"github.com/nexus-db/nexus/pkg/core/schema"
 )
 
@@ -37,3 +41,207 @@ func Generate() error {
 
 	return nil
 }
+
+// GenerateAPI generates the Go code of Generate plus CRUD HTTP handlers
+// and an OpenAPI document describing them.
+func GenerateAPI() error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("validating schema: %w", err)
+	}
+
+	gen := codegen.NewGenerator(s, config.Output.Package, config.Output.Dir)
+	if err := gen.Generate(); err != nil {
+		return fmt.Errorf("generating code: %w", err)
+	}
+	if err := gen.GenerateAPI(); err != nil {
+		return fmt.Errorf("generating API: %w", err)
+	}
+
+	fmt.Printf("✓ Generated API in %s/\n", config.Output.Dir)
+	fmt.Printf("  - models.go (struct definitions)\n")
+	fmt.Printf("  - queries.go (query methods)\n")
+	fmt.Printf("  - handlers.go (CRUD HTTP handlers)\n")
+	fmt.Printf("  - openapi.json (OpenAPI 3 document)\n")
+
+	return nil
+}
+
+// GenerateGraphQL generates the Go code of Generate plus a GraphQL schema
+// and the resolvers and handler serving it.
+func GenerateGraphQL() error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("validating schema: %w", err)
+	}
+
+	gen := codegen.NewGenerator(s, config.Output.Package, config.Output.Dir)
+	if err := gen.Generate(); err != nil {
+		return fmt.Errorf("generating code: %w", err)
+	}
+	if err := gen.GenerateGraphQL(); err != nil {
+		return fmt.Errorf("generating GraphQL: %w", err)
+	}
+
+	fmt.Printf("✓ Generated GraphQL API in %s/\n", config.Output.Dir)
+	fmt.Printf("  - models.go (struct definitions)\n")
+	fmt.Printf("  - queries.go (query methods)\n")
+	fmt.Printf("  - schema.graphql (GraphQL schema)\n")
+	fmt.Printf("  - graphql.go (resolvers and handler)\n")
+
+	return nil
+}
+
+// GenerateTypeScript generates TypeScript types, and with zod also zod
+// schemas, into outDir, or the configured output directory if empty.
+func GenerateTypeScript(outDir string, zod bool) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("validating schema: %w", err)
+	}
+
+	if outDir == "" {
+		outDir = config.Output.Dir
+	}
+	gen := codegen.NewGenerator(s, config.Output.Package, outDir)
+	if err := gen.GenerateTypeScript(zod); err != nil {
+		return fmt.Errorf("generating TypeScript: %w", err)
+	}
+
+	fmt.Printf("✓ Generated TypeScript in %s/\n", outDir)
+	fmt.Printf("  - models.ts (interfaces and enums)\n")
+	if zod {
+		fmt.Printf("  - schemas.ts (zod schemas)\n")
+	}
+
+	return nil
+}
+
+// GenerateFactories generates test factories for every model into the
+// factory package under the configured output directory.
+func GenerateFactories() error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("validating schema: %w", err)
+	}
+
+	gen := codegen.NewGenerator(s, config.Output.Package, config.Output.Dir)
+	if err := gen.GenerateFactories(); err != nil {
+		return fmt.Errorf("generating factories: %w", err)
+	}
+
+	fmt.Printf("✓ Generated factories in %s/\n", filepath.Join(config.Output.Dir, "factory"))
+	fmt.Printf("  - factory.go (fake data per model)\n")
+
+	return nil
+}
+
+// GenerateQueries generates typed Go functions for the annotated queries
+// of the .sql files in dir, or the configured queries directory if empty.
+func GenerateQueries(dir string) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("validating schema: %w", err)
+	}
+
+	if dir == "" {
+		dir = config.Schema.Queries
+	}
+	if dir == "" {
+		dir = "queries"
+	}
+	queries, err := codegen.ParseQueryFiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("no queries in %s (start each with -- name: GetUser :one)", dir)
+	}
+
+	gen := codegen.NewGenerator(s, config.Output.Package, config.Output.Dir)
+	if err := gen.GenerateQueries(queries); err != nil {
+		return fmt.Errorf("generating queries: %w", err)
+	}
+
+	fmt.Printf("✓ Generated %d queries from %s/ in %s/\n", len(queries), dir, config.Output.Dir)
+	fmt.Printf("  - sql_queries.go (typed query functions)\n")
+
+	return nil
+}
+
+// GenerateDocs generates documentation of the schema, with an ER diagram
+// and the history of the migrations directory, into outDir (default:
+// docs) as Markdown, or HTML with format html.
+func GenerateDocs(outDir, format string) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("validating schema: %w", err)
+	}
+
+	engine := migration.NewEngine(nil)
+	if err := engine.LoadFromDir(migrationsDir); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+
+	if outDir == "" {
+		outDir = "docs"
+	}
+	gen := codegen.NewGenerator(s, config.Output.Package, outDir)
+	opts := codegen.DocsOptions{Format: format, Diagram: studio.SchemaMermaid(s), Migrations: engine.Migrations()}
+	if err := gen.GenerateDocs(opts); err != nil {
+		return fmt.Errorf("generating docs: %w", err)
+	}
+
+	fmt.Printf("✓ Generated schema docs in %s/\n", outDir)
+	fmt.Printf("  - %s (models, ER diagram and migration history)\n", codegen.DocsFile(format))
+
+	return nil
+}
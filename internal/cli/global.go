@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// GlobalOptions holds the settings of the flags every command takes.
+type GlobalOptions struct {
+	Yes     bool          // Answer yes to confirmations, for unattended runs
+	Timeout time.Duration // Cancel the command after this long, 0 for no limit
+	Quiet   bool          // Print only errors and the output asked for, such as dumps
+}
+
+// global is the GlobalOptions the commands run with.
+var global struct {
+	GlobalOptions
+	ctx context.Context
+}
+
+// stdout is the standard output of the process, which output asked for
+// goes to even with Quiet.
+var stdout io.Writer = os.Stdout
+
+// SetGlobalOptions makes the commands run with opts. With Quiet, what the
+// commands print to the standard output is discarded, apart from output
+// they were asked for. The function returned ends the timeout of the
+// command.
+func SetGlobalOptions(opts GlobalOptions) (func(), error) {
+	global.GlobalOptions = opts
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+	}
+	global.ctx = ctx
+
+	if opts.Quiet {
+		null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		os.Stdout = null
+	}
+	return cancel, nil
+}
+
+// commandContext returns the context of the command, which ends with the
+// timeout set by --timeout.
+func commandContext() context.Context {
+	if global.ctx == nil {
+		return context.Background()
+	}
+	return global.ctx
+}
+
+// TimedOut reports whether the command ran past its timeout.
+func TimedOut() bool {
+	return global.Timeout > 0 && commandContext().Err() == context.DeadlineExceeded
+}
+
+// confirm asks whether to go ahead with action, which can't be undone,
+// failing unless the answer is yes. With Yes it goes ahead without asking;
+// without a terminal to ask on it fails, so that unattended runs neither
+// hang nor act unasked.
+func confirm(action string) error {
+	if global.Yes {
+		return nil
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("refusing to %s without confirmation (use --yes to confirm)", action)
+	}
+	fmt.Fprintf(os.Stderr, "This will %s. Continue? [y/N] ", action)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("cancelled")
+}
@@ -13,17 +13,36 @@ type Config struct {
 	Database DatabaseConfig `json:"database"`
 	Schema   SchemaConfig   `json:"schema"`
 	Output   OutputConfig   `json:"output"`
+	Seeds    SeedsConfig    `json:"seeds,omitempty"`
+
+	Migrations MigrationsConfig `json:"migrations,omitempty"`
+	Dev        DevConfig        `json:"dev,omitempty"`
+
+	// Other databases by name, selected with --db, or given to --from and
+	// --to of db copy and db sample
+	Databases map[string]DatabaseConfig `json:"databases,omitempty"`
+
+	Logging LoggingConfig `json:"logging,omitempty"`
 }
 
 // DatabaseConfig holds database connection settings.
 type DatabaseConfig struct {
-	Dialect string `json:"dialect"` // postgres, sqlite, mysql
+	Dialect string `json:"dialect"` // postgres, sqlite, mysql, mssql, libsql
 	URL     string `json:"url"`     // Connection string
+
+	// libSQL/Turso only
+	AuthToken    string `json:"authToken,omitempty"`    // Defaults to $TURSO_AUTH_TOKEN
+	Replica      string `json:"replica,omitempty"`      // Local file of an embedded replica
+	SyncInterval string `json:"syncInterval,omitempty"` // How often the replica syncs, e.g. 1m
+
+	unset error // Names an environment variable the settings use that is unset
 }
 
 // SchemaConfig holds schema file settings.
 type SchemaConfig struct {
-	Path string `json:"path"` // Path to schema.nexus file
+	Path     string `json:"path"`               // Path to schema.nexus file or a directory of .nexus files
+	Snapshot string `json:"snapshot,omitempty"` // Path to schema snapshot (default .nexus.lock)
+	Queries  string `json:"queries,omitempty"`  // Directory of annotated .sql files for nexus gen queries (default queries)
 }
 
 // OutputConfig holds code generation output settings.
@@ -32,6 +51,43 @@ type OutputConfig struct {
 	Package string `json:"package"` // Go package name
 }
 
+// SeedsConfig holds seed settings.
+type SeedsConfig struct {
+	OnChange string `json:"onChange,omitempty"` // warn (default), error or rerun-on-change
+}
+
+// DevConfig holds settings of nexus dev.
+type DevConfig struct {
+	Build  string   `json:"build,omitempty"`  // Command checking the generated code after each generation, e.g. go vet ./...
+	Watch  []string `json:"watch,omitempty"`  // Globs of more files to regenerate on, e.g. schema/**/*.nexus
+	Ignore []string `json:"ignore,omitempty"` // Globs of files and directories not to react to
+}
+
+// MigrationsConfig holds migration settings.
+type MigrationsConfig struct {
+	Lock   LockConfig  `json:"lock,omitempty"`
+	Strict bool        `json:"strict,omitempty"` // Refuse out-of-order migrations in migrate up
+	Auto   bool        `json:"auto,omitempty"`   // Allow migrate auto; enable it for development only
+	Hooks  HooksConfig `json:"hooks,omitempty"`
+}
+
+// HooksConfig lists commands run around migrations: shell commands, or
+// "go:name" for Go functions registered with migration.RegisterHook.
+type HooksConfig struct {
+	BeforeMigrate []string `json:"before_migrate,omitempty"`
+	AfterMigrate  []string `json:"after_migrate,omitempty"`
+}
+
+// LockConfig selects how concurrent migration runs are kept apart.
+type LockConfig struct {
+	Backend string `json:"backend,omitempty"` // auto (default), table, advisory, file or redis
+	Path    string `json:"path,omitempty"`    // Lock file of the file backend
+	URL     string `json:"url,omitempty"`     // Redis URL of the redis backend
+	Key     string `json:"key,omitempty"`     // Redis key (default nexus:migration_lock)
+	TTL     string `json:"ttl,omitempty"`     // Lease of table, file and redis locks (default 10m)
+	Timeout string `json:"timeout,omitempty"` // How long to wait for the lock (default 0, fail at once)
+}
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() *Config {
 	return &Config{
@@ -84,11 +140,11 @@ func Init(dir string) error {
 // Define your models here
 
 model User {
-  id        Int       @id @autoincrement
-  email     String    @unique
+  id        Int      @id @autoincrement
+  email     String   @unique
   name      String?
-  createdAt DateTime  @default(now())
-  updatedAt DateTime  @default(now())
+  createdAt DateTime @default(now())
+  updatedAt DateTime @default(now())
 }
 
 // Add more models below...
@@ -124,18 +180,9 @@ model User {
 
 // LoadConfig loads the configuration from the current directory.
 func LoadConfig() (*Config, error) {
-	data, err := os.ReadFile(configFileName)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("not a Nexus project (nexus.json not found). Run 'nexus init' first")
-		}
-		return nil, err
+	config, err := LoadConfigFile(configFileName)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("not a Nexus project (nexus.json not found). Run 'nexus init' first")
 	}
-
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("parsing config: %w", err)
-	}
-
-	return &config, nil
+	return config, err
 }
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// LoggingConfig sets what the migration engine, seed engine, dev watcher
+// and studio log.
+type LoggingConfig struct {
+	Level  string            `json:"level,omitempty"`  // debug, info (default), warn or error
+	Levels map[string]string `json:"levels,omitempty"` // By subsystem: migrate, seed, dev or studio
+	Format string            `json:"format,omitempty"` // text (default) or json
+}
+
+// validate checks the levels and format.
+func (c LoggingConfig) validate() error {
+	if _, err := c.levels(); err != nil {
+		return err
+	}
+	switch strings.ToLower(c.Format) {
+	case "", "text", "json":
+		return nil
+	}
+	return fmt.Errorf("unknown format %q (expected text or json)", c.Format)
+}
+
+func (c LoggingConfig) levels() (nexuslog.Levels, error) {
+	return nexuslog.ParseLevels(c.Level, c.Levels)
+}
+
+// configured reports whether a level is set for subsystem.
+func (c LoggingConfig) configured(subsystem string) bool {
+	_, ok := c.Levels[subsystem]
+	return ok || c.Level != ""
+}
+
+// logger returns the logger of subsystem, writing to w.
+func (c LoggingConfig) logger(subsystem string, w io.Writer) nexuslog.Logger {
+	var base nexuslog.Logger
+	if strings.EqualFold(c.Format, "json") {
+		base = nexuslog.Slog(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	} else {
+		base = nexuslog.NewText(w)
+	}
+	levels, _ := c.levels() // Checked by LoadConfig
+	return levels.For(base, subsystem)
+}
+
+// engineLogger returns the logger of the migration or seed engine of a
+// command. The commands print their own progress, so the engines only log,
+// to stderr, when a level is configured.
+func (c LoggingConfig) engineLogger(subsystem string) nexuslog.Logger {
+	if !c.configured(subsystem) {
+		return nexuslog.Nop()
+	}
+	return c.logger(subsystem, os.Stderr)
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/nexus-db/nexus/internal/lsp"
+)
+
+// LSP runs the language server on stdin and stdout. Inside a Nexus project
+// the configured schema is checked as a whole; elsewhere each open file is
+// checked on its own.
+func LSP() error {
+	var schemaPath string
+	if config, err := LoadConfig(); err == nil {
+		schemaPath = config.Schema.Path
+	}
+
+	server := lsp.NewServer(lsp.Config{
+		In:         os.Stdin,
+		Out:        stdout,
+		Log:        os.Stderr,
+		SchemaPath: schemaPath,
+	})
+	return server.Run()
+}
@@ -12,9 +12,11 @@ import (
 	"github.com/nexus-db/nexus/pkg/core/migration"
 	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
 	"github.com/nexus-db/nexus/pkg/dialects/mysql"
 	"github.com/nexus-db/nexus/pkg/dialects/postgres"
 	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
 )
 
 const migrationsDir = "migrations"
@@ -67,9 +69,22 @@ func MigrateNew(name string) error {
 	return nil
 }
 
+// MigrateUpOptions configures migrate up.
+type MigrateUpOptions struct {
+	Force   bool   // Break any stale locks before proceeding
+	Strict  bool   // Fail on migrations older than the newest applied one
+	Seed    bool   // Run pending seeds afterwards
+	Env     string // Environment of the migrations and seeds to run; migrations default to the selected config environment
+	Verbose bool   // Print each statement with its timing and affected rows
+}
+
+// DefaultMigrateUpOptions returns the default migrate up options.
+func DefaultMigrateUpOptions() MigrateUpOptions {
+	return MigrateUpOptions{}
+}
+
 // MigrateUp applies all pending migrations.
-// If force is true, breaks any stale locks before proceeding.
-func MigrateUp(force bool) error {
+func MigrateUp(opts MigrateUpOptions) error {
 	config, err := LoadConfig()
 	if err != nil {
 		return err
@@ -81,8 +96,11 @@ func MigrateUp(force bool) error {
 	}
 	defer conn.Close()
 
-	ctx := context.Background()
-	engine := migration.NewEngine(conn)
+	ctx := commandContext()
+	engine, lockOpts, err := migrationEngine(config, conn)
+	if err != nil {
+		return err
+	}
 
 	// Initialize migrations table
 	if err := engine.Init(ctx); err != nil {
@@ -90,14 +108,13 @@ func MigrateUp(force bool) error {
 	}
 
 	// Handle force unlock
-	if force {
+	if opts.Force {
 		if err := engine.ForceUnlock(ctx); err != nil {
 			return fmt.Errorf("force unlocking: %w", err)
 		}
 	}
 
 	// Acquire lock
-	lockOpts := migration.DefaultLockOptions()
 	if err := engine.AcquireLock(ctx, lockOpts); err != nil {
 		return fmt.Errorf("acquiring lock: %w", err)
 	}
@@ -112,7 +129,32 @@ func MigrateUp(force bool) error {
 		return fmt.Errorf("loading migrations: %w", err)
 	}
 
+	if opts.Verbose {
+		engine.WithProgress(statementPrinter())
+	}
+	if opts.Env != "" {
+		engine.WithEnvironment(opts.Env)
+	}
+	if err := printExcluded(ctx, engine); err != nil {
+		return err
+	}
+
 	// Apply pending
+	if opts.Strict || config.Migrations.Strict {
+		engine.WithStrictOrder(true)
+	} else {
+		older, latest, err := engine.OutOfOrder(ctx)
+		if err != nil {
+			return fmt.Errorf("checking migration order: %w", err)
+		}
+		for _, m := range older {
+			fmt.Printf("⚠ Migration %s_%s is older than the applied migration %s; applying it anyway.\n", m.ID, m.Name, latest)
+		}
+		if len(older) > 0 {
+			fmt.Println("  Use --strict to refuse this, or 'nexus migrate repair' to renumber it.")
+		}
+	}
+	start := time.Now()
 	applied, err := engine.Up(ctx)
 	if err != nil {
 		return fmt.Errorf("applying migrations: %w", err)
@@ -121,12 +163,78 @@ func MigrateUp(force bool) error {
 	if applied == 0 {
 		fmt.Println("No pending migrations.")
 	} else {
-		fmt.Printf("✓ Applied %d migration(s)\n", applied)
+		fmt.Printf("✓ Applied %d migration(s) in %s\n", applied, formatElapsed(time.Since(start)))
 	}
 
+	if opts.Seed {
+		engine.ReleaseLock(ctx)
+		return SeedRun(opts.Env, false, "", false)
+	}
 	return nil
 }
 
+// printExcluded lists the pending migrations gated to other environments
+// than the one the engine migrates, which are left out.
+func printExcluded(ctx context.Context, engine *migration.Engine) error {
+	status, err := engine.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("getting status: %w", err)
+	}
+	current := "not " + engine.Environment()
+	if engine.Environment() == "" {
+		current = "and no environment is selected (use --env or NEXUS_ENV)"
+	}
+	for _, s := range status {
+		if s.Excluded {
+			fmt.Printf("⊘ Skipping %s_%s: runs only in %s, %s\n", s.ID, s.Name, strings.Join(s.Environments, ", "), current)
+		}
+	}
+	return nil
+}
+
+// statementPrinter returns a progress function that prints each statement
+// of a migration with its elapsed time and affected rows.
+func statementPrinter() migration.ProgressFunc {
+	var total time.Duration
+	return func(e migration.StatementEvent) {
+		if e.Index == 1 {
+			total = 0
+			fmt.Printf("→ %s_%s (%s, %d statement(s))\n", e.Migration.ID, e.Migration.Name, e.Direction, e.Total)
+		}
+		total += e.Elapsed
+
+		rows := ""
+		if e.RowsAffected >= 0 {
+			rows = fmt.Sprintf(", %d row(s)", e.RowsAffected)
+		}
+		fmt.Printf("  [%d/%d] %s (%s%s)\n", e.Index, e.Total, statementSummary(e.SQL), formatElapsed(e.Elapsed), rows)
+		if e.Index == e.Total {
+			fmt.Printf("  done in %s\n", formatElapsed(total))
+		}
+	}
+}
+
+// statementSummary shortens a statement to one line.
+func statementSummary(stmt string) string {
+	stmt = strings.Join(strings.Fields(stmt), " ")
+	if len(stmt) > 72 {
+		stmt = stmt[:69] + "..."
+	}
+	return stmt
+}
+
+// formatElapsed rounds d for display.
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Minute:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
 // MigrateDown rolls back migrations.
 // If targetID is specified, rolls back to that migration (exclusive).
 // If n > 0, rolls back n migrations.
@@ -144,8 +252,11 @@ func MigrateDown(targetID string, n int, force bool) error {
 	}
 	defer conn.Close()
 
-	ctx := context.Background()
-	engine := migration.NewEngine(conn)
+	ctx := commandContext()
+	engine, lockOpts, err := migrationEngine(config, conn)
+	if err != nil {
+		return err
+	}
 
 	// Initialize migrations table
 	if err := engine.Init(ctx); err != nil {
@@ -159,8 +270,17 @@ func MigrateDown(targetID string, n int, force bool) error {
 		}
 	}
 
+	action := "roll back the last migration"
+	if targetID != "" {
+		action = "roll back the migrations after " + targetID
+	} else if n > 0 {
+		action = fmt.Sprintf("roll back the last %d migration(s)", n)
+	}
+	if err := confirm(fmt.Sprintf("%s of the %s database, dropping what they created", action, config.Database.Dialect)); err != nil {
+		return err
+	}
+
 	// Acquire lock
-	lockOpts := migration.DefaultLockOptions()
 	if err := engine.AcquireLock(ctx, lockOpts); err != nil {
 		return fmt.Errorf("acquiring lock: %w", err)
 	}
@@ -214,8 +334,11 @@ func MigrateStatus() error {
 	}
 	defer conn.Close()
 
-	ctx := context.Background()
-	engine := migration.NewEngine(conn)
+	ctx := commandContext()
+	engine, _, err := migrationEngine(config, conn)
+	if err != nil {
+		return err
+	}
 
 	// Initialize migrations table
 	if err := engine.Init(ctx); err != nil {
@@ -244,21 +367,339 @@ func MigrateStatus() error {
 
 	fmt.Println("Migration Status:")
 	fmt.Println(strings.Repeat("-", 60))
+	outOfOrder := 0
 	for _, s := range status {
 		indicator := "[ ]"
 		appliedAt := ""
-		if s.Applied {
+		if s.Excluded {
+			indicator = "[-]"
+			appliedAt = fmt.Sprintf("(only in %s)", strings.Join(s.Environments, ", "))
+		} else if s.Applied {
 			indicator = "[✓]"
 			appliedAt = s.AppliedAt.Format(time.RFC3339)
+			if s.Duration > 0 {
+				appliedAt += fmt.Sprintf(" (took %s)", formatElapsed(s.Duration))
+			}
+		} else if s.OutOfOrder {
+			indicator = "[!]"
+			appliedAt = "(out of order: older than an applied migration)"
+			outOfOrder++
 		}
 		fmt.Printf("%s %s_%s %s\n", indicator, s.ID, s.Name, appliedAt)
+		if details := statusDetails(s); details != "" {
+			fmt.Printf("    %s\n", details)
+		}
+	}
+	if outOfOrder > 0 {
+		fmt.Printf("\n⚠ %d pending migration(s) out of order. Run 'nexus migrate repair' to renumber them.\n", outOfOrder)
+	}
+
+	return printLockStatus(ctx, engine)
+}
+
+// statusDetails describes what the nexus: header of a migration says
+// about it, for the status listing.
+func statusDetails(s migration.MigrationStatus) string {
+	var details []string
+	if s.Description != "" {
+		details = append(details, s.Description)
+	}
+	if s.Author != "" {
+		details = append(details, "by "+s.Author)
+	}
+	if s.NoTransaction {
+		details = append(details, "no transaction")
+	}
+	if len(s.Environments) > 0 && !s.Excluded {
+		details = append(details, "env: "+strings.Join(s.Environments, ", "))
+	}
+	return strings.Join(details, " · ")
+}
+
+// MigrateRepair renumbers pending migrations that are older than applied
+// ones and points history rows at renamed migration files. With dryRun it
+// only shows what it would change.
+func MigrateRepair(dryRun bool) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	conn, err := connect(config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := commandContext()
+	engine, lockOpts, err := migrationEngine(config, conn)
+	if err != nil {
+		return err
+	}
+	if err := engine.Init(ctx); err != nil {
+		return fmt.Errorf("initializing migrations table: %w", err)
+	}
+	if err := engine.LoadFromDir(migrationsDir); err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("No migrations found.")
+			return nil
+		}
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+
+	if !dryRun {
+		if err := engine.AcquireLock(ctx, lockOpts); err != nil {
+			return fmt.Errorf("acquiring lock: %w", err)
+		}
+		defer engine.ReleaseLock(ctx)
+	}
+
+	plan, err := engine.PlanRepair(ctx)
+	if err != nil {
+		return fmt.Errorf("planning repair: %w", err)
+	}
+	if plan.Empty() {
+		fmt.Println("✓ Migrations and history are in order.")
+		return nil
+	}
+
+	for _, r := range plan.Relink {
+		fmt.Printf("  relink   %s_%s -> %s (file was renamed)\n", r.OldID, r.Name, r.NewID)
+	}
+	for _, r := range plan.Renumber {
+		fmt.Printf("  renumber %s_%s -> %s_%s.sql\n", r.OldID, r.Name, r.NewID, r.Name)
+	}
+	for _, h := range plan.Orphaned {
+		fmt.Printf("  ⚠ %s_%s is applied but has no file; left in the history\n", h.MigrationID, h.Name)
+	}
+
+	if dryRun {
+		fmt.Println("\nDry run: nothing was changed.")
+		return nil
+	}
+	if err := engine.Repair(ctx, migrationsDir, plan); err != nil {
+		return fmt.Errorf("repairing: %w", err)
+	}
+	fmt.Printf("✓ Renumbered %d migration(s), relinked %d history row(s)\n", len(plan.Renumber), len(plan.Relink))
+	return nil
+}
+
+// MigrateImportOptions configures migrate import.
+type MigrateImportOptions struct {
+	Format   string // goose, golang-migrate or prisma
+	Backfill bool   // Record the migrations the other tool applied as applied
+	DryRun   bool   // Show what would be imported without changing anything
+}
+
+// DefaultMigrateImportOptions returns the default migrate import options.
+func DefaultMigrateImportOptions() MigrateImportOptions {
+	return MigrateImportOptions{}
+}
+
+// MigrateImport converts the migrations another tool keeps in dir into
+// migration files. With Backfill, the migrations the history table of the
+// tool records as applied are recorded in the Nexus history, so that
+// migrate up doesn't run them again.
+func MigrateImport(dir string, opts MigrateImportOptions) error {
+	format, err := migration.ParseImportFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	result, err := migration.ImportMigrations(dir, format)
+	if err != nil {
+		return fmt.Errorf("reading %s migrations: %w", format, err)
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Printf("  ⚠ skipped %s\n", skipped)
+	}
+	if len(result.Migrations) == 0 {
+		fmt.Printf("No %s migrations found in %s.\n", format, dir)
+		return nil
+	}
+
+	var imported []*migration.ImportedMigration
+	for _, m := range result.Migrations {
+		path := filepath.Join(migrationsDir, fmt.Sprintf("%s_%s.sql", m.ID, m.Name))
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("  exists   %s (from %s)\n", path, m.Source)
+			continue
+		}
+		fmt.Printf("  import   %s -> %s\n", m.Source, path)
+		imported = append(imported, m)
+	}
+
+	var applied []*migration.ImportedMigration
+	var engine *migration.Engine
+	if opts.Backfill {
+		config, err := LoadConfig()
+		if err != nil {
+			return err
+		}
+		conn, err := connect(config)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		ctx := commandContext()
+		if engine, _, err = migrationEngine(config, conn); err != nil {
+			return err
+		}
+		if applied, err = migration.AppliedImports(ctx, conn, format, result.Migrations); err != nil {
+			return err
+		}
+		fmt.Printf("%d of %d migration(s) are applied according to %s\n", len(applied), len(result.Migrations), format)
+	}
+
+	if opts.DryRun {
+		fmt.Println("\nDry run: nothing was changed.")
+		return nil
+	}
+	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+		return err
+	}
+	for _, m := range imported {
+		if err := migration.SaveMigration(migrationsDir, m.Migration); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("✓ Imported %d migration(s) into %s\n", len(imported), migrationsDir)
+
+	if engine != nil {
+		ctx := commandContext()
+		if err := engine.Init(ctx); err != nil {
+			return fmt.Errorf("initializing migrations table: %w", err)
+		}
+		migrations := make([]*migration.Migration, len(applied))
+		for i, m := range applied {
+			migrations[i] = m.Migration
+		}
+		n, err := engine.Record(ctx, migrations)
+		if err != nil {
+			return fmt.Errorf("backfilling history: %w", err)
+		}
+		fmt.Printf("✓ Recorded %d applied migration(s) in the history\n", n)
 	}
+	return nil
+}
 
+// printLockStatus shows who holds the migration lock.
+func printLockStatus(ctx context.Context, engine *migration.Engine) error {
+	info, err := engine.GetLockInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("reading lock: %w", err)
+	}
+	fmt.Println(strings.Repeat("-", 60))
+	if info == nil {
+		fmt.Printf("Lock: free (%s)\n", engine.Locker().Name())
+		return nil
+	}
+	state := "held"
+	if info.IsExpired {
+		state = "expired"
+	}
+	fmt.Printf("Lock: %s (%s)\n", state, info.Backend)
+	fmt.Printf("  host:       %s\n", info.Host)
+	fmt.Printf("  pid:        %d\n", info.PID)
+	fmt.Printf("  holder:     %s\n", info.LockedBy)
+	fmt.Printf("  started_at: %s\n", info.LockedAt.Format(time.RFC3339))
+	if !info.ExpiresAt.IsZero() {
+		fmt.Printf("  expires_at: %s\n", info.ExpiresAt.Format(time.RFC3339))
+	}
 	return nil
 }
 
+// migrationEngine returns a migration engine with the lock backend, lock
+// options and hooks of the config, migrating the selected environment.
+func migrationEngine(config *Config, conn *dialects.Connection) (*migration.Engine, migration.LockOptions, error) {
+	lc := config.Migrations.Lock
+	opts := migration.DefaultLockOptions()
+	opts.OnRenewError = func(err error) {
+		fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+	}
+	for _, d := range []struct {
+		value  string
+		target *time.Duration
+		name   string
+	}{{lc.TTL, &opts.LockTTL, "ttl"}, {lc.Timeout, &opts.Timeout, "timeout"}} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, opts, fmt.Errorf("migrations.lock.%s: %w", d.name, err)
+		}
+		*d.target = v
+	}
+
+	engine := migration.NewEngine(conn).WithLogger(config.Logging.engineLogger(nexuslog.SubsystemMigrate)).
+		WithEnvironment(selectedEnvironment())
+	hooks, err := migrationHooks(config)
+	if err != nil {
+		return nil, opts, err
+	}
+	engine.WithHooks(hooks)
+
+	table := migration.LockTable
+	switch strings.ToLower(lc.Backend) {
+	case "", "auto":
+	case "table":
+		engine.WithLocker(migration.NewTableLocker(conn, table))
+	case "advisory":
+		locker, err := migration.NewAdvisoryLocker(conn, table)
+		if err != nil {
+			return nil, opts, err
+		}
+		engine.WithLocker(locker)
+	case "file":
+		path := lc.Path
+		if path == "" {
+			path = filepath.Join(migrationsDir, ".lock")
+		}
+		engine.WithLocker(migration.NewFileLocker(path))
+	case "redis":
+		if lc.URL == "" {
+			return nil, opts, fmt.Errorf("migrations.lock.url is required for the redis lock")
+		}
+		locker, err := migration.NewRedisLocker(lc.URL, lc.Key)
+		if err != nil {
+			return nil, opts, err
+		}
+		engine.WithLocker(locker)
+	default:
+		return nil, opts, fmt.Errorf("unknown lock backend %q (expected auto, table, advisory, file or redis)", lc.Backend)
+	}
+	return engine, opts, nil
+}
+
+// MigrateValidateOptions configures migrate validate.
+type MigrateValidateOptions struct {
+	FailOn  string // Lowest severity that fails validation: "error" or "warning"
+	Dialect string // Dialect for the zero-downtime rules; defaults to the configured one
+}
+
+// DefaultMigrateValidateOptions returns the default migrate validate options.
+func DefaultMigrateValidateOptions() MigrateValidateOptions {
+	return MigrateValidateOptions{FailOn: "error"}
+}
+
 // MigrateValidate validates all migration files.
-func MigrateValidate() error {
+func MigrateValidate(opts MigrateValidateOptions) error {
+	failOn, err := migration.ParseSeverity(opts.FailOn)
+	if err != nil {
+		return err
+	}
+	validateOpts := migration.DefaultValidateOptions()
+	validateOpts.Dialect = opts.Dialect
+	if validateOpts.Dialect == "" {
+		if config, err := LoadConfig(); err == nil {
+			validateOpts.Dialect = config.Database.Dialect
+		}
+	}
+	// Migrations may be gated to environments of nexus.json or of seeds/
+	if envs := CompleteEnvironments(""); len(envs) > 0 {
+		validateOpts.Environments = append(envs, CompleteSeedEnvs("")...)
+	}
+
 	// Load migrations from directory
 	files, err := os.ReadDir(migrationsDir)
 	if err != nil {
@@ -280,7 +721,7 @@ func MigrateValidate() error {
 			return fmt.Errorf("reading %s: %w", f.Name(), err)
 		}
 
-		m, err := parseMigrationFile(f.Name(), string(content))
+		m, err := migration.ParseMigrationFile(f.Name(), string(content))
 		if err != nil {
 			return fmt.Errorf("parsing %s: %w", f.Name(), err)
 		}
@@ -296,9 +737,9 @@ func MigrateValidate() error {
 	fmt.Printf("Validating %d migration(s)...\n\n", len(migrations))
 
 	// Validate all migrations
-	results := migration.ValidateMigrations(migrations)
+	results := migration.ValidateMigrations(migrations, validateOpts)
 
-	hasErrors := false
+	failed := false
 	hasWarnings := false
 
 	for _, result := range results {
@@ -312,10 +753,12 @@ func MigrateValidate() error {
 			prefix := "⚠️  WARNING"
 			if issue.Severity == migration.SeverityError {
 				prefix = "❌ ERROR"
-				hasErrors = true
 			} else {
 				hasWarnings = true
 			}
+			if issue.Rule != "" {
+				prefix += " [" + issue.Rule + "]"
+			}
 
 			fmt.Printf("  %s: %s\n", prefix, issue.Message)
 			if issue.Suggestion != "" {
@@ -323,9 +766,13 @@ func MigrateValidate() error {
 			}
 		}
 		fmt.Println()
+		failed = failed || result.Fails(failOn)
 	}
 
-	if hasErrors {
+	if failed {
+		if failOn == migration.SeverityWarning {
+			return fmt.Errorf("validation failed with warnings (--fail-on warning)")
+		}
 		return fmt.Errorf("validation failed with errors")
 	}
 
@@ -339,7 +786,7 @@ func MigrateValidate() error {
 }
 
 // MigrateReset drops all tables and reruns all migrations.
-func MigrateReset() error {
+func MigrateReset(seed bool, seedEnv string) error {
 	config, err := LoadConfig()
 	if err != nil {
 		return err
@@ -351,24 +798,37 @@ func MigrateReset() error {
 	}
 	defer conn.Close()
 
-	ctx := context.Background()
-	engine := migration.NewEngine(conn)
+	ctx := commandContext()
+	engine, lockOpts, err := migrationEngine(config, conn)
+	if err != nil {
+		return err
+	}
+	if seedEnv != "" {
+		engine.WithEnvironment(seedEnv)
+	}
+
+	// Initialize migrations table
+	if err := engine.Init(ctx); err != nil {
+		return err
+	}
 
 	// Load migrations
 	if err := engine.LoadFromDir(migrationsDir); err != nil {
 		return fmt.Errorf("loading migrations: %w", err)
 	}
 
-	// Rollback all
-	for {
-		if err := engine.Down(ctx); err != nil {
-			break // No more migrations to rollback
-		}
+	if err := confirm(fmt.Sprintf("roll back every migration of the %s database, dropping its data, and apply them again", config.Database.Dialect)); err != nil {
+		return err
 	}
 
-	// Initialize and apply all
-	if err := engine.Init(ctx); err != nil {
-		return err
+	if err := engine.AcquireLock(ctx, lockOpts); err != nil {
+		return fmt.Errorf("acquiring lock: %w", err)
+	}
+	defer engine.ReleaseLock(ctx)
+
+	// Rollback all, then apply all
+	if _, err := engine.DownAll(ctx); err != nil {
+		return fmt.Errorf("rolling back: %w", err)
 	}
 
 	applied, err := engine.Up(ctx)
@@ -377,22 +837,55 @@ func MigrateReset() error {
 	}
 
 	fmt.Printf("✓ Reset complete. Applied %d migration(s)\n", applied)
+
+	if seed {
+		// The seeded rows are gone, so every seed runs again
+		engine.ReleaseLock(ctx)
+		return SeedRun(seedEnv, true, "", false)
+	}
 	return nil
 }
 
+// migrationHooks returns the hooks of the config. Shell commands get the
+// database in NEXUS_DIALECT and NEXUS_DATABASE_URL.
+func migrationHooks(config *Config) (migration.Hooks, error) {
+	env := []string{
+		"NEXUS_DIALECT=" + config.Database.Dialect,
+		"NEXUS_DATABASE_URL=" + config.Database.URL,
+	}
+	var hooks migration.Hooks
+	for _, stage := range []struct {
+		entries []string
+		hooks   *[]migration.Hook
+		name    migration.HookStage
+	}{
+		{config.Migrations.Hooks.BeforeMigrate, &hooks.BeforeMigrate, migration.BeforeMigrate},
+		{config.Migrations.Hooks.AfterMigrate, &hooks.AfterMigrate, migration.AfterMigrate},
+	} {
+		for _, entry := range stage.entries {
+			hook, err := migration.ParseHook(entry, env)
+			if err != nil {
+				return hooks, fmt.Errorf("migrations.hooks.%s: %w", stage.name, err)
+			}
+			*stage.hooks = append(*stage.hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
 func connect(config *Config) (*dialects.Connection, error) {
 	dialect, err := getDialect(config.Database.Dialect)
 	if err != nil {
 		return nil, err
 	}
 
-	db, err := sql.Open(dialect.DriverName(), config.Database.URL)
+	db, err := openDB(config.Database, dialect)
 	if err != nil {
 		return nil, fmt.Errorf("connecting to database: %w", err)
 	}
 
 	// Test connection
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(commandContext()); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("pinging database: %w", err)
 	}
@@ -400,6 +893,34 @@ func connect(config *Config) (*dialects.Connection, error) {
 	return dialects.NewConnection(db, dialect), nil
 }
 
+// openDB opens the database of cfg with the driver of dialect. libSQL
+// databases get their auth token and may be embedded replicas.
+func openDB(cfg DatabaseConfig, dialect dialects.Dialect) (*sql.DB, error) {
+	if cfg.unset != nil {
+		return nil, cfg.unset
+	}
+	if dialect.DriverName() != "libsql" {
+		return sql.Open(dialect.DriverName(), cfg.URL)
+	}
+
+	opts := sqlite.LibSQLOptions{
+		URL:         cfg.URL,
+		AuthToken:   cfg.AuthToken,
+		ReplicaPath: cfg.Replica,
+	}
+	if opts.AuthToken == "" {
+		opts.AuthToken = os.Getenv("TURSO_AUTH_TOKEN")
+	}
+	if cfg.SyncInterval != "" {
+		interval, err := time.ParseDuration(cfg.SyncInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syncInterval %q: %w", cfg.SyncInterval, err)
+		}
+		opts.SyncInterval = interval
+	}
+	return sqlite.OpenLibSQL(opts)
+}
+
 func getDialect(name string) (dialects.Dialect, error) {
 	switch strings.ToLower(name) {
 	case "postgres", "postgresql":
@@ -408,8 +929,12 @@ func getDialect(name string) (dialects.Dialect, error) {
 		return sqlite.New(), nil
 	case "mysql":
 		return mysql.New(), nil
+	case "mssql", "sqlserver":
+		return mssql.New(), nil
+	case "libsql", "turso":
+		return sqlite.NewLibSQL(), nil
 	default:
-		return nil, fmt.Errorf("unknown dialect: %s (supported: postgres, sqlite, mysql)", name)
+		return nil, fmt.Errorf("unknown dialect: %s (supported: postgres, sqlite, mysql, mssql, libsql)", name)
 	}
 }
 
@@ -438,17 +963,24 @@ func MigrateFresh(name string) error {
 
 	var upStatements []string
 	var downStatements []string
+	createdEnums := make(map[string]bool)
+	createdSchemas := make(map[string]bool)
 
 	for _, model := range s.GetModels() {
+		upStatements = append(upStatements, migration.SchemaStatements(dialect, model, createdSchemas)...)
+		upStatements = append(upStatements, migration.EnumTypeStatements(dialect, model.GetFields(), createdEnums)...)
 		upStatements = append(upStatements, dialect.CreateTableSQL(model))
-		downStatements = append(downStatements, dialect.DropTableSQL(model.Name))
+		upStatements = append(upStatements, migration.PartitionStatements(dialect, model)...)
+		downStatements = append(downStatements, dialect.DropTableSQL(model.Table()))
 
 		for _, idx := range model.Indexes {
 			if len(idx.Fields) > 1 || !idx.Unique {
-				upStatements = append(upStatements, dialect.CreateIndexSQL(model.Name, idx))
-				downStatements = append(downStatements, dialect.DropIndexSQL(model.Name, idx.Name))
+				upStatements = append(upStatements, dialect.CreateIndexSQL(model.Table(), idx))
+				downStatements = append(downStatements, dialect.DropIndexSQL(model.Table(), idx.Name))
 			}
 		}
+		upStatements = append(upStatements, migration.CommentStatements(dialect, model)...)
+		upStatements = append(upStatements, migration.RowSecurityStatements(dialect, model)...)
 	}
 
 	// Create migration file
@@ -476,7 +1008,9 @@ func MigrateFresh(name string) error {
 }
 
 // MigrateDiff compares the schema with the current database and generates a migration.
-func MigrateDiff(name string) error {
+// If offline is true, the schema is compared against the snapshot file instead of
+// the database, and the snapshot is updated after the migration is written.
+func MigrateDiff(name string, offline bool) error {
 	config, err := LoadConfig()
 	if err != nil {
 		return err
@@ -492,31 +1026,58 @@ func MigrateDiff(name string) error {
 		return fmt.Errorf("validating schema: %w", err)
 	}
 
-	// Connect to database
-	conn, err := connect(config)
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
+	var dialect dialects.Dialect
+	var snapshot *migration.DatabaseSnapshot
 
-	ctx := context.Background()
+	if offline {
+		dialect, err = getDialect(config.Database.Dialect)
+		if err != nil {
+			return err
+		}
 
-	// Get the introspector from the dialect
-	introspector, ok := conn.Dialect.(migration.Introspector)
-	if !ok {
-		return fmt.Errorf("dialect %s does not support introspection", conn.Dialect.Name())
-	}
+		path := snapshotPath(config)
+		fmt.Printf("Reading snapshot %s...\n", path)
+		saved, err := migration.LoadSnapshot(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return fmt.Errorf("loading snapshot: %w", err)
+			}
+			// No snapshot yet: everything in the schema is new
+			fmt.Println("No snapshot found, diffing against an empty database")
+			snapshot = migration.NewDatabaseSnapshot()
+		} else {
+			if saved.Dialect != dialect.Name() {
+				return fmt.Errorf("snapshot was written for %s but config uses %s", saved.Dialect, dialect.Name())
+			}
+			snapshot = saved.Database()
+		}
+	} else {
+		// Connect to database
+		conn, err := connect(config)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
 
-	// Introspect current database state
-	fmt.Println("Introspecting database...")
-	snapshot, err := migration.IntrospectDatabase(ctx, conn.DB, introspector)
-	if err != nil {
-		return fmt.Errorf("introspecting database: %w", err)
+		dialect = conn.Dialect
+
+		// Get the introspector from the dialect
+		introspector, ok := conn.Dialect.(migration.Introspector)
+		if !ok {
+			return fmt.Errorf("dialect %s does not support introspection", conn.Dialect.Name())
+		}
+
+		// Introspect current database state
+		fmt.Println("Introspecting database...")
+		snapshot, err = migration.IntrospectDatabase(commandContext(), conn.DB, introspector)
+		if err != nil {
+			return fmt.Errorf("introspecting database: %w", err)
+		}
 	}
 
 	// Compute diff
 	fmt.Println("Computing schema diff...")
-	diff := migration.Diff(s, snapshot)
+	diff := migration.DiffWithOptions(s, snapshot, migration.DiffOptions{Dialect: dialect})
 
 	if !diff.HasChanges() {
 		fmt.Println("No schema changes detected. Database is up to date.")
@@ -531,7 +1092,7 @@ func MigrateDiff(name string) error {
 	fmt.Println()
 
 	// Generate migration
-	m, err := migration.GenerateMigrationFromDiff(conn.Dialect, diff.Changes, name)
+	m, err := migration.GenerateMigrationFromDiff(dialect, diff.Changes, name)
 	if err != nil {
 		return fmt.Errorf("generating migration: %w", err)
 	}
@@ -549,11 +1110,21 @@ func MigrateDiff(name string) error {
 	filename := fmt.Sprintf("%s_%s.sql", m.ID, m.Name)
 	fmt.Printf("✓ Created migration: %s/%s\n", migrationsDir, filename)
 
+	if offline {
+		path := snapshotPath(config)
+		if err := migration.SaveSnapshot(path, migration.NewSchemaSnapshot(s, dialect)); err != nil {
+			return fmt.Errorf("updating snapshot: %w", err)
+		}
+		fmt.Printf("✓ Updated snapshot: %s\n", path)
+	}
+
 	return nil
 }
 
 // MigrateSquash combines multiple migrations into a single optimized migration.
-func MigrateSquash(name, fromID, toID string, keepOriginals bool) error {
+// With verify, files are only changed once the squashed migration is shown
+// to leave the schema the originals do.
+func MigrateSquash(name, fromID, toID string, keepOriginals, verify bool) error {
 	// Load migrations from directory
 	files, err := os.ReadDir(migrationsDir)
 	if err != nil {
@@ -574,7 +1145,7 @@ func MigrateSquash(name, fromID, toID string, keepOriginals bool) error {
 			return fmt.Errorf("reading %s: %w", f.Name(), err)
 		}
 
-		m, err := parseMigrationFile(f.Name(), string(content))
+		m, err := migration.ParseMigrationFile(f.Name(), string(content))
 		if err != nil {
 			return fmt.Errorf("parsing %s: %w", f.Name(), err)
 		}
@@ -594,6 +1165,9 @@ func MigrateSquash(name, fromID, toID string, keepOriginals bool) error {
 		ToID:       toID,
 		OutputName: name,
 	}
+	if config, err := LoadConfig(); err == nil {
+		opts.Dialect = config.Database.Dialect
+	}
 
 	result, err := migration.SquashMigrations(migrations, opts)
 	if err != nil {
@@ -606,6 +1180,15 @@ func MigrateSquash(name, fromID, toID string, keepOriginals bool) error {
 	if result.RemovedCount > 0 {
 		fmt.Printf("  Redundant statements removed: %d\n", result.RemovedCount)
 	}
+	if result.MergedCount > 0 {
+		fmt.Printf("  Statements merged: %d\n", result.MergedCount)
+	}
+
+	if verify {
+		if err := verifySquash(migrations, result, opts.Dialect); err != nil {
+			return err
+		}
+	}
 
 	// Backup and/or delete original migrations
 	if !keepOriginals {
@@ -640,32 +1223,105 @@ func MigrateSquash(name, fromID, toID string, keepOriginals bool) error {
 	return nil
 }
 
-// parseMigrationFile parses a migration file (local copy for CLI).
-func parseMigrationFile(filename, content string) (*migration.Migration, error) {
-	// Expected format: 20231221_123000_create_users.sql
-	parts := strings.SplitN(strings.TrimSuffix(filename, ".sql"), "_", 3)
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("invalid migration filename format")
+// verifySquash applies the squashed migration and the migrations it
+// replaces to two temporary SQLite databases, failing unless their
+// schemas match, applied and rolled back.
+func verifySquash(migrations []*migration.Migration, result *migration.SquashResult, dialect string) error {
+	dir, err := os.MkdirTemp("", "nexus-squash-")
+	if err != nil {
+		return err
 	}
+	defer os.RemoveAll(dir)
 
-	id := parts[0] + "_" + parts[1]
-	name := parts[2]
+	var conns []*dialects.Connection
+	for _, name := range []string{"original.db", "squashed.db"} {
+		scratch := sqlite.New()
+		db, err := sql.Open(scratch.DriverName(), filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		conns = append(conns, dialects.NewConnection(db, scratch))
+	}
 
-	// Parse UP and DOWN sections
-	upSQL, downSQL := "", ""
-	sections := strings.Split(content, "-- DOWN")
-	if len(sections) >= 1 {
-		upPart := strings.TrimPrefix(sections[0], "-- UP")
-		upSQL = strings.TrimSpace(upPart)
+	fmt.Println("\nVerifying on temporary SQLite databases...")
+	verification, err := migration.VerifySquash(commandContext(), conns[0], conns[1], migrations, result)
+	if err != nil {
+		if dialect != "" && !strings.EqualFold(dialect, "sqlite") {
+			return fmt.Errorf("verifying squash: %w (verification runs the %s migrations on SQLite)", err, dialect)
+		}
+		return fmt.Errorf("verifying squash: %w", err)
 	}
-	if len(sections) >= 2 {
-		downSQL = strings.TrimSpace(sections[1])
+	if !verification.Equivalent() {
+		for _, c := range migration.DescribeChanges(verification.Up) {
+			fmt.Printf("  ✗ up: %s\n", c)
+		}
+		for _, c := range migration.DescribeChanges(verification.Down) {
+			fmt.Printf("  ✗ down: %s\n", c)
+		}
+		return fmt.Errorf("squashed migration leaves a different schema than the originals; no files were changed")
+	}
+	fmt.Println("✓ Squashed migration leaves the same schema as the originals, applied and rolled back")
+	return nil
+}
+
+// MigrateAuto applies the additive changes between the schema and the
+// database directly, without writing a migration. It only runs when
+// "migrations": {"auto": true} is set, which should be in the config of
+// development environments only. With dryRun it only prints the changes.
+func MigrateAuto(dryRun bool) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if !config.Migrations.Auto && !dryRun {
+		return fmt.Errorf(`auto-migration is disabled; enable it for development with "migrations": {"auto": true}, e.g. in "env": {"development": ...} of %s`, configFileName)
+	}
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("validating schema: %w", err)
+	}
+
+	conn, err := connect(config)
+	if err != nil {
+		return err
 	}
+	defer conn.Close()
+
+	ctx := commandContext()
+	engine := migration.NewEngine(conn).WithLogger(config.Logging.engineLogger(nexuslog.SubsystemMigrate))
 
-	return &migration.Migration{
-		ID:      id,
-		Name:    name,
-		UpSQL:   upSQL,
-		DownSQL: downSQL,
-	}, nil
+	var result *migration.AutoMigrateResult
+	if dryRun {
+		result, err = engine.PlanAutoMigrate(ctx, s)
+	} else {
+		result, err = engine.AutoMigrate(ctx, s)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(result.Applied) == 0 {
+		fmt.Println("No additive changes. Database is up to date.")
+	} else {
+		if dryRun {
+			fmt.Println("Would apply:")
+		} else {
+			fmt.Println("Applied:")
+		}
+		for _, desc := range migration.DescribeChanges(result.Applied) {
+			fmt.Printf("  %s\n", desc)
+		}
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Println("\nSkipped (destructive, use nexus migrate diff):")
+		for _, desc := range migration.DescribeChanges(result.Skipped) {
+			fmt.Printf("  %s\n", desc)
+		}
+	}
+	return nil
 }
@@ -2,16 +2,23 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
 	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
 	"github.com/nexus-db/nexus/pkg/query"
@@ -25,6 +32,9 @@ type ProfileOptions struct {
 	SlowThreshold time.Duration
 	// OutputFormat is "text" or "json".
 	OutputFormat string
+	// Output writes the report to this file instead of printing it, as
+	// JSON, HTML or Markdown by its extension.
+	Output string
 }
 
 // DefaultProfileOptions returns sensible defaults.
@@ -53,6 +63,11 @@ func Profile(opts ProfileOptions) error {
 	// Create profiler
 	profilerOpts := query.DefaultProfilerOptions()
 	profilerOpts.SlowThreshold = opts.SlowThreshold
+	// The schema only improves N+1 hints, so profile without it if it
+	// doesn't parse
+	if s, err := schema.ParseFile(config.Schema.Path); err == nil {
+		profilerOpts.Schema = s
+	}
 	profiler := query.NewProfiler(profilerOpts)
 
 	printProfileBanner(opts)
@@ -89,6 +104,9 @@ func Profile(opts ProfileOptions) error {
 	// Generate and display report
 	report := profiler.Report()
 
+	if opts.Output != "" {
+		return writeProfileReport(report, opts.Output)
+	}
 	if opts.OutputFormat == "json" {
 		fmt.Println(reportToJSON(report))
 	} else {
@@ -98,8 +116,131 @@ func Profile(opts ProfileOptions) error {
 	return nil
 }
 
-// ProfileDemo runs a demo profiling session with sample queries.
-func ProfileDemo() error {
+// ProfileAttach profiles a running application through the endpoint
+// query.ProfilerHandler serves at baseURL. It starts a session, waits for
+// opts.Duration or Ctrl+C, then stops it and prints the report. headers
+// are sent with every request, for the application's authentication.
+func ProfileAttach(baseURL string, headers []string, opts ProfileOptions) error {
+	base := strings.TrimSuffix(baseURL, "/")
+	if _, err := url.ParseRequestURI(base); err != nil {
+		return fmt.Errorf("invalid profiler URL %q: %w", baseURL, err)
+	}
+	header := make(http.Header)
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return fmt.Errorf("invalid header %q, expected \"Name: value\"", h)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	call := func(method, path string) ([]byte, error) {
+		req, err := http.NewRequest(method, base+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = header.Clone()
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+		}
+		return body, nil
+	}
+
+	fmt.Println()
+	fmt.Println("🔬 Nexus Performance Profiler")
+	fmt.Printf("   Attached to: %s\n", base)
+	if opts.Duration > 0 {
+		fmt.Printf("   Duration: %s\n", opts.Duration)
+	}
+	fmt.Println()
+
+	start := "/start"
+	if opts.Duration > 0 {
+		// The application stops the session itself should this client go away
+		start += "?duration=" + url.QueryEscape(opts.Duration.String())
+	}
+	if _, err := call(http.MethodPost, start); err != nil {
+		return fmt.Errorf("starting profiler: %w", err)
+	}
+	fmt.Printf("[%s] ▶ Profiling started\n", timestamp())
+	fmt.Println("   Press Ctrl+C to stop and view report")
+	fmt.Println()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	if opts.Duration > 0 {
+		select {
+		case <-sigChan:
+		case <-time.After(opts.Duration):
+			fmt.Printf("\n[%s] ⏱ Duration reached (%s)\n", timestamp(), opts.Duration)
+		}
+	} else {
+		<-sigChan
+	}
+
+	body, err := call(http.MethodPost, "/stop")
+	if err != nil {
+		return fmt.Errorf("stopping profiler: %w", err)
+	}
+	fmt.Printf("\n[%s] ⏹ Profiling stopped\n", timestamp())
+
+	if opts.Output != "" {
+		format := query.ReportFormatForPath(opts.Output)
+		report, err := call(http.MethodGet, "/report?format="+format)
+		if err != nil {
+			return fmt.Errorf("fetching report: %w", err)
+		}
+		if format == query.ReportFormatJSON {
+			var resp struct {
+				Report json.RawMessage `json:"report"`
+			}
+			if err := json.Unmarshal(report, &resp); err != nil {
+				return fmt.Errorf("reading report: %w", err)
+			}
+			var out bytes.Buffer
+			if err := json.Indent(&out, resp.Report, "", "  "); err != nil {
+				return fmt.Errorf("reading report: %w", err)
+			}
+			report = out.Bytes()
+		}
+		return saveProfileReport(report, opts.Output)
+	}
+	if opts.OutputFormat == "json" {
+		var resp struct {
+			Report json.RawMessage `json:"report"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return fmt.Errorf("reading report: %w", err)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, resp.Report, "", "  "); err != nil {
+			return fmt.Errorf("reading report: %w", err)
+		}
+		fmt.Println(out.String())
+		return nil
+	}
+
+	text, err := call(http.MethodGet, "/report?format=text")
+	if err != nil {
+		return fmt.Errorf("fetching report: %w", err)
+	}
+	fmt.Println(string(text))
+	return nil
+}
+
+// ProfileDemo runs a demo profiling session with sample queries, writing
+// the report to output if it is set.
+func ProfileDemo(output string) error {
 	fmt.Println("\n🔬 Performance Profiler Demo")
 	fmt.Println("   This demo shows how the profiler captures query metrics.")
 	fmt.Println()
@@ -168,11 +309,33 @@ func ProfileDemo() error {
 	fmt.Println()
 
 	report := profiler.Report()
+	if output != "" {
+		return writeProfileReport(report, output)
+	}
 	fmt.Println(report.String())
 
 	return nil
 }
 
+// writeProfileReport writes a report to path in the format of its
+// extension.
+func writeProfileReport(report *query.ProfileReport, path string) error {
+	content, err := report.Format(query.ReportFormatForPath(path))
+	if err != nil {
+		return err
+	}
+	return saveProfileReport(content, path)
+}
+
+// saveProfileReport writes a rendered report to path.
+func saveProfileReport(content []byte, path string) error {
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	fmt.Printf("✓ Report written to %s\n", path)
+	return nil
+}
+
 // printProfileBanner prints the startup banner.
 func printProfileBanner(opts ProfileOptions) {
 	fmt.Println()
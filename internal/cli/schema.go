@@ -0,0 +1,274 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// SchemaSnapshot writes the current schema to a snapshot file.
+// If out is empty, the configured snapshot path is used.
+func SchemaSnapshot(out string) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("validating schema: %w", err)
+	}
+
+	dialect, err := getDialect(config.Database.Dialect)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		out = snapshotPath(config)
+	}
+
+	snapshot := migration.NewSchemaSnapshot(s, dialect)
+	if err := migration.SaveSnapshot(out, snapshot); err != nil {
+		return fmt.Errorf("saving snapshot: %w", err)
+	}
+
+	fmt.Printf("✓ Wrote schema snapshot: %s (%d tables)\n", out, len(snapshot.Tables))
+	return nil
+}
+
+// SchemaCheck parses and validates the schema without touching the
+// database. With asJSON, problems are printed as a JSON array of
+// diagnostics on stdout for editors and other tools.
+func SchemaCheck(asJSON bool) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err == nil {
+		err = s.Validate()
+	}
+
+	if asJSON {
+		out, jsonErr := schema.DiagnosticsJSON(err)
+		if jsonErr != nil {
+			return jsonErr
+		}
+		fmt.Fprintln(stdout, string(out))
+		if err != nil {
+			return fmt.Errorf("schema has %d problem(s)", len(schema.Diagnostics(err)))
+		}
+		return nil
+	}
+
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		return fmt.Errorf("schema check failed")
+	}
+	fmt.Printf("✓ Schema is valid (%d models, %d enums)\n", len(s.Models), len(s.Enums))
+	return nil
+}
+
+// SchemaAtOptions configures schema at.
+type SchemaAtOptions struct {
+	JSON bool   // Print the snapshot as JSON instead of a schema file
+	Diff string // Print the changes from this migration's schema, or "current" for the database now
+}
+
+// SchemaAt prints the schema of the database as it was right after the
+// given migration was applied, from the schema history the migration
+// engine records. With opts.Diff it prints the changes between the two
+// schemas instead.
+func SchemaAt(id string, opts SchemaAtOptions) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	conn, err := connect(config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := commandContext()
+	engine, _, err := migrationEngine(config, conn)
+	if err != nil {
+		return err
+	}
+	snapshot, err := engine.SchemaVersion(ctx, id)
+	if err != nil {
+		return schemaHistoryError(err)
+	}
+
+	if opts.Diff != "" {
+		from, err := engine.SchemaVersion(ctx, opts.Diff)
+		if err != nil {
+			return schemaHistoryError(err)
+		}
+		changes := migration.DescribeChanges(migration.DiffSnapshots(from, snapshot))
+		if len(changes) == 0 {
+			fmt.Printf("No changes from %s to %s\n", opts.Diff, id)
+			return nil
+		}
+		fmt.Printf("Changes from %s to %s:\n", opts.Diff, id)
+		for _, c := range changes {
+			fmt.Printf("  %s\n", c)
+		}
+		return nil
+	}
+
+	if opts.JSON {
+		out, err := json.MarshalIndent(snapshot, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(out))
+		return nil
+	}
+	s, warnings := migration.SchemaFromSnapshot(snapshot.Database())
+	fmt.Fprintf(stdout, "// Schema of the %s database after migration %s\n\n", snapshot.Dialect, id)
+	fmt.Fprint(stdout, schema.Format(s))
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", w)
+	}
+	return nil
+}
+
+// schemaHistoryError tells when schemas start being recorded if none was.
+func schemaHistoryError(err error) error {
+	if errors.Is(err, migration.ErrNoSchemaHistory) {
+		return fmt.Errorf("%w; schemas are recorded by 'nexus migrate up' from this version on", err)
+	}
+	return err
+}
+
+// snapshotPath returns the configured snapshot path or the default.
+func snapshotPath(config *Config) string {
+	if config.Schema.Snapshot != "" {
+		return config.Schema.Snapshot
+	}
+	return migration.DefaultSnapshotFile
+}
+
+// SchemaVerifyOptions configures schema verify.
+type SchemaVerifyOptions struct {
+	JSON bool // Print the result as JSON
+}
+
+// schemaVerifyJSON is the JSON output of schema verify.
+type schemaVerifyJSON struct {
+	Drifted    bool     `json:"drifted"`
+	Baseline   string   `json:"baseline,omitempty"`
+	OutOfBand  []string `json:"outOfBand"`
+	Mismatches []string `json:"mismatches"`
+	Pending    []string `json:"pending"`
+	Edited     []string `json:"edited"`
+}
+
+// SchemaVerify compares the database with the schema recorded after the
+// last applied migration and with the schema file, and reports changes
+// made outside migrations, such as columns or indexes added by hand. It
+// fails with an *ExitError of status 1 if there are any, for monitoring
+// jobs.
+func SchemaVerify(opts SchemaVerifyOptions) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+
+	conn, err := connect(config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := commandContext()
+	engine, _, err := migrationEngine(config, conn)
+	if err != nil {
+		return err
+	}
+	if err := engine.Init(ctx); err != nil {
+		return fmt.Errorf("initializing migrations table: %w", err)
+	}
+	if err := engine.LoadFromDir(migrationsDir); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+
+	result, err := engine.Verify(ctx, s)
+	if err != nil {
+		return fmt.Errorf("verifying schema: %w", err)
+	}
+	var drift error
+	if result.Drifted() {
+		drift = &ExitError{Code: 1, Err: fmt.Errorf("database schema drifted")}
+	}
+
+	if opts.JSON {
+		out := schemaVerifyJSON{
+			Drifted:    result.Drifted(),
+			Baseline:   result.Baseline,
+			OutOfBand:  append([]string{}, migration.DescribeChanges(result.OutOfBand)...),
+			Mismatches: append([]string{}, migration.DescribeChanges(result.Mismatches)...),
+			Pending:    []string{},
+			Edited:     []string{},
+		}
+		for _, m := range result.Pending {
+			out.Pending = append(out.Pending, m.ID+"_"+m.Name)
+		}
+		for _, d := range result.Edited {
+			out.Edited = append(out.Edited, d.ID+"_"+d.Name)
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(data))
+		return drift
+	}
+
+	switch {
+	case result.Baseline == "":
+		fmt.Println("⚠ No schema recorded for the applied migrations; only comparing with the schema file")
+	case len(result.OutOfBand) == 0:
+		fmt.Printf("✓ Database matches its schema after migration %s\n", result.Baseline)
+	default:
+		fmt.Printf("✗ Database changed outside migrations since %s:\n", result.Baseline)
+		for _, c := range migration.DescribeChanges(result.OutOfBand) {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+
+	switch {
+	case len(result.Mismatches) == 0:
+		fmt.Printf("✓ Database matches %s\n", config.Schema.Path)
+	case len(result.Pending) > 0:
+		fmt.Printf("⚠ Database differs from %s with %d migration(s) pending; run 'nexus migrate up'\n", config.Schema.Path, len(result.Pending))
+	default:
+		fmt.Printf("✗ Database differs from %s; these changes would make it match:\n", config.Schema.Path)
+		for _, c := range migration.DescribeChanges(result.Mismatches) {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+
+	if len(result.Edited) > 0 {
+		fmt.Printf("✗ %d applied migration(s) edited since they were applied:\n", len(result.Edited))
+		for _, d := range result.Edited {
+			fmt.Printf("  %s_%s\n", d.ID, d.Name)
+		}
+	}
+	return drift
+}
@@ -1,37 +1,58 @@
 package cli
 
 import (
-	"context"
-	"database/sql"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/core/seed"
 	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
 	"github.com/nexus-db/nexus/pkg/dialects/mysql"
 	"github.com/nexus-db/nexus/pkg/dialects/postgres"
 	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
 )
 
 const seedsDir = "seeds"
 
-// SeedRun runs pending seeds for the specified environment.
-func SeedRun(env string, reset bool) error {
+// SeedRun runs pending seeds for the specified environment. onChange is the
+// policy for applied seeds that changed since; empty uses the config. With
+// cont, seeds that do not depend on a failed seed still run.
+func SeedRun(env string, reset bool, onChange string, cont bool) error {
 	config, err := LoadConfig()
 	if err != nil {
 		return err
 	}
 
+	if onChange == "" {
+		onChange = config.Seeds.OnChange
+	}
+	policy, err := seed.ParseDriftPolicy(onChange)
+	if err != nil {
+		return err
+	}
+
 	conn, err := connectForSeed(config)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	ctx := context.Background()
-	engine := seed.NewEngine(conn)
+	ctx := commandContext()
+	engine := seed.NewEngine(conn).WithDriftPolicy(policy).WithContinueOnError(cont).
+		WithLogger(config.Logging.engineLogger(nexuslog.SubsystemSeed))
+
+	// Data seeds (.csv, .json, .yaml) map columns through the schema
+	s, err := schema.ParseFile(config.Schema.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("parsing schema: %w", err)
+	}
+	if s != nil {
+		engine.WithSchema(s)
+	}
 
 	// Initialize seeds table
 	if err := engine.Init(ctx); err != nil {
@@ -41,7 +62,7 @@ func SeedRun(env string, reset bool) error {
 	// Load seeds
 	if err := engine.LoadFromDir(seedsDir); err != nil {
 		if os.IsNotExist(err) {
-			fmt.Println("No seeds directory found. Create 'seeds/' with .sql files.")
+			fmt.Println("No seeds directory found. Create 'seeds/' with .sql, .csv, .json or .yaml files.")
 			return nil
 		}
 		return fmt.Errorf("loading seeds: %w", err)
@@ -55,29 +76,66 @@ func SeedRun(env string, reset bool) error {
 
 	fmt.Printf("Found %d seed(s)\n", len(seeds))
 
-	var applied int
+	if policy == seed.DriftWarn && !reset {
+		changed, err := engine.Changed(ctx, env)
+		if err != nil {
+			return fmt.Errorf("checking seeds: %w", err)
+		}
+		for _, s := range changed {
+			fmt.Printf("⚠ Seed %s changed since it was applied; not re-running it (use --on-change rerun-on-change)\n", seedLabel(s.Name, s.Env))
+		}
+	}
+
+	var report *seed.Report
 	if reset {
 		fmt.Println("Resetting seeds...")
-		applied, err = engine.Reset(ctx, env)
+		report, err = engine.ResetReport(ctx, env)
 	} else {
-		applied, err = engine.Run(ctx, env)
+		report, err = engine.RunReport(ctx, env)
 	}
 
-	if err != nil {
-		return fmt.Errorf("running seeds: %w", err)
+	if len(report.Failed) == 0 {
+		if err != nil {
+			return fmt.Errorf("running seeds: %w", err)
+		}
+		if len(report.Applied) == 0 {
+			fmt.Println("No pending seeds.")
+		} else {
+			fmt.Printf("✓ Applied %d seed(s)\n", len(report.Applied))
+		}
+		return nil
 	}
 
-	if applied == 0 {
-		fmt.Println("No pending seeds.")
-	} else {
-		fmt.Printf("✓ Applied %d seed(s)\n", applied)
-	}
+	printSeedReport(report)
+	return fmt.Errorf("%d seed(s) failed; their changes were rolled back", len(report.Failed))
+}
 
-	return nil
+// printSeedReport prints what happened to each seed of a failed run.
+func printSeedReport(report *seed.Report) {
+	fmt.Println()
+	fmt.Println("Seed Report:")
+	fmt.Println(strings.Repeat("-", 60))
+	for _, s := range report.Applied {
+		fmt.Printf("✓ %s\n", seedLabel(s.Name, s.Env))
+	}
+	for _, f := range report.Failed {
+		fmt.Printf("✗ %s: %v\n", seedLabel(f.Seed.Name, f.Seed.Env), f.Err)
+		if f.Statement != "" {
+			for _, line := range strings.Split(f.Statement, "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+	for _, s := range report.Skipped {
+		fmt.Printf("- %s (skipped)\n", seedLabel(s.Name, s.Env))
+	}
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("Applied: %d, failed: %d, skipped: %d\n", len(report.Applied), len(report.Failed), len(report.Skipped))
 }
 
-// SeedStatus shows the status of all seeds.
-func SeedStatus() error {
+// SeedStatus shows the status of all seeds. With verify, it fails if an
+// applied seed changed since it ran.
+func SeedStatus(verify bool) error {
 	config, err := LoadConfig()
 	if err != nil {
 		return err
@@ -89,8 +147,8 @@ func SeedStatus() error {
 	}
 	defer conn.Close()
 
-	ctx := context.Background()
-	engine := seed.NewEngine(conn)
+	ctx := commandContext()
+	engine := seed.NewEngine(conn).WithLogger(config.Logging.engineLogger(nexuslog.SubsystemSeed))
 
 	// Initialize seeds table
 	if err := engine.Init(ctx); err != nil {
@@ -119,6 +177,7 @@ func SeedStatus() error {
 
 	fmt.Println("Seed Status:")
 	fmt.Println(strings.Repeat("-", 60))
+	changed := 0
 	for _, s := range status {
 		indicator := "[ ]"
 		appliedAt := ""
@@ -127,15 +186,35 @@ func SeedStatus() error {
 			indicator = "[✓]"
 			appliedAt = s.AppliedAt.Format(time.RFC3339)
 		}
+		if s.Changed {
+			indicator = "[~]"
+			appliedAt += " (changed since applied)"
+			changed++
+		}
 		if s.Env != "" {
 			envLabel = fmt.Sprintf(" [%s]", s.Env)
 		}
 		fmt.Printf("%s %s%s %s\n", indicator, s.Name, envLabel, appliedAt)
 	}
 
+	if verify {
+		if changed > 0 {
+			return fmt.Errorf("%d applied seed(s) changed since they ran", changed)
+		}
+		fmt.Println("✓ Applied seeds match their files")
+	}
+
 	return nil
 }
 
+// seedLabel names a seed with its environment, e.g. dev/fixtures.
+func seedLabel(name, env string) string {
+	if env != "" {
+		return env + "/" + name
+	}
+	return name
+}
+
 // SeedCreate creates a new seed file.
 func SeedCreate(name, env string) error {
 	// Ensure seeds directory exists
@@ -194,12 +273,12 @@ func connectForSeed(config *Config) (*dialects.Connection, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open(dialect.DriverName(), config.Database.URL)
+	db, err := openDB(config.Database, dialect)
 	if err != nil {
 		return nil, fmt.Errorf("connecting to database: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(commandContext()); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("pinging database: %w", err)
 	}
@@ -215,6 +294,10 @@ func getDialectForSeed(name string) (dialects.Dialect, error) {
 		return sqlite.New(), nil
 	case "mysql":
 		return mysql.New(), nil
+	case "mssql", "sqlserver":
+		return mssql.New(), nil
+	case "libsql", "turso":
+		return sqlite.NewLibSQL(), nil
 	default:
 		return nil, fmt.Errorf("unknown dialect: %s", name)
 	}
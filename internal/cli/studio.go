@@ -18,13 +18,15 @@ import (
 	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
 	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
 )
 
 // StudioOptions configures the studio server.
 type StudioOptions struct {
-	Port   int
-	Host   string
-	NoOpen bool
+	Port    int
+	Host    string
+	NoOpen  bool
+	ShowPII bool // Show @pii fields unmasked
 }
 
 // DefaultStudioOptions returns the default studio options.
@@ -49,24 +51,26 @@ func Studio(opts StudioOptions) error {
 		return fmt.Errorf("connecting to database: %w", err)
 	}
 
+	log := config.Logging.logger(nexuslog.SubsystemStudio, os.Stdout)
+
 	// Parse schema if available
 	var sch *schema.Schema
 	if config.Schema.Path != "" {
 		sch, err = schema.ParseFile(config.Schema.Path)
 		if err != nil {
 			// Non-fatal, continue without schema
-			fmt.Printf("⚠ Could not parse schema: %v\n", err)
+			log.Warn("Could not parse schema", nexuslog.Err(err))
 		}
 	}
 
-	// Set up migration engine
-	migrationEngine := migration.NewEngine(conn)
-	if err := migrationEngine.Init(context.Background()); err != nil {
-		fmt.Printf("⚠ Could not initialize migrations: %v\n", err)
+	// Set up migration engine, which logs the migrations run from the browser
+	migrationEngine := migration.NewEngine(conn).WithLogger(config.Logging.logger(nexuslog.SubsystemMigrate, os.Stdout))
+	if err := migrationEngine.Init(commandContext()); err != nil {
+		log.Warn("Could not initialize migrations", nexuslog.Err(err))
 	}
 
 	// Load migrations from directory
-	if err := migrationEngine.LoadFromDir("./migrations"); err != nil {
+	if err := migrationEngine.LoadFromDir(migrationsDir); err != nil {
 		// Non-fatal, migrations might not exist yet
 	}
 
@@ -77,6 +81,11 @@ func Studio(opts StudioOptions) error {
 		Connection: conn,
 		Schema:     sch,
 		Migrations: migrationEngine,
+
+		MigrationsDir:  migrationsDir,
+		QueryStoreFile: studio.DefaultQueryStoreFile,
+		ShowPII:        opts.ShowPII,
+		Logger:         log,
 	})
 
 	// Print startup banner
@@ -88,7 +97,7 @@ func Studio(opts StudioOptions) error {
 	}
 
 	// Handle signals for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(commandContext())
 	defer cancel()
 
 	sigChan := make(chan os.Signal, 1)
@@ -110,6 +119,10 @@ func Studio(opts StudioOptions) error {
 
 // connectToDatabase establishes a database connection based on config.
 func connectToDatabase(config *Config) (*dialects.Connection, error) {
+	if config.Database.unset != nil {
+		return nil, config.Database.unset
+	}
+
 	var db *sql.DB
 	var dialect dialects.Dialect
 	var err error
@@ -134,12 +147,20 @@ func connectToDatabase(config *Config) (*dialects.Connection, error) {
 	case "mysql":
 		return nil, fmt.Errorf("MySQL support requires additional driver. Install github.com/go-sql-driver/mysql")
 
+	case "mssql", "sqlserver":
+		// Works once github.com/microsoft/go-mssqldb is linked in
+		return connect(config)
+
+	case "libsql", "turso":
+		// Works once a libSQL driver is linked in
+		return connect(config)
+
 	default:
 		return nil, fmt.Errorf("unsupported dialect: %s", config.Database.Dialect)
 	}
 
 	// Test connection
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(commandContext()); err != nil {
 		return nil, fmt.Errorf("database ping failed: %w", err)
 	}
 
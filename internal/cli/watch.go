@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// matchGlob reports whether name, a slash-separated path, matches pattern.
+// A ** element matches any number of directories; other elements match as
+// with path.Match.
+func matchGlob(pattern, name string) bool {
+	return matchElements(strings.Split(cleanGlob(pattern), "/"), strings.Split(name, "/"))
+}
+
+func matchElements(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// cleanGlob returns pattern slash-separated and without a leading ./.
+func cleanGlob(pattern string) string {
+	return path.Clean(filepath.ToSlash(pattern))
+}
+
+// globRoot returns the directory of pattern before its first wildcard,
+// which a watcher walks to find the files matching it.
+func globRoot(pattern string) string {
+	elems := strings.Split(cleanGlob(pattern), "/")
+	var root []string
+	for _, elem := range elems[:len(elems)-1] {
+		if strings.ContainsAny(elem, "*?[") {
+			break
+		}
+		root = append(root, elem)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	return path.Join(root...)
+}
+
+// projectPath returns abs relative to the working directory, slash
+// separated, as the globs of the config are written.
+func projectPath(abs string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// matchesAny reports whether abs matches one of patterns.
+func matchesAny(patterns []string, abs string) bool {
+	name := projectPath(abs)
+	for _, pattern := range patterns {
+		if matchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored reports whether dev mode ignores abs, a file or a directory.
+func (o DevOptions) ignored(abs string) bool {
+	return matchesAny(o.Ignore, abs)
+}
+
+// watched reports whether abs matches the watch globs of dev mode.
+func (o DevOptions) watched(abs string) bool {
+	return matchesAny(o.Watch, abs)
+}
+
+// watchDirs returns the directories under the roots of the watch globs,
+// recursively, leaving out hidden and ignored directories.
+func (o DevOptions) watchDirs() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, pattern := range o.Watch {
+		root, err := filepath.Abs(filepath.FromSlash(globRoot(pattern)))
+		if err != nil || seen[root] {
+			continue
+		}
+		filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if p != root && (strings.HasPrefix(d.Name(), ".") || o.ignored(p)) {
+				return filepath.SkipDir
+			}
+			if seen[p] {
+				return filepath.SkipDir
+			}
+			seen[p] = true
+			dirs = append(dirs, p)
+			return nil
+		})
+	}
+	return dirs
+}
+
+// underWatchRoot reports whether dir is inside the root of a watch glob,
+// so that directories created there are watched too.
+func (o DevOptions) underWatchRoot(dir string) bool {
+	if strings.HasPrefix(filepath.Base(dir), ".") || o.ignored(dir) {
+		return false
+	}
+	for _, pattern := range o.Watch {
+		root, err := filepath.Abs(filepath.FromSlash(globRoot(pattern)))
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(root, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchedFiles returns the files under the watch globs.
+func (o DevOptions) watchedFiles() []string {
+	var files []string
+	for _, dir := range o.watchDirs() {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			p := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() && o.watched(p) && !o.ignored(p) {
+				files = append(files, p)
+			}
+		}
+	}
+	return files
+}
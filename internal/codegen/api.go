@@ -0,0 +1,668 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// apiModel describes the REST resource of a model for the templates.
+type apiModel struct {
+	Name   string
+	Plural string // Typed query constructor, e.g. Users
+	Path   string // URL path segment, e.g. blog-posts
+	PK     apiField
+	Fields []apiField // Writable fields
+}
+
+// apiField describes a column of a REST resource.
+type apiField struct {
+	Column    string
+	GoName    string
+	InputType string // Go type in the request body, always a pointer
+	Numeric   bool   // Integer key, parsed from the URL
+	Auto      bool   // Generated by the database
+	Required  bool   // Must be set on create
+	MaxLength int    // String length limit
+	Enum      []string
+}
+
+// GenerateAPI generates CRUD HTTP handlers (handlers.go) and an OpenAPI 3
+// document describing them (openapi.json). The handlers use the typed
+// queries of queries.go, so Generate must be run too.
+func (g *Generator) GenerateAPI() error {
+	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
+		return err
+	}
+	if err := g.generateHandlers(); err != nil {
+		return err
+	}
+
+	doc, err := json.MarshalIndent(g.OpenAPI(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(g.outputDir, "openapi.json"), append(doc, '\n'), 0644)
+}
+
+// apiModels returns the models served by the API. Models without a
+// primary key cannot be addressed by URL and are left out.
+func (g *Generator) apiModels() []apiModel {
+	var models []apiModel
+	for _, model := range g.schema.GetModels() {
+		m := apiModel{Name: model.Name, Plural: plural(model.Name), Path: resourcePath(model.Name)}
+		hasPK := false
+		for _, f := range model.GetFields() {
+			field := apiField{
+				Column:    f.Name,
+				GoName:    goFieldName(f.Name),
+				InputType: "*" + strings.TrimPrefix(goType(f), "*"),
+				Numeric:   f.Type.IsInteger(),
+				Auto:      f.AutoIncrement,
+				Required:  !f.Nullable && !f.AutoIncrement && f.DefaultValue == nil && f.DefaultExpr == "",
+			}
+			if f.Type == schema.FieldTypeString {
+				field.MaxLength = f.Length
+				if field.MaxLength == 0 {
+					field.MaxLength = 255
+				}
+			}
+			if f.Enum != nil {
+				field.Enum = f.Enum.Values
+			}
+
+			if f.IsPrimaryKey && !hasPK {
+				m.PK, hasPK = field, true
+			}
+			if !field.Auto {
+				m.Fields = append(m.Fields, field)
+			}
+		}
+		if hasPK {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// resourcePath returns the URL path segment of a model: BlogPost -> blog-posts.
+func resourcePath(name string) string {
+	var sb strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			sb.WriteByte('-')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return plural(sb.String())
+}
+
+func (g *Generator) generateHandlers() error {
+	tmpl := `// Code generated by Nexus. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nexus-db/nexus/pkg/query"
+	"github.com/nexus-db/nexus/pkg/validate"
+)
+
+// Suppress unused import warnings
+var (
+	_ = time.Now
+	_ = utf8.RuneCountInString
+)
+
+// Handler returns an http.Handler serving the CRUD endpoints described in
+// openapi.json.
+func (db *DB) Handler() http.Handler {
+	mux := http.NewServeMux()
+	db.RegisterRoutes(mux)
+	return mux
+}
+
+// RegisterRoutes adds the CRUD endpoints of every model to mux.
+func (db *DB) RegisterRoutes(mux *http.ServeMux) {
+{{- range .Models}}
+	mux.HandleFunc("GET /{{.Path}}", db.list{{.Name}})
+	mux.HandleFunc("POST /{{.Path}}", db.create{{.Name}})
+	mux.HandleFunc("GET /{{.Path}}/{id}", db.get{{.Name}})
+	mux.HandleFunc("PATCH /{{.Path}}/{id}", db.update{{.Name}})
+	mux.HandleFunc("DELETE /{{.Path}}/{id}", db.delete{{.Name}})
+{{- end}}
+}
+
+// ValidationError maps invalid request fields to what is wrong with them.
+type ValidationError map[string]string
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%d invalid field(s)", len(e))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes {"error": "..."}, plus "fields" for validation errors.
+func writeError(w http.ResponseWriter, status int, err error) {
+	body := map[string]interface{}{"error": err.Error()}
+	var invalid ValidationError
+	var rules validate.Errors
+	switch {
+	case errors.As(err, &invalid):
+		body["error"] = "validation failed"
+		body["fields"] = invalid
+	case errors.As(err, &rules):
+		body["error"] = "validation failed"
+		body["fields"] = rules
+	}
+	writeJSON(w, status, body)
+}
+
+// decodeBody decodes a JSON request body, rejecting unknown fields.
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// pathID reads the {id} path parameter; numeric keys must be integers.
+func pathID(r *http.Request, numeric bool) (interface{}, error) {
+	id := r.PathValue("id")
+	if !numeric {
+		return id, nil
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id %q", id)
+	}
+	return n, nil
+}
+
+// pageParams reads the limit and offset query parameters.
+func pageParams(r *http.Request) (limit, offset int, err error) {
+	for name, dst := range map[string]*int{"limit": &limit, "offset": &offset} {
+		if v := r.URL.Query().Get(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil || *dst < 0 {
+				return 0, 0, fmt.Errorf("invalid %s %q", name, v)
+			}
+		}
+	}
+	return limit, offset, nil
+}
+{{range .Models}}{{$m := .}}
+// {{.Name}}Input is the request body for creating and updating {{.Name}}
+// records. Fields left out are not written.
+type {{.Name}}Input struct {
+{{- range .Fields}}
+	{{.GoName}} {{.InputType}} ` + "`" + `json:"{{.Column}},omitempty"` + "`" + `
+{{- end}}
+}
+
+// Validate checks the input against the schema. On create, fields without
+// a default are required.
+func (in *{{.Name}}Input) Validate(create bool) error {
+	invalid := ValidationError{}
+{{- range .Fields}}
+{{- if .Required}}
+	if create && in.{{.GoName}} == nil {
+		invalid["{{.Column}}"] = "is required"
+	}
+{{- end}}
+{{- if .MaxLength}}
+	if in.{{.GoName}} != nil && utf8.RuneCountInString(*in.{{.GoName}}) > {{.MaxLength}} {
+		invalid["{{.Column}}"] = "must be at most {{.MaxLength}} characters"
+	}
+{{- end}}
+{{- if .Enum}}
+	if in.{{.GoName}} != nil {
+		switch *in.{{.GoName}} {
+		case {{quoteList .Enum}}:
+		default:
+			invalid["{{.Column}}"] = "must be one of {{join .Enum ", "}}"
+		}
+	}
+{{- end}}
+{{- end}}
+	var rules validate.Errors
+	if errors.As(validate{{.Name}}(in.values()), &rules) {
+		for field, message := range rules {
+			if _, ok := invalid[field]; !ok {
+				invalid[field] = message
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		return invalid
+	}
+	return nil
+}
+
+// values returns the columns set in the input.
+func (in *{{.Name}}Input) values() map[string]interface{} {
+	values := make(map[string]interface{})
+{{- range .Fields}}
+	if in.{{.GoName}} != nil {
+		values["{{.Column}}"] = *in.{{.GoName}}
+	}
+{{- end}}
+	return values
+}
+
+// list{{.Name}} handles GET /{{.Path}}?limit=&offset=.
+func (db *DB) list{{.Name}}(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	q := db.{{.Plural}}().OrderBy("{{.PK.Column}}", query.Asc)
+	if limit > 0 {
+		q.Limit(limit)
+	}
+	if offset > 0 {
+		q.Offset(offset)
+	}
+	records, err := q.All(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// get{{.Name}} handles GET /{{.Path}}/{id}.
+func (db *DB) get{{.Name}}(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, {{.PK.Numeric}})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	record, err := db.{{.Plural}}().Where(query.Eq("{{.PK.Column}}", id)).First(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if record == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("{{.Name}} %v not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// create{{.Name}} handles POST /{{.Path}}.
+func (db *DB) create{{.Name}}(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var in {{.Name}}Input
+	if err := decodeBody(r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := in.Validate(true); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	values := in.values()
+{{- if .PK.Auto}}
+	var id interface{}
+	if db.conn.Dialect.SupportsReturning() {
+		row, err := db.{{.Name}}Query().Insert(values).Returning("{{.PK.Column}}").One(ctx)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		id = row["{{.PK.Column}}"]
+	} else {
+		n, err := db.{{.Name}}Query().Insert(values).LastInsertId(ctx)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		id = n
+	}
+{{- else}}
+	if _, err := db.{{.Name}}Query().Insert(values).Exec(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	id := values["{{.PK.Column}}"]
+{{- end}}
+
+	record, err := db.{{.Plural}}().Where(query.Eq("{{.PK.Column}}", id)).First(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, record)
+}
+
+// update{{.Name}} handles PATCH /{{.Path}}/{id}; only fields in the body change.
+func (db *DB) update{{.Name}}(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := pathID(r, {{.PK.Numeric}})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var in {{.Name}}Input
+	if err := decodeBody(r, &in); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := in.Validate(false); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	values := in.values()
+	if len(values) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("no fields to update"))
+		return
+	}
+
+	existing, err := db.{{.Plural}}().Where(query.Eq("{{.PK.Column}}", id)).First(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if existing == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("{{.Name}} %v not found", id))
+		return
+	}
+	if _, err := db.{{.Name}}Query().Update(values).Where(query.Eq("{{.PK.Column}}", id)).Exec(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if v, ok := values["{{.PK.Column}}"]; ok {
+		id = v
+	}
+
+	record, err := db.{{.Plural}}().Where(query.Eq("{{.PK.Column}}", id)).First(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// delete{{.Name}} handles DELETE /{{.Path}}/{id}.
+func (db *DB) delete{{.Name}}(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, {{.PK.Numeric}})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	n, err := db.{{.Name}}Query().Delete().Where(query.Eq("{{.PK.Column}}", id)).Exec(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if n == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("{{.Name}} %v not found", id))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+{{end}}
+`
+
+	t, err := template.New("handlers").Funcs(g.funcs()).Funcs(template.FuncMap{
+		"join": strings.Join,
+		"quoteList": func(values []string) string {
+			quoted := make([]string, len(values))
+			for i, v := range values {
+				quoted[i] = strconv.Quote(v)
+			}
+			return strings.Join(quoted, ", ")
+		},
+	}).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		PackageName string
+		Models      []apiModel
+	}{
+		PackageName: g.packageName,
+		Models:      g.apiModels(),
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		// If formatting fails, write unformatted
+		formatted = buf.Bytes()
+	}
+
+	return os.WriteFile(filepath.Join(g.outputDir, "handlers.go"), formatted, 0644)
+}
+
+// OpenAPI returns an OpenAPI 3 document for the handlers of GenerateAPI.
+func (g *Generator) OpenAPI() map[string]interface{} {
+	paths := make(map[string]interface{})
+	schemas := map[string]interface{}{
+		"Error": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"error":  map[string]interface{}{"type": "string"},
+				"fields": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+			},
+			"required": []string{"error"},
+		},
+	}
+
+	for _, m := range g.apiModels() {
+		model := g.schema.Models[m.Name]
+		ref := refTo(m.Name)
+		inputRef := refTo(m.Name + "Input")
+
+		// Record schema: every column
+		props := make(map[string]interface{})
+		var required []string
+		for _, f := range model.GetFields() {
+			props[f.Name] = fieldSchema(f)
+			if !f.Nullable {
+				required = append(required, f.Name)
+			}
+		}
+		schemas[m.Name] = map[string]interface{}{"type": "object", "properties": props, "required": required}
+
+		// Input schema: writable columns; required applies to create
+		inputProps := make(map[string]interface{})
+		var inputRequired []string
+		for _, f := range m.Fields {
+			inputProps[f.Column] = fieldSchema(model.Fields[f.Column])
+			if f.Required {
+				inputRequired = append(inputRequired, f.Column)
+			}
+		}
+		input := map[string]interface{}{"type": "object", "properties": inputProps, "additionalProperties": false}
+		if len(inputRequired) > 0 {
+			input["required"] = inputRequired
+		}
+		schemas[m.Name+"Input"] = input
+
+		idParam := map[string]interface{}{
+			"name": "id", "in": "path", "required": true,
+			"schema": fieldSchema(model.Fields[m.PK.Column]),
+		}
+		pageParam := func(name, desc string) map[string]interface{} {
+			return map[string]interface{}{
+				"name": name, "in": "query", "description": desc,
+				"schema": map[string]interface{}{"type": "integer", "minimum": 0},
+			}
+		}
+		body := map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(inputRef),
+		}
+		tags := []string{m.Name}
+
+		paths["/"+m.Path] = map[string]interface{}{
+			"get": map[string]interface{}{
+				"operationId": "list" + m.Plural,
+				"tags":        tags,
+				"parameters":  []interface{}{pageParam("limit", "Maximum number of records"), pageParam("offset", "Number of records to skip")},
+				"responses": map[string]interface{}{
+					"200": response("List of "+m.Name+" records", map[string]interface{}{"type": "array", "items": ref}),
+					"400": errorResponse("Invalid parameters"),
+				},
+			},
+			"post": map[string]interface{}{
+				"operationId": "create" + m.Name,
+				"tags":        tags,
+				"requestBody": body,
+				"responses": map[string]interface{}{
+					"201": response("The created "+m.Name, ref),
+					"400": errorResponse("Invalid request body"),
+					"422": errorResponse("Validation failed"),
+				},
+			},
+		}
+		paths["/"+m.Path+"/{id}"] = map[string]interface{}{
+			"parameters": []interface{}{idParam},
+			"get": map[string]interface{}{
+				"operationId": "get" + m.Name,
+				"tags":        tags,
+				"responses": map[string]interface{}{
+					"200": response("The "+m.Name, ref),
+					"404": errorResponse(m.Name + " not found"),
+				},
+			},
+			"patch": map[string]interface{}{
+				"operationId": "update" + m.Name,
+				"tags":        tags,
+				"requestBody": body,
+				"responses": map[string]interface{}{
+					"200": response("The updated "+m.Name, ref),
+					"400": errorResponse("Invalid request body"),
+					"404": errorResponse(m.Name + " not found"),
+					"422": errorResponse("Validation failed"),
+				},
+			},
+			"delete": map[string]interface{}{
+				"operationId": "delete" + m.Name,
+				"tags":        tags,
+				"responses": map[string]interface{}{
+					"204": map[string]interface{}{"description": m.Name + " deleted"},
+					"404": errorResponse(m.Name + " not found"),
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi":    "3.0.3",
+		"info":       map[string]interface{}{"title": g.packageName + " API", "version": "1.0.0"},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// fieldSchema returns the OpenAPI schema of a column.
+func fieldSchema(f *schema.Field) map[string]interface{} {
+	var s map[string]interface{}
+	switch f.Type {
+	case schema.FieldTypeInt, schema.FieldTypeSmallInt, schema.FieldTypeTinyInt:
+		s = map[string]interface{}{"type": "integer", "format": "int32"}
+		if f.Type != schema.FieldTypeInt {
+			s["minimum"], s["maximum"] = f.IntegerRange()
+		}
+	case schema.FieldTypeBigInt:
+		s = map[string]interface{}{"type": "integer", "format": "int64"}
+	case schema.FieldTypeString:
+		maxLength := f.Length
+		if maxLength == 0 {
+			maxLength = 255
+		}
+		s = map[string]interface{}{"type": "string", "maxLength": maxLength}
+	case schema.FieldTypeText:
+		s = map[string]interface{}{"type": "string"}
+	case schema.FieldTypeUUID:
+		s = map[string]interface{}{"type": "string", "format": "uuid"}
+	case schema.FieldTypeBool:
+		s = map[string]interface{}{"type": "boolean"}
+	case schema.FieldTypeFloat, schema.FieldTypeDecimal, schema.FieldTypeMoney:
+		s = map[string]interface{}{"type": "number", "format": "double"}
+	case schema.FieldTypeDateTime, schema.FieldTypeDate, schema.FieldTypeTime:
+		// Go encodes time.Time as RFC 3339
+		s = map[string]interface{}{"type": "string", "format": "date-time"}
+	case schema.FieldTypeBytes:
+		s = map[string]interface{}{"type": "string", "format": "byte"}
+	case schema.FieldTypeEnum:
+		s = map[string]interface{}{"type": "string"}
+		if f.Enum != nil {
+			s["enum"] = f.Enum.Values
+		}
+	default:
+		// JSON columns hold any value
+		s = map[string]interface{}{}
+	}
+	if f.IsEmail {
+		s["format"] = "email"
+	}
+	if s["type"] == "string" {
+		if f.MinValue != nil {
+			s["minLength"] = int(*f.MinValue)
+		}
+		if f.MaxValue != nil {
+			s["maxLength"] = int(*f.MaxValue)
+		}
+	} else {
+		if f.MinValue != nil {
+			s["minimum"] = *f.MinValue
+		}
+		if f.MaxValue != nil {
+			s["maximum"] = *f.MaxValue
+		}
+	}
+	if f.Pattern != "" {
+		s["pattern"] = f.Pattern
+	}
+	if f.Nullable {
+		s["nullable"] = true
+	}
+	return s
+}
+
+func refTo(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+func response(description string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"description": description, "content": jsonContent(schema)}
+}
+
+func errorResponse(description string) map[string]interface{} {
+	return response(description, refTo("Error"))
+}
@@ -0,0 +1,561 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// DocsOptions configures the schema documentation of GenerateDocs.
+type DocsOptions struct {
+	Format     string                 // "markdown" (default) or "html"
+	Title      string                 // Top heading; "Database schema" if empty
+	Diagram    string                 // Mermaid erDiagram of the models; left out if empty
+	Migrations []*migration.Migration // Migration history; left out if empty
+}
+
+// docsPage describes the whole documentation for the templates.
+type docsPage struct {
+	Title       string
+	Diagram     string
+	Models      []docsModel
+	Enums       []docsEnum
+	ForeignKeys []docsForeignKey
+	Migrations  []docsMigration
+}
+
+// docsModel describes a model and its table.
+type docsModel struct {
+	Name      string
+	Anchor    string
+	Table     string
+	Comment   string
+	Fields    []docsField
+	Relations []docsRelation
+	Indexes   []docsIndex
+}
+
+// docsEnum describes an enum.
+type docsEnum struct {
+	Name   string
+	Anchor string
+	Values []string
+}
+
+// docsField describes a column.
+type docsField struct {
+	Name       string
+	Type       string
+	EnumAnchor string // Anchor of the enum the column takes values of
+	Nullable   bool
+	Default    string
+	Keys       string // PK, FK and UK, comma-separated
+	Comment    string
+}
+
+// docsRelation describes a relation of a model.
+type docsRelation struct {
+	Name     string
+	Kind     string // "belongs to", "has one", "has many" or "many to many"
+	Model    string
+	Anchor   string
+	Columns  string
+	OnDelete string
+}
+
+// docsIndex describes the primary key or an index of a table.
+type docsIndex struct {
+	Name    string
+	Columns string
+	Unique  bool
+}
+
+// docsForeignKey describes a foreign key column and the index covering
+// it, which is empty when no index leads with the column.
+type docsForeignKey struct {
+	Table  string
+	Column string
+	Target string
+	Index  string
+}
+
+// docsMigration describes a migration of the history.
+type docsMigration struct {
+	ID          string
+	Name        string
+	Date        string
+	Author      string
+	Description string
+}
+
+// GenerateDocs writes documentation of the schema to schema.md, or
+// schema.html with the html format, in the output directory: its models
+// with their columns, relations and indexes, its enums, whether every
+// foreign key is indexed and, given in opts, an ER diagram and the
+// migration history.
+func (g *Generator) GenerateDocs(opts DocsOptions) error {
+	doc, err := g.Docs(opts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(g.outputDir, DocsFile(opts.Format)), []byte(doc), 0644)
+}
+
+// DocsFile returns the name of the file GenerateDocs writes in format.
+func DocsFile(format string) string {
+	if format == "html" {
+		return "schema.html"
+	}
+	return "schema.md"
+}
+
+// Docs returns the documentation GenerateDocs writes.
+func (g *Generator) Docs(opts DocsOptions) (string, error) {
+	page := g.docsPage(opts)
+	var buf bytes.Buffer
+	switch opts.Format {
+	case "", "markdown", "md":
+		tmpl, err := template.New("docs").Funcs(template.FuncMap{"cell": markdownCell}).Parse(markdownDocsTemplate)
+		if err != nil {
+			return "", err
+		}
+		if err := tmpl.Execute(&buf, page); err != nil {
+			return "", err
+		}
+	case "html":
+		tmpl, err := htmltemplate.New("docs").Parse(htmlDocsTemplate)
+		if err != nil {
+			return "", err
+		}
+		if err := tmpl.Execute(&buf, page); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("docs format must be markdown or html, got %q", opts.Format)
+	}
+	return buf.String(), nil
+}
+
+func (g *Generator) docsPage(opts DocsOptions) docsPage {
+	page := docsPage{Title: opts.Title, Diagram: strings.TrimSpace(opts.Diagram)}
+	if page.Title == "" {
+		page.Title = "Database schema"
+	}
+	for _, e := range g.schema.GetEnums() {
+		page.Enums = append(page.Enums, docsEnum{Name: e.Name, Anchor: docsAnchor(e.Name), Values: e.Values})
+	}
+	for _, model := range g.schema.GetModels() {
+		m := docsModel{Name: model.Name, Anchor: docsAnchor(model.Name), Table: model.Table(), Comment: model.Description}
+		foreignKeys := make(map[string]bool)
+		for _, rel := range model.Relations {
+			if rel.Type == schema.RelationBelongsTo {
+				foreignKeys[rel.ForeignKey] = true
+			}
+			m.Relations = append(m.Relations, g.docsRelation(rel))
+		}
+		for _, f := range model.GetFields() {
+			m.Fields = append(m.Fields, docsColumn(f, foreignKeys[f.Name] || f.IsReference))
+		}
+		m.Indexes = docsIndexes(model)
+
+		for _, rel := range model.Relations {
+			if rel.Type != schema.RelationBelongsTo {
+				continue
+			}
+			target := rel.TargetModel
+			if parent := g.schema.Models[rel.TargetModel]; parent != nil {
+				target = parent.Table()
+			}
+			fk := docsForeignKey{Table: model.Table(), Column: rel.ForeignKey, Target: target}
+			for _, idx := range m.Indexes {
+				if strings.SplitN(idx.Columns, ", ", 2)[0] == rel.ForeignKey {
+					fk.Index = idx.Name
+					break
+				}
+			}
+			page.ForeignKeys = append(page.ForeignKeys, fk)
+		}
+		page.Models = append(page.Models, m)
+	}
+
+	migrations := slices.Clone(opts.Migrations)
+	slices.SortFunc(migrations, func(a, b *migration.Migration) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	for _, mig := range migrations {
+		m := docsMigration{ID: mig.ID, Name: strings.ReplaceAll(mig.Name, "_", " "), Author: mig.Author, Description: mig.Description}
+		if t, err := time.Parse("20060102_150405", mig.ID); err == nil {
+			m.Date = t.Format("2006-01-02 15:04")
+		}
+		page.Migrations = append(page.Migrations, m)
+	}
+	return page
+}
+
+// docsColumn describes f, a foreign key column if fk.
+func docsColumn(f *schema.Field, fk bool) docsField {
+	field := docsField{Name: f.Name, Type: f.Type.String(), Nullable: f.Nullable, Default: docsDefault(f), Comment: f.Description}
+	if f.Enum != nil {
+		field.Type, field.EnumAnchor = f.Enum.Name, docsAnchor(f.Enum.Name)
+	} else if f.Type == schema.FieldTypeString && f.Length > 0 {
+		field.Type += fmt.Sprintf("(%d)", f.Length)
+	} else if f.Type == schema.FieldTypeDecimal && f.Precision > 0 {
+		field.Type += fmt.Sprintf("(%d, %d)", f.Precision, f.Scale)
+	}
+
+	var keys []string
+	if f.IsPrimaryKey {
+		keys = append(keys, "PK")
+	}
+	if fk {
+		keys = append(keys, "FK")
+	}
+	if f.IsUnique && !f.IsPrimaryKey {
+		keys = append(keys, "UK")
+	}
+	field.Keys = strings.Join(keys, ", ")
+	return field
+}
+
+// docsDefault returns the default of f as the schema declares it.
+func docsDefault(f *schema.Field) string {
+	switch f.DefaultExpr {
+	case "":
+	case schema.DefaultExprNow:
+		return "now()"
+	case schema.DefaultExprUUID:
+		return "uuid()"
+	case schema.DefaultExprRandom:
+		return "random()"
+	default:
+		return f.DefaultExpr
+	}
+	switch v := f.DefaultValue.(type) {
+	case nil:
+		if f.AutoIncrement {
+			return "autoincrement()"
+		}
+		return ""
+	case string:
+		if f.Enum != nil {
+			return v
+		}
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (g *Generator) docsRelation(rel *schema.Relation) docsRelation {
+	r := docsRelation{Name: rel.Name, Model: rel.TargetModel, Anchor: docsAnchor(rel.TargetModel)}
+	target := rel.TargetModel
+	if parent := g.schema.Models[rel.TargetModel]; parent != nil {
+		target = parent.Table()
+	}
+	switch rel.Type {
+	case schema.RelationBelongsTo:
+		ref := rel.ReferenceKey
+		if ref == "" {
+			ref = "id"
+		}
+		r.Kind = "belongs to"
+		r.Columns = fmt.Sprintf("%s → %s.%s", rel.ForeignKey, target, ref)
+		if rel.OnDeleteAction != schema.NoAction {
+			r.OnDelete = rel.OnDeleteAction.String()
+		}
+	case schema.RelationHasOne, schema.RelationHasMany:
+		r.Kind = "has many"
+		if rel.Type == schema.RelationHasOne {
+			r.Kind = "has one"
+		}
+		r.Columns = target + "." + rel.ForeignKey
+	case schema.RelationManyToMany:
+		r.Kind = "many to many"
+		r.Columns = fmt.Sprintf("%s (%s, %s)", rel.Through, rel.ThroughSourceKey, rel.ThroughTargetKey)
+	}
+	return r
+}
+
+// docsIndexes returns the primary key of model, then its unique columns
+// and its indexes, each once.
+func docsIndexes(model *schema.Model) []docsIndex {
+	var indexes []docsIndex
+	var primaryKey []string
+	for _, f := range model.GetFields() {
+		if f.IsPrimaryKey {
+			primaryKey = append(primaryKey, f.Name)
+		}
+	}
+	if len(primaryKey) > 0 {
+		indexes = append(indexes, docsIndex{Name: "PRIMARY KEY", Columns: strings.Join(primaryKey, ", "), Unique: true})
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range model.GetFields() {
+		if f.IsUnique && !f.IsPrimaryKey {
+			name := schema.DefaultIndexName(model.Table(), true, []string{f.Name})
+			seen[name] = true
+			indexes = append(indexes, docsIndex{Name: name, Columns: f.Name, Unique: true})
+		}
+	}
+	for _, idx := range model.Indexes {
+		name := idx.Name
+		if name == "" {
+			name = schema.DefaultIndexName(model.Table(), idx.Unique, idx.Fields)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		indexes = append(indexes, docsIndex{Name: name, Columns: strings.Join(idx.Fields, ", "), Unique: idx.Unique})
+	}
+	return indexes
+}
+
+// docsAnchor returns the fragment linking to the heading of a model or
+// enum, as GitHub and most Markdown renderers derive it.
+func docsAnchor(name string) string {
+	return strings.ToLower(name)
+}
+
+// markdownCell escapes text for a cell of a Markdown table.
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(text)
+}
+
+const markdownDocsTemplate = `# {{.Title}}
+
+<!-- Generated by Nexus (nexus gen docs). Do not edit by hand. -->
+
+## Contents
+{{if .Diagram}}
+- [Entity-relationship diagram](#entity-relationship-diagram)
+{{- end}}
+- [Models](#models)
+{{- range .Models}}
+  - [{{.Name}}](#{{.Anchor}})
+{{- end}}
+{{- if .Enums}}
+- [Enums](#enums)
+{{- end}}
+- [Index coverage](#index-coverage)
+{{- if .Migrations}}
+- [Migration history](#migration-history)
+{{- end}}
+{{- if .Diagram}}
+
+## Entity-relationship diagram
+
+` + "```mermaid" + `
+{{.Diagram}}
+` + "```" + `
+{{- end}}
+
+## Models
+{{- range .Models}}
+
+### {{.Name}}
+
+Table ` + "`{{.Table}}`" + `
+{{- if .Comment}}
+
+{{.Comment}}
+{{- end}}
+
+| Column | Type | Nullable | Default | Keys | Description |
+|--------|------|----------|---------|------|-------------|
+{{- range .Fields}}
+| ` + "`{{.Name}}`" + ` | {{if .EnumAnchor}}[{{.Type}}](#{{.EnumAnchor}}){{else}}{{.Type}}{{end}} | {{if .Nullable}}yes{{else}}no{{end}} | {{if .Default}}` + "`{{cell .Default}}`" + `{{end}} | {{.Keys}} | {{cell .Comment}} |
+{{- end}}
+{{- if .Relations}}
+
+**Relations**
+
+| Name | Kind | Model | Columns | On delete |
+|------|------|-------|---------|-----------|
+{{- range .Relations}}
+| {{if .Name}}` + "`{{.Name}}`" + `{{end}} | {{.Kind}} | [{{.Model}}](#{{.Anchor}}) | {{.Columns}} | {{.OnDelete}} |
+{{- end}}
+{{- end}}
+{{- if .Indexes}}
+
+**Indexes**
+
+| Name | Columns | Unique |
+|------|---------|--------|
+{{- range .Indexes}}
+| ` + "`{{.Name}}`" + ` | {{.Columns}} | {{if .Unique}}yes{{else}}no{{end}} |
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Enums}}
+
+## Enums
+{{- range .Enums}}
+
+### {{.Name}}
+{{range .Values}}
+- ` + "`{{.}}`" + `
+{{- end}}
+{{- end}}
+{{- end}}
+
+## Index coverage
+{{if .ForeignKeys}}
+Foreign keys and the index a lookup by them uses: one whose first column is the foreign key.
+
+| Foreign key | References | Index |
+|-------------|------------|-------|
+{{- range .ForeignKeys}}
+| ` + "`{{.Table}}.{{.Column}}`" + ` | ` + "`{{.Target}}`" + ` | {{if .Index}}` + "`{{.Index}}`" + `{{else}}**not indexed**{{end}} |
+{{- end}}
+{{- else}}
+The schema declares no foreign keys.
+{{- end}}
+{{- if .Migrations}}
+
+## Migration history
+
+| ID | Date | Name | Author | Description |
+|----|------|------|--------|-------------|
+{{- range .Migrations}}
+| ` + "`{{.ID}}`" + ` | {{.Date}} | {{cell .Name}} | {{cell .Author}} | {{cell .Description}} |
+{{- end}}
+{{- end}}
+`
+
+const htmlDocsTemplate = `<!DOCTYPE html>
+<!-- Generated by Nexus (nexus gen docs). Do not edit by hand. -->
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 72rem; margin: 2rem auto; padding: 0 1rem; color: #1f2328; }
+table { border-collapse: collapse; margin: 1rem 0; }
+th, td { border: 1px solid #d0d7de; padding: 0.3rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+code { font-size: 0.9em; }
+.missing { color: #cf222e; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+
+<nav>
+<h2>Contents</h2>
+<ul>
+{{- if .Diagram}}
+<li><a href="#entity-relationship-diagram">Entity-relationship diagram</a></li>
+{{- end}}
+<li><a href="#models">Models</a>
+<ul>
+{{- range .Models}}
+<li><a href="#{{.Anchor}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+</li>
+{{- if .Enums}}
+<li><a href="#enums">Enums</a></li>
+{{- end}}
+<li><a href="#index-coverage">Index coverage</a></li>
+{{- if .Migrations}}
+<li><a href="#migration-history">Migration history</a></li>
+{{- end}}
+</ul>
+</nav>
+{{- if .Diagram}}
+
+<h2 id="entity-relationship-diagram">Entity-relationship diagram</h2>
+<pre class="mermaid">
+{{.Diagram}}
+</pre>
+<script type="module">
+import mermaid from "https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.esm.min.mjs";
+mermaid.initialize({ startOnLoad: true });
+</script>
+{{- end}}
+
+<h2 id="models">Models</h2>
+{{- range .Models}}
+
+<h3 id="{{.Anchor}}">{{.Name}}</h3>
+<p>Table <code>{{.Table}}</code></p>
+{{- if .Comment}}
+<p>{{.Comment}}</p>
+{{- end}}
+<table>
+<tr><th>Column</th><th>Type</th><th>Nullable</th><th>Default</th><th>Keys</th><th>Description</th></tr>
+{{- range .Fields}}
+<tr><td><code>{{.Name}}</code></td><td>{{if .EnumAnchor}}<a href="#{{.EnumAnchor}}">{{.Type}}</a>{{else}}{{.Type}}{{end}}</td><td>{{if .Nullable}}yes{{else}}no{{end}}</td><td>{{if .Default}}<code>{{.Default}}</code>{{end}}</td><td>{{.Keys}}</td><td>{{.Comment}}</td></tr>
+{{- end}}
+</table>
+{{- if .Relations}}
+<h4>Relations</h4>
+<table>
+<tr><th>Name</th><th>Kind</th><th>Model</th><th>Columns</th><th>On delete</th></tr>
+{{- range .Relations}}
+<tr><td>{{if .Name}}<code>{{.Name}}</code>{{end}}</td><td>{{.Kind}}</td><td><a href="#{{.Anchor}}">{{.Model}}</a></td><td>{{.Columns}}</td><td>{{.OnDelete}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Indexes}}
+<h4>Indexes</h4>
+<table>
+<tr><th>Name</th><th>Columns</th><th>Unique</th></tr>
+{{- range .Indexes}}
+<tr><td><code>{{.Name}}</code></td><td>{{.Columns}}</td><td>{{if .Unique}}yes{{else}}no{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- if .Enums}}
+
+<h2 id="enums">Enums</h2>
+{{- range .Enums}}
+<h3 id="{{.Anchor}}">{{.Name}}</h3>
+<ul>
+{{- range .Values}}
+<li><code>{{.}}</code></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+
+<h2 id="index-coverage">Index coverage</h2>
+{{- if .ForeignKeys}}
+<p>Foreign keys and the index a lookup by them uses: one whose first column is the foreign key.</p>
+<table>
+<tr><th>Foreign key</th><th>References</th><th>Index</th></tr>
+{{- range .ForeignKeys}}
+<tr><td><code>{{.Table}}.{{.Column}}</code></td><td><code>{{.Target}}</code></td><td>{{if .Index}}<code>{{.Index}}</code>{{else}}<span class="missing">not indexed</span>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>The schema declares no foreign keys.</p>
+{{- end}}
+{{- if .Migrations}}
+
+<h2 id="migration-history">Migration history</h2>
+<table>
+<tr><th>ID</th><th>Date</th><th>Name</th><th>Author</th><th>Description</th></tr>
+{{- range .Migrations}}
+<tr><td><code>{{.ID}}</code></td><td>{{.Date}}</td><td>{{.Name}}</td><td>{{.Author}}</td><td>{{.Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`
@@ -0,0 +1,100 @@
+package codegen
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// GenerateFactories generates package factory (factory/factory.go) with a
+// function per model returning fake column values, and one inserting a
+// fake record together with the records it depends on. The functions
+// share a pkg/factory Factory built from the schema, which is embedded.
+func (g *Generator) GenerateFactories() error {
+	dir := filepath.Join(g.outputDir, "factory")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmpl := `// Code generated by Nexus. DO NOT EDIT.
+
+// Package factory builds records with fake data for tests.
+package factory
+
+import (
+	"context"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	nxfactory "github.com/nexus-db/nexus/pkg/factory"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// schemaSource is the schema the factories were generated from.
+const schemaSource = {{.Source}}
+
+// Default builds the records of the functions in this package. Call
+// Default.Seed to get different data.
+var Default = nxfactory.MustParse(schemaSource)
+{{range .Models}}
+// {{.Name}} returns fake column values of a {{.Name}}; overrides replace
+// them. It panics if an override is not a field or relation of {{.Name}}.
+func {{.Name}}(overrides ...map[string]interface{}) map[string]interface{} {
+	values, err := Default.Build("{{.Name}}", overrides...)
+	if err != nil {
+		panic(err)
+	}
+	return values
+}
+
+// Create{{.Name}} inserts a fake {{.Name}}, creating the records it
+// depends on first.
+func Create{{.Name}}(ctx context.Context, conn *dialects.Connection, overrides ...map[string]interface{}) (query.Result, error) {
+	return Default.Create(ctx, conn, "{{.Name}}", overrides...)
+}
+
+// Create{{plural .Name}} inserts n fake {{.Name}} records.
+func Create{{plural .Name}}(ctx context.Context, conn *dialects.Connection, n int, overrides ...map[string]interface{}) (query.Results, error) {
+	return Default.CreateMany(ctx, conn, "{{.Name}}", n, overrides...)
+}
+{{end}}`
+
+	t, err := template.New("factories").Funcs(g.funcs()).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Source string
+		Models []*schema.Model
+	}{
+		Source: goString(schema.Format(g.schema)),
+		Models: g.schema.GetModels(),
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		// If formatting fails, write unformatted
+		formatted = buf.Bytes()
+	}
+
+	return os.WriteFile(filepath.Join(dir, "factory.go"), formatted, 0644)
+}
+
+// goString returns s as a Go string literal, raw when possible.
+func goString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
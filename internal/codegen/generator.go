@@ -7,6 +7,9 @@ import (
 	"go/format"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"unicode"
@@ -54,40 +57,59 @@ func (g *Generator) generateModels() error {
 package {{.PackageName}}
 
 import (
+	"encoding/json"
 	"time"
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
 )
 
-// Suppress unused import warning
-var _ = time.Now
+// Suppress unused import warnings
+var (
+	_ = time.Now
+	_ = json.RawMessage{}
+)
 
 {{range .Models}}
 // {{.Name}} represents a row in the {{.Name}} table.
+{{- with .Description}}
+//
+{{comment .}}
+{{- end}}
 type {{.Name}} struct {
 {{- range .Fields}}
-	{{goFieldName .Name}} {{goType .}} ` + "`" + `json:"{{.Name}}" db:"{{.Name}}"` + "`" + `
+{{- with .Description}}
+	{{comment .}}
+{{- end}}
+	{{goFieldName .Name}} {{modelGoType .}} ` + "`" + `json:"{{.Name}}" db:"{{.Name}}"` + "`" + `
+{{- end}}
+
+	db     *DB             // Set when loaded through a DB, for relation accessors
+	loaded map[string]bool // Relations loaded so far
+{{- range relations .}}
+	rel{{.Method}} {{.GoType}}
 {{- end}}
 }
 
 // TableName returns the table name for {{.Name}}.
 func ({{.Name}}) TableName() string {
-	return "{{.Name}}"
+	return "{{.Table}}"
 }
 {{end}}
 `
 
-	t, err := template.New("models").Funcs(template.FuncMap{
-		"goFieldName": goFieldName,
-		"goType":      goType,
-	}).Parse(tmpl)
+	t, err := template.New("models").Funcs(g.funcs()).Parse(tmpl)
 	if err != nil {
 		return err
 	}
 
 	data := struct {
 		PackageName string
+		Imports     []string
 		Models      []*schema.Model
 	}{
 		PackageName: g.packageName,
+		Imports:     g.customImports(),
 		Models:      g.schema.GetModels(),
 	}
 
@@ -111,14 +133,24 @@ package {{.PackageName}}
 
 import (
 	"context"
+	"errors"
+{{- if .Regexp}}
+	"regexp"
+{{- end}}
 
 	"github.com/nexus-db/nexus/pkg/dialects"
 	"github.com/nexus-db/nexus/pkg/query"
+	"github.com/nexus-db/nexus/pkg/validate"
 )
 
+// ErrDetached is returned by relation accessors of records that were not
+// loaded through a DB.
+var ErrDetached = errors.New("nexus: record was not loaded through a DB")
+
 // DB wraps a database connection with type-safe query methods.
 type DB struct {
-	conn *dialects.Connection
+	conn       *dialects.Connection
+	authorizer query.Authorizer
 }
 
 // NewDB creates a new DB wrapper.
@@ -126,14 +158,31 @@ func NewDB(conn *dialects.Connection) *DB {
 	return &DB{conn: conn}
 }
 
+// WithAuthorizer returns a copy of the DB whose queries are checked by a.
+func (db *DB) WithAuthorizer(a query.Authorizer) *DB {
+	return &DB{conn: db.conn, authorizer: a}
+}
+
 {{range .Models}}
 // {{.Name}}Query returns a query builder for {{.Name}}.
 func (db *DB) {{.Name}}Query() *query.Builder {
-	return query.New(db.conn, "{{.Name}}")
+{{- $query := newQuery .}}
+{{- with goTypes .}}
+	return {{$query}}.WithAuthorizer(db.authorizer).WithGoTypes(map[string]string{
+{{- range .}}
+		{{printf "%q" .Column}}: {{printf "%q" .GoType}},
+{{- end}}
+	})
+{{- else}}
+	return {{newQuery .}}.WithAuthorizer(db.authorizer)
+{{- end}}
 }
 
 // Create{{.Name}} inserts a new {{.Name}} record.
 func (db *DB) Create{{.Name}}(ctx context.Context, data map[string]interface{}) (query.Result, error) {
+	if err := validate{{.Name}}(data); err != nil {
+		return nil, err
+	}
 	return db.{{.Name}}Query().Insert(data).Returning("*").One(ctx)
 }
 
@@ -149,6 +198,9 @@ func (db *DB) FindAll{{.Name}}s(ctx context.Context) (query.Results, error) {
 
 // Update{{.Name}} updates a {{.Name}} by ID.
 func (db *DB) Update{{.Name}}(ctx context.Context, id interface{}, data map[string]interface{}) (int64, error) {
+	if err := validate{{.Name}}(data); err != nil {
+		return 0, err
+	}
 	return db.{{.Name}}Query().Update(data).Where(query.Eq("id", id)).Exec(ctx)
 }
 
@@ -156,19 +208,211 @@ func (db *DB) Update{{.Name}}(ctx context.Context, id interface{}, data map[stri
 func (db *DB) Delete{{.Name}}(ctx context.Context, id interface{}) (int64, error) {
 	return db.{{.Name}}Query().Delete().Where(query.Eq("id", id)).Exec(ctx)
 }
+{{- $rules := validations .}}
+{{- range $rules}}{{if .Pattern}}
+var {{.PatternVar}} = regexp.MustCompile({{printf "%q" .Pattern}})
+{{- end}}{{end}}
+
+// validate{{.Name}} checks the columns present in values against the
+// validation rules of {{.Name}}.
+func validate{{.Name}}(values map[string]interface{}) error {
+	errs := validate.Errors{}
+{{- range $r := $rules}}
+	if v, ok := values["{{$r.Column}}"]; ok {
+{{- range $r.Checks}}
+		errs.Add("{{$r.Column}}", {{.}})
+{{- end}}
+	}
+{{- end}}
+	return errs.Err()
+}
+
+// Validate checks the {{.Name}} against the validation rules of its fields.
+func (m *{{.Name}}) Validate() error {
+	return validate{{.Name}}(map[string]interface{}{
+{{- range $rules}}
+		"{{.Column}}": m.{{.GoName}},
+{{- end}}
+	})
+}
+{{$m := .Name}}
+// {{.Name}}Query is a typed query for {{.Name}} records.
+type {{.Name}}Query struct {
+	db       *DB
+	builder  *query.SelectBuilder
+	includes []func(context.Context, []*{{.Name}}) error
+}
+
+// {{plural .Name}} starts a typed query for {{.Name}} records.
+func (db *DB) {{plural .Name}}() *{{.Name}}Query {
+	return &{{.Name}}Query{db: db, builder: db.{{.Name}}Query().Select()}
+}
+
+// Where adds conditions to the query.
+func (q *{{.Name}}Query) Where(conditions ...query.Condition) *{{.Name}}Query {
+	q.builder.Where(conditions...)
+	return q
+}
+
+// OrderBy adds an ORDER BY clause.
+func (q *{{.Name}}Query) OrderBy(column string, direction query.OrderDirection) *{{.Name}}Query {
+	q.builder.OrderBy(column, direction)
+	return q
+}
+
+// Limit sets the maximum number of records.
+func (q *{{.Name}}Query) Limit(n int) *{{.Name}}Query {
+	q.builder.Limit(n)
+	return q
+}
+
+// Offset sets the number of records to skip.
+func (q *{{.Name}}Query) Offset(n int) *{{.Name}}Query {
+	q.builder.Offset(n)
+	return q
+}
+{{range relations .}}
+// Include{{.Method}} eager loads {{.Doc}} with one more query.
+func (q *{{$m}}Query) Include{{.Method}}() *{{$m}}Query {
+	q.includes = append(q.includes, q.db.load{{$m}}{{.Method}})
+	return q
+}
+{{end}}
+// All runs the query and returns the matching records.
+func (q *{{.Name}}Query) All(ctx context.Context) ([]*{{.Name}}, error) {
+	rows, err := q.builder.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*{{.Name}}, len(rows))
+	for i, row := range rows {
+		records[i] = &{{.Name}}{db: q.db}
+		if err := query.ScanStruct(row, records[i]); err != nil {
+			return nil, err
+		}
+	}
+	for _, include := range q.includes {
+		if err := include(ctx, records); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// First runs the query and returns the first record, or nil if none match.
+func (q *{{.Name}}Query) First(ctx context.Context) (*{{.Name}}, error) {
+	records, err := q.Limit(1).All(ctx)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return records[0], nil
+}
+
+func (m *{{.Name}}) setLoaded(relation string) {
+	if m.loaded == nil {
+		m.loaded = make(map[string]bool)
+	}
+	m.loaded[relation] = true
+}
+{{range relations .}}
+// {{.Method}} returns {{.Doc}} of the {{$m}}, querying them on first use.
+func (m *{{$m}}) {{.Method}}(ctx context.Context) ({{.GoType}}, error) {
+	if !m.loaded["{{.Method}}"] {
+		if m.db == nil {
+			return nil, ErrDetached
+		}
+		if err := m.db.load{{$m}}{{.Method}}(ctx, []*{{$m}}{m}); err != nil {
+			return nil, err
+		}
+	}
+	return m.rel{{.Method}}, nil
+}
+
+// load{{$m}}{{.Method}} loads {{.Doc}} of records in batch.
+func (db *DB) load{{$m}}{{.Method}}(ctx context.Context, records []*{{$m}}) error {
+	var keys []interface{}
+	for _, r := range records {
+		if k, ok := query.KeyValue(r.{{.LocalField}}); ok {
+			keys = append(keys, k)
+		}
+	}
+{{- if .Through}}
+
+	// Map each record to its targets through the junction table
+	targets := make(map[interface{}][]interface{})
+	seen := make(map[interface{}]bool)
+	var targetKeys []interface{}
+	if len(keys) > 0 {
+		links, err := query.New(db.conn, "{{.Through}}").WithAuthorizer(db.authorizer).
+			Select("{{.ThroughSource}}", "{{.ThroughTarget}}").
+			Where(query.In("{{.ThroughSource}}", keys...)).All(ctx)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			source, ok := query.KeyValue(link["{{.ThroughSource}}"])
+			target, ok2 := query.KeyValue(link["{{.ThroughTarget}}"])
+			if !ok || !ok2 {
+				continue
+			}
+			targets[source] = append(targets[source], target)
+			if !seen[target] {
+				seen[target] = true
+				targetKeys = append(targetKeys, target)
+			}
+		}
+	}
+	keys = targetKeys
+{{- end}}
+
+	related := make(map[interface{}][]*{{.Target}})
+	if len(keys) > 0 {
+		rows, err := db.{{plural .Target}}().Where(query.In("{{.RemoteColumn}}", keys...)).All(ctx)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if k, ok := query.KeyValue(row.{{.RemoteField}}); ok {
+				related[k] = append(related[k], row)
+			}
+		}
+	}
+
+	for _, r := range records {
+		k, _ := query.KeyValue(r.{{.LocalField}})
+{{- if .Through}}
+		r.rel{{.Method}} = []*{{.Target}}{}
+		for _, target := range targets[k] {
+			r.rel{{.Method}} = append(r.rel{{.Method}}, related[target]...)
+		}
+{{- else if .Many}}
+		r.rel{{.Method}} = append([]*{{.Target}}{}, related[k]...)
+{{- else}}
+		r.rel{{.Method}} = nil
+		if rows := related[k]; len(rows) > 0 {
+			r.rel{{.Method}} = rows[0]
+		}
+{{- end}}
+		r.setLoaded("{{.Method}}")
+	}
+	return nil
+}
+{{end}}
 {{end}}
 `
 
-	t, err := template.New("queries").Parse(tmpl)
+	t, err := template.New("queries").Funcs(g.funcs()).Parse(tmpl)
 	if err != nil {
 		return err
 	}
 
 	data := struct {
 		PackageName string
+		Regexp      bool // A field has a @regex rule
 		Models      []*schema.Model
 	}{
 		PackageName: g.packageName,
+		Regexp:      g.hasPatterns(),
 		Models:      g.schema.GetModels(),
 	}
 
@@ -186,6 +430,183 @@ func (db *DB) Delete{{.Name}}(ctx context.Context, id interface{}) (int64, error
 	return os.WriteFile(filepath.Join(g.outputDir, "queries.go"), formatted, 0644)
 }
 
+// hasPatterns reports whether a field of the schema has a @regex rule,
+// which the generated validation compiles.
+func (g *Generator) hasPatterns() bool {
+	for _, model := range g.schema.GetModels() {
+		for _, f := range model.GetFields() {
+			if f.Pattern != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *Generator) funcs() template.FuncMap {
+	return template.FuncMap{
+		"comment":     comment,
+		"goFieldName": goFieldName,
+		"goType":      goType,
+		"goTypes":     goTypes,
+		"modelGoType": modelGoType,
+		"newQuery":    newQuery,
+		"plural":      plural,
+		"relations":   g.relations,
+		"validations": validations,
+	}
+}
+
+// comment renders text, such as a model or field comment, as // comment
+// lines.
+func comment(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+strings.TrimSpace(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newQuery returns the Go expression that creates a query builder for the
+// table of model, placed in its @@schema if it has one.
+func newQuery(model *schema.Model) string {
+	if model.SchemaName == "" {
+		return fmt.Sprintf("query.New(db.conn, %q)", model.Table())
+	}
+	table := strings.TrimPrefix(model.Table(), model.SchemaName+".")
+	return fmt.Sprintf("query.New(db.conn, %q).InSchema(%q)", table, model.SchemaName)
+}
+
+// validationView describes the validation rules of a column.
+type validationView struct {
+	Column     string
+	GoName     string
+	Checks     []string // Calls of package validate on v
+	Pattern    string
+	PatternVar string // Compiled @regex pattern
+}
+
+// validations returns the columns of model with validation rules.
+func validations(model *schema.Model) []validationView {
+	var views []validationView
+	for _, f := range model.GetFields() {
+		v := validationView{Column: f.Name, GoName: goFieldName(f.Name)}
+		if f.IsEmail {
+			v.Checks = append(v.Checks, "validate.Email(v)")
+		}
+		if f.MinValue != nil {
+			v.Checks = append(v.Checks, "validate.Min(v, "+strconv.FormatFloat(*f.MinValue, 'g', -1, 64)+")")
+		}
+		if f.MaxValue != nil {
+			v.Checks = append(v.Checks, "validate.Max(v, "+strconv.FormatFloat(*f.MaxValue, 'g', -1, 64)+")")
+		}
+		if f.Pattern != "" {
+			v.Pattern = f.Pattern
+			v.PatternVar = lowerFirst(model.Name) + v.GoName + "Pattern"
+			v.Checks = append(v.Checks, "validate.Match(v, "+v.PatternVar+")")
+		}
+		if len(v.Checks) > 0 {
+			views = append(views, v)
+		}
+	}
+	return views
+}
+
+// relationView describes a relation accessor of a generated model.
+type relationView struct {
+	Method string // Accessor name, e.g. Posts
+	Target string // Target model
+	Many   bool
+	Doc    string // e.g. "the related Post records"
+
+	LocalField   string // Go field of the source holding the key
+	RemoteField  string // Go field of the target matched against it
+	RemoteColumn string // Column of the target filtered by key
+
+	Through       string // Junction table of a many-to-many relation
+	ThroughSource string
+	ThroughTarget string
+}
+
+// GoType returns the Go type of the accessor result.
+func (r relationView) GoType() string {
+	if r.Many {
+		return "[]*" + r.Target
+	}
+	return "*" + r.Target
+}
+
+// relations returns the relation accessors of a model. Relations declared
+// in the schema are named after their field; detected ones after the
+// target model. Accessors that would clash with a column field are skipped.
+func (g *Generator) relations(model *schema.Model) []relationView {
+	taken := map[string]bool{"TableName": true}
+	for _, f := range model.GetFields() {
+		taken[goFieldName(f.Name)] = true
+	}
+
+	var views []relationView
+	for _, rel := range model.GetRelations() {
+		target, ok := g.schema.Models[rel.TargetModel]
+		if !ok {
+			continue
+		}
+
+		v := relationView{Target: target.Name, Many: rel.Type == schema.RelationHasMany || rel.Type == schema.RelationManyToMany}
+		switch rel.Type {
+		case schema.RelationBelongsTo:
+			v.LocalField, v.RemoteColumn = rel.ForeignKey, rel.ReferenceKey
+		case schema.RelationHasOne, schema.RelationHasMany:
+			v.LocalField, v.RemoteColumn = rel.ReferenceKey, rel.ForeignKey
+		case schema.RelationManyToMany:
+			v.LocalField, v.RemoteColumn = rel.ReferenceKey, primaryKey(target)
+			v.Through, v.ThroughSource, v.ThroughTarget = rel.Through, rel.ThroughSourceKey, rel.ThroughTargetKey
+		default:
+			continue
+		}
+		if model.Fields[v.LocalField] == nil || target.Fields[v.RemoteColumn] == nil {
+			continue
+		}
+		v.LocalField, v.RemoteField = goFieldName(v.LocalField), goFieldName(v.RemoteColumn)
+
+		switch {
+		case rel.Name != "":
+			v.Method = goFieldName(rel.Name)
+		case v.Many:
+			v.Method = plural(target.Name)
+		default:
+			v.Method = target.Name
+		}
+		if taken[v.Method] {
+			continue
+		}
+		taken[v.Method] = true
+
+		if v.Many {
+			v.Doc = "the related " + target.Name + " records"
+		} else {
+			v.Doc = "the related " + target.Name
+		}
+		views = append(views, v)
+	}
+	return views
+}
+
+// primaryKey returns the primary key column of a model.
+func primaryKey(model *schema.Model) string {
+	for _, f := range model.GetFields() {
+		if f.IsPrimaryKey {
+			return f.Name
+		}
+	}
+	return "id"
+}
+
+// plural returns the plural used in generated names: User -> Users.
+func plural(name string) string {
+	return name + "s"
+}
+
 // goFieldName converts a database column name to a Go field name.
 func goFieldName(name string) string {
 	// Convert snake_case to PascalCase
@@ -200,6 +621,74 @@ func goFieldName(name string) string {
 	return strings.Join(parts, "")
 }
 
+// goTypeView is a column declared with a custom Go type.
+type goTypeView struct {
+	Column string
+	GoType string
+}
+
+// goTypes returns the columns of model declared with a custom Go type.
+func goTypes(model *schema.Model) []goTypeView {
+	var views []goTypeView
+	for _, f := range model.GetFields() {
+		if f.GoTypeName != "" {
+			views = append(views, goTypeView{Column: f.Name, GoType: f.GoTypeName})
+		}
+	}
+	return views
+}
+
+// customImports returns the packages of the custom Go types of fields
+// that models.go doesn't import already, sorted.
+func (g *Generator) customImports() []string {
+	seen := map[string]bool{"encoding/json": true, "time": true}
+	var imports []string
+	for _, model := range g.schema.GetModels() {
+		for _, f := range model.GetFields() {
+			if path, _ := customGoType(f.GoTypeName); path != "" && !seen[path] {
+				seen[path] = true
+				imports = append(imports, path)
+			}
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// customGoType splits a custom Go type, as fields declare it with GoType,
+// into its import path and the type as code refers to it, e.g.
+// "github.com/shopspring/decimal" and "decimal.Decimal". Types without an
+// import path are builtin or of the generated package.
+func customGoType(name string) (importPath, typeName string) {
+	dot := strings.LastIndex(name, ".")
+	if dot <= strings.LastIndex(name, "/") {
+		return "", name
+	}
+	importPath = name[:dot]
+	elems := strings.Split(importPath, "/")
+	pkg := elems[len(elems)-1]
+	if len(elems) > 1 && majorVersion.MatchString(pkg) {
+		pkg = elems[len(elems)-2] // github.com/jackc/pgx/v5
+	}
+	pkg, _, _ = strings.Cut(pkg, ".") // gopkg.in/yaml.v3
+	return importPath, strings.ReplaceAll(pkg, "-", "") + name[dot:]
+}
+
+var majorVersion = regexp.MustCompile(`^v\d+$`)
+
+// modelGoType returns the Go type of a field in a model struct: its
+// custom Go type if it has one, else that of goType.
+func modelGoType(field *schema.Field) string {
+	if field.GoTypeName == "" {
+		return goType(field)
+	}
+	_, typeName := customGoType(field.GoTypeName)
+	if field.Nullable {
+		return "*" + typeName
+	}
+	return typeName
+}
+
 // goType returns the Go type for a schema field.
 func goType(field *schema.Field) string {
 	var baseType string
@@ -208,13 +697,17 @@ func goType(field *schema.Field) string {
 		baseType = "int"
 	case schema.FieldTypeBigInt:
 		baseType = "int64"
-	case schema.FieldTypeString, schema.FieldTypeText, schema.FieldTypeUUID:
+	case schema.FieldTypeSmallInt:
+		baseType = "int16"
+	case schema.FieldTypeTinyInt:
+		baseType = "int8"
+	case schema.FieldTypeString, schema.FieldTypeText, schema.FieldTypeUUID, schema.FieldTypeEnum:
 		baseType = "string"
 	case schema.FieldTypeBool:
 		baseType = "bool"
 	case schema.FieldTypeFloat:
 		baseType = "float64"
-	case schema.FieldTypeDecimal:
+	case schema.FieldTypeDecimal, schema.FieldTypeMoney:
 		baseType = "float64" // Could use decimal package
 	case schema.FieldTypeDateTime, schema.FieldTypeDate, schema.FieldTypeTime:
 		baseType = "time.Time"
@@ -225,6 +718,9 @@ func goType(field *schema.Field) string {
 	default:
 		baseType = "interface{}"
 	}
+	if field.IsUnsigned && field.Type.IsInteger() {
+		baseType = "u" + baseType
+	}
 
 	if field.Nullable {
 		// Use pointer for nullable types
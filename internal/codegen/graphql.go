@@ -0,0 +1,389 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/graphql"
+)
+
+// gqlModel describes the GraphQL API of a model for the templates.
+type gqlModel struct {
+	apiModel
+	Single    string // Query field for one record, e.g. blogPost
+	List      string // Query field for many records, e.g. blogPosts
+	HasPK     bool
+	Relations []gqlRelation
+}
+
+// gqlRelation is a relation field resolved by a generated loader.
+type gqlRelation struct {
+	Field  string // GraphQL field name
+	Method string // Loader and cache suffix, see relationView
+}
+
+// GenerateGraphQL generates a GraphQL schema (schema.graphql) and the Go
+// code serving it (graphql.go). The resolvers use the typed queries and
+// relation loaders of queries.go, so Generate must be run too.
+func (g *Generator) GenerateGraphQL() error {
+	sdl := g.GraphQLSchema()
+	if _, err := graphql.ParseSchema(sdl); err != nil {
+		return fmt.Errorf("generated GraphQL schema is invalid: %w", err)
+	}
+
+	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(g.outputDir, "schema.graphql"), []byte(sdl), 0644); err != nil {
+		return err
+	}
+
+	tmpl := `// Code generated by Nexus. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/nexus-db/nexus/pkg/graphql"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// GraphQLSDL is the GraphQL schema served by GraphQLHandler. It is also
+// written to schema.graphql.
+const GraphQLSDL = ` + "`" + `{{.SDL}}` + "`" + `
+
+// GraphQLHandler returns an http.Handler serving the GraphQL API.
+func (db *DB) GraphQLHandler() http.Handler {
+	return graphql.Handler(db.GraphQLSchema())
+}
+
+// GraphQLSchema returns the GraphQL schema with resolvers bound to db.
+// Relations are loaded in one query per relation and depth.
+func (db *DB) GraphQLSchema() *graphql.Schema {
+	s := graphql.MustParseSchema(GraphQLSDL)
+{{- range .Models}}
+	db.resolve{{.Name}}GraphQL(s)
+{{- end}}
+	return s
+}
+
+// graphQLFilter applies the where, orderBy, limit and offset arguments
+// of a list query.
+func graphQLFilter(b *query.SelectBuilder, args map[string]interface{}) {
+	if where, ok := args["where"].(map[string]interface{}); ok {
+		columns := make([]string, 0, len(where))
+		for column := range where {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		for _, column := range columns {
+			if where[column] == nil {
+				b.Where(query.IsNull(column))
+			} else {
+				b.Where(query.Eq(column, where[column]))
+			}
+		}
+	}
+	if orderBy, ok := args["orderBy"].([]interface{}); ok {
+		for _, o := range orderBy {
+			o, _ := o.(map[string]interface{})
+			column, _ := o["field"].(string)
+			direction := query.Asc
+			if o["direction"] == "DESC" {
+				direction = query.Desc
+			}
+			b.OrderBy(column, direction)
+		}
+	}
+	if limit, ok := args["limit"].(int); ok && limit > 0 {
+		b.Limit(limit)
+	}
+	if offset, ok := args["offset"].(int); ok && offset > 0 {
+		b.Offset(offset)
+	}
+}
+{{range .Models}}{{$m := .}}
+// resolve{{.Name}}GraphQL binds the queries, mutations and relations of {{.Name}}.
+func (db *DB) resolve{{.Name}}GraphQL(s *graphql.Schema) {
+	s.Resolve("Query", "{{.List}}", func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+		q := db.{{.Plural}}()
+		graphQLFilter(q.builder, args)
+		return q.All(ctx)
+	})
+{{- if .HasPK}}
+	s.Resolve("Query", "{{.Single}}", func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+		return db.{{.Plural}}().Where(query.Eq("{{.PK.Column}}", args["{{.PK.Column}}"])).First(ctx)
+	})
+
+	s.Resolve("Mutation", "create{{.Name}}", func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+		values, _ := args["data"].(map[string]interface{})
+		if err := validate{{.Name}}(values); err != nil {
+			return nil, err
+		}
+{{- if .PK.Auto}}
+		var id interface{}
+		if db.conn.Dialect.SupportsReturning() {
+			row, err := db.{{.Name}}Query().Insert(values).Returning("{{.PK.Column}}").One(ctx)
+			if err != nil {
+				return nil, err
+			}
+			id = row["{{.PK.Column}}"]
+		} else {
+			n, err := db.{{.Name}}Query().Insert(values).LastInsertId(ctx)
+			if err != nil {
+				return nil, err
+			}
+			id = n
+		}
+{{- else}}
+		if _, err := db.{{.Name}}Query().Insert(values).Exec(ctx); err != nil {
+			return nil, err
+		}
+		id := values["{{.PK.Column}}"]
+{{- end}}
+		return db.{{.Plural}}().Where(query.Eq("{{.PK.Column}}", id)).First(ctx)
+	})
+
+	s.Resolve("Mutation", "update{{.Name}}", func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+		id := args["{{.PK.Column}}"]
+		existing, err := db.{{.Plural}}().Where(query.Eq("{{.PK.Column}}", id)).First(ctx)
+		if err != nil || existing == nil {
+			return nil, err
+		}
+		values, _ := args["data"].(map[string]interface{})
+		if err := validate{{.Name}}(values); err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			return existing, nil
+		}
+		if _, err := db.{{.Name}}Query().Update(values).Where(query.Eq("{{.PK.Column}}", id)).Exec(ctx); err != nil {
+			return nil, err
+		}
+		if v, ok := values["{{.PK.Column}}"]; ok {
+			id = v
+		}
+		return db.{{.Plural}}().Where(query.Eq("{{.PK.Column}}", id)).First(ctx)
+	})
+
+	s.Resolve("Mutation", "delete{{.Name}}", func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+		n, err := db.{{.Name}}Query().Delete().Where(query.Eq("{{.PK.Column}}", args["{{.PK.Column}}"])).Exec(ctx)
+		return n > 0, err
+	})
+{{- end}}
+{{- range .Relations}}
+
+	s.Batch("{{$m.Name}}", "{{.Field}}", func(ctx context.Context, sources []interface{}, _ map[string]interface{}) ([]interface{}, error) {
+		records := make([]*{{$m.Name}}, len(sources))
+		for i, source := range sources {
+			records[i] = source.(*{{$m.Name}})
+		}
+		if err := db.load{{$m.Name}}{{.Method}}(ctx, records); err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(records))
+		for i, r := range records {
+			values[i] = r.rel{{.Method}}
+		}
+		return values, nil
+	})
+{{- end}}
+}
+{{end}}
+`
+
+	t, err := template.New("graphql").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		PackageName string
+		SDL         string
+		Models      []gqlModel
+	}{
+		PackageName: g.packageName,
+		SDL:         sdl,
+		Models:      g.gqlModels(),
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		// If formatting fails, write unformatted
+		formatted = buf.Bytes()
+	}
+
+	return os.WriteFile(filepath.Join(g.outputDir, "graphql.go"), formatted, 0644)
+}
+
+// gqlModels returns the GraphQL view of every model. Models without a
+// primary key only get a list query.
+func (g *Generator) gqlModels() []gqlModel {
+	withPK := make(map[string]apiModel)
+	for _, m := range g.apiModels() {
+		withPK[m.Name] = m
+	}
+
+	var models []gqlModel
+	for _, model := range g.schema.GetModels() {
+		m := gqlModel{
+			apiModel: apiModel{Name: model.Name, Plural: plural(model.Name)},
+			Single:   lowerFirst(model.Name),
+			List:     lowerFirst(plural(model.Name)),
+		}
+		if api, ok := withPK[model.Name]; ok {
+			m.apiModel, m.HasPK = api, true
+		}
+		for _, rel := range g.relations(model) {
+			m.Relations = append(m.Relations, gqlRelation{Field: lowerFirst(rel.Method), Method: rel.Method})
+		}
+		models = append(models, m)
+	}
+	return models
+}
+
+// GraphQLSchema returns the GraphQL SDL for the schema: a type per model
+// with its relations, filter, ordering and input types, list and single
+// record queries, and create, update and delete mutations.
+func (g *Generator) GraphQLSchema() string {
+	var sb strings.Builder
+	sb.WriteString("\"An RFC 3339 timestamp.\"\nscalar DateTime\n\n")
+	sb.WriteString("\"Any JSON value.\"\nscalar JSON\n\n")
+	sb.WriteString("enum SortDirection {\n  ASC\n  DESC\n}\n")
+
+	for _, e := range g.schema.GetEnums() {
+		fmt.Fprintf(&sb, "\nenum %s {\n", e.Name)
+		for _, v := range e.Values {
+			fmt.Fprintf(&sb, "  %s\n", v)
+		}
+		sb.WriteString("}\n")
+	}
+
+	models := g.gqlModels()
+	var queries, mutations []string
+	for _, m := range models {
+		model := g.schema.Models[m.Name]
+
+		fmt.Fprintf(&sb, "\ntype %s {\n", m.Name)
+		for _, f := range model.GetFields() {
+			typ := gqlType(f)
+			if !f.Nullable {
+				typ += "!"
+			}
+			fmt.Fprintf(&sb, "  %s: %s\n", f.Name, typ)
+		}
+		for i, rel := range g.relations(model) {
+			typ := rel.Target
+			if rel.Many {
+				typ = "[" + typ + "!]!"
+			}
+			fmt.Fprintf(&sb, "  %s: %s\n", m.Relations[i].Field, typ)
+		}
+		sb.WriteString("}\n")
+
+		// Filtering and ordering work on scalar columns; JSON has no equality
+		var filterable []*schema.Field
+		for _, f := range model.GetFields() {
+			if f.Type != schema.FieldTypeJSON {
+				filterable = append(filterable, f)
+			}
+		}
+		fmt.Fprintf(&sb, "\ninput %sWhere {\n", m.Name)
+		for _, f := range filterable {
+			fmt.Fprintf(&sb, "  %s: %s\n", f.Name, gqlType(f))
+		}
+		sb.WriteString("}\n")
+		fmt.Fprintf(&sb, "\nenum %sField {\n", m.Name)
+		for _, f := range filterable {
+			fmt.Fprintf(&sb, "  %s\n", f.Name)
+		}
+		sb.WriteString("}\n")
+		fmt.Fprintf(&sb, "\ninput %sOrderBy {\n  field: %sField!\n  direction: SortDirection = ASC\n}\n", m.Name, m.Name)
+
+		queries = append(queries, fmt.Sprintf("%s(where: %sWhere, orderBy: [%sOrderBy!], limit: Int, offset: Int): [%s!]!", m.List, m.Name, m.Name, m.Name))
+		if !m.HasPK {
+			continue
+		}
+		pkType := gqlType(model.Fields[m.PK.Column]) + "!"
+		queries = append(queries, fmt.Sprintf("%s(%s: %s): %s", m.Single, m.PK.Column, pkType, m.Name))
+
+		fmt.Fprintf(&sb, "\ninput %sCreateInput {\n", m.Name)
+		for _, f := range m.Fields {
+			typ := gqlType(model.Fields[f.Column])
+			if f.Required {
+				typ += "!"
+			}
+			fmt.Fprintf(&sb, "  %s: %s\n", f.Column, typ)
+		}
+		sb.WriteString("}\n")
+		fmt.Fprintf(&sb, "\ninput %sUpdateInput {\n", m.Name)
+		for _, f := range m.Fields {
+			fmt.Fprintf(&sb, "  %s: %s\n", f.Column, gqlType(model.Fields[f.Column]))
+		}
+		sb.WriteString("}\n")
+
+		mutations = append(mutations,
+			fmt.Sprintf("create%s(data: %sCreateInput!): %s!", m.Name, m.Name, m.Name),
+			fmt.Sprintf("update%s(%s: %s, data: %sUpdateInput!): %s", m.Name, m.PK.Column, pkType, m.Name, m.Name),
+			fmt.Sprintf("delete%s(%s: %s): Boolean!", m.Name, m.PK.Column, pkType),
+		)
+	}
+
+	sb.WriteString("\ntype Query {\n")
+	for _, q := range queries {
+		fmt.Fprintf(&sb, "  %s\n", q)
+	}
+	sb.WriteString("}\n")
+	if len(mutations) > 0 {
+		sb.WriteString("\ntype Mutation {\n")
+		for _, m := range mutations {
+			fmt.Fprintf(&sb, "  %s\n", m)
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+// gqlType returns the nullable GraphQL type of a column.
+func gqlType(f *schema.Field) string {
+	if f.Enum != nil {
+		return f.Enum.Name
+	}
+	switch f.Type {
+	case schema.FieldTypeInt, schema.FieldTypeBigInt, schema.FieldTypeSmallInt, schema.FieldTypeTinyInt:
+		return "Int"
+	case schema.FieldTypeFloat, schema.FieldTypeDecimal, schema.FieldTypeMoney:
+		return "Float"
+	case schema.FieldTypeBool:
+		return "Boolean"
+	case schema.FieldTypeDateTime, schema.FieldTypeDate, schema.FieldTypeTime:
+		return "DateTime"
+	case schema.FieldTypeJSON:
+		return "JSON"
+	default:
+		return "String"
+	}
+}
+
+// lowerFirst lowercases the first letter: BlogPost -> blogPost.
+func lowerFirst(s string) string {
+	r := []rune(s)
+	if len(r) > 0 {
+		r[0] = unicode.ToLower(r[0])
+	}
+	return string(r)
+}
@@ -0,0 +1,558 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// sqlTokenKind is the kind of a token of a query.
+type sqlTokenKind int
+
+const (
+	tokWord   sqlTokenKind = iota // Keyword or identifier
+	tokQuoted                     // Quoted identifier, without its quotes
+	tokParam                      // :name, without the colon
+	tokString
+	tokNumber
+	tokSymbol
+)
+
+// sqlToken is a token of a query.
+type sqlToken struct {
+	kind  sqlTokenKind
+	text  string
+	depth int // Parentheses around the token
+}
+
+// isIdent reports whether the token is an identifier that isn't a
+// keyword ending a table reference.
+func (t sqlToken) isIdent() bool {
+	return t.kind == tokQuoted || t.kind == tokWord && !clauseWords[strings.ToUpper(t.text)]
+}
+
+// upper returns the text of a word in upper case, for keywords.
+func (t sqlToken) upper() string {
+	if t.kind != tokWord {
+		return ""
+	}
+	return strings.ToUpper(t.text)
+}
+
+// clauseWords are keywords that can follow a table and so are no alias.
+var clauseWords = map[string]bool{
+	"WHERE": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "OUTER": true,
+	"CROSS": true, "NATURAL": true, "ON": true, "USING": true, "SET": true, "VALUES": true, "SELECT": true,
+	"ORDER": true, "GROUP": true, "HAVING": true, "LIMIT": true, "OFFSET": true, "FETCH": true, "UNION": true,
+	"EXCEPT": true, "INTERSECT": true, "RETURNING": true, "DEFAULT": true, "AS": true, "FROM": true,
+	"WINDOW": true, "FOR": true, "OUTPUT": true, "END": true, "AND": true, "OR": true, "NOT": true,
+}
+
+// comparisons are the operators a parameter is compared to a column with.
+var comparisons = map[string]bool{"=": true, "<>": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true, "LIKE": true, "ILIKE": true}
+
+// tokenizeSQL splits a query into tokens, leaving out comments.
+func tokenizeSQL(sql string) []sqlToken {
+	var tokens []sqlToken
+	depth := 0
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(sql[i:], "--"):
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+			continue
+		case c == '\'':
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			i = min(i+1, len(sql))
+			tokens = append(tokens, sqlToken{kind: tokString, text: sql[start:i], depth: depth})
+		case c == '"' || c == '`':
+			end := strings.IndexByte(sql[i+1:], c)
+			if end < 0 {
+				end = len(sql) - i - 1
+			}
+			tokens = append(tokens, sqlToken{kind: tokQuoted, text: sql[i+1 : i+1+end], depth: depth})
+			i = min(i+end+2, len(sql))
+		case c == ':' && i+1 < len(sql) && isNameStart(sql[i+1]):
+			for i++; i < len(sql) && isNameByte(sql[i]); i++ {
+			}
+			tokens = append(tokens, sqlToken{kind: tokParam, text: sql[start+1 : i], depth: depth})
+		case isNameStart(c):
+			for i < len(sql) && (isNameByte(sql[i]) || sql[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokWord, text: sql[start:i], depth: depth})
+		case c >= '0' && c <= '9':
+			for i < len(sql) && (sql[i] >= '0' && sql[i] <= '9' || sql[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokNumber, text: sql[start:i], depth: depth})
+		default:
+			i++
+			if i < len(sql) {
+				switch two := sql[start : i+1]; two {
+				case "<=", ">=", "<>", "!=", "::", "||":
+					i++
+				}
+			}
+			text := sql[start:i]
+			if text == ")" {
+				depth--
+			}
+			tokens = append(tokens, sqlToken{kind: tokSymbol, text: text, depth: depth})
+			if text == "(" {
+				depth++
+			}
+		}
+	}
+	return tokens
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNameByte(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9'
+}
+
+// scopeTable is a table a query reads or writes.
+type scopeTable struct {
+	name     string // Alias, or the table name
+	model    *schema.Model
+	nullable bool // LEFT JOINed, so its columns can be NULL
+}
+
+// queryAnalysis resolves the columns of a query against the schema.
+type queryAnalysis struct {
+	schema *schema.Schema
+	tokens []sqlToken
+	tables []scopeTable
+}
+
+// analyzeQuery infers the types of the parameters and result columns of q.
+func analyzeQuery(s *schema.Schema, q *SQLQuery) (queryView, error) {
+	a := &queryAnalysis{schema: s, tokens: tokenizeSQL(q.SQL)}
+	a.findTables()
+	view := queryView{SQLQuery: q}
+
+	insertColumns := a.insertColumns()
+	seen := make(map[string]int)
+	for i, tok := range a.tokens {
+		if tok.kind != tokParam {
+			continue
+		}
+		goType := a.paramType(i, insertColumns)
+		if j, ok := seen[tok.text]; ok {
+			if view.Params[j].GoType == "interface{}" {
+				view.Params[j].GoType = goType
+			}
+			continue
+		}
+		seen[tok.text] = len(view.Params)
+		view.Params = append(view.Params, queryField{Name: tok.text, GoName: goFieldName(tok.text), GoType: goType, Arg: argName(tok.text)})
+	}
+	args := make(map[string]string)
+	for _, p := range view.Params {
+		if other, ok := args[p.Arg]; ok {
+			return view, fmt.Errorf("parameters :%s and :%s have the same Go name", other, p.Name)
+		}
+		args[p.Arg] = p.Name
+	}
+
+	if !view.HasRows() {
+		return view, nil
+	}
+	columns, err := a.resultColumns()
+	if err != nil {
+		return view, err
+	}
+	if len(columns) == 0 {
+		return view, fmt.Errorf("%s queries need a SELECT or RETURNING", q.Kind)
+	}
+	names := make(map[string]bool)
+	for _, c := range columns {
+		if names[c.GoName] {
+			return view, fmt.Errorf("column %s appears twice; name one with AS", c.Name)
+		}
+		names[c.GoName] = true
+	}
+	view.Columns = columns
+	return view, nil
+}
+
+// model returns the model whose table is named name.
+func (a *queryAnalysis) model(name string) *schema.Model {
+	for _, m := range a.schema.GetModels() {
+		if strings.EqualFold(m.Table(), name) || strings.EqualFold(m.Name, name) {
+			return m
+		}
+	}
+	return nil
+}
+
+// findTables collects the tables after FROM, JOIN, UPDATE and INTO with
+// their aliases.
+func (a *queryAnalysis) findTables() {
+	t := a.tokens
+	for i := 0; i < len(t); i++ {
+		switch t[i].upper() {
+		case "FROM", "JOIN", "UPDATE", "INTO":
+		default:
+			continue
+		}
+		nullable := t[i].upper() == "JOIN" && i > 0 &&
+			(t[i-1].upper() == "LEFT" || i > 1 && t[i-1].upper() == "OUTER" && t[i-2].upper() == "LEFT")
+		for j := i + 1; j < len(t) && t[j].isIdent(); {
+			name := t[j].text
+			j++
+			// schema.table
+			if j+1 < len(t) && t[j].text == "." && t[j+1].isIdent() {
+				name = t[j+1].text
+				j += 2
+			}
+			alias := name
+			if j < len(t) && t[j].upper() == "AS" {
+				j++
+			}
+			if j < len(t) && t[j].isIdent() {
+				alias = t[j].text
+				j++
+			}
+			if m := a.model(name); m != nil {
+				a.tables = append(a.tables, scopeTable{name: alias, model: m, nullable: nullable})
+			}
+			// FROM a, b
+			if t[i].upper() != "FROM" || j >= len(t) || t[j].text != "," {
+				break
+			}
+			j++
+		}
+	}
+}
+
+// column resolves a column, optionally qualified by a table or alias.
+func (a *queryAnalysis) column(qualifier, name string) (*schema.Field, bool) {
+	for _, table := range a.tables {
+		if qualifier != "" && !strings.EqualFold(table.name, qualifier) && !strings.EqualFold(table.model.Table(), qualifier) {
+			continue
+		}
+		for _, f := range table.model.GetFields() {
+			if strings.EqualFold(f.Name, name) {
+				return f, table.nullable
+			}
+		}
+	}
+	return nil, false
+}
+
+// columnEndingAt resolves the column reference whose last token is at i.
+func (a *queryAnalysis) columnEndingAt(i int) (*schema.Field, bool) {
+	t := a.tokens
+	if i < 0 || !t[i].isIdent() {
+		return nil, false
+	}
+	if i >= 2 && t[i-1].text == "." && t[i-2].isIdent() {
+		return a.column(t[i-2].text, t[i].text)
+	}
+	return a.column("", t[i].text)
+}
+
+// columnStartingAt resolves the column reference whose first token is at i.
+func (a *queryAnalysis) columnStartingAt(i int) (*schema.Field, bool) {
+	t := a.tokens
+	if i >= len(t) || !t[i].isIdent() {
+		return nil, false
+	}
+	if i+2 < len(t) && t[i+1].text == "." && t[i+2].isIdent() {
+		return a.column(t[i].text, t[i+2].text)
+	}
+	return a.column("", t[i].text)
+}
+
+// insertColumns maps the parameters that are whole values of an INSERT
+// ... VALUES to their column.
+func (a *queryAnalysis) insertColumns() map[int]*schema.Field {
+	t := a.tokens
+	result := make(map[int]*schema.Field)
+	for i := 0; i+1 < len(t); i++ {
+		if t[i].upper() != "INTO" {
+			continue
+		}
+		j := i + 1
+		for j < len(t) && t[j].text != "(" && t[j].upper() != "VALUES" {
+			j++
+		}
+		if j >= len(t) || t[j].text != "(" {
+			continue
+		}
+		depth := t[j].depth + 1
+		var columns []*schema.Field
+		for j++; j < len(t) && t[j].text != ")"; j++ {
+			if t[j].isIdent() && t[j].depth == depth {
+				f, _ := a.column("", t[j].text)
+				columns = append(columns, f)
+			}
+		}
+		for j < len(t) && t[j].upper() != "VALUES" && t[j].upper() != "SELECT" {
+			j++
+		}
+		if j >= len(t) || t[j].upper() != "VALUES" {
+			continue
+		}
+		// Each tuple of VALUES (...), (...)
+		for j++; j < len(t) && t[j].text == "("; j++ {
+			position, start := 0, j+1
+			for j++; j < len(t) && !(t[j].text == ")" && t[j].depth == depth-1); j++ {
+				if t[j].text == "," && t[j].depth == depth {
+					position, start = position+1, j+1
+					continue
+				}
+				if t[j].kind == tokParam && j == start && position < len(columns) &&
+					j+1 < len(t) && (t[j+1].text == "," || t[j+1].text == ")") {
+					result[j] = columns[position]
+				}
+			}
+			if j+1 >= len(t) || t[j+1].text != "," {
+				break
+			}
+			j++
+		}
+	}
+	return result
+}
+
+// paramType infers the Go type of the parameter at i from the column it
+// is inserted into, assigned to or compared with.
+func (a *queryAnalysis) paramType(i int, insertColumns map[int]*schema.Field) string {
+	t := a.tokens
+	if f := insertColumns[i]; f != nil {
+		return goType(f)
+	}
+	prev := func(n int) sqlToken {
+		if i-n < 0 {
+			return sqlToken{}
+		}
+		return t[i-n]
+	}
+	op := strings.ToUpper(prev(1).text)
+
+	switch {
+	case op == "LIMIT" || op == "OFFSET" || op == "TOP" || op == "FETCH":
+		return "int"
+	case comparisons[op]:
+		if f, _ := a.columnEndingAt(i - 2); f != nil {
+			if op == "=" && a.inSet(i) {
+				return goType(f)
+			}
+			return baseType(f)
+		}
+	case op == "(" && prev(2).upper() == "IN":
+		if f, _ := a.columnEndingAt(i - 3); f != nil {
+			return "[]" + baseType(f)
+		}
+	case op == "BETWEEN":
+		if f, _ := a.columnEndingAt(i - 2); f != nil {
+			return baseType(f)
+		}
+	case op == "AND" && prev(2).kind == tokParam && prev(3).upper() == "BETWEEN":
+		if f, _ := a.columnEndingAt(i - 4); f != nil {
+			return baseType(f)
+		}
+	}
+	if i+1 < len(t) && comparisons[strings.ToUpper(t[i+1].text)] {
+		if f, _ := a.columnStartingAt(i + 2); f != nil {
+			return baseType(f)
+		}
+	}
+	return "interface{}"
+}
+
+// inSet reports whether the token at i is in the SET clause of an UPDATE,
+// where parameters may be NULL.
+func (a *queryAnalysis) inSet(i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		if a.tokens[j].depth != a.tokens[i].depth {
+			continue
+		}
+		switch a.tokens[j].upper() {
+		case "SET":
+			return true
+		case "WHERE", "FROM", "ON", "HAVING", "RETURNING", "VALUES", "SELECT":
+			return false
+		}
+	}
+	return false
+}
+
+// resultColumns returns the columns of the first SELECT of the query, or
+// of its RETURNING clause.
+func (a *queryAnalysis) resultColumns() ([]queryField, error) {
+	t := a.tokens
+	start, returning := -1, false
+	for i, tok := range t {
+		if tok.depth != 0 {
+			continue
+		}
+		if tok.upper() == "SELECT" && start < 0 {
+			start = i + 1
+		}
+		if tok.upper() == "RETURNING" {
+			start, returning = i+1, true
+			break
+		}
+	}
+	if start < 0 {
+		return nil, nil
+	}
+	for start < len(t) && (t[start].upper() == "DISTINCT" || t[start].upper() == "ALL") {
+		start++
+	}
+	if start+1 < len(t) && t[start].upper() == "TOP" {
+		start += 2
+	}
+
+	var items [][]sqlToken
+	var item []sqlToken
+	for i := start; i < len(t); i++ {
+		if t[i].depth == 0 && !returning {
+			switch t[i].upper() {
+			case "FROM", "INTO", "WHERE", "GROUP", "ORDER", "LIMIT", "UNION", "EXCEPT", "INTERSECT", "HAVING":
+				i = len(t)
+				continue
+			}
+		}
+		if t[i].depth == 0 && t[i].text == "," {
+			items = append(items, item)
+			item = nil
+			continue
+		}
+		item = append(item, t[i])
+	}
+	if len(item) > 0 {
+		items = append(items, item)
+	}
+
+	var columns []queryField
+	for n, item := range items {
+		fields, err := a.resultItem(item)
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %w", n+1, err)
+		}
+		columns = append(columns, fields...)
+	}
+	return columns, nil
+}
+
+// resultItem returns the columns of an item of a select list.
+func (a *queryAnalysis) resultItem(item []sqlToken) ([]queryField, error) {
+	n := len(item)
+	// * and t.*
+	if n == 1 && item[0].text == "*" || n == 3 && item[1].text == "." && item[2].text == "*" {
+		var columns []queryField
+		for _, table := range a.tables {
+			if n == 3 && !strings.EqualFold(table.name, item[0].text) && !strings.EqualFold(table.model.Table(), item[0].text) {
+				continue
+			}
+			for _, f := range table.model.GetFields() {
+				columns = append(columns, resultField(f.Name, f, table.nullable))
+			}
+		}
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("* of a table that is not in the schema; list the columns")
+		}
+		return columns, nil
+	}
+
+	alias := ""
+	switch {
+	case n >= 3 && item[n-2].upper() == "AS":
+		alias, item = item[n-1].text, item[:n-2]
+	case n >= 2 && item[n-1].isIdent() && item[n-1].upper() != "END" &&
+		(item[n-2].kind == tokWord || item[n-2].kind == tokQuoted || item[n-2].text == ")"):
+		alias, item = item[n-1].text, item[:n-1]
+	}
+	n = len(item)
+
+	// A column
+	if n == 1 || n == 3 && item[1].text == "." {
+		if f, nullable := a.column(qualifierOf(item), item[n-1].text); f != nil {
+			if alias == "" {
+				alias = item[n-1].text
+			}
+			return []queryField{resultField(alias, f, nullable)}, nil
+		}
+		if alias == "" && item[n-1].isIdent() {
+			alias = item[n-1].text
+		}
+	}
+	if alias == "" {
+		return nil, fmt.Errorf("expression has no name; add AS name")
+	}
+
+	goType := "interface{}"
+	if n >= 2 && item[1].text == "(" {
+		switch item[0].upper() {
+		case "COUNT":
+			goType = "int64"
+		case "EXISTS":
+			goType = "bool"
+		case "SUM", "AVG":
+			goType = "*float64"
+		case "MIN", "MAX":
+			// MIN(column) and MAX(column) are NULL without rows
+			inner := item[2 : n-1]
+			if len(inner) == 1 || len(inner) == 3 && inner[1].text == "." {
+				if f, _ := a.column(qualifierOf(inner), inner[len(inner)-1].text); f != nil {
+					goType = "*" + baseType(f)
+				}
+			}
+		}
+	}
+	return []queryField{{Name: alias, GoName: goFieldName(alias), GoType: goType}}, nil
+}
+
+// qualifierOf returns the table of a column reference of one or three
+// tokens.
+func qualifierOf(ref []sqlToken) string {
+	if len(ref) == 3 {
+		return ref[0].text
+	}
+	return ""
+}
+
+// resultField returns the result column name holding field f.
+func resultField(name string, f *schema.Field, nullable bool) queryField {
+	goType := goType(f)
+	if nullable && !strings.HasPrefix(goType, "*") {
+		goType = "*" + goType
+	}
+	return queryField{Name: name, GoName: goFieldName(name), GoType: goType}
+}
+
+// baseType returns the Go type of a field without the pointer of NULL.
+func baseType(f *schema.Field) string {
+	return strings.TrimPrefix(goType(f), "*")
+}
@@ -0,0 +1,340 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// QueryKind is what a generated query function returns.
+type QueryKind string
+
+const (
+	QueryOne      QueryKind = ":one"      // The first row, or sql.ErrNoRows
+	QueryMany     QueryKind = ":many"     // All rows
+	QueryExec     QueryKind = ":exec"     // Only an error
+	QueryExecRows QueryKind = ":execrows" // The number of rows affected
+)
+
+// SQLQuery is an annotated query of a .sql file:
+//
+//	-- name: GetUserByEmail :one
+//	-- GetUserByEmail finds a user by email address.
+//	SELECT id, name FROM users WHERE email = :email;
+//
+// Comment lines right after the name line document the function.
+type SQLQuery struct {
+	Name   string
+	Kind   QueryKind
+	SQL    string
+	Doc    []string // Comment lines after the name line, without --
+	Source string   // File and line of the name line
+}
+
+// Params of more than maxQueryArgs parameters are passed as a struct.
+const maxQueryArgs = 3
+
+var (
+	queryName  = regexp.MustCompile(`^--\s*name:\s*(\S+)\s+(\S+)\s*$`)
+	goFuncName = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+)
+
+// ParseQueryFiles reads the annotated queries of the .sql files in dir, in
+// file name order.
+func ParseQueryFiles(dir string) ([]*SQLQuery, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var queries []*SQLQuery
+	names := make(map[string]string)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := ParseQueries(filepath.Base(file), string(content))
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range parsed {
+			if other, ok := names[q.Name]; ok {
+				return nil, fmt.Errorf("%s: query %s is already defined at %s", q.Source, q.Name, other)
+			}
+			names[q.Name] = q.Source
+		}
+		queries = append(queries, parsed...)
+	}
+	return queries, nil
+}
+
+// ParseQueries reads the annotated queries of a .sql file. SQL before the
+// first name line is ignored.
+func ParseQueries(filename, content string) ([]*SQLQuery, error) {
+	var queries []*SQLQuery
+	var body []string
+	var current *SQLQuery
+	inDoc := false
+
+	flush := func() {
+		if current != nil {
+			current.SQL = strings.TrimSuffix(strings.TrimSpace(strings.Join(body, "\n")), ";")
+			current.SQL = strings.TrimSpace(current.SQL)
+			queries = append(queries, current)
+		}
+		body = nil
+	}
+	for i, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if match := queryName.FindStringSubmatch(trimmed); match != nil {
+			flush()
+			source := fmt.Sprintf("%s:%d", filename, i+1)
+			kind := QueryKind(strings.ToLower(match[2]))
+			switch kind {
+			case QueryOne, QueryMany, QueryExec, QueryExecRows:
+			default:
+				return nil, fmt.Errorf("%s: unknown query kind %s (expected :one, :many, :exec or :execrows)", source, match[2])
+			}
+			if !goFuncName.MatchString(match[1]) {
+				return nil, fmt.Errorf("%s: query name %s must be an exported Go name, like GetUser", source, match[1])
+			}
+			current = &SQLQuery{Name: match[1], Kind: kind, Source: source}
+			inDoc = true
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if inDoc && strings.HasPrefix(trimmed, "--") {
+			current.Doc = append(current.Doc, strings.TrimSpace(strings.TrimPrefix(trimmed, "--")))
+			continue
+		}
+		inDoc = false
+		body = append(body, line)
+	}
+	flush()
+
+	for _, q := range queries {
+		if q.SQL == "" {
+			return nil, fmt.Errorf("%s: query %s has no SQL", q.Source, q.Name)
+		}
+	}
+	return queries, nil
+}
+
+// queryView is a query ready to generate, with the types of its
+// parameters and columns.
+type queryView struct {
+	*SQLQuery
+	Params  []queryField
+	Columns []queryField
+}
+
+// queryField is a parameter or result column of a query.
+type queryField struct {
+	Name   string // :name of the parameter, or the column name
+	GoName string // Field name
+	GoType string
+	Arg    string // Argument name of a parameter
+}
+
+// ParamsStruct reports whether the parameters are passed as a struct.
+func (q queryView) ParamsStruct() bool {
+	return len(q.Params) > maxQueryArgs
+}
+
+// ConstName returns the name of the constant holding the SQL.
+func (q queryView) ConstName() string {
+	return lowerFirst(q.Name) + "SQL"
+}
+
+// Literal returns the SQL as a Go string literal.
+func (q queryView) Literal() string {
+	return goString(q.SQL)
+}
+
+// Result returns the result types of the function.
+func (q queryView) Result() string {
+	switch q.Kind {
+	case QueryOne:
+		return "(*" + q.Name + "Row, error)"
+	case QueryMany:
+		return "([]" + q.Name + "Row, error)"
+	case QueryExecRows:
+		return "(int64, error)"
+	}
+	return "error"
+}
+
+// Zero returns what the function returns with an error.
+func (q queryView) Zero() string {
+	switch q.Kind {
+	case QueryOne, QueryMany:
+		return "nil, "
+	case QueryExecRows:
+		return "0, "
+	}
+	return ""
+}
+
+// HasRows reports whether the query returns rows.
+func (q queryView) HasRows() bool {
+	return q.Kind == QueryOne || q.Kind == QueryMany
+}
+
+// reservedArgs are names the generated functions use themselves.
+var reservedArgs = map[string]bool{"ctx": true, "conn": true, "q": true, "err": true, "row": true, "rows": true, "result": true, "arg": true}
+
+// GenerateQueries generates sql_queries.go with a function per query,
+// which runs it through a *dialects.Connection with typed parameters and
+// scans its rows into a generated struct. Parameter and column types are
+// inferred from the columns of the schema they are compared with, inserted
+// into or selected from; the rest are interface{}.
+func (g *Generator) GenerateQueries(queries []*SQLQuery) error {
+	var views []queryView
+	imports := map[string]bool{"context": true, "github.com/nexus-db/nexus/pkg/dialects": true, "github.com/nexus-db/nexus/pkg/query": true}
+	for _, q := range queries {
+		view, err := analyzeQuery(g.schema, q)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", q.Source, q.Name, err)
+		}
+		for _, f := range append(append([]queryField{}, view.Params...), view.Columns...) {
+			if strings.Contains(f.GoType, "time.") {
+				imports["time"] = true
+			}
+			if strings.Contains(f.GoType, "json.") {
+				imports["encoding/json"] = true
+			}
+		}
+		views = append(views, view)
+	}
+	var std, nexus []string
+	for path := range imports {
+		if strings.Contains(path, ".") {
+			nexus = append(nexus, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(nexus)
+
+	tmpl := `// Code generated by Nexus from annotated SQL. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+{{- range .Std}}
+	"{{.}}"
+{{- end}}
+{{range .Nexus}}
+	"{{.}}"
+{{- end}}
+)
+{{range .Queries}}
+// {{.ConstName}} is query {{.Name}} of {{.Source}}.
+const {{.ConstName}} = {{.Literal}}
+{{if .HasRows}}
+// {{.Name}}Row is a row of {{.Name}}.
+type {{.Name}}Row struct {
+{{- range .Columns}}
+	{{.GoName}} {{.GoType}} ` + "`" + `json:"{{.Name}}" db:"{{.Name}}"` + "`" + `
+{{- end}}
+}
+{{end}}
+{{- if .ParamsStruct}}
+// {{.Name}}Params are the parameters of {{.Name}}.
+type {{.Name}}Params struct {
+{{- range .Params}}
+	{{.GoName}} {{.GoType}} ` + "`" + `db:"{{.Name}}"` + "`" + `
+{{- end}}
+}
+{{end}}
+{{- if .Doc}}
+{{- range .Doc}}
+// {{.}}
+{{- end}}
+{{- else}}
+// {{.Name}} runs query {{.Name}} of {{.Source}}.
+{{- end}}
+func {{.Name}}(ctx context.Context, conn *dialects.Connection
+{{- if .ParamsStruct}}, arg {{.Name}}Params{{else}}{{range .Params}}, {{.Arg}} {{.GoType}}{{end}}{{end}}) {{.Result}} {
+	q, err := query.NewNamedQuery(conn, {{.ConstName}},
+	{{- if .ParamsStruct}} arg
+	{{- else if .Params}} map[string]interface{}{ {{- range $i, $p := .Params}}{{if $i}}, {{end}}"{{$p.Name}}": {{$p.Arg}}{{end -}} }
+	{{- else}} nil{{end}})
+	if err != nil {
+		return {{.Zero}}err
+	}
+{{- if eq .Kind ":one"}}
+	var row {{.Name}}Row
+	if err := q.ScanOne(ctx, &row); err != nil {
+		return nil, err
+	}
+	return &row, nil
+{{- else if eq .Kind ":many"}}
+	var rows []{{.Name}}Row
+	if err := q.ScanAll(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+{{- else if eq .Kind ":execrows"}}
+	result, err := q.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+{{- else}}
+	_, err = q.Exec(ctx)
+	return err
+{{- end}}
+}
+{{end}}`
+
+	t, err := template.New("sqlqueries").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	data := struct {
+		PackageName string
+		Std, Nexus  []string
+		Queries     []queryView
+	}{
+		PackageName: g.packageName,
+		Std:         std,
+		Nexus:       nexus,
+		Queries:     views,
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		// If formatting fails, write unformatted
+		formatted = buf.Bytes()
+	}
+
+	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(g.outputDir, "sql_queries.go"), formatted, 0644)
+}
+
+// argName returns the Go argument name of a parameter.
+func argName(param string) string {
+	name := lowerFirst(goFieldName(param))
+	if token.IsKeyword(name) || reservedArgs[name] {
+		name += "Param"
+	}
+	return name
+}
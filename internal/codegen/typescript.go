@@ -0,0 +1,239 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// tsHeader starts every generated TypeScript file.
+const tsHeader = "// Code generated by Nexus. DO NOT EDIT.\n"
+
+// GenerateTypeScript generates TypeScript types for the JSON the generated
+// Go models, handlers and GraphQL API exchange (models.ts), and with zod
+// also zod schemas validating it (schemas.ts).
+func (g *Generator) GenerateTypeScript(zod bool) error {
+	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(g.outputDir, "models.ts"), []byte(g.TypeScript()), 0644); err != nil {
+		return err
+	}
+	if !zod {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(g.outputDir, "schemas.ts"), []byte(g.ZodSchemas()), 0644)
+}
+
+// TypeScript returns TypeScript declarations for the schema: a union type
+// per enum and, per model, an interface for records and for create and
+// update inputs. Relations are optional properties, present when loaded.
+func (g *Generator) TypeScript() string {
+	var sb strings.Builder
+	sb.WriteString(tsHeader)
+
+	for _, e := range g.schema.GetEnums() {
+		fmt.Fprintf(&sb, "\nexport const %s = {\n", e.Name)
+		for _, v := range e.Values {
+			fmt.Fprintf(&sb, "  %s: %s,\n", v, strconv.Quote(v))
+		}
+		sb.WriteString("} as const;\n")
+		fmt.Fprintf(&sb, "export type %s = (typeof %s)[keyof typeof %s];\n", e.Name, e.Name, e.Name)
+	}
+
+	for _, m := range g.gqlModels() {
+		model := g.schema.Models[m.Name]
+
+		doc := fmt.Sprintf("A row of the %s table.", model.Table())
+		if model.Description != "" {
+			doc = jsDoc(model.Description)
+		}
+		fmt.Fprintf(&sb, "\n/** %s */\n", doc)
+		fmt.Fprintf(&sb, "export interface %s {\n", m.Name)
+		for _, f := range model.GetFields() {
+			if f.Description != "" {
+				fmt.Fprintf(&sb, "  /** %s */\n", jsDoc(f.Description))
+			}
+			fmt.Fprintf(&sb, "  %s: %s;\n", f.Name, tsType(f))
+		}
+		for i, rel := range g.relations(model) {
+			typ := rel.Target + " | null"
+			if rel.Many {
+				typ = rel.Target + "[]"
+			}
+			fmt.Fprintf(&sb, "  %s?: %s;\n", m.Relations[i].Field, typ)
+		}
+		sb.WriteString("}\n")
+
+		if !m.HasPK {
+			continue
+		}
+		fmt.Fprintf(&sb, "\nexport interface %sCreateInput {\n", m.Name)
+		for _, f := range m.Fields {
+			optional := "?"
+			if f.Required {
+				optional = ""
+			}
+			fmt.Fprintf(&sb, "  %s%s: %s;\n", f.Column, optional, tsType(model.Fields[f.Column]))
+		}
+		sb.WriteString("}\n")
+		fmt.Fprintf(&sb, "\nexport type %sUpdateInput = Partial<%sCreateInput>;\n", m.Name, m.Name)
+	}
+	return sb.String()
+}
+
+// ZodSchemas returns zod schemas matching the declarations of TypeScript,
+// which schemas.ts imports from ./models.
+func (g *Generator) ZodSchemas() string {
+	models := g.gqlModels()
+
+	var sb strings.Builder
+	sb.WriteString(tsHeader)
+	sb.WriteString("import { z } from \"zod\";\n")
+	var imports []string
+	for _, m := range models {
+		imports = append(imports, m.Name)
+	}
+	if len(imports) > 0 {
+		fmt.Fprintf(&sb, "import type { %s } from \"./models\";\n", strings.Join(imports, ", "))
+	}
+
+	for _, e := range g.schema.GetEnums() {
+		values := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			values[i] = strconv.Quote(v)
+		}
+		fmt.Fprintf(&sb, "\nexport const %sSchema = z.enum([%s]);\n", e.Name, strings.Join(values, ", "))
+	}
+
+	for _, m := range models {
+		model := g.schema.Models[m.Name]
+
+		// Relations refer to each other, so records are typed and lazy
+		fmt.Fprintf(&sb, "\nexport const %sSchema: z.ZodType<%s> = z.object({\n", m.Name, m.Name)
+		for _, f := range model.GetFields() {
+			fmt.Fprintf(&sb, "  %s: %s,\n", f.Name, zodType(f, false))
+		}
+		for i, rel := range g.relations(model) {
+			target := "z.lazy(() => " + rel.Target + "Schema)"
+			if rel.Many {
+				target = "z.array(" + target + ")"
+			} else {
+				target += ".nullable()"
+			}
+			fmt.Fprintf(&sb, "  %s: %s.optional(),\n", m.Relations[i].Field, target)
+		}
+		sb.WriteString("});\n")
+
+		if !m.HasPK {
+			continue
+		}
+		fmt.Fprintf(&sb, "\nexport const %sCreateInputSchema = z.object({\n", m.Name)
+		for _, f := range m.Fields {
+			fmt.Fprintf(&sb, "  %s: %s,\n", f.Column, zodType(model.Fields[f.Column], !f.Required))
+		}
+		sb.WriteString("}).strict();\n")
+		fmt.Fprintf(&sb, "\nexport const %sUpdateInputSchema = %sCreateInputSchema.partial();\n", m.Name, m.Name)
+	}
+	return sb.String()
+}
+
+// jsDoc returns text for a single-line JSDoc comment, which it must not
+// end.
+func jsDoc(text string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "*/", "* /")
+}
+
+// tsType returns the TypeScript type of a column as the Go models encode
+// it: times as RFC 3339 strings and bytes as base64 strings.
+func tsType(f *schema.Field) string {
+	var t string
+	switch {
+	case f.Enum != nil:
+		t = f.Enum.Name
+	case f.Type == schema.FieldTypeJSON:
+		return "unknown" // Includes null
+	default:
+		switch f.Type {
+		case schema.FieldTypeInt, schema.FieldTypeBigInt, schema.FieldTypeSmallInt, schema.FieldTypeTinyInt,
+			schema.FieldTypeFloat, schema.FieldTypeDecimal, schema.FieldTypeMoney:
+			t = "number"
+		case schema.FieldTypeBool:
+			t = "boolean"
+		default:
+			t = "string"
+		}
+	}
+	if f.Nullable {
+		t += " | null"
+	}
+	return t
+}
+
+// zodType returns the zod schema of a column; optional fields of inputs
+// may be left out.
+func zodType(f *schema.Field, optional bool) string {
+	var t string
+	switch {
+	case f.Enum != nil:
+		t = f.Enum.Name + "Schema"
+	case f.Type == schema.FieldTypeJSON:
+		t = "z.unknown()"
+	default:
+		switch f.Type {
+		case schema.FieldTypeInt, schema.FieldTypeBigInt, schema.FieldTypeSmallInt, schema.FieldTypeTinyInt:
+			t = "z.number().int()"
+			if f.IsUnsigned {
+				t += ".nonnegative()"
+			}
+		case schema.FieldTypeFloat, schema.FieldTypeDecimal, schema.FieldTypeMoney:
+			t = "z.number()"
+		case schema.FieldTypeBool:
+			t = "z.boolean()"
+		case schema.FieldTypeString:
+			length := f.Length
+			if length == 0 {
+				length = 255
+			}
+			t = fmt.Sprintf("z.string().max(%d)", length)
+		case schema.FieldTypeUUID:
+			t = "z.string().uuid()"
+		case schema.FieldTypeDateTime, schema.FieldTypeDate, schema.FieldTypeTime:
+			t = "z.string().datetime({ offset: true })"
+		case schema.FieldTypeBytes:
+			t = "z.string().base64()"
+		default:
+			t = "z.string()"
+		}
+		t += zodRules(f)
+	}
+	if f.Nullable && f.Type != schema.FieldTypeJSON {
+		t += ".nullable()"
+	}
+	if optional {
+		t += ".optional()"
+	}
+	return t
+}
+
+// zodRules returns the zod refinements of the validation rules of f.
+func zodRules(f *schema.Field) string {
+	var rules string
+	if f.IsEmail {
+		rules += ".email()"
+	}
+	if f.MinValue != nil {
+		rules += ".min(" + strconv.FormatFloat(*f.MinValue, 'g', -1, 64) + ")"
+	}
+	if f.MaxValue != nil {
+		rules += ".max(" + strconv.FormatFloat(*f.MaxValue, 'g', -1, 64) + ")"
+	}
+	if f.Pattern != "" {
+		rules += ".regex(new RegExp(" + strconv.Quote(f.Pattern) + "))"
+	}
+	return rules
+}
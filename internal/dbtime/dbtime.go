@@ -0,0 +1,54 @@
+// Package dbtime reads and writes times the same way in every database,
+// SQLite included, which stores them as text.
+package dbtime
+
+import (
+	"fmt"
+	"time"
+)
+
+// Now returns the current time in UTC, to microseconds, so that times
+// compare the same way in every database; SQLite compares them as text.
+func Now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// Time scans a nullable time, which SQLite returns as text. A NULL scans
+// as the zero time.
+type Time struct {
+	time.Time
+}
+
+// layouts are the text forms of times written by the SQLite driver.
+var layouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+}
+
+// Scan implements sql.Scanner.
+func (t *Time) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	case time.Time:
+		t.Time = v.UTC()
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	}
+	return fmt.Errorf("cannot scan %T into a time", value)
+}
+
+func (t *Time) parse(s string) error {
+	for _, layout := range layouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed.UTC()
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse time %q", s)
+}
@@ -0,0 +1,431 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// document is a parsed schema file.
+type document struct {
+	path  string
+	src   string
+	lines []string
+	file  *schema.File
+}
+
+// source returns the text of path: the open buffer, or the file on disk.
+func (s *Server) source(path string) (string, bool) {
+	if src, ok := s.docs[path]; ok {
+		return src, true
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func (s *Server) parse(path string) *document {
+	src, ok := s.source(path)
+	if !ok {
+		return nil
+	}
+	f, _ := schema.NewParser(src).ParseAST()
+	return &document{path: path, src: src, lines: strings.Split(src, "\n"), file: f}
+}
+
+// workspace parses the files of the schema at root, following imports.
+func (s *Server) workspace(root string) map[string]*document {
+	docs := make(map[string]*document)
+
+	files, err := schema.SchemaFiles(root)
+	if err != nil {
+		files = []string{root}
+	}
+
+	var visit func(path string)
+	visit = func(path string) {
+		path, _ = filepath.Abs(path)
+		if _, seen := docs[path]; seen {
+			return
+		}
+		doc := s.parse(path)
+		if doc == nil {
+			return
+		}
+		docs[path] = doc
+
+		for _, decl := range doc.file.Decls {
+			imp, ok := decl.(*schema.ImportDecl)
+			if !ok {
+				continue
+			}
+			matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), imp.Path.Value))
+			for _, match := range matches {
+				visit(match)
+			}
+		}
+	}
+	for _, file := range files {
+		visit(file)
+	}
+	return docs
+}
+
+// rootFor returns the schema path a document is checked with: the
+// configured schema if the document is part of it, else the document.
+func (s *Server) rootFor(path string) string {
+	if s.schemaPath != "" {
+		if _, ok := s.workspace(s.schemaPath)[path]; ok {
+			return s.schemaPath
+		}
+	}
+	return path
+}
+
+// check parses and validates the schema containing path and publishes
+// diagnostics for every file in it.
+func (s *Server) check(path string) {
+	root := s.rootFor(path)
+	ws := s.workspace(root)
+
+	// The checked document is always published, so fixed errors clear
+	diags := map[string][]Diagnostic{path: nil}
+	sch, err := schema.ParseFileOverlay(root, s.docs)
+	if err == nil {
+		err = sch.Validate()
+		if err != nil {
+			s.validationDiagnostics(err, path, ws, diags)
+		}
+	} else {
+		for _, d := range schema.Diagnostics(err) {
+			file := path
+			if d.File != "" {
+				file, _ = filepath.Abs(d.File)
+			}
+			var lines []string
+			if doc, ok := ws[file]; ok {
+				lines = doc.lines
+			}
+			diags[file] = append(diags[file], Diagnostic{
+				Range:    diagnosticRange(lines, d),
+				Severity: severityError,
+				Code:     d.Code,
+				Source:   "nexus",
+				Message:  diagnosticMessage(d),
+			})
+		}
+	}
+
+	for file, list := range diags {
+		s.publish(file, list)
+	}
+	for file := range s.published {
+		if _, ok := diags[file]; !ok {
+			s.publish(file, nil)
+		}
+	}
+}
+
+func (s *Server) publish(file string, diags []Diagnostic) {
+	if len(diags) == 0 {
+		diags = []Diagnostic{}
+		delete(s.published, file)
+	} else {
+		s.published[file] = true
+	}
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         pathToURI(file),
+		Diagnostics: diags,
+	})
+}
+
+var validationModel = regexp.MustCompile(`model "(\w+)"`)
+
+// validationDiagnostics reports Validate errors, which have no position,
+// at the model they mention or at the start of the document.
+func (s *Server) validationDiagnostics(err error, path string, ws map[string]*document, diags map[string][]Diagnostic) {
+	for _, line := range strings.Split(err.Error(), "\n") {
+		msg, ok := strings.CutPrefix(strings.TrimSpace(line), "- ")
+		if !ok {
+			continue
+		}
+
+		file, rng := path, Range{}
+		if m := validationModel.FindStringSubmatch(msg); m != nil {
+			if doc, ident := lookupDecl(ws, m[1]); ident != nil {
+				file, rng = doc.path, identRange(doc.lines, ident)
+			}
+		}
+		diags[file] = append(diags[file], Diagnostic{
+			Range:    rng,
+			Severity: severityError,
+			Code:     "SCHEMA_VALIDATION",
+			Source:   "nexus",
+			Message:  msg,
+		})
+	}
+}
+
+func diagnosticMessage(d schema.Diagnostic) string {
+	if d.Suggestion == "" {
+		return d.Message
+	}
+	return d.Message + "\n" + d.Suggestion
+}
+
+func diagnosticRange(lines []string, d schema.Diagnostic) Range {
+	if d.Line == 0 {
+		return Range{}
+	}
+	return Range{
+		Start: lspPosition(lines, d.Line, d.Column),
+		End:   lspPosition(lines, d.EndLine, d.EndColumn),
+	}
+}
+
+// definition finds the declaration of the model, enum, field or enum value
+// under the cursor.
+func (s *Server) definition(path string, pos Position) *Location {
+	ws := s.workspace(s.rootFor(path))
+	doc, ok := ws[path]
+	if !ok {
+		if doc = s.parse(path); doc == nil {
+			return nil
+		}
+		ws[path] = doc
+	}
+
+	at := parserPos(doc.lines, pos)
+	model, field := enclosing(doc.file, at)
+	ident := identAt(doc, at)
+	if ident == nil {
+		return nil
+	}
+
+	target, targetIdent := doc, (*schema.Ident)(nil)
+	switch {
+	case field != nil && contains(field.Type, at):
+		target, targetIdent = lookupDecl(ws, field.Type.Name)
+
+	case field != nil:
+		for _, attr := range field.Attributes {
+			if !contains(attr, at) {
+				continue
+			}
+			switch attr.Name {
+			case "relation":
+				for _, arg := range attr.Args {
+					if !contains(arg, at) {
+						continue
+					}
+					switch arg.Name {
+					case "fields":
+						targetIdent = fieldIdent(model, ident.Name)
+					case "references":
+						var decl *schema.ModelDecl
+						target, decl = lookupModel(ws, field.Type.Name)
+						targetIdent = fieldIdent(decl, ident.Name)
+					}
+				}
+			case "default":
+				if target, enum := lookupEnum(ws, field.Type.Name); enum != nil {
+					for _, value := range enum.Values {
+						if value.Name == ident.Name {
+							return location(target, value)
+						}
+					}
+				}
+			}
+		}
+
+	case model != nil:
+		// Fields listed in @@index and @@unique
+		for _, attr := range model.Attributes {
+			if contains(attr, at) {
+				targetIdent = fieldIdent(model, ident.Name)
+			}
+		}
+	}
+
+	if targetIdent == nil {
+		target, targetIdent = lookupDecl(ws, ident.Name)
+	}
+	if targetIdent == nil {
+		return nil
+	}
+	return location(target, targetIdent)
+}
+
+func location(doc *document, ident *schema.Ident) *Location {
+	return &Location{URI: pathToURI(doc.path), Range: identRange(doc.lines, ident)}
+}
+
+// lookupDecl finds the model or enum called name, returning its name.
+func lookupDecl(ws map[string]*document, name string) (*document, *schema.Ident) {
+	for _, doc := range sortedDocs(ws) {
+		switch d := doc.file.Lookup(name).(type) {
+		case *schema.ModelDecl:
+			return doc, d.Name
+		case *schema.EnumDecl:
+			return doc, d.Name
+		}
+	}
+	return nil, nil
+}
+
+func lookupModel(ws map[string]*document, name string) (*document, *schema.ModelDecl) {
+	for _, doc := range sortedDocs(ws) {
+		if m, ok := doc.file.Lookup(name).(*schema.ModelDecl); ok {
+			return doc, m
+		}
+	}
+	return nil, nil
+}
+
+func lookupEnum(ws map[string]*document, name string) (*document, *schema.EnumDecl) {
+	for _, doc := range sortedDocs(ws) {
+		if e, ok := doc.file.Lookup(name).(*schema.EnumDecl); ok {
+			return doc, e
+		}
+	}
+	return nil, nil
+}
+
+// sortedDocs returns the documents in path order, so lookups are stable.
+func sortedDocs(ws map[string]*document) []*document {
+	docs := make([]*document, 0, len(ws))
+	for _, doc := range ws {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].path < docs[j].path })
+	return docs
+}
+
+func fieldIdent(model *schema.ModelDecl, name string) *schema.Ident {
+	if model == nil {
+		return nil
+	}
+	for _, f := range model.Fields {
+		if f.Name.Name == name {
+			return f.Name
+		}
+	}
+	return nil
+}
+
+// enclosing returns the model and field declarations containing pos.
+func enclosing(f *schema.File, pos schema.Pos) (*schema.ModelDecl, *schema.FieldDecl) {
+	for _, decl := range f.Decls {
+		model, ok := decl.(*schema.ModelDecl)
+		if !ok || !contains(model, pos) {
+			continue
+		}
+		for _, field := range model.Fields {
+			if contains(field, pos) {
+				return model, field
+			}
+		}
+		return model, nil
+	}
+	return nil, nil
+}
+
+// identAt returns the identifier token under pos, including a cursor
+// placed right after it.
+func identAt(doc *document, pos schema.Pos) *schema.Ident {
+	for _, tok := range schema.Tokenize(doc.src) {
+		if tok.Kind == schema.TokenIdent && tok.Pos.Line == pos.Line &&
+			tok.Pos.Column <= pos.Column && pos.Column <= tok.End.Column {
+			return &schema.Ident{Name: tok.Text, Pos: tok.Pos, End: tok.End}
+		}
+	}
+	return nil
+}
+
+type spanner interface {
+	Span() (schema.Pos, schema.Pos)
+}
+
+// contains reports whether pos lies within n, including its end.
+func contains(n spanner, pos schema.Pos) bool {
+	start, end := n.Span()
+	if end.Line == 0 {
+		end = schema.Pos{Line: 1 << 30}
+	}
+	if pos.Line < start.Line || pos.Line > end.Line {
+		return false
+	}
+	if pos.Line == start.Line && pos.Column < start.Column {
+		return false
+	}
+	if pos.Line == end.Line && pos.Column > end.Column {
+		return false
+	}
+	return true
+}
+
+func identRange(lines []string, ident *schema.Ident) Range {
+	return Range{
+		Start: lspPosition(lines, ident.Pos.Line, ident.Pos.Column),
+		End:   lspPosition(lines, ident.End.Line, ident.End.Column),
+	}
+}
+
+// lspPosition converts a 1-based line and rune column to an LSP position,
+// which counts UTF-16 code units.
+func lspPosition(lines []string, line, column int) Position {
+	pos := Position{Line: line - 1, Character: column - 1}
+	if line < 1 || line > len(lines) {
+		return pos
+	}
+	units, runes := 0, 0
+	for _, r := range lines[line-1] {
+		if runes == column-1 {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		runes++
+	}
+	pos.Character = units + (column - 1 - runes)
+	return pos
+}
+
+// parserPos converts an LSP position to a parser position.
+func parserPos(lines []string, pos Position) schema.Pos {
+	p := schema.Pos{Line: pos.Line + 1, Column: pos.Character + 1}
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return p
+	}
+	units, runes := 0, 0
+	for _, r := range lines[pos.Line] {
+		if units >= pos.Character {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		runes++
+	}
+	p.Column = runes + 1
+	return p
+}
+
+// linePrefix returns the text of the line before the cursor.
+func linePrefix(lines []string, pos schema.Pos) string {
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return ""
+	}
+	line := lines[pos.Line-1]
+	offset := 0
+	for i := 1; i < pos.Column && offset < len(line); i++ {
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
+	}
+	return line[:offset]
+}
@@ -0,0 +1,113 @@
+package lsp
+
+import (
+	"regexp"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+var (
+	completeModelAttr = regexp.MustCompile(`@@\w*$`)
+	completeFieldAttr = regexp.MustCompile(`@\w*$`)
+	completeRelation  = regexp.MustCompile(`@relation\([^)]*$`)
+	completeAction    = regexp.MustCompile(`on(Delete|Update)\s*:\s*\w*$`)
+	completeDefault   = regexp.MustCompile(`^\s*\w+\s+(\w+)\??.*@default\(\w*$`)
+	completeType      = regexp.MustCompile(`^\s*\w+\s+\w*$`)
+	completeKeyword   = regexp.MustCompile(`^\w*$`)
+)
+
+// completion proposes attributes, relation arguments, default values,
+// field types and top-level keywords depending on the text before the
+// cursor.
+func (s *Server) completion(path string, pos Position) []CompletionItem {
+	ws := s.workspace(s.rootFor(path))
+	doc, ok := ws[path]
+	if !ok {
+		if doc = s.parse(path); doc == nil {
+			return []CompletionItem{}
+		}
+		ws[path] = doc
+	}
+
+	at := parserPos(doc.lines, pos)
+	prefix := linePrefix(doc.lines, at)
+	model, _ := enclosing(doc.file, at)
+
+	var items []CompletionItem
+	switch {
+	case completeModelAttr.MatchString(prefix):
+		items = entryItems(modelAttributeDocs, kindFunction)
+
+	case completeRelation.MatchString(prefix):
+		if completeAction.MatchString(prefix) {
+			for _, action := range cascadeActions {
+				items = append(items, CompletionItem{Label: action, Kind: kindValue})
+			}
+		} else {
+			items = entryItems(relationArgDocs, kindProperty)
+			for i := range items {
+				items[i].InsertText = items[i].Label + ": "
+			}
+		}
+
+	case completeDefault.MatchString(prefix):
+		typ := completeDefault.FindStringSubmatch(prefix)[1]
+		if _, enum := lookupEnum(ws, typ); enum != nil {
+			for _, value := range enum.Values {
+				items = append(items, CompletionItem{Label: value.Name, Kind: kindValue, Detail: typ})
+			}
+			break
+		}
+		items = []CompletionItem{
+			{Label: "now()", Kind: kindFunction, Detail: "Current timestamp"},
+			{Label: "uuid()", Kind: kindFunction, Detail: "Generated UUID"},
+			{Label: "true", Kind: kindValue},
+			{Label: "false", Kind: kindValue},
+		}
+
+	case completeFieldAttr.MatchString(prefix):
+		items = entryItems(fieldAttributeDocs, kindFunction)
+
+	case model != nil && completeType.MatchString(prefix):
+		items = entryItems(typeDocs, kindClass)
+		for _, d := range sortedDocs(ws) {
+			for _, decl := range d.file.Decls {
+				switch decl := decl.(type) {
+				case *schema.ModelDecl:
+					items = append(items, CompletionItem{Label: decl.Name.Name, Kind: kindClass, Detail: "model"})
+				case *schema.EnumDecl:
+					items = append(items, CompletionItem{Label: decl.Name.Name, Kind: kindEnum, Detail: "enum"})
+				}
+			}
+		}
+
+	case !insideDecl(doc.file, at) && completeKeyword.MatchString(prefix):
+		items = entryItems(keywordDocs, kindKeyword)
+	}
+
+	if items == nil {
+		items = []CompletionItem{}
+	}
+	return items
+}
+
+func entryItems(entries []entry, kind int) []CompletionItem {
+	items := make([]CompletionItem, len(entries))
+	for i, e := range entries {
+		items[i] = CompletionItem{Label: e.name, Kind: kind, Detail: e.detail, Documentation: markdown(e.doc)}
+	}
+	return items
+}
+
+// insideDecl reports whether pos lies within a model or enum block.
+func insideDecl(f *schema.File, pos schema.Pos) bool {
+	for _, decl := range f.Decls {
+		switch decl.(type) {
+		case *schema.ModelDecl, *schema.EnumDecl:
+			if contains(decl, pos) {
+				return true
+			}
+		}
+	}
+	return false
+}
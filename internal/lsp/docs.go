@@ -0,0 +1,88 @@
+package lsp
+
+// Documentation shown in completion items and hovers. Column types are
+// given for PostgreSQL.
+
+type entry struct {
+	name   string
+	detail string
+	doc    string
+}
+
+var typeDocs = []entry{
+	{"Int", "32-bit integer", "Maps to `INTEGER`."},
+	{"BigInt", "64-bit integer", "Maps to `BIGINT`."},
+	{"SmallInt", "16-bit integer", "Maps to `SMALLINT`."},
+	{"TinyInt", "8-bit integer", "Maps to `TINYINT` on MySQL and SQLite, and to `SMALLINT` elsewhere."},
+	{"String", "Variable-length string", "Maps to `VARCHAR(255)`; change the length with `@length(n)`."},
+	{"Text", "Unbounded text", "Maps to `TEXT`."},
+	{"Bool", "Boolean", "Maps to `BOOLEAN`."},
+	{"Float", "Double-precision float", "Maps to `DOUBLE PRECISION`."},
+	{"Decimal", "Fixed-point number", "Maps to `NUMERIC`; set precision and scale with `@precision(p, s)`."},
+	{"Money", "Amount of money", "Maps to `NUMERIC(19,4)`, or `MONEY` on SQL Server; change the precision with `@precision(p, s)`."},
+	{"DateTime", "Timestamp", "Maps to `TIMESTAMP WITH TIME ZONE`. Use `@default(now())` for creation times."},
+	{"Date", "Calendar date", "Maps to `DATE`."},
+	{"Time", "Time of day", "Maps to `TIME`."},
+	{"JSON", "JSON document", "Maps to `JSONB`."},
+	{"Bytes", "Binary data", "Maps to `BYTEA`."},
+	{"UUID", "UUID", "Maps to `UUID`. Use `@default(uuid())` to generate values."},
+}
+
+var fieldAttributeDocs = []entry{
+	{"id", "@id", "Marks the field as the primary key."},
+	{"unique", "@unique", "Adds a unique constraint on the field."},
+	{"autoincrement", "@autoincrement", "Generates increasing values for an integer primary key."},
+	{"unsigned", "@unsigned", "Rejects negative values of an integer column: `UNSIGNED` on MySQL, a `CHECK` elsewhere."},
+	{"default", "@default(value)", "Sets the column default: a literal, an enum value, `now()`, `uuid()` or `random()`, which each database writes in its own SQL. On a `Json` field a string is a JSON document, such as `\"{}\"`."},
+	{"length", "@length(n)", "Sets the length of a `String` column."},
+	{"precision", "@precision(p, s)", "Sets the precision and scale of a `Decimal` column."},
+	{"charset", "@charset(\"utf8mb4\")", "Sets the MySQL character set of a `String`, `Text` or enum column."},
+	{"collate", "@collate(\"und-x-icu\")", "Sets the collation of a `String`, `Text` or enum column, which decides how its values compare and sort."},
+	{"comment", "@comment(\"text\")", "Stores a comment on the column in the database, and repeats it as the doc comment of the generated field."},
+	{"email", "@email", "Rejects writes whose value is not an email address."},
+	{"min", "@min(n)", "Rejects numbers below `n`, or strings shorter than `n` characters."},
+	{"max", "@max(n)", "Rejects numbers above `n`, or strings longer than `n` characters."},
+	{"regex", "@regex(\"pattern\")", "Rejects strings that do not match the Go regular expression `pattern`."},
+	{"pii", "@pii(\"mask\")", "Marks personal data, masked by `nexus db sample`, `nexus db dump --anonymize`, the studio and masked queries. The optional mask is `email`, `name`, `phone`, `hash`, `redact` or `null`."},
+	{"gotype", "@gotype(\"github.com/shopspring/decimal.Decimal\")", "Sets the Go type of the field in generated code and query results, converted with the converter registered by `query.RegisterConverter`."},
+	{"relation", "@relation(fields: [...], references: [...])", "Links the field to another model through a foreign key. Optional arguments: `name`, `onDelete` and `onUpdate`."},
+}
+
+var modelAttributeDocs = []entry{
+	{"index", "@@index([fields], name: \"...\")", "Creates an index on the listed fields."},
+	{"unique", "@@unique([fields])", "Adds a unique constraint across the listed fields."},
+	{"map", "@@map(\"table\")", "Sets the table name of the model."},
+	{"schema", "@@schema(\"billing\")", "Puts the table in a database schema other than the default: a PostgreSQL schema or a MySQL database. Generated SQL qualifies the table with it."},
+	{"charset", "@@charset(\"utf8mb4\")", "Sets the default MySQL character set of the table."},
+	{"collate", "@@collate(\"utf8mb4_unicode_ci\")", "Sets the default collation of the table's text columns: a table option on MySQL, and the collation of each text column without `@collate` elsewhere."},
+	{"comment", "@@comment(\"text\")", "Stores a comment on the table in the database, and repeats it in the doc comment of the generated struct."},
+	{"partition", "@@partition(range: [...], interval: monthly)", "Partitions the table on PostgreSQL and MySQL, by date ranges (`interval`: daily, monthly or yearly) or by `hash: [...]` into `partitions: N`. The primary key includes the partition fields."},
+	{"rls", "@@rls(force: true)", "Enables PostgreSQL row-level security on the table; `force` applies it to the table owner too."},
+	{"policy", "@@policy(\"name\", \"condition\")", "Adds a PostgreSQL row-level security policy and enables row-level security. Optional arguments: `for`, `to` and `check`."},
+}
+
+var relationArgDocs = []entry{
+	{"fields", "fields: [...]", "Foreign key fields on this model."},
+	{"references", "references: [...]", "Referenced fields on the target model."},
+	{"name", "name: \"...\"", "Disambiguates several relations between the same models."},
+	{"onDelete", "onDelete: Action", "Action when the referenced row is deleted."},
+	{"onUpdate", "onUpdate: Action", "Action when the referenced key changes."},
+}
+
+var cascadeActions = []string{"Cascade", "SetNull", "Restrict", "NoAction"}
+
+var keywordDocs = []entry{
+	{"model", "model Name { ... }", "Declares a table."},
+	{"enum", "enum Name { ... }", "Declares a set of named values."},
+	{"import", "import \"file.nexus\"", "Includes declarations from another schema file."},
+	{"include", "include \"dir/*.nexus\"", "Includes declarations from matching schema files."},
+}
+
+func lookupEntry(entries []entry, name string) *entry {
+	for i := range entries {
+		if entries[i].name == name {
+			return &entries[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package lsp
+
+import (
+	"fmt"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// hover describes the attribute, builtin type, model or enum under the
+// cursor.
+func (s *Server) hover(path string, pos Position) *Hover {
+	ws := s.workspace(s.rootFor(path))
+	doc, ok := ws[path]
+	if !ok {
+		if doc = s.parse(path); doc == nil {
+			return nil
+		}
+		ws[path] = doc
+	}
+
+	at := parserPos(doc.lines, pos)
+	tokens := schema.Tokenize(doc.src)
+	for i, tok := range tokens {
+		if tok.Kind != schema.TokenIdent || tok.Pos.Line != at.Line ||
+			at.Column < tok.Pos.Column || at.Column > tok.End.Column {
+			continue
+		}
+
+		rng := identRange(doc.lines, &schema.Ident{Name: tok.Text, Pos: tok.Pos, End: tok.End})
+		var prev schema.TokenKind = schema.TokenEOF
+		if i > 0 {
+			prev = tokens[i-1].Kind
+		}
+
+		var text string
+		switch {
+		case prev == schema.TokenAt:
+			text = entryHover(lookupEntry(fieldAttributeDocs, tok.Text))
+		case prev == schema.TokenAtAt:
+			text = entryHover(lookupEntry(modelAttributeDocs, tok.Text))
+		case lookupEntry(typeDocs, tok.Text) != nil:
+			e := lookupEntry(typeDocs, tok.Text)
+			text = fmt.Sprintf("**%s** — %s\n\n%s", e.name, e.detail, e.doc)
+		default:
+			if target, ident := lookupDecl(ws, tok.Text); ident != nil {
+				text = "```nexus\n" + declSource(target, target.file.Lookup(tok.Text)) + "\n```"
+			}
+		}
+		if text == "" {
+			return nil
+		}
+		return &Hover{Contents: *markdown(text), Range: &rng}
+	}
+	return nil
+}
+
+func entryHover(e *entry) string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("`%s`\n\n%s", e.detail, e.doc)
+}
+
+// declSource returns the source text of a declaration.
+func declSource(doc *document, decl schema.Decl) string {
+	start, end := decl.Span()
+	from, to := start.Offset, end.Offset
+	if to > len(doc.src) || to < from {
+		to = len(doc.src)
+	}
+	return doc.src[from:to]
+}
@@ -0,0 +1,243 @@
+// Package lsp implements a Language Server Protocol server for .nexus
+// schema files: diagnostics, go-to-definition, completion and hover.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is a language server speaking JSON-RPC over a stream, usually
+// the editor's stdin and stdout.
+type Server struct {
+	in         *bufio.Reader
+	out        io.Writer
+	outMu      sync.Mutex
+	log        *log.Logger
+	schemaPath string // Configured schema file or directory, absolute
+
+	docs      map[string]string // Open documents by absolute path
+	published map[string]bool   // Files with published diagnostics
+}
+
+// Config holds the server configuration.
+type Config struct {
+	In  io.Reader
+	Out io.Writer
+	Log io.Writer // Server log; defaults to io.Discard
+
+	// SchemaPath is the project's schema file or directory. Documents that
+	// belong to it are checked together with the rest of the schema; other
+	// .nexus files are checked on their own.
+	SchemaPath string
+}
+
+// NewServer creates a new language server.
+func NewServer(cfg Config) *Server {
+	logOut := cfg.Log
+	if logOut == nil {
+		logOut = io.Discard
+	}
+	s := &Server{
+		in:        bufio.NewReader(cfg.In),
+		out:       cfg.Out,
+		log:       log.New(logOut, "nexus-lsp: ", log.LstdFlags),
+		docs:      make(map[string]string),
+		published: make(map[string]bool),
+	}
+	if cfg.SchemaPath != "" {
+		s.schemaPath, _ = filepath.Abs(cfg.SchemaPath)
+	}
+	return s
+}
+
+// Run serves requests until the client sends exit or closes the stream.
+func (s *Server) Run() error {
+	for {
+		body, err := s.readMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		s.handle(&req)
+	}
+}
+
+// handle dispatches a request or notification.
+func (s *Server) handle(req *request) {
+	var result any
+	var err error
+
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.initialize(params)
+		}
+	case "initialized", "$/cancelRequest", "$/setTrace", "textDocument/didSave":
+		return
+	case "shutdown":
+		// Nothing to clean up; the client follows up with exit
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			path := uriToPath(params.TextDocument.URI)
+			s.docs[path] = params.TextDocument.Text
+			s.check(path)
+		}
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err = json.Unmarshal(req.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			// Full sync: the last change holds the whole document
+			path := uriToPath(params.TextDocument.URI)
+			s.docs[path] = params.ContentChanges[len(params.ContentChanges)-1].Text
+			s.check(path)
+		}
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			path := uriToPath(params.TextDocument.URI)
+			delete(s.docs, path)
+			s.check(path)
+		}
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.definition(uriToPath(params.TextDocument.URI), params.Position)
+		}
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.completion(uriToPath(params.TextDocument.URI), params.Position)
+		}
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = s.hover(uriToPath(params.TextDocument.URI), params.Position)
+		}
+	default:
+		if req.ID != nil {
+			s.reply(req.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method})
+		}
+		return
+	}
+
+	if req.ID == nil {
+		if err != nil {
+			s.log.Printf("%s: %v", req.Method, err)
+		}
+		return
+	}
+	if err != nil {
+		s.reply(req.ID, nil, &responseError{Code: codeInvalidParams, Message: err.Error()})
+		return
+	}
+	s.reply(req.ID, result, nil)
+}
+
+func (s *Server) initialize(params initializeParams) any {
+	if s.schemaPath == "" {
+		s.log.Printf("no schema path configured; checking files on their own")
+	}
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync":   1, // Full
+			"definitionProvider": true,
+			"hoverProvider":      true,
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{"@", "(", " "},
+			},
+		},
+		"serverInfo": map[string]any{"name": "nexus-lsp"},
+	}
+}
+
+// Framing: each message is preceded by a Content-Length header.
+
+func (s *Server) readMessage() ([]byte, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) write(msg any) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		s.log.Printf("encoding message: %v", err)
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *Server) reply(id *json.RawMessage, result any, rpcErr *responseError) {
+	msg := map[string]any{"jsonrpc": "2.0", "id": id}
+	if rpcErr != nil {
+		msg["error"] = rpcErr
+	} else {
+		msg["result"] = result
+	}
+	s.write(msg)
+}
+
+func (s *Server) notify(method string, params any) {
+	s.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// uriToPath converts a file:// URI to an absolute path.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	// file:///C:/dir on Windows
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.Clean(filepath.FromSlash(path))
+}
+
+// pathToURI converts an absolute path to a file:// URI.
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,121 @@
+package studio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// handleExplain runs the dialect's EXPLAIN on a statement and returns the
+// parsed plan. ANALYZE executes the statement, so it is only allowed for
+// statements isReadStatement accepts, and never in read-only mode.
+func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.denyRawSQL(w) {
+		return
+	}
+
+	var req struct {
+		Query   string `json:"query"`
+		Analyze bool   `json:"analyze"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	statements := migration.SplitStatements(req.Query)
+	switch {
+	case len(statements) == 0:
+		s.jsonError(w, "Query is required", http.StatusBadRequest)
+		return
+	case len(statements) > 1:
+		s.jsonError(w, "Only a single statement can be explained", http.StatusBadRequest)
+		return
+	}
+	stmt := statements[0]
+
+	// A dialect without an EXPLAIN prefix would run the statement itself
+	if !s.conn.Dialect.SupportsExplainFormat(string(query.ExplainFormatText)) {
+		s.jsonError(w, fmt.Sprintf("dialect %s does not support EXPLAIN", s.conn.Dialect.Name()), http.StatusBadRequest)
+		return
+	}
+	if req.Analyze {
+		if s.readOnly {
+			s.jsonError(w, "Studio is read-only; ANALYZE is not allowed", http.StatusForbidden)
+			return
+		}
+		if !isReadStatement(stmt) {
+			s.jsonError(w, "ANALYZE is only allowed for queries that don't write", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	plan, err := query.NewRawQuery(s.conn, stmt).Explain(ctx, query.ExplainOptions{
+		Analyze: req.Analyze,
+		Format:  query.ExplainFormatText,
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("explain timed out after %s", s.timeout)
+	}
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.jsonResponse(w, planJSON(s.conn.Dialect.Name(), stmt, plan))
+}
+
+// planJSON converts a query plan for a response. Nodes with warnings are
+// marked so the UI can highlight them.
+func planJSON(dialect, stmt string, plan *query.QueryPlan) map[string]interface{} {
+	return map[string]interface{}{
+		"query":       stmt,
+		"dialect":     dialect,
+		"raw":         plan.Raw,
+		"analyzed":    plan.IsAnalyzed,
+		"scanTypes":   nonNil(plan.ScanTypes),
+		"usedIndexes": nonNil(plan.UsedIndexes),
+		"warnings":    nonNil(plan.Warnings),
+		"nodes":       planNodesJSON(plan.Nodes),
+	}
+}
+
+// planNodesJSON converts a level of the plan tree.
+func planNodesJSON(nodes []*query.PlanNode) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		entry := map[string]interface{}{
+			"detail":   node.Detail,
+			"scanType": node.ScanType,
+			"index":    node.Index,
+			"warnings": nonNil(node.Warnings),
+			"problem":  len(node.Warnings) > 0,
+			"children": planNodesJSON(node.Children),
+		}
+		if len(node.Properties) > 0 {
+			entry["properties"] = node.Properties
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// nonNil returns s, or an empty slice when it is nil, so it is encoded as
+// an array.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
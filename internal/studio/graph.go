@@ -0,0 +1,382 @@
+package studio
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// schemaGraph is an entity-relationship graph of the tables in a schema or
+// database, for rendering as a diagram.
+type schemaGraph struct {
+	Source string      `json:"source"` // "schema" or "database"
+	Nodes  []graphNode `json:"nodes"`
+	Edges  []graphEdge `json:"edges"`
+}
+
+// graphNode is a table, with the model it is declared by if the graph was
+// built from the schema.
+type graphNode struct {
+	ID     string       `json:"id"` // Table name
+	Model  string       `json:"model,omitempty"`
+	Fields []graphField `json:"fields"`
+}
+
+// graphField is a column of a graphNode.
+type graphField struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable,omitempty"`
+	PrimaryKey bool   `json:"primaryKey,omitempty"`
+	Unique     bool   `json:"unique,omitempty"`
+	ForeignKey bool   `json:"foreignKey,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+}
+
+// graphEdge is a relation between two tables. For "N:1" and "1:1" edges
+// From holds the foreign key Columns referencing RefColumns of To; "N:M"
+// edges go through a junction table.
+type graphEdge struct {
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Cardinality string   `json:"cardinality"` // "N:1", "1:1" or "N:M"
+	Name        string   `json:"name,omitempty"`
+	Columns     []string `json:"columns,omitempty"`
+	RefColumns  []string `json:"refColumns,omitempty"`
+	Through     string   `json:"through,omitempty"`
+}
+
+// handleSchemaGraph returns the entity-relationship graph of the schema,
+// or of the database when there is no schema or ?source=database is
+// given. ?format=mermaid or ?format=dot renders it for docs instead.
+func (s *Server) handleSchemaGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var graph *schemaGraph
+	switch source := r.URL.Query().Get("source"); {
+	case source == "schema" && s.schema == nil:
+		s.jsonError(w, "No schema loaded", http.StatusBadRequest)
+		return
+	case source == "" && s.schema != nil, source == "schema":
+		graph = buildSchemaGraph(s.schema)
+	case source == "" || source == "database":
+		var err error
+		if graph, err = s.introspectGraph(r.Context()); err != nil {
+			s.jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		s.jsonError(w, fmt.Sprintf("source must be schema or database, got %q", source), http.StatusBadRequest)
+		return
+	}
+	if s.tables != nil {
+		graph.restrict(s.tableVisible)
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		s.jsonResponse(w, graph)
+	case "mermaid":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, graph.Mermaid())
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		fmt.Fprint(w, graph.DOT())
+	default:
+		s.jsonError(w, fmt.Sprintf("format must be json, mermaid or dot, got %q", format), http.StatusBadRequest)
+	}
+}
+
+// buildSchemaGraph builds the graph of the models in sch. Each foreign key
+// is one edge, whether it is declared on one side of the relation or both.
+func buildSchemaGraph(sch *schema.Schema) *schemaGraph {
+	graph := &schemaGraph{Source: "schema", Nodes: []graphNode{}, Edges: []graphEdge{}}
+	table := func(model string) string {
+		if m := sch.Models[model]; m != nil {
+			return m.Table()
+		}
+		return model
+	}
+
+	foreignKeys := make(map[string]bool)
+	for _, model := range sch.GetModels() {
+		for _, rel := range model.Relations {
+			if rel.Type == schema.RelationBelongsTo {
+				foreignKeys[model.Name+"."+rel.ForeignKey] = true
+			}
+		}
+	}
+	for _, model := range sch.GetModels() {
+		node := graphNode{ID: model.Table(), Model: model.Name}
+		for _, f := range model.GetFields() {
+			node.Fields = append(node.Fields, graphField{
+				Name:       f.Name,
+				Type:       f.Type.String(),
+				Nullable:   f.Nullable,
+				PrimaryKey: f.IsPrimaryKey,
+				Unique:     f.IsUnique,
+				ForeignKey: f.IsReference || foreignKeys[model.Name+"."+f.Name],
+				Comment:    f.Description,
+			})
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	// Owning sides first, keyed by child model, parent model and column
+	edges := make(map[string]int)
+	for _, model := range sch.GetModels() {
+		for _, rel := range model.Relations {
+			if rel.Type != schema.RelationBelongsTo {
+				continue
+			}
+			edges[model.Name+"|"+rel.TargetModel+"|"+rel.ForeignKey] = len(graph.Edges)
+			graph.Edges = append(graph.Edges, graphEdge{
+				From:        model.Table(),
+				To:          table(rel.TargetModel),
+				Cardinality: "N:1",
+				Name:        rel.Name,
+				Columns:     []string{rel.ForeignKey},
+				RefColumns:  []string{relationRefKey(sch, rel)},
+			})
+		}
+	}
+
+	junctions := make(map[string]bool)
+	for _, model := range sch.GetModels() {
+		for _, rel := range model.Relations {
+			switch rel.Type {
+			case schema.RelationHasOne, schema.RelationHasMany:
+				cardinality := "N:1"
+				if rel.Type == schema.RelationHasOne {
+					cardinality = "1:1"
+				}
+				key := rel.TargetModel + "|" + model.Name + "|" + rel.ForeignKey
+				if i, ok := edges[key]; ok {
+					graph.Edges[i].Cardinality = cardinality
+					if graph.Edges[i].Name == "" {
+						graph.Edges[i].Name = rel.Name
+					}
+					continue
+				}
+				edges[key] = len(graph.Edges)
+				graph.Edges = append(graph.Edges, graphEdge{
+					From:        table(rel.TargetModel),
+					To:          model.Table(),
+					Cardinality: cardinality,
+					Name:        rel.Name,
+					Columns:     []string{rel.ForeignKey},
+					RefColumns:  []string{rel.ReferenceKey},
+				})
+			case schema.RelationManyToMany:
+				if junctions[rel.Through] {
+					continue
+				}
+				junctions[rel.Through] = true
+				graph.Edges = append(graph.Edges, graphEdge{
+					From:        model.Table(),
+					To:          table(rel.TargetModel),
+					Cardinality: "N:M",
+					Name:        rel.Name,
+					Columns:     []string{rel.ThroughSourceKey},
+					RefColumns:  []string{rel.ThroughTargetKey},
+					Through:     rel.Through,
+				})
+			}
+		}
+	}
+	return graph
+}
+
+// SchemaMermaid renders the models of sch as a Mermaid erDiagram, as
+// /api/schema/graph?format=mermaid does.
+func SchemaMermaid(sch *schema.Schema) string {
+	return buildSchemaGraph(sch).Mermaid()
+}
+
+// relationRefKey returns the column a belongs-to relation refers to.
+func relationRefKey(sch *schema.Schema, rel *schema.Relation) string {
+	if rel.ReferenceKey != "" {
+		return rel.ReferenceKey
+	}
+	if parent := sch.Models[rel.TargetModel]; parent != nil {
+		for _, f := range parent.GetFields() {
+			if f.IsPrimaryKey {
+				return f.Name
+			}
+		}
+	}
+	return "id"
+}
+
+// introspectGraph builds the graph of the tables in the database from
+// their foreign keys. A foreign key on unique columns is "1:1".
+func (s *Server) introspectGraph(ctx context.Context) (*schemaGraph, error) {
+	tables, err := s.getTables()
+	if err != nil {
+		return nil, err
+	}
+	introspector, ok := s.conn.Dialect.(migration.Introspector)
+	if !ok {
+		return nil, fmt.Errorf("dialect %s does not support introspection", s.conn.Dialect.Name())
+	}
+
+	graph := &schemaGraph{Source: "database", Nodes: []graphNode{}, Edges: []graphEdge{}}
+	for _, table := range tables {
+		columns, err := introspector.IntrospectColumns(ctx, s.conn.DB, table)
+		if err != nil {
+			return nil, err
+		}
+		fks, err := introspector.IntrospectForeignKeys(ctx, s.conn.DB, table)
+		if err != nil {
+			return nil, err
+		}
+
+		fkColumns := make(map[string]bool)
+		for _, fk := range fks {
+			for _, col := range fk.Columns {
+				fkColumns[col] = true
+			}
+		}
+		node := graphNode{ID: table}
+		unique := make(map[string]bool)
+		var primaryKey []string
+		for _, col := range columns {
+			node.Fields = append(node.Fields, graphField{
+				Name:       col.Name,
+				Type:       col.Type,
+				Nullable:   col.Nullable,
+				PrimaryKey: col.IsPrimaryKey,
+				Unique:     col.IsUnique,
+				ForeignKey: fkColumns[col.Name],
+				Comment:    col.Comment,
+			})
+			if col.IsUnique {
+				unique[col.Name] = true
+			}
+			if col.IsPrimaryKey {
+				primaryKey = append(primaryKey, col.Name)
+			}
+		}
+		graph.Nodes = append(graph.Nodes, node)
+
+		for _, fk := range fks {
+			cardinality := "N:1"
+			if (len(fk.Columns) == 1 && unique[fk.Columns[0]]) || slices.Equal(fk.Columns, primaryKey) {
+				cardinality = "1:1"
+			}
+			graph.Edges = append(graph.Edges, graphEdge{
+				From:        table,
+				To:          fk.RefTable,
+				Cardinality: cardinality,
+				Name:        fk.Name,
+				Columns:     fk.Columns,
+				RefColumns:  fk.RefColumns,
+			})
+		}
+	}
+	return graph, nil
+}
+
+// restrict removes the tables visible rejects from the graph, with the
+// edges touching them.
+func (g *schemaGraph) restrict(visible func(table string) bool) {
+	g.Nodes = slices.DeleteFunc(g.Nodes, func(n graphNode) bool {
+		return !visible(n.ID)
+	})
+	g.Edges = slices.DeleteFunc(g.Edges, func(e graphEdge) bool {
+		return !visible(e.From) || !visible(e.To) || (e.Through != "" && !visible(e.Through))
+	})
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// mermaidName makes s usable as a Mermaid entity or attribute name.
+func mermaidName(s string) string {
+	return strings.Trim(nonIdentifier.ReplaceAllString(s, "_"), "_")
+}
+
+// Mermaid renders the graph as a Mermaid erDiagram.
+func (g *schemaGraph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "    %s {\n", mermaidName(node.ID))
+		for _, f := range node.Fields {
+			var keys []string
+			if f.PrimaryKey {
+				keys = append(keys, "PK")
+			}
+			if f.ForeignKey {
+				keys = append(keys, "FK")
+			}
+			if f.Unique && !f.PrimaryKey {
+				keys = append(keys, "UK")
+			}
+			fmt.Fprintf(&b, "        %s %s", mermaidName(f.Type), mermaidName(f.Name))
+			if len(keys) > 0 {
+				b.WriteString(" " + strings.Join(keys, ","))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, e := range g.Edges {
+		link := "}o--||"
+		switch e.Cardinality {
+		case "1:1":
+			link = "|o--||"
+		case "N:M":
+			link = "}o--o{"
+		}
+		label := e.Name
+		if label == "" {
+			label = strings.Join(e.Columns, ", ")
+		}
+		fmt.Fprintf(&b, "    %s %s %s : %q\n", mermaidName(e.From), link, mermaidName(e.To), label)
+	}
+	return b.String()
+}
+
+// dotEscaper escapes the characters with meaning in a Graphviz record label.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`)
+
+// DOT renders the graph as a Graphviz digraph of record nodes.
+func (g *schemaGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph schema {\n")
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [shape=record];\n")
+	for _, node := range g.Nodes {
+		fields := make([]string, len(node.Fields))
+		for i, f := range node.Fields {
+			name := f.Name
+			if f.PrimaryKey {
+				name += " (PK)"
+			} else if f.ForeignKey {
+				name += " (FK)"
+			}
+			fields[i] = dotEscaper.Replace(name+" : "+f.Type) + `\l`
+		}
+		fmt.Fprintf(&b, "    %q [label=\"{%s|%s}\"];\n", node.ID, dotEscaper.Replace(node.ID), strings.Join(fields, ""))
+	}
+	for _, e := range g.Edges {
+		label := e.Cardinality
+		if len(e.Columns) > 0 && e.Through == "" {
+			label = strings.Join(e.Columns, ", ") + " " + label
+		} else if e.Through != "" {
+			label = e.Through + " " + label
+		}
+		fmt.Fprintf(&b, "    %q -> %q [label=%q];\n", e.From, e.To, label)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
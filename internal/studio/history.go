@@ -0,0 +1,158 @@
+package studio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultQueryStoreFile is where the studio keeps query history and saved
+// queries when run from the CLI.
+const DefaultQueryStoreFile = ".nexus-studio.json"
+
+// maxQueryHistory is the number of history entries kept.
+const maxQueryHistory = 100
+
+// HistoryEntry is a query run from the query editor.
+type HistoryEntry struct {
+	Query      string    `json:"query"`
+	ExecutedAt time.Time `json:"executedAt"`
+	Duration   int64     `json:"duration"` // Milliseconds
+	Error      string    `json:"error,omitempty"`
+}
+
+// SavedQuery is a query saved under a name.
+type SavedQuery struct {
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// queryStore holds the query history, newest first, and the saved queries
+// sorted by name. With a path it is read from and written to that file;
+// without one it only lives in memory.
+type queryStore struct {
+	mu     sync.Mutex
+	path   string
+	loaded bool
+
+	History []HistoryEntry `json:"history"`
+	Saved   []SavedQuery   `json:"saved"`
+}
+
+// load reads the store file the first time it is needed. A missing file
+// is an empty store.
+func (q *queryStore) load() error {
+	if q.loaded || q.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		q.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return fmt.Errorf("reading %s: %w", q.path, err)
+	}
+	q.loaded = true
+	return nil
+}
+
+// save writes the store file.
+func (q *queryStore) save() error {
+	if q.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(q.path, append(data, '\n'), 0o644)
+}
+
+// history returns the query history, newest first.
+func (q *queryStore) history() ([]HistoryEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(q.History), nil
+}
+
+// record adds an entry to the history, dropping the oldest past
+// maxQueryHistory.
+func (q *queryStore) record(entry HistoryEntry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.load(); err != nil {
+		return err
+	}
+	q.History = slices.Insert(q.History, 0, entry)
+	if len(q.History) > maxQueryHistory {
+		q.History = q.History[:maxQueryHistory]
+	}
+	return q.save()
+}
+
+// clearHistory removes every history entry.
+func (q *queryStore) clearHistory() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.load(); err != nil {
+		return err
+	}
+	q.History = nil
+	return q.save()
+}
+
+// saved returns the saved queries sorted by name.
+func (q *queryStore) saved() ([]SavedQuery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(q.Saved), nil
+}
+
+// saveQuery saves a query, replacing any saved under the same name.
+func (q *queryStore) saveQuery(saved SavedQuery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.load(); err != nil {
+		return err
+	}
+	i, found := slices.BinarySearchFunc(q.Saved, saved.Name, func(s SavedQuery, name string) int {
+		return strings.Compare(s.Name, name)
+	})
+	if found {
+		q.Saved[i] = saved
+	} else {
+		q.Saved = slices.Insert(q.Saved, i, saved)
+	}
+	return q.save()
+}
+
+// deleteQuery removes a saved query, reporting whether it existed.
+func (q *queryStore) deleteQuery(name string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.load(); err != nil {
+		return false, err
+	}
+	i := slices.IndexFunc(q.Saved, func(s SavedQuery) bool { return s.Name == name })
+	if i < 0 {
+		return false, nil
+	}
+	q.Saved = slices.Delete(q.Saved, i, i+1)
+	return true, q.save()
+}
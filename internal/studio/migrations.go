@@ -0,0 +1,295 @@
+package studio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+// migrationName is the form of names of migrations generated from the
+// browser, which end up in their file names.
+var migrationName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// validateOptions returns the options migrations are validated with,
+// tailored to the connected dialect.
+func (s *Server) validateOptions() migration.ValidateOptions {
+	opts := migration.DefaultValidateOptions()
+	if s.conn != nil {
+		opts.Dialect = s.conn.Dialect.Name()
+	}
+	return opts
+}
+
+// handleMigration serves /api/migrations/validate, /api/migrations/diff,
+// /api/migrations/history, /api/migrations/{id}/schema and
+// /api/migrations/{id}.
+func (s *Server) handleMigration(w http.ResponseWriter, r *http.Request) {
+	if s.migrations == nil {
+		s.jsonError(w, "Migrations not configured", http.StatusNotFound)
+		return
+	}
+
+	switch path := strings.TrimPrefix(r.URL.Path, "/api/migrations/"); path {
+	case "validate":
+		s.handleValidateMigrations(w, r)
+	case "diff":
+		s.handleMigrationDiff(w, r)
+	case "history":
+		s.handleSchemaHistory(w, r)
+	default:
+		if id, ok := strings.CutSuffix(path, "/schema"); ok {
+			s.handleSchemaAt(w, r, id)
+			return
+		}
+		s.handleMigrationDetails(w, r, path)
+	}
+}
+
+// handleSchemaHistory lists the migrations whose resulting schema was
+// recorded.
+func (s *Server) handleSchemaHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	versions, err := s.migrations.SchemaHistory(r.Context())
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if versions == nil {
+		versions = []migration.SchemaVersion{}
+	}
+	s.jsonResponse(w, map[string]interface{}{"versions": versions})
+}
+
+// handleSchemaAt returns the schema recorded after a migration, or the
+// database now for "current". With ?diff= naming another migration, or
+// "current", it also returns the changes from that schema to this one.
+func (s *Server) handleSchemaAt(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	snapshot, err := s.migrations.SchemaVersion(r.Context(), id)
+	if err != nil {
+		s.schemaVersionError(w, err)
+		return
+	}
+	resp := map[string]interface{}{
+		"id":     id,
+		"schema": snapshot,
+	}
+	if diff := r.URL.Query().Get("diff"); diff != "" {
+		from, err := s.migrations.SchemaVersion(r.Context(), diff)
+		if err != nil {
+			s.schemaVersionError(w, err)
+			return
+		}
+		changes := migration.DescribeChanges(migration.DiffSnapshots(from, snapshot))
+		if changes == nil {
+			changes = []string{}
+		}
+		resp["diff"] = diff
+		resp["changes"] = changes
+	}
+	s.jsonResponse(w, resp)
+}
+
+func (s *Server) schemaVersionError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, migration.ErrNoSchemaHistory) {
+		status = http.StatusNotFound
+	}
+	s.jsonError(w, err.Error(), status)
+}
+
+// handleMigrationDetails returns the UP and DOWN SQL of a migration, its
+// status and its validation issues.
+func (s *Server) handleMigrationDetails(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var m *migration.Migration
+	for _, loaded := range s.migrations.Migrations() {
+		if loaded.ID == id {
+			m = loaded
+			break
+		}
+	}
+	if m == nil {
+		s.jsonError(w, fmt.Sprintf("migration %q not found", id), http.StatusNotFound)
+		return
+	}
+
+	status, err := s.migrations.Status(r.Context())
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]interface{}{
+		"id":            m.ID,
+		"name":          m.Name,
+		"upSQL":         m.UpSQL,
+		"downSQL":       m.DownSQL,
+		"checksum":      m.Checksum,
+		"author":        m.Author,
+		"description":   m.Description,
+		"noTransaction": m.NoTransaction,
+		"applied":       false,
+		"issues":        issuesJSON(migration.ValidateWithOptions(m, s.validateOptions()).Issues),
+	}
+	for _, st := range status {
+		if st.ID == m.ID {
+			resp["applied"] = st.Applied
+			resp["appliedAt"] = st.AppliedAt
+			resp["outOfOrder"] = st.OutOfOrder
+		}
+	}
+	s.jsonResponse(w, resp)
+}
+
+// handleValidateMigrations validates every loaded migration.
+func (s *Server) handleValidateMigrations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	valid := true
+	results := make([]map[string]interface{}, 0)
+	for _, result := range migration.ValidateMigrations(s.migrations.Migrations(), s.validateOptions()) {
+		valid = valid && result.Valid
+		results = append(results, map[string]interface{}{
+			"id":     result.MigrationID,
+			"valid":  result.Valid,
+			"issues": issuesJSON(result.Issues),
+		})
+	}
+	s.jsonResponse(w, map[string]interface{}{
+		"valid":   valid,
+		"results": results,
+	})
+}
+
+// handleMigrationDiff diffs the schema against the database and returns
+// the migration that would bring the database up to date. With save set
+// the migration is written to the migrations directory and loaded, ready
+// to apply.
+func (s *Server) handleMigrationDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+		Save bool   `json:"save"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		req.Name = "studio_diff"
+	}
+	if !migrationName.MatchString(req.Name) {
+		s.jsonError(w, "Name may only contain letters, digits and underscores", http.StatusBadRequest)
+		return
+	}
+	if req.Save && s.denyReadOnly(w) {
+		return
+	}
+	if req.Save && s.migrationsDir == "" {
+		s.jsonError(w, "No migrations directory configured", http.StatusBadRequest)
+		return
+	}
+	if s.schema == nil {
+		s.jsonError(w, "No schema loaded", http.StatusBadRequest)
+		return
+	}
+	if err := s.schema.Validate(); err != nil {
+		s.jsonError(w, fmt.Sprintf("validating schema: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	introspector, ok := s.conn.Dialect.(migration.Introspector)
+	if !ok {
+		s.jsonError(w, fmt.Sprintf("dialect %s does not support introspection", s.conn.Dialect.Name()), http.StatusBadRequest)
+		return
+	}
+	snapshot, err := migration.IntrospectDatabase(r.Context(), s.conn.DB, introspector)
+	if err != nil {
+		s.jsonError(w, fmt.Sprintf("introspecting database: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	diff := migration.DiffWithOptions(s.schema, snapshot, migration.DiffOptions{Dialect: s.conn.Dialect})
+	if !diff.HasChanges() {
+		s.jsonResponse(w, map[string]interface{}{
+			"changes": []string{},
+			"message": "No schema changes detected. Database is up to date.",
+		})
+		return
+	}
+
+	m, err := migration.GenerateMigrationFromDiff(s.conn.Dialect, diff.Changes, req.Name)
+	if err != nil {
+		s.jsonError(w, fmt.Sprintf("generating migration: %v", err), http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]interface{}{
+		"id":      m.ID,
+		"name":    m.Name,
+		"changes": migration.DescribeChanges(diff.Changes),
+		"upSQL":   m.UpSQL,
+		"downSQL": m.DownSQL,
+		"issues":  issuesJSON(migration.ValidateWithOptions(m, s.validateOptions()).Issues),
+		"saved":   false,
+	}
+
+	if req.Save {
+		if err := os.MkdirAll(s.migrationsDir, 0755); err != nil {
+			s.jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := migration.SaveMigration(s.migrationsDir, m); err != nil {
+			s.jsonError(w, fmt.Sprintf("saving migration: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.migrations.Add(m)
+		resp["saved"] = true
+		resp["file"] = fmt.Sprintf("%s_%s.sql", m.ID, m.Name)
+	}
+	s.jsonResponse(w, resp)
+}
+
+// issuesJSON converts validation issues for a response.
+func issuesJSON(issues []migration.ValidationIssue) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(issues))
+	for _, issue := range issues {
+		entry := map[string]interface{}{
+			"severity": issue.Severity.String(),
+			"message":  issue.Message,
+		}
+		if issue.Line > 0 {
+			entry["line"] = issue.Line
+		}
+		if issue.Suggestion != "" {
+			entry["suggestion"] = issue.Suggestion
+		}
+		if issue.Rule != "" {
+			entry["rule"] = issue.Rule
+		}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package studio
+
+import (
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/core/transfer"
+	"github.com/nexus-db/nexus/pkg/mask"
+)
+
+// tablePII returns the fields marked @pii of the model of tableName, by
+// column name, or nil when they are shown or there is no schema.
+func (s *Server) tablePII(tableName string) map[string]*schema.Field {
+	if s.showPII || s.schema == nil {
+		return nil
+	}
+	model := transfer.FindModel(s.schema, tableName)
+	if model == nil {
+		return nil
+	}
+	fields := make(map[string]*schema.Field)
+	for _, f := range model.GetFields() {
+		if f.IsPII {
+			fields[f.Name] = f
+		}
+	}
+	return fields
+}
+
+// queryPII returns the fields marked @pii of every model by column name,
+// for query editor results, whose columns can come from any table. A
+// column named like a @pii field of any model is masked.
+func (s *Server) queryPII() map[string]*schema.Field {
+	if s.showPII || s.schema == nil {
+		return nil
+	}
+	fields := make(map[string]*schema.Field)
+	for _, model := range s.schema.GetModels() {
+		for _, f := range model.GetFields() {
+			if f.IsPII {
+				fields[f.Name] = f
+			}
+		}
+	}
+	return fields
+}
+
+// maskRows masks the columns of rows that are in fields, in place.
+func maskRows(rows []map[string]interface{}, fields map[string]*schema.Field) {
+	if len(fields) == 0 {
+		return
+	}
+	for _, row := range rows {
+		for col, value := range row {
+			if f := fields[col]; f != nil {
+				if b, ok := value.([]byte); ok {
+					value = string(b)
+				}
+				row[col] = mask.Value(f, value)
+			}
+		}
+	}
+}
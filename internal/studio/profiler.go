@@ -0,0 +1,262 @@
+package studio
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// profilerStreamBuffer is how many profiles a stream client may fall
+// behind by before profiles are dropped for it.
+const profilerStreamBuffer = 256
+
+// handleProfiler returns the attached profiler's report on GET, and
+// starts, stops or resets it on POST.
+func (s *Server) handleProfiler(w http.ResponseWriter, r *http.Request) {
+	if s.profiler == nil {
+		s.jsonError(w, "Profiler not attached", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.jsonResponse(w, map[string]interface{}{
+			"enabled": s.profiler.IsEnabled(),
+			"report":  reportJSON(s.profiler.Report(), s.showPII),
+		})
+
+	case http.MethodPost:
+		var req struct {
+			Action string `json:"action"` // "start", "stop" or "reset"
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		switch req.Action {
+		case "start":
+			s.profiler.Start()
+		case "stop":
+			s.profiler.Stop()
+		case "reset":
+			s.profiler.Reset()
+		default:
+			s.jsonError(w, "Invalid action", http.StatusBadRequest)
+			return
+		}
+		s.jsonResponse(w, map[string]interface{}{
+			"enabled": s.profiler.IsEnabled(),
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleProfilerStream upgrades to a WebSocket and pushes every query the
+// attached profiler records as a {"type": "query", "query": {...}}
+// message until the client goes away.
+func (s *Server) handleProfilerStream(w http.ResponseWriter, r *http.Request) {
+	if s.profiler == nil {
+		s.jsonError(w, "Profiler not attached", http.StatusNotFound)
+		return
+	}
+
+	// Subscribe first so no query is missed once the client is connected
+	profiles, unsubscribe := s.profiler.Subscribe(profilerStreamBuffer)
+	defer unsubscribe()
+
+	ws, err := upgradeWebSocket(w, r, s.allowedOrigins)
+	if errors.Is(err, errCrossOrigin) {
+		s.jsonError(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer ws.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		ws.readLoop()
+		close(closed)
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case profile := <-profiles:
+			data, err := json.Marshal(map[string]interface{}{
+				"type":  "query",
+				"query": profileJSON(profile, s.showPII),
+			})
+			if err != nil {
+				continue
+			}
+			if err := ws.WriteText(data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// profileJSON converts a query profile for a response. The arguments of
+// the query, which can hold any value the application writes, @pii or
+// not, are only included with withArgs.
+func profileJSON(p *query.QueryProfile, withArgs bool) map[string]interface{} {
+	entry := map[string]interface{}{
+		"sql":          p.SQL,
+		"duration":     query.Milliseconds(p.Duration),
+		"rowsAffected": p.RowsAffected,
+		"rowsReturned": p.RowsReturned,
+		"startTime":    p.StartTime,
+		"slow":         p.IsSlow,
+	}
+	if withArgs {
+		entry["args"] = p.Args
+	}
+	if p.CallerInfo != "" {
+		entry["caller"] = p.CallerInfo
+	}
+	if p.Error != nil {
+		entry["error"] = p.Error.Error()
+	}
+	if len(p.Tags) > 0 {
+		entry["tags"] = p.Tags
+	}
+	return entry
+}
+
+// reportJSON converts a profile report for a response, with durations in
+// milliseconds. Query arguments are included with withArgs.
+func reportJSON(r *query.ProfileReport, withArgs bool) map[string]interface{} {
+	profiles := func(ps []*query.QueryProfile) []map[string]interface{} {
+		out := make([]map[string]interface{}, 0, len(ps))
+		for _, p := range ps {
+			out = append(out, profileJSON(p, withArgs))
+		}
+		return out
+	}
+
+	frequency := make([]map[string]interface{}, 0, len(r.TopByFrequency))
+	for _, f := range r.TopByFrequency {
+		frequency = append(frequency, map[string]interface{}{
+			"pattern":       f.Pattern,
+			"count":         f.Count,
+			"totalDuration": query.Milliseconds(f.TotalDuration),
+			"avgDuration":   query.Milliseconds(f.AvgDuration),
+		})
+	}
+	nPlusOne := make([]map[string]interface{}, 0, len(r.NPlusOneWarnings))
+	for _, w := range r.NPlusOneWarnings {
+		fixes := make([]map[string]interface{}, 0, len(w.Fixes))
+		for _, fix := range w.Fixes {
+			fixes = append(fixes, map[string]interface{}{
+				"model":     fix.Model,
+				"relation":  fix.Relation,
+				"include":   fix.Include,
+				"batchLoad": fix.BatchLoad,
+			})
+		}
+		nPlusOne = append(nPlusOne, map[string]interface{}{
+			"pattern":  w.Pattern,
+			"count":    w.Count,
+			"examples": w.Examples,
+			"callers":  w.Callers,
+			"fixes":    fixes,
+		})
+	}
+	suggestions := r.Suggestions
+	if suggestions == nil {
+		suggestions = []string{}
+	}
+
+	return map[string]interface{}{
+		"sessionId":        r.SessionID,
+		"totalQueries":     r.TotalQueries,
+		"totalDuration":    query.Milliseconds(r.TotalDuration),
+		"averageDuration":  query.Milliseconds(r.AverageDuration),
+		"sessionDuration":  query.Milliseconds(r.SessionDuration),
+		"errorCount":       r.ErrorCount,
+		"sqlCacheHits":     r.SQLCacheHits,
+		"sqlCacheMisses":   r.SQLCacheMisses,
+		"slowQueries":      profiles(r.SlowQueries),
+		"topByDuration":    profiles(r.TopByDuration),
+		"topByFrequency":   frequency,
+		"nPlusOneWarnings": nPlusOne,
+		"suggestions":      suggestions,
+		"pool":             poolReportJSON(r.Pool),
+	}
+}
+
+// handlePool returns the statistics of the connection pool: a sample
+// taken now, the samples taken by every request so far, and those of the
+// attached profiler's session if it samples the pool. Polling it builds
+// up the history.
+func (s *Server) handlePool(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.pool == nil {
+		s.jsonError(w, "No connection", http.StatusNotFound)
+		return
+	}
+
+	current := s.pool.Sample()
+	history := s.pool.Samples()
+	samples := make([]map[string]interface{}, 0, len(history))
+	for _, sample := range history {
+		samples = append(samples, poolStatsJSON(sample))
+	}
+	resp := map[string]interface{}{
+		"current": poolStatsJSON(current),
+		"samples": samples,
+	}
+	if s.profiler != nil {
+		if pool := s.profiler.Report().Pool; pool != nil {
+			resp["session"] = poolReportJSON(pool)
+		}
+	}
+	s.jsonResponse(w, resp)
+}
+
+// poolStatsJSON converts a pool sample for a response, with durations in
+// milliseconds.
+func poolStatsJSON(p query.PoolStats) map[string]interface{} {
+	return map[string]interface{}{
+		"time":              p.Time,
+		"maxOpen":           p.MaxOpen,
+		"open":              p.Open,
+		"inUse":             p.InUse,
+		"idle":              p.Idle,
+		"waitCount":         p.WaitCount,
+		"waitDuration":      query.Milliseconds(p.WaitDuration),
+		"maxIdleClosed":     p.MaxIdleClosed,
+		"maxLifetimeClosed": p.MaxLifetimeClosed,
+		"saturated":         p.Saturated(),
+	}
+}
+
+// poolReportJSON converts the pool summary of a profile report, or nil.
+func poolReportJSON(r *query.PoolReport) map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	samples := make([]map[string]interface{}, 0, len(r.Samples))
+	for _, p := range r.Samples {
+		samples = append(samples, poolStatsJSON(p))
+	}
+	return map[string]interface{}{
+		"maxOpen":          r.MaxOpen,
+		"peakInUse":        r.PeakInUse,
+		"saturatedSamples": r.SaturatedSamples,
+		"waits":            r.Waits,
+		"waitDuration":     query.Milliseconds(r.WaitDuration),
+		"samples":          samples,
+	}
+}
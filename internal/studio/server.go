@@ -4,9 +4,14 @@ package studio
 import (
 	"context"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -14,16 +19,28 @@ import (
 	"github.com/nexus-db/nexus/pkg/core/migration"
 	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+	"github.com/nexus-db/nexus/pkg/query"
 )
 
 // Server represents the studio web server.
 type Server struct {
-	conn       *dialects.Connection
-	schema     *schema.Schema
-	mux        *http.ServeMux
-	port       int
-	host       string
-	migrations *migration.Engine
+	conn           *dialects.Connection
+	schema         *schema.Schema
+	mux            *http.ServeMux
+	port           int
+	host           string
+	migrations     *migration.Engine
+	migrationsDir  string
+	queries        *queryStore
+	timeout        time.Duration
+	profiler       *query.Profiler
+	pool           *query.PoolHistory
+	readOnly       bool
+	tables         map[string]bool // Visible tables, nil for all
+	showPII        bool
+	allowedOrigins []string
+	logger         nexuslog.Logger
 }
 
 // Config holds the server configuration.
@@ -33,17 +50,83 @@ type Config struct {
 	Connection *dialects.Connection
 	Schema     *schema.Schema
 	Migrations *migration.Engine
+
+	// MigrationsDir is where migrations generated from the browser are
+	// written. Empty only lets them be previewed.
+	MigrationsDir string
+
+	// QueryStoreFile is the JSON file query history and saved queries are
+	// kept in. Empty keeps them in memory.
+	QueryStoreFile string
+
+	// Profiler, if set, is reported on at /api/profiler and streamed to
+	// the dashboard as it records queries. With ProfilerOptions.Pool set,
+	// /api/pool also returns the pool samples of its session.
+	Profiler *query.Profiler
+
+	// QueryTimeout bounds each statement run from the query editor when
+	// the request sets no timeout. Zero uses DefaultQueryTimeout.
+	QueryTimeout time.Duration
+
+	// ReadOnly disables everything that changes the database: row edits,
+	// migrations and statements other than queries in the query editor.
+	ReadOnly bool
+
+	// Tables, if set, are the only tables the studio shows. Since a SQL
+	// statement can read any table, the query editor and EXPLAIN are
+	// disabled when tables are restricted.
+	Tables []string
+
+	// ShowPII shows the fields marked @pii in the schema as they are. By
+	// default the table browser, and the query editor for columns named
+	// like them, show fake values instead, and the profiler leaves out
+	// query arguments.
+	ShowPII bool
+
+	// AllowedOrigins are the origins, e.g. "https://admin.example.com",
+	// whose pages may open the profiler stream besides the studio's own.
+	AllowedOrigins []string
+
+	// Logger receives the errors the studio answers requests with. Nil
+	// discards them.
+	Logger nexuslog.Logger
 }
 
+// DefaultQueryTimeout is the statement timeout of the query editor.
+const DefaultQueryTimeout = 30 * time.Second
+
 // NewServer creates a new studio server.
 func NewServer(cfg Config) *Server {
 	s := &Server{
-		conn:       cfg.Connection,
-		schema:     cfg.Schema,
-		port:       cfg.Port,
-		host:       cfg.Host,
-		mux:        http.NewServeMux(),
-		migrations: cfg.Migrations,
+		conn:           cfg.Connection,
+		schema:         cfg.Schema,
+		port:           cfg.Port,
+		host:           cfg.Host,
+		mux:            http.NewServeMux(),
+		migrations:     cfg.Migrations,
+		migrationsDir:  cfg.MigrationsDir,
+		queries:        &queryStore{path: cfg.QueryStoreFile},
+		timeout:        cfg.QueryTimeout,
+		profiler:       cfg.Profiler,
+		readOnly:       cfg.ReadOnly,
+		showPII:        cfg.ShowPII,
+		allowedOrigins: cfg.AllowedOrigins,
+		logger:         cfg.Logger,
+	}
+	if s.logger == nil {
+		s.logger = nexuslog.Nop()
+	}
+	if s.timeout <= 0 {
+		s.timeout = DefaultQueryTimeout
+	}
+	if s.conn != nil && s.conn.DB != nil {
+		s.pool = query.NewPoolHistory(s.conn.DB)
+	}
+	if cfg.Tables != nil {
+		s.tables = make(map[string]bool, len(cfg.Tables))
+		for _, table := range cfg.Tables {
+			s.tables[table] = true
+		}
 	}
 
 	s.setupRoutes()
@@ -56,14 +139,61 @@ func (s *Server) setupRoutes() {
 	s.mux.HandleFunc("/api/tables", s.handleTables)
 	s.mux.HandleFunc("/api/tables/", s.handleTableDetails)
 	s.mux.HandleFunc("/api/query", s.handleQuery)
+	s.mux.HandleFunc("/api/query/history", s.handleQueryHistory)
+	s.mux.HandleFunc("/api/query/saved", s.handleSavedQueries)
+	s.mux.HandleFunc("/api/query/saved/", s.handleSavedQuery)
 	s.mux.HandleFunc("/api/schema", s.handleSchema)
+	s.mux.HandleFunc("/api/schema/graph", s.handleSchemaGraph)
 	s.mux.HandleFunc("/api/migrations", s.handleMigrations)
+	s.mux.HandleFunc("/api/migrations/", s.handleMigration)
+	s.mux.HandleFunc("/api/explain", s.handleExplain)
 	s.mux.HandleFunc("/api/info", s.handleInfo)
+	s.mux.HandleFunc("/api/profiler", s.handleProfiler)
+	s.mux.HandleFunc("/api/profiler/stream", s.handleProfilerStream)
+	s.mux.HandleFunc("/api/pool", s.handlePool)
 
 	// Serve static files (embedded SvelteKit build)
 	s.mux.HandleFunc("/", s.handleStatic)
 }
 
+// Handler returns the server's HTTP handler.
+func (s *Server) Handler() http.Handler {
+	return s.corsMiddleware(s.mux)
+}
+
+// Handler returns the studio as an http.Handler to mount in an
+// application, without the CORS headers the standalone server sends for
+// development. It serves paths from the root, so mount it under a prefix
+// with http.StripPrefix.
+func Handler(cfg Config) http.Handler {
+	return NewServer(cfg).mux
+}
+
+// tableVisible reports whether the studio shows table.
+func (s *Server) tableVisible(table string) bool {
+	return s.tables == nil || s.tables[table]
+}
+
+// denyReadOnly answers a request that would change the database with 403
+// when the studio is read-only, reporting whether it did.
+func (s *Server) denyReadOnly(w http.ResponseWriter) bool {
+	if !s.readOnly {
+		return false
+	}
+	s.jsonError(w, "Studio is read-only", http.StatusForbidden)
+	return true
+}
+
+// denyRawSQL answers a request to run SQL with 403 when tables are
+// restricted, reporting whether it did.
+func (s *Server) denyRawSQL(w http.ResponseWriter) bool {
+	if s.tables == nil {
+		return false
+	}
+	s.jsonError(w, "Running SQL is disabled when tables are restricted", http.StatusForbidden)
+	return true
+}
+
 // Addr returns the server address.
 func (s *Server) Addr() string {
 	return fmt.Sprintf("%s:%d", s.host, s.port)
@@ -73,7 +203,7 @@ func (s *Server) Addr() string {
 func (s *Server) Start() error {
 	server := &http.Server{
 		Addr:         s.Addr(),
-		Handler:      s.corsMiddleware(s.mux),
+		Handler:      s.Handler(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 	}
@@ -85,7 +215,7 @@ func (s *Server) Start() error {
 func (s *Server) StartWithContext(ctx context.Context) error {
 	server := &http.Server{
 		Addr:         s.Addr(),
-		Handler:      s.corsMiddleware(s.mux),
+		Handler:      s.Handler(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 	}
@@ -116,24 +246,48 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// handleTables returns a list of all tables.
+// handleTables returns a list of all tables, and the tables grouped by the
+// schema they live in, the default schema first.
 func (s *Server) handleTables(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	tables, err := s.getTables()
+	list, err := s.listTables()
 	if err != nil {
 		s.jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	tables := make([]string, 0, len(list))
+	for _, t := range list {
+		tables = append(tables, t.String())
+	}
 
 	s.jsonResponse(w, map[string]interface{}{
-		"tables": tables,
+		"tables":  tables,
+		"schemas": groupBySchema(list),
 	})
 }
 
+// groupBySchema groups tables by their schema, in the order the schemas
+// first appear. Tables of the default schema are in the group of schema "".
+func groupBySchema(tables []studioTable) []map[string]interface{} {
+	groups := make([]map[string]interface{}, 0)
+	index := make(map[string]int)
+	for _, table := range tables {
+		namespace := table.namespace
+		i, ok := index[namespace]
+		if !ok {
+			i = len(groups)
+			index[namespace] = i
+			groups = append(groups, map[string]interface{}{"schema": namespace, "tables": []string{}})
+		}
+		groups[i]["tables"] = append(groups[i]["tables"].([]string), table.String())
+	}
+	return groups
+}
+
 // handleTableDetails handles requests for specific table details and data.
 func (s *Server) handleTableDetails(w http.ResponseWriter, r *http.Request) {
 	// Extract table name from path: /api/tables/{name} or /api/tables/{name}/data
@@ -145,6 +299,15 @@ func (s *Server) handleTableDetails(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Table name required", http.StatusBadRequest)
 		return
 	}
+	tables, err := s.tableSet()
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !tables.Contains(tableName) {
+		s.jsonError(w, fmt.Sprintf("table %q not found", tableName), http.StatusNotFound)
+		return
+	}
 
 	if len(parts) > 1 && parts[1] == "data" {
 		s.handleTableData(w, r, tableName)
@@ -167,20 +330,93 @@ func (s *Server) handleTableSchema(w http.ResponseWriter, r *http.Request, table
 		s.jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	_, primaryKey, err := s.getTableKey(r.Context(), tableName)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	comment, columnComments, err := s.getComments(r.Context(), tableName)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, col := range columns {
+		if c := columnComments[col["name"].(string)]; c != "" {
+			col["comment"] = c
+		}
+	}
 
-	s.jsonResponse(w, map[string]interface{}{
-		"name":    tableName,
-		"columns": columns,
-	})
+	resp := map[string]interface{}{
+		"name":       tableName,
+		"columns":    columns,
+		"primaryKey": primaryKey,
+	}
+	if comment != "" {
+		resp["comment"] = comment
+	}
+	s.jsonResponse(w, resp)
+}
+
+// getComments returns the comment of a table and those of its columns:
+// the ones the database stores, or else those of the schema.
+func (s *Server) getComments(ctx context.Context, tableName string) (string, map[string]string, error) {
+	var comment string
+	columns := make(map[string]string)
+	if s.schema != nil {
+		for _, model := range s.schema.GetModels() {
+			if model.Table() != tableName {
+				continue
+			}
+			comment = model.Description
+			for _, f := range model.GetFields() {
+				if f.Description != "" {
+					columns[f.Name] = f.Description
+				}
+			}
+		}
+	}
+
+	infos, err := s.getColumnInfo(ctx, tableName)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, col := range infos {
+		if col.Comment != "" {
+			columns[col.Name] = col.Comment
+		}
+	}
+	if ci, ok := s.conn.Dialect.(migration.CommentIntrospector); ok {
+		stored, err := ci.IntrospectTableComment(ctx, s.conn.DB, tableName)
+		if err != nil {
+			return "", nil, err
+		}
+		if stored != "" {
+			comment = stored
+		}
+	}
+	return comment, columns, nil
 }
 
-// handleTableData returns paginated data for a specific table.
+// handleTableData lists the rows of a table on GET and inserts, updates
+// or deletes a row on POST, PUT or DELETE.
 func (s *Server) handleTableData(w http.ResponseWriter, r *http.Request, tableName string) {
-	if r.Method != http.MethodGet {
+	switch r.Method {
+	case http.MethodGet:
+		s.listTableData(w, r, tableName)
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+		if s.denyReadOnly(w) {
+			return
+		}
+		s.editTableData(w, r, tableName)
+	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
 	}
+}
 
+// listTableData returns paginated data for a specific table, optionally
+// sorted with ?sort=col&dir=desc, narrowed with ?filter[col]=value and
+// searched across text columns with ?search=term.
+func (s *Server) listTableData(w http.ResponseWriter, r *http.Request, tableName string) {
 	// Parse pagination parameters
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	if page < 1 {
@@ -192,19 +428,35 @@ func (s *Server) handleTableData(w http.ResponseWriter, r *http.Request, tableNa
 	}
 	offset := (page - 1) * limit
 
+	infos, err := s.getColumnInfo(r.Context(), tableName)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if infos == nil {
+		s.jsonError(w, fmt.Sprintf("table %q not found", tableName), http.StatusNotFound)
+		return
+	}
+	view, err := parseDataView(s.conn.Dialect, infos, r.URL.Query())
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Get total count
-	total, err := s.getTableRowCount(tableName)
+	total, err := s.getTableRowCount(tableName, view)
 	if err != nil {
 		s.jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Get data
-	rows, columns, err := s.getTableData(tableName, limit, offset)
+	rows, columns, err := s.getTableData(tableName, view, limit, offset)
 	if err != nil {
 		s.jsonError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	maskRows(rows, s.tablePII(tableName))
 
 	s.jsonResponse(w, map[string]interface{}{
 		"data":    rows,
@@ -216,44 +468,472 @@ func (s *Server) handleTableData(w http.ResponseWriter, r *http.Request, tableNa
 	})
 }
 
-// handleQuery executes a SQL query.
+// dataView is the WHERE and ORDER BY clauses a table data request asks
+// for. Column names are checked against the table and every value is a
+// query parameter.
+type dataView struct {
+	where string
+	args  []interface{}
+	order string
+}
+
+// parseDataView builds the dataView for the sort, dir, filter[col] and
+// search parameters of a table data request. Filter values are converted
+// to the column's type, and search matches every text column.
+func parseDataView(dialect dialects.Dialect, infos []*migration.ColumnInfo, params url.Values) (dataView, error) {
+	var view dataView
+	types := make(map[string]string, len(infos))
+	for _, col := range infos {
+		types[col.Name] = col.Type
+	}
+
+	var conditions []string
+	var filters []string
+	for param := range params {
+		if strings.HasPrefix(param, "filter[") && strings.HasSuffix(param, "]") {
+			filters = append(filters, param)
+		}
+	}
+	slices.Sort(filters)
+	for _, param := range filters {
+		col := param[len("filter[") : len(param)-1]
+		colType, ok := types[col]
+		if !ok {
+			return view, fmt.Errorf("unknown filter column %q", col)
+		}
+		val, err := filterValue(colType, params.Get(param))
+		if err != nil {
+			return view, fmt.Errorf("filter on column %q: %w", col, err)
+		}
+		view.args = append(view.args, val)
+		conditions = append(conditions, fmt.Sprintf("%s = %s", dialect.Quote(col), dialect.Placeholder(len(view.args))))
+	}
+
+	if search := params.Get("search"); search != "" {
+		var matches []string
+		for _, col := range infos {
+			if columnKind(col.Type) != "text" {
+				continue
+			}
+			view.args = append(view.args, "%"+strings.ToLower(search)+"%")
+			matches = append(matches, fmt.Sprintf("LOWER(%s) LIKE %s", dialect.Quote(col.Name), dialect.Placeholder(len(view.args))))
+		}
+		if len(matches) == 0 {
+			return view, fmt.Errorf("table has no text columns to search")
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+	}
+	if len(conditions) > 0 {
+		view.where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if sort := params.Get("sort"); sort != "" {
+		if _, ok := types[sort]; !ok {
+			return view, fmt.Errorf("unknown sort column %q", sort)
+		}
+		dir := query.Asc
+		switch strings.ToLower(params.Get("dir")) {
+		case "", "asc":
+		case "desc":
+			dir = query.Desc
+		default:
+			return view, fmt.Errorf("dir must be asc or desc, got %q", params.Get("dir"))
+		}
+		view.order = fmt.Sprintf(" ORDER BY %s %s", dialect.Quote(sort), dir)
+	} else if params.Get("dir") != "" {
+		return view, fmt.Errorf("dir requires sort")
+	}
+
+	return view, nil
+}
+
+// columnKind classifies a database column type as "integer", "number",
+// "bool" or "text", or "" for types compared as given.
+func columnKind(sqlType string) string {
+	t := strings.ToLower(sqlType)
+	switch {
+	case strings.Contains(t, "int"):
+		return "integer"
+	case strings.Contains(t, "bool") || t == "bit":
+		return "bool"
+	case strings.Contains(t, "real"), strings.Contains(t, "float"), strings.Contains(t, "double"),
+		strings.Contains(t, "numeric"), strings.Contains(t, "decimal"), strings.Contains(t, "money"):
+		return "number"
+	case strings.Contains(t, "char"), strings.Contains(t, "text"), strings.Contains(t, "clob"):
+		return "text"
+	}
+	return ""
+}
+
+// filterValue converts a filter parameter to the Go value compared with a
+// column of sqlType.
+func filterValue(sqlType, raw string) (interface{}, error) {
+	switch columnKind(sqlType) {
+	case "integer":
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", raw)
+		}
+		return i, nil
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return f, nil
+	case "bool":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return b, nil
+	}
+	return raw, nil
+}
+
+// rowEdit is the body of a row edit. Key holds the primary key of the row
+// to update or delete, Values the columns to insert or update.
+type rowEdit struct {
+	Key    map[string]interface{} `json:"key"`
+	Values map[string]interface{} `json:"values"`
+}
+
+// editTableData inserts (POST), updates (PUT) or deletes (DELETE) a single
+// row. Rows are addressed by their full primary key, and every value is
+// sent as a query parameter.
+func (s *Server) editTableData(w http.ResponseWriter, r *http.Request, tableName string) {
+	var req rowEdit
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	columns, primaryKey, err := s.getTableKey(r.Context(), tableName)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if columns == nil {
+		s.jsonError(w, fmt.Sprintf("table %q not found", tableName), http.StatusNotFound)
+		return
+	}
+	for _, data := range []map[string]interface{}{req.Key, req.Values} {
+		for col, val := range data {
+			if !columns[col] {
+				s.jsonError(w, fmt.Sprintf("table %q has no column %q", tableName, col), http.StatusBadRequest)
+				return
+			}
+			data[col] = bindValue(val)
+		}
+	}
+
+	t, err := s.lookupTable(tableName)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	builder := query.New(s.conn, t.name).InSchema(t.namespace)
+	var affected int64
+	if r.Method == http.MethodPost {
+		if len(req.Values) == 0 {
+			s.jsonError(w, "Values are required", http.StatusBadRequest)
+			return
+		}
+		affected, err = builder.Insert(req.Values).Exec(r.Context())
+	} else {
+		if len(primaryKey) == 0 {
+			s.jsonError(w, fmt.Sprintf("table %q has no primary key to address rows by", tableName), http.StatusBadRequest)
+			return
+		}
+		conditions := make([]query.Condition, 0, len(primaryKey))
+		for _, col := range primaryKey {
+			val, ok := req.Key[col]
+			if !ok {
+				s.jsonError(w, fmt.Sprintf("key is missing primary key column %q", col), http.StatusBadRequest)
+				return
+			}
+			conditions = append(conditions, query.Eq(col, val))
+		}
+		if len(req.Key) != len(primaryKey) {
+			s.jsonError(w, fmt.Sprintf("key must only hold the primary key columns %s", strings.Join(primaryKey, ", ")), http.StatusBadRequest)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			if len(req.Values) == 0 {
+				s.jsonError(w, "Values are required", http.StatusBadRequest)
+				return
+			}
+			affected, err = builder.Update(req.Values).Where(conditions...).Exec(r.Context())
+		} else {
+			affected, err = builder.Delete().Where(conditions...).Exec(r.Context())
+		}
+	}
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if affected == 0 && r.Method != http.MethodPost {
+		s.jsonError(w, "Row not found", http.StatusNotFound)
+		return
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"rowsAffected": affected,
+	})
+}
+
+// statementResult is the outcome of one statement run from the query
+// editor.
+type statementResult struct {
+	Statement    string                   `json:"statement"`
+	Data         []map[string]interface{} `json:"data,omitempty"`
+	Columns      []string                 `json:"columns,omitempty"`
+	RowsAffected int64                    `json:"rowsAffected"`
+	Duration     int64                    `json:"duration"`
+	Error        string                   `json:"error,omitempty"`
+}
+
+// handleQuery executes the statements of a SQL query in order, stopping at
+// the first that fails. The response carries every statement's result, and
+// the last one's at the top level. With format "csv" or "json" the last
+// result set is sent as a download instead.
 func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if s.denyRawSQL(w) {
+		return
+	}
 
 	var req struct {
-		Query string `json:"query"`
+		Query   string `json:"query"`
+		Timeout int64  `json:"timeout"` // Milliseconds per statement
+		Format  string `json:"format"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if strings.TrimSpace(req.Query) == "" {
+	statements := migration.SplitStatements(req.Query)
+	if len(statements) == 0 {
 		s.jsonError(w, "Query is required", http.StatusBadRequest)
 		return
 	}
+	if req.Format != "" && req.Format != "csv" && req.Format != "json" {
+		s.jsonError(w, fmt.Sprintf("unsupported export format %q", req.Format), http.StatusBadRequest)
+		return
+	}
+	if s.readOnly {
+		for _, stmt := range statements {
+			if !isReadStatement(stmt) {
+				s.jsonError(w, fmt.Sprintf("Studio is read-only; only queries are allowed: %s", stmt), http.StatusForbidden)
+				return
+			}
+		}
+	}
+	timeout := s.timeout
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Millisecond
+	}
 
 	start := time.Now()
-	rows, columns, rowsAffected, err := s.executeQuery(req.Query)
+	results := make([]statementResult, 0, len(statements))
+	pii := s.queryPII()
+	var failed error
+	for _, stmt := range statements {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		stmtStart := time.Now()
+		rows, columns, rowsAffected, err := s.executeQuery(ctx, stmt)
+		cancel()
+		maskRows(rows, pii)
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("statement timed out after %s", timeout)
+		}
+
+		result := statementResult{
+			Statement:    stmt,
+			Data:         rows,
+			Columns:      columns,
+			RowsAffected: rowsAffected,
+			Duration:     time.Since(stmtStart).Milliseconds(),
+		}
+		if err != nil {
+			result.Error = err.Error()
+			failed = err
+		}
+		results = append(results, result)
+		if failed != nil {
+			break
+		}
+	}
 	duration := time.Since(start)
 
-	if err != nil {
-		s.jsonResponse(w, map[string]interface{}{
-			"error":    err.Error(),
-			"duration": duration.Milliseconds(),
-		})
+	entry := HistoryEntry{Query: req.Query, ExecutedAt: start, Duration: duration.Milliseconds()}
+	if failed != nil {
+		entry.Error = failed.Error()
+	}
+	historyErr := s.queries.record(entry)
+
+	if req.Format != "" {
+		if failed != nil {
+			s.jsonError(w, failed.Error(), http.StatusBadRequest)
+			return
+		}
+		s.exportResult(w, results, req.Format)
 		return
 	}
 
-	s.jsonResponse(w, map[string]interface{}{
-		"data":         rows,
-		"columns":      columns,
-		"rowsAffected": rowsAffected,
-		"duration":     duration.Milliseconds(),
-	})
+	last := results[len(results)-1]
+	resp := map[string]interface{}{
+		"results":  results,
+		"duration": duration.Milliseconds(),
+	}
+	if failed != nil {
+		resp["error"] = last.Error
+	} else {
+		resp["data"] = last.Data
+		resp["columns"] = last.Columns
+		resp["rowsAffected"] = last.RowsAffected
+	}
+	if historyErr != nil {
+		resp["historyError"] = historyErr.Error()
+	}
+	s.jsonResponse(w, resp)
+}
+
+// exportResult sends the last result set among results as a CSV or JSON
+// download.
+func (s *Server) exportResult(w http.ResponseWriter, results []statementResult, format string) {
+	var result *statementResult
+	for i := len(results) - 1; i >= 0 && result == nil; i-- {
+		if results[i].Columns != nil {
+			result = &results[i]
+		}
+	}
+	if result == nil {
+		s.jsonError(w, "Query returned no result set to export", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="query.%s"`, format))
+	if format == "json" {
+		data := result.Data
+		if data == nil {
+			data = []map[string]interface{}{}
+		}
+		s.jsonResponse(w, data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	cw := csv.NewWriter(w)
+	cw.Write(result.Columns)
+	record := make([]string, len(result.Columns))
+	for _, row := range result.Data {
+		for i, col := range result.Columns {
+			record[i] = csvValue(row[col])
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+}
+
+// csvValue formats a scanned value as a CSV field, with NULL as empty.
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// handleQueryHistory lists the query history on GET and clears it on
+// DELETE.
+func (s *Server) handleQueryHistory(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		history, err := s.queries.history()
+		if err != nil {
+			s.jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if history == nil {
+			history = []HistoryEntry{}
+		}
+		s.jsonResponse(w, map[string]interface{}{"history": history})
+	case http.MethodDelete:
+		if err := s.queries.clearHistory(); err != nil {
+			s.jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, map[string]interface{}{"history": []HistoryEntry{}})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSavedQueries lists the saved queries on GET and saves a query on
+// POST, replacing any with the same name.
+func (s *Server) handleSavedQueries(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		saved, err := s.queries.saved()
+		if err != nil {
+			s.jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if saved == nil {
+			saved = []SavedQuery{}
+		}
+		s.jsonResponse(w, map[string]interface{}{"queries": saved})
+	case http.MethodPost:
+		var req SavedQuery
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || strings.TrimSpace(req.Query) == "" {
+			s.jsonError(w, "Name and query are required", http.StatusBadRequest)
+			return
+		}
+		req.UpdatedAt = time.Now().UTC()
+		if err := s.queries.saveQuery(req); err != nil {
+			s.jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, req)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSavedQuery deletes the saved query named by the path.
+func (s *Server) handleSavedQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/api/query/saved/")
+	found, err := s.queries.deleteQuery(name)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		s.jsonError(w, fmt.Sprintf("saved query %q not found", name), http.StatusNotFound)
+		return
+	}
+	s.jsonResponse(w, map[string]interface{}{"deleted": name})
 }
 
 // handleSchema returns the full schema.
@@ -268,13 +948,19 @@ func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
 		for _, model := range s.schema.GetModels() {
 			fields := make([]map[string]interface{}, 0)
 			for _, field := range model.GetFields() {
+				def := field.DefaultValue
+				if field.DefaultExpr != "" {
+					def = field.DefaultExpr
+				}
 				fields = append(fields, map[string]interface{}{
 					"name":       field.Name,
 					"type":       field.Type.String(),
 					"nullable":   field.Nullable,
-					"primaryKey": field.PrimaryKey,
-					"unique":     field.Unique,
-					"default":    field.Default,
+					"primaryKey": field.IsPrimaryKey,
+					"unique":     field.IsUnique,
+					"default":    def,
+					"pii":        field.IsPII,
+					"comment":    field.Description,
 				})
 			}
 
@@ -289,8 +975,10 @@ func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
 
 			models = append(models, map[string]interface{}{
 				"name":      model.Name,
+				"table":     model.Table(),
 				"fields":    fields,
 				"relations": relations,
+				"comment":   model.Description,
 			})
 		}
 
@@ -324,14 +1012,25 @@ func (s *Server) handleMigrations(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		results := make(map[string]*migration.ValidationResult)
+		for _, m := range s.migrations.Migrations() {
+			results[m.ID] = migration.ValidateWithOptions(m, s.validateOptions())
+		}
+
 		migrations := make([]map[string]interface{}, 0)
 		for _, m := range status {
-			migrations = append(migrations, map[string]interface{}{
-				"id":        m.ID,
-				"name":      m.Name,
-				"applied":   m.Applied,
-				"appliedAt": m.AppliedAt,
-			})
+			entry := map[string]interface{}{
+				"id":         m.ID,
+				"name":       m.Name,
+				"applied":    m.Applied,
+				"appliedAt":  m.AppliedAt,
+				"outOfOrder": m.OutOfOrder,
+			}
+			if result := results[m.ID]; result != nil {
+				entry["errors"] = len(result.Errors())
+				entry["warnings"] = len(result.Warnings())
+			}
+			migrations = append(migrations, entry)
 		}
 
 		s.jsonResponse(w, map[string]interface{}{
@@ -339,8 +1038,12 @@ func (s *Server) handleMigrations(w http.ResponseWriter, r *http.Request) {
 		})
 
 	case http.MethodPost:
+		if s.denyReadOnly(w) {
+			return
+		}
 		var req struct {
-			Action string `json:"action"` // "up" or "down"
+			Action string `json:"action"` // "up", "down" or "downTo"
+			Target string `json:"target"` // Migration to roll back to for "downTo"
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
@@ -368,6 +1071,21 @@ func (s *Server) handleMigrations(w http.ResponseWriter, r *http.Request) {
 				"message": "Rolled back 1 migration",
 			})
 
+		case "downTo":
+			if req.Target == "" {
+				s.jsonError(w, "Target is required", http.StatusBadRequest)
+				return
+			}
+			count, err := s.migrations.DownTo(r.Context(), req.Target)
+			if err != nil {
+				s.jsonError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			s.jsonResponse(w, map[string]interface{}{
+				"rolledBack": count,
+				"message":    fmt.Sprintf("Rolled back %d migration(s) to %s", count, req.Target),
+			})
+
 		default:
 			s.jsonError(w, "Invalid action", http.StatusBadRequest)
 		}
@@ -390,8 +1108,10 @@ func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.jsonResponse(w, map[string]interface{}{
-		"dialect": dialect,
-		"version": "0.5.0",
+		"dialect":  dialect,
+		"version":  "0.5.0",
+		"readOnly": s.readOnly,
+		"rawSQL":   s.tables == nil,
 	})
 }
 
@@ -439,12 +1159,42 @@ func (s *Server) jsonResponse(w http.ResponseWriter, data interface{}) {
 }
 
 func (s *Server) jsonError(w http.ResponseWriter, message string, status int) {
+	if status >= http.StatusInternalServerError {
+		s.logger.Error("Request failed", nexuslog.F("status", status), nexuslog.F("error", message))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
 
+// studioTable is a table the studio lists: its name, and the schema it
+// lives in if that isn't the connection's default one.
+type studioTable struct {
+	namespace string
+	name      string
+}
+
+// String returns the name the studio lists the table by, qualified with
+// its schema if it has one, e.g. billing.invoices.
+func (t studioTable) String() string {
+	return schema.QualifiedTable(t.namespace, t.name)
+}
+
 func (s *Server) getTables() ([]string, error) {
+	list, err := s.listTables()
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for _, t := range list {
+		tables = append(tables, t.String())
+	}
+	return tables, nil
+}
+
+// listTables returns the tables the studio lists, those of the default
+// schema first.
+func (s *Server) listTables() ([]studioTable, error) {
 	if s.conn == nil {
 		return nil, fmt.Errorf("no database connection")
 	}
@@ -452,11 +1202,21 @@ func (s *Server) getTables() ([]string, error) {
 	var query string
 	switch s.conn.Dialect.Name() {
 	case "sqlite":
-		query = "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '_nexus_%' ORDER BY name"
+		query = "SELECT '', name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '_nexus_%' ORDER BY name"
 	case "postgres":
-		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = 'public' AND table_name NOT LIKE '_nexus_%' ORDER BY table_name"
+		// Tables of other schemas come with theirs
+		query = `SELECT CASE WHEN table_schema = current_schema() THEN '' ELSE table_schema END, table_name
+			FROM information_schema.tables
+			WHERE table_schema NOT IN ('pg_catalog', 'information_schema') AND table_schema NOT LIKE 'pg\_%' AND table_name NOT LIKE '_nexus_%'
+			ORDER BY table_schema <> current_schema(), table_schema, table_name`
 	case "mysql":
-		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name NOT LIKE '_nexus_%' ORDER BY table_name"
+		// Tables of other databases come with theirs
+		query = `SELECT CASE WHEN table_schema = DATABASE() THEN '' ELSE table_schema END, table_name
+			FROM information_schema.tables
+			WHERE table_schema NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys') AND table_name NOT LIKE '_nexus_%'
+			ORDER BY table_schema <> DATABASE(), table_schema, table_name`
+	case "mssql":
+		query = "SELECT '', name FROM sys.tables WHERE schema_id = SCHEMA_ID() AND is_ms_shipped = 0 AND name NOT LIKE '[_]nexus[_]%' ORDER BY name"
 	default:
 		return nil, fmt.Errorf("unsupported dialect")
 	}
@@ -467,36 +1227,64 @@ func (s *Server) getTables() ([]string, error) {
 	}
 	defer rows.Close()
 
-	var tables []string
+	var tables []studioTable
 	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
+		var t studioTable
+		if err := rows.Scan(&t.namespace, &t.name); err != nil {
 			return nil, err
 		}
-		tables = append(tables, name)
+		if s.tableVisible(t.String()) {
+			tables = append(tables, t)
+		}
 	}
 
 	return tables, rows.Err()
 }
 
+// lookupTable finds a table from a request among those the studio lists,
+// failing with dialects.ErrUnknownIdentifier if it isn't one of them.
+func (s *Server) lookupTable(tableName string) (studioTable, error) {
+	tables, err := s.listTables()
+	if err != nil {
+		return studioTable{}, err
+	}
+	for _, t := range tables {
+		if t.String() == tableName {
+			return t, nil
+		}
+	}
+	return studioTable{}, fmt.Errorf("%w: %q", dialects.ErrUnknownIdentifier, tableName)
+}
+
 func (s *Server) getTableColumns(tableName string) ([]map[string]interface{}, error) {
 	if s.conn == nil {
 		return nil, fmt.Errorf("no database connection")
 	}
 
+	// The table name is sent as a parameter, never interpolated
 	var query string
+	args := []interface{}{tableName}
+	t, err := s.lookupTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	namespace, table := t.namespace, t.name
 	switch s.conn.Dialect.Name() {
 	case "sqlite":
-		query = fmt.Sprintf("PRAGMA table_info(%s)", tableName)
+		query = `SELECT cid, name, type, "notnull", dflt_value, pk FROM pragma_table_info(?)`
 	case "postgres":
-		query = fmt.Sprintf("SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_name = '%s' ORDER BY ordinal_position", tableName)
+		query = "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_name = $1 AND table_schema = COALESCE(NULLIF($2, ''), current_schema()) ORDER BY ordinal_position"
+		args = []interface{}{table, namespace}
 	case "mysql":
-		query = fmt.Sprintf("SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_name = '%s' AND table_schema = DATABASE() ORDER BY ordinal_position", tableName)
+		query = "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_name = ? AND table_schema = COALESCE(NULLIF(?, ''), DATABASE()) ORDER BY ordinal_position"
+		args = []interface{}{table, namespace}
+	case "mssql":
+		query = "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_name = @p1 AND table_schema = SCHEMA_NAME() ORDER BY ordinal_position"
 	default:
 		return nil, fmt.Errorf("unsupported dialect")
 	}
 
-	rows, err := s.conn.DB.Query(query)
+	rows, err := s.conn.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -538,26 +1326,113 @@ func (s *Server) getTableColumns(tableName string) ([]map[string]interface{}, er
 	return columns, rows.Err()
 }
 
-func (s *Server) getTableRowCount(tableName string) (int, error) {
+// tableSet returns the tables the studio lists, which table names from
+// requests are checked against before they are put in SQL.
+func (s *Server) tableSet() (*dialects.IdentifierSet, error) {
+	tables, err := s.getTables()
+	if err != nil {
+		return nil, err
+	}
+	return dialects.NewIdentifierSet(tables), nil
+}
+
+// quoteTable quotes a table name from a request, failing with
+// dialects.ErrUnknownIdentifier if it isn't a table the studio lists.
+func (s *Server) quoteTable(tableName string) (string, error) {
+	t, err := s.lookupTable(tableName)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range []string{t.namespace, t.name} {
+		if name == "" {
+			continue
+		}
+		if err := dialects.ValidateIdentifier(name); err != nil {
+			return "", err
+		}
+	}
+	return s.conn.Dialect.QuoteQualified(t.namespace, t.name), nil
+}
+
+// getColumnInfo introspects the columns of a table, returning nil if the
+// table isn't one the studio lists.
+func (s *Server) getColumnInfo(ctx context.Context, tableName string) ([]*migration.ColumnInfo, error) {
+	tables, err := s.tableSet()
+	if err != nil {
+		return nil, err
+	}
+	if !tables.Contains(tableName) {
+		return nil, nil
+	}
+
+	introspector, ok := s.conn.Dialect.(migration.Introspector)
+	if !ok {
+		return nil, fmt.Errorf("dialect %s does not support introspection", s.conn.Dialect.Name())
+	}
+	return introspector.IntrospectColumns(ctx, s.conn.DB, tableName)
+}
+
+// getTableKey returns the columns and primary key of a table, or nil
+// columns if the table isn't one the studio lists.
+func (s *Server) getTableKey(ctx context.Context, tableName string) (map[string]bool, []string, error) {
+	infos, err := s.getColumnInfo(ctx, tableName)
+	if err != nil || infos == nil {
+		return nil, nil, err
+	}
+
+	columns := make(map[string]bool, len(infos))
+	var primaryKey []string
+	for _, col := range infos {
+		columns[col.Name] = true
+		if col.IsPrimaryKey {
+			primaryKey = append(primaryKey, col.Name)
+		}
+	}
+	return columns, primaryKey, nil
+}
+
+// bindValue converts a JSON number to an int64 when it is whole, so
+// integer columns are compared with integers.
+func bindValue(v interface{}) interface{} {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+func (s *Server) getTableRowCount(tableName string, view dataView) (int, error) {
 	if s.conn == nil {
 		return 0, fmt.Errorf("no database connection")
 	}
 
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", s.conn.Dialect.Quote(tableName))
+	table, err := s.quoteTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+	query := "SELECT COUNT(*) FROM " + table + view.where
 	var count int
-	err := s.conn.DB.QueryRow(query).Scan(&count)
+	err = s.conn.DB.QueryRow(query, view.args...).Scan(&count)
 	return count, err
 }
 
-func (s *Server) getTableData(tableName string, limit, offset int) ([]map[string]interface{}, []string, error) {
+func (s *Server) getTableData(tableName string, view dataView, limit, offset int) ([]map[string]interface{}, []string, error) {
 	if s.conn == nil {
 		return nil, nil, fmt.Errorf("no database connection")
 	}
 
-	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d OFFSET %d",
-		s.conn.Dialect.Quote(tableName), limit, offset)
+	table, err := s.quoteTable(tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	query := "SELECT * FROM " + table + view.where + view.order +
+		dialects.LimitClause(s.conn.Dialect, limit, offset, view.order != "")
 
-	rows, err := s.conn.DB.Query(query)
+	rows, err := s.conn.DB.Query(query, view.args...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -590,7 +1465,26 @@ func (s *Server) getTableData(tableName string, limit, offset int) ([]map[string
 	return results, columns, rows.Err()
 }
 
-func (s *Server) executeQuery(query string) ([]map[string]interface{}, []string, int64, error) {
+// writeKeyword matches keywords of statements and clauses that change the
+// database, including SELECT ... INTO and data-modifying CTEs.
+var writeKeyword = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE|UPSERT|REPLACE|INTO|CREATE|ALTER|DROP|TRUNCATE|RENAME|GRANT|REVOKE|ATTACH|DETACH|VACUUM|REINDEX|ANALYZE|COPY|CALL|EXEC|EXECUTE|LOCK|SET)\b`)
+
+// firstKeyword matches the keyword a statement starts with.
+var firstKeyword = regexp.MustCompile(`^\s*[A-Za-z]+`)
+
+// isReadStatement reports whether stmt only reads the database. It is
+// conservative: a query mentioning a write keyword anywhere, even in a
+// string, is not treated as a read.
+func isReadStatement(stmt string) bool {
+	first := firstKeyword.FindString(stmt)
+	switch strings.ToUpper(strings.TrimSpace(first)) {
+	case "SELECT", "WITH", "VALUES", "SHOW", "DESCRIBE", "EXPLAIN":
+		return !writeKeyword.MatchString(stmt)
+	}
+	return false
+}
+
+func (s *Server) executeQuery(ctx context.Context, query string) ([]map[string]interface{}, []string, int64, error) {
 	if s.conn == nil {
 		return nil, nil, 0, fmt.Errorf("no database connection")
 	}
@@ -605,7 +1499,7 @@ func (s *Server) executeQuery(query string) ([]map[string]interface{}, []string,
 		strings.HasPrefix(trimmedQuery, "EXPLAIN")
 
 	if isSelect {
-		rows, err := s.conn.DB.Query(query)
+		rows, err := s.conn.DB.QueryContext(ctx, query)
 		if err != nil {
 			return nil, nil, 0, err
 		}
@@ -639,7 +1533,7 @@ func (s *Server) executeQuery(query string) ([]map[string]interface{}, []string,
 	}
 
 	// Execute non-SELECT statement
-	result, err := s.conn.DB.Exec(query)
+	result, err := s.conn.DB.ExecContext(ctx, query)
 	if err != nil {
 		return nil, nil, 0, err
 	}
@@ -0,0 +1,24 @@
+// Package yamlline holds helpers of the line-based readers of the small
+// YAML subsets Nexus reads, such as seed data and plan checks.
+package yamlline
+
+// StripComment removes a # comment outside quotes.
+func StripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,294 @@
+// Package nexus connects an application to its database. Open ties the
+// schema, the migrations and the query builders to one pooled connection:
+//
+//	client, err := nexus.Open(ctx, nexus.Config{
+//		URL:        os.Getenv("DATABASE_URL"),
+//		SchemaPath: "schema.nexus",
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer client.Close()
+//
+//	if _, err := client.Migrate.Up(ctx); err != nil {
+//		log.Fatal(err)
+//	}
+//	users, err := client.Model("User").Select().Where(query.Eq("active", true)).All(ctx)
+//
+// Code generated by nexus gen takes client.Conn.
+package nexus
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/connection"
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// Config configures a client.
+type Config struct {
+	// URL is the database to connect to: postgres://..., mysql://...,
+	// sqlserver://..., libsql://..., sqlite:<path> or a SQLite file. With
+	// Dialect set, it is passed to the driver as is.
+	URL string
+	// Dialect is postgres, sqlite, mysql, mssql or libsql. Empty infers it
+	// from URL.
+	Dialect string
+
+	// Schema holds the models of Model. SchemaPath, a schema file or
+	// directory, is parsed when Schema is nil. Both may be empty.
+	Schema     *schema.Schema
+	SchemaPath string
+
+	// MigrationsDir is where Migrate loads migrations from; it defaults to
+	// "migrations".
+	MigrationsDir string
+	// MigrationsFS, when set, is loaded instead of MigrationsDir, so that a
+	// binary can embed its migrations:
+	//
+	//	//go:embed migrations/*.sql
+	//	var files embed.FS
+	//
+	//	migrations, _ := fs.Sub(files, "migrations")
+	//	client, err := nexus.Open(ctx, nexus.Config{URL: url, MigrationsFS: migrations})
+	MigrationsFS fs.FS
+
+	// Pool sizes the connection pool. The zero value uses
+	// connection.DefaultPoolConfig, with a single connection for in-memory
+	// SQLite databases, which exist once per connection.
+	Pool connection.PoolConfig
+
+	// Logger receives the logs of the migration engine. Nil discards them.
+	Logger nexuslog.Logger
+
+	// AutoMigrate enables Client.AutoMigrate. Set it in development only.
+	AutoMigrate bool
+}
+
+// Client is a connection to a database with its schema and migrations.
+type Client struct {
+	// Conn is the connection the client's builders use, for code
+	// generated by nexus gen and the packages of pkg.
+	Conn *dialects.Connection
+	// Schema is the schema of the client, nil if it has none.
+	Schema *schema.Schema
+	// Migrate runs the migrations of Config.MigrationsDir or MigrationsFS.
+	Migrate *Migrator
+
+	pool        *connection.Pool
+	autoMigrate bool
+}
+
+// Open connects to the database of cfg and checks that it is reachable.
+func Open(ctx context.Context, cfg Config) (*Client, error) {
+	name, dsn := cfg.Dialect, cfg.URL
+	if name == "" {
+		var err error
+		if name, dsn, err = dialects.ParseURL(cfg.URL); err != nil {
+			return nil, err
+		}
+	}
+	dialect, err := dialectByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	sch := cfg.Schema
+	if sch == nil && cfg.SchemaPath != "" {
+		if sch, err = schema.ParseFile(cfg.SchemaPath); err != nil {
+			return nil, fmt.Errorf("parsing schema: %w", err)
+		}
+		if err := sch.Validate(); err != nil {
+			return nil, fmt.Errorf("validating schema: %w", err)
+		}
+	}
+
+	db, err := open(dialect, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to database: %w", err)
+	}
+	poolConfig := cfg.Pool
+	if poolConfig == (connection.PoolConfig{}) {
+		poolConfig = connection.DefaultPoolConfig()
+		if dialect.Name() == "sqlite" && isMemory(dsn) {
+			poolConfig.MaxOpenConns = 1
+			poolConfig.MaxIdleConns = 1
+			poolConfig.ConnMaxLifetime = 0
+			poolConfig.ConnMaxIdleTime = 0
+		}
+	}
+	pool := connection.NewPool(db, poolConfig)
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("pinging database: %w", err)
+	}
+
+	conn := dialects.NewConnection(db, dialect)
+	dir := cfg.MigrationsDir
+	if dir == "" {
+		dir = "migrations"
+	}
+	engine := migration.NewEngine(conn)
+	if cfg.Logger != nil {
+		engine.WithLogger(cfg.Logger)
+	}
+
+	return &Client{
+		Conn:        conn,
+		Schema:      sch,
+		Migrate:     &Migrator{engine: engine, dir: dir, fsys: cfg.MigrationsFS},
+		pool:        pool,
+		autoMigrate: cfg.AutoMigrate,
+	}, nil
+}
+
+// Model returns a query builder for the table of a model of the schema,
+// or for the table named name if the schema has no such model.
+func (c *Client) Model(name string) *query.Builder {
+	table := name
+	if c.Schema != nil {
+		if model, ok := c.Schema.Models[name]; ok {
+			table = model.Table()
+		}
+	}
+	return query.NewWithSchema(c.Conn, table, c.Schema)
+}
+
+// AutoMigrate creates the tables, columns and indexes of the schema that
+// the database lacks, without writing migrations, and reports the
+// destructive changes it left out. It fails unless Config.AutoMigrate is
+// set, so that it only runs where it is enabled.
+func (c *Client) AutoMigrate(ctx context.Context) (*migration.AutoMigrateResult, error) {
+	if !c.autoMigrate {
+		return nil, fmt.Errorf("auto-migration is disabled (enable Config.AutoMigrate in development)")
+	}
+	if c.Schema == nil {
+		return nil, fmt.Errorf("auto-migration needs a schema (set Config.Schema or Config.SchemaPath)")
+	}
+	return c.Migrate.engine.AutoMigrate(ctx, c.Schema)
+}
+
+// Tx runs fn in a transaction, committed if fn returns nil and rolled
+// back otherwise.
+func (c *Client) Tx(ctx context.Context, fn func(tx *dialects.Tx) error) error {
+	return query.Transaction(ctx, c.Conn, fn)
+}
+
+// Ping checks that the database is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.pool.Ping(ctx)
+}
+
+// Stats returns the statistics of the connection pool.
+func (c *Client) Stats() sql.DBStats {
+	return c.pool.Stats()
+}
+
+// Close closes the connections of the client.
+func (c *Client) Close() error {
+	return c.pool.Close()
+}
+
+// Migrator runs the migrations of a client.
+type Migrator struct {
+	engine *migration.Engine
+	dir    string
+	fsys   fs.FS
+	loaded bool
+}
+
+// load creates the migrations table and loads the migrations, once. A
+// missing migrations directory has no migrations.
+func (m *Migrator) load(ctx context.Context) error {
+	if m.loaded {
+		return nil
+	}
+	if err := m.engine.Init(ctx); err != nil {
+		return fmt.Errorf("initializing migrations: %w", err)
+	}
+	var err error
+	if m.fsys != nil {
+		err = m.engine.LoadFromFS(m.fsys)
+	} else if err = m.engine.LoadFromDir(m.dir); os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+	m.loaded = true
+	return nil
+}
+
+// Up applies the pending migrations and returns how many it applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	if err := m.load(ctx); err != nil {
+		return 0, err
+	}
+	return m.engine.Up(ctx)
+}
+
+// Down rolls back the last applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	if err := m.load(ctx); err != nil {
+		return err
+	}
+	return m.engine.Down(ctx)
+}
+
+// Status returns the status of every migration.
+func (m *Migrator) Status(ctx context.Context) ([]migration.MigrationStatus, error) {
+	if err := m.load(ctx); err != nil {
+		return nil, err
+	}
+	return m.engine.Status(ctx)
+}
+
+// Engine returns the migration engine, for locking and the other
+// operations Migrator doesn't wrap.
+func (m *Migrator) Engine() *migration.Engine {
+	return m.engine
+}
+
+// dialectByName returns the dialect named name.
+func dialectByName(name string) (dialects.Dialect, error) {
+	switch strings.ToLower(name) {
+	case "postgres", "postgresql":
+		return postgres.New(), nil
+	case "sqlite", "sqlite3":
+		return sqlite.New(), nil
+	case "mysql":
+		return mysql.New(), nil
+	case "mssql", "sqlserver":
+		return mssql.New(), nil
+	case "libsql", "turso":
+		return sqlite.NewLibSQL(), nil
+	}
+	return nil, fmt.Errorf("unknown dialect: %s (supported: postgres, sqlite, mysql, mssql, libsql)", name)
+}
+
+// open opens the database of dsn with the driver of dialect. libSQL
+// databases use $TURSO_AUTH_TOKEN.
+func open(dialect dialects.Dialect, dsn string) (*sql.DB, error) {
+	if dialect.DriverName() == "libsql" {
+		return sqlite.OpenLibSQL(sqlite.LibSQLOptions{URL: dsn, AuthToken: os.Getenv("TURSO_AUTH_TOKEN")})
+	}
+	return sql.Open(dialect.DriverName(), dsn)
+}
+
+// isMemory reports whether a SQLite DSN is an in-memory database.
+func isMemory(dsn string) bool {
+	return strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
@@ -0,0 +1,397 @@
+// Package bench replays a workload against a database and reports the
+// throughput and latency of each query pattern, to check the effect of an
+// index change or a move to another dialect before it reaches production.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// Options configures a benchmark run.
+type Options struct {
+	// Concurrency is the number of workers running statements at once.
+	Concurrency int
+	// Duration stops the run after this long. Ignored when Iterations is
+	// set.
+	Duration time.Duration
+	// Iterations stops the run after this many statements. For a
+	// sequential workload one pass over the capture is len(Queries).
+	Iterations int
+	// AllowWrites runs statements other than queries. They are skipped by
+	// default, since replaying them changes the target database.
+	AllowWrites bool
+}
+
+// DefaultOptions returns options running 4 workers for 10 seconds.
+func DefaultOptions() Options {
+	return Options{
+		Concurrency: 4,
+		Duration:    10 * time.Second,
+	}
+}
+
+// Report is the result of a benchmark run.
+type Report struct {
+	Dialect     string
+	Concurrency int
+	Elapsed     time.Duration
+	// Total is the number of statements run, and Errors how many failed.
+	Total  int
+	Errors int
+	// Skipped is the number of workload statements left out because they
+	// write and writes were not allowed.
+	Skipped int
+	// Throughput is statements per second.
+	Throughput float64
+	// Patterns are sorted by the total time spent in them, most first.
+	Patterns []PatternReport
+}
+
+// PatternReport is the latency of a query pattern.
+type PatternReport struct {
+	Pattern    string
+	Count      int
+	Errors     int
+	Throughput float64
+	Mean       time.Duration
+	P50        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	// Baseline is the mean captured duration, zero if unknown.
+	Baseline time.Duration
+	// FirstError is the first error of the pattern, to tell why it failed.
+	FirstError string
+}
+
+// statement is a workload query prepared for the target.
+type statement struct {
+	pattern string
+	sql     string
+	args    []interface{}
+	read    bool
+	weight  int
+}
+
+// samples are the latencies a worker measured for a pattern.
+type samples struct {
+	latencies  []time.Duration
+	errors     int
+	firstError string
+}
+
+// Run runs w against conn until opts.Duration passes, opts.Iterations
+// statements have run or ctx is done, and reports what was measured.
+func Run(ctx context.Context, conn *dialects.Connection, w *Workload, opts Options) (*Report, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Iterations <= 0 && opts.Duration <= 0 {
+		return nil, fmt.Errorf("either a duration or a number of iterations is required")
+	}
+
+	report := &Report{Dialect: conn.Dialect.Name(), Concurrency: opts.Concurrency}
+	var statements []statement
+	baselines := make(map[string][]time.Duration)
+	totalWeight := 0
+	for _, q := range w.Queries {
+		read := isRead(q.SQL)
+		if !read && !opts.AllowWrites {
+			report.Skipped++
+			continue
+		}
+		weight := q.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		totalWeight += weight
+		statements = append(statements, statement{
+			pattern: q.pattern(),
+			sql:     rebind(q.SQL, conn.Dialect),
+			args:    q.Args,
+			read:    read,
+			weight:  weight,
+		})
+		if q.Baseline > 0 {
+			baselines[q.pattern()] = append(baselines[q.pattern()], q.Baseline)
+		}
+	}
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("every statement of the workload writes; allow writes to run them")
+	}
+
+	if opts.Iterations <= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	// next picks the statement a worker runs next, or false when the run
+	// is over
+	var counter atomic.Int64
+	next := func() (*statement, bool) {
+		n := counter.Add(1) - 1
+		if opts.Iterations > 0 && n >= int64(opts.Iterations) {
+			return nil, false
+		}
+		if w.Sequential {
+			return &statements[n%int64(len(statements))], true
+		}
+		pick := rand.IntN(totalWeight)
+		for i := range statements {
+			if pick < statements[i].weight {
+				return &statements[i], true
+			}
+			pick -= statements[i].weight
+		}
+		return &statements[len(statements)-1], true
+	}
+
+	results := make([]map[string]*samples, opts.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range results {
+		results[i] = make(map[string]*samples)
+		wg.Add(1)
+		go func(measured map[string]*samples) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				stmt, ok := next()
+				if !ok {
+					return
+				}
+				began := time.Now()
+				err := run(ctx, conn, stmt)
+				elapsed := time.Since(began)
+				// Statements cut short by the end of the run don't count
+				if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+					return
+				}
+
+				s := measured[stmt.pattern]
+				if s == nil {
+					s = &samples{}
+					measured[stmt.pattern] = s
+				}
+				s.latencies = append(s.latencies, elapsed)
+				if err != nil {
+					if s.errors == 0 {
+						s.firstError = err.Error()
+					}
+					s.errors++
+				}
+			}
+		}(results[i])
+	}
+	wg.Wait()
+	report.Elapsed = time.Since(start)
+
+	merged := make(map[string]*samples)
+	for _, measured := range results {
+		for pattern, s := range measured {
+			m := merged[pattern]
+			if m == nil {
+				merged[pattern] = s
+				continue
+			}
+			m.latencies = append(m.latencies, s.latencies...)
+			if m.errors == 0 {
+				m.firstError = s.firstError
+			}
+			m.errors += s.errors
+		}
+	}
+
+	seconds := report.Elapsed.Seconds()
+	totals := make(map[string]time.Duration)
+	for pattern, s := range merged {
+		p := summarize(pattern, s, seconds)
+		p.Baseline = mean(baselines[pattern])
+		report.Patterns = append(report.Patterns, p)
+		report.Total += p.Count
+		report.Errors += p.Errors
+		totals[pattern] = p.Mean * time.Duration(p.Count)
+	}
+	sort.Slice(report.Patterns, func(i, j int) bool {
+		a, b := report.Patterns[i], report.Patterns[j]
+		if totals[a.Pattern] != totals[b.Pattern] {
+			return totals[a.Pattern] > totals[b.Pattern]
+		}
+		return a.Pattern < b.Pattern
+	})
+	if seconds > 0 {
+		report.Throughput = float64(report.Total) / seconds
+	}
+	return report, nil
+}
+
+// run runs a statement, reading every row a query returns.
+func run(ctx context.Context, conn *dialects.Connection, stmt *statement) error {
+	if !stmt.read {
+		_, err := conn.Exec(ctx, stmt.sql, stmt.args...)
+		return err
+	}
+	rows, err := conn.Query(ctx, stmt.sql, stmt.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+// summarize computes the latency percentiles of a pattern.
+func summarize(pattern string, s *samples, seconds float64) PatternReport {
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	p := PatternReport{
+		Pattern:    pattern,
+		Count:      len(s.latencies),
+		Errors:     s.errors,
+		Mean:       mean(s.latencies),
+		P50:        percentile(s.latencies, 0.50),
+		P95:        percentile(s.latencies, 0.95),
+		P99:        percentile(s.latencies, 0.99),
+		Max:        s.latencies[len(s.latencies)-1],
+		FirstError: s.firstError,
+	}
+	if seconds > 0 {
+		p.Throughput = float64(p.Count) / seconds
+	}
+	return p
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func mean(ds []time.Duration) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range ds {
+		total += d
+	}
+	return total / time.Duration(len(ds))
+}
+
+// readKeyword matches the first keyword of statements that only read.
+var readKeyword = regexp.MustCompile(`(?i)^\s*(SELECT|WITH|SHOW|EXPLAIN|VALUES|TABLE)\b`)
+
+// writeKeyword matches keywords of a WITH statement that writes.
+var writeKeyword = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE)\b`)
+
+// isRead reports whether sql only reads.
+func isRead(sql string) bool {
+	if !readKeyword.MatchString(sql) {
+		return false
+	}
+	return !writeKeyword.MatchString(sql)
+}
+
+// rebind converts the placeholders of sql, written for any dialect, to
+// those of d. ? placeholders are numbered in order; $n and @pn keep their
+// number. Placeholders in string literals and quoted names are left alone.
+func rebind(sql string, d dialects.Dialect) string {
+	var b strings.Builder
+	var quote byte
+	n := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			b.WriteByte(c)
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		case c == '$' || (c == '@' && i+1 < len(sql) && sql[i+1] == 'p'):
+			start := i + 1
+			if c == '@' {
+				start++
+			}
+			end := start
+			for end < len(sql) && sql[end] >= '0' && sql[end] <= '9' {
+				end++
+			}
+			if end > start {
+				var index int
+				fmt.Sscan(sql[start:end], &index)
+				b.WriteString(d.Placeholder(index))
+				i = end - 1
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// String returns the report as a table.
+func (r *Report) String() string {
+	var sb strings.Builder
+	ms := func(d time.Duration) string {
+		return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+	}
+
+	sb.WriteString("\n📊 Benchmark Report\n")
+	sb.WriteString(strings.Repeat("─", 50) + "\n\n")
+	sb.WriteString(fmt.Sprintf("Dialect:          %s\n", r.Dialect))
+	sb.WriteString(fmt.Sprintf("Concurrency:      %d\n", r.Concurrency))
+	sb.WriteString(fmt.Sprintf("Duration:         %s\n", r.Elapsed.Round(time.Millisecond)))
+	sb.WriteString(fmt.Sprintf("Statements:       %d\n", r.Total))
+	sb.WriteString(fmt.Sprintf("Throughput:       %.1f/s\n", r.Throughput))
+	sb.WriteString(fmt.Sprintf("Errors:           %d\n", r.Errors))
+	if r.Skipped > 0 {
+		sb.WriteString(fmt.Sprintf("Skipped writes:   %d\n", r.Skipped))
+	}
+
+	for _, p := range r.Patterns {
+		sb.WriteString(fmt.Sprintf("\n• %s\n", truncate(p.Pattern, 70)))
+		sb.WriteString(fmt.Sprintf("   %d runs, %.1f/s, mean %s, p50 %s, p95 %s, p99 %s, max %s\n",
+			p.Count, p.Throughput, ms(p.Mean), ms(p.P50), ms(p.P95), ms(p.P99), ms(p.Max)))
+		if p.Baseline > 0 {
+			sb.WriteString(fmt.Sprintf("   captured mean %s (%+.0f%%)\n",
+				ms(p.Baseline), (float64(p.Mean)/float64(p.Baseline)-1)*100))
+		}
+		if p.Errors > 0 {
+			sb.WriteString(fmt.Sprintf("   ✗ %d errors, first: %s\n", p.Errors, p.FirstError))
+		}
+	}
+
+	sb.WriteString("\n" + strings.Repeat("─", 50) + "\n")
+	return sb.String()
+}
+
+// truncate shortens a pattern for display.
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
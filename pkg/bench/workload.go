@@ -0,0 +1,232 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// Workload is the statements a benchmark runs.
+type Workload struct {
+	Queries []Query
+	// Sequential replays the queries in order, as captured, instead of
+	// picking them at random by weight.
+	Sequential bool
+}
+
+// Query is a statement of a workload.
+type Query struct {
+	// Name groups the statement in the report; it defaults to the SQL.
+	Name string
+	// SQL uses ?, $1 or @p1 placeholders, which are converted to the
+	// placeholders of the target dialect.
+	SQL  string
+	Args []interface{}
+	// Weight is how often the statement is picked relative to the others
+	// of a random workload; zero counts as one.
+	Weight int
+	// Baseline is how long the statement took when it was captured, to
+	// compare the replay against. Zero if unknown.
+	Baseline time.Duration
+}
+
+// pattern returns the name the statement is reported under.
+func (q Query) pattern() string {
+	if q.Name != "" {
+		return q.Name
+	}
+	return strings.Join(strings.Fields(q.SQL), " ")
+}
+
+// LoadWorkload reads a workload from a JSON profiler capture (written by
+// query.Profiler.WriteCapture) or a YAML workload spec.
+func LoadWorkload(path string) (*Workload, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var w *Workload
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		w, err = parseCapture(content)
+	case ".yaml", ".yml":
+		w, err = parseSpec(string(content))
+	default:
+		return nil, fmt.Errorf("unsupported workload file %s (use a .json capture or a .yaml spec)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(w.Queries) == 0 {
+		return nil, fmt.Errorf("%s: workload has no queries", path)
+	}
+	return w, nil
+}
+
+// FromCapture converts a profiler capture to a workload replaying its
+// statements in order.
+func FromCapture(capture *query.ProfileCapture) *Workload {
+	w := &Workload{Sequential: true}
+	for _, q := range capture.Queries {
+		args := make([]interface{}, len(q.Args))
+		for i, arg := range q.Args {
+			args[i] = jsonArg(arg)
+		}
+		w.Queries = append(w.Queries, Query{
+			SQL:      q.SQL,
+			Args:     args,
+			Baseline: q.Duration,
+		})
+	}
+	return w
+}
+
+func parseCapture(content []byte) (*Workload, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var capture query.ProfileCapture
+	if err := dec.Decode(&capture); err != nil {
+		return nil, fmt.Errorf("expected a profiler capture: %w", err)
+	}
+	return FromCapture(&capture), nil
+}
+
+// jsonArg converts a decoded JSON argument to a value drivers accept:
+// integers to int64 and other numbers to float64.
+func jsonArg(v interface{}) interface{} {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// parseSpec reads a YAML workload spec, a sequence of queries:
+//
+//   - name: user by id
+//     sql: SELECT * FROM users WHERE id = ?
+//     args: [42]
+//     weight: 5
+//
+// Values are scalars, except args which is a flow sequence of JSON values.
+func parseSpec(content string) (*Workload, error) {
+	w := &Workload{}
+	var q *Query
+	itemIndent := -1
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		line = strings.TrimRight(stripComment(line), " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			if itemIndent >= 0 && indent != itemIndent {
+				return nil, fmt.Errorf("line %d: nested lists are not supported", lineNo)
+			}
+			itemIndent = indent
+			w.Queries = append(w.Queries, Query{})
+			q = &w.Queries[len(w.Queries)-1]
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			if trimmed == "" {
+				continue
+			}
+		} else if q == nil || indent <= itemIndent {
+			return nil, fmt.Errorf("line %d: expected a list item (\"- sql: ...\")", lineNo)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || (value != "" && value[0] != ' ' && value[0] != '\t') {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		if err := q.set(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+
+	for i, q := range w.Queries {
+		if q.SQL == "" {
+			return nil, fmt.Errorf("query %d has no sql", i+1)
+		}
+	}
+	return w, nil
+}
+
+// set sets a key of a query from the spec.
+func (q *Query) set(key, value string) error {
+	switch key {
+	case "name":
+		q.Name = unquote(value)
+	case "sql":
+		q.SQL = unquote(value)
+	case "weight":
+		weight, err := strconv.Atoi(value)
+		if err != nil || weight < 0 {
+			return fmt.Errorf("invalid weight %q", value)
+		}
+		q.Weight = weight
+	case "args":
+		dec := json.NewDecoder(strings.NewReader(value))
+		dec.UseNumber()
+		var args []interface{}
+		if err := dec.Decode(&args); err != nil {
+			return fmt.Errorf("args must be a list such as [1, \"a\"]: %w", err)
+		}
+		for i, arg := range args {
+			args[i] = jsonArg(arg)
+		}
+		q.Args = args
+	default:
+		return fmt.Errorf("unknown key %q (expected name, sql, args or weight)", key)
+	}
+	return nil
+}
+
+// unquote removes the quotes of a quoted scalar.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+// stripComment removes a # comment outside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
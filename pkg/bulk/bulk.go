@@ -0,0 +1,307 @@
+// Package bulk loads many rows into a table with the fastest method the
+// database offers: COPY FROM STDIN on PostgreSQL, LOAD DATA LOCAL INFILE on
+// MySQL and multi-row INSERTs everywhere else.
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// Method is how rows are sent to the database.
+type Method string
+
+const (
+	MethodAuto     Method = "auto"      // The fastest method the dialect and driver support
+	MethodCopy     Method = "copy"      // COPY FROM STDIN, PostgreSQL with github.com/lib/pq
+	MethodLoadData Method = "load-data" // LOAD DATA LOCAL INFILE, MySQL (see UseMySQLReaders)
+	MethodInsert   Method = "insert"    // Multi-row INSERT statements
+)
+
+// ParseMethod parses a method name; empty is MethodAuto.
+func ParseMethod(name string) (Method, error) {
+	switch m := Method(strings.ToLower(name)); m {
+	case "":
+		return MethodAuto, nil
+	case MethodAuto, MethodCopy, MethodLoadData, MethodInsert:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown bulk method %q (expected auto, copy, load-data or insert)", name)
+}
+
+// maxParams keeps batched INSERTs under the parameter limit of SQLite.
+const maxParams = 900
+
+// Options configures a bulk load.
+type Options struct {
+	Method    Method // How rows are sent (default auto)
+	BatchSize int    // Rows per INSERT (default 100) or per LOAD DATA (default 10000)
+	Identity  bool   // Columns include an IDENTITY column, which SQL Server only fills with IDENTITY_INSERT on
+}
+
+// DefaultOptions returns the default bulk options.
+func DefaultOptions() Options {
+	return Options{Method: MethodAuto}
+}
+
+func (o Options) batchSize(method Method) int {
+	if o.BatchSize > 0 {
+		return o.BatchSize
+	}
+	if method == MethodLoadData {
+		return 10000
+	}
+	return 100
+}
+
+// Rows iterates over the rows to load, like sql.Rows: Values returns the
+// current row after Next, in the order of Columns.
+type Rows interface {
+	Columns() []string
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+// NewRows returns Rows over values.
+func NewRows(columns []string, values [][]interface{}) Rows {
+	return &sliceRows{columns: columns, values: values, pos: -1}
+}
+
+type sliceRows struct {
+	columns []string
+	values  [][]interface{}
+	pos     int
+}
+
+func (r *sliceRows) Columns() []string { return r.columns }
+func (r *sliceRows) Err() error        { return nil }
+
+func (r *sliceRows) Next() bool {
+	r.pos++
+	return r.pos < len(r.values)
+}
+
+func (r *sliceRows) Values() ([]interface{}, error) {
+	if r.pos < 0 || r.pos >= len(r.values) {
+		return nil, fmt.Errorf("no current row")
+	}
+	return r.values[r.pos], nil
+}
+
+// Load inserts rows into table in a transaction with the default options
+// and returns how many it inserted.
+func Load(ctx context.Context, conn *dialects.Connection, table string, rows Rows) (int64, error) {
+	return LoadWithOptions(ctx, conn, table, rows, DefaultOptions())
+}
+
+// LoadWithOptions inserts rows into table in a transaction and returns how
+// many it inserted. Nothing is inserted if it fails.
+func LoadWithOptions(ctx context.Context, conn *dialects.Connection, table string, rows Rows, opts Options) (int64, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := LoadTx(ctx, tx, table, rows, opts)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// LoadTx inserts rows into table in tx and returns how many it inserted.
+func LoadTx(ctx context.Context, tx *dialects.Tx, table string, rows Rows, opts Options) (int64, error) {
+	w, err := NewWriter(ctx, tx, table, rows.Columns(), opts)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err == nil {
+			err = w.Write(values)
+		}
+		if err != nil {
+			w.abort()
+			return w.Count(), err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		w.abort()
+		return w.Count(), err
+	}
+	return w.Close()
+}
+
+// Writer sends rows to a table as they are written. Rows are buffered up
+// to the batch size; Close sends the rest.
+type Writer struct {
+	ctx     context.Context
+	tx      *dialects.Tx
+	table   string
+	columns []string
+	opts    Options
+	method  Method
+
+	batch [][]interface{} // Rows not sent yet (insert and load-data)
+	copy  *copier
+	count int64 // Rows sent
+}
+
+// NewWriter starts a bulk load of columns into table in tx.
+func NewWriter(ctx context.Context, tx *dialects.Tx, table string, columns []string, opts Options) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s: bulk load needs columns", table)
+	}
+	method, err := resolveMethod(tx, opts.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{ctx: ctx, tx: tx, table: table, columns: columns, opts: opts, method: method}
+	if method == MethodCopy {
+		if w.copy, err = startCopy(ctx, tx, table, columns); err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	return w, nil
+}
+
+// Method returns the method the writer uses.
+func (w *Writer) Method() Method {
+	return w.method
+}
+
+// Count returns how many rows were sent so far.
+func (w *Writer) Count() int64 {
+	return w.count
+}
+
+// Write queues a row with a value per column.
+func (w *Writer) Write(values []interface{}) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("%s row %d: %d values for %d columns", w.table, w.count+int64(len(w.batch))+1, len(values), len(w.columns))
+	}
+	if w.copy != nil {
+		if err := w.copy.write(values); err != nil {
+			return fmt.Errorf("%s row %d: %w", w.table, w.count+1, err)
+		}
+		w.count++
+		return nil
+	}
+
+	w.batch = append(w.batch, values)
+	size := w.opts.batchSize(w.method)
+	if limit := maxParams / len(w.columns); w.method == MethodInsert && limit < size {
+		size = limit
+	}
+	if len(w.batch) >= size {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close sends the queued rows and returns how many rows were sent.
+func (w *Writer) Close() (int64, error) {
+	if w.copy != nil {
+		n, err := w.copy.close()
+		if err != nil {
+			return w.count, fmt.Errorf("%s: %w", w.table, err)
+		}
+		if n >= 0 {
+			w.count = n
+		}
+		return w.count, nil
+	}
+	return w.count, w.flush()
+}
+
+// abort drops the queued rows after a failed load.
+func (w *Writer) abort() {
+	w.batch = nil
+	if w.copy != nil {
+		w.copy.stmt.Close()
+	}
+}
+
+func (w *Writer) flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+	var err error
+	if w.method == MethodLoadData {
+		err = w.loadData()
+	} else {
+		err = w.insert()
+	}
+	if err != nil {
+		return fmt.Errorf("%s rows %d-%d: %w", w.table, w.count+1, w.count+int64(len(w.batch)), err)
+	}
+	w.count += int64(len(w.batch))
+	w.batch = w.batch[:0]
+	return nil
+}
+
+// insert sends the batch as one multi-row INSERT.
+func (w *Writer) insert() error {
+	dialect := w.tx.Dialect
+	var rows []string
+	var args []interface{}
+	for _, values := range w.batch {
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			args = append(args, v)
+			placeholders[i] = dialect.Placeholder(len(args))
+		}
+		rows = append(rows, "("+strings.Join(placeholders, ", ")+")")
+	}
+
+	table := dialect.Quote(w.table)
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, quoteColumns(dialect, w.columns), strings.Join(rows, ", "))
+	if w.opts.Identity && dialect.Name() == "mssql" {
+		sql = fmt.Sprintf("SET IDENTITY_INSERT %s ON; %s; SET IDENTITY_INSERT %s OFF", table, sql, table)
+	}
+	_, err := w.tx.Exec(w.ctx, sql, args...)
+	return err
+}
+
+// resolveMethod picks the method for m on the dialect and driver of tx.
+func resolveMethod(tx *dialects.Tx, m Method) (Method, error) {
+	dialect := tx.Dialect.Name()
+	switch m {
+	case MethodAuto, "":
+		switch {
+		case dialect == "postgres" && copyDriver(tx.Driver):
+			return MethodCopy, nil
+		case dialect == "mysql" && mysqlReadersSet():
+			return MethodLoadData, nil
+		}
+		return MethodInsert, nil
+	case MethodCopy:
+		if dialect != "postgres" {
+			return "", fmt.Errorf("COPY needs PostgreSQL, not %s", dialect)
+		}
+	case MethodLoadData:
+		if dialect != "mysql" {
+			return "", fmt.Errorf("LOAD DATA needs MySQL, not %s", dialect)
+		}
+		if !mysqlReadersSet() {
+			return "", fmt.Errorf("LOAD DATA needs the reader handlers of the MySQL driver; call bulk.UseMySQLReaders")
+		}
+	case MethodInsert:
+	default:
+		return "", fmt.Errorf("unknown bulk method %q", m)
+	}
+	return m, nil
+}
+
+func quoteColumns(dialect dialects.Dialect, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = dialect.Quote(c)
+	}
+	return strings.Join(quoted, ", ")
+}
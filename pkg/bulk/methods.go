@@ -0,0 +1,154 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// copyDriver reports whether d takes COPY FROM STDIN as a prepared
+// statement, executed once per row and once without arguments to finish,
+// as github.com/lib/pq does.
+func copyDriver(d driver.Driver) bool {
+	return d != nil && fmt.Sprintf("%T", d) == "*pq.Driver"
+}
+
+// copier streams rows through COPY FROM STDIN.
+type copier struct {
+	ctx  context.Context
+	stmt *sql.Stmt
+}
+
+func startCopy(ctx context.Context, tx *dialects.Tx, table string, columns []string) (*copier, error) {
+	sql := fmt.Sprintf("COPY %s (%s) FROM STDIN", tx.Dialect.Quote(table), quoteColumns(tx.Dialect, columns))
+	stmt, err := tx.Tx.PrepareContext(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return &copier{ctx: ctx, stmt: stmt}, nil
+}
+
+func (c *copier) write(values []interface{}) error {
+	_, err := c.stmt.ExecContext(c.ctx, values...)
+	return err
+}
+
+// close ends the COPY and returns how many rows it loaded, or -1 if the
+// driver does not tell.
+func (c *copier) close() (int64, error) {
+	defer c.stmt.Close()
+	res, err := c.stmt.ExecContext(c.ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1, nil
+	}
+	return n, nil
+}
+
+var (
+	mysqlMu         sync.RWMutex
+	mysqlRegister   func(name string, handler func() io.Reader)
+	mysqlDeregister func(name string)
+	mysqlReaderSeq  atomic.Int64
+)
+
+// UseMySQLReaders enables LOAD DATA LOCAL INFILE on MySQL. Nexus does not
+// link a MySQL driver, so it needs the functions of
+// github.com/go-sql-driver/mysql that register the io.Reader behind
+// 'Reader::<name>':
+//
+//	bulk.UseMySQLReaders(mysql.RegisterReaderHandler, mysql.DeregisterReaderHandler)
+//
+// The server must allow it with local_infile=ON.
+func UseMySQLReaders(register func(name string, handler func() io.Reader), deregister func(name string)) {
+	mysqlMu.Lock()
+	defer mysqlMu.Unlock()
+	mysqlRegister, mysqlDeregister = register, deregister
+}
+
+func mysqlReadersSet() bool {
+	mysqlMu.RLock()
+	defer mysqlMu.RUnlock()
+	return mysqlRegister != nil && mysqlDeregister != nil
+}
+
+// loadData sends the batch as tab-separated text through LOAD DATA LOCAL
+// INFILE. MySQL turns errors of LOCAL loads into warnings and skips the
+// row, so a batch with fewer rows loaded than sent fails.
+func (w *Writer) loadData() error {
+	var buf bytes.Buffer
+	for _, values := range w.batch {
+		for i, v := range values {
+			if i > 0 {
+				buf.WriteByte('\t')
+			}
+			if err := writeLoadDataValue(&buf, v); err != nil {
+				return fmt.Errorf("column %s: %w", w.columns[i], err)
+			}
+		}
+		buf.WriteByte('\n')
+	}
+
+	mysqlMu.RLock()
+	register, deregister := mysqlRegister, mysqlDeregister
+	mysqlMu.RUnlock()
+
+	name := fmt.Sprintf("nexus_bulk_%d", mysqlReaderSeq.Add(1))
+	register(name, func() io.Reader { return bytes.NewReader(buf.Bytes()) })
+	defer deregister(name)
+
+	sql := fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 "+
+		"FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '\\n' (%s)",
+		name, w.tx.Dialect.Quote(w.table), quoteColumns(w.tx.Dialect, w.columns))
+	res, err := w.tx.Exec(w.ctx, sql)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n < int64(len(w.batch)) {
+		return fmt.Errorf("%d of %d rows were skipped as duplicates or invalid values (see SHOW WARNINGS)", int64(len(w.batch))-n, len(w.batch))
+	}
+	return nil
+}
+
+var loadDataEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x00", `\0`)
+
+// writeLoadDataValue writes v as a field of LOAD DATA, \N for NULL.
+func writeLoadDataValue(buf *bytes.Buffer, v interface{}) error {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return err
+		}
+	}
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString(`\N`)
+	case string:
+		loadDataEscaper.WriteString(buf, v)
+	case []byte:
+		loadDataEscaper.WriteString(buf, string(v))
+	case bool:
+		if v {
+			buf.WriteByte('1')
+		} else {
+			buf.WriteByte('0')
+		}
+	case time.Time:
+		buf.WriteString(v.UTC().Format("2006-01-02 15:04:05.999999"))
+	default:
+		loadDataEscaper.WriteString(buf, fmt.Sprint(v))
+	}
+	return nil
+}
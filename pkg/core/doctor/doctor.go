@@ -0,0 +1,404 @@
+// Package doctor checks the health of a database: that it answers, that
+// its migrations are applied and unchanged, that foreign keys are indexed
+// and tables have primary keys, and how large and bloated its tables are.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// Severity indicates how urgent a finding is.
+type Severity int
+
+const (
+	SeverityCritical Severity = iota // The database is down or diverges from its migrations
+	SeverityWarning                  // Should be fixed, but nothing is broken
+	SeverityInfo                     // For information only
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityCritical:
+		return "critical"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "info"
+}
+
+// MarshalText writes the severity by name in JSON reports.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Checks reported by the doctor.
+const (
+	CheckConnectivity = "connectivity"
+	CheckMigrations   = "migrations"
+	CheckDrift        = "drift"
+	CheckForeignKeys  = "fk_index"
+	CheckPrimaryKeys  = "primary_key"
+	CheckBloat        = "bloat"
+)
+
+// Finding is one problem, or fact, found by a check.
+type Finding struct {
+	Check      string   `json:"check"`
+	Severity   Severity `json:"severity"`
+	Table      string   `json:"table,omitempty"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+// Report is the result of Run, with the most urgent findings first.
+type Report struct {
+	Dialect  string                   `json:"dialect"`
+	Latency  time.Duration            `json:"latency_ns"` // Round trip of a ping
+	Findings []Finding                `json:"findings"`
+	Stats    *migration.DatabaseStats `json:"stats,omitempty"` // Nil where the dialect has none
+}
+
+// Worst returns the severity of the most urgent finding, or SeverityInfo
+// if there are none.
+func (r *Report) Worst() Severity {
+	worst := SeverityInfo
+	for _, f := range r.Findings {
+		worst = min(worst, f.Severity)
+	}
+	return worst
+}
+
+// Count returns the number of findings of the given severity.
+func (r *Report) Count(s Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == s {
+			n++
+		}
+	}
+	return n
+}
+
+// ExitCode returns the exit status for the report in the convention of
+// monitoring checks: 0 when healthy, 1 on warnings and 2 on critical
+// findings.
+func (r *Report) ExitCode() int {
+	switch r.Worst() {
+	case SeverityCritical:
+		return 2
+	case SeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+func (r *Report) add(f Finding) {
+	r.Findings = append(r.Findings, f)
+}
+
+// Options configures Run.
+type Options struct {
+	// Engine has the migrations loaded to compare with the history table;
+	// nil skips the migration checks.
+	Engine *migration.Engine
+
+	// BloatRatio is the share of a table, or of a SQLite database, that
+	// reclaimable space must exceed to be reported (default 0.2).
+	BloatRatio float64
+
+	// BloatMinBytes is the reclaimable space below which bloat is not
+	// reported, however large its share (default 1 MiB).
+	BloatMinBytes int64
+}
+
+// DefaultOptions returns the default doctor options.
+func DefaultOptions() Options {
+	return Options{BloatRatio: 0.2, BloatMinBytes: 1 << 20}
+}
+
+// Run checks the database of conn. It only reads; a check that cannot run
+// is reported as a warning and the others still run, except that nothing
+// runs once the database doesn't answer.
+func Run(ctx context.Context, conn *dialects.Connection, opts Options) *Report {
+	report := &Report{Dialect: conn.Dialect.Name()}
+	defer report.sort()
+
+	start := time.Now()
+	if err := conn.DB.PingContext(ctx); err != nil {
+		report.add(Finding{
+			Check:      CheckConnectivity,
+			Severity:   SeverityCritical,
+			Message:    fmt.Sprintf("cannot connect to the database: %v", err),
+			Suggestion: "check that the database is running and the connection settings of nexus.json",
+		})
+		return report
+	}
+	report.Latency = time.Since(start)
+
+	introspector, ok := conn.Dialect.(migration.Introspector)
+	if !ok {
+		report.add(unchecked(CheckConnectivity, fmt.Errorf("dialect %s does not support introspection", conn.Dialect.Name())))
+		return report
+	}
+	tables, err := introspector.IntrospectTables(ctx, conn.DB)
+	if err != nil {
+		report.add(unchecked(CheckConnectivity, err))
+		return report
+	}
+
+	if opts.Engine != nil {
+		checkMigrations(ctx, report, opts.Engine, tables)
+	}
+	for _, table := range tables {
+		checkTable(ctx, report, conn, introspector, table)
+	}
+	if si, ok := introspector.(migration.StatsIntrospector); ok {
+		checkStats(ctx, report, conn, si, opts)
+	}
+	return report
+}
+
+// sort orders the findings by severity, keeping the order of the checks
+// within one.
+func (r *Report) sort() {
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		return r.Findings[i].Severity < r.Findings[j].Severity
+	})
+}
+
+// unchecked is the finding of a check that failed to run.
+func unchecked(check string, err error) Finding {
+	return Finding{Check: check, Severity: SeverityWarning, Message: fmt.Sprintf("check failed: %v", err)}
+}
+
+// checkMigrations reports pending migrations, applied migrations whose file
+// changed, and history rows without a file.
+func checkMigrations(ctx context.Context, report *Report, engine *migration.Engine, tables []string) {
+	migrations := engine.Migrations()
+	hasHistory := false
+	for _, t := range tables {
+		hasHistory = hasHistory || t == migration.HistoryTable
+	}
+	if !hasHistory {
+		if len(migrations) > 0 {
+			report.add(Finding{
+				Check:      CheckMigrations,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("no migration has been applied; %d pending", len(migrations)),
+				Suggestion: "run 'nexus migrate up'",
+			})
+		}
+		return
+	}
+
+	pending, err := engine.Pending(ctx)
+	if err != nil {
+		report.add(unchecked(CheckMigrations, err))
+		return
+	}
+	if len(pending) > 0 {
+		ids := make([]string, len(pending))
+		for i, m := range pending {
+			ids[i] = m.ID + "_" + m.Name
+		}
+		report.add(Finding{
+			Check:      CheckMigrations,
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("%d pending migration(s): %s", len(pending), strings.Join(ids, ", ")),
+			Suggestion: "run 'nexus migrate up'",
+		})
+	}
+
+	drifted, err := engine.Drifted(ctx)
+	if err != nil {
+		report.add(unchecked(CheckDrift, err))
+		return
+	}
+	for _, d := range drifted {
+		report.add(Finding{
+			Check:      CheckDrift,
+			Severity:   SeverityCritical,
+			Message:    fmt.Sprintf("migration %s_%s changed after it was applied (checksum %.8s, now %.8s)", d.ID, d.Name, d.Applied, d.Current),
+			Suggestion: "restore the applied version of the file and put the change in a new migration",
+		})
+	}
+
+	plan, err := engine.PlanRepair(ctx)
+	if err != nil {
+		report.add(unchecked(CheckDrift, err))
+		return
+	}
+	if len(plan.Relink) > 0 {
+		report.add(Finding{
+			Check:      CheckDrift,
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("%d applied migration file(s) were renamed", len(plan.Relink)),
+			Suggestion: "run 'nexus migrate repair'",
+		})
+	}
+	for _, h := range plan.Orphaned {
+		report.add(Finding{
+			Check:    CheckDrift,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("migration %s_%s is applied but has no file", h.MigrationID, h.Name),
+		})
+	}
+}
+
+// checkTable reports a missing primary key and unindexed foreign keys.
+func checkTable(ctx context.Context, report *Report, conn *dialects.Connection, introspector migration.Introspector, table string) {
+	columns, err := introspector.IntrospectColumns(ctx, conn.DB, table)
+	if err != nil {
+		report.add(unchecked(CheckPrimaryKeys, fmt.Errorf("%s: %w", table, err)))
+		return
+	}
+	var pk []string
+	for _, c := range columns {
+		if c.IsPrimaryKey {
+			pk = append(pk, c.Name)
+		}
+	}
+	if len(pk) == 0 {
+		report.add(Finding{
+			Check:      CheckPrimaryKeys,
+			Severity:   SeverityWarning,
+			Table:      table,
+			Message:    fmt.Sprintf("table %s has no primary key", table),
+			Suggestion: "add an @id field; rows without a key cannot be updated or replicated reliably",
+		})
+	}
+
+	fks, err := introspector.IntrospectForeignKeys(ctx, conn.DB, table)
+	if err != nil || len(fks) == 0 {
+		if err != nil {
+			report.add(unchecked(CheckForeignKeys, fmt.Errorf("%s: %w", table, err)))
+		}
+		return
+	}
+	indexes, err := introspector.IntrospectIndexes(ctx, conn.DB, table)
+	if err != nil {
+		report.add(unchecked(CheckForeignKeys, fmt.Errorf("%s: %w", table, err)))
+		return
+	}
+	leading := [][]string{pk}
+	for _, idx := range indexes {
+		leading = append(leading, idx.Columns)
+	}
+	dialect := conn.Dialect
+	for _, fk := range fks {
+		if indexed(fk.Columns, leading) {
+			continue
+		}
+		quoted := make([]string, len(fk.Columns))
+		for i, c := range fk.Columns {
+			quoted[i] = dialect.Quote(c)
+		}
+		report.add(Finding{
+			Check:    CheckForeignKeys,
+			Severity: SeverityWarning,
+			Table:    table,
+			Message: fmt.Sprintf("foreign key %s.%s to %s has no index; joins and deletes of %s rows scan %s",
+				table, strings.Join(fk.Columns, ", "), fk.RefTable, fk.RefTable, table),
+			Suggestion: fmt.Sprintf("CREATE INDEX %s ON %s (%s)",
+				dialect.Quote("idx_"+table+"_"+strings.Join(fk.Columns, "_")), dialect.Quote(table), strings.Join(quoted, ", ")),
+		})
+	}
+}
+
+// indexed reports whether columns lead one of the column lists, in any
+// order.
+func indexed(columns []string, lists [][]string) bool {
+	for _, list := range lists {
+		if len(list) < len(columns) {
+			continue
+		}
+		lead := make(map[string]bool, len(columns))
+		for _, c := range list[:len(columns)] {
+			lead[strings.ToLower(c)] = true
+		}
+		all := true
+		for _, c := range columns {
+			all = all && lead[strings.ToLower(c)]
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStats reads the sizes of the database and reports bloated tables.
+func checkStats(ctx context.Context, report *Report, conn *dialects.Connection, si migration.StatsIntrospector, opts Options) {
+	stats, err := si.IntrospectStats(ctx, conn.DB)
+	if err != nil {
+		report.add(unchecked(CheckBloat, err))
+		return
+	}
+	report.Stats = stats
+	if opts.BloatRatio <= 0 {
+		opts.BloatRatio = DefaultOptions().BloatRatio
+	}
+	bloated := func(reclaimable, size int64) bool {
+		return reclaimable >= opts.BloatMinBytes && size > 0 && float64(reclaimable)/float64(size) > opts.BloatRatio
+	}
+
+	dialect := conn.Dialect
+	if dialect.Name() == "sqlite" {
+		if bloated(stats.Reclaimable, stats.Bytes) {
+			report.add(Finding{
+				Check:      CheckBloat,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("%s of the %s database file are free pages", FormatBytes(stats.Reclaimable), FormatBytes(stats.Bytes)),
+				Suggestion: "VACUUM",
+			})
+		}
+		return
+	}
+	for _, t := range stats.Tables {
+		if !bloated(t.Reclaimable, t.Bytes) {
+			continue
+		}
+		report.add(Finding{
+			Check:      CheckBloat,
+			Severity:   SeverityWarning,
+			Table:      t.Name,
+			Message:    fmt.Sprintf("table %s holds %s of reclaimable space in %s", t.Name, FormatBytes(t.Reclaimable), FormatBytes(t.Bytes)),
+			Suggestion: reclaimSQL(dialect, t.Name),
+		})
+	}
+}
+
+// reclaimSQL returns the statement that gives the space of dead rows of a
+// table back.
+func reclaimSQL(dialect dialects.Dialect, table string) string {
+	switch dialect.Name() {
+	case "postgres":
+		return "VACUUM (ANALYZE) " + dialect.Quote(table) + "; VACUUM FULL returns the space to the disk but locks the table"
+	case "mysql":
+		return "OPTIMIZE TABLE " + dialect.Quote(table)
+	case "mssql":
+		return "ALTER INDEX ALL ON " + dialect.Quote(table) + " REBUILD"
+	}
+	return "VACUUM"
+}
+
+// FormatBytes formats a size in bytes for people, e.g. 1.5 MB.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
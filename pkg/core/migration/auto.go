@@ -0,0 +1,93 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// AutoMigrateResult is what AutoMigrate did.
+type AutoMigrateResult struct {
+	Applied    []SchemaChange // Changes made to the database
+	Skipped    []SchemaChange // Destructive changes, left for a migration
+	Statements []string       // Statements run for the applied changes
+}
+
+// IsAdditive reports whether a change only adds to the database: a new
+// table, column or index.
+func IsAdditive(change SchemaChange) bool {
+	switch change.Type {
+	case ChangeCreateTable, ChangeAddColumn, ChangeAddIndex:
+		return true
+	}
+	return false
+}
+
+// AutoMigrate makes the database match s as far as it can without losing
+// data: it creates the tables, columns and indexes s adds, in one
+// transaction where the dialect allows DDL in transactions. Other changes
+// are reported as skipped. Nothing is written to the migration history, so
+// it is meant for development databases; use migrations elsewhere.
+func (e *Engine) AutoMigrate(ctx context.Context, s *schema.Schema) (*AutoMigrateResult, error) {
+	result, err := e.PlanAutoMigrate(ctx, s)
+	if err != nil || len(result.Statements) == 0 {
+		return result, err
+	}
+
+	tx, err := e.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	for _, stmt := range result.Statements {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("auto-migrating: %s: %w", stmt, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	log := e.log(ctx)
+	for _, desc := range DescribeChanges(result.Applied) {
+		log.Info("Auto-migrated", nexuslog.F("change", desc))
+	}
+	for _, desc := range DescribeChanges(result.Skipped) {
+		log.Warn("Skipped destructive change", nexuslog.F("change", desc))
+	}
+	return result, nil
+}
+
+// PlanAutoMigrate returns what AutoMigrate would do, without changing the
+// database.
+func (e *Engine) PlanAutoMigrate(ctx context.Context, s *schema.Schema) (*AutoMigrateResult, error) {
+	introspector, ok := e.conn.Dialect.(Introspector)
+	if !ok {
+		return nil, fmt.Errorf("dialect %s does not support introspection", e.conn.Dialect.Name())
+	}
+	snapshot, err := IntrospectDatabase(ctx, e.conn.DB, introspector)
+	if err != nil {
+		return nil, fmt.Errorf("introspecting database: %w", err)
+	}
+
+	result := &AutoMigrateResult{}
+	for _, change := range DiffWithOptions(s, snapshot, DiffOptions{Dialect: e.conn.Dialect}).Changes {
+		if IsAdditive(change) {
+			result.Applied = append(result.Applied, change)
+		} else {
+			result.Skipped = append(result.Skipped, change)
+		}
+	}
+	if len(result.Applied) == 0 {
+		return result, nil
+	}
+
+	m, err := GenerateMigrationFromDiff(e.conn.Dialect, result.Applied, "auto")
+	if err != nil {
+		return nil, err
+	}
+	result.Statements = SplitStatements(m.UpSQL)
+	return result, nil
+}
@@ -0,0 +1,217 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// BackfillTable is the table Backfill keeps its checkpoints in.
+const BackfillTable = "_nexus_backfills"
+
+// BackfillSpec describes a backfill: UPDATE Table SET Set WHERE Where, run
+// over ranges of Key of BatchSize rows.
+type BackfillSpec struct {
+	Table     string        // Table to update
+	Set       string        // Assignments, such as "status = 'active'"
+	Where     string        // Condition rows to update match (default all rows)
+	Key       string        // Integer column the batches are ranges of, usually the primary key (default id)
+	BatchSize int           // Rows per batch (default 1000)
+	Sleep     time.Duration // Pause between batches, to leave room for other writes and replication
+	Name      string        // Name of the checkpoint (default Table)
+
+	Progress func(BackfillProgress) // Called after each batch
+}
+
+// BackfillProgress reports a batch of a backfill that was committed.
+type BackfillProgress struct {
+	Name    string
+	Batch   int           // 1-based number of the batch in this run
+	Rows    int64         // Rows the batch updated
+	Total   int64         // Rows updated so far, including earlier runs
+	LastKey int64         // Highest key the batch covered
+	Elapsed time.Duration // Time since the run started
+}
+
+// Backfill updates the rows of a table in batches, each in its own
+// transaction, so that a giant UPDATE neither locks the table nor writes
+// all its changes to the log at once. Batches are ranges of the key in
+// order; the highest key done is saved in BackfillTable with each batch,
+// so a backfill that fails or is canceled resumes where it stopped when
+// run again with the same name, and one that finished does nothing. It
+// returns the rows updated in this run.
+//
+//	n, err := migration.Backfill(ctx, conn, migration.BackfillSpec{
+//		Table: "users",
+//		Set:   "email_lower = LOWER(email)",
+//		Where: "email_lower IS NULL",
+//		Sleep: 100 * time.Millisecond,
+//	})
+func Backfill(ctx context.Context, conn *dialects.Connection, spec BackfillSpec) (int64, error) {
+	if spec.Table == "" || spec.Set == "" {
+		return 0, fmt.Errorf("backfill needs a table and assignments")
+	}
+	if spec.Key == "" {
+		spec.Key = "id"
+	}
+	if spec.BatchSize <= 0 {
+		spec.BatchSize = 1000
+	}
+	if spec.Name == "" {
+		spec.Name = spec.Table
+	}
+	if err := initBackfills(ctx, conn); err != nil {
+		return 0, fmt.Errorf("creating %s: %w", BackfillTable, err)
+	}
+	lastKey, total, done, err := backfillCheckpoint(ctx, conn, spec.Name)
+	if err != nil || done {
+		return 0, err
+	}
+
+	dialect := conn.Dialect
+	table, key := dialect.Quote(spec.Table), dialect.Quote(spec.Key)
+	where := ""
+	if spec.Where != "" {
+		where = " AND (" + spec.Where + ")"
+	}
+	selectSQL := fmt.Sprintf("SELECT %s FROM %s WHERE %s > %s%s ORDER BY %s", key, table, key, dialect.Placeholder(1), where, key) +
+		dialects.LimitClause(dialect, spec.BatchSize, 0, true)
+	updateSQL := fmt.Sprintf("UPDATE %s SET %s WHERE %s > %s AND %s <= %s%s",
+		table, spec.Set, key, dialect.Placeholder(1), key, dialect.Placeholder(2), where)
+	checkpointSQL := fmt.Sprintf("UPDATE %s SET last_key = %s, rows_updated = %s, updated_at = CURRENT_TIMESTAMP WHERE name = %s",
+		dialect.Quote(BackfillTable), dialect.Placeholder(1), dialect.Placeholder(2), dialect.Placeholder(3))
+
+	start := time.Now()
+	var updated int64
+	for batch := 1; ; batch++ {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+		upTo, found, err := backfillBatchEnd(ctx, conn, selectSQL, lastKey)
+		if err != nil {
+			return updated, fmt.Errorf("backfill %s: %w", spec.Name, err)
+		}
+		if !found {
+			break
+		}
+
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return updated, err
+		}
+		result, err := tx.Exec(ctx, updateSQL, lastKey, upTo)
+		var n int64
+		if err == nil {
+			n, err = result.RowsAffected()
+		}
+		if err == nil {
+			_, err = tx.Exec(ctx, checkpointSQL, upTo, total+n, spec.Name)
+		}
+		if err == nil {
+			err = tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+		if err != nil {
+			return updated, fmt.Errorf("backfill %s: batch after %s %d: %w", spec.Name, spec.Key, lastKey, err)
+		}
+		lastKey, total, updated = upTo, total+n, updated+n
+
+		if spec.Progress != nil {
+			spec.Progress(BackfillProgress{Name: spec.Name, Batch: batch, Rows: n, Total: total, LastKey: lastKey, Elapsed: time.Since(start)})
+		}
+		if spec.Sleep > 0 {
+			select {
+			case <-ctx.Done():
+				return updated, ctx.Err()
+			case <-time.After(spec.Sleep):
+			}
+		}
+	}
+
+	completeSQL := fmt.Sprintf("UPDATE %s SET completed_at = CURRENT_TIMESTAMP WHERE name = %s",
+		dialect.Quote(BackfillTable), dialect.Placeholder(1))
+	_, err = conn.Exec(ctx, completeSQL, spec.Name)
+	return updated, err
+}
+
+// ResetBackfill removes the checkpoint of the backfill named name, so
+// that it runs from the start again.
+func ResetBackfill(ctx context.Context, conn *dialects.Connection, name string) error {
+	if err := initBackfills(ctx, conn); err != nil {
+		return err
+	}
+	dialect := conn.Dialect
+	_, err := conn.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE name = %s", dialect.Quote(BackfillTable), dialect.Placeholder(1)), name)
+	return err
+}
+
+// initBackfills creates the checkpoint table if it doesn't exist.
+func initBackfills(ctx context.Context, conn *dialects.Connection) error {
+	dialect := conn.Dialect
+	var sql string
+	switch dialect.Name() {
+	case "mssql":
+		sql = fmt.Sprintf(`IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (
+		name NVARCHAR(255) NOT NULL PRIMARY KEY,
+		last_key BIGINT NOT NULL,
+		rows_updated BIGINT NOT NULL,
+		started_at DATETIME2 NOT NULL DEFAULT SYSDATETIME(),
+		updated_at DATETIME2 NOT NULL DEFAULT SYSDATETIME(),
+		completed_at DATETIME2 NULL
+	)`, BackfillTable, dialect.Quote(BackfillTable))
+	default:
+		sql = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		name VARCHAR(255) NOT NULL PRIMARY KEY,
+		last_key BIGINT NOT NULL,
+		rows_updated BIGINT NOT NULL,
+		started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		completed_at TIMESTAMP NULL
+	)`, dialect.Quote(BackfillTable))
+	}
+	_, err := conn.Exec(ctx, sql)
+	return err
+}
+
+// backfillCheckpoint returns the checkpoint of the backfill named name,
+// starting one if there is none: the highest key done, the rows updated
+// so far and whether it finished.
+func backfillCheckpoint(ctx context.Context, conn *dialects.Connection, name string) (lastKey, total int64, done bool, err error) {
+	dialect := conn.Dialect
+	table := dialect.Quote(BackfillTable)
+	var completed sql.NullString
+	err = conn.QueryRow(ctx, fmt.Sprintf("SELECT last_key, rows_updated, completed_at FROM %s WHERE name = %s", table, dialect.Placeholder(1)), name).
+		Scan(&lastKey, &total, &completed)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Keys start above the smallest BIGINT, so the first batch takes the lowest
+		lastKey = -1 << 63
+		_, err = conn.Exec(ctx, fmt.Sprintf("INSERT INTO %s (name, last_key, rows_updated) VALUES (%s, %s, 0)",
+			table, dialect.Placeholder(1), dialect.Placeholder(2)), name, lastKey)
+		return lastKey, 0, false, err
+	}
+	return lastKey, total, completed.Valid, err
+}
+
+// backfillBatchEnd returns the highest key of the next batch after
+// lastKey, and false when no rows are left.
+func backfillBatchEnd(ctx context.Context, conn *dialects.Connection, selectSQL string, lastKey int64) (int64, bool, error) {
+	rows, err := conn.Query(ctx, selectSQL, lastKey)
+	if err != nil {
+		return 0, false, err
+	}
+	defer rows.Close()
+	var key int64
+	found := false
+	for rows.Next() {
+		if err := rows.Scan(&key); err != nil {
+			return 0, false, err
+		}
+		found = true
+	}
+	return key, found, rows.Err()
+}
@@ -0,0 +1,124 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/bulk"
+)
+
+// copyHead matches the statement of a COPY ... FROM stdin: the table, the
+// column list and what follows FROM stdin, which must be nothing for the
+// text format.
+var copyHead = regexp.MustCompile(`(?is)^COPY\s+((?:"[^"]*"|[^\s(])+)\s*(?:\(([^)]*)\))?\s*FROM\s+STDIN\b([^;]*);`)
+
+// copyRows loads the rows of a COPY ... FROM stdin in the text format, as
+// pg_dump writes them, with pkg/bulk: through COPY where the driver takes
+// it and as INSERTs otherwise. It returns how many rows it loaded.
+func (t *migrationTx) copyRows(ctx context.Context, stmt string) (int64, error) {
+	table, columns, values, err := parseCopy(stmt)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+	if t.tx != nil {
+		return bulk.LoadTx(ctx, t.tx, table, bulk.NewRows(columns, values), bulk.DefaultOptions())
+	}
+	return bulk.Load(ctx, t.conn, table, bulk.NewRows(columns, values))
+}
+
+// parseCopy returns the table, columns and rows of a COPY ... FROM stdin
+// statement with its rows.
+func parseCopy(stmt string) (string, []string, [][]interface{}, error) {
+	m := copyHead.FindStringSubmatch(stmt)
+	if m == nil {
+		return "", nil, nil, fmt.Errorf("malformed COPY statement")
+	}
+	if strings.TrimSpace(m[3]) != "" {
+		return "", nil, nil, fmt.Errorf("COPY options are not supported, only the text format: %s", strings.TrimSpace(m[3]))
+	}
+	if strings.TrimSpace(m[2]) == "" {
+		return "", nil, nil, fmt.Errorf("COPY FROM stdin needs a column list")
+	}
+	var columns []string
+	for _, column := range splitTopLevel(m[2]) {
+		columns = append(columns, copyIdent(strings.TrimSpace(column)))
+	}
+
+	var rows [][]interface{}
+	data := strings.TrimPrefix(stmt[len(m[0]):], "\n")
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == `\.` {
+			break
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != len(columns) {
+			return "", nil, nil, fmt.Errorf("COPY row %d has %d values for %d columns", n+1, len(fields), len(columns))
+		}
+		row := make([]interface{}, len(fields))
+		for i, field := range fields {
+			if field != `\N` {
+				row[i] = unescapeCopy(field)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return copyIdent(m[1]), columns, rows, nil
+}
+
+// copyIdent returns the name of a table or column of a COPY statement
+// without its schema and quotes. Unquoted names are folded to lowercase,
+// as PostgreSQL does.
+func copyIdent(ident string) string {
+	quoted := false
+	for i := len(ident) - 1; i >= 0; i-- {
+		if ident[i] == '"' {
+			quoted = !quoted
+		} else if ident[i] == '.' && !quoted {
+			ident = ident[i+1:]
+			break
+		}
+	}
+	if strings.HasPrefix(ident, `"`) && strings.HasSuffix(ident, `"`) && len(ident) > 1 {
+		return strings.ReplaceAll(ident[1:len(ident)-1], `""`, `"`)
+	}
+	return strings.ToLower(ident)
+}
+
+// unescapeCopy decodes the backslash escapes of a value of the COPY text
+// format.
+func unescapeCopy(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] != '\\' || i+1 == len(field) {
+			b.WriteByte(field[i])
+			continue
+		}
+		i++
+		switch c := field[i]; c {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
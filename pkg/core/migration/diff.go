@@ -5,6 +5,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +26,18 @@ const (
 	ChangeModifyColumn
 	ChangeAddIndex
 	ChangeDropIndex
+	ChangeSetRowSecurity
+	ChangeCreatePolicy
+	ChangeDropPolicy
+	ChangeReplacePolicy
+	ChangeCreateFunction
+	ChangeReplaceFunction
+	ChangeDropFunction
+	ChangeSetCollation
+	ChangeSetTableCollation
+	ChangeSetDefault
+	ChangeSetComment
+	ChangeSetTableComment
 )
 
 // String returns a human-readable name for the change type.
@@ -42,6 +57,30 @@ func (c ChangeType) String() string {
 		return "ADD INDEX"
 	case ChangeDropIndex:
 		return "DROP INDEX"
+	case ChangeSetRowSecurity:
+		return "SET ROW SECURITY"
+	case ChangeCreatePolicy:
+		return "CREATE POLICY"
+	case ChangeDropPolicy:
+		return "DROP POLICY"
+	case ChangeReplacePolicy:
+		return "REPLACE POLICY"
+	case ChangeCreateFunction:
+		return "CREATE FUNCTION"
+	case ChangeReplaceFunction:
+		return "REPLACE FUNCTION"
+	case ChangeDropFunction:
+		return "DROP FUNCTION"
+	case ChangeSetCollation:
+		return "SET COLLATION"
+	case ChangeSetTableCollation:
+		return "SET TABLE COLLATION"
+	case ChangeSetDefault:
+		return "SET DEFAULT"
+	case ChangeSetComment:
+		return "SET COMMENT"
+	case ChangeSetTableComment:
+		return "SET TABLE COMMENT"
 	default:
 		return "UNKNOWN"
 	}
@@ -56,6 +95,30 @@ type SchemaChange struct {
 	Field      *schema.Field // For add/modify column
 	Index      *schema.Index // For add index
 	Model      *schema.Model // For create table
+
+	RowSecurity    *RowSecurityInfo // For set row security: the target state
+	OldRowSecurity *RowSecurityInfo // For set row security: the current state, nil if off
+	Policy         *PolicyInfo      // For create and replace policy
+	OldPolicy      *PolicyInfo      // For drop and replace policy
+
+	Function    *schema.Function // For create and replace function
+	OldFunction *FunctionInfo    // For drop and replace function
+
+	// For set collation and set table collation: the target and current
+	// character set and collation, empty where left to the database
+	Charset      string
+	Collation    string
+	OldCharset   string
+	OldCollation string
+
+	// For set default: the current default as the database reports it;
+	// the target is that of Field
+	OldDefault string
+
+	// For set comment and set table comment: the target and current
+	// comments, empty for none
+	Comment    string
+	OldComment string
 }
 
 // DiffResult contains all detected changes between schema and database.
@@ -68,15 +131,42 @@ func (d *DiffResult) HasChanges() bool {
 	return len(d.Changes) > 0
 }
 
+// DiffOptions configures schema diffing.
+type DiffOptions struct {
+	// Dialect the migration is for. Row-level security is only compared
+	// when it implements dialects.RowSecurity, functions when it
+	// implements dialects.FunctionCreator, collations when it implements
+	// dialects.Collator or dialects.TableCollator, column defaults when it
+	// implements dialects.Defaulter, and comments when it implements
+	// dialects.Commenter.
+	Dialect dialects.Dialect
+}
+
+// DefaultDiffOptions returns the default diff options, which compare
+// tables, columns and indexes only.
+func DefaultDiffOptions() DiffOptions {
+	return DiffOptions{}
+}
+
 // Diff compares a target schema with the current database snapshot and returns detected changes.
 // The changes, when applied, will make the database match the schema.
 func Diff(targetSchema *schema.Schema, currentDB *DatabaseSnapshot) *DiffResult {
+	return DiffWithOptions(targetSchema, currentDB, DefaultDiffOptions())
+}
+
+// DiffWithOptions compares a target schema with the current database
+// snapshot using the given options.
+func DiffWithOptions(targetSchema *schema.Schema, currentDB *DatabaseSnapshot, opts DiffOptions) *DiffResult {
 	result := &DiffResult{}
+	_, rowSecurity := opts.Dialect.(dialects.RowSecurity)
 
-	// Build a set of schema table names for quick lookup
+	// Build a set of schema table names for quick lookup, and of the
+	// database schemas they live in
 	schemaTableNames := make(map[string]bool)
+	namespaces := make(map[string]bool)
 	for _, model := range targetSchema.GetModels() {
-		schemaTableNames[model.Name] = true
+		schemaTableNames[model.Table()] = true
+		namespaces[model.SchemaName] = true
 	}
 
 	// Build a set of DB table names
@@ -87,10 +177,10 @@ func Diff(targetSchema *schema.Schema, currentDB *DatabaseSnapshot) *DiffResult
 
 	// 1. Detect tables to CREATE (in schema, not in DB)
 	for _, model := range targetSchema.GetModels() {
-		if _, exists := currentDB.Tables[model.Name]; !exists {
+		if _, exists := currentDB.Tables[model.Table()]; !exists {
 			result.Changes = append(result.Changes, SchemaChange{
 				Type:      ChangeCreateTable,
-				TableName: model.Name,
+				TableName: model.Table(),
 				Model:     model,
 			})
 		}
@@ -98,8 +188,12 @@ func Diff(targetSchema *schema.Schema, currentDB *DatabaseSnapshot) *DiffResult
 
 	// 2. Detect tables to DROP (in DB, not in schema)
 	for tableName := range currentDB.Tables {
-		// Skip internal migration table
-		if tableName == "_nexus_migrations" {
+		// Skip internal migration, lock and seed tables
+		if isInternalTable(tableName) {
+			continue
+		}
+		// Leave alone the schemas the schema file doesn't use
+		if namespace, _ := schema.SplitTable(tableName); namespace != "" && !namespaces[namespace] {
 			continue
 		}
 		if !schemaTableNames[tableName] {
@@ -112,7 +206,7 @@ func Diff(targetSchema *schema.Schema, currentDB *DatabaseSnapshot) *DiffResult
 
 	// 3. For tables that exist in both, check columns and indexes
 	for _, model := range targetSchema.GetModels() {
-		tableInfo, exists := currentDB.Tables[model.Name]
+		tableInfo, exists := currentDB.Tables[model.Table()]
 		if !exists {
 			continue // Already handled as CREATE TABLE
 		}
@@ -133,7 +227,7 @@ func Diff(targetSchema *schema.Schema, currentDB *DatabaseSnapshot) *DiffResult
 			if _, exists := dbColumns[field.Name]; !exists {
 				result.Changes = append(result.Changes, SchemaChange{
 					Type:       ChangeAddColumn,
-					TableName:  model.Name,
+					TableName:  model.Table(),
 					ColumnName: field.Name,
 					Field:      field,
 				})
@@ -145,12 +239,16 @@ func Diff(targetSchema *schema.Schema, currentDB *DatabaseSnapshot) *DiffResult
 			if _, exists := schemaColumns[colName]; !exists {
 				result.Changes = append(result.Changes, SchemaChange{
 					Type:       ChangeDropColumn,
-					TableName:  model.Name,
+					TableName:  model.Table(),
 					ColumnName: colName,
 				})
 			}
 		}
 
+		result.Changes = append(result.Changes, diffCollations(model, tableInfo, opts.Dialect)...)
+		result.Changes = append(result.Changes, diffDefaults(model, tableInfo, opts.Dialect)...)
+		result.Changes = append(result.Changes, diffComments(model, tableInfo, opts.Dialect)...)
+
 		// Detect index changes
 		schemaIndexes := make(map[string]*schema.Index)
 		for _, idx := range model.Indexes {
@@ -167,7 +265,7 @@ func Diff(targetSchema *schema.Schema, currentDB *DatabaseSnapshot) *DiffResult
 			if _, exists := dbIndexes[idx.Name]; !exists {
 				result.Changes = append(result.Changes, SchemaChange{
 					Type:      ChangeAddIndex,
-					TableName: model.Name,
+					TableName: model.Table(),
 					IndexName: idx.Name,
 					Index:     idx,
 				})
@@ -183,16 +281,344 @@ func Diff(targetSchema *schema.Schema, currentDB *DatabaseSnapshot) *DiffResult
 			if _, exists := schemaIndexes[idxName]; !exists {
 				result.Changes = append(result.Changes, SchemaChange{
 					Type:      ChangeDropIndex,
-					TableName: model.Name,
+					TableName: model.Table(),
 					IndexName: idxName,
 				})
 			}
 		}
+
+		if rowSecurity {
+			result.Changes = append(result.Changes, diffRowSecurity(model.Table(), rowSecurityInfo(model), tableInfo.RowSecurity)...)
+		}
+	}
+
+	// 4. Functions, after the tables their bodies may use
+	if _, ok := opts.Dialect.(dialects.FunctionCreator); ok {
+		result.Changes = append(result.Changes, diffFunctions(targetSchema, currentDB)...)
 	}
 
 	return result
 }
 
+// diffCollations compares the character sets and collations a model sets
+// for its table and text columns with those of the database. Those the
+// model leaves out are left to the database and not compared.
+func diffCollations(model *schema.Model, table *TableInfo, dialect dialects.Dialect) []SchemaChange {
+	var changes []SchemaChange
+	if _, ok := dialect.(dialects.TableCollator); ok && !sameCollation(model.CharacterSet, model.Collation, table.Charset, table.Collation) {
+		changes = append(changes, SchemaChange{
+			Type:         ChangeSetTableCollation,
+			TableName:    model.Table(),
+			Charset:      model.CharacterSet,
+			Collation:    model.Collation,
+			OldCharset:   table.Charset,
+			OldCollation: table.Collation,
+		})
+	}
+	if _, ok := dialect.(dialects.Collator); !ok {
+		return changes
+	}
+
+	for _, field := range model.GetFields() {
+		col, exists := table.Columns[field.Name]
+		if !exists || !field.Collatable() {
+			continue
+		}
+		// Columns only report what differs from the defaults of their table
+		oldCharset, oldCollation := col.Charset, col.Collation
+		if oldCharset == "" {
+			oldCharset = table.Charset
+		}
+		if oldCollation == "" {
+			oldCollation = table.Collation
+		}
+		charset, collation := field.ColumnCollation()
+		if sameCollation(charset, collation, oldCharset, oldCollation) {
+			continue
+		}
+		target := *field
+		target.CharacterSet, target.Collation = charset, collation
+		changes = append(changes, SchemaChange{
+			Type:         ChangeSetCollation,
+			TableName:    model.Table(),
+			ColumnName:   field.Name,
+			Field:        &target,
+			Charset:      charset,
+			Collation:    collation,
+			OldCharset:   oldCharset,
+			OldCollation: oldCollation,
+		})
+	}
+	return changes
+}
+
+// sameCollation reports whether the current character set and collation
+// match the wanted ones, an empty one matching any.
+func sameCollation(charset, collation, currentCharset, currentCollation string) bool {
+	return (charset == "" || strings.EqualFold(charset, currentCharset)) &&
+		(collation == "" || strings.EqualFold(collation, currentCollation))
+}
+
+// diffDefaults compares the defaults of the columns of a table, if the
+// dialect can change them. Defaults are compared by what they mean rather
+// than how they are written, so CURRENT_TIMESTAMP is NOW() and
+// 'draft'::character varying is "draft".
+func diffDefaults(model *schema.Model, table *TableInfo, dialect dialects.Dialect) []SchemaChange {
+	d, ok := dialect.(dialects.Defaulter)
+	if !ok {
+		return nil
+	}
+	var changes []SchemaChange
+	for _, field := range model.GetFields() {
+		col, exists := table.Columns[field.Name]
+		if !exists || field.AutoIncrement || col.AutoInc || sameDefault(d, field, col.Default) {
+			continue
+		}
+		changes = append(changes, SchemaChange{
+			Type:       ChangeSetDefault,
+			TableName:  model.Table(),
+			ColumnName: field.Name,
+			Field:      field,
+			OldDefault: col.Default,
+		})
+	}
+	return changes
+}
+
+// diffComments compares the comments of a model and its fields with those
+// of its table and columns, if the dialect stores comments.
+func diffComments(model *schema.Model, table *TableInfo, dialect dialects.Dialect) []SchemaChange {
+	if _, ok := dialect.(dialects.Commenter); !ok {
+		return nil
+	}
+	var changes []SchemaChange
+	if model.Description != table.Comment {
+		changes = append(changes, SchemaChange{
+			Type:       ChangeSetTableComment,
+			TableName:  model.Table(),
+			Comment:    model.Description,
+			OldComment: table.Comment,
+		})
+	}
+	for _, field := range model.GetFields() {
+		col, exists := table.Columns[field.Name]
+		if !exists || field.Description == col.Comment {
+			continue
+		}
+		changes = append(changes, SchemaChange{
+			Type:       ChangeSetComment,
+			TableName:  model.Table(),
+			ColumnName: field.Name,
+			Field:      field,
+			Comment:    field.Description,
+			OldComment: col.Comment,
+		})
+	}
+	return changes
+}
+
+// sameDefault reports whether current, a default as the database reports
+// it, is the default of field.
+func sameDefault(d dialects.Defaulter, field *schema.Field, current string) bool {
+	want := d.DefaultSQL(field)
+	if isNullDefault(current) || want == "" {
+		return isNullDefault(current) == (want == "")
+	}
+	if expr, value, ok := parseDefault(current, field.Type); ok {
+		wantExpr := field.DefaultExpr
+		if portable, _, ok := parseDefault(wantExpr, field.Type); ok && portable != "" {
+			wantExpr = portable
+		}
+		return expr == wantExpr && fmt.Sprint(value) == fmt.Sprint(field.DefaultValue)
+	}
+	// Expressions kept as written, such as those of SQLite, and values as
+	// schema snapshots record them
+	return compactDefault(current) == compactDefault(want) || current == fmt.Sprint(field.DefaultValue)
+}
+
+// sameDefaultSQL reports whether two defaults as databases report them
+// are the same.
+func sameDefaultSQL(a, b string) bool {
+	if a == b || isNullDefault(a) || isNullDefault(b) {
+		return isNullDefault(a) == isNullDefault(b)
+	}
+	exprA, valueA, okA := parseDefault(a, schema.FieldTypeInt)
+	exprB, valueB, okB := parseDefault(b, schema.FieldTypeInt)
+	if okA && okB {
+		return exprA == exprB && fmt.Sprint(valueA) == fmt.Sprint(valueB)
+	}
+	return compactDefault(a) == compactDefault(b)
+}
+
+func isNullDefault(def string) bool {
+	def = unwrapParens(strings.TrimSpace(def))
+	return def == "" || strings.EqualFold(def, "NULL")
+}
+
+var (
+	defaultNoise      = strings.NewReplacer(" ", "", "\t", "", "\n", "", "(", "", ")", "")
+	defaultInlineCast = regexp.MustCompile(`::[\w."]+`)
+)
+
+// compactDefault strips a default expression of what databases change
+// when they store it: case, whitespace, parentheses and casts.
+func compactDefault(def string) string {
+	return defaultNoise.Replace(defaultInlineCast.ReplaceAllString(strings.ToLower(def), ""))
+}
+
+// diffFunctions compares the functions of the schema and the database.
+func diffFunctions(targetSchema *schema.Schema, currentDB *DatabaseSnapshot) []SchemaChange {
+	var changes []SchemaChange
+	for _, f := range targetSchema.GetFunctions() {
+		have, exists := currentDB.Functions[f.Name]
+		switch {
+		case !exists:
+			changes = append(changes, SchemaChange{Type: ChangeCreateFunction, Function: f})
+		case !sameFunction(functionInfo(f), have):
+			changes = append(changes, SchemaChange{Type: ChangeReplaceFunction, Function: f, OldFunction: have})
+		}
+	}
+
+	names := make([]string, 0, len(currentDB.Functions))
+	for name := range currentDB.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, exists := targetSchema.Functions[name]; !exists {
+			changes = append(changes, SchemaChange{Type: ChangeDropFunction, OldFunction: currentDB.Functions[name]})
+		}
+	}
+	return changes
+}
+
+// sameSignature reports whether CREATE OR REPLACE can turn a into b: the
+// arguments, return type and kind must stay the same.
+func sameSignature(a, b *FunctionInfo) bool {
+	return a.Procedure == b.Procedure &&
+		normalizeSQLType(a.Args) == normalizeSQLType(b.Args) &&
+		normalizeSQLType(a.Returns) == normalizeSQLType(b.Returns)
+}
+
+func sameFunction(a, b *FunctionInfo) bool {
+	return sameSignature(a, b) &&
+		a.Immutable == b.Immutable &&
+		strings.EqualFold(a.Language, b.Language) &&
+		strings.Join(strings.Fields(a.Body), " ") == strings.Join(strings.Fields(b.Body), " ")
+}
+
+// sqlTypeAliases maps type names to the ones PostgreSQL reports.
+var sqlTypeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"int2":        "smallint",
+	"bool":        "boolean",
+	"float8":      "double precision",
+	"float4":      "real",
+	"decimal":     "numeric",
+	"varchar":     "character varying",
+	"timestamptz": "timestamp with time zone",
+}
+
+var sqlTypeWord = regexp.MustCompile(`[a-z_][a-z0-9_]*`)
+
+// normalizeSQLType lowercases types in a signature, spells out their
+// aliases and collapses whitespace.
+func normalizeSQLType(sig string) string {
+	sig = strings.Join(strings.Fields(strings.ToLower(sig)), " ")
+	sig = sqlTypeWord.ReplaceAllStringFunc(sig, func(word string) string {
+		if alias, ok := sqlTypeAliases[word]; ok {
+			return alias
+		}
+		return word
+	})
+	return strings.ReplaceAll(sig, ", ", ",")
+}
+
+// diffRowSecurity compares the row-level security of a table, either of
+// which may be nil when it is off without policies.
+func diffRowSecurity(table string, target, current *RowSecurityInfo) []SchemaChange {
+	if target == nil {
+		target = &RowSecurityInfo{}
+	}
+	if current == nil {
+		current = &RowSecurityInfo{}
+	}
+
+	var changes []SchemaChange
+	if target.Enabled != current.Enabled || target.Forced != current.Forced {
+		var old *RowSecurityInfo
+		if current.Enabled || current.Forced {
+			old = current
+		}
+		changes = append(changes, SchemaChange{
+			Type:           ChangeSetRowSecurity,
+			TableName:      table,
+			RowSecurity:    target,
+			OldRowSecurity: old,
+		})
+	}
+
+	for _, name := range sortedPolicyNames(target.Policies) {
+		want := target.Policies[name]
+		have, exists := current.Policies[name]
+		switch {
+		case !exists:
+			changes = append(changes, SchemaChange{Type: ChangeCreatePolicy, TableName: table, Policy: want})
+		case !samePolicy(want, have):
+			changes = append(changes, SchemaChange{Type: ChangeReplacePolicy, TableName: table, Policy: want, OldPolicy: have})
+		}
+	}
+	for _, name := range sortedPolicyNames(current.Policies) {
+		if _, exists := target.Policies[name]; !exists {
+			changes = append(changes, SchemaChange{Type: ChangeDropPolicy, TableName: table, OldPolicy: current.Policies[name]})
+		}
+	}
+	return changes
+}
+
+func sortedPolicyNames(policies map[string]*PolicyInfo) []string {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// samePolicy reports whether two policies are equivalent. PostgreSQL
+// stores conditions deparsed, so they are compared loosely.
+func samePolicy(a, b *PolicyInfo) bool {
+	return strings.EqualFold(a.Command, b.Command) &&
+		strings.Join(normalizeRoles(a.Roles), ",") == strings.Join(normalizeRoles(b.Roles), ",") &&
+		normalizeCondition(a.Using) == normalizeCondition(b.Using) &&
+		normalizeCondition(a.Check) == normalizeCondition(b.Check)
+}
+
+func normalizeRoles(roles []string) []string {
+	var normalized []string
+	for _, r := range roles {
+		if r = strings.ToLower(strings.Trim(r, `"`)); r != "public" {
+			normalized = append(normalized, r)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+var (
+	conditionNoise = strings.NewReplacer(" ", "", "\t", "", "\n", "", "(", "", ")", "", "::text", "")
+	conditionInt   = regexp.MustCompile(`::int\b`)
+)
+
+// normalizeCondition strips what PostgreSQL adds to a condition when it
+// stores it: parentheses, whitespace and casts to text.
+func normalizeCondition(condition string) string {
+	condition = conditionNoise.Replace(strings.ToLower(condition))
+	return conditionInt.ReplaceAllString(condition, "::integer")
+}
+
 // GenerateMigrationFromDiff creates a migration from the detected changes.
 func GenerateMigrationFromDiff(dialect dialects.Dialect, changes []SchemaChange, name string) (*Migration, error) {
 	if len(changes) == 0 {
@@ -201,11 +627,16 @@ func GenerateMigrationFromDiff(dialect dialects.Dialect, changes []SchemaChange,
 
 	var upStatements []string
 	var downStatements []string
+	createdEnums := make(map[string]bool)
+	createdSchemas := make(map[string]bool)
 
 	for _, change := range changes {
 		switch change.Type {
 		case ChangeCreateTable:
+			upStatements = append(upStatements, SchemaStatements(dialect, change.Model, createdSchemas)...)
+			upStatements = append(upStatements, EnumTypeStatements(dialect, change.Model.GetFields(), createdEnums)...)
 			upStatements = append(upStatements, dialect.CreateTableSQL(change.Model))
+			upStatements = append(upStatements, PartitionStatements(dialect, change.Model)...)
 			downStatements = append(downStatements, dialect.DropTableSQL(change.TableName))
 
 			// Also create indexes for the new table
@@ -215,6 +646,8 @@ func GenerateMigrationFromDiff(dialect dialects.Dialect, changes []SchemaChange,
 					downStatements = append(downStatements, dialect.DropIndexSQL(change.TableName, idx.Name))
 				}
 			}
+			upStatements = append(upStatements, CommentStatements(dialect, change.Model)...)
+			upStatements = append(upStatements, RowSecurityStatements(dialect, change.Model)...)
 
 		case ChangeDropTable:
 			upStatements = append(upStatements, dialect.DropTableSQL(change.TableName))
@@ -223,7 +656,11 @@ func GenerateMigrationFromDiff(dialect dialects.Dialect, changes []SchemaChange,
 			downStatements = append(downStatements, fmt.Sprintf("-- Cannot auto-generate: CREATE TABLE %s (manual intervention required)", change.TableName))
 
 		case ChangeAddColumn:
+			upStatements = append(upStatements, EnumTypeStatements(dialect, []*schema.Field{change.Field}, createdEnums)...)
 			upStatements = append(upStatements, dialect.AddColumnSQL(change.TableName, change.Field))
+			if c, ok := dialect.(dialects.Commenter); ok && !c.InlineComments() && change.Field.Description != "" {
+				upStatements = append(upStatements, c.ColumnCommentSQL(change.TableName, change.Field))
+			}
 			downStatements = append(downStatements, dialect.DropColumnSQL(change.TableName, change.ColumnName))
 
 		case ChangeDropColumn:
@@ -239,6 +676,68 @@ func GenerateMigrationFromDiff(dialect dialects.Dialect, changes []SchemaChange,
 			upStatements = append(upStatements, dialect.DropIndexSQL(change.TableName, change.IndexName))
 			// Note: For rollback, we would need the index definition
 			downStatements = append(downStatements, fmt.Sprintf("-- Cannot auto-generate: CREATE INDEX %s (manual intervention required)", change.IndexName))
+
+		case ChangeSetRowSecurity, ChangeCreatePolicy, ChangeDropPolicy, ChangeReplacePolicy:
+			rls, ok := dialect.(dialects.RowSecurity)
+			if !ok {
+				return nil, fmt.Errorf("%s does not support row-level security", dialect.Name())
+			}
+			up, down := rowSecurityChangeSQL(rls, change)
+			upStatements = append(upStatements, up...)
+			downStatements = append(downStatements, down...)
+
+		case ChangeSetCollation:
+			c, ok := dialect.(dialects.Collator)
+			if !ok {
+				return nil, fmt.Errorf("%s cannot change the collation of a column", dialect.Name())
+			}
+			old := *change.Field
+			old.CharacterSet, old.Collation = change.OldCharset, change.OldCollation
+			upStatements = append(upStatements, c.AlterCollationSQL(change.TableName, change.Field))
+			downStatements = append(downStatements, c.AlterCollationSQL(change.TableName, &old))
+
+		case ChangeSetTableCollation:
+			tc, ok := dialect.(dialects.TableCollator)
+			if !ok {
+				return nil, fmt.Errorf("%s has no table collations", dialect.Name())
+			}
+			upStatements = append(upStatements, tc.TableCollationSQL(change.TableName, change.Charset, change.Collation))
+			downStatements = append(downStatements, tc.TableCollationSQL(change.TableName, change.OldCharset, change.OldCollation))
+
+		case ChangeSetDefault:
+			d, ok := dialect.(dialects.Defaulter)
+			if !ok {
+				return nil, fmt.Errorf("%s cannot change the default of a column", dialect.Name())
+			}
+			upStatements = append(upStatements, d.AlterDefaultSQL(change.TableName, change.Field))
+			downStatements = append(downStatements, d.AlterDefaultSQL(change.TableName, previousDefault(change)))
+
+		case ChangeSetComment:
+			c, ok := dialect.(dialects.Commenter)
+			if !ok {
+				return nil, fmt.Errorf("%s has no column comments", dialect.Name())
+			}
+			old := *change.Field
+			old.Description = change.OldComment
+			upStatements = append(upStatements, c.ColumnCommentSQL(change.TableName, change.Field))
+			downStatements = append(downStatements, c.ColumnCommentSQL(change.TableName, &old))
+
+		case ChangeSetTableComment:
+			c, ok := dialect.(dialects.Commenter)
+			if !ok {
+				return nil, fmt.Errorf("%s has no table comments", dialect.Name())
+			}
+			upStatements = append(upStatements, c.TableCommentSQL(change.TableName, change.Comment))
+			downStatements = append(downStatements, c.TableCommentSQL(change.TableName, change.OldComment))
+
+		case ChangeCreateFunction, ChangeReplaceFunction, ChangeDropFunction:
+			fc, ok := dialect.(dialects.FunctionCreator)
+			if !ok {
+				return nil, fmt.Errorf("%s does not support stored functions", dialect.Name())
+			}
+			up, down := functionChangeSQL(fc, change)
+			upStatements = append(upStatements, up...)
+			downStatements = append(downStatements, down...)
 		}
 	}
 
@@ -260,6 +759,163 @@ func GenerateMigrationFromDiff(dialect dialects.Dialect, changes []SchemaChange,
 	}, nil
 }
 
+// EnumTypeStatements returns the statements creating the enum types used by
+// fields, for dialects with named enum types. created holds the enums already
+// emitted in this migration.
+func EnumTypeStatements(dialect dialects.Dialect, fields []*schema.Field, created map[string]bool) []string {
+	creator, ok := dialect.(dialects.EnumTypeCreator)
+	if !ok {
+		return nil
+	}
+
+	var statements []string
+	for _, field := range fields {
+		if field.Enum == nil || created[field.Enum.Name] {
+			continue
+		}
+		created[field.Enum.Name] = true
+		statements = append(statements, creator.CreateEnumTypeSQL(field.Enum))
+	}
+	return statements
+}
+
+// SchemaStatements returns the statement creating the schema of a model's
+// table, for dialects with named schemas. created holds the schemas already
+// emitted in this migration.
+func SchemaStatements(dialect dialects.Dialect, model *schema.Model, created map[string]bool) []string {
+	creator, ok := dialect.(dialects.SchemaCreator)
+	if !ok || model.SchemaName == "" || created[model.SchemaName] {
+		return nil
+	}
+	created[model.SchemaName] = true
+	return []string{creator.CreateSchemaSQL(model.SchemaName)}
+}
+
+// PartitionStatements returns the statements creating the partitions a new
+// partitioned table starts with, for dialects that have them.
+func PartitionStatements(dialect dialects.Dialect, model *schema.Model) []string {
+	p, ok := dialect.(dialects.Partitioner)
+	if !ok || model.Partition == nil {
+		return nil
+	}
+	return p.InitialPartitionsSQL(model)
+}
+
+// CommentStatements returns the statements setting the comments of a new
+// table and its columns, for dialects that store comments outside CREATE
+// TABLE.
+func CommentStatements(dialect dialects.Dialect, model *schema.Model) []string {
+	c, ok := dialect.(dialects.Commenter)
+	if !ok || c.InlineComments() {
+		return nil
+	}
+	var statements []string
+	if model.Description != "" {
+		statements = append(statements, c.TableCommentSQL(model.Table(), model.Description))
+	}
+	for _, field := range model.GetFields() {
+		if field.Description != "" {
+			statements = append(statements, c.ColumnCommentSQL(model.Table(), field))
+		}
+	}
+	return statements
+}
+
+// RowSecurityStatements returns the statements enabling row-level security
+// on a new table and creating its policies, for dialects that have it.
+func RowSecurityStatements(dialect dialects.Dialect, model *schema.Model) []string {
+	rls, ok := dialect.(dialects.RowSecurity)
+	if !ok || !model.RowSecurity {
+		return nil
+	}
+
+	statements := []string{rls.RowSecuritySQL(model.Table(), true, model.ForceRowSecurity)}
+	for _, p := range model.Policies {
+		statements = append(statements, rls.CreatePolicySQL(model.Table(), p))
+	}
+	return statements
+}
+
+// rowSecurityChangeSQL returns the up and down statements of a row-level
+// security change.
+func rowSecurityChangeSQL(rls dialects.RowSecurity, change SchemaChange) (up, down []string) {
+	table := change.TableName
+	switch change.Type {
+	case ChangeSetRowSecurity:
+		old := change.OldRowSecurity
+		if old == nil {
+			old = &RowSecurityInfo{}
+		}
+		up = append(up, rls.RowSecuritySQL(table, change.RowSecurity.Enabled, change.RowSecurity.Forced))
+		down = append(down, rls.RowSecuritySQL(table, old.Enabled, old.Forced))
+	case ChangeCreatePolicy:
+		up = append(up, rls.CreatePolicySQL(table, change.Policy.schemaPolicy()))
+		down = append(down, rls.DropPolicySQL(table, change.Policy.Name))
+	case ChangeDropPolicy:
+		up = append(up, rls.DropPolicySQL(table, change.OldPolicy.Name))
+		down = append(down, rls.CreatePolicySQL(table, change.OldPolicy.schemaPolicy()))
+	case ChangeReplacePolicy:
+		up = append(up, rls.DropPolicySQL(table, change.OldPolicy.Name), rls.CreatePolicySQL(table, change.Policy.schemaPolicy()))
+		down = append(down, rls.DropPolicySQL(table, change.Policy.Name), rls.CreatePolicySQL(table, change.OldPolicy.schemaPolicy()))
+	}
+	return up, down
+}
+
+// FunctionStatements returns the statements creating the functions of a
+// schema and dropping them, for dialects with stored functions.
+func FunctionStatements(dialect dialects.Dialect, s *schema.Schema) (up, down []string) {
+	fc, ok := dialect.(dialects.FunctionCreator)
+	if !ok {
+		return nil, nil
+	}
+	for _, f := range s.GetFunctions() {
+		up = append(up, fc.CreateFunctionSQL(f))
+		down = append(down, fc.DropFunctionSQL(f))
+	}
+	return up, down
+}
+
+// functionChangeSQL returns the up and down statements of a function
+// change. A changed signature cannot be replaced, so the old function is
+// dropped first.
+func functionChangeSQL(fc dialects.FunctionCreator, change SchemaChange) (up, down []string) {
+	switch change.Type {
+	case ChangeCreateFunction:
+		up = append(up, fc.CreateFunctionSQL(change.Function))
+		down = append(down, fc.DropFunctionSQL(change.Function))
+	case ChangeReplaceFunction:
+		old := change.OldFunction.schemaFunction()
+		if !sameSignature(functionInfo(change.Function), change.OldFunction) {
+			up = append(up, fc.DropFunctionSQL(old))
+			down = append(down, fc.DropFunctionSQL(change.Function))
+		}
+		up = append(up, fc.CreateFunctionSQL(change.Function))
+		down = append(down, fc.CreateFunctionSQL(old))
+	case ChangeDropFunction:
+		old := change.OldFunction.schemaFunction()
+		up = append(up, fc.DropFunctionSQL(old))
+		down = append(down, fc.CreateFunctionSQL(old))
+	}
+	return up, down
+}
+
+// previousDefault returns the field of a set default change with the
+// default it had before. Expressions that have no portable form are kept
+// as the database reported them.
+func previousDefault(change SchemaChange) *schema.Field {
+	old := *change.Field
+	old.DefaultExpr, old.DefaultValue = "", nil
+	if isNullDefault(change.OldDefault) {
+		return &old
+	}
+	if expr, value, ok := parseDefault(change.OldDefault, old.Type); ok {
+		old.DefaultExpr, old.DefaultValue = expr, value
+	} else {
+		old.DefaultExpr = change.OldDefault
+	}
+	return &old
+}
+
 // DescribeChanges returns a human-readable description of the changes.
 func DescribeChanges(changes []SchemaChange) []string {
 	var descriptions []string
@@ -280,8 +936,86 @@ func DescribeChanges(changes []SchemaChange) []string {
 			desc = fmt.Sprintf("+ ADD INDEX %s.%s", change.TableName, change.IndexName)
 		case ChangeDropIndex:
 			desc = fmt.Sprintf("- DROP INDEX %s.%s", change.TableName, change.IndexName)
+		case ChangeSetRowSecurity:
+			state := "DISABLE"
+			if change.RowSecurity.Enabled {
+				state = "ENABLE"
+			}
+			if change.RowSecurity.Forced {
+				state += ", FORCE"
+			}
+			desc = fmt.Sprintf("~ %s ROW LEVEL SECURITY %s", state, change.TableName)
+		case ChangeCreatePolicy:
+			desc = fmt.Sprintf("+ CREATE POLICY %s.%s", change.TableName, change.Policy.Name)
+		case ChangeDropPolicy:
+			desc = fmt.Sprintf("- DROP POLICY %s.%s", change.TableName, change.OldPolicy.Name)
+		case ChangeReplacePolicy:
+			desc = fmt.Sprintf("~ REPLACE POLICY %s.%s", change.TableName, change.Policy.Name)
+		case ChangeCreateFunction:
+			desc = fmt.Sprintf("+ CREATE %s %s", routineKind(change.Function.Procedure), change.Function.Name)
+		case ChangeReplaceFunction:
+			desc = fmt.Sprintf("~ REPLACE %s %s", routineKind(change.Function.Procedure), change.Function.Name)
+		case ChangeDropFunction:
+			desc = fmt.Sprintf("- DROP %s %s", routineKind(change.OldFunction.Procedure), change.OldFunction.Name)
+		case ChangeSetCollation:
+			desc = fmt.Sprintf("~ SET COLLATION %s.%s: %s", change.TableName, change.ColumnName, collationDescription(change.Charset, change.Collation))
+		case ChangeSetTableCollation:
+			desc = fmt.Sprintf("~ SET COLLATION %s: %s", change.TableName, collationDescription(change.Charset, change.Collation))
+		case ChangeSetDefault:
+			desc = fmt.Sprintf("~ SET DEFAULT %s.%s: %s", change.TableName, change.ColumnName, defaultDescription(change.Field))
+		case ChangeSetComment:
+			desc = fmt.Sprintf("~ SET COMMENT %s.%s: %s", change.TableName, change.ColumnName, commentDescription(change.Comment))
+		case ChangeSetTableComment:
+			desc = fmt.Sprintf("~ SET COMMENT %s: %s", change.TableName, commentDescription(change.Comment))
 		}
 		descriptions = append(descriptions, desc)
 	}
 	return descriptions
 }
+
+// commentDescription quotes a comment, or returns "none".
+func commentDescription(comment string) string {
+	if comment == "" {
+		return "none"
+	}
+	return strconv.Quote(comment)
+}
+
+// collationDescription describes a character set and collation, e.g.
+// "CHARACTER SET utf8mb4 COLLATE utf8mb4_bin".
+func collationDescription(charset, collation string) string {
+	var parts []string
+	if charset != "" {
+		parts = append(parts, "CHARACTER SET "+charset)
+	}
+	if collation != "" {
+		parts = append(parts, "COLLATE "+collation)
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+	return strings.Join(parts, " ")
+}
+
+// defaultDescription describes the default of a field as the schema DSL
+// writes it, e.g. now() or "draft".
+func defaultDescription(f *schema.Field) string {
+	switch v := f.DefaultValue.(type) {
+	case nil:
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+	if f.DefaultExpr == "" {
+		return "none"
+	}
+	return strings.ToLower(f.DefaultExpr)
+}
+
+func routineKind(procedure bool) string {
+	if procedure {
+		return "PROCEDURE"
+	}
+	return "FUNCTION"
+}
@@ -4,8 +4,10 @@ package migration
 import (
 	"context"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
 )
 
 // Migration represents a single database migration.
@@ -24,6 +27,12 @@ type Migration struct {
 	DownSQL   string    // SQL to rollback migration
 	Checksum  string    // SHA256 hash of UpSQL
 	AppliedAt time.Time // When migration was applied (zero if pending)
+
+	// From the nexus: header of the migration file, if any
+	Author        string
+	Description   string
+	NoTransaction bool     // Run outside a transaction (transaction: false)
+	Environments  []string // Run only in these environments (env:); empty for all
 }
 
 // MigrationHistory represents applied migrations stored in the database.
@@ -33,23 +42,52 @@ type MigrationHistory struct {
 	Name        string
 	Checksum    string
 	AppliedAt   time.Time
+	Duration    time.Duration // How long the migration took (zero if unknown)
 }
 
+// HistoryTable is the table the engine records applied migrations in.
+const HistoryTable = "_nexus_migrations"
+
 // Engine manages database migrations.
 type Engine struct {
-	conn          *dialects.Connection
-	migrations    []*Migration
-	tableName     string
-	lockTableName string
+	conn       *dialects.Connection
+	migrations []*Migration
+	tableName  string
+
+	strictOrder     bool   // Refuse out-of-order migrations in Up
+	environment     string // Environment gated migrations are selected for
+	noSchemaHistory bool   // Don't record the schema after each migration
+	hooks           Hooks  // Run around Up and Down
+	progress        ProgressFunc
+	onMigration     func(MigrationEvent)
+	logger          nexuslog.Logger
+
+	locker    Locker
+	held      *LockInfo // Lock taken by AcquireLock
+	stopRenew func()    // Stops renewing its lease
 }
 
 // NewEngine creates a new migration engine.
 func NewEngine(conn *dialects.Connection) *Engine {
 	return &Engine{
-		conn:          conn,
-		tableName:     "_nexus_migrations",
-		lockTableName: "_nexus_migration_lock",
+		conn:      conn,
+		tableName: HistoryTable,
+	}
+}
+
+// WithLogger sets the logger of the engine. Without it, the engine logs to
+// the logger of the context, if any.
+func (e *Engine) WithLogger(l nexuslog.Logger) *Engine {
+	e.logger = l
+	return e
+}
+
+// log returns the logger of the engine.
+func (e *Engine) log(ctx context.Context) nexuslog.Logger {
+	if e.logger != nil {
+		return e.logger
 	}
+	return nexuslog.FromContext(ctx)
 }
 
 // Init creates the migrations table if it doesn't exist.
@@ -60,16 +98,54 @@ func (e *Engine) Init(ctx context.Context) error {
 		migration_id TEXT NOT NULL UNIQUE,
 		name TEXT NOT NULL,
 		checksum TEXT NOT NULL,
-		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		execution_ms INTEGER
 	)`, dialect.Quote(e.tableName))
+	if dialect.Name() == "mssql" {
+		// No IF NOT EXISTS, TIMESTAMP is a row version and TEXT can't be unique
+		sql = fmt.Sprintf(`IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (
+		id INT IDENTITY(1,1) PRIMARY KEY,
+		migration_id NVARCHAR(255) NOT NULL UNIQUE,
+		name NVARCHAR(255) NOT NULL,
+		checksum NVARCHAR(64) NOT NULL,
+		applied_at DATETIME2 NOT NULL DEFAULT SYSDATETIME(),
+		execution_ms INT
+	)`, e.tableName, dialect.Quote(e.tableName))
+	}
 
-	_, err := e.conn.Exec(ctx, sql)
-	return err
+	if _, err := e.conn.Exec(ctx, sql); err != nil {
+		return err
+	}
+
+	// Tables created before execution times were recorded lack the column
+	table := dialect.Quote(e.tableName)
+	if _, err := e.conn.Exec(ctx, fmt.Sprintf("SELECT execution_ms FROM %s WHERE id = 0", table)); err == nil {
+		return e.initSchemaHistory(ctx)
+	}
+	if _, err := e.conn.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN execution_ms INTEGER", table)); err != nil {
+		return err
+	}
+	return e.initSchemaHistory(ctx)
 }
 
 // LoadFromDir loads migrations from a directory.
 func (e *Engine) LoadFromDir(dir string) error {
-	files, err := os.ReadDir(dir)
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	return e.LoadFromFS(os.DirFS(dir))
+}
+
+// LoadFromFS loads the migrations at the root of fsys, such as an
+// embed.FS, so that a binary carries its migrations:
+//
+//	//go:embed migrations/*.sql
+//	var files embed.FS
+//
+//	dir, _ := fs.Sub(files, "migrations")
+//	err := engine.LoadFromFS(dir)
+func (e *Engine) LoadFromFS(fsys fs.FS) error {
+	files, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return err
 	}
@@ -79,12 +155,12 @@ func (e *Engine) LoadFromDir(dir string) error {
 			continue
 		}
 
-		content, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		content, err := fs.ReadFile(fsys, f.Name())
 		if err != nil {
 			return err
 		}
 
-		migration, err := parseMigrationFile(f.Name(), string(content))
+		migration, err := ParseMigrationFile(f.Name(), string(content))
 		if err != nil {
 			return fmt.Errorf("parsing %s: %w", f.Name(), err)
 		}
@@ -100,38 +176,18 @@ func (e *Engine) LoadFromDir(dir string) error {
 	return nil
 }
 
-// parseMigrationFile parses a migration file with UP/DOWN sections.
-func parseMigrationFile(filename, content string) (*Migration, error) {
-	// Expected format: 20231221_123000_create_users.sql
-	parts := strings.SplitN(strings.TrimSuffix(filename, ".sql"), "_", 3)
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("invalid migration filename format")
-	}
-
-	id := parts[0] + "_" + parts[1]
-	name := parts[2]
-
-	// Parse UP and DOWN sections
-	upSQL, downSQL := "", ""
-	sections := strings.Split(content, "-- DOWN")
-	if len(sections) >= 1 {
-		upPart := strings.TrimPrefix(sections[0], "-- UP")
-		upSQL = strings.TrimSpace(upPart)
-	}
-	if len(sections) >= 2 {
-		downSQL = strings.TrimSpace(sections[1])
-	}
-
-	hash := sha256.Sum256([]byte(upSQL))
-	checksum := hex.EncodeToString(hash[:])
+// Migrations returns the loaded migrations, oldest first.
+func (e *Engine) Migrations() []*Migration {
+	return append([]*Migration(nil), e.migrations...)
+}
 
-	return &Migration{
-		ID:       id,
-		Name:     name,
-		UpSQL:    upSQL,
-		DownSQL:  downSQL,
-		Checksum: checksum,
-	}, nil
+// Add loads a migration, such as one just generated and saved, keeping
+// the migrations sorted by ID.
+func (e *Engine) Add(m *Migration) {
+	e.migrations = append(e.migrations, m)
+	sort.Slice(e.migrations, func(i, j int) bool {
+		return e.migrations[i].ID < e.migrations[j].ID
+	})
 }
 
 // Pending returns migrations that haven't been applied yet.
@@ -148,7 +204,7 @@ func (e *Engine) Pending(ctx context.Context) ([]*Migration, error) {
 
 	var pending []*Migration
 	for _, m := range e.migrations {
-		if !appliedMap[m.ID] {
+		if !appliedMap[m.ID] && e.InEnvironment(m) {
 			pending = append(pending, m)
 		}
 	}
@@ -156,50 +212,101 @@ func (e *Engine) Pending(ctx context.Context) ([]*Migration, error) {
 	return pending, nil
 }
 
-// Up applies all pending migrations.
+// Up applies all pending migrations. With WithStrictOrder it fails if any
+// of them is older than the newest applied migration.
 func (e *Engine) Up(ctx context.Context) (int, error) {
+	if e.strictOrder {
+		older, latest, err := e.OutOfOrder(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if len(older) > 0 {
+			return 0, &OutOfOrderError{Migrations: older, LatestApplied: latest}
+		}
+	}
+
 	pending, err := e.Pending(ctx)
 	if err != nil {
 		return 0, err
 	}
 
+	if len(pending) == 0 {
+		e.log(ctx).Debug("No pending migrations")
+	}
+	if err := e.runHooks(ctx, BeforeMigrate, "up", pending); err != nil {
+		return 0, err
+	}
 	for _, m := range pending {
 		if err := e.applyMigration(ctx, m); err != nil {
+			e.log(ctx).Error("Migration failed", nexuslog.F("migration", m.ID), nexuslog.Err(err))
 			return 0, fmt.Errorf("applying migration %s: %w", m.ID, err)
 		}
 	}
+	if err := e.runHooks(ctx, AfterMigrate, "up", pending); err != nil {
+		return len(pending), err
+	}
 
 	return len(pending), nil
 }
 
 // Down rolls back the last applied migration.
 func (e *Engine) Down(ctx context.Context) error {
-	applied, err := e.getApplied(ctx)
+	migrations, err := e.lastApplied(ctx, 1)
 	if err != nil {
 		return err
 	}
-
-	if len(applied) == 0 {
+	if len(migrations) == 0 {
 		return fmt.Errorf("no migrations to rollback")
 	}
+	_, err = e.rollbackAll(ctx, migrations)
+	return err
+}
 
-	// Get the last applied migration
-	last := applied[len(applied)-1]
+// lastApplied returns up to n applied migrations, newest first. n < 0
+// returns all of them.
+func (e *Engine) lastApplied(ctx context.Context, n int) ([]*Migration, error) {
+	applied, err := e.getApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	// Find corresponding migration
-	var migration *Migration
-	for _, m := range e.migrations {
-		if m.ID == last.MigrationID {
-			migration = m
-			break
+	var migrations []*Migration
+	for i := len(applied) - 1; i >= 0 && (n < 0 || len(migrations) < n); i-- {
+		migration, err := e.loaded(applied[i].MigrationID)
+		if err != nil {
+			return nil, err
 		}
+		migrations = append(migrations, migration)
 	}
+	return migrations, nil
+}
 
-	if migration == nil {
-		return fmt.Errorf("migration %s not found in loaded migrations", last.MigrationID)
+// loaded returns the loaded migration of an applied one.
+func (e *Engine) loaded(id string) (*Migration, error) {
+	for _, m := range e.migrations {
+		if m.ID == id {
+			return m, nil
+		}
 	}
+	return nil, fmt.Errorf("migration %s not found in loaded migrations", id)
+}
 
-	return e.rollbackMigration(ctx, migration)
+// rollbackAll rolls back migrations in order with the migration hooks
+// around them, and returns how many it rolled back.
+func (e *Engine) rollbackAll(ctx context.Context, migrations []*Migration) (int, error) {
+	if err := e.runHooks(ctx, BeforeMigrate, "down", migrations); err != nil {
+		return 0, err
+	}
+	for i, m := range migrations {
+		if err := e.rollbackMigration(ctx, m); err != nil {
+			e.log(ctx).Error("Rollback failed", nexuslog.F("migration", m.ID), nexuslog.Err(err))
+			if len(migrations) == 1 {
+				return i, err
+			}
+			return i, fmt.Errorf("rolling back %s: %w", m.ID, err)
+		}
+	}
+	return len(migrations), e.runHooks(ctx, AfterMigrate, "down", migrations)
 }
 
 // DownTo rolls back migrations until reaching the specified target migration ID.
@@ -229,30 +336,11 @@ func (e *Engine) DownTo(ctx context.Context, targetID string) (int, error) {
 	}
 
 	// Rollback from the last applied down to (but not including) the target
-	count := 0
-	for i := len(applied) - 1; i > targetIdx; i-- {
-		h := applied[i]
-
-		// Find corresponding migration
-		var migration *Migration
-		for _, m := range e.migrations {
-			if m.ID == h.MigrationID {
-				migration = m
-				break
-			}
-		}
-
-		if migration == nil {
-			return count, fmt.Errorf("migration %s not found in loaded migrations", h.MigrationID)
-		}
-
-		if err := e.rollbackMigration(ctx, migration); err != nil {
-			return count, fmt.Errorf("rolling back %s: %w", migration.ID, err)
-		}
-		count++
+	migrations, err := e.lastApplied(ctx, len(applied)-1-targetIdx)
+	if err != nil {
+		return 0, err
 	}
-
-	return count, nil
+	return e.rollbackAll(ctx, migrations)
 }
 
 // DownN rolls back the specified number of migrations.
@@ -262,19 +350,24 @@ func (e *Engine) DownN(ctx context.Context, n int) (int, error) {
 		return 0, fmt.Errorf("n must be positive")
 	}
 
-	count := 0
-	for i := 0; i < n; i++ {
-		if err := e.Down(ctx); err != nil {
-			if count == 0 {
-				return 0, err
-			}
-			// We've rolled back some, but hit an error or end
-			break
-		}
-		count++
+	migrations, err := e.lastApplied(ctx, n)
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, fmt.Errorf("no migrations to rollback")
 	}
+	return e.rollbackAll(ctx, migrations)
+}
 
-	return count, nil
+// DownAll rolls back every applied migration, newest first, and returns
+// how many it rolled back.
+func (e *Engine) DownAll(ctx context.Context) (int, error) {
+	migrations, err := e.lastApplied(ctx, -1)
+	if err != nil {
+		return 0, err
+	}
+	return e.rollbackAll(ctx, migrations)
 }
 
 // Status returns the status of all migrations.
@@ -288,16 +381,26 @@ func (e *Engine) Status(ctx context.Context) ([]MigrationStatus, error) {
 	for i := range applied {
 		appliedMap[applied[i].MigrationID] = &applied[i]
 	}
+	latest := latestID(applied)
 
 	var status []MigrationStatus
 	for _, m := range e.migrations {
 		s := MigrationStatus{
-			ID:   m.ID,
-			Name: m.Name,
+			ID:            m.ID,
+			Name:          m.Name,
+			Author:        m.Author,
+			Description:   m.Description,
+			NoTransaction: m.NoTransaction,
+			Environments:  m.Environments,
 		}
 		if h, ok := appliedMap[m.ID]; ok {
 			s.Applied = true
 			s.AppliedAt = h.AppliedAt
+			s.Duration = h.Duration
+		} else if !e.InEnvironment(m) {
+			s.Excluded = true
+		} else {
+			s.OutOfOrder = m.ID < latest
 		}
 		status = append(status, s)
 	}
@@ -307,16 +410,24 @@ func (e *Engine) Status(ctx context.Context) ([]MigrationStatus, error) {
 
 // MigrationStatus represents the status of a migration.
 type MigrationStatus struct {
-	ID        string
-	Name      string
-	Applied   bool
-	AppliedAt time.Time
+	ID         string
+	Name       string
+	Applied    bool
+	AppliedAt  time.Time
+	Duration   time.Duration // How long the migration took (zero if unknown)
+	OutOfOrder bool          // Pending but older than the newest applied migration
+
+	Author        string // From the nexus: header of the migration file
+	Description   string
+	NoTransaction bool
+	Environments  []string
+	Excluded      bool // Pending, but for other environments than the engine's
 }
 
 func (e *Engine) getApplied(ctx context.Context) ([]MigrationHistory, error) {
 	dialect := e.conn.Dialect
 	query := fmt.Sprintf(
-		"SELECT id, migration_id, name, checksum, applied_at FROM %s ORDER BY id",
+		"SELECT id, migration_id, name, checksum, applied_at, execution_ms FROM %s ORDER BY id",
 		dialect.Quote(e.tableName),
 	)
 
@@ -329,9 +440,11 @@ func (e *Engine) getApplied(ctx context.Context) ([]MigrationHistory, error) {
 	var history []MigrationHistory
 	for rows.Next() {
 		var h MigrationHistory
-		if err := rows.Scan(&h.ID, &h.MigrationID, &h.Name, &h.Checksum, &h.AppliedAt); err != nil {
+		var ms sql.NullInt64
+		if err := rows.Scan(&h.ID, &h.MigrationID, &h.Name, &h.Checksum, &h.AppliedAt, &ms); err != nil {
 			return nil, err
 		}
+		h.Duration = time.Duration(ms.Int64) * time.Millisecond
 		history = append(history, h)
 	}
 
@@ -340,24 +453,38 @@ func (e *Engine) getApplied(ctx context.Context) ([]MigrationHistory, error) {
 
 func (e *Engine) applyMigration(ctx context.Context, m *Migration) error {
 	dialect := e.conn.Dialect
+	tx, err := e.begin(ctx, m, m.UpSQL)
+	if err != nil {
+		return err
+	}
+	defer tx.rollback()
 
 	// Execute migration SQL
-	_, err := e.conn.Exec(ctx, m.UpSQL)
+	elapsed, err := e.execute(ctx, tx, m, "up", m.UpSQL)
 	if err != nil {
 		return err
 	}
 
 	// Record in history
 	insertSQL := fmt.Sprintf(
-		"INSERT INTO %s (migration_id, name, checksum) VALUES (%s, %s, %s)",
+		"INSERT INTO %s (migration_id, name, checksum, execution_ms) VALUES (%s, %s, %s, %s)",
 		dialect.Quote(e.tableName),
 		dialect.Placeholder(1),
 		dialect.Placeholder(2),
 		dialect.Placeholder(3),
+		dialect.Placeholder(4),
 	)
 
-	_, err = e.conn.Exec(ctx, insertSQL, m.ID, m.Name, m.Checksum)
-	return err
+	if _, err := tx.Exec(ctx, insertSQL, m.ID, m.Name, m.Checksum, durationMillis(elapsed)); err != nil {
+		return err
+	}
+	if err := tx.commit(); err != nil {
+		return err
+	}
+	e.recordSchema(ctx, m)
+	e.log(ctx).Info("Applied migration", nexuslog.F("migration", m.ID), nexuslog.F("name", m.Name), nexuslog.F("duration", elapsed))
+	e.migrated(m, "up", elapsed)
+	return nil
 }
 
 func (e *Engine) rollbackMigration(ctx context.Context, m *Migration) error {
@@ -367,8 +494,14 @@ func (e *Engine) rollbackMigration(ctx context.Context, m *Migration) error {
 		return fmt.Errorf("migration %s has no DOWN section", m.ID)
 	}
 
+	tx, err := e.begin(ctx, m, m.DownSQL)
+	if err != nil {
+		return err
+	}
+	defer tx.rollback()
+
 	// Execute rollback SQL
-	_, err := e.conn.Exec(ctx, m.DownSQL)
+	elapsed, err := e.execute(ctx, tx, m, "down", m.DownSQL)
 	if err != nil {
 		return err
 	}
@@ -380,8 +513,16 @@ func (e *Engine) rollbackMigration(ctx context.Context, m *Migration) error {
 		dialect.Placeholder(1),
 	)
 
-	_, err = e.conn.Exec(ctx, deleteSQL, m.ID)
-	return err
+	if _, err := tx.Exec(ctx, deleteSQL, m.ID); err != nil {
+		return err
+	}
+	if err := tx.commit(); err != nil {
+		return err
+	}
+	e.forgetSchema(ctx, m)
+	e.log(ctx).Info("Rolled back migration", nexuslog.F("migration", m.ID), nexuslog.F("name", m.Name), nexuslog.F("duration", elapsed))
+	e.migrated(m, "down", elapsed)
+	return nil
 }
 
 // GenerateFromSchema generates migrations from schema changes.
@@ -390,20 +531,31 @@ func (e *Engine) GenerateFromSchema(s *schema.Schema, name string) (*Migration,
 
 	var upStatements []string
 	var downStatements []string
+	createdEnums := make(map[string]bool)
+	createdSchemas := make(map[string]bool)
 
 	for _, model := range s.GetModels() {
+		upStatements = append(upStatements, SchemaStatements(dialect, model, createdSchemas)...)
+		upStatements = append(upStatements, EnumTypeStatements(dialect, model.GetFields(), createdEnums)...)
 		upStatements = append(upStatements, dialect.CreateTableSQL(model))
-		downStatements = append(downStatements, dialect.DropTableSQL(model.Name))
+		upStatements = append(upStatements, PartitionStatements(dialect, model)...)
+		downStatements = append(downStatements, dialect.DropTableSQL(model.Table()))
 
 		// Create indexes
 		for _, idx := range model.Indexes {
 			if len(idx.Fields) > 1 || !idx.Unique {
-				upStatements = append(upStatements, dialect.CreateIndexSQL(model.Name, idx))
-				downStatements = append(downStatements, dialect.DropIndexSQL(model.Name, idx.Name))
+				upStatements = append(upStatements, dialect.CreateIndexSQL(model.Table(), idx))
+				downStatements = append(downStatements, dialect.DropIndexSQL(model.Table(), idx.Name))
 			}
 		}
+		upStatements = append(upStatements, CommentStatements(dialect, model)...)
+		upStatements = append(upStatements, RowSecurityStatements(dialect, model)...)
 	}
 
+	functionsUp, functionsDown := FunctionStatements(dialect, s)
+	upStatements = append(upStatements, functionsUp...)
+	downStatements = append(functionsDown, downStatements...)
+
 	now := time.Now()
 	id := now.Format("20060102_150405")
 
@@ -425,6 +577,5 @@ func (e *Engine) GenerateFromSchema(s *schema.Schema, name string) (*Migration,
 // SaveMigration saves a migration to a file.
 func SaveMigration(dir string, m *Migration) error {
 	filename := fmt.Sprintf("%s_%s.sql", m.ID, m.Name)
-	content := fmt.Sprintf("-- UP\n%s\n\n-- DOWN\n%s\n", m.UpSQL, m.DownSQL)
-	return os.WriteFile(filepath.Join(dir, filename), []byte(content), 0644)
+	return os.WriteFile(filepath.Join(dir, filename), []byte(FormatMigration(m)), 0644)
 }
@@ -0,0 +1,32 @@
+package migration
+
+import "strings"
+
+// WithEnvironment sets the environment the engine migrates, such as
+// production. Migrations gated to environments with env: in their header,
+// such as fixtures for development or backfills for production, only run
+// in those; elsewhere they are never pending. Without an environment, no
+// gated migration runs, so that one can't be applied by accident.
+func (e *Engine) WithEnvironment(env string) *Engine {
+	e.environment = env
+	return e
+}
+
+// Environment returns the environment set with WithEnvironment.
+func (e *Engine) Environment() string {
+	return e.environment
+}
+
+// InEnvironment reports whether m runs in the environment of the engine:
+// migrations without environments run in all of them.
+func (e *Engine) InEnvironment(m *Migration) bool {
+	if len(m.Environments) == 0 {
+		return true
+	}
+	for _, env := range m.Environments {
+		if e.environment != "" && strings.EqualFold(env, e.environment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Section markers of migration files. -- UP and -- DOWN may be in any case;
+// -- +nexus Up and -- +nexus Down are read as goose (+goose) and
+// sql-migrate (+migrate) write them, so that their files load as they are.
+var (
+	upMarker   = regexp.MustCompile(`^--\s*(?:\+(?:nexus|goose|migrate)\s+(?i:up)\b.*|UP(?:\s.*)?|(?i:up))$`)
+	downMarker = regexp.MustCompile(`^--\s*(?:\+(?:nexus|goose|migrate)\s+(?i:down)\b.*|DOWN(?:\s.*)?|(?i:down))$`)
+
+	// noTransactionMarker matches goose's -- +goose NO TRANSACTION and the
+	// notransaction option of sql-migrate's -- +migrate Up.
+	noTransactionMarker = regexp.MustCompile(`(?i)^--\s*\+(?:nexus|goose|migrate)\s+(?:NO\s*TRANSACTION|up\s+notransaction)\s*$`)
+
+	// headerStart matches the first line of the header, with the header
+	// itself when it is written inline: -- nexus: { transaction: false }
+	headerStart = regexp.MustCompile(`(?i)^--\s*nexus:\s*(.*)$`)
+
+	// headerLine matches a line of a header written over several lines:
+	//
+	//	-- nexus:
+	//	--   author: ann
+	//	--   description: Add users
+	headerLine = regexp.MustCompile(`^--\s+([A-Za-z_]+)\s*:\s*(.*)$`)
+)
+
+// ParseMigrationFile parses a migration file named like
+// 20231221_123000_create_users.sql. The file may start with a nexus:
+// header, which is not part of the UP SQL, so editing it doesn't change
+// the checksum.
+func ParseMigrationFile(filename, content string) (*Migration, error) {
+	parts := strings.SplitN(strings.TrimSuffix(filename, ".sql"), "_", 3)
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("invalid migration filename format")
+	}
+
+	m := &Migration{ID: parts[0] + "_" + parts[1], Name: parts[2]}
+	lines := strings.SplitAfter(content, "\n")
+	body, err := m.parseHeader(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	// The UP marker is left out only as the first line, as it always was
+	upStart := len(lines)
+	for i := body; i < len(lines); i++ {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			upStart = i
+			if upMarker.MatchString(line) {
+				m.NoTransaction = m.NoTransaction || noTransactionMarker.MatchString(line)
+				upStart++
+			}
+			break
+		}
+	}
+	downStart := len(lines)
+	for i := upStart; i < len(lines); i++ {
+		if downMarker.MatchString(strings.TrimSpace(lines[i])) {
+			downStart = i
+			break
+		}
+	}
+	m.UpSQL = strings.TrimSpace(strings.Join(lines[min(upStart, downStart):downStart], ""))
+	if downStart < len(lines) {
+		m.DownSQL = strings.TrimSpace(strings.Join(lines[downStart+1:], ""))
+	}
+
+	hash := sha256.Sum256([]byte(m.UpSQL))
+	m.Checksum = hex.EncodeToString(hash[:])
+	return m, nil
+}
+
+// FormatMigration returns the content of the file of m, with a nexus:
+// header when m has an author, a description, environments or
+// NoTransaction.
+func FormatMigration(m *Migration) string {
+	var fields []string
+	if m.NoTransaction {
+		fields = append(fields, "transaction: false")
+	}
+	if m.Author != "" {
+		fields = append(fields, "author: "+strconv.Quote(m.Author))
+	}
+	if m.Description != "" {
+		fields = append(fields, "description: "+strconv.Quote(m.Description))
+	}
+	if len(m.Environments) > 0 {
+		fields = append(fields, "env: ["+strings.Join(m.Environments, ", ")+"]")
+	}
+	header := ""
+	if len(fields) > 0 {
+		header = "-- nexus: { " + strings.Join(fields, ", ") + " }\n"
+	}
+	return fmt.Sprintf("%s-- UP\n%s\n\n-- DOWN\n%s\n", header, m.UpSQL, m.DownSQL)
+}
+
+// parseHeader reads the nexus: header and NO TRANSACTION markers at the
+// start of lines into m and returns the index of the line after them.
+func (m *Migration) parseHeader(lines []string) (int, error) {
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	for i < len(lines) {
+		line := strings.TrimSpace(lines[i])
+		if noTransactionMarker.MatchString(line) && !upMarker.MatchString(line) {
+			m.NoTransaction = true
+			i++
+			continue
+		}
+		match := headerStart.FindStringSubmatch(line)
+		if match == nil {
+			break
+		}
+		i++
+
+		var fields [][2]string
+		if inline := strings.TrimSpace(match[1]); inline != "" {
+			if !strings.HasPrefix(inline, "{") || !strings.HasSuffix(inline, "}") {
+				return 0, fmt.Errorf("nexus: header must be { key: value, ... } or key: value lines")
+			}
+			for _, field := range splitTopLevel(inline[1 : len(inline)-1]) {
+				if field = strings.TrimSpace(field); field == "" {
+					continue
+				}
+				key, value, ok := strings.Cut(field, ":")
+				if !ok {
+					return 0, fmt.Errorf("nexus: header: %q is not key: value", field)
+				}
+				fields = append(fields, [2]string{strings.TrimSpace(key), strings.TrimSpace(value)})
+			}
+		}
+		for ; i < len(lines); i++ {
+			match := headerLine.FindStringSubmatch(strings.TrimSpace(lines[i]))
+			if match == nil {
+				break
+			}
+			fields = append(fields, [2]string{match[1], strings.TrimSpace(match[2])})
+		}
+		for _, field := range fields {
+			if err := m.setHeaderField(field[0], unquoteHeader(field[1])); err != nil {
+				return 0, fmt.Errorf("nexus: header: %w", err)
+			}
+		}
+	}
+	return i, nil
+}
+
+// setHeaderField sets the field of m that key of the nexus: header holds.
+func (m *Migration) setHeaderField(key, value string) error {
+	switch strings.ToLower(key) {
+	case "transaction":
+		transaction, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("transaction must be true or false, not %q", value)
+		}
+		m.NoTransaction = !transaction
+	case "author":
+		m.Author = value
+	case "description":
+		m.Description = value
+	case "env":
+		m.Environments = nil
+		for _, env := range splitTopLevel(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")) {
+			if env = unquoteHeader(env); env != "" {
+				m.Environments = append(m.Environments, env)
+			}
+		}
+		if len(m.Environments) == 0 {
+			return fmt.Errorf("env must name at least one environment")
+		}
+	default:
+		return fmt.Errorf("unknown key %q (expected transaction, author, description or env)", key)
+	}
+	return nil
+}
+
+// unquoteHeader returns the value of a header field without its quotes.
+func unquoteHeader(value string) string {
+	if len(value) >= 2 {
+		switch {
+		case value[0] == '"' && value[len(value)-1] == '"':
+			if s, err := strconv.Unquote(value); err == nil {
+				return s
+			}
+		case value[0] == '\'' && value[len(value)-1] == '\'':
+			return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+	}
+	return value
+}
@@ -0,0 +1,326 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// SchemaHistoryTable is the table the engine records the schema after each
+// applied migration in.
+const SchemaHistoryTable = "_nexus_schema_history"
+
+// ErrNoSchemaHistory is returned by SchemaAt for migrations whose schema
+// was not recorded, such as those applied before the history was kept.
+var ErrNoSchemaHistory = errors.New("no schema recorded")
+
+// SchemaVersion is a schema recorded in the history.
+type SchemaVersion struct {
+	MigrationID string    `json:"migration_id"`
+	Name        string    `json:"name"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+// WithSchemaHistory sets whether the engine records the schema of the
+// database after each migration it applies, which SchemaAt returns. It is
+// on by default for dialects that support introspection; Init creates the
+// table.
+func (e *Engine) WithSchemaHistory(on bool) *Engine {
+	e.noSchemaHistory = !on
+	return e
+}
+
+// schemaHistory returns the introspector of the dialect if the engine
+// records the schema history.
+func (e *Engine) schemaHistory() (Introspector, bool) {
+	if e.noSchemaHistory {
+		return nil, false
+	}
+	introspector, ok := e.conn.Dialect.(Introspector)
+	return introspector, ok
+}
+
+// initSchemaHistory creates the schema history table if it doesn't exist.
+func (e *Engine) initSchemaHistory(ctx context.Context) error {
+	if _, ok := e.schemaHistory(); !ok {
+		return nil
+	}
+	dialect := e.conn.Dialect
+	var sql string
+	switch dialect.Name() {
+	case "mssql":
+		sql = fmt.Sprintf(`IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (
+		migration_id NVARCHAR(255) NOT NULL PRIMARY KEY,
+		name NVARCHAR(255) NOT NULL,
+		snapshot NVARCHAR(MAX) NOT NULL,
+		recorded_at DATETIME2 NOT NULL DEFAULT SYSDATETIME()
+	)`, SchemaHistoryTable, dialect.Quote(SchemaHistoryTable))
+	default:
+		// TEXT holds 64 KB on MySQL
+		text := "TEXT"
+		if dialect.Name() == "mysql" {
+			text = "LONGTEXT"
+		}
+		sql = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		migration_id VARCHAR(255) NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		snapshot %s NOT NULL,
+		recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`, dialect.Quote(SchemaHistoryTable), text)
+	}
+	_, err := e.conn.Exec(ctx, sql)
+	return err
+}
+
+// recordSchema stores the schema of the database after m was applied. A
+// failure is logged, not returned: the migration itself is applied.
+func (e *Engine) recordSchema(ctx context.Context, m *Migration) {
+	introspector, ok := e.schemaHistory()
+	if !ok {
+		return
+	}
+	err := func() error {
+		snapshot, err := e.introspectSchema(ctx, introspector)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+
+		dialect := e.conn.Dialect
+		table := dialect.Quote(SchemaHistoryTable)
+		// A migration applied again after a rollback replaces its row
+		deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE migration_id = %s", table, dialect.Placeholder(1))
+		if _, err := e.conn.Exec(ctx, deleteSQL, m.ID); err != nil {
+			return err
+		}
+		insertSQL := fmt.Sprintf("INSERT INTO %s (migration_id, name, snapshot) VALUES (%s, %s, %s)",
+			table, dialect.Placeholder(1), dialect.Placeholder(2), dialect.Placeholder(3))
+		_, err = e.conn.Exec(ctx, insertSQL, m.ID, m.Name, string(data))
+		return err
+	}()
+	if err != nil {
+		e.log(ctx).Warn("Could not record schema history", nexuslog.F("migration", m.ID), nexuslog.Err(err))
+	}
+}
+
+// CurrentSchema returns the schema of the database now, in the form of the
+// recorded ones, to compare them with.
+func (e *Engine) CurrentSchema(ctx context.Context) (*SchemaSnapshot, error) {
+	introspector, ok := e.conn.Dialect.(Introspector)
+	if !ok {
+		return nil, fmt.Errorf("dialect %s does not support introspection", e.conn.Dialect.Name())
+	}
+	return e.introspectSchema(ctx, introspector)
+}
+
+// introspectSchema snapshots the tables and functions of the database,
+// leaving out those of Nexus.
+func (e *Engine) introspectSchema(ctx context.Context, introspector Introspector) (*SchemaSnapshot, error) {
+	db, err := IntrospectDatabase(ctx, e.conn.DB, introspector)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &SchemaSnapshot{
+		Version: SnapshotVersion,
+		Dialect: e.conn.Dialect.Name(),
+		Tables:  make(map[string]*TableInfo),
+	}
+	for name, table := range db.Tables {
+		if !isInternalTable(name) {
+			snapshot.Tables[name] = table
+		}
+	}
+	if len(db.Functions) > 0 {
+		snapshot.Functions = db.Functions
+	}
+	return snapshot, nil
+}
+
+// forgetSchema removes the schema recorded for a rolled back migration.
+func (e *Engine) forgetSchema(ctx context.Context, m *Migration) {
+	if _, ok := e.schemaHistory(); !ok {
+		return
+	}
+	dialect := e.conn.Dialect
+	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE migration_id = %s", dialect.Quote(SchemaHistoryTable), dialect.Placeholder(1))
+	if _, err := e.conn.Exec(ctx, deleteSQL, m.ID); err != nil {
+		e.log(ctx).Warn("Could not remove schema history", nexuslog.F("migration", m.ID), nexuslog.Err(err))
+	}
+}
+
+// SchemaHistory returns the recorded schemas, oldest migration first.
+func (e *Engine) SchemaHistory(ctx context.Context) ([]SchemaVersion, error) {
+	query := fmt.Sprintf("SELECT migration_id, name, recorded_at FROM %s ORDER BY migration_id",
+		e.conn.Dialect.Quote(SchemaHistoryTable))
+	rows, err := e.conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []SchemaVersion
+	for rows.Next() {
+		var v SchemaVersion
+		if err := rows.Scan(&v.MigrationID, &v.Name, &v.RecordedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// SchemaVersion returns the schema of the database as SchemaAt does, or
+// as it is now for "current".
+func (e *Engine) SchemaVersion(ctx context.Context, id string) (*SchemaSnapshot, error) {
+	if id == "current" {
+		return e.CurrentSchema(ctx)
+	}
+	return e.SchemaAt(ctx, id)
+}
+
+// SchemaAt returns the schema of the database as it was right after the
+// given migration was applied. id is a migration ID, optionally followed
+// by _name as in its file name.
+func (e *Engine) SchemaAt(ctx context.Context, id string) (*SchemaSnapshot, error) {
+	versions, err := e.SchemaHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var match *SchemaVersion
+	for i, v := range versions {
+		if v.MigrationID == id || v.MigrationID+"_"+v.Name == id {
+			match = &versions[i]
+			break
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w for migration %s", ErrNoSchemaHistory, id)
+	}
+
+	dialect := e.conn.Dialect
+	query := fmt.Sprintf("SELECT snapshot FROM %s WHERE migration_id = %s",
+		dialect.Quote(SchemaHistoryTable), dialect.Placeholder(1))
+	var data string
+	if err := e.conn.QueryRow(ctx, query, match.MigrationID).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w for migration %s", ErrNoSchemaHistory, id)
+		}
+		return nil, err
+	}
+	var snapshot SchemaSnapshot
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		return nil, fmt.Errorf("parsing schema of migration %s: %w", id, err)
+	}
+	if snapshot.Tables == nil {
+		snapshot.Tables = make(map[string]*TableInfo)
+	}
+	return &snapshot, nil
+}
+
+// DiffSnapshots compares two snapshots and returns the changes that turn
+// from into to: tables, columns, indexes and functions added, dropped or
+// changed, and table collations and comments changed. Column defaults are
+// compared by what they mean rather than how they are written. The changes
+// name what changed, for DescribeChanges; unlike those of Diff, they cannot
+// generate a migration.
+func DiffSnapshots(from, to *SchemaSnapshot) []SchemaChange {
+	var changes []SchemaChange
+	for _, name := range sortedKeys(to.Tables) {
+		if _, ok := from.Tables[name]; !ok {
+			changes = append(changes, SchemaChange{Type: ChangeCreateTable, TableName: name})
+		}
+	}
+	for _, name := range sortedKeys(from.Tables) {
+		old := from.Tables[name]
+		table, ok := to.Tables[name]
+		if !ok {
+			changes = append(changes, SchemaChange{Type: ChangeDropTable, TableName: name})
+			continue
+		}
+		if !strings.EqualFold(old.Charset, table.Charset) || !strings.EqualFold(old.Collation, table.Collation) {
+			changes = append(changes, SchemaChange{
+				Type:         ChangeSetTableCollation,
+				TableName:    name,
+				Charset:      table.Charset,
+				Collation:    table.Collation,
+				OldCharset:   old.Charset,
+				OldCollation: old.Collation,
+			})
+		}
+		if old.Comment != table.Comment {
+			changes = append(changes, SchemaChange{Type: ChangeSetTableComment, TableName: name, Comment: table.Comment, OldComment: old.Comment})
+		}
+		for _, col := range sortedKeys(table.Columns) {
+			before, ok := old.Columns[col]
+			now := table.Columns[col]
+			switch {
+			case !ok:
+				changes = append(changes, SchemaChange{Type: ChangeAddColumn, TableName: name, ColumnName: col})
+			case !sameColumn(before, now):
+				changes = append(changes, SchemaChange{Type: ChangeModifyColumn, TableName: name, ColumnName: col})
+			case before.Comment != now.Comment:
+				changes = append(changes, SchemaChange{Type: ChangeSetComment, TableName: name, ColumnName: col, Comment: now.Comment, OldComment: before.Comment})
+			}
+		}
+		for _, col := range sortedKeys(old.Columns) {
+			if _, ok := table.Columns[col]; !ok {
+				changes = append(changes, SchemaChange{Type: ChangeDropColumn, TableName: name, ColumnName: col})
+			}
+		}
+		for _, idx := range sortedKeys(old.Indexes) {
+			if now, ok := table.Indexes[idx]; !ok || !sameIndex(old.Indexes[idx], now) {
+				changes = append(changes, SchemaChange{Type: ChangeDropIndex, TableName: name, IndexName: idx})
+			}
+		}
+		for _, idx := range sortedKeys(table.Indexes) {
+			if before, ok := old.Indexes[idx]; !ok || !sameIndex(before, table.Indexes[idx]) {
+				changes = append(changes, SchemaChange{Type: ChangeAddIndex, TableName: name, IndexName: idx})
+			}
+		}
+	}
+	for _, name := range sortedKeys(to.Functions) {
+		f := to.Functions[name]
+		before, ok := from.Functions[name]
+		switch {
+		case !ok:
+			changes = append(changes, SchemaChange{Type: ChangeCreateFunction, Function: f.schemaFunction()})
+		case before.Body != f.Body || before.Args != f.Args || before.Returns != f.Returns:
+			changes = append(changes, SchemaChange{Type: ChangeReplaceFunction, Function: f.schemaFunction(), OldFunction: before})
+		}
+	}
+	for _, name := range sortedKeys(from.Functions) {
+		if _, ok := to.Functions[name]; !ok {
+			changes = append(changes, SchemaChange{Type: ChangeDropFunction, OldFunction: from.Functions[name]})
+		}
+	}
+	return changes
+}
+
+func sameColumn(a, b *ColumnInfo) bool {
+	return strings.EqualFold(a.Type, b.Type) && a.Nullable == b.Nullable && sameDefaultSQL(a.Default, b.Default) &&
+		a.IsPrimaryKey == b.IsPrimaryKey && a.IsUnique == b.IsUnique && a.Enum == b.Enum &&
+		strings.EqualFold(a.Charset, b.Charset) && strings.EqualFold(a.Collation, b.Collation)
+}
+
+func sameIndex(a, b *IndexInfo) bool {
+	return a.Unique == b.Unique && strings.Join(a.Columns, ",") == strings.Join(b.Columns, ",")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,138 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// HookStage is when a hook runs.
+type HookStage string
+
+const (
+	BeforeMigrate HookStage = "before_migrate"
+	AfterMigrate  HookStage = "after_migrate"
+)
+
+// HookEvent describes the migrations a hook runs around.
+type HookEvent struct {
+	Stage      HookStage
+	Direction  string       // "up" or "down"
+	Migrations []*Migration // Migrations about to run, or that ran
+}
+
+// Hook runs before or after migrations. A failing before_migrate hook stops
+// the migrations from running.
+type Hook func(ctx context.Context, event HookEvent) error
+
+// Hooks are the hooks of an engine.
+type Hooks struct {
+	BeforeMigrate []Hook
+	AfterMigrate  []Hook
+}
+
+var (
+	hooksMu    sync.RWMutex
+	registered = make(map[string]Hook)
+)
+
+// RegisterHook registers a Go function under name, so configs can refer
+// to it as "go:name". Programs that embed the CLI call it from init.
+func RegisterHook(name string, hook Hook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	registered[name] = hook
+}
+
+// RegisteredHook returns the hook registered under name.
+func RegisteredHook(name string) (Hook, bool) {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	hook, ok := registered[name]
+	return hook, ok
+}
+
+// RegisteredHooks returns the names of the registered hooks, sorted.
+func RegisteredHooks() []string {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseHook returns the hook of a config entry: "go:name" for a
+// registered function, anything else for a shell command.
+func ParseHook(entry string, env []string) (Hook, error) {
+	if name, ok := strings.CutPrefix(entry, "go:"); ok {
+		hook, ok := RegisteredHook(name)
+		if !ok {
+			return nil, fmt.Errorf("no hook registered as %q", name)
+		}
+		return hook, nil
+	}
+	return ShellHook(entry, env), nil
+}
+
+// ShellHook returns a hook that runs command with the shell, with env and
+// NEXUS_HOOK, NEXUS_DIRECTION and NEXUS_MIGRATIONS (comma-separated IDs)
+// added to the environment. Its output goes to stdout and stderr.
+func ShellHook(command string, env []string) Hook {
+	return func(ctx context.Context, event HookEvent) error {
+		ids := make([]string, len(event.Migrations))
+		for i, m := range event.Migrations {
+			ids[i] = m.ID + "_" + m.Name
+		}
+
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", command)
+		}
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Env = append(cmd.Env,
+			"NEXUS_HOOK="+string(event.Stage),
+			"NEXUS_DIRECTION="+event.Direction,
+			"NEXUS_MIGRATIONS="+strings.Join(ids, ","),
+		)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%q: %w", command, err)
+		}
+		return nil
+	}
+}
+
+// WithHooks sets the hooks run around Up, Down, DownTo and DownN.
+func (e *Engine) WithHooks(hooks Hooks) *Engine {
+	e.hooks = hooks
+	return e
+}
+
+// runHooks runs the hooks of stage when there are migrations to run.
+func (e *Engine) runHooks(ctx context.Context, stage HookStage, direction string, migrations []*Migration) error {
+	if len(migrations) == 0 {
+		return nil
+	}
+	hooks := e.hooks.BeforeMigrate
+	if stage == AfterMigrate {
+		hooks = e.hooks.AfterMigrate
+	}
+	event := HookEvent{Stage: stage, Direction: direction, Migrations: migrations}
+	for _, hook := range hooks {
+		if err := hook(ctx, event); err != nil {
+			return fmt.Errorf("%s hook: %w", stage, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// ImportFormat is a migration tool whose migrations can be imported.
+type ImportFormat string
+
+const (
+	FormatGoose         ImportFormat = "goose"          // NNNNN_name.sql with -- +goose Up/Down
+	FormatGolangMigrate ImportFormat = "golang-migrate" // NNNNN_name.up.sql and NNNNN_name.down.sql
+	FormatPrisma        ImportFormat = "prisma"         // YYYYMMDDHHMMSS_name/migration.sql
+)
+
+// ParseImportFormat returns the ImportFormat named name.
+func ParseImportFormat(name string) (ImportFormat, error) {
+	switch f := ImportFormat(strings.ToLower(name)); f {
+	case FormatGoose, FormatGolangMigrate, FormatPrisma:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown migration format %q (expected goose, golang-migrate or prisma)", name)
+}
+
+// ImportedMigration is a migration converted from another tool.
+type ImportedMigration struct {
+	*Migration
+	Source  string // File or directory it was read from, relative to the import directory
+	Version string // What the history of the other tool records it as
+}
+
+// ImportResult is what ImportMigrations read.
+type ImportResult struct {
+	Migrations []*ImportedMigration // Oldest first
+	Skipped    []string             // Files that can't be imported, with the reason
+}
+
+var (
+	// goose and golang-migrate files: a version, then the name
+	versionedFile = regexp.MustCompile(`^(\d+)_(.+?)(\.up|\.down)?\.sql$`)
+	// Prisma migration directories: a timestamp, then the name
+	prismaDir = regexp.MustCompile(`^(\d{14})_(.+)$`)
+
+	// gooseDirective matches goose annotations Nexus doesn't need, as the
+	// statement splitter finds where statements end by itself
+	gooseDirective = regexp.MustCompile(`(?im)^--\s*\+goose\s+(?:StatementBegin|StatementEnd|ENVSUB\s+(?:ON|OFF))\s*\r?\n?`)
+
+	unsafeName = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// ImportMigrations reads the migrations format keeps in dir and converts
+// them to Nexus migrations, recording their origin in the description of
+// their header. Versions that are timestamps become the same IDs; other
+// versions, such as sequence numbers, become IDs on 1 January 1970 that
+// keep their order and sort before migrations created by Nexus.
+func ImportMigrations(dir string, format ImportFormat) (*ImportResult, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	result := &ImportResult{}
+	switch format {
+	case FormatGoose:
+		err = importGoose(dir, entries, result)
+	case FormatGolangMigrate:
+		err = importGolangMigrate(dir, entries, result)
+	case FormatPrisma:
+		err = importPrisma(dir, entries, result)
+	default:
+		_, err = ParseImportFormat(string(format))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(result.Skipped)
+	sort.Slice(result.Migrations, func(i, j int) bool {
+		return result.Migrations[i].ID < result.Migrations[j].ID
+	})
+	for i := 1; i < len(result.Migrations); i++ {
+		if prev, m := result.Migrations[i-1], result.Migrations[i]; prev.ID == m.ID {
+			return nil, fmt.Errorf("%s and %s both become migration %s", prev.Source, m.Source, m.ID)
+		}
+	}
+	return result, nil
+}
+
+func importGoose(dir string, entries []os.DirEntry, result *ImportResult) error {
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") && versionedFile.MatchString(strings.TrimSuffix(name, ".go")+".sql") {
+			result.Skipped = append(result.Skipped, name+": Go migrations can't be converted")
+			continue
+		}
+		match := versionedFile.FindStringSubmatch(name)
+		if match == nil || match[3] != "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		parsed, err := ParseMigrationFile("0_0_x.sql", gooseDirective.ReplaceAllString(string(content), ""))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := result.add(FormatGoose, name, match[1], match[2], parsed.UpSQL, parsed.DownSQL, parsed.NoTransaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importGolangMigrate(dir string, entries []os.DirEntry, result *ImportResult) error {
+	type pair struct{ name, up, down, upFile string }
+	pairs := make(map[string]*pair)
+	for _, entry := range entries {
+		match := versionedFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil || match[3] == "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		p := pairs[match[1]]
+		if p == nil {
+			p = &pair{name: match[2]}
+			pairs[match[1]] = p
+		}
+		if match[3] == ".up" {
+			p.up, p.upFile = strings.TrimSpace(string(content)), entry.Name()
+		} else {
+			p.down = strings.TrimSpace(string(content))
+		}
+	}
+	for version, p := range pairs {
+		if p.upFile == "" {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s_%s.down.sql: no up migration", version, p.name))
+			continue
+		}
+		if err := result.add(FormatGolangMigrate, p.upFile, version, p.name, p.up, p.down, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importPrisma(dir string, entries []os.DirEntry, result *ImportResult) error {
+	for _, entry := range entries {
+		match := prismaDir.FindStringSubmatch(entry.Name())
+		if !entry.IsDir() || match == nil {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name(), "migration.sql"))
+		if os.IsNotExist(err) {
+			result.Skipped = append(result.Skipped, entry.Name()+": no migration.sql")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		// Prisma migrations have no down SQL and are known by their directory
+		m := strings.TrimSpace(string(content))
+		if err := result.add(FormatPrisma, filepath.Join(entry.Name(), "migration.sql"), match[1], match[2], m, "", false); err != nil {
+			return err
+		}
+		result.Migrations[len(result.Migrations)-1].Version = entry.Name()
+	}
+	return nil
+}
+
+// add converts a migration of format and adds it to the result.
+func (r *ImportResult) add(format ImportFormat, source, version, name, up, down string, noTransaction bool) error {
+	id, err := importID(version)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	m := &Migration{
+		ID:            id,
+		Name:          strings.Trim(unsafeName.ReplaceAllString(strings.ToLower(name), "_"), "_"),
+		UpSQL:         up,
+		DownSQL:       down,
+		Description:   fmt.Sprintf("Imported from %s %s", format, source),
+		NoTransaction: noTransaction,
+	}
+	// Parse the file as written, so that the checksum is that of the file
+	filename := fmt.Sprintf("%s_%s.sql", m.ID, m.Name)
+	if m, err = ParseMigrationFile(filename, FormatMigration(m)); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	n, _ := strconv.ParseInt(version, 10, 64)
+	r.Migrations = append(r.Migrations, &ImportedMigration{Migration: m, Source: source, Version: strconv.FormatInt(n, 10)})
+	return nil
+}
+
+// importEpoch is the start of the IDs of versions that aren't timestamps.
+var importEpoch = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// importID returns the ID of the migration another tool knows as version:
+// the same time for timestamps (YYYYMMDDHHMMSS or Unix seconds), and
+// version seconds after importEpoch for sequence numbers.
+func importID(version string) (string, error) {
+	n, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q", version)
+	}
+	switch {
+	case len(version) == 14:
+		t, err := time.Parse("20060102150405", version)
+		if err != nil {
+			return "", fmt.Errorf("invalid timestamp version %q", version)
+		}
+		return t.Format("20060102_150405"), nil
+	case len(version) == 10 && n >= 1e9:
+		return time.Unix(n, 0).UTC().Format("20060102_150405"), nil
+	}
+	return importEpoch.Add(time.Duration(n) * time.Second).Format("20060102_150405"), nil
+}
+
+// AppliedImports returns the migrations of imported that the history table
+// of format in the database records as applied: goose_db_version for
+// goose, schema_migrations for golang-migrate and _prisma_migrations for
+// Prisma.
+func AppliedImports(ctx context.Context, conn *dialects.Connection, format ImportFormat, imported []*ImportedMigration) ([]*ImportedMigration, error) {
+	applied := make(map[string]bool)
+	switch format {
+	case FormatGoose:
+		rows, err := conn.Query(ctx, "SELECT version_id, is_applied FROM goose_db_version ORDER BY id")
+		if err != nil {
+			return nil, fmt.Errorf("reading goose_db_version: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var version int64
+			var isApplied bool
+			if err := rows.Scan(&version, &isApplied); err != nil {
+				return nil, err
+			}
+			applied[strconv.FormatInt(version, 10)] = isApplied
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	case FormatGolangMigrate:
+		var version int64
+		var dirty bool
+		err := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty)
+		if err != nil {
+			return nil, fmt.Errorf("reading schema_migrations: %w", err)
+		}
+		if dirty {
+			return nil, fmt.Errorf("schema_migrations is dirty at version %d; fix it with golang-migrate first", version)
+		}
+		// golang-migrate records only the latest version
+		for _, m := range imported {
+			if n, _ := strconv.ParseInt(m.Version, 10, 64); n <= version {
+				applied[m.Version] = true
+			}
+		}
+	case FormatPrisma:
+		rows, err := conn.Query(ctx, "SELECT migration_name FROM _prisma_migrations WHERE finished_at IS NOT NULL AND rolled_back_at IS NULL")
+		if err != nil {
+			return nil, fmt.Errorf("reading _prisma_migrations: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return nil, err
+			}
+			applied[name] = true
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		_, err := ParseImportFormat(string(format))
+		return nil, err
+	}
+
+	var result []*ImportedMigration
+	for _, m := range imported {
+		if applied[m.Version] {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// Record adds migrations to the history as applied without running them,
+// such as migrations another tool applied. Migrations already recorded are
+// left alone; it returns how many it recorded.
+func (e *Engine) Record(ctx context.Context, migrations []*Migration) (int, error) {
+	history, err := e.getApplied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	recorded := make(map[string]bool)
+	for _, h := range history {
+		recorded[h.MigrationID] = true
+	}
+
+	dialect := e.conn.Dialect
+	insertSQL := fmt.Sprintf(
+		"INSERT INTO %s (migration_id, name, checksum) VALUES (%s, %s, %s)",
+		dialect.Quote(e.tableName),
+		dialect.Placeholder(1),
+		dialect.Placeholder(2),
+		dialect.Placeholder(3),
+	)
+	count := 0
+	for _, m := range migrations {
+		if recorded[m.ID] {
+			continue
+		}
+		if _, err := e.conn.Exec(ctx, insertSQL, m.ID, m.Name, m.Checksum); err != nil {
+			return count, fmt.Errorf("recording %s: %w", m.ID, err)
+		}
+		count++
+	}
+	return count, nil
+}
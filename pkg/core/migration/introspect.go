@@ -4,42 +4,126 @@ package migration
 import (
 	"context"
 	"database/sql"
+	"sort"
 )
 
 // ColumnInfo represents metadata about a database column.
 type ColumnInfo struct {
-	Name         string
-	Type         string // The SQL type as returned by the database
-	Nullable     bool
-	IsPrimaryKey bool
-	IsUnique     bool
-	Default      string
-	AutoInc      bool
+	Name         string `json:"name"`
+	Type         string `json:"type"` // The SQL type as returned by the database
+	Nullable     bool   `json:"nullable,omitempty"`
+	IsPrimaryKey bool   `json:"primary_key,omitempty"`
+	IsUnique     bool   `json:"unique,omitempty"`
+	Default      string `json:"default,omitempty"`
+	AutoInc      bool   `json:"auto_increment,omitempty"`
+	Position     int    `json:"position"` // Ordinal position within the table, starting at 0
+	Comment      string `json:"comment,omitempty"`
+	Enum         string `json:"enum,omitempty"`     // Name of the enum type, if the column uses one
+	Unsigned     bool   `json:"unsigned,omitempty"` // Integer type without negative values
+
+	// Character set and collation, where they differ from the defaults of
+	// the table (MySQL) or the database
+	Charset   string `json:"charset,omitempty"`
+	Collation string `json:"collation,omitempty"`
 }
 
 // IndexInfo represents metadata about a database index.
 type IndexInfo struct {
-	Name    string
-	Unique  bool
-	Columns []string
+	Name    string   `json:"name"`
+	Unique  bool     `json:"unique,omitempty"`
+	Columns []string `json:"columns"`
+}
+
+// ForeignKeyInfo represents a foreign key constraint.
+type ForeignKeyInfo struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+	OnDelete   string   `json:"on_delete,omitempty"` // CASCADE, SET NULL, RESTRICT, NO ACTION, SET DEFAULT
+	OnUpdate   string   `json:"on_update,omitempty"`
+}
+
+// CheckInfo represents a CHECK constraint.
+type CheckInfo struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"` // The condition, without the CHECK keyword
+}
+
+// EnumInfo represents a native enum type.
+type EnumInfo struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// PolicyInfo represents a row-level security policy.
+type PolicyInfo struct {
+	Name    string   `json:"name"`
+	Command string   `json:"command"`         // ALL, SELECT, INSERT, UPDATE or DELETE
+	Roles   []string `json:"roles,omitempty"` // Empty means PUBLIC
+	Using   string   `json:"using,omitempty"`
+	Check   string   `json:"check,omitempty"`
+}
+
+// RowSecurityInfo represents the row-level security of a table.
+type RowSecurityInfo struct {
+	Enabled  bool                   `json:"enabled"`
+	Forced   bool                   `json:"forced,omitempty"`
+	Policies map[string]*PolicyInfo `json:"policies,omitempty"`
 }
 
 // TableInfo represents metadata about a database table.
 type TableInfo struct {
-	Name    string
-	Columns map[string]*ColumnInfo
-	Indexes map[string]*IndexInfo
+	Name        string                     `json:"name"`
+	Columns     map[string]*ColumnInfo     `json:"columns"`
+	Indexes     map[string]*IndexInfo      `json:"indexes"`
+	ForeignKeys map[string]*ForeignKeyInfo `json:"foreign_keys,omitempty"`
+	Checks      map[string]*CheckInfo      `json:"checks,omitempty"`
+	RowSecurity *RowSecurityInfo           `json:"row_security,omitempty"` // Nil when off or unsupported
+	Charset     string                     `json:"charset,omitempty"`      // Default character set (MySQL)
+	Collation   string                     `json:"collation,omitempty"`    // Default collation (MySQL)
+	Comment     string                     `json:"comment,omitempty"`
+}
+
+// OrderedColumns returns the table's columns in their ordinal position.
+func (t *TableInfo) OrderedColumns() []*ColumnInfo {
+	cols := make([]*ColumnInfo, 0, len(t.Columns))
+	for _, col := range t.Columns {
+		cols = append(cols, col)
+	}
+	sort.Slice(cols, func(i, j int) bool {
+		if cols[i].Position != cols[j].Position {
+			return cols[i].Position < cols[j].Position
+		}
+		return cols[i].Name < cols[j].Name
+	})
+	return cols
+}
+
+// FunctionInfo represents a stored function or procedure.
+type FunctionInfo struct {
+	Name      string `json:"name"`
+	Args      string `json:"args"`              // Arguments as SQL, e.g. "title text"
+	Returns   string `json:"returns,omitempty"` // Empty for procedures
+	Body      string `json:"body"`
+	Language  string `json:"language"`
+	Procedure bool   `json:"procedure,omitempty"`
+	Immutable bool   `json:"immutable,omitempty"`
 }
 
 // DatabaseSnapshot represents the current state of the database.
 type DatabaseSnapshot struct {
-	Tables map[string]*TableInfo
+	Tables    map[string]*TableInfo
+	Enums     map[string]*EnumInfo
+	Functions map[string]*FunctionInfo
 }
 
 // NewDatabaseSnapshot creates an empty snapshot.
 func NewDatabaseSnapshot() *DatabaseSnapshot {
 	return &DatabaseSnapshot{
-		Tables: make(map[string]*TableInfo),
+		Tables:    make(map[string]*TableInfo),
+		Enums:     make(map[string]*EnumInfo),
+		Functions: make(map[string]*FunctionInfo),
 	}
 }
 
@@ -54,12 +138,117 @@ type Introspector interface {
 
 	// IntrospectIndexes returns index metadata for a table.
 	IntrospectIndexes(ctx context.Context, db *sql.DB, tableName string) ([]*IndexInfo, error)
+
+	// IntrospectForeignKeys returns foreign key constraints for a table,
+	// including their ON DELETE / ON UPDATE rules.
+	IntrospectForeignKeys(ctx context.Context, db *sql.DB, tableName string) ([]*ForeignKeyInfo, error)
+
+	// IntrospectCheckConstraints returns CHECK constraints for a table.
+	IntrospectCheckConstraints(ctx context.Context, db *sql.DB, tableName string) ([]*CheckInfo, error)
+
+	// IntrospectEnums returns native enum types. Dialects without enum types
+	// return nil.
+	IntrospectEnums(ctx context.Context, db *sql.DB) ([]*EnumInfo, error)
+}
+
+// RowSecurityIntrospector is implemented by introspectors of databases with
+// row-level security.
+type RowSecurityIntrospector interface {
+	// IntrospectRowSecurity returns the row-level security of a table, or
+	// nil if it is off and the table has no policies.
+	IntrospectRowSecurity(ctx context.Context, db *sql.DB, tableName string) (*RowSecurityInfo, error)
+}
+
+// CollationIntrospector is implemented by introspectors of databases whose
+// tables have a default character set and collation.
+type CollationIntrospector interface {
+	// IntrospectTableCollation returns the default character set and
+	// collation of a table.
+	IntrospectTableCollation(ctx context.Context, db *sql.DB, tableName string) (charset, collation string, err error)
+}
+
+// CommentIntrospector is implemented by introspectors of databases that
+// store comments on tables.
+type CommentIntrospector interface {
+	// IntrospectTableComment returns the comment of a table, empty if it
+	// has none.
+	IntrospectTableComment(ctx context.Context, db *sql.DB, tableName string) (string, error)
+}
+
+// FunctionIntrospector is implemented by introspectors of databases with
+// stored functions.
+type FunctionIntrospector interface {
+	// IntrospectFunctions returns the user-defined functions and procedures,
+	// leaving out those of extensions and trigger functions.
+	IntrospectFunctions(ctx context.Context, db *sql.DB) ([]*FunctionInfo, error)
+}
+
+// TableStats is the size of a table. Row counts are the estimates of the
+// database's statistics, and zero where it keeps none (SQLite).
+type TableStats struct {
+	Name        string `json:"name"`
+	Rows        int64  `json:"rows"`
+	Bytes       int64  `json:"bytes"`                 // Data and indexes
+	Reclaimable int64  `json:"reclaimable,omitempty"` // Space held by dead rows or free pages
+}
+
+// DatabaseStats is the size of a database and its tables.
+type DatabaseStats struct {
+	Bytes       int64         `json:"bytes"`
+	Reclaimable int64         `json:"reclaimable,omitempty"`
+	Tables      []*TableStats `json:"tables,omitempty"` // Largest first
+}
+
+// StatsIntrospector is implemented by introspectors that can read the
+// sizes of the database and its tables.
+type StatsIntrospector interface {
+	// IntrospectStats returns the size of the database and of the tables
+	// the database keeps statistics for.
+	IntrospectStats(ctx context.Context, db *sql.DB) (*DatabaseStats, error)
+}
+
+// ScanTableStats reads rows of table name, rows, bytes and reclaimable
+// bytes, largest table first, for implementations of StatsIntrospector.
+func ScanTableStats(rows *sql.Rows) ([]*TableStats, error) {
+	defer rows.Close()
+	var tables []*TableStats
+	for rows.Next() {
+		var t TableStats
+		var reclaimable sql.NullInt64
+		if err := rows.Scan(&t.Name, &t.Rows, &t.Bytes, &reclaimable); err != nil {
+			return nil, err
+		}
+		t.Reclaimable = max(reclaimable.Int64, 0)
+		tables = append(tables, &t)
+	}
+	sort.SliceStable(tables, func(i, j int) bool { return tables[i].Bytes > tables[j].Bytes })
+	return tables, rows.Err()
 }
 
 // IntrospectDatabase reads the current database schema using the provided introspector.
 func IntrospectDatabase(ctx context.Context, db *sql.DB, introspector Introspector) (*DatabaseSnapshot, error) {
 	snapshot := NewDatabaseSnapshot()
 
+	// Get enum types
+	enums, err := introspector.IntrospectEnums(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for _, enum := range enums {
+		snapshot.Enums[enum.Name] = enum
+	}
+
+	// Get functions
+	if fi, ok := introspector.(FunctionIntrospector); ok {
+		functions, err := fi.IntrospectFunctions(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range functions {
+			snapshot.Functions[f.Name] = f
+		}
+	}
+
 	// Get all tables
 	tableNames, err := introspector.IntrospectTables(ctx, db)
 	if err != nil {
@@ -68,9 +257,11 @@ func IntrospectDatabase(ctx context.Context, db *sql.DB, introspector Introspect
 
 	for _, tableName := range tableNames {
 		tableInfo := &TableInfo{
-			Name:    tableName,
-			Columns: make(map[string]*ColumnInfo),
-			Indexes: make(map[string]*IndexInfo),
+			Name:        tableName,
+			Columns:     make(map[string]*ColumnInfo),
+			Indexes:     make(map[string]*IndexInfo),
+			ForeignKeys: make(map[string]*ForeignKeyInfo),
+			Checks:      make(map[string]*CheckInfo),
 		}
 
 		// Get columns
@@ -78,7 +269,8 @@ func IntrospectDatabase(ctx context.Context, db *sql.DB, introspector Introspect
 		if err != nil {
 			return nil, err
 		}
-		for _, col := range columns {
+		for i, col := range columns {
+			col.Position = i
 			tableInfo.Columns[col.Name] = col
 		}
 
@@ -91,6 +283,48 @@ func IntrospectDatabase(ctx context.Context, db *sql.DB, introspector Introspect
 			tableInfo.Indexes[idx.Name] = idx
 		}
 
+		// Get foreign keys
+		foreignKeys, err := introspector.IntrospectForeignKeys(ctx, db, tableName)
+		if err != nil {
+			return nil, err
+		}
+		for _, fk := range foreignKeys {
+			tableInfo.ForeignKeys[fk.Name] = fk
+		}
+
+		// Get check constraints
+		checks, err := introspector.IntrospectCheckConstraints(ctx, db, tableName)
+		if err != nil {
+			return nil, err
+		}
+		for _, check := range checks {
+			tableInfo.Checks[check.Name] = check
+		}
+
+		// Get row-level security
+		if rls, ok := introspector.(RowSecurityIntrospector); ok {
+			tableInfo.RowSecurity, err = rls.IntrospectRowSecurity(ctx, db, tableName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Get the default character set and collation
+		if ci, ok := introspector.(CollationIntrospector); ok {
+			tableInfo.Charset, tableInfo.Collation, err = ci.IntrospectTableCollation(ctx, db, tableName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Get the comment
+		if ci, ok := introspector.(CommentIntrospector); ok {
+			tableInfo.Comment, err = ci.IntrospectTableComment(ctx, db, tableName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		snapshot.Tables[tableName] = tableInfo
 	}
 
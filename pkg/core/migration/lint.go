@@ -0,0 +1,235 @@
+package migration
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Zero-downtime lint rules, reported in ValidationIssue.Rule.
+const (
+	RuleNotNullColumn = "not-null-column" // ADD COLUMN ... NOT NULL without a default
+	RuleSetNotNull    = "set-not-null"    // SET NOT NULL on an existing column
+	RuleTypeChange    = "type-change"     // Changing the type of an existing column
+	RuleBlockingIndex = "blocking-index"  // CREATE INDEX without CONCURRENTLY on PostgreSQL
+	RuleRename        = "rename"          // Renaming a table or column in place
+)
+
+var (
+	lintIdent       = `("[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[\w.]+)`
+	lintCreateTable = regexp.MustCompile(`(?is)^CREATE\s+(?:TEMP(?:ORARY)?\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + lintIdent)
+	lintAlterTable  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?` + lintIdent + `\s+(.*)$`)
+	lintRenameTable = regexp.MustCompile(`(?is)^RENAME\s+TABLE\s+` + lintIdent)
+	lintCreateIndex = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?.*?\bON\s+(?:ONLY\s+)?` + lintIdent)
+
+	lintAddColumn  = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` + lintIdent + `\s+(.*)$`)
+	lintAlterType  = regexp.MustCompile(`(?is)^ALTER\s+(?:COLUMN\s+)?` + lintIdent + `\s+(?:SET\s+DATA\s+)?TYPE\b`)
+	lintAlterTo    = regexp.MustCompile(`(?is)^ALTER\s+(?:COLUMN\s+)?` + lintIdent + `\s+TO\s+` + lintIdent)
+	lintSetNotNull = regexp.MustCompile(`(?is)^ALTER\s+(?:COLUMN\s+)?` + lintIdent + `\s+SET\s+NOT\s+NULL\b`)
+	lintModify     = regexp.MustCompile(`(?is)^MODIFY\s+(?:COLUMN\s+)?` + lintIdent)
+	lintChange     = regexp.MustCompile(`(?is)^CHANGE\s+(?:COLUMN\s+)?` + lintIdent + `\s+` + lintIdent)
+	lintRenameCol  = regexp.MustCompile(`(?is)^RENAME\s+(?:COLUMN\s+)?` + lintIdent + `\s+TO\s+` + lintIdent)
+	lintRenameTo   = regexp.MustCompile(`(?is)^RENAME\s+(?:TO|AS)\s+` + lintIdent)
+
+	lintNotNull    = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	lintHasDefault = regexp.MustCompile(`(?i)\b(DEFAULT|GENERATED|AUTO_?INCREMENT|IDENTITY)\b`)
+	lintConstraint = regexp.MustCompile(`(?i)^(CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK|INDEX|KEY|FULLTEXT|SPATIAL)\b`)
+)
+
+// lintZeroDowntime reports statements of an UP section that lock tables
+// for long or break app instances still running the previous release.
+// Tables created in the same migration are empty and unused, so they are
+// not reported.
+func lintZeroDowntime(sql, dialect string) []ValidationIssue {
+	dialect = lintDialect(dialect)
+	statements := SplitStatements(sql)
+
+	created := make(map[string]bool)
+	for _, stmt := range statements {
+		if m := lintCreateTable.FindStringSubmatch(stmt); m != nil {
+			created[lintName(m[1])] = true
+		}
+	}
+
+	var issues []ValidationIssue
+	for _, stmt := range statements {
+		switch {
+		case lintAlterTable.MatchString(stmt):
+			m := lintAlterTable.FindStringSubmatch(stmt)
+			table := lintName(m[1])
+			if created[table] {
+				continue
+			}
+			for _, action := range splitTopLevel(m[2]) {
+				issues = append(issues, lintAlterAction(table, action, dialect)...)
+			}
+		case lintRenameTable.MatchString(stmt):
+			m := lintRenameTable.FindStringSubmatch(stmt)
+			if !created[lintName(m[1])] {
+				issues = append(issues, renameIssue("table "+lintName(m[1]), dialect))
+			}
+		case lintCreateIndex.MatchString(stmt):
+			m := lintCreateIndex.FindStringSubmatch(stmt)
+			if dialect == "postgres" && m[1] == "" && !created[lintName(m[2])] {
+				issues = append(issues, ValidationIssue{
+					Severity:   SeverityWarning,
+					Rule:       RuleBlockingIndex,
+					Message:    "CREATE INDEX on " + lintName(m[2]) + " blocks writes to the table while the index builds",
+					Suggestion: "Use CREATE INDEX CONCURRENTLY, alone in its own migration since it cannot run in a transaction.",
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// lintAlterAction checks one action of an ALTER TABLE statement.
+func lintAlterAction(table, action, dialect string) []ValidationIssue {
+	var issues []ValidationIssue
+	switch {
+	case lintAddColumn.MatchString(action) && !lintConstraint.MatchString(strings.TrimSpace(action[3:])):
+		m := lintAddColumn.FindStringSubmatch(action)
+		if !lintNotNull.MatchString(m[2]) || lintHasDefault.MatchString(m[2]) {
+			break
+		}
+		issue := ValidationIssue{
+			Severity: SeverityWarning,
+			Rule:     RuleNotNullColumn,
+			Message:  "Adding NOT NULL column " + table + "." + lintName(m[1]) + " without a default",
+		}
+		switch dialect {
+		case "sqlite", "libsql":
+			issue.Severity = SeverityError
+			issue.Message += " always fails on SQLite"
+			issue.Suggestion = "Add a DEFAULT, or add the column as nullable and rebuild the table later."
+		case "mysql":
+			issue.Suggestion = "MySQL fills existing rows with an implicit default (0 or ''); add an explicit DEFAULT, or add the column as nullable and backfill it."
+		default:
+			issue.Message += " fails once the table has rows"
+			issue.Suggestion = "Add the column as nullable, backfill it in batches, then set NOT NULL; or add a constant DEFAULT, which PostgreSQL 11+ applies without a rewrite."
+		}
+		issues = append(issues, issue)
+
+	case lintSetNotNull.MatchString(action):
+		m := lintSetNotNull.FindStringSubmatch(action)
+		issues = append(issues, ValidationIssue{
+			Severity:   SeverityWarning,
+			Rule:       RuleSetNotNull,
+			Message:    "SET NOT NULL on " + table + "." + lintName(m[1]) + " scans the whole table under an exclusive lock",
+			Suggestion: "Add CHECK (" + lintName(m[1]) + " IS NOT NULL) NOT VALID, VALIDATE CONSTRAINT in a later migration, then SET NOT NULL, which PostgreSQL 12+ does without a scan.",
+		})
+
+	case lintAlterType.MatchString(action):
+		m := lintAlterType.FindStringSubmatch(action)
+		issues = append(issues, typeChangeIssue(table+"."+lintName(m[1]), dialect))
+
+	case lintAlterTo.MatchString(action):
+		// libSQL's ALTER COLUMN old TO new <definition>
+		m := lintAlterTo.FindStringSubmatch(action)
+		issues = append(issues, typeChangeIssue(table+"."+lintName(m[1]), dialect))
+		if lintName(m[1]) != lintName(m[2]) {
+			issues = append(issues, renameIssue("column "+table+"."+lintName(m[1]), dialect))
+		}
+
+	case lintModify.MatchString(action):
+		m := lintModify.FindStringSubmatch(action)
+		issues = append(issues, typeChangeIssue(table+"."+lintName(m[1]), dialect))
+
+	case lintChange.MatchString(action):
+		m := lintChange.FindStringSubmatch(action)
+		issues = append(issues, typeChangeIssue(table+"."+lintName(m[1]), dialect))
+		if lintName(m[1]) != lintName(m[2]) {
+			issues = append(issues, renameIssue("column "+table+"."+lintName(m[1]), dialect))
+		}
+
+	case lintRenameCol.MatchString(action):
+		m := lintRenameCol.FindStringSubmatch(action)
+		issues = append(issues, renameIssue("column "+table+"."+lintName(m[1]), dialect))
+
+	case lintRenameTo.MatchString(action):
+		issues = append(issues, renameIssue("table "+table, dialect))
+	}
+	return issues
+}
+
+func typeChangeIssue(column, dialect string) ValidationIssue {
+	issue := ValidationIssue{
+		Severity: SeverityWarning,
+		Rule:     RuleTypeChange,
+		Message:  "Changing the type of " + column + " rewrites the table",
+	}
+	switch dialect {
+	case "mysql":
+		issue.Message += " (ALGORITHM=COPY) and blocks writes on large tables"
+		issue.Suggestion = "Add ALGORITHM=INPLACE, LOCK=NONE to fail fast when MySQL cannot change it online, or use gh-ost or pt-online-schema-change."
+	case "sqlite":
+		issue.Suggestion = "SQLite has to rebuild the table; run it when the app is stopped, or add a new column and backfill it."
+	case "libsql":
+		issue.Message = "Changing the definition of " + column + " only applies to rows written afterwards"
+		issue.Suggestion = "libSQL's ALTER COLUMN does not check or convert existing rows; backfill them in batches so they match the new definition."
+	default:
+		issue.Message += " under an ACCESS EXCLUSIVE lock on large tables"
+		issue.Suggestion = "Expand and contract: add a column with the new type, write both, backfill in batches, switch reads, then drop the old column."
+	}
+	return issue
+}
+
+func renameIssue(what, dialect string) ValidationIssue {
+	issue := ValidationIssue{
+		Severity:   SeverityWarning,
+		Rule:       RuleRename,
+		Message:    "Renaming " + what + " breaks app instances still using the old name",
+		Suggestion: "Expand and contract: add the new name, write both, backfill, switch reads, then drop the old one in a later release.",
+	}
+	if dialect == "postgres" && strings.HasPrefix(what, "table ") {
+		issue.Suggestion += " For a table, a view with the old name can bridge the deploy."
+	}
+	return issue
+}
+
+// lintDialect returns the canonical name of a dialect or one of its aliases.
+func lintDialect(dialect string) string {
+	switch strings.ToLower(dialect) {
+	case "postgresql":
+		return "postgres"
+	case "sqlite3":
+		return "sqlite"
+	case "turso":
+		return "libsql"
+	}
+	return strings.ToLower(dialect)
+}
+
+// lintName returns an identifier without quotes or schema, lowercased.
+func lintName(ident string) string {
+	ident = strings.Trim(ident, "\"`[]")
+	if i := strings.LastIndex(ident, "."); i >= 0 {
+		ident = strings.Trim(ident[i+1:], "\"`[]")
+	}
+	return strings.ToLower(ident)
+}
+
+// splitTopLevel splits s at commas outside parentheses, brackets and quotes.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
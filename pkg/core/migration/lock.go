@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
 )
 
 // LockOptions configures migration locking behavior.
@@ -16,6 +19,8 @@ type LockOptions struct {
 	LockTTL time.Duration
 	// Identifier is the process identifier for the lock (default: hostname)
 	Identifier string
+	// OnRenewError is called when renewing the lease of the lock fails
+	OnRenewError func(error)
 }
 
 // DefaultLockOptions returns default lock configuration.
@@ -33,33 +38,83 @@ func DefaultLockOptions() LockOptions {
 
 // LockInfo contains information about the current lock.
 type LockInfo struct {
-	LockedAt  time.Time
-	LockedBy  string
-	ExpiresAt time.Time
+	LockedAt  time.Time // When the holder took the lock
+	LockedBy  string    // Identifier of the holder
+	Host      string    // Host of the holder
+	PID       int       // Process id of the holder
+	ExpiresAt time.Time // End of the lease; zero for session locks
 	IsExpired bool
+	Backend   string // Name of the lock backend
 }
 
-// initLockTable creates the lock table if it doesn't exist.
-func (e *Engine) initLockTable(ctx context.Context) error {
-	dialect := e.conn.Dialect
-	sql := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
-		id INTEGER PRIMARY KEY,
-		locked_at TIMESTAMP NOT NULL,
-		locked_by TEXT NOT NULL,
-		expires_at TIMESTAMP NOT NULL
-	)`, dialect.Quote(e.lockTableName))
+// Holder describes the holder of a lock.
+func (i *LockInfo) Holder() string {
+	s := i.LockedBy
+	if i.Host != "" && i.Host != i.LockedBy {
+		s += " on " + i.Host
+	}
+	if i.PID > 0 {
+		s += fmt.Sprintf(" (pid %d)", i.PID)
+	}
+	return s
+}
 
-	_, err := e.conn.Exec(ctx, sql)
-	return err
+// owns reports whether the lock described by i was taken by holder.
+func (i *LockInfo) owns(holder *LockInfo) bool {
+	return i != nil && i.LockedBy == holder.LockedBy && i.Host == holder.Host && i.PID == holder.PID
 }
 
-// AcquireLock attempts to acquire the migration lock.
-// Returns error if lock is held by another process and not expired.
-func (e *Engine) AcquireLock(ctx context.Context, opts LockOptions) error {
-	if err := e.initLockTable(ctx); err != nil {
-		return fmt.Errorf("initializing lock table: %w", err)
+// Locker is a migration lock backend. Lease backends keep the lock until
+// its TTL passes and are renewed while migrations run; session backends
+// hold it as long as their connection is open.
+type Locker interface {
+	// Name describes the backend, e.g. "postgres advisory lock".
+	Name() string
+	// TryAcquire takes the lock for holder if it is free or its lease
+	// expired. It returns false and the current holder if it is taken.
+	TryAcquire(ctx context.Context, holder *LockInfo, ttl time.Duration) (bool, *LockInfo, error)
+	// Renew extends the lease of a lock held by holder. Session backends
+	// do nothing.
+	Renew(ctx context.Context, holder *LockInfo, ttl time.Duration) error
+	// Release releases the lock if holder holds it.
+	Release(ctx context.Context, holder *LockInfo) error
+	// ForceRelease clears the lock regardless of who holds it.
+	ForceRelease(ctx context.Context) error
+	// Info returns the current holder, or nil if the lock is free.
+	Info(ctx context.Context) (*LockInfo, error)
+}
+
+// DefaultLocker returns the lock backend of a dialect: advisory locks on
+// PostgreSQL and MySQL, and the lock table elsewhere.
+func DefaultLocker(conn *dialects.Connection) Locker {
+	if l, err := NewAdvisoryLocker(conn, LockTable); err == nil {
+		return l
 	}
+	return NewTableLocker(conn, LockTable)
+}
 
+// LockTable is the table the lock table backend keeps the lock in, and
+// advisory backends record the holder in.
+const LockTable = "_nexus_migration_lock"
+
+// WithLocker sets the lock backend of the engine.
+func (e *Engine) WithLocker(l Locker) *Engine {
+	e.locker = l
+	return e
+}
+
+// Locker returns the lock backend of the engine.
+func (e *Engine) Locker() Locker {
+	if e.locker == nil {
+		e.locker = DefaultLocker(e.conn)
+	}
+	return e.locker
+}
+
+// AcquireLock attempts to acquire the migration lock, waiting up to
+// opts.Timeout. Returns error if lock is held by another process and not
+// expired. Leases are renewed in the background until ReleaseLock.
+func (e *Engine) AcquireLock(ctx context.Context, opts LockOptions) error {
 	if opts.Identifier == "" {
 		opts.Identifier = DefaultLockOptions().Identifier
 	}
@@ -67,78 +122,102 @@ func (e *Engine) AcquireLock(ctx context.Context, opts LockOptions) error {
 		opts.LockTTL = DefaultLockOptions().LockTTL
 	}
 
-	dialect := e.conn.Dialect
-	now := time.Now()
-	expiresAt := now.Add(opts.LockTTL)
-
-	// Check for existing lock
-	lockInfo, err := e.GetLockInfo(ctx)
-	if err != nil {
-		return err
+	locker := e.Locker()
+	host, _ := os.Hostname()
+	holder := &LockInfo{
+		LockedBy: opts.Identifier,
+		Host:     host,
+		PID:      os.Getpid(),
+		LockedAt: time.Now(),
+		Backend:  locker.Name(),
 	}
 
-	if lockInfo != nil {
-		// Lock exists
-		if !lockInfo.IsExpired {
-			return fmt.Errorf("migrations locked by %s since %s (expires %s)",
-				lockInfo.LockedBy,
-				lockInfo.LockedAt.Format(time.RFC3339),
-				lockInfo.ExpiresAt.Format(time.RFC3339))
+	deadline := time.Now().Add(opts.Timeout)
+	for {
+		ok, current, err := locker.TryAcquire(ctx, holder, opts.LockTTL)
+		if err != nil {
+			return fmt.Errorf("acquiring lock: %w", err)
+		}
+		if ok {
+			break
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return lockedError(current)
 		}
-		// Lock is expired, remove it
-		if err := e.ReleaseLock(ctx); err != nil {
-			return fmt.Errorf("clearing expired lock: %w", err)
+		if current != nil {
+			e.log(ctx).Debug("Waiting for migration lock", nexuslog.F("holder", current.Holder()))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(min(wait, 250*time.Millisecond)):
 		}
 	}
 
-	// Insert new lock
-	insertSQL := fmt.Sprintf(
-		"INSERT INTO %s (id, locked_at, locked_by, expires_at) VALUES (1, %s, %s, %s)",
-		dialect.Quote(e.lockTableName),
-		dialect.Placeholder(1),
-		dialect.Placeholder(2),
-		dialect.Placeholder(3),
-	)
-
-	_, err = e.conn.Exec(ctx, insertSQL, now, opts.Identifier, expiresAt)
-	if err != nil {
-		return fmt.Errorf("acquiring lock: %w", err)
+	e.held = holder
+	e.log(ctx).Debug("Acquired migration lock", nexuslog.F("backend", holder.Backend))
+	renewCtx, cancel := context.WithCancel(nexuslog.NewContext(context.Background(), e.log(ctx)))
+	done := make(chan struct{})
+	e.stopRenew = func() {
+		cancel()
+		<-done
 	}
-
+	go func() {
+		defer close(done)
+		e.renew(renewCtx, holder, opts)
+	}()
 	return nil
 }
 
-// ReleaseLock releases the migration lock.
-func (e *Engine) ReleaseLock(ctx context.Context) error {
-	dialect := e.conn.Dialect
-	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE id = 1", dialect.Quote(e.lockTableName))
-	_, err := e.conn.Exec(ctx, deleteSQL)
-	return err
+// renew extends the lease of the lock every third of its TTL.
+func (e *Engine) renew(ctx context.Context, holder *LockInfo, opts LockOptions) {
+	ticker := time.NewTicker(opts.LockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.locker.Renew(ctx, holder, opts.LockTTL); err != nil && ctx.Err() == nil {
+				e.log(ctx).Warn("Renewing migration lock failed", nexuslog.Err(err))
+				if opts.OnRenewError != nil {
+					opts.OnRenewError(err)
+				}
+			}
+		}
+	}
 }
 
-// GetLockInfo returns information about the current lock, or nil if not locked.
-func (e *Engine) GetLockInfo(ctx context.Context) (*LockInfo, error) {
-	if err := e.initLockTable(ctx); err != nil {
-		return nil, err
+func lockedError(current *LockInfo) error {
+	if current == nil {
+		return fmt.Errorf("migrations are locked by another process")
 	}
+	msg := fmt.Sprintf("migrations locked by %s since %s", current.Holder(), current.LockedAt.Format(time.RFC3339))
+	if !current.ExpiresAt.IsZero() {
+		msg += fmt.Sprintf(" (expires %s)", current.ExpiresAt.Format(time.RFC3339))
+	}
+	return fmt.Errorf("%s", msg)
+}
 
-	dialect := e.conn.Dialect
-	query := fmt.Sprintf(
-		"SELECT locked_at, locked_by, expires_at FROM %s WHERE id = 1",
-		dialect.Quote(e.lockTableName),
-	)
-
-	row := e.conn.QueryRow(ctx, query)
-
-	var info LockInfo
-	err := row.Scan(&info.LockedAt, &info.LockedBy, &info.ExpiresAt)
-	if err != nil {
-		// No lock exists
-		return nil, nil
+// ReleaseLock releases the migration lock taken by AcquireLock.
+func (e *Engine) ReleaseLock(ctx context.Context) error {
+	if e.held == nil {
+		return nil
+	}
+	e.stopRenew()
+	holder := e.held
+	e.held = nil
+	if err := e.Locker().Release(ctx, holder); err != nil {
+		return err
 	}
+	e.log(ctx).Debug("Released migration lock", nexuslog.F("backend", holder.Backend))
+	return nil
+}
 
-	info.IsExpired = time.Now().After(info.ExpiresAt)
-	return &info, nil
+// GetLockInfo returns information about the current lock, or nil if not locked.
+func (e *Engine) GetLockInfo(ctx context.Context) (*LockInfo, error) {
+	return e.Locker().Info(ctx)
 }
 
 // IsLocked checks if migrations are currently locked (and lock is not expired).
@@ -164,5 +243,169 @@ func (e *Engine) WithLock(ctx context.Context, opts LockOptions, fn func() error
 // ForceUnlock removes the lock regardless of who holds it.
 // Use with caution - only for breaking stale locks.
 func (e *Engine) ForceUnlock(ctx context.Context) error {
-	return e.ReleaseLock(ctx)
+	return e.Locker().ForceRelease(ctx)
+}
+
+// tableLocker keeps the lock as a row of a table of the database, with a
+// lease that expires if the holder dies.
+type tableLocker struct {
+	conn  *dialects.Connection
+	table string
+}
+
+// NewTableLocker returns a lock backend that keeps the lock in a table of
+// the database. It works on every dialect.
+func NewTableLocker(conn *dialects.Connection, table string) Locker {
+	return &tableLocker{conn: conn, table: table}
+}
+
+func (l *tableLocker) Name() string { return "lock table" }
+
+// init creates the lock table. A table of older versions, without the
+// holder columns, is recreated: it only holds the current lock.
+func (l *tableLocker) init(ctx context.Context) error {
+	table := l.conn.Dialect.Quote(l.table)
+	sql := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id INTEGER PRIMARY KEY,
+		locked_at TIMESTAMP NOT NULL,
+		locked_by TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		host TEXT NOT NULL,
+		pid INTEGER NOT NULL
+	)`, table)
+	if l.conn.Dialect.Name() == "mssql" {
+		sql = fmt.Sprintf(`IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (
+		id INT PRIMARY KEY,
+		locked_at DATETIME2 NOT NULL,
+		locked_by NVARCHAR(MAX) NOT NULL,
+		expires_at DATETIME2 NOT NULL,
+		host NVARCHAR(255) NOT NULL,
+		pid INT NOT NULL
+	)`, l.table, table)
+	}
+
+	if _, err := l.conn.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err := l.conn.Exec(ctx, fmt.Sprintf("SELECT host, pid FROM %s WHERE id = 0", table)); err == nil {
+		return nil
+	}
+	if _, err := l.conn.Exec(ctx, "DROP TABLE "+table); err != nil {
+		return err
+	}
+	_, err := l.conn.Exec(ctx, sql)
+	return err
+}
+
+func (l *tableLocker) TryAcquire(ctx context.Context, holder *LockInfo, ttl time.Duration) (bool, *LockInfo, error) {
+	if err := l.init(ctx); err != nil {
+		return false, nil, fmt.Errorf("initializing lock table: %w", err)
+	}
+	current, err := l.read(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	if current != nil {
+		if !current.IsExpired {
+			return false, current, nil
+		}
+		// Lock is expired, remove it unless another process just did
+		dialect := l.conn.Dialect
+		deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE id = 1 AND locked_by = %s AND pid = %s",
+			dialect.Quote(l.table), dialect.Placeholder(1), dialect.Placeholder(2))
+		if _, err := l.conn.Exec(ctx, deleteSQL, current.LockedBy, current.PID); err != nil {
+			return false, nil, fmt.Errorf("clearing expired lock: %w", err)
+		}
+	}
+
+	holder.ExpiresAt = time.Now().Add(ttl)
+	if err := l.write(ctx, holder); err != nil {
+		// Another process inserted first
+		if current, _ := l.read(ctx); current != nil {
+			return false, current, nil
+		}
+		return false, nil, err
+	}
+	return true, nil, nil
+}
+
+// write inserts the lock row of holder. Session locks, which do not
+// expire, store their start as expiry.
+func (l *tableLocker) write(ctx context.Context, holder *LockInfo) error {
+	expiresAt := holder.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = holder.LockedAt
+	}
+	dialect := l.conn.Dialect
+	insertSQL := fmt.Sprintf(
+		"INSERT INTO %s (id, locked_at, locked_by, expires_at, host, pid) VALUES (1, %s, %s, %s, %s, %s)",
+		dialect.Quote(l.table),
+		dialect.Placeholder(1),
+		dialect.Placeholder(2),
+		dialect.Placeholder(3),
+		dialect.Placeholder(4),
+		dialect.Placeholder(5),
+	)
+	_, err := l.conn.Exec(ctx, insertSQL, holder.LockedAt, holder.LockedBy, expiresAt, holder.Host, holder.PID)
+	return err
+}
+
+func (l *tableLocker) Renew(ctx context.Context, holder *LockInfo, ttl time.Duration) error {
+	dialect := l.conn.Dialect
+	expiresAt := time.Now().Add(ttl)
+	updateSQL := fmt.Sprintf("UPDATE %s SET expires_at = %s WHERE id = 1 AND locked_by = %s AND host = %s AND pid = %s",
+		dialect.Quote(l.table), dialect.Placeholder(1), dialect.Placeholder(2), dialect.Placeholder(3), dialect.Placeholder(4))
+	res, err := l.conn.Exec(ctx, updateSQL, expiresAt, holder.LockedBy, holder.Host, holder.PID)
+	if err != nil {
+		return fmt.Errorf("renewing lock: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("renewing lock: the lock was taken over by another process")
+	}
+	holder.ExpiresAt = expiresAt
+	return nil
+}
+
+func (l *tableLocker) Release(ctx context.Context, holder *LockInfo) error {
+	dialect := l.conn.Dialect
+	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE id = 1 AND locked_by = %s AND host = %s AND pid = %s",
+		dialect.Quote(l.table), dialect.Placeholder(1), dialect.Placeholder(2), dialect.Placeholder(3))
+	_, err := l.conn.Exec(ctx, deleteSQL, holder.LockedBy, holder.Host, holder.PID)
+	return err
+}
+
+func (l *tableLocker) ForceRelease(ctx context.Context) error {
+	if err := l.init(ctx); err != nil {
+		return err
+	}
+	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE id = 1", l.conn.Dialect.Quote(l.table))
+	_, err := l.conn.Exec(ctx, deleteSQL)
+	return err
+}
+
+func (l *tableLocker) Info(ctx context.Context) (*LockInfo, error) {
+	if err := l.init(ctx); err != nil {
+		return nil, err
+	}
+	return l.read(ctx)
+}
+
+// read returns the lock row, or nil if there is none.
+func (l *tableLocker) read(ctx context.Context) (*LockInfo, error) {
+	query := fmt.Sprintf(
+		"SELECT locked_at, locked_by, expires_at, host, pid FROM %s WHERE id = 1",
+		l.conn.Dialect.Quote(l.table),
+	)
+
+	row := l.conn.QueryRow(ctx, query)
+
+	info := LockInfo{Backend: l.Name()}
+	err := row.Scan(&info.LockedAt, &info.LockedBy, &info.ExpiresAt, &info.Host, &info.PID)
+	if err != nil {
+		// No lock exists
+		return nil, nil
+	}
+
+	info.IsExpired = time.Now().After(info.ExpiresAt)
+	return &info, nil
 }
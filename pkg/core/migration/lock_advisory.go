@@ -0,0 +1,162 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/crc32"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// advisoryLocker holds a PostgreSQL advisory lock or a MySQL named lock
+// on a connection of its own, so the database releases it when the holder
+// dies. The holder is also written to the lock table for status.
+type advisoryLocker struct {
+	conn     *dialects.Connection
+	meta     *tableLocker
+	session  *sql.Conn // Connection holding the lock
+	postgres bool
+}
+
+// NewAdvisoryLocker returns a lock backend that uses PostgreSQL advisory
+// locks or MySQL GET_LOCK. Holder details are kept in table. It fails on
+// other dialects.
+func NewAdvisoryLocker(conn *dialects.Connection, table string) (Locker, error) {
+	switch conn.Dialect.Name() {
+	case "postgres", "mysql":
+	default:
+		return nil, fmt.Errorf("advisory locks need PostgreSQL or MySQL, not %s", conn.Dialect.Name())
+	}
+	return &advisoryLocker{
+		conn:     conn,
+		meta:     &tableLocker{conn: conn, table: table},
+		postgres: conn.Dialect.Name() == "postgres",
+	}, nil
+}
+
+func (l *advisoryLocker) Name() string {
+	if l.postgres {
+		return "postgres advisory lock"
+	}
+	return "mysql GET_LOCK"
+}
+
+// advisoryKey is the PostgreSQL lock key. It fits in 32 bits, so it is the
+// objid of the lock in pg_locks.
+var advisoryKey = int64(crc32.ChecksumIEEE([]byte("nexus_migrations")) >> 1)
+
+// mysqlLockName scopes the server-wide MySQL lock to the current database.
+const mysqlLockName = "CONCAT('nexus_migrations:', DATABASE())"
+
+func (l *advisoryLocker) TryAcquire(ctx context.Context, holder *LockInfo, ttl time.Duration) (bool, *LockInfo, error) {
+	if l.session != nil {
+		return false, nil, fmt.Errorf("the lock is already held by this process")
+	}
+	if err := l.meta.init(ctx); err != nil {
+		return false, nil, fmt.Errorf("initializing lock table: %w", err)
+	}
+	session, err := l.conn.DB.Conn(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var ok sql.NullBool
+	if l.postgres {
+		err = session.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", advisoryKey).Scan(&ok)
+	} else {
+		err = session.QueryRowContext(ctx, "SELECT GET_LOCK("+mysqlLockName+", 0)").Scan(&ok)
+	}
+	if err != nil || !ok.Bool {
+		session.Close()
+		if err != nil {
+			return false, nil, err
+		}
+		current, err := l.Info(ctx)
+		return false, current, err
+	}
+	l.session = session
+
+	// The lock is ours, so any row left is from a holder that died
+	holder.ExpiresAt = time.Time{}
+	if err := l.meta.ForceRelease(ctx); err != nil {
+		return false, nil, l.unlock(ctx, err)
+	}
+	if err := l.meta.write(ctx, holder); err != nil {
+		return false, nil, l.unlock(ctx, err)
+	}
+	return true, nil, nil
+}
+
+// Renew does nothing: the lock lasts as long as its connection.
+func (l *advisoryLocker) Renew(ctx context.Context, holder *LockInfo, ttl time.Duration) error {
+	return nil
+}
+
+func (l *advisoryLocker) Release(ctx context.Context, holder *LockInfo) error {
+	if l.session == nil {
+		return nil
+	}
+	err := l.meta.Release(ctx, holder)
+	return l.unlock(ctx, err)
+}
+
+// unlock releases the lock and closes its connection, returning err or the
+// first error of doing so.
+func (l *advisoryLocker) unlock(ctx context.Context, err error) error {
+	var unlockErr error
+	if l.postgres {
+		_, unlockErr = l.session.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryKey)
+	} else {
+		_, unlockErr = l.session.ExecContext(ctx, "SELECT RELEASE_LOCK("+mysqlLockName+")")
+	}
+	closeErr := l.session.Close()
+	l.session = nil
+	for _, e := range []error{err, unlockErr, closeErr} {
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// ForceRelease clears the holder details. The lock itself cannot be taken
+// from another session; it is released when the holder disconnects.
+func (l *advisoryLocker) ForceRelease(ctx context.Context) error {
+	return l.meta.ForceRelease(ctx)
+}
+
+func (l *advisoryLocker) Info(ctx context.Context) (*LockInfo, error) {
+	var held bool
+	if l.postgres {
+		err := l.conn.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory'
+			AND classid = 0 AND objid = $1 AND granted
+			AND database = (SELECT oid FROM pg_database WHERE datname = current_database()))`, advisoryKey).Scan(&held)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var id sql.NullInt64
+		if err := l.conn.QueryRow(ctx, "SELECT IS_USED_LOCK("+mysqlLockName+")").Scan(&id); err != nil {
+			return nil, err
+		}
+		held = id.Valid
+	}
+	if !held {
+		return nil, nil
+	}
+
+	info, err := l.meta.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		// Taken by a version that did not record its holder
+		info = &LockInfo{LockedBy: "unknown"}
+	}
+	info.Backend = l.Name()
+	info.ExpiresAt = time.Time{}
+	info.IsExpired = false
+	return info, nil
+}
@@ -0,0 +1,428 @@
+package migration
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockRecord is a lock holder as stored by the file and Redis backends.
+type lockRecord struct {
+	LockedBy  string    `json:"locked_by"`
+	Host      string    `json:"host"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func encodeLock(holder *LockInfo) []byte {
+	data, _ := json.Marshal(lockRecord{
+		LockedBy:  holder.LockedBy,
+		Host:      holder.Host,
+		PID:       holder.PID,
+		StartedAt: holder.LockedAt,
+		ExpiresAt: holder.ExpiresAt,
+	})
+	return data
+}
+
+func decodeLock(data []byte, backend string) *LockInfo {
+	var r lockRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		// Unreadable: treat it as a lock that expired
+		return &LockInfo{LockedBy: "unknown", IsExpired: true, Backend: backend}
+	}
+	return &LockInfo{
+		LockedBy:  r.LockedBy,
+		Host:      r.Host,
+		PID:       r.PID,
+		LockedAt:  r.StartedAt,
+		ExpiresAt: r.ExpiresAt,
+		IsExpired: time.Now().After(r.ExpiresAt),
+		Backend:   backend,
+	}
+}
+
+// fileLocker keeps the lock as a file created exclusively, for SQLite
+// databases shared by processes of one machine or a shared volume.
+//
+// The lock file is only ever created whole, by hard linking a file
+// written aside, which fails if it exists, and is never overwritten. To
+// change or remove it, a process first renames it to a name of its own;
+// only one process can, and it checks the file it moved is the one it
+// meant to change, putting it back otherwise.
+type fileLocker struct {
+	path string
+}
+
+// NewFileLocker returns a lock backend that keeps the lock in a file.
+func NewFileLocker(path string) Locker {
+	return &fileLocker{path: path}
+}
+
+func (l *fileLocker) Name() string { return "lock file " + l.path }
+
+func (l *fileLocker) TryAcquire(ctx context.Context, holder *LockInfo, ttl time.Duration) (bool, *LockInfo, error) {
+	holder.ExpiresAt = time.Now().Add(ttl)
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err := l.create(holder)
+		if err != nil || created {
+			return created, nil, err
+		}
+
+		data, err := os.ReadFile(l.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return false, nil, err
+		}
+		if current := decodeLock(data, l.Name()); !current.IsExpired {
+			return false, current, nil
+		}
+		// Lock is expired: move it aside, and remove it if it is still the
+		// one read, rather than one another process took it over with
+		aside, err := l.moveAside()
+		if err != nil {
+			return false, nil, fmt.Errorf("clearing expired lock: %w", err)
+		}
+		if aside == "" {
+			continue
+		}
+		moved, err := os.ReadFile(aside)
+		if err != nil {
+			return false, nil, fmt.Errorf("clearing expired lock: %w", err)
+		}
+		if !bytes.Equal(moved, data) {
+			return false, decodeLock(moved, l.Name()), l.restore(aside)
+		}
+		if err := os.Remove(aside); err != nil {
+			return false, nil, fmt.Errorf("clearing expired lock: %w", err)
+		}
+	}
+	current, err := l.Info(ctx)
+	return false, current, err
+}
+
+func (l *fileLocker) Renew(ctx context.Context, holder *LockInfo, ttl time.Duration) error {
+	taken := errors.New("renewing lock: the lock was taken over by another process")
+	current, err := l.Info(ctx)
+	if err != nil {
+		return err
+	}
+	if !current.owns(holder) {
+		return taken
+	}
+	aside, err := l.moveAside()
+	if err != nil {
+		return err
+	}
+	if aside == "" {
+		return taken
+	}
+	moved, err := os.ReadFile(aside)
+	if err != nil {
+		return err
+	}
+	if !decodeLock(moved, l.Name()).owns(holder) {
+		if err := l.restore(aside); err != nil {
+			return err
+		}
+		return taken
+	}
+	defer os.Remove(aside)
+	holder.ExpiresAt = time.Now().Add(ttl)
+	created, err := l.create(holder)
+	if err != nil {
+		return err
+	}
+	if !created {
+		// Another process took the lock while it was aside
+		return taken
+	}
+	return nil
+}
+
+func (l *fileLocker) Release(ctx context.Context, holder *LockInfo) error {
+	current, err := l.Info(ctx)
+	if err != nil || !current.owns(holder) {
+		return err
+	}
+	aside, err := l.moveAside()
+	if err != nil || aside == "" {
+		return err
+	}
+	moved, err := os.ReadFile(aside)
+	if err != nil {
+		return err
+	}
+	if !decodeLock(moved, l.Name()).owns(holder) {
+		return l.restore(aside)
+	}
+	return os.Remove(aside)
+}
+
+func (l *fileLocker) ForceRelease(ctx context.Context) error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *fileLocker) Info(ctx context.Context) (*LockInfo, error) {
+	data, err := os.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeLock(data, l.Name()), nil
+}
+
+// create writes the lock file for holder unless it exists, reporting
+// whether it did. The record is written aside and linked into place, so
+// the lock file is never seen partly written.
+func (l *fileLocker) create(holder *LockInfo) (bool, error) {
+	tmp, err := l.tempFile()
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp)
+	if err := os.WriteFile(tmp, encodeLock(holder), 0644); err != nil {
+		return false, err
+	}
+	if err := os.Link(tmp, l.path); err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// moveAside renames the lock file to a name only this call uses, and
+// returns it, or "" if there is no lock file.
+func (l *fileLocker) moveAside() (string, error) {
+	aside, err := l.tempFile()
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(l.path, aside); err != nil {
+		os.Remove(aside)
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return aside, nil
+}
+
+// restore puts a lock file moved aside back, unless another process
+// created the lock file meanwhile.
+func (l *fileLocker) restore(aside string) error {
+	defer os.Remove(aside)
+	if err := os.Link(aside, l.path); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+// tempFile creates an empty file next to the lock file with a name of
+// its own.
+func (l *fileLocker) tempFile() (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(l.path), "."+filepath.Base(l.path)+".*")
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// redisLocker keeps the lock as a Redis key that expires with the lease.
+type redisLocker struct {
+	addr     string
+	password string
+	db       int
+	key      string
+	value    string // Value written by this process, to check ownership
+}
+
+// Scripts that change the key only if this process still holds it.
+const (
+	redisRenewScript   = `if redis.call('get', KEYS[1]) == ARGV[1] then return redis.call('set', KEYS[1], ARGV[2], 'px', ARGV[3]) else return nil end`
+	redisReleaseScript = `if redis.call('get', KEYS[1]) == ARGV[1] then return redis.call('del', KEYS[1]) else return 0 end`
+)
+
+// NewRedisLocker returns a lock backend that keeps the lock in Redis, at
+// a URL such as redis://:password@localhost:6379/0, under key (default
+// "nexus:migration_lock").
+func NewRedisLocker(rawURL, key string) (Locker, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("expected a redis:// URL, got %q", rawURL)
+	}
+	l := &redisLocker{addr: u.Host, key: key}
+	if u.Port() == "" {
+		l.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		l.password = password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if l.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+	if l.key == "" {
+		l.key = "nexus:migration_lock"
+	}
+	return l, nil
+}
+
+func (l *redisLocker) Name() string { return "redis " + l.addr }
+
+func (l *redisLocker) TryAcquire(ctx context.Context, holder *LockInfo, ttl time.Duration) (bool, *LockInfo, error) {
+	holder.ExpiresAt = time.Now().Add(ttl)
+	value := string(encodeLock(holder))
+	reply, err := l.do(ctx, "SET", l.key, value, "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return false, nil, err
+	}
+	if reply == nil {
+		current, err := l.Info(ctx)
+		return false, current, err
+	}
+	l.value = value
+	return true, nil, nil
+}
+
+func (l *redisLocker) Renew(ctx context.Context, holder *LockInfo, ttl time.Duration) error {
+	expiresAt := time.Now().Add(ttl)
+	renewed := *holder
+	renewed.ExpiresAt = expiresAt
+	value := string(encodeLock(&renewed))
+	reply, err := l.do(ctx, "EVAL", redisRenewScript, "1", l.key, l.value, value, strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return fmt.Errorf("renewing lock: %w", err)
+	}
+	if reply == nil {
+		return fmt.Errorf("renewing lock: the lock was taken over by another process")
+	}
+	l.value = value
+	holder.ExpiresAt = expiresAt
+	return nil
+}
+
+func (l *redisLocker) Release(ctx context.Context, holder *LockInfo) error {
+	if l.value == "" {
+		return nil
+	}
+	_, err := l.do(ctx, "EVAL", redisReleaseScript, "1", l.key, l.value)
+	l.value = ""
+	return err
+}
+
+func (l *redisLocker) ForceRelease(ctx context.Context) error {
+	_, err := l.do(ctx, "DEL", l.key)
+	return err
+}
+
+func (l *redisLocker) Info(ctx context.Context) (*LockInfo, error) {
+	reply, err := l.do(ctx, "GET", l.key)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	s, _ := reply.(string)
+	return decodeLock([]byte(s), l.Name()), nil
+}
+
+// do runs a command on a new connection and returns its reply: a string,
+// an int64 or nil.
+func (l *redisLocker) do(ctx context.Context, args ...string) (interface{}, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", l.addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to redis: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+
+	r := bufio.NewReader(conn)
+	var commands [][]string
+	if l.password != "" {
+		commands = append(commands, []string{"AUTH", l.password})
+	}
+	if l.db != 0 {
+		commands = append(commands, []string{"SELECT", strconv.Itoa(l.db)})
+	}
+	commands = append(commands, args)
+
+	var reply interface{}
+	for _, cmd := range commands {
+		if err := writeRESP(conn, cmd); err != nil {
+			return nil, err
+		}
+		if reply, err = readRESP(r); err != nil {
+			return nil, fmt.Errorf("redis %s: %w", cmd[0], err)
+		}
+	}
+	return reply, nil
+}
+
+func writeRESP(w io.Writer, args []string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
@@ -0,0 +1,252 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// idLayout is the timestamp format of migration IDs.
+const idLayout = "20060102_150405"
+
+// OutOfOrderError reports pending migrations older than the newest applied
+// one, typically from a merged branch.
+type OutOfOrderError struct {
+	Migrations    []*Migration
+	LatestApplied string
+}
+
+func (e *OutOfOrderError) Error() string {
+	ids := make([]string, len(e.Migrations))
+	for i, m := range e.Migrations {
+		ids[i] = m.ID + "_" + m.Name
+	}
+	return fmt.Sprintf("pending migration(s) %s are older than the applied migration %s; run 'nexus migrate repair' to renumber them",
+		strings.Join(ids, ", "), e.LatestApplied)
+}
+
+// WithStrictOrder makes Up fail with an *OutOfOrderError instead of
+// applying pending migrations older than the newest applied one.
+func (e *Engine) WithStrictOrder(strict bool) *Engine {
+	e.strictOrder = strict
+	return e
+}
+
+// OutOfOrder returns the pending migrations older than the newest applied
+// migration, and the ID of that migration.
+func (e *Engine) OutOfOrder(ctx context.Context) ([]*Migration, string, error) {
+	applied, err := e.getApplied(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	latest := latestID(applied)
+	pending, err := e.Pending(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	var older []*Migration
+	for _, m := range pending {
+		if m.ID < latest {
+			older = append(older, m)
+		}
+	}
+	return older, latest, nil
+}
+
+func latestID(applied []MigrationHistory) string {
+	latest := ""
+	for _, h := range applied {
+		if h.MigrationID > latest {
+			latest = h.MigrationID
+		}
+	}
+	return latest
+}
+
+// Drift is an applied migration whose file changed after it was applied.
+type Drift struct {
+	ID      string
+	Name    string
+	Applied string // Checksum recorded when the migration was applied
+	Current string // Checksum of the file now
+}
+
+// Drifted returns the applied migrations whose file no longer matches the
+// checksum recorded when they were applied. Editing an applied migration
+// doesn't change the databases it already ran on, so they no longer match
+// new ones.
+func (e *Engine) Drifted(ctx context.Context) ([]Drift, error) {
+	applied, err := e.getApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loaded := make(map[string]*Migration, len(e.migrations))
+	for _, m := range e.migrations {
+		loaded[m.ID] = m
+	}
+	var drifted []Drift
+	for _, h := range applied {
+		if m, ok := loaded[h.MigrationID]; ok && m.Checksum != h.Checksum {
+			drifted = append(drifted, Drift{ID: m.ID, Name: m.Name, Applied: h.Checksum, Current: m.Checksum})
+		}
+	}
+	return drifted, nil
+}
+
+// MigrationRename is a migration moved to another ID.
+type MigrationRename struct {
+	OldID string
+	NewID string
+	Name  string
+}
+
+// RepairPlan lists what Repair changes.
+type RepairPlan struct {
+	// Renumber moves pending migrations older than the newest applied one
+	// after all migrations, keeping their order.
+	Renumber []MigrationRename
+	// Relink points history rows at migration files that were renamed,
+	// matched by checksum.
+	Relink []MigrationRename
+	// Orphaned are applied migrations without a file. They are reported
+	// but kept.
+	Orphaned []MigrationHistory
+}
+
+// Empty reports whether there is nothing to repair.
+func (p *RepairPlan) Empty() bool {
+	return len(p.Renumber) == 0 && len(p.Relink) == 0 && len(p.Orphaned) == 0
+}
+
+// PlanRepair compares the loaded migrations with the history table and
+// returns how to reconcile them.
+func (e *Engine) PlanRepair(ctx context.Context) (*RepairPlan, error) {
+	applied, err := e.getApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*Migration)
+	for _, m := range e.migrations {
+		files[m.ID] = m
+	}
+	appliedIDs := make(map[string]bool)
+	for _, h := range applied {
+		appliedIDs[h.MigrationID] = true
+	}
+
+	plan := &RepairPlan{}
+	relinked := make(map[string]bool)
+	for _, h := range applied {
+		if files[h.MigrationID] != nil {
+			continue
+		}
+		var match *Migration
+		for _, m := range e.migrations {
+			if !appliedIDs[m.ID] && !relinked[m.ID] && m.Checksum == h.Checksum && m.Name == h.Name {
+				match = m
+				break
+			}
+		}
+		if match == nil {
+			plan.Orphaned = append(plan.Orphaned, h)
+			continue
+		}
+		relinked[match.ID] = true
+		appliedIDs[match.ID] = true
+		plan.Relink = append(plan.Relink, MigrationRename{OldID: h.MigrationID, NewID: match.ID, Name: match.Name})
+	}
+
+	latest := ""
+	for id := range appliedIDs {
+		if files[id] != nil && id > latest {
+			latest = id
+		}
+	}
+
+	var older []*Migration
+	for _, m := range e.migrations {
+		if !appliedIDs[m.ID] && m.ID < latest && e.InEnvironment(m) {
+			older = append(older, m)
+		}
+	}
+	if len(older) == 0 {
+		return plan, nil
+	}
+
+	// New IDs follow every existing migration and the current time
+	used := make(map[string]bool)
+	next := time.Now().Truncate(time.Second)
+	for _, m := range e.migrations {
+		used[m.ID] = true
+		if t, err := time.ParseInLocation(idLayout, m.ID, time.Local); err == nil && !t.Before(next) {
+			next = t.Add(time.Second)
+		}
+	}
+	for _, m := range older {
+		id := next.Format(idLayout)
+		for used[id] {
+			next = next.Add(time.Second)
+			id = next.Format(idLayout)
+		}
+		used[id] = true
+		next = next.Add(time.Second)
+		plan.Renumber = append(plan.Renumber, MigrationRename{OldID: m.ID, NewID: id, Name: m.Name})
+	}
+	return plan, nil
+}
+
+// Repair carries out plan: it renames the renumbered migration files in
+// dir and updates relinked history rows in one transaction.
+func (e *Engine) Repair(ctx context.Context, dir string, plan *RepairPlan) error {
+	if len(plan.Relink) > 0 {
+		tx, err := e.conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		dialect := e.conn.Dialect
+		updateSQL := fmt.Sprintf("UPDATE %s SET migration_id = %s WHERE migration_id = %s",
+			dialect.Quote(e.tableName), dialect.Placeholder(1), dialect.Placeholder(2))
+		_, history := e.schemaHistory()
+		historySQL := fmt.Sprintf("UPDATE %s SET migration_id = %s WHERE migration_id = %s",
+			dialect.Quote(SchemaHistoryTable), dialect.Placeholder(1), dialect.Placeholder(2))
+		for _, r := range plan.Relink {
+			if _, err := tx.Exec(ctx, updateSQL, r.NewID, r.OldID); err != nil {
+				return fmt.Errorf("relinking %s: %w", r.OldID, err)
+			}
+			if history {
+				if _, err := tx.Exec(ctx, historySQL, r.NewID, r.OldID); err != nil {
+					return fmt.Errorf("relinking schema history of %s: %w", r.OldID, err)
+				}
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range plan.Renumber {
+		oldPath := filepath.Join(dir, r.OldID+"_"+r.Name+".sql")
+		newPath := filepath.Join(dir, r.NewID+"_"+r.Name+".sql")
+		if _, err := os.Stat(newPath); err == nil {
+			return fmt.Errorf("renumbering %s: %s already exists", r.OldID, newPath)
+		}
+		if err := os.Rename(oldPath, newPath); err != nil {
+			return fmt.Errorf("renumbering %s: %w", r.OldID, err)
+		}
+		for _, m := range e.migrations {
+			if m.ID == r.OldID {
+				m.ID = r.NewID
+			}
+		}
+	}
+	sort.Slice(e.migrations, func(i, j int) bool {
+		return e.migrations[i].ID < e.migrations[j].ID
+	})
+	return nil
+}
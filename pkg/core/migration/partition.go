@@ -0,0 +1,79 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// PartitionOptions configures EnsurePartitions.
+type PartitionOptions struct {
+	Ahead  int       // Partitions to create past the current one
+	Now    time.Time // Time in the current partition; zero means now
+	DryRun bool      // Return the missing partitions without creating them
+}
+
+// DefaultPartitionOptions returns the default options, which keep three
+// partitions ahead of the current one.
+func DefaultPartitionOptions() PartitionOptions {
+	return PartitionOptions{Ahead: 3}
+}
+
+// CreatedPartition is a partition EnsurePartitions created.
+type CreatedPartition struct {
+	Table     string
+	Partition string
+	SQL       string
+}
+
+// EnsurePartitions creates the missing range partitions of the models
+// partitioned by an interval: the partition of the current period and
+// opts.Ahead more. Tables that do not exist yet are skipped.
+func EnsurePartitions(ctx context.Context, conn *dialects.Connection, s *schema.Schema, opts PartitionOptions) ([]CreatedPartition, error) {
+	partitioner, ok := conn.Dialect.(dialects.Partitioner)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support partitioned tables", conn.Dialect.Name())
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var created []CreatedPartition
+	for _, model := range s.GetModels() {
+		part := model.Partition
+		if part == nil || part.Strategy != schema.Range || part.Interval == "" {
+			continue
+		}
+
+		names, err := partitioner.IntrospectPartitions(ctx, conn.DB, model.Table())
+		if err != nil {
+			return created, fmt.Errorf("%s: listing partitions: %w", model.Table(), err)
+		}
+		if len(names) == 0 {
+			continue // Not created, or not partitioned yet
+		}
+		existing := make(map[string]bool, len(names))
+		for _, name := range names {
+			existing[name] = true
+		}
+
+		for _, p := range part.RangePartitions(now, opts.Ahead) {
+			name := partitioner.PartitionName(model, p)
+			if existing[name] {
+				continue
+			}
+			c := CreatedPartition{Table: model.Table(), Partition: name, SQL: partitioner.AddPartitionSQL(model, p)}
+			if !opts.DryRun {
+				if _, err := conn.Exec(ctx, c.SQL); err != nil {
+					return created, fmt.Errorf("%s: creating partition %s: %w", model.Table(), name, err)
+				}
+			}
+			created = append(created, c)
+		}
+	}
+	return created, nil
+}
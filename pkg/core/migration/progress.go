@@ -0,0 +1,121 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// StatementEvent reports a statement of a migration that finished running.
+type StatementEvent struct {
+	Migration    *Migration
+	Direction    string // "up" or "down"
+	Index        int    // 1-based position of the statement
+	Total        int    // Number of statements in the migration
+	SQL          string
+	RowsAffected int64 // -1 for statements that do not change rows, such as DDL
+	Elapsed      time.Duration
+}
+
+// ProgressFunc receives statement events.
+type ProgressFunc func(StatementEvent)
+
+// WithProgress makes the engine report each statement of the migrations it
+// runs to fn.
+func (e *Engine) WithProgress(fn ProgressFunc) *Engine {
+	e.progress = fn
+	return e
+}
+
+// MigrationEvent reports a migration that was applied or rolled back.
+type MigrationEvent struct {
+	Migration *Migration
+	Direction string // "up" or "down"
+	Elapsed   time.Duration
+}
+
+// OnMigration makes the engine report each migration it applies or rolls
+// back to fn, once it is recorded in the history.
+func (e *Engine) OnMigration(fn func(MigrationEvent)) *Engine {
+	e.onMigration = fn
+	return e
+}
+
+// migrated reports a finished migration to the OnMigration function.
+func (e *Engine) migrated(m *Migration, direction string, elapsed time.Duration) {
+	if e.onMigration != nil {
+		e.onMigration(MigrationEvent{Migration: m, Direction: direction, Elapsed: elapsed})
+	}
+}
+
+// execute runs the statements of the SQL of a migration one at a time,
+// split as the dialect of the connection reads them, and returns how long
+// it took.
+func (e *Engine) execute(ctx context.Context, tx *migrationTx, m *Migration, direction, sql string) (time.Duration, error) {
+	start := time.Now()
+	e.log(ctx).Debug("Running migration", nexuslog.F("migration", m.ID), nexuslog.F("direction", direction))
+	statements := SplitDialectStatements(sql, e.conn.Dialect.Name())
+	for i, stmt := range statements {
+		began := time.Now()
+		rows, err := tx.execute(ctx, stmt)
+		if err != nil {
+			return time.Since(start), fmt.Errorf("statement %d of %d: %w", i+1, len(statements), err)
+		}
+		e.log(ctx).Debug("Ran statement", nexuslog.F("migration", m.ID), nexuslog.F("statement", fmt.Sprintf("%d/%d", i+1, len(statements))), nexuslog.F("duration", time.Since(began)))
+		if e.progress == nil {
+			continue
+		}
+		e.progress(StatementEvent{
+			Migration:    m,
+			Direction:    direction,
+			Index:        i + 1,
+			Total:        len(statements),
+			SQL:          stmt,
+			RowsAffected: rows,
+			Elapsed:      time.Since(began),
+		})
+	}
+	return time.Since(start), nil
+}
+
+// execute runs stmt and returns how many rows it changed, or -1 for
+// statements that do not change rows. The rows of a COPY ... FROM stdin
+// are loaded with pkg/bulk.
+func (t *migrationTx) execute(ctx context.Context, stmt string) (int64, error) {
+	if copyFromStdin.MatchString(stmt) {
+		return t.copyRows(ctx, stmt)
+	}
+	result, err := t.Exec(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+	if isDML(stmt) {
+		if n, err := result.RowsAffected(); err == nil {
+			return n, nil
+		}
+	}
+	return -1, nil
+}
+
+// isDML reports whether stmt changes rows, so that its affected row count
+// means something. Some drivers report a stale count for DDL.
+func isDML(stmt string) bool {
+	words := strings.Fields(strings.ToUpper(stmt))
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "MERGE", "WITH":
+		return true
+	}
+	return false
+}
+
+// durationMillis returns d in milliseconds, rounded up so that a recorded
+// duration is never zero.
+func durationMillis(d time.Duration) int64 {
+	return int64((d + time.Millisecond - 1) / time.Millisecond)
+}
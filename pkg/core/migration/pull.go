@@ -0,0 +1,368 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// SchemaFromSnapshot reverse-engineers a schema from an introspected database.
+// Model names match table names exactly so that a later Diff against the same
+// database reports no changes; tables in other schemas get @@schema, and are
+// prefixed with their schema if another model has their name.
+// Anything that cannot be expressed in the schema is skipped and described in
+// the returned warnings.
+func SchemaFromSnapshot(snapshot *DatabaseSnapshot) (*schema.Schema, []string) {
+	s := schema.NewSchema()
+	var warnings []string
+
+	tableNames := make([]string, 0, len(snapshot.Tables))
+	for name := range snapshot.Tables {
+		if isInternalTable(name) {
+			continue
+		}
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames)
+
+	// Model names, starting with the tables of the default schema
+	taken := make(map[string]bool, len(tableNames))
+	for _, tableName := range tableNames {
+		taken[tableName] = true
+	}
+	models := make(map[string]*schema.Model, len(tableNames))
+	for _, tableName := range tableNames {
+		table := snapshot.Tables[tableName]
+		namespace, name := schema.SplitTable(tableName)
+		modelName := name
+		if namespace != "" {
+			if taken[name] {
+				modelName = namespace + "_" + name
+			}
+			taken[modelName] = true
+		}
+		s.Model(modelName, func(m *schema.Model) {
+			models[tableName] = m
+			if namespace != "" {
+				m.Schema(namespace)
+				if modelName != name {
+					m.Map(name)
+				}
+			}
+			m.CharacterSet, m.Collation = table.Charset, table.Collation
+			m.Description = table.Comment
+			for _, col := range table.OrderedColumns() {
+				fieldType, length, precision, scale, known := FieldTypeFromSQL(col.Type)
+				if col.Enum != "" {
+					// Native enums have no schema equivalent yet; store the label
+					fieldType, length, known = schema.FieldTypeString, 0, true
+					warnings = append(warnings, fmt.Sprintf("%s.%s: enum type %s mapped to String", tableName, col.Name, col.Enum))
+				}
+				if !known {
+					warnings = append(warnings, fmt.Sprintf("%s.%s: unknown type %q, using Text", tableName, col.Name, col.Type))
+				}
+
+				f := m.AddField(col.Name, fieldType)
+				f.Nullable = col.Nullable && !col.IsPrimaryKey
+				f.IsPrimaryKey = col.IsPrimaryKey
+				f.IsUnique = col.IsUnique
+				f.AutoIncrement = col.AutoInc || strings.Contains(strings.ToUpper(col.Type), "SERIAL")
+				f.Length = length
+				f.Precision = precision
+				f.Scale = scale
+				f.IsUnsigned = col.Unsigned && fieldType.IsInteger()
+				f.Description = col.Comment
+				if f.Collatable() {
+					f.CharacterSet, f.Collation = col.Charset, col.Collation
+				}
+
+				if warning := applyIntrospectedDefault(f, col.Default); warning != "" {
+					warnings = append(warnings, fmt.Sprintf("%s.%s: %s", tableName, col.Name, warning))
+				}
+			}
+
+			indexNames := make([]string, 0, len(table.Indexes))
+			for name := range table.Indexes {
+				indexNames = append(indexNames, name)
+			}
+			sort.Strings(indexNames)
+
+			for _, name := range indexNames {
+				idx := table.Indexes[name]
+				if idx.Unique {
+					m.UniqueIndex(idx.Name, idx.Columns...)
+				} else {
+					m.Index(idx.Name, idx.Columns...)
+				}
+			}
+		})
+	}
+
+	// Declared foreign keys take precedence over naming conventions
+	for _, tableName := range tableNames {
+		table := snapshot.Tables[tableName]
+		warnings = append(warnings, applyForeignKeys(models, models[tableName], table)...)
+
+		for _, check := range table.Checks {
+			// Columns of unsigned fields check they are not negative
+			if m := nonNegativeCheck.FindStringSubmatch(checkNoise.Replace(check.Expression)); m != nil {
+				if f, ok := models[tableName].Fields[m[1]]; ok && f.Type.IsInteger() {
+					f.IsUnsigned = true
+					continue
+				}
+			}
+			warnings = append(warnings, fmt.Sprintf("%s: check constraint %s (%s) is not expressible in the schema DSL, skipped",
+				tableName, check.Name, check.Expression))
+		}
+	}
+
+	s.DetectRelations()
+	return s, warnings
+}
+
+// applyForeignKeys turns introspected foreign keys into explicit relations,
+// given the models of the tables.
+func applyForeignKeys(models map[string]*schema.Model, model *schema.Model, table *TableInfo) []string {
+	var warnings []string
+
+	fkNames := make([]string, 0, len(table.ForeignKeys))
+	for name := range table.ForeignKeys {
+		fkNames = append(fkNames, name)
+	}
+	sort.Strings(fkNames)
+
+	for _, name := range fkNames {
+		fk := table.ForeignKeys[name]
+		target, ok := models[fk.RefTable]
+		if !ok || len(fk.Columns) != 1 || len(fk.RefColumns) != 1 {
+			warnings = append(warnings, fmt.Sprintf("%s: foreign key %s on (%s) cannot be mapped to a relation, skipped",
+				model.Name, name, strings.Join(fk.Columns, ", ")))
+			continue
+		}
+
+		field, ok := model.Fields[fk.Columns[0]]
+		if !ok {
+			continue
+		}
+		field.Ref(target.Name)
+
+		onDelete := CascadeActionFromSQL(fk.OnDelete)
+		onUpdate := CascadeActionFromSQL(fk.OnUpdate)
+
+		model.Relations = append(model.Relations, &schema.Relation{
+			Type:           schema.RelationBelongsTo,
+			TargetModel:    target.Name,
+			ForeignKey:     field.Name,
+			ReferenceKey:   fk.RefColumns[0],
+			OnDeleteAction: onDelete,
+			OnUpdateAction: onUpdate,
+		})
+		target.Relations = append(target.Relations, &schema.Relation{
+			Type:           schema.RelationHasMany,
+			TargetModel:    model.Name,
+			ForeignKey:     field.Name,
+			ReferenceKey:   fk.RefColumns[0],
+			OnDeleteAction: onDelete,
+			OnUpdateAction: onUpdate,
+		})
+	}
+
+	return warnings
+}
+
+// CascadeActionFromSQL maps a referential action such as "ON DELETE SET NULL"
+// to a schema cascade action. SET DEFAULT has no equivalent and maps to NoAction.
+func CascadeActionFromSQL(action string) schema.CascadeAction {
+	switch strings.ToUpper(strings.TrimSpace(action)) {
+	case "CASCADE":
+		return schema.Cascade
+	case "SET NULL":
+		return schema.SetNull
+	case "RESTRICT":
+		return schema.Restrict
+	default:
+		return schema.NoAction
+	}
+}
+
+// isInternalTable reports whether a table is managed by Nexus itself.
+func isInternalTable(name string) bool {
+	_, name = schema.SplitTable(name)
+	return strings.HasPrefix(name, "_nexus_")
+}
+
+var sqlTypeArgs = regexp.MustCompile(`\(([^)]*)\)`)
+
+// FieldTypeFromSQL maps a database column type to a schema field type.
+// It understands the type names produced by the postgres, mysql, sqlite and
+// mssql dialects plus common aliases. known is false if the type was not recognized.
+func FieldTypeFromSQL(sqlType string) (ft schema.FieldType, length, precision, scale int, known bool) {
+	upper := strings.ToUpper(strings.TrimSpace(sqlType))
+
+	var args []int
+	if m := sqlTypeArgs.FindStringSubmatch(upper); m != nil {
+		for _, part := range strings.Split(m[1], ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+				args = append(args, n)
+			}
+		}
+	}
+	base := strings.TrimSpace(sqlTypeArgs.ReplaceAllString(upper, ""))
+	base = strings.TrimSuffix(base, " UNSIGNED")
+
+	switch {
+	case base == "TINYINT" && len(args) == 1 && args[0] == 1:
+		return schema.FieldTypeBool, 0, 0, 0, true
+	case base == "BIGINT" || base == "INT8" || base == "BIGSERIAL":
+		return schema.FieldTypeBigInt, 0, 0, 0, true
+	case base == "SMALLINT" || base == "INT2" || base == "SMALLSERIAL":
+		return schema.FieldTypeSmallInt, 0, 0, 0, true
+	case base == "TINYINT":
+		return schema.FieldTypeTinyInt, 0, 0, 0, true
+	case base == "INT" || base == "INTEGER" || base == "INT4" || base == "MEDIUMINT" || base == "SERIAL":
+		return schema.FieldTypeInt, 0, 0, 0, true
+	case base == "BOOL" || base == "BOOLEAN" || base == "BIT":
+		return schema.FieldTypeBool, 0, 0, 0, true
+	case base == "UUID" || base == "UNIQUEIDENTIFIER" || (base == "CHAR" && len(args) == 1 && args[0] == 36):
+		return schema.FieldTypeUUID, 0, 0, 0, true
+	case (base == "NVARCHAR" || base == "VARCHAR") && strings.HasSuffix(upper, "(MAX)"):
+		return schema.FieldTypeText, 0, 0, 0, true
+	case base == "VARCHAR" || base == "CHARACTER VARYING" || base == "CHAR" ||
+		base == "CHARACTER" || base == "NVARCHAR" || base == "NCHAR":
+		if len(args) > 0 {
+			length = args[0]
+		}
+		return schema.FieldTypeString, length, 0, 0, true
+	case base == "TEXT" || base == "CLOB" || base == "TINYTEXT" ||
+		base == "MEDIUMTEXT" || base == "LONGTEXT" || base == "CITEXT" || base == "NTEXT":
+		return schema.FieldTypeText, 0, 0, 0, true
+	case base == "REAL" || base == "FLOAT" || base == "FLOAT4" || base == "FLOAT8" ||
+		base == "DOUBLE" || base == "DOUBLE PRECISION":
+		return schema.FieldTypeFloat, 0, 0, 0, true
+	case base == "MONEY":
+		return schema.FieldTypeMoney, 0, schema.MoneyPrecision, schema.MoneyScale, true
+	case base == "SMALLMONEY":
+		return schema.FieldTypeMoney, 0, 10, 4, true
+	case base == "NUMERIC" || base == "DECIMAL":
+		if len(args) > 0 {
+			precision = args[0]
+		}
+		if len(args) > 1 {
+			scale = args[1]
+		}
+		// Money columns are decimals of its precision
+		if precision == schema.MoneyPrecision && scale == schema.MoneyScale {
+			return schema.FieldTypeMoney, 0, precision, scale, true
+		}
+		return schema.FieldTypeDecimal, 0, precision, scale, true
+	case strings.HasPrefix(base, "TIMESTAMP") || strings.HasPrefix(base, "DATETIME") || base == "SMALLDATETIME":
+		return schema.FieldTypeDateTime, 0, 0, 0, true
+	case base == "DATE":
+		return schema.FieldTypeDate, 0, 0, 0, true
+	case strings.HasPrefix(base, "TIME"):
+		return schema.FieldTypeTime, 0, 0, 0, true
+	case base == "JSON" || base == "JSONB":
+		return schema.FieldTypeJSON, 0, 0, 0, true
+	case base == "BLOB" || base == "BYTEA" || base == "BINARY" || base == "VARBINARY" ||
+		base == "TINYBLOB" || base == "MEDIUMBLOB" || base == "LONGBLOB":
+		return schema.FieldTypeBytes, 0, 0, 0, true
+	default:
+		return schema.FieldTypeText, 0, 0, 0, false
+	}
+}
+
+var (
+	checkNoise       = strings.NewReplacer(" ", "", "(", "", ")", "", "[", "", "]", "", `"`, "", "`", "")
+	nonNegativeCheck = regexp.MustCompile(`(?i)^(?:CHECK)?(\w+)>=0$`)
+)
+
+var (
+	defaultCast = regexp.MustCompile(`::[\w\s."]+(\[\])?$`)
+	defaultCall = regexp.MustCompile(`^(\w+)(?:\(\s*\d*\s*\))?$`)
+)
+
+// applyIntrospectedDefault sets a field default from a database default expression.
+// It returns a warning if the default could not be represented.
+func applyIntrospectedDefault(f *schema.Field, def string) string {
+	def = strings.TrimSpace(def)
+	if def == "" || strings.EqualFold(def, "NULL") {
+		return ""
+	}
+
+	// Sequences back auto-increment columns
+	if strings.HasPrefix(strings.ToLower(def), "nextval(") {
+		f.AutoIncrement = true
+		return ""
+	}
+
+	expr, value, ok := parseDefault(def, f.Type)
+	if !ok {
+		return fmt.Sprintf("default expression %q skipped", def)
+	}
+	if s, ok := value.(string); ok && strings.ContainsAny(s, " \t\"") {
+		return fmt.Sprintf("default %s cannot be expressed in the schema DSL, skipped", def)
+	}
+	f.DefaultExpr, f.DefaultValue = expr, value
+	return ""
+}
+
+// parseDefault reads a default as a database reports it, for a column of
+// type ft: the portable expression of a function such as CURRENT_TIMESTAMP
+// or gen_random_uuid(), or the value of a literal. ok is false if it is
+// neither.
+func parseDefault(def string, ft schema.FieldType) (expr string, value interface{}, ok bool) {
+	def = unwrapParens(defaultCast.ReplaceAllString(unwrapParens(strings.TrimSpace(def)), ""))
+	if m := defaultCall.FindStringSubmatch(def); m != nil {
+		if expr, ok := schema.PortableDefaultExpr(m[1]); ok {
+			return expr, nil, true
+		}
+	}
+
+	switch lower := strings.ToLower(def); lower {
+	case "true", "false":
+		return "", lower == "true", true
+	}
+
+	if strings.HasPrefix(def, "N'") {
+		def = def[1:] // SQL Server Unicode literal
+	}
+	if len(def) >= 2 && def[0] == '\'' && def[len(def)-1] == '\'' {
+		return "", strings.ReplaceAll(def[1:len(def)-1], "''", "'"), true
+	}
+
+	if ft == schema.FieldTypeBool && (def == "0" || def == "1") {
+		return "", def == "1", true
+	}
+	if i, err := strconv.ParseInt(def, 10, 64); err == nil {
+		return "", i, true
+	}
+	if fl, err := strconv.ParseFloat(def, 64); err == nil {
+		return "", fl, true
+	}
+	return "", nil, false
+}
+
+// unwrapParens removes the parentheses SQL Server and MySQL put around
+// whole default expressions, as in ((0)) or (uuid()).
+func unwrapParens(def string) string {
+	for len(def) >= 2 && def[0] == '(' && def[len(def)-1] == ')' {
+		depth := 0
+		for i := 0; i < len(def)-1; i++ {
+			switch def[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				return def // The first parenthesis closes before the end
+			}
+		}
+		def = strings.TrimSpace(def[1 : len(def)-1])
+	}
+	return def
+}
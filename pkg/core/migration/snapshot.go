@@ -0,0 +1,263 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// SnapshotVersion is the current snapshot file format version.
+// Bump it whenever the serialized layout changes incompatibly.
+const SnapshotVersion = 1
+
+// DefaultSnapshotFile is the default location of the schema snapshot.
+const DefaultSnapshotFile = ".nexus.lock"
+
+// SchemaSnapshot is a serializable record of the schema as it was when the
+// last migration was generated. Diffing against it needs no database.
+type SchemaSnapshot struct {
+	Version   int                      `json:"version"`
+	Dialect   string                   `json:"dialect"`
+	Tables    map[string]*TableInfo    `json:"tables"`
+	Functions map[string]*FunctionInfo `json:"functions,omitempty"`
+}
+
+// NewSchemaSnapshot builds a snapshot of the schema as the given dialect would create it.
+func NewSchemaSnapshot(s *schema.Schema, dialect dialects.Dialect) *SchemaSnapshot {
+	db := SnapshotFromSchema(s, dialect)
+	snapshot := &SchemaSnapshot{
+		Version: SnapshotVersion,
+		Dialect: dialect.Name(),
+		Tables:  db.Tables,
+	}
+	if len(db.Functions) > 0 {
+		snapshot.Functions = db.Functions
+	}
+	return snapshot
+}
+
+// Database returns the snapshot as a DatabaseSnapshot suitable for Diff.
+func (s *SchemaSnapshot) Database() *DatabaseSnapshot {
+	snapshot := NewDatabaseSnapshot()
+	for name, table := range s.Tables {
+		snapshot.Tables[name] = table
+	}
+	for name, f := range s.Functions {
+		snapshot.Functions[name] = f
+	}
+	return snapshot
+}
+
+// SnapshotFromSchema converts a schema into the DatabaseSnapshot the
+// dialect would produce after applying it to an empty database.
+func SnapshotFromSchema(s *schema.Schema, dialect dialects.Dialect) *DatabaseSnapshot {
+	snapshot := NewDatabaseSnapshot()
+
+	_, comments := dialect.(dialects.Commenter)
+	_, collations := dialect.(dialects.Collator)
+	_, tableCollations := dialect.(dialects.TableCollator)
+	for _, model := range s.GetModels() {
+		table := &TableInfo{
+			Name:    model.Table(),
+			Columns: make(map[string]*ColumnInfo),
+			Indexes: make(map[string]*IndexInfo),
+		}
+		if tableCollations {
+			table.Charset, table.Collation = model.CharacterSet, model.Collation
+		}
+
+		for i, field := range model.GetFields() {
+			col := &ColumnInfo{
+				Name:         field.Name,
+				Position:     i,
+				Type:         dialect.TypeMapping(field),
+				Nullable:     field.Nullable,
+				IsPrimaryKey: field.IsPrimaryKey,
+				IsUnique:     field.IsUnique,
+				AutoInc:      field.AutoIncrement,
+				Unsigned:     field.IsUnsigned,
+			}
+			if comments {
+				col.Comment = field.Description
+			}
+			if collations && field.Collatable() {
+				// Columns only report what differs from the defaults of their table
+				charset, collation := field.ColumnCollation()
+				if !strings.EqualFold(charset, table.Charset) {
+					col.Charset = charset
+				}
+				if !strings.EqualFold(collation, table.Collation) {
+					col.Collation = collation
+				}
+			}
+			if field.DefaultExpr != "" {
+				col.Default = field.DefaultExpr
+			} else if field.DefaultValue != nil {
+				col.Default = fmt.Sprintf("%v", field.DefaultValue)
+			}
+			table.Columns[field.Name] = col
+		}
+
+		for _, idx := range model.Indexes {
+			table.Indexes[idx.Name] = &IndexInfo{
+				Name:    idx.Name,
+				Unique:  idx.Unique,
+				Columns: idx.Fields,
+			}
+		}
+
+		if _, ok := dialect.(dialects.RowSecurity); ok {
+			table.RowSecurity = rowSecurityInfo(model)
+		}
+		if comments {
+			table.Comment = model.Description
+		}
+
+		snapshot.Tables[model.Table()] = table
+	}
+
+	if _, ok := dialect.(dialects.FunctionCreator); ok {
+		for _, f := range s.GetFunctions() {
+			snapshot.Functions[f.Name] = functionInfo(f)
+		}
+	}
+
+	return snapshot
+}
+
+// rowSecurityInfo returns the row-level security of a model as PostgreSQL
+// reports it, or nil if it is off without policies.
+func rowSecurityInfo(model *schema.Model) *RowSecurityInfo {
+	if !model.RowSecurity && len(model.Policies) == 0 {
+		return nil
+	}
+
+	info := &RowSecurityInfo{
+		Enabled:  model.RowSecurity,
+		Forced:   model.ForceRowSecurity,
+		Policies: make(map[string]*PolicyInfo),
+	}
+	for _, p := range model.Policies {
+		policy := &PolicyInfo{
+			Name:    p.Name,
+			Command: strings.ToUpper(p.Command),
+			Roles:   p.Roles,
+			Using:   p.Using,
+			Check:   p.Check,
+		}
+		if policy.Command == "" {
+			policy.Command = schema.PolicyAll
+		}
+		// INSERT policies only check new rows
+		if policy.Command == schema.PolicyInsert {
+			if policy.Check == "" {
+				policy.Check = policy.Using
+			}
+			policy.Using = ""
+		}
+		info.Policies[p.Name] = policy
+	}
+	return info
+}
+
+// functionInfo records a schema function as a snapshot would.
+func functionInfo(f *schema.Function) *FunctionInfo {
+	language := f.Language
+	if language == "" {
+		language = "sql"
+	}
+	return &FunctionInfo{
+		Name:      f.Name,
+		Args:      f.Signature(),
+		Returns:   f.Returns,
+		Body:      strings.TrimSpace(f.Body),
+		Language:  language,
+		Procedure: f.Procedure,
+		Immutable: f.Immutable,
+	}
+}
+
+// schemaFunction converts the function back for the dialect to create.
+func (f *FunctionInfo) schemaFunction() *schema.Function {
+	fn := &schema.Function{
+		Name:      f.Name,
+		Returns:   f.Returns,
+		Body:      f.Body,
+		Language:  f.Language,
+		Procedure: f.Procedure,
+		Immutable: f.Immutable,
+	}
+	for _, arg := range splitArgs(f.Args) {
+		name, typ, found := strings.Cut(arg, " ")
+		if !found {
+			name, typ = "", arg
+		}
+		fn.Args = append(fn.Args, schema.Param(name, typ))
+	}
+	return fn
+}
+
+// splitArgs splits an argument list at the commas outside parentheses,
+// such as the one in numeric(10, 2).
+func splitArgs(args string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range args {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(args[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(args[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// schemaPolicy converts the policy back for the dialect to create.
+func (p *PolicyInfo) schemaPolicy() *schema.Policy {
+	return &schema.Policy{Name: p.Name, Command: p.Command, Roles: p.Roles, Using: p.Using, Check: p.Check}
+}
+
+// SaveSnapshot writes the snapshot to path as indented JSON.
+func SaveSnapshot(path string, snapshot *SchemaSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadSnapshot reads a snapshot file written by SaveSnapshot.
+func LoadSnapshot(path string) (*SchemaSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot SchemaSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("parsing snapshot %s: %w", path, err)
+	}
+
+	if snapshot.Version == 0 || snapshot.Version > SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d in %s (this nexus supports up to %d)",
+			snapshot.Version, path, SnapshotVersion)
+	}
+	if snapshot.Tables == nil {
+		snapshot.Tables = make(map[string]*TableInfo)
+	}
+
+	return &snapshot, nil
+}
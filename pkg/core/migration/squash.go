@@ -2,6 +2,7 @@
 package migration
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -9,6 +10,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
 )
 
 // SquashOptions configures the squash operation.
@@ -16,6 +19,7 @@ type SquashOptions struct {
 	FromID     string // Optional: start from this migration (inclusive)
 	ToID       string // Optional: end at this migration (inclusive)
 	OutputName string // Name for the squashed migration
+	Dialect    string // Optional: lets ALTER TABLE statements merge where the dialect allows
 }
 
 // SquashResult contains the squashed migration and metadata.
@@ -24,6 +28,7 @@ type SquashResult struct {
 	OriginalCount  int
 	OptimizedCount int // Number of statements after optimization
 	RemovedCount   int // Number of redundant statements removed
+	MergedCount    int // Number of statements folded into others
 	OriginalIDs    []string
 }
 
@@ -49,6 +54,12 @@ func SquashMigrations(migrations []*Migration, opts SquashOptions) (*SquashResul
 	if len(filtered) == 1 {
 		return nil, fmt.Errorf("need at least 2 migrations to squash")
 	}
+	// The squashed migration would run in every environment
+	for _, m := range filtered {
+		if len(m.Environments) > 0 {
+			return nil, fmt.Errorf("migration %s_%s runs only in %s; squash a range without it", m.ID, m.Name, strings.Join(m.Environments, ", "))
+		}
+	}
 
 	// Collect all UP statements
 	var allUpStatements []string
@@ -59,11 +70,11 @@ func SquashMigrations(migrations []*Migration, opts SquashOptions) (*SquashResul
 		originalIDs = append(originalIDs, m.ID)
 
 		// Split SQL into individual statements
-		upStmts := splitStatements(m.UpSQL)
+		upStmts := SplitDialectStatements(m.UpSQL, opts.Dialect)
 		allUpStatements = append(allUpStatements, upStmts...)
 
 		// For DOWN, we need to reverse order (LIFO)
-		downStmts := splitStatements(m.DownSQL)
+		downStmts := SplitDialectStatements(m.DownSQL, opts.Dialect)
 		allDownStatements = append(downStmts, allDownStatements...)
 	}
 
@@ -75,12 +86,16 @@ func SquashMigrations(migrations []*Migration, opts SquashOptions) (*SquashResul
 		return nil, fmt.Errorf("all statements cancelled out - nothing to squash")
 	}
 
+	// Fold column changes into CREATE TABLE and merge ALTER TABLE statements
+	mergedUp := mergeStatements(optimizedUp, opts.Dialect)
+	mergedDown := mergeStatements(optimizedDown, opts.Dialect)
+
 	// Generate new migration
 	now := time.Now()
 	id := now.Format("20060102_150405")
 
-	upSQL := strings.Join(optimizedUp, ";\n\n") + ";"
-	downSQL := strings.Join(optimizedDown, ";\n\n") + ";"
+	upSQL := strings.Join(mergedUp, ";\n\n") + ";"
+	downSQL := strings.Join(mergedDown, ";\n\n") + ";"
 
 	hash := sha256.Sum256([]byte(upSQL))
 	checksum := hex.EncodeToString(hash[:])
@@ -99,12 +114,95 @@ func SquashMigrations(migrations []*Migration, opts SquashOptions) (*SquashResul
 			Checksum: checksum,
 		},
 		OriginalCount:  len(filtered),
-		OptimizedCount: len(optimizedUp),
+		OptimizedCount: len(mergedUp),
 		RemovedCount:   len(allUpStatements) - len(optimizedUp),
+		MergedCount:    len(optimizedUp) - len(mergedUp),
 		OriginalIDs:    originalIDs,
 	}, nil
 }
 
+// SquashVerification is how the schemas of a squashed migration and of the
+// migrations it replaces differ.
+type SquashVerification struct {
+	Up   []SchemaChange // From the schema after the originals to that after the squash
+	Down []SchemaChange // The same after rolling them back
+}
+
+// Equivalent reports whether the squashed migration leaves the same
+// schema as the originals, applied and rolled back.
+func (v *SquashVerification) Equivalent() bool {
+	return len(v.Up) == 0 && len(v.Down) == 0
+}
+
+// VerifySquash applies the migrations that result replaces to before, and
+// the squashed migration to after, two empty databases of one dialect, and
+// compares the schemas they end up with, then rolls both back and compares
+// them again. The migrations of all older than those squashed are applied
+// to both first.
+func VerifySquash(ctx context.Context, before, after *dialects.Connection, all []*Migration, result *SquashResult) (*SquashVerification, error) {
+	squashed := make(map[string]bool)
+	for _, id := range result.OriginalIDs {
+		squashed[id] = true
+	}
+	var base, originals []*Migration
+	for _, m := range all {
+		switch {
+		case squashed[m.ID]:
+			originals = append(originals, m)
+		case m.ID < result.OriginalIDs[0]:
+			base = append(base, m)
+		}
+	}
+
+	beforeEngine, from, err := schemaAfter(ctx, before, base, originals)
+	if err != nil {
+		return nil, fmt.Errorf("applying the original migrations: %w", err)
+	}
+	afterEngine, to, err := schemaAfter(ctx, after, base, []*Migration{result.Migration})
+	if err != nil {
+		return nil, fmt.Errorf("applying the squashed migration: %w", err)
+	}
+	verification := &SquashVerification{Up: DiffSnapshots(from, to)}
+
+	if _, err := beforeEngine.DownN(ctx, len(originals)); err != nil {
+		return nil, fmt.Errorf("rolling back the original migrations: %w", err)
+	}
+	if _, err := afterEngine.DownN(ctx, 1); err != nil {
+		return nil, fmt.Errorf("rolling back the squashed migration: %w", err)
+	}
+	if from, err = beforeEngine.CurrentSchema(ctx); err != nil {
+		return nil, err
+	}
+	if to, err = afterEngine.CurrentSchema(ctx); err != nil {
+		return nil, err
+	}
+	verification.Down = DiffSnapshots(from, to)
+	return verification, nil
+}
+
+// schemaAfter applies the base migrations and then migrations to conn, and
+// returns the engine and the schema of the database.
+func schemaAfter(ctx context.Context, conn *dialects.Connection, base, migrations []*Migration) (*Engine, *SchemaSnapshot, error) {
+	engine := NewEngine(conn)
+	if err := engine.Init(ctx); err != nil {
+		return nil, nil, err
+	}
+	for _, m := range base {
+		engine.Add(m)
+	}
+	for _, m := range migrations {
+		engine.Add(m)
+	}
+	if _, err := engine.Up(ctx); err != nil {
+		return nil, nil, err
+	}
+	snapshot, err := engine.CurrentSchema(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return engine, snapshot, nil
+}
+
 // filterMigrationRange filters migrations to only include those in the specified range.
 func filterMigrationRange(migrations []*Migration, fromID, toID string) []*Migration {
 	if fromID == "" && toID == "" {
@@ -131,59 +229,6 @@ func filterMigrationRange(migrations []*Migration, fromID, toID string) []*Migra
 	return result
 }
 
-// splitStatements splits SQL into individual statements.
-func splitStatements(sql string) []string {
-	// Remove comments
-	sql = removeComments(sql)
-
-	// Split by semicolon, but be careful with strings
-	var statements []string
-	var current strings.Builder
-	inString := false
-	stringChar := rune(0)
-
-	for _, r := range sql {
-		switch {
-		case r == '\'' || r == '"':
-			if !inString {
-				inString = true
-				stringChar = r
-			} else if r == stringChar {
-				inString = false
-			}
-			current.WriteRune(r)
-		case r == ';' && !inString:
-			stmt := strings.TrimSpace(current.String())
-			if stmt != "" {
-				statements = append(statements, stmt)
-			}
-			current.Reset()
-		default:
-			current.WriteRune(r)
-		}
-	}
-
-	// Don't forget the last statement
-	if stmt := strings.TrimSpace(current.String()); stmt != "" {
-		statements = append(statements, stmt)
-	}
-
-	return statements
-}
-
-// removeComments removes SQL comments from the input.
-func removeComments(sql string) string {
-	// Remove single-line comments
-	re := regexp.MustCompile(`--[^\n]*`)
-	sql = re.ReplaceAllString(sql, "")
-
-	// Remove multi-line comments
-	re = regexp.MustCompile(`/\*[\s\S]*?\*/`)
-	sql = re.ReplaceAllString(sql, "")
-
-	return sql
-}
-
 // optimizeStatements removes redundant operations.
 func optimizeStatements(statements []string) []string {
 	// Track table operations
@@ -240,6 +285,23 @@ func optimizeStatements(statements []string) []string {
 		}
 	}
 
+	// Remove changes to tables dropped later: between their CREATE and
+	// DROP, or before the DROP of a table created before these statements
+	for i, stmt := range statements {
+		table := extractStatementTable(strings.ToUpper(stmt))
+		if table == "" {
+			continue
+		}
+		dropIdx, dropped := tableDrops[table]
+		if !dropped || dropIdx < i {
+			continue
+		}
+		if createIdx, created := tableCreates[table]; created && createIdx > i {
+			continue
+		}
+		skip[i] = true
+	}
+
 	// Build result, skipping redundant statements
 	var result []string
 	for i, stmt := range statements {
@@ -291,6 +353,18 @@ func extractDropColumn(stmt string) string {
 	return ""
 }
 
+// extractStatementTable extracts the table name from ALTER TABLE and
+// CREATE INDEX statements.
+func extractStatementTable(stmt string) string {
+	if matches := lintAlterTable.FindStringSubmatch(stmt); matches != nil {
+		return strings.ToUpper(lintName(matches[1]))
+	}
+	if matches := lintCreateIndex.FindStringSubmatch(stmt); matches != nil {
+		return strings.ToUpper(lintName(matches[2]))
+	}
+	return ""
+}
+
 // extractCreateIndex extracts index name from CREATE INDEX statement.
 func extractCreateIndex(stmt string) string {
 	re := regexp.MustCompile(`CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:IF\s+NOT\s+EXISTS\s+)?["'\x60]?(\w+)["'\x60]?`)
@@ -310,3 +384,278 @@ func extractDropIndex(stmt string) string {
 	}
 	return ""
 }
+
+var (
+	squashCreateTable = regexp.MustCompile(`(?is)^CREATE\s+(?:TEMP(?:ORARY)?\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + lintIdent + `\s*\(`)
+	squashIdent       = regexp.MustCompile(`^` + lintIdent)
+)
+
+// tableDefinition is a CREATE TABLE statement split into its column and
+// constraint definitions, so that later column changes fold into it.
+type tableDefinition struct {
+	index     int      // Index of the statement in the merged statements
+	head      string   // Up to and including the opening parenthesis
+	defs      []string // Column and constraint definitions
+	tail      string   // From the closing parenthesis on
+	multiline bool     // Definitions are written a line each
+	indent    string   // Indentation of the definitions
+	later     []string // Statements kept since that refer to the table
+}
+
+// parseCreateTable splits a CREATE TABLE statement with a list of
+// definitions, reporting false for other forms such as CREATE TABLE AS.
+func parseCreateTable(stmt string) (*tableDefinition, bool) {
+	loc := squashCreateTable.FindStringIndex(stmt)
+	if loc == nil {
+		return nil, false
+	}
+	end := closingParen(stmt, loc[1])
+	if end < 0 {
+		return nil, false
+	}
+	body := stmt[loc[1]:end]
+	if strings.TrimSpace(body) == "" {
+		return nil, false
+	}
+	def := &tableDefinition{
+		head:      stmt[:loc[1]],
+		defs:      splitTopLevel(body),
+		tail:      stmt[end:],
+		multiline: strings.Contains(body, "\n"),
+		indent:    "  ",
+	}
+	for _, line := range strings.Split(body, "\n")[1:] {
+		if strings.TrimSpace(line) != "" {
+			def.indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			break
+		}
+	}
+	return def, true
+}
+
+// String returns the CREATE TABLE statement, laid out as it was written.
+func (d *tableDefinition) String() string {
+	if d.multiline {
+		return d.head + "\n" + d.indent + strings.Join(d.defs, ",\n"+d.indent) + "\n" + d.tail
+	}
+	return d.head + strings.Join(d.defs, ", ") + d.tail
+}
+
+// column returns the index of the definition of the column name, or -1.
+func (d *tableDefinition) column(name string) int {
+	for i, def := range d.defs {
+		if col := definitionColumn(def); col != "" && col == lintName(name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// fold applies action, of an ALTER TABLE statement on the table, to the
+// definitions, reporting whether it could: columns added are appended,
+// and columns dropped are removed unless something else refers to them.
+func (d *tableDefinition) fold(action string) bool {
+	words := strings.Fields(action)
+	if len(words) < 2 {
+		return false
+	}
+	switch strings.ToUpper(words[0]) {
+	case "ADD":
+		def := strings.TrimSpace(action[len(words[0]):])
+		if strings.EqualFold(words[1], "COLUMN") {
+			def = strings.TrimSpace(def[len(words[1]):])
+		}
+		col := definitionColumn(def)
+		if col == "" || strings.HasPrefix(strings.ToUpper(def), "IF NOT EXISTS") || d.column(col) >= 0 {
+			return false
+		}
+		d.defs = append(d.defs, def)
+		return true
+	case "DROP":
+		rest := words[1:]
+		if strings.EqualFold(rest[0], "COLUMN") {
+			rest = rest[1:]
+		}
+		if len(rest) != 1 {
+			return false
+		}
+		col := lintName(rest[0])
+		i := d.column(col)
+		if i < 0 {
+			return false
+		}
+		for j, def := range d.defs {
+			if j != i && mentionsIdentifier(def, col) {
+				return false
+			}
+		}
+		for _, stmt := range d.later {
+			if mentionsIdentifier(stmt, col) {
+				return false
+			}
+		}
+		d.defs = append(d.defs[:i], d.defs[i+1:]...)
+		return true
+	}
+	return false
+}
+
+// mergeStatements folds the columns added to tables created earlier in
+// statements into their CREATE TABLE, and removes the columns dropped
+// later from it. With a dialect that takes several changes in one ALTER
+// TABLE, consecutive ALTER TABLE statements on a table are merged too.
+func mergeStatements(statements []string, dialect string) []string {
+	result := make([]string, 0, len(statements))
+	created := make(map[string]*tableDefinition) // table name -> definition
+
+	for _, stmt := range statements {
+		stmtUpper := strings.ToUpper(stmt)
+		target, creates, folded := "", false, false
+
+		if table := extractCreateTable(stmtUpper); table != "" {
+			target, creates = table, true
+			delete(created, table)
+		} else if table := extractDropTable(stmtUpper); table != "" {
+			delete(created, table)
+		} else if matches := lintAlterTable.FindStringSubmatch(stmt); matches != nil {
+			table := strings.ToUpper(lintName(matches[1]))
+			if def := created[table]; def != nil {
+				if folded = def.fold(matches[2]); folded {
+					target = table
+					result[def.index] = def.String()
+				} else if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(matches[2])), "RENAME") {
+					delete(created, table)
+				}
+			}
+		}
+
+		// Keep track of what refers to the other tables, so that columns in
+		// use aren't dropped, and stop folding into tables whose rows are
+		// changed, as moving columns would change what the rows hold
+		for table, def := range created {
+			if table == target || !mentionsIdentifier(stmt, table) {
+				continue
+			}
+			if isSchemaStatement(stmtUpper) {
+				def.later = append(def.later, stmt)
+			} else {
+				delete(created, table)
+			}
+		}
+
+		if folded {
+			continue
+		}
+		if creates {
+			if def, ok := parseCreateTable(stmt); ok {
+				def.index = len(result)
+				created[target] = def
+			}
+		}
+		result = append(result, stmt)
+	}
+
+	if mergesAlterTable(dialect) {
+		result = mergeAlterTable(result)
+	}
+	return result
+}
+
+// mergeAlterTable merges consecutive ALTER TABLE statements on a table
+// into one, as in ALTER TABLE users ADD COLUMN a TEXT, DROP COLUMN b.
+func mergeAlterTable(statements []string) []string {
+	var result []string
+	lastTable := ""
+	for _, stmt := range statements {
+		matches := lintAlterTable.FindStringSubmatch(stmt)
+		if matches == nil || !isMergeableAction(matches[2]) {
+			result = append(result, stmt)
+			lastTable = ""
+			continue
+		}
+		table := strings.ToUpper(lintName(matches[1]))
+		if table == lastTable {
+			result[len(result)-1] += ", " + strings.TrimSpace(matches[2])
+			continue
+		}
+		result = append(result, stmt)
+		lastTable = table
+	}
+	return result
+}
+
+// mergesAlterTable reports whether dialect takes a list of changes in one
+// ALTER TABLE statement. SQLite doesn't, and SQL Server only for some.
+func mergesAlterTable(dialect string) bool {
+	switch strings.ToLower(dialect) {
+	case "postgres", "postgresql", "mysql":
+		return true
+	}
+	return false
+}
+
+// isMergeableAction reports whether an ALTER TABLE action can be listed
+// with others: renames can't.
+func isMergeableAction(action string) bool {
+	words := strings.Fields(strings.ToUpper(action))
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "ADD", "DROP", "ALTER", "MODIFY", "CHANGE":
+		return true
+	}
+	return false
+}
+
+// isSchemaStatement reports whether stmt, in upper case, changes the
+// schema rather than the rows.
+func isSchemaStatement(stmt string) bool {
+	for _, prefix := range []string{"CREATE", "ALTER", "DROP", "COMMENT"} {
+		if strings.HasPrefix(stmt, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// definitionColumn returns the column a definition of CREATE TABLE
+// defines, lowercased, or "" for table constraints.
+func definitionColumn(def string) string {
+	if lintConstraint.MatchString(def) {
+		return ""
+	}
+	return lintName(squashIdent.FindString(def))
+}
+
+// mentionsIdentifier reports whether stmt refers to name as a word.
+func mentionsIdentifier(stmt, name string) bool {
+	re := regexp.MustCompile(`(?i)(^|[^\w])` + regexp.QuoteMeta(name) + `($|[^\w])`)
+	return re.MatchString(stmt)
+}
+
+// closingParen returns the index of the parenthesis closing the one
+// before start, skipping quoted text, or -1.
+func closingParen(s string, start int) int {
+	depth := 1
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,217 @@
+package migration
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// copyFromStdin matches a COPY statement whose rows follow it, as written
+// by pg_dump, up to a line holding \.
+var copyFromStdin = regexp.MustCompile(`(?is)^COPY\s+.+\s+FROM\s+STDIN\b`)
+
+// delimiterDirective matches the DELIMITER command of the mysql client,
+// which changes what ends a statement, as around stored routines.
+var delimiterDirective = regexp.MustCompile(`(?i)^[ \t]*DELIMITER[ \t]+(\S+)[ \t]*(?:\r?\n|$)`)
+
+// SplitStatements splits SQL into statements without comments. Semicolons
+// in quotes, comments, dollar-quoted bodies and the BEGIN ... END block of
+// a trigger or routine do not end a statement. DELIMITER lines change what
+// ends statements, as with the mysql client, and the rows of a COPY ...
+// FROM stdin, up to \., stay with the statement.
+func SplitStatements(sql string) []string {
+	return SplitDialectStatements(sql, "")
+}
+
+// SplitDialectStatements splits SQL as SplitStatements does, reading the
+// strings and comments of dialect: MySQL strings take backslash escapes
+// and # starts a comment.
+func SplitDialectStatements(sql, dialect string) []string {
+	mysql := strings.EqualFold(dialect, "mysql")
+	delimiter := ";"
+	var statements []string
+	var current strings.Builder
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		lineStart := i == 0 || sql[i-1] == '\n'
+		switch {
+		case lineStart && delimiterDirective.MatchString(sql[i:]):
+			m := delimiterDirective.FindStringSubmatch(sql[i:])
+			flush()
+			delimiter = m[1]
+			i += len(m[0]) - 1
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), mysql && c == '#':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end - 1 // Keep the newline
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == '\'' || c == '"' || c == '`':
+			escapes := mysql && c != '`' || c == '\'' && escapeString(sql, i)
+			end := quoteEnd(sql, i, escapes)
+			current.WriteString(sql[i:end])
+			i = end - 1
+		case delimiter != ";" && strings.HasPrefix(sql[i:], delimiter):
+			flush()
+			i += len(delimiter) - 1
+		case c == '$' && dollarTag(sql[i:]) != "":
+			tag := dollarTag(sql[i:])
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				end = len(sql) - i - len(tag)
+			} else {
+				end += len(tag)
+			}
+			current.WriteString(sql[i : i+len(tag)+end])
+			i += len(tag) + end - 1
+		case c == ';' && delimiter == ";":
+			if inBlock(current.String()) {
+				current.WriteByte(c)
+				continue
+			}
+			if copyFromStdin.MatchString(strings.TrimSpace(current.String())) {
+				current.WriteString(";\n")
+				i = copyData(sql, i+1, &current) - 1
+			}
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// quoteEnd returns the index after the string, quoted identifier or
+// unterminated rest of sql starting at start. With escapes, a backslash
+// escapes the character after it.
+func quoteEnd(sql string, start int, escapes bool) int {
+	quote := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if escapes {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// escapeString reports whether the string at start is a PostgreSQL escape
+// string, E'...', which takes backslash escapes.
+func escapeString(sql string, start int) bool {
+	if start == 0 || (sql[start-1] != 'E' && sql[start-1] != 'e') {
+		return false
+	}
+	return start == 1 || !isWordByte(sql[start-2])
+}
+
+// copyData writes the rows of a COPY ... FROM stdin that follow its
+// statement, from start, up to and including the line holding \., and
+// returns the index after them.
+func copyData(sql string, start int, w *strings.Builder) int {
+	// The rows start on the line after the statement
+	if nl := strings.IndexByte(sql[start:], '\n'); nl >= 0 && strings.TrimSpace(sql[start:start+nl]) == "" {
+		start += nl + 1
+	}
+	for i := start; i < len(sql); {
+		end := strings.IndexByte(sql[i:], '\n')
+		if end < 0 {
+			end = len(sql) - i
+		}
+		line := sql[i : i+end]
+		w.WriteString(line)
+		i += end + 1
+		if strings.TrimRight(line, "\r") == `\.` {
+			return i
+		}
+		w.WriteByte('\n')
+	}
+	return len(sql)
+}
+
+// dollarTag returns the PostgreSQL dollar quote ($$ or $tag$) s starts with.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch c := rune(s[i]); {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || unicode.IsLetter(c) || (i > 1 && unicode.IsDigit(c)):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// blockKinds are the objects whose CREATE statement may hold a BEGIN ...
+// END block of statements.
+var blockKinds = map[string]bool{"TRIGGER": true, "PROCEDURE": true, "FUNCTION": true, "EVENT": true}
+
+// inBlock reports whether stmt creates a trigger or routine whose BEGIN ...
+// END block has not been closed yet. CASE ... END counts as a block too,
+// while END IF, END LOOP, END WHILE and END REPEAT close other statements.
+func inBlock(stmt string) bool {
+	// Semicolons stay as words, so that END; WHILE is not END WHILE
+	words := strings.FieldsFunc(strings.ReplaceAll(strings.ToUpper(stmt), ";", " ; "), func(r rune) bool {
+		return !(r == ';' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+	routine := false
+	for _, word := range words[1:min(len(words), 8)] {
+		if blockKinds[word] {
+			routine = true
+			break
+		}
+	}
+	if !routine {
+		return false
+	}
+
+	depth := 0
+	for i, word := range words {
+		switch word {
+		case "BEGIN", "CASE":
+			if word == "CASE" && i > 0 && words[i-1] == "END" {
+				continue // END CASE, counted by its END
+			}
+			depth++
+		case "END":
+			if i+1 < len(words) {
+				switch words[i+1] {
+				case "IF", "LOOP", "WHILE", "REPEAT":
+					continue
+				}
+			}
+			depth--
+		}
+	}
+	return depth > 0
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
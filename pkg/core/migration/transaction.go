@@ -0,0 +1,69 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// nonTransactional matches statements that can't run in a transaction,
+// such as CREATE INDEX CONCURRENTLY, or that manage one themselves.
+var nonTransactional = regexp.MustCompile(`(?is)^(?:\s*(?:VACUUM|BEGIN\s*$|BEGIN\s+(?:TRANSACTION|WORK|DEFERRED|IMMEDIATE|EXCLUSIVE)|START\s+TRANSACTION|COMMIT|ROLLBACK|END\s*$|(?:CREATE|DROP)\s+DATABASE|ALTER\s+SYSTEM)\b|.*\bCONCURRENTLY\b)`)
+
+// migrationTx runs a migration and records it in the history in one
+// transaction, so that a migration failing halfway leaves nothing behind,
+// or on the connection when the migration can't run in a transaction.
+type migrationTx struct {
+	conn *dialects.Connection
+	tx   *dialects.Tx // nil outside a transaction
+}
+
+// begin starts the transaction m runs in, with the statements of sql. It
+// runs on the connection instead when m has NoTransaction, when the
+// dialect commits DDL implicitly (MySQL) or when a statement can't run in
+// a transaction.
+func (e *Engine) begin(ctx context.Context, m *Migration, sql string) (*migrationTx, error) {
+	t := &migrationTx{conn: e.conn}
+	if m.NoTransaction || e.conn.Dialect.Name() == "mysql" {
+		return t, nil
+	}
+	for _, stmt := range SplitDialectStatements(sql, e.conn.Dialect.Name()) {
+		if nonTransactional.MatchString(stmt) {
+			return t, nil
+		}
+	}
+	tx, err := e.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.tx = tx
+	return t, nil
+}
+
+// Exec runs query in the transaction, if any.
+func (t *migrationTx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if t.tx != nil {
+		return t.tx.Exec(ctx, query, args...)
+	}
+	return t.conn.Exec(ctx, query, args...)
+}
+
+// commit commits the transaction, if any.
+func (t *migrationTx) commit() error {
+	if t.tx == nil {
+		return nil
+	}
+	err := t.tx.Commit()
+	t.tx = nil
+	return err
+}
+
+// rollback rolls back the transaction unless it was committed.
+func (t *migrationTx) rollback() {
+	if t.tx != nil {
+		t.tx.Rollback()
+		t.tx = nil
+	}
+}
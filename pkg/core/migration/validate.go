@@ -2,6 +2,7 @@
 package migration
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -14,12 +15,32 @@ const (
 	SeverityWarning                           // Non-fatal but concerning
 )
 
+// String returns "error" or "warning".
+func (s ValidationSeverity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// ParseSeverity parses "error" or "warning".
+func ParseSeverity(s string) (ValidationSeverity, error) {
+	switch strings.ToLower(s) {
+	case "error":
+		return SeverityError, nil
+	case "warning":
+		return SeverityWarning, nil
+	}
+	return 0, fmt.Errorf("unknown severity %q (expected error or warning)", s)
+}
+
 // ValidationIssue represents a single validation finding.
 type ValidationIssue struct {
 	Severity   ValidationSeverity
 	Message    string
 	Line       int    // Optional line number
 	Suggestion string // Optional fix suggestion
+	Rule       string // Zero-downtime rule, e.g. RuleTypeChange (empty for other checks)
 }
 
 // ValidationResult contains all validation findings for a migration.
@@ -71,8 +92,43 @@ func (r *ValidationResult) Warnings() []ValidationIssue {
 	return warnings
 }
 
-// Validate checks a migration for issues.
+// Fails reports whether the result has issues at least as severe as
+// failOn, e.g. any issue for SeverityWarning.
+func (r *ValidationResult) Fails(failOn ValidationSeverity) bool {
+	for _, issue := range r.Issues {
+		if issue.Severity <= failOn {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateOptions configures Validate.
+type ValidateOptions struct {
+	// Dialect ("postgres", "mysql", "sqlite" or "libsql") tailors the zero-downtime
+	// rules and their advice. Empty gives generic advice.
+	Dialect string
+	// ZeroDowntime reports operations that lock tables for long or break
+	// app instances still running during a deploy.
+	ZeroDowntime bool
+	// Environments are the known environments. A migration gated to
+	// another, such as a misspelled one, is an error, as it would never
+	// run. Empty skips the check.
+	Environments []string
+}
+
+// DefaultValidateOptions returns the default validate options.
+func DefaultValidateOptions() ValidateOptions {
+	return ValidateOptions{ZeroDowntime: true}
+}
+
+// Validate checks a migration for issues with the default options.
 func Validate(m *Migration) *ValidationResult {
+	return ValidateWithOptions(m, DefaultValidateOptions())
+}
+
+// ValidateWithOptions checks a migration for issues.
+func ValidateWithOptions(m *Migration, opts ValidateOptions) *ValidationResult {
 	result := &ValidationResult{
 		MigrationID: m.ID,
 		Valid:       true,
@@ -81,6 +137,11 @@ func Validate(m *Migration) *ValidationResult {
 	// Validate UP SQL
 	upIssues := ValidateSQL(m.UpSQL, "UP")
 	result.Issues = append(result.Issues, upIssues...)
+	if opts.ZeroDowntime {
+		result.Issues = append(result.Issues, lintZeroDowntime(m.UpSQL, opts.Dialect)...)
+	}
+
+	result.Issues = append(result.Issues, lintEnvironments(m, opts.Environments)...)
 
 	// Validate DOWN SQL (less strict - can be empty for irreversible migrations)
 	if m.DownSQL != "" {
@@ -94,6 +155,29 @@ func Validate(m *Migration) *ValidationResult {
 	return result
 }
 
+// lintEnvironments reports the environments m is gated to that aren't
+// among known.
+func lintEnvironments(m *Migration, known []string) []ValidationIssue {
+	if len(known) == 0 {
+		return nil
+	}
+	var issues []ValidationIssue
+	for _, env := range m.Environments {
+		found := false
+		for _, k := range known {
+			found = found || strings.EqualFold(env, k)
+		}
+		if !found {
+			issues = append(issues, ValidationIssue{
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("env: %s is not a known environment, so the migration never runs there", env),
+				Suggestion: "Use one of " + strings.Join(known, ", "),
+			})
+		}
+	}
+	return issues
+}
+
 // ValidateSQL checks raw SQL for issues.
 func ValidateSQL(sql string, section string) []ValidationIssue {
 	var issues []ValidationIssue
@@ -274,10 +358,10 @@ func hasWhereClause(sql, stmtType string) bool {
 }
 
 // ValidateMigrations validates multiple migrations.
-func ValidateMigrations(migrations []*Migration) []*ValidationResult {
+func ValidateMigrations(migrations []*Migration, opts ValidateOptions) []*ValidationResult {
 	var results []*ValidationResult
 	for _, m := range migrations {
-		results = append(results, Validate(m))
+		results = append(results, ValidateWithOptions(m, opts))
 	}
 	return results
 }
@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// VerifyResult is how a database differs from its migrations and schema,
+// as found by Verify.
+type VerifyResult struct {
+	// Baseline is the newest applied migration, whose recorded schema the
+	// database was compared with; empty if none was recorded.
+	Baseline string
+	// OutOfBand are the changes made to the database since Baseline was
+	// applied, outside migrations, such as a column or an index added by
+	// hand.
+	OutOfBand []SchemaChange
+	// Mismatches are the differences between the schema file and the
+	// database, as the changes that would make the database match it.
+	Mismatches []SchemaChange
+	// Pending are the migrations not applied yet. While there are any,
+	// Mismatches are expected.
+	Pending []*Migration
+	// Edited are the applied migrations whose files changed since.
+	Edited []Drift
+}
+
+// Drifted reports whether the database or its migrations were changed
+// outside migrations: the database changed since its last recorded
+// schema, it doesn't match the schema file with every migration applied,
+// or an applied migration was edited.
+func (r *VerifyResult) Drifted() bool {
+	return len(r.OutOfBand) > 0 || len(r.Edited) > 0 || (len(r.Pending) == 0 && len(r.Mismatches) > 0)
+}
+
+// Verify compares the database with the schema recorded after the newest
+// applied migration, and with s if it is not nil, to find changes made
+// outside migrations. The migrations must be loaded. Out-of-band changes
+// are only found for migrations applied with the schema history on; see
+// WithSchemaHistory.
+func (e *Engine) Verify(ctx context.Context, s *schema.Schema) (*VerifyResult, error) {
+	current, err := e.CurrentSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &VerifyResult{}
+	if result.Pending, err = e.Pending(ctx); err != nil {
+		return nil, err
+	}
+	if result.Edited, err = e.Drifted(ctx); err != nil {
+		return nil, err
+	}
+
+	if s != nil {
+		result.Mismatches = DiffWithOptions(s, current.Database(), DiffOptions{Dialect: e.conn.Dialect}).Changes
+	}
+
+	applied, err := e.getApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := e.schemaHistory(); !ok || len(applied) == 0 {
+		return result, nil
+	}
+	latest := latestID(applied)
+	recorded, err := e.SchemaAt(ctx, latest)
+	if errors.Is(err, ErrNoSchemaHistory) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Baseline = latest
+	result.OutOfBand = DiffSnapshots(recorded, current)
+	return result, nil
+}
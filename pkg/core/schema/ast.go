@@ -0,0 +1,157 @@
+package schema
+
+// File is the syntax tree of one .nexus file. Declarations keep their
+// source order and every node records its span, so editors and the
+// formatter can work from the tree.
+type File struct {
+	Decls    []Decl
+	Comments []*Comment
+}
+
+// Decl is a top-level declaration: *ImportDecl, *EnumDecl or *ModelDecl.
+type Decl interface {
+	Span() (Pos, Pos)
+}
+
+// Comment is a // comment.
+type Comment struct {
+	Text     string
+	Pos, End Pos
+}
+
+// Ident is a name with its position.
+type Ident struct {
+	Name     string
+	Pos, End Pos
+}
+
+// ImportDecl is an import "file" or include "glob" directive.
+type ImportDecl struct {
+	Keyword  string // "import" or "include"
+	Path     *StringLit
+	Pos, End Pos
+}
+
+// EnumDecl is an enum block.
+type EnumDecl struct {
+	Name     *Ident
+	Values   []*Ident
+	Pos, End Pos
+}
+
+// ModelDecl is a model block with its fields and @@ attributes.
+type ModelDecl struct {
+	Name       *Ident
+	Fields     []*FieldDecl
+	Attributes []*Attribute
+	Pos, End   Pos
+}
+
+// FieldDecl is a field line: name Type @attribute...
+type FieldDecl struct {
+	Name       *Ident
+	Type       *TypeRef
+	Attributes []*Attribute
+	Pos, End   Pos
+}
+
+// TypeRef is a field type such as String, Post[] or User?.
+type TypeRef struct {
+	Name     string
+	List     bool
+	Optional bool
+	Pos, End Pos
+}
+
+// Attribute is a field attribute (@default(now())) or, if Model is set, a
+// model attribute (@@index([a, b])). Args is nil when there are no parentheses.
+type Attribute struct {
+	Name     string // May be dotted, e.g. db.VarChar
+	Model    bool
+	Args     []*Arg
+	Pos, End Pos
+}
+
+// Arg is an attribute argument, optionally named: fields: [author_id].
+type Arg struct {
+	Name     string
+	Value    Expr
+	Pos, End Pos
+}
+
+// Expr is an attribute argument value: *StringLit, *NumberLit, *Ident,
+// *CallExpr or *ListExpr.
+type Expr interface {
+	Span() (Pos, Pos)
+}
+
+// StringLit is a quoted string; Value is unquoted.
+type StringLit struct {
+	Value    string
+	Pos, End Pos
+}
+
+// NumberLit is an integer or decimal number as written.
+type NumberLit struct {
+	Text     string
+	Pos, End Pos
+}
+
+// CallExpr is a function call such as now() or uuid().
+type CallExpr struct {
+	Name     *Ident
+	Args     []Expr
+	Pos, End Pos
+}
+
+// ListExpr is a bracketed list such as [tenant_id, email].
+type ListExpr struct {
+	Elems    []Expr
+	Pos, End Pos
+}
+
+// Span returns the start and end of the node.
+func (n *Comment) Span() (Pos, Pos)    { return n.Pos, n.End }
+func (n *Ident) Span() (Pos, Pos)      { return n.Pos, n.End }
+func (n *ImportDecl) Span() (Pos, Pos) { return n.Pos, n.End }
+func (n *EnumDecl) Span() (Pos, Pos)   { return n.Pos, n.End }
+func (n *ModelDecl) Span() (Pos, Pos)  { return n.Pos, n.End }
+func (n *FieldDecl) Span() (Pos, Pos)  { return n.Pos, n.End }
+func (n *TypeRef) Span() (Pos, Pos)    { return n.Pos, n.End }
+func (n *Attribute) Span() (Pos, Pos)  { return n.Pos, n.End }
+func (n *Arg) Span() (Pos, Pos)        { return n.Pos, n.End }
+func (n *StringLit) Span() (Pos, Pos)  { return n.Pos, n.End }
+func (n *NumberLit) Span() (Pos, Pos)  { return n.Pos, n.End }
+func (n *CallExpr) Span() (Pos, Pos)   { return n.Pos, n.End }
+func (n *ListExpr) Span() (Pos, Pos)   { return n.Pos, n.End }
+
+// Arg returns the named argument, or nil.
+func (a *Attribute) Arg(name string) *Arg {
+	for _, arg := range a.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+// Lookup returns the model or enum declared as name, or nil.
+func (f *File) Lookup(name string) Decl {
+	for _, decl := range f.Decls {
+		if ident := declName(decl); ident != nil && ident.Name == name {
+			return decl
+		}
+	}
+	return nil
+}
+
+// declName returns the name of a model or enum declaration.
+func declName(d Decl) *Ident {
+	switch d := d.(type) {
+	case *ModelDecl:
+		return d.Name
+	case *EnumDecl:
+		return d.Name
+	}
+	return nil
+}
@@ -0,0 +1,879 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	nxerr "github.com/nexus-db/nexus/pkg/errors"
+)
+
+// build lowers a syntax tree into a schema. Fields typed with a model or
+// enum name are recorded in p.relations and resolved once all files are
+// loaded; errors are reported at the node that caused them.
+func (p *Parser) build(f *File) *Schema {
+	schema := NewSchema()
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ImportDecl:
+			if d.Path.Value == "" {
+				p.addError(nxerr.ErrSchemaInvalidImport, "Import path is empty", d.Path)
+				continue
+			}
+			p.imports = append(p.imports, d)
+
+		case *EnumDecl:
+			e := p.buildEnum(d)
+			if p.checkUnique(schema, d.Name) {
+				schema.addEnum(e)
+			}
+
+		case *ModelDecl:
+			relations := len(p.relations)
+			model := p.buildModel(d)
+			if !p.checkUnique(schema, d.Name) {
+				p.relations = p.relations[:relations]
+				continue
+			}
+			schema.Models[model.Name] = model
+			schema.modelList = append(schema.modelList, model)
+		}
+	}
+
+	return schema
+}
+
+// checkUnique reports an error if a model or enum called name already exists.
+func (p *Parser) checkUnique(schema *Schema, name *Ident) bool {
+	_, isModel := schema.Models[name.Name]
+	_, isEnum := schema.Enums[name.Name]
+	if isModel || isEnum {
+		p.addError(nxerr.ErrSchemaDuplicateModel, fmt.Sprintf("'%s' is declared more than once", name.Name), name)
+		return false
+	}
+	return true
+}
+
+func (p *Parser) buildEnum(d *EnumDecl) *Enum {
+	e := &Enum{Name: d.Name.Name}
+	if !isModelTypeName(e.Name) {
+		p.addError(nxerr.ErrSchemaInvalidEnum, fmt.Sprintf("Invalid enum name '%s'", e.Name), d.Name).
+			WithSuggestion("Enum names are capitalized and must not be a builtin type, e.g.: enum Role { ADMIN USER }")
+	}
+
+	for _, value := range d.Values {
+		if e.Has(value.Name) {
+			p.addError(nxerr.ErrSchemaInvalidEnum, fmt.Sprintf("Duplicate value '%s' in enum %s", value.Name, e.Name), value)
+			continue
+		}
+		e.Values = append(e.Values, value.Name)
+	}
+	if len(d.Values) == 0 {
+		p.addError(nxerr.ErrSchemaInvalidEnum, fmt.Sprintf("Enum %s has no values", e.Name), d.Name)
+	}
+	return e
+}
+
+func (p *Parser) buildModel(d *ModelDecl) *Model {
+	model := &Model{
+		Name:   d.Name.Name,
+		Fields: make(map[string]*Field),
+	}
+
+	declared := make(map[string]bool)
+	for _, fd := range d.Fields {
+		if declared[fd.Name.Name] {
+			p.addError(nxerr.ErrSchemaDuplicateField,
+				fmt.Sprintf("Field '%s' is declared more than once in model %s", fd.Name.Name, model.Name), fd.Name)
+			continue
+		}
+		declared[fd.Name.Name] = true
+
+		field := p.buildField(model, fd)
+		if field != nil {
+			field.Model = model
+			model.Fields[field.Name] = field
+			model.fieldList = append(model.fieldList, field)
+		}
+	}
+
+	// @@map first, so unnamed indexes are named after the mapped table
+	var indexes []*Attribute
+	for _, attr := range d.Attributes {
+		switch attr.Name {
+		case "map":
+			table, ok := stringArg(attr)
+			if !ok || table == "" {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@map expects a quoted table name", attr).
+					WithSuggestion(`Use format: @@map("table_name")`)
+				continue
+			}
+			model.TableName = table
+		case "schema":
+			name, ok := stringArg(attr)
+			if !ok || name == "" || strings.Contains(name, ".") {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@schema expects a quoted schema name", attr).
+					WithSuggestion(`Use format: @@schema("billing")`)
+				continue
+			}
+			model.SchemaName = name
+		case "charset", "collate":
+			name, ok := stringArg(attr)
+			if !ok || !ValidCollationName(name) {
+				p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@@%s expects a quoted name", attr.Name), attr).
+					WithSuggestion(fmt.Sprintf(`Use format: @@%s("%s")`, attr.Name, collationExample[attr.Name]))
+				continue
+			}
+			if attr.Name == "charset" {
+				model.CharacterSet = name
+			} else {
+				model.Collation = name
+			}
+		case "comment":
+			text, ok := stringArg(attr)
+			if !ok {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@comment expects a quoted string", attr).
+					WithSuggestion(`Use format: @@comment("Registered customers")`)
+				continue
+			}
+			model.Description = text
+		case "index", "unique":
+			indexes = append(indexes, attr)
+		case "rls":
+			p.buildRowSecurity(model, attr)
+		case "policy":
+			p.buildPolicy(model, attr)
+		case "partition":
+			p.buildPartition(model, attr)
+		default:
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("Unknown model attribute '@@%s'", attr.Name), attr).
+				WithSuggestion(nxerr.SuggestSimilar(attr.Name, []string{"index", "unique", "map", "schema", "charset", "collate", "comment", "rls", "policy", "partition"}))
+		}
+	}
+	for _, attr := range indexes {
+		p.buildIndex(model, attr)
+	}
+
+	return model
+}
+
+// buildRowSecurity applies @@rls or @@rls(force: true).
+func (p *Parser) buildRowSecurity(model *Model, attr *Attribute) {
+	model.EnableRLS()
+	for _, arg := range attr.Args {
+		value, ok := arg.Value.(*Ident)
+		if arg.Name != "force" || !ok || (value.Name != "true" && value.Name != "false") {
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@@rls: unknown argument '%s'", p.text(arg)), arg).
+				WithSuggestion("Use format: @@rls or @@rls(force: true)")
+			continue
+		}
+		if value.Name == "true" {
+			model.ForceRLS()
+		}
+	}
+}
+
+// buildPolicy attaches a @@policy("name", "condition", check: "...",
+// for: SELECT, to: [role]) attribute.
+func (p *Parser) buildPolicy(model *Model, attr *Attribute) {
+	usage := `Use format: @@policy("name", "condition", for: SELECT, to: [role], check: "condition")`
+	var positional []string
+	policy := &Policy{Command: PolicyAll}
+	valid := true
+	for _, arg := range attr.Args {
+		switch arg.Name {
+		case "":
+			lit, ok := arg.Value.(*StringLit)
+			if !ok || len(positional) == 2 {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@policy takes a quoted name and condition", arg).WithSuggestion(usage)
+				valid = false
+				continue
+			}
+			positional = append(positional, lit.Value)
+		case "check":
+			lit, ok := arg.Value.(*StringLit)
+			if !ok {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@policy check expects a quoted condition", arg).WithSuggestion(usage)
+				valid = false
+				continue
+			}
+			policy.Check = lit.Value
+		case "for":
+			command, ok := identOrString(arg.Value)
+			if !ok {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@policy for expects ALL, SELECT, INSERT, UPDATE or DELETE", arg).WithSuggestion(usage)
+				valid = false
+				continue
+			}
+			policy.For(command)
+		case "to":
+			list, ok := arg.Value.(*ListExpr)
+			if !ok {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@policy to expects a list of roles", arg).WithSuggestion(usage)
+				valid = false
+				continue
+			}
+			for _, elem := range list.Elems {
+				role, ok := identOrString(elem)
+				if !ok {
+					p.addError(nxerr.ErrSchemaInvalidModifier, "@@policy roles must be names", elem).WithSuggestion(usage)
+					valid = false
+					continue
+				}
+				policy.Roles = append(policy.Roles, role)
+			}
+		default:
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@@policy: unknown argument '%s'", p.text(arg)), arg).
+				WithSuggestion(usage)
+			valid = false
+		}
+	}
+	if len(positional) == 0 {
+		p.addError(nxerr.ErrSchemaInvalidModifier, "@@policy needs a name", attr).WithSuggestion(usage)
+		return
+	}
+	if !valid {
+		return
+	}
+
+	policy.Name = positional[0]
+	if len(positional) > 1 {
+		policy.Using = positional[1]
+	}
+	if err := policy.validate(); err != nil {
+		p.addError(nxerr.ErrSchemaInvalidModifier, "@@"+err.Error(), attr).WithSuggestion(usage)
+		return
+	}
+	model.RowSecurity = true
+	model.Policies = append(model.Policies, policy)
+}
+
+// buildPartition applies @@partition(range: [created_at], interval: monthly)
+// or @@partition(hash: [tenant_id], partitions: 4).
+func (p *Parser) buildPartition(model *Model, attr *Attribute) {
+	usage := "Use format: @@partition(range: [created_at], interval: monthly) or @@partition(hash: [tenant_id], partitions: 4)"
+	part := &Partitioning{}
+	for _, arg := range attr.Args {
+		switch arg.Name {
+		case "range", "hash":
+			list, ok := arg.Value.(*ListExpr)
+			if !ok || part.Strategy != "" {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@partition takes one strategy with a list of fields", arg).WithSuggestion(usage)
+				return
+			}
+			part.Strategy = PartitionStrategy(strings.ToUpper(arg.Name))
+			for _, elem := range list.Elems {
+				ident, ok := elem.(*Ident)
+				if !ok {
+					p.addError(nxerr.ErrSchemaInvalidModifier, "@@partition fields must be names", elem).WithSuggestion(usage)
+					return
+				}
+				part.Columns = append(part.Columns, ident.Name)
+			}
+		case "interval":
+			interval, ok := identOrString(arg.Value)
+			if !ok {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@partition interval expects daily, monthly or yearly", arg).WithSuggestion(usage)
+				return
+			}
+			part.Interval = PartitionInterval(strings.ToLower(interval))
+		case "partitions":
+			num, ok := arg.Value.(*NumberLit)
+			n, err := 0, error(nil)
+			if ok {
+				n, err = strconv.Atoi(num.Text)
+			}
+			if !ok || err != nil || n < 1 {
+				p.addError(nxerr.ErrSchemaInvalidModifier, "@@partition partitions expects a positive number", arg).WithSuggestion(usage)
+				return
+			}
+			part.Count = n
+		default:
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@@partition: unknown argument '%s'", p.text(arg)), arg).
+				WithSuggestion(usage)
+			return
+		}
+	}
+	if part.Strategy == "" {
+		p.addError(nxerr.ErrSchemaInvalidModifier, "@@partition needs range or hash fields", attr).WithSuggestion(usage)
+		return
+	}
+	model.Partition = part
+}
+
+// identOrString returns the name of an identifier or the value of a
+// quoted string.
+func identOrString(e Expr) (string, bool) {
+	switch v := e.(type) {
+	case *Ident:
+		return v.Name, true
+	case *StringLit:
+		return v.Value, true
+	}
+	return "", false
+}
+
+// buildIndex attaches an @@index([a, b]) or @@unique([a, b], name: "x")
+// attribute. An unnamed single-field @@unique is the same as @unique.
+func (p *Parser) buildIndex(model *Model, attr *Attribute) {
+	idx := &Index{Unique: attr.Name == "unique"}
+	usage := fmt.Sprintf(`Use format: @@%s([field1, field2], name: "index_name")`, attr.Name)
+
+	var list *ListExpr
+	if len(attr.Args) > 0 && attr.Args[0].Name == "" {
+		list, _ = attr.Args[0].Value.(*ListExpr)
+	}
+	if list == nil || len(list.Elems) == 0 {
+		p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@@%s needs a list of fields", attr.Name), attr).
+			WithSuggestion(usage)
+		return
+	}
+
+	valid := true
+	for _, elem := range list.Elems {
+		ident, ok := elem.(*Ident)
+		if !ok {
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@@%s fields must be field names", attr.Name), elem).
+				WithSuggestion(usage)
+			valid = false
+			continue
+		}
+		if _, ok := model.Fields[ident.Name]; !ok {
+			p.addError(nxerr.ErrSchemaInvalidModifier,
+				fmt.Sprintf("Index on %s references unknown field '%s'", model.Name, ident.Name), ident).
+				WithSuggestion(nxerr.SuggestSimilar(ident.Name, fieldNames(model)))
+			valid = false
+			continue
+		}
+		idx.Fields = append(idx.Fields, ident.Name)
+	}
+
+	for _, arg := range attr.Args[1:] {
+		name, ok := arg.Value.(*StringLit)
+		if arg.Name != "name" || !ok {
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@@%s: unknown argument '%s'", attr.Name, p.text(arg)), arg).
+				WithSuggestion(usage)
+			valid = false
+			continue
+		}
+		idx.Name = name.Value
+	}
+	if !valid {
+		return
+	}
+
+	if idx.Unique && len(idx.Fields) == 1 && idx.Name == "" {
+		model.Fields[idx.Fields[0]].IsUnique = true
+		return
+	}
+	if idx.Name == "" {
+		idx.Name = DefaultIndexName(model.Table(), idx.Unique, idx.Fields)
+	}
+	model.Indexes = append(model.Indexes, idx)
+}
+
+// buildField creates the column for a field declaration. Fields typed with
+// a model or enum name are handed to buildRelationField.
+func (p *Parser) buildField(model *Model, fd *FieldDecl) *Field {
+	typ := fd.Type
+
+	// Fields typed with a model name declare relations, not columns
+	if isModelTypeName(typ.Name) {
+		return p.buildRelationField(model, fd)
+	}
+	if typ.List {
+		p.addError(nxerr.ErrSchemaUnknownType,
+			fmt.Sprintf("List type '%s[]' is only supported for relations", typ.Name), typ).
+			WithSuggestion("Use a JSON field to store lists of scalar values")
+		return nil
+	}
+
+	fieldType, ok := builtinFieldType(typ.Name)
+	if !ok {
+		suggestion := nxerr.SuggestSimilar(typ.Name, nxerr.ValidTypes)
+		if suggestion == "" {
+			suggestion = nxerr.Suggestions[nxerr.ErrSchemaUnknownType]
+		}
+		p.addError(nxerr.ErrSchemaUnknownType, fmt.Sprintf("Unknown type '%s'", typ.Name), typ).
+			WithSuggestion(suggestion)
+		return nil
+	}
+
+	field := &Field{
+		Name:     fd.Name.Name,
+		Type:     fieldType,
+		Nullable: typ.Optional,
+	}
+	if fieldType == FieldTypeMoney {
+		field.Precision, field.Scale = MoneyPrecision, MoneyScale
+	}
+	for _, attr := range fd.Attributes {
+		if attr.Name == "relation" {
+			p.addError(nxerr.ErrSchemaInvalidRelation,
+				fmt.Sprintf("@relation is not allowed on field '%s' of type %s", field.Name, typ.Name), attr).
+				WithSuggestion(nxerr.Suggestions[nxerr.ErrSchemaInvalidRelation])
+			continue
+		}
+		p.applyAttribute(field, attr)
+	}
+	return field
+}
+
+// buildRelationField records a field typed with a model or enum name, such as:
+//
+//	posts  Post[]
+//	author User   @relation(fields: [author_id], references: [id], onDelete: Cascade)
+//	role   Role   @default(USER)
+//
+// Singular fields return a placeholder column that is kept if the type turns
+// out to be an enum.
+func (p *Parser) buildRelationField(model *Model, fd *FieldDecl) *Field {
+	decl := relationDecl{
+		Model:   model.Name,
+		Field:   fd.Name.Name,
+		Target:  fd.Type.Name,
+		List:    fd.Type.List,
+		Decl:    fd,
+		File:    p.file,
+		Context: p.lineText(fd.Pos.Line),
+	}
+
+	if !decl.List {
+		decl.Column = &Field{Name: decl.Field, Type: FieldTypeEnum, Nullable: fd.Type.Optional}
+	}
+
+	for _, attr := range fd.Attributes {
+		if attr.Name != "relation" {
+			decl.Modifiers = append(decl.Modifiers, attr.display())
+			if decl.Column != nil {
+				p.applyAttribute(decl.Column, attr)
+			}
+			continue
+		}
+
+		errors := len(p.errors)
+		decl.Relation = true
+		p.buildRelationArgs(&decl, attr)
+		if decl.List && len(decl.Fields) > 0 {
+			p.addError(nxerr.ErrSchemaInvalidRelation,
+				fmt.Sprintf("List relation '%s' cannot declare foreign key fields", decl.Field), attr).
+				WithSuggestion(fmt.Sprintf("Put @relation(fields: ..., references: ...) on the %s side of the relation", decl.Target))
+		}
+		// A broken @relation is not resolved, to avoid follow-up errors
+		if len(p.errors) > errors {
+			return nil
+		}
+	}
+
+	p.relations = append(p.relations, decl)
+	return decl.Column
+}
+
+var relationArgs = []string{"name", "fields", "references", "onDelete", "onUpdate"}
+
+// buildRelationArgs reads the arguments of @relation(...).
+func (p *Parser) buildRelationArgs(decl *relationDecl, attr *Attribute) {
+	usage := nxerr.Suggestions[nxerr.ErrSchemaInvalidRelation]
+
+	for i, arg := range attr.Args {
+		if arg.Name == "" {
+			name, ok := arg.Value.(*StringLit)
+			if i != 0 || !ok {
+				p.addError(nxerr.ErrSchemaInvalidRelation, fmt.Sprintf("invalid @relation argument '%s'", p.text(arg)), arg).
+					WithSuggestion(usage)
+				continue
+			}
+			decl.Name = name.Value
+			continue
+		}
+
+		switch arg.Name {
+		case "name":
+			name, ok := arg.Value.(*StringLit)
+			if !ok {
+				p.addError(nxerr.ErrSchemaInvalidRelation,
+					fmt.Sprintf("relation name must be a quoted string, got %s", p.text(arg.Value)), arg.Value)
+				continue
+			}
+			decl.Name = name.Value
+		case "fields":
+			decl.Fields = p.fieldList(arg)
+		case "references":
+			decl.References = p.fieldList(arg)
+		case "onDelete", "onUpdate":
+			action, err := p.cascadeAction(arg.Value)
+			if err != nil {
+				p.addError(nxerr.ErrSchemaInvalidRelation, err.Error(), arg.Value)
+				continue
+			}
+			if arg.Name == "onDelete" {
+				decl.OnDelete = action
+			} else {
+				decl.OnUpdate = action
+			}
+		default:
+			msg := fmt.Sprintf("unknown @relation argument '%s'", arg.Name)
+			suggestion := nxerr.SuggestSimilar(arg.Name, relationArgs)
+			if suggestion == "" {
+				suggestion = usage
+			}
+			p.addError(nxerr.ErrSchemaInvalidRelation, msg, arg).WithSuggestion(suggestion)
+		}
+	}
+
+	if len(decl.Fields) != len(decl.References) {
+		p.addError(nxerr.ErrSchemaInvalidRelation, "@relation fields and references must list the same number of fields", attr).
+			WithSuggestion(usage)
+	}
+}
+
+// fieldList reads a bracketed list of field names such as [author_id, tenant_id].
+func (p *Parser) fieldList(arg *Arg) []string {
+	list, ok := arg.Value.(*ListExpr)
+	if !ok {
+		p.addError(nxerr.ErrSchemaInvalidRelation,
+			fmt.Sprintf("expected a list like [field], got %s", p.text(arg.Value)), arg.Value)
+		return nil
+	}
+	if len(list.Elems) == 0 {
+		p.addError(nxerr.ErrSchemaInvalidRelation, fmt.Sprintf("%s list is empty", arg.Name), list)
+		return nil
+	}
+
+	var fields []string
+	for _, elem := range list.Elems {
+		ident, ok := elem.(*Ident)
+		if !ok {
+			p.addError(nxerr.ErrSchemaInvalidRelation, fmt.Sprintf("expected a field name, got %s", p.text(elem)), elem)
+			return nil
+		}
+		fields = append(fields, ident.Name)
+	}
+	return fields
+}
+
+// cascadeAction reads a referential action name.
+func (p *Parser) cascadeAction(e Expr) (CascadeAction, error) {
+	ident, ok := e.(*Ident)
+	if !ok {
+		return NoAction, fmt.Errorf("unknown referential action '%s' (use Cascade, SetNull, Restrict or NoAction)", p.text(e))
+	}
+	return parseCascadeAction(ident.Name)
+}
+
+// parseCascadeAction parses a referential action name.
+func parseCascadeAction(value string) (CascadeAction, error) {
+	switch value {
+	case "Cascade":
+		return Cascade, nil
+	case "SetNull":
+		return SetNull, nil
+	case "Restrict":
+		return Restrict, nil
+	case "NoAction":
+		return NoAction, nil
+	default:
+		return NoAction, fmt.Errorf("unknown referential action '%s' (use Cascade, SetNull, Restrict or NoAction)", value)
+	}
+}
+
+// applyAttribute applies a column attribute such as @id, @unique or
+// @default(value) to field.
+func (p *Parser) applyAttribute(field *Field, attr *Attribute) {
+	name := strings.ToLower(attr.Name)
+
+	switch name {
+	case "id", "unique", "autoincrement", "auto":
+		if attr.Args != nil {
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@%s takes no arguments", attr.Name), attr)
+			return
+		}
+		switch name {
+		case "id":
+			field.IsPrimaryKey = true
+		case "unique":
+			field.IsUnique = true
+		default:
+			field.AutoIncrement = true
+		}
+
+	case "default":
+		if len(attr.Args) != 1 || attr.Args[0].Name != "" {
+			p.addError(nxerr.ErrSchemaInvalidModifier, "@default takes exactly one value", attr).
+				WithSuggestion("Use format: @default(value), e.g.: @default(now()) or @default(\"draft\")")
+			return
+		}
+		p.applyDefault(field, attr.Args[0].Value)
+
+	case "length", "size":
+		if n, ok := p.intArgs(attr, 1); ok {
+			field.Length = n[0]
+		} else {
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@%s expects a length", attr.Name), attr).
+				WithSuggestion(fmt.Sprintf("Use format: @%s(100)", attr.Name))
+		}
+
+	case "precision":
+		if n, ok := p.intArgs(attr, 2); ok {
+			field.Precision = n[0]
+			if len(n) > 1 {
+				field.Scale = n[1]
+			}
+		} else {
+			p.addError(nxerr.ErrSchemaInvalidModifier, "@precision expects a precision and an optional scale", attr).
+				WithSuggestion("Use format: @precision(10,2)")
+		}
+
+	case "email":
+		if attr.Args != nil {
+			p.addError(nxerr.ErrSchemaInvalidModifier, "@email takes no arguments", attr)
+			return
+		}
+		field.IsEmail = true
+
+	case "unsigned":
+		if attr.Args != nil {
+			p.addError(nxerr.ErrSchemaInvalidModifier, "@unsigned takes no arguments", attr)
+			return
+		}
+		field.IsUnsigned = true
+
+	case "pii":
+		if attr.Args == nil {
+			field.IsPII = true
+			return
+		}
+		mask, ok := stringArg(attr)
+		if !ok || !slices.Contains(PIIMasks, mask) {
+			p.addError(nxerr.ErrSchemaInvalidModifier, "@pii expects no arguments or one of "+strings.Join(PIIMasks, ", "), attr).
+				WithSuggestion(`Use format: @pii or @pii("email")`)
+			return
+		}
+		field.IsPII = true
+		field.PIIMask = mask
+
+	case "min", "max":
+		n, ok := p.numberArg(attr)
+		if !ok {
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@%s expects a number", attr.Name), attr).
+				WithSuggestion(fmt.Sprintf("Use format: @%s(0); on strings it limits the length", attr.Name))
+			return
+		}
+		if name == "min" {
+			field.MinValue = &n
+		} else {
+			field.MaxValue = &n
+		}
+
+	case "regex":
+		pattern, ok := stringArg(attr)
+		if !ok {
+			p.addError(nxerr.ErrSchemaInvalidModifier, "@regex expects a quoted pattern", attr).
+				WithSuggestion(`Use format: @regex("^[a-z0-9-]+$")`)
+			return
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("Invalid pattern: %v", err), attr.Args[0].Value)
+			return
+		}
+		field.Pattern = pattern
+
+	case "charset", "collate":
+		value, ok := stringArg(attr)
+		if !ok || !ValidCollationName(value) {
+			p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("@%s expects a quoted name", name), attr).
+				WithSuggestion(fmt.Sprintf(`Use format: @%s("%s")`, name, collationExample[name]))
+			return
+		}
+		if name == "charset" {
+			field.CharacterSet = value
+		} else {
+			field.Collation = value
+		}
+
+	case "gotype":
+		value, ok := stringArg(attr)
+		if !ok || !ValidGoTypeName(value) {
+			p.addError(nxerr.ErrSchemaInvalidModifier, "@gotype expects a quoted Go type", attr).
+				WithSuggestion(`Use format: @gotype("github.com/shopspring/decimal.Decimal")`)
+			return
+		}
+		field.GoTypeName = value
+
+	case "comment":
+		value, ok := stringArg(attr)
+		if !ok {
+			p.addError(nxerr.ErrSchemaInvalidModifier, "@comment expects a quoted string", attr).
+				WithSuggestion(`Use format: @comment("Shown on invoices")`)
+			return
+		}
+		field.Description = value
+
+	case "db", "map":
+		// Column name mapping, ignore for now
+
+	default:
+		// Native type hints such as @db.VarChar(255) are ignored for now
+		if strings.HasPrefix(name, "db.") {
+			return
+		}
+		suggestion := nxerr.SuggestSimilar(attr.Name, nxerr.ValidModifiers)
+		if suggestion == "" {
+			suggestion = nxerr.Suggestions[nxerr.ErrSchemaInvalidModifier]
+		}
+		p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("Unknown modifier '@%s'", attr.Name), attr).
+			WithSuggestion(suggestion)
+	}
+}
+
+// collationExample is the example name of the suggestions for @charset and
+// @collate.
+var collationExample = map[string]string{"charset": "utf8mb4", "collate": "utf8mb4_unicode_ci"}
+
+// intArgs reads between one and max positional integer arguments.
+func (p *Parser) intArgs(attr *Attribute, max int) ([]int, bool) {
+	if len(attr.Args) == 0 || len(attr.Args) > max {
+		return nil, false
+	}
+	var values []int
+	for _, arg := range attr.Args {
+		num, ok := arg.Value.(*NumberLit)
+		if !ok || arg.Name != "" {
+			return nil, false
+		}
+		n, err := strconv.Atoi(num.Text)
+		if err != nil {
+			return nil, false
+		}
+		values = append(values, n)
+	}
+	return values, true
+}
+
+// numberArg reads the single positional number argument of attr.
+func (p *Parser) numberArg(attr *Attribute) (float64, bool) {
+	if len(attr.Args) != 1 || attr.Args[0].Name != "" {
+		return 0, false
+	}
+	num, ok := attr.Args[0].Value.(*NumberLit)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(num.Text, 64)
+	return n, err == nil
+}
+
+// applyDefault sets the default of field from the @default value.
+func (p *Parser) applyDefault(field *Field, value Expr) {
+	switch v := value.(type) {
+	case *CallExpr:
+		if expr, ok := PortableDefaultExpr(v.Name.Name); ok && len(v.Args) == 0 {
+			field.DefaultExpr = expr
+		} else {
+			field.DefaultExpr = p.text(v)
+		}
+
+	case *StringLit:
+		field.DefaultValue = v.Value
+
+	case *NumberLit:
+		if i, err := strconv.ParseInt(v.Text, 10, 64); err == nil {
+			field.DefaultValue = i
+		} else if f, err := strconv.ParseFloat(v.Text, 64); err == nil {
+			field.DefaultValue = f
+		}
+
+	case *Ident:
+		switch v.Name {
+		case "true":
+			field.DefaultValue = true
+		case "false":
+			field.DefaultValue = false
+		default:
+			field.DefaultValue = v.Name
+		}
+
+	default:
+		p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("Invalid default value %s", p.text(value)), value).
+			WithSuggestion("Defaults are literals, enum values or functions such as now(), uuid() and random()")
+	}
+}
+
+// stringArg returns the single quoted-string argument of attr.
+func stringArg(attr *Attribute) (string, bool) {
+	if len(attr.Args) != 1 || attr.Args[0].Name != "" {
+		return "", false
+	}
+	s, ok := attr.Args[0].Value.(*StringLit)
+	if !ok {
+		return "", false
+	}
+	return s.Value, true
+}
+
+// text returns the source text of a node.
+func (p *Parser) text(n spanner) string {
+	start, end := n.Span()
+	return p.input[start.Offset:end.Offset]
+}
+
+// fieldNames returns the names of the model's fields in declaration order.
+func fieldNames(model *Model) []string {
+	names := make([]string, 0, len(model.fieldList))
+	for _, f := range model.fieldList {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// isModelTypeName reports whether typeName is capitalized and not a builtin type.
+func isModelTypeName(typeName string) bool {
+	if len(typeName) == 0 || typeName[0] < 'A' || typeName[0] > 'Z' {
+		return false
+	}
+	_, builtin := builtinFieldType(typeName)
+	return !builtin
+}
+
+// builtinFieldType maps a DSL type name, including aliases, to a field type.
+func builtinFieldType(typeName string) (FieldType, bool) {
+	switch strings.ToLower(typeName) {
+	case "int", "integer":
+		return FieldTypeInt, true
+	case "bigint":
+		return FieldTypeBigInt, true
+	case "smallint":
+		return FieldTypeSmallInt, true
+	case "tinyint":
+		return FieldTypeTinyInt, true
+	case "money":
+		return FieldTypeMoney, true
+	case "string", "varchar":
+		return FieldTypeString, true
+	case "text":
+		return FieldTypeText, true
+	case "bool", "boolean":
+		return FieldTypeBool, true
+	case "float", "double":
+		return FieldTypeFloat, true
+	case "decimal", "numeric":
+		return FieldTypeDecimal, true
+	case "datetime", "timestamp":
+		return FieldTypeDateTime, true
+	case "date":
+		return FieldTypeDate, true
+	case "time":
+		return FieldTypeTime, true
+	case "json", "jsonb":
+		return FieldTypeJSON, true
+	case "bytes", "blob", "binary":
+		return FieldTypeBytes, true
+	case "uuid":
+		return FieldTypeUUID, true
+	default:
+		return FieldTypeString, false
+	}
+}
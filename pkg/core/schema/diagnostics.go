@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	nxerr "github.com/nexus-db/nexus/pkg/errors"
+)
+
+// ParseError holds every error found while parsing a schema.
+type ParseError struct {
+	Errors []*nxerr.NexusError
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Schema parsing failed with %d error(s):\n\n", len(e.Errors)))
+
+	for _, err := range e.Errors {
+		sb.WriteString(err.Print())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// Diagnostic severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a machine-readable schema problem for editors and tools.
+// Lines and columns are 1-based; the end column is exclusive. Positions
+// are zero when the problem has no location, e.g. for validation errors.
+type Diagnostic struct {
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	EndLine    int    `json:"endLine"`
+	EndColumn  int    `json:"endColumn"`
+	Severity   string `json:"severity"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// NewDiagnostic converts a structured error to a diagnostic.
+func NewDiagnostic(e *nxerr.NexusError) Diagnostic {
+	d := Diagnostic{
+		File:       e.File,
+		Line:       e.Line,
+		Column:     e.Column,
+		EndLine:    e.EndLine,
+		EndColumn:  e.EndColumn,
+		Severity:   SeverityError,
+		Code:       string(e.Code),
+		Message:    e.Message,
+		Suggestion: e.Suggestion,
+	}
+	if d.EndLine == 0 {
+		d.EndLine, d.EndColumn = d.Line, d.Column
+	}
+	return d
+}
+
+// Diagnostics converts an error returned by Parse, ParseFile or Validate
+// to diagnostics. Errors without a location become a single diagnostic
+// with code SCHEMA_VALIDATION.
+func Diagnostics(err error) []Diagnostic {
+	if err == nil {
+		return nil
+	}
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		diags := make([]Diagnostic, 0, len(parseErr.Errors))
+		for _, e := range parseErr.Errors {
+			diags = append(diags, NewDiagnostic(e))
+		}
+		return diags
+	}
+
+	var nexusErr *nxerr.NexusError
+	if errors.As(err, &nexusErr) {
+		return []Diagnostic{NewDiagnostic(nexusErr)}
+	}
+
+	return []Diagnostic{{
+		Severity: SeverityError,
+		Code:     string(nxerr.ErrSchemaValidation),
+		Message:  err.Error(),
+	}}
+}
+
+// DiagnosticsJSON encodes the diagnostics for err as a JSON array.
+func DiagnosticsJSON(err error) ([]byte, error) {
+	diags := Diagnostics(err)
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	return json.MarshalIndent(diags, "", "  ")
+}
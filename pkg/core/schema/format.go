@@ -0,0 +1,273 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format renders a schema as .nexus DSL source.
+// Columns within each model are aligned the same way `nexus init` writes them.
+func Format(s *Schema) string {
+	var sb strings.Builder
+
+	for _, e := range s.GetEnums() {
+		fmt.Fprintf(&sb, "enum %s {\n", e.Name)
+		for _, v := range e.Values {
+			fmt.Fprintf(&sb, "  %s\n", v)
+		}
+		sb.WriteString("}\n\n")
+	}
+
+	for i, model := range s.GetModels() {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		formatModel(&sb, model)
+	}
+
+	return sb.String()
+}
+
+func formatModel(sb *strings.Builder, model *Model) {
+	type row struct {
+		name, typ string
+		modifiers []string
+	}
+
+	var rows []row
+	for _, f := range model.GetFields() {
+		rows = append(rows, row{f.Name, formatFieldType(f), formatModifiers(f)})
+	}
+	for _, rel := range model.Relations {
+		// Implicit relations are recreated when the schema is parsed
+		if rel.Name == "" {
+			continue
+		}
+		typ, modifiers := formatRelation(model, rel)
+		rows = append(rows, row{rel.Name, typ, modifiers})
+	}
+
+	nameWidth, typeWidth := 0, 0
+	for _, r := range rows {
+		if len(r.name) > nameWidth {
+			nameWidth = len(r.name)
+		}
+		if len(r.typ) > typeWidth {
+			typeWidth = len(r.typ)
+		}
+	}
+
+	fmt.Fprintf(sb, "model %s {\n", model.Name)
+	for _, r := range rows {
+		if len(r.modifiers) == 0 {
+			fmt.Fprintf(sb, "  %-*s %s\n", nameWidth, r.name, r.typ)
+			continue
+		}
+		fmt.Fprintf(sb, "  %-*s %-*s %s\n", nameWidth, r.name, typeWidth, r.typ, strings.Join(r.modifiers, " "))
+	}
+
+	attributes := formatModelAttributes(model)
+	if len(attributes) > 0 {
+		sb.WriteString("\n")
+		for _, attr := range attributes {
+			fmt.Fprintf(sb, "  %s\n", attr)
+		}
+	}
+	sb.WriteString("}\n")
+}
+
+// formatModelAttributes renders @@index, @@unique, @@map, @@schema,
+// @@charset, @@collate, @@partition, @@rls, @@policy and @@comment
+// attributes.
+// Index names are omitted when they match the default name.
+func formatModelAttributes(model *Model) []string {
+	var attrs []string
+	for _, idx := range model.Indexes {
+		// Single-field unique indexes on @unique fields need no attribute
+		if idx.Unique && len(idx.Fields) == 1 {
+			if f, ok := model.Fields[idx.Fields[0]]; ok && f.IsUnique {
+				continue
+			}
+		}
+
+		name := "index"
+		if idx.Unique {
+			name = "unique"
+		}
+		attr := fmt.Sprintf("@@%s([%s]", name, strings.Join(idx.Fields, ", "))
+		if idx.Name != DefaultIndexName(model.Table(), idx.Unique, idx.Fields) {
+			attr += fmt.Sprintf(", name: %q", idx.Name)
+		}
+		attrs = append(attrs, attr+")")
+	}
+	if model.TableName != "" && model.TableName != model.Name {
+		attrs = append(attrs, fmt.Sprintf("@@map(%q)", model.TableName))
+	}
+	if model.SchemaName != "" {
+		attrs = append(attrs, fmt.Sprintf("@@schema(%q)", model.SchemaName))
+	}
+	if model.CharacterSet != "" {
+		attrs = append(attrs, fmt.Sprintf("@@charset(%q)", model.CharacterSet))
+	}
+	if model.Collation != "" {
+		attrs = append(attrs, fmt.Sprintf("@@collate(%q)", model.Collation))
+	}
+	if p := model.Partition; p != nil {
+		attr := fmt.Sprintf("@@partition(%s: [%s]", strings.ToLower(string(p.Strategy)), strings.Join(p.Columns, ", "))
+		if p.Interval != "" {
+			attr += ", interval: " + string(p.Interval)
+		}
+		if p.Count > 0 {
+			attr += fmt.Sprintf(", partitions: %d", p.Count)
+		}
+		attrs = append(attrs, attr+")")
+	}
+	switch {
+	case model.ForceRowSecurity:
+		attrs = append(attrs, "@@rls(force: true)")
+	case model.RowSecurity && len(model.Policies) == 0:
+		attrs = append(attrs, "@@rls")
+	}
+	for _, p := range model.Policies {
+		attr := fmt.Sprintf("@@policy(%q", p.Name)
+		if p.Using != "" {
+			attr += fmt.Sprintf(", %q", p.Using)
+		}
+		if p.Command != "" && p.Command != PolicyAll {
+			attr += ", for: " + p.Command
+		}
+		if len(p.Roles) > 0 {
+			attr += ", to: [" + strings.Join(p.Roles, ", ") + "]"
+		}
+		if p.Check != "" {
+			attr += fmt.Sprintf(", check: %q", p.Check)
+		}
+		attrs = append(attrs, attr+")")
+	}
+	if model.Description != "" {
+		attrs = append(attrs, fmt.Sprintf("@@comment(%q)", model.Description))
+	}
+	return attrs
+}
+
+// formatRelation renders the type and @relation attribute of a relation field.
+func formatRelation(model *Model, rel *Relation) (string, []string) {
+	switch rel.Type {
+	case RelationHasMany:
+		return rel.TargetModel + "[]", nil
+	case RelationHasOne:
+		return rel.TargetModel + "?", nil
+	}
+
+	typ := rel.TargetModel
+	if fk, ok := model.Fields[rel.ForeignKey]; ok && fk.Nullable {
+		typ += "?"
+	}
+
+	args := []string{
+		fmt.Sprintf("fields: [%s]", rel.ForeignKey),
+		fmt.Sprintf("references: [%s]", rel.ReferenceKey),
+	}
+	if rel.OnDeleteAction != NoAction {
+		args = append(args, "onDelete: "+rel.OnDeleteAction.String())
+	}
+	if rel.OnUpdateAction != NoAction {
+		args = append(args, "onUpdate: "+rel.OnUpdateAction.String())
+	}
+	return typ, []string{"@relation(" + strings.Join(args, ", ") + ")"}
+}
+
+func formatFieldType(f *Field) string {
+	t := f.Type.String()
+	if f.Enum != nil {
+		t = f.Enum.Name
+	}
+	if f.Nullable {
+		t += "?"
+	}
+	return t
+}
+
+func formatModifiers(f *Field) []string {
+	var mods []string
+	if f.IsPrimaryKey {
+		mods = append(mods, "@id")
+	}
+	if f.AutoIncrement {
+		mods = append(mods, "@autoincrement")
+	}
+	if f.IsUnsigned {
+		mods = append(mods, "@unsigned")
+	}
+	if f.IsUnique {
+		mods = append(mods, "@unique")
+	}
+	if def := formatDefault(f); def != "" {
+		mods = append(mods, "@default("+def+")")
+	}
+	if f.Type == FieldTypeString && f.Length > 0 && f.Length != 255 {
+		mods = append(mods, fmt.Sprintf("@size(%d)", f.Length))
+	}
+	if f.Type == FieldTypeDecimal && f.Precision > 0 {
+		mods = append(mods, fmt.Sprintf("@precision(%d,%d)", f.Precision, f.Scale))
+	}
+	if f.Type == FieldTypeMoney && (f.Precision != MoneyPrecision || f.Scale != MoneyScale) {
+		mods = append(mods, fmt.Sprintf("@precision(%d,%d)", f.Precision, f.Scale))
+	}
+	if f.CharacterSet != "" {
+		mods = append(mods, "@charset("+strconv.Quote(f.CharacterSet)+")")
+	}
+	if f.Collation != "" {
+		mods = append(mods, "@collate("+strconv.Quote(f.Collation)+")")
+	}
+	if f.IsEmail {
+		mods = append(mods, "@email")
+	}
+	if f.MinValue != nil {
+		mods = append(mods, "@min("+strconv.FormatFloat(*f.MinValue, 'g', -1, 64)+")")
+	}
+	if f.MaxValue != nil {
+		mods = append(mods, "@max("+strconv.FormatFloat(*f.MaxValue, 'g', -1, 64)+")")
+	}
+	if f.Pattern != "" {
+		mods = append(mods, "@regex("+strconv.Quote(f.Pattern)+")")
+	}
+	if f.IsPII {
+		if f.PIIMask != "" {
+			mods = append(mods, "@pii("+strconv.Quote(f.PIIMask)+")")
+		} else {
+			mods = append(mods, "@pii")
+		}
+	}
+	if f.GoTypeName != "" {
+		mods = append(mods, "@gotype("+strconv.Quote(f.GoTypeName)+")")
+	}
+	if f.Description != "" {
+		mods = append(mods, "@comment("+strconv.Quote(f.Description)+")")
+	}
+	return mods
+}
+
+func formatDefault(f *Field) string {
+	switch f.DefaultExpr {
+	case "":
+	case DefaultExprNow:
+		return "now()"
+	case DefaultExprUUID:
+		return "uuid()"
+	case DefaultExprRandom:
+		return "random()"
+	default:
+		return f.DefaultExpr
+	}
+
+	switch v := f.DefaultValue.(type) {
+	case nil:
+		return ""
+	case string:
+		return `"` + v + `"`
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,335 @@
+package schema
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormatSource returns .nexus source in canonical form: two-space
+// indentation, aligned field names, types and trailing comments, attributes
+// in the order Format writes them, one enum value per line and at most one
+// blank line between items. Unlike Format it works on the syntax tree, so
+// comments and the order of declarations are kept. Source with syntax
+// errors is not formatted; the error lists them.
+func FormatSource(src string) (string, error) {
+	f, err := NewParser(src).ParseAST()
+	if err != nil {
+		return "", err
+	}
+
+	fm := &formatter{comments: f.Comments}
+	for i, decl := range f.Decls {
+		start, end := decl.Span()
+		if i > 0 {
+			fm.blank()
+		}
+		fm.leadingComments(start.Line)
+
+		switch d := decl.(type) {
+		case *ImportDecl:
+			fm.line(d.Keyword+" "+strconv.Quote(d.Path.Value), fm.trailing(end.Line))
+		case *EnumDecl:
+			fm.enum(d)
+		case *ModelDecl:
+			fm.model(d)
+		}
+	}
+
+	// Comments after the last declaration
+	if len(fm.comments) > 0 && len(f.Decls) > 0 {
+		fm.blank()
+	}
+	fm.leadingComments(endOfFile)
+
+	return fm.buf.String(), nil
+}
+
+// endOfFile is a line number past the end of any file.
+const endOfFile = 1 << 30
+
+type formatter struct {
+	buf      strings.Builder
+	comments []*Comment // Not yet written, in source order
+}
+
+// formatLine is one line of a block: cells are aligned across the block.
+type formatLine struct {
+	cells   []string
+	comment string
+	first   int // First and last source line
+	last    int
+}
+
+func (fm *formatter) line(text, comment string) {
+	if comment != "" {
+		text += " " + comment
+	}
+	fm.buf.WriteString(text + "\n")
+}
+
+func (fm *formatter) blank() {
+	fm.buf.WriteString("\n")
+}
+
+// leadingComments writes the comments before line, keeping a blank line
+// where the source had one.
+func (fm *formatter) leadingComments(line int) {
+	prev := 0
+	for len(fm.comments) > 0 && fm.comments[0].Pos.Line < line {
+		c := fm.comments[0]
+		fm.comments = fm.comments[1:]
+		if prev > 0 && c.Pos.Line > prev+1 {
+			fm.blank()
+		}
+		fm.line(c.Text, "")
+		prev = c.Pos.Line
+	}
+	if prev > 0 && line != endOfFile && line > prev+1 {
+		fm.blank()
+	}
+}
+
+// trailing returns the comment at the end of line, if any.
+func (fm *formatter) trailing(line int) string {
+	if len(fm.comments) > 0 && fm.comments[0].Pos.Line == line {
+		c := fm.comments[0]
+		fm.comments = fm.comments[1:]
+		return c.Text
+	}
+	return ""
+}
+
+// ownLine returns the comments that start on a line of their own before
+// line, as block lines.
+func (fm *formatter) ownLine(line int) []formatLine {
+	var lines []formatLine
+	for len(fm.comments) > 0 && fm.comments[0].Pos.Line < line {
+		c := fm.comments[0]
+		fm.comments = fm.comments[1:]
+		lines = append(lines, formatLine{comment: c.Text, first: c.Pos.Line, last: c.Pos.Line})
+	}
+	return lines
+}
+
+func (fm *formatter) enum(e *EnumDecl) {
+	header := ""
+	if len(e.Values) == 0 || e.Values[0].Pos.Line != e.Pos.Line {
+		header = fm.trailing(e.Pos.Line)
+	}
+	fm.line("enum "+e.Name.Name+" {", header)
+
+	var lines []formatLine
+	for i, v := range e.Values {
+		lines = append(lines, fm.ownLine(v.Pos.Line)...)
+		l := formatLine{cells: []string{v.Name}, first: v.Pos.Line, last: v.Pos.Line}
+		// Values sharing a line are split up; the comment goes to the last
+		if i == len(e.Values)-1 || e.Values[i+1].Pos.Line != v.Pos.Line {
+			l.comment = fm.trailing(v.Pos.Line)
+		}
+		lines = append(lines, l)
+	}
+	lines = append(lines, fm.ownLine(e.End.Line)...)
+
+	fm.block(lines)
+	fm.line("}", fm.trailing(e.End.Line))
+}
+
+func (fm *formatter) model(m *ModelDecl) {
+	fm.line("model "+m.Name.Name+" {", fm.trailing(m.Pos.Line))
+
+	var lines []formatLine
+	for _, field := range m.Fields {
+		lines = append(lines, fm.ownLine(field.Pos.Line)...)
+		lines = append(lines, formatLine{
+			cells:   []string{field.Name.Name, formatType(field.Type), formatAttributes(field.Attributes)},
+			comment: fm.trailing(field.End.Line),
+			first:   field.Pos.Line,
+			last:    field.End.Line,
+		})
+	}
+
+	// Model attributes follow the fields, separated by a blank line
+	attrs := sortAttributes(m.Attributes)
+	for i, attr := range attrs {
+		comments := fm.ownLine(attr.Pos.Line)
+		if i == 0 && len(m.Fields) > 0 {
+			lines = append(lines, formatLine{})
+		}
+		lines = append(lines, comments...)
+		lines = append(lines, formatLine{
+			cells:   []string{formatAttribute(attr)},
+			comment: fm.trailing(attr.End.Line),
+			first:   attr.Pos.Line,
+			last:    attr.End.Line,
+		})
+	}
+	lines = append(lines, fm.ownLine(m.End.Line)...)
+
+	fm.block(lines)
+	fm.line("}", fm.trailing(m.End.Line))
+}
+
+// block writes the lines of a model or enum body. Field cells and trailing
+// comments are aligned; a blank line in the source is kept as one.
+func (fm *formatter) block(lines []formatLine) {
+	var widths []int
+	for _, l := range lines {
+		if len(l.cells) < 2 {
+			continue
+		}
+		for i, cell := range l.cells[:len(l.cells)-1] {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	// Trailing comments are aligned among lines of the same shape
+	texts := make([]string, len(lines))
+	commentCol := make(map[int]int)
+	for i, l := range lines {
+		var parts []string
+		for j, cell := range l.cells {
+			if j < len(l.cells)-1 && len(l.cells) > 1 {
+				cell += strings.Repeat(" ", widths[j]-len(cell))
+			}
+			parts = append(parts, cell)
+		}
+		texts[i] = strings.TrimRight(strings.Join(parts, " "), " ")
+		if l.comment != "" && texts[i] != "" {
+			commentCol[len(l.cells)] = max(commentCol[len(l.cells)], len(texts[i]))
+		}
+	}
+
+	prev := 0
+	for i, l := range lines {
+		if l.cells == nil && l.comment == "" {
+			// Explicit separator; never doubled
+			if prev != -1 {
+				fm.blank()
+			}
+			prev = -1
+			continue
+		}
+		if prev > 0 && l.first > prev+1 {
+			fm.blank()
+		}
+		// Sorted attributes may move up, so gaps count from the furthest line
+		prev = max(prev, l.last)
+
+		text := texts[i]
+		switch {
+		case text == "":
+			text = l.comment
+		case l.comment != "":
+			text += strings.Repeat(" ", commentCol[len(l.cells)]-len(text)) + " " + l.comment
+		}
+		fm.buf.WriteString("  " + text + "\n")
+	}
+}
+
+func formatType(t *TypeRef) string {
+	s := t.Name
+	if t.List {
+		s += "[]"
+	}
+	if t.Optional {
+		s += "?"
+	}
+	return s
+}
+
+func formatAttributes(attrs []*Attribute) string {
+	var parts []string
+	for _, attr := range sortAttributes(attrs) {
+		parts = append(parts, formatAttribute(attr))
+	}
+	return strings.Join(parts, " ")
+}
+
+// attributeOrder is the canonical order of field attributes; @@map comes
+// after indexes. Unknown attributes go last, in source order.
+var attributeOrder = map[string]int{
+	"id": 0, "autoincrement": 1, "auto": 1, "unsigned": 1, "unique": 2, "default": 3,
+	"length": 4, "size": 4, "precision": 5, "charset": 6, "collate": 7,
+	"email": 8, "min": 9, "max": 10, "regex": 11, "pii": 12, "gotype": 13, "comment": 14,
+	"relation": 15, "map": 16, "db": 16,
+}
+
+var modelAttributeOrder = map[string]int{
+	"index": 0, "unique": 0, "map": 1, "schema": 1, "charset": 2, "collate": 2, "partition": 3, "rls": 4, "policy": 5,
+	"comment": 6,
+}
+
+// relationArgOrder is the canonical order of @relation arguments. An
+// unnamed relation name must stay first.
+var relationArgOrder = map[string]int{
+	"": 0, "name": 1, "fields": 2, "references": 3, "onDelete": 4, "onUpdate": 5,
+}
+
+func sortAttributes(attrs []*Attribute) []*Attribute {
+	rank := func(a *Attribute) int {
+		order := attributeOrder
+		if a.Model {
+			order = modelAttributeOrder
+		}
+		if r, ok := order[strings.ToLower(a.Name)]; ok {
+			return r
+		}
+		return len(attributeOrder)
+	}
+	sorted := append([]*Attribute(nil), attrs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return rank(sorted[i]) < rank(sorted[j]) })
+	return sorted
+}
+
+func formatAttribute(a *Attribute) string {
+	s := "@" + a.Name
+	if a.Model {
+		s = "@" + s
+	}
+	if a.Args == nil {
+		return s
+	}
+	sorted := a.Args
+	if !a.Model && a.Name == "relation" {
+		sorted = append([]*Arg(nil), a.Args...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return relationArgOrder[sorted[i].Name] < relationArgOrder[sorted[j].Name]
+		})
+	}
+	args := make([]string, len(sorted))
+	for i, arg := range sorted {
+		args[i] = formatExpr(arg.Value)
+		if arg.Name != "" {
+			args[i] = arg.Name + ": " + args[i]
+		}
+	}
+	return s + "(" + strings.Join(args, ", ") + ")"
+}
+
+func formatExpr(e Expr) string {
+	switch e := e.(type) {
+	case *StringLit:
+		return strconv.Quote(e.Value)
+	case *NumberLit:
+		return e.Text
+	case *Ident:
+		return e.Name
+	case *CallExpr:
+		return e.Name.Name + "(" + formatExprs(e.Args) + ")"
+	case *ListExpr:
+		return "[" + formatExprs(e.Elems) + "]"
+	}
+	return ""
+}
+
+func formatExprs(exprs []Expr) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = formatExpr(e)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var functionNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// FunctionArg is an argument of a stored function.
+type FunctionArg struct {
+	Name string // May be empty for positional arguments ($1)
+	Type string // SQL type, e.g. text or integer
+}
+
+// Param returns a function argument of the given SQL type.
+func Param(name, sqlType string) FunctionArg {
+	return FunctionArg{Name: name, Type: sqlType}
+}
+
+// Function is a stored function or procedure. Migrations create it with
+// CREATE OR REPLACE and drop it on rollback.
+type Function struct {
+	Name      string
+	Args      []FunctionArg
+	Returns   string // SQL return type; empty for procedures
+	Body      string // Function body, without the surrounding quotes
+	Language  string // sql (default), plpgsql, ...
+	Procedure bool   // CREATE PROCEDURE instead of CREATE FUNCTION
+	Immutable bool   // Same result for the same arguments, so it may be used in indexes
+}
+
+// Function defines a stored function and returns it for further options.
+// Example: s.Function("slugify", []schema.FunctionArg{schema.Param("title", "text")}, "text",
+// "SELECT lower(regexp_replace(title, '[^a-zA-Z0-9]+', '-', 'g'))")
+func (s *Schema) Function(name string, args []FunctionArg, returns, body string) *Function {
+	f := &Function{Name: name, Args: args, Returns: returns, Body: body, Language: "sql"}
+	s.addFunction(f)
+	return f
+}
+
+// Procedure defines a stored procedure, run with CALL.
+func (s *Schema) Procedure(name string, args []FunctionArg, body string) *Function {
+	f := &Function{Name: name, Args: args, Body: body, Language: "sql", Procedure: true}
+	s.addFunction(f)
+	return f
+}
+
+func (s *Schema) addFunction(f *Function) {
+	if s.Functions == nil {
+		s.Functions = make(map[string]*Function)
+	}
+	s.Functions[f.Name] = f
+	s.functionList = append(s.functionList, f)
+}
+
+// GetFunctions returns functions and procedures in definition order.
+func (s *Schema) GetFunctions() []*Function {
+	return s.functionList
+}
+
+// Lang sets the language of the body, such as plpgsql.
+func (f *Function) Lang(language string) *Function {
+	f.Language = language
+	return f
+}
+
+// Pure marks the function immutable: its result only depends on its
+// arguments.
+func (f *Function) Pure() *Function {
+	f.Immutable = true
+	return f
+}
+
+// Signature returns the arguments as SQL, e.g. "title text, n integer".
+func (f *Function) Signature() string {
+	args := make([]string, len(f.Args))
+	for i, a := range f.Args {
+		args[i] = strings.TrimSpace(a.Name + " " + a.Type)
+	}
+	return strings.Join(args, ", ")
+}
+
+// validate reports what is wrong with the function, if anything.
+func (f *Function) validate() error {
+	kind := "function"
+	if f.Procedure {
+		kind = "procedure"
+	}
+	if !functionNamePattern.MatchString(f.Name) {
+		return fmt.Errorf("%s name %q is not a valid identifier", kind, f.Name)
+	}
+	if strings.TrimSpace(f.Body) == "" {
+		return fmt.Errorf("%s %q has no body", kind, f.Name)
+	}
+	if f.Procedure && f.Returns != "" {
+		return fmt.Errorf("procedure %q cannot return a value", f.Name)
+	}
+	if !f.Procedure && f.Returns == "" {
+		return fmt.Errorf("function %q has no return type", f.Name)
+	}
+	for _, a := range f.Args {
+		if a.Type == "" {
+			return fmt.Errorf("%s %q: argument %q has no type", kind, f.Name, a.Name)
+		}
+	}
+	return nil
+}
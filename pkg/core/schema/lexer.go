@@ -0,0 +1,255 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pos is a position in schema source. Line and Column are 1-based; Column
+// counts runes. Offset is the byte offset from the start of the source.
+type Pos struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// String returns the position as "line:column".
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// TokenKind identifies the kind of a token.
+type TokenKind int
+
+// Token kinds produced by Tokenize.
+const (
+	TokenEOF TokenKind = iota
+	TokenIllegal
+	TokenNewline
+	TokenComment
+	TokenIdent
+	TokenString
+	TokenNumber
+	TokenLBrace   // {
+	TokenRBrace   // }
+	TokenLParen   // (
+	TokenRParen   // )
+	TokenLBracket // [
+	TokenRBracket // ]
+	TokenComma    // ,
+	TokenColon    // :
+	TokenDot      // .
+	TokenQuestion // ?
+	TokenAt       // @
+	TokenAtAt     // @@
+)
+
+var tokenNames = map[TokenKind]string{
+	TokenEOF:      "end of file",
+	TokenIllegal:  "illegal character",
+	TokenNewline:  "end of line",
+	TokenComment:  "comment",
+	TokenIdent:    "identifier",
+	TokenString:   "string",
+	TokenNumber:   "number",
+	TokenLBrace:   "'{'",
+	TokenRBrace:   "'}'",
+	TokenLParen:   "'('",
+	TokenRParen:   "')'",
+	TokenLBracket: "'['",
+	TokenRBracket: "']'",
+	TokenComma:    "','",
+	TokenColon:    "':'",
+	TokenDot:      "'.'",
+	TokenQuestion: "'?'",
+	TokenAt:       "'@'",
+	TokenAtAt:     "'@@'",
+}
+
+// String returns a human-readable name for the token kind.
+func (k TokenKind) String() string {
+	if name, ok := tokenNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("token(%d)", int(k))
+}
+
+// Token is a lexical token with its source span. Text is the exact source
+// text, including quotes for strings and the // of comments.
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  Pos
+	End  Pos
+}
+
+// describe returns the token as it should appear in error messages.
+func (t Token) describe() string {
+	switch t.Kind {
+	case TokenEOF, TokenNewline:
+		return t.Kind.String()
+	default:
+		return fmt.Sprintf("'%s'", t.Text)
+	}
+}
+
+// Tokenize splits schema source into tokens. Newlines and comments are
+// returned as tokens because fields end at the end of a line and the
+// formatter keeps comments. The last token is always TokenEOF.
+func Tokenize(src string) []Token {
+	l := &lexer{src: src, pos: Pos{Line: 1, Column: 1}}
+	var tokens []Token
+	for {
+		tok := l.next()
+		tokens = append(tokens, tok)
+		if tok.Kind == TokenEOF {
+			return tokens
+		}
+	}
+}
+
+type lexer struct {
+	src string
+	pos Pos
+}
+
+func (l *lexer) peek(n int) byte {
+	if l.pos.Offset+n < len(l.src) {
+		return l.src[l.pos.Offset+n]
+	}
+	return 0
+}
+
+// advance moves past one rune, tracking lines and columns.
+func (l *lexer) advance() {
+	r, size := utf8.DecodeRuneInString(l.src[l.pos.Offset:])
+	l.pos.Offset += size
+	if r == '\n' {
+		l.pos.Line++
+		l.pos.Column = 1
+	} else {
+		l.pos.Column++
+	}
+}
+
+func (l *lexer) token(kind TokenKind, start Pos) Token {
+	return Token{Kind: kind, Text: l.src[start.Offset:l.pos.Offset], Pos: start, End: l.pos}
+}
+
+func (l *lexer) next() Token {
+	for {
+		c := l.peek(0)
+		if c != ' ' && c != '\t' && c != '\r' {
+			break
+		}
+		l.advance()
+	}
+
+	start := l.pos
+	if start.Offset >= len(l.src) {
+		return Token{Kind: TokenEOF, Pos: start, End: start}
+	}
+
+	c := l.peek(0)
+	switch {
+	case c == '\n':
+		l.advance()
+		// Keep the span on the line it ends so errors point there
+		end := Pos{Offset: l.pos.Offset, Line: start.Line, Column: start.Column + 1}
+		return Token{Kind: TokenNewline, Text: "\n", Pos: start, End: end}
+
+	case c == '/' && l.peek(1) == '/':
+		for l.pos.Offset < len(l.src) && l.peek(0) != '\n' {
+			l.advance()
+		}
+		tok := l.token(TokenComment, start)
+		tok.Text = strings.TrimRight(tok.Text, " \t\r")
+		return tok
+
+	case isIdentStart(c):
+		for isIdentChar(l.peek(0)) {
+			l.advance()
+		}
+		return l.token(TokenIdent, start)
+
+	case isDigit(c) || (c == '-' && isDigit(l.peek(1))):
+		l.advance()
+		for isDigit(l.peek(0)) {
+			l.advance()
+		}
+		if l.peek(0) == '.' && isDigit(l.peek(1)) {
+			l.advance()
+			for isDigit(l.peek(0)) {
+				l.advance()
+			}
+		}
+		return l.token(TokenNumber, start)
+
+	case c == '"' || c == '\'':
+		l.advance()
+		for {
+			switch l.peek(0) {
+			case 0, '\n':
+				// Unterminated string
+				return l.token(TokenIllegal, start)
+			case '\\':
+				l.advance()
+				if l.peek(0) != 0 && l.peek(0) != '\n' {
+					l.advance()
+				}
+			case c:
+				l.advance()
+				return l.token(TokenString, start)
+			default:
+				l.advance()
+			}
+		}
+
+	case c == '@':
+		l.advance()
+		if l.peek(0) == '@' {
+			l.advance()
+			return l.token(TokenAtAt, start)
+		}
+		return l.token(TokenAt, start)
+	}
+
+	kind := TokenIllegal
+	switch c {
+	case '{':
+		kind = TokenLBrace
+	case '}':
+		kind = TokenRBrace
+	case '(':
+		kind = TokenLParen
+	case ')':
+		kind = TokenRParen
+	case '[':
+		kind = TokenLBracket
+	case ']':
+		kind = TokenRBracket
+	case ',':
+		kind = TokenComma
+	case ':':
+		kind = TokenColon
+	case '.':
+		kind = TokenDot
+	case '?':
+		kind = TokenQuestion
+	}
+	l.advance()
+	return l.token(kind, start)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,162 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	nxerr "github.com/nexus-db/nexus/pkg/errors"
+)
+
+// SchemaExt is the file extension of schema files.
+const SchemaExt = ".nexus"
+
+// ParseFile parses a schema from path. path may be a single .nexus file or a
+// directory, in which case every .nexus file directly inside it is loaded in
+// name order. import and include directives are resolved relative to the
+// file that contains them, and each file is loaded at most once.
+//
+// Models from all files are merged into one schema, so a field may reference
+// a model declared in any loaded file.
+func ParseFile(path string) (*Schema, error) {
+	return ParseFileOverlay(path, nil)
+}
+
+// ParseFileOverlay is like ParseFile, but files whose absolute path is a
+// key of overlay are read from the map instead of disk. Editors use this to
+// check unsaved buffers.
+func ParseFileOverlay(path string, overlay map[string]string) (*Schema, error) {
+	files := []string{path}
+	abs, _ := filepath.Abs(path)
+	if _, open := overlay[abs]; !open {
+		var err error
+		if files, err = SchemaFiles(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l := &loader{
+		schema:  NewSchema(),
+		loaded:  make(map[string]bool),
+		origin:  make(map[string]string),
+		overlay: overlay,
+	}
+	for _, file := range files {
+		if err := l.load(file); err != nil {
+			return nil, err
+		}
+	}
+	l.errors = append(l.errors, resolveRelations(l.schema, l.relations)...)
+
+	if len(l.errors) > 0 {
+		return nil, formatErrors(l.errors)
+	}
+	return l.schema, nil
+}
+
+// SchemaFiles returns the schema files at path: the file itself, or the
+// sorted .nexus files in a directory. Imported files are not included.
+func SchemaFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*"+SchemaExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no %s files found in %s", SchemaExt, path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loader merges several schema files into one schema.
+type loader struct {
+	schema    *Schema
+	loaded    map[string]bool   // Absolute paths already parsed
+	origin    map[string]string // Model name -> file that declared it
+	relations []relationDecl
+	errors    []*nxerr.NexusError
+	overlay   map[string]string // Absolute path -> unsaved content
+}
+
+// load parses one file and, recursively, the files it imports.
+func (l *loader) load(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if l.loaded[abs] {
+		return nil
+	}
+	l.loaded[abs] = true
+
+	content, ok := l.overlay[abs]
+	if !ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content = string(data)
+	}
+
+	p := NewParser(content)
+	p.file = path
+	f := p.parseFile()
+	s := p.build(f)
+
+	for _, e := range s.GetEnums() {
+		if l.declare(p, f, e.Name) {
+			l.schema.addEnum(e)
+		}
+	}
+	for _, model := range s.GetModels() {
+		if l.declare(p, f, model.Name) {
+			l.schema.Models[model.Name] = model
+			l.schema.modelList = append(l.schema.modelList, model)
+		}
+	}
+
+	// Relation fields of models that lost to an earlier declaration are dropped
+	for _, d := range p.relations {
+		if l.origin[d.Model] == path {
+			l.relations = append(l.relations, d)
+		}
+	}
+
+	dir := filepath.Dir(path)
+	for _, imp := range p.imports {
+		matches, err := filepath.Glob(filepath.Join(dir, imp.Path.Value))
+		if err != nil || len(matches) == 0 {
+			p.addError(nxerr.ErrSchemaInvalidImport, fmt.Sprintf("Imported schema '%s' not found", imp.Path.Value), imp.Path)
+			continue
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if err := l.load(match); err != nil {
+				return err
+			}
+		}
+	}
+
+	l.errors = append(l.errors, p.errors...)
+	return nil
+}
+
+// declare records that the file parsed by p declares name, reporting an
+// error if another file already declared a model or enum with that name.
+func (l *loader) declare(p *Parser, f *File, name string) bool {
+	if first, ok := l.origin[name]; ok {
+		p.addError(nxerr.ErrSchemaDuplicateModel, fmt.Sprintf("'%s' is already declared in %s", name, first), declName(f.Lookup(name)))
+		return false
+	}
+	l.origin[name] = p.file
+	return true
+}
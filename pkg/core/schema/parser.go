@@ -2,10 +2,8 @@
 package schema
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -13,338 +11,585 @@ import (
 )
 
 // Parser parses .nexus schema files.
+//
+// Parsing happens in two steps: the tokens are parsed into a syntax tree
+// (see File), which is then lowered into a Schema. Both steps recover from
+// errors, so a single run reports every problem in the file.
 type Parser struct {
-	input  string
-	lines  []string // All lines for context
-	pos    int
-	line   int
-	col    int
-	errors []*nxerr.NexusError
+	input    string
+	lines    []string // All lines for context
+	tokens   []Token  // Tokens without comments
+	comments []*Comment
+	pos      int // Index of the current token
+	errors   []*nxerr.NexusError
+
+	file      string         // Source file, set when loaded via ParseFile
+	imports   []*ImportDecl  // import/include directives in this file
+	relations []relationDecl // Relation fields, resolved after parsing
 }
 
+// relationDecl is a field typed with a model or enum name. The target may be
+// declared in another file, so these fields are resolved once all files are
+// loaded: enum fields keep their placeholder column, relations drop it.
+type relationDecl struct {
+	Model      string
+	Field      string
+	Target     string
+	Name       string   // Optional relation name: @relation("Name")
+	List       bool     // Target[] declares the many side
+	Fields     []string // Foreign key fields on this model
+	References []string // Referenced fields on the target model
+	OnDelete   CascadeAction
+	OnUpdate   CascadeAction
+	Relation   bool     // Has a @relation attribute
+	Modifiers  []string // Column modifiers, only valid if the target is an enum
+	Column     *Field   // Placeholder column for singular fields
+	Decl       *FieldDecl
+	File       string
+	Context    string
+}
+
+// spanner is any syntax node or token with a source span.
+type spanner interface {
+	Span() (Pos, Pos)
+}
+
+// Span returns the start and end of the token.
+func (t Token) Span() (Pos, Pos) { return t.Pos, t.End }
+
 // NewParser creates a new parser for the given input.
 func NewParser(input string) *Parser {
-	return &Parser{
+	p := &Parser{
 		input: input,
-		line:  1,
-		col:   1,
+		lines: strings.Split(input, "\n"),
+	}
+	for _, tok := range Tokenize(input) {
+		if tok.Kind == TokenComment {
+			p.comments = append(p.comments, &Comment{Text: tok.Text, Pos: tok.Pos, End: tok.End})
+			continue
+		}
+		p.tokens = append(p.tokens, tok)
 	}
+	return p
 }
 
 // Parse parses the input and returns a Schema.
+// Relation fields must refer to models declared in the same input; use
+// ParseFile to load schemas split across several files.
 func (p *Parser) Parse() (*Schema, error) {
-	schema := NewSchema()
-
-	scanner := bufio.NewScanner(strings.NewReader(p.input))
-	var currentModel *Model
-	var inModel bool
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		p.line++
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "//") {
-			continue
-		}
-
-		// Model definition start
-		if strings.HasPrefix(line, "model ") {
-			name := p.parseModelName(line)
-			if name == "" {
-				p.addError(nxerr.ErrSchemaInvalidModel, "Invalid model definition", line).
-					WithSuggestion("Use format: model ModelName {")
-				continue
-			}
-			currentModel = &Model{
-				Name:   name,
-				Fields: make(map[string]*Field),
-			}
-			inModel = true
-			continue
-		}
-
-		// Model definition end
-		if line == "}" && inModel {
-			schema.Models[currentModel.Name] = currentModel
-			schema.modelList = append(schema.modelList, currentModel)
-			currentModel = nil
-			inModel = false
-			continue
-		}
-
-		// Field definition inside model
-		if inModel && currentModel != nil {
-			field, nxErr := p.parseField(line)
-			if nxErr != nil {
-				p.errors = append(p.errors, nxErr)
-				continue
-			}
-			if field != nil {
-				field.Model = currentModel
-				currentModel.Fields[field.Name] = field
-				currentModel.fieldList = append(currentModel.fieldList, field)
-			}
-		}
-	}
+	schema := p.parse()
+	p.errors = append(p.errors, resolveRelations(schema, p.relations)...)
 
 	if len(p.errors) > 0 {
-		return nil, p.formatErrors()
+		return nil, formatErrors(p.errors)
 	}
 
 	return schema, nil
 }
 
-func (p *Parser) parseModelName(line string) string {
-	// model User { or model User{
-	re := regexp.MustCompile(`^model\s+(\w+)\s*\{?$`)
-	matches := re.FindStringSubmatch(line)
-	if len(matches) < 2 {
-		return ""
-	}
-	return matches[1]
+// ParseAST parses the input into a syntax tree without building a schema.
+// The tree is returned even if there are syntax errors; nodes that could not
+// be parsed are left out.
+func (p *Parser) ParseAST() (*File, error) {
+	f := p.parseFile()
+	return f, formatErrors(p.errors)
 }
 
-func (p *Parser) parseField(line string) (*Field, *nxerr.NexusError) {
-	// Skip closing brace or empty
-	if line == "}" || line == "{" {
-		return nil, nil
-	}
+// parse builds the models of a single file without resolving relations.
+func (p *Parser) parse() *Schema {
+	return p.build(p.parseFile())
+}
 
-	// Parse: fieldName Type @modifier1 @modifier2(arg)
-	parts := strings.Fields(line)
-	if len(parts) < 2 {
-		return nil, p.makeError(nxerr.ErrSchemaInvalidField, "Invalid field definition", line).
-			WithSuggestion("Use format: fieldName Type @modifier")
-	}
+// Token helpers
 
-	fieldName := parts[0]
-	fieldType := parts[1]
+func (p *Parser) cur() Token {
+	return p.tokens[p.pos]
+}
 
-	// Handle nullable types (Type?)
-	nullable := strings.HasSuffix(fieldType, "?")
-	if nullable {
-		fieldType = strings.TrimSuffix(fieldType, "?")
+func (p *Parser) peek(n int) Token {
+	if p.pos+n < len(p.tokens) {
+		return p.tokens[p.pos+n]
 	}
+	return p.tokens[len(p.tokens)-1]
+}
 
-	// Handle array types (Type[])
-	isArray := strings.HasSuffix(fieldType, "[]")
-	if isArray {
-		fieldType = strings.TrimSuffix(fieldType, "[]")
-		// Array types represent relations, skip for now
-		return nil, nil
-	}
+func (p *Parser) at(kind TokenKind) bool {
+	return p.cur().Kind == kind
+}
 
-	// Validate type and get suggestions for unknown types
-	parsedType, typeErr := p.parseFieldTypeWithValidation(fieldType, line)
-	if typeErr != nil {
-		return nil, typeErr
+func (p *Parser) advance() Token {
+	tok := p.cur()
+	if tok.Kind != TokenEOF {
+		p.pos++
 	}
+	return tok
+}
 
-	field := &Field{
-		Name:     fieldName,
-		Type:     parsedType,
-		Nullable: nullable,
+func (p *Parser) skipNewlines() {
+	for p.at(TokenNewline) {
+		p.advance()
 	}
+}
 
-	// Parse modifiers
-	for i := 2; i < len(parts); i++ {
-		modifier := parts[i]
-		if err := p.applyModifier(field, modifier); err != nil {
-			return nil, p.makeError(nxerr.ErrSchemaInvalidModifier, err.Error(), line).
-				WithSuggestion(nxerr.Suggestions[nxerr.ErrSchemaInvalidModifier])
+// skipLine skips the rest of a broken line, stopping before the newline or
+// before a closing brace that ends the enclosing block.
+func (p *Parser) skipLine() {
+	depth := 0
+	for {
+		switch p.cur().Kind {
+		case TokenEOF, TokenNewline:
+			return
+		case TokenRBrace:
+			if depth <= 0 {
+				return
+			}
+			depth--
+		case TokenLBrace, TokenLParen, TokenLBracket:
+			depth++
+		case TokenRParen, TokenRBracket:
+			depth--
 		}
+		p.advance()
 	}
+}
 
-	return field, nil
-}
-
-func (p *Parser) parseFieldTypeWithValidation(typeName, context string) (FieldType, *nxerr.NexusError) {
-	switch strings.ToLower(typeName) {
-	case "int", "integer":
-		return FieldTypeInt, nil
-	case "bigint":
-		return FieldTypeBigInt, nil
-	case "string", "varchar":
-		return FieldTypeString, nil
-	case "text":
-		return FieldTypeText, nil
-	case "bool", "boolean":
-		return FieldTypeBool, nil
-	case "float", "double":
-		return FieldTypeFloat, nil
-	case "decimal", "numeric":
-		return FieldTypeDecimal, nil
-	case "datetime", "timestamp":
-		return FieldTypeDateTime, nil
-	case "date":
-		return FieldTypeDate, nil
-	case "time":
-		return FieldTypeTime, nil
-	case "json", "jsonb":
-		return FieldTypeJSON, nil
-	case "bytes", "blob", "binary":
-		return FieldTypeBytes, nil
-	case "uuid":
-		return FieldTypeUUID, nil
-	default:
-		// Check if it looks like a relation (capitalized) - allow it
-		if len(typeName) > 0 && typeName[0] >= 'A' && typeName[0] <= 'Z' {
-			return FieldTypeString, nil // Treat as relation reference
+// skipDecl skips a broken top-level declaration: the rest of the line and,
+// if the line opens a block, everything up to its closing brace.
+func (p *Parser) skipDecl() {
+	for !p.at(TokenNewline) && !p.at(TokenEOF) {
+		if p.advance().Kind != TokenLBrace {
+			continue
 		}
-
-		// Unknown type - suggest similar
-		suggestion := nxerr.SuggestSimilar(typeName, nxerr.ValidTypes)
-		if suggestion == "" {
-			suggestion = nxerr.Suggestions[nxerr.ErrSchemaUnknownType]
+		for depth := 1; depth > 0 && !p.at(TokenEOF); {
+			switch p.advance().Kind {
+			case TokenLBrace:
+				depth++
+			case TokenRBrace:
+				depth--
+			}
 		}
-		return FieldTypeString, p.makeError(nxerr.ErrSchemaUnknownType,
-			fmt.Sprintf("Unknown type '%s'", typeName), context).WithSuggestion(suggestion)
+		return
 	}
 }
 
-func (p *Parser) parseFieldType(typeName string) FieldType {
-	ft, _ := p.parseFieldTypeWithValidation(typeName, "")
-	return ft
+// atDeclStart reports whether the current line starts a new model or enum,
+// which means the previous block was not closed.
+func (p *Parser) atDeclStart() bool {
+	tok := p.cur()
+	return tok.Kind == TokenIdent && (tok.Text == "model" || tok.Text == "enum") &&
+		p.peek(1).Kind == TokenIdent && p.peek(2).Kind == TokenLBrace
+}
+
+// unexpected reports tok as unexpected, describing unterminated strings.
+func (p *Parser) unexpected(code nxerr.ErrorCode, tok Token, where string) *nxerr.NexusError {
+	if tok.Kind == TokenIllegal && (strings.HasPrefix(tok.Text, `"`) || strings.HasPrefix(tok.Text, "'")) {
+		return p.addError(code, "Unterminated string", tok).
+			WithSuggestion("Close the string with a matching quote on the same line")
+	}
+	return p.addError(code, fmt.Sprintf("Unexpected %s %s", tok.describe(), where), tok)
 }
 
-func (p *Parser) applyModifier(field *Field, modifier string) error {
-	// Handle modifiers like @id, @unique, @default(value)
-	modifier = strings.TrimPrefix(modifier, "@")
+// expectLineEnd reports anything left on the line after a complete field,
+// attribute or directive.
+func (p *Parser) expectLineEnd(code nxerr.ErrorCode, where string) {
+	switch p.cur().Kind {
+	case TokenNewline, TokenEOF, TokenRBrace:
+		return
+	}
+	p.unexpected(code, p.cur(), where)
+	p.skipLine()
+}
 
-	// Check for parentheses (modifier with args)
-	if strings.Contains(modifier, "(") {
-		name := strings.Split(modifier, "(")[0]
-		argPart := strings.TrimSuffix(strings.Split(modifier, "(")[1], ")")
+// Syntax
 
-		switch strings.ToLower(name) {
-		case "default":
-			return p.parseDefault(field, argPart)
-		case "db", "map":
-			// Column name mapping, ignore for now
-		case "relation":
-			// Relation config, ignore for now
-		case "length", "size":
-			if length, err := strconv.Atoi(argPart); err == nil {
-				field.Length = length
+func (p *Parser) parseFile() *File {
+	f := &File{Comments: p.comments}
+	for {
+		p.skipNewlines()
+		tok := p.cur()
+		if tok.Kind == TokenEOF {
+			return f
+		}
+
+		var decl Decl
+		switch {
+		case tok.Kind == TokenIdent && tok.Text == "model":
+			if m := p.parseModel(); m != nil {
+				decl = m
 			}
-		case "precision":
-			parts := strings.Split(argPart, ",")
-			if len(parts) >= 1 {
-				if prec, err := strconv.Atoi(strings.TrimSpace(parts[0])); err == nil {
-					field.Precision = prec
-				}
+		case tok.Kind == TokenIdent && tok.Text == "enum":
+			if e := p.parseEnum(); e != nil {
+				decl = e
 			}
-			if len(parts) >= 2 {
-				if scale, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
-					field.Scale = scale
-				}
+		case tok.Kind == TokenIdent && (tok.Text == "import" || tok.Text == "include"):
+			if imp := p.parseImport(); imp != nil {
+				decl = imp
 			}
+		default:
+			p.unexpected(nxerr.ErrSchemaInvalidModel, tok, "at the top level").
+				WithSuggestion("Top-level declarations are model, enum, import and include")
+			p.skipDecl()
 		}
-	} else {
-		// Simple modifier without args
-		switch strings.ToLower(modifier) {
-		case "id":
-			field.IsPrimaryKey = true
-		case "unique":
-			field.IsUnique = true
-		case "autoincrement", "auto":
-			field.AutoIncrement = true
+		if decl != nil {
+			f.Decls = append(f.Decls, decl)
 		}
 	}
+}
 
-	return nil
+// parseImport parses import "path.nexus" or include "dir/*.nexus".
+func (p *Parser) parseImport() *ImportDecl {
+	kw := p.advance()
+	if !p.at(TokenString) {
+		p.addError(nxerr.ErrSchemaInvalidImport, "Invalid import directive", p.cur()).
+			WithSuggestion(`Use format: import "billing.nexus" or include "domains/*.nexus"`)
+		p.skipLine()
+		return nil
+	}
+	path := p.parseString(p.advance())
+	p.expectLineEnd(nxerr.ErrSchemaInvalidImport, "after "+kw.Text)
+	return &ImportDecl{Keyword: kw.Text, Path: path, Pos: kw.Pos, End: path.End}
 }
 
-func (p *Parser) parseDefault(field *Field, value string) error {
-	value = strings.TrimSpace(value)
+// parseEnum parses enum Role { ADMIN USER }, with values separated by
+// spaces, commas or newlines.
+func (p *Parser) parseEnum() *EnumDecl {
+	kw := p.advance()
+	if !p.at(TokenIdent) {
+		p.addError(nxerr.ErrSchemaInvalidEnum, "Invalid enum definition", p.cur()).
+			WithSuggestion("Use format: enum Role { ADMIN USER }")
+		p.skipDecl()
+		return nil
+	}
+	e := &EnumDecl{Name: p.parseIdent(), Pos: kw.Pos}
 
-	// Check for function calls
-	if strings.HasSuffix(value, "()") {
-		funcName := strings.TrimSuffix(value, "()")
-		switch strings.ToLower(funcName) {
-		case "now", "current_timestamp":
-			field.DefaultExpr = "NOW()"
-		case "uuid", "gen_random_uuid":
-			field.DefaultExpr = "UUID()"
+	p.skipNewlines()
+	if !p.at(TokenLBrace) {
+		p.addError(nxerr.ErrSchemaInvalidEnum, "Invalid enum definition", p.cur()).
+			WithSuggestion("Use format: enum Role { ADMIN USER }")
+		p.skipDecl()
+		return nil
+	}
+	p.advance()
+
+	for {
+		tok := p.cur()
+		switch {
+		case tok.Kind == TokenRBrace:
+			e.End = p.advance().End
+			return e
+		case tok.Kind == TokenEOF || p.atDeclStart():
+			p.addError(nxerr.ErrSchemaInvalidEnum, fmt.Sprintf("Enum '%s' is missing its closing brace", e.Name.Name), e.Name)
+			e.End = tok.Pos
+			return e
+		case tok.Kind == TokenNewline || tok.Kind == TokenComma:
+			p.advance()
+		case tok.Kind == TokenIdent:
+			e.Values = append(e.Values, p.parseIdent())
 		default:
-			field.DefaultExpr = value
+			p.addError(nxerr.ErrSchemaInvalidEnum, fmt.Sprintf("Invalid value %s in enum %s", tok.describe(), e.Name.Name), tok).
+				WithSuggestion("Enum values must be identifiers, e.g.: ADMIN")
+			p.advance()
 		}
-		return nil
 	}
+}
 
-	// String literal
-	if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-		field.DefaultValue = strings.Trim(value, "\"")
+// parseModel parses a model block. A broken field or attribute is reported
+// and skipped; parsing continues with the next line.
+func (p *Parser) parseModel() *ModelDecl {
+	kw := p.advance()
+	if !p.at(TokenIdent) {
+		p.addError(nxerr.ErrSchemaInvalidModel, "Invalid model definition", p.cur()).
+			WithSuggestion("Use format: model ModelName {")
+		p.skipDecl()
 		return nil
 	}
-	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
-		field.DefaultValue = strings.Trim(value, "'")
+	m := &ModelDecl{Name: p.parseIdent(), Pos: kw.Pos}
+
+	p.skipNewlines()
+	if !p.at(TokenLBrace) {
+		p.addError(nxerr.ErrSchemaInvalidModel, "Invalid model definition", p.cur()).
+			WithSuggestion("Use format: model ModelName {")
+		p.skipDecl()
 		return nil
 	}
+	p.advance()
+
+	for {
+		p.skipNewlines()
+		tok := p.cur()
+		switch {
+		case tok.Kind == TokenRBrace:
+			m.End = p.advance().End
+			return m
+		case tok.Kind == TokenEOF || p.atDeclStart():
+			p.addError(nxerr.ErrSchemaInvalidModel, fmt.Sprintf("Model '%s' is missing its closing brace", m.Name.Name), m.Name)
+			m.End = tok.Pos
+			return m
+		case tok.Kind == TokenAtAt:
+			if attr := p.parseAttribute(); attr != nil {
+				m.Attributes = append(m.Attributes, attr)
+				p.expectLineEnd(nxerr.ErrSchemaInvalidModifier, "after model attribute")
+			}
+		case tok.Kind == TokenIdent:
+			if f := p.parseField(); f != nil {
+				m.Fields = append(m.Fields, f)
+			}
+		default:
+			p.unexpected(nxerr.ErrSchemaInvalidField, tok, "in model "+m.Name.Name).
+				WithSuggestion("Use format: fieldName Type @modifier")
+			p.skipLine()
+		}
+	}
+}
+
+// parseField parses: fieldName Type[]? @modifier1 @modifier2(arg)
+func (p *Parser) parseField() *FieldDecl {
+	f := &FieldDecl{Name: p.parseIdent()}
+	f.Pos = f.Name.Pos
 
-	// Boolean
-	if value == "true" {
-		field.DefaultValue = true
+	if !p.at(TokenIdent) {
+		msg := "Invalid field definition"
+		if p.at(TokenNewline) || p.at(TokenEOF) || p.at(TokenRBrace) {
+			msg = fmt.Sprintf("Field '%s' is missing a type", f.Name.Name)
+		}
+		p.addError(nxerr.ErrSchemaInvalidField, msg, p.cur()).
+			WithSuggestion("Use format: fieldName Type @modifier")
+		p.skipLine()
 		return nil
 	}
-	if value == "false" {
-		field.DefaultValue = false
-		return nil
+
+	typeTok := p.advance()
+	f.Type = &TypeRef{Name: typeTok.Text, Pos: typeTok.Pos, End: typeTok.End}
+	if p.at(TokenLBracket) {
+		p.advance()
+		if !p.at(TokenRBracket) {
+			p.unexpected(nxerr.ErrSchemaInvalidField, p.cur(), "in list type, expected ']'").
+				WithSuggestion("List types are written as: posts Post[]")
+			p.skipLine()
+			return nil
+		}
+		f.Type.List = true
+		f.Type.End = p.advance().End
+	}
+	if p.at(TokenQuestion) {
+		f.Type.Optional = true
+		f.Type.End = p.advance().End
 	}
+	f.End = f.Type.End
 
-	// Number
-	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
-		field.DefaultValue = i
-		return nil
+	for p.at(TokenAt) {
+		attr := p.parseAttribute()
+		if attr == nil {
+			return f
+		}
+		f.Attributes = append(f.Attributes, attr)
+		f.End = attr.End
 	}
-	if f, err := strconv.ParseFloat(value, 64); err == nil {
-		field.DefaultValue = f
+
+	p.expectLineEnd(nxerr.ErrSchemaInvalidField, fmt.Sprintf("after field '%s'", f.Name.Name))
+	return f
+}
+
+// parseAttribute parses @name, @name(args), @@name(args) and dotted names
+// such as @db.VarChar(255). On error the rest of the line is skipped.
+func (p *Parser) parseAttribute() *Attribute {
+	at := p.advance()
+	attr := &Attribute{Model: at.Kind == TokenAtAt, Pos: at.Pos}
+
+	if !p.at(TokenIdent) || p.cur().Pos.Offset != at.End.Offset {
+		p.addError(nxerr.ErrSchemaInvalidModifier, fmt.Sprintf("Expected an attribute name after '%s'", at.Text), at).
+			WithSuggestion(nxerr.Suggestions[nxerr.ErrSchemaInvalidModifier])
+		p.skipLine()
 		return nil
 	}
+	name := p.advance()
+	attr.Name, attr.End = name.Text, name.End
+	for p.at(TokenDot) && p.peek(1).Kind == TokenIdent {
+		p.advance()
+		part := p.advance()
+		attr.Name += "." + part.Text
+		attr.End = part.End
+	}
+
+	if !p.at(TokenLParen) {
+		return attr
+	}
+
+	code := nxerr.ErrSchemaInvalidModifier
+	if attr.Name == "relation" {
+		code = nxerr.ErrSchemaInvalidRelation
+	}
+
+	p.advance()
+	attr.Args = []*Arg{}
+	for {
+		if p.at(TokenRParen) {
+			attr.End = p.advance().End
+			return attr
+		}
+
+		arg := &Arg{Pos: p.cur().Pos}
+		if p.at(TokenIdent) && p.peek(1).Kind == TokenColon {
+			arg.Name = p.advance().Text
+			p.advance()
+		}
+		arg.Value = p.parseExpr(code)
+		if arg.Value == nil {
+			p.skipLine()
+			return nil
+		}
+		_, arg.End = arg.Value.Span()
+		attr.Args = append(attr.Args, arg)
+
+		switch p.cur().Kind {
+		case TokenComma:
+			p.advance()
+		case TokenRParen:
+		default:
+			p.unexpected(code, p.cur(), fmt.Sprintf("in %s, expected ',' or ')'", attr.display()))
+			p.skipLine()
+			return nil
+		}
+	}
+}
+
+// parseExpr parses an attribute argument value.
+func (p *Parser) parseExpr(code nxerr.ErrorCode) Expr {
+	tok := p.cur()
+	switch tok.Kind {
+	case TokenString:
+		return p.parseString(p.advance())
+
+	case TokenNumber:
+		p.advance()
+		return &NumberLit{Text: tok.Text, Pos: tok.Pos, End: tok.End}
+
+	case TokenIdent:
+		name := p.parseIdent()
+		if !p.at(TokenLParen) {
+			return name
+		}
+		p.advance()
+		call := &CallExpr{Name: name, Pos: name.Pos}
+		for !p.at(TokenRParen) {
+			arg := p.parseExpr(code)
+			if arg == nil {
+				return nil
+			}
+			call.Args = append(call.Args, arg)
+			if !p.at(TokenComma) {
+				break
+			}
+			p.advance()
+		}
+		if !p.at(TokenRParen) {
+			p.unexpected(code, p.cur(), fmt.Sprintf("in call to %s(), expected ')'", name.Name))
+			return nil
+		}
+		call.End = p.advance().End
+		return call
+
+	case TokenLBracket:
+		p.advance()
+		list := &ListExpr{Pos: tok.Pos}
+		for {
+			if p.at(TokenRBracket) {
+				list.End = p.advance().End
+				return list
+			}
+			elem := p.parseExpr(code)
+			if elem == nil {
+				return nil
+			}
+			list.Elems = append(list.Elems, elem)
+			switch p.cur().Kind {
+			case TokenComma:
+				p.advance()
+			case TokenRBracket:
+			default:
+				p.unexpected(code, p.cur(), "in list, expected ',' or ']'")
+				return nil
+			}
+		}
+	}
 
-	field.DefaultValue = value
+	p.unexpected(code, tok, "where a value was expected")
 	return nil
 }
 
-// ParseFile parses a .nexus file from the given path.
-func ParseFile(path string) (*Schema, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+func (p *Parser) parseIdent() *Ident {
+	tok := p.advance()
+	return &Ident{Name: tok.Text, Pos: tok.Pos, End: tok.End}
+}
+
+func (p *Parser) parseString(tok Token) *StringLit {
+	value := tok.Text[1 : len(tok.Text)-1]
+	if tok.Text[0] == '"' {
+		if s, err := strconv.Unquote(tok.Text); err == nil {
+			value = s
+		}
 	}
-	return NewParser(string(content)).Parse()
+	return &StringLit{Value: value, Pos: tok.Pos, End: tok.End}
+}
+
+// display returns the attribute as written: @name or @@name.
+func (a *Attribute) display() string {
+	if a.Model {
+		return "@@" + a.Name
+	}
+	return "@" + a.Name
 }
 
 // Helper methods for structured errors
 
-func (p *Parser) addError(code nxerr.ErrorCode, message, context string) *nxerr.NexusError {
-	err := p.makeError(code, message, context)
+func (p *Parser) addError(code nxerr.ErrorCode, message string, n spanner) *nxerr.NexusError {
+	err := p.makeError(code, message, n)
 	p.errors = append(p.errors, err)
 	return err
 }
 
-func (p *Parser) makeError(code nxerr.ErrorCode, message, context string) *nxerr.NexusError {
-	return &nxerr.NexusError{
-		Code:    code,
-		Message: message,
-		Line:    p.line,
-		Context: context,
-	}
+func (p *Parser) makeError(code nxerr.ErrorCode, message string, n spanner) *nxerr.NexusError {
+	start, _ := n.Span()
+	return newError(code, message, p.file, n, p.lineText(start.Line))
 }
 
-func (p *Parser) formatErrors() error {
-	if len(p.errors) == 0 {
-		return nil
+// lineText returns source line n (1-based) for error context.
+func (p *Parser) lineText(n int) string {
+	if n < 1 || n > len(p.lines) {
+		return ""
 	}
+	return strings.TrimRight(p.lines[n-1], "\r")
+}
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Schema parsing failed with %d error(s):\n\n", len(p.errors)))
-
-	for _, e := range p.errors {
-		sb.WriteString(e.Print())
-		sb.WriteString("\n")
+// newError creates an error spanning the node n.
+func newError(code nxerr.ErrorCode, message, file string, n spanner, context string) *nxerr.NexusError {
+	start, end := n.Span()
+	return &nxerr.NexusError{
+		Code:      code,
+		Message:   message,
+		File:      file,
+		Line:      start.Line,
+		Column:    start.Column,
+		EndLine:   end.Line,
+		EndColumn: end.Column,
+		Context:   context,
 	}
+}
 
-	return fmt.Errorf("%s", sb.String())
+// formatErrors combines errs into a ParseError, ordered by location.
+func formatErrors(errs []*nxerr.NexusError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errs[i], errs[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return &ParseError{Errors: errs}
 }
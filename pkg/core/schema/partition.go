@@ -0,0 +1,181 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PartitionStrategy is how rows of a partitioned table are split.
+type PartitionStrategy string
+
+const (
+	Range PartitionStrategy = "RANGE" // By ranges of a date or time column
+	Hash  PartitionStrategy = "HASH"  // Evenly by a hash of the columns
+)
+
+// PartitionInterval is the span of each partition of a range partitioned table.
+type PartitionInterval string
+
+const (
+	Daily   PartitionInterval = "daily"
+	Monthly PartitionInterval = "monthly"
+	Yearly  PartitionInterval = "yearly"
+)
+
+// Partitioning describes how a model's table is partitioned.
+type Partitioning struct {
+	Strategy PartitionStrategy
+	Columns  []string
+	Interval PartitionInterval // Range: partitions created by `nexus db partitions ensure`
+	Count    int               // Hash: number of partitions
+}
+
+// PartitionBy partitions the model's table by columns.
+// Example: m.PartitionBy(schema.Range, "created_at").Monthly()
+func (m *Model) PartitionBy(strategy PartitionStrategy, columns ...string) *Partitioning {
+	m.Partition = &Partitioning{Strategy: strategy, Columns: columns}
+	return m.Partition
+}
+
+// Every sets the span of each range partition.
+func (p *Partitioning) Every(interval PartitionInterval) *Partitioning {
+	p.Interval = interval
+	return p
+}
+
+// Monthly gives each month a range partition.
+func (p *Partitioning) Monthly() *Partitioning {
+	return p.Every(Monthly)
+}
+
+// Into splits a hash partitioned table into n partitions.
+func (p *Partitioning) Into(n int) *Partitioning {
+	p.Count = n
+	return p
+}
+
+// Partition is one partition of a table.
+type Partition struct {
+	Suffix    string    // Appended to the table name, e.g. 2026_10 or p0
+	From, To  time.Time // Range: rows from From up to, not including, To
+	Remainder int       // Hash: rows whose hash modulo Count is Remainder
+}
+
+// RangePartitions returns the partition containing now and the ahead
+// partitions after it.
+func (p *Partitioning) RangePartitions(now time.Time, ahead int) []Partition {
+	if p.Strategy != Range || p.Interval == "" {
+		return nil
+	}
+	now = now.UTC()
+	var start time.Time
+	switch p.Interval {
+	case Daily:
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	case Yearly:
+		start = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	partitions := make([]Partition, 0, ahead+1)
+	for i := 0; i <= ahead; i++ {
+		var end time.Time
+		var suffix string
+		switch p.Interval {
+		case Daily:
+			end, suffix = start.AddDate(0, 0, 1), start.Format("2006_01_02")
+		case Yearly:
+			end, suffix = start.AddDate(1, 0, 0), start.Format("2006")
+		default:
+			end, suffix = start.AddDate(0, 1, 0), start.Format("2006_01")
+		}
+		partitions = append(partitions, Partition{Suffix: suffix, From: start, To: end})
+		start = end
+	}
+	return partitions
+}
+
+// HashPartitions returns the partitions of a hash partitioned table.
+func (p *Partitioning) HashPartitions() []Partition {
+	if p.Strategy != Hash {
+		return nil
+	}
+	partitions := make([]Partition, p.Count)
+	for i := range partitions {
+		partitions[i] = Partition{Suffix: fmt.Sprintf("p%d", i), Remainder: i}
+	}
+	return partitions
+}
+
+// validate reports what is wrong with the partitioning of model, if
+// anything. Unique keys must include the partition columns.
+func (p *Partitioning) validate(model *Model) []string {
+	var errors []string
+	switch p.Strategy {
+	case Range:
+		if len(p.Columns) != 1 {
+			errors = append(errors, fmt.Sprintf("model %q: range partitioning needs exactly one column", model.Name))
+		}
+		switch p.Interval {
+		case "", Daily, Monthly, Yearly:
+		default:
+			errors = append(errors, fmt.Sprintf("model %q: unknown partition interval %q (expected daily, monthly or yearly)", model.Name, p.Interval))
+		}
+	case Hash:
+		if len(p.Columns) == 0 {
+			errors = append(errors, fmt.Sprintf("model %q: hash partitioning needs columns", model.Name))
+		}
+		if p.Count < 1 {
+			errors = append(errors, fmt.Sprintf("model %q: hash partitioning needs a number of partitions", model.Name))
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("model %q: unknown partition strategy %q (expected RANGE or HASH)", model.Name, p.Strategy))
+	}
+
+	for _, col := range p.Columns {
+		field, exists := model.Fields[col]
+		if !exists {
+			errors = append(errors, fmt.Sprintf("model %q is partitioned by unknown field %q", model.Name, col))
+			continue
+		}
+		if p.Strategy == Range && field.Type != FieldTypeDateTime && field.Type != FieldTypeDate {
+			errors = append(errors, fmt.Sprintf("model %q: range partition field %q must be a DateTime or Date", model.Name, col))
+		}
+	}
+
+	for _, field := range model.fieldList {
+		if field.IsUnique && !field.IsPrimaryKey && !slices.Contains(p.Columns, field.Name) {
+			errors = append(errors, fmt.Sprintf("model %q: unique field %q must be a partition column, as unique keys of partitioned tables include them", model.Name, field.Name))
+		}
+	}
+	for _, idx := range model.Indexes {
+		if !idx.Unique {
+			continue
+		}
+		for _, col := range p.Columns {
+			if !slices.Contains(idx.Fields, col) {
+				errors = append(errors, fmt.Sprintf("model %q: unique index %q must include partition field %q", model.Name, idx.Name, col))
+			}
+		}
+	}
+	return errors
+}
+
+// PrimaryKey returns the primary key columns of a partitioned model: its
+// primary key fields followed by the partition columns not among them.
+func (p *Partitioning) PrimaryKey(model *Model) []string {
+	var columns []string
+	for _, field := range model.fieldList {
+		if field.IsPrimaryKey {
+			columns = append(columns, field.Name)
+		}
+	}
+	for _, col := range p.Columns {
+		if !slices.Contains(columns, col) {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policy commands: the statements a row-level security policy applies to.
+const (
+	PolicyAll    = "ALL"
+	PolicySelect = "SELECT"
+	PolicyInsert = "INSERT"
+	PolicyUpdate = "UPDATE"
+	PolicyDelete = "DELETE"
+)
+
+// Policy is a row-level security policy of a model. Rows a query reads,
+// updates or deletes must match Using; rows it writes must match Check.
+type Policy struct {
+	Name    string
+	Using   string   // SQL condition on existing rows
+	Check   string   // SQL condition on written rows (WITH CHECK); defaults to Using
+	Command string   // PolicyAll (default), PolicySelect, PolicyInsert, PolicyUpdate or PolicyDelete
+	Roles   []string // Roles the policy applies to; empty is everyone (PUBLIC)
+}
+
+// EnableRLS turns on row-level security for the model's table: rows are
+// only visible to queries through its policies, or to none without any.
+func (m *Model) EnableRLS() *Model {
+	m.RowSecurity = true
+	return m
+}
+
+// ForceRLS turns on row-level security for the table owner too, who
+// bypasses policies otherwise. Apps connecting as the owner need it.
+func (m *Model) ForceRLS() *Model {
+	m.RowSecurity = true
+	m.ForceRowSecurity = true
+	return m
+}
+
+// Policy adds a row-level security policy restricting rows to those where
+// using holds, and enables row-level security.
+// Example: m.EnableRLS().Policy("tenant_isolation", "tenant_id = current_setting('app.tenant')::int")
+func (m *Model) Policy(name, using string) *Policy {
+	p := &Policy{Name: name, Using: using, Command: PolicyAll}
+	m.RowSecurity = true
+	m.Policies = append(m.Policies, p)
+	return p
+}
+
+// For restricts the policy to one command: SELECT, INSERT, UPDATE or DELETE.
+func (p *Policy) For(command string) *Policy {
+	p.Command = strings.ToUpper(command)
+	return p
+}
+
+// To restricts the policy to roles.
+func (p *Policy) To(roles ...string) *Policy {
+	p.Roles = roles
+	return p
+}
+
+// WithCheck sets the condition rows written by INSERT and UPDATE must meet.
+func (p *Policy) WithCheck(check string) *Policy {
+	p.Check = check
+	return p
+}
+
+// validate reports what is wrong with the policy, if anything.
+func (p *Policy) validate() error {
+	switch p.Command {
+	case PolicyAll, PolicySelect, PolicyInsert, PolicyUpdate, PolicyDelete:
+	default:
+		return fmt.Errorf("policy %q has unknown command %q (expected ALL, SELECT, INSERT, UPDATE or DELETE)", p.Name, p.Command)
+	}
+	if p.Using == "" && p.Check == "" {
+		return fmt.Errorf("policy %q needs a condition", p.Name)
+	}
+	if p.Command == PolicySelect || p.Command == PolicyDelete {
+		if p.Check != "" {
+			return fmt.Errorf("policy %q: %s policies take no check condition", p.Name, p.Command)
+		}
+	}
+	return nil
+}
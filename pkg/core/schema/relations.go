@@ -2,8 +2,11 @@
 package schema
 
 import (
+	"fmt"
 	"strings"
 	"unicode"
+
+	nxerr "github.com/nexus-db/nexus/pkg/errors"
 )
 
 // DetectRelations scans all models and auto-detects relations based on
@@ -23,7 +26,7 @@ func (s *Schema) detectModelRelations(model *Model) {
 		}
 
 		// Only consider integer types as potential foreign keys
-		if field.Type != FieldTypeInt && field.Type != FieldTypeBigInt {
+		if !field.Type.IsInteger() {
 			continue
 		}
 
@@ -165,3 +168,253 @@ func (f *Field) GetReferencedModel(schema *Schema) *Model {
 	}
 	return schema.Models[f.References]
 }
+
+// resolveRelations turns fields typed with a model or enum name into enum
+// columns and relations. The side with @relation(fields: ..., references: ...), or a singular field
+// with a conventional foreign key (author → author_id), owns the foreign key
+// and becomes BelongsTo. List fields become HasMany and other singular fields
+// HasOne; both take their keys and cascade actions from the owning side.
+// Owning relations without a declared back side still get an implicit
+// HasMany on the target so cascades apply.
+func resolveRelations(s *Schema, decls []relationDecl) []*nxerr.NexusError {
+	var errs []*nxerr.NexusError
+	owners := make(map[*Relation]*relationDecl)
+	var owning []*Relation
+	var backSides []*relationDecl
+
+	typeNames := make([]string, 0, len(s.Models)+len(s.Enums))
+	for name := range s.Models {
+		typeNames = append(typeNames, name)
+	}
+	for name := range s.Enums {
+		typeNames = append(typeNames, name)
+	}
+
+	for i := range decls {
+		d := &decls[i]
+		model, target := s.Models[d.Model], s.Models[d.Target]
+
+		if e, ok := s.Enums[d.Target]; ok {
+			if err := resolveEnumField(d, e); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		// Relations are not columns; drop the placeholder
+		if d.Column != nil {
+			model.removeField(d.Column.Name)
+		}
+		if len(d.Modifiers) > 0 && target != nil {
+			errs = append(errs, d.error(nxerr.ErrSchemaInvalidModifier,
+				fmt.Sprintf("Modifier '%s' is not allowed on relation field '%s'", d.Modifiers[0], d.Field)).
+				WithSuggestion("Relation fields only accept @relation(...); put column modifiers on the foreign key field"))
+			continue
+		}
+
+		if target == nil {
+			suggestion := nxerr.SuggestSimilar(d.Target, typeNames)
+			if builtin := nxerr.SuggestSimilar(d.Target, nxerr.ValidTypes); suggestion == "" && builtin != "" {
+				errs = append(errs, d.errorAt(d.Decl.Type, nxerr.ErrSchemaUnknownType,
+					fmt.Sprintf("Unknown type '%s'", d.Target)).WithSuggestion(builtin))
+				continue
+			}
+			if suggestion == "" {
+				suggestion = nxerr.Suggestions[nxerr.ErrSchemaUnknownModel]
+			}
+			errs = append(errs, d.errorAt(d.Decl.Type, nxerr.ErrSchemaUnknownModel,
+				fmt.Sprintf("Field '%s.%s' references unknown model '%s'", d.Model, d.Field, d.Target)).
+				WithSuggestion(suggestion))
+			continue
+		}
+
+		var fk *Field
+		refKey := s.getPrimaryKeyName(target)
+		switch {
+		case len(d.Fields) > 1:
+			errs = append(errs, d.error(nxerr.ErrSchemaInvalidRelation,
+				fmt.Sprintf("Relation '%s.%s' uses a composite foreign key, which is not supported", d.Model, d.Field)))
+			continue
+		case len(d.Fields) == 1:
+			var err *nxerr.NexusError
+			if fk, err = relationField(d, model, d.Fields[0]); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if _, err = relationField(d, target, d.References[0]); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			refKey = d.References[0]
+		case !d.List:
+			fk = conventionalForeignKey(model, d.Field)
+		}
+
+		if fk == nil {
+			backSides = append(backSides, d)
+			continue
+		}
+
+		fk.Ref(target.Name)
+		rel := &Relation{
+			Type:           RelationBelongsTo,
+			Name:           d.Field,
+			TargetModel:    target.Name,
+			ForeignKey:     fk.Name,
+			ReferenceKey:   refKey,
+			OnDeleteAction: d.OnDelete,
+			OnUpdateAction: d.OnUpdate,
+		}
+		model.Relations = append(model.Relations, rel)
+		owners[rel] = d
+		owning = append(owning, rel)
+	}
+
+	matched := make(map[*Relation]bool)
+	for _, d := range backSides {
+		model, target := s.Models[d.Model], s.Models[d.Target]
+
+		var candidates []*Relation
+		for _, rel := range target.GetBelongsTo() {
+			owner, ok := owners[rel]
+			if rel.TargetModel != model.Name || !ok || matched[rel] || owner.Name != d.Name {
+				continue
+			}
+			candidates = append(candidates, rel)
+		}
+
+		// Fall back to a conventional foreign key on the target (user_id → User)
+		if len(candidates) == 0 && d.Name == "" {
+			if fk := conventionalBackReference(target, model.Name); fk != nil {
+				fk.Ref(model.Name)
+				rel := &Relation{
+					Type:         RelationBelongsTo,
+					TargetModel:  model.Name,
+					ForeignKey:   fk.Name,
+					ReferenceKey: s.getPrimaryKeyName(model),
+				}
+				target.Relations = append(target.Relations, rel)
+				candidates = append(candidates, rel)
+			}
+		}
+
+		switch len(candidates) {
+		case 0:
+			errs = append(errs, d.error(nxerr.ErrSchemaInvalidRelation,
+				fmt.Sprintf("No foreign key on '%s' points back to '%s' for relation '%s.%s'", target.Name, model.Name, d.Model, d.Field)).
+				WithSuggestion(fmt.Sprintf("Add a field to %s such as: %s %s @relation(fields: [%s_id], references: [%s])",
+					target.Name, strings.ToLower(model.Name), model.Name, strings.ToLower(model.Name), s.getPrimaryKeyName(model))))
+			continue
+		case 1:
+		default:
+			errs = append(errs, d.error(nxerr.ErrSchemaInvalidRelation,
+				fmt.Sprintf("Relation '%s.%s' is ambiguous: %s has %d foreign keys to %s", d.Model, d.Field, target.Name, len(candidates), model.Name)).
+				WithSuggestion(`Name both sides of the relation, e.g.: @relation("AuthoredPosts")`))
+			continue
+		}
+
+		own := candidates[0]
+		matched[own] = true
+
+		relType := RelationHasOne
+		if d.List {
+			relType = RelationHasMany
+		}
+		model.Relations = append(model.Relations, &Relation{
+			Type:           relType,
+			Name:           d.Field,
+			TargetModel:    target.Name,
+			ForeignKey:     own.ForeignKey,
+			ReferenceKey:   own.ReferenceKey,
+			OnDeleteAction: own.OnDeleteAction,
+			OnUpdateAction: own.OnUpdateAction,
+		})
+	}
+
+	// Owning relations without a declared back side
+	for _, rel := range owning {
+		if matched[rel] {
+			continue
+		}
+		owner := owners[rel]
+		s.Models[rel.TargetModel].Relations = append(s.Models[rel.TargetModel].Relations, &Relation{
+			Type:           RelationHasMany,
+			TargetModel:    owner.Model,
+			ForeignKey:     rel.ForeignKey,
+			ReferenceKey:   rel.ReferenceKey,
+			OnDeleteAction: rel.OnDeleteAction,
+			OnUpdateAction: rel.OnUpdateAction,
+		})
+	}
+
+	return errs
+}
+
+// resolveEnumField binds a field typed with an enum name to the enum.
+func resolveEnumField(d *relationDecl, e *Enum) *nxerr.NexusError {
+	if d.List || d.Relation {
+		return d.error(nxerr.ErrSchemaInvalidEnum,
+			fmt.Sprintf("Field '%s.%s' has enum type %s and cannot be a list or relation", d.Model, d.Field, e.Name))
+	}
+
+	d.Column.Enum = e
+	if v, ok := d.Column.DefaultValue.(string); ok && !e.Has(v) {
+		return d.error(nxerr.ErrSchemaInvalidEnum,
+			fmt.Sprintf("Default '%s' of field '%s.%s' is not a value of enum %s", v, d.Model, d.Field, e.Name)).
+			WithSuggestion(nxerr.SuggestSimilar(v, e.Values))
+	}
+	return nil
+}
+
+// error creates an error located at the relation field's declaration.
+func (d *relationDecl) error(code nxerr.ErrorCode, message string) *nxerr.NexusError {
+	return d.errorAt(d.Decl, code, message)
+}
+
+// errorAt creates an error located at a node of the field's declaration.
+func (d *relationDecl) errorAt(n spanner, code nxerr.ErrorCode, message string) *nxerr.NexusError {
+	return newError(code, message, d.File, n, d.Context)
+}
+
+// relationAttr returns the field's @relation attribute, or the field itself.
+func (d *relationDecl) relationAttr() spanner {
+	for _, attr := range d.Decl.Attributes {
+		if attr.Name == "relation" {
+			return attr
+		}
+	}
+	return d.Decl
+}
+
+// relationField looks up a field named in @relation(fields/references).
+func relationField(d *relationDecl, model *Model, name string) (*Field, *nxerr.NexusError) {
+	if f, ok := model.Fields[name]; ok {
+		return f, nil
+	}
+
+	return nil, d.errorAt(d.relationAttr(), nxerr.ErrSchemaInvalidRelation,
+		fmt.Sprintf("Relation '%s.%s' uses unknown field '%s' on %s", d.Model, d.Field, name, model.Name)).
+		WithSuggestion(nxerr.SuggestSimilar(name, fieldNames(model)))
+}
+
+// conventionalForeignKey finds the foreign key backing a singular relation
+// field: author → author_id or authorId.
+func conventionalForeignKey(model *Model, relationName string) *Field {
+	for _, name := range []string{relationName + "_id", relationName + "Id"} {
+		if f, ok := model.Fields[name]; ok && f.References == "" {
+			return f
+		}
+	}
+	return nil
+}
+
+// conventionalBackReference finds a field on model named after target by
+// the field_id convention that is not yet bound to another relation.
+func conventionalBackReference(model *Model, target string) *Field {
+	for _, f := range model.fieldList {
+		if f.References == "" && extractModelName(f.Name) == target {
+			return f
+		}
+	}
+	return nil
+}
@@ -3,22 +3,66 @@ package schema
 
 import (
 	"fmt"
+	"math"
+	"regexp"
 	"strings"
 )
 
 // Schema represents a complete database schema with models and relations.
 type Schema struct {
-	Models    map[string]*Model
-	modelList []*Model // Preserve order
+	Models       map[string]*Model
+	Enums        map[string]*Enum
+	Functions    map[string]*Function
+	modelList    []*Model    // Preserve order
+	enumList     []*Enum     // Preserve order
+	functionList []*Function // Preserve order
 }
 
 // NewSchema creates a new empty schema.
 func NewSchema() *Schema {
 	return &Schema{
-		Models: make(map[string]*Model),
+		Models:    make(map[string]*Model),
+		Enums:     make(map[string]*Enum),
+		Functions: make(map[string]*Function),
 	}
 }
 
+// Enum is a named set of allowed string values.
+type Enum struct {
+	Name   string
+	Values []string
+}
+
+// Has reports whether value is one of the enum's values.
+func (e *Enum) Has(value string) bool {
+	for _, v := range e.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Enum defines an enum type in the schema and returns it for use with Model.Enum.
+func (s *Schema) Enum(name string, values ...string) *Enum {
+	e := &Enum{Name: name, Values: values}
+	s.addEnum(e)
+	return e
+}
+
+func (s *Schema) addEnum(e *Enum) {
+	if s.Enums == nil {
+		s.Enums = make(map[string]*Enum)
+	}
+	s.Enums[e.Name] = e
+	s.enumList = append(s.enumList, e)
+}
+
+// GetEnums returns enums in definition order.
+func (s *Schema) GetEnums() []*Enum {
+	return s.enumList
+}
+
 // Model defines a model (table) in the schema using a fluent API.
 func (s *Schema) Model(name string, fn func(m *Model)) *Schema {
 	m := &Model{
@@ -38,11 +82,88 @@ func (s *Schema) GetModels() []*Model {
 
 // Model represents a database table.
 type Model struct {
-	Name      string
-	Fields    map[string]*Field
-	fieldList []*Field // Preserve order
-	Indexes   []*Index
-	Relations []*Relation
+	Name       string
+	TableName  string // Table name override (@@map); defaults to Name
+	SchemaName string // Database schema (@@schema), a PostgreSQL schema or MySQL database; empty for the default
+	Fields     map[string]*Field
+	fieldList  []*Field // Preserve order
+	Indexes    []*Index
+	Relations  []*Relation
+
+	RowSecurity      bool      // Row-level security is enabled (PostgreSQL)
+	ForceRowSecurity bool      // Row-level security also applies to the table owner
+	Policies         []*Policy // Row-level security policies
+
+	Partition *Partitioning // Table partitioning (PostgreSQL, MySQL); nil if not partitioned
+
+	CharacterSet string // Default character set of text columns (@@charset, MySQL)
+	Collation    string // Default collation of text columns (@@collate)
+
+	Description string // Comment on the table (@@comment); see Comment
+}
+
+// Table returns the database table name of the model, qualified with its
+// schema if it has one, e.g. billing.invoices.
+func (m *Model) Table() string {
+	table := m.Name
+	if m.TableName != "" {
+		table = m.TableName
+	}
+	return QualifiedTable(m.SchemaName, table)
+}
+
+// Map sets the database table name of the model.
+func (m *Model) Map(tableName string) *Model {
+	m.TableName = tableName
+	return m
+}
+
+// Schema puts the model's table in a database schema other than the
+// default one: a PostgreSQL schema or a MySQL database.
+func (m *Model) Schema(name string) *Model {
+	m.SchemaName = name
+	return m
+}
+
+// Charset sets the default character set of the model's text columns, a
+// table option in MySQL.
+func (m *Model) Charset(name string) *Model {
+	m.CharacterSet = name
+	return m
+}
+
+// Collate sets the default collation of the model's text columns. MySQL
+// makes it a table option; other databases give it to each String, Text
+// and Enum column without a collation of its own.
+func (m *Model) Collate(name string) *Model {
+	m.Collation = name
+	return m
+}
+
+// Comment sets the comment of the model's table, which the database
+// stores with it and generated code repeats as a doc comment.
+func (m *Model) Comment(text string) *Model {
+	m.Description = text
+	return m
+}
+
+// QualifiedTable returns table qualified with schemaName, or table alone if
+// schemaName is empty.
+func QualifiedTable(schemaName, table string) string {
+	if schemaName == "" {
+		return table
+	}
+	return schemaName + "." + table
+}
+
+// SplitTable splits a table name qualified with its schema, such as
+// billing.invoices, into the schema and the table. The schema is empty for
+// an unqualified name.
+func SplitTable(name string) (schemaName, table string) {
+	if i := strings.Index(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
 }
 
 // GetFields returns fields in definition order.
@@ -58,6 +179,23 @@ func (m *Model) addField(f *Field) *Field {
 	return f
 }
 
+// removeField removes a field from the model.
+func (m *Model) removeField(name string) {
+	delete(m.Fields, name)
+	for i, f := range m.fieldList {
+		if f.Name == name {
+			m.fieldList = append(m.fieldList[:i], m.fieldList[i+1:]...)
+			return
+		}
+	}
+}
+
+// AddField creates a field of the given type.
+// It is the generic form of Int, String, etc. for callers that build models dynamically.
+func (m *Model) AddField(name string, fieldType FieldType) *Field {
+	return m.addField(&Field{Name: name, Type: fieldType})
+}
+
 // Int creates an integer field.
 func (m *Model) Int(name string) *Field {
 	return m.addField(&Field{Name: name, Type: FieldTypeInt})
@@ -68,6 +206,17 @@ func (m *Model) BigInt(name string) *Field {
 	return m.addField(&Field{Name: name, Type: FieldTypeBigInt})
 }
 
+// SmallInt creates a 16-bit integer field.
+func (m *Model) SmallInt(name string) *Field {
+	return m.addField(&Field{Name: name, Type: FieldTypeSmallInt})
+}
+
+// TinyInt creates an 8-bit integer field, a SMALLINT where the database
+// has no TINYINT.
+func (m *Model) TinyInt(name string) *Field {
+	return m.addField(&Field{Name: name, Type: FieldTypeTinyInt})
+}
+
 // String creates a string/varchar field.
 func (m *Model) String(name string) *Field {
 	return m.addField(&Field{Name: name, Type: FieldTypeString, Length: 255})
@@ -93,6 +242,18 @@ func (m *Model) Decimal(name string) *Field {
 	return m.addField(&Field{Name: name, Type: FieldTypeDecimal, Precision: 10, Scale: 2})
 }
 
+// Money creates a field for amounts of money, a DECIMAL(19,4) unless the
+// database has a money type of that precision. Prec changes the precision.
+func (m *Model) Money(name string) *Field {
+	return m.addField(&Field{Name: name, Type: FieldTypeMoney, Precision: MoneyPrecision, Scale: MoneyScale})
+}
+
+// The precision and scale of Money fields unless set otherwise.
+const (
+	MoneyPrecision = 19
+	MoneyScale     = 4
+)
+
 // DateTime creates a datetime/timestamp field.
 func (m *Model) DateTime(name string) *Field {
 	return m.addField(&Field{Name: name, Type: FieldTypeDateTime})
@@ -123,6 +284,11 @@ func (m *Model) UUID(name string) *Field {
 	return m.addField(&Field{Name: name, Type: FieldTypeUUID})
 }
 
+// Enum creates a field restricted to the values of e.
+func (m *Model) Enum(name string, e *Enum) *Field {
+	return m.addField(&Field{Name: name, Type: FieldTypeEnum, Enum: e})
+}
+
 // Index adds an index to the model.
 func (m *Model) Index(name string, fields ...string) *Model {
 	m.Indexes = append(m.Indexes, &Index{
@@ -144,36 +310,39 @@ func (m *Model) UniqueIndex(name string, fields ...string) *Model {
 }
 
 // BelongsTo creates a belongs-to relation.
-func (m *Model) BelongsTo(targetModel, foreignKey string) *Model {
-	m.Relations = append(m.Relations, &Relation{
+func (m *Model) BelongsTo(targetModel, foreignKey string) *Relation {
+	rel := &Relation{
 		Type:         RelationBelongsTo,
 		TargetModel:  targetModel,
 		ForeignKey:   foreignKey,
 		ReferenceKey: "id",
-	})
-	return m
+	}
+	m.Relations = append(m.Relations, rel)
+	return rel
 }
 
 // HasMany creates a has-many relation.
-func (m *Model) HasMany(targetModel, foreignKey string) *Model {
-	m.Relations = append(m.Relations, &Relation{
+func (m *Model) HasMany(targetModel, foreignKey string) *Relation {
+	rel := &Relation{
 		Type:         RelationHasMany,
 		TargetModel:  targetModel,
 		ForeignKey:   foreignKey,
 		ReferenceKey: "id",
-	})
-	return m
+	}
+	m.Relations = append(m.Relations, rel)
+	return rel
 }
 
 // HasOne creates a has-one relation.
-func (m *Model) HasOne(targetModel, foreignKey string) *Model {
-	m.Relations = append(m.Relations, &Relation{
+func (m *Model) HasOne(targetModel, foreignKey string) *Relation {
+	rel := &Relation{
 		Type:         RelationHasOne,
 		TargetModel:  targetModel,
 		ForeignKey:   foreignKey,
 		ReferenceKey: "id",
-	})
-	return m
+	}
+	m.Relations = append(m.Relations, rel)
+	return rel
 }
 
 // FieldType represents a column data type.
@@ -193,13 +362,18 @@ const (
 	FieldTypeJSON
 	FieldTypeBytes
 	FieldTypeUUID
+	FieldTypeEnum
+	FieldTypeSmallInt
+	FieldTypeTinyInt
+	FieldTypeMoney
 )
 
 // String returns the string representation of a field type.
 func (ft FieldType) String() string {
 	names := []string{
 		"Int", "BigInt", "String", "Text", "Bool", "Float",
-		"Decimal", "DateTime", "Date", "Time", "JSON", "Bytes", "UUID",
+		"Decimal", "DateTime", "Date", "Time", "JSON", "Bytes", "UUID", "Enum",
+		"SmallInt", "TinyInt", "Money",
 	}
 	if int(ft) < len(names) {
 		return names[ft]
@@ -207,6 +381,16 @@ func (ft FieldType) String() string {
 	return "Unknown"
 }
 
+// IsInteger reports whether the type is an integer type.
+func (ft FieldType) IsInteger() bool {
+	return ft == FieldTypeInt || ft == FieldTypeBigInt || ft == FieldTypeSmallInt || ft == FieldTypeTinyInt
+}
+
+// IsNumeric reports whether the type holds numbers.
+func (ft FieldType) IsNumeric() bool {
+	return ft.IsInteger() || ft == FieldTypeFloat || ft == FieldTypeDecimal || ft == FieldTypeMoney
+}
+
 // Field represents a column in a model.
 type Field struct {
 	Name          string
@@ -220,7 +404,29 @@ type Field struct {
 	Precision     int
 	Scale         int
 	DefaultValue  interface{}
-	DefaultExpr   string // For expressions like NOW()
+	DefaultExpr   string // For expressions like NOW(); see DefaultExprNow
+	Enum          *Enum  // Allowed values for FieldTypeEnum
+	IsUnsigned    bool   // Integer without negative values
+
+	// Validation, checked before inserts and updates
+	IsEmail  bool     // Value must be an email address
+	MinValue *float64 // Minimum number, or minimum length of strings
+	MaxValue *float64 // Maximum number, or maximum length of strings
+	Pattern  string   // Regular expression string values must match
+
+	// Personal data, masked by nexus db sample
+	IsPII   bool   // Column holds personal data
+	PIIMask string // How to mask it (one of PIIMasks); empty infers it
+
+	// Text columns only; empty for the model's or the database's default
+	CharacterSet string // Character set (MySQL)
+	Collation    string // Collation, e.g. "und-x-icu" or "utf8mb4_bin"
+
+	Description string // Comment on the column (@comment); see Comment
+
+	// Go type of generated code and converted query values, as an import
+	// path and a type name, e.g. "github.com/shopspring/decimal.Decimal"
+	GoTypeName string
 
 	// Relation detection
 	References  string // Target model name (e.g., "User")
@@ -265,16 +471,93 @@ func (f *Field) Default(value interface{}) *Field {
 
 // DefaultNow sets the default to the current timestamp.
 func (f *Field) DefaultNow() *Field {
-	f.DefaultExpr = "NOW()"
+	f.DefaultExpr = DefaultExprNow
 	return f
 }
 
 // DefaultUUID sets the default to a generated UUID.
 func (f *Field) DefaultUUID() *Field {
-	f.DefaultExpr = "UUID()"
+	f.DefaultExpr = DefaultExprUUID
 	return f
 }
 
+// DefaultRandom sets the default to a random number from 0 up to 1.
+func (f *Field) DefaultRandom() *Field {
+	f.DefaultExpr = DefaultExprRandom
+	return f
+}
+
+// Portable default expressions. Each dialect translates them into its
+// own SQL, such as CURRENT_TIMESTAMP for NOW() on SQLite.
+const (
+	DefaultExprNow    = "NOW()"
+	DefaultExprUUID   = "UUID()"
+	DefaultExprRandom = "RANDOM()"
+)
+
+// defaultFunctions are the functions databases spell the portable default
+// expressions with.
+var defaultFunctions = map[string]string{
+	"now":               DefaultExprNow,
+	"current_timestamp": DefaultExprNow,
+	"localtimestamp":    DefaultExprNow,
+	"getdate":           DefaultExprNow,
+	"getutcdate":        DefaultExprNow,
+	"sysdatetime":       DefaultExprNow,
+	"sysutcdatetime":    DefaultExprNow,
+	"uuid":              DefaultExprUUID,
+	"gen_random_uuid":   DefaultExprUUID,
+	"uuid_generate_v4":  DefaultExprUUID,
+	"newid":             DefaultExprUUID,
+	"newsequentialid":   DefaultExprUUID,
+	"random":            DefaultExprRandom,
+	"rand":              DefaultExprRandom,
+}
+
+// PortableDefaultExpr returns the portable default expression of a
+// database function, given its name without arguments, such as
+// "current_timestamp" or "gen_random_uuid", and whether it has one.
+func PortableDefaultExpr(function string) (string, bool) {
+	expr, ok := defaultFunctions[strings.ToLower(function)]
+	return expr, ok
+}
+
+// Unsigned makes an integer field unsigned: an UNSIGNED column on MySQL,
+// and a CHECK that the value is not negative elsewhere.
+func (f *Field) Unsigned() *Field {
+	f.IsUnsigned = true
+	return f
+}
+
+// IntegerRange returns the smallest and largest values the column of an
+// integer field holds, as an int64: the largest unsigned BigInt is capped
+// at math.MaxInt64.
+func (f *Field) IntegerRange() (lo, hi int64) {
+	switch f.Type {
+	case FieldTypeTinyInt:
+		lo, hi = math.MinInt8, math.MaxInt8
+		if f.IsUnsigned {
+			return 0, math.MaxUint8
+		}
+	case FieldTypeSmallInt:
+		lo, hi = math.MinInt16, math.MaxInt16
+		if f.IsUnsigned {
+			return 0, math.MaxUint16
+		}
+	case FieldTypeInt:
+		lo, hi = math.MinInt32, math.MaxInt32
+		if f.IsUnsigned {
+			return 0, math.MaxUint32
+		}
+	default:
+		lo, hi = math.MinInt64, math.MaxInt64
+		if f.IsUnsigned {
+			return 0, math.MaxInt64
+		}
+	}
+	return lo, hi
+}
+
 // Size sets the length for string fields.
 func (f *Field) Size(length int) *Field {
 	f.Length = length
@@ -288,6 +571,106 @@ func (f *Field) Prec(precision, scale int) *Field {
 	return f
 }
 
+// Email requires values to be email addresses.
+func (f *Field) Email() *Field {
+	f.IsEmail = true
+	return f
+}
+
+// Min sets the minimum value of numbers, or the minimum length of strings.
+func (f *Field) Min(n float64) *Field {
+	f.MinValue = &n
+	return f
+}
+
+// Max sets the maximum value of numbers, or the maximum length of strings.
+func (f *Field) Max(n float64) *Field {
+	f.MaxValue = &n
+	return f
+}
+
+// Regex requires string values to match a regular expression.
+func (f *Field) Regex(pattern string) *Field {
+	f.Pattern = pattern
+	return f
+}
+
+// PII marks the field as personal data, masked when data is sampled or
+// dumped with anonymization, in the studio and in masked queries. mask is
+// one of PIIMasks; an empty mask is inferred from the name and type.
+func (f *Field) PII(mask string) *Field {
+	f.IsPII = true
+	f.PIIMask = mask
+	return f
+}
+
+// Charset sets the character set of a text column (MySQL).
+func (f *Field) Charset(name string) *Field {
+	f.CharacterSet = name
+	return f
+}
+
+// Collate sets the collation of a text column, which decides how its
+// values compare and sort.
+func (f *Field) Collate(name string) *Field {
+	f.Collation = name
+	return f
+}
+
+// Comment sets the comment of the field's column, which the database
+// stores with it and generated code repeats as a doc comment.
+func (f *Field) Comment(text string) *Field {
+	f.Description = text
+	return f
+}
+
+// Collatable reports whether the field's column holds text, so it can have
+// a character set and a collation.
+func (f *Field) Collatable() bool {
+	return f.Type == FieldTypeString || f.Type == FieldTypeText || f.Type == FieldTypeEnum
+}
+
+// ColumnCollation returns the character set and collation of the field's
+// column: its own if it sets either, or else its model's defaults for
+// text columns. Empty values leave them to the database.
+func (f *Field) ColumnCollation() (charset, collation string) {
+	if f.CharacterSet != "" || f.Collation != "" || f.Model == nil || !f.Collatable() {
+		return f.CharacterSet, f.Collation
+	}
+	return f.Model.CharacterSet, f.Model.Collation
+}
+
+// collationName matches the names of character sets and collations, such
+// as utf8mb4_0900_ai_ci, und-x-icu or en_US.utf8.
+var collationName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.@-]*$`)
+
+// ValidCollationName reports whether name can be a character set or
+// collation name, which some dialects write unquoted.
+func ValidCollationName(name string) bool {
+	return collationName.MatchString(name)
+}
+
+// GoType sets the Go type of the field in generated code, and of its
+// values in queries given the schema, named by its import path and type
+// name, e.g. "github.com/google/uuid.UUID". Queries convert values with
+// the converter registered for the type with query.RegisterConverter.
+func (f *Field) GoType(name string) *Field {
+	f.GoTypeName = name
+	return f
+}
+
+// goTypeName matches Go type names with their import path, such as
+// uuid.UUID, github.com/shopspring/decimal.Decimal or gopkg.in/x.v1.Type.
+var goTypeName = regexp.MustCompile(`^(?:[\w.~-]+/)*(?:[\w.~-]+\.)?[A-Za-z_]\w*$`)
+
+// ValidGoTypeName reports whether name can be the Go type of a field.
+func ValidGoTypeName(name string) bool {
+	return goTypeName.MatchString(name)
+}
+
+// PIIMasks are the ways @pii fields can be masked.
+var PIIMasks = []string{"email", "name", "phone", "hash", "redact", "null"}
+
 // Ref explicitly marks this field as referencing another model.
 // This overrides auto-detection for cases where naming conventions don't apply.
 func (f *Field) Ref(modelName string) *Field {
@@ -296,6 +679,47 @@ func (f *Field) Ref(modelName string) *Field {
 	return f
 }
 
+// validationRuleErrors reports validation rules that do not fit the type
+// of the field.
+func (f *Field) validationRuleErrors() []string {
+	var errors []string
+	where := fmt.Sprintf("field %q in model %q", f.Name, f.Model.Name)
+	text := f.Type == FieldTypeString || f.Type == FieldTypeText || f.Type == FieldTypeUUID
+	numeric := f.Type.IsNumeric()
+
+	if f.IsEmail && !text {
+		errors = append(errors, fmt.Sprintf("@email on %s needs a String or Text field", where))
+	}
+	if (f.MinValue != nil || f.MaxValue != nil) && !text && !numeric {
+		errors = append(errors, fmt.Sprintf("@min and @max on %s need a number or string field", where))
+	}
+	if f.MinValue != nil && f.MaxValue != nil && *f.MinValue > *f.MaxValue {
+		errors = append(errors, fmt.Sprintf("@min is greater than @max on %s", where))
+	}
+	if f.IsUnsigned && !f.Type.IsInteger() {
+		errors = append(errors, fmt.Sprintf("@unsigned on %s needs an integer field", where))
+	}
+	if (f.CharacterSet != "" || f.Collation != "") && !f.Collatable() {
+		errors = append(errors, fmt.Sprintf("@charset and @collate on %s need a String, Text or Enum field", where))
+	}
+	for _, name := range []string{f.CharacterSet, f.Collation} {
+		if name != "" && !ValidCollationName(name) {
+			errors = append(errors, fmt.Sprintf("invalid character set or collation %q on %s", name, where))
+		}
+	}
+	if f.GoTypeName != "" && !ValidGoTypeName(f.GoTypeName) {
+		errors = append(errors, fmt.Sprintf("invalid Go type %q on %s", f.GoTypeName, where))
+	}
+	if f.Pattern != "" {
+		if !text {
+			errors = append(errors, fmt.Sprintf("@regex on %s needs a String or Text field", where))
+		} else if _, err := regexp.Compile(f.Pattern); err != nil {
+			errors = append(errors, fmt.Sprintf("invalid @regex pattern on %s: %v", where, err))
+		}
+	}
+	return errors
+}
+
 // Index represents a database index.
 type Index struct {
 	Name   string
@@ -303,6 +727,19 @@ type Index struct {
 	Unique bool
 }
 
+// DefaultIndexName returns the name given to an index declared without one:
+// idx_<table>_<fields> for plain indexes and uq_<table>_<fields> for unique ones.
+// The schema of a qualified table is left out, as indexes live in the schema
+// of their table.
+func DefaultIndexName(table string, unique bool, fields []string) string {
+	_, table = SplitTable(table)
+	prefix := "idx"
+	if unique {
+		prefix = "uq"
+	}
+	return fmt.Sprintf("%s_%s_%s", prefix, strings.ToLower(table), strings.Join(fields, "_"))
+}
+
 // RelationType represents the type of relation.
 type RelationType int
 
@@ -327,9 +764,24 @@ const (
 	Restrict
 )
 
+// String returns the DSL name of the action.
+func (a CascadeAction) String() string {
+	switch a {
+	case Cascade:
+		return "Cascade"
+	case SetNull:
+		return "SetNull"
+	case Restrict:
+		return "Restrict"
+	default:
+		return "NoAction"
+	}
+}
+
 // Relation represents a relationship between models.
 type Relation struct {
 	Type             RelationType
+	Name             string // Relation field name from the DSL, e.g. "posts"; empty if implicit
 	TargetModel      string
 	ForeignKey       string
 	ReferenceKey     string
@@ -340,6 +792,18 @@ type Relation struct {
 	OnUpdateAction   CascadeAction // Action on parent update
 }
 
+// As names the relation. Name relations to tell apart several relations
+// to the same model, such as the author and the reviewer of a post:
+//
+//	m.BelongsTo("User", "author_id").As("Author")
+//	m.BelongsTo("User", "reviewer_id").As("Reviewer")
+//
+// Include, GetRelation and generated accessors use the name.
+func (r *Relation) As(name string) *Relation {
+	r.Name = name
+	return r
+}
+
 // OnDelete sets the cascade action for delete operations.
 func (r *Relation) OnDelete(action CascadeAction) *Relation {
 	r.OnDeleteAction = action
@@ -384,6 +848,31 @@ func (s *Schema) Validate() error {
 			errors = append(errors, fmt.Sprintf("model %q has no primary key", model.Name))
 		}
 
+		// Validate enum fields
+		for _, field := range model.fieldList {
+			if field.Type != FieldTypeEnum {
+				continue
+			}
+			if field.Enum == nil {
+				errors = append(errors, fmt.Sprintf("enum field %q in model %q has no enum type", field.Name, model.Name))
+				continue
+			}
+			if v, ok := field.DefaultValue.(string); ok && !field.Enum.Has(v) {
+				errors = append(errors, fmt.Sprintf("default %q of field %q in model %q is not a value of enum %s", v, field.Name, model.Name, field.Enum.Name))
+			}
+		}
+
+		// Validate validation rules
+		for _, field := range model.fieldList {
+			errors = append(errors, field.validationRuleErrors()...)
+		}
+
+		for _, name := range []string{model.CharacterSet, model.Collation} {
+			if name != "" && !ValidCollationName(name) {
+				errors = append(errors, fmt.Sprintf("invalid character set or collation %q on model %q", name, model.Name))
+			}
+		}
+
 		// Validate relations
 		for _, rel := range model.Relations {
 			if _, exists := s.Models[rel.TargetModel]; !exists {
@@ -399,6 +888,28 @@ func (s *Schema) Validate() error {
 				}
 			}
 		}
+
+		if model.Partition != nil {
+			errors = append(errors, model.Partition.validate(model)...)
+		}
+
+		// Validate row-level security policies
+		policies := make(map[string]bool)
+		for _, p := range model.Policies {
+			if policies[p.Name] {
+				errors = append(errors, fmt.Sprintf("model %q declares policy %q more than once", model.Name, p.Name))
+			}
+			policies[p.Name] = true
+			if err := p.validate(); err != nil {
+				errors = append(errors, fmt.Sprintf("model %q: %v", model.Name, err))
+			}
+		}
+	}
+
+	for _, f := range s.functionList {
+		if err := f.validate(); err != nil {
+			errors = append(errors, err.Error())
+		}
 	}
 
 	if len(errors) > 0 {
@@ -0,0 +1,379 @@
+package seed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nexus-db/nexus/internal/yamlline"
+	"github.com/nexus-db/nexus/pkg/bulk"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/core/transfer"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// dataFormats are the extensions of data seeds.
+var dataFormats = map[string]bool{".csv": true, ".json": true, ".yaml": true, ".yml": true}
+
+// WithSchema sets the schema data seeds are checked against. Data seeds
+// need it to find their table and convert values to the column types.
+func (e *Engine) WithSchema(s *schema.Schema) *Engine {
+	e.schema = s
+	return e
+}
+
+// parseDataFile parses a data seed. The file name names the table, e.g.
+// 002_users.csv seeds the users table (or the User model) second.
+//
+// CSV files start with a header row of column names; empty cells are left
+// to the column default. JSON files hold an array of objects, and YAML
+// files a sequence of flat mappings ("- email: ada@example.com" followed
+// by indented "name: Ada" lines).
+func parseDataFile(filename string, content []byte, env string) (*Seed, error) {
+	name, order := seedName(filename)
+
+	var rows []map[string]interface{}
+	var err error
+	switch filepath.Ext(filename) {
+	case ".csv":
+		rows, err = parseCSVRows(content)
+	case ".json":
+		rows, err = parseJSONRows(content)
+	default:
+		rows, err = parseYAMLRows(string(content))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+
+	hash := sha256.Sum256(content)
+	return &Seed{
+		Name:     name,
+		Order:    order,
+		Env:      env,
+		Checksum: hex.EncodeToString(hash[:]),
+		Table:    name,
+		Rows:     rows,
+	}, nil
+}
+
+func parseCSVRows(content []byte) ([]map[string]interface{}, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\uFEFF"))))
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	var rows []map[string]interface{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(header))
+		for i, cell := range record {
+			if cell != "" {
+				row[header[i]] = cell
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func parseJSONRows(content []byte) ([]map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var rows []map[string]interface{}
+	if err := dec.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("expected an array of objects: %w", err)
+	}
+	return rows, nil
+}
+
+// parseYAMLRows reads the YAML data seeds use: a sequence of mappings from
+// column names to scalars. Nested collections, anchors and multi-line
+// scalars are not supported.
+func parseYAMLRows(content string) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	var row map[string]interface{}
+	itemIndent := -1
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		line = strings.TrimRight(yamlline.StripComment(line), " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if trimmed == "[]" && rows == nil {
+			return nil, nil
+		}
+		indent := len(line) - len(trimmed)
+
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			if itemIndent >= 0 && indent != itemIndent {
+				return nil, fmt.Errorf("line %d: nested lists are not supported", lineNo)
+			}
+			itemIndent = indent
+			row = make(map[string]interface{})
+			rows = append(rows, row)
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			if trimmed == "" {
+				continue
+			}
+		} else if row == nil || indent <= itemIndent {
+			return nil, fmt.Errorf("line %d: expected a list item (\"- column: value\")", lineNo)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || (value != "" && value[0] != ' ' && value[0] != '\t') {
+			return nil, fmt.Errorf("line %d: expected \"column: value\"", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if _, dup := row[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate column %q", lineNo, key)
+		}
+		v, err := yamlScalar(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		row[key] = v
+	}
+	return rows, nil
+}
+
+// yamlScalar converts a scalar as JSON decoding would: numbers become
+// json.Number.
+func yamlScalar(s string) (interface{}, error) {
+	switch {
+	case s == "" || s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return nil, nil
+	case s == "true" || s == "True" || s == "TRUE":
+		return true, nil
+	case s == "false" || s == "False" || s == "FALSE":
+		return false, nil
+	case s[0] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return v, nil
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s[0] == '[' || s[0] == '{' || s[0] == '&' || s[0] == '*' || s[0] == '|' || s[0] == '>':
+		return nil, fmt.Errorf("unsupported value %s; quote it to use it as a string", s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return json.Number(s), nil
+	}
+	return s, nil
+}
+
+// applyData inserts the rows of a data seed, updating rows that already
+// exist when every row carries a unique key of the model.
+func (e *Engine) applyData(ctx context.Context, tx *dialects.Tx, seed *Seed) error {
+	if e.schema == nil {
+		return fmt.Errorf("data seed %s needs a schema to map its columns", seed.Name)
+	}
+	model := transfer.FindModel(e.schema, seed.Table)
+	if model == nil {
+		return fmt.Errorf("no model for table %q", seed.Table)
+	}
+
+	rows := make([]map[string]interface{}, len(seed.Rows))
+	for i, raw := range seed.Rows {
+		row := make(map[string]interface{}, len(raw))
+		for column, v := range raw {
+			field, ok := model.Fields[column]
+			if !ok {
+				return fmt.Errorf("row %d: %s has no column %q", i+1, model.Table(), column)
+			}
+			val, err := transfer.Convert(field, v)
+			if err != nil {
+				return fmt.Errorf("row %d: column %s: %w", i+1, column, err)
+			}
+			row[column] = val
+		}
+		rows[i] = row
+	}
+
+	key := upsertKey(model, seed.Rows)
+	if len(key) == 0 {
+		return insertRows(ctx, tx, model.Table(), rows)
+	}
+	for i, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		query, args := upsertSQL(tx.Dialect, model.Table(), row, key)
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("row %d: %w", i+1, &StatementError{Statement: query, Err: err})
+		}
+	}
+	return nil
+}
+
+// insertRows bulk loads rows without a key to upsert on, a load per run of
+// rows with the same columns.
+func insertRows(ctx context.Context, tx *dialects.Tx, table string, rows []map[string]interface{}) error {
+	var columns []string
+	var values [][]interface{}
+	load := func() error {
+		if len(values) == 0 {
+			return nil
+		}
+		_, err := bulk.LoadTx(ctx, tx, table, bulk.NewRows(columns, values), bulk.DefaultOptions())
+		values = nil
+		return err
+	}
+
+	for _, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		rowColumns := make([]string, 0, len(row))
+		for column := range row {
+			rowColumns = append(rowColumns, column)
+		}
+		sort.Strings(rowColumns)
+		if strings.Join(rowColumns, ",") != strings.Join(columns, ",") {
+			if err := load(); err != nil {
+				return err
+			}
+			columns = rowColumns
+		}
+
+		rowValues := make([]interface{}, len(columns))
+		for i, column := range columns {
+			rowValues[i] = row[column]
+		}
+		values = append(values, rowValues)
+	}
+	return load()
+}
+
+// upsertKey returns the first unique key of model (primary key, unique
+// fields, then unique indexes) set in every row, or nil if there is none.
+func upsertKey(model *schema.Model, rows []map[string]interface{}) []string {
+	var candidates [][]string
+	var pk []string
+	for _, f := range model.GetFields() {
+		if f.IsPrimaryKey {
+			pk = append(pk, f.Name)
+		}
+	}
+	if len(pk) > 0 {
+		candidates = append(candidates, pk)
+	}
+	for _, f := range model.GetFields() {
+		if f.IsUnique && !f.IsPrimaryKey {
+			candidates = append(candidates, []string{f.Name})
+		}
+	}
+	for _, idx := range model.Indexes {
+		if idx.Unique {
+			candidates = append(candidates, idx.Fields)
+		}
+	}
+
+	for _, key := range candidates {
+		complete := true
+		for _, row := range rows {
+			for _, column := range key {
+				if row[column] == nil {
+					complete = false
+				}
+			}
+		}
+		if complete && len(rows) > 0 {
+			return key
+		}
+	}
+	return nil
+}
+
+// upsertSQL builds an INSERT of row that updates the other columns when a
+// row with the same key exists.
+func upsertSQL(dialect dialects.Dialect, table string, row map[string]interface{}, key []string) (string, []interface{}) {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		quoted[i] = dialect.Quote(column)
+		placeholders[i] = dialect.Placeholder(i + 1)
+		args[i] = row[column]
+	}
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		dialect.Quote(table), strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+	if len(key) == 0 {
+		return sql, args
+	}
+
+	isKey := make(map[string]bool)
+	quotedKey := make([]string, len(key))
+	for i, column := range key {
+		isKey[column] = true
+		quotedKey[i] = dialect.Quote(column)
+	}
+	var updates []string
+	for _, column := range columns {
+		if isKey[column] {
+			continue
+		}
+		switch dialect.Name() {
+		case "mysql":
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", dialect.Quote(column), dialect.Quote(column)))
+		case "mssql":
+			updates = append(updates, fmt.Sprintf("%s = source.%s", dialect.Quote(column), dialect.Quote(column)))
+		default:
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", dialect.Quote(column), dialect.Quote(column)))
+		}
+	}
+
+	if upserter, ok := dialect.(dialects.Upserter); ok {
+		values := "(" + strings.Join(placeholders, ", ") + ")"
+		return upserter.UpsertSQL(dialect.Quote(table), columns, []string{values}, key, updates), args
+	}
+
+	switch {
+	case dialect.Name() == "mysql" && len(updates) == 0:
+		sql += fmt.Sprintf(" ON DUPLICATE KEY UPDATE %s = %s", quotedKey[0], quotedKey[0])
+	case dialect.Name() == "mysql":
+		sql += " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+	case len(updates) == 0:
+		sql += fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(quotedKey, ", "))
+	default:
+		sql += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(quotedKey, ", "), strings.Join(updates, ", "))
+	}
+	return sql, args
+}
@@ -0,0 +1,72 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DriftPolicy decides what Run does with applied seeds whose file changed
+// since they ran.
+type DriftPolicy string
+
+const (
+	DriftWarn  DriftPolicy = "warn"            // Leave them; Changed lists them
+	DriftError DriftPolicy = "error"           // Fail before running anything
+	DriftRerun DriftPolicy = "rerun-on-change" // Run them again
+)
+
+// ParseDriftPolicy parses a policy name; empty means DriftWarn.
+func ParseDriftPolicy(name string) (DriftPolicy, error) {
+	switch p := DriftPolicy(strings.ToLower(strings.TrimSpace(name))); p {
+	case "":
+		return DriftWarn, nil
+	case DriftWarn, DriftError, DriftRerun:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown seed change policy %q (expected %s, %s or %s)", name, DriftWarn, DriftError, DriftRerun)
+}
+
+// WithDriftPolicy sets what Run does with changed seeds. The default is
+// DriftWarn.
+func (e *Engine) WithDriftPolicy(p DriftPolicy) *Engine {
+	e.drift = p
+	return e
+}
+
+// Changed returns the applied seeds of env whose checksum no longer
+// matches the recorded one, in run order.
+func (e *Engine) Changed(ctx context.Context, env string) ([]*Seed, error) {
+	seeds, err := e.selectSeeds(env)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := e.getApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return changedSeeds(seeds, applied), nil
+}
+
+func changedSeeds(seeds []*Seed, applied []SeedHistory) []*Seed {
+	checksums := make(map[string]string, len(applied))
+	for _, h := range applied {
+		checksums[h.Name+":"+h.Env] = h.Checksum
+	}
+	var changed []*Seed
+	for _, s := range seeds {
+		if sum, ok := checksums[s.Name+":"+s.Env]; ok && sum != s.Checksum {
+			changed = append(changed, s)
+		}
+	}
+	return changed
+}
+
+// driftError reports changed seeds under DriftError.
+func driftError(changed []*Seed) error {
+	names := make([]string, len(changed))
+	for i, s := range changed {
+		names[i] = describeSeed(s)
+	}
+	return fmt.Errorf("seeds changed since they were applied: %s", strings.Join(names, ", "))
+}
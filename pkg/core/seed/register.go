@@ -0,0 +1,147 @@
+package seed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// Func is a seed written in Go. It runs in the transaction of its seed, so
+// returning an error undoes everything it wrote.
+type Func func(ctx context.Context, tx *dialects.Tx) error
+
+var (
+	registryMu sync.Mutex
+	registry   []*Seed
+)
+
+// Register adds a Go seed that runs after the seeds named in dependsOn.
+// Engines created afterwards include it. It is meant to be called from
+// init functions and panics if name is empty or already registered.
+//
+//	func init() {
+//		seed.Register("admins", []string{"roles"}, func(ctx context.Context, tx *dialects.Tx) error {
+//			_, err := tx.Exec(ctx, `INSERT INTO users (email, role) VALUES ('admin@example.com', 'ADMIN')`)
+//			return err
+//		})
+//	}
+func Register(name string, dependsOn []string, fn Func) {
+	s := newFuncSeed(name, dependsOn, fn)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, existing := range registry {
+		if existing.Name == name {
+			panic(fmt.Sprintf("seed: Register called twice for %q", name))
+		}
+	}
+	registry = append(registry, s)
+}
+
+// Register adds a Go seed to this engine only.
+func (e *Engine) Register(name string, dependsOn []string, fn Func) {
+	e.seeds = append(e.seeds, newFuncSeed(name, dependsOn, fn))
+}
+
+func newFuncSeed(name string, dependsOn []string, fn Func) *Seed {
+	if name == "" || fn == nil {
+		panic("seed: Register needs a name and a function")
+	}
+	// The code cannot be hashed; the checksum tracks the declaration
+	hash := sha256.Sum256([]byte(name + "\x00" + strings.Join(dependsOn, ",")))
+	return &Seed{
+		Name:      name,
+		Func:      fn,
+		DependsOn: append([]string(nil), dependsOn...),
+		Checksum:  hex.EncodeToString(hash[:]),
+	}
+}
+
+// registered returns the seeds added with Register.
+func registered() []*Seed {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]*Seed(nil), registry...)
+}
+
+// order sorts seeds so every seed comes after its dependencies. Seeds that
+// do not depend on each other keep the file order: by number, then name.
+func order(seeds []*Seed) ([]*Seed, error) {
+	byName := make(map[string][]*Seed)
+	seen := make(map[string]bool)
+	for _, s := range seeds {
+		key := s.Name + ":" + s.Env
+		if seen[key] {
+			return nil, fmt.Errorf("seed %s is defined twice", describeSeed(s))
+		}
+		seen[key] = true
+		byName[s.Name] = append(byName[s.Name], s)
+	}
+
+	pending := make(map[*Seed]int) // Unmet dependencies
+	dependents := make(map[*Seed][]*Seed)
+	for _, s := range seeds {
+		for _, dep := range s.DependsOn {
+			targets, ok := byName[dep]
+			if !ok {
+				return nil, fmt.Errorf("seed %s depends on %q, which is not loaded for this environment", describeSeed(s), dep)
+			}
+			for _, t := range targets {
+				pending[s]++
+				dependents[t] = append(dependents[t], s)
+			}
+		}
+	}
+
+	less := func(a, b *Seed) bool {
+		if a.Order != b.Order {
+			return a.Order < b.Order
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Env < b.Env
+	}
+
+	var ready, sorted []*Seed
+	for _, s := range seeds {
+		if pending[s] == 0 {
+			ready = append(ready, s)
+		}
+	}
+	for len(ready) > 0 {
+		sort.SliceStable(ready, func(i, j int) bool { return less(ready[i], ready[j]) })
+		s := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, s)
+		for _, d := range dependents[s] {
+			if pending[d]--; pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	if len(sorted) < len(seeds) {
+		var cycle []string
+		for _, s := range seeds {
+			if pending[s] > 0 {
+				cycle = append(cycle, describeSeed(s))
+			}
+		}
+		return nil, fmt.Errorf("seeds depend on each other in a cycle: %s", strings.Join(cycle, ", "))
+	}
+	return sorted, nil
+}
+
+func describeSeed(s *Seed) string {
+	if s.Env != "" {
+		return s.Env + "/" + s.Name
+	}
+	return s.Name
+}
@@ -0,0 +1,134 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// Report lists what a run did with each seed it did not find applied.
+type Report struct {
+	Applied []*Seed
+	Failed  []Failure
+	Skipped []*Seed // Not run because a dependency failed or the run stopped
+}
+
+// Failure is a seed that failed. Nothing it wrote was kept.
+type Failure struct {
+	Seed      *Seed
+	Statement string // Failing statement, if known
+	Err       error
+}
+
+// StatementError is the error of one statement of a seed.
+type StatementError struct {
+	Index     int // 1-based position in a SQL seed, 0 for data seeds
+	Statement string
+	Err       error
+}
+
+func (e *StatementError) Error() string {
+	if e.Index > 0 {
+		return fmt.Sprintf("statement %d: %v", e.Index, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *StatementError) Unwrap() error { return e.Err }
+
+// WithContinueOnError makes Run go on after a seed fails, skipping only the
+// seeds that depend on it. By default the run stops at the first failure.
+func (e *Engine) WithContinueOnError(on bool) *Engine {
+	e.continueOnError = on
+	return e
+}
+
+// RunReport is like Run, but reports every seed it applied, failed or
+// skipped. The error joins the failures.
+func (e *Engine) RunReport(ctx context.Context, env string) (*Report, error) {
+	report := &Report{}
+
+	seeds, err := e.selectSeeds(env)
+	if err != nil {
+		return report, err
+	}
+
+	applied, err := e.getApplied(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	appliedMap := make(map[string]bool)
+	for _, h := range applied {
+		key := h.Name + ":" + h.Env
+		appliedMap[key] = true
+	}
+
+	log := e.log(ctx)
+	changed := changedSeeds(seeds, applied)
+	if len(changed) > 0 && e.drift == DriftError {
+		return report, driftError(changed)
+	}
+	rerun := make(map[*Seed]bool)
+	for _, seed := range changed {
+		if e.drift == DriftRerun {
+			rerun[seed] = true
+		} else {
+			log.Warn("Seed changed since it was applied", nexuslog.F("seed", describeSeed(seed)))
+		}
+	}
+
+	var errs []error
+	blocked := make(map[string]bool) // Seeds that did not run, by name
+	for _, seed := range seeds {
+		key := seed.Name + ":" + seed.Env
+		if appliedMap[key] && !rerun[seed] {
+			continue // Already applied
+		}
+
+		if len(report.Failed) > 0 && !e.continueOnError || dependsOnAny(seed, blocked) {
+			log.Warn("Skipped seed", nexuslog.F("seed", describeSeed(seed)))
+			report.Skipped = append(report.Skipped, seed)
+			blocked[seed.Name] = true
+			continue
+		}
+
+		start := time.Now()
+		if err := e.applySeed(ctx, seed); err != nil {
+			log.Error("Seed failed", nexuslog.F("seed", describeSeed(seed)), nexuslog.Err(err))
+			failure := Failure{Seed: seed, Err: err}
+			var stmtErr *StatementError
+			if errors.As(err, &stmtErr) {
+				failure.Statement = stmtErr.Statement
+			}
+			report.Failed = append(report.Failed, failure)
+			errs = append(errs, fmt.Errorf("applying seed %s: %w", seed.Name, err))
+			blocked[seed.Name] = true
+			continue
+		}
+		log.Info("Applied seed", nexuslog.F("seed", describeSeed(seed)), nexuslog.F("duration", time.Since(start)))
+		report.Applied = append(report.Applied, seed)
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// ResetReport is like Reset, but returns the report of the run.
+func (e *Engine) ResetReport(ctx context.Context, env string) (*Report, error) {
+	if err := e.clearHistory(ctx, env); err != nil {
+		return &Report{}, err
+	}
+	return e.RunReport(ctx, env)
+}
+
+func dependsOnAny(seed *Seed, names map[string]bool) bool {
+	for _, dep := range seed.DependsOn {
+		if names[dep] {
+			return true
+		}
+	}
+	return false
+}
@@ -5,15 +5,21 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/core/transfer"
 	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
 )
 
 // Seed represents a single seed file.
@@ -25,6 +31,12 @@ type Seed struct {
 	Env         string    // Environment (dev, test, prod, or empty for all)
 	Checksum    string    // SHA256 hash of SQL
 	AppliedAt   time.Time // When seed was applied (zero if pending)
+	DependsOn   []string  // Seeds that must run first (from "-- depends:" or Register)
+	Func        Func      // Go seeds run this instead of SQL
+
+	// Data seeds (.csv, .json, .yaml) insert Rows into Table instead
+	Table string
+	Rows  []map[string]interface{}
 }
 
 // SeedHistory represents applied seeds stored in the database.
@@ -42,6 +54,7 @@ type SeedStatus struct {
 	Env       string
 	Applied   bool
 	AppliedAt time.Time
+	Changed   bool // Applied, but the seed changed since
 }
 
 // Engine manages seed operations.
@@ -49,14 +62,37 @@ type Engine struct {
 	conn      *dialects.Connection
 	seeds     []*Seed
 	tableName string
+	schema    *schema.Schema // Maps data seeds to tables and column types
+	drift     DriftPolicy    // What Run does with changed seeds
+	logger    nexuslog.Logger
+
+	continueOnError bool // Run on after a seed fails
 }
 
-// NewEngine creates a new seed engine.
+// NewEngine creates a new seed engine, including the Go seeds added with
+// Register.
 func NewEngine(conn *dialects.Connection) *Engine {
 	return &Engine{
 		conn:      conn,
+		seeds:     registered(),
 		tableName: "_nexus_seeds",
+		drift:     DriftWarn,
+	}
+}
+
+// WithLogger sets the logger of the engine. Without it, the engine logs to
+// the logger of the context, if any.
+func (e *Engine) WithLogger(l nexuslog.Logger) *Engine {
+	e.logger = l
+	return e
+}
+
+// log returns the logger of the engine.
+func (e *Engine) log(ctx context.Context) nexuslog.Logger {
+	if e.logger != nil {
+		return e.logger
 	}
+	return nexuslog.FromContext(ctx)
 }
 
 // Init creates the seeds tracking table if it doesn't exist.
@@ -70,6 +106,17 @@ func (e *Engine) Init(ctx context.Context) error {
 		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(name, env)
 	)`, dialect.Quote(e.tableName))
+	if dialect.Name() == "mssql" {
+		// No IF NOT EXISTS, TIMESTAMP is a row version and TEXT can't be unique
+		sql = fmt.Sprintf(`IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (
+		id INT IDENTITY(1,1) PRIMARY KEY,
+		name NVARCHAR(255) NOT NULL,
+		env NVARCHAR(64) NOT NULL DEFAULT '',
+		checksum NVARCHAR(64) NOT NULL,
+		applied_at DATETIME2 NOT NULL DEFAULT SYSDATETIME(),
+		UNIQUE(name, env)
+	)`, e.tableName, dialect.Quote(e.tableName))
+	}
 
 	_, err := e.conn.Exec(ctx, sql)
 	return err
@@ -86,13 +133,28 @@ func (e *Engine) Init(ctx context.Context) error {
 //	└── test/
 //	    └── 001_fixtures.sql
 func (e *Engine) LoadFromDir(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	return e.LoadFromFS(os.DirFS(dir))
+}
+
+// LoadFromFS loads seeds laid out as for LoadFromDir at the root of fsys,
+// such as an embed.FS, so that a binary carries its seeds:
+//
+//	//go:embed seeds
+//	var files embed.FS
+//
+//	dir, _ := fs.Sub(files, "seeds")
+//	err := engine.LoadFromFS(dir)
+func (e *Engine) LoadFromFS(fsys fs.FS) error {
 	// Load root-level seeds (all environments)
-	if err := e.loadSeedsFromPath(dir, ""); err != nil {
+	if err := e.loadSeedsFromPath(fsys, ".", ""); err != nil {
 		return err
 	}
 
 	// Load environment-specific seeds
-	entries, err := os.ReadDir(dir)
+	entries, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return err
 	}
@@ -104,8 +166,7 @@ func (e *Engine) LoadFromDir(dir string) error {
 			if strings.HasPrefix(envName, ".") {
 				continue
 			}
-			envPath := filepath.Join(dir, envName)
-			if err := e.loadSeedsFromPath(envPath, envName); err != nil {
+			if err := e.loadSeedsFromPath(fsys, envName, envName); err != nil {
 				return err
 			}
 		}
@@ -122,26 +183,32 @@ func (e *Engine) LoadFromDir(dir string) error {
 	return nil
 }
 
-func (e *Engine) loadSeedsFromPath(dir, env string) error {
-	files, err := os.ReadDir(dir)
+func (e *Engine) loadSeedsFromPath(fsys fs.FS, dir, env string) error {
+	files, err := fs.ReadDir(fsys, dir)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return nil
 		}
 		return err
 	}
 
 	for _, f := range files {
-		if f.IsDir() || !strings.HasSuffix(f.Name(), ".sql") {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".sql" && !dataFormats[ext]) {
 			continue
 		}
 
-		content, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		content, err := fs.ReadFile(fsys, path.Join(dir, f.Name()))
 		if err != nil {
 			return err
 		}
 
-		seed, err := parseSeedFile(f.Name(), string(content), env)
+		var seed *Seed
+		if ext == ".sql" {
+			seed, err = parseSeedFile(f.Name(), string(content), env)
+		} else {
+			seed, err = parseDataFile(f.Name(), content, env)
+		}
 		if err != nil {
 			return fmt.Errorf("parsing %s: %w", f.Name(), err)
 		}
@@ -155,15 +222,7 @@ func (e *Engine) loadSeedsFromPath(dir, env string) error {
 // parseSeedFile parses a seed file.
 // Expected filename format: 001_seed_name.sql or seed_name.sql
 func parseSeedFile(filename, content, env string) (*Seed, error) {
-	name := strings.TrimSuffix(filename, ".sql")
-	order := 0
-
-	// Extract order from prefix if present (e.g., 001_users)
-	re := regexp.MustCompile(`^(\d+)_(.+)$`)
-	if matches := re.FindStringSubmatch(name); len(matches) == 3 {
-		fmt.Sscanf(matches[1], "%d", &order)
-		name = matches[2]
-	}
+	name, order := seedName(filename)
 
 	// Parse header comments for metadata
 	description := ""
@@ -172,6 +231,17 @@ func parseSeedFile(filename, content, env string) (*Seed, error) {
 		description = strings.TrimSpace(matches[1])
 	}
 
+	// Dependencies: "-- depends: users, categories"
+	var dependsOn []string
+	dependsRe := regexp.MustCompile(`(?m)^--[ \t]*depends:[ \t]*(.+)$`)
+	if matches := dependsRe.FindStringSubmatch(content); len(matches) == 2 {
+		for _, dep := range strings.Split(matches[1], ",") {
+			if dep = strings.TrimSpace(dep); dep != "" {
+				dependsOn = append(dependsOn, dep)
+			}
+		}
+	}
+
 	// Compute checksum
 	hash := sha256.Sum256([]byte(content))
 	checksum := hex.EncodeToString(hash[:])
@@ -183,43 +253,44 @@ func parseSeedFile(filename, content, env string) (*Seed, error) {
 		Order:       order,
 		Env:         env,
 		Checksum:    checksum,
+		DependsOn:   dependsOn,
 	}, nil
 }
 
-// Run executes pending seeds for the specified environment.
+// seedName splits a seed file name such as 001_users.sql into the seed
+// name and its order (0 without a numeric prefix).
+func seedName(filename string) (string, int) {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	order := 0
+
+	// Extract order from prefix if present (e.g., 001_users)
+	re := regexp.MustCompile(`^(\d+)_(.+)$`)
+	if matches := re.FindStringSubmatch(name); len(matches) == 3 {
+		fmt.Sscanf(matches[1], "%d", &order)
+		name = matches[2]
+	}
+	return name, order
+}
+
+// Run executes pending seeds for the specified environment, each after the
+// seeds it depends on and in its own transaction. Applied seeds that changed
+// since are handled by the drift policy.
 // If env is empty, only runs seeds with no environment specified.
 // If env is "*", runs all seeds regardless of environment.
 func (e *Engine) Run(ctx context.Context, env string) (int, error) {
-	applied, err := e.getApplied(ctx)
-	if err != nil {
-		return 0, err
-	}
-
-	appliedMap := make(map[string]bool)
-	for _, h := range applied {
-		key := h.Name + ":" + h.Env
-		appliedMap[key] = true
-	}
+	report, err := e.RunReport(ctx, env)
+	return len(report.Applied), err
+}
 
-	count := 0
+// selectSeeds returns the seeds of env in run order.
+func (e *Engine) selectSeeds(env string) ([]*Seed, error) {
+	var selected []*Seed
 	for _, seed := range e.seeds {
-		// Check if this seed should run for this environment
-		if !shouldRunSeed(seed, env) {
-			continue
-		}
-
-		key := seed.Name + ":" + seed.Env
-		if appliedMap[key] {
-			continue // Already applied
+		if shouldRunSeed(seed, env) {
+			selected = append(selected, seed)
 		}
-
-		if err := e.applySeed(ctx, seed); err != nil {
-			return count, fmt.Errorf("applying seed %s: %w", seed.Name, err)
-		}
-		count++
 	}
-
-	return count, nil
+	return order(selected)
 }
 
 // shouldRunSeed determines if a seed should run for the given environment.
@@ -240,6 +311,12 @@ func shouldRunSeed(seed *Seed, env string) bool {
 
 // Reset clears seed history and re-runs all seeds.
 func (e *Engine) Reset(ctx context.Context, env string) (int, error) {
+	report, err := e.ResetReport(ctx, env)
+	return len(report.Applied), err
+}
+
+// clearHistory forgets the applied seeds of env.
+func (e *Engine) clearHistory(ctx context.Context, env string) error {
 	dialect := e.conn.Dialect
 
 	// Clear seed history for this environment
@@ -247,20 +324,12 @@ func (e *Engine) Reset(ctx context.Context, env string) (int, error) {
 	if env == "*" || env == "" {
 		deleteSQL = fmt.Sprintf("DELETE FROM %s", dialect.Quote(e.tableName))
 		_, err := e.conn.Exec(ctx, deleteSQL)
-		if err != nil {
-			return 0, err
-		}
-	} else {
-		deleteSQL = fmt.Sprintf("DELETE FROM %s WHERE env = %s OR env = ''",
-			dialect.Quote(e.tableName), dialect.Placeholder(1))
-		_, err := e.conn.Exec(ctx, deleteSQL, env)
-		if err != nil {
-			return 0, err
-		}
+		return err
 	}
-
-	// Re-run seeds
-	return e.Run(ctx, env)
+	deleteSQL = fmt.Sprintf("DELETE FROM %s WHERE env = %s OR env = ''",
+		dialect.Quote(e.tableName), dialect.Placeholder(1))
+	_, err := e.conn.Exec(ctx, deleteSQL, env)
+	return err
 }
 
 // Status returns the status of all seeds.
@@ -286,6 +355,7 @@ func (e *Engine) Status(ctx context.Context) ([]SeedStatus, error) {
 		if h, ok := appliedMap[key]; ok {
 			s.Applied = true
 			s.AppliedAt = h.AppliedAt
+			s.Changed = h.Checksum != seed.Checksum
 		}
 		status = append(status, s)
 	}
@@ -318,16 +388,45 @@ func (e *Engine) getApplied(ctx context.Context) ([]SeedHistory, error) {
 	return history, rows.Err()
 }
 
+// applySeed runs a seed and records it in one transaction.
 func (e *Engine) applySeed(ctx context.Context, seed *Seed) error {
 	dialect := e.conn.Dialect
 
-	// Execute seed SQL
-	_, err := e.conn.Exec(ctx, seed.SQL)
+	tx, err := e.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	switch {
+	case seed.Func != nil:
+		err = seed.Func(ctx, tx)
+	case seed.Rows != nil:
+		err = e.applyData(ctx, tx, seed)
+	default:
+		// One statement at a time, to report the one that fails
+		for i, stmt := range transfer.SplitStatements(seed.SQL) {
+			if _, err = tx.Exec(ctx, stmt); err != nil {
+				err = &StatementError{Index: i + 1, Statement: stmt, Err: err}
+				break
+			}
+		}
+	}
 	if err != nil {
 		return err
 	}
 
-	// Record in history
+	// Record in history, replacing the entry of a re-run seed
+	deleteSQL := fmt.Sprintf(
+		"DELETE FROM %s WHERE name = %s AND env = %s",
+		dialect.Quote(e.tableName),
+		dialect.Placeholder(1),
+		dialect.Placeholder(2),
+	)
+	if _, err := tx.Exec(ctx, deleteSQL, seed.Name, seed.Env); err != nil {
+		return err
+	}
+
 	insertSQL := fmt.Sprintf(
 		"INSERT INTO %s (name, env, checksum) VALUES (%s, %s, %s)",
 		dialect.Quote(e.tableName),
@@ -336,8 +435,10 @@ func (e *Engine) applySeed(ctx context.Context, seed *Seed) error {
 		dialect.Placeholder(3),
 	)
 
-	_, err = e.conn.Exec(ctx, insertSQL, seed.Name, seed.Env, seed.Checksum)
-	return err
+	if _, err := tx.Exec(ctx, insertSQL, seed.Name, seed.Env, seed.Checksum); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // GetSeeds returns all loaded seeds.
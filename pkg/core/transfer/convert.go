@@ -0,0 +1,162 @@
+package transfer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// Convert converts a value read from a data file or another database to
+// the Go type of the column. CSV values are strings; JSON and YAML values
+// may already be numbers (json.Number) or booleans, and values of another
+// database may be any type its driver returns.
+func Convert(f *schema.Field, v interface{}) (interface{}, error) {
+	if v == nil {
+		if !f.Nullable && !f.AutoIncrement && f.DefaultValue == nil && f.DefaultExpr == "" {
+			return nil, fmt.Errorf("cannot be null")
+		}
+		return nil, nil
+	}
+
+	// Driver values already of the column type
+	switch n := v.(type) {
+	case []byte:
+		if f.Type == schema.FieldTypeBytes {
+			return n, nil
+		}
+		v = string(n)
+	case time.Time:
+		switch f.Type {
+		case schema.FieldTypeDateTime, schema.FieldTypeDate, schema.FieldTypeTime:
+			return n, nil
+		}
+		v = n.Format(time.RFC3339Nano)
+	case json.RawMessage:
+		v = string(n)
+	case int64:
+		v = json.Number(strconv.FormatInt(n, 10))
+	case int:
+		v = json.Number(strconv.Itoa(n))
+	case float64:
+		v = json.Number(strconv.FormatFloat(n, 'f', -1, 64))
+	}
+
+	s, isString := v.(string)
+	if n, ok := v.(json.Number); ok {
+		s, isString = string(n), true
+	}
+
+	switch f.Type {
+	case schema.FieldTypeInt, schema.FieldTypeBigInt, schema.FieldTypeSmallInt, schema.FieldTypeTinyInt:
+		if isString {
+			if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+				return n, nil
+			}
+		}
+		return nil, fmt.Errorf("expected an integer, got %v", v)
+
+	case schema.FieldTypeFloat:
+		if isString {
+			if n, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return n, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a number, got %v", v)
+
+	case schema.FieldTypeDecimal, schema.FieldTypeMoney:
+		// Kept as text so no precision is lost
+		if isString {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return strings.TrimSpace(s), nil
+			}
+		}
+		return nil, fmt.Errorf("expected a number, got %v", v)
+
+	case schema.FieldTypeBool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "true", "t", "yes", "y", "1":
+			return true, nil
+		case "false", "f", "no", "n", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("expected a boolean, got %v", v)
+
+	case schema.FieldTypeDateTime, schema.FieldTypeDate, schema.FieldTypeTime:
+		if !isString {
+			break
+		}
+		if t, ok := parseTime(s); ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("expected an RFC 3339 time, got %q", s)
+
+	case schema.FieldTypeJSON:
+		if isString && json.Valid([]byte(s)) {
+			return s, nil
+		}
+		if isString {
+			// A plain string is stored as a JSON string
+			b, _ := json.Marshal(s)
+			return string(b), nil
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+
+	case schema.FieldTypeBytes:
+		if isString {
+			if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("expected base64 data")
+
+	case schema.FieldTypeEnum:
+		if isString && f.Enum != nil {
+			for _, value := range f.Enum.Values {
+				if value == s {
+					return s, nil
+				}
+			}
+			return nil, fmt.Errorf("%q is not a value of enum %s", s, f.Enum.Name)
+		}
+	}
+
+	if isString {
+		return s, nil
+	}
+	if b, ok := v.(bool); ok {
+		return strconv.FormatBool(b), nil
+	}
+	return nil, fmt.Errorf("unexpected value %v", v)
+}
+
+// timeLayouts are the time formats read from files and text columns,
+// including the one the SQLite driver writes.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"15:04:05",
+}
+
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,108 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// CreateTables creates the tables of the models selected by opts that the
+// database of conn does not have yet, with their enum types and indexes,
+// and returns their names. Existing tables are left as they are.
+func CreateTables(ctx context.Context, conn *dialects.Connection, s *schema.Schema, opts Options) ([]string, error) {
+	models, err := Models(s, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool)
+	if introspector, ok := conn.Dialect.(migration.Introspector); ok {
+		tables, err := introspector.IntrospectTables(ctx, conn.DB)
+		if err != nil {
+			return nil, fmt.Errorf("listing tables: %w", err)
+		}
+		for _, t := range tables {
+			existing[t] = true
+		}
+	}
+
+	dialect := conn.Dialect
+	var statements, created []string
+	enums, schemas := make(map[string]bool), make(map[string]bool)
+	for _, model := range models {
+		if existing[model.Table()] {
+			continue
+		}
+		statements = append(statements, migration.SchemaStatements(dialect, model, schemas)...)
+		statements = append(statements, migration.EnumTypeStatements(dialect, model.GetFields(), enums)...)
+		statements = append(statements, dialect.CreateTableSQL(model))
+		statements = append(statements, migration.PartitionStatements(dialect, model)...)
+		statements = append(statements, migration.CommentStatements(dialect, model)...)
+		for _, idx := range model.Indexes {
+			if len(idx.Fields) > 1 || !idx.Unique {
+				statements = append(statements, dialect.CreateIndexSQL(model.Table(), idx))
+			}
+		}
+		created = append(created, model.Table())
+	}
+
+	if len(statements) == 0 {
+		return nil, nil
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	for _, stmt := range statements {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("creating tables: %w\n%s", err, stmt)
+		}
+	}
+	return created, tx.Commit()
+}
+
+// Copy copies the rows of the models selected by opts from one database to
+// another, parents first, converting values to the column types of the
+// target. The rows are inserted in batches in one transaction, so a failed
+// copy leaves the target unchanged. It returns how many rows it copied.
+func Copy(ctx context.Context, from, to *dialects.Connection, s *schema.Schema, opts Options) (int64, error) {
+	models, err := Models(s, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := to.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Reading reports progress; the inserter would only repeat it
+	insertOpts := opts
+	insertOpts.Progress = nil
+
+	var count int64
+	for _, model := range models {
+		fields := model.GetFields()
+		in := newInserter(ctx, tx, model, insertOpts)
+		err := eachRow(ctx, from, model, opts, func(values []interface{}) error {
+			row := make(map[string]interface{}, len(fields))
+			for i, f := range fields {
+				row[f.Name] = values[i]
+			}
+			return in.add(row)
+		})
+		if err == nil {
+			err = in.finish()
+		}
+		if err != nil {
+			return count, err
+		}
+		count += in.done
+	}
+	return count, tx.Commit()
+}
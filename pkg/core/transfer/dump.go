@@ -0,0 +1,355 @@
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/mask"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// nullCell marks NULL in CSV dumps, so it differs from an empty string.
+const nullCell = `\N`
+
+// DumpSQL writes the rows of the models selected by opts as INSERT
+// statements for dialect (the dialect of conn if nil), parents first.
+func DumpSQL(ctx context.Context, conn *dialects.Connection, s *schema.Schema, dialect dialects.Dialect, w io.Writer, opts Options) error {
+	if dialect == nil {
+		dialect = conn.Dialect
+	}
+	models, err := Models(s, opts)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "-- Nexus data dump for %s\n", dialect.Name())
+	for _, model := range models {
+		fields := model.GetFields()
+		columns := make([]string, len(fields))
+		for i, f := range fields {
+			columns[i] = dialect.Quote(f.Name)
+		}
+		insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES", dialects.QuoteTable(dialect, model), strings.Join(columns, ", "))
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			names[i] = f.Name
+		}
+		identity := needsIdentityInsert(dialect, model, names)
+
+		fmt.Fprintf(bw, "\n-- %s\n", model.Table())
+		if identity {
+			fmt.Fprintf(bw, "SET IDENTITY_INSERT %s ON;\n", dialects.QuoteTable(dialect, model))
+		}
+		var batch []string
+		flush := func() {
+			if len(batch) > 0 {
+				fmt.Fprintf(bw, "%s\n  %s;\n", insert, strings.Join(batch, ",\n  "))
+				batch = batch[:0]
+			}
+		}
+		err := eachRow(ctx, conn, model, opts, func(values []interface{}) error {
+			literals := make([]string, len(values))
+			for i, v := range values {
+				literals[i] = sqlLiteral(dialect, v)
+			}
+			batch = append(batch, "("+strings.Join(literals, ", ")+")")
+			if len(batch) >= opts.batchSize() {
+				flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		flush()
+		if identity {
+			fmt.Fprintf(bw, "SET IDENTITY_INSERT %s OFF;\n", dialects.QuoteTable(dialect, model))
+		}
+	}
+	return bw.Flush()
+}
+
+// DumpJSON writes the rows of the models selected by opts as a JSON object
+// of table names to arrays of rows, parents first.
+func DumpJSON(ctx context.Context, conn *dialects.Connection, s *schema.Schema, w io.Writer, opts Options) error {
+	models, err := Models(s, opts)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("{")
+	for i, model := range models {
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		name, _ := json.Marshal(model.Table())
+		fmt.Fprintf(bw, "\n  %s: [", name)
+
+		fields := model.GetFields()
+		rows := 0
+		err := eachRow(ctx, conn, model, opts, func(values []interface{}) error {
+			if rows > 0 {
+				bw.WriteString(",")
+			}
+			rows++
+			bw.WriteString("\n    {")
+			for i, v := range values {
+				if i > 0 {
+					bw.WriteString(", ")
+				}
+				key, _ := json.Marshal(fields[i].Name)
+				value, err := json.Marshal(v)
+				if err != nil {
+					return fmt.Errorf("%s.%s: %w", model.Table(), fields[i].Name, err)
+				}
+				fmt.Fprintf(bw, "%s: %s", key, value)
+			}
+			bw.WriteString("}")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if rows > 0 {
+			bw.WriteString("\n  ")
+		}
+		bw.WriteString("]")
+	}
+	bw.WriteString("\n}\n")
+	return bw.Flush()
+}
+
+// DumpCSV writes the rows of model as CSV with a header row. NULL is
+// written as \N.
+func DumpCSV(ctx context.Context, conn *dialects.Connection, model *schema.Model, w io.Writer, opts Options) error {
+	cw := csv.NewWriter(w)
+	fields := model.GetFields()
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	err := eachRow(ctx, conn, model, opts, func(values []interface{}) error {
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = csvCell(v)
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// eachRow streams the rows of model, ordered by primary key, as values in
+// field order, with the columns of opts.Masked masked.
+func eachRow(ctx context.Context, conn *dialects.Connection, model *schema.Model, opts Options, fn func(values []interface{}) error) error {
+	table := model.Table()
+	total, err := query.New(conn, table).Select().Count(ctx)
+	if err != nil {
+		return fmt.Errorf("counting %s: %w", table, err)
+	}
+	if total > 0 {
+		opts.progress(table, 0, total)
+	}
+
+	fields := model.GetFields()
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Name
+	}
+	sel := query.New(conn, table).Select(columns...)
+	for _, f := range fields {
+		if f.IsPrimaryKey {
+			sel.OrderBy(f.Name, query.Asc)
+		}
+	}
+
+	it, err := sel.Iter(ctx)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", table, err)
+	}
+	defer it.Close()
+
+	var done int64
+	for it.Next() {
+		row := it.Result()
+		values := make([]interface{}, len(fields))
+		for i, f := range fields {
+			values[i] = exportValue(f, row[f.Name])
+			if opts.Masked[table+"."+f.Name] {
+				values[i] = mask.Value(f, values[i])
+			}
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+		if done++; done%int64(opts.batchSize()) == 0 && done < total {
+			opts.progress(table, done, total)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", table, err)
+	}
+	opts.progress(table, done, done)
+	return nil
+}
+
+// exportValue normalizes a value scanned by the driver to the column type:
+// int64, float64, bool, string (decimals and times), []byte or
+// json.RawMessage.
+func exportValue(f *schema.Field, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if b, ok := v.([]byte); ok {
+		if f.Type == schema.FieldTypeBytes {
+			return append([]byte(nil), b...)
+		}
+		v = string(b)
+	}
+
+	switch f.Type {
+	case schema.FieldTypeInt, schema.FieldTypeBigInt, schema.FieldTypeSmallInt, schema.FieldTypeTinyInt:
+		switch n := v.(type) {
+		case string:
+			if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+				return i
+			}
+		case float64:
+			return int64(n)
+		}
+	case schema.FieldTypeFloat:
+		switch n := v.(type) {
+		case int64:
+			return float64(n)
+		case string:
+			if x, err := strconv.ParseFloat(n, 64); err == nil {
+				return x
+			}
+		}
+	case schema.FieldTypeDecimal, schema.FieldTypeMoney:
+		switch n := v.(type) {
+		case int64:
+			return strconv.FormatInt(n, 10)
+		case float64:
+			return strconv.FormatFloat(n, 'f', -1, 64)
+		}
+	case schema.FieldTypeBool:
+		switch b := v.(type) {
+		case int64:
+			return b != 0
+		case string:
+			switch strings.ToLower(b) {
+			case "1", "t", "true":
+				return true
+			case "0", "f", "false":
+				return false
+			}
+		}
+	case schema.FieldTypeDateTime, schema.FieldTypeDate, schema.FieldTypeTime:
+		t, ok := v.(time.Time)
+		if s, isString := v.(string); isString {
+			// Text columns, e.g. in SQLite
+			t, ok = parseTime(s)
+		}
+		if !ok {
+			break
+		}
+		switch f.Type {
+		case schema.FieldTypeDate:
+			return t.Format("2006-01-02")
+		case schema.FieldTypeTime:
+			return t.Format("15:04:05")
+		}
+		return t.Format(time.RFC3339Nano)
+	case schema.FieldTypeJSON:
+		if s, ok := v.(string); ok && json.Valid([]byte(s)) {
+			return json.RawMessage(s)
+		}
+	}
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+func csvCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return nullCell
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case json.RawMessage:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// sqlLiteral writes an exported value as a SQL literal of dialect.
+func sqlLiteral(dialect dialects.Dialect, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		if dialect.Name() == "mssql" {
+			// BIT columns take 1 and 0
+			if v {
+				return "1"
+			}
+			return "0"
+		}
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case []byte:
+		switch dialect.Name() {
+		case "postgres":
+			return `'\x` + hex.EncodeToString(v) + `'`
+		case "mssql":
+			return "0x" + hex.EncodeToString(v)
+		}
+		return "X'" + hex.EncodeToString(v) + "'"
+	case json.RawMessage:
+		return quoteString(dialect, string(v))
+	}
+	return quoteString(dialect, fmt.Sprint(v))
+}
+
+func quoteString(dialect dialects.Dialect, s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	switch dialect.Name() {
+	case "mysql":
+		// MySQL treats backslashes in strings as escapes by default
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	case "mssql":
+		// Unicode literal, as columns are NVARCHAR
+		return "N'" + s + "'"
+	}
+	return "'" + s + "'"
+}
@@ -0,0 +1,279 @@
+package transfer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/bulk"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// LoadSQL runs the statements of a SQL dump in tx and returns how many ran.
+func LoadSQL(ctx context.Context, tx *dialects.Tx, r io.Reader, opts Options) (int64, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	statements := SplitStatements(string(content))
+	total := int64(len(statements))
+
+	if total > 0 {
+		opts.progress("statements", 0, total)
+	}
+	for i, stmt := range statements {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return int64(i), fmt.Errorf("statement %d: %w", i+1, err)
+		}
+		opts.progress("statements", int64(i+1), total)
+	}
+	return total, nil
+}
+
+// LoadJSON inserts the rows of a JSON dump (an object of table names to
+// arrays of rows) in tx, streaming them, and returns how many it inserted.
+// Tables not selected by opts are skipped.
+func LoadJSON(ctx context.Context, tx *dialects.Tx, s *schema.Schema, r io.Reader, opts Options) (int64, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := expectDelim(dec, '{'); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return count, err
+		}
+		table, _ := tok.(string)
+		model := FindModel(s, table)
+		if model == nil {
+			return count, fmt.Errorf("no model for table %q", table)
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return count, fmt.Errorf("%s: %w", table, err)
+		}
+
+		var in *inserter
+		if opts.includes(model) {
+			in = newInserter(ctx, tx, model, opts)
+		}
+		for dec.More() {
+			var row map[string]interface{}
+			if err := dec.Decode(&row); err != nil {
+				return count, fmt.Errorf("%s: %w", table, err)
+			}
+			if in == nil {
+				continue
+			}
+			if err := in.add(row); err != nil {
+				return count + in.done, err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return count, fmt.Errorf("%s: %w", table, err)
+		}
+		if in != nil {
+			if err := in.finish(); err != nil {
+				return count + in.done, err
+			}
+			count += in.done
+		}
+	}
+	return count, expectDelim(dec, '}')
+}
+
+// LoadCSV inserts the rows of a CSV file with a header row into the table
+// of model and returns how many it inserted. \N cells are NULL; empty cells
+// are empty strings in text columns and NULL otherwise.
+func LoadCSV(ctx context.Context, tx *dialects.Tx, model *schema.Model, r io.Reader, opts Options) (int64, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+	}
+
+	in := newInserter(ctx, tx, model, opts)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return in.done, err
+		}
+		row := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			if i >= len(record) {
+				break
+			}
+			cell := record[i]
+			if f := model.Fields[column]; cell == nullCell ||
+				cell == "" && f != nil && f.Type != schema.FieldTypeString && f.Type != schema.FieldTypeText {
+				row[column] = nil
+			} else {
+				row[column] = cell
+			}
+		}
+		if err := in.add(row); err != nil {
+			return in.done, err
+		}
+	}
+	return in.done, in.finish()
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+// inserter sends the rows of a table through a bulk writer, starting a
+// new one when the columns of the rows change.
+type inserter struct {
+	ctx     context.Context
+	tx      *dialects.Tx
+	model   *schema.Model
+	opts    Options
+	columns []string
+	writer  *bulk.Writer
+	sent    int64 // Rows of the previous writers
+	done    int64
+}
+
+func newInserter(ctx context.Context, tx *dialects.Tx, model *schema.Model, opts Options) *inserter {
+	opts.progress(model.Table(), 0, -1)
+	return &inserter{ctx: ctx, tx: tx, model: model, opts: opts}
+}
+
+// add converts row to the column types and writes it.
+func (in *inserter) add(raw map[string]interface{}) error {
+	n := in.done + 1
+	if in.writer != nil {
+		n = in.sent + in.writer.Count() + 1
+	}
+	columns := make([]string, 0, len(raw))
+	for column := range raw {
+		if _, ok := in.model.Fields[column]; !ok {
+			return fmt.Errorf("%s row %d: no column %q", in.model.Table(), n, column)
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	if len(columns) == 0 {
+		return nil
+	}
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		v, err := Convert(in.model.Fields[column], raw[column])
+		if err != nil {
+			return fmt.Errorf("%s row %d: column %s: %w", in.model.Table(), n, column, err)
+		}
+		values[i] = v
+	}
+
+	// Rows with other columns go to the next writer
+	if in.writer == nil || strings.Join(columns, ",") != strings.Join(in.columns, ",") {
+		if err := in.close(); err != nil {
+			return err
+		}
+		w, err := bulk.NewWriter(in.ctx, in.tx, in.model.Table(), columns, bulk.Options{
+			Method:    in.opts.Method,
+			BatchSize: in.opts.BatchSize,
+			Identity:  needsIdentityInsert(in.tx.Dialect, in.model, columns),
+		})
+		if err != nil {
+			return err
+		}
+		in.writer, in.columns = w, columns
+	}
+
+	before := in.writer.Count()
+	if err := in.writer.Write(values); err != nil {
+		return err
+	}
+	if in.done = in.sent + in.writer.Count(); in.writer.Count() != before {
+		in.opts.progress(in.model.Table(), in.done, -1)
+	}
+	return nil
+}
+
+// close sends the rows left in the writer.
+func (in *inserter) close() error {
+	if in.writer == nil {
+		return nil
+	}
+	n, err := in.writer.Close()
+	in.sent += n
+	in.done = in.sent
+	in.writer = nil
+	return err
+}
+
+// finish sends the queued rows and moves PostgreSQL sequences past the
+// loaded ids.
+func (in *inserter) finish() error {
+	if err := in.close(); err != nil {
+		return err
+	}
+	if err := resetSequences(in.ctx, in.tx, in.model); err != nil {
+		return err
+	}
+	in.opts.progress(in.model.Table(), in.done, in.done)
+	return nil
+}
+
+// needsIdentityInsert reports whether columns include an autoincrement
+// column of model on SQL Server, which rejects explicit values for IDENTITY
+// columns unless IDENTITY_INSERT is on. SQL Server moves the identity past
+// them by itself.
+func needsIdentityInsert(dialect dialects.Dialect, model *schema.Model, columns []string) bool {
+	if dialect.Name() != "mssql" {
+		return false
+	}
+	for _, column := range columns {
+		if f, ok := model.Fields[column]; ok && f.AutoIncrement {
+			return true
+		}
+	}
+	return false
+}
+
+// resetSequences moves the sequences of autoincrement columns past the
+// largest id, as rows were inserted with explicit ids. Only PostgreSQL
+// needs this.
+func resetSequences(ctx context.Context, tx *dialects.Tx, model *schema.Model) error {
+	dialect := tx.Dialect
+	if dialect.Name() != "postgres" {
+		return nil
+	}
+	for _, f := range model.GetFields() {
+		if !f.AutoIncrement {
+			continue
+		}
+		sql := fmt.Sprintf("SELECT setval(pg_get_serial_sequence(%s, %s), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+			dialect.Placeholder(1), dialect.Placeholder(2), dialect.Quote(f.Name), dialects.QuoteTable(dialect, model))
+		if _, err := tx.Exec(ctx, sql, dialects.QuoteTable(dialect, model), f.Name); err != nil {
+			return fmt.Errorf("resetting the sequence of %s.%s: %w", model.Table(), f.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/mask"
+)
+
+// maxParams keeps IN lists under the parameter limit of SQLite.
+const maxParams = 900
+
+// SampleOptions configures Sample.
+type SampleOptions struct {
+	Options
+	Rows      int      // Rows to pick at random per table (default 1000)
+	Anonymize []string // Columns to mask besides @pii fields: column or table.column
+	Clear     bool     // Delete the rows of the sampled tables in the target first
+}
+
+func (o SampleOptions) rows() int {
+	if o.Rows > 0 {
+		return o.Rows
+	}
+	return 1000
+}
+
+// Sample copies a random subset of the rows of the models selected by opts
+// from one database to another. Rows are picked per table; the rows they
+// reference through foreign keys are added, from any table, so the subset
+// is referentially consistent. Columns marked @pii and the columns of
+// opts.Anonymize are masked on the way. The rows are inserted in one
+// transaction; Sample returns how many it inserted.
+func Sample(ctx context.Context, from, to *dialects.Connection, s *schema.Schema, opts SampleOptions) (int64, error) {
+	models, err := Models(s, opts.Options)
+	if err != nil {
+		return 0, err
+	}
+	masked, err := MaskedColumns(s, opts)
+	if err != nil {
+		return 0, err
+	}
+	m := mask.New()
+
+	set := newSampleSet(s)
+	for _, model := range models {
+		rows, err := sampleRows(ctx, from, model, opts.rows())
+		if err != nil {
+			return 0, err
+		}
+		set.add(model, rows)
+	}
+	if err := set.addReferenced(ctx, from); err != nil {
+		return 0, err
+	}
+
+	tx, err := to.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	order := TableOrder(s)
+	if opts.Clear {
+		for i := len(order) - 1; i >= 0; i-- {
+			if model := order[i]; len(set.rows[model]) > 0 {
+				if _, err := tx.Exec(ctx, "DELETE FROM "+dialects.QuoteTable(tx.Dialect, model)); err != nil {
+					return 0, fmt.Errorf("clearing %s: %w", model.Table(), err)
+				}
+			}
+		}
+	}
+
+	var count int64
+	for _, model := range order {
+		rows := set.rows[model]
+		if len(rows) == 0 {
+			continue
+		}
+		fields := model.GetFields()
+		in := newInserter(ctx, tx, model, opts.Options)
+		for _, values := range rows {
+			row := make(map[string]interface{}, len(fields))
+			for i, f := range fields {
+				v := values[i]
+				if masked[model.Table()+"."+f.Name] {
+					v = m.Value(f, v)
+				}
+				row[f.Name] = v
+			}
+			if err := in.add(row); err != nil {
+				return count, err
+			}
+		}
+		if err := in.finish(); err != nil {
+			return count, err
+		}
+		count += in.done
+	}
+	return count, tx.Commit()
+}
+
+// MaskedColumns returns the columns Sample masks, as table.column: the
+// fields marked @pii and the columns named by opts.Anonymize, as a column
+// of any table or as table.column. Keys cannot be masked, as the rows that
+// reference them would no longer match.
+func MaskedColumns(s *schema.Schema, opts SampleOptions) (map[string]bool, error) {
+	keys := keyColumns(s)
+	masked := make(map[string]bool)
+	add := func(model *schema.Model, f *schema.Field) error {
+		column := model.Table() + "." + f.Name
+		if keys[column] {
+			return fmt.Errorf("cannot anonymize %s: it is a key other rows refer to", column)
+		}
+		if f.PIIMask == "null" && !f.Nullable {
+			return fmt.Errorf("cannot mask %s with null: it is not optional", column)
+		}
+		masked[column] = true
+		return nil
+	}
+
+	for _, model := range s.GetModels() {
+		for _, f := range model.GetFields() {
+			if f.IsPII {
+				if err := add(model, f); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, name := range opts.Anonymize {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		table, column, qualified := strings.Cut(name, ".")
+		if !qualified {
+			table, column = "", name
+		}
+		found := false
+		for _, model := range s.GetModels() {
+			if qualified && model != FindModel(s, table) {
+				continue
+			}
+			if f := model.Fields[column]; f != nil {
+				if err := add(model, f); err != nil {
+					return nil, err
+				}
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no column %q to anonymize", name)
+		}
+	}
+	return masked, nil
+}
+
+// keyColumns returns the primary keys, foreign keys and referenced columns
+// of s as table.column.
+func keyColumns(s *schema.Schema) map[string]bool {
+	keys := make(map[string]bool)
+	for _, model := range s.GetModels() {
+		for _, f := range model.GetFields() {
+			if f.IsPrimaryKey {
+				keys[model.Table()+"."+f.Name] = true
+			}
+		}
+		for _, rel := range model.Relations {
+			parent := s.Models[rel.TargetModel]
+			if rel.Type != schema.RelationBelongsTo || parent == nil {
+				continue
+			}
+			keys[model.Table()+"."+rel.ForeignKey] = true
+			keys[parent.Table()+"."+referenceKey(parent, rel)] = true
+		}
+	}
+	return keys
+}
+
+// referenceKey returns the column of parent a belongs-to relation refers to.
+func referenceKey(parent *schema.Model, rel *schema.Relation) string {
+	if rel.ReferenceKey != "" {
+		return rel.ReferenceKey
+	}
+	for _, f := range parent.GetFields() {
+		if f.IsPrimaryKey {
+			return f.Name
+		}
+	}
+	return "id"
+}
+
+// sampleSet holds the sampled rows of each model, in field order, with
+// indexes of the values of their key columns.
+type sampleSet struct {
+	schema *schema.Schema
+	rows   map[*schema.Model][][]interface{}
+	seen   map[*schema.Model]map[string]bool            // Primary keys of the rows
+	values map[*schema.Model]map[string]map[string]bool // Column -> values present
+	asked  map[*schema.Model]map[string]map[string]bool // Column -> values looked up
+}
+
+func newSampleSet(s *schema.Schema) *sampleSet {
+	return &sampleSet{
+		schema: s,
+		rows:   make(map[*schema.Model][][]interface{}),
+		seen:   make(map[*schema.Model]map[string]bool),
+		values: make(map[*schema.Model]map[string]map[string]bool),
+		asked:  make(map[*schema.Model]map[string]map[string]bool),
+	}
+}
+
+// add adds the rows not in the set yet and indexes their values.
+func (set *sampleSet) add(model *schema.Model, rows [][]interface{}) {
+	fields := model.GetFields()
+	if set.seen[model] == nil {
+		set.seen[model] = make(map[string]bool)
+		set.values[model] = make(map[string]map[string]bool)
+	}
+	for _, values := range rows {
+		var pk []string
+		for i, f := range fields {
+			if f.IsPrimaryKey {
+				pk = append(pk, valueKey(values[i]))
+			}
+		}
+		if len(pk) > 0 {
+			key := strings.Join(pk, "\x00")
+			if set.seen[model][key] {
+				continue
+			}
+			set.seen[model][key] = true
+		}
+		set.rows[model] = append(set.rows[model], values)
+		for i, f := range fields {
+			if values[i] == nil {
+				continue
+			}
+			if set.values[model][f.Name] == nil {
+				set.values[model][f.Name] = make(map[string]bool)
+			}
+			set.values[model][f.Name][valueKey(values[i])] = true
+		}
+	}
+}
+
+// addReferenced adds the rows the rows of the set reference, and the rows
+// those reference, until every foreign key of the set resolves.
+func (set *sampleSet) addReferenced(ctx context.Context, conn *dialects.Connection) error {
+	for {
+		added := false
+		for _, model := range set.schema.GetModels() {
+			for _, rel := range model.Relations {
+				parent := set.schema.Models[rel.TargetModel]
+				if rel.Type != schema.RelationBelongsTo || parent == nil {
+					continue
+				}
+				n, err := set.addParents(ctx, conn, model, parent, rel)
+				if err != nil {
+					return err
+				}
+				added = added || n > 0
+			}
+		}
+		if !added {
+			return nil
+		}
+	}
+}
+
+// addParents looks up the parent rows that the rows of model reference
+// through rel and are not in the set yet. It returns how many it asked for.
+func (set *sampleSet) addParents(ctx context.Context, conn *dialects.Connection, model, parent *schema.Model, rel *schema.Relation) (int, error) {
+	fk := -1
+	for i, f := range model.GetFields() {
+		if f.Name == rel.ForeignKey {
+			fk = i
+		}
+	}
+	if fk < 0 {
+		return 0, nil
+	}
+	column := referenceKey(parent, rel)
+	if set.asked[parent] == nil {
+		set.asked[parent] = make(map[string]map[string]bool)
+	}
+	if set.asked[parent][column] == nil {
+		set.asked[parent][column] = make(map[string]bool)
+	}
+	asked := set.asked[parent][column]
+
+	var missing []interface{}
+	for _, values := range set.rows[model] {
+		v := values[fk]
+		if v == nil {
+			continue
+		}
+		key := valueKey(v)
+		if asked[key] || set.values[parent][column][key] {
+			continue
+		}
+		asked[key] = true
+		missing = append(missing, v)
+	}
+
+	for start := 0; start < len(missing); start += maxParams {
+		end := min(start+maxParams, len(missing))
+		rows, err := selectRows(ctx, conn, parent, column, missing[start:end], 0)
+		if err != nil {
+			return 0, err
+		}
+		set.add(parent, rows)
+	}
+	return len(missing), nil
+}
+
+// sampleRows picks up to n rows of model at random.
+func sampleRows(ctx context.Context, conn *dialects.Connection, model *schema.Model, n int) ([][]interface{}, error) {
+	return selectRows(ctx, conn, model, "", nil, n)
+}
+
+// selectRows reads the rows of model whose column is one of values, or up
+// to limit random rows if column is empty, as values in field order.
+func selectRows(ctx context.Context, conn *dialects.Connection, model *schema.Model, column string, values []interface{}, limit int) ([][]interface{}, error) {
+	dialect := conn.Dialect
+	fields := model.GetFields()
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = dialect.Quote(f.Name)
+	}
+	sql := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), dialects.QuoteTable(dialect, model))
+	if column != "" {
+		placeholders := make([]string, len(values))
+		for i := range values {
+			placeholders[i] = dialect.Placeholder(i + 1)
+		}
+		sql += fmt.Sprintf(" WHERE %s IN (%s)", dialect.Quote(column), strings.Join(placeholders, ", "))
+	} else {
+		random := "RANDOM()"
+		switch dialect.Name() {
+		case "mysql":
+			random = "RAND()"
+		case "mssql":
+			random = "NEWID()"
+		}
+		sql += " ORDER BY " + random + dialects.LimitClause(dialect, limit, 0, true)
+	}
+
+	rows, err := conn.DB.QueryContext(ctx, sql, values...)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", model.Table(), err)
+	}
+	defer rows.Close()
+
+	var result [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(fields))
+		ptrs := make([]interface{}, len(fields))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", model.Table(), err)
+		}
+		for i, f := range fields {
+			row[i] = exportValue(f, row[i])
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", model.Table(), err)
+	}
+	return result, nil
+}
+
+// valueKey returns a key for an exported value, equal for the same value
+// read from a foreign key and from the column it references.
+func valueKey(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
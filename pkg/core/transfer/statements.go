@@ -0,0 +1,9 @@
+package transfer
+
+import "github.com/nexus-db/nexus/pkg/core/migration"
+
+// SplitStatements splits SQL into statements without comments. See
+// migration.SplitStatements.
+func SplitStatements(sql string) []string {
+	return migration.SplitStatements(sql)
+}
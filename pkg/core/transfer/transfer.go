@@ -0,0 +1,159 @@
+// Package transfer moves table data in and out of a database as SQL, CSV
+// or JSON, converting values with the types of the schema so that a dump
+// of one dialect loads into another.
+package transfer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/bulk"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// Format is a dump format.
+type Format string
+
+const (
+	FormatSQL  Format = "sql"  // INSERT statements
+	FormatCSV  Format = "csv"  // One file per table with a header row
+	FormatJSON Format = "json" // An object of table names to arrays of rows
+)
+
+// ParseFormat parses a format name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatSQL, FormatCSV, FormatJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q (expected sql, csv or json)", name)
+}
+
+// Progress is called as the rows of a table are written or read. total is
+// -1 while unknown; the last call for a table has done == total.
+type Progress func(table string, done, total int64)
+
+// Options configures a dump or load.
+type Options struct {
+	Tables    []string    // Tables or models to include; empty means all
+	BatchSize int         // Rows per INSERT statement (default 100)
+	Method    bulk.Method // How loads send rows (default auto: COPY, LOAD DATA or INSERT)
+	Progress  Progress    // Optional progress callback
+
+	// Masked are the columns, as table.column, that dumps replace with
+	// fake values (see MaskedColumns and the mask package).
+	Masked map[string]bool
+}
+
+func (o Options) batchSize() int {
+	if o.BatchSize > 0 {
+		return o.BatchSize
+	}
+	return 100
+}
+
+func (o Options) progress(table string, done, total int64) {
+	if o.Progress != nil {
+		o.Progress(table, done, total)
+	}
+}
+
+// includes reports whether the options select model.
+func (o Options) includes(model *schema.Model) bool {
+	if len(o.Tables) == 0 {
+		return true
+	}
+	for _, t := range o.Tables {
+		if t == model.Table() || strings.EqualFold(t, model.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Models returns the models selected by opts, parents before the models
+// that reference them. It fails on a table the schema does not have.
+func Models(s *schema.Schema, opts Options) ([]*schema.Model, error) {
+	for _, t := range opts.Tables {
+		if FindModel(s, t) == nil {
+			return nil, fmt.Errorf("no model for table %q", t)
+		}
+	}
+	var models []*schema.Model
+	for _, m := range TableOrder(s) {
+		if opts.includes(m) {
+			models = append(models, m)
+		}
+	}
+	return models, nil
+}
+
+// TableOrder returns the models of s with every model after the models its
+// foreign keys reference, so rows can be inserted in this order. Models
+// that reference each other keep the schema order.
+func TableOrder(s *schema.Schema) []*schema.Model {
+	models := s.GetModels()
+	pending := make(map[*schema.Model]int)
+	dependents := make(map[*schema.Model][]*schema.Model)
+	for _, m := range models {
+		seen := make(map[*schema.Model]bool)
+		for _, rel := range m.Relations {
+			if rel.Type != schema.RelationBelongsTo {
+				continue
+			}
+			parent := s.Models[rel.TargetModel]
+			if parent == nil || parent == m || seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			pending[m]++
+			dependents[parent] = append(dependents[parent], m)
+		}
+	}
+
+	var sorted []*schema.Model
+	done := make(map[*schema.Model]bool)
+	for len(sorted) < len(models) {
+		progressed := false
+		for _, m := range models {
+			if done[m] || pending[m] > 0 {
+				continue
+			}
+			done[m] = true
+			sorted = append(sorted, m)
+			for _, d := range dependents[m] {
+				pending[d]--
+			}
+			progressed = true
+			break // Restart to keep the schema order
+		}
+		if !progressed {
+			// A cycle: take the first remaining model
+			for _, m := range models {
+				if !done[m] {
+					pending[m] = 0
+					break
+				}
+			}
+		}
+	}
+	return sorted
+}
+
+// FindModel finds the model of a table: by table name, then by model name
+// ignoring case or in its plural.
+func FindModel(s *schema.Schema, name string) *schema.Model {
+	for _, m := range s.GetModels() {
+		if m.Table() == name {
+			return m
+		}
+	}
+	for _, m := range s.GetModels() {
+		lower := strings.ToLower(m.Name)
+		if strings.EqualFold(m.Name, name) || lower+"s" == name || lower+"es" == name ||
+			(strings.HasSuffix(lower, "y") && strings.TrimSuffix(lower, "y")+"ies" == name) {
+			return m
+		}
+	}
+	return nil
+}
@@ -4,6 +4,10 @@ package dialects
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/nexus-db/nexus/pkg/core/schema"
 )
@@ -16,9 +20,14 @@ type Dialect interface {
 	// DriverName returns the Go sql driver name.
 	DriverName() string
 
-	// Quote quotes an identifier (table/column name).
+	// Quote quotes an identifier (table/column name). Dots are part of
+	// the name.
 	Quote(identifier string) string
 
+	// QuoteQualified quotes a table name qualified with the schema it
+	// lives in, or the name alone if schema is empty.
+	QuoteQualified(schema, name string) string
+
 	// Placeholder returns the parameter placeholder for the given index (1-based).
 	// PostgreSQL uses $1, $2; MySQL/SQLite use ?.
 	Placeholder(index int) string
@@ -62,10 +71,159 @@ type Dialect interface {
 	SupportsExplainFormat(format string) bool
 }
 
+// EnumTypeCreator is implemented by dialects whose enum columns use a named
+// type that must exist before tables can reference it.
+type EnumTypeCreator interface {
+	// CreateEnumTypeSQL generates a statement creating the enum type if it
+	// does not exist yet.
+	CreateEnumTypeSQL(e *schema.Enum) string
+}
+
+// SchemaCreator is implemented by dialects whose tables can live in named
+// schemas, which must exist before tables are created in them.
+type SchemaCreator interface {
+	// CreateSchemaSQL generates a statement creating the schema if it
+	// does not exist yet.
+	CreateSchemaSQL(name string) string
+}
+
+// Collator is implemented by dialects that can change the collation of an
+// existing column.
+type Collator interface {
+	// AlterCollationSQL generates a statement changing the collation, and
+	// the character set where the dialect has them, of a column to the
+	// field's own, or to the defaults if it has none.
+	AlterCollationSQL(tableName string, field *schema.Field) string
+}
+
+// TableCollator is implemented by dialects whose tables have a default
+// character set and collation.
+type TableCollator interface {
+	// TableCollationSQL generates a statement changing the defaults of a
+	// table; an empty charset or collation is left as it is.
+	TableCollationSQL(tableName, charset, collation string) string
+}
+
+// Commenter is implemented by dialects that store comments on tables and
+// columns.
+type Commenter interface {
+	// InlineComments reports whether CREATE TABLE and ADD COLUMN declare
+	// the comments of models and fields themselves; otherwise new tables
+	// and columns take TableCommentSQL and ColumnCommentSQL statements.
+	InlineComments() bool
+
+	// TableCommentSQL generates a statement setting the comment of a
+	// table, or removing it if comment is empty.
+	TableCommentSQL(tableName, comment string) string
+
+	// ColumnCommentSQL generates a statement setting the comment of a
+	// column to the field's, or removing it if the field has none.
+	ColumnCommentSQL(tableName string, field *schema.Field) string
+}
+
+// Defaulter is implemented by dialects that can change the default of an
+// existing column.
+type Defaulter interface {
+	// DefaultSQL renders the default of a field as it follows DEFAULT,
+	// translating portable expressions such as schema.DefaultExprNow into
+	// the dialect's own; empty if the field has none.
+	DefaultSQL(field *schema.Field) string
+
+	// AlterDefaultSQL generates a statement setting the default of a
+	// column to the field's, or dropping it if the field has none.
+	AlterDefaultSQL(tableName string, field *schema.Field) string
+}
+
+// RowSecurity is implemented by dialects with row-level security policies.
+type RowSecurity interface {
+	// RowSecuritySQL generates a statement enabling or disabling row-level
+	// security on a table, and applying it to the table owner if forced.
+	RowSecuritySQL(tableName string, enabled, forced bool) string
+
+	// CreatePolicySQL generates a CREATE POLICY statement.
+	CreatePolicySQL(tableName string, policy *schema.Policy) string
+
+	// DropPolicySQL generates a DROP POLICY statement.
+	DropPolicySQL(tableName, policyName string) string
+}
+
+// FunctionCreator is implemented by dialects with stored functions.
+type FunctionCreator interface {
+	// CreateFunctionSQL generates a statement creating or replacing a
+	// function or procedure.
+	CreateFunctionSQL(f *schema.Function) string
+
+	// DropFunctionSQL generates a statement dropping a function or
+	// procedure if it exists.
+	DropFunctionSQL(f *schema.Function) string
+}
+
+// Partitioner is implemented by dialects with partitioned tables, whose
+// CreateTableSQL declares the partitioning of models with a Partition.
+type Partitioner interface {
+	// InitialPartitionsSQL generates the statements creating the
+	// partitions a new partitioned table starts with.
+	InitialPartitionsSQL(model *schema.Model) []string
+
+	// AddPartitionSQL generates a statement adding a range partition.
+	AddPartitionSQL(model *schema.Model, p schema.Partition) string
+
+	// PartitionName returns the name of a partition in the database.
+	PartitionName(model *schema.Model, p schema.Partition) string
+
+	// IntrospectPartitions returns the names of the partitions of a table.
+	IntrospectPartitions(ctx context.Context, db *sql.DB, tableName string) ([]string, error)
+}
+
+// Paginator is implemented by dialects without LIMIT and OFFSET.
+type Paginator interface {
+	// LimitClause returns the clause appended to a query to return limit
+	// rows after skipping offset; either is 0 when unset. ordered reports
+	// whether the query ends with an ORDER BY.
+	LimitClause(limit, offset int, ordered bool) string
+}
+
+// QuoteTable quotes the table of model for dialect, qualified with the
+// schema the model declares with @@schema, if any.
+func QuoteTable(dialect Dialect, model *schema.Model) string {
+	if model.SchemaName == "" {
+		return dialect.Quote(model.Table())
+	}
+	return dialect.QuoteQualified(model.SchemaName, strings.TrimPrefix(model.Table(), model.SchemaName+"."))
+}
+
+// LimitClause returns the clause limiting a query for dialect: LIMIT and
+// OFFSET, unless the dialect is a Paginator.
+func LimitClause(dialect Dialect, limit, offset int, ordered bool) string {
+	if p, ok := dialect.(Paginator); ok {
+		return p.LimitClause(limit, offset, ordered)
+	}
+	sql := ""
+	if limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	if offset > 0 {
+		sql += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return sql
+}
+
+// Upserter is implemented by dialects without INSERT ... ON CONFLICT.
+type Upserter interface {
+	// UpsertSQL generates an upsert into table, quoted already, of rows,
+	// each a parenthesized list of placeholders for columns, matching existing rows on the conflict
+	// columns. updates are "column = value" assignments for matching rows;
+	// without them matching rows are left alone.
+	UpsertSQL(table string, columns, rows, conflict, updates []string) string
+}
+
 // Connection represents a database connection with dialect awareness.
 type Connection struct {
 	DB      *sql.DB
 	Dialect Dialect
+
+	hooks  []QueryHook
+	safety Safety
 }
 
 // NewConnection creates a new connection with the specified dialect.
@@ -78,26 +236,55 @@ func NewConnection(db *sql.DB, dialect Dialect) *Connection {
 
 // Exec executes a query without returning rows.
 func (c *Connection) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return c.DB.ExecContext(ctx, query, args...)
+	if err := c.safety.Check(ctx, query); err != nil {
+		return nil, err
+	}
+	if c.safety.MaxRows > 0 && c.safety.guarded(ctx, query) {
+		return c.execLimited(ctx, query, args)
+	}
+	start := time.Now()
+	result, err := c.DB.ExecContext(ctx, query, args...)
+	runHooks(ctx, c.hooks, query, args, start, err)
+	return result, err
 }
 
 // Query executes a query that returns rows.
 func (c *Connection) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	return c.DB.QueryContext(ctx, query, args...)
+	if err := c.safety.Check(ctx, query); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	rows, err := c.DB.QueryContext(ctx, query, args...)
+	runHooks(ctx, c.hooks, query, args, start, err)
+	return rows, err
+}
+
+// QueryStmt executes a statement prepared from query that returns rows.
+func (c *Connection) QueryStmt(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := stmt.QueryContext(ctx, args...)
+	runHooks(ctx, c.hooks, query, args, start, err)
+	return rows, err
 }
 
 // QueryRow executes a query that returns at most one row.
 func (c *Connection) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	return c.DB.QueryRowContext(ctx, query, args...)
+	if err := c.safety.Check(ctx, query); err != nil {
+		return c.DB.QueryRowContext(refusedContext{ctx, err}, query, args...)
+	}
+	start := time.Now()
+	row := c.DB.QueryRowContext(ctx, query, args...)
+	runHooks(ctx, c.hooks, query, args, start, row.Err())
+	return row
 }
 
-// Begin starts a transaction.
+// Begin starts a transaction. It runs the connection's hooks too.
 func (c *Connection) Begin(ctx context.Context) (*Tx, error) {
 	tx, err := c.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &Tx{Tx: tx, Dialect: c.Dialect}, nil
+	return &Tx{Tx: tx, Dialect: c.Dialect, Driver: c.DB.Driver(), hooks: c.hooks, safety: c.safety}, nil
 }
 
 // Close closes the database connection.
@@ -109,21 +296,59 @@ func (c *Connection) Close() error {
 type Tx struct {
 	Tx      *sql.Tx
 	Dialect Dialect
+	Driver  driver.Driver // Driver of the connection; set by Connection.Begin
+
+	hooks  []QueryHook
+	safety Safety
 }
 
 // Exec executes a query within the transaction.
 func (t *Tx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return t.Tx.ExecContext(ctx, query, args...)
+	if err := t.safety.Check(ctx, query); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	result, err := t.Tx.ExecContext(ctx, query, args...)
+	runHooks(ctx, t.hooks, query, args, start, err)
+	if err == nil && t.safety.MaxRows > 0 {
+		if n, rowsErr := result.RowsAffected(); rowsErr == nil {
+			err = t.safety.CheckRows(ctx, query, n)
+		}
+	}
+	return result, err
 }
 
 // Query executes a query that returns rows within the transaction.
 func (t *Tx) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	return t.Tx.QueryContext(ctx, query, args...)
+	if err := t.safety.Check(ctx, query); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	rows, err := t.Tx.QueryContext(ctx, query, args...)
+	runHooks(ctx, t.hooks, query, args, start, err)
+	return rows, err
 }
 
 // QueryRow executes a query that returns at most one row within the transaction.
 func (t *Tx) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	return t.Tx.QueryRowContext(ctx, query, args...)
+	if err := t.safety.Check(ctx, query); err != nil {
+		return t.Tx.QueryRowContext(refusedContext{ctx, err}, query, args...)
+	}
+	start := time.Now()
+	row := t.Tx.QueryRowContext(ctx, query, args...)
+	runHooks(ctx, t.hooks, query, args, start, row.Err())
+	return row
+}
+
+// SetLocal sets a configuration parameter such as app.tenant until the
+// transaction ends, for row-level security policies to read with
+// current_setting. Only PostgreSQL has transaction-scoped settings.
+func (t *Tx) SetLocal(ctx context.Context, name string, value interface{}) error {
+	if t.Dialect.Name() != "postgres" {
+		return fmt.Errorf("SetLocal needs PostgreSQL, not %s", t.Dialect.Name())
+	}
+	_, err := t.Exec(ctx, "SELECT set_config($1, $2, true)", name, fmt.Sprint(value))
+	return err
 }
 
 // Commit commits the transaction.
@@ -0,0 +1,52 @@
+package dialects
+
+import (
+	"context"
+	"time"
+)
+
+// QueryEvent describes a statement run through a Connection or one of its
+// transactions.
+type QueryEvent struct {
+	// Query is the SQL of the statement.
+	Query string
+	// Args are the statement's parameters.
+	Args []interface{}
+	// Start is when the statement was sent.
+	Start time.Time
+	// Duration is how long the database took to run the statement. For
+	// queries returning rows it ends when the first rows are ready, not
+	// when they have all been read.
+	Duration time.Duration
+	// Err is the error the statement failed with, if any.
+	Err error
+}
+
+// QueryHook is called after every statement run through a Connection it
+// is added to, such as by builders and raw queries. Hooks run on the
+// goroutine of the query, so slow work should be handed off.
+type QueryHook func(ctx context.Context, event QueryEvent)
+
+// Use adds hooks to the connection. Transactions begun afterwards run
+// them too. Add hooks while setting the connection up, before it is
+// shared between goroutines.
+func (c *Connection) Use(hooks ...QueryHook) {
+	c.hooks = append(c.hooks, hooks...)
+}
+
+// runHooks calls hooks with the event of a finished statement.
+func runHooks(ctx context.Context, hooks []QueryHook, query string, args []interface{}, start time.Time, err error) {
+	if len(hooks) == 0 {
+		return
+	}
+	event := QueryEvent{
+		Query:    query,
+		Args:     args,
+		Start:    start,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	for _, hook := range hooks {
+		hook(ctx, event)
+	}
+}
@@ -0,0 +1,83 @@
+package dialects
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxIdentifierLength is the longest identifier ValidateIdentifier
+// accepts. Databases have lower limits of their own (PostgreSQL truncates
+// at 63 bytes), this only bounds what is sent to them.
+const MaxIdentifierLength = 128
+
+// ErrInvalidIdentifier is returned for names that can't be used as an
+// identifier, and ErrUnknownIdentifier for names not in an IdentifierSet.
+var (
+	ErrInvalidIdentifier = errors.New("invalid identifier")
+	ErrUnknownIdentifier = errors.New("unknown identifier")
+)
+
+// ValidateIdentifier checks that name can be quoted as a table or column
+// name: it must be non-empty valid UTF-8 of at most MaxIdentifierLength
+// bytes without NUL or other control characters. Quote escapes quote
+// characters, so a valid name can't break out of its quotes.
+func ValidateIdentifier(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: empty name", ErrInvalidIdentifier)
+	case len(name) > MaxIdentifierLength:
+		return fmt.Errorf("%w: name is longer than %d bytes", ErrInvalidIdentifier, MaxIdentifierLength)
+	case !utf8.ValidString(name):
+		return fmt.Errorf("%w: %q is not valid UTF-8", ErrInvalidIdentifier, name)
+	case strings.IndexFunc(name, isControl) >= 0:
+		return fmt.Errorf("%w: %q contains a control character", ErrInvalidIdentifier, name)
+	}
+	return nil
+}
+
+// isControl reports whether r is an ASCII control character.
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7F
+}
+
+// QuoteIdentifier validates name and quotes it for d. Use it instead of
+// Quote for names that come from outside the program.
+func QuoteIdentifier(d Dialect, name string) (string, error) {
+	if err := ValidateIdentifier(name); err != nil {
+		return "", err
+	}
+	return d.Quote(name), nil
+}
+
+// IdentifierSet is a set of known identifiers, such as the tables of a
+// database, that untrusted names are checked against before they are put
+// in SQL.
+type IdentifierSet struct {
+	names map[string]bool
+}
+
+// NewIdentifierSet creates a set of names.
+func NewIdentifierSet(names []string) *IdentifierSet {
+	s := &IdentifierSet{names: make(map[string]bool, len(names))}
+	for _, name := range names {
+		s.names[name] = true
+	}
+	return s
+}
+
+// Contains reports whether name is in the set. Names are compared
+// exactly.
+func (s *IdentifierSet) Contains(name string) bool {
+	return s.names[name]
+}
+
+// Quote quotes name for d if it is in the set, and returns
+// ErrUnknownIdentifier otherwise.
+func (s *IdentifierSet) Quote(d Dialect, name string) (string, error) {
+	if !s.Contains(name) {
+		return "", fmt.Errorf("%w: %q", ErrUnknownIdentifier, name)
+	}
+	return QuoteIdentifier(d, name)
+}
@@ -0,0 +1,422 @@
+// Package mssql provides Microsoft SQL Server dialect implementation.
+package mssql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// Dialect implements the SQL Server dialect. It targets SQL Server 2016
+// and later, for DROP ... IF EXISTS.
+type Dialect struct{}
+
+// New creates a new SQL Server dialect.
+func New() *Dialect {
+	return &Dialect{}
+}
+
+// Name returns the dialect name.
+func (d *Dialect) Name() string {
+	return "mssql"
+}
+
+// DriverName returns the Go sql driver name, registered by
+// github.com/microsoft/go-mssqldb.
+func (d *Dialect) DriverName() string {
+	return "sqlserver"
+}
+
+// Quote quotes an identifier with brackets, doubling any closing brackets
+// in it.
+func (d *Dialect) Quote(identifier string) string {
+	return "[" + strings.ReplaceAll(identifier, "]", "]]") + "]"
+}
+
+// QuoteQualified quotes a table name qualified with the schema it lives
+// in, or the name alone if namespace is empty.
+func (d *Dialect) QuoteQualified(namespace, name string) string {
+	if namespace == "" {
+		return d.Quote(name)
+	}
+	return d.Quote(namespace) + "." + d.Quote(name)
+}
+
+// quoteTable quotes a table name as the statements generated from schemas
+// are given it, by Model.Table: qualified with the schema of models that
+// declare one with @@schema.
+func (d *Dialect) quoteTable(table string) string {
+	return d.QuoteQualified(schema.SplitTable(table))
+}
+
+// Placeholder returns the parameter placeholder.
+func (d *Dialect) Placeholder(index int) string {
+	return fmt.Sprintf("@p%d", index)
+}
+
+// TypeMapping maps schema field types to SQL Server types.
+func (d *Dialect) TypeMapping(field *schema.Field) string {
+	switch field.Type {
+	case schema.FieldTypeInt:
+		return "INT"
+	case schema.FieldTypeBigInt:
+		return "BIGINT"
+	case schema.FieldTypeSmallInt:
+		return "SMALLINT"
+	case schema.FieldTypeTinyInt:
+		// TINYINT is unsigned
+		if field.IsUnsigned {
+			return "TINYINT"
+		}
+		return "SMALLINT"
+	case schema.FieldTypeString:
+		if field.Length > 0 {
+			return fmt.Sprintf("NVARCHAR(%d)", field.Length)
+		}
+		return "NVARCHAR(255)"
+	case schema.FieldTypeText:
+		return "NVARCHAR(MAX)"
+	case schema.FieldTypeBool:
+		return "BIT"
+	case schema.FieldTypeFloat:
+		return "FLOAT"
+	case schema.FieldTypeDecimal:
+		return fmt.Sprintf("DECIMAL(%d,%d)", field.Precision, field.Scale)
+	case schema.FieldTypeMoney:
+		switch {
+		case field.Precision == schema.MoneyPrecision && field.Scale == schema.MoneyScale:
+			return "MONEY"
+		case field.Precision == 10 && field.Scale == 4:
+			return "SMALLMONEY"
+		}
+		return fmt.Sprintf("DECIMAL(%d,%d)", field.Precision, field.Scale)
+	case schema.FieldTypeDateTime:
+		return "DATETIME2"
+	case schema.FieldTypeDate:
+		return "DATE"
+	case schema.FieldTypeTime:
+		return "TIME"
+	case schema.FieldTypeJSON:
+		return "NVARCHAR(MAX)"
+	case schema.FieldTypeBytes:
+		return "VARBINARY(MAX)"
+	case schema.FieldTypeUUID:
+		return "UNIQUEIDENTIFIER"
+	case schema.FieldTypeEnum:
+		return "NVARCHAR(255)"
+	default:
+		return "NVARCHAR(MAX)"
+	}
+}
+
+// CreateTableSQL generates CREATE TABLE statement. SQL Server has no
+// CREATE TABLE IF NOT EXISTS, so it checks OBJECT_ID instead.
+func (d *Dialect) CreateTableSQL(model *schema.Model) string {
+	var columns []string
+	var constraints []string
+
+	for _, field := range model.GetFields() {
+		columns = append(columns, d.columnDefinition(field))
+	}
+
+	// Handle composite unique constraints
+	for _, idx := range model.Indexes {
+		if idx.Unique && len(idx.Fields) > 1 {
+			quotedFields := make([]string, len(idx.Fields))
+			for i, f := range idx.Fields {
+				quotedFields[i] = d.Quote(f)
+			}
+			constraints = append(constraints, fmt.Sprintf("UNIQUE (%s)", strings.Join(quotedFields, ", ")))
+		}
+	}
+
+	allParts := append(columns, constraints...)
+	return fmt.Sprintf("IF OBJECT_ID(%s, N'U') IS NULL\nCREATE TABLE %s (\n  %s\n)",
+		d.literal(model.Table()),
+		d.quoteTable(model.Table()),
+		strings.Join(allParts, ",\n  "))
+}
+
+func (d *Dialect) columnDefinition(field *schema.Field) string {
+	parts := []string{d.Quote(field.Name), d.TypeMapping(field)}
+
+	// The collation sets the code page too; there are no character sets
+	if _, collation := field.ColumnCollation(); collation != "" {
+		parts = append(parts, "COLLATE "+collation)
+	}
+
+	if field.AutoIncrement {
+		parts = append(parts, "IDENTITY(1,1)")
+	}
+
+	if field.IsPrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+	}
+
+	if !field.Nullable && !field.IsPrimaryKey {
+		parts = append(parts, "NOT NULL")
+	}
+
+	if field.IsUnique && !field.IsPrimaryKey {
+		parts = append(parts, "UNIQUE")
+	}
+
+	// Only TINYINT is unsigned
+	if field.IsUnsigned && d.TypeMapping(field) != "TINYINT" {
+		parts = append(parts, fmt.Sprintf("CHECK (%s >= 0)", d.Quote(field.Name)))
+	}
+
+	if def := d.DefaultSQL(field); def != "" {
+		parts = append(parts, "DEFAULT "+def)
+	}
+
+	// Enums are NVARCHAR columns restricted to their values
+	if field.Type == schema.FieldTypeEnum && field.Enum != nil {
+		values := make([]string, len(field.Enum.Values))
+		for i, v := range field.Enum.Values {
+			values[i] = d.literal(v)
+		}
+		parts = append(parts, fmt.Sprintf("CHECK (%s IN (%s))", d.Quote(field.Name), strings.Join(values, ", ")))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// literal returns s as a Unicode string literal.
+func (d *Dialect) literal(s string) string {
+	return "N'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// DefaultSQL renders the default of a field, translating the portable
+// expressions.
+func (d *Dialect) DefaultSQL(field *schema.Field) string {
+	switch strings.ToUpper(field.DefaultExpr) {
+	case "":
+	case schema.DefaultExprNow:
+		return "SYSDATETIME()"
+	case schema.DefaultExprUUID:
+		return "NEWID()"
+	case schema.DefaultExprRandom:
+		return "RAND()"
+	default:
+		return field.DefaultExpr
+	}
+
+	switch v := field.DefaultValue.(type) {
+	case nil:
+		return ""
+	case string:
+		return d.literal(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// AlterDefaultSQL drops the default constraint of a column, whose name
+// SQL Server generated, and adds one with the field's default if it has
+// one. The statements are one batch, without semicolons between them.
+func (d *Dialect) AlterDefaultSQL(tableName string, field *schema.Field) string {
+	table := d.literal(d.quoteTable(tableName))
+	sql := fmt.Sprintf("DECLARE @df sysname = (SELECT name FROM sys.default_constraints "+
+		"WHERE parent_object_id = OBJECT_ID(%s) AND parent_column_id = COLUMNPROPERTY(OBJECT_ID(%s), %s, 'ColumnId'))\n"+
+		"IF @df IS NOT NULL EXEC(N'ALTER TABLE %s DROP CONSTRAINT ' + QUOTENAME(@df))",
+		table, table, d.literal(field.Name), strings.ReplaceAll(d.quoteTable(tableName), "'", "''"))
+	if def := d.DefaultSQL(field); def != "" {
+		sql += fmt.Sprintf("\nALTER TABLE %s ADD DEFAULT %s FOR %s", d.quoteTable(tableName), def, d.Quote(field.Name))
+	}
+	return sql
+}
+
+// DropTableSQL generates DROP TABLE statement.
+func (d *Dialect) DropTableSQL(tableName string) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s", d.quoteTable(tableName))
+}
+
+// CreateIndexSQL generates CREATE INDEX statement, skipped if an index of
+// that name exists on the table.
+func (d *Dialect) CreateIndexSQL(tableName string, index *schema.Index) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+
+	quotedFields := make([]string, len(index.Fields))
+	for i, f := range index.Fields {
+		quotedFields[i] = d.Quote(f)
+	}
+
+	return fmt.Sprintf("IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = %s AND object_id = OBJECT_ID(%s))\nCREATE %sINDEX %s ON %s (%s)",
+		d.literal(index.Name),
+		d.literal(tableName),
+		unique,
+		d.Quote(index.Name),
+		d.quoteTable(tableName),
+		strings.Join(quotedFields, ", "))
+}
+
+// DropIndexSQL generates DROP INDEX statement.
+func (d *Dialect) DropIndexSQL(tableName, indexName string) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s ON %s", d.Quote(indexName), d.quoteTable(tableName))
+}
+
+// CreateSchemaSQL generates CREATE SCHEMA if the schema doesn't exist.
+// CREATE SCHEMA must be alone in its batch, so it runs through EXEC.
+func (d *Dialect) CreateSchemaSQL(name string) string {
+	literal := strings.NewReplacer("'", "''")
+	return fmt.Sprintf("IF SCHEMA_ID(N'%s') IS NULL EXEC(N'CREATE SCHEMA %s')",
+		literal.Replace(name), literal.Replace(d.Quote(name)))
+}
+
+// AddColumnSQL generates ALTER TABLE ADD statement.
+func (d *Dialect) AddColumnSQL(tableName string, field *schema.Field) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s",
+		d.quoteTable(tableName),
+		d.columnDefinition(field))
+}
+
+// AlterCollationSQL generates ALTER TABLE ALTER COLUMN ... COLLATE, which
+// fails while the column is in an index or constraint. A field without a
+// collation gets the database default.
+func (d *Dialect) AlterCollationSQL(tableName string, field *schema.Field) string {
+	collation := field.Collation
+	if collation == "" {
+		collation = "DATABASE_DEFAULT"
+	}
+	null := "NOT NULL"
+	if field.Nullable && !field.IsPrimaryKey {
+		null = "NULL"
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s COLLATE %s %s",
+		d.quoteTable(tableName), d.Quote(field.Name), d.TypeMapping(field), collation, null)
+}
+
+// InlineComments reports false: comments are extended properties, set by
+// statements of their own.
+func (d *Dialect) InlineComments() bool {
+	return false
+}
+
+// TableCommentSQL sets the MS_Description extended property of a table,
+// the description SQL Server tools show.
+func (d *Dialect) TableCommentSQL(tableName, comment string) string {
+	return d.descriptionSQL(tableName, "", comment)
+}
+
+// ColumnCommentSQL sets the MS_Description extended property of a column.
+func (d *Dialect) ColumnCommentSQL(tableName string, field *schema.Field) string {
+	return d.descriptionSQL(tableName, field.Name, field.Description)
+}
+
+// descriptionSQL generates a batch adding, updating or, for an empty
+// comment, dropping the MS_Description property of a table, or of one of
+// its columns if column is not empty.
+func (d *Dialect) descriptionSQL(tableName, column, comment string) string {
+	_, table := schema.SplitTable(tableName)
+	object := d.literal(d.quoteTable(tableName))
+	level := "N'SCHEMA', @schema, N'TABLE', " + d.literal(table)
+	minor := "0"
+	if column != "" {
+		level += ", N'COLUMN', " + d.literal(column)
+		minor = fmt.Sprintf("COLUMNPROPERTY(OBJECT_ID(%s), %s, 'ColumnId')", object, d.literal(column))
+	}
+
+	sql := fmt.Sprintf("DECLARE @schema sysname = OBJECT_SCHEMA_NAME(OBJECT_ID(%s))\n"+
+		"IF EXISTS (SELECT 1 FROM sys.extended_properties WHERE class = 1 AND major_id = OBJECT_ID(%s) AND minor_id = %s AND name = N'MS_Description')\n",
+		object, object, minor)
+	if comment == "" {
+		return sql + "EXEC sys.sp_dropextendedproperty N'MS_Description', " + level
+	}
+	value := d.literal(comment)
+	return sql + fmt.Sprintf("EXEC sys.sp_updateextendedproperty N'MS_Description', %s, %s\nELSE EXEC sys.sp_addextendedproperty N'MS_Description', %s, %s",
+		value, level, value, level)
+}
+
+// DropColumnSQL generates ALTER TABLE DROP COLUMN statement.
+func (d *Dialect) DropColumnSQL(tableName, columnName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
+		d.quoteTable(tableName),
+		d.Quote(columnName))
+}
+
+// RenameColumnSQL renames a column with sp_rename.
+func (d *Dialect) RenameColumnSQL(tableName, oldName, newName string) string {
+	return fmt.Sprintf("EXEC sp_rename %s, %s, N'COLUMN'",
+		d.literal(tableName+"."+oldName),
+		d.literal(newName))
+}
+
+// SupportsReturning returns false: SQL Server returns rows with an OUTPUT
+// clause, which sits in the middle of the statement.
+func (d *Dialect) SupportsReturning() bool {
+	return false
+}
+
+// SupportsUpsert returns true; upserts use MERGE (see UpsertSQL).
+func (d *Dialect) SupportsUpsert() bool {
+	return true
+}
+
+// LimitClause returns OFFSET ... FETCH NEXT, which needs an ORDER BY; an
+// unordered query gets ORDER BY (SELECT NULL).
+func (d *Dialect) LimitClause(limit, offset int, ordered bool) string {
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+	sql := ""
+	if !ordered {
+		sql = " ORDER BY (SELECT NULL)"
+	}
+	sql += fmt.Sprintf(" OFFSET %d ROWS", offset)
+	if limit > 0 {
+		sql += fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", limit)
+	}
+	return sql
+}
+
+// UpsertSQL generates a MERGE inserting rows into table, which is quoted,
+// or updating the row matching on the conflict columns. Without updates,
+// matching rows are left alone. Updates refer to the new row as source,
+// e.g. "[name] = source.[name]".
+func (d *Dialect) UpsertSQL(table string, columns []string, rows []string, conflict []string, updates []string) string {
+	quoted := make([]string, len(columns))
+	source := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = d.Quote(c)
+		source[i] = "source." + d.Quote(c)
+	}
+	match := make([]string, len(conflict))
+	for i, c := range conflict {
+		match[i] = fmt.Sprintf("target.%s = source.%s", d.Quote(c), d.Quote(c))
+	}
+
+	sql := fmt.Sprintf("MERGE INTO %s WITH (HOLDLOCK) AS target USING (VALUES %s) AS source (%s) ON %s",
+		table,
+		strings.Join(rows, ", "),
+		strings.Join(quoted, ", "),
+		strings.Join(match, " AND "))
+	if len(updates) > 0 {
+		sql += " WHEN MATCHED THEN UPDATE SET " + strings.Join(updates, ", ")
+	}
+	sql += fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);",
+		strings.Join(quoted, ", "),
+		strings.Join(source, ", "))
+	return sql
+}
+
+// ExplainSQL returns the query unchanged: SQL Server shows plans through
+// session settings (SET SHOWPLAN_XML), not a statement prefix. See
+// SupportsExplainFormat.
+func (d *Dialect) ExplainSQL(query string, format string, analyze bool) string {
+	return query
+}
+
+// SupportsExplainFormat returns false for every format.
+func (d *Dialect) SupportsExplainFormat(format string) bool {
+	return false
+}
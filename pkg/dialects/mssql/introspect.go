@@ -0,0 +1,302 @@
+// Package mssql provides Microsoft SQL Server dialect implementation.
+package mssql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+// IntrospectTables returns all user table names in the default schema.
+func (d *Dialect) IntrospectTables(ctx context.Context, db *sql.DB) ([]string, error) {
+	query := `SELECT t.name
+		FROM sys.tables t
+		WHERE t.schema_id = SCHEMA_ID()
+		AND t.is_ms_shipped = 0
+		ORDER BY t.name`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+
+	return tables, rows.Err()
+}
+
+// IntrospectColumns returns column metadata for a table.
+func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName string) ([]*migration.ColumnInfo, error) {
+	query := `SELECT
+		c.name,
+		ty.name,
+		c.max_length,
+		c.precision,
+		c.scale,
+		c.is_nullable,
+		c.is_identity,
+		dc.definition,
+		CAST(CASE WHEN EXISTS (
+			SELECT 1 FROM sys.index_columns ic
+			JOIN sys.indexes i ON i.object_id = ic.object_id AND i.index_id = ic.index_id
+			WHERE ic.object_id = c.object_id AND ic.column_id = c.column_id AND i.is_primary_key = 1
+		) THEN 1 ELSE 0 END AS BIT),
+		CAST(CASE WHEN EXISTS (
+			SELECT 1 FROM sys.index_columns ic
+			JOIN sys.indexes i ON i.object_id = ic.object_id AND i.index_id = ic.index_id
+			WHERE ic.object_id = c.object_id AND ic.column_id = c.column_id
+			AND i.is_unique_constraint = 1
+			AND (SELECT COUNT(*) FROM sys.index_columns x WHERE x.object_id = i.object_id AND x.index_id = i.index_id) = 1
+		) THEN 1 ELSE 0 END AS BIT),
+		NULLIF(c.collation_name, CONVERT(sysname, DATABASEPROPERTYEX(DB_NAME(), 'Collation'))),
+		CAST(ep.value AS NVARCHAR(MAX))
+	FROM sys.columns c
+	JOIN sys.types ty ON ty.user_type_id = c.user_type_id
+	LEFT JOIN sys.default_constraints dc ON dc.object_id = c.default_object_id
+	LEFT JOIN sys.extended_properties ep ON ep.class = 1 AND ep.major_id = c.object_id
+		AND ep.minor_id = c.column_id AND ep.name = N'MS_Description'
+	WHERE c.object_id = OBJECT_ID(@p1)
+	ORDER BY c.column_id`
+
+	rows, err := db.QueryContext(ctx, query, d.quoteTable(tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []*migration.ColumnInfo
+	for rows.Next() {
+		var name, typeName string
+		var maxLength, precision, scale int
+		var nullable, identity, primaryKey, unique bool
+		var defaultVal sql.NullString
+		var collation sql.NullString // Null for the database default
+		var comment sql.NullString
+
+		if err := rows.Scan(&name, &typeName, &maxLength, &precision, &scale,
+			&nullable, &identity, &defaultVal, &primaryKey, &unique, &collation, &comment); err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, &migration.ColumnInfo{
+			Name:         name,
+			Type:         columnType(typeName, maxLength, precision, scale),
+			Nullable:     nullable,
+			IsPrimaryKey: primaryKey,
+			IsUnique:     unique,
+			Default:      defaultVal.String,
+			AutoInc:      identity,
+			Unsigned:     strings.EqualFold(typeName, "tinyint"),
+			Collation:    collation.String,
+			Comment:      comment.String,
+		})
+	}
+
+	return columns, rows.Err()
+}
+
+// IntrospectTableComment returns the MS_Description extended property of
+// a table.
+func (d *Dialect) IntrospectTableComment(ctx context.Context, db *sql.DB, tableName string) (string, error) {
+	var comment sql.NullString
+	err := db.QueryRowContext(ctx, `SELECT CAST(value AS NVARCHAR(MAX)) FROM sys.extended_properties
+	WHERE class = 1 AND major_id = OBJECT_ID(@p1) AND minor_id = 0 AND name = N'MS_Description'`, d.quoteTable(tableName)).Scan(&comment)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return comment.String, err
+}
+
+// columnType spells out a column type with its length or precision, as
+// written in CREATE TABLE.
+func columnType(name string, maxLength, precision, scale int) string {
+	name = strings.ToUpper(name)
+	switch name {
+	case "NVARCHAR", "NCHAR", "VARCHAR", "CHAR", "VARBINARY", "BINARY":
+		if maxLength == -1 {
+			return name + "(MAX)"
+		}
+		if name == "NVARCHAR" || name == "NCHAR" {
+			maxLength /= 2 // Lengths are in bytes, two per character
+		}
+		return fmt.Sprintf("%s(%d)", name, maxLength)
+	case "DECIMAL", "NUMERIC":
+		return fmt.Sprintf("%s(%d,%d)", name, precision, scale)
+	}
+	return name
+}
+
+// IntrospectIndexes returns index metadata for a table, without the primary
+// key and the unique constraints of single columns.
+func (d *Dialect) IntrospectIndexes(ctx context.Context, db *sql.DB, tableName string) ([]*migration.IndexInfo, error) {
+	query := `SELECT i.name, i.is_unique, i.is_unique_constraint, c.name
+	FROM sys.indexes i
+	JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+	JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+	WHERE i.object_id = OBJECT_ID(@p1)
+	AND i.is_primary_key = 0
+	AND i.type > 0
+	AND ic.is_included_column = 0
+	ORDER BY i.name, ic.key_ordinal`
+
+	rows, err := db.QueryContext(ctx, query, d.quoteTable(tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []*migration.IndexInfo
+	byName := make(map[string]*migration.IndexInfo)
+	constraints := make(map[string]bool)
+	for rows.Next() {
+		var name, column string
+		var unique, constraint bool
+		if err := rows.Scan(&name, &unique, &constraint, &column); err != nil {
+			return nil, err
+		}
+
+		idx, ok := byName[name]
+		if !ok {
+			idx = &migration.IndexInfo{Name: name, Unique: unique}
+			byName[name] = idx
+			indexes = append(indexes, idx)
+			constraints[name] = constraint
+		}
+		idx.Columns = append(idx.Columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Single-column unique constraints are reported on the column
+	var result []*migration.IndexInfo
+	for _, idx := range indexes {
+		if constraints[idx.Name] && len(idx.Columns) == 1 {
+			continue
+		}
+		result = append(result, idx)
+	}
+	return result, nil
+}
+
+// IntrospectForeignKeys returns foreign key constraints for a table.
+func (d *Dialect) IntrospectForeignKeys(ctx context.Context, db *sql.DB, tableName string) ([]*migration.ForeignKeyInfo, error) {
+	query := `SELECT
+		fk.name,
+		pc.name,
+		OBJECT_NAME(fk.referenced_object_id),
+		rc.name,
+		fk.delete_referential_action_desc,
+		fk.update_referential_action_desc
+	FROM sys.foreign_keys fk
+	JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+	JOIN sys.columns pc ON pc.object_id = fkc.parent_object_id AND pc.column_id = fkc.parent_column_id
+	JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+	WHERE fk.parent_object_id = OBJECT_ID(@p1)
+	ORDER BY fk.name, fkc.constraint_column_id`
+
+	rows, err := db.QueryContext(ctx, query, d.quoteTable(tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []*migration.ForeignKeyInfo
+	byName := make(map[string]*migration.ForeignKeyInfo)
+	for rows.Next() {
+		var name, column, refTable, refColumn, onDelete, onUpdate string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+
+		fk, ok := byName[name]
+		if !ok {
+			fk = &migration.ForeignKeyInfo{
+				Name:     name,
+				RefTable: refTable,
+				OnDelete: referentialAction(onDelete),
+				OnUpdate: referentialAction(onUpdate),
+			}
+			byName[name] = fk
+			fks = append(fks, fk)
+		}
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+	}
+
+	return fks, rows.Err()
+}
+
+// referentialAction turns an action like SET_NULL into SQL (SET NULL).
+func referentialAction(desc string) string {
+	return strings.ReplaceAll(desc, "_", " ")
+}
+
+// IntrospectCheckConstraints returns CHECK constraints for a table.
+func (d *Dialect) IntrospectCheckConstraints(ctx context.Context, db *sql.DB, tableName string) ([]*migration.CheckInfo, error) {
+	query := `SELECT name, definition
+	FROM sys.check_constraints
+	WHERE parent_object_id = OBJECT_ID(@p1)
+	ORDER BY name`
+
+	rows, err := db.QueryContext(ctx, query, d.quoteTable(tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []*migration.CheckInfo
+	for rows.Next() {
+		var name, definition string
+		if err := rows.Scan(&name, &definition); err != nil {
+			return nil, err
+		}
+		checks = append(checks, &migration.CheckInfo{Name: name, Expression: definition})
+	}
+
+	return checks, rows.Err()
+}
+
+// IntrospectEnums returns nil: SQL Server has no enum types.
+func (d *Dialect) IntrospectEnums(ctx context.Context, db *sql.DB) ([]*migration.EnumInfo, error) {
+	return nil, nil
+}
+
+// IntrospectStats returns the size of the tables of the default schema and
+// their sum. Reclaimable space is reserved but unused pages.
+func (d *Dialect) IntrospectStats(ctx context.Context, db *sql.DB) (*migration.DatabaseStats, error) {
+	query := `SELECT t.name,
+		SUM(CASE WHEN p.index_id IN (0, 1) THEN p.row_count ELSE 0 END),
+		SUM(p.reserved_page_count) * 8192,
+		SUM(p.reserved_page_count - p.used_page_count) * 8192
+	FROM sys.dm_db_partition_stats p
+	JOIN sys.tables t ON t.object_id = p.object_id
+	WHERE t.schema_id = SCHEMA_ID()
+	AND t.is_ms_shipped = 0
+	GROUP BY t.name`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	stats := &migration.DatabaseStats{}
+	if stats.Tables, err = migration.ScanTableStats(rows); err != nil {
+		return nil, err
+	}
+	for _, t := range stats.Tables {
+		stats.Bytes += t.Bytes
+		stats.Reclaimable += t.Reclaimable
+	}
+	return stats, nil
+}
@@ -26,9 +26,25 @@ func (d *Dialect) DriverName() string {
 	return "mysql"
 }
 
-// Quote quotes an identifier.
+// Quote quotes an identifier, doubling any quote characters in it.
 func (d *Dialect) Quote(identifier string) string {
-	return "`" + identifier + "`"
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+// QuoteQualified quotes a table name qualified with the schema it lives
+// in, or the name alone if namespace is empty.
+func (d *Dialect) QuoteQualified(namespace, name string) string {
+	if namespace == "" {
+		return d.Quote(name)
+	}
+	return d.Quote(namespace) + "." + d.Quote(name)
+}
+
+// quoteTable quotes a table name as the statements generated from schemas
+// are given it, by Model.Table: qualified with the schema of models that
+// declare one with @@schema.
+func (d *Dialect) quoteTable(table string) string {
+	return d.QuoteQualified(schema.SplitTable(table))
 }
 
 // Placeholder returns the parameter placeholder.
@@ -38,11 +54,21 @@ func (d *Dialect) Placeholder(index int) string {
 
 // TypeMapping maps schema field types to MySQL types.
 func (d *Dialect) TypeMapping(field *schema.Field) string {
+	if field.IsUnsigned && field.Type.IsInteger() {
+		f := *field
+		f.IsUnsigned = false
+		return d.TypeMapping(&f) + " UNSIGNED"
+	}
+
 	switch field.Type {
 	case schema.FieldTypeInt:
 		return "INT"
 	case schema.FieldTypeBigInt:
 		return "BIGINT"
+	case schema.FieldTypeSmallInt:
+		return "SMALLINT"
+	case schema.FieldTypeTinyInt:
+		return "TINYINT"
 	case schema.FieldTypeString:
 		if field.Length > 0 {
 			return fmt.Sprintf("VARCHAR(%d)", field.Length)
@@ -54,7 +80,7 @@ func (d *Dialect) TypeMapping(field *schema.Field) string {
 		return "TINYINT(1)"
 	case schema.FieldTypeFloat:
 		return "DOUBLE"
-	case schema.FieldTypeDecimal:
+	case schema.FieldTypeDecimal, schema.FieldTypeMoney:
 		return fmt.Sprintf("DECIMAL(%d,%d)", field.Precision, field.Scale)
 	case schema.FieldTypeDateTime:
 		return "DATETIME"
@@ -68,6 +94,11 @@ func (d *Dialect) TypeMapping(field *schema.Field) string {
 		return "BLOB"
 	case schema.FieldTypeUUID:
 		return "CHAR(36)"
+	case schema.FieldTypeEnum:
+		if field.Enum != nil {
+			return "ENUM(" + quoteEnumValues(field.Enum) + ")"
+		}
+		return "VARCHAR(255)"
 	default:
 		return "TEXT"
 	}
@@ -79,9 +110,19 @@ func (d *Dialect) CreateTableSQL(model *schema.Model) string {
 	var constraints []string
 
 	for _, field := range model.GetFields() {
+		if model.Partition != nil && field.IsPrimaryKey {
+			// The primary key of a partitioned table includes the partition columns
+			f := *field
+			f.IsPrimaryKey = false
+			columns = append(columns, d.columnDefinition(&f))
+			continue
+		}
 		col := d.columnDefinition(field)
 		columns = append(columns, col)
 	}
+	if model.Partition != nil {
+		constraints = append(constraints, fmt.Sprintf("PRIMARY KEY (%s)", d.quoteList(model.Partition.PrimaryKey(model))))
+	}
 
 	// Handle composite unique constraints
 	for _, idx := range model.Indexes {
@@ -95,14 +136,88 @@ func (d *Dialect) CreateTableSQL(model *schema.Model) string {
 	}
 
 	allParts := append(columns, constraints...)
-	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
-		d.Quote(model.Name),
-		strings.Join(allParts, ",\n  "))
+	sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n) ENGINE=InnoDB%s",
+		d.quoteTable(model.Table()),
+		strings.Join(allParts, ",\n  "),
+		tableOptions(model))
+
+	// Range partitions are split off the catch-all p_max partition as they are added
+	if part := model.Partition; part != nil {
+		switch part.Strategy {
+		case schema.Range:
+			sql += fmt.Sprintf("\nPARTITION BY RANGE COLUMNS(%s) (PARTITION %s VALUES LESS THAN (MAXVALUE))",
+				d.quoteList(part.Columns), maxPartition)
+		case schema.Hash:
+			sql += fmt.Sprintf("\nPARTITION BY KEY(%s) PARTITIONS %d", d.quoteList(part.Columns), part.Count)
+		}
+	}
+	return sql
+}
+
+// tableOptions returns the default character set and collation of a
+// model's table, utf8mb4 unless it sets either, and its comment.
+func tableOptions(model *schema.Model) string {
+	charset := model.CharacterSet
+	if charset == "" && model.Collation == "" {
+		charset = "utf8mb4"
+	}
+	options := ""
+	if charset != "" {
+		options += " DEFAULT CHARSET=" + charset
+	}
+	if model.Collation != "" {
+		options += " COLLATE=" + model.Collation
+	}
+	if model.Description != "" {
+		options += " COMMENT=" + literal(model.Description)
+	}
+	return options
+}
+
+// maxPartition holds the rows of a range partitioned table past its last
+// partition.
+const maxPartition = "p_max"
+
+func (d *Dialect) quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = d.Quote(n)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// InitialPartitionsSQL returns nothing: MySQL declares partitions in
+// CREATE TABLE.
+func (d *Dialect) InitialPartitionsSQL(model *schema.Model) []string {
+	return nil
+}
+
+// AddPartitionSQL splits a range partition off p_max. Partitions must be
+// added in ascending order.
+func (d *Dialect) AddPartitionSQL(model *schema.Model, p schema.Partition) string {
+	return fmt.Sprintf("ALTER TABLE %s REORGANIZE PARTITION %s INTO (PARTITION %s VALUES LESS THAN ('%s'), PARTITION %s VALUES LESS THAN (MAXVALUE))",
+		d.quoteTable(model.Table()), maxPartition, d.PartitionName(model, p), p.To.Format("2006-01-02 15:04:05"), maxPartition)
+}
+
+// PartitionName returns the name of a partition, e.g. p2026_10.
+func (d *Dialect) PartitionName(model *schema.Model, p schema.Partition) string {
+	if strings.HasPrefix(p.Suffix, "p") {
+		return p.Suffix
+	}
+	return "p" + p.Suffix
 }
 
 func (d *Dialect) columnDefinition(field *schema.Field) string {
 	parts := []string{d.Quote(field.Name), d.TypeMapping(field)}
 
+	// Columns without their own take the defaults of the table
+	if field.CharacterSet != "" {
+		parts = append(parts, "CHARACTER SET "+field.CharacterSet)
+	}
+	if field.Collation != "" {
+		parts = append(parts, "COLLATE "+field.Collation)
+	}
+
 	if field.AutoIncrement {
 		parts = append(parts, "AUTO_INCREMENT")
 	}
@@ -119,36 +234,69 @@ func (d *Dialect) columnDefinition(field *schema.Field) string {
 		parts = append(parts, "PRIMARY KEY")
 	}
 
-	if field.DefaultExpr != "" {
-		expr := field.DefaultExpr
-		switch strings.ToUpper(expr) {
-		case "NOW()":
-			expr = "CURRENT_TIMESTAMP"
-		case "UUID()":
-			expr = "(UUID())"
+	if def := d.DefaultSQL(field); def != "" {
+		parts = append(parts, "DEFAULT "+def)
+	}
+
+	if field.Description != "" {
+		parts = append(parts, "COMMENT "+literal(field.Description))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// DefaultSQL renders the default of a field, translating the portable
+// expressions. Functions other than CURRENT_TIMESTAMP, and defaults of
+// TEXT and JSON columns, must be expressions in parentheses.
+func (d *Dialect) DefaultSQL(field *schema.Field) string {
+	switch strings.ToUpper(field.DefaultExpr) {
+	case "":
+	case schema.DefaultExprNow:
+		return "CURRENT_TIMESTAMP"
+	case schema.DefaultExprUUID:
+		return "(UUID())"
+	case schema.DefaultExprRandom:
+		return "(RAND())"
+	default:
+		return field.DefaultExpr
+	}
+
+	switch v := field.DefaultValue.(type) {
+	case nil:
+		return ""
+	case string:
+		if field.Type == schema.FieldTypeText || field.Type == schema.FieldTypeJSON {
+			return "(" + literal(v) + ")"
 		}
-		parts = append(parts, "DEFAULT "+expr)
-	} else if field.DefaultValue != nil {
-		switch v := field.DefaultValue.(type) {
-		case string:
-			parts = append(parts, fmt.Sprintf("DEFAULT '%s'", v))
-		case bool:
-			if v {
-				parts = append(parts, "DEFAULT 1")
-			} else {
-				parts = append(parts, "DEFAULT 0")
-			}
-		default:
-			parts = append(parts, fmt.Sprintf("DEFAULT %v", v))
+		return literal(v)
+	case bool:
+		if v {
+			return "1"
 		}
+		return "0"
+	default:
+		return fmt.Sprintf("%v", v)
 	}
+}
 
-	return strings.Join(parts, " ")
+// AlterDefaultSQL generates ALTER TABLE ... MODIFY COLUMN with the field's
+// default, as ALTER COLUMN ... SET DEFAULT takes no CURRENT_TIMESTAMP.
+func (d *Dialect) AlterDefaultSQL(tableName string, field *schema.Field) string {
+	return d.AlterCollationSQL(tableName, field)
+}
+
+// quoteEnumValues renders enum values as a quoted, comma-separated list.
+func quoteEnumValues(e *schema.Enum) string {
+	values := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		values[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return strings.Join(values, ", ")
 }
 
 // DropTableSQL generates DROP TABLE statement.
 func (d *Dialect) DropTableSQL(tableName string) string {
-	return fmt.Sprintf("DROP TABLE IF EXISTS %s", d.Quote(tableName))
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s", d.quoteTable(tableName))
 }
 
 // CreateIndexSQL generates CREATE INDEX statement.
@@ -166,33 +314,88 @@ func (d *Dialect) CreateIndexSQL(tableName string, index *schema.Index) string {
 	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)",
 		unique,
 		d.Quote(index.Name),
-		d.Quote(tableName),
+		d.quoteTable(tableName),
 		strings.Join(quotedFields, ", "))
 }
 
 // DropIndexSQL generates DROP INDEX statement.
 func (d *Dialect) DropIndexSQL(tableName, indexName string) string {
-	return fmt.Sprintf("DROP INDEX %s ON %s", d.Quote(indexName), d.Quote(tableName))
+	return fmt.Sprintf("DROP INDEX %s ON %s", d.Quote(indexName), d.quoteTable(tableName))
+}
+
+// CreateSchemaSQL generates CREATE DATABASE IF NOT EXISTS, as MySQL
+// schemas are databases.
+func (d *Dialect) CreateSchemaSQL(name string) string {
+	return fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", d.Quote(name))
 }
 
 // AddColumnSQL generates ALTER TABLE ADD COLUMN statement.
 func (d *Dialect) AddColumnSQL(tableName string, field *schema.Field) string {
 	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s",
-		d.Quote(tableName),
+		d.quoteTable(tableName),
 		d.columnDefinition(field))
 }
 
+// AlterCollationSQL redefines a column with ALTER TABLE MODIFY COLUMN. A
+// field without a character set or collation gets the table's defaults.
+func (d *Dialect) AlterCollationSQL(tableName string, field *schema.Field) string {
+	// Keys are left as they are rather than declared again
+	f := *field
+	f.IsPrimaryKey, f.IsUnique = false, false
+	if field.IsPrimaryKey {
+		f.Nullable = false
+	}
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", d.quoteTable(tableName), d.columnDefinition(&f))
+}
+
+// TableCollationSQL generates ALTER TABLE DEFAULT CHARACTER SET ...
+// COLLATE. Existing columns keep theirs.
+func (d *Dialect) TableCollationSQL(tableName, charset, collation string) string {
+	sql := "ALTER TABLE " + d.quoteTable(tableName) + " DEFAULT"
+	if charset != "" {
+		sql += " CHARACTER SET " + charset
+	}
+	if collation != "" {
+		sql += " COLLATE " + collation
+	}
+	return sql
+}
+
+// InlineComments reports true: comments are part of column definitions
+// and table options.
+func (d *Dialect) InlineComments() bool {
+	return true
+}
+
+// TableCommentSQL generates ALTER TABLE ... COMMENT, an empty comment
+// removing it.
+func (d *Dialect) TableCommentSQL(tableName, comment string) string {
+	return fmt.Sprintf("ALTER TABLE %s COMMENT = %s", d.quoteTable(tableName), literal(comment))
+}
+
+// ColumnCommentSQL redefines a column with ALTER TABLE MODIFY COLUMN, as
+// MySQL has no statement changing the comment alone.
+func (d *Dialect) ColumnCommentSQL(tableName string, field *schema.Field) string {
+	return d.AlterCollationSQL(tableName, field)
+}
+
+// literal returns s as a string literal, escaping backslashes, which
+// MySQL reads as escapes.
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", "''") + "'"
+}
+
 // DropColumnSQL generates ALTER TABLE DROP COLUMN statement.
 func (d *Dialect) DropColumnSQL(tableName, columnName string) string {
 	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
-		d.Quote(tableName),
+		d.quoteTable(tableName),
 		d.Quote(columnName))
 }
 
 // RenameColumnSQL generates ALTER TABLE RENAME COLUMN statement.
 func (d *Dialect) RenameColumnSQL(tableName, oldName, newName string) string {
 	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
-		d.Quote(tableName),
+		d.quoteTable(tableName),
 		d.Quote(oldName),
 		d.Quote(newName))
 }
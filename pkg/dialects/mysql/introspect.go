@@ -4,18 +4,31 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
 )
 
-// IntrospectTables returns all user table names in the database.
+// inSchema matches the database of a table name split with
+// schema.SplitTable: the database it is qualified with, or the current one.
+const inSchema = "COALESCE(NULLIF(?, ''), DATABASE())"
+
+// systemSchemas are the databases of the server itself.
+const systemSchemas = "('mysql', 'information_schema', 'performance_schema', 'sys')"
+
+// IntrospectTables returns all user table names on the server. Tables of
+// the current database are unqualified, and those of other databases are
+// qualified with theirs, e.g. billing.invoices.
 func (d *Dialect) IntrospectTables(ctx context.Context, db *sql.DB) ([]string, error) {
-	query := `SELECT table_name 
+	query := `SELECT CASE WHEN table_schema = DATABASE() THEN table_name
+			ELSE CONCAT(table_schema, '.', table_name) END
 		FROM information_schema.tables 
-		WHERE table_schema = DATABASE()
+		WHERE table_schema NOT IN ` + systemSchemas + `
 		AND table_type = 'BASE TABLE'
-		ORDER BY table_name`
+		ORDER BY table_schema <> DATABASE(), table_schema, table_name`
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -37,18 +50,25 @@ func (d *Dialect) IntrospectTables(ctx context.Context, db *sql.DB) ([]string, e
 
 // IntrospectColumns returns column metadata for a table.
 func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName string) ([]*migration.ColumnInfo, error) {
+	// Character sets and collations are left out where they are the table's
 	query := `SELECT 
-		column_name,
-		data_type,
-		is_nullable,
-		column_default,
-		column_key,
-		extra
-	FROM information_schema.columns
-	WHERE table_name = ? AND table_schema = DATABASE()
-	ORDER BY ordinal_position`
+		c.column_name,
+		c.column_type,
+		c.is_nullable,
+		c.column_default,
+		c.column_key,
+		c.extra,
+		c.column_comment,
+		NULLIF(c.character_set_name, cs.character_set_name),
+		NULLIF(c.collation_name, t.table_collation)
+	FROM information_schema.columns c
+	JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+	LEFT JOIN information_schema.collations cs ON cs.collation_name = t.table_collation
+	WHERE c.table_name = ? AND c.table_schema = ` + inSchema + `
+	ORDER BY c.ordinal_position`
 
-	rows, err := db.QueryContext(ctx, query, tableName)
+	namespace, table := schema.SplitTable(tableName)
+	rows, err := db.QueryContext(ctx, query, table, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -62,8 +82,10 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 		var defaultVal sql.NullString
 		var columnKey string
 		var extra string
+		var comment string
+		var charset, collation sql.NullString
 
-		if err := rows.Scan(&name, &colType, &nullable, &defaultVal, &columnKey, &extra); err != nil {
+		if err := rows.Scan(&name, &colType, &nullable, &defaultVal, &columnKey, &extra, &comment, &charset, &collation); err != nil {
 			return nil, err
 		}
 
@@ -73,8 +95,17 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 			Nullable:     nullable == "YES",
 			IsPrimaryKey: columnKey == "PRI",
 			IsUnique:     columnKey == "UNI",
-			Default:      defaultVal.String,
+			Default:      columnDefault(defaultVal, extra),
 			AutoInc:      strings.Contains(extra, "auto_increment"),
+			Comment:      comment,
+			Unsigned:     strings.HasSuffix(strings.ToLower(colType), " unsigned"),
+			Charset:      charset.String,
+			Collation:    collation.String,
+		}
+
+		// MySQL enums are inline column types; name them after the column
+		if strings.HasPrefix(strings.ToLower(colType), "enum(") {
+			col.Enum = enumName(tableName, name)
 		}
 
 		columns = append(columns, col)
@@ -83,9 +114,60 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 	return columns, rows.Err()
 }
 
+// literalIntroducer matches the character set MySQL writes before the
+// string literals of expression defaults, as in _utf8mb4\'{}\'.
+var literalIntroducer = regexp.MustCompile(`_\w+\\'`)
+
+// columnDefault returns the default of a column as SQL. MySQL reports
+// literal defaults unquoted, and expressions with their quotes escaped.
+func columnDefault(def sql.NullString, extra string) string {
+	switch {
+	case !def.Valid:
+		return ""
+	case strings.Contains(extra, "DEFAULT_GENERATED"):
+		return strings.ReplaceAll(literalIntroducer.ReplaceAllString(def.String, `\'`), `\'`, "'")
+	case strings.HasPrefix(strings.ToUpper(def.String), "CURRENT_TIMESTAMP"):
+		return def.String
+	}
+	if _, err := strconv.ParseFloat(def.String, 64); err == nil {
+		return def.String
+	}
+	return "'" + strings.ReplaceAll(def.String, "'", "''") + "'"
+}
+
+// IntrospectTableCollation returns the default character set and collation
+// of a table.
+func (d *Dialect) IntrospectTableCollation(ctx context.Context, db *sql.DB, tableName string) (string, string, error) {
+	query := `SELECT cs.character_set_name, t.table_collation
+	FROM information_schema.tables t
+	JOIN information_schema.collations cs ON cs.collation_name = t.table_collation
+	WHERE t.table_name = ? AND t.table_schema = ` + inSchema
+
+	namespace, table := schema.SplitTable(tableName)
+	var charset, collation string
+	err := db.QueryRowContext(ctx, query, table, namespace).Scan(&charset, &collation)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return charset, collation, err
+}
+
+// IntrospectTableComment returns the comment of a table.
+func (d *Dialect) IntrospectTableComment(ctx context.Context, db *sql.DB, tableName string) (string, error) {
+	query := `SELECT table_comment FROM information_schema.tables WHERE table_name = ? AND table_schema = ` + inSchema
+
+	namespace, table := schema.SplitTable(tableName)
+	var comment string
+	err := db.QueryRowContext(ctx, query, table, namespace).Scan(&comment)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return comment, err
+}
+
 // IntrospectIndexes returns index metadata for a table.
 func (d *Dialect) IntrospectIndexes(ctx context.Context, db *sql.DB, tableName string) ([]*migration.IndexInfo, error) {
-	query := `SHOW INDEX FROM ` + d.Quote(tableName)
+	query := `SHOW INDEX FROM ` + d.quoteTable(tableName)
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -136,3 +218,209 @@ func (d *Dialect) IntrospectIndexes(ctx context.Context, db *sql.DB, tableName s
 
 	return indexes, rows.Err()
 }
+
+// IntrospectForeignKeys returns foreign key constraints for a table.
+// Referenced tables outside the current database are qualified with theirs.
+func (d *Dialect) IntrospectForeignKeys(ctx context.Context, db *sql.DB, tableName string) ([]*migration.ForeignKeyInfo, error) {
+	query := `SELECT
+		kcu.constraint_name,
+		kcu.column_name,
+		CASE WHEN kcu.referenced_table_schema = DATABASE() THEN kcu.referenced_table_name
+			ELSE CONCAT(kcu.referenced_table_schema, '.', kcu.referenced_table_name) END,
+		kcu.referenced_column_name,
+		rc.delete_rule,
+		rc.update_rule
+	FROM information_schema.key_column_usage kcu
+	JOIN information_schema.referential_constraints rc
+		ON rc.constraint_name = kcu.constraint_name
+		AND rc.constraint_schema = kcu.table_schema
+	WHERE kcu.table_schema = ` + inSchema + `
+	AND kcu.table_name = ?
+	AND kcu.referenced_table_name IS NOT NULL
+	ORDER BY kcu.constraint_name, kcu.ordinal_position`
+
+	namespace, table := schema.SplitTable(tableName)
+	rows, err := db.QueryContext(ctx, query, namespace, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []*migration.ForeignKeyInfo
+	byName := make(map[string]*migration.ForeignKeyInfo)
+	for rows.Next() {
+		var name, column, refTable, refColumn, onDelete, onUpdate string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+
+		fk, ok := byName[name]
+		if !ok {
+			fk = &migration.ForeignKeyInfo{
+				Name:     name,
+				RefTable: refTable,
+				OnDelete: onDelete,
+				OnUpdate: onUpdate,
+			}
+			byName[name] = fk
+			fks = append(fks, fk)
+		}
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+	}
+
+	return fks, rows.Err()
+}
+
+// IntrospectCheckConstraints returns CHECK constraints for a table.
+// CHECK constraints are enforced from MySQL 8.0.16; older servers have no
+// check_constraints table, in which case no constraints are returned.
+func (d *Dialect) IntrospectCheckConstraints(ctx context.Context, db *sql.DB, tableName string) ([]*migration.CheckInfo, error) {
+	query := `SELECT cc.constraint_name, cc.check_clause
+	FROM information_schema.check_constraints cc
+	JOIN information_schema.table_constraints tc
+		ON tc.constraint_name = cc.constraint_name
+		AND tc.constraint_schema = cc.constraint_schema
+	WHERE tc.table_schema = ` + inSchema + `
+	AND tc.table_name = ?
+	AND tc.constraint_type = 'CHECK'
+	ORDER BY cc.constraint_name`
+
+	namespace, table := schema.SplitTable(tableName)
+	rows, err := db.QueryContext(ctx, query, namespace, table)
+	if err != nil {
+		return nil, nil
+	}
+	defer rows.Close()
+
+	var checks []*migration.CheckInfo
+	for rows.Next() {
+		var name, clause string
+		if err := rows.Scan(&name, &clause); err != nil {
+			return nil, err
+		}
+		checks = append(checks, &migration.CheckInfo{Name: name, Expression: clause})
+	}
+
+	return checks, rows.Err()
+}
+
+// IntrospectEnums returns the inline ENUM column types of the current database.
+// Each enum is named <table>_<column>, matching ColumnInfo.Enum.
+func (d *Dialect) IntrospectEnums(ctx context.Context, db *sql.DB) ([]*migration.EnumInfo, error) {
+	query := `SELECT table_name, column_name, column_type
+	FROM information_schema.columns
+	WHERE table_schema = DATABASE()
+	AND data_type = 'enum'
+	ORDER BY table_name, ordinal_position`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var enums []*migration.EnumInfo
+	for rows.Next() {
+		var table, column, colType string
+		if err := rows.Scan(&table, &column, &colType); err != nil {
+			return nil, err
+		}
+		enums = append(enums, &migration.EnumInfo{
+			Name:   enumName(table, column),
+			Values: parseEnumValues(colType),
+		})
+	}
+
+	return enums, rows.Err()
+}
+
+// enumName builds the synthetic enum name for a MySQL ENUM column.
+func enumName(table, column string) string {
+	return table + "_" + column
+}
+
+// parseEnumValues extracts the labels from a column type like enum('a','b').
+func parseEnumValues(colType string) []string {
+	start := strings.Index(colType, "(")
+	end := strings.LastIndex(colType, ")")
+	if start < 0 || end <= start {
+		return nil
+	}
+
+	var values []string
+	var current strings.Builder
+	inQuote := false
+	body := colType[start+1 : end]
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '\'' && inQuote && i+1 < len(body) && body[i+1] == '\'':
+			current.WriteByte('\'')
+			i++
+		case c == '\'':
+			inQuote = !inQuote
+			if !inQuote {
+				values = append(values, current.String())
+				current.Reset()
+			}
+		case inQuote:
+			current.WriteByte(c)
+		}
+	}
+	return values
+}
+
+// IntrospectPartitions returns the partitions of a table.
+func (d *Dialect) IntrospectPartitions(ctx context.Context, db *sql.DB, tableName string) ([]string, error) {
+	query := `SELECT partition_name
+		FROM information_schema.partitions
+		WHERE table_schema = ` + inSchema + `
+		AND table_name = ?
+		AND partition_name IS NOT NULL
+		ORDER BY partition_ordinal_position`
+
+	namespace, table := schema.SplitTable(tableName)
+	rows, err := db.QueryContext(ctx, query, namespace, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// IntrospectStats returns the size of the user tables, named as
+// IntrospectTables names them, and their total. Free space is the
+// data_free InnoDB reports, which OPTIMIZE TABLE reclaims.
+func (d *Dialect) IntrospectStats(ctx context.Context, db *sql.DB) (*migration.DatabaseStats, error) {
+	query := `SELECT CASE WHEN table_schema = DATABASE() THEN table_name
+			ELSE CONCAT(table_schema, '.', table_name) END,
+		COALESCE(table_rows, 0),
+		COALESCE(data_length, 0) + COALESCE(index_length, 0), data_free
+	FROM information_schema.tables
+	WHERE table_schema NOT IN ` + systemSchemas + `
+	AND table_type = 'BASE TABLE'`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	stats := &migration.DatabaseStats{}
+	if stats.Tables, err = migration.ScanTableStats(rows); err != nil {
+		return nil, err
+	}
+	for _, t := range stats.Tables {
+		stats.Bytes += t.Bytes
+		stats.Reclaimable += t.Reclaimable
+	}
+	return stats, nil
+}
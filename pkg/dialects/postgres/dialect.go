@@ -26,9 +26,25 @@ func (d *Dialect) DriverName() string {
 	return "postgres"
 }
 
-// Quote quotes an identifier.
+// Quote quotes an identifier, doubling any quote characters in it.
 func (d *Dialect) Quote(identifier string) string {
-	return `"` + identifier + `"`
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// QuoteQualified quotes a table name qualified with the schema it lives
+// in, or the name alone if namespace is empty.
+func (d *Dialect) QuoteQualified(namespace, name string) string {
+	if namespace == "" {
+		return d.Quote(name)
+	}
+	return d.Quote(namespace) + "." + d.Quote(name)
+}
+
+// quoteTable quotes a table name as the statements generated from schemas
+// are given it, by Model.Table: qualified with the schema of models that
+// declare one with @@schema.
+func (d *Dialect) quoteTable(table string) string {
+	return d.QuoteQualified(schema.SplitTable(table))
 }
 
 // Placeholder returns the parameter placeholder.
@@ -49,6 +65,12 @@ func (d *Dialect) TypeMapping(field *schema.Field) string {
 			return "BIGSERIAL"
 		}
 		return "BIGINT"
+	case schema.FieldTypeSmallInt, schema.FieldTypeTinyInt:
+		// There is no TINYINT
+		if field.AutoIncrement {
+			return "SMALLSERIAL"
+		}
+		return "SMALLINT"
 	case schema.FieldTypeString:
 		if field.Length > 0 {
 			return fmt.Sprintf("VARCHAR(%d)", field.Length)
@@ -60,7 +82,8 @@ func (d *Dialect) TypeMapping(field *schema.Field) string {
 		return "BOOLEAN"
 	case schema.FieldTypeFloat:
 		return "DOUBLE PRECISION"
-	case schema.FieldTypeDecimal:
+	case schema.FieldTypeDecimal, schema.FieldTypeMoney:
+		// The money type formats amounts by locale; NUMERIC keeps them exact
 		return fmt.Sprintf("NUMERIC(%d,%d)", field.Precision, field.Scale)
 	case schema.FieldTypeDateTime:
 		return "TIMESTAMP WITH TIME ZONE"
@@ -74,6 +97,11 @@ func (d *Dialect) TypeMapping(field *schema.Field) string {
 		return "BYTEA"
 	case schema.FieldTypeUUID:
 		return "UUID"
+	case schema.FieldTypeEnum:
+		if field.Enum != nil {
+			return d.Quote(field.Enum.Name)
+		}
+		return "TEXT"
 	default:
 		return "TEXT"
 	}
@@ -85,9 +113,19 @@ func (d *Dialect) CreateTableSQL(model *schema.Model) string {
 	var constraints []string
 
 	for _, field := range model.GetFields() {
+		if model.Partition != nil && field.IsPrimaryKey {
+			// The primary key of a partitioned table includes the partition columns
+			f := *field
+			f.IsPrimaryKey = false
+			columns = append(columns, d.columnDefinition(&f))
+			continue
+		}
 		col := d.columnDefinition(field)
 		columns = append(columns, col)
 	}
+	if model.Partition != nil {
+		constraints = append(constraints, fmt.Sprintf("PRIMARY KEY (%s)", d.quoteList(model.Partition.PrimaryKey(model))))
+	}
 
 	// Handle composite unique constraints
 	for _, idx := range model.Indexes {
@@ -101,14 +139,64 @@ func (d *Dialect) CreateTableSQL(model *schema.Model) string {
 	}
 
 	allParts := append(columns, constraints...)
-	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n)",
-		d.Quote(model.Name),
+	sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n)",
+		d.quoteTable(model.Table()),
 		strings.Join(allParts, ",\n  "))
+	if model.Partition != nil {
+		sql += fmt.Sprintf(" PARTITION BY %s (%s)", model.Partition.Strategy, d.quoteList(model.Partition.Columns))
+	}
+	return sql
+}
+
+func (d *Dialect) quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = d.Quote(n)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// InitialPartitionsSQL generates the partitions of a hash partitioned
+// table, or the DEFAULT partition of a range partitioned one, which keeps
+// rows insertable until their partition exists.
+func (d *Dialect) InitialPartitionsSQL(model *schema.Model) []string {
+	part := model.Partition
+	if part == nil {
+		return nil
+	}
+	if part.Strategy == schema.Range {
+		return []string{fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s DEFAULT",
+			d.quoteTable(model.Table()+"_default"), d.quoteTable(model.Table()))}
+	}
+
+	var statements []string
+	for _, p := range part.HashPartitions() {
+		statements = append(statements, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES WITH (MODULUS %d, REMAINDER %d)",
+			d.quoteTable(d.PartitionName(model, p)), d.quoteTable(model.Table()), part.Count, p.Remainder))
+	}
+	return statements
+}
+
+// AddPartitionSQL generates CREATE TABLE ... PARTITION OF for a range.
+func (d *Dialect) AddPartitionSQL(model *schema.Model, p schema.Partition) string {
+	const layout = "2006-01-02 15:04:05+00"
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+		d.quoteTable(d.PartitionName(model, p)), d.quoteTable(model.Table()), p.From.Format(layout), p.To.Format(layout))
+}
+
+// PartitionName returns the table of a partition, e.g. events_2026_10.
+func (d *Dialect) PartitionName(model *schema.Model, p schema.Partition) string {
+	return model.Table() + "_" + p.Suffix
 }
 
 func (d *Dialect) columnDefinition(field *schema.Field) string {
 	parts := []string{d.Quote(field.Name), d.TypeMapping(field)}
 
+	// Enum types have no collation
+	if _, collation := field.ColumnCollation(); collation != "" && field.Type != schema.FieldTypeEnum {
+		parts = append(parts, "COLLATE "+d.Quote(collation))
+	}
+
 	if field.IsPrimaryKey {
 		parts = append(parts, "PRIMARY KEY")
 	}
@@ -121,36 +209,166 @@ func (d *Dialect) columnDefinition(field *schema.Field) string {
 		parts = append(parts, "UNIQUE")
 	}
 
-	if field.DefaultExpr != "" {
-		expr := field.DefaultExpr
-		switch strings.ToUpper(expr) {
-		case "NOW()":
-			expr = "NOW()"
-		case "UUID()":
-			expr = "gen_random_uuid()"
+	// There are no unsigned integers
+	if field.IsUnsigned {
+		parts = append(parts, fmt.Sprintf("CHECK (%s >= 0)", d.Quote(field.Name)))
+	}
+
+	if def := d.DefaultSQL(field); def != "" {
+		parts = append(parts, "DEFAULT "+def)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// DefaultSQL renders the default of a field, translating the portable
+// expressions.
+func (d *Dialect) DefaultSQL(field *schema.Field) string {
+	switch strings.ToUpper(field.DefaultExpr) {
+	case "":
+	case schema.DefaultExprNow:
+		return "NOW()"
+	case schema.DefaultExprUUID:
+		return "gen_random_uuid()"
+	case schema.DefaultExprRandom:
+		return "random()"
+	default:
+		return field.DefaultExpr
+	}
+
+	switch v := field.DefaultValue.(type) {
+	case nil:
+		return ""
+	case string:
+		return literal(v)
+	case bool:
+		if v {
+			return "TRUE"
 		}
-		parts = append(parts, "DEFAULT "+expr)
-	} else if field.DefaultValue != nil {
-		switch v := field.DefaultValue.(type) {
-		case string:
-			parts = append(parts, fmt.Sprintf("DEFAULT '%s'", v))
-		case bool:
-			if v {
-				parts = append(parts, "DEFAULT TRUE")
-			} else {
-				parts = append(parts, "DEFAULT FALSE")
+		return "FALSE"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// AlterDefaultSQL generates ALTER TABLE ... ALTER COLUMN ... SET DEFAULT,
+// or DROP DEFAULT if the field has none.
+func (d *Dialect) AlterDefaultSQL(tableName string, field *schema.Field) string {
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", d.quoteTable(tableName), d.Quote(field.Name))
+	if def := d.DefaultSQL(field); def != "" {
+		return alter + " SET DEFAULT " + def
+	}
+	return alter + " DROP DEFAULT"
+}
+
+// CreateEnumTypeSQL generates CREATE TYPE ... AS ENUM, skipping it if the
+// type already exists since PostgreSQL has no CREATE TYPE IF NOT EXISTS.
+func (d *Dialect) CreateEnumTypeSQL(e *schema.Enum) string {
+	values := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		values[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return fmt.Sprintf("DO $$ BEGIN\n  CREATE TYPE %s AS ENUM (%s);\nEXCEPTION WHEN duplicate_object THEN NULL;\nEND $$",
+		d.Quote(e.Name), strings.Join(values, ", "))
+}
+
+// RowSecuritySQL generates ALTER TABLE ... ENABLE/DISABLE ROW LEVEL
+// SECURITY, with FORCE or NO FORCE for the table owner.
+func (d *Dialect) RowSecuritySQL(tableName string, enabled, forced bool) string {
+	enable, force := "DISABLE", "NO FORCE"
+	if enabled {
+		enable = "ENABLE"
+	}
+	if forced {
+		force = "FORCE"
+	}
+	return fmt.Sprintf("ALTER TABLE %s %s ROW LEVEL SECURITY, %s ROW LEVEL SECURITY", d.quoteTable(tableName), enable, force)
+}
+
+// CreatePolicySQL generates CREATE POLICY. INSERT policies only check new
+// rows, so their condition goes in WITH CHECK.
+func (d *Dialect) CreatePolicySQL(tableName string, policy *schema.Policy) string {
+	command := policy.Command
+	if command == "" {
+		command = schema.PolicyAll
+	}
+	sql := fmt.Sprintf("CREATE POLICY %s ON %s FOR %s", d.Quote(policy.Name), d.quoteTable(tableName), command)
+
+	if len(policy.Roles) > 0 {
+		roles := make([]string, len(policy.Roles))
+		for i, r := range policy.Roles {
+			roles[i] = d.Quote(r)
+			if strings.EqualFold(r, "public") || strings.EqualFold(r, "current_user") || strings.EqualFold(r, "session_user") {
+				roles[i] = strings.ToUpper(r)
 			}
-		default:
-			parts = append(parts, fmt.Sprintf("DEFAULT %v", v))
 		}
+		sql += " TO " + strings.Join(roles, ", ")
 	}
 
-	return strings.Join(parts, " ")
+	using, check := policy.Using, policy.Check
+	if command == schema.PolicyInsert {
+		if check == "" {
+			check = using
+		}
+		using = ""
+	}
+	if using != "" {
+		sql += " USING (" + using + ")"
+	}
+	if check != "" {
+		sql += " WITH CHECK (" + check + ")"
+	}
+	return sql
+}
+
+// DropPolicySQL generates DROP POLICY statement.
+func (d *Dialect) DropPolicySQL(tableName, policyName string) string {
+	return fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s", d.Quote(policyName), d.quoteTable(tableName))
+}
+
+// CreateFunctionSQL generates CREATE OR REPLACE FUNCTION or PROCEDURE,
+// with the body dollar-quoted.
+func (d *Dialect) CreateFunctionSQL(f *schema.Function) string {
+	kind, returns := "FUNCTION", ""
+	if f.Procedure {
+		kind = "PROCEDURE"
+	} else {
+		returns = " RETURNS " + f.Returns
+	}
+	language := f.Language
+	if language == "" {
+		language = "sql"
+	}
+	volatility := ""
+	if f.Immutable {
+		volatility = " IMMUTABLE"
+	}
+
+	tag := "$nexus$"
+	for strings.Contains(f.Body, tag) {
+		tag = "$" + strings.Trim(tag, "$") + "_$"
+	}
+	return fmt.Sprintf("CREATE OR REPLACE %s %s(%s)%s LANGUAGE %s%s AS %s\n%s\n%s",
+		kind, d.Quote(f.Name), f.Signature(), returns, language, volatility, tag, strings.TrimSpace(f.Body), tag)
+}
+
+// DropFunctionSQL generates DROP FUNCTION or PROCEDURE with the argument
+// types, which identify overloads.
+func (d *Dialect) DropFunctionSQL(f *schema.Function) string {
+	kind := "FUNCTION"
+	if f.Procedure {
+		kind = "PROCEDURE"
+	}
+	types := make([]string, len(f.Args))
+	for i, a := range f.Args {
+		types[i] = a.Type
+	}
+	return fmt.Sprintf("DROP %s IF EXISTS %s(%s)", kind, d.Quote(f.Name), strings.Join(types, ", "))
 }
 
 // DropTableSQL generates DROP TABLE statement.
 func (d *Dialect) DropTableSQL(tableName string) string {
-	return fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", d.Quote(tableName))
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", d.quoteTable(tableName))
 }
 
 // CreateIndexSQL generates CREATE INDEX statement.
@@ -168,33 +386,81 @@ func (d *Dialect) CreateIndexSQL(tableName string, index *schema.Index) string {
 	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)",
 		unique,
 		d.Quote(index.Name),
-		d.Quote(tableName),
+		d.quoteTable(tableName),
 		strings.Join(quotedFields, ", "))
 }
 
 // DropIndexSQL generates DROP INDEX statement.
+// The index is qualified with the schema of its table.
 func (d *Dialect) DropIndexSQL(tableName, indexName string) string {
-	return fmt.Sprintf("DROP INDEX IF EXISTS %s", d.Quote(indexName))
+	namespace, _ := schema.SplitTable(tableName)
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s", d.QuoteQualified(namespace, indexName))
+}
+
+// CreateSchemaSQL generates CREATE SCHEMA IF NOT EXISTS.
+func (d *Dialect) CreateSchemaSQL(name string) string {
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", d.Quote(name))
 }
 
 // AddColumnSQL generates ALTER TABLE ADD COLUMN statement.
 func (d *Dialect) AddColumnSQL(tableName string, field *schema.Field) string {
 	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s",
-		d.Quote(tableName),
+		d.quoteTable(tableName),
 		d.columnDefinition(field))
 }
 
+// AlterCollationSQL generates ALTER TABLE ALTER COLUMN TYPE ... COLLATE,
+// which rewrites the column's indexes. A field without a collation gets
+// the database default.
+func (d *Dialect) AlterCollationSQL(tableName string, field *schema.Field) string {
+	collation := field.Collation
+	if collation == "" {
+		collation = "default"
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s COLLATE %s",
+		d.quoteTable(tableName), d.Quote(field.Name), d.TypeMapping(field), d.Quote(collation))
+}
+
+// InlineComments reports false: comments take COMMENT ON statements.
+func (d *Dialect) InlineComments() bool {
+	return false
+}
+
+// TableCommentSQL generates COMMENT ON TABLE, with NULL for no comment.
+func (d *Dialect) TableCommentSQL(tableName, comment string) string {
+	return fmt.Sprintf("COMMENT ON TABLE %s IS %s", d.quoteTable(tableName), commentLiteral(comment))
+}
+
+// ColumnCommentSQL generates COMMENT ON COLUMN, with NULL for no comment.
+func (d *Dialect) ColumnCommentSQL(tableName string, field *schema.Field) string {
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", d.quoteTable(tableName), d.Quote(field.Name), commentLiteral(field.Description))
+}
+
+// commentLiteral returns a comment as a string literal, or NULL if it is
+// empty, which removes the comment.
+func commentLiteral(comment string) string {
+	if comment == "" {
+		return "NULL"
+	}
+	return literal(comment)
+}
+
+// literal returns s as a string literal.
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 // DropColumnSQL generates ALTER TABLE DROP COLUMN statement.
 func (d *Dialect) DropColumnSQL(tableName, columnName string) string {
 	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
-		d.Quote(tableName),
+		d.quoteTable(tableName),
 		d.Quote(columnName))
 }
 
 // RenameColumnSQL generates ALTER TABLE RENAME COLUMN statement.
 func (d *Dialect) RenameColumnSQL(tableName, oldName, newName string) string {
 	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
-		d.Quote(tableName),
+		d.quoteTable(tableName),
 		d.Quote(oldName),
 		d.Quote(newName))
 }
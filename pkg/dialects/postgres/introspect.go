@@ -4,17 +4,29 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
 	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
 )
 
-// IntrospectTables returns all user table names in the database.
+// inSchema matches the schema of a table name split with schema.SplitTable
+// and passed as $2: the schema it is qualified with, or the current schema,
+// the first of the search_path that exists.
+const inSchema = "COALESCE(NULLIF($2, ''), current_schema())"
+
+// IntrospectTables returns all user table names in the database. Tables of
+// the current schema are unqualified, and those of other schemas are
+// qualified with theirs, e.g. billing.invoices.
 func (d *Dialect) IntrospectTables(ctx context.Context, db *sql.DB) ([]string, error) {
-	query := `SELECT table_name 
+	query := `SELECT CASE WHEN table_schema = current_schema() THEN table_name
+			ELSE table_schema || '.' || table_name END
 		FROM information_schema.tables 
-		WHERE table_schema = 'public' 
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+		AND table_schema NOT LIKE 'pg\_%'
 		AND table_type = 'BASE TABLE'
-		ORDER BY table_name`
+		ORDER BY table_schema <> current_schema(), table_schema, table_name`
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -39,23 +51,31 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 	query := `SELECT 
 		c.column_name,
 		c.data_type,
+		c.udt_name,
+		c.character_maximum_length,
+		c.numeric_precision,
+		c.numeric_scale,
 		c.is_nullable,
 		c.column_default,
 		CASE WHEN pk.column_name IS NOT NULL THEN true ELSE false END as is_primary_key,
-		CASE WHEN c.column_default LIKE 'nextval%' THEN true ELSE false END as is_auto_inc
+		CASE WHEN c.column_default LIKE 'nextval%' THEN true ELSE false END as is_auto_inc,
+		col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position) as comment,
+		c.collation_name
 	FROM information_schema.columns c
 	LEFT JOIN (
 		SELECT ku.column_name
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage ku 
-			ON tc.constraint_name = ku.constraint_name
+			ON tc.constraint_name = ku.constraint_name AND tc.constraint_schema = ku.constraint_schema
 		WHERE tc.table_name = $1 
+		AND tc.table_schema = ` + inSchema + `
 		AND tc.constraint_type = 'PRIMARY KEY'
 	) pk ON c.column_name = pk.column_name
-	WHERE c.table_name = $1 AND c.table_schema = 'public'
+	WHERE c.table_name = $1 AND c.table_schema = ` + inSchema + `
 	ORDER BY c.ordinal_position`
 
-	rows, err := db.QueryContext(ctx, query, tableName)
+	namespace, table := schema.SplitTable(tableName)
+	rows, err := db.QueryContext(ctx, query, table, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -65,12 +85,17 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 	for rows.Next() {
 		var name string
 		var colType string
+		var udtName string
+		var maxLength, precision, scale sql.NullInt64
 		var nullable string
 		var defaultVal sql.NullString
 		var isPK bool
 		var isAutoInc bool
+		var comment sql.NullString
+		var collation sql.NullString // Null for the database default
 
-		if err := rows.Scan(&name, &colType, &nullable, &defaultVal, &isPK, &isAutoInc); err != nil {
+		if err := rows.Scan(&name, &colType, &udtName, &maxLength, &precision, &scale,
+			&nullable, &defaultVal, &isPK, &isAutoInc, &comment, &collation); err != nil {
 			return nil, err
 		}
 
@@ -81,6 +106,19 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 			IsPrimaryKey: isPK,
 			Default:      defaultVal.String,
 			AutoInc:      isAutoInc,
+			Comment:      comment.String,
+			Collation:    collation.String,
+		}
+
+		// Include size information so the type round-trips
+		switch {
+		case colType == "USER-DEFINED":
+			col.Type = udtName
+			col.Enum = udtName
+		case maxLength.Valid:
+			col.Type = fmt.Sprintf("%s(%d)", colType, maxLength.Int64)
+		case colType == "numeric" && precision.Valid:
+			col.Type = fmt.Sprintf("numeric(%d,%d)", precision.Int64, scale.Int64)
 		}
 
 		columns = append(columns, col)
@@ -93,9 +131,9 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 			ON ccu.constraint_name = tc.constraint_name
 		WHERE tc.table_name = $1 
 		AND tc.constraint_type = 'UNIQUE'
-		AND tc.table_schema = 'public'`
+		AND tc.table_schema = ` + inSchema
 
-	uniqueRows, err := db.QueryContext(ctx, uniqueQuery, tableName)
+	uniqueRows, err := db.QueryContext(ctx, uniqueQuery, table, namespace)
 	if err != nil {
 		return columns, nil
 	}
@@ -126,16 +164,19 @@ func (d *Dialect) IntrospectIndexes(ctx context.Context, db *sql.DB, tableName s
 		ix.indisunique as is_unique,
 		array_agg(a.attname ORDER BY array_position(ix.indkey, a.attnum)) as column_names
 	FROM pg_class t
+	JOIN pg_namespace ns ON ns.oid = t.relnamespace
 	JOIN pg_index ix ON t.oid = ix.indrelid
 	JOIN pg_class i ON i.oid = ix.indexrelid
 	JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
 	WHERE t.relname = $1
+	AND ns.nspname = ` + inSchema + `
 	AND t.relkind = 'r'
 	AND NOT ix.indisprimary
 	GROUP BY i.relname, ix.indisunique
 	ORDER BY i.relname`
 
-	rows, err := db.QueryContext(ctx, query, tableName)
+	namespace, table := schema.SplitTable(tableName)
+	rows, err := db.QueryContext(ctx, query, table, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -199,3 +240,294 @@ func splitArray(s string) []string {
 	}
 	return result
 }
+
+// IntrospectForeignKeys returns foreign key constraints for a table.
+// Referenced tables outside the current schema are qualified with theirs.
+func (d *Dialect) IntrospectForeignKeys(ctx context.Context, db *sql.DB, tableName string) ([]*migration.ForeignKeyInfo, error) {
+	query := `SELECT
+		con.conname,
+		att.attname,
+		CASE WHEN refns.nspname = current_schema() THEN ref.relname
+			ELSE refns.nspname || '.' || ref.relname END,
+		ratt.attname,
+		con.confdeltype,
+		con.confupdtype
+	FROM pg_constraint con
+	JOIN pg_class cls ON cls.oid = con.conrelid
+	JOIN pg_namespace ns ON ns.oid = cls.relnamespace
+	JOIN pg_class ref ON ref.oid = con.confrelid
+	JOIN pg_namespace refns ON refns.oid = ref.relnamespace
+	CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord)
+	JOIN pg_attribute att ON att.attrelid = con.conrelid AND att.attnum = k.attnum
+	JOIN pg_attribute ratt ON ratt.attrelid = con.confrelid AND ratt.attnum = k.refattnum
+	WHERE con.contype = 'f'
+	AND ns.nspname = ` + inSchema + `
+	AND cls.relname = $1
+	ORDER BY con.conname, k.ord`
+
+	namespace, table := schema.SplitTable(tableName)
+	rows, err := db.QueryContext(ctx, query, table, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []*migration.ForeignKeyInfo
+	byName := make(map[string]*migration.ForeignKeyInfo)
+	for rows.Next() {
+		var name, column, refTable, refColumn, onDelete, onUpdate string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+
+		fk, ok := byName[name]
+		if !ok {
+			fk = &migration.ForeignKeyInfo{
+				Name:     name,
+				RefTable: refTable,
+				OnDelete: referentialAction(onDelete),
+				OnUpdate: referentialAction(onUpdate),
+			}
+			byName[name] = fk
+			fks = append(fks, fk)
+		}
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+	}
+
+	return fks, rows.Err()
+}
+
+// referentialAction decodes pg_constraint.confdeltype/confupdtype.
+func referentialAction(code string) string {
+	switch code {
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	case "r":
+		return "RESTRICT"
+	default:
+		return "NO ACTION"
+	}
+}
+
+// IntrospectCheckConstraints returns CHECK constraints for a table.
+func (d *Dialect) IntrospectCheckConstraints(ctx context.Context, db *sql.DB, tableName string) ([]*migration.CheckInfo, error) {
+	query := `SELECT con.conname, pg_get_constraintdef(con.oid)
+	FROM pg_constraint con
+	JOIN pg_class cls ON cls.oid = con.conrelid
+	JOIN pg_namespace ns ON ns.oid = cls.relnamespace
+	WHERE con.contype = 'c'
+	AND ns.nspname = ` + inSchema + `
+	AND cls.relname = $1
+	ORDER BY con.conname`
+
+	namespace, table := schema.SplitTable(tableName)
+	rows, err := db.QueryContext(ctx, query, table, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []*migration.CheckInfo
+	for rows.Next() {
+		var name, def string
+		if err := rows.Scan(&name, &def); err != nil {
+			return nil, err
+		}
+
+		// pg_get_constraintdef returns "CHECK ((expr))"
+		expr := strings.TrimSpace(strings.TrimPrefix(def, "CHECK"))
+		if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+			expr = expr[1 : len(expr)-1]
+		}
+
+		checks = append(checks, &migration.CheckInfo{Name: name, Expression: expr})
+	}
+
+	return checks, rows.Err()
+}
+
+// IntrospectRowSecurity returns whether row-level security is on for a
+// table, and its policies.
+func (d *Dialect) IntrospectRowSecurity(ctx context.Context, db *sql.DB, tableName string) (*migration.RowSecurityInfo, error) {
+	info := &migration.RowSecurityInfo{Policies: make(map[string]*migration.PolicyInfo)}
+	namespace, table := schema.SplitTable(tableName)
+	err := db.QueryRowContext(ctx, `SELECT cls.relrowsecurity, cls.relforcerowsecurity
+	FROM pg_class cls
+	JOIN pg_namespace ns ON ns.oid = cls.relnamespace
+	WHERE ns.nspname = `+inSchema+` AND cls.relname = $1`, table, namespace).Scan(&info.Enabled, &info.Forced)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT policyname, cmd, array_to_string(roles, ','), COALESCE(qual, ''), COALESCE(with_check, '')
+	FROM pg_policies
+	WHERE schemaname = `+inSchema+` AND tablename = $1
+	ORDER BY policyname`, table, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p := &migration.PolicyInfo{}
+		var roles string
+		if err := rows.Scan(&p.Name, &p.Command, &roles, &p.Using, &p.Check); err != nil {
+			return nil, err
+		}
+		if roles != "public" {
+			p.Roles = strings.Split(roles, ",")
+		}
+		info.Policies[p.Name] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !info.Enabled && len(info.Policies) == 0 {
+		return nil, nil
+	}
+	return info, nil
+}
+
+// IntrospectTableComment returns the comment of a table.
+func (d *Dialect) IntrospectTableComment(ctx context.Context, db *sql.DB, tableName string) (string, error) {
+	namespace, table := schema.SplitTable(tableName)
+	var comment sql.NullString
+	err := db.QueryRowContext(ctx, `SELECT obj_description(cls.oid, 'pg_class')
+	FROM pg_class cls
+	JOIN pg_namespace ns ON ns.oid = cls.relnamespace
+	WHERE ns.nspname = `+inSchema+` AND cls.relname = $1`, table, namespace).Scan(&comment)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return comment.String, err
+}
+
+// IntrospectFunctions returns the functions and procedures of the current
+// schema, except those of extensions and trigger functions.
+func (d *Dialect) IntrospectFunctions(ctx context.Context, db *sql.DB) ([]*migration.FunctionInfo, error) {
+	query := `SELECT p.proname, pg_get_function_arguments(p.oid), COALESCE(pg_get_function_result(p.oid), ''),
+		p.prosrc, l.lanname, p.prokind = 'p', p.provolatile = 'i'
+	FROM pg_proc p
+	JOIN pg_namespace n ON n.oid = p.pronamespace
+	JOIN pg_language l ON l.oid = p.prolang
+	WHERE n.nspname = current_schema()
+		AND p.prokind IN ('f', 'p')
+		AND p.prorettype <> 'trigger'::regtype
+		AND NOT EXISTS (SELECT 1 FROM pg_depend dep WHERE dep.objid = p.oid AND dep.deptype = 'e')
+	ORDER BY p.proname`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var functions []*migration.FunctionInfo
+	for rows.Next() {
+		f := &migration.FunctionInfo{}
+		if err := rows.Scan(&f.Name, &f.Args, &f.Returns, &f.Body, &f.Language, &f.Procedure, &f.Immutable); err != nil {
+			return nil, err
+		}
+		f.Body = strings.TrimSpace(f.Body)
+		functions = append(functions, f)
+	}
+	return functions, rows.Err()
+}
+
+// IntrospectPartitions returns the partitions of a table, qualified with its
+// schema if the table name is.
+func (d *Dialect) IntrospectPartitions(ctx context.Context, db *sql.DB, tableName string) ([]string, error) {
+	query := `SELECT child.relname
+	FROM pg_inherits inh
+	JOIN pg_class parent ON parent.oid = inh.inhparent
+	JOIN pg_class child ON child.oid = inh.inhrelid
+	JOIN pg_namespace ns ON ns.oid = parent.relnamespace
+	WHERE ns.nspname = ` + inSchema + ` AND parent.relname = $1
+	ORDER BY child.relname`
+
+	namespace, table := schema.SplitTable(tableName)
+	rows, err := db.QueryContext(ctx, query, table, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, schema.QualifiedTable(namespace, name))
+	}
+	return names, rows.Err()
+}
+
+// IntrospectEnums returns enum types defined in the current schema.
+func (d *Dialect) IntrospectEnums(ctx context.Context, db *sql.DB) ([]*migration.EnumInfo, error) {
+	query := `SELECT t.typname, e.enumlabel
+	FROM pg_type t
+	JOIN pg_enum e ON e.enumtypid = t.oid
+	JOIN pg_namespace n ON n.oid = t.typnamespace
+	WHERE n.nspname = current_schema()
+	ORDER BY t.typname, e.enumsortorder`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var enums []*migration.EnumInfo
+	byName := make(map[string]*migration.EnumInfo)
+	for rows.Next() {
+		var name, label string
+		if err := rows.Scan(&name, &label); err != nil {
+			return nil, err
+		}
+
+		enum, ok := byName[name]
+		if !ok {
+			enum = &migration.EnumInfo{Name: name}
+			byName[name] = enum
+			enums = append(enums, enum)
+		}
+		enum.Values = append(enum.Values, label)
+	}
+
+	return enums, rows.Err()
+}
+
+// IntrospectStats returns the size of the database and of its user tables,
+// named as IntrospectTables names them. Space held by dead rows is
+// estimated from the dead row count of the statistics collector.
+func (d *Dialect) IntrospectStats(ctx context.Context, db *sql.DB) (*migration.DatabaseStats, error) {
+	stats := &migration.DatabaseStats{}
+	if err := db.QueryRowContext(ctx, `SELECT pg_database_size(current_database())`).Scan(&stats.Bytes); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT CASE WHEN schemaname = current_schema() THEN relname ELSE schemaname || '.' || relname END,
+		n_live_tup, pg_total_relation_size(relid),
+		CASE WHEN n_live_tup + n_dead_tup > 0
+			THEN (pg_relation_size(relid) * n_dead_tup / (n_live_tup + n_dead_tup))::bigint END
+	FROM pg_stat_user_tables`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Tables, err = migration.ScanTableStats(rows); err != nil {
+		return nil, err
+	}
+	for _, t := range stats.Tables {
+		stats.Reclaimable += t.Reclaimable
+	}
+	return stats, nil
+}
@@ -0,0 +1,198 @@
+package dialects
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrUnsafeStatement is returned for UPDATE and DELETE statements refused
+// by the Safety of a connection.
+var ErrUnsafeStatement = errors.New("unsafe statement")
+
+// Safety guards against UPDATE and DELETE statements that change more of a
+// table than meant to, such as one whose WHERE clause was left out. It is
+// off unless set with Connection.SetSafety, and applies to every statement
+// run through the connection and its transactions, including those of
+// builders and migrations; run intended full-table changes with a context
+// from AllowFullTable, or the builders' AllowFullTable.
+type Safety struct {
+	// RequireWhere refuses UPDATE and DELETE statements without a WHERE
+	// clause.
+	RequireWhere bool
+
+	// MaxRows refuses UPDATE and DELETE statements run with Exec that
+	// affect more rows than this, zero for no limit. Outside a transaction
+	// the statement runs in one of its own that is rolled back; in a
+	// transaction Exec returns the error after the statement ran, and the
+	// transaction must be rolled back.
+	MaxRows int64
+}
+
+// SetSafety sets the guards of the connection. Transactions begun
+// afterwards use them too. Set them while setting the connection up,
+// before it is shared between goroutines.
+func (c *Connection) SetSafety(s Safety) {
+	c.safety = s
+}
+
+// Safety returns the guards of the connection.
+func (c *Connection) Safety() Safety {
+	return c.safety
+}
+
+type fullTableKey struct{}
+
+// AllowFullTable returns a context in which the Safety of connections
+// allows UPDATE and DELETE statements without WHERE or over MaxRows.
+func AllowFullTable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fullTableKey{}, true)
+}
+
+// fullTableAllowed reports whether ctx comes from AllowFullTable.
+func fullTableAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(fullTableKey{}).(bool)
+	return allowed
+}
+
+// sqlNoise matches the parts of a statement its keywords aren't looked
+// for in: string literals, quoted identifiers and comments.
+var sqlNoise = regexp.MustCompile(`(?s)'(?:[^']|'')*'|"(?:[^"]|"")*"|` + "`[^`]*`" + `|\[[^\]]*\]|--[^\n]*|/\*.*?\*/`)
+
+// topLevelWords returns the words of query outside parentheses, upper
+// cased, leaving out those of subqueries and CTE bodies.
+func topLevelWords(query string) []string {
+	query = sqlNoise.ReplaceAllString(query, " ")
+	var words []string
+	depth, start := 0, -1
+	for i := 0; i <= len(query); i++ {
+		c := byte(' ')
+		if i < len(query) {
+			c = query[i]
+		}
+		if c == '_' || c == '$' || c >= 0x80 || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && depth == 0 {
+			words = append(words, strings.ToUpper(query[start:i]))
+		}
+		start = -1
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	return words
+}
+
+// modifyVerb returns UPDATE or DELETE if words, from topLevelWords, are
+// those of such a statement, also after a WITH clause, and "" otherwise.
+func modifyVerb(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	switch words[0] {
+	case "UPDATE", "DELETE":
+		return words[0]
+	case "WITH":
+		for _, w := range words[1:] {
+			switch w {
+			case "UPDATE", "DELETE":
+				return w
+			case "SELECT", "INSERT", "MERGE", "VALUES", "TABLE":
+				return ""
+			}
+		}
+	}
+	return ""
+}
+
+// guarded reports whether the guards apply to query in ctx.
+func (s Safety) guarded(ctx context.Context, query string) bool {
+	return (s.RequireWhere || s.MaxRows > 0) && modifyVerb(topLevelWords(query)) != "" && !fullTableAllowed(ctx)
+}
+
+// Check returns an ErrUnsafeStatement error if RequireWhere is set and
+// query is an UPDATE or DELETE without a WHERE clause of its own, unless
+// ctx allows full-table statements. A WHERE in a subquery doesn't count.
+func (s Safety) Check(ctx context.Context, query string) error {
+	if !s.RequireWhere || fullTableAllowed(ctx) {
+		return nil
+	}
+	words := topLevelWords(query)
+	verb := modifyVerb(words)
+	if verb == "" {
+		return nil
+	}
+	for _, w := range words {
+		if w == "WHERE" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s without WHERE would change every row; use AllowFullTable if that is intended",
+		ErrUnsafeStatement, verb)
+}
+
+// refusedContext is a done context whose Err is why a statement was
+// refused. A *sql.Row can't be made with an error, so QueryRow runs a
+// refused statement with it, which database/sql gives up on with that
+// error before using a connection.
+type refusedContext struct {
+	context.Context
+	err error
+}
+
+// closedDone is the Done channel of every refusedContext.
+var closedDone = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+func (c refusedContext) Done() <-chan struct{} { return closedDone }
+func (c refusedContext) Err() error            { return c.err }
+
+// CheckRows returns an ErrUnsafeStatement error if MaxRows is set and n,
+// the rows query affects, exceeds it, unless ctx allows full-table
+// statements.
+func (s Safety) CheckRows(ctx context.Context, query string, n int64) error {
+	if s.MaxRows <= 0 || n <= s.MaxRows || !s.guarded(ctx, query) {
+		return nil
+	}
+	return fmt.Errorf("%w: statement would change %d rows, more than the limit of %d; use AllowFullTable if that is intended",
+		ErrUnsafeStatement, n, s.MaxRows)
+}
+
+// execLimited runs an UPDATE or DELETE in a transaction that is rolled
+// back if it affects more than MaxRows rows.
+func (c *Connection) execLimited(ctx context.Context, query string, args []interface{}) (sql.Result, error) {
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	result, err := tx.ExecContext(ctx, query, args...)
+	runHooks(ctx, c.hooks, query, args, start, err)
+	if err == nil {
+		var n int64
+		if n, err = result.RowsAffected(); err == nil {
+			err = c.safety.CheckRows(ctx, query, n)
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return result, tx.Commit()
+}
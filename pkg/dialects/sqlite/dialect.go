@@ -9,7 +9,9 @@ import (
 )
 
 // Dialect implements the SQLite dialect.
-type Dialect struct{}
+type Dialect struct {
+	driver string // Go sql driver name; sqlite3 when empty
+}
 
 // New creates a new SQLite dialect.
 func New() *Dialect {
@@ -23,12 +25,24 @@ func (d *Dialect) Name() string {
 
 // DriverName returns the Go sql driver name.
 func (d *Dialect) DriverName() string {
+	if d.driver != "" {
+		return d.driver
+	}
 	return "sqlite3"
 }
 
-// Quote quotes an identifier.
+// Quote quotes an identifier, doubling any quote characters in it.
 func (d *Dialect) Quote(identifier string) string {
-	return `"` + identifier + `"`
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// QuoteQualified quotes a table name qualified with the schema it lives
+// in, or the name alone if namespace is empty.
+func (d *Dialect) QuoteQualified(namespace, name string) string {
+	if namespace == "" {
+		return d.Quote(name)
+	}
+	return d.Quote(namespace) + "." + d.Quote(name)
 }
 
 // Placeholder returns the parameter placeholder.
@@ -43,6 +57,16 @@ func (d *Dialect) TypeMapping(field *schema.Field) string {
 		return "INTEGER"
 	case schema.FieldTypeBigInt:
 		return "INTEGER"
+	case schema.FieldTypeSmallInt, schema.FieldTypeTinyInt:
+		// Only INTEGER primary keys are rowids; other integer names keep
+		// the type for introspection
+		if field.IsPrimaryKey {
+			return "INTEGER"
+		}
+		if field.Type == schema.FieldTypeTinyInt {
+			return "TINYINT"
+		}
+		return "SMALLINT"
 	case schema.FieldTypeString:
 		return "TEXT"
 	case schema.FieldTypeText:
@@ -53,6 +77,9 @@ func (d *Dialect) TypeMapping(field *schema.Field) string {
 		return "REAL"
 	case schema.FieldTypeDecimal:
 		return "REAL" // SQLite doesn't have native DECIMAL
+	case schema.FieldTypeMoney:
+		// NUMERIC affinity keeps whole amounts as integers
+		return fmt.Sprintf("NUMERIC(%d,%d)", field.Precision, field.Scale)
 	case schema.FieldTypeDateTime:
 		return "TEXT" // ISO8601 string
 	case schema.FieldTypeDate:
@@ -93,13 +120,19 @@ func (d *Dialect) CreateTableSQL(model *schema.Model) string {
 
 	allParts := append(columns, constraints...)
 	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n)",
-		d.Quote(model.Name),
+		d.Quote(model.Table()),
 		strings.Join(allParts, ",\n  "))
 }
 
 func (d *Dialect) columnDefinition(field *schema.Field) string {
 	parts := []string{d.Quote(field.Name), d.TypeMapping(field)}
 
+	// Collations such as NOCASE are registered names; SQLite has no
+	// character sets
+	if _, collation := field.ColumnCollation(); collation != "" {
+		parts = append(parts, "COLLATE "+collation)
+	}
+
 	if field.IsPrimaryKey {
 		parts = append(parts, "PRIMARY KEY")
 		if field.AutoIncrement {
@@ -115,34 +148,58 @@ func (d *Dialect) columnDefinition(field *schema.Field) string {
 		parts = append(parts, "UNIQUE")
 	}
 
-	if field.DefaultExpr != "" {
-		// Map common expressions to SQLite equivalents
-		expr := field.DefaultExpr
-		switch strings.ToUpper(expr) {
-		case "NOW()":
-			expr = "CURRENT_TIMESTAMP"
-		case "UUID()":
-			expr = "(lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))),2) || '-' || substr('89ab',abs(random()) % 4 + 1, 1) || substr(lower(hex(randomblob(2))),2) || '-' || lower(hex(randomblob(6))))"
-		}
-		parts = append(parts, "DEFAULT "+expr)
-	} else if field.DefaultValue != nil {
-		switch v := field.DefaultValue.(type) {
-		case string:
-			parts = append(parts, fmt.Sprintf("DEFAULT '%s'", v))
-		case bool:
-			if v {
-				parts = append(parts, "DEFAULT 1")
-			} else {
-				parts = append(parts, "DEFAULT 0")
-			}
-		default:
-			parts = append(parts, fmt.Sprintf("DEFAULT %v", v))
+	// SQLite has no unsigned integers
+	if field.IsUnsigned {
+		parts = append(parts, fmt.Sprintf("CHECK (%s >= 0)", d.Quote(field.Name)))
+	}
+
+	// SQLite has no enum type; restrict the values instead
+	if field.Type == schema.FieldTypeEnum && field.Enum != nil {
+		values := make([]string, len(field.Enum.Values))
+		for i, v := range field.Enum.Values {
+			values[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
 		}
+		parts = append(parts, fmt.Sprintf("CHECK (%s IN (%s))", d.Quote(field.Name), strings.Join(values, ", ")))
+	}
+
+	if def := d.DefaultSQL(field); def != "" {
+		parts = append(parts, "DEFAULT "+def)
 	}
 
 	return strings.Join(parts, " ")
 }
 
+// DefaultSQL renders the default of a field, mapping the portable
+// expressions to SQLite equivalents. SQLite has no UUID function, and its
+// random() is a 64-bit integer.
+func (d *Dialect) DefaultSQL(field *schema.Field) string {
+	switch strings.ToUpper(field.DefaultExpr) {
+	case "":
+	case schema.DefaultExprNow:
+		return "CURRENT_TIMESTAMP"
+	case schema.DefaultExprUUID:
+		return "(lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))),2) || '-' || substr('89ab',abs(random()) % 4 + 1, 1) || substr(lower(hex(randomblob(2))),2) || '-' || lower(hex(randomblob(6))))"
+	case schema.DefaultExprRandom:
+		return "(random() / 18446744073709551616.0 + 0.5)"
+	default:
+		return field.DefaultExpr
+	}
+
+	switch v := field.DefaultValue.(type) {
+	case nil:
+		return ""
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
 // DropTableSQL generates DROP TABLE statement.
 func (d *Dialect) DropTableSQL(tableName string) string {
 	return fmt.Sprintf("DROP TABLE IF EXISTS %s", d.Quote(tableName))
@@ -4,6 +4,8 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/nexus-db/nexus/pkg/core/migration"
@@ -36,7 +38,7 @@ func (d *Dialect) IntrospectTables(ctx context.Context, db *sql.DB) ([]string, e
 
 // IntrospectColumns returns column metadata for a table.
 func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName string) ([]*migration.ColumnInfo, error) {
-	query := `PRAGMA table_info("` + tableName + `")`
+	query := `PRAGMA table_info(` + d.Quote(tableName) + `)`
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -74,7 +76,7 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 	}
 
 	// Check for unique constraints
-	uniqueQuery := `PRAGMA index_list("` + tableName + `")`
+	uniqueQuery := `PRAGMA index_list(` + d.Quote(tableName) + `)`
 	indexRows, err := db.QueryContext(ctx, uniqueQuery)
 	if err != nil {
 		return columns, nil // Return columns even if we can't get unique info
@@ -95,7 +97,7 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 
 		if unique == 1 && origin == "u" { // Unique constraint from CREATE TABLE
 			// Get the columns in this index
-			infoQuery := `PRAGMA index_info("` + name + `")`
+			infoQuery := `PRAGMA index_info(` + d.Quote(name) + `)`
 			infoRows, err := db.QueryContext(ctx, infoQuery)
 			if err != nil {
 				continue
@@ -125,7 +127,7 @@ func (d *Dialect) IntrospectColumns(ctx context.Context, db *sql.DB, tableName s
 
 // IntrospectIndexes returns index metadata for a table.
 func (d *Dialect) IntrospectIndexes(ctx context.Context, db *sql.DB, tableName string) ([]*migration.IndexInfo, error) {
-	query := `PRAGMA index_list("` + tableName + `")`
+	query := `PRAGMA index_list(` + d.Quote(tableName) + `)`
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -156,7 +158,7 @@ func (d *Dialect) IntrospectIndexes(ctx context.Context, db *sql.DB, tableName s
 		}
 
 		// Get columns in this index
-		infoQuery := `PRAGMA index_info("` + name + `")`
+		infoQuery := `PRAGMA index_info(` + d.Quote(name) + `)`
 		infoRows, err := db.QueryContext(ctx, infoQuery)
 		if err != nil {
 			continue
@@ -177,3 +179,146 @@ func (d *Dialect) IntrospectIndexes(ctx context.Context, db *sql.DB, tableName s
 
 	return indexes, rows.Err()
 }
+
+// IntrospectForeignKeys returns foreign key constraints for a table.
+// SQLite does not name foreign keys, so names are synthesized as
+// fk_<table>_<id> from the constraint id reported by PRAGMA foreign_key_list.
+func (d *Dialect) IntrospectForeignKeys(ctx context.Context, db *sql.DB, tableName string) ([]*migration.ForeignKeyInfo, error) {
+	query := `PRAGMA foreign_key_list(` + d.Quote(tableName) + `)`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []*migration.ForeignKeyInfo
+	byID := make(map[int]*migration.ForeignKeyInfo)
+	for rows.Next() {
+		var id, seq int
+		var refTable, from string
+		var to sql.NullString
+		var onUpdate, onDelete, match string
+
+		if err := rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			return nil, err
+		}
+
+		fk, ok := byID[id]
+		if !ok {
+			fk = &migration.ForeignKeyInfo{
+				Name:     fmt.Sprintf("fk_%s_%d", tableName, id),
+				RefTable: refTable,
+				OnDelete: onDelete,
+				OnUpdate: onUpdate,
+			}
+			byID[id] = fk
+			fks = append(fks, fk)
+		}
+
+		fk.Columns = append(fk.Columns, from)
+		// A NULL target column means the parent's primary key
+		refColumn := to.String
+		if refColumn == "" {
+			refColumn = "id"
+		}
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+	}
+
+	return fks, rows.Err()
+}
+
+// IntrospectCheckConstraints returns CHECK constraints for a table.
+// SQLite has no catalog for constraints, so they are parsed from the
+// CREATE TABLE statement stored in sqlite_master.
+func (d *Dialect) IntrospectCheckConstraints(ctx context.Context, db *sql.DB, tableName string) ([]*migration.CheckInfo, error) {
+	var createSQL sql.NullString
+	err := db.QueryRowContext(ctx,
+		`SELECT sql FROM sqlite_master WHERE type='table' AND name = ?`, tableName).Scan(&createSQL)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return parseCheckConstraints(tableName, createSQL.String), nil
+}
+
+// IntrospectEnums returns nil; SQLite has no enum types.
+func (d *Dialect) IntrospectEnums(ctx context.Context, db *sql.DB) ([]*migration.EnumInfo, error) {
+	return nil, nil
+}
+
+// IntrospectStats returns the size of the database file and the free pages
+// VACUUM would reclaim. Table sizes come from the dbstat table, which only
+// some builds of SQLite have; without it the stats have no tables.
+func (d *Dialect) IntrospectStats(ctx context.Context, db *sql.DB) (*migration.DatabaseStats, error) {
+	var pageSize, pageCount, freePages int64
+	for _, p := range []struct {
+		pragma string
+		dest   *int64
+	}{{"page_size", &pageSize}, {"page_count", &pageCount}, {"freelist_count", &freePages}} {
+		if err := db.QueryRowContext(ctx, "PRAGMA "+p.pragma).Scan(p.dest); err != nil {
+			return nil, err
+		}
+	}
+	stats := &migration.DatabaseStats{Bytes: pageSize * pageCount, Reclaimable: pageSize * freePages}
+
+	query := `SELECT m.tbl_name, 0, SUM(s.pgsize), 0
+		FROM dbstat s
+		JOIN sqlite_master m ON m.name = s.name
+		WHERE m.tbl_name NOT LIKE 'sqlite_%'
+		GROUP BY m.tbl_name`
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return stats, nil
+	}
+	if stats.Tables, err = migration.ScanTableStats(rows); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+var checkKeyword = regexp.MustCompile(`(?i)(?:CONSTRAINT\s+["` + "`" + `]?(\w+)["` + "`" + `]?\s+)?\bCHECK\s*\(`)
+
+// parseCheckConstraints extracts CHECK (...) clauses from a CREATE TABLE statement.
+func parseCheckConstraints(tableName, createSQL string) []*migration.CheckInfo {
+	var checks []*migration.CheckInfo
+
+	for _, loc := range checkKeyword.FindAllStringSubmatchIndex(createSQL, -1) {
+		// loc[1] is just past the opening parenthesis
+		depth := 1
+		end := -1
+		inString := false
+		for i := loc[1]; i < len(createSQL) && end < 0; i++ {
+			switch c := createSQL[i]; {
+			case c == '\'':
+				inString = !inString
+			case inString:
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			continue
+		}
+
+		name := fmt.Sprintf("%s_check_%d", tableName, len(checks)+1)
+		if loc[2] >= 0 {
+			name = createSQL[loc[2]:loc[3]]
+		}
+
+		checks = append(checks, &migration.CheckInfo{
+			Name:       name,
+			Expression: strings.TrimSpace(createSQL[loc[1]:end]),
+		})
+	}
+
+	return checks
+}
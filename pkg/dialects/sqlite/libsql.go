@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewLibSQL creates a SQLite dialect for libSQL and Turso databases, opened
+// with the "libsql" driver registered by github.com/tursodatabase/libsql-client-go
+// or github.com/tursodatabase/go-libsql. It generates the same SQL as New,
+// so the same schema files and migrations work against both.
+func NewLibSQL() *Dialect {
+	return &Dialect{driver: "libsql"}
+}
+
+// LibSQLOptions configures OpenLibSQL.
+type LibSQLOptions struct {
+	// URL of the database: libsql://, https:// or wss:// for a remote
+	// database such as Turso, or file: for a local one.
+	URL string
+	// AuthToken is sent to remote databases; it can also be set as the
+	// authToken parameter of URL.
+	AuthToken string
+	// ReplicaPath, when set, opens an embedded replica: a local SQLite file
+	// that serves reads and is synced from URL. Writes go to URL.
+	ReplicaPath string
+	// SyncInterval syncs the replica in the background; 0 only syncs when
+	// the replica is opened.
+	SyncInterval time.Duration
+}
+
+// ReplicaConnector opens an embedded replica at path, synced from the
+// database at primaryURL. Nexus does not link a libSQL driver; apps using
+// go-libsql register its connector:
+//
+//	sqlite.RegisterReplicaConnector(func(path, primaryURL, authToken string, every time.Duration) (driver.Connector, error) {
+//		return libsql.NewEmbeddedReplicaConnector(path, primaryURL,
+//			libsql.WithAuthToken(authToken), libsql.WithSyncInterval(every))
+//	})
+type ReplicaConnector func(path, primaryURL, authToken string, syncInterval time.Duration) (driver.Connector, error)
+
+var (
+	replicaMu        sync.RWMutex
+	replicaConnector ReplicaConnector
+)
+
+// RegisterReplicaConnector sets the connector OpenLibSQL uses for embedded
+// replicas.
+func RegisterReplicaConnector(fn ReplicaConnector) {
+	replicaMu.Lock()
+	defer replicaMu.Unlock()
+	replicaConnector = fn
+}
+
+// OpenLibSQL opens a libSQL database: the embedded replica at
+// opts.ReplicaPath if set, else the database at opts.URL through the
+// "libsql" driver.
+func OpenLibSQL(opts LibSQLOptions) (*sql.DB, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("libsql: URL is required")
+	}
+
+	if opts.ReplicaPath == "" {
+		dsn, err := LibSQLDSN(opts.URL, opts.AuthToken)
+		if err != nil {
+			return nil, err
+		}
+		return sql.Open("libsql", dsn)
+	}
+
+	replicaMu.RLock()
+	connect := replicaConnector
+	replicaMu.RUnlock()
+	if connect == nil {
+		return nil, fmt.Errorf("libsql: embedded replicas need a connector; call sqlite.RegisterReplicaConnector with go-libsql's NewEmbeddedReplicaConnector")
+	}
+
+	connector, err := connect(opts.ReplicaPath, opts.URL, opts.AuthToken, opts.SyncInterval)
+	if err != nil {
+		return nil, fmt.Errorf("libsql: opening replica %s: %w", opts.ReplicaPath, err)
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// LibSQLDSN returns the driver DSN for a libSQL URL, with authToken added
+// as the authToken parameter unless the URL already has one.
+func LibSQLDSN(rawURL, authToken string) (string, error) {
+	if strings.HasPrefix(rawURL, "file:") {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("libsql: invalid URL: %w", err)
+	}
+	switch u.Scheme {
+	case "libsql", "http", "https", "ws", "wss":
+	default:
+		return "", fmt.Errorf("libsql: unsupported URL scheme %q (use libsql://, https://, wss:// or file:)", u.Scheme)
+	}
+
+	if authToken != "" {
+		q := u.Query()
+		if q.Get("authToken") == "" {
+			q.Set("authToken", authToken)
+			u.RawQuery = q.Encode()
+		}
+	}
+	return u.String(), nil
+}
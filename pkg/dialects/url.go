@@ -0,0 +1,54 @@
+package dialects
+
+import (
+	"fmt"
+	neturl "net/url"
+	"path/filepath"
+	"strings"
+)
+
+// ParseURL returns the dialect name and driver DSN of a database URL:
+// postgres://..., mysql://..., sqlserver://..., libsql://..., sqlite:<path>,
+// "<dialect>:<dsn>", or the path of a SQLite file.
+func ParseURL(raw string) (dialect, dsn string, err error) {
+	if scheme, rest, ok := strings.Cut(raw, "://"); ok {
+		switch strings.ToLower(scheme) {
+		case "postgres", "postgresql":
+			return "postgres", raw, nil
+		case "mysql":
+			u, err := neturl.Parse(raw)
+			if err != nil {
+				return "", "", err
+			}
+			user := u.User.Username()
+			if password, ok := u.User.Password(); ok {
+				user += ":" + password
+			}
+			dsn := fmt.Sprintf("%s@tcp(%s)%s", user, u.Host, u.Path)
+			if u.RawQuery != "" {
+				dsn += "?" + u.RawQuery
+			}
+			return "mysql", dsn, nil
+		case "sqlserver", "mssql":
+			return "mssql", "sqlserver://" + rest, nil
+		case "libsql":
+			return "libsql", raw, nil
+		case "sqlite", "sqlite3":
+			return "sqlite", rest, nil
+		}
+		return "", "", fmt.Errorf("unknown database URL scheme %q", scheme)
+	}
+	if prefix, rest, ok := strings.Cut(raw, ":"); ok {
+		switch strings.ToLower(prefix) {
+		case "sqlite", "sqlite3", "postgres", "postgresql", "mysql", "mssql":
+			return prefix, rest, nil
+		case "file":
+			return "sqlite", raw, nil
+		}
+	}
+	switch filepath.Ext(raw) {
+	case ".db", ".sqlite", ".sqlite3":
+		return "sqlite", raw, nil
+	}
+	return "", "", fmt.Errorf("cannot tell the dialect of %q (use sqlite:<path>, postgres://..., mysql://..., sqlserver://... or libsql://...)", raw)
+}
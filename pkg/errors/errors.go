@@ -18,6 +18,11 @@ const (
 	ErrSchemaMissingPK       ErrorCode = "SCHEMA_MISSING_PRIMARY_KEY"
 	ErrSchemaDuplicateField  ErrorCode = "SCHEMA_DUPLICATE_FIELD"
 	ErrSchemaValidation      ErrorCode = "SCHEMA_VALIDATION"
+	ErrSchemaInvalidImport   ErrorCode = "SCHEMA_INVALID_IMPORT"
+	ErrSchemaDuplicateModel  ErrorCode = "SCHEMA_DUPLICATE_MODEL"
+	ErrSchemaUnknownModel    ErrorCode = "SCHEMA_UNKNOWN_MODEL"
+	ErrSchemaInvalidRelation ErrorCode = "SCHEMA_INVALID_RELATION"
+	ErrSchemaInvalidEnum     ErrorCode = "SCHEMA_INVALID_ENUM"
 
 	// Migration errors
 	ErrMigrationNotFound      ErrorCode = "MIGRATION_NOT_FOUND"
@@ -30,6 +35,8 @@ const (
 	// Query errors
 	ErrQueryDialectUnsupported ErrorCode = "QUERY_DIALECT_UNSUPPORTED"
 	ErrQueryCascadeRestrict    ErrorCode = "QUERY_CASCADE_RESTRICT"
+	ErrQueryUnauthorized       ErrorCode = "QUERY_UNAUTHORIZED"
+	ErrQueryInvalidFields      ErrorCode = "QUERY_INVALID_FIELDS"
 
 	// General errors
 	ErrGeneral ErrorCode = "GENERAL_ERROR"
@@ -50,13 +57,19 @@ type NexusError struct {
 	Code       ErrorCode
 	Message    string
 	Suggestion string
+	File       string // Source file, if the error came from a file
 	Line       int
 	Column     int
+	EndLine    int    // End of the error span, if known
+	EndColumn  int    // Exclusive end column on EndLine
 	Context    string // The line of code with the error
 }
 
 // Error implements the error interface.
 func (e *NexusError) Error() string {
+	if e.File != "" && e.Line > 0 {
+		return fmt.Sprintf("[%s] %s:%d: %s", e.Code, e.File, e.Line, e.Message)
+	}
 	if e.Line > 0 {
 		return fmt.Sprintf("[%s] line %d: %s", e.Code, e.Line, e.Message)
 	}
@@ -71,13 +84,20 @@ func (e *NexusError) Print() string {
 	sb.WriteString(fmt.Sprintf("%s%sError:%s %s\n", colorBold, colorRed, colorReset, e.Message))
 
 	// Location with context
+	if e.File != "" {
+		sb.WriteString(fmt.Sprintf("  %s--> %s%s\n", colorGray, e.File, colorReset))
+	}
 	if e.Line > 0 && e.Context != "" {
 		sb.WriteString(fmt.Sprintf("\n  %s%d |%s  %s\n", colorGray, e.Line, colorReset, e.Context))
 
 		// Underline the error position if column is set
 		if e.Column > 0 {
-			padding := strings.Repeat(" ", len(fmt.Sprintf("%d", e.Line))+4+e.Column-1)
-			sb.WriteString(fmt.Sprintf("%s%s^%s\n", colorRed, padding, colorReset))
+			width := 1
+			if e.EndLine == e.Line && e.EndColumn > e.Column {
+				width = e.EndColumn - e.Column
+			}
+			padding := strings.Repeat(" ", len(fmt.Sprintf("%d", e.Line))+6) + indent(e.Context, e.Column-1)
+			sb.WriteString(fmt.Sprintf("%s%s%s%s\n", colorRed, padding, strings.Repeat("^", width), colorReset))
 		}
 	} else if e.Line > 0 {
 		sb.WriteString(fmt.Sprintf("\n  %sAt line %d%s\n", colorGray, e.Line, colorReset))
@@ -91,6 +111,25 @@ func (e *NexusError) Print() string {
 	return sb.String()
 }
 
+// indent returns whitespace as wide as the first n runes of line, keeping
+// tabs so the underline lines up with the printed context.
+func indent(line string, n int) string {
+	var sb strings.Builder
+	for _, r := range line {
+		if n == 0 {
+			break
+		}
+		if r == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteRune(' ')
+		}
+		n--
+	}
+	sb.WriteString(strings.Repeat(" ", n))
+	return sb.String()
+}
+
 // NewSchemaError creates a schema-related error.
 func NewSchemaError(code ErrorCode, message string, line int, context string) *NexusError {
 	return &NexusError{
@@ -133,6 +172,8 @@ func NewQueryError(code ErrorCode, message string) *NexusError {
 var Suggestions = map[ErrorCode]string{
 	ErrSchemaUnknownType:       "Valid types: Int, BigInt, String, Text, Bool, Float, Decimal, DateTime, Date, Time, JSON, Bytes, UUID",
 	ErrSchemaInvalidModifier:   "Valid modifiers: @id, @unique, @autoincrement, @default(value)",
+	ErrSchemaUnknownModel:      "Declare the model in this file, or import the file that defines it, e.g.: import \"billing.nexus\"",
+	ErrSchemaInvalidRelation:   "Use format: author User @relation(fields: [author_id], references: [id], onDelete: Cascade)",
 	ErrSchemaMissingPK:         "Add a primary key field with @id modifier, e.g.: id Int @id @autoincrement",
 	ErrMigrationNoRollback:     "Run 'nexus migrate up' first to apply migrations",
 	ErrMigrationLocked:         "Use 'nexus migrate --force' to override the lock (use with caution)",
@@ -213,9 +254,11 @@ func min(a, b, c int) int {
 var ValidTypes = []string{
 	"Int", "BigInt", "String", "Text", "Bool", "Float",
 	"Decimal", "DateTime", "Date", "Time", "JSON", "Bytes", "UUID",
+	"SmallInt", "TinyInt", "Money",
 }
 
 // ValidModifiers lists all valid field modifiers.
 var ValidModifiers = []string{
-	"id", "unique", "autoincrement", "auto", "default", "db", "map", "relation", "length", "size", "precision",
+	"id", "unique", "autoincrement", "auto", "unsigned", "default", "db", "map", "relation", "length", "size", "precision",
+	"email", "min", "max", "regex", "pii", "charset", "collate", "comment", "gotype",
 }
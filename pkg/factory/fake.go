@@ -0,0 +1,225 @@
+package factory
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+var (
+	firstNames = []string{"Ada", "Alan", "Grace", "Linus", "Barbara", "Dennis", "Margaret", "Ken", "Frances", "Edsger", "Radia", "Donald"}
+	lastNames  = []string{"Lovelace", "Turing", "Hopper", "Torvalds", "Liskov", "Ritchie", "Hamilton", "Thompson", "Allen", "Dijkstra", "Perlman", "Knuth"}
+	words      = []string{"amber", "river", "quiet", "harbor", "silver", "maple", "orbit", "lantern", "meadow", "cobalt", "summit", "willow", "ember", "canyon", "prairie", "falcon"}
+	cities     = []string{"Lisbon", "Osaka", "Toronto", "Nairobi", "Oslo", "Pune", "Valparaíso", "Melbourne"}
+	countries  = []string{"Portugal", "Japan", "Canada", "Kenya", "Norway", "India", "Chile", "Australia"}
+	companies  = []string{"Acme", "Globex", "Initech", "Umbrella", "Hooli", "Vandelay", "Soylent", "Stark"}
+	colors     = []string{"red", "green", "blue", "teal", "orange", "purple", "black", "white"}
+
+	// baseTime anchors generated timestamps, keeping them reproducible.
+	baseTime = time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+)
+
+// fake returns a value for field of the seq-th record of its model.
+// Unique columns include seq so records do not collide.
+func fake(r *rand.Rand, f *schema.Field, seq int) interface{} {
+	switch f.Type {
+	case schema.FieldTypeInt, schema.FieldTypeBigInt, schema.FieldTypeSmallInt, schema.FieldTypeTinyInt:
+		if f.IsUnique || f.IsPrimaryKey {
+			return int64(seq)
+		}
+		lo, hi := intRange(f.Name)
+		lo, hi = bounds(f, lo, hi)
+		// Keep within the column's type
+		least, most := f.IntegerRange()
+		lo, hi = int(max(int64(lo), least)), int(min(int64(hi), most))
+		if lo > hi {
+			lo = hi
+		}
+		return int64(lo) + r.Int63n(int64(hi-lo)+1)
+	case schema.FieldTypeFloat, schema.FieldTypeDecimal, schema.FieldTypeMoney:
+		lo, hi := floatRange(f.Name)
+		lo, hi = bounds(f, lo, hi)
+		return math.Round((lo+r.Float64()*(hi-lo))*100) / 100
+	case schema.FieldTypeBool:
+		return r.Intn(2) == 1
+	case schema.FieldTypeDateTime, schema.FieldTypeDate, schema.FieldTypeTime:
+		return baseTime.Add(time.Duration(r.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+	case schema.FieldTypeUUID:
+		return uuid(r)
+	case schema.FieldTypeJSON:
+		return "{}"
+	case schema.FieldTypeBytes:
+		b := make([]byte, 16)
+		r.Read(b)
+		return b
+	case schema.FieldTypeEnum:
+		if f.Enum == nil || len(f.Enum.Values) == 0 {
+			return nil
+		}
+		return f.Enum.Values[r.Intn(len(f.Enum.Values))]
+	}
+	return fitLength(f, fakeText(r, f, seq))
+}
+
+// fakeText returns text matching the name of a String or Text field.
+func fakeText(r *rand.Rand, f *schema.Field, seq int) string {
+	first, last := pick(r, firstNames), pick(r, lastNames)
+	name := strings.ToLower(strings.ReplaceAll(f.Name, "_", ""))
+	switch {
+	case f.IsEmail || strings.Contains(name, "email"):
+		return fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(last), seq)
+	case name == "firstname" || name == "givenname":
+		return first
+	case name == "lastname" || name == "surname" || name == "familyname":
+		return last
+	case strings.Contains(name, "username") || name == "login" || name == "handle":
+		return fmt.Sprintf("%s%d", strings.ToLower(first), seq)
+	case strings.HasSuffix(name, "name") && name != "name" && !strings.Contains(name, "full"):
+		return title(sentence(r, 2))
+	case strings.Contains(name, "name"):
+		return first + " " + last
+	case strings.Contains(name, "slug"):
+		return fmt.Sprintf("%s-%s-%d", pick(r, words), pick(r, words), seq)
+	case strings.Contains(name, "url") || strings.Contains(name, "website") || strings.Contains(name, "link"):
+		return fmt.Sprintf("https://example.com/%s-%d", pick(r, words), seq)
+	case strings.Contains(name, "phone"):
+		return fmt.Sprintf("+1-555-%04d", r.Intn(10000))
+	case strings.Contains(name, "password") || strings.Contains(name, "secret") || strings.Contains(name, "token"):
+		return fmt.Sprintf("%s-%s-%d", pick(r, words), pick(r, words), r.Intn(1e6))
+	case strings.Contains(name, "city"):
+		return pick(r, cities)
+	case strings.Contains(name, "country"):
+		return pick(r, countries)
+	case strings.Contains(name, "address") || strings.Contains(name, "street"):
+		return fmt.Sprintf("%d %s Street", 1+r.Intn(999), capitalize(pick(r, words)))
+	case strings.Contains(name, "zip") || strings.Contains(name, "postal"):
+		return fmt.Sprintf("%05d", r.Intn(100000))
+	case strings.Contains(name, "company") || strings.Contains(name, "organization"):
+		return pick(r, companies)
+	case strings.Contains(name, "color") || strings.Contains(name, "colour"):
+		return pick(r, colors)
+	case strings.Contains(name, "title") || strings.Contains(name, "subject") || strings.Contains(name, "label"):
+		return title(sentence(r, 2+r.Intn(3)))
+	case f.Type == schema.FieldTypeText || strings.Contains(name, "description") || strings.Contains(name, "body") ||
+		strings.Contains(name, "content") || strings.Contains(name, "bio") || strings.Contains(name, "summary") ||
+		strings.Contains(name, "comment") || strings.Contains(name, "message") || strings.Contains(name, "note"):
+		return capitalize(sentence(r, 6+r.Intn(8))) + "."
+	}
+	if f.IsUnique || f.IsPrimaryKey {
+		return fmt.Sprintf("%s-%d", pick(r, words), seq)
+	}
+	return pick(r, words)
+}
+
+// fitLength trims or pads text to the @size, @min and @max of f.
+func fitLength(f *schema.Field, s string) string {
+	limit := 0
+	if f.Type == schema.FieldTypeString {
+		limit = f.Length
+		if limit == 0 {
+			limit = 255
+		}
+	}
+	if f.MaxValue != nil && (limit == 0 || int(*f.MaxValue) < limit) {
+		limit = int(*f.MaxValue)
+	}
+	if limit > 0 && utf8.RuneCountInString(s) > limit {
+		s = string([]rune(s)[:limit])
+	}
+	if f.MinValue != nil {
+		for utf8.RuneCountInString(s) < int(*f.MinValue) {
+			s += "x"
+		}
+	}
+	return s
+}
+
+// bounds narrows a default range to the @min and @max of f.
+func bounds[T int | float64](f *schema.Field, lo, hi T) (T, T) {
+	if f.MinValue != nil {
+		lo = T(*f.MinValue)
+		if hi < lo {
+			hi = lo * 2
+		}
+	}
+	if f.MaxValue != nil {
+		hi = T(*f.MaxValue)
+		if lo > hi {
+			lo = hi
+		}
+	}
+	return lo, hi
+}
+
+// intRange returns a plausible range for an integer column.
+func intRange(name string) (int, int) {
+	name = strings.ToLower(name)
+	switch {
+	case name == "age" || strings.HasSuffix(name, "_age"):
+		return 18, 80
+	case strings.Contains(name, "year"):
+		return 1990, 2024
+	case strings.Contains(name, "rating") || strings.Contains(name, "stars"):
+		return 1, 5
+	case strings.Contains(name, "count") || strings.Contains(name, "quantity") || strings.Contains(name, "stock"):
+		return 0, 100
+	}
+	return 1, 1000
+}
+
+// floatRange returns a plausible range for a decimal column.
+func floatRange(name string) (float64, float64) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(name, "lat"):
+		return -90, 90
+	case strings.HasPrefix(name, "lng") || strings.HasPrefix(name, "lon"):
+		return -180, 180
+	case strings.Contains(name, "rate") || strings.Contains(name, "ratio") || strings.Contains(name, "score"):
+		return 0, 1
+	}
+	return 1, 500 // Prices and amounts
+}
+
+func sentence(r *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = pick(r, words)
+	}
+	return strings.Join(parts, " ")
+}
+
+// title capitalizes every word of s.
+func title(s string) string {
+	parts := strings.Fields(s)
+	for i, p := range parts {
+		parts[i] = capitalize(p)
+	}
+	return strings.Join(parts, " ")
+}
+
+// capitalize upper-cases the first letter of s; the words are ASCII.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func pick(r *rand.Rand, list []string) string {
+	return list[r.Intn(len(list))]
+}
+
+// uuid returns a random version 4 UUID.
+func uuid(r *rand.Rand) string {
+	b := make([]byte, 16)
+	r.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,552 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Request is a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response. Data is nil if the request failed before
+// execution, e.g. with a syntax error.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a GraphQL error with the location in the query and the path in
+// the response it applies to.
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Locations) > 0 {
+		return fmt.Sprintf("%s (line %d, column %d)", e.Message, e.Locations[0].Line, e.Locations[0].Column)
+	}
+	return e.Message
+}
+
+// orderedMap is a response object whose keys keep the query's order.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{})}
+}
+
+func (m *orderedMap) set(key string, v interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// executor runs one operation.
+type executor struct {
+	doc    *document
+	vars   map[string]interface{}
+	errors []*Error
+}
+
+// Execute runs a request against the schema. Fields of the same depth are
+// resolved together, so a batch resolver runs once per depth rather than
+// once per record.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parseDocument(req.Query)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	var root *Object
+	switch op.kind {
+	case "query":
+		root = s.Query
+	case "mutation":
+		root = s.Mutation
+	}
+	if root == nil {
+		return errorResponse(&Error{Message: fmt.Sprintf("Schema does not support %s operations", op.kind), Locations: []Location{op.loc}})
+	}
+
+	e := &executor{doc: doc}
+	if e.vars, err = s.coerceVariables(op, req.Variables); err != nil {
+		return errorResponse(err)
+	}
+
+	results := e.executeFields(ctx, root, []interface{}{nil}, op.selections, [][]interface{}{nil})
+	return &Response{Data: results[0], Errors: e.errors}
+}
+
+func errorResponse(err error) *Response {
+	gqlErr, ok := err.(*Error)
+	if !ok {
+		gqlErr = &Error{Message: err.Error()}
+	}
+	return &Response{Errors: []*Error{gqlErr}}
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, &Error{Message: "Must provide operationName if query contains multiple operations"}
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation %q", name)}
+}
+
+func (s *Schema) coerceVariables(op *operation, given map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{}, len(op.variables))
+	for _, def := range op.variables {
+		t, err := s.resolveType(def.typ)
+		if err != nil {
+			return nil, &Error{Message: err.Error(), Locations: []Location{def.loc}}
+		}
+		switch namedType(t).(type) {
+		case *Object:
+			return nil, &Error{Message: fmt.Sprintf("Variable $%s cannot have output type %s", def.name, t), Locations: []Location{def.loc}}
+		}
+
+		v, ok := given[def.name]
+		if !ok {
+			if def.defaultVal != nil {
+				val, err := coerceLiteral(t, def.defaultVal, nil)
+				if err != nil {
+					return nil, &Error{Message: fmt.Sprintf("Variable $%s: %s", def.name, err), Locations: []Location{def.loc}}
+				}
+				vars[def.name] = val
+			} else if _, required := t.(*NonNull); required {
+				return nil, &Error{Message: fmt.Sprintf("Variable $%s of type %s is required", def.name, def.typ), Locations: []Location{def.loc}}
+			}
+			continue
+		}
+		val, err := coerceInput(t, normalizeJSON(v))
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("Variable $%s: %s", def.name, err), Locations: []Location{def.loc}}
+		}
+		vars[def.name] = val
+	}
+	return vars, nil
+}
+
+// normalizeJSON turns numbers decoded as float64 into json.Number, so
+// variables from any decoder coerce like literals.
+func normalizeJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		return json.Number(fmt.Sprint(v))
+	case int:
+		return json.Number(fmt.Sprint(v))
+	case int64:
+		return json.Number(fmt.Sprint(v))
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalizeJSON(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = normalizeJSON(item)
+		}
+		return out
+	}
+	return v
+}
+
+func (e *executor) fail(path []interface{}, loc Location, format string, args ...interface{}) {
+	e.errors = append(e.errors, &Error{
+		Message:   fmt.Sprintf(format, args...),
+		Locations: []Location{loc},
+		Path:      append([]interface{}(nil), path...),
+	})
+}
+
+// collectFields flattens fragments and applies @skip and @include,
+// grouping fields by response key in query order.
+func (e *executor) collectFields(obj *Object, selections []selection, keys *[]string, groups map[string][]*field, visited map[string]bool) {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			if !e.included(sel.directives) {
+				continue
+			}
+			key := sel.key()
+			if _, ok := groups[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			groups[key] = append(groups[key], sel)
+
+		case *inlineFragment:
+			if !e.included(sel.directives) || sel.typeCondition != "" && sel.typeCondition != obj.Name {
+				continue
+			}
+			e.collectFields(obj, sel.selections, keys, groups, visited)
+
+		case *fragmentSpread:
+			if !e.included(sel.directives) || visited[sel.name] {
+				continue
+			}
+			visited[sel.name] = true
+			frag, ok := e.doc.fragments[sel.name]
+			if !ok {
+				e.fail(nil, sel.loc, "Unknown fragment %q", sel.name)
+				continue
+			}
+			if frag.typeCondition != obj.Name {
+				continue
+			}
+			e.collectFields(obj, frag.selections, keys, groups, visited)
+		}
+	}
+}
+
+// included evaluates @skip(if:) and @include(if:).
+func (e *executor) included(directives []*directive) bool {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		cond := false
+		for _, arg := range d.args {
+			if arg.name == "if" {
+				v, _ := literalValue(arg.val, e.vars)
+				cond, _ = v.(bool)
+			}
+		}
+		if d.name == "skip" && cond || d.name == "include" && !cond {
+			return false
+		}
+	}
+	return true
+}
+
+// executeFields resolves the selections of obj for every source at once
+// and returns one result object per source.
+func (e *executor) executeFields(ctx context.Context, obj *Object, sources []interface{}, selections []selection, paths [][]interface{}) []*orderedMap {
+	var keys []string
+	groups := make(map[string][]*field)
+	e.collectFields(obj, selections, &keys, groups, make(map[string]bool))
+
+	results := make([]*orderedMap, len(sources))
+	for i := range results {
+		results[i] = newOrderedMap()
+	}
+
+	for _, key := range keys {
+		fields := groups[key]
+		f := fields[0]
+		fieldPaths := make([][]interface{}, len(sources))
+		for i := range sources {
+			fieldPaths[i] = append(append([]interface{}(nil), paths[i]...), key)
+		}
+
+		if f.name == "__typename" {
+			for _, r := range results {
+				r.set(key, obj.Name)
+			}
+			continue
+		}
+
+		def := obj.Field(f.name)
+		if def == nil {
+			e.fail(nil, f.loc, "Cannot query field %q on type %q.", f.name, obj.Name)
+			for _, r := range results {
+				r.set(key, nil)
+			}
+			continue
+		}
+
+		values := e.resolve(ctx, def, f, sources, fieldPaths)
+		completed := e.complete(ctx, def.Type, fields, values, fieldPaths)
+		for i, r := range results {
+			r.set(key, completed[i])
+		}
+	}
+	return results
+}
+
+// resolve runs the field resolver for every source. Failed sources get a
+// nil value and an error.
+func (e *executor) resolve(ctx context.Context, def *Field, f *field, sources []interface{}, paths [][]interface{}) []interface{} {
+	values := make([]interface{}, len(sources))
+
+	given := make(map[string]interface{}, len(f.args))
+	for _, arg := range f.args {
+		if v, ok := literalValue(arg.val, e.vars); ok {
+			given[arg.name] = v
+		}
+	}
+	args, err := coerceFields(def.Args, given, func(name string) string {
+		return fmt.Sprintf("Argument %s(%s:)", f.name, name)
+	})
+	if err != nil {
+		for _, path := range paths {
+			e.fail(path, f.loc, "%s", err)
+		}
+		return values
+	}
+
+	switch {
+	case def.Batch != nil:
+		batch, err := def.Batch(ctx, sources, args)
+		if err == nil && len(batch) != len(sources) {
+			err = fmt.Errorf("batch resolver returned %d values for %d sources", len(batch), len(sources))
+		}
+		if err != nil {
+			for _, path := range paths {
+				e.fail(path, f.loc, "%s", err)
+			}
+			return values
+		}
+		copy(values, batch)
+
+	case def.Resolve != nil:
+		for i, source := range sources {
+			v, err := def.Resolve(ctx, source, args)
+			if err != nil {
+				e.fail(paths[i], f.loc, "%s", err)
+				continue
+			}
+			values[i] = v
+		}
+
+	default:
+		for i, source := range sources {
+			values[i] = defaultResolve(source, def.Name)
+		}
+	}
+	return values
+}
+
+// defaultResolve reads a field from a map or struct.
+func defaultResolve(source interface{}, name string) interface{} {
+	rv := reflect.ValueOf(source)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		v := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		if !v.IsValid() {
+			return nil
+		}
+		return v.Interface()
+
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			sf := rt.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			tag := strings.Split(sf.Tag.Get("json"), ",")[0]
+			if tag == name || tag == "" && strings.EqualFold(sf.Name, name) {
+				return rv.Field(i).Interface()
+			}
+		}
+	}
+	return nil
+}
+
+// isNil reports whether v is nil or a nil pointer, slice or map.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map:
+		return rv.IsNil()
+	case reflect.Slice:
+		// json.RawMessage(nil) is a null JSON value
+		return rv.IsNil() && rv.Type() == reflect.TypeOf(json.RawMessage(nil))
+	}
+	return false
+}
+
+// complete turns resolved values into response values of type t.
+func (e *executor) complete(ctx context.Context, t Type, fields []*field, values []interface{}, paths [][]interface{}) []interface{} {
+	out := make([]interface{}, len(values))
+	f := fields[0]
+
+	switch t := t.(type) {
+	case *NonNull:
+		completed := e.complete(ctx, t.Of, fields, values, paths)
+		for i, v := range completed {
+			if v == nil && !e.failedAt(paths[i]) {
+				e.fail(paths[i], f.loc, "Cannot return null for non-nullable field %s.", f.name)
+			}
+		}
+		return completed
+
+	case *Scalar, *Enum:
+		if f.selections != nil {
+			e.fail(nil, f.loc, "Field %q of type %s must not have a selection.", f.name, t)
+			return out
+		}
+		for i, v := range values {
+			if isNil(v) {
+				continue
+			}
+			v = reflect.Indirect(reflect.ValueOf(v)).Interface()
+			var err error
+			switch t := t.(type) {
+			case *Scalar:
+				if t.Serialize == nil {
+					out[i] = v
+				} else {
+					out[i], err = t.Serialize(v)
+				}
+			case *Enum:
+				s, _ := textValue(v)
+				if !t.has(s) {
+					err = fmt.Errorf("Enum %s cannot represent %v", t.Name, v)
+				}
+				out[i] = s
+			}
+			if err != nil {
+				out[i] = nil
+				e.fail(paths[i], f.loc, "%s", err)
+			}
+		}
+		return out
+
+	case *List:
+		// Flatten every list so the items are completed together
+		var items []interface{}
+		var itemPaths [][]interface{}
+		lengths := make([]int, len(values))
+		for i, v := range values {
+			lengths[i] = -1
+			if isNil(v) {
+				continue
+			}
+			rv := reflect.ValueOf(v)
+			if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+				e.fail(paths[i], f.loc, "Expected a list for field %s, got %T.", f.name, v)
+				continue
+			}
+			lengths[i] = rv.Len()
+			for j := 0; j < rv.Len(); j++ {
+				items = append(items, rv.Index(j).Interface())
+				itemPaths = append(itemPaths, append(append([]interface{}(nil), paths[i]...), j))
+			}
+		}
+		completed := e.complete(ctx, t.Of, fields, items, itemPaths)
+		for i, n := range lengths {
+			if n < 0 {
+				continue
+			}
+			list := make([]interface{}, n)
+			copy(list, completed[:n])
+			completed = completed[n:]
+			out[i] = list
+		}
+		return out
+
+	case *Object:
+		var selections []selection
+		for _, f := range fields {
+			selections = append(selections, f.selections...)
+		}
+		if len(selections) == 0 {
+			e.fail(nil, f.loc, "Field %q of type %s must have a selection of subfields.", f.name, t.Name)
+			return out
+		}
+
+		var sources []interface{}
+		var sourcePaths [][]interface{}
+		var index []int
+		for i, v := range values {
+			if isNil(v) {
+				continue
+			}
+			sources = append(sources, v)
+			sourcePaths = append(sourcePaths, paths[i])
+			index = append(index, i)
+		}
+		if len(sources) == 0 {
+			return out
+		}
+		for j, r := range e.executeFields(ctx, t, sources, selections, sourcePaths) {
+			out[index[j]] = r
+		}
+		return out
+	}
+
+	e.fail(nil, f.loc, "Field %q has the input type %s.", f.name, t)
+	return out
+}
+
+// failedAt reports whether an error was already recorded at path or below.
+func (e *executor) failedAt(path []interface{}) bool {
+	for _, err := range e.errors {
+		if len(err.Path) < len(path) {
+			continue
+		}
+		match := true
+		for i := range path {
+			if err.Path[i] != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
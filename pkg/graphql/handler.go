@@ -0,0 +1,70 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Handler serves a schema over HTTP. Queries are accepted as GET requests
+// with query, operationName and variables parameters; queries and
+// mutations as POST requests with a JSON body.
+func Handler(s *Schema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		switch r.Method {
+		case http.MethodGet:
+			q := r.URL.Query()
+			req.Query = q.Get("query")
+			req.OperationName = q.Get("operationName")
+			if vars := q.Get("variables"); vars != "" {
+				if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+					writeResponse(w, http.StatusBadRequest, errorResponse(&Error{Message: "Invalid variables: " + err.Error()}))
+					return
+				}
+			}
+
+		case http.MethodPost:
+			dec := json.NewDecoder(r.Body)
+			dec.UseNumber()
+			if err := dec.Decode(&req); err != nil {
+				writeResponse(w, http.StatusBadRequest, errorResponse(&Error{Message: "Invalid request body: " + err.Error()}))
+				return
+			}
+
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeResponse(w, http.StatusMethodNotAllowed, errorResponse(&Error{Message: "GraphQL requests must use GET or POST"}))
+			return
+		}
+
+		if req.Query == "" {
+			writeResponse(w, http.StatusBadRequest, errorResponse(&Error{Message: "Missing query"}))
+			return
+		}
+
+		// Mutations over GET could be triggered by links and prefetching
+		if r.Method == http.MethodGet {
+			if doc, err := parseDocument(req.Query); err == nil {
+				if op, err := selectOperation(doc, req.OperationName); err == nil && op.kind != "query" {
+					writeResponse(w, http.StatusMethodNotAllowed, errorResponse(&Error{Message: "Mutations must use POST"}))
+					return
+				}
+			}
+		}
+
+		resp := s.Execute(r.Context(), req)
+		status := http.StatusOK
+		if resp.Data == nil {
+			status = http.StatusBadRequest
+		}
+		writeResponse(w, status, resp)
+	})
+}
+
+func writeResponse(w http.ResponseWriter, status int, resp *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(resp)
+}
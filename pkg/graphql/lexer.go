@@ -0,0 +1,268 @@
+// Package graphql provides a small GraphQL runtime for generated Nexus APIs:
+// a schema defined in SDL, resolvers bound by type and field name, batched
+// execution and an HTTP handler.
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind identifies the kind of a lexical token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// token is a lexical token of a GraphQL document.
+type token struct {
+	kind  tokenKind
+	value string // Punctuator, name, number, or the decoded string
+	loc   Location
+}
+
+// Location is a position in a GraphQL document; line and column start at 1.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// lexer splits a GraphQL document into tokens.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+// syntaxError reports a malformed document.
+func syntaxError(loc Location, format string, args ...interface{}) *Error {
+	return &Error{
+		Message:   "Syntax Error: " + fmt.Sprintf(format, args...),
+		Locations: []Location{loc},
+	}
+}
+
+func (l *lexer) advance(n int) {
+	for _, r := range l.src[l.pos : l.pos+n] {
+		if r == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+	}
+	l.pos += n
+}
+
+// skipIgnored skips whitespace, commas and comments.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				end = len(l.src) - l.pos
+			}
+			l.advance(end)
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"): // Byte order mark
+			l.advance(len("\uFEFF"))
+		default:
+			return
+		}
+	}
+}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	loc := Location{Line: l.line, Column: l.col}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, loc: loc}, nil
+	}
+
+	rest := l.src[l.pos:]
+	c := rest[0]
+	switch {
+	case strings.HasPrefix(rest, "..."):
+		l.advance(3)
+		return token{kind: tokenPunct, value: "...", loc: loc}, nil
+
+	case strings.IndexByte("!$&()/:=@[]{}|", c) >= 0:
+		l.advance(1)
+		return token{kind: tokenPunct, value: string(c), loc: loc}, nil
+
+	case c == '_' || isLetter(c):
+		n := 1
+		for n < len(rest) && (rest[n] == '_' || isLetter(rest[n]) || isDigit(rest[n])) {
+			n++
+		}
+		l.advance(n)
+		return token{kind: tokenName, value: rest[:n], loc: loc}, nil
+
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+
+	case strings.HasPrefix(rest, `"""`):
+		return l.blockString(loc)
+
+	case c == '"':
+		return l.string(loc)
+	}
+
+	r, _ := utf8.DecodeRuneInString(rest)
+	return token{}, syntaxError(loc, "Unexpected character %q.", r)
+}
+
+func (l *lexer) number(loc Location) (token, error) {
+	rest := l.src[l.pos:]
+	n := 0
+	if rest[n] == '-' {
+		n++
+	}
+	digits := func() int {
+		start := n
+		for n < len(rest) && isDigit(rest[n]) {
+			n++
+		}
+		return n - start
+	}
+	if digits() == 0 {
+		return token{}, syntaxError(loc, "Invalid number %q.", rest[:n])
+	}
+
+	kind := tokenInt
+	if n < len(rest) && rest[n] == '.' {
+		n++
+		kind = tokenFloat
+		if digits() == 0 {
+			return token{}, syntaxError(loc, "Invalid number %q.", rest[:n])
+		}
+	}
+	if n < len(rest) && (rest[n] == 'e' || rest[n] == 'E') {
+		n++
+		kind = tokenFloat
+		if n < len(rest) && (rest[n] == '+' || rest[n] == '-') {
+			n++
+		}
+		if digits() == 0 {
+			return token{}, syntaxError(loc, "Invalid number %q.", rest[:n])
+		}
+	}
+	if n < len(rest) && (rest[n] == '_' || isLetter(rest[n]) || rest[n] == '.') {
+		return token{}, syntaxError(loc, "Invalid number %q.", rest[:n+1])
+	}
+	l.advance(n)
+	return token{kind: kind, value: rest[:n], loc: loc}, nil
+}
+
+func (l *lexer) string(loc Location) (token, error) {
+	var sb strings.Builder
+	rest := l.src[l.pos:]
+	for i := 1; i < len(rest); i++ {
+		switch rest[i] {
+		case '"':
+			l.advance(i + 1)
+			return token{kind: tokenString, value: sb.String(), loc: loc}, nil
+		case '\n', '\r':
+			return token{}, syntaxError(loc, "Unterminated string.")
+		case '\\':
+			i++
+			if i >= len(rest) {
+				return token{}, syntaxError(loc, "Unterminated string.")
+			}
+			switch rest[i] {
+			case '"', '\\', '/':
+				sb.WriteByte(rest[i])
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				var r rune
+				if i+4 >= len(rest) {
+					return token{}, syntaxError(loc, "Invalid unicode escape.")
+				}
+				if _, err := fmt.Sscanf(rest[i+1:i+5], "%04x", &r); err != nil {
+					return token{}, syntaxError(loc, "Invalid unicode escape.")
+				}
+				sb.WriteRune(r)
+				i += 4
+			default:
+				return token{}, syntaxError(loc, "Invalid escape sequence \\%c.", rest[i])
+			}
+		default:
+			sb.WriteByte(rest[i])
+		}
+	}
+	return token{}, syntaxError(loc, "Unterminated string.")
+}
+
+// blockString reads a """ string, removing common indentation.
+func (l *lexer) blockString(loc Location) (token, error) {
+	rest := l.src[l.pos+3:]
+	end := strings.Index(rest, `"""`)
+	for end > 0 && rest[end-1] == '\\' {
+		next := strings.Index(rest[end+3:], `"""`)
+		if next < 0 {
+			end = -1
+			break
+		}
+		end += 3 + next
+	}
+	if end < 0 {
+		return token{}, syntaxError(loc, "Unterminated string.")
+	}
+	raw := strings.ReplaceAll(rest[:end], `\"""`, `"""`)
+	l.advance(3 + end + 3)
+
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return token{kind: tokenString, value: strings.Join(lines, "\n"), loc: loc}, nil
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
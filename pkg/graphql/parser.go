@@ -0,0 +1,517 @@
+package graphql
+
+import (
+	"strconv"
+)
+
+// document is a parsed executable document: operations and fragments.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query or mutation
+	name       string
+	variables  []*variableDef
+	selections []selection
+	loc        Location
+}
+
+type variableDef struct {
+	name       string
+	typ        *typeRef
+	defaultVal *value
+	loc        Location
+}
+
+// typeRef is a type as written in a document, e.g. [Int!]!.
+type typeRef struct {
+	name    string
+	elem    *typeRef // Set for lists
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment.
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	args       []*argument
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+// key returns the name of the field in the response.
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        Location
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+}
+
+type argument struct {
+	name string
+	val  *value
+}
+
+type directive struct {
+	name string
+	args []*argument
+}
+
+// valueKind identifies the kind of a literal value.
+type valueKind int
+
+const (
+	valueVariable valueKind = iota
+	valueInt
+	valueFloat
+	valueString
+	valueBoolean
+	valueNull
+	valueEnum
+	valueList
+	valueObject
+)
+
+// value is a literal input value or a variable reference.
+type value struct {
+	kind   valueKind
+	raw    string // Variable name, number, string, boolean or enum value
+	list   []*value
+	fields []*argument
+	loc    Location
+}
+
+// parser is a recursive descent parser for documents and SDL.
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func newParser(src string) (*parser, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// peek reports whether the current token is the punctuator or keyword s.
+func (p *parser) peek(s string) bool {
+	return (p.tok.kind == tokenPunct || p.tok.kind == tokenName) && p.tok.value == s
+}
+
+// skip consumes the current token if it is s.
+func (p *parser) skip(s string) (bool, error) {
+	if !p.peek(s) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expect(s string) error {
+	if !p.peek(s) {
+		return p.unexpected("Expected %q", s)
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected("Expected a name")
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) unexpected(format string, args ...interface{}) *Error {
+	found := "<EOF>"
+	if p.tok.kind != tokenEOF {
+		found = strconv.Quote(p.tok.value)
+	}
+	args = append(args, found)
+	return syntaxError(p.tok.loc, format+", found %s.", args...)
+}
+
+// parseDocument parses an executable document.
+func parseDocument(src string) (*document, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections})
+
+		case p.peek("query") || p.peek("mutation") || p.peek("subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+
+		case p.peek("fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			doc.fragments[f.name] = f
+
+		default:
+			return nil, p.unexpected("Expected an operation or fragment")
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, &Error{Message: "Document has no operations"}
+	}
+	return doc, nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.value, loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(")") {
+			def := &variableDef{loc: p.tok.loc}
+			if err := p.expect("$"); err != nil {
+				return nil, err
+			}
+			if def.name, err = p.name(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if def.typ, err = p.typeRef(); err != nil {
+				return nil, err
+			}
+			if ok, err := p.skip("="); err != nil {
+				return nil, err
+			} else if ok {
+				if def.defaultVal, err = p.value(true); err != nil {
+					return nil, err
+				}
+			}
+			op.variables = append(op.variables, def)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	f := &fragment{}
+	var err error
+	if f.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("on"); err != nil {
+		return nil, err
+	}
+	if f.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if f.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !p.peek("}") {
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+	if len(selections) == 0 {
+		return nil, p.unexpected("Expected a field")
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (selection, error) {
+	loc := p.tok.loc
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			spread := &fragmentSpread{name: p.tok.value, loc: loc}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if spread.directives, err = p.directives(); err != nil {
+				return nil, err
+			}
+			return spread, nil
+		}
+
+		inline := &inlineFragment{}
+		if ok, err := p.skip("on"); err != nil {
+			return nil, err
+		} else if ok {
+			if inline.typeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if inline.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if inline.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return inline, nil
+	}
+
+	f := &field{loc: loc}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	f.name = name
+
+	if f.args, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments(constant bool) ([]*argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+	var args []*argument
+	for !p.peek(")") {
+		arg, err := p.argument(constant)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, p.advance()
+}
+
+func (p *parser) argument(constant bool) (*argument, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	v, err := p.value(constant)
+	if err != nil {
+		return nil, err
+	}
+	return &argument{name: name, val: v}, nil
+}
+
+func (p *parser) directives() ([]*directive, error) {
+	var directives []*directive
+	for p.peek("@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments(false)
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &directive{name: name, args: args})
+	}
+	return directives, nil
+}
+
+// value parses a literal; constant values may not reference variables.
+func (p *parser) value(constant bool) (*value, error) {
+	v := &value{raw: p.tok.value, loc: p.tok.loc}
+	switch p.tok.kind {
+	case tokenInt:
+		v.kind = valueInt
+	case tokenFloat:
+		v.kind = valueFloat
+	case tokenString:
+		v.kind = valueString
+	case tokenName:
+		switch p.tok.value {
+		case "true", "false":
+			v.kind = valueBoolean
+		case "null":
+			v.kind = valueNull
+		default:
+			v.kind = valueEnum
+		}
+	case tokenPunct:
+		switch p.tok.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected("Unexpected variable")
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			v.kind, v.raw = valueVariable, name
+			return v, nil
+
+		case "[":
+			v.kind = valueList
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			for !p.peek("]") {
+				item, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				v.list = append(v.list, item)
+			}
+			return v, p.advance()
+
+		case "{":
+			v.kind = valueObject
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			for !p.peek("}") {
+				f, err := p.argument(constant)
+				if err != nil {
+					return nil, err
+				}
+				v.fields = append(v.fields, f)
+			}
+			return v, p.advance()
+		}
+		return nil, p.unexpected("Expected a value")
+	default:
+		return nil, p.unexpected("Expected a value")
+	}
+	return v, p.advance()
+}
+
+func (p *parser) typeRef() (*typeRef, error) {
+	t := &typeRef{}
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		if t.elem, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t.name = name
+	}
+	ok, err := p.skip("!")
+	t.nonNull = ok
+	return t, err
+}
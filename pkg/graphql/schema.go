@@ -0,0 +1,552 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type is a GraphQL type: *Scalar, *Enum, *Object, *InputObject, *List
+// or *NonNull.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Serialize converts resolved Go values to JSON
+// values; Parse converts input values (JSON or literals) to Go values.
+type Scalar struct {
+	Name        string
+	Description string
+	Serialize   func(v interface{}) (interface{}, error)
+	Parse       func(v interface{}) (interface{}, error)
+}
+
+func (t *Scalar) String() string { return t.Name }
+
+// Enum is a leaf type with a fixed set of values, represented as strings.
+type Enum struct {
+	Name        string
+	Description string
+	Values      []string
+}
+
+func (t *Enum) String() string { return t.Name }
+
+// has reports whether v is one of the enum values.
+func (t *Enum) has(v string) bool {
+	for _, value := range t.Values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Object is an output type with fields.
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+func (t *Object) String() string { return t.Name }
+
+// Field returns the field called name, or nil.
+func (t *Object) Field(name string) *Field {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// ResolveFunc resolves a field for one parent value.
+type ResolveFunc func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// BatchFunc resolves a field for all parent values at the same depth of a
+// query at once, returning one value per source. It is how relations avoid
+// one query per record.
+type BatchFunc func(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error)
+
+// Field is a field of an object type. Without Resolve or Batch, the value
+// is read from the parent: map keys, or struct fields by json tag or name.
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*InputValue
+	Resolve     ResolveFunc
+	Batch       BatchFunc
+}
+
+// InputValue is an argument or a field of an input object.
+type InputValue struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     interface{} // Coerced default, or nil
+	HasDefault  bool
+	defaultLit  *value
+}
+
+// InputObject is an input type with fields.
+type InputObject struct {
+	Name        string
+	Description string
+	Fields      []*InputValue
+}
+
+func (t *InputObject) String() string { return t.Name }
+
+// List is a list of another type.
+type List struct {
+	Of Type
+}
+
+func (t *List) String() string { return "[" + t.Of.String() + "]" }
+
+// NonNull marks a type as never null.
+type NonNull struct {
+	Of Type
+}
+
+func (t *NonNull) String() string { return t.Of.String() + "!" }
+
+// Schema is an executable GraphQL schema.
+type Schema struct {
+	Query    *Object
+	Mutation *Object
+	types    map[string]Type
+	order    []string // Type names in definition order
+}
+
+// Type returns the named type, or nil.
+func (s *Schema) Type(name string) Type {
+	return s.types[name]
+}
+
+// Resolve binds fn to a field. It panics if the field does not exist,
+// since bindings are fixed at build time.
+func (s *Schema) Resolve(typeName, fieldName string, fn ResolveFunc) {
+	s.field(typeName, fieldName).Resolve = fn
+}
+
+// Batch binds a batch resolver to a field; see BatchFunc.
+func (s *Schema) Batch(typeName, fieldName string, fn BatchFunc) {
+	s.field(typeName, fieldName).Batch = fn
+}
+
+func (s *Schema) field(typeName, fieldName string) *Field {
+	obj, ok := s.types[typeName].(*Object)
+	if !ok {
+		panic(fmt.Sprintf("graphql: no object type %s", typeName))
+	}
+	f := obj.Field(fieldName)
+	if f == nil {
+		panic(fmt.Sprintf("graphql: type %s has no field %s", typeName, fieldName))
+	}
+	return f
+}
+
+// MustParseSchema is like ParseSchema but panics on errors. It is meant
+// for schemas embedded in generated code.
+func MustParseSchema(sdl string) *Schema {
+	s, err := ParseSchema(sdl)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ParseSchema builds a schema from SDL type definitions. The built-in
+// scalars and DateTime and JSON are predefined; other scalars pass values
+// through unchanged. Resolvers are bound afterwards with Resolve and Batch.
+//
+// Example:
+//
+//	s, err := graphql.ParseSchema(`type Query { hello: String }`)
+//	s.Resolve("Query", "hello", func(ctx context.Context, _ interface{}, _ map[string]interface{}) (interface{}, error) {
+//		return "world", nil
+//	})
+func ParseSchema(sdl string) (*Schema, error) {
+	p, err := newParser(sdl)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Schema{types: make(map[string]Type)}
+	for _, scalar := range []*Scalar{Int, Float, String, Boolean, ID, DateTime, JSON} {
+		s.types[scalar.Name] = scalar
+	}
+
+	// Definitions refer to each other, so types are resolved after parsing
+	type pendingField struct {
+		field *Field
+		typ   *typeRef
+	}
+	type pendingInput struct {
+		input *InputValue
+		typ   *typeRef
+	}
+	var fields []pendingField
+	var inputs []pendingInput
+	rootNames := map[string]string{"query": "Query", "mutation": "Mutation"}
+
+	define := func(name string, t Type, loc Location) error {
+		if existing, ok := s.types[name]; ok {
+			if _, builtin := existing.(*Scalar); builtin {
+				if _, redeclared := t.(*Scalar); redeclared {
+					s.order = append(s.order, name) // scalar DateTime
+					return nil
+				}
+			}
+			return syntaxError(loc, "Type %s is defined more than once.", name)
+		}
+		s.types[name] = t
+		s.order = append(s.order, name)
+		return nil
+	}
+
+	inputValues := func() ([]*InputValue, error) {
+		var values []*InputValue
+		for !p.peek(")") && !p.peek("}") {
+			v := &InputValue{}
+			if p.tok.kind == tokenString {
+				v.Description = p.tok.value
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			v.Name = name
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			ref, err := p.typeRef()
+			if err != nil {
+				return nil, err
+			}
+			if ok, err := p.skip("="); err != nil {
+				return nil, err
+			} else if ok {
+				if v.defaultLit, err = p.value(true); err != nil {
+					return nil, err
+				}
+				v.HasDefault = true
+			}
+			inputs = append(inputs, pendingInput{v, ref})
+			values = append(values, v)
+		}
+		return values, p.advance()
+	}
+
+	for p.tok.kind != tokenEOF {
+		var description string
+		if p.tok.kind == tokenString {
+			description = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		loc := p.tok.loc
+		keyword, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		switch keyword {
+		case "schema":
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			for !p.peek("}") {
+				op, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if rootNames[op], err = p.name(); err != nil {
+					return nil, err
+				}
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+
+		case "scalar":
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := define(name, &Scalar{Name: name, Description: description}, loc); err != nil {
+				return nil, err
+			}
+
+		case "enum":
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			e := &Enum{Name: name, Description: description}
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			for !p.peek("}") {
+				if p.tok.kind == tokenString {
+					if err := p.advance(); err != nil {
+						return nil, err
+					}
+				}
+				v, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				e.Values = append(e.Values, v)
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if err := define(name, e, loc); err != nil {
+				return nil, err
+			}
+
+		case "input":
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			values, err := inputValues()
+			if err != nil {
+				return nil, err
+			}
+			if err := define(name, &InputObject{Name: name, Description: description, Fields: values}, loc); err != nil {
+				return nil, err
+			}
+
+		case "type":
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			obj := &Object{Name: name, Description: description}
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			for !p.peek("}") {
+				f := &Field{}
+				if p.tok.kind == tokenString {
+					f.Description = p.tok.value
+					if err := p.advance(); err != nil {
+						return nil, err
+					}
+				}
+				if f.Name, err = p.name(); err != nil {
+					return nil, err
+				}
+				if ok, err := p.skip("("); err != nil {
+					return nil, err
+				} else if ok {
+					if f.Args, err = inputValues(); err != nil {
+						return nil, err
+					}
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				ref, err := p.typeRef()
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, pendingField{f, ref})
+				obj.Fields = append(obj.Fields, f)
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if err := define(name, obj, loc); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, syntaxError(loc, "Unexpected %q; expected a type definition.", keyword)
+		}
+	}
+
+	for _, pf := range fields {
+		t, err := s.resolveType(pf.typ)
+		if err != nil {
+			return nil, err
+		}
+		if _, isInput := namedType(t).(*InputObject); isInput {
+			return nil, &Error{Message: fmt.Sprintf("Field %s cannot have input type %s.", pf.field.Name, t)}
+		}
+		pf.field.Type = t
+	}
+	for _, pi := range inputs {
+		t, err := s.resolveType(pi.typ)
+		if err != nil {
+			return nil, err
+		}
+		if _, isObject := namedType(t).(*Object); isObject {
+			return nil, &Error{Message: fmt.Sprintf("Argument %s cannot have output type %s.", pi.input.Name, t)}
+		}
+		pi.input.Type = t
+	}
+	for _, pi := range inputs {
+		if pi.input.defaultLit != nil {
+			v, err := coerceLiteral(pi.input.Type, pi.input.defaultLit, nil)
+			if err != nil {
+				return nil, &Error{Message: fmt.Sprintf("Invalid default for %s: %s", pi.input.Name, err)}
+			}
+			pi.input.Default = v
+		}
+	}
+
+	var ok bool
+	if s.Query, ok = s.types[rootNames["query"]].(*Object); !ok {
+		return nil, &Error{Message: "Schema has no Query type"}
+	}
+	if t, exists := s.types[rootNames["mutation"]]; exists {
+		if s.Mutation, ok = t.(*Object); !ok {
+			return nil, &Error{Message: "Mutation root is not an object type"}
+		}
+	}
+	return s, nil
+}
+
+// literalSDL prints a literal value as it would be written in SDL.
+func literalSDL(v *value) string {
+	switch v.kind {
+	case valueString:
+		return strconv.Quote(v.raw)
+	case valueList:
+		items := make([]string, len(v.list))
+		for i, item := range v.list {
+			items[i] = literalSDL(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case valueObject:
+		fields := make([]string, len(v.fields))
+		for i, f := range v.fields {
+			fields[i] = f.name + ": " + literalSDL(f.val)
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case valueVariable:
+		return "$" + v.raw
+	}
+	return v.raw
+}
+
+func (s *Schema) resolveType(ref *typeRef) (Type, error) {
+	var t Type
+	if ref.elem != nil {
+		elem, err := s.resolveType(ref.elem)
+		if err != nil {
+			return nil, err
+		}
+		t = &List{Of: elem}
+	} else {
+		var ok bool
+		if t, ok = s.types[ref.name]; !ok {
+			return nil, &Error{Message: fmt.Sprintf("Unknown type %q.", ref.name)}
+		}
+	}
+	if ref.nonNull {
+		t = &NonNull{Of: t}
+	}
+	return t, nil
+}
+
+// namedType strips List and NonNull wrappers.
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.Of
+		case *NonNull:
+			t = w.Of
+		default:
+			return t
+		}
+	}
+}
+
+// String returns the schema in SDL, in definition order.
+func (s *Schema) String() string {
+	var sb strings.Builder
+	for _, name := range s.order {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		switch t := s.types[name].(type) {
+		case *Scalar:
+			writeDescription(&sb, "", t.Description)
+			fmt.Fprintf(&sb, "scalar %s\n", t.Name)
+		case *Enum:
+			writeDescription(&sb, "", t.Description)
+			fmt.Fprintf(&sb, "enum %s {\n", t.Name)
+			for _, v := range t.Values {
+				fmt.Fprintf(&sb, "  %s\n", v)
+			}
+			sb.WriteString("}\n")
+		case *InputObject:
+			writeDescription(&sb, "", t.Description)
+			fmt.Fprintf(&sb, "input %s {\n", t.Name)
+			for _, f := range t.Fields {
+				writeDescription(&sb, "  ", f.Description)
+				fmt.Fprintf(&sb, "  %s\n", inputValueSDL(f))
+			}
+			sb.WriteString("}\n")
+		case *Object:
+			writeDescription(&sb, "", t.Description)
+			fmt.Fprintf(&sb, "type %s {\n", t.Name)
+			for _, f := range t.Fields {
+				writeDescription(&sb, "  ", f.Description)
+				args := ""
+				if len(f.Args) > 0 {
+					parts := make([]string, len(f.Args))
+					for i, a := range f.Args {
+						parts[i] = inputValueSDL(a)
+					}
+					args = "(" + strings.Join(parts, ", ") + ")"
+				}
+				fmt.Fprintf(&sb, "  %s%s: %s\n", f.Name, args, f.Type)
+			}
+			sb.WriteString("}\n")
+		}
+	}
+	return sb.String()
+}
+
+func inputValueSDL(v *InputValue) string {
+	s := v.Name + ": " + v.Type.String()
+	if v.HasDefault {
+		s += " = " + literalSDL(v.defaultLit)
+	}
+	return s
+}
+
+func writeDescription(sb *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	if !strings.Contains(description, "\n") && !strings.Contains(description, `"`) {
+		fmt.Fprintf(sb, "%s\"%s\"\n", indent, description)
+		return
+	}
+	fmt.Fprintf(sb, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(description, "\n") {
+		fmt.Fprintf(sb, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(sb, "%s\"\"\"\n", indent)
+}
@@ -0,0 +1,362 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Built-in scalars. DateTime values are RFC 3339 strings; JSON values are
+// any JSON, passed to resolvers as json.RawMessage.
+var (
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(v interface{}) (interface{}, error) {
+			rv := reflect.ValueOf(v)
+			switch {
+			case rv.CanInt():
+				return rv.Int(), nil
+			case rv.CanUint():
+				return int64(rv.Uint()), nil
+			case rv.CanFloat() && rv.Float() == math.Trunc(rv.Float()):
+				return int64(rv.Float()), nil
+			}
+			return nil, fmt.Errorf("Int cannot represent %v", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if n, ok := v.(json.Number); ok {
+				if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+					return int(i), nil
+				}
+				if f, err := n.Float64(); err == nil && f == math.Trunc(f) {
+					return int(f), nil
+				}
+			}
+			switch n := v.(type) {
+			case int:
+				return n, nil
+			case int64:
+				return int(n), nil
+			case float64:
+				if n == math.Trunc(n) {
+					return int(n), nil
+				}
+			}
+			return nil, fmt.Errorf("Int cannot represent %s", describe(v))
+		},
+	}
+
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(v interface{}) (interface{}, error) {
+			rv := reflect.ValueOf(v)
+			switch {
+			case rv.CanFloat():
+				return rv.Float(), nil
+			case rv.CanInt():
+				return float64(rv.Int()), nil
+			case rv.CanUint():
+				return float64(rv.Uint()), nil
+			}
+			if s, ok := textValue(v); ok {
+				// Drivers return DECIMAL as text
+				return strconv.ParseFloat(s, 64)
+			}
+			return nil, fmt.Errorf("Float cannot represent %v", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			switch n := v.(type) {
+			case json.Number:
+				return n.Float64()
+			case float64:
+				return n, nil
+			case int:
+				return float64(n), nil
+			}
+			return nil, fmt.Errorf("Float cannot represent %s", describe(v))
+		},
+	}
+
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(v interface{}) (interface{}, error) {
+			if s, ok := textValue(v); ok {
+				return s, nil
+			}
+			return fmt.Sprint(v), nil
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("String cannot represent %s", describe(v))
+		},
+	}
+
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(v interface{}) (interface{}, error) {
+			rv := reflect.ValueOf(v)
+			switch {
+			case rv.Kind() == reflect.Bool:
+				return rv.Bool(), nil
+			case rv.CanInt():
+				// SQLite and MySQL store booleans as integers
+				return rv.Int() != 0, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %v", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent %s", describe(v))
+		},
+	}
+
+	ID = &Scalar{
+		Name: "ID",
+		Serialize: func(v interface{}) (interface{}, error) {
+			if s, ok := textValue(v); ok {
+				return s, nil
+			}
+			return fmt.Sprint(v), nil
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			switch id := v.(type) {
+			case string:
+				return id, nil
+			case json.Number:
+				if _, err := id.Int64(); err == nil {
+					return string(id), nil
+				}
+			}
+			return nil, fmt.Errorf("ID cannot represent %s", describe(v))
+		},
+	}
+
+	DateTime = &Scalar{
+		Name:        "DateTime",
+		Description: "An RFC 3339 timestamp.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			switch t := v.(type) {
+			case time.Time:
+				return t.Format(time.RFC3339Nano), nil
+			case string:
+				return t, nil
+			case []byte:
+				return string(t), nil
+			}
+			return nil, fmt.Errorf("DateTime cannot represent %v", v)
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					return t, nil
+				}
+			}
+			return nil, fmt.Errorf("DateTime cannot represent %s; expected an RFC 3339 timestamp", describe(v))
+		},
+	}
+
+	JSON = &Scalar{
+		Name:        "JSON",
+		Description: "Any JSON value.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			switch raw := v.(type) {
+			case json.RawMessage:
+				if len(raw) == 0 {
+					return nil, nil
+				}
+				return raw, nil
+			case []byte:
+				if !json.Valid(raw) {
+					return nil, fmt.Errorf("JSON cannot represent %q", raw)
+				}
+				return json.RawMessage(raw), nil
+			case string:
+				if !json.Valid([]byte(raw)) {
+					return nil, fmt.Errorf("JSON cannot represent %q", raw)
+				}
+				return json.RawMessage(raw), nil
+			}
+			return v, nil
+		},
+		Parse: func(v interface{}) (interface{}, error) {
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			return json.RawMessage(raw), nil
+		},
+	}
+)
+
+// textValue returns strings and byte slices as a string.
+func textValue(v interface{}) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case []byte:
+		return string(s), true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.String {
+		return rv.String(), true
+	}
+	return "", false
+}
+
+// describe formats an input value for error messages.
+func describe(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// literalValue converts a literal to a Go value as if it had been decoded
+// from JSON; variables are looked up in vars.
+func literalValue(v *value, vars map[string]interface{}) (interface{}, bool) {
+	switch v.kind {
+	case valueVariable:
+		val, ok := vars[v.raw]
+		return val, ok
+	case valueInt, valueFloat:
+		return json.Number(v.raw), true
+	case valueString, valueEnum:
+		return v.raw, true
+	case valueBoolean:
+		return v.raw == "true", true
+	case valueList:
+		list := make([]interface{}, 0, len(v.list))
+		for _, item := range v.list {
+			val, ok := literalValue(item, vars)
+			if !ok {
+				val = nil // Missing variables in lists are null
+			}
+			list = append(list, val)
+		}
+		return list, true
+	case valueObject:
+		obj := make(map[string]interface{}, len(v.fields))
+		for _, f := range v.fields {
+			if val, ok := literalValue(f.val, vars); ok {
+				obj[f.name] = val
+			}
+		}
+		return obj, true
+	}
+	return nil, true // null
+}
+
+// coerceLiteral coerces a literal to an input type.
+func coerceLiteral(t Type, v *value, vars map[string]interface{}) (interface{}, error) {
+	val, _ := literalValue(v, vars)
+	if v.kind == valueString {
+		if e, ok := namedType(t).(*Enum); ok {
+			return nil, fmt.Errorf("Enum %s cannot represent the string %q; use the bare value", e.Name, v.raw)
+		}
+	}
+	return coerceInput(t, val)
+}
+
+// coerceInput coerces a JSON-like value (from variables or literals) to an
+// input type. Input objects become maps holding only the fields that were
+// given or have defaults, so absent and null fields can be told apart.
+func coerceInput(t Type, v interface{}) (interface{}, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("Expected a non-null %s", nn.Of)
+		}
+		return coerceInput(nn.Of, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *Scalar:
+		if t.Parse == nil {
+			return v, nil
+		}
+		return t.Parse(v)
+
+	case *Enum:
+		s, ok := v.(string)
+		if !ok || !t.has(s) {
+			return nil, fmt.Errorf("Enum %s has no value %s", t.Name, describe(v))
+		}
+		return s, nil
+
+	case *List:
+		items, ok := v.([]interface{})
+		if !ok {
+			// A single value is a list of one
+			item, err := coerceInput(t.Of, v)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			val, err := coerceInput(t.Of, item)
+			if err != nil {
+				return nil, fmt.Errorf("In item %d: %w", i, err)
+			}
+			list[i] = val
+		}
+		return list, nil
+
+	case *InputObject:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected an object for %s, got %s", t.Name, describe(v))
+		}
+		return coerceFields(t.Fields, obj, func(name string) string { return "Field " + t.Name + "." + name })
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// coerceFields coerces the fields of an input object or field arguments;
+// label names a field in errors.
+func coerceFields(defs []*InputValue, given map[string]interface{}, label func(name string) string) (map[string]interface{}, error) {
+	for name := range given {
+		found := false
+		for _, def := range defs {
+			if def.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown %s", label(name))
+		}
+	}
+
+	out := make(map[string]interface{}, len(defs))
+	for _, def := range defs {
+		v, ok := given[def.Name]
+		if !ok {
+			if def.HasDefault {
+				out[def.Name] = def.Default
+			} else if _, required := def.Type.(*NonNull); required {
+				return nil, fmt.Errorf("%s of type %s is required", label(def.Name), def.Type)
+			}
+			continue
+		}
+		val, err := coerceInput(def.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", label(def.Name), err)
+		}
+		out[def.Name] = val
+	}
+	return out, nil
+}
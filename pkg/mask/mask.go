@@ -0,0 +1,176 @@
+// Package mask replaces personal data, the fields marked @pii, with fake
+// values: fake emails, phone numbers and names, or hashes for other
+// columns. Data dumps and samples, query results in masked mode and the
+// studio use it so that copies and screens of the data don't show it.
+package mask
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+var (
+	maskFirstNames = []string{
+		"Alex", "Blake", "Casey", "Dana", "Drew", "Eden", "Finley", "Harper",
+		"Jamie", "Jordan", "Kai", "Morgan", "Quinn", "Riley", "Robin", "Sage",
+	}
+	maskLastNames = []string{
+		"Adams", "Baker", "Carter", "Ellis", "Foster", "Gray", "Hayes", "Hughes",
+		"Lane", "Morris", "Parker", "Reed", "Shaw", "Stone", "Turner", "Walsh",
+	}
+)
+
+// Masker replaces values with fake ones derived from a keyed hash of the
+// value, so the same value masks to the same fake one for a Masker (and
+// joins on masked columns still match) while the key, random per Masker,
+// keeps them from being reversed by hashing guesses.
+type Masker struct {
+	key []byte
+}
+
+// New returns a Masker with a new random key.
+func New() *Masker {
+	key := make([]byte, 32)
+	rand.Read(key) // Never fails since Go 1.24
+	return &Masker{key: key}
+}
+
+// process masks the values of Value, with a key random per process.
+var process = New()
+
+// Value returns the fake value of v for f with a key random per process.
+func Value(f *schema.Field, v interface{}) interface{} {
+	return process.Value(f, v)
+}
+
+func (m *Masker) sum(v interface{}) []byte {
+	h := hmac.New(sha256.New, m.key)
+	if b, ok := v.([]byte); ok {
+		h.Write(b)
+	} else {
+		fmt.Fprint(h, v)
+	}
+	return h.Sum(nil)
+}
+
+// Value returns the fake value of v for f. NULL stays NULL.
+func (m *Masker) Value(f *schema.Field, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	sum := m.sum(v)
+	text := f.Type == schema.FieldTypeString || f.Type == schema.FieldTypeText
+
+	switch kind := Kind(f); {
+	case kind == "null":
+		return nil
+	case kind == "email" && text:
+		return fit(f, "user-"+hex.EncodeToString(sum[:5])+"@example.com")
+	case kind == "phone" && text:
+		n := binary.BigEndian.Uint32(sum)
+		return fit(f, fmt.Sprintf("+1 555 %03d %04d", n/10000%1000, n%10000))
+	case kind == "name" && text:
+		return fit(f, fakeName(f, sum))
+	case kind == "redact" && text:
+		return fit(f, "[redacted]")
+	}
+
+	switch f.Type {
+	case schema.FieldTypeString, schema.FieldTypeText:
+		return fit(f, hex.EncodeToString(sum[:16]))
+	case schema.FieldTypeUUID:
+		b := sum[:16]
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	case schema.FieldTypeInt:
+		return int64(binary.BigEndian.Uint32(sum) % 1_000_000_000)
+	case schema.FieldTypeBigInt:
+		return int64(binary.BigEndian.Uint64(sum) >> 1)
+	case schema.FieldTypeSmallInt, schema.FieldTypeTinyInt:
+		// Within the range of the smallest column
+		return int64(binary.BigEndian.Uint32(sum) % 100)
+	case schema.FieldTypeFloat, schema.FieldTypeDecimal, schema.FieldTypeMoney:
+		return float64(binary.BigEndian.Uint32(sum) % 100_000)
+	case schema.FieldTypeDate, schema.FieldTypeDateTime:
+		// Keep the year only
+		switch t := v.(type) {
+		case time.Time:
+			return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+		case string:
+			if len(t) >= 4 {
+				if year, err := strconv.Atoi(t[:4]); err == nil {
+					return fmt.Sprintf("%04d-01-01", year)
+				}
+			}
+		}
+	case schema.FieldTypeJSON:
+		return "{}"
+	case schema.FieldTypeEnum:
+		if f.Enum != nil && len(f.Enum.Values) > 0 {
+			return f.Enum.Values[int(sum[0])%len(f.Enum.Values)]
+		}
+	}
+	if f.Nullable {
+		return nil
+	}
+	return v
+}
+
+// Kind returns the mask of f, one of schema.PIIMasks: the one of @pii, or
+// one inferred from its name and type.
+func Kind(f *schema.Field) string {
+	if f.PIIMask != "" {
+		return f.PIIMask
+	}
+	name := strings.ToLower(f.Name)
+	switch {
+	case f.IsEmail || strings.Contains(name, "email"):
+		return "email"
+	case strings.Contains(name, "phone") || strings.Contains(name, "mobile"):
+		return "phone"
+	case strings.Contains(name, "name"):
+		return "name"
+	case f.Type == schema.FieldTypeText:
+		return "redact"
+	}
+	return "hash"
+}
+
+// fakeName returns a first, last or full name depending on the field name.
+// Unique fields get a suffix so different values stay different.
+func fakeName(f *schema.Field, sum []byte) string {
+	first := maskFirstNames[int(sum[0])%len(maskFirstNames)]
+	last := maskLastNames[int(sum[1])%len(maskLastNames)]
+	name := strings.ToLower(f.Name)
+	var s string
+	switch {
+	case strings.Contains(name, "first") || strings.Contains(name, "given"):
+		s = first
+	case strings.Contains(name, "last") || strings.Contains(name, "surname") || strings.Contains(name, "family"):
+		s = last
+	default:
+		s = first + " " + last
+	}
+	if f.IsUnique {
+		s += " " + hex.EncodeToString(sum[2:6])
+	}
+	return s
+}
+
+// fit truncates s to the length of the column.
+func fit(f *schema.Field, s string) string {
+	if f.Type == schema.FieldTypeString && f.Length > 0 && len(s) > f.Length {
+		return s[:f.Length]
+	}
+	return s
+}
@@ -0,0 +1,167 @@
+// Package migrate applies migrations when an application starts. Auto
+// loads them from an fs.FS, typically embedded in the binary, and applies
+// those pending under the migration lock, so that replicas starting
+// together apply each migration once:
+//
+//	//go:embed migrations/*.sql
+//	var files embed.FS
+//
+//	migrations, _ := fs.Sub(files, "migrations")
+//	if _, err := migrate.Auto(ctx, conn, migrations, migrate.Options{FailOnDrift: true}); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Replicas that wait for the lock find the migrations applied once they
+// get it, and start without applying anything.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// DefaultLockTimeout is how long Auto waits for the lock held by another
+// replica unless Options.LockTimeout is set.
+const DefaultLockTimeout = 5 * time.Minute
+
+// ErrDrift is returned by Auto with FailOnDrift when applied migrations
+// were edited since they ran.
+var ErrDrift = errors.New("applied migrations were edited")
+
+// Phase is the step of Auto an Event reports.
+type Phase string
+
+const (
+	PhaseWaiting Phase = "waiting" // Another replica holds the lock
+	PhaseLocked  Phase = "locked"  // The lock was taken
+	PhaseDrift   Phase = "drift"   // Applied migrations were edited
+	PhasePending Phase = "pending" // Total migrations are about to be applied
+	PhaseApplied Phase = "applied" // Migration was applied
+	PhaseDone    Phase = "done"    // All pending migrations were applied
+)
+
+// Event reports the progress of Auto.
+type Event struct {
+	Phase     Phase
+	Holder    *migration.LockInfo  // PhaseWaiting: the replica holding the lock, if known
+	Drift     []migration.Drift    // PhaseDrift
+	Migration *migration.Migration // PhaseApplied
+	Index     int                  // PhaseApplied: 1-based position among the pending migrations
+	Total     int                  // PhasePending, PhaseApplied and PhaseDone: number of pending migrations
+	Elapsed   time.Duration        // PhaseApplied: of the migration; PhaseLocked and PhaseDone: since Auto started
+}
+
+// Options configures Auto.
+type Options struct {
+	// LockTimeout is how long to wait for another replica to release the
+	// lock (default DefaultLockTimeout).
+	LockTimeout time.Duration
+	// LockTTL is the lease of the lock, for backends that expire it
+	// (default migration.DefaultLockOptions).
+	LockTTL time.Duration
+	// Identifier names the replica in the lock (default the hostname,
+	// which is the pod name in Kubernetes).
+	Identifier string
+	// FailOnDrift refuses to migrate when applied migrations were edited
+	// since they ran. Without it, drift is logged and reported.
+	FailOnDrift bool
+	// Progress receives the events of Auto. It is called from the
+	// goroutine of Auto.
+	Progress func(Event)
+	// Logger receives the logs of the migration engine. Nil logs to the
+	// logger of the context, if any.
+	Logger nexuslog.Logger
+}
+
+// Result is what Auto did.
+type Result struct {
+	Applied []*migration.Migration // Migrations applied, in order
+	Drift   []migration.Drift      // Applied migrations edited since they ran
+	Elapsed time.Duration
+}
+
+// Auto applies the pending migrations of fsys to conn. It takes the
+// migration lock first, waiting up to opts.LockTimeout for other replicas,
+// and creates the migrations table under it.
+func Auto(ctx context.Context, conn *dialects.Connection, fsys fs.FS, opts Options) (*Result, error) {
+	start := time.Now()
+	if opts.LockTimeout == 0 {
+		opts.LockTimeout = DefaultLockTimeout
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(Event) {}
+	}
+
+	engine := migration.NewEngine(conn)
+	if opts.Logger != nil {
+		engine.WithLogger(opts.Logger)
+	}
+	if err := engine.LoadFromFS(fsys); err != nil {
+		return nil, fmt.Errorf("loading migrations: %w", err)
+	}
+
+	lock := migration.LockOptions{LockTTL: opts.LockTTL, Identifier: opts.Identifier}
+	if err := engine.AcquireLock(ctx, lock); err != nil {
+		// Taken by another replica, most likely: report it and wait
+		holder, _ := engine.GetLockInfo(ctx)
+		progress(Event{Phase: PhaseWaiting, Holder: holder})
+		lock.Timeout = opts.LockTimeout
+		if err := engine.AcquireLock(ctx, lock); err != nil {
+			return nil, fmt.Errorf("acquiring migration lock: %w", err)
+		}
+	}
+	defer engine.ReleaseLock(context.WithoutCancel(ctx))
+	progress(Event{Phase: PhaseLocked, Elapsed: time.Since(start)})
+
+	if err := engine.Init(ctx); err != nil {
+		return nil, fmt.Errorf("initializing migrations: %w", err)
+	}
+
+	result := &Result{}
+	drift, err := engine.Drifted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(drift) > 0 {
+		result.Drift = drift
+		progress(Event{Phase: PhaseDrift, Drift: drift})
+		ids := make([]string, len(drift))
+		for i, d := range drift {
+			ids[i] = d.ID
+		}
+		if opts.FailOnDrift {
+			return result, fmt.Errorf("%w: %s", ErrDrift, strings.Join(ids, ", "))
+		}
+		log := opts.Logger
+		if log == nil {
+			log = nexuslog.FromContext(ctx)
+		}
+		log.Warn("Applied migrations were edited", nexuslog.F("migrations", strings.Join(ids, ", ")))
+	}
+
+	pending, err := engine.Pending(ctx)
+	if err != nil {
+		return result, err
+	}
+	progress(Event{Phase: PhasePending, Total: len(pending)})
+	engine.OnMigration(func(e migration.MigrationEvent) {
+		result.Applied = append(result.Applied, e.Migration)
+		progress(Event{Phase: PhaseApplied, Migration: e.Migration, Index: len(result.Applied), Total: len(pending), Elapsed: e.Elapsed})
+	})
+	if _, err := engine.Up(ctx); err != nil {
+		return result, err
+	}
+
+	result.Elapsed = time.Since(start)
+	progress(Event{Phase: PhaseDone, Total: len(result.Applied), Elapsed: result.Elapsed})
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package nexuslog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Slog returns a logger writing to l, with fields as attributes.
+func Slog(l *slog.Logger) Logger {
+	return &slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s *slogLogger) Debug(msg string, fields ...Field) { s.log(slog.LevelDebug, msg, fields) }
+func (s *slogLogger) Info(msg string, fields ...Field)  { s.log(slog.LevelInfo, msg, fields) }
+func (s *slogLogger) Warn(msg string, fields ...Field)  { s.log(slog.LevelWarn, msg, fields) }
+func (s *slogLogger) Error(msg string, fields ...Field) { s.log(slog.LevelError, msg, fields) }
+
+func (s *slogLogger) log(level slog.Level, msg string, fields []Field) {
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		value := f.Value
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		attrs[i] = slog.Any(f.Key, value)
+	}
+	s.l.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// SugaredLogger is the part of *zap.SugaredLogger the Zap adapter uses,
+// so that Nexus doesn't depend on zap.
+type SugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// Zap returns a logger writing to a zap logger, given as its sugared
+// form:
+//
+//	logger := nexuslog.Zap(zapLogger.Sugar())
+func Zap(l SugaredLogger) Logger {
+	return &zapLogger{l: l}
+}
+
+type zapLogger struct {
+	l SugaredLogger
+}
+
+func (z *zapLogger) Debug(msg string, fields ...Field) { z.l.Debugw(msg, keysAndValues(fields)...) }
+func (z *zapLogger) Info(msg string, fields ...Field)  { z.l.Infow(msg, keysAndValues(fields)...) }
+func (z *zapLogger) Warn(msg string, fields ...Field)  { z.l.Warnw(msg, keysAndValues(fields)...) }
+func (z *zapLogger) Error(msg string, fields ...Field) { z.l.Errorw(msg, keysAndValues(fields)...) }
+
+// keysAndValues flattens fields to alternating keys and values.
+func keysAndValues(fields []Field) []interface{} {
+	kv := make([]interface{}, 0, 2*len(fields))
+	for _, f := range fields {
+		kv = append(kv, f.Key, f.Value)
+	}
+	return kv
+}
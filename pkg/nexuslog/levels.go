@@ -0,0 +1,51 @@
+package nexuslog
+
+// Subsystems that log through Nexus.
+const (
+	SubsystemMigrate = "migrate"
+	SubsystemSeed    = "seed"
+	SubsystemDev     = "dev"
+	SubsystemStudio  = "studio"
+)
+
+// Levels holds the minimum level of each subsystem.
+type Levels struct {
+	// Default applies to subsystems without a level of their own.
+	Default Level
+	// Subsystems maps subsystem names to their level.
+	Subsystems map[string]Level
+}
+
+// ParseLevels parses a default level name and subsystem level names.
+func ParseLevels(defaultLevel string, subsystems map[string]string) (Levels, error) {
+	def, err := ParseLevel(defaultLevel)
+	if err != nil {
+		return Levels{}, err
+	}
+	levels := Levels{Default: def, Subsystems: make(map[string]Level, len(subsystems))}
+	for name, value := range subsystems {
+		level, err := ParseLevel(value)
+		if err != nil {
+			return Levels{}, err
+		}
+		levels.Subsystems[name] = level
+	}
+	return levels, nil
+}
+
+// Level returns the level of subsystem.
+func (l Levels) Level(subsystem string) Level {
+	if level, ok := l.Subsystems[subsystem]; ok {
+		return level
+	}
+	return l.Default
+}
+
+// For returns the logger of subsystem: base filtered at its level, with a
+// "subsystem" field when base isn't a text logger.
+func (l Levels) For(base Logger, subsystem string) Logger {
+	if _, ok := base.(*text); !ok {
+		base = With(base, F("subsystem", subsystem))
+	}
+	return WithLevel(base, l.Level(subsystem))
+}
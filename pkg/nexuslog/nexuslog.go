@@ -0,0 +1,169 @@
+// Package nexuslog is the logging interface of Nexus. The migration and
+// seed engines, the dev watcher and the studio log through a Logger, which
+// can write text, or be backed by log/slog or zap with the adapters here.
+package nexuslog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the name of the level.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel parses a level name: debug, info, warn or error.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", name)
+}
+
+// Field is a key-value pair attached to a log entry.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F creates a field.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err creates an "error" field.
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// Logger writes structured log entries. Implementations must be safe for
+// concurrent use.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+// Log writes an entry to l at level.
+func Log(l Logger, level Level, msg string, fields ...Field) {
+	switch level {
+	case LevelDebug:
+		l.Debug(msg, fields...)
+	case LevelWarn:
+		l.Warn(msg, fields...)
+	case LevelError:
+		l.Error(msg, fields...)
+	default:
+		l.Info(msg, fields...)
+	}
+}
+
+// Nop returns a logger that discards every entry.
+func Nop() Logger {
+	return nop{}
+}
+
+type nop struct{}
+
+func (nop) Debug(string, ...Field) {}
+func (nop) Info(string, ...Field)  {}
+func (nop) Warn(string, ...Field)  {}
+func (nop) Error(string, ...Field) {}
+
+// With returns a logger adding fields to every entry of l.
+func With(l Logger, fields ...Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	return &withFields{l: l, fields: fields}
+}
+
+type withFields struct {
+	l      Logger
+	fields []Field
+}
+
+func (w *withFields) add(fields []Field) []Field {
+	return append(append(make([]Field, 0, len(w.fields)+len(fields)), w.fields...), fields...)
+}
+
+func (w *withFields) Debug(msg string, fields ...Field) { w.l.Debug(msg, w.add(fields)...) }
+func (w *withFields) Info(msg string, fields ...Field)  { w.l.Info(msg, w.add(fields)...) }
+func (w *withFields) Warn(msg string, fields ...Field)  { w.l.Warn(msg, w.add(fields)...) }
+func (w *withFields) Error(msg string, fields ...Field) { w.l.Error(msg, w.add(fields)...) }
+
+// WithLevel returns a logger dropping the entries of l below level.
+func WithLevel(l Logger, level Level) Logger {
+	return &leveled{l: l, level: level}
+}
+
+type leveled struct {
+	l     Logger
+	level Level
+}
+
+func (v *leveled) Debug(msg string, fields ...Field) {
+	if v.level <= LevelDebug {
+		v.l.Debug(msg, fields...)
+	}
+}
+
+func (v *leveled) Info(msg string, fields ...Field) {
+	if v.level <= LevelInfo {
+		v.l.Info(msg, fields...)
+	}
+}
+
+func (v *leveled) Warn(msg string, fields ...Field) {
+	if v.level <= LevelWarn {
+		v.l.Warn(msg, fields...)
+	}
+}
+
+func (v *leveled) Error(msg string, fields ...Field) {
+	v.l.Error(msg, fields...)
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying l.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger of ctx, or a Nop logger if it has none.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return l
+	}
+	return Nop()
+}
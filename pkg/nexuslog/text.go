@@ -0,0 +1,59 @@
+package nexuslog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewText returns a logger writing entries below each other as
+//
+//	[15:04:05] ⚠ Watcher error error="too many open files"
+//
+// with fields as key=value pairs, and warnings and errors marked.
+func NewText(w io.Writer) Logger {
+	return &text{w: w, now: time.Now}
+}
+
+type text struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+func (t *text) Debug(msg string, fields ...Field) { t.write("", msg, fields) }
+func (t *text) Info(msg string, fields ...Field)  { t.write("", msg, fields) }
+func (t *text) Warn(msg string, fields ...Field)  { t.write("⚠ ", msg, fields) }
+func (t *text) Error(msg string, fields ...Field) { t.write("❌ ", msg, fields) }
+
+func (t *text) write(mark, msg string, fields []Field) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s%s", t.now().Format("15:04:05"), mark, msg)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%s", f.Key, textValue(f.Value))
+	}
+	b.WriteByte('\n')
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	io.WriteString(t.w, b.String())
+}
+
+// textValue formats a field value, quoting it when it has spaces.
+func textValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case error:
+		s = v.Error()
+	case time.Duration:
+		s = v.Round(time.Millisecond).String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -0,0 +1,45 @@
+// Package nexustest provides test helpers for code using Nexus, such as
+// assertions on the query plans of queries, to catch plans that degrade
+// when the schema or the queries change.
+package nexustest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// Explainer is a query whose plan can be explained, such as a
+// *query.SelectBuilder or a *query.RawQuery.
+type Explainer interface {
+	Explain(ctx context.Context, opts ...query.ExplainOptions) (*query.QueryPlan, error)
+}
+
+// AssertUsesIndex fails the test unless the plan of q uses the index.
+//
+//	nexustest.AssertUsesIndex(t, users.Select().Where(query.Eq("email", email)), "idx_users_email")
+func AssertUsesIndex(t testing.TB, q Explainer, index string) {
+	t.Helper()
+	AssertPlan(t, q, query.PlanCheck{UsesIndex: []string{index}})
+}
+
+// AssertNoSeqScan fails the test if a step of the plan of q scans a whole
+// table.
+func AssertNoSeqScan(t testing.TB, q Explainer) {
+	t.Helper()
+	AssertPlan(t, q, query.PlanCheck{NoSeqScan: true})
+}
+
+// AssertPlan fails the test unless the plan of q satisfies check.
+func AssertPlan(t testing.TB, q Explainer, check query.PlanCheck) {
+	t.Helper()
+	plan, err := q.Explain(context.Background())
+	if err != nil {
+		t.Fatalf("explaining query: %v", err)
+	}
+	if problems := plan.Check(check); len(problems) > 0 {
+		t.Errorf("query plan: %s\n%s", strings.Join(problems, "; "), plan.Raw)
+	}
+}
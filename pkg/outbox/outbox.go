@@ -0,0 +1,196 @@
+// Package outbox publishes events with the transactional outbox pattern:
+// Emit writes an event to an outbox table in the transaction that changes
+// the data, and a Relay delivers committed events to a message broker,
+// marking each published only once the broker accepted it. Delivery is at
+// least once; consumers should ignore events they have seen.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/internal/dbtime"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// DefaultTable is the outbox table unless Options.Table is set.
+const DefaultTable = "nexus_outbox"
+
+// Event is a row of the outbox table.
+type Event struct {
+	ID        int64 // Increases in commit order within one writer
+	Topic     string
+	Payload   json.RawMessage
+	Attempts  int    // Failed deliveries so far
+	LastError string // Error of the last failed delivery
+	CreatedAt time.Time
+}
+
+// Decode unmarshals the payload of the event into v.
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Options configures an outbox.
+type Options struct {
+	Table string // Outbox table (default nexus_outbox)
+}
+
+// Outbox writes and reads the events of an outbox table.
+type Outbox struct {
+	conn  *dialects.Connection
+	table string
+}
+
+// New returns the outbox in opts.Table of conn.
+func New(conn *dialects.Connection, opts Options) *Outbox {
+	if opts.Table == "" {
+		opts.Table = DefaultTable
+	}
+	return &Outbox{conn: conn, table: opts.Table}
+}
+
+// Define adds the outbox model to s under the name OutboxEvent, so that
+// migrations create the table. Init creates it without migrations.
+func Define(s *schema.Schema, table string) *schema.Model {
+	s.Model("OutboxEvent", func(m *schema.Model) {
+		m.BigInt("id").PrimaryKey().AutoInc()
+		m.String("topic").Size(200)
+		m.Text("payload")
+		m.Int("attempts").Default(0)
+		m.Text("last_error").Null()
+		m.DateTime("created_at")
+		m.DateTime("published_at").Null()
+		m.Index("idx_"+table+"_pending", "published_at", "id")
+		m.Map(table)
+	})
+	return s.Models["OutboxEvent"]
+}
+
+// Init creates the outbox table and its index if they don't exist.
+func (o *Outbox) Init(ctx context.Context) error {
+	model := Define(schema.NewSchema(), o.table)
+	dialect := o.conn.Dialect
+	if _, err := o.conn.Exec(ctx, dialect.CreateTableSQL(model)); err != nil {
+		return fmt.Errorf("creating table %s: %w", o.table, err)
+	}
+	for _, idx := range model.Indexes {
+		_, err := o.conn.Exec(ctx, dialect.CreateIndexSQL(model.Table(), idx))
+		// MySQL has no CREATE INDEX IF NOT EXISTS
+		if err != nil && !strings.Contains(err.Error(), "Duplicate key name") {
+			return fmt.Errorf("creating index %s: %w", idx.Name, err)
+		}
+	}
+	return nil
+}
+
+// Emit writes an event to the default outbox table within tx, so that the
+// event is only published if tx commits. The payload is marshaled to JSON.
+//
+//	err := query.Transaction(ctx, conn, func(tx *dialects.Tx) error {
+//		// ... write the order ...
+//		return outbox.Emit(ctx, tx, "order.created", order)
+//	})
+func Emit(ctx context.Context, tx *dialects.Tx, topic string, payload interface{}) error {
+	return emit(ctx, tx, DefaultTable, topic, payload)
+}
+
+// Emit writes an event to the outbox within tx.
+func (o *Outbox) Emit(ctx context.Context, tx *dialects.Tx, topic string, payload interface{}) error {
+	return emit(ctx, tx, o.table, topic, payload)
+}
+
+func emit(ctx context.Context, tx *dialects.Tx, table, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding payload of %s event: %w", topic, err)
+	}
+	dialect := tx.Dialect
+	query := fmt.Sprintf("INSERT INTO %s (topic, payload, attempts, created_at) VALUES (%s, %s, 0, %s)",
+		dialect.Quote(table), dialect.Placeholder(1), dialect.Placeholder(2), dialect.Placeholder(3))
+	_, err = tx.Exec(ctx, query, topic, string(data), dbtime.Now())
+	return err
+}
+
+// Pending returns the number of events not yet published.
+func (o *Outbox) Pending(ctx context.Context) (int64, error) {
+	var n int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE published_at IS NULL", o.conn.Dialect.Quote(o.table))
+	err := o.conn.QueryRow(ctx, query).Scan(&n)
+	return n, err
+}
+
+// Prune deletes the events published before the given time and returns
+// how many it deleted.
+func (o *Outbox) Prune(ctx context.Context, before time.Time) (int64, error) {
+	dialect := o.conn.Dialect
+	query := fmt.Sprintf("DELETE FROM %s WHERE published_at IS NOT NULL AND published_at < %s",
+		dialect.Quote(o.table), dialect.Placeholder(1))
+	result, err := o.conn.Exec(ctx, query, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// lock returns the oldest unpublished events, at most limit, locking them
+// on PostgreSQL and MySQL so that concurrent relays skip them.
+func (o *Outbox) lock(ctx context.Context, tx *dialects.Tx, limit int) ([]*Event, error) {
+	dialect := o.conn.Dialect
+	query := fmt.Sprintf("SELECT id, topic, payload, attempts, last_error, created_at FROM %s WHERE published_at IS NULL ORDER BY id",
+		dialect.Quote(o.table)) + dialects.LimitClause(dialect, limit, 0, true)
+	switch dialect.Name() {
+	case "postgres", "mysql":
+		query += " FOR UPDATE SKIP LOCKED"
+	}
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []*Event
+	for rows.Next() {
+		var e Event
+		var payload string
+		var lastError sql.NullString
+		var createdAt dbtime.Time
+		if err := rows.Scan(&e.ID, &e.Topic, &payload, &e.Attempts, &lastError, &createdAt); err != nil {
+			return nil, err
+		}
+		e.Payload, e.LastError, e.CreatedAt = json.RawMessage(payload), lastError.String, createdAt.Time
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// markPublished records that the events with the given IDs were delivered.
+func (o *Outbox) markPublished(ctx context.Context, tx *dialects.Tx, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	dialect := o.conn.Dialect
+	args := []interface{}{dbtime.Now()}
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		args = append(args, id)
+		placeholders[i] = dialect.Placeholder(i + 2)
+	}
+	query := fmt.Sprintf("UPDATE %s SET published_at = %s WHERE id IN (%s)",
+		dialect.Quote(o.table), dialect.Placeholder(1), strings.Join(placeholders, ", "))
+	_, err := tx.Exec(ctx, query, args...)
+	return err
+}
+
+// markFailed records a failed delivery of the event.
+func (o *Outbox) markFailed(ctx context.Context, tx *dialects.Tx, id int64, cause error) error {
+	dialect := o.conn.Dialect
+	query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = %s WHERE id = %s",
+		dialect.Quote(o.table), dialect.Placeholder(1), dialect.Placeholder(2))
+	_, err := tx.Exec(ctx, query, cause.Error(), id)
+	return err
+}
@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// Publisher delivers events to a message broker. Publish must return nil
+// only once the broker has accepted the event.
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// PublisherFunc adapts a function to Publisher.
+type PublisherFunc func(ctx context.Context, event *Event) error
+
+// Publish calls f.
+func (f PublisherFunc) Publish(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// RelayOptions configures a relay.
+type RelayOptions struct {
+	BatchSize    int             // Events read per transaction (default 100, at most 900)
+	PollInterval time.Duration   // Wait between polls when the outbox is empty or delivery failed (default 1s)
+	Retention    time.Duration   // Prune events published longer ago than this; zero keeps them
+	Logger       nexuslog.Logger // Logs failed deliveries (default discards)
+}
+
+// Relay delivers the events of an outbox to a publisher in ID order.
+type Relay struct {
+	outbox    *Outbox
+	publisher Publisher
+	opts      RelayOptions
+}
+
+// NewRelay returns a relay from o to p.
+func NewRelay(o *Outbox, p Publisher, opts RelayOptions) *Relay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	// markPublished binds one parameter per event, within SQLite's limit
+	opts.BatchSize = min(opts.BatchSize, 900)
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = nexuslog.Nop()
+	}
+	return &Relay{outbox: o, publisher: p, opts: opts}
+}
+
+// Run delivers events until ctx is canceled. Errors are logged and the
+// delivery retried at the next poll.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		if _, err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+			r.opts.Logger.Error("outbox delivery failed", nexuslog.F("table", r.outbox.table), nexuslog.Err(err))
+		}
+		if r.opts.Retention > 0 {
+			if _, err := r.outbox.Prune(ctx, time.Now().Add(-r.opts.Retention)); err != nil && ctx.Err() == nil {
+				r.opts.Logger.Error("outbox prune failed", nexuslog.F("table", r.outbox.table), nexuslog.Err(err))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.opts.PollInterval):
+		}
+	}
+}
+
+// Flush delivers the unpublished events, batch by batch, until none are
+// left, and returns how many it delivered. It stops at the first event the
+// publisher rejects, so that later events are not delivered before it.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, more, err := r.deliver(ctx)
+		total += n
+		if err != nil || !more {
+			return total, err
+		}
+	}
+}
+
+// deliver publishes one batch in a transaction that keeps its events
+// locked, and reports whether a full batch was delivered.
+func (r *Relay) deliver(ctx context.Context) (int, bool, error) {
+	tx, err := r.outbox.conn.Begin(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	events, err := r.outbox.lock(ctx, tx, r.opts.BatchSize)
+	if err != nil {
+		return 0, false, err
+	}
+	var published []int64
+	var failed error
+	for _, event := range events {
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			r.opts.Logger.Warn("outbox event not published",
+				nexuslog.F("topic", event.Topic), nexuslog.F("id", event.ID), nexuslog.Err(err))
+			if err := r.outbox.markFailed(ctx, tx, event.ID, err); err != nil {
+				return 0, false, err
+			}
+			failed = err
+			break
+		}
+		published = append(published, event.ID)
+	}
+	// Published events are recorded even when a later one failed
+	if err := r.outbox.markPublished(ctx, tx, published); err != nil {
+		return 0, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return len(published), failed == nil && len(events) == r.opts.BatchSize, failed
+}
@@ -0,0 +1,79 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	nxerr "github.com/nexus-db/nexus/pkg/errors"
+)
+
+// Operation identifies the kind of access a query performs on a model.
+type Operation string
+
+const (
+	OpSelect Operation = "select"
+	OpCount  Operation = "count"
+	OpInsert Operation = "insert"
+	OpUpdate Operation = "update"
+	OpDelete Operation = "delete"
+)
+
+// IsWrite reports whether the operation modifies data.
+func (o Operation) IsWrite() bool {
+	return o == OpInsert || o == OpUpdate || o == OpDelete
+}
+
+// Authorizer decides whether the caller in ctx may access a model.
+// Returning a non-nil error denies the operation; the query is not executed.
+type Authorizer interface {
+	CanRead(ctx context.Context, model string, op Operation) error
+	CanWrite(ctx context.Context, model string, op Operation) error
+}
+
+// WithAuthorizer attaches an authorizer that is consulted before every
+// query executed through this builder, including eager- and lazy-loaded
+// relations, and the set operations, CTEs and derived tables built on its
+// selects.
+func (b *Builder) WithAuthorizer(a Authorizer) *Builder {
+	b.authorizer = a
+	return b
+}
+
+// authorize checks access to the model backing tableName.
+// When a schema is available the model name is used, otherwise the table name.
+func authorize(ctx context.Context, a Authorizer, sch *schema.Schema, tableName string, op Operation) error {
+	if a == nil {
+		return nil
+	}
+
+	model := tableName
+	if m := findModelByTable(sch, tableName); m != nil {
+		model = m.Name
+	}
+
+	var err error
+	if op.IsWrite() {
+		err = a.CanWrite(ctx, model, op)
+	} else {
+		err = a.CanRead(ctx, model, op)
+	}
+	if err != nil {
+		return nxerr.NewQueryError(nxerr.ErrQueryUnauthorized,
+			fmt.Sprintf("%s on %s denied: %v", op, model, err))
+	}
+	return nil
+}
+
+// authorizeOperand checks access to the table s reads as part of an
+// enclosing query, with the authorizer and schema of s, or a and sch
+// where s has none.
+func authorizeOperand(ctx context.Context, a Authorizer, sch *schema.Schema, s *SelectBuilder, op Operation) error {
+	if s.authorizer != nil {
+		a = s.authorizer
+	}
+	if s.schema != nil {
+		sch = s.schema
+	}
+	return authorize(ctx, a, sch, s.tableName, op)
+}
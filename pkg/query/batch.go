@@ -0,0 +1,139 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// BatchOptions configures UpdateMany and DeleteMany.
+type BatchOptions struct {
+	// Column is the column the IDs are matched against. It defaults to the
+	// primary key of the model, or "id" without a schema.
+	Column string
+	// ChunkSize is the most IDs a statement matches. Zero fits as many as
+	// the parameter limit of the dialect allows.
+	ChunkSize int
+	// Where restricts every statement further.
+	Where []Condition
+	// Transaction runs the statements in one transaction, so that either
+	// every chunk is applied or none is. Without it, chunks applied before
+	// a failing one are kept.
+	Transaction bool
+}
+
+// MaxParams returns the most bound parameters a statement of dialect may
+// have.
+func MaxParams(dialect dialects.Dialect) int {
+	switch dialect.Name() {
+	case "sqlite":
+		return 999 // SQLITE_MAX_VARIABLE_NUMBER before SQLite 3.32
+	case "mssql":
+		return 2100
+	}
+	return 65535
+}
+
+// UpdateMany sets data on the rows whose IDs are ids, with as few
+// statements as the dialect's parameter limit allows, and returns how many
+// rows were updated in total.
+func (b *Builder) UpdateMany(ctx context.Context, ids []interface{}, data map[string]interface{}, opts BatchOptions) (int64, error) {
+	if err := authorize(ctx, b.authorizer, b.schema, b.tableName, OpUpdate); err != nil {
+		return 0, err
+	}
+	if err := validateRows(b.schema, b.tableName, data); err != nil {
+		return 0, err
+	}
+	return b.batch(ctx, ids, len(data), opts, func(conds []Condition) (string, []interface{}) {
+		return b.Update(data).Where(conds...).Build()
+	})
+}
+
+// DeleteMany deletes the rows whose IDs are ids, with as few statements
+// as the dialect's parameter limit allows, and returns how many rows were
+// deleted in total.
+func (b *Builder) DeleteMany(ctx context.Context, ids []interface{}, opts BatchOptions) (int64, error) {
+	if err := authorize(ctx, b.authorizer, b.schema, b.tableName, OpDelete); err != nil {
+		return 0, err
+	}
+	return b.batch(ctx, ids, 0, opts, func(conds []Condition) (string, []interface{}) {
+		return b.Delete().Where(conds...).Build()
+	})
+}
+
+// execer runs statements on a connection or in a transaction.
+type execer interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// execProfiled runs a statement on e, recording it with p, and returns the
+// rows it affected.
+func execProfiled(ctx context.Context, p *Profiler, e execer, query string, args []interface{}) (int64, error) {
+	var profile *QueryProfile
+	if p != nil && p.IsEnabled() {
+		profile = p.StartQueryContext(ctx, query, args)
+	}
+	result, err := e.Exec(ctx, query, args...)
+	var affected int64
+	if err == nil {
+		affected, err = result.RowsAffected()
+	}
+	if profile != nil {
+		profile.RowsAffected = affected
+		p.EndQuery(profile, err)
+	}
+	return affected, err
+}
+
+// batch runs the statement build returns for each chunk of ids. fixed is
+// the number of parameters of the statement besides its conditions.
+func (b *Builder) batch(ctx context.Context, ids []interface{}, fixed int, opts BatchOptions, build func([]Condition) (string, []interface{})) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	column := opts.Column
+	if column == "" {
+		column = primaryKeyOf(findModelByTable(b.schema, b.tableName))
+	}
+	_, whereArgs := buildWhere(b.conn.Dialect, opts.Where, 1)
+	size := MaxParams(b.conn.Dialect) - fixed - len(whereArgs)
+	if opts.ChunkSize > 0 && opts.ChunkSize < size {
+		size = opts.ChunkSize
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	var exec execer = b.conn
+	var tx *dialects.Tx
+	if opts.Transaction {
+		var err error
+		if tx, err = b.conn.Begin(ctx); err != nil {
+			return 0, err
+		}
+		defer tx.Rollback()
+		exec = tx
+	}
+
+	var total int64
+	for start := 0; start < len(ids); start += size {
+		chunk := ids[start:min(start+size, len(ids))]
+		conds := append(append([]Condition{}, opts.Where...), In(column, chunk...))
+		query, args := build(conds)
+
+		affected, err := execProfiled(ctx, b.profiler, exec, query, args)
+		if err != nil {
+			return total, err
+		}
+		total += affected
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
@@ -13,10 +13,15 @@ import (
 
 // Builder is the main query builder.
 type Builder struct {
-	conn      *dialects.Connection
-	tableName string
-	schema    *schema.Schema
-	profiler  *Profiler
+	conn       *dialects.Connection
+	tableName  string
+	namespace  string // Schema the table lives in (@@schema); see InSchema
+	schema     *schema.Schema
+	profiler   *Profiler
+	authorizer Authorizer
+	sqlCache   *SQLCache
+	masked     bool              // Mask @pii fields in results
+	goTypes    map[string]string // Go types of columns, by column
 }
 
 // New creates a new query builder for the given table.
@@ -27,13 +32,39 @@ func New(conn *dialects.Connection, tableName string) *Builder {
 	}
 }
 
-// NewWithSchema creates a query builder with schema awareness for eager loading.
+// NewWithSchema creates a query builder with schema awareness for eager
+// loading. The table of a model declaring @@schema is qualified with it.
 func NewWithSchema(conn *dialects.Connection, tableName string, sch *schema.Schema) *Builder {
-	return &Builder{
+	b := &Builder{
 		conn:      conn,
 		tableName: tableName,
 		schema:    sch,
 	}
+	if model := findModelByTable(sch, tableName); model != nil && model.SchemaName != "" && model.Table() == tableName {
+		b.namespace = model.SchemaName
+	}
+	return b
+}
+
+// InSchema sets the schema the table lives in, a PostgreSQL schema, MySQL
+// database or SQL Server schema, as @@schema does for models. The table is
+// then known by its qualified name, e.g. billing.invoices, to authorizers
+// and profilers. Table names are otherwise quoted whole, dots and all.
+//
+//	invoices := query.New(conn, "invoices").InSchema("billing")
+func (b *Builder) InSchema(name string) *Builder {
+	b.namespace = name
+	b.tableName = schema.QualifiedTable(name, b.tableName)
+	return b
+}
+
+// quoteTable quotes tableName, which is qualified with namespace, the
+// schema the table lives in, unless that is empty.
+func quoteTable(dialect dialects.Dialect, namespace, tableName string) string {
+	if namespace == "" {
+		return dialect.Quote(tableName)
+	}
+	return dialect.QuoteQualified(namespace, strings.TrimPrefix(tableName, namespace+"."))
 }
 
 // WithProfiler attaches a profiler to the builder for performance tracking.
@@ -46,50 +77,68 @@ func (b *Builder) WithProfiler(p *Profiler) *Builder {
 // Select creates a SELECT query builder.
 func (b *Builder) Select(columns ...string) *SelectBuilder {
 	return &SelectBuilder{
-		conn:      b.conn,
-		tableName: b.tableName,
-		columns:   columns,
-		schema:    b.schema,
-		profiler:  b.profiler,
+		conn:       b.conn,
+		tableName:  b.tableName,
+		namespace:  b.namespace,
+		columns:    columns,
+		schema:     b.schema,
+		profiler:   b.profiler,
+		authorizer: b.authorizer,
+		sqlCache:   b.sqlCache,
+		masked:     b.masked,
+		goTypes:    b.goTypes,
 	}
 }
 
 // Insert creates an INSERT query builder.
 func (b *Builder) Insert(data map[string]interface{}) *InsertBuilder {
 	return &InsertBuilder{
-		conn:      b.conn,
-		tableName: b.tableName,
-		data:      data,
-		profiler:  b.profiler,
+		conn:       b.conn,
+		tableName:  b.tableName,
+		namespace:  b.namespace,
+		data:       data,
+		schema:     b.schema,
+		profiler:   b.profiler,
+		authorizer: b.authorizer,
+		goTypes:    b.goTypes,
 	}
 }
 
 // Update creates an UPDATE query builder.
 func (b *Builder) Update(data map[string]interface{}) *UpdateBuilder {
 	return &UpdateBuilder{
-		conn:      b.conn,
-		tableName: b.tableName,
-		data:      data,
-		profiler:  b.profiler,
+		conn:       b.conn,
+		tableName:  b.tableName,
+		namespace:  b.namespace,
+		data:       data,
+		schema:     b.schema,
+		profiler:   b.profiler,
+		authorizer: b.authorizer,
+		goTypes:    b.goTypes,
 	}
 }
 
 // Delete creates a DELETE query builder.
 func (b *Builder) Delete() *DeleteBuilder {
 	return &DeleteBuilder{
-		conn:      b.conn,
-		tableName: b.tableName,
-		schema:    b.schema,
-		profiler:  b.profiler,
+		conn:       b.conn,
+		tableName:  b.tableName,
+		namespace:  b.namespace,
+		schema:     b.schema,
+		profiler:   b.profiler,
+		authorizer: b.authorizer,
+		goTypes:    b.goTypes,
 	}
 }
 
 // Condition represents a WHERE condition.
 type Condition struct {
-	Column   string
-	Operator string
-	Value    interface{}
-	Raw      string // For raw SQL conditions
+	Column    string
+	Columns   []string // For conditions on a tuple of columns
+	Aggregate string   // Function applied to Column, such as COUNT, for HAVING
+	Operator  string
+	Value     interface{}
+	Raw       string // For raw SQL conditions
 }
 
 // Eq creates an equality condition.
@@ -127,6 +176,27 @@ func Like(column string, pattern string) Condition {
 	return Condition{Column: column, Operator: "LIKE", Value: pattern}
 }
 
+// ILike creates a case-insensitive LIKE condition: ILIKE on PostgreSQL,
+// and LOWER(column) LIKE LOWER(pattern) elsewhere.
+func ILike(column string, pattern string) Condition {
+	return Condition{Column: column, Operator: "ILIKE", Value: pattern}
+}
+
+// Regexp creates a condition matching column against a regular
+// expression, case-sensitively: ~ on PostgreSQL, REGEXP_LIKE on MySQL and
+// REGEXP on SQLite. SQLite has no REGEXP function unless the regexp
+// extension is loaded or the driver registers one; without it, queries
+// return ErrNoRegexp. SQL Server queries return ErrRegexpUnsupported.
+func Regexp(column string, expr string) Condition {
+	return Condition{Column: column, Operator: "REGEXP", Value: expr}
+}
+
+// Between creates a BETWEEN condition, matching values from low up to and
+// including high.
+func Between(column string, low, high interface{}) Condition {
+	return Condition{Column: column, Operator: "BETWEEN", Value: []interface{}{low, high}}
+}
+
 // In creates an IN condition.
 func In(column string, values ...interface{}) Condition {
 	return Condition{Column: column, Operator: "IN", Value: values}
@@ -184,12 +254,29 @@ func buildWhere(dialect dialects.Dialect, conditions []Condition, startIndex int
 			parts = append(parts, cond.Raw)
 			continue
 		}
+		if len(cond.Columns) > 0 {
+			sql, tupleArgs := buildTuple(dialect, cond, argIndex)
+			parts = append(parts, sql)
+			args = append(args, tupleArgs...)
+			argIndex += len(tupleArgs)
+			continue
+		}
 
-		quotedCol := dialect.Quote(cond.Column)
+		quotedCol := conditionColumn(dialect, cond)
 
 		switch cond.Operator {
 		case "IS NULL", "IS NOT NULL":
 			parts = append(parts, fmt.Sprintf("%s %s", quotedCol, cond.Operator))
+		case "EXPR":
+			sql, exprArgs := buildExpr(dialect, cond, argIndex)
+			parts = append(parts, sql)
+			args = append(args, exprArgs...)
+			argIndex += len(exprArgs)
+		case "BETWEEN", "ILIKE", "REGEXP":
+			sql, matchArgs := buildMatch(dialect, cond, argIndex)
+			parts = append(parts, sql)
+			args = append(args, matchArgs...)
+			argIndex += len(matchArgs)
 		case "IN":
 			values := cond.Value.([]interface{})
 			placeholders := make([]string, len(values))
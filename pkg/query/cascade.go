@@ -10,8 +10,9 @@ import (
 )
 
 // cascadeDelete handles cascade operations for a delete.
-// It processes HasMany and HasOne relations with cascade/setNull actions.
-func cascadeDelete(ctx context.Context, conn *dialects.Connection, sch *schema.Schema,
+// It processes HasMany and HasOne relations with cascade/setNull actions,
+// checking with a that the related records may be deleted or updated.
+func cascadeDelete(ctx context.Context, conn *dialects.Connection, sch *schema.Schema, a Authorizer,
 	tableName string, deletedRows Results) error {
 
 	model := findModelByTable(sch, tableName)
@@ -19,23 +20,40 @@ func cascadeDelete(ctx context.Context, conn *dialects.Connection, sch *schema.S
 		return nil
 	}
 
+	// Check every related table before changing any
+	var relations []*schema.Relation
 	for _, rel := range model.GetRelations() {
 		// Only process HasMany and HasOne (parent -> children)
 		if rel.Type != schema.RelationHasMany && rel.Type != schema.RelationHasOne {
 			continue
 		}
+		relations = append(relations, rel)
+		var op Operation
+		switch rel.OnDeleteAction {
+		case schema.Cascade:
+			op = OpDelete
+		case schema.SetNull:
+			op = OpUpdate
+		default:
+			continue
+		}
+		if err := authorize(ctx, a, sch, relatedTable(sch, rel.TargetModel), op); err != nil {
+			return err
+		}
+	}
 
+	for _, rel := range relations {
 		switch rel.OnDeleteAction {
 		case schema.Cascade:
-			if err := cascadeDeleteRelated(ctx, conn, rel, deletedRows); err != nil {
+			if err := cascadeDeleteRelated(ctx, conn, sch, rel, deletedRows); err != nil {
 				return err
 			}
 		case schema.SetNull:
-			if err := setNullRelated(ctx, conn, rel, deletedRows); err != nil {
+			if err := setNullRelated(ctx, conn, sch, rel, deletedRows); err != nil {
 				return err
 			}
 		case schema.Restrict:
-			hasRelated, err := hasRelatedRecords(ctx, conn, rel, deletedRows)
+			hasRelated, err := hasRelatedRecords(ctx, conn, sch, rel, deletedRows)
 			if err != nil {
 				return err
 			}
@@ -49,7 +67,7 @@ func cascadeDelete(ctx context.Context, conn *dialects.Connection, sch *schema.S
 }
 
 // cascadeDeleteRelated deletes related records for cascade action.
-func cascadeDeleteRelated(ctx context.Context, conn *dialects.Connection,
+func cascadeDeleteRelated(ctx context.Context, conn *dialects.Connection, sch *schema.Schema,
 	rel *schema.Relation, parentRows Results) error {
 
 	pkValues := collectFieldValues(parentRows, rel.ReferenceKey)
@@ -57,8 +75,8 @@ func cascadeDeleteRelated(ctx context.Context, conn *dialects.Connection,
 		return nil
 	}
 
-	targetTable := toTableName(rel.TargetModel)
 	dialect := conn.Dialect
+	targetTable := quoteRelatedTable(dialect, sch, rel.TargetModel)
 
 	// Build DELETE ... WHERE fk IN (...)
 	placeholders := make([]string, len(pkValues))
@@ -67,7 +85,7 @@ func cascadeDeleteRelated(ctx context.Context, conn *dialects.Connection,
 	}
 
 	query := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)",
-		dialect.Quote(targetTable),
+		targetTable,
 		dialect.Quote(rel.ForeignKey),
 		strings.Join(placeholders, ", "))
 
@@ -76,7 +94,7 @@ func cascadeDeleteRelated(ctx context.Context, conn *dialects.Connection,
 }
 
 // setNullRelated sets foreign keys to NULL for setNull action.
-func setNullRelated(ctx context.Context, conn *dialects.Connection,
+func setNullRelated(ctx context.Context, conn *dialects.Connection, sch *schema.Schema,
 	rel *schema.Relation, parentRows Results) error {
 
 	pkValues := collectFieldValues(parentRows, rel.ReferenceKey)
@@ -84,8 +102,8 @@ func setNullRelated(ctx context.Context, conn *dialects.Connection,
 		return nil
 	}
 
-	targetTable := toTableName(rel.TargetModel)
 	dialect := conn.Dialect
+	targetTable := quoteRelatedTable(dialect, sch, rel.TargetModel)
 
 	// Build UPDATE ... SET fk = NULL WHERE fk IN (...)
 	placeholders := make([]string, len(pkValues))
@@ -94,7 +112,7 @@ func setNullRelated(ctx context.Context, conn *dialects.Connection,
 	}
 
 	query := fmt.Sprintf("UPDATE %s SET %s = NULL WHERE %s IN (%s)",
-		dialect.Quote(targetTable),
+		targetTable,
 		dialect.Quote(rel.ForeignKey),
 		dialect.Quote(rel.ForeignKey),
 		strings.Join(placeholders, ", "))
@@ -104,7 +122,7 @@ func setNullRelated(ctx context.Context, conn *dialects.Connection,
 }
 
 // hasRelatedRecords checks if any related records exist.
-func hasRelatedRecords(ctx context.Context, conn *dialects.Connection,
+func hasRelatedRecords(ctx context.Context, conn *dialects.Connection, sch *schema.Schema,
 	rel *schema.Relation, parentRows Results) (bool, error) {
 
 	pkValues := collectFieldValues(parentRows, rel.ReferenceKey)
@@ -112,8 +130,8 @@ func hasRelatedRecords(ctx context.Context, conn *dialects.Connection,
 		return false, nil
 	}
 
-	targetTable := toTableName(rel.TargetModel)
 	dialect := conn.Dialect
+	targetTable := quoteRelatedTable(dialect, sch, rel.TargetModel)
 
 	placeholders := make([]string, len(pkValues))
 	for i := range pkValues {
@@ -121,7 +139,7 @@ func hasRelatedRecords(ctx context.Context, conn *dialects.Connection,
 	}
 
 	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IN (%s)",
-		dialect.Quote(targetTable),
+		targetTable,
 		dialect.Quote(rel.ForeignKey),
 		strings.Join(placeholders, ", "))
 
@@ -136,8 +154,8 @@ func hasRelatedRecords(ctx context.Context, conn *dialects.Connection,
 
 // cascadeUpdate handles cascade operations for an update.
 // If the reference key (usually primary key) is being updated,
-// propagate the change to related records.
-func cascadeUpdate(ctx context.Context, conn *dialects.Connection, sch *schema.Schema,
+// propagate the change to related records, if a allows updating them.
+func cascadeUpdate(ctx context.Context, conn *dialects.Connection, sch *schema.Schema, a Authorizer,
 	tableName string, oldRows Results, newData map[string]interface{}) error {
 
 	model := findModelByTable(sch, tableName)
@@ -167,15 +185,21 @@ func cascadeUpdate(ctx context.Context, conn *dialects.Connection, sch *schema.S
 
 		switch rel.OnUpdateAction {
 		case schema.Cascade:
-			if err := cascadeUpdateRelated(ctx, conn, rel, oldRows, pkField, newPKValue); err != nil {
+			if err := authorize(ctx, a, sch, relatedTable(sch, rel.TargetModel), OpUpdate); err != nil {
+				return err
+			}
+			if err := cascadeUpdateRelated(ctx, conn, sch, rel, oldRows, pkField, newPKValue); err != nil {
 				return err
 			}
 		case schema.SetNull:
-			if err := setNullRelated(ctx, conn, rel, oldRows); err != nil {
+			if err := authorize(ctx, a, sch, relatedTable(sch, rel.TargetModel), OpUpdate); err != nil {
+				return err
+			}
+			if err := setNullRelated(ctx, conn, sch, rel, oldRows); err != nil {
 				return err
 			}
 		case schema.Restrict:
-			hasRelated, err := hasRelatedRecords(ctx, conn, rel, oldRows)
+			hasRelated, err := hasRelatedRecords(ctx, conn, sch, rel, oldRows)
 			if err != nil {
 				return err
 			}
@@ -189,7 +213,7 @@ func cascadeUpdate(ctx context.Context, conn *dialects.Connection, sch *schema.S
 }
 
 // cascadeUpdateRelated updates foreign keys in related records.
-func cascadeUpdateRelated(ctx context.Context, conn *dialects.Connection,
+func cascadeUpdateRelated(ctx context.Context, conn *dialects.Connection, sch *schema.Schema,
 	rel *schema.Relation, parentRows Results, pkField string, newPKValue interface{}) error {
 
 	for _, row := range parentRows {
@@ -198,11 +222,11 @@ func cascadeUpdateRelated(ctx context.Context, conn *dialects.Connection,
 			continue
 		}
 
-		targetTable := toTableName(rel.TargetModel)
 		dialect := conn.Dialect
+		targetTable := quoteRelatedTable(dialect, sch, rel.TargetModel)
 
 		query := fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s = %s",
-			dialect.Quote(targetTable),
+			targetTable,
 			dialect.Quote(rel.ForeignKey),
 			dialect.Placeholder(1),
 			dialect.Quote(rel.ForeignKey),
@@ -217,6 +241,18 @@ func cascadeUpdateRelated(ctx context.Context, conn *dialects.Connection,
 	return nil
 }
 
+// quoteRelatedTable quotes the table of a related model for dialect,
+// qualified with the model's @@schema if it has one.
+func quoteRelatedTable(dialect dialects.Dialect, sch *schema.Schema, modelName string) string {
+	table := relatedTable(sch, modelName)
+	if sch != nil {
+		if model, ok := sch.Models[modelName]; ok && model.SchemaName != "" {
+			return dialect.QuoteQualified(model.SchemaName, table)
+		}
+	}
+	return dialect.Quote(table)
+}
+
 // findModelByTable finds a model by table name (case-insensitive, with pluralization).
 func findModelByTable(sch *schema.Schema, tableName string) *schema.Model {
 	if sch == nil {
@@ -228,6 +264,13 @@ func findModelByTable(sch *schema.Schema, tableName string) *schema.Model {
 		return model
 	}
 
+	// Mapped and qualified table names
+	for _, model := range sch.Models {
+		if (model.TableName != "" && model.TableName == tableName) || model.Table() == tableName {
+			return model
+		}
+	}
+
 	// Case-insensitive and plural matching
 	for name, model := range sch.Models {
 		if strings.EqualFold(name, tableName) ||
@@ -0,0 +1,163 @@
+package query
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// Converter converts between a custom Go type and the values the driver
+// reads and writes, for fields declared with GoType, such as decimals,
+// UUIDs, enums or encrypted strings.
+//
+// Example:
+//
+//	query.RegisterConverter("github.com/shopspring/decimal.Decimal", query.Converter{
+//		Scan: func(src interface{}) (interface{}, error) {
+//			var d decimal.Decimal
+//			err := d.Scan(src)
+//			return d, err
+//		},
+//		Value: func(v interface{}) (driver.Value, error) {
+//			return v.(decimal.Decimal).String(), nil
+//		},
+//	})
+type Converter struct {
+	// Scan converts a value read from the database, such as an int64, a
+	// string or []byte, into the Go type. It is not called for NULL.
+	Scan func(src interface{}) (interface{}, error)
+
+	// Value converts a value written to the column into one the driver
+	// accepts. Values of other types than the Go type may be passed, such
+	// as strings; nil leaves values as they are.
+	Value func(v interface{}) (driver.Value, error)
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = make(map[string]Converter)
+)
+
+// RegisterConverter registers the converter of a Go type, named as fields
+// name it with GoType: the import path and the type name, such as
+// "github.com/google/uuid.UUID". Programs call it from init.
+func RegisterConverter(goType string, c Converter) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[goType] = c
+}
+
+// RegisteredConverter returns the converter registered for a Go type.
+func RegisteredConverter(goType string) (Converter, bool) {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	c, ok := converters[goType]
+	return c, ok
+}
+
+// RegisteredConverters returns the Go types with a converter, sorted.
+func RegisteredConverters() []string {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	names := make([]string, 0, len(converters))
+	for name := range converters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithGoTypes sets the Go types of columns, by column name, whose values
+// the queries of the builder convert with the registered converters. The
+// fields of a schema given to NewWithSchema declare theirs with GoType.
+func (b *Builder) WithGoTypes(goTypes map[string]string) *Builder {
+	b.goTypes = goTypes
+	return b
+}
+
+// columnConverters returns the converters of the columns of table: those
+// of goTypes, and those of the fields of its model in sch declared with a
+// GoType. Types without a registered converter are left out.
+func columnConverters(sch *schema.Schema, table string, goTypes map[string]string) map[string]Converter {
+	types := make(map[string]string)
+	if model := findModelByTable(sch, table); model != nil {
+		for _, f := range model.GetFields() {
+			if f.GoTypeName != "" {
+				types[f.Name] = f.GoTypeName
+			}
+		}
+	}
+	for column, goType := range goTypes {
+		types[column] = goType
+	}
+
+	var convs map[string]Converter
+	for column, goType := range types {
+		if c, ok := RegisteredConverter(goType); ok {
+			if convs == nil {
+				convs = make(map[string]Converter)
+			}
+			convs[column] = c
+		}
+	}
+	return convs
+}
+
+// scanConverted scans rows as scanRows does, converting the columns that
+// have a converter.
+func scanConverted(rows *sql.Rows, convs map[string]Converter) (Results, error) {
+	results, err := scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := convertResults(convs, results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// convertResults converts, in place, the values of the columns of results
+// that have a Scan function.
+func convertResults(convs map[string]Converter, results Results) error {
+	for _, row := range results {
+		for column, c := range convs {
+			v, ok := row[column]
+			if !ok || v == nil || c.Scan == nil {
+				continue
+			}
+			converted, err := c.Scan(v)
+			if err != nil {
+				return fmt.Errorf("converting column %s: %w", column, err)
+			}
+			row[column] = converted
+		}
+	}
+	return nil
+}
+
+// convertArg converts a value written to column with its Value function.
+// A failed conversion becomes an argument that fails the query with the
+// error when the driver reads it.
+func convertArg(convs map[string]Converter, column string, v interface{}) interface{} {
+	c, ok := convs[column]
+	if !ok || v == nil || c.Value == nil {
+		return v
+	}
+	converted, err := c.Value(v)
+	if err != nil {
+		return conversionError{fmt.Errorf("converting column %s: %w", column, err)}
+	}
+	return converted
+}
+
+// conversionError is an argument whose conversion failed.
+type conversionError struct{ err error }
+
+// Value returns the error of the conversion.
+func (e conversionError) Value() (driver.Value, error) {
+	return nil, e.err
+}
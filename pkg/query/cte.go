@@ -3,17 +3,21 @@ package query
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
 )
 
 // CTE represents a Common Table Expression.
 type CTE struct {
-	Name      string
-	Query     *SelectBuilder
-	Recursive bool
-	Columns   []string // Optional column aliases
+	Name           string
+	Query          *SelectBuilder
+	RecursiveQuery *SelectBuilder // Joined to Query with UNION ALL
+	Recursive      bool
+	Columns        []string // Optional column aliases
 }
 
 // CTEBuilder builds queries with CTEs.
@@ -21,38 +25,58 @@ type CTEBuilder struct {
 	conn      *dialects.Connection
 	ctes      []*CTE
 	recursive bool
+	schema    *schema.Schema
+	// Consulted for the queries of the CTEs without their own and the
+	// tables the main SELECT reads besides the CTEs
+	authorizer Authorizer
 }
 
 // With creates a new CTE builder with a named CTE.
 func With(conn *dialects.Connection, name string, query *SelectBuilder) *CTEBuilder {
 	return &CTEBuilder{
-		conn: conn,
-		ctes: []*CTE{{Name: name, Query: query}},
+		conn:       conn,
+		ctes:       []*CTE{{Name: name, Query: query}},
+		schema:     query.schema,
+		authorizer: query.authorizer,
 	}
 }
 
 // WithColumns creates a CTE with explicit column aliases.
 func WithColumns(conn *dialects.Connection, name string, columns []string, query *SelectBuilder) *CTEBuilder {
 	return &CTEBuilder{
-		conn: conn,
-		ctes: []*CTE{{Name: name, Query: query, Columns: columns}},
+		conn:       conn,
+		ctes:       []*CTE{{Name: name, Query: query, Columns: columns}},
+		schema:     query.schema,
+		authorizer: query.authorizer,
 	}
 }
 
-// WithRecursive creates a recursive CTE.
-// The query should be a UNION of base case and recursive case.
-func WithRecursive(conn *dialects.Connection, name string, columns []string, baseQuery, recursiveQuery *SelectBuilder) *CTEBuilder {
-	// Create a combined query using UNION ALL
-	_ = baseQuery.UnionAll(recursiveQuery) // Used in Build phase
+// WithRecursive creates a recursive CTE: the rows of anchorQuery, UNION ALL
+// the rows recursiveQuery finds by joining the CTE itself, until it finds
+// none. Rows that form a cycle recurse forever, unless recursiveQuery stops
+// them.
+//
+//	anchor := query.New(conn, "categories").Select().Where(query.Eq("id", 1))
+//	children := query.New(conn, "categories").Select("categories.*").
+//		Join("tree", `"categories"."parent_id" = "tree"."id"`)
+//	rows, err := query.WithRecursive(conn, "tree", anchor, children).Select().From("tree").All(ctx)
+func WithRecursive(conn *dialects.Connection, name string, anchorQuery, recursiveQuery *SelectBuilder) *CTEBuilder {
+	return WithRecursiveColumns(conn, name, nil, anchorQuery, recursiveQuery)
+}
 
+// WithRecursiveColumns creates a recursive CTE with explicit column aliases.
+func WithRecursiveColumns(conn *dialects.Connection, name string, columns []string, anchorQuery, recursiveQuery *SelectBuilder) *CTEBuilder {
 	return &CTEBuilder{
-		conn:      conn,
-		recursive: true,
+		conn:       conn,
+		recursive:  true,
+		schema:     anchorQuery.schema,
+		authorizer: anchorQuery.authorizer,
 		ctes: []*CTE{{
-			Name:      name,
-			Columns:   columns,
-			Recursive: true,
-			Query:     baseQuery, // Store the base query for now
+			Name:           name,
+			Columns:        columns,
+			Recursive:      true,
+			Query:          anchorQuery,
+			RecursiveQuery: recursiveQuery,
 		}},
 	}
 }
@@ -131,17 +155,24 @@ func (s *CTESelectBuilder) Build() (string, []interface{}) {
 	var allArgs []interface{}
 	argOffset := 0
 
-	// Build WITH clause
+	// Build WITH clause. SQL Server has no RECURSIVE keyword
 	withKeyword := "WITH"
-	if s.cteBuilder.recursive {
+	if s.cteBuilder.recursive && dialect.Name() != "mssql" {
 		withKeyword = "WITH RECURSIVE"
 	}
 
 	cteParts := make([]string, len(s.cteBuilder.ctes))
 	for i, cte := range s.cteBuilder.ctes {
 		cteSQL, cteArgs := cte.Query.Build()
+		cteSQL = shiftPlaceholders(dialect, cteSQL, argOffset)
 		allArgs = append(allArgs, cteArgs...)
 		argOffset += len(cteArgs)
+		if cte.RecursiveQuery != nil {
+			recursiveSQL, recursiveArgs := cte.RecursiveQuery.Build()
+			cteSQL += " UNION ALL " + shiftPlaceholders(dialect, recursiveSQL, argOffset)
+			allArgs = append(allArgs, recursiveArgs...)
+			argOffset += len(recursiveArgs)
+		}
 
 		cteName := dialect.Quote(cte.Name)
 		if len(cte.Columns) > 0 {
@@ -193,21 +224,51 @@ func (s *CTESelectBuilder) Build() (string, []interface{}) {
 		sql += " ORDER BY " + strings.Join(orderParts, ", ")
 	}
 
-	// LIMIT
-	if s.limit > 0 {
-		sql += fmt.Sprintf(" LIMIT %d", s.limit)
-	}
-
-	// OFFSET
-	if s.offset > 0 {
-		sql += fmt.Sprintf(" OFFSET %d", s.offset)
-	}
+	// LIMIT and OFFSET
+	sql += dialects.LimitClause(dialect, s.limit, s.offset, len(s.orders) > 0)
 
 	return sql, allArgs
 }
 
+// authorize checks access to the tables the CTEs read, then to those the
+// main SELECT reads that aren't CTEs.
+func (s *CTESelectBuilder) authorize(ctx context.Context) error {
+	c := s.cteBuilder
+	names := make(map[string]bool, len(c.ctes))
+	for _, cte := range c.ctes {
+		names[cte.Name] = true
+	}
+	for _, cte := range c.ctes {
+		for _, q := range []*SelectBuilder{cte.Query, cte.RecursiveQuery} {
+			// A recursive query reads the CTE itself
+			if q == nil || names[q.tableName] {
+				continue
+			}
+			if err := authorizeOperand(ctx, c.authorizer, c.schema, q, OpSelect); err != nil {
+				return err
+			}
+		}
+	}
+	tables := []string{s.tableName}
+	for _, join := range s.joins {
+		tables = append(tables, join.table)
+	}
+	for _, table := range tables {
+		if names[table] {
+			continue
+		}
+		if err := authorize(ctx, c.authorizer, c.schema, table, OpSelect); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // All executes the query and returns all results.
 func (s *CTESelectBuilder) All(ctx context.Context) (Results, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
 	sql, args := s.Build()
 	rows, err := s.cteBuilder.conn.Query(ctx, sql, args...)
 	if err != nil {
@@ -229,3 +290,18 @@ func (s *CTESelectBuilder) One(ctx context.Context) (Result, error) {
 	}
 	return results[0], nil
 }
+
+// shiftPlaceholders renumbers the numbered placeholders ($1, @p1) of a
+// query built on its own to follow offset arguments of the enclosing query.
+func shiftPlaceholders(dialect dialects.Dialect, sql string, offset int) string {
+	first := dialect.Placeholder(1)
+	if offset == 0 || first == dialect.Placeholder(2) {
+		return sql
+	}
+	prefix := strings.TrimSuffix(first, "1")
+	re := regexp.MustCompile(regexp.QuoteMeta(prefix) + `(\d+)`)
+	return re.ReplaceAllStringFunc(sql, func(m string) string {
+		n, _ := strconv.Atoi(m[len(prefix):])
+		return dialect.Placeholder(n + offset)
+	})
+}
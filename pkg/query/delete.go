@@ -13,11 +13,16 @@ import (
 type DeleteBuilder struct {
 	conn       *dialects.Connection
 	tableName  string
+	namespace  string
 	conditions []Condition
+	joins      []joinClause
 	returning  []string
 	schema     *schema.Schema
 	cascade    bool
 	profiler   *Profiler
+	authorizer Authorizer
+	fullTable  bool
+	goTypes    map[string]string
 }
 
 // Where adds a WHERE condition.
@@ -45,17 +50,42 @@ func (d *DeleteBuilder) Cascade() *DeleteBuilder {
 	return d
 }
 
+// AllowFullTable lets the delete through the Safety of the connection when
+// it has no conditions or deletes more rows than the limit.
+func (d *DeleteBuilder) AllowFullTable() *DeleteBuilder {
+	d.fullTable = true
+	return d
+}
+
 // Build generates the SQL query and arguments.
 func (d *DeleteBuilder) Build() (string, []interface{}) {
 	dialect := d.conn.Dialect
 	var args []interface{}
 	argIndex := 1
 
-	sql := fmt.Sprintf("DELETE FROM %s", dialect.Quote(d.tableName))
+	table := quoteTable(dialect, d.namespace, d.tableName)
+	sql := fmt.Sprintf("DELETE FROM %s", table)
+
+	// Joined tables; SQLite selects the rows in the WHERE clause instead
+	conditions := d.conditions
+	if len(d.joins) > 0 && dialect.Name() != "sqlite" {
+		var tables string
+		tables, conditions = joinedTables(dialect, d.joins, d.conditions)
+		if dialect.Name() == "postgres" {
+			sql += " USING " + tables
+		} else {
+			sql = fmt.Sprintf("DELETE %s FROM %s, %s", table, table, tables)
+		}
+	}
 
 	// WHERE clause
-	if len(d.conditions) > 0 {
-		whereSQL, whereArgs := buildWhere(dialect, d.conditions, argIndex)
+	switch {
+	case len(d.joins) > 0 && dialect.Name() == "sqlite":
+		whereSQL, whereArgs := keySubquery(dialect, table, primaryKeyColumns(d.schema, d.tableName), d.joins, d.conditions, argIndex)
+		sql += " " + whereSQL
+		args = append(args, whereArgs...)
+	case len(conditions) > 0:
+		whereSQL, whereArgs := buildWhere(dialect, conditions, argIndex)
 		sql += " " + whereSQL
 		args = append(args, whereArgs...)
 	}
@@ -64,7 +94,9 @@ func (d *DeleteBuilder) Build() (string, []interface{}) {
 	if len(d.returning) > 0 && dialect.SupportsReturning() {
 		retCols := make([]string, len(d.returning))
 		for i, c := range d.returning {
-			if c == "*" {
+			if c == "*" && len(d.joins) > 0 {
+				retCols[i] = table + ".*" // Not the columns of joined tables
+			} else if c == "*" {
 				retCols[i] = "*"
 			} else {
 				retCols[i] = dialect.Quote(c)
@@ -79,6 +111,16 @@ func (d *DeleteBuilder) Build() (string, []interface{}) {
 // Exec executes the delete and returns the number of affected rows.
 // If Cascade() is enabled and schema is set, related records are also deleted/nullified.
 func (d *DeleteBuilder) Exec(ctx context.Context) (int64, error) {
+	if err := authorize(ctx, d.authorizer, d.schema, d.tableName, OpDelete); err != nil {
+		return 0, err
+	}
+	if err := checkRegexp(ctx, d.conn, d.conditions); err != nil {
+		return 0, err
+	}
+	if d.fullTable {
+		ctx = dialects.AllowFullTable(ctx)
+	}
+
 	// For cascade, we need to fetch the rows first to know what to cascade
 	if d.cascade && d.schema != nil {
 		return d.execWithCascade(ctx)
@@ -89,7 +131,7 @@ func (d *DeleteBuilder) Exec(ctx context.Context) (int64, error) {
 	// Start profiling if enabled
 	var profile *QueryProfile
 	if d.profiler != nil && d.profiler.IsEnabled() {
-		profile = d.profiler.StartQuery(query, args)
+		profile = d.profiler.StartQueryContext(ctx, query, args)
 	}
 
 	result, err := d.conn.Exec(ctx, query, args...)
@@ -104,7 +146,7 @@ func (d *DeleteBuilder) Exec(ctx context.Context) (int64, error) {
 	}
 
 	if err != nil {
-		return 0, err
+		return 0, rowidError(err, d.tableName)
 	}
 	return result.RowsAffected()
 }
@@ -112,6 +154,13 @@ func (d *DeleteBuilder) Exec(ctx context.Context) (int64, error) {
 // execWithCascade performs delete with cascade to related records.
 func (d *DeleteBuilder) execWithCascade(ctx context.Context) (int64, error) {
 	dialect := d.conn.Dialect
+	// The cascade deletes by primary key, so check the guards against the
+	// delete as written
+	deleteQuery, _ := d.Build()
+	safety := d.conn.Safety()
+	if err := safety.Check(ctx, deleteQuery); err != nil {
+		return 0, err
+	}
 
 	// First, SELECT the rows to be deleted
 	selectQuery, selectArgs := d.buildSelect()
@@ -129,9 +178,12 @@ func (d *DeleteBuilder) execWithCascade(ctx context.Context) (int64, error) {
 	if len(toDelete) == 0 {
 		return 0, nil
 	}
+	if err := safety.CheckRows(ctx, deleteQuery, int64(len(toDelete))); err != nil {
+		return 0, err
+	}
 
 	// Cascade to related records first
-	if err := cascadeDelete(ctx, d.conn, d.schema, d.tableName, toDelete); err != nil {
+	if err := cascadeDelete(ctx, d.conn, d.schema, d.authorizer, d.tableName, toDelete); err != nil {
 		return 0, err
 	}
 
@@ -154,8 +206,8 @@ func (d *DeleteBuilder) execWithCascade(ctx context.Context) (int64, error) {
 		placeholders[i] = dialect.Placeholder(i + 1)
 	}
 
-	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)",
-		dialect.Quote(d.tableName),
+	deleteQuery = fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)",
+		quoteTable(dialect, d.namespace, d.tableName),
 		dialect.Quote(pkField),
 		strings.Join(placeholders, ", "))
 
@@ -173,10 +225,17 @@ func (d *DeleteBuilder) buildSelect() (string, []interface{}) {
 	var args []interface{}
 	argIndex := 1
 
-	sql := fmt.Sprintf("SELECT * FROM %s", dialect.Quote(d.tableName))
+	table := quoteTable(dialect, d.namespace, d.tableName)
+	sql := fmt.Sprintf("SELECT * FROM %s", table)
+	conditions := d.conditions
+	if len(d.joins) > 0 {
+		var tables string
+		tables, conditions = joinedTables(dialect, d.joins, d.conditions)
+		sql = fmt.Sprintf("SELECT %s.* FROM %s, %s", table, table, tables)
+	}
 
-	if len(d.conditions) > 0 {
-		whereSQL, whereArgs := buildWhere(dialect, d.conditions, argIndex)
+	if len(conditions) > 0 {
+		whereSQL, whereArgs := buildWhere(dialect, conditions, argIndex)
 		sql += " " + whereSQL
 		args = append(args, whereArgs...)
 	}
@@ -186,6 +245,16 @@ func (d *DeleteBuilder) buildSelect() (string, []interface{}) {
 
 // All executes the delete and returns all deleted rows (requires RETURNING).
 func (d *DeleteBuilder) All(ctx context.Context) (Results, error) {
+	if err := authorize(ctx, d.authorizer, d.schema, d.tableName, OpDelete); err != nil {
+		return nil, err
+	}
+	if err := checkRegexp(ctx, d.conn, d.conditions); err != nil {
+		return nil, err
+	}
+	if d.fullTable {
+		ctx = dialects.AllowFullTable(ctx)
+	}
+
 	if !d.conn.Dialect.SupportsReturning() {
 		return nil, fmt.Errorf("dialect %s does not support RETURNING clause", d.conn.Dialect.Name())
 	}
@@ -197,11 +266,11 @@ func (d *DeleteBuilder) All(ctx context.Context) (Results, error) {
 	query, args := d.Build()
 	rows, err := d.conn.Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, rowidError(err, d.tableName)
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanConverted(rows, columnConverters(d.schema, d.tableName, d.goTypes))
 }
 
 // One executes the delete and returns the first deleted row (requires RETURNING).
@@ -3,6 +3,7 @@ package query
 
 import (
 	"context"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -57,6 +58,26 @@ type QueryPlan struct {
 	Warnings []string
 	// IsAnalyzed indicates if this was an EXPLAIN ANALYZE.
 	IsAnalyzed bool
+	// Nodes is the plan as a tree of steps, for text format plans.
+	Nodes []*PlanNode
+}
+
+// PlanNode is a step of a query plan.
+type PlanNode struct {
+	// Detail is the step as the database describes it.
+	Detail string
+	// ScanType is the kind of step (sequential_scan, index_scan, etc.),
+	// empty when not recognized.
+	ScanType string
+	// Index is the index the step uses, if any.
+	Index string
+	// Warnings contains performance problems found in this step.
+	Warnings []string
+	// Properties holds extra details of the step: the lines below a
+	// PostgreSQL node, or the columns of a MySQL EXPLAIN row.
+	Properties map[string]string
+	// Children are the steps this one is built on.
+	Children []*PlanNode
 }
 
 // Explain returns the query plan for the SELECT query.
@@ -79,6 +100,16 @@ func (s *SelectBuilder) Analyze(ctx context.Context) (*QueryPlan, error) {
 	})
 }
 
+// Explain returns the query plan for the raw query.
+func (r *RawQuery) Explain(ctx context.Context, opts ...ExplainOptions) (*QueryPlan, error) {
+	opt := ExplainOptions{Format: ExplainFormatText}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	return explain(ctx, r.conn, r.convertPlaceholders(), r.args, opt)
+}
+
 // explain runs EXPLAIN on a query and parses the result.
 func explain(ctx context.Context, conn *dialects.Connection, query string, args []interface{}, opts ExplainOptions) (*QueryPlan, error) {
 	dialect := conn.Dialect
@@ -95,6 +126,7 @@ func explain(ctx context.Context, conn *dialects.Connection, query string, args
 
 	// Collect output
 	var rawLines []string
+	var rowValues [][]string
 	columns, _ := rows.Columns()
 
 	for rows.Next() {
@@ -110,19 +142,15 @@ func explain(ctx context.Context, conn *dialects.Connection, query string, args
 
 		// Convert row to string line
 		var lineParts []string
-		for _, v := range values {
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = stringValue(v)
 			if v != nil {
-				switch val := v.(type) {
-				case []byte:
-					lineParts = append(lineParts, string(val))
-				case string:
-					lineParts = append(lineParts, val)
-				default:
-					lineParts = append(lineParts, stringValue(v))
-				}
+				lineParts = append(lineParts, row[i])
 			}
 		}
 		rawLines = append(rawLines, strings.Join(lineParts, " | "))
+		rowValues = append(rowValues, row)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -139,6 +167,9 @@ func explain(ctx context.Context, conn *dialects.Connection, query string, args
 	}
 
 	parsePlan(plan, raw, dialect.Name())
+	if opts.Format == "" || opts.Format == ExplainFormatText {
+		plan.Nodes = buildPlanNodes(columns, rowValues, dialect.Name())
+	}
 
 	return plan, nil
 }
@@ -153,11 +184,181 @@ func stringValue(v interface{}) string {
 		return val
 	case []byte:
 		return string(val)
-	case int64:
-		return strings.TrimSpace(strings.Repeat(" ", 10) + string(rune(val)))
 	default:
-		return ""
+		return fmt.Sprint(val)
+	}
+}
+
+// buildPlanNodes builds the plan tree from the rows EXPLAIN returned.
+func buildPlanNodes(columns []string, rows [][]string, dialectName string) []*PlanNode {
+	switch {
+	case len(columns) == 1:
+		// PostgreSQL and MySQL FORMAT=TREE print one line per row
+		var lines []string
+		for _, row := range rows {
+			lines = append(lines, strings.Split(row[0], "\n")...)
+		}
+		return indentedPlanNodes(lines, dialectName)
+	case hasColumns(columns, "id", "parent", "detail"):
+		return sqlitePlanNodes(columns, rows, dialectName)
+	}
+
+	// Tabular plans, such as MySQL's, get a node per row
+	var nodes []*PlanNode
+	for _, row := range rows {
+		props := make(map[string]string)
+		var parts []string
+		for i, col := range columns {
+			if row[i] == "" {
+				continue
+			}
+			props[col] = row[i]
+			parts = append(parts, col+": "+row[i])
+		}
+		node := newPlanNode(strings.Join(parts, ", "), dialectName)
+		node.Properties = props
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// hasColumns reports whether columns contains every name.
+func hasColumns(columns []string, names ...string) bool {
+	for _, name := range names {
+		found := false
+		for _, col := range columns {
+			if strings.EqualFold(col, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// sqlitePlanNodes links EXPLAIN QUERY PLAN rows into a tree by their id
+// and parent columns.
+func sqlitePlanNodes(columns []string, rows [][]string, dialectName string) []*PlanNode {
+	index := make(map[string]int)
+	for i, col := range columns {
+		index[strings.ToLower(col)] = i
+	}
+
+	var roots []*PlanNode
+	byID := make(map[string]*PlanNode)
+	for _, row := range rows {
+		node := newPlanNode(row[index["detail"]], dialectName)
+		byID[row[index["id"]]] = node
+		if parent, ok := byID[row[index["parent"]]]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
 	}
+	return roots
+}
+
+// indentedPlanNodes parses plans whose steps are nested by indentation
+// and start with "->", except the first. Other lines are properties of
+// the step above them.
+func indentedPlanNodes(lines []string, dialectName string) []*PlanNode {
+	type level struct {
+		indent int
+		node   *PlanNode
+	}
+	var roots []*PlanNode
+	var stack []level
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if !strings.HasPrefix(trimmed, "->") && len(stack) > 0 {
+			node := stack[len(stack)-1].node
+			key, value, ok := strings.Cut(trimmed, ":")
+			if !ok {
+				key, value = trimmed, ""
+			}
+			if node.Properties == nil {
+				node.Properties = make(map[string]string)
+			}
+			node.Properties[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			continue
+		}
+
+		node := newPlanNode(strings.TrimSpace(strings.TrimPrefix(trimmed, "->")), dialectName)
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, level{indent: indent, node: node})
+	}
+	return roots
+}
+
+// planScanTypes lists the scan types a node can have, most specific
+// first.
+var planScanTypes = []struct {
+	scanType string
+	patterns []string
+}{
+	{"index_only_scan", []string{"index only scan", "covering index"}},
+	{"bitmap_scan", []string{"bitmap heap scan", "bitmap index scan"}},
+	{"index_scan", []string{"index scan", "using index", "search ", "index lookup", "index range scan"}},
+	{"sequential_scan", []string{"seq scan", "table scan", "full scan", "scan "}},
+	{"nested_loop", []string{"nested loop"}},
+	{"hash_join", []string{"hash join"}},
+	{"merge_join", []string{"merge join"}},
+	{"sort", []string{"sort", "filesort"}},
+	{"aggregate", []string{"aggregate", "group"}},
+}
+
+// newPlanNode creates a node for a plan step, classifying it.
+func newPlanNode(detail, dialectName string) *PlanNode {
+	lower := strings.ToLower(detail)
+	node := &PlanNode{Detail: detail}
+	for _, st := range planScanTypes {
+		for _, pattern := range st.patterns {
+			if strings.Contains(lower, pattern) {
+				node.ScanType = st.scanType
+				break
+			}
+		}
+		if node.ScanType != "" {
+			break
+		}
+	}
+	if indexes := extractIndexes(detail, dialectName); len(indexes) > 0 {
+		node.Index = indexes[0]
+	}
+	node.Warnings = nodeWarnings(lower, node.ScanType)
+	return node
+}
+
+// nodeWarnings returns the performance warnings for a single plan step.
+func nodeWarnings(lower, scanType string) []string {
+	var warnings []string
+	if scanType == "sequential_scan" {
+		warnings = append(warnings, "Full table scan detected - consider adding an index")
+	}
+	if strings.Contains(lower, "filesort") {
+		warnings = append(warnings, "Using filesort - consider adding an index for ORDER BY columns")
+	}
+	if strings.Contains(lower, "temporary") || strings.Contains(lower, "temp b-tree") {
+		warnings = append(warnings, "Using temporary table - query may be slow for large datasets")
+	}
+	return warnings
 }
 
 // parsePlan extracts structured information from the raw plan.
@@ -182,6 +383,7 @@ func extractIndexes(raw, dialectName string) []string {
 	// Common patterns for index usage across dialects
 	patterns := []*regexp.Regexp{
 		regexp.MustCompile(`USING INDEX\s+(\w+)`),            // SQLite
+		regexp.MustCompile(`USING COVERING INDEX\s+(\w+)`),   // SQLite
 		regexp.MustCompile(`using\s+(\w+)`),                  // SQLite EXPLAIN QUERY PLAN
 		regexp.MustCompile(`Index Scan using (\w+)`),         // PostgreSQL
 		regexp.MustCompile(`Index Only Scan using (\w+)`),    // PostgreSQL
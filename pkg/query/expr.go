@@ -0,0 +1,186 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// Expression is a raw SQL fragment with ? placeholders bound to Args, as
+// Expr creates it.
+type Expression struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Expr creates a condition from a raw SQL fragment whose values are bound
+// as arguments rather than written into the SQL, unlike RawSQL. ? marks
+// each argument, in order, outside quoted strings, identifiers and
+// comments; it becomes the placeholder of the dialect. PostgreSQL's ?|
+// and ?& operators are left as they are, and its ? operator is written ??.
+//
+// Example:
+//
+//	orders.Select("customer_id").GroupBy("customer_id").Having(query.Expr("sum(amount) > ?", 100))
+func Expr(sql string, args ...interface{}) Condition {
+	n := 0
+	for _, mark := range exprMarks(sql) {
+		if mark.placeholder {
+			n++
+		}
+	}
+	if n != len(args) {
+		panic(fmt.Sprintf("query: Expr %q has %d placeholders for %d arguments", sql, n, len(args)))
+	}
+	return Condition{Operator: "EXPR", Value: Expression{SQL: sql, Args: args}}
+}
+
+// exprMark is a ? of Expr SQL that is rewritten: a placeholder, or the
+// ?? that stands for PostgreSQL's ? operator.
+type exprMark struct {
+	offset      int
+	placeholder bool
+}
+
+// exprMarks returns the ? of sql that are rewritten, skipping quoted
+// strings and identifiers, comments, and the ?| and ?& operators.
+func exprMarks(sql string) []exprMark {
+	var marks []exprMark
+	for i := 0; i < len(sql); i++ {
+		var open, close string
+		switch c := sql[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			open, close = sql[i:i+1], sql[i:i+1]
+		case strings.HasPrefix(sql[i:], "--"):
+			open, close = "--", "\n"
+		case strings.HasPrefix(sql[i:], "/*"):
+			open, close = "/*", "*/"
+		case c == '?':
+			next := sql[i+1:]
+			switch {
+			case strings.HasPrefix(next, "?"):
+				marks = append(marks, exprMark{offset: i})
+				i++
+			case strings.HasPrefix(next, "&") || strings.HasPrefix(next, "|") && !strings.HasPrefix(next, "||"):
+				i++
+			default:
+				marks = append(marks, exprMark{offset: i, placeholder: true})
+			}
+			continue
+		default:
+			continue
+		}
+		end := strings.Index(sql[i+len(open):], close)
+		if end < 0 {
+			return marks
+		}
+		i += len(open) + end + len(close) - 1
+	}
+	return marks
+}
+
+// buildExpr renders an Expr condition with placeholders numbered from
+// argIndex, and returns its arguments.
+func buildExpr(dialect dialects.Dialect, cond Condition, argIndex int) (string, []interface{}) {
+	expr := cond.Value.(Expression)
+	var out strings.Builder
+	last, n := 0, 0
+	for _, mark := range exprMarks(expr.SQL) {
+		out.WriteString(expr.SQL[last:mark.offset])
+		if mark.placeholder {
+			out.WriteString(dialect.Placeholder(argIndex + n))
+			n++
+			last = mark.offset + 1
+		} else {
+			out.WriteString("?")
+			last = mark.offset + 2
+		}
+	}
+	out.WriteString(expr.SQL[last:])
+	return "(" + out.String() + ")", expr.Args
+}
+
+// aggregateFunctions and aggregateOperators are those Aggregate accepts;
+// both are written into the SQL as they are.
+var (
+	aggregateFunctions = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+	aggregateOperators = map[string]bool{"=": true, "!=": true, ">": true, ">=": true, "<": true, "<=": true}
+)
+
+// Aggregate creates a condition comparing an aggregate of column, such as
+// SUM(amount) or COUNT(*), for Having. function is COUNT, SUM, AVG, MIN or
+// MAX, and operator one of =, !=, >, >=, < and <=; it panics on others.
+func Aggregate(function, column, operator string, value interface{}) Condition {
+	function = strings.ToUpper(function)
+	if !aggregateFunctions[function] {
+		panic(fmt.Sprintf("query: Aggregate function %q is not COUNT, SUM, AVG, MIN or MAX", function))
+	}
+	if !aggregateOperators[operator] {
+		panic(fmt.Sprintf("query: Aggregate operator %q is not a comparison", operator))
+	}
+	return Condition{Aggregate: function, Column: column, Operator: operator, Value: value}
+}
+
+// CountEq creates a COUNT(column) = value condition; column may be "*".
+func CountEq(column string, value interface{}) Condition {
+	return Aggregate("COUNT", column, "=", value)
+}
+
+// CountGt creates a COUNT(column) > value condition.
+func CountGt(column string, value interface{}) Condition {
+	return Aggregate("COUNT", column, ">", value)
+}
+
+// CountGte creates a COUNT(column) >= value condition.
+func CountGte(column string, value interface{}) Condition {
+	return Aggregate("COUNT", column, ">=", value)
+}
+
+// CountLt creates a COUNT(column) < value condition.
+func CountLt(column string, value interface{}) Condition {
+	return Aggregate("COUNT", column, "<", value)
+}
+
+// CountLte creates a COUNT(column) <= value condition.
+func CountLte(column string, value interface{}) Condition {
+	return Aggregate("COUNT", column, "<=", value)
+}
+
+// SumEq creates a SUM(column) = value condition.
+func SumEq(column string, value interface{}) Condition {
+	return Aggregate("SUM", column, "=", value)
+}
+
+// SumGt creates a SUM(column) > value condition.
+func SumGt(column string, value interface{}) Condition {
+	return Aggregate("SUM", column, ">", value)
+}
+
+// SumGte creates a SUM(column) >= value condition.
+func SumGte(column string, value interface{}) Condition {
+	return Aggregate("SUM", column, ">=", value)
+}
+
+// SumLt creates a SUM(column) < value condition.
+func SumLt(column string, value interface{}) Condition {
+	return Aggregate("SUM", column, "<", value)
+}
+
+// SumLte creates a SUM(column) <= value condition.
+func SumLte(column string, value interface{}) Condition {
+	return Aggregate("SUM", column, "<=", value)
+}
+
+// conditionColumn returns the quoted column of a condition, wrapped in
+// its aggregate function if it has one.
+func conditionColumn(dialect dialects.Dialect, cond Condition) string {
+	if cond.Aggregate == "" {
+		return quoteColumnRef(dialect, cond.Column)
+	}
+	column := cond.Column
+	if column != "*" {
+		column = quoteColumnRef(dialect, column)
+	}
+	return cond.Aggregate + "(" + column + ")"
+}
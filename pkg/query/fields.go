@@ -0,0 +1,303 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	nxerr "github.com/nexus-db/nexus/pkg/errors"
+)
+
+// FieldPlan is a validated sparse fieldset for a model, produced by ParseFields.
+// Example: "id,name,posts(title,created_at)" selects id and name from the
+// root model and eager loads the Post relation with only title and created_at.
+type FieldPlan struct {
+	Model    *schema.Model
+	Relation *schema.Relation // Set on nested plans; nil for the root
+	Fields   []string         // Requested columns; nil means all columns
+	Includes []*FieldPlan
+}
+
+// ParseFields parses a fields expression against the named model.
+// Field names must exist on the model; relations may be given by their
+// name ("author"), or by the target model ("Post") or its table name
+// ("posts") where only one relation targets it. Only one level of nesting
+// is supported, matching what Include can eager load.
+func ParseFields(sch *schema.Schema, modelName, expr string) (*FieldPlan, error) {
+	model, ok := sch.Models[modelName]
+	if !ok {
+		model = findModelByTable(sch, modelName)
+	}
+	if model == nil {
+		return nil, nxerr.NewQueryError(nxerr.ErrQueryInvalidFields,
+			fmt.Sprintf("unknown model '%s'", modelName))
+	}
+
+	p := &fieldsParser{input: expr}
+	items, err := p.parseList(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.input) {
+		return nil, p.errorf(p.pos, "unexpected '%c'", p.input[p.pos])
+	}
+
+	return buildFieldPlan(sch, model, nil, items, 0)
+}
+
+// Select creates a SelectBuilder for the plan on b, including any columns
+// needed to join the requested relations.
+func (p *FieldPlan) Select(b *Builder) *SelectBuilder {
+	var columns []string
+	if p.Fields != nil {
+		columns = append(columns, p.Fields...)
+		for _, inc := range p.Includes {
+			key := inc.Relation.ReferenceKey
+			if inc.Relation.Type == schema.RelationBelongsTo {
+				key = inc.Relation.ForeignKey
+			}
+			columns = appendUnique(columns, key)
+		}
+	}
+
+	sb := b.Select(columns...)
+	for _, inc := range p.Includes {
+		sb.Include(inc.key())
+	}
+	return sb
+}
+
+// key returns the name the relation of a nested plan is included by,
+// which its records are stored under.
+func (p *FieldPlan) key() string {
+	return relationKey(p.Relation, p.Relation.Name)
+}
+
+// Project trims results down to the requested fields, removing join columns
+// that Select added and unrequested columns of included relations.
+func (p *FieldPlan) Project(results Results) Results {
+	for i := range results {
+		results[i] = p.projectOne(results[i])
+	}
+	return results
+}
+
+func (p *FieldPlan) projectOne(r Result) Result {
+	if r == nil {
+		return nil
+	}
+
+	out := Result{}
+	if p.Fields == nil {
+		for k, v := range r {
+			out[k] = v
+		}
+	} else {
+		for _, f := range p.Fields {
+			if v, ok := r[f]; ok {
+				out[f] = v
+			}
+		}
+	}
+
+	for _, inc := range p.Includes {
+		key := inc.key()
+		switch v := r[key].(type) {
+		case Result:
+			out[key] = inc.projectOne(v)
+		case Results:
+			out[key] = inc.Project(v)
+		}
+	}
+
+	return out
+}
+
+// fieldItem is a parsed entry of a fields expression.
+type fieldItem struct {
+	name     string
+	pos      int
+	children []fieldItem // non-nil when the item had a parenthesized list
+}
+
+// fieldsParser is a small recursive-descent parser for fields expressions.
+type fieldsParser struct {
+	input string
+	pos   int
+}
+
+func (p *fieldsParser) parseList(depth int) ([]fieldItem, error) {
+	var items []fieldItem
+	for {
+		p.skipSpace()
+		start := p.pos
+		for p.pos < len(p.input) && isFieldChar(p.input[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start {
+			if p.pos >= len(p.input) {
+				return nil, p.errorf(p.pos, "expected field name")
+			}
+			return nil, p.errorf(p.pos, "expected field name, got '%c'", p.input[p.pos])
+		}
+
+		item := fieldItem{name: p.input[start:p.pos], pos: start}
+		p.skipSpace()
+
+		if p.pos < len(p.input) && p.input[p.pos] == '(' {
+			p.pos++
+			children, err := p.parseList(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+				return nil, p.errorf(p.pos, "missing ')' for '%s'", item.name)
+			}
+			p.pos++
+			item.children = children
+			p.skipSpace()
+		}
+
+		items = append(items, item)
+
+		if p.pos < len(p.input) && p.input[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if depth > 0 || p.pos >= len(p.input) {
+			return items, nil
+		}
+		return nil, p.errorf(p.pos, "expected ',' or end of input, got '%c'", p.input[p.pos])
+	}
+}
+
+func (p *fieldsParser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *fieldsParser) errorf(pos int, format string, args ...interface{}) error {
+	e := nxerr.NewQueryError(nxerr.ErrQueryInvalidFields, fmt.Sprintf(format, args...))
+	e.Context = p.input
+	return e.WithColumn(pos + 1)
+}
+
+func isFieldChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// buildFieldPlan validates parsed items against the model.
+func buildFieldPlan(sch *schema.Schema, model *schema.Model, rel *schema.Relation, items []fieldItem, depth int) (*FieldPlan, error) {
+	plan := &FieldPlan{Model: model, Relation: rel}
+
+	for _, item := range items {
+		if item.children == nil {
+			if _, ok := model.Fields[item.name]; ok {
+				plan.Fields = appendUnique(plan.Fields, item.name)
+				continue
+			}
+		}
+
+		target, candidates := findFieldRelation(model, item.name)
+		if len(candidates) > 1 {
+			return nil, nxerr.NewQueryError(nxerr.ErrQueryInvalidFields,
+				fmt.Sprintf("relation '%s' on %s is ambiguous, use one of %s", item.name, model.Name, strings.Join(candidates, ", "))).
+				WithColumn(item.pos + 1)
+		}
+		if target == nil {
+			return nil, unknownFieldError(model, item)
+		}
+		if depth > 0 {
+			return nil, nxerr.NewQueryError(nxerr.ErrQueryInvalidFields,
+				fmt.Sprintf("nested relation '%s' on %s is not supported (only one level of includes)", item.name, model.Name)).
+				WithColumn(item.pos + 1)
+		}
+
+		targetModel, ok := sch.Models[target.TargetModel]
+		if !ok {
+			return nil, nxerr.NewQueryError(nxerr.ErrQueryInvalidFields,
+				fmt.Sprintf("relation '%s' targets unknown model '%s'", item.name, target.TargetModel))
+		}
+
+		sub, err := buildFieldPlan(sch, targetModel, target, item.children, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		plan.Includes = append(plan.Includes, sub)
+	}
+
+	return plan, nil
+}
+
+// findFieldRelation finds a relation by its name, as findRelation does,
+// else by target model name or its table name. When several relations
+// target the model, none is returned but their names are.
+func findFieldRelation(model *schema.Model, name string) (*schema.Relation, []string) {
+	for _, rel := range model.GetRelations() {
+		if rel.Name != "" && strings.EqualFold(rel.Name, name) {
+			return rel, nil
+		}
+	}
+	var matches []*schema.Relation
+	for _, rel := range model.GetRelations() {
+		if strings.EqualFold(rel.TargetModel, name) || strings.EqualFold(toTableName(rel.TargetModel), name) {
+			matches = append(matches, rel)
+		}
+	}
+	if len(matches) > 1 {
+		names := make([]string, len(matches))
+		for i, rel := range matches {
+			names[i] = fieldRelationName(rel)
+		}
+		return nil, names
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return nil, nil
+}
+
+// fieldRelationName returns the name a fields expression best refers to
+// rel by: its name, else the table of its target model.
+func fieldRelationName(rel *schema.Relation) string {
+	if rel.Name != "" {
+		return rel.Name
+	}
+	return toTableName(rel.TargetModel)
+}
+
+// unknownFieldError reports a field that is neither a column nor a relation.
+func unknownFieldError(model *schema.Model, item fieldItem) error {
+	var options []string
+	for _, f := range model.GetFields() {
+		options = append(options, f.Name)
+	}
+	for _, rel := range model.GetRelations() {
+		options = append(options, fieldRelationName(rel))
+	}
+
+	kind := "field"
+	if item.children != nil {
+		kind = "relation"
+	}
+
+	e := nxerr.NewQueryError(nxerr.ErrQueryInvalidFields,
+		fmt.Sprintf("unknown %s '%s' on %s", kind, item.name, model.Name)).
+		WithColumn(item.pos + 1)
+	if suggestion := nxerr.SuggestSimilar(item.name, options); suggestion != "" {
+		e.WithSuggestion(suggestion)
+	}
+	return e
+}
+
+// appendUnique appends s to list if it is not already present.
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
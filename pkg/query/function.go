@@ -0,0 +1,93 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// FuncCall is a call of a database function, for use in a SELECT list.
+type FuncCall struct {
+	name  string
+	args  []interface{}
+	alias string
+}
+
+// ColumnRef is a function argument naming a column rather than a value.
+type ColumnRef string
+
+// Col refers to a column as a function argument.
+func Col(name string) ColumnRef {
+	return ColumnRef(name)
+}
+
+// CallFunction calls a database function with args, which are sent as
+// parameters unless they are a Col or another CallFunction. The result
+// column is named after the function unless As renames it.
+// Example: b.Select("id").SelectFunc(query.CallFunction("slugify", query.Col("title")).As("slug"))
+func CallFunction(name string, args ...interface{}) *FuncCall {
+	return &FuncCall{name: name, args: args}
+}
+
+// As names the result column.
+func (f *FuncCall) As(alias string) *FuncCall {
+	f.alias = alias
+	return f
+}
+
+// build renders the call with placeholders starting at argIndex.
+func (f *FuncCall) build(dialect dialects.Dialect, argIndex int) (string, []interface{}) {
+	var args []interface{}
+	parts := make([]string, len(f.args))
+	for i, arg := range f.args {
+		switch v := arg.(type) {
+		case ColumnRef:
+			parts[i] = quoteColumnRef(dialect, string(v))
+		case *FuncCall:
+			inner := *v
+			inner.alias = ""
+			sql, innerArgs := inner.build(dialect, argIndex+len(args))
+			parts[i] = sql
+			args = append(args, innerArgs...)
+		default:
+			args = append(args, v)
+			parts[i] = dialect.Placeholder(argIndex + len(args) - 1)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", f.name, strings.Join(parts, ", ")), args
+}
+
+// selectSQL renders the call as a SELECT list item.
+func (f *FuncCall) selectSQL(dialect dialects.Dialect, argIndex int) (string, []interface{}) {
+	sql, args := f.build(dialect, argIndex)
+	alias := f.alias
+	if alias == "" {
+		alias = f.name[strings.LastIndex(f.name, ".")+1:]
+	}
+	return sql + " AS " + dialect.Quote(alias), args
+}
+
+// quoteColumnRef quotes a column reference, which may be qualified with
+// its table, as in users.id.
+func quoteColumnRef(dialect dialects.Dialect, name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = dialect.Quote(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// SelectFunc adds function calls to the SELECT list, after the columns.
+func (s *SelectBuilder) SelectFunc(calls ...*FuncCall) *SelectBuilder {
+	s.calls = append(s.calls, calls...)
+	return s
+}
+
+// CallProcedure runs a stored procedure with CALL.
+func CallProcedure(ctx context.Context, conn *dialects.Connection, name string, args ...interface{}) error {
+	sql, params := CallFunction(name, args...).build(conn.Dialect, 1)
+	_, err := conn.Exec(ctx, "CALL "+sql, params...)
+	return err
+}
@@ -3,8 +3,10 @@ package query
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
 )
 
@@ -12,11 +14,15 @@ import (
 type InsertBuilder struct {
 	conn       *dialects.Connection
 	tableName  string
+	namespace  string
 	data       map[string]interface{}
 	returning  []string
 	onConflict *conflictClause
 	batchData  []map[string]interface{}
+	schema     *schema.Schema
 	profiler   *Profiler
+	authorizer Authorizer
+	goTypes    map[string]string
 }
 
 type conflictClause struct {
@@ -64,11 +70,12 @@ func (i *InsertBuilder) Build() (string, []interface{}) {
 	var args []interface{}
 	argIndex := 1
 
-	// Get columns from first data row
+	// Get columns from first data row, sorted so the SQL is stable
 	var columns []string
 	for col := range i.data {
 		columns = append(columns, col)
 	}
+	sort.Strings(columns)
 
 	// Quote columns
 	quotedCols := make([]string, len(columns))
@@ -83,18 +90,30 @@ func (i *InsertBuilder) Build() (string, []interface{}) {
 		allData = []map[string]interface{}{i.data}
 	}
 
+	convs := columnConverters(i.schema, i.tableName, i.goTypes)
 	for _, row := range allData {
 		placeholders := make([]string, len(columns))
 		for idx, col := range columns {
 			placeholders[idx] = dialect.Placeholder(argIndex)
-			args = append(args, row[col])
+			args = append(args, convertArg(convs, col, row[col]))
 			argIndex++
 		}
 		valueSets = append(valueSets, "("+strings.Join(placeholders, ", ")+")")
 	}
 
+	// Dialects without ON CONFLICT write the whole upsert
+	if upserter, ok := dialect.(dialects.Upserter); ok && i.onConflict != nil {
+		var updates []string
+		for _, col := range sortedKeys(i.onConflict.doUpdate) {
+			updates = append(updates, fmt.Sprintf("%s = %s", dialect.Quote(col), dialect.Placeholder(argIndex)))
+			args = append(args, convertArg(convs, col, i.onConflict.doUpdate[col]))
+			argIndex++
+		}
+		return upserter.UpsertSQL(quoteTable(dialect, i.namespace, i.tableName), columns, valueSets, i.onConflict.columns, updates), args
+	}
+
 	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
-		dialect.Quote(i.tableName),
+		quoteTable(dialect, i.namespace, i.tableName),
 		strings.Join(quotedCols, ", "),
 		strings.Join(valueSets, ", "))
 
@@ -109,9 +128,9 @@ func (i *InsertBuilder) Build() (string, []interface{}) {
 			sql += fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(conflictCols, ", "))
 		} else if i.onConflict.doUpdate != nil {
 			updates := make([]string, 0, len(i.onConflict.doUpdate))
-			for col, val := range i.onConflict.doUpdate {
+			for _, col := range sortedKeys(i.onConflict.doUpdate) {
 				updates = append(updates, fmt.Sprintf("%s = %s", dialect.Quote(col), dialect.Placeholder(argIndex)))
-				args = append(args, val)
+				args = append(args, convertArg(convs, col, i.onConflict.doUpdate[col]))
 				argIndex++
 			}
 			sql += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s",
@@ -138,12 +157,19 @@ func (i *InsertBuilder) Build() (string, []interface{}) {
 
 // Exec executes the insert and returns the number of affected rows.
 func (i *InsertBuilder) Exec(ctx context.Context) (int64, error) {
+	if err := authorize(ctx, i.authorizer, i.schema, i.tableName, OpInsert); err != nil {
+		return 0, err
+	}
+	if err := validateRows(i.schema, i.tableName, i.rows()...); err != nil {
+		return 0, err
+	}
+
 	query, args := i.Build()
 
 	// Start profiling if enabled
 	var profile *QueryProfile
 	if i.profiler != nil && i.profiler.IsEnabled() {
-		profile = i.profiler.StartQuery(query, args)
+		profile = i.profiler.StartQueryContext(ctx, query, args)
 	}
 
 	result, err := i.conn.Exec(ctx, query, args...)
@@ -165,6 +191,13 @@ func (i *InsertBuilder) Exec(ctx context.Context) (int64, error) {
 
 // One executes the insert and returns the inserted row (requires RETURNING).
 func (i *InsertBuilder) One(ctx context.Context) (Result, error) {
+	if err := authorize(ctx, i.authorizer, i.schema, i.tableName, OpInsert); err != nil {
+		return nil, err
+	}
+	if err := validateRows(i.schema, i.tableName, i.rows()...); err != nil {
+		return nil, err
+	}
+
 	if !i.conn.Dialect.SupportsReturning() {
 		return nil, fmt.Errorf("dialect %s does not support RETURNING clause", i.conn.Dialect.Name())
 	}
@@ -180,7 +213,7 @@ func (i *InsertBuilder) One(ctx context.Context) (Result, error) {
 	}
 	defer rows.Close()
 
-	results, err := scanRows(rows)
+	results, err := scanConverted(rows, columnConverters(i.schema, i.tableName, i.goTypes))
 	if err != nil {
 		return nil, err
 	}
@@ -193,6 +226,13 @@ func (i *InsertBuilder) One(ctx context.Context) (Result, error) {
 // LastInsertId executes the insert and returns the last insert ID.
 // For PostgreSQL, use One() with RETURNING instead.
 func (i *InsertBuilder) LastInsertId(ctx context.Context) (int64, error) {
+	if err := authorize(ctx, i.authorizer, i.schema, i.tableName, OpInsert); err != nil {
+		return 0, err
+	}
+	if err := validateRows(i.schema, i.tableName, i.rows()...); err != nil {
+		return 0, err
+	}
+
 	query, args := i.Build()
 	result, err := i.conn.Exec(ctx, query, args...)
 	if err != nil {
@@ -200,3 +240,13 @@ func (i *InsertBuilder) LastInsertId(ctx context.Context) (int64, error) {
 	}
 	return result.LastInsertId()
 }
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,134 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// Iterator streams the rows of a query one at a time, for result sets too
+// large to load with All. Includes are not loaded.
+//
+//	it, err := query.New(conn, "users").Select().Iter(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		row := it.Result()
+//	}
+//	return it.Err()
+type Iterator struct {
+	rows    *sql.Rows
+	columns []string
+	current Result
+	err     error
+	end     func(rows int, err error)
+	count   int
+	mask    *schema.Model // Model whose @pii fields are masked, if masked
+	schema  *schema.Schema
+	convs   map[string]Converter
+}
+
+// Iter executes the query and returns an iterator over its rows. The
+// iterator must be closed.
+func (s *SelectBuilder) Iter(ctx context.Context) (*Iterator, error) {
+	if err := authorize(ctx, s.authorizer, s.schema, s.tableName, OpSelect); err != nil {
+		return nil, err
+	}
+	if err := checkRegexp(ctx, s.conn, s.conditions, s.having); err != nil {
+		return nil, err
+	}
+
+	query, args := s.Build()
+
+	it := &Iterator{convs: columnConverters(s.schema, s.tableName, s.goTypes)}
+	if s.masked {
+		it.mask, it.schema = findModelByTable(s.schema, s.tableName), s.schema
+	}
+	if s.profiler != nil && s.profiler.IsEnabled() {
+		profile := s.profiler.StartQueryContext(ctx, query, args)
+		it.end = func(rows int, err error) {
+			profile.RowsReturned = rows
+			s.profiler.EndQuery(profile, err)
+		}
+	}
+
+	rows, err := s.conn.Query(ctx, query, args...)
+	if err != nil {
+		if it.end != nil {
+			it.end(0, err)
+		}
+		return nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		if it.end != nil {
+			it.end(0, err)
+		}
+		return nil, err
+	}
+
+	it.rows = rows
+	it.columns = columns
+	return it, nil
+}
+
+// Columns returns the column names of the rows.
+func (it *Iterator) Columns() []string {
+	return it.columns
+}
+
+// Next advances to the next row. It returns false at the end or on error;
+// check Err afterwards.
+func (it *Iterator) Next() bool {
+	if it.err != nil || !it.rows.Next() {
+		return false
+	}
+
+	values := make([]interface{}, len(it.columns))
+	valuePtrs := make([]interface{}, len(it.columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	if err := it.rows.Scan(valuePtrs...); err != nil {
+		it.err = err
+		return false
+	}
+
+	row := make(Result, len(it.columns))
+	for i, col := range it.columns {
+		row[col] = values[i]
+	}
+	if err := convertResults(it.convs, Results{row}); err != nil {
+		it.err = err
+		return false
+	}
+	it.current = maskRow(it.schema, it.mask, row)
+	it.count++
+	return true
+}
+
+// Result returns the current row.
+func (it *Iterator) Result() Result {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.rows.Err()
+}
+
+// Close releases the rows. It is safe to call more than once.
+func (it *Iterator) Close() error {
+	if it.end != nil {
+		it.end(it.count, it.Err())
+		it.end = nil
+	}
+	return it.rows.Close()
+}
@@ -0,0 +1,92 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// Join adds a table the update reads from, matched to the updated rows by
+// condition, as in Join("orders", "orders.user_id = users.id"). Conditions
+// added with Where may refer to the columns of joined tables. It becomes
+// UPDATE ... FROM on PostgreSQL and SQL Server, a multi-table UPDATE on
+// MySQL, and on SQLite a subquery selecting the rows by primary key, or
+// rowid without a schema.
+func (u *UpdateBuilder) Join(table, condition string) *UpdateBuilder {
+	u.joins = append(u.joins, joinClause{joinType: "INNER", table: table, condition: condition})
+	return u
+}
+
+// Join adds a table whose rows select the rows to delete, matched by
+// condition, as in Join("users", "users.id = sessions.user_id"). Conditions
+// added with Where may refer to the columns of joined tables. It becomes
+// DELETE ... USING on PostgreSQL, a multi-table DELETE on MySQL and SQL
+// Server, and on SQLite a subquery selecting the rows by primary key, or
+// rowid without a schema.
+func (d *DeleteBuilder) Join(table, condition string) *DeleteBuilder {
+	d.joins = append(d.joins, joinClause{joinType: "INNER", table: table, condition: condition})
+	return d
+}
+
+// joinedTables returns the quoted tables of joins, comma-separated, and
+// conditions preceded by the join conditions.
+func joinedTables(dialect dialects.Dialect, joins []joinClause, conditions []Condition) (string, []Condition) {
+	tables := make([]string, len(joins))
+	joined := make([]Condition, 0, len(joins)+len(conditions))
+	for i, join := range joins {
+		tables[i] = dialect.Quote(join.table)
+		joined = append(joined, RawSQL("("+join.condition+")"))
+	}
+	return strings.Join(tables, ", "), append(joined, conditions...)
+}
+
+// keySubquery renders, for SQLite, the condition selecting the rows of
+// table, quoted, that match conditions with joins, which SQLite's UPDATE
+// and DELETE have no syntax for. The rows are matched by their primary key
+// columns, key, or without them by rowid, which WITHOUT ROWID tables lack.
+func keySubquery(dialect dialects.Dialect, quoted string, key []string, joins []joinClause, conditions []Condition, argIndex int) (string, []interface{}) {
+	tables, conds := joinedTables(dialect, joins, conditions)
+	whereSQL, args := buildWhere(dialect, conds, argIndex)
+	columns, selected := "rowid", quoted+".rowid"
+	if len(key) > 0 {
+		quotedKey := make([]string, len(key))
+		qualified := make([]string, len(key))
+		for i, col := range key {
+			quotedKey[i] = dialect.Quote(col)
+			qualified[i] = quoted + "." + quotedKey[i]
+		}
+		columns, selected = strings.Join(quotedKey, ", "), strings.Join(qualified, ", ")
+		if len(key) > 1 {
+			columns = "(" + columns + ")"
+		}
+	}
+	return fmt.Sprintf("WHERE %s IN (SELECT %s FROM %s, %s %s)", columns, selected, quoted, tables, whereSQL), args
+}
+
+// primaryKeyColumns returns the primary key columns of the model of
+// tableName, or nil without a schema or model.
+func primaryKeyColumns(sch *schema.Schema, tableName string) []string {
+	model := findModelByTable(sch, tableName)
+	if model == nil {
+		return nil
+	}
+	var key []string
+	for _, field := range model.GetFields() {
+		if field.IsPrimaryKey {
+			key = append(key, field.Name)
+		}
+	}
+	return key
+}
+
+// rowidError explains the failure of a joined UPDATE or DELETE matched by
+// rowid on a SQLite table without one.
+func rowidError(err error, tableName string) error {
+	if err != nil && strings.Contains(err.Error(), "no such column") && strings.Contains(err.Error(), "rowid") {
+		return fmt.Errorf("table %s has no rowid: a joined UPDATE or DELETE on SQLite matches its rows by primary key, "+
+			"which needs the table's model; use NewWithSchema: %w", tableName, err)
+	}
+	return err
+}
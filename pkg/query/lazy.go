@@ -13,11 +13,12 @@ import (
 // Unlike eager loading which fetches all relations upfront, lazy loading
 // defers relation queries until GetRelation() is called.
 type LazyResult struct {
-	data      Result                 // Base data
-	conn      *dialects.Connection   // For database queries
-	schema    *schema.Schema         // For relation lookups
-	tableName string                 // Source table name
-	loaded    map[string]interface{} // Cache for loaded relations
+	data       Result                 // Base data
+	conn       *dialects.Connection   // For database queries
+	schema     *schema.Schema         // For relation lookups
+	tableName  string                 // Source table name
+	loaded     map[string]interface{} // Cache for loaded relations
+	authorizer Authorizer             // Optional access control hook
 }
 
 // NewLazyResult creates a new LazyResult from a Result.
@@ -31,6 +32,18 @@ func NewLazyResult(data Result, conn *dialects.Connection, sch *schema.Schema, t
 	}
 }
 
+// WithAuthorizer attaches an authorizer that is consulted before every
+// relation is loaded, and by the results it loads in turn.
+func (lr *LazyResult) WithAuthorizer(a Authorizer) *LazyResult {
+	lr.authorizer = a
+	return lr
+}
+
+// related wraps a loaded row of table, with the authorizer of lr.
+func (lr *LazyResult) related(data Result, table string) *LazyResult {
+	return NewLazyResult(data, lr.conn, lr.schema, table).WithAuthorizer(lr.authorizer)
+}
+
 // Get returns a field value from the result.
 func (lr *LazyResult) Get(key string) interface{} {
 	return lr.data[key]
@@ -97,25 +110,7 @@ func (lr *LazyResult) GetRelation(ctx context.Context, name string) (interface{}
 
 // findModel finds the schema model for this result.
 func (lr *LazyResult) findModel() *schema.Model {
-	if lr.schema == nil {
-		return nil
-	}
-
-	// Direct lookup
-	if model, exists := lr.schema.Models[lr.tableName]; exists {
-		return model
-	}
-
-	// Try case-insensitive and plural matching
-	for name, model := range lr.schema.Models {
-		if strings.EqualFold(name, lr.tableName) ||
-			strings.EqualFold(name+"s", lr.tableName) ||
-			strings.EqualFold(name, strings.TrimSuffix(lr.tableName, "s")) {
-			return model
-		}
-	}
-
-	return nil
+	return findModelByTable(lr.schema, lr.tableName)
 }
 
 // loadBelongsTo loads a parent record for a BelongsTo relation.
@@ -125,7 +120,7 @@ func (lr *LazyResult) loadBelongsTo(ctx context.Context, rel *schema.Relation) (
 		return nil, nil
 	}
 
-	targetTable := toTableName(rel.TargetModel)
+	targetTable := relatedTable(lr.schema, rel.TargetModel)
 	related, err := lr.queryOne(ctx, targetTable, rel.ReferenceKey, fkValue)
 	if err != nil {
 		return nil, err
@@ -135,7 +130,7 @@ func (lr *LazyResult) loadBelongsTo(ctx context.Context, rel *schema.Relation) (
 		return nil, nil
 	}
 
-	return NewLazyResult(related, lr.conn, lr.schema, targetTable), nil
+	return lr.related(related, targetTable), nil
 }
 
 // loadHasMany loads child records for a HasMany relation.
@@ -145,7 +140,7 @@ func (lr *LazyResult) loadHasMany(ctx context.Context, rel *schema.Relation) (La
 		return LazyResults{}, nil
 	}
 
-	targetTable := toTableName(rel.TargetModel)
+	targetTable := relatedTable(lr.schema, rel.TargetModel)
 	related, err := lr.queryMany(ctx, targetTable, rel.ForeignKey, pkValue)
 	if err != nil {
 		return nil, err
@@ -153,7 +148,7 @@ func (lr *LazyResult) loadHasMany(ctx context.Context, rel *schema.Relation) (La
 
 	results := make(LazyResults, len(related))
 	for i, r := range related {
-		results[i] = NewLazyResult(r, lr.conn, lr.schema, targetTable)
+		results[i] = lr.related(r, targetTable)
 	}
 
 	return results, nil
@@ -166,7 +161,7 @@ func (lr *LazyResult) loadHasOne(ctx context.Context, rel *schema.Relation) (*La
 		return nil, nil
 	}
 
-	targetTable := toTableName(rel.TargetModel)
+	targetTable := relatedTable(lr.schema, rel.TargetModel)
 	related, err := lr.queryOne(ctx, targetTable, rel.ForeignKey, pkValue)
 	if err != nil {
 		return nil, err
@@ -176,17 +171,22 @@ func (lr *LazyResult) loadHasOne(ctx context.Context, rel *schema.Relation) (*La
 		return nil, nil
 	}
 
-	return NewLazyResult(related, lr.conn, lr.schema, targetTable), nil
+	return lr.related(related, targetTable), nil
 }
 
 // queryOne executes a query that returns at most one result.
 func (lr *LazyResult) queryOne(ctx context.Context, table, column string, value interface{}) (Result, error) {
+	if err := authorize(ctx, lr.authorizer, lr.schema, table, OpSelect); err != nil {
+		return nil, err
+	}
 	dialect := lr.conn.Dialect
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = %s LIMIT 1",
-		dialect.Quote(table),
-		dialect.Quote(column),
-		dialect.Placeholder(1))
+	quoted, err := quoteIdentifiers(dialect, table, column)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = %s",
+		quoted[0], quoted[1], dialect.Placeholder(1)) + dialects.LimitClause(dialect, 1, 0, false)
 
 	rows, err := lr.conn.Query(ctx, query, value)
 	if err != nil {
@@ -208,12 +208,17 @@ func (lr *LazyResult) queryOne(ctx context.Context, table, column string, value
 
 // queryMany executes a query that returns multiple results.
 func (lr *LazyResult) queryMany(ctx context.Context, table, column string, value interface{}) (Results, error) {
+	if err := authorize(ctx, lr.authorizer, lr.schema, table, OpSelect); err != nil {
+		return nil, err
+	}
 	dialect := lr.conn.Dialect
 
+	quoted, err := quoteIdentifiers(dialect, table, column)
+	if err != nil {
+		return nil, err
+	}
 	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = %s",
-		dialect.Quote(table),
-		dialect.Quote(column),
-		dialect.Placeholder(1))
+		quoted[0], quoted[1], dialect.Placeholder(1))
 
 	rows, err := lr.conn.Query(ctx, query, value)
 	if err != nil {
@@ -243,14 +248,23 @@ func (lr *LazyResult) loadBelongsToMany(ctx context.Context, rel *schema.Relatio
 		return LazyResults{}, nil
 	}
 
+	// The junction table and the target table are both read
+	targetTable := relatedTable(lr.schema, rel.TargetModel)
+	for _, table := range []string{rel.Through, targetTable} {
+		if err := authorize(ctx, lr.authorizer, lr.schema, table, OpSelect); err != nil {
+			return nil, err
+		}
+	}
+
 	dialect := lr.conn.Dialect
 
 	// Query junction table
+	quoted, err := quoteIdentifiers(dialect, rel.ThroughTargetKey, rel.Through, rel.ThroughSourceKey)
+	if err != nil {
+		return nil, err
+	}
 	junctionQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s",
-		dialect.Quote(rel.ThroughTargetKey),
-		dialect.Quote(rel.Through),
-		dialect.Quote(rel.ThroughSourceKey),
-		dialect.Placeholder(1))
+		quoted[0], quoted[1], quoted[2], dialect.Placeholder(1))
 
 	junctionRows, err := lr.conn.Query(ctx, junctionQuery, pkValue)
 	if err != nil {
@@ -280,14 +294,17 @@ func (lr *LazyResult) loadBelongsToMany(ctx context.Context, rel *schema.Relatio
 	}
 
 	// Query target table
-	targetTable := toTableName(rel.TargetModel)
 	placeholders := make([]string, len(targetIDs))
 	for i := range targetIDs {
 		placeholders[i] = dialect.Placeholder(i + 1)
 	}
 
+	quotedTarget, err := dialects.QuoteIdentifier(dialect, targetTable)
+	if err != nil {
+		return nil, err
+	}
 	targetQuery := fmt.Sprintf("SELECT * FROM %s WHERE %s IN (%s)",
-		dialect.Quote(targetTable),
+		quotedTarget,
 		dialect.Quote("id"),
 		strings.Join(placeholders, ", "))
 
@@ -304,8 +321,22 @@ func (lr *LazyResult) loadBelongsToMany(ctx context.Context, rel *schema.Relatio
 
 	results := make(LazyResults, len(targetResults))
 	for i, r := range targetResults {
-		results[i] = NewLazyResult(r, lr.conn, lr.schema, targetTable)
+		results[i] = lr.related(r, targetTable)
 	}
 
 	return results, nil
 }
+
+// quoteIdentifiers validates and quotes the table and column names of a
+// relation.
+func quoteIdentifiers(dialect dialects.Dialect, names ...string) ([]string, error) {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		q, err := dialects.QuoteIdentifier(dialect, name)
+		if err != nil {
+			return nil, err
+		}
+		quoted[i] = q
+	}
+	return quoted, nil
+}
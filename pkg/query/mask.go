@@ -0,0 +1,55 @@
+package query
+
+import (
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/mask"
+)
+
+// Masked makes the queries of the builder return the fields marked @pii
+// with fake values, as MaskedResults does, so that results shown in logs,
+// admin screens or exports don't leak personal data. It needs a schema.
+func (b *Builder) Masked() *Builder {
+	b.masked = true
+	return b
+}
+
+// MaskedResults returns copies of the rows of table with the fields
+// marked @pii in sch replaced with fake values, including those of loaded
+// relations. The same value masks to the same fake one within a process.
+// Columns that aren't fields of the model are kept as they are.
+func MaskedResults(sch *schema.Schema, table string, results Results) Results {
+	model := findModelByTable(sch, table)
+	if model == nil {
+		return results
+	}
+	masked := make(Results, len(results))
+	for i, row := range results {
+		masked[i] = maskRow(sch, model, row)
+	}
+	return masked
+}
+
+// maskRow returns a copy of row with the @pii fields of model masked.
+func maskRow(sch *schema.Schema, model *schema.Model, row Result) Result {
+	if model == nil || row == nil {
+		return row
+	}
+	masked := make(Result, len(row))
+	for key, value := range row {
+		if f := model.Fields[key]; f != nil {
+			if f.IsPII {
+				value = mask.Value(f, value)
+			}
+		} else if rel := findRelation(model, key); rel != nil {
+			table := relatedTable(sch, rel.TargetModel)
+			switch related := value.(type) {
+			case Result:
+				value = maskRow(sch, findModelByTable(sch, table), related)
+			case Results:
+				value = MaskedResults(sch, table, related)
+			}
+		}
+		masked[key] = value
+	}
+	return masked
+}
@@ -0,0 +1,113 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// buildMatch renders a BETWEEN, ILIKE or REGEXP condition in the SQL of
+// dialect, with placeholders numbered from argIndex, and returns its
+// arguments.
+func buildMatch(dialect dialects.Dialect, cond Condition, argIndex int) (string, []interface{}) {
+	column := conditionColumn(dialect, cond)
+	placeholder := dialect.Placeholder(argIndex)
+
+	switch cond.Operator {
+	case "BETWEEN":
+		bounds := cond.Value.([]interface{})
+		return fmt.Sprintf("%s BETWEEN %s AND %s", column, placeholder, dialect.Placeholder(argIndex+1)), bounds
+
+	case "ILIKE":
+		if dialect.Name() == "postgres" {
+			return fmt.Sprintf("%s ILIKE %s", column, placeholder), []interface{}{cond.Value}
+		}
+		return fmt.Sprintf("LOWER(%s) LIKE LOWER(%s)", column, placeholder), []interface{}{cond.Value}
+	}
+
+	// Matches are case-sensitive everywhere: MySQL's REGEXP follows the
+	// collation, so it is asked to with 'c'
+	switch dialect.Name() {
+	case "postgres":
+		return fmt.Sprintf("%s ~ %s", column, placeholder), []interface{}{cond.Value}
+	case "mysql":
+		return fmt.Sprintf("REGEXP_LIKE(%s, %s, 'c')", column, placeholder), []interface{}{cond.Value}
+	case "mssql":
+		// SQL Server 2025 only; queries return ErrRegexpUnsupported
+		return fmt.Sprintf("REGEXP_LIKE(%s, %s)", column, placeholder), []interface{}{cond.Value}
+	}
+	return fmt.Sprintf("%s REGEXP %s", column, placeholder), []interface{}{cond.Value}
+}
+
+// ErrNoRegexp is returned for queries with a Regexp condition on a SQLite
+// database without a REGEXP function.
+var ErrNoRegexp = errors.New("SQLite has no REGEXP function: load the regexp extension or register a regexp function with the driver")
+
+// ErrRegexpUnsupported is returned for queries with a Regexp condition on
+// SQL Server, which has no regular expressions before SQL Server 2025.
+var ErrRegexpUnsupported = errors.New("SQL Server has no regular expressions before SQL Server 2025: use Like, or Expr with REGEXP_LIKE on SQL Server 2025")
+
+// sqliteRegexp caches, by database, whether SQLite databases have a
+// REGEXP function.
+var sqliteRegexp sync.Map
+
+// checkRegexp returns ErrRegexpUnsupported if conditions, including those
+// of subqueries, use Regexp on SQL Server, and ErrNoRegexp if they do on a
+// SQLite database without a REGEXP function, rather than letting the query
+// fail with "no such function". Each database is probed once.
+func checkRegexp(ctx context.Context, conn *dialects.Connection, conditions ...[]Condition) error {
+	name := conn.Dialect.Name()
+	if name != "sqlite" && name != "mssql" {
+		return nil
+	}
+	uses := false
+	for _, conds := range conditions {
+		if usesRegexp(conds) {
+			uses = true
+			break
+		}
+	}
+	if !uses {
+		return nil
+	}
+	if name == "mssql" {
+		return ErrRegexpUnsupported
+	}
+	if conn.DB == nil {
+		return nil
+	}
+
+	has, probed := sqliteRegexp.Load(conn.DB)
+	if !probed {
+		var matched sql.NullBool
+		err := conn.DB.QueryRowContext(ctx, "SELECT 'a' REGEXP 'a'").Scan(&matched)
+		if err != nil && !strings.Contains(err.Error(), "no such function") {
+			return nil // Let the query report it
+		}
+		has = err == nil
+		sqliteRegexp.Store(conn.DB, has)
+	}
+	if !has.(bool) {
+		return ErrNoRegexp
+	}
+	return nil
+}
+
+// usesRegexp reports whether conditions, or those of their subqueries,
+// include a Regexp condition.
+func usesRegexp(conditions []Condition) bool {
+	for _, cond := range conditions {
+		if cond.Raw == "" && cond.Operator == "REGEXP" {
+			return true
+		}
+		if sub, ok := cond.Value.(*SelectBuilder); ok && (usesRegexp(sub.conditions) || usesRegexp(sub.having)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package query
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// NewNamedQuery creates a raw SQL query with :name parameters, bound from
+// params: a map with string keys, or a struct or pointer to one whose
+// fields are matched by their db tag, or by field name in any case if
+// there is no tag. A parameter may appear several times; slices (other
+// than []byte and driver.Valuer types) expand to a list for IN. Text in
+// quotes and comments, and PostgreSQL casts such as ::jsonb, are left
+// alone.
+//
+// Example:
+//
+//	q, err := query.NewNamedQuery(conn,
+//		"SELECT * FROM users WHERE email = :email AND role IN (:roles)",
+//		map[string]interface{}{"email": "ann@example.com", "roles": []string{"admin", "owner"}})
+func NewNamedQuery(conn *dialects.Connection, sql string, params interface{}) (*RawQuery, error) {
+	bound, args, err := bindNamed(conn.Dialect, sql, params)
+	if err != nil {
+		return nil, err
+	}
+	return &RawQuery{conn: conn, sql: bound, args: args, bound: true}, nil
+}
+
+// NamedQuery creates a raw SQL query with :name parameters from the
+// builder's connection; see NewNamedQuery.
+func (b *Builder) NamedQuery(sql string, params interface{}) (*RawQuery, error) {
+	return NewNamedQuery(b.conn, sql, params)
+}
+
+// bindNamed replaces the :name parameters of sql with placeholders of the
+// dialect and returns the arguments in their order.
+func bindNamed(dialect dialects.Dialect, sql string, params interface{}) (string, []interface{}, error) {
+	lookup, err := namedParams(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var out strings.Builder
+	var args []interface{}
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(sql) && sql[end] != c {
+				end++
+			}
+			end = min(end+1, len(sql))
+			out.WriteString(sql[i:end])
+			i = end
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			out.WriteString(sql[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 4
+			}
+			out.WriteString(sql[i : i+end+4])
+			i += end + 4
+		case c == ':' && i+1 < len(sql) && sql[i+1] == ':':
+			// A PostgreSQL cast
+			out.WriteString("::")
+			i += 2
+		case c == ':' && i+1 < len(sql) && isNameStart(sql[i+1]):
+			end := i + 2
+			for end < len(sql) && (isNameStart(sql[end]) || sql[end] >= '0' && sql[end] <= '9') {
+				end++
+			}
+			name := sql[i+1 : end]
+			value, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("named query: no value for parameter :%s", name)
+			}
+			values := []interface{}{value}
+			if list, ok := expandList(value); ok {
+				if len(list) == 0 {
+					return "", nil, fmt.Errorf("named query: parameter :%s is an empty list", name)
+				}
+				values = list
+			}
+			for j, v := range values {
+				if j > 0 {
+					out.WriteString(", ")
+				}
+				args = append(args, v)
+				out.WriteString(dialect.Placeholder(len(args)))
+			}
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String(), args, nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// namedParams returns a function looking up parameters in params.
+func namedParams(params interface{}) (func(string) (interface{}, bool), error) {
+	rv := reflect.ValueOf(params)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("named query: map keys must be strings, not %s", rv.Type().Key())
+		}
+		return func(name string) (interface{}, bool) {
+			v := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			if !v.IsValid() {
+				return nil, false
+			}
+			return v.Interface(), true
+		}, nil
+	case reflect.Struct:
+		rt := rv.Type()
+		return func(name string) (interface{}, bool) {
+			for i := 0; i < rt.NumField(); i++ {
+				field := rt.Field(i)
+				if field.PkgPath != "" {
+					continue // Unexported
+				}
+				tag := field.Tag.Get("db")
+				if tag == "-" {
+					continue
+				}
+				if tag == name || tag == "" && strings.EqualFold(field.Name, name) {
+					return rv.Field(i).Interface(), true
+				}
+			}
+			return nil, false
+		}, nil
+	case reflect.Invalid:
+		return func(string) (interface{}, bool) { return nil, false }, nil
+	}
+	return nil, fmt.Errorf("named query: parameters must be a map or a struct, not %T", params)
+}
+
+// expandList returns the elements of a slice or array parameter, which is
+// bound as a list. []byte and types the driver converts itself are single
+// values.
+func expandList(value interface{}) ([]interface{}, bool) {
+	if _, ok := value.(driver.Valuer); ok {
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
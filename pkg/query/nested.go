@@ -0,0 +1,530 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// Nested write operations. A relation in the data of InsertWithRelations
+// or UpdateWithRelations is either rows to create or a map of operations:
+//
+//	"Posts": map[string]interface{}{
+//		"create": []map[string]interface{}{{"title": "Hello"}},
+//		"update": []map[string]interface{}{{"id": 3, "title": "Edited"}},
+//		"delete": []interface{}{4},
+//	}
+const (
+	NestedCreate     = "create"     // Insert related rows, wiring their keys
+	NestedUpdate     = "update"     // Update related rows, matched by primary key (has-many, has-one)
+	NestedDelete     = "delete"     // Delete related rows by ID (has-many, has-one)
+	NestedConnect    = "connect"    // Link existing rows by ID (belongs-to, many-to-many)
+	NestedDisconnect = "disconnect" // Unlink rows: IDs, or true for belongs-to
+	NestedSet        = "set"        // Link exactly these IDs (many-to-many)
+)
+
+// nestedOrder is the order operations on one relation run in.
+var nestedOrder = []string{NestedDelete, NestedDisconnect, NestedSet, NestedUpdate, NestedCreate, NestedConnect}
+
+// InsertWithRelations inserts data together with the related rows nested
+// in it, in one transaction. Keys naming a relation of the model (by
+// relation or target model name, e.g. "Posts" or "Post") hold rows to
+// create, or a map of nested operations; their foreign keys are set from
+// the rows they belong to:
+//
+//	user, err := users.InsertWithRelations(ctx, map[string]interface{}{
+//		"name":  "Ada",
+//		"Posts": []map[string]interface{}{{"title": "Hello"}},
+//		"Tag":   []interface{}{1, 2}, // Many-to-many: IDs to link
+//	})
+//
+// It returns the inserted row with the created related rows under their
+// keys. Without RETURNING, IDs are read with LastInsertId, so on SQL
+// Server rows must include their primary keys.
+func (b *Builder) InsertWithRelations(ctx context.Context, data map[string]interface{}) (Result, error) {
+	var created Result
+	err := Transaction(ctx, b.conn, func(tx *dialects.Tx) error {
+		var err error
+		created, err = (&nestedWriter{b: b, tx: tx}).insert(ctx, b.tableName, data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateWithRelations updates the row whose primary key is id and runs the
+// nested operations of data on its relations, in one transaction. Related
+// rows are only updated or deleted if they belong to the row.
+func (b *Builder) UpdateWithRelations(ctx context.Context, id interface{}, data map[string]interface{}) error {
+	return Transaction(ctx, b.conn, func(tx *dialects.Tx) error {
+		return (&nestedWriter{b: b, tx: tx}).update(ctx, b.tableName, id, data)
+	})
+}
+
+// nestedWriter runs a nested write in a transaction.
+type nestedWriter struct {
+	b  *Builder
+	tx *dialects.Tx
+}
+
+// nestedWrite is a relation of a nested write and its operations.
+type nestedWrite struct {
+	key string
+	rel *schema.Relation
+	ops map[string]interface{}
+}
+
+// insert inserts data into table, parents first and children after.
+func (w *nestedWriter) insert(ctx context.Context, table string, data map[string]interface{}) (Result, error) {
+	model := findModelByTable(w.b.schema, table)
+	columns, nested := splitNested(model, data)
+
+	parents := map[string]Result{}
+	for _, n := range nested {
+		if n.rel.Type != schema.RelationBelongsTo {
+			continue
+		}
+		parent, err := w.writeParent(ctx, n, columns)
+		if err != nil {
+			return nil, err
+		}
+		if parent != nil {
+			parents[n.key] = parent
+		}
+	}
+
+	if err := authorize(ctx, w.b.authorizer, w.b.schema, table, OpInsert); err != nil {
+		return nil, err
+	}
+	if err := validateRows(w.b.schema, table, columns); err != nil {
+		return nil, err
+	}
+	row, err := w.insertRow(ctx, table, model, columns)
+	if err != nil {
+		return nil, err
+	}
+	for key, parent := range parents {
+		row[key] = parent
+	}
+
+	for _, n := range nested {
+		if n.rel.Type == schema.RelationBelongsTo {
+			continue
+		}
+		created, err := w.writeChildren(ctx, n, row[n.rel.ReferenceKey])
+		if err != nil {
+			return nil, err
+		}
+		if created != nil {
+			row[n.key] = created
+		}
+	}
+	return row, nil
+}
+
+// update updates the row of table whose primary key is id, within scope.
+func (w *nestedWriter) update(ctx context.Context, table string, id interface{}, data map[string]interface{}, scope ...Condition) error {
+	model := findModelByTable(w.b.schema, table)
+	if model == nil {
+		return fmt.Errorf("no model for table %s in the schema", table)
+	}
+	columns, nested := splitNested(model, data)
+	pk := primaryKeyOf(model)
+	delete(columns, pk)
+
+	for _, n := range nested {
+		if n.rel.Type == schema.RelationBelongsTo {
+			if _, err := w.writeParent(ctx, n, columns); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := authorize(ctx, w.b.authorizer, w.b.schema, table, OpUpdate); err != nil {
+		return err
+	}
+	conds := append([]Condition{Eq(pk, id)}, scope...)
+	if len(columns) > 0 {
+		if err := validateRows(w.b.schema, table, columns); err != nil {
+			return err
+		}
+		query, args := New(w.b.conn, table).Update(columns).Where(conds...).Build()
+		n, err := execProfiled(ctx, w.b.profiler, w.tx, query, args)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("no %s row with %s %v", model.Name, pk, id)
+		}
+	}
+
+	for _, n := range nested {
+		if n.rel.Type == schema.RelationBelongsTo {
+			continue
+		}
+		ref := id
+		if n.rel.ReferenceKey != pk {
+			var err error
+			if ref, err = w.lookup(ctx, table, n.rel.ReferenceKey, conds); err != nil {
+				return err
+			}
+		}
+		if _, err := w.writeChildren(ctx, n, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeParent runs the operations of a belongs-to relation, setting the
+// foreign key in columns, and returns the parent it created.
+func (w *nestedWriter) writeParent(ctx context.Context, n nestedWrite, columns map[string]interface{}) (Result, error) {
+	var created Result
+	for _, op := range nestedOrder {
+		value, ok := n.ops[op]
+		if !ok {
+			continue
+		}
+		switch op {
+		case NestedCreate:
+			rows, err := nestedRows(n.key, value)
+			if err != nil {
+				return nil, err
+			}
+			if len(rows) != 1 {
+				return nil, fmt.Errorf("%s: belongs-to creates one row, got %d", n.key, len(rows))
+			}
+			if created, err = w.insert(ctx, relatedTable(w.b.schema, n.rel.TargetModel), rows[0]); err != nil {
+				return nil, err
+			}
+			columns[n.rel.ForeignKey] = created[n.rel.ReferenceKey]
+		case NestedConnect:
+			columns[n.rel.ForeignKey] = value
+		case NestedDisconnect:
+			columns[n.rel.ForeignKey] = nil
+		default:
+			return nil, fmt.Errorf("%s: %s is not supported on a belongs-to relation", n.key, op)
+		}
+	}
+	return created, nil
+}
+
+// writeChildren runs the operations of a has-many, has-one or many-to-many
+// relation of the row whose reference key is ref, and returns the rows it
+// created: Results, or a Result for has-one.
+func (w *nestedWriter) writeChildren(ctx context.Context, n nestedWrite, ref interface{}) (interface{}, error) {
+	if ref == nil {
+		return nil, fmt.Errorf("%s: the row has no %s to relate rows to", n.key, n.rel.ReferenceKey)
+	}
+	if n.rel.Type == schema.RelationManyToMany {
+		created, err := w.writeLinks(ctx, n, ref)
+		if err != nil || created == nil {
+			return nil, err
+		}
+		return created, nil
+	}
+
+	table := relatedTable(w.b.schema, n.rel.TargetModel)
+	childPK := primaryKeyOf(findModelByTable(w.b.schema, table))
+	owned := Eq(n.rel.ForeignKey, ref)
+	var created Results
+	for _, op := range nestedOrder {
+		value, ok := n.ops[op]
+		if !ok {
+			continue
+		}
+		switch op {
+		case NestedCreate:
+			rows, err := nestedRows(n.key, value)
+			if err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				child := make(map[string]interface{}, len(row)+1)
+				for k, v := range row {
+					child[k] = v
+				}
+				child[n.rel.ForeignKey] = ref
+				result, err := w.insert(ctx, table, child)
+				if err != nil {
+					return nil, err
+				}
+				created = append(created, result)
+			}
+		case NestedUpdate:
+			rows, err := nestedRows(n.key, value)
+			if err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				id, ok := row[childPK]
+				if !ok {
+					return nil, fmt.Errorf("%s: rows to update need %s", n.key, childPK)
+				}
+				if err := w.update(ctx, table, id, row, owned); err != nil {
+					return nil, err
+				}
+			}
+		case NestedDelete:
+			ids := nestedIDs(value)
+			if len(ids) == 0 {
+				continue
+			}
+			if err := authorize(ctx, w.b.authorizer, w.b.schema, table, OpDelete); err != nil {
+				return nil, err
+			}
+			query, args := New(w.b.conn, table).Delete().Where(In(childPK, ids...), owned).Build()
+			if _, err := execProfiled(ctx, w.b.profiler, w.tx, query, args); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%s: %s is not supported on a has-many or has-one relation", n.key, op)
+		}
+	}
+
+	if created == nil {
+		return nil, nil
+	}
+	if n.rel.Type == schema.RelationHasOne {
+		return created[0], nil
+	}
+	return created, nil
+}
+
+// writeLinks runs the operations of a many-to-many relation. Rows to
+// create are inserted into the target table and linked; IDs are linked.
+func (w *nestedWriter) writeLinks(ctx context.Context, n nestedWrite, ref interface{}) (Results, error) {
+	links := &RelationBuilder{builder: w.b}
+	if err := authorize(ctx, w.b.authorizer, w.b.schema, w.b.tableName, OpUpdate); err != nil {
+		return nil, err
+	}
+	table := relatedTable(w.b.schema, n.rel.TargetModel)
+	targetPK := primaryKeyOf(findModelByTable(w.b.schema, table))
+
+	var created Results
+	var attach, detach []interface{}
+	for _, op := range nestedOrder {
+		value, ok := n.ops[op]
+		if !ok {
+			continue
+		}
+		switch op {
+		case NestedCreate, NestedConnect:
+			for _, item := range nestedIDs(value) {
+				row, ok := item.(map[string]interface{})
+				if !ok {
+					attach = append(attach, item)
+					continue
+				}
+				result, err := w.insert(ctx, table, row)
+				if err != nil {
+					return nil, err
+				}
+				created = append(created, result)
+				attach = append(attach, result[targetPK])
+			}
+		case NestedDisconnect:
+			detach = append(detach, nestedIDs(value)...)
+		case NestedSet:
+			existing, err := links.linked(ctx, w.tx, n.rel, ref)
+			if err != nil {
+				return nil, err
+			}
+			ids := nestedIDs(value)
+			detach = append(detach, missing(existing, ids)...)
+			attach = append(attach, ids...)
+		default:
+			return nil, fmt.Errorf("%s: %s is not supported on a many-to-many relation", n.key, op)
+		}
+	}
+
+	if _, err := links.delete(ctx, w.tx, n.rel, ref, detach); err != nil {
+		return nil, err
+	}
+	if len(attach) > 0 {
+		existing, err := links.linked(ctx, w.tx, n.rel, ref)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := links.insert(ctx, w.tx, n.rel, ref, missing(attach, existing)); err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+// insertRow inserts columns into table and returns the row, with its
+// primary key.
+func (w *nestedWriter) insertRow(ctx context.Context, table string, model *schema.Model, columns map[string]interface{}) (Result, error) {
+	dialect := w.b.conn.Dialect
+	insert := New(w.b.conn, table).Insert(columns)
+	if dialect.SupportsReturning() {
+		query, args := insert.Returning("*").Build()
+		var profile *QueryProfile
+		if w.b.profiler != nil && w.b.profiler.IsEnabled() {
+			profile = w.b.profiler.StartQueryContext(ctx, query, args)
+		}
+		rows, err := w.tx.Query(ctx, query, args...)
+		var results Results
+		if err == nil {
+			results, err = scanRows(rows)
+			rows.Close()
+		}
+		if profile != nil {
+			profile.RowsAffected = int64(len(results))
+			w.b.profiler.EndQuery(profile, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(results) == 0 {
+			return nil, fmt.Errorf("inserting into %s returned no row", table)
+		}
+		return results[0], nil
+	}
+
+	query, args := insert.Build()
+	var profile *QueryProfile
+	if w.b.profiler != nil && w.b.profiler.IsEnabled() {
+		profile = w.b.profiler.StartQueryContext(ctx, query, args)
+	}
+	result, err := w.tx.Exec(ctx, query, args...)
+	if profile != nil {
+		profile.RowsAffected = 1
+		w.b.profiler.EndQuery(profile, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	row := make(Result, len(columns)+1)
+	for k, v := range columns {
+		row[k] = v
+	}
+	pk := primaryKeyOf(model)
+	if row[pk] == nil {
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("reading the %s of the new %s row (include it in the data): %w", pk, table, err)
+		}
+		row[pk] = id
+	}
+	return row, nil
+}
+
+// lookup returns column of the row of table matching conds.
+func (w *nestedWriter) lookup(ctx context.Context, table, column string, conds []Condition) (interface{}, error) {
+	query, args := New(w.b.conn, table).Select(column).Where(conds...).Limit(1).Build()
+	var value interface{}
+	if err := w.tx.QueryRow(ctx, query, args...).Scan(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// splitNested splits data into the columns of model and the relations
+// nested in it. Keys that are fields of model are always columns.
+func splitNested(model *schema.Model, data map[string]interface{}) (map[string]interface{}, []nestedWrite) {
+	columns := make(map[string]interface{}, len(data))
+	var nested []nestedWrite
+	for _, key := range sortedKeys(data) {
+		value := data[key]
+		var rel *schema.Relation
+		if model != nil {
+			if _, isField := model.Fields[key]; !isField {
+				rel = relationForKey(model, key)
+			}
+		}
+		if rel == nil {
+			columns[key] = value
+			continue
+		}
+		ops, ok := value.(map[string]interface{})
+		if !ok || !isNestedOps(ops) {
+			ops = map[string]interface{}{NestedCreate: value}
+		}
+		nested = append(nested, nestedWrite{key: key, rel: rel, ops: ops})
+	}
+	return columns, nested
+}
+
+// relationForKey returns the relation of model that key names, by relation
+// name or by target model name, singular or plural.
+func relationForKey(model *schema.Model, key string) *schema.Relation {
+	if rel := findRelation(model, key); rel != nil {
+		return rel
+	}
+	for _, rel := range model.GetRelations() {
+		if strings.EqualFold(rel.TargetModel+"s", key) {
+			return rel
+		}
+	}
+	return nil
+}
+
+// isNestedOps reports whether every key of m is a nested operation.
+func isNestedOps(m map[string]interface{}) bool {
+	if len(m) == 0 {
+		return false
+	}
+	for key := range m {
+		switch key {
+		case NestedCreate, NestedUpdate, NestedDelete, NestedConnect, NestedDisconnect, NestedSet:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// nestedRows returns the rows of a nested value: a row or a slice of rows.
+func nestedRows(key string, value interface{}) ([]map[string]interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}, nil
+	case []map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		rows := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: expected rows, got %T", key, item)
+			}
+			rows[i] = row
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("%s: expected rows, got %T", key, value)
+}
+
+// nestedIDs returns the items of a nested value: the elements of a slice,
+// or the value itself.
+func nestedIDs(value interface{}) []interface{} {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []interface{}{value}
+	}
+	ids := make([]interface{}, rv.Len())
+	for i := range ids {
+		ids[i] = rv.Index(i).Interface()
+	}
+	return ids
+}
+
+// primaryKeyOf returns the primary key column of model, or "id".
+func primaryKeyOf(model *schema.Model) string {
+	if model != nil {
+		for _, field := range model.GetFields() {
+			if field.IsPrimaryKey {
+				return field.Name
+			}
+		}
+	}
+	return "id"
+}
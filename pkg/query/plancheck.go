@@ -0,0 +1,250 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nexus-db/nexus/internal/yamlline"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// PlanCheck is what the plan of a query must satisfy.
+type PlanCheck struct {
+	UsesIndex []string // Indexes the plan must use
+	NoSeqScan bool     // No step may scan a whole table
+	MaxCost   float64  // Highest estimated cost, if the database reports one; zero for none
+	MaxRows   int64    // Highest estimated rows; zero for none
+}
+
+// UsesIndex reports whether a step of the plan uses the index name.
+func (p *QueryPlan) UsesIndex(name string) bool {
+	for _, index := range p.UsedIndexes {
+		if strings.EqualFold(index, name) {
+			return true
+		}
+	}
+	found := false
+	walkPlanNodes(p.Nodes, func(n *PlanNode) {
+		found = found || strings.EqualFold(n.Index, name)
+	})
+	return found
+}
+
+// SeqScans returns the steps of the plan that scan a whole table.
+func (p *QueryPlan) SeqScans() []string {
+	var scans []string
+	walkPlanNodes(p.Nodes, func(n *PlanNode) {
+		if n.ScanType == "sequential_scan" {
+			scans = append(scans, n.Detail)
+		}
+	})
+	if len(p.Nodes) == 0 {
+		for _, st := range p.ScanTypes {
+			if st == "sequential_scan" {
+				scans = append(scans, "sequential scan")
+			}
+		}
+	}
+	return scans
+}
+
+// Check returns how the plan fails c, or nothing if it satisfies it.
+func (p *QueryPlan) Check(c PlanCheck) []string {
+	var problems []string
+	for _, index := range c.UsesIndex {
+		if !p.UsesIndex(index) {
+			problems = append(problems, fmt.Sprintf("index %s is not used", index))
+		}
+	}
+	if c.NoSeqScan {
+		for _, scan := range p.SeqScans() {
+			problems = append(problems, "full table scan: "+scan)
+		}
+	}
+	if c.MaxCost > 0 && p.EstimatedCost > c.MaxCost {
+		problems = append(problems, fmt.Sprintf("estimated cost %g is over %g", p.EstimatedCost, c.MaxCost))
+	}
+	if c.MaxRows > 0 && p.EstimatedRows > c.MaxRows {
+		problems = append(problems, fmt.Sprintf("estimated rows %d are over %d", p.EstimatedRows, c.MaxRows))
+	}
+	return problems
+}
+
+func walkPlanNodes(nodes []*PlanNode, fn func(*PlanNode)) {
+	for _, n := range nodes {
+		fn(n)
+		walkPlanNodes(n.Children, fn)
+	}
+}
+
+// TrackedQuery is a query whose plan is checked, as listed in a plans
+// file.
+type TrackedQuery struct {
+	Name string
+	SQL  string // Uses ? placeholders
+	Args []interface{}
+	PlanCheck
+}
+
+// PlanResult is the outcome of checking the plan of a tracked query.
+type PlanResult struct {
+	Query    TrackedQuery
+	Plan     *QueryPlan
+	Problems []string
+}
+
+// CheckPlans explains each tracked query and checks its plan. It fails
+// only if a query can't be explained; degraded plans have problems.
+func CheckPlans(ctx context.Context, conn *dialects.Connection, queries []TrackedQuery) ([]PlanResult, error) {
+	var results []PlanResult
+	for _, q := range queries {
+		plan, err := NewRawQuery(conn, q.SQL, q.Args...).Explain(ctx)
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", q.Name, err)
+		}
+		results = append(results, PlanResult{Query: q, Plan: plan, Problems: plan.Check(q.PlanCheck)})
+	}
+	return results, nil
+}
+
+// LoadPlanChecks reads a YAML plans file, a sequence of tracked queries:
+//
+//   - name: user by email
+//     sql: SELECT * FROM users WHERE email = ?
+//     args: ["ann@example.com"]
+//     uses_index: idx_users_email
+//     no_seq_scan: true
+//     max_rows: 10
+//
+// uses_index is a name or a flow sequence of names; args is a flow
+// sequence of JSON values.
+func LoadPlanChecks(path string) ([]TrackedQuery, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	queries, err := parsePlanChecks(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("%s: no queries", path)
+	}
+	return queries, nil
+}
+
+func parsePlanChecks(content string) ([]TrackedQuery, error) {
+	var queries []TrackedQuery
+	itemIndent := -1
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		line = strings.TrimRight(yamlline.StripComment(line), " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			if itemIndent >= 0 && indent != itemIndent {
+				return nil, fmt.Errorf("line %d: nested lists are not supported", lineNo)
+			}
+			itemIndent = indent
+			queries = append(queries, TrackedQuery{})
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			if trimmed == "" {
+				continue
+			}
+		} else if len(queries) == 0 || indent <= itemIndent {
+			return nil, fmt.Errorf("line %d: expected a list item (\"- sql: ...\")", lineNo)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || (value != "" && value[0] != ' ' && value[0] != '\t') {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		if err := queries[len(queries)-1].set(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+
+	for i := range queries {
+		q := &queries[i]
+		if q.SQL == "" {
+			return nil, fmt.Errorf("query %d has no sql", i+1)
+		}
+		if q.Name == "" {
+			q.Name = strings.Join(strings.Fields(q.SQL), " ")
+		}
+		if len(q.UsesIndex) == 0 && !q.NoSeqScan && q.MaxCost == 0 && q.MaxRows == 0 {
+			return nil, fmt.Errorf("%s: nothing to check (set uses_index, no_seq_scan, max_cost or max_rows)", q.Name)
+		}
+	}
+	return queries, nil
+}
+
+// set sets a key of a tracked query from the plans file.
+func (q *TrackedQuery) set(key, value string) error {
+	var err error
+	switch key {
+	case "name":
+		q.Name = unquoteYAML(value)
+	case "sql":
+		q.SQL = unquoteYAML(value)
+	case "args":
+		dec := json.NewDecoder(strings.NewReader(value))
+		dec.UseNumber()
+		var args []interface{}
+		if err := dec.Decode(&args); err != nil {
+			return fmt.Errorf("args must be a list such as [1, \"a\"]: %w", err)
+		}
+		for i, arg := range args {
+			if n, ok := arg.(json.Number); ok {
+				if args[i], err = n.Int64(); err != nil {
+					args[i], _ = n.Float64()
+				}
+			}
+		}
+		q.Args = args
+	case "uses_index":
+		q.UsesIndex = nil
+		for _, name := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"), ",") {
+			if name = unquoteYAML(strings.TrimSpace(name)); name != "" {
+				q.UsesIndex = append(q.UsesIndex, name)
+			}
+		}
+	case "no_seq_scan":
+		if q.NoSeqScan, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("no_seq_scan must be true or false, not %q", value)
+		}
+	case "max_cost":
+		if q.MaxCost, err = strconv.ParseFloat(value, 64); err != nil || q.MaxCost < 0 {
+			return fmt.Errorf("invalid max_cost %q", value)
+		}
+	case "max_rows":
+		if q.MaxRows, err = strconv.ParseInt(value, 10, 64); err != nil || q.MaxRows < 0 {
+			return fmt.Errorf("invalid max_rows %q", value)
+		}
+	default:
+		return fmt.Errorf("unknown key %q (expected name, sql, args, uses_index, no_seq_scan, max_cost or max_rows)", key)
+	}
+	return nil
+}
+
+// unquoteYAML removes the quotes of a quoted scalar.
+func unquoteYAML(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
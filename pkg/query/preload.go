@@ -14,18 +14,8 @@ func (s *SelectBuilder) preloadRelations(ctx context.Context, results Results) e
 		return nil
 	}
 
-	model, exists := s.schema.Models[s.tableName]
-	if !exists {
-		// Try lowercase table name as model lookup
-		for name, m := range s.schema.Models {
-			if strings.EqualFold(name, s.tableName) || strings.EqualFold(name+"s", s.tableName) {
-				model = m
-				exists = true
-				break
-			}
-		}
-	}
-	if !exists {
+	model := findModelByTable(s.schema, s.tableName)
+	if model == nil {
 		return nil
 	}
 
@@ -35,21 +25,27 @@ func (s *SelectBuilder) preloadRelations(ctx context.Context, results Results) e
 			continue
 		}
 
+		if err := authorize(ctx, s.authorizer, s.schema, relatedTable(s.schema, rel.TargetModel), OpSelect); err != nil {
+			return err
+		}
+
+		key := relationKey(rel, include)
+
 		switch rel.Type {
 		case schema.RelationBelongsTo:
-			if err := s.preloadBelongsTo(ctx, results, rel); err != nil {
+			if err := s.preloadBelongsTo(ctx, results, rel, key); err != nil {
 				return err
 			}
 		case schema.RelationHasMany:
-			if err := s.preloadHasMany(ctx, results, rel); err != nil {
+			if err := s.preloadHasMany(ctx, results, rel, key); err != nil {
 				return err
 			}
 		case schema.RelationHasOne:
-			if err := s.preloadHasOne(ctx, results, rel); err != nil {
+			if err := s.preloadHasOne(ctx, results, rel, key); err != nil {
 				return err
 			}
 		case schema.RelationManyToMany:
-			if err := s.preloadBelongsToMany(ctx, results, rel); err != nil {
+			if err := s.preloadBelongsToMany(ctx, results, rel, key); err != nil {
 				return err
 			}
 		}
@@ -58,8 +54,14 @@ func (s *SelectBuilder) preloadRelations(ctx context.Context, results Results) e
 	return nil
 }
 
-// findRelation finds a relation by name (target model name).
+// findRelation finds a relation by its name, or else by its target model
+// name.
 func findRelation(model *schema.Model, name string) *schema.Relation {
+	for _, rel := range model.GetRelations() {
+		if rel.Name != "" && strings.EqualFold(rel.Name, name) {
+			return rel
+		}
+	}
 	for _, rel := range model.GetRelations() {
 		if strings.EqualFold(rel.TargetModel, name) {
 			return rel
@@ -68,9 +70,18 @@ func findRelation(model *schema.Model, name string) *schema.Relation {
 	return nil
 }
 
+// relationKey returns the key the records of rel, included as name, are
+// stored under: the relation name if name is it, else the target model.
+func relationKey(rel *schema.Relation, name string) string {
+	if rel.Name != "" && strings.EqualFold(rel.Name, name) {
+		return rel.Name
+	}
+	return rel.TargetModel
+}
+
 // preloadBelongsTo loads parent records for BelongsTo relations.
 // Example: For Posts with user_id, load corresponding Users.
-func (s *SelectBuilder) preloadBelongsTo(ctx context.Context, results Results, rel *schema.Relation) error {
+func (s *SelectBuilder) preloadBelongsTo(ctx context.Context, results Results, rel *schema.Relation, key string) error {
 	if len(results) == 0 {
 		return nil
 	}
@@ -82,7 +93,7 @@ func (s *SelectBuilder) preloadBelongsTo(ctx context.Context, results Results, r
 	}
 
 	// Query related records
-	targetTable := toTableName(rel.TargetModel)
+	targetTable := relatedTable(s.schema, rel.TargetModel)
 	related, err := s.queryRelated(ctx, targetTable, rel.ReferenceKey, fkValues)
 	if err != nil {
 		return err
@@ -91,15 +102,14 @@ func (s *SelectBuilder) preloadBelongsTo(ctx context.Context, results Results, r
 	// Build lookup map: referenceKey -> related record
 	lookup := make(map[interface{}]Result)
 	for _, r := range related {
-		key := r[rel.ReferenceKey]
-		lookup[key] = r
+		lookup[r[rel.ReferenceKey]] = r
 	}
 
 	// Associate related records to parent results
 	for i := range results {
 		fkValue := results[i][rel.ForeignKey]
 		if relatedRecord, ok := lookup[fkValue]; ok {
-			results[i][rel.TargetModel] = relatedRecord
+			results[i][key] = relatedRecord
 		}
 	}
 
@@ -108,7 +118,7 @@ func (s *SelectBuilder) preloadBelongsTo(ctx context.Context, results Results, r
 
 // preloadHasMany loads child records for HasMany relations.
 // Example: For Users, load all their Posts.
-func (s *SelectBuilder) preloadHasMany(ctx context.Context, results Results, rel *schema.Relation) error {
+func (s *SelectBuilder) preloadHasMany(ctx context.Context, results Results, rel *schema.Relation, key string) error {
 	if len(results) == 0 {
 		return nil
 	}
@@ -120,7 +130,7 @@ func (s *SelectBuilder) preloadHasMany(ctx context.Context, results Results, rel
 	}
 
 	// Query related records
-	targetTable := toTableName(rel.TargetModel)
+	targetTable := relatedTable(s.schema, rel.TargetModel)
 	related, err := s.queryRelated(ctx, targetTable, rel.ForeignKey, pkValues)
 	if err != nil {
 		return err
@@ -137,9 +147,9 @@ func (s *SelectBuilder) preloadHasMany(ctx context.Context, results Results, rel
 	for i := range results {
 		pkValue := results[i][rel.ReferenceKey]
 		if relatedRecords, ok := lookup[pkValue]; ok {
-			results[i][rel.TargetModel] = relatedRecords
+			results[i][key] = relatedRecords
 		} else {
-			results[i][rel.TargetModel] = Results{}
+			results[i][key] = Results{}
 		}
 	}
 
@@ -147,7 +157,7 @@ func (s *SelectBuilder) preloadHasMany(ctx context.Context, results Results, rel
 }
 
 // preloadHasOne loads single child record for HasOne relations.
-func (s *SelectBuilder) preloadHasOne(ctx context.Context, results Results, rel *schema.Relation) error {
+func (s *SelectBuilder) preloadHasOne(ctx context.Context, results Results, rel *schema.Relation, key string) error {
 	if len(results) == 0 {
 		return nil
 	}
@@ -159,7 +169,7 @@ func (s *SelectBuilder) preloadHasOne(ctx context.Context, results Results, rel
 	}
 
 	// Query related records
-	targetTable := toTableName(rel.TargetModel)
+	targetTable := relatedTable(s.schema, rel.TargetModel)
 	related, err := s.queryRelated(ctx, targetTable, rel.ForeignKey, pkValues)
 	if err != nil {
 		return err
@@ -178,7 +188,7 @@ func (s *SelectBuilder) preloadHasOne(ctx context.Context, results Results, rel
 	for i := range results {
 		pkValue := results[i][rel.ReferenceKey]
 		if relatedRecord, ok := lookup[pkValue]; ok {
-			results[i][rel.TargetModel] = relatedRecord
+			results[i][key] = relatedRecord
 		}
 	}
 
@@ -230,6 +240,17 @@ func collectFieldValues(results Results, fieldName string) []interface{} {
 	return values
 }
 
+// relatedTable returns the table of a related model: its mapped table, or
+// the conventional name.
+func relatedTable(sch *schema.Schema, modelName string) string {
+	if sch != nil {
+		if model, ok := sch.Models[modelName]; ok && model.TableName != "" {
+			return model.TableName
+		}
+	}
+	return toTableName(modelName)
+}
+
 // toTableName converts a model name to table name (lowercase + 's').
 // User -> users, Post -> posts
 func toTableName(modelName string) string {
@@ -238,7 +259,7 @@ func toTableName(modelName string) string {
 
 // preloadBelongsToMany loads related records via a junction table.
 // Example: For Users with Tags via user_tags, loads all tags for each user.
-func (s *SelectBuilder) preloadBelongsToMany(ctx context.Context, results Results, rel *schema.Relation) error {
+func (s *SelectBuilder) preloadBelongsToMany(ctx context.Context, results Results, rel *schema.Relation, key string) error {
 	if len(results) == 0 {
 		return nil
 	}
@@ -278,7 +299,7 @@ func (s *SelectBuilder) preloadBelongsToMany(ctx context.Context, results Result
 	if len(junctionResults) == 0 {
 		// No relations, set empty slices
 		for i := range results {
-			results[i][rel.TargetModel] = Results{}
+			results[i][key] = Results{}
 		}
 		return nil
 	}
@@ -302,13 +323,13 @@ func (s *SelectBuilder) preloadBelongsToMany(ctx context.Context, results Result
 
 	if len(targetIDs) == 0 {
 		for i := range results {
-			results[i][rel.TargetModel] = Results{}
+			results[i][key] = Results{}
 		}
 		return nil
 	}
 
 	// Step 4: Query target table
-	targetTable := toTableName(rel.TargetModel)
+	targetTable := relatedTable(s.schema, rel.TargetModel)
 	targetPlaceholders := make([]string, len(targetIDs))
 	for i := range targetIDs {
 		targetPlaceholders[i] = dialect.Placeholder(i + 1)
@@ -348,7 +369,7 @@ func (s *SelectBuilder) preloadBelongsToMany(ctx context.Context, results Result
 			}
 		}
 
-		results[i][rel.TargetModel] = relatedResults
+		results[i][key] = relatedResults
 	}
 
 	return nil
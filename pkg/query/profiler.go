@@ -3,12 +3,15 @@ package query
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"runtime"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
 )
 
 // ProfilerOptions configures the profiler behavior.
@@ -21,6 +24,15 @@ type ProfilerOptions struct {
 	MaxProfiles int
 	// NPlusOneThreshold triggers warning if same query runs this many times.
 	NPlusOneThreshold int
+	// Schema maps N+1 patterns back to the relations they load, so their
+	// warnings show the Include or batch query that replaces them.
+	Schema *schema.Schema
+	// Pool, if set, is the connection pool sampled during sessions, so
+	// the report shows whether statements waited for connections.
+	Pool *sql.DB
+	// PoolSampleInterval is how often Pool is sampled; zero uses
+	// DefaultPoolSampleInterval.
+	PoolSampleInterval time.Duration
 }
 
 // DefaultProfilerOptions returns sensible defaults.
@@ -53,7 +65,8 @@ type QueryProfile struct {
 	CallerInfo string
 	// Error if the query failed.
 	Error error
-	// Tags are user-defined labels.
+	// Tags are user-defined labels, such as "tenant:42", from the context
+	// of the query (see WithTags) and Profiler.Tag.
 	Tags []string
 	// IsSlow indicates if query exceeded slow threshold.
 	IsSlow bool
@@ -69,6 +82,15 @@ type ProfilingSession struct {
 	EndTime time.Time
 	// Profiles collected during this session.
 	Profiles []*QueryProfile
+	// SQLCacheHits and SQLCacheMisses count lookups of builders with an
+	// SQLCache during this session.
+	SQLCacheHits   int64
+	SQLCacheMisses int64
+
+	// endpoints are the requests seen by ProfileMiddleware, by endpoint.
+	endpoints map[string]*EndpointProfile
+	// pool are the samples of ProfilerOptions.Pool, oldest first.
+	pool []PoolStats
 }
 
 // IsActive returns true if the session is still running.
@@ -94,6 +116,9 @@ type NPlusOneWarning struct {
 	Examples []string
 	// Callers are the source locations where these queries originated.
 	Callers []string
+	// Fixes are the relations loading the same rows, when the pattern is
+	// a lookup by a key of a table in ProfilerOptions.Schema.
+	Fixes []NPlusOneFix
 }
 
 // ProfileReport contains analysis of a profiling session.
@@ -120,6 +145,15 @@ type ProfileReport struct {
 	ErrorCount int
 	// SessionDuration is the total profiling window.
 	SessionDuration time.Duration
+	// SQLCacheHits and SQLCacheMisses count the SQL cache lookups of
+	// profiled builders.
+	SQLCacheHits   int64
+	SQLCacheMisses int64
+	// Endpoints break the requests seen by ProfileMiddleware down by
+	// endpoint, those spending the most time in the database first.
+	Endpoints []EndpointProfile
+	// Pool summarizes the samples of ProfilerOptions.Pool, if set.
+	Pool *PoolReport
 }
 
 // QueryFrequency tracks how often a query pattern was executed.
@@ -132,10 +166,13 @@ type QueryFrequency struct {
 
 // Profiler manages performance profiling sessions.
 type Profiler struct {
-	mu      sync.RWMutex
-	opts    ProfilerOptions
-	session *ProfilingSession
-	enabled bool
+	mu          sync.RWMutex
+	opts        ProfilerOptions
+	session     *ProfilingSession
+	enabled     bool
+	tags        []string      // Added to every recorded profile
+	poolStop    chan struct{} // Stops sampling the pool
+	subscribers map[chan *QueryProfile]struct{}
 }
 
 // NewProfiler creates a new profiler with the given options.
@@ -157,6 +194,7 @@ func (p *Profiler) Start() {
 		Profiles:  make([]*QueryProfile, 0, 100),
 	}
 	p.enabled = true
+	p.startPoolSampling()
 }
 
 // Stop ends the current profiling session.
@@ -166,6 +204,7 @@ func (p *Profiler) Stop() {
 
 	if p.session != nil {
 		p.session.EndTime = time.Now()
+		p.stopPoolSampling()
 	}
 	p.enabled = false
 }
@@ -192,6 +231,23 @@ func (p *Profiler) StartQuery(sql string, args []interface{}) *QueryProfile {
 	return profile
 }
 
+// StartQueryContext is StartQuery for a query run with ctx, tagging the
+// profile with the tags of ctx.
+func (p *Profiler) StartQueryContext(ctx context.Context, sql string, args []interface{}) *QueryProfile {
+	profile := &QueryProfile{
+		SQL:       sql,
+		Args:      args,
+		StartTime: time.Now(),
+		Tags:      TagsFromContext(ctx),
+	}
+
+	if p.opts.EnableCallerInfo {
+		profile.CallerInfo = getCallerInfo(4) // Skip internal frames
+	}
+
+	return profile
+}
+
 // EndQuery completes a query profile and records it.
 func (p *Profiler) EndQuery(profile *QueryProfile, err error) {
 	profile.EndTime = time.Now()
@@ -210,6 +266,9 @@ func (p *Profiler) Record(profile *QueryProfile) {
 	if p.session == nil || !p.enabled {
 		return
 	}
+	if len(p.tags) > 0 {
+		profile.Tags = append(profile.Tags[:len(profile.Tags):len(profile.Tags)], p.tags...)
+	}
 
 	// Enforce max profiles limit
 	if p.opts.MaxProfiles > 0 && len(p.session.Profiles) >= p.opts.MaxProfiles {
@@ -218,11 +277,46 @@ func (p *Profiler) Record(profile *QueryProfile) {
 	}
 
 	p.session.Profiles = append(p.session.Profiles, profile)
+
+	for ch := range p.subscribers {
+		select {
+		case ch <- profile:
+		default: // Subscriber is behind; drop rather than slow the query
+		}
+	}
 }
 
-// Tag adds tags to the current context for subsequent queries.
+// Subscribe returns a channel that receives every profile recorded from
+// now on, buffering up to buffer of them, and a function that ends the
+// subscription. Profiles are dropped for subscribers that fall behind.
+func (p *Profiler) Subscribe(buffer int) (<-chan *QueryProfile, func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch := make(chan *QueryProfile, buffer)
+	if p.subscribers == nil {
+		p.subscribers = make(map[chan *QueryProfile]struct{})
+	}
+	p.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			delete(p.subscribers, ch)
+			close(ch)
+		})
+	}
+}
+
+// Tag adds tags to every query recorded from now on, such as the phase of
+// a benchmark. Tags of a request or tenant belong in the query's context
+// instead; see WithTags.
 func (p *Profiler) Tag(tags ...string) *Profiler {
-	// Tags are applied per-query via context in real usage
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tags = append(p.tags, tags...)
 	return p
 }
 
@@ -233,6 +327,23 @@ func (p *Profiler) Reset() {
 
 	if p.session != nil {
 		p.session.Profiles = make([]*QueryProfile, 0, 100)
+		p.session.SQLCacheHits, p.session.SQLCacheMisses = 0, 0
+		p.session.endpoints = nil
+	}
+}
+
+// recordSQLCache counts an SQL cache lookup in the session.
+func (p *Profiler) recordSQLCache(hit bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.session == nil || !p.enabled {
+		return
+	}
+	if hit {
+		p.session.SQLCacheHits++
+	} else {
+		p.session.SQLCacheMisses++
 	}
 }
 
@@ -245,13 +356,74 @@ func (p *Profiler) Report() *ProfileReport {
 		return &ProfileReport{}
 	}
 
+	report := p.report(p.session.Profiles, newPoolReport(p.session.pool))
+	report.SQLCacheHits = p.session.SQLCacheHits
+	report.SQLCacheMisses = p.session.SQLCacheMisses
+	report.Endpoints = endpointProfiles(p.session)
+	return report
+}
+
+// ReportTagged generates a report of the queries of the current session
+// having all of tags.
+func (p *Profiler) ReportTagged(tags ...string) *ProfileReport {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.session == nil {
+		return &ProfileReport{}
+	}
+
+	var profiles []*QueryProfile
+	for _, profile := range p.session.Profiles {
+		tagged := true
+		for _, tag := range tags {
+			tagged = tagged && profile.HasTag(tag)
+		}
+		if tagged {
+			profiles = append(profiles, profile)
+		}
+	}
+	return p.report(profiles, nil)
+}
+
+// ReportsByTag generates a report per value of the tag key, of the queries
+// of the current session tagged "key:value". Reporting by "tenant" gives
+// one report per tenant, for queries tagged "tenant:42" and so on.
+func (p *Profiler) ReportsByTag(key string) map[string]*ProfileReport {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	reports := make(map[string]*ProfileReport)
+	if p.session == nil {
+		return reports
+	}
+
+	groups := make(map[string][]*QueryProfile)
+	for _, profile := range p.session.Profiles {
+		if value, ok := profile.TagValue(key); ok {
+			groups[value] = append(groups[value], profile)
+		}
+	}
+	for value, profiles := range groups {
+		reports[value] = p.report(profiles, nil)
+	}
+	return reports
+}
+
+// report analyzes profiles of the current session, with the summary of
+// its pool samples if any.
+func (p *Profiler) report(profiles []*QueryProfile, pool *PoolReport) *ProfileReport {
 	report := &ProfileReport{
 		SessionID:       p.session.ID,
-		TotalQueries:    len(p.session.Profiles),
+		TotalQueries:    len(profiles),
 		SessionDuration: p.session.Duration(),
+		Pool:            pool,
 	}
 
 	if report.TotalQueries == 0 {
+		if s := poolSuggestion(pool); s != "" {
+			report.Suggestions = []string{s}
+		}
 		return report
 	}
 
@@ -259,7 +431,7 @@ func (p *Profiler) Report() *ProfileReport {
 	var totalDuration time.Duration
 	patternCounts := make(map[string]*patternStats)
 
-	for _, profile := range p.session.Profiles {
+	for _, profile := range profiles {
 		totalDuration += profile.Duration
 
 		if profile.IsSlow {
@@ -297,8 +469,8 @@ func (p *Profiler) Report() *ProfileReport {
 	}
 
 	// Top by duration (slowest queries)
-	sortedByDuration := make([]*QueryProfile, len(p.session.Profiles))
-	copy(sortedByDuration, p.session.Profiles)
+	sortedByDuration := make([]*QueryProfile, len(profiles))
+	copy(sortedByDuration, profiles)
 	sort.Slice(sortedByDuration, func(i, j int) bool {
 		return sortedByDuration[i].Duration > sortedByDuration[j].Duration
 	})
@@ -326,12 +498,16 @@ func (p *Profiler) Report() *ProfileReport {
 	// Detect N+1 patterns
 	for pattern, stats := range patternCounts {
 		if stats.count >= p.opts.NPlusOneThreshold {
-			report.NPlusOneWarnings = append(report.NPlusOneWarnings, NPlusOneWarning{
+			warning := NPlusOneWarning{
 				Pattern:  pattern,
 				Count:    stats.count,
 				Examples: stats.examples,
 				Callers:  stats.callers,
-			})
+			}
+			if p.opts.Schema != nil {
+				warning.Fixes = relationFixes(p.opts.Schema, pattern)
+			}
+			report.NPlusOneWarnings = append(report.NPlusOneWarnings, warning)
 		}
 	}
 
@@ -356,9 +532,17 @@ func (p *Profiler) generateSuggestions(report *ProfileReport, patterns map[strin
 	// N+1 detection
 	if len(report.NPlusOneWarnings) > 0 {
 		for _, warning := range report.NPlusOneWarnings {
-			suggestions = append(suggestions,
-				fmt.Sprintf("🔁 N+1 detected: Query pattern executed %d times - consider using eager loading or batch queries:\n   %s",
-					warning.Count, truncateSQL(warning.Pattern, 80)))
+			if len(warning.Fixes) == 0 {
+				suggestions = append(suggestions,
+					fmt.Sprintf("🔁 N+1 detected: Query pattern executed %d times - consider using eager loading or batch queries:\n   %s",
+						warning.Count, truncateSQL(warning.Pattern, 80)))
+				continue
+			}
+			for _, fix := range warning.Fixes {
+				suggestions = append(suggestions,
+					fmt.Sprintf("🔁 N+1 detected: %s.%s loaded one row at a time (%d queries) - eager load it:\n   %s\n   or batch load it:\n   %s",
+						fix.Model, fix.Relation, warning.Count, fix.Include, fix.BatchLoad))
+			}
 		}
 	}
 
@@ -389,6 +573,10 @@ func (p *Profiler) generateSuggestions(report *ProfileReport, patterns map[strin
 		}
 	}
 
+	if s := poolSuggestion(report.Pool); s != "" {
+		suggestions = append(suggestions, s)
+	}
+
 	if len(suggestions) == 0 {
 		suggestions = append(suggestions, "✅ No obvious performance issues detected")
 	}
@@ -410,6 +598,10 @@ func (r *ProfileReport) String() string {
 	sb.WriteString(fmt.Sprintf("Avg Query Time:   %s\n", r.AverageDuration.Round(time.Microsecond)))
 	sb.WriteString(fmt.Sprintf("Slow Queries:     %d\n", len(r.SlowQueries)))
 	sb.WriteString(fmt.Sprintf("Errors:           %d\n", r.ErrorCount))
+	if lookups := r.SQLCacheHits + r.SQLCacheMisses; lookups > 0 {
+		sb.WriteString(fmt.Sprintf("SQL Cache:        %d hits, %d misses (%.0f%%)\n",
+			r.SQLCacheHits, r.SQLCacheMisses, float64(r.SQLCacheHits)/float64(lookups)*100))
+	}
 
 	if len(r.TopByDuration) > 0 {
 		sb.WriteString("\n🐢 Slowest Queries:\n")
@@ -439,6 +631,28 @@ func (r *ProfileReport) String() string {
 		}
 	}
 
+	if r.Pool != nil {
+		sb.WriteString("\n🚰 Connection Pool:\n")
+		sb.WriteString(fmt.Sprintf("   Peak in use:    %d of %s\n", r.Pool.PeakInUse, poolLimit(r.Pool.MaxOpen)))
+		sb.WriteString(fmt.Sprintf("   Saturated:      %d of %d samples\n", r.Pool.SaturatedSamples, len(r.Pool.Samples)))
+		sb.WriteString(fmt.Sprintf("   Waits:          %d (%s total, avg %s)\n", r.Pool.Waits,
+			r.Pool.WaitDuration.Round(time.Microsecond), r.Pool.AvgWait().Round(time.Microsecond)))
+	}
+
+	if len(r.Endpoints) > 0 {
+		sb.WriteString("\n🌐 Endpoints:\n")
+		for i, e := range r.Endpoints {
+			if i >= 5 {
+				break
+			}
+			sb.WriteString(fmt.Sprintf("   %d. %s [%d requests, %.1f queries avg, max %d, %s]\n", i+1,
+				e.Endpoint, e.Requests, e.AvgQueries(), e.MaxQueries, e.Duration.Round(time.Microsecond)))
+			for _, w := range e.NPlusOne {
+				sb.WriteString(fmt.Sprintf("      └─ N+1: %dx %s\n", w.Count, truncateSQL(w.Pattern, 50)))
+			}
+		}
+	}
+
 	if len(r.NPlusOneWarnings) > 0 {
 		sb.WriteString("\n⚠️  N+1 Query Warnings:\n")
 		for _, w := range r.NPlusOneWarnings {
@@ -446,6 +660,9 @@ func (r *ProfileReport) String() string {
 			if len(w.Callers) > 0 {
 				sb.WriteString(fmt.Sprintf("     └─ from: %s\n", w.Callers[0]))
 			}
+			for _, fix := range w.Fixes {
+				sb.WriteString(fmt.Sprintf("     └─ fix: %s\n", fix.Include))
+			}
 		}
 	}
 
@@ -512,6 +729,51 @@ func truncateSQL(sql string, maxLen int) string {
 	return sql[:maxLen-3] + "..."
 }
 
+// HasTag reports whether the query is tagged with tag.
+func (q *QueryProfile) HasTag(tag string) bool {
+	for _, t := range q.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// TagValue returns the value of the first "key:value" tag of the query
+// with key.
+func (q *QueryProfile) TagValue(key string) (string, bool) {
+	for _, t := range q.Tags {
+		if k, v, ok := strings.Cut(t, ":"); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+type tagsContextKey struct{}
+
+// WithTags returns a context whose queries are profiled with tags, in
+// addition to the tags of ctx. Tags are free-form; "key:value" tags can be
+// grouped by key with Profiler.ReportsByTag:
+//
+//	ctx = query.WithTags(ctx, "handler:/users", "tenant:42")
+//	users.Select().All(ctx) // Profiled with both tags
+func WithTags(ctx context.Context, tags ...string) context.Context {
+	if len(tags) == 0 {
+		return ctx
+	}
+	parent := TagsFromContext(ctx)
+	merged := make([]string, 0, len(parent)+len(tags))
+	merged = append(append(merged, parent...), tags...)
+	return context.WithValue(ctx, tagsContextKey{}, merged)
+}
+
+// TagsFromContext returns the tags added to ctx with WithTags.
+func TagsFromContext(ctx context.Context) []string {
+	tags, _ := ctx.Value(tagsContextKey{}).([]string)
+	return tags
+}
+
 // ProfilerContext is a context key for profiler.
 type profilerContextKey struct{}
 
@@ -0,0 +1,62 @@
+package query
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ProfileCapture is a profiling session saved for replay, such as by
+// `nexus bench`. Unlike a report it keeps every statement with its
+// arguments, so it should be stored like the data it was captured from.
+type ProfileCapture struct {
+	SessionID string          `json:"sessionId"`
+	StartTime time.Time       `json:"startTime"`
+	Queries   []CapturedQuery `json:"queries"`
+}
+
+// CapturedQuery is a statement of a ProfileCapture.
+type CapturedQuery struct {
+	SQL  string        `json:"sql"`
+	Args []interface{} `json:"args,omitempty"`
+	// Offset is when the statement started, from the start of the session.
+	Offset time.Duration `json:"offsetNs"`
+	// Duration is how long the statement took when it was captured.
+	Duration time.Duration `json:"durationNs"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Capture returns the statements of the current session, oldest first.
+func (p *Profiler) Capture() *ProfileCapture {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.session == nil {
+		return &ProfileCapture{}
+	}
+	capture := &ProfileCapture{
+		SessionID: p.session.ID,
+		StartTime: p.session.StartTime,
+		Queries:   make([]CapturedQuery, 0, len(p.session.Profiles)),
+	}
+	for _, profile := range p.session.Profiles {
+		q := CapturedQuery{
+			SQL:      profile.SQL,
+			Args:     profile.Args,
+			Offset:   profile.StartTime.Sub(p.session.StartTime),
+			Duration: profile.Duration,
+		}
+		if profile.Error != nil {
+			q.Error = profile.Error.Error()
+		}
+		capture.Queries = append(capture.Queries, q)
+	}
+	return capture
+}
+
+// WriteCapture writes the capture of the current session to w as JSON.
+func (p *Profiler) WriteCapture(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p.Capture())
+}
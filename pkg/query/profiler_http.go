@@ -0,0 +1,270 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// ProfilerHandler returns an http.Handler that controls p, so a running
+// service can be profiled for a while without redeploying it. Mount it
+// behind the service's authentication:
+//
+//	mux.Handle("/debug/nexus/profiler/", requireAdmin(http.StripPrefix("/debug/nexus/profiler", query.ProfilerHandler(p))))
+//
+// Requests are routed by the last element of their path:
+//
+//	GET  /report  the report as JSON, or with ?format= as text, html or markdown;
+//	              ?tag=tenant:42 reports only the queries with the tag
+//	POST /start   starts a session; ?duration=30s stops it after 30s
+//	POST /stop    stops the session and returns the report
+//	POST /reset   clears the profiles collected so far
+//
+// Query arguments are left out of responses since they often hold user
+// data.
+func ProfilerHandler(p *Profiler) http.Handler {
+	return &profilerHandler{profiler: p}
+}
+
+// profilerHandler serves ProfilerHandler, stopping timed sessions.
+type profilerHandler struct {
+	profiler *Profiler
+
+	mu      sync.Mutex
+	timer   *time.Timer // Stops the current timed session
+	stopsAt time.Time
+}
+
+func (h *profilerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := path.Base(r.URL.Path)
+	method := http.MethodPost
+	if action == "report" || action == "/" || action == "." {
+		action, method = "report", http.MethodGet
+	}
+	if r.Method != method {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch action {
+	case "report":
+		switch format := r.URL.Query().Get("format"); format {
+		case "", ReportFormatJSON:
+			h.respond(w, r, http.StatusOK)
+		case ReportFormatText, ReportFormatHTML, ReportFormatMarkdown:
+			body, err := h.report(r).Format(format)
+			if err != nil {
+				h.error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			contentType := "text/plain; charset=utf-8"
+			if format == ReportFormatHTML {
+				contentType = "text/html; charset=utf-8"
+			} else if format == ReportFormatMarkdown {
+				contentType = "text/markdown; charset=utf-8"
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Write(body)
+		default:
+			h.error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+		}
+
+	case "start":
+		var duration time.Duration
+		if d := r.URL.Query().Get("duration"); d != "" {
+			var err error
+			if duration, err = time.ParseDuration(d); err != nil || duration <= 0 {
+				h.error(w, fmt.Sprintf("invalid duration %q", d), http.StatusBadRequest)
+				return
+			}
+		}
+		h.start(duration)
+		h.respond(w, r, http.StatusOK)
+
+	case "stop":
+		h.stop()
+		h.respond(w, r, http.StatusOK)
+
+	case "reset":
+		h.profiler.Reset()
+		h.respond(w, r, http.StatusOK)
+
+	default:
+		h.error(w, fmt.Sprintf("unknown profiler action %q", action), http.StatusNotFound)
+	}
+}
+
+// start begins a session, replacing any running one, and schedules its
+// end when duration is set.
+func (h *profilerHandler) start(duration time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.cancelTimer()
+	h.profiler.Start()
+	if duration > 0 {
+		h.stopsAt = time.Now().Add(duration)
+		var timer *time.Timer
+		timer = time.AfterFunc(duration, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			// A later start or stop replaced this session
+			if h.timer != timer {
+				return
+			}
+			h.timer = nil
+			h.profiler.Stop()
+		})
+		h.timer = timer
+	}
+}
+
+// stop ends the session.
+func (h *profilerHandler) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.cancelTimer()
+	h.profiler.Stop()
+}
+
+// cancelTimer stops the timer of a timed session. h.mu must be held.
+func (h *profilerHandler) cancelTimer() {
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	h.stopsAt = time.Time{}
+}
+
+// respond writes the profiler's state and report.
+func (h *profilerHandler) respond(w http.ResponseWriter, r *http.Request, status int) {
+	h.mu.Lock()
+	resp := map[string]interface{}{
+		"enabled": h.profiler.IsEnabled(),
+		"report":  profileReportJSON(h.report(r)),
+	}
+	if h.timer != nil {
+		resp["stopsAt"] = h.stopsAt
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// report returns the report of the queries with the tags of the request.
+func (h *profilerHandler) report(r *http.Request) *ProfileReport {
+	if tags := r.URL.Query()["tag"]; len(tags) > 0 {
+		return h.profiler.ReportTagged(tags...)
+	}
+	return h.profiler.Report()
+}
+
+func (h *profilerHandler) error(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// Milliseconds converts a duration to fractional milliseconds, the unit
+// profiles report durations in.
+func Milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// profileReportJSON converts a report for a response, with durations in
+// milliseconds and without query arguments.
+func profileReportJSON(r *ProfileReport) map[string]interface{} {
+	profiles := func(ps []*QueryProfile) []map[string]interface{} {
+		out := make([]map[string]interface{}, 0, len(ps))
+		for _, p := range ps {
+			entry := map[string]interface{}{
+				"sql":          p.SQL,
+				"duration":     Milliseconds(p.Duration),
+				"rowsAffected": p.RowsAffected,
+				"rowsReturned": p.RowsReturned,
+				"startTime":    p.StartTime,
+				"slow":         p.IsSlow,
+			}
+			if p.CallerInfo != "" {
+				entry["caller"] = p.CallerInfo
+			}
+			if p.Error != nil {
+				entry["error"] = p.Error.Error()
+			}
+			out = append(out, entry)
+		}
+		return out
+	}
+
+	frequency := make([]map[string]interface{}, 0, len(r.TopByFrequency))
+	for _, f := range r.TopByFrequency {
+		frequency = append(frequency, map[string]interface{}{
+			"pattern":       f.Pattern,
+			"count":         f.Count,
+			"totalDuration": Milliseconds(f.TotalDuration),
+			"avgDuration":   Milliseconds(f.AvgDuration),
+		})
+	}
+	warnings := func(ws []NPlusOneWarning) []map[string]interface{} {
+		out := make([]map[string]interface{}, 0, len(ws))
+		for _, w := range ws {
+			fixes := make([]map[string]interface{}, 0, len(w.Fixes))
+			for _, fix := range w.Fixes {
+				fixes = append(fixes, map[string]interface{}{
+					"model":     fix.Model,
+					"relation":  fix.Relation,
+					"include":   fix.Include,
+					"batchLoad": fix.BatchLoad,
+				})
+			}
+			out = append(out, map[string]interface{}{
+				"pattern":  w.Pattern,
+				"count":    w.Count,
+				"examples": w.Examples,
+				"callers":  w.Callers,
+				"fixes":    fixes,
+			})
+		}
+		return out
+	}
+	endpoints := make([]map[string]interface{}, 0, len(r.Endpoints))
+	for _, e := range r.Endpoints {
+		endpoints = append(endpoints, map[string]interface{}{
+			"endpoint":         e.Endpoint,
+			"requests":         e.Requests,
+			"queries":          e.Queries,
+			"maxQueries":       e.MaxQueries,
+			"duration":         Milliseconds(e.Duration),
+			"nPlusOneRequests": e.NPlusOneRequests,
+			"nPlusOne":         warnings(e.NPlusOne),
+		})
+	}
+	suggestions := r.Suggestions
+	if suggestions == nil {
+		suggestions = []string{}
+	}
+
+	return map[string]interface{}{
+		"sessionId":        r.SessionID,
+		"totalQueries":     r.TotalQueries,
+		"totalDuration":    Milliseconds(r.TotalDuration),
+		"averageDuration":  Milliseconds(r.AverageDuration),
+		"sessionDuration":  Milliseconds(r.SessionDuration),
+		"errorCount":       r.ErrorCount,
+		"sqlCacheHits":     r.SQLCacheHits,
+		"sqlCacheMisses":   r.SQLCacheMisses,
+		"slowQueries":      profiles(r.SlowQueries),
+		"topByDuration":    profiles(r.TopByDuration),
+		"topByFrequency":   frequency,
+		"nPlusOneWarnings": warnings(r.NPlusOneWarnings),
+		"endpoints":        endpoints,
+		"pool":             poolReportJSON(r.Pool),
+		"suggestions":      suggestions,
+	}
+}
@@ -0,0 +1,356 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// Headers ProfileMiddleware sets on responses.
+const (
+	QueryCountHeader = "X-Nexus-Query-Count"
+	QueryTimeHeader  = "X-Nexus-Query-Time"
+	NPlusOneHeader   = "X-Nexus-N-Plus-One"
+)
+
+// Hook returns a hook that profiles every statement run through a
+// connection, including those of raw queries, tagging them with the tags
+// of their context and the request of a ProfileMiddleware scope. Use it
+// instead of WithProfiler on builders, which would profile their
+// statements twice:
+//
+//	conn.Use(profiler.Hook())
+func (p *Profiler) Hook() dialects.QueryHook {
+	return func(ctx context.Context, event dialects.QueryEvent) {
+		scope := RequestProfileFromContext(ctx)
+		if scope == nil && !p.IsEnabled() {
+			return
+		}
+		profile := &QueryProfile{
+			SQL:       event.Query,
+			Args:      event.Args,
+			Duration:  event.Duration,
+			StartTime: event.Start,
+			EndTime:   event.Start.Add(event.Duration),
+			Error:     event.Err,
+			IsSlow:    event.Duration > p.opts.SlowThreshold,
+			Tags:      TagsFromContext(ctx),
+		}
+		if p.opts.EnableCallerInfo {
+			profile.CallerInfo = externalCallerInfo()
+		}
+		if scope != nil {
+			profile.Tags = append(profile.Tags[:len(profile.Tags):len(profile.Tags)], "method:"+scope.Method, "route:"+scope.Route())
+			scope.add(profile)
+		}
+		p.Record(profile)
+	}
+}
+
+// RequestProfile is the profile of one HTTP request, collected by
+// ProfileMiddleware from the statements run with its context.
+type RequestProfile struct {
+	// Method is the request method.
+	Method string
+
+	req       *http.Request
+	threshold int
+
+	mu      sync.Mutex
+	queries []*QueryProfile
+}
+
+type requestProfileKey struct{}
+
+// RequestProfileFromContext returns the request profile of a
+// ProfileMiddleware scope, or nil outside one.
+func RequestProfileFromContext(ctx context.Context) *RequestProfile {
+	rp, _ := ctx.Value(requestProfileKey{}).(*RequestProfile)
+	return rp
+}
+
+// Route returns the pattern of the route http.ServeMux matched, or the URL
+// path if there is none.
+func (rp *RequestProfile) Route() string {
+	if rp.req.Pattern != "" {
+		// Patterns may start with a method, which Endpoint adds back
+		if _, route, ok := strings.Cut(rp.req.Pattern, " "); ok {
+			return strings.TrimSpace(route)
+		}
+		return rp.req.Pattern
+	}
+	return rp.req.URL.Path
+}
+
+// Endpoint returns the method and route of the request, such as
+// "GET /users/{id}".
+func (rp *RequestProfile) Endpoint() string {
+	return rp.Method + " " + rp.Route()
+}
+
+// Queries returns the statements run so far.
+func (rp *RequestProfile) Queries() []*QueryProfile {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return append([]*QueryProfile(nil), rp.queries...)
+}
+
+// Count returns the number of statements run so far.
+func (rp *RequestProfile) Count() int {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return len(rp.queries)
+}
+
+// Duration returns the time spent in the database so far.
+func (rp *RequestProfile) Duration() time.Duration {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	var total time.Duration
+	for _, q := range rp.queries {
+		total += q.Duration
+	}
+	return total
+}
+
+// NPlusOne returns the patterns the request ran at least the profiler's
+// NPlusOneThreshold times, most repeated first.
+func (rp *RequestProfile) NPlusOne() []NPlusOneWarning {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	byPattern := make(map[string]*NPlusOneWarning)
+	var order []string
+	for _, q := range rp.queries {
+		pattern := normalizeSQL(q.SQL)
+		w, ok := byPattern[pattern]
+		if !ok {
+			w = &NPlusOneWarning{Pattern: pattern}
+			byPattern[pattern] = w
+			order = append(order, pattern)
+		}
+		w.Count++
+		if len(w.Examples) < 3 {
+			w.Examples = append(w.Examples, q.SQL)
+		}
+		if q.CallerInfo != "" && len(w.Callers) < 3 {
+			w.Callers = append(w.Callers, q.CallerInfo)
+		}
+	}
+
+	var warnings []NPlusOneWarning
+	for _, pattern := range order {
+		if w := byPattern[pattern]; w.Count >= rp.threshold {
+			warnings = append(warnings, *w)
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Count > warnings[j].Count })
+	return warnings
+}
+
+func (rp *RequestProfile) add(q *QueryProfile) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.queries = append(rp.queries, q)
+}
+
+// ProfileMiddleware returns net/http middleware that gives each request a
+// profiling scope, like a debug toolbar. Statements run with the request's
+// context through a connection using p.Hook() are tagged with its method
+// and route, and the response gets headers summarizing them:
+//
+//	X-Nexus-Query-Count: 12
+//	X-Nexus-Query-Time: 3.52ms
+//	X-Nexus-N-Plus-One: 1
+//	Server-Timing: db;dur=3.52;desc="12 queries"
+//
+// Headers are sent with the response, so they count the statements run
+// before the handler first writes. While p is started, the report also
+// breaks the requests down by endpoint.
+//
+//	conn.Use(profiler.Hook())
+//	http.ListenAndServe(":8080", query.ProfileMiddleware(profiler)(mux))
+func ProfileMiddleware(p *Profiler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rp := &RequestProfile{Method: r.Method, threshold: p.opts.NPlusOneThreshold}
+			r = r.WithContext(context.WithValue(r.Context(), requestProfileKey{}, rp))
+			// http.ServeMux sets the pattern on this request as it routes it
+			rp.req = r
+
+			pw := &profiledResponseWriter{ResponseWriter: w, profile: rp}
+			next.ServeHTTP(pw, r)
+			pw.writeSummary()
+			p.recordRequest(rp)
+		})
+	}
+}
+
+// profiledResponseWriter adds the summary of a request profile to the
+// headers of the response as it is written.
+type profiledResponseWriter struct {
+	http.ResponseWriter
+	profile *RequestProfile
+	written bool
+}
+
+func (w *profiledResponseWriter) WriteHeader(status int) {
+	w.writeSummary()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *profiledResponseWriter) Write(b []byte) (int, error) {
+	w.writeSummary()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the response, for streaming handlers.
+func (w *profiledResponseWriter) Flush() {
+	w.writeSummary()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *profiledResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writeSummary sets the summary headers, once, before the response is
+// written.
+func (w *profiledResponseWriter) writeSummary() {
+	if w.written {
+		return
+	}
+	w.written = true
+
+	count, total := w.profile.Count(), w.profile.Duration()
+	h := w.Header()
+	h.Set(QueryCountHeader, strconv.Itoa(count))
+	h.Set(QueryTimeHeader, total.Round(time.Microsecond).String())
+	if n := len(w.profile.NPlusOne()); n > 0 {
+		h.Set(NPlusOneHeader, strconv.Itoa(n))
+	}
+	h.Add("Server-Timing", fmt.Sprintf("db;dur=%.2f;desc=\"%d queries\"", Milliseconds(total), count))
+}
+
+// EndpointProfile summarizes the requests of one endpoint seen by
+// ProfileMiddleware during a session.
+type EndpointProfile struct {
+	// Endpoint is the method and route, such as "GET /users/{id}".
+	Endpoint string
+	// Requests is the number of requests.
+	Requests int
+	// Queries is the number of statements of all requests.
+	Queries int
+	// MaxQueries is the most statements a single request ran.
+	MaxQueries int
+	// Duration is the database time of all requests.
+	Duration time.Duration
+	// NPlusOneRequests is the number of requests that ran an N+1 pattern.
+	NPlusOneRequests int
+	// NPlusOne are the N+1 patterns of the endpoint, with the most
+	// repetitions seen in a single request.
+	NPlusOne []NPlusOneWarning
+}
+
+// AvgQueries returns the mean number of statements per request.
+func (e EndpointProfile) AvgQueries() float64 {
+	if e.Requests == 0 {
+		return 0
+	}
+	return float64(e.Queries) / float64(e.Requests)
+}
+
+// recordRequest adds a finished request to the session.
+func (p *Profiler) recordRequest(rp *RequestProfile) {
+	warnings := rp.NPlusOne()
+	count, total := rp.Count(), rp.Duration()
+	endpoint := rp.Endpoint()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.session == nil || !p.enabled {
+		return
+	}
+	if p.session.endpoints == nil {
+		p.session.endpoints = make(map[string]*EndpointProfile)
+	}
+	e, ok := p.session.endpoints[endpoint]
+	if !ok {
+		e = &EndpointProfile{Endpoint: endpoint}
+		p.session.endpoints[endpoint] = e
+	}
+	e.Requests++
+	e.Queries += count
+	e.Duration += total
+	if count > e.MaxQueries {
+		e.MaxQueries = count
+	}
+	if len(warnings) > 0 {
+		e.NPlusOneRequests++
+	}
+	for _, w := range warnings {
+		merged := false
+		for i := range e.NPlusOne {
+			if e.NPlusOne[i].Pattern == w.Pattern {
+				if w.Count > e.NPlusOne[i].Count {
+					e.NPlusOne[i] = w
+				}
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			e.NPlusOne = append(e.NPlusOne, w)
+		}
+	}
+}
+
+// endpointProfiles returns the endpoints of a session, those spending the
+// most time in the database first.
+func endpointProfiles(s *ProfilingSession) []EndpointProfile {
+	var endpoints []EndpointProfile
+	for _, e := range s.endpoints {
+		c := *e
+		c.NPlusOne = append([]NPlusOneWarning(nil), e.NPlusOne...)
+		endpoints = append(endpoints, c)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Duration != endpoints[j].Duration {
+			return endpoints[i].Duration > endpoints[j].Duration
+		}
+		return endpoints[i].Endpoint < endpoints[j].Endpoint
+	})
+	return endpoints
+}
+
+// externalCallerInfo returns the file:line of the first caller outside
+// Nexus and database/sql, where a hooked statement was run from.
+func externalCallerInfo() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.Function, "github.com/nexus-db/nexus/pkg/") &&
+			!strings.HasPrefix(frame.Function, "database/sql.") && frame.File != "" {
+			parts := strings.Split(frame.File, "/")
+			if len(parts) > 2 {
+				parts = parts[len(parts)-2:]
+			}
+			return fmt.Sprintf("%s:%d", strings.Join(parts, "/"), frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
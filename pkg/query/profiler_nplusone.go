@@ -0,0 +1,122 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// NPlusOneFix is a code change that replaces an N+1 pattern with the
+// relation it loads, found by matching the repeated lookup to the schema.
+type NPlusOneFix struct {
+	// Model is the model the relation is declared on.
+	Model string
+	// Relation is the name to pass to Include.
+	Relation string
+	// Include loads the relation eagerly with the parent rows.
+	Include string
+	// BatchLoad loads the related rows of many parents in one query.
+	BatchLoad string
+}
+
+// relationLookup matches a lookup of rows by a single column, as run by
+// lazy loading or by hand in a loop:
+//
+//	SELECT * FROM "posts" WHERE "user_id" = ?
+var relationLookup = regexp.MustCompile(`(?is)^SELECT\s+.+?\s+FROM\s+` + identPattern + `\s+WHERE\s+` + identPattern +
+	`\s*=\s*(?:\?|\$\d+|@p\d+|:\w+|\d+)(?:\s+(?:LIMIT|OFFSET|FETCH)\b.*)?;?$`)
+
+// identPattern matches an identifier, quoted in any dialect's style.
+const identPattern = "(?:\"([^\"]+)\"|`([^`]+)`|\\[([^\\]]+)\\]|(\\w+))"
+
+// parseRelationLookup returns the table and column of a relation lookup.
+func parseRelationLookup(pattern string) (table, column string, ok bool) {
+	m := relationLookup.FindStringSubmatch(strings.Join(strings.Fields(pattern), " "))
+	if m == nil {
+		return "", "", false
+	}
+	first := func(groups []string) string {
+		for _, g := range groups {
+			if g != "" {
+				return g
+			}
+		}
+		return ""
+	}
+	return first(m[1:5]), first(m[5:9]), true
+}
+
+// relationFixes maps a repeated lookup back to the schema relations that
+// load the same rows, and returns the code that loads them in one query.
+func relationFixes(sch *schema.Schema, pattern string) []NPlusOneFix {
+	table, column, ok := parseRelationLookup(pattern)
+	if !ok {
+		return nil
+	}
+
+	var fixes []NPlusOneFix
+	for _, model := range sch.GetModels() {
+		for _, rel := range model.GetRelations() {
+			var batch string
+			switch rel.Type {
+			case schema.RelationHasMany, schema.RelationHasOne:
+				// Children looked up by their foreign key
+				if !modelHasTable(sch, rel.TargetModel, table) || rel.ForeignKey != column {
+					continue
+				}
+				batch = batchLoad(table, column, model.Name)
+			case schema.RelationBelongsTo:
+				// Parents looked up by the key the foreign key references
+				if !modelHasTable(sch, rel.TargetModel, table) || rel.ReferenceKey != column {
+					continue
+				}
+				batch = batchLoad(table, column, rel.TargetModel)
+			case schema.RelationManyToMany:
+				// Junction rows looked up by the source model's key
+				if !strings.EqualFold(rel.Through, table) || rel.ThroughSourceKey != column {
+					continue
+				}
+				batch = batchLoad(table, column, model.Name)
+			default:
+				continue
+			}
+
+			fixes = append(fixes, NPlusOneFix{
+				Model:    model.Name,
+				Relation: rel.TargetModel,
+				Include: fmt.Sprintf("query.New(conn, %q).Select().WithSchema(schema).Include(%q)",
+					modelTable(model), rel.TargetModel),
+				BatchLoad: batch,
+			})
+		}
+	}
+	return fixes
+}
+
+// modelHasTable reports whether the named model is stored in table, under
+// its mapped name or the name eager loading queries.
+func modelHasTable(sch *schema.Schema, name, table string) bool {
+	if model, ok := sch.Models[name]; ok && strings.EqualFold(model.Table(), table) {
+		return true
+	}
+	return strings.EqualFold(toTableName(name), table)
+}
+
+// modelTable returns the table a builder for model should be created on.
+func modelTable(model *schema.Model) string {
+	if model.TableName != "" {
+		return model.TableName
+	}
+	return toTableName(model.Name)
+}
+
+// batchLoad returns a query loading the rows of table for many keys at
+// once, named after the model the keys belong to.
+func batchLoad(table, column, keyModel string) string {
+	ids := []rune(keyModel)
+	ids[0] = unicode.ToLower(ids[0])
+	return fmt.Sprintf("query.New(conn, %q).Select().Where(query.In(%q, %sIDs...))", table, column, string(ids))
+}
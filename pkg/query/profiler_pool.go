@@ -0,0 +1,255 @@
+package query
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PoolStats is a sample of the statistics of a connection pool, from
+// sql.DB.Stats.
+type PoolStats struct {
+	// Time the sample was taken.
+	Time time.Time
+	// MaxOpen is the pool's limit of open connections, 0 for none.
+	MaxOpen int
+	// Open is the number of open connections, in use or idle.
+	Open int
+	// InUse is the number of connections running statements.
+	InUse int
+	// Idle is the number of connections waiting in the pool.
+	Idle int
+	// WaitCount is the total number of waits for a free connection.
+	WaitCount int64
+	// WaitDuration is the total time spent waiting for one.
+	WaitDuration time.Duration
+	// MaxIdleClosed and MaxLifetimeClosed count connections closed for
+	// SetMaxIdleConns and SetConnMaxLifetime.
+	MaxIdleClosed     int64
+	MaxLifetimeClosed int64
+}
+
+// SamplePool returns the current statistics of db.
+func SamplePool(db *sql.DB) PoolStats {
+	s := db.Stats()
+	return PoolStats{
+		Time:              time.Now(),
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitDuration:      s.WaitDuration,
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
+}
+
+// Saturated reports whether every connection the pool may open was in
+// use, so further statements had to wait.
+func (s PoolStats) Saturated() bool {
+	return s.MaxOpen > 0 && s.InUse >= s.MaxOpen
+}
+
+// PoolReport summarizes the pool samples of a profiling session.
+type PoolReport struct {
+	// Samples are the statistics sampled during the session, oldest
+	// first.
+	Samples []PoolStats
+	// MaxOpen is the pool's limit of open connections, 0 for none.
+	MaxOpen int
+	// PeakInUse is the most connections in use in a sample.
+	PeakInUse int
+	// SaturatedSamples is the number of samples with every connection
+	// in use.
+	SaturatedSamples int
+	// Waits and WaitDuration are the waits for a free connection during
+	// the session.
+	Waits        int64
+	WaitDuration time.Duration
+}
+
+// AvgWait returns the mean time a wait for a connection took.
+func (r *PoolReport) AvgWait() time.Duration {
+	if r.Waits == 0 {
+		return 0
+	}
+	return r.WaitDuration / time.Duration(r.Waits)
+}
+
+// newPoolReport summarizes samples.
+func newPoolReport(samples []PoolStats) *PoolReport {
+	if len(samples) == 0 {
+		return nil
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	r := &PoolReport{
+		Samples:      append([]PoolStats(nil), samples...),
+		MaxOpen:      last.MaxOpen,
+		Waits:        last.WaitCount - first.WaitCount,
+		WaitDuration: last.WaitDuration - first.WaitDuration,
+	}
+	for _, s := range samples {
+		if s.InUse > r.PeakInUse {
+			r.PeakInUse = s.InUse
+		}
+		if s.Saturated() {
+			r.SaturatedSamples++
+		}
+	}
+	return r
+}
+
+// DefaultPoolSampleInterval is how often a profiler with a Pool samples it
+// when ProfilerOptions.PoolSampleInterval is zero.
+const DefaultPoolSampleInterval = time.Second
+
+// maxPoolSamples bounds the samples kept by a session or a PoolHistory.
+const maxPoolSamples = 3600
+
+// startPoolSampling samples the pool of the options until the session is
+// stopped. It is called with p.mu held.
+func (p *Profiler) startPoolSampling() {
+	if p.poolStop != nil {
+		close(p.poolStop)
+		p.poolStop = nil
+	}
+	if p.opts.Pool == nil {
+		return
+	}
+	interval := p.opts.PoolSampleInterval
+	if interval <= 0 {
+		interval = DefaultPoolSampleInterval
+	}
+	stop := make(chan struct{})
+	p.poolStop = stop
+	session := p.session
+	session.pool = append(session.pool, SamplePool(p.opts.Pool))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				sample := SamplePool(p.opts.Pool)
+				p.mu.Lock()
+				select {
+				case <-stop: // Stopped while sampling
+				default:
+					session.addPoolSample(sample)
+				}
+				p.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// stopPoolSampling takes a last sample and stops sampling. It is called
+// with p.mu held.
+func (p *Profiler) stopPoolSampling() {
+	if p.poolStop == nil {
+		return
+	}
+	close(p.poolStop)
+	p.poolStop = nil
+	p.session.addPoolSample(SamplePool(p.opts.Pool))
+}
+
+func (s *ProfilingSession) addPoolSample(sample PoolStats) {
+	if len(s.pool) >= maxPoolSamples {
+		s.pool = s.pool[1:]
+	}
+	s.pool = append(s.pool, sample)
+}
+
+// PoolHistory keeps the latest samples of a pool's statistics, taken as
+// they are asked for, for dashboards polling the pool without a profiling
+// session.
+type PoolHistory struct {
+	db *sql.DB
+
+	mu      sync.Mutex
+	samples []PoolStats
+}
+
+// NewPoolHistory creates a history of the statistics of db.
+func NewPoolHistory(db *sql.DB) *PoolHistory {
+	return &PoolHistory{db: db}
+}
+
+// Sample takes a sample, keeps it and returns it.
+func (h *PoolHistory) Sample() PoolStats {
+	sample := SamplePool(h.db)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.samples) >= maxPoolSamples {
+		h.samples = h.samples[1:]
+	}
+	h.samples = append(h.samples, sample)
+	return sample
+}
+
+// Samples returns the samples taken, oldest first.
+func (h *PoolHistory) Samples() []PoolStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]PoolStats(nil), h.samples...)
+}
+
+// poolLimit formats the limit of open connections of a pool.
+func poolLimit(maxOpen int) string {
+	if maxOpen == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(maxOpen)
+}
+
+// poolSuggestion suggests a larger pool if statements waited for
+// connections during the session.
+func poolSuggestion(r *PoolReport) string {
+	if r == nil || r.Waits == 0 {
+		return ""
+	}
+	return fmt.Sprintf("🚰 %d statements waited for a free connection (avg %s, peak %d of %s in use) - consider raising SetMaxOpenConns or shortening transactions",
+		r.Waits, r.AvgWait().Round(time.Microsecond), r.PeakInUse, poolLimit(r.MaxOpen))
+}
+
+// poolStatsJSON converts pool statistics for a response, with durations in
+// milliseconds.
+func poolStatsJSON(s PoolStats) map[string]interface{} {
+	return map[string]interface{}{
+		"time":              s.Time,
+		"maxOpen":           s.MaxOpen,
+		"open":              s.Open,
+		"inUse":             s.InUse,
+		"idle":              s.Idle,
+		"waitCount":         s.WaitCount,
+		"waitDuration":      Milliseconds(s.WaitDuration),
+		"maxIdleClosed":     s.MaxIdleClosed,
+		"maxLifetimeClosed": s.MaxLifetimeClosed,
+		"saturated":         s.Saturated(),
+	}
+}
+
+// poolReportJSON converts a pool report for a response.
+func poolReportJSON(r *PoolReport) map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	samples := make([]map[string]interface{}, 0, len(r.Samples))
+	for _, s := range r.Samples {
+		samples = append(samples, poolStatsJSON(s))
+	}
+	return map[string]interface{}{
+		"maxOpen":          r.MaxOpen,
+		"peakInUse":        r.PeakInUse,
+		"saturatedSamples": r.SaturatedSamples,
+		"waits":            r.Waits,
+		"waitDuration":     Milliseconds(r.WaitDuration),
+		"samples":          samples,
+	}
+}
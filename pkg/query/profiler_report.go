@@ -0,0 +1,370 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Report formats accepted by ProfileReport.Format.
+const (
+	ReportFormatText     = "text"
+	ReportFormatJSON     = "json"
+	ReportFormatHTML     = "html"
+	ReportFormatMarkdown = "markdown"
+)
+
+// ReportFormatForPath returns the report format of a file by its
+// extension: .json, .html or .htm, .md or .markdown, and text otherwise.
+func ReportFormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ReportFormatJSON
+	case ".html", ".htm":
+		return ReportFormatHTML
+	case ".md", ".markdown":
+		return ReportFormatMarkdown
+	}
+	return ReportFormatText
+}
+
+// Format renders the report as text, json, html or markdown.
+func (r *ProfileReport) Format(format string) ([]byte, error) {
+	switch format {
+	case ReportFormatText, "":
+		return []byte(r.String()), nil
+	case ReportFormatJSON:
+		return r.JSON()
+	case ReportFormatHTML:
+		return r.HTML()
+	case ReportFormatMarkdown:
+		return []byte(r.Markdown()), nil
+	}
+	return nil, fmt.Errorf("unknown report format %q (expected text, json, html or markdown)", format)
+}
+
+// JSON returns the report as indented JSON, with durations in milliseconds
+// and without query arguments, as ProfilerHandler serves it.
+func (r *ProfileReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(profileReportJSON(r), "", "  ")
+}
+
+// Markdown returns the report as Markdown, to attach to a pull request.
+func (r *ProfileReport) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("## 📊 Performance Profile Report\n\n")
+	sb.WriteString("| Metric | Value |\n|---|---|\n")
+	for _, row := range r.summary() {
+		sb.WriteString(fmt.Sprintf("| %s | %s |\n", row[0], row[1]))
+	}
+
+	if len(r.TopByDuration) > 0 {
+		sb.WriteString("\n### 🐢 Slowest Queries\n\n| # | Duration | Query | Caller |\n|---|---|---|---|\n")
+		for i, q := range r.TopByDuration {
+			sb.WriteString(fmt.Sprintf("| %d | %s | %s | %s |\n", i+1,
+				q.Duration.Round(time.Microsecond), markdownCode(q.SQL), markdownCell(q.CallerInfo)))
+		}
+	}
+
+	if len(r.TopByFrequency) > 0 {
+		sb.WriteString("\n### 🔄 Most Frequent Queries\n\n| # | Count | Avg | Total | Query |\n|---|---|---|---|---|\n")
+		for i, f := range r.TopByFrequency {
+			sb.WriteString(fmt.Sprintf("| %d | %d | %s | %s | %s |\n", i+1, f.Count,
+				f.AvgDuration.Round(time.Microsecond), f.TotalDuration.Round(time.Microsecond), markdownCode(f.Pattern)))
+		}
+	}
+
+	if r.Pool != nil {
+		sb.WriteString("\n### 🚰 Connection Pool\n\n| Metric | Value |\n|---|---|\n")
+		sb.WriteString(fmt.Sprintf("| Peak In Use | %d of %s |\n", r.Pool.PeakInUse, poolLimit(r.Pool.MaxOpen)))
+		sb.WriteString(fmt.Sprintf("| Saturated Samples | %d of %d |\n", r.Pool.SaturatedSamples, len(r.Pool.Samples)))
+		sb.WriteString(fmt.Sprintf("| Waits | %d |\n", r.Pool.Waits))
+		sb.WriteString(fmt.Sprintf("| Wait Time | %s (avg %s) |\n",
+			r.Pool.WaitDuration.Round(time.Microsecond), r.Pool.AvgWait().Round(time.Microsecond)))
+	}
+
+	if len(r.Endpoints) > 0 {
+		sb.WriteString("\n### 🌐 Endpoints\n\n| Endpoint | Requests | Avg Queries | Max Queries | DB Time | N+1 Requests |\n|---|---|---|---|---|---|\n")
+		for _, e := range r.Endpoints {
+			sb.WriteString(fmt.Sprintf("| %s | %d | %.1f | %d | %s | %d |\n", markdownCode(e.Endpoint), e.Requests,
+				e.AvgQueries(), e.MaxQueries, e.Duration.Round(time.Microsecond), e.NPlusOneRequests))
+		}
+	}
+
+	if len(r.NPlusOneWarnings) > 0 {
+		sb.WriteString("\n### ⚠️ N+1 Query Warnings\n\n")
+		for _, w := range r.NPlusOneWarnings {
+			sb.WriteString(fmt.Sprintf("- **%dx** %s\n", w.Count, markdownCode(w.Pattern)))
+			if len(w.Callers) > 0 {
+				sb.WriteString(fmt.Sprintf("  - from: %s\n", markdownCell(w.Callers[0])))
+			}
+			for _, fix := range w.Fixes {
+				sb.WriteString(fmt.Sprintf("  - fix: %s\n", markdownCode(fix.Include)))
+			}
+		}
+	}
+
+	if len(r.Suggestions) > 0 {
+		sb.WriteString("\n### 💡 Suggestions\n\n")
+		for _, s := range r.Suggestions {
+			sb.WriteString(fmt.Sprintf("- %s\n", s))
+		}
+	}
+
+	return sb.String()
+}
+
+// HTML returns the report as a self-contained page, with bar charts of the
+// slowest and most frequent queries, for dashboards and CI artifacts.
+func (r *ProfileReport) HTML() ([]byte, error) {
+	type bar struct {
+		Label string
+		Value string
+		Title string
+		Width float64 // Percent of the largest bar
+		Y     int
+	}
+	chart := func(n int, value func(i int) float64, label func(i int) (string, string, string)) []bar {
+		var largest float64
+		for i := 0; i < n; i++ {
+			if v := value(i); v > largest {
+				largest = v
+			}
+		}
+		bars := make([]bar, n)
+		for i := range bars {
+			b := &bars[i]
+			b.Label, b.Value, b.Title = label(i)
+			if largest > 0 {
+				b.Width = value(i) / largest * 100
+			}
+			b.Y = i * reportBarHeight
+		}
+		return bars
+	}
+
+	slowest := r.TopByDuration
+	if len(slowest) > reportChartBars {
+		slowest = slowest[:reportChartBars]
+	}
+	frequent := r.TopByFrequency
+	if len(frequent) > reportChartBars {
+		frequent = frequent[:reportChartBars]
+	}
+
+	var buf bytes.Buffer
+	err := profileReportHTML.Execute(&buf, map[string]interface{}{
+		"Report":  r,
+		"Summary": r.summary(),
+		"Slowest": chart(len(slowest), func(i int) float64 { return float64(slowest[i].Duration) },
+			func(i int) (string, string, string) {
+				q := slowest[i]
+				return truncateSQL(q.SQL, 70), q.Duration.Round(time.Microsecond).String(), q.SQL
+			}),
+		"Frequent": chart(len(frequent), func(i int) float64 { return float64(frequent[i].Count) },
+			func(i int) (string, string, string) {
+				f := frequent[i]
+				return truncateSQL(f.Pattern, 70), fmt.Sprintf("%dx", f.Count), f.Pattern
+			}),
+		"BarHeight": reportBarHeight,
+		"PoolChart": poolChart(r.Pool),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// poolChart returns the points of a line of the connections in use over
+// the pool samples, scaled to reportPoolWidth by reportPoolHeight.
+func poolChart(pool *PoolReport) string {
+	if pool == nil || len(pool.Samples) < 2 {
+		return ""
+	}
+	top := pool.MaxOpen
+	if top == 0 || pool.PeakInUse > top {
+		top = pool.PeakInUse
+	}
+	if top == 0 {
+		top = 1
+	}
+	points := make([]string, len(pool.Samples))
+	for i, s := range pool.Samples {
+		x := float64(i) / float64(len(pool.Samples)-1) * reportPoolWidth
+		y := reportPoolHeight - float64(s.InUse)/float64(top)*reportPoolHeight
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	return strings.Join(points, " ")
+}
+
+// summary returns the headline numbers of the report as label, value
+// pairs.
+func (r *ProfileReport) summary() [][2]string {
+	rows := [][2]string{
+		{"Session", r.SessionID},
+		{"Duration", r.SessionDuration.Round(time.Millisecond).String()},
+		{"Total Queries", fmt.Sprint(r.TotalQueries)},
+		{"Total Time", r.TotalDuration.Round(time.Microsecond).String()},
+		{"Avg Query Time", r.AverageDuration.Round(time.Microsecond).String()},
+		{"Slow Queries", fmt.Sprint(len(r.SlowQueries))},
+		{"Errors", fmt.Sprint(r.ErrorCount)},
+	}
+	if lookups := r.SQLCacheHits + r.SQLCacheMisses; lookups > 0 {
+		rows = append(rows, [2]string{"SQL Cache", fmt.Sprintf("%d hits, %d misses (%.0f%%)",
+			r.SQLCacheHits, r.SQLCacheMisses, float64(r.SQLCacheHits)/float64(lookups)*100)})
+	}
+	return rows
+}
+
+// markdownCode formats SQL as inline code on one line of a table cell.
+func markdownCode(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if sql == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(sql, fence) {
+		fence += "`"
+	}
+	return fence + " " + strings.ReplaceAll(sql, "|", `\|`) + " " + fence
+}
+
+// markdownCell escapes text for a table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+const (
+	reportChartBars = 10 // Bars per chart of the HTML report
+	reportBarHeight = 28 // Pixels per bar
+
+	reportPoolWidth  = 1000 // Width of the pool chart
+	reportPoolHeight = 120  // Height of the pool chart
+)
+
+var profileReportHTML = template.Must(template.New("report").Funcs(template.FuncMap{
+	"mul":      func(a, b int) int { return a * b },
+	"ms":       func(d time.Duration) string { return fmt.Sprintf("%.2f", Milliseconds(d)) },
+	"inc":      func(i int) int { return i + 1 },
+	"hasError": func(q *QueryProfile) bool { return q.Error != nil },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Nexus Profile Report {{.Report.SessionID}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; padding: 24px 32px; color: #1f2328; background: #f6f8fa; }
+h1 { font-size: 22px; margin: 0 0 16px; }
+h2 { font-size: 16px; margin: 28px 0 10px; }
+.cards { display: flex; flex-wrap: wrap; gap: 12px; }
+.card { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 10px 14px; min-width: 120px; }
+.card .label { font-size: 12px; color: #656d76; }
+.card .value { font-size: 18px; font-weight: 600; margin-top: 2px; }
+.panel { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 12px 16px; }
+svg { width: 100%; display: block; }
+svg text { font-size: 12px; font-family: ui-monospace, SFMono-Regular, Menlo, monospace; }
+table { border-collapse: collapse; width: 100%; background: #fff; }
+th, td { border: 1px solid #d0d7de; padding: 6px 8px; text-align: left; vertical-align: top; font-size: 13px; }
+th { background: #f6f8fa; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 12px; white-space: pre-wrap; word-break: break-word; }
+.slow { color: #cf222e; }
+ul { margin: 0; padding-left: 20px; }
+</style>
+</head>
+<body>
+<h1>📊 Performance Profile Report</h1>
+<div class="cards">
+{{- range .Summary}}
+<div class="card"><div class="label">{{index . 0}}</div><div class="value">{{index . 1}}</div></div>
+{{- end}}
+</div>
+{{- if .Slowest}}
+<h2>🐢 Slowest Queries</h2>
+<div class="panel">
+<svg height="{{mul (len .Slowest) $.BarHeight}}">
+{{- range .Slowest}}
+<g><title>{{.Title}}</title>
+<rect x="0" y="{{.Y}}" width="{{printf "%.1f" .Width}}%" height="22" rx="3" fill="#fd8c73"></rect>
+<text x="6" y="{{.Y}}" dy="15">{{.Value}} · {{.Label}}</text></g>
+{{- end}}
+</svg>
+</div>
+{{- end}}
+{{- if .Frequent}}
+<h2>🔄 Most Frequent Queries</h2>
+<div class="panel">
+<svg height="{{mul (len .Frequent) $.BarHeight}}">
+{{- range .Frequent}}
+<g><title>{{.Title}}</title>
+<rect x="0" y="{{.Y}}" width="{{printf "%.1f" .Width}}%" height="22" rx="3" fill="#54aeff"></rect>
+<text x="6" y="{{.Y}}" dy="15">{{.Value}} · {{.Label}}</text></g>
+{{- end}}
+</svg>
+</div>
+<h2>Query Patterns</h2>
+<table>
+<tr><th>#</th><th>Count</th><th>Avg (ms)</th><th>Total (ms)</th><th>Query</th></tr>
+{{- range $i, $f := .Report.TopByFrequency}}
+<tr><td>{{inc $i}}</td><td>{{$f.Count}}</td><td>{{ms $f.AvgDuration}}</td><td>{{ms $f.TotalDuration}}</td><td><code>{{$f.Pattern}}</code></td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Report.SlowQueries}}
+<h2>Slow Queries</h2>
+<table>
+<tr><th>Duration (ms)</th><th>Query</th><th>Caller</th></tr>
+{{- range .Report.SlowQueries}}
+<tr><td class="slow">{{ms .Duration}}</td><td><code>{{.SQL}}</code>{{if hasError .}}<br><span class="slow">{{.Error}}</span>{{end}}</td><td>{{.CallerInfo}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- with .Report.Pool}}
+<h2>🚰 Connection Pool</h2>
+<div class="cards">
+<div class="card"><div class="label">Peak In Use</div><div class="value">{{.PeakInUse}}{{if .MaxOpen}} / {{.MaxOpen}}{{end}}</div></div>
+<div class="card"><div class="label">Saturated Samples</div><div class="value">{{.SaturatedSamples}} / {{len .Samples}}</div></div>
+<div class="card"><div class="label">Waits</div><div class="value">{{.Waits}}</div></div>
+<div class="card"><div class="label">Wait Time (ms)</div><div class="value">{{ms .WaitDuration}}</div></div>
+</div>
+{{- end}}
+{{- if .PoolChart}}
+<div class="panel" style="margin-top: 12px">
+<svg viewBox="0 0 1000 120" preserveAspectRatio="none" height="120"><title>Connections in use</title>
+<polyline points="{{.PoolChart}}" fill="none" stroke="#8250df" stroke-width="2" vector-effect="non-scaling-stroke"></polyline>
+</svg>
+</div>
+{{- end}}
+{{- if .Report.Endpoints}}
+<h2>🌐 Endpoints</h2>
+<table>
+<tr><th>Endpoint</th><th>Requests</th><th>Avg Queries</th><th>Max Queries</th><th>DB Time (ms)</th><th>N+1</th></tr>
+{{- range .Report.Endpoints}}
+<tr><td><code>{{.Endpoint}}</code></td><td>{{.Requests}}</td><td>{{printf "%.1f" .AvgQueries}}</td><td>{{.MaxQueries}}</td><td>{{ms .Duration}}</td><td>{{range .NPlusOne}}<code>{{.Count}}x {{.Pattern}}</code><br>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Report.NPlusOneWarnings}}
+<h2>⚠️ N+1 Query Warnings</h2>
+<table>
+<tr><th>Count</th><th>Pattern</th><th>Callers</th><th>Fixes</th></tr>
+{{- range .Report.NPlusOneWarnings}}
+<tr><td>{{.Count}}x</td><td><code>{{.Pattern}}</code></td><td>{{range .Callers}}{{.}}<br>{{end}}</td><td>{{range .Fixes}}<code>{{.Include}}</code><br>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Report.Suggestions}}
+<h2>💡 Suggestions</h2>
+<div class="panel"><ul>
+{{- range .Report.Suggestions}}
+<li>{{.}}</li>
+{{- end}}
+</ul></div>
+{{- end}}
+</body>
+</html>
+`))
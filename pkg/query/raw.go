@@ -3,6 +3,8 @@ package query
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/nexus-db/nexus/pkg/dialects"
@@ -10,9 +12,10 @@ import (
 
 // RawQuery represents a raw SQL query.
 type RawQuery struct {
-	conn *dialects.Connection
-	sql  string
-	args []interface{}
+	conn  *dialects.Connection
+	sql   string
+	args  []interface{}
+	bound bool // sql has the placeholders of the dialect already
 }
 
 // Raw creates a new raw SQL query.
@@ -33,6 +36,9 @@ func NewRawQuery(conn *dialects.Connection, sql string, args ...interface{}) *Ra
 // convertPlaceholders converts ? placeholders to dialect-specific format.
 func (r *RawQuery) convertPlaceholders() string {
 	dialect := r.conn.Dialect
+	if r.bound {
+		return r.sql
+	}
 
 	// Count placeholders
 	count := strings.Count(r.sql, "?")
@@ -53,6 +59,12 @@ func (r *RawQuery) convertPlaceholders() string {
 	return result
 }
 
+// Build returns the SQL as it is sent, with the placeholders of the
+// dialect, and its arguments.
+func (r *RawQuery) Build() (string, []interface{}) {
+	return r.convertPlaceholders(), r.args
+}
+
 // Query executes the raw SQL and returns rows.
 func (r *RawQuery) Query(ctx context.Context) (*sql.Rows, error) {
 	sql := r.convertPlaceholders()
@@ -99,29 +111,61 @@ func (r *RawQuery) Scan(ctx context.Context, dest ...interface{}) error {
 	return r.QueryRow(ctx).Scan(dest...)
 }
 
-// ScanStruct executes the query and scans the result into a struct.
-// The struct fields should have `db` tags matching column names.
+// ScanAll executes the query and scans the results into dest, a pointer
+// to a slice of structs or of pointers to structs, matching columns to
+// fields as ScanStruct does.
+//
+// Example:
+//
+//	var users []User
+//	err := query.NewRawQuery(conn, "SELECT * FROM users WHERE active = ?", true).ScanAll(ctx, &users)
 func (r *RawQuery) ScanAll(ctx context.Context, dest interface{}) error {
-	rows, err := r.Query(ctx)
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("ScanAll: expected a pointer to a slice, got %T", dest)
+	}
+	slice := rv.Elem()
+	elem := slice.Type().Elem()
+	isPtr := elem.Kind() == reflect.Ptr
+	if isPtr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("ScanAll: expected a slice of structs, got %T", dest)
+	}
+
+	results, err := r.All(ctx)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-
-	// This is a simplified implementation
-	// For production, use a library like sqlx for struct scanning
-	return scanIntoSlice(rows, dest)
+	out := reflect.MakeSlice(slice.Type(), 0, len(results))
+	for _, result := range results {
+		item := reflect.New(elem)
+		if err := ScanStruct(result, item.Interface()); err != nil {
+			return err
+		}
+		if isPtr {
+			out = reflect.Append(out, item)
+		} else {
+			out = reflect.Append(out, item.Elem())
+		}
+	}
+	slice.Set(out)
+	return nil
 }
 
-// scanIntoSlice is a placeholder for struct scanning.
-// In production, this should handle reflection-based scanning.
-func scanIntoSlice(rows *sql.Rows, dest interface{}) error {
-	// For now, just exhaust the rows
-	// TODO: Implement proper struct scanning
-	for rows.Next() {
-		// Skip
+// ScanOne executes the query and scans the first result into the struct
+// dest points to, as ScanStruct does. It returns sql.ErrNoRows if there
+// is no result.
+func (r *RawQuery) ScanOne(ctx context.Context, dest interface{}) error {
+	result, err := r.One(ctx)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return sql.ErrNoRows
 	}
-	return rows.Err()
+	return ScanStruct(result, dest)
 }
 
 // RawBuilder adds raw SQL support to the Builder.
@@ -0,0 +1,212 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// RelationBuilder writes the junction table of a many-to-many relation.
+type RelationBuilder struct {
+	builder *Builder
+	name    string
+}
+
+// SyncResult is what Sync changed.
+type SyncResult struct {
+	Attached []interface{} // Target IDs linked
+	Detached []interface{} // Target IDs unlinked
+}
+
+// Relation returns a builder for the many-to-many relation name of the
+// builder's model, matched by relation or target model name:
+//
+//	users.Relation("Tag").Attach(ctx, userID, tagIDs...)
+func (b *Builder) Relation(name string) *RelationBuilder {
+	return &RelationBuilder{builder: b, name: name}
+}
+
+// Attach links sourceID to targetIDs, skipping the links that exist, and
+// returns how many it added.
+func (r *RelationBuilder) Attach(ctx context.Context, sourceID interface{}, targetIDs ...interface{}) (int64, error) {
+	rel, err := r.relation(ctx, OpInsert)
+	if err != nil || len(targetIDs) == 0 {
+		return 0, err
+	}
+	var added int64
+	err = Transaction(ctx, r.builder.conn, func(tx *dialects.Tx) error {
+		existing, err := r.linked(ctx, tx, rel, sourceID)
+		if err != nil {
+			return err
+		}
+		added, err = r.insert(ctx, tx, rel, sourceID, missing(targetIDs, existing))
+		return err
+	})
+	return added, err
+}
+
+// Detach unlinks sourceID from targetIDs and returns how many links it
+// removed. It removes nothing without targetIDs; Sync with no IDs removes
+// every link.
+func (r *RelationBuilder) Detach(ctx context.Context, sourceID interface{}, targetIDs ...interface{}) (int64, error) {
+	rel, err := r.relation(ctx, OpDelete)
+	if err != nil || len(targetIDs) == 0 {
+		return 0, err
+	}
+	var removed int64
+	err = Transaction(ctx, r.builder.conn, func(tx *dialects.Tx) error {
+		removed, err = r.delete(ctx, tx, rel, sourceID, targetIDs)
+		return err
+	})
+	return removed, err
+}
+
+// Sync links sourceID to exactly targetIDs, adding and removing links in
+// one transaction.
+func (r *RelationBuilder) Sync(ctx context.Context, sourceID interface{}, targetIDs ...interface{}) (*SyncResult, error) {
+	rel, err := r.relation(ctx, OpInsert, OpDelete)
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{}
+	err = Transaction(ctx, r.builder.conn, func(tx *dialects.Tx) error {
+		existing, err := r.linked(ctx, tx, rel, sourceID)
+		if err != nil {
+			return err
+		}
+		result.Attached = missing(targetIDs, existing)
+		result.Detached = missing(existing, targetIDs)
+		if _, err := r.delete(ctx, tx, rel, sourceID, result.Detached); err != nil {
+			return err
+		}
+		_, err = r.insert(ctx, tx, rel, sourceID, result.Attached)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// relation finds the relation and checks that the caller may write the
+// builder's model, and perform ops on the junction table.
+func (r *RelationBuilder) relation(ctx context.Context, ops ...Operation) (*schema.Relation, error) {
+	b := r.builder
+	model := findModelByTable(b.schema, b.tableName)
+	if model == nil {
+		return nil, fmt.Errorf("no model for table %s in the schema", b.tableName)
+	}
+	rel := findRelation(model, r.name)
+	if rel == nil {
+		return nil, fmt.Errorf("model %s has no relation %s", model.Name, r.name)
+	}
+	if rel.Type != schema.RelationManyToMany {
+		return nil, fmt.Errorf("relation %s of %s is not many-to-many", r.name, model.Name)
+	}
+	if err := authorize(ctx, b.authorizer, b.schema, b.tableName, OpUpdate); err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if err := authorize(ctx, b.authorizer, b.schema, rel.Through, op); err != nil {
+			return nil, err
+		}
+	}
+	return rel, nil
+}
+
+// linked returns the target IDs sourceID is linked to.
+func (r *RelationBuilder) linked(ctx context.Context, tx *dialects.Tx, rel *schema.Relation, sourceID interface{}) ([]interface{}, error) {
+	dialect := r.builder.conn.Dialect
+	quoted, err := quoteIdentifiers(dialect, rel.ThroughTargetKey, rel.Through, rel.ThroughSourceKey)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s",
+		quoted[0], quoted[1], quoted[2], dialect.Placeholder(1))
+	rows, err := tx.Query(ctx, query, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results, err := scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]interface{}, 0, len(results))
+	for _, row := range results {
+		ids = append(ids, row[rel.ThroughTargetKey])
+	}
+	return ids, nil
+}
+
+// insert links sourceID to targetIDs with multi-row inserts, each within
+// the parameter limit of the dialect.
+func (r *RelationBuilder) insert(ctx context.Context, tx *dialects.Tx, rel *schema.Relation, sourceID interface{}, targetIDs []interface{}) (int64, error) {
+	b := r.builder
+	size := min(MaxParams(b.conn.Dialect)/2, 1000) // SQL Server takes 1000 rows per VALUES
+	var total int64
+	for start := 0; start < len(targetIDs); start += size {
+		var insert *InsertBuilder
+		for _, id := range targetIDs[start:min(start+size, len(targetIDs))] {
+			row := map[string]interface{}{rel.ThroughSourceKey: sourceID, rel.ThroughTargetKey: id}
+			if insert == nil {
+				insert = New(b.conn, rel.Through).Insert(row)
+			} else {
+				insert.Values(row)
+			}
+		}
+		query, args := insert.Build()
+		n, err := execProfiled(ctx, b.profiler, tx, query, args)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// delete unlinks sourceID from targetIDs, in chunks within the parameter
+// limit of the dialect.
+func (r *RelationBuilder) delete(ctx context.Context, tx *dialects.Tx, rel *schema.Relation, sourceID interface{}, targetIDs []interface{}) (int64, error) {
+	b := r.builder
+	size := MaxParams(b.conn.Dialect) - 1
+	var total int64
+	for start := 0; start < len(targetIDs); start += size {
+		chunk := targetIDs[start:min(start+size, len(targetIDs))]
+		query, args := New(b.conn, rel.Through).Delete().
+			Where(Eq(rel.ThroughSourceKey, sourceID), In(rel.ThroughTargetKey, chunk...)).Build()
+		n, err := execProfiled(ctx, b.profiler, tx, query, args)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// missing returns the IDs of ids that are not in other, without
+// duplicates. IDs compare by value, so 1 and int64(1) are the same ID.
+func missing(ids, other []interface{}) []interface{} {
+	seen := make(map[string]bool, len(other)+len(ids))
+	for _, id := range other {
+		seen[idKey(id)] = true
+	}
+	var result []interface{}
+	for _, id := range ids {
+		if key := idKey(id); !seen[key] {
+			seen[key] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// idKey returns the comparable form of an ID.
+func idKey(id interface{}) string {
+	if b, ok := id.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(id)
+}
@@ -0,0 +1,265 @@
+package query
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// timeLayouts are the formats drivers use for dates and times stored as text.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"15:04:05",
+}
+
+// ScanStruct copies a Result into the struct dst points to. Columns are
+// matched to exported fields by their db tag, or by field name if there is
+// no tag; fields tagged db:"-" and columns without a field are skipped.
+// Values are converted to the field type, so driver types such as int64,
+// []byte and text timestamps fill int, string and time.Time fields, and
+// fields of types implementing sql.Scanner scan them.
+//
+// Example:
+//
+//	var u User
+//	err := query.ScanStruct(row, &u)
+func ScanStruct(r Result, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ScanStruct: expected a pointer to a struct, got %T", dst)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue // Unexported
+		}
+		column := field.Tag.Get("db")
+		if column == "-" {
+			continue
+		}
+		if column == "" {
+			column = field.Name
+		}
+
+		value, ok := r[column]
+		if !ok {
+			continue
+		}
+		if err := assign(rv.Field(i), value); err != nil {
+			return fmt.Errorf("ScanStruct: column %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// assign stores v in dst, converting between compatible types.
+func assign(dst reflect.Value, v interface{}) error {
+	if v == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assign(elem.Elem(), v); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	src := reflect.ValueOf(v)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	// Types that scan themselves, such as decimals, UUIDs and sql.Null*
+	if dst.CanAddr() {
+		if scanner, ok := dst.Addr().Interface().(sql.Scanner); ok {
+			return scanner.Scan(v)
+		}
+	}
+
+	// Text values: []byte from MySQL, strings from SQLite
+	text, isText := v.(string)
+	if b, ok := v.([]byte); ok {
+		text, isText = string(b), true
+	}
+
+	if _, ok := dst.Interface().(time.Time); ok && isText {
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, text); err == nil {
+				dst.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot parse %q as a time", text)
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		if isText {
+			dst.SetString(text)
+		} else {
+			dst.SetString(fmt.Sprint(v))
+		}
+		return nil
+
+	case reflect.Bool:
+		switch {
+		case isText:
+			b, err := strconv.ParseBool(text)
+			if err != nil {
+				return err
+			}
+			dst.SetBool(b)
+			return nil
+		case src.CanInt():
+			dst.SetBool(src.Int() != 0)
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case isText:
+			n, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return err
+			}
+			dst.SetInt(n)
+			return nil
+		case src.CanInt() || src.CanUint() || src.CanFloat():
+			dst.Set(src.Convert(dst.Type()))
+			return nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch {
+		case isText:
+			n, err := strconv.ParseUint(text, 10, 64)
+			if err != nil {
+				return err
+			}
+			dst.SetUint(n)
+			return nil
+		case src.CanInt() || src.CanUint() || src.CanFloat():
+			dst.Set(src.Convert(dst.Type()))
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch {
+		case isText:
+			f, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return err
+			}
+			dst.SetFloat(f)
+			return nil
+		case src.CanInt() || src.CanUint() || src.CanFloat():
+			dst.Set(src.Convert(dst.Type()))
+			return nil
+		}
+
+	case reflect.Slice:
+		// []byte and named byte slices such as json.RawMessage
+		if dst.Type().Elem().Kind() == reflect.Uint8 && isText {
+			dst.Set(reflect.ValueOf([]byte(text)).Convert(dst.Type()))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot assign %T to %s", v, dst.Type())
+}
+
+// GetString returns the value of column as a string. ok is false if the
+// column is missing or NULL; []byte values, as MySQL returns text, and
+// other types are converted.
+func (r Result) GetString(column string) (string, bool) {
+	return get[string](r, column)
+}
+
+// GetInt64 returns the value of column as an int64, converting other
+// integer and float types and numbers returned as text. ok is false if the
+// column is missing, NULL or not a number.
+func (r Result) GetInt64(column string) (int64, bool) {
+	return get[int64](r, column)
+}
+
+// GetFloat64 returns the value of column as a float64, converting integers
+// and decimals returned as text. ok is false if the column is missing,
+// NULL or not a number.
+func (r Result) GetFloat64(column string) (float64, bool) {
+	return get[float64](r, column)
+}
+
+// GetBool returns the value of column as a bool. Integers are true unless
+// 0, as SQLite and MySQL store booleans, and text is parsed with
+// strconv.ParseBool. ok is false if the column is missing, NULL or not a
+// boolean.
+func (r Result) GetBool(column string) (bool, bool) {
+	return get[bool](r, column)
+}
+
+// GetTime returns the value of column as a time.Time, parsing timestamps
+// returned as text, as SQLite stores them. ok is false if the column is
+// missing, NULL or not a time.
+func (r Result) GetTime(column string) (time.Time, bool) {
+	return get[time.Time](r, column)
+}
+
+// GetBytes returns the value of column as a []byte. ok is false if the
+// column is missing, NULL or not text or binary.
+func (r Result) GetBytes(column string) ([]byte, bool) {
+	return get[[]byte](r, column)
+}
+
+// get converts the value of column to T as ScanStruct converts it to a
+// field of type T.
+func get[T any](r Result, column string) (T, bool) {
+	var out T
+	v, ok := r[column]
+	if !ok || v == nil {
+		return out, false
+	}
+	if err := assign(reflect.ValueOf(&out).Elem(), v); err != nil {
+		var zero T
+		return zero, false
+	}
+	return out, true
+}
+
+// KeyValue normalizes a key value so related records can be matched in a
+// map: pointers are dereferenced, integers become int64 and []byte becomes
+// string. ok is false for nil values.
+func KeyValue(v interface{}) (key interface{}, ok bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, false
+	}
+
+	switch {
+	case rv.CanInt():
+		return rv.Int(), true
+	case rv.CanUint():
+		return int64(rv.Uint()), true
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		return string(rv.Bytes()), true
+	}
+	return rv.Interface(), true
+}
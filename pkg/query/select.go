@@ -13,7 +13,9 @@ import (
 type SelectBuilder struct {
 	conn       *dialects.Connection
 	tableName  string
+	namespace  string
 	columns    []string
+	calls      []*FuncCall // Function calls in the SELECT list
 	conditions []Condition
 	orders     []OrderBy
 	limit      int
@@ -24,6 +26,10 @@ type SelectBuilder struct {
 	schema     *schema.Schema // Optional schema for relation-aware queries
 	includes   []string       // Relations to eager load
 	profiler   *Profiler      // Optional profiler for performance tracking
+	authorizer Authorizer     // Optional access control hook
+	sqlCache   *SQLCache      // Optional cache of the rendered SQL
+	masked     bool           // Mask @pii fields in results
+	goTypes    map[string]string
 }
 
 type joinClause struct {
@@ -80,7 +86,8 @@ func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
 	return s
 }
 
-// Having adds a HAVING condition.
+// Having adds a HAVING condition. Conditions on aggregates are built with
+// CountGt, SumGte and the like, Aggregate, or Expr.
 func (s *SelectBuilder) Having(conditions ...Condition) *SelectBuilder {
 	s.having = append(s.having, conditions...)
 	return s
@@ -101,6 +108,12 @@ func (s *SelectBuilder) Include(relations ...string) *SelectBuilder {
 
 // Build generates the SQL query and arguments.
 func (s *SelectBuilder) Build() (string, []interface{}) {
+	query, args, _ := s.buildCached()
+	return query, args
+}
+
+// render generates the SQL query and arguments without the SQL cache.
+func (s *SelectBuilder) render() (string, []interface{}, bool) {
 	dialect := s.conn.Dialect
 	var args []interface{}
 	argIndex := 1
@@ -118,8 +131,14 @@ func (s *SelectBuilder) Build() (string, []interface{}) {
 		}
 		cols = strings.Join(quotedCols, ", ")
 	}
+	for _, call := range s.calls {
+		callSQL, callArgs := call.selectSQL(dialect, argIndex)
+		cols += ", " + callSQL
+		args = append(args, callArgs...)
+		argIndex += len(callArgs)
+	}
 
-	sql := fmt.Sprintf("SELECT %s FROM %s", cols, dialect.Quote(s.tableName))
+	sql := fmt.Sprintf("SELECT %s FROM %s", cols, quoteTable(dialect, s.namespace, s.tableName))
 
 	// JOINs
 	for _, join := range s.joins {
@@ -160,30 +179,30 @@ func (s *SelectBuilder) Build() (string, []interface{}) {
 		sql += " ORDER BY " + strings.Join(orderParts, ", ")
 	}
 
-	// LIMIT
-	if s.limit > 0 {
-		sql += fmt.Sprintf(" LIMIT %d", s.limit)
-	}
+	// LIMIT and OFFSET
+	sql += dialects.LimitClause(dialect, s.limit, s.offset, len(s.orders) > 0)
 
-	// OFFSET
-	if s.offset > 0 {
-		sql += fmt.Sprintf(" OFFSET %d", s.offset)
-	}
-
-	return sql, args
+	return sql, args, false
 }
 
 // All executes the query and returns all matching rows.
 func (s *SelectBuilder) All(ctx context.Context) (Results, error) {
-	query, args := s.Build()
+	if err := authorize(ctx, s.authorizer, s.schema, s.tableName, OpSelect); err != nil {
+		return nil, err
+	}
+	if err := checkRegexp(ctx, s.conn, s.conditions, s.having); err != nil {
+		return nil, err
+	}
+
+	query, args, cached := s.buildCached()
 
 	// Start profiling if enabled
 	var profile *QueryProfile
 	if s.profiler != nil && s.profiler.IsEnabled() {
-		profile = s.profiler.StartQuery(query, args)
+		profile = s.profiler.StartQueryContext(ctx, query, args)
 	}
 
-	rows, err := s.conn.Query(ctx, query, args...)
+	rows, err := s.query(ctx, query, args, cached)
 	if err != nil {
 		if profile != nil {
 			s.profiler.EndQuery(profile, err)
@@ -206,11 +225,18 @@ func (s *SelectBuilder) All(ctx context.Context) (Results, error) {
 		s.profiler.EndQuery(profile, nil)
 	}
 
+	if err := convertResults(columnConverters(s.schema, s.tableName, s.goTypes), results); err != nil {
+		return nil, err
+	}
+
 	// Eager load related data if includes are specified
 	if err := s.preloadRelations(ctx, results); err != nil {
 		return nil, err
 	}
 
+	if s.masked {
+		results = MaskedResults(s.schema, s.tableName, results)
+	}
 	return results, nil
 }
 
@@ -231,8 +257,15 @@ func (s *SelectBuilder) One(ctx context.Context) (Result, error) {
 // Unlike Include() which eagerly loads relations, lazy loading defers queries
 // until GetRelation() is called on each result.
 func (s *SelectBuilder) AllLazy(ctx context.Context) (LazyResults, error) {
-	query, args := s.Build()
-	rows, err := s.conn.Query(ctx, query, args...)
+	if err := authorize(ctx, s.authorizer, s.schema, s.tableName, OpSelect); err != nil {
+		return nil, err
+	}
+	if err := checkRegexp(ctx, s.conn, s.conditions, s.having); err != nil {
+		return nil, err
+	}
+
+	query, args, cached := s.buildCached()
+	rows, err := s.query(ctx, query, args, cached)
 	if err != nil {
 		return nil, err
 	}
@@ -246,7 +279,7 @@ func (s *SelectBuilder) AllLazy(ctx context.Context) (LazyResults, error) {
 	// Wrap each result in LazyResult
 	lazyResults := make(LazyResults, len(results))
 	for i, r := range results {
-		lazyResults[i] = NewLazyResult(r, s.conn, s.schema, s.tableName)
+		lazyResults[i] = NewLazyResult(r, s.conn, s.schema, s.tableName).WithAuthorizer(s.authorizer)
 	}
 
 	return lazyResults, nil
@@ -267,12 +300,19 @@ func (s *SelectBuilder) OneLazy(ctx context.Context) (*LazyResult, error) {
 
 // Count returns the count of matching rows.
 func (s *SelectBuilder) Count(ctx context.Context) (int64, error) {
+	if err := authorize(ctx, s.authorizer, s.schema, s.tableName, OpCount); err != nil {
+		return 0, err
+	}
+	if err := checkRegexp(ctx, s.conn, s.conditions, s.having); err != nil {
+		return 0, err
+	}
+
 	// Build count query
 	dialect := s.conn.Dialect
 	var args []interface{}
 	argIndex := 1
 
-	sql := fmt.Sprintf("SELECT COUNT(*) FROM %s", dialect.Quote(s.tableName))
+	sql := fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteTable(dialect, s.namespace, s.tableName))
 
 	// JOINs
 	for _, join := range s.joins {
@@ -289,7 +329,7 @@ func (s *SelectBuilder) Count(ctx context.Context) (int64, error) {
 	// Start profiling if enabled
 	var profile *QueryProfile
 	if s.profiler != nil && s.profiler.IsEnabled() {
-		profile = s.profiler.StartQuery(sql, args)
+		profile = s.profiler.StartQueryContext(ctx, sql, args)
 	}
 
 	var count int64
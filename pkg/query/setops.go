@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
 )
 
@@ -16,6 +17,8 @@ const (
 	SetUnionAll
 	SetIntersect
 	SetExcept
+	SetIntersectAll
+	SetExceptAll
 )
 
 // String returns the SQL keyword for the set operation.
@@ -29,69 +32,120 @@ func (s SetOperation) String() string {
 		return "INTERSECT"
 	case SetExcept:
 		return "EXCEPT"
+	case SetIntersectAll:
+		return "INTERSECT ALL"
+	case SetExceptAll:
+		return "EXCEPT ALL"
 	default:
 		return "UNION"
 	}
 }
 
-// SetOpQuery represents a query with set operations.
+// intersect reports whether the operation is an INTERSECT, which binds
+// tighter than UNION and EXCEPT except on SQLite.
+func (s SetOperation) intersect() bool {
+	return s == SetIntersect || s == SetIntersectAll
+}
+
+// SetOperand is a query combined by a set operation: a *SelectBuilder, or
+// a *SetOpQuery to nest one compound query in another.
+type SetOperand interface {
+	Build() (string, []interface{})
+}
+
+// SetOpQuery represents a query with set operations. Operations apply
+// from left to right on every dialect, so a.Union(b).Intersect(c) is
+// (a UNION b) INTERSECT c. ORDER BY, LIMIT and OFFSET set on the
+// SetOpQuery apply to the combined result; those of an operand apply to
+// the operand alone.
 type SetOpQuery struct {
 	conn       *dialects.Connection
-	queries    []*SelectBuilder
+	queries    []SetOperand
 	operations []SetOperation
 	orders     []OrderBy
 	limit      int
 	offset     int
+	schema     *schema.Schema
+	authorizer Authorizer // Consulted for operands without their own
 }
 
-// Union creates a UNION of two queries.
-func (s *SelectBuilder) Union(other *SelectBuilder) *SetOpQuery {
+func (s *SelectBuilder) setOp(op SetOperation, other SetOperand) *SetOpQuery {
 	return &SetOpQuery{
 		conn:       s.conn,
-		queries:    []*SelectBuilder{s, other},
-		operations: []SetOperation{SetUnion},
+		schema:     s.schema,
+		authorizer: s.authorizer,
+		queries:    []SetOperand{s, other},
+		operations: []SetOperation{op},
 	}
 }
 
+// Union creates a UNION of two queries.
+func (s *SelectBuilder) Union(other SetOperand) *SetOpQuery {
+	return s.setOp(SetUnion, other)
+}
+
 // UnionAll creates a UNION ALL of two queries.
-func (s *SelectBuilder) UnionAll(other *SelectBuilder) *SetOpQuery {
-	return &SetOpQuery{
-		conn:       s.conn,
-		queries:    []*SelectBuilder{s, other},
-		operations: []SetOperation{SetUnionAll},
-	}
+func (s *SelectBuilder) UnionAll(other SetOperand) *SetOpQuery {
+	return s.setOp(SetUnionAll, other)
 }
 
-// Intersect creates an INTERSECT of two queries.
-func (s *SelectBuilder) Intersect(other *SelectBuilder) *SetOpQuery {
-	return &SetOpQuery{
-		conn:       s.conn,
-		queries:    []*SelectBuilder{s, other},
-		operations: []SetOperation{SetIntersect},
-	}
+// Intersect creates an INTERSECT of two queries. MySQL has INTERSECT
+// from 8.0.31.
+func (s *SelectBuilder) Intersect(other SetOperand) *SetOpQuery {
+	return s.setOp(SetIntersect, other)
 }
 
-// Except creates an EXCEPT of two queries.
-func (s *SelectBuilder) Except(other *SelectBuilder) *SetOpQuery {
-	return &SetOpQuery{
-		conn:       s.conn,
-		queries:    []*SelectBuilder{s, other},
-		operations: []SetOperation{SetExcept},
-	}
+// Except creates an EXCEPT of two queries. MySQL has EXCEPT from 8.0.31.
+func (s *SelectBuilder) Except(other SetOperand) *SetOpQuery {
+	return s.setOp(SetExcept, other)
 }
 
-// Union adds another UNION to the set operation.
-func (q *SetOpQuery) Union(other *SelectBuilder) *SetOpQuery {
+// IntersectAll creates an INTERSECT ALL of two queries, keeping
+// duplicates. SQLite and SQL Server have none.
+func (s *SelectBuilder) IntersectAll(other SetOperand) *SetOpQuery {
+	return s.setOp(SetIntersectAll, other)
+}
+
+// ExceptAll creates an EXCEPT ALL of two queries, keeping duplicates.
+// SQLite and SQL Server have none.
+func (s *SelectBuilder) ExceptAll(other SetOperand) *SetOpQuery {
+	return s.setOp(SetExceptAll, other)
+}
+
+func (q *SetOpQuery) add(op SetOperation, other SetOperand) *SetOpQuery {
 	q.queries = append(q.queries, other)
-	q.operations = append(q.operations, SetUnion)
+	q.operations = append(q.operations, op)
 	return q
 }
 
+// Union adds another UNION to the set operation.
+func (q *SetOpQuery) Union(other SetOperand) *SetOpQuery {
+	return q.add(SetUnion, other)
+}
+
 // UnionAll adds another UNION ALL to the set operation.
-func (q *SetOpQuery) UnionAll(other *SelectBuilder) *SetOpQuery {
-	q.queries = append(q.queries, other)
-	q.operations = append(q.operations, SetUnionAll)
-	return q
+func (q *SetOpQuery) UnionAll(other SetOperand) *SetOpQuery {
+	return q.add(SetUnionAll, other)
+}
+
+// Intersect adds an INTERSECT to the set operation.
+func (q *SetOpQuery) Intersect(other SetOperand) *SetOpQuery {
+	return q.add(SetIntersect, other)
+}
+
+// Except adds an EXCEPT to the set operation.
+func (q *SetOpQuery) Except(other SetOperand) *SetOpQuery {
+	return q.add(SetExcept, other)
+}
+
+// IntersectAll adds an INTERSECT ALL to the set operation.
+func (q *SetOpQuery) IntersectAll(other SetOperand) *SetOpQuery {
+	return q.add(SetIntersectAll, other)
+}
+
+// ExceptAll adds an EXCEPT ALL to the set operation.
+func (q *SetOpQuery) ExceptAll(other SetOperand) *SetOpQuery {
+	return q.add(SetExceptAll, other)
 }
 
 // OrderBy adds an ORDER BY clause to the final result.
@@ -112,24 +166,80 @@ func (q *SetOpQuery) Offset(n int) *SetOpQuery {
 	return q
 }
 
+// check returns an error if the dialect lacks one of the operations.
+func (q *SetOpQuery) check() error {
+	dialect := q.conn.Dialect
+	for _, op := range q.operations {
+		if (op == SetIntersectAll || op == SetExceptAll) && (dialect.Name() == "sqlite" || dialect.Name() == "mssql") {
+			return fmt.Errorf("dialect %s does not support %s", dialect.Name(), op)
+		}
+	}
+	for _, operand := range q.queries {
+		if nested, ok := operand.(*SetOpQuery); ok {
+			if err := nested.check(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// authorize checks access to the table of every operand, those of nested
+// set operations included, with a and sch where the query has none.
+func (q *SetOpQuery) authorize(ctx context.Context, a Authorizer, sch *schema.Schema, op Operation) error {
+	if q.authorizer != nil {
+		a = q.authorizer
+	}
+	if q.schema != nil {
+		sch = q.schema
+	}
+	for _, operand := range q.queries {
+		switch o := operand.(type) {
+		case *SelectBuilder:
+			if err := authorizeOperand(ctx, a, sch, o, op); err != nil {
+				return err
+			}
+		case *SetOpQuery:
+			if err := o.authorize(ctx, a, sch, op); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Build generates the SQL query and arguments.
 func (q *SetOpQuery) Build() (string, []interface{}) {
 	dialect := q.conn.Dialect
 	var allArgs []interface{}
-	var parts []string
 
-	for i, selectQuery := range q.queries {
-		sql, args := selectQuery.Build()
-		// Don't wrap in parentheses for SQLite compatibility
-		parts = append(parts, sql)
+	sql := ""
+	mixed := false // The operations so far include a UNION or EXCEPT
+	for i, operand := range q.queries {
+		operandSQL, args := q.operand(operand, i+1)
+		operandSQL = shiftPlaceholders(dialect, operandSQL, len(allArgs))
 		allArgs = append(allArgs, args...)
 
-		if i < len(q.operations) {
-			parts = append(parts, q.operations[i].String())
+		if i == 0 {
+			sql = operandSQL
+			continue
+		}
+		op := q.operations[i-1]
+		if op.intersect() && mixed {
+			// Keep left to right order where INTERSECT binds tighter
+			sql = fmt.Sprintf("SELECT * FROM (%s) AS %s", sql, dialect.Quote(fmt.Sprintf("_c%d", i)))
+			mixed = false
 		}
+		mixed = mixed || !op.intersect()
+		sql += " " + op.String() + " " + operandSQL
 	}
 
-	sql := strings.Join(parts, " ")
+	// SQL Server orders a compound only by columns it selects, so one
+	// paged without an ORDER BY is paged from a derived table
+	_, paginator := dialect.(dialects.Paginator)
+	if paginator && len(q.orders) == 0 && (q.limit > 0 || q.offset > 0) {
+		sql = fmt.Sprintf("SELECT * FROM (%s) AS %s", sql, dialect.Quote("_compound"))
+	}
 
 	// ORDER BY
 	if len(q.orders) > 0 {
@@ -140,21 +250,38 @@ func (q *SetOpQuery) Build() (string, []interface{}) {
 		sql += " ORDER BY " + strings.Join(orderParts, ", ")
 	}
 
-	// LIMIT
-	if q.limit > 0 {
-		sql += fmt.Sprintf(" LIMIT %d", q.limit)
-	}
-
-	// OFFSET
-	if q.offset > 0 {
-		sql += fmt.Sprintf(" OFFSET %d", q.offset)
-	}
+	// LIMIT and OFFSET
+	sql += dialects.LimitClause(dialect, q.limit, q.offset, len(q.orders) > 0)
 
 	return sql, allArgs
 }
 
+// operand renders the nth query of the set operation. Selects with their
+// own LIMIT or OFFSET and nested set operations become derived tables,
+// which every dialect accepts as operands where parenthesized ones are
+// not (SQLite); the ORDER BY of a select without them is dropped, since
+// it can't order the combined result.
+func (q *SetOpQuery) operand(operand SetOperand, n int) (string, []interface{}) {
+	if s, ok := operand.(*SelectBuilder); ok && s.limit == 0 && s.offset == 0 {
+		if len(s.orders) == 0 {
+			return s.Build()
+		}
+		unordered := *s
+		unordered.orders = nil
+		return unordered.Build()
+	}
+	sql, args := operand.Build()
+	return fmt.Sprintf("SELECT * FROM (%s) AS %s", sql, q.conn.Dialect.Quote(fmt.Sprintf("_s%d", n))), args
+}
+
 // All executes the query and returns all results.
 func (q *SetOpQuery) All(ctx context.Context) (Results, error) {
+	if err := q.authorize(ctx, nil, nil, OpSelect); err != nil {
+		return nil, err
+	}
+	if err := q.check(); err != nil {
+		return nil, err
+	}
 	sql, args := q.Build()
 	rows, err := q.conn.Query(ctx, sql, args...)
 	if err != nil {
@@ -179,6 +306,12 @@ func (q *SetOpQuery) One(ctx context.Context) (Result, error) {
 
 // Count returns the count of results (wraps in subquery).
 func (q *SetOpQuery) Count(ctx context.Context) (int64, error) {
+	if err := q.authorize(ctx, nil, nil, OpCount); err != nil {
+		return 0, err
+	}
+	if err := q.check(); err != nil {
+		return 0, err
+	}
 	sql, args := q.Build()
 	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS _count", sql)
 
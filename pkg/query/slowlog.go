@@ -0,0 +1,254 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// SlowQueryLogOptions configures a SlowQueryLog.
+type SlowQueryLogOptions struct {
+	// Threshold is how long a statement must take to be logged.
+	Threshold time.Duration
+	// SampleRate is the fraction of slow statements logged, between 0 and
+	// 1. Zero logs them all.
+	SampleRate float64
+	// LogArgs logs statement arguments as they are. By default each is
+	// redacted to its type, since arguments often hold user data.
+	LogArgs bool
+	// Sink receives the logged statements. Nil writes JSON lines to
+	// stderr.
+	Sink SlowQuerySink
+}
+
+// DefaultSlowQueryLogOptions returns options logging every statement over
+// 200ms to stderr, with redacted arguments.
+func DefaultSlowQueryLogOptions() SlowQueryLogOptions {
+	return SlowQueryLogOptions{
+		Threshold: 200 * time.Millisecond,
+	}
+}
+
+// SlowQuery is a logged slow statement.
+type SlowQuery struct {
+	Time     time.Time     `json:"time"`
+	Query    string        `json:"query"`
+	Args     []interface{} `json:"args,omitempty"`
+	Duration time.Duration `json:"-"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the duration in milliseconds.
+func (q SlowQuery) MarshalJSON() ([]byte, error) {
+	type plain SlowQuery
+	return json.Marshal(struct {
+		plain
+		DurationMs float64 `json:"durationMs"`
+	}{plain(q), Milliseconds(q.Duration)})
+}
+
+// SlowQuerySink receives slow statements from a SlowQueryLog. Sinks are
+// called from the goroutines running the statements, so they must be safe
+// for concurrent use.
+type SlowQuerySink interface {
+	Write(q SlowQuery) error
+}
+
+// SlowQuerySinkFunc adapts a function to a SlowQuerySink.
+type SlowQuerySinkFunc func(q SlowQuery) error
+
+// Write calls f.
+func (f SlowQuerySinkFunc) Write(q SlowQuery) error {
+	return f(q)
+}
+
+// SlowQueryLog is an always-on log of slow statements. Unlike a Profiler
+// it keeps nothing in memory: statements over the threshold are passed to
+// the sink as they finish. Add it to a connection to cover every builder
+// and raw query run through it:
+//
+//	conn.Use(query.NewSlowQueryLog(query.DefaultSlowQueryLogOptions()).Hook())
+type SlowQueryLog struct {
+	opts SlowQueryLogOptions
+}
+
+// NewSlowQueryLog creates a slow query log.
+func NewSlowQueryLog(opts SlowQueryLogOptions) *SlowQueryLog {
+	if opts.Sink == nil {
+		opts.Sink = NewJSONLinesSink(os.Stderr)
+	}
+	return &SlowQueryLog{opts: opts}
+}
+
+// Hook returns the hook that logs statements run through a connection.
+func (l *SlowQueryLog) Hook() dialects.QueryHook {
+	return func(ctx context.Context, event dialects.QueryEvent) {
+		l.Observe(event)
+	}
+}
+
+// Observe logs event if it is slow and sampled. Sink errors are dropped,
+// so that logging never fails a query.
+func (l *SlowQueryLog) Observe(event dialects.QueryEvent) {
+	if event.Duration < l.opts.Threshold {
+		return
+	}
+	if rate := l.opts.SampleRate; rate > 0 && rate < 1 && rand.Float64() >= rate {
+		return
+	}
+
+	q := SlowQuery{
+		Time:     event.Start,
+		Query:    event.Query,
+		Args:     event.Args,
+		Duration: event.Duration,
+	}
+	if !l.opts.LogArgs {
+		q.Args = redactArgs(event.Args)
+	}
+	if event.Err != nil {
+		q.Error = event.Err.Error()
+	}
+	_ = l.opts.Sink.Write(q)
+}
+
+// redactArgs replaces each argument with its type.
+func redactArgs(args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			redacted[i] = "<nil>"
+		} else {
+			redacted[i] = fmt.Sprintf("<%T>", arg)
+		}
+	}
+	return redacted
+}
+
+// jsonLinesSink writes each statement as a line of JSON.
+type jsonLinesSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesSink returns a sink writing each statement to w as a line
+// of JSON.
+func NewJSONLinesSink(w io.Writer) SlowQuerySink {
+	return &jsonLinesSink{w: w}
+}
+
+func (s *jsonLinesSink) Write(q SlowQuery) error {
+	line, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// RotatingFileSink writes statements as JSON lines to a file, rotating it
+// when it grows past a size: app.log is renamed to app.log.1, app.log.1 to
+// app.log.2 and so on, keeping a number of old files.
+type RotatingFileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingFileSink opens path for appending. The file is rotated once
+// it would grow past maxBytes, keeping maxBackups old files.
+func NewRotatingFileSink(path string, maxBytes int64, maxBackups int) (*RotatingFileSink, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("maxBytes must be positive, got %d", maxBytes)
+	}
+	s := &RotatingFileSink{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open opens the log file, picking up the size of an existing one.
+func (s *RotatingFileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file, s.size = f, info.Size()
+	return nil
+}
+
+// Write appends q to the file, rotating it first if needed.
+func (s *RotatingFileSink) Write(q SlowQuery) error {
+	line, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	if s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+// rotate shifts the old files along, dropping the oldest, and starts a
+// new file. s.mu must be held.
+func (s *RotatingFileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+	if s.maxBackups > 0 {
+		for i := s.maxBackups - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// Close closes the file.
+func (s *RotatingFileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
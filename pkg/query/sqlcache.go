@@ -0,0 +1,233 @@
+package query
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// SQLCache is an LRU cache of the SQL rendered by SELECT builders, keyed
+// by the shape of the builder: its table, columns, joins, the columns and
+// operators of its conditions, ordering and paging. Builders of a cached
+// shape only collect their argument values, so queries repeated with
+// different values skip rendering SQL. Builders with subqueries or
+// function calls are always rendered.
+type SQLCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	stmts    *StmtCache
+	hits     int64
+	misses   int64
+}
+
+// sqlCacheEntry holds the SQL of a builder shape.
+type sqlCacheEntry struct {
+	key string
+	sql string
+}
+
+// NewSQLCache creates a cache of the SQL of capacity builder shapes.
+func NewSQLCache(capacity int) *SQLCache {
+	if capacity <= 0 {
+		capacity = 100
+	}
+	return &SQLCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// WithStmtCache runs the queries of cached shapes as statements prepared
+// by stmts, so they are parsed by the database once too. stmts must be
+// created on the same database as the connections the builders use.
+func (c *SQLCache) WithStmtCache(stmts *StmtCache) *SQLCache {
+	c.stmts = stmts
+	return c
+}
+
+// get returns the SQL of a shape, rendering it with render on a miss.
+func (c *SQLCache) get(key string, render func() string) (string, bool) {
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		c.hits++
+		c.mu.Unlock()
+		return elem.Value.(*sqlCacheEntry).sql, true
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	rendered := render()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; !ok {
+		if c.order.Len() >= c.capacity {
+			oldest := c.order.Back()
+			delete(c.items, oldest.Value.(*sqlCacheEntry).key)
+			c.order.Remove(oldest)
+		}
+		c.items[key] = c.order.PushFront(&sqlCacheEntry{key: key, sql: rendered})
+	}
+	return rendered, false
+}
+
+// Clear empties the cache. Statistics are kept.
+func (c *SQLCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string]*list.Element)
+	c.order = list.New()
+}
+
+// Stats returns the cache statistics.
+func (c *SQLCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Size:     len(c.items),
+		Capacity: c.capacity,
+		Hits:     c.hits,
+		Misses:   c.misses,
+	}
+}
+
+// WithSQLCache caches the SQL of the builder's SELECT queries in c.
+func (b *Builder) WithSQLCache(c *SQLCache) *Builder {
+	b.sqlCache = c
+	return b
+}
+
+// WithSQLCache caches the SQL of the query in c.
+func (s *SelectBuilder) WithSQLCache(c *SQLCache) *SelectBuilder {
+	s.sqlCache = c
+	return s
+}
+
+// buildCached returns the SQL and arguments of the query, from the SQL
+// cache when the builder has one and its shape can be cached.
+func (s *SelectBuilder) buildCached() (query string, args []interface{}, cached bool) {
+	if s.sqlCache == nil {
+		return s.render()
+	}
+	key, ok := s.shapeKey()
+	if !ok {
+		return s.render()
+	}
+
+	query, hit := s.sqlCache.get(key, func() string {
+		query, args, _ = s.render()
+		return query
+	})
+	if s.profiler != nil {
+		s.profiler.recordSQLCache(hit)
+	}
+	if hit {
+		args = append(conditionArgs(s.conn.Dialect, s.conditions), conditionArgs(s.conn.Dialect, s.having)...)
+	}
+	return query, args, true
+}
+
+// shapeKey describes everything but the argument values that the SQL of
+// the query depends on, or returns false if the query can't be cached.
+func (s *SelectBuilder) shapeKey() (string, bool) {
+	if len(s.calls) > 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+	field := func(parts ...string) {
+		for _, p := range parts {
+			fmt.Fprintf(&b, "%d:%s", len(p), p)
+		}
+		b.WriteByte(';')
+	}
+	conditions := func(conds []Condition) bool {
+		for _, cond := range conds {
+			switch cond.Operator {
+			case "IN_SUBQUERY", "NOT_IN_SUBQUERY", "EXISTS", "NOT_EXISTS":
+				return false
+			}
+			size := ""
+			switch {
+			case cond.Raw != "":
+			case len(cond.Columns) > 0 && cond.Operator == "IN":
+				size = fmt.Sprint(len(cond.Value.([][]interface{})))
+			case cond.Operator == "EXPR":
+				size = cond.Value.(Expression).SQL
+			case cond.Operator == "IN":
+				size = fmt.Sprint(len(cond.Value.([]interface{})))
+			}
+			field(cond.Raw, cond.Aggregate, cond.Column, cond.Operator, size)
+			field(cond.Columns...)
+		}
+		b.WriteByte('|')
+		return true
+	}
+
+	field(s.conn.Dialect.Name(), s.tableName)
+	field(s.columns...)
+	for _, join := range s.joins {
+		field(join.joinType, join.table, join.condition)
+	}
+	b.WriteByte('|')
+	if !conditions(s.conditions) {
+		return "", false
+	}
+	field(s.groupBy...)
+	if !conditions(s.having) {
+		return "", false
+	}
+	for _, o := range s.orders {
+		field(o.Column, o.Direction.String())
+	}
+	fmt.Fprintf(&b, "|%d|%d", s.limit, s.offset)
+	return b.String(), true
+}
+
+// conditionArgs returns the arguments buildWhere binds for conditions
+// without subqueries, in the same order.
+func conditionArgs(dialect dialects.Dialect, conditions []Condition) []interface{} {
+	var args []interface{}
+	for _, cond := range conditions {
+		if cond.Raw != "" {
+			continue
+		}
+		if len(cond.Columns) > 0 {
+			_, tupleArgs := buildTuple(dialect, cond, 1)
+			args = append(args, tupleArgs...)
+			continue
+		}
+		switch cond.Operator {
+		case "IS NULL", "IS NOT NULL":
+		case "IN", "BETWEEN":
+			args = append(args, cond.Value.([]interface{})...)
+		case "EXPR":
+			args = append(args, cond.Value.(Expression).Args...)
+		default:
+			args = append(args, cond.Value)
+		}
+	}
+	return args
+}
+
+// query runs the query, as a prepared statement when its SQL came from a
+// cache with a statement cache.
+func (s *SelectBuilder) query(ctx context.Context, query string, args []interface{}, cached bool) (*sql.Rows, error) {
+	if cached && s.sqlCache.stmts != nil {
+		stmt, err := s.sqlCache.stmts.Get(query)
+		if err != nil {
+			return nil, err
+		}
+		return s.conn.QueryStmt(ctx, stmt, query, args...)
+	}
+	return s.conn.Query(ctx, query, args...)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
 )
 
@@ -71,19 +72,26 @@ func buildWhereWithSubqueries(dialect dialects.Dialect, conditions []Condition,
 			parts = append(parts, cond.Raw)
 			continue
 		}
+		if len(cond.Columns) > 0 {
+			sql, tupleArgs := buildTuple(dialect, cond, argIndex)
+			parts = append(parts, sql)
+			args = append(args, tupleArgs...)
+			argIndex += len(tupleArgs)
+			continue
+		}
 
 		switch cond.Operator {
 		case "IN_SUBQUERY":
 			subquery := cond.Value.(*SelectBuilder)
 			subSQL, subArgs := subquery.Build()
-			parts = append(parts, fmt.Sprintf("%s IN (%s)", dialect.Quote(cond.Column), subSQL))
+			parts = append(parts, fmt.Sprintf("%s IN (%s)", conditionColumn(dialect, cond), subSQL))
 			args = append(args, subArgs...)
 			argIndex += len(subArgs)
 
 		case "NOT_IN_SUBQUERY":
 			subquery := cond.Value.(*SelectBuilder)
 			subSQL, subArgs := subquery.Build()
-			parts = append(parts, fmt.Sprintf("%s NOT IN (%s)", dialect.Quote(cond.Column), subSQL))
+			parts = append(parts, fmt.Sprintf("%s NOT IN (%s)", conditionColumn(dialect, cond), subSQL))
 			args = append(args, subArgs...)
 			argIndex += len(subArgs)
 
@@ -102,9 +110,21 @@ func buildWhereWithSubqueries(dialect dialects.Dialect, conditions []Condition,
 			argIndex += len(subArgs)
 
 		case "IS NULL", "IS NOT NULL":
-			quotedCol := dialect.Quote(cond.Column)
+			quotedCol := conditionColumn(dialect, cond)
 			parts = append(parts, fmt.Sprintf("%s %s", quotedCol, cond.Operator))
 
+		case "EXPR":
+			sql, exprArgs := buildExpr(dialect, cond, argIndex)
+			parts = append(parts, sql)
+			args = append(args, exprArgs...)
+			argIndex += len(exprArgs)
+
+		case "BETWEEN", "ILIKE", "REGEXP":
+			sql, matchArgs := buildMatch(dialect, cond, argIndex)
+			parts = append(parts, sql)
+			args = append(args, matchArgs...)
+			argIndex += len(matchArgs)
+
 		case "IN":
 			values := cond.Value.([]interface{})
 			placeholders := make([]string, len(values))
@@ -113,10 +133,10 @@ func buildWhereWithSubqueries(dialect dialects.Dialect, conditions []Condition,
 				args = append(args, v)
 				argIndex++
 			}
-			parts = append(parts, fmt.Sprintf("%s IN (%s)", dialect.Quote(cond.Column), strings.Join(placeholders, ", ")))
+			parts = append(parts, fmt.Sprintf("%s IN (%s)", conditionColumn(dialect, cond), strings.Join(placeholders, ", ")))
 
 		default:
-			quotedCol := dialect.Quote(cond.Column)
+			quotedCol := conditionColumn(dialect, cond)
 			parts = append(parts, fmt.Sprintf("%s %s %s", quotedCol, cond.Operator, dialect.Placeholder(argIndex)))
 			args = append(args, cond.Value)
 			argIndex++
@@ -143,9 +163,11 @@ func (s *SelectBuilder) AsScalar(alias string) *ScalarSubquery {
 // FromSubquery creates a query from a subquery (derived table).
 func FromSubquery(conn *dialects.Connection, subquery *SelectBuilder, alias string) *DerivedTableBuilder {
 	return &DerivedTableBuilder{
-		conn:     conn,
-		subquery: subquery,
-		alias:    alias,
+		conn:       conn,
+		subquery:   subquery,
+		alias:      alias,
+		schema:     subquery.schema,
+		authorizer: subquery.authorizer,
 	}
 }
 
@@ -159,6 +181,8 @@ type DerivedTableBuilder struct {
 	orders     []OrderBy
 	limit      int
 	offset     int
+	schema     *schema.Schema
+	authorizer Authorizer
 }
 
 // Select specifies columns to select.
@@ -225,21 +249,17 @@ func (d *DerivedTableBuilder) Build() (string, []interface{}) {
 		sql += " ORDER BY " + strings.Join(orderParts, ", ")
 	}
 
-	// LIMIT
-	if d.limit > 0 {
-		sql += fmt.Sprintf(" LIMIT %d", d.limit)
-	}
-
-	// OFFSET
-	if d.offset > 0 {
-		sql += fmt.Sprintf(" OFFSET %d", d.offset)
-	}
+	// LIMIT and OFFSET
+	sql += dialects.LimitClause(dialect, d.limit, d.offset, len(d.orders) > 0)
 
 	return sql, args
 }
 
 // All executes the query and returns all results.
 func (d *DerivedTableBuilder) All(ctx context.Context) (Results, error) {
+	if err := authorize(ctx, d.authorizer, d.schema, d.subquery.tableName, OpSelect); err != nil {
+		return nil, err
+	}
 	sql, args := d.Build()
 	rows, err := d.conn.Query(ctx, sql, args...)
 	if err != nil {
@@ -0,0 +1,71 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// treeCTE is the name of the recursive CTE of Descendants and Ancestors.
+const treeCTE = "nexus_tree"
+
+// Descendants returns a query for the rows below the row whose key is id
+// in the tree of a self-referential relation, such as
+// m.BelongsTo("Category", "parent_id").As("Parent") or its has-many side.
+// The row itself is not included; refine the query with Where and OrderBy:
+//
+//	rows, err := categories.Descendants("Parent", 1).OrderBy("name", query.Asc).All(ctx)
+func (b *Builder) Descendants(relation string, id interface{}) (*CTESelectBuilder, error) {
+	rel, err := b.treeRelation(relation)
+	if err != nil {
+		return nil, err
+	}
+	dialect := b.conn.Dialect
+	table := quoteTable(dialect, b.namespace, b.tableName)
+	anchor := b.sameTable().Select().Where(Eq(rel.ForeignKey, id))
+	children := b.sameTable().Select(table+".*").
+		Join(treeCTE, fmt.Sprintf("%s.%s = %s.%s",
+			table, dialect.Quote(rel.ForeignKey), dialect.Quote(treeCTE), dialect.Quote(rel.ReferenceKey)))
+	return WithRecursive(b.conn, treeCTE, anchor, children).Select().From(treeCTE), nil
+}
+
+// Ancestors returns a query for the rows above the row whose key is id in
+// the tree of a self-referential relation, from its parent to the root.
+// The row itself is not included.
+func (b *Builder) Ancestors(relation string, id interface{}) (*CTESelectBuilder, error) {
+	rel, err := b.treeRelation(relation)
+	if err != nil {
+		return nil, err
+	}
+	dialect := b.conn.Dialect
+	table := quoteTable(dialect, b.namespace, b.tableName)
+	parent := b.sameTable().Select(rel.ForeignKey).Where(Eq(rel.ReferenceKey, id))
+	anchor := b.sameTable().Select().WhereIn(rel.ReferenceKey, parent)
+	parents := b.sameTable().Select(table+".*").
+		Join(treeCTE, fmt.Sprintf("%s.%s = %s.%s",
+			table, dialect.Quote(rel.ReferenceKey), dialect.Quote(treeCTE), dialect.Quote(rel.ForeignKey)))
+	return WithRecursive(b.conn, treeCTE, anchor, parents).Select().From(treeCTE), nil
+}
+
+// sameTable returns a plain builder of b's table, for the parts of a tree
+// query.
+func (b *Builder) sameTable() *Builder {
+	return &Builder{conn: b.conn, tableName: b.tableName, namespace: b.namespace}
+}
+
+// treeRelation returns the self-referential relation named relation of the
+// builder's model.
+func (b *Builder) treeRelation(relation string) (*schema.Relation, error) {
+	model := findModelByTable(b.schema, b.tableName)
+	if model == nil {
+		return nil, fmt.Errorf("no model for table %s in the schema", b.tableName)
+	}
+	rel := findRelation(model, relation)
+	if rel == nil {
+		return nil, fmt.Errorf("model %s has no relation %s", model.Name, relation)
+	}
+	if rel.TargetModel != model.Name || (rel.Type != schema.RelationBelongsTo && rel.Type != schema.RelationHasMany) {
+		return nil, fmt.Errorf("relation %s of %s is not a self-referential belongs-to or has-many", relation, model.Name)
+	}
+	return rel, nil
+}
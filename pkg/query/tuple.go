@@ -0,0 +1,136 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// InTuples creates a condition matching rows whose columns equal one of
+// rows, such as (user_id, role) IN ((1, 'admin'), (2, 'member')), for
+// lookups by a composite key. Each row has a value for every column.
+//
+// Example:
+//
+//	query.InTuples([]string{"user_id", "role"}, [][]interface{}{{1, "admin"}, {2, "member"}})
+func InTuples(columns []string, rows [][]interface{}) Condition {
+	if len(columns) == 0 {
+		panic("query: InTuples without columns")
+	}
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			panic(fmt.Sprintf("query: InTuples row %d has %d values for %d columns", i, len(row), len(columns)))
+		}
+	}
+	return Condition{Columns: columns, Operator: "IN", Value: rows}
+}
+
+// TupleGt creates a condition comparing columns to values in order, as
+// (created_at, id) > ('2024-01-01', 42) does, such as for keyset
+// pagination past the last row of a page.
+func TupleGt(columns []string, values ...interface{}) Condition {
+	return tuple(columns, ">", values)
+}
+
+// TupleGte creates a greater-than-or-equal tuple comparison.
+func TupleGte(columns []string, values ...interface{}) Condition {
+	return tuple(columns, ">=", values)
+}
+
+// TupleLt creates a less-than tuple comparison.
+func TupleLt(columns []string, values ...interface{}) Condition {
+	return tuple(columns, "<", values)
+}
+
+// TupleLte creates a less-than-or-equal tuple comparison.
+func TupleLte(columns []string, values ...interface{}) Condition {
+	return tuple(columns, "<=", values)
+}
+
+func tuple(columns []string, operator string, values []interface{}) Condition {
+	if len(columns) == 0 || len(values) != len(columns) {
+		panic(fmt.Sprintf("query: %d values compared to %d columns", len(values), len(columns)))
+	}
+	return Condition{Columns: columns, Operator: operator, Value: values}
+}
+
+// rowValues reports whether dialect compares row values, such as
+// (a, b) > (1, 2). SQL Server has none, and MySQL can't use indexes for
+// row value ranges, so tuples are expanded into OR groups there.
+func rowValues(dialect dialects.Dialect) bool {
+	switch dialect.Name() {
+	case "postgres", "sqlite":
+		return true
+	}
+	return false
+}
+
+// buildTuple renders a condition on a tuple of columns, with placeholders
+// numbered from argIndex, and returns its arguments.
+func buildTuple(dialect dialects.Dialect, cond Condition, argIndex int) (string, []interface{}) {
+	var args []interface{}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		argIndex++
+		return dialect.Placeholder(argIndex - 1)
+	}
+
+	columns := make([]string, len(cond.Columns))
+	for i, c := range cond.Columns {
+		columns[i] = dialect.Quote(c)
+	}
+
+	if cond.Operator == "IN" {
+		rows := cond.Value.([][]interface{})
+		if len(rows) == 0 {
+			return "1 = 0", nil
+		}
+		groups := make([]string, len(rows))
+		for i, row := range rows {
+			parts := make([]string, len(row))
+			if rowValues(dialect) {
+				for j, v := range row {
+					parts[j] = bind(v)
+				}
+				groups[i] = "(" + strings.Join(parts, ", ") + ")"
+				continue
+			}
+			for j, v := range row {
+				parts[j] = columns[j] + " = " + bind(v)
+			}
+			groups[i] = "(" + strings.Join(parts, " AND ") + ")"
+		}
+		if rowValues(dialect) {
+			return fmt.Sprintf("(%s) IN (%s)", strings.Join(columns, ", "), strings.Join(groups, ", ")), args
+		}
+		return "(" + strings.Join(groups, " OR ") + ")", args
+	}
+
+	values := cond.Value.([]interface{})
+	if rowValues(dialect) {
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			placeholders[i] = bind(v)
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), cond.Operator, strings.Join(placeholders, ", ")), args
+	}
+
+	// (a, b, c) > (x, y, z) is a > x OR (a = x AND b > y) OR (a = x AND
+	// b = y AND c > z); only the last column takes >= or <=
+	strict := strings.TrimSuffix(cond.Operator, "=")
+	groups := make([]string, len(columns))
+	for i := range columns {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j]+" = "+bind(values[j]))
+		}
+		op := strict
+		if i == len(columns)-1 {
+			op = cond.Operator
+		}
+		parts = append(parts, columns[i]+" "+op+" "+bind(values[i]))
+		groups[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+	return "(" + strings.Join(groups, " OR ") + ")", args
+}
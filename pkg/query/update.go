@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/dialects"
 )
 
@@ -12,10 +13,16 @@ import (
 type UpdateBuilder struct {
 	conn       *dialects.Connection
 	tableName  string
+	namespace  string
 	data       map[string]interface{}
 	conditions []Condition
+	joins      []joinClause
 	returning  []string
+	schema     *schema.Schema
 	profiler   *Profiler
+	authorizer Authorizer
+	fullTable  bool
+	goTypes    map[string]string
 }
 
 // Where adds a WHERE condition.
@@ -39,27 +46,61 @@ func (u *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
 	return u
 }
 
+// AllowFullTable lets the update through the Safety of the connection when
+// it has no conditions or updates more rows than the limit.
+func (u *UpdateBuilder) AllowFullTable() *UpdateBuilder {
+	u.fullTable = true
+	return u
+}
+
 // Build generates the SQL query and arguments.
 func (u *UpdateBuilder) Build() (string, []interface{}) {
 	dialect := u.conn.Dialect
 	var args []interface{}
 	argIndex := 1
 
-	// Build SET clause
+	// Build SET clause. MySQL's multi-table UPDATE needs the columns
+	// qualified with the table
+	qualify := len(u.joins) > 0 && dialect.Name() == "mysql"
+	table := quoteTable(dialect, u.namespace, u.tableName)
+	convs := columnConverters(u.schema, u.tableName, u.goTypes)
 	sets := make([]string, 0, len(u.data))
 	for col, val := range u.data {
-		sets = append(sets, fmt.Sprintf("%s = %s", dialect.Quote(col), dialect.Placeholder(argIndex)))
-		args = append(args, val)
+		quotedCol := dialect.Quote(col)
+		if qualify {
+			quotedCol = table + "." + quotedCol
+		}
+		sets = append(sets, fmt.Sprintf("%s = %s", quotedCol, dialect.Placeholder(argIndex)))
+		args = append(args, convertArg(convs, col, val))
 		argIndex++
 	}
 
-	sql := fmt.Sprintf("UPDATE %s SET %s",
-		dialect.Quote(u.tableName),
-		strings.Join(sets, ", "))
+	set := strings.Join(sets, ", ")
+	sql := fmt.Sprintf("UPDATE %s SET %s", table, set)
+
+	// Joined tables; SQLite selects the rows in the WHERE clause instead
+	conditions := u.conditions
+	if len(u.joins) > 0 && dialect.Name() != "sqlite" {
+		var tables string
+		tables, conditions = joinedTables(dialect, u.joins, u.conditions)
+		switch dialect.Name() {
+		case "mysql":
+			sql = fmt.Sprintf("UPDATE %s, %s SET %s", table, tables, set)
+		case "mssql":
+			sql += fmt.Sprintf(" FROM %s, %s", table, tables)
+		default:
+			sql += " FROM " + tables
+		}
+	}
 
 	// WHERE clause
-	if len(u.conditions) > 0 {
-		whereSQL, whereArgs := buildWhere(dialect, u.conditions, argIndex)
+	switch {
+	case len(u.joins) > 0 && dialect.Name() == "sqlite":
+		whereSQL, whereArgs := keySubquery(dialect, table, primaryKeyColumns(u.schema, u.tableName), u.joins, u.conditions, argIndex)
+		sql += " " + whereSQL
+		args = append(args, whereArgs...)
+	case len(conditions) > 0:
+		whereSQL, whereArgs := buildWhere(dialect, conditions, argIndex)
 		sql += " " + whereSQL
 		args = append(args, whereArgs...)
 	}
@@ -68,7 +109,9 @@ func (u *UpdateBuilder) Build() (string, []interface{}) {
 	if len(u.returning) > 0 && dialect.SupportsReturning() {
 		retCols := make([]string, len(u.returning))
 		for i, c := range u.returning {
-			if c == "*" {
+			if c == "*" && len(u.joins) > 0 {
+				retCols[i] = table + ".*" // Not the columns of joined tables
+			} else if c == "*" {
 				retCols[i] = "*"
 			} else {
 				retCols[i] = dialect.Quote(c)
@@ -82,12 +125,25 @@ func (u *UpdateBuilder) Build() (string, []interface{}) {
 
 // Exec executes the update and returns the number of affected rows.
 func (u *UpdateBuilder) Exec(ctx context.Context) (int64, error) {
+	if err := authorize(ctx, u.authorizer, u.schema, u.tableName, OpUpdate); err != nil {
+		return 0, err
+	}
+	if err := checkRegexp(ctx, u.conn, u.conditions); err != nil {
+		return 0, err
+	}
+	if err := validateRows(u.schema, u.tableName, u.data); err != nil {
+		return 0, err
+	}
+	if u.fullTable {
+		ctx = dialects.AllowFullTable(ctx)
+	}
+
 	query, args := u.Build()
 
 	// Start profiling if enabled
 	var profile *QueryProfile
 	if u.profiler != nil && u.profiler.IsEnabled() {
-		profile = u.profiler.StartQuery(query, args)
+		profile = u.profiler.StartQueryContext(ctx, query, args)
 	}
 
 	result, err := u.conn.Exec(ctx, query, args...)
@@ -102,13 +158,26 @@ func (u *UpdateBuilder) Exec(ctx context.Context) (int64, error) {
 	}
 
 	if err != nil {
-		return 0, err
+		return 0, rowidError(err, u.tableName)
 	}
 	return result.RowsAffected()
 }
 
 // All executes the update and returns all affected rows (requires RETURNING).
 func (u *UpdateBuilder) All(ctx context.Context) (Results, error) {
+	if err := authorize(ctx, u.authorizer, u.schema, u.tableName, OpUpdate); err != nil {
+		return nil, err
+	}
+	if err := checkRegexp(ctx, u.conn, u.conditions); err != nil {
+		return nil, err
+	}
+	if err := validateRows(u.schema, u.tableName, u.data); err != nil {
+		return nil, err
+	}
+	if u.fullTable {
+		ctx = dialects.AllowFullTable(ctx)
+	}
+
 	if !u.conn.Dialect.SupportsReturning() {
 		return nil, fmt.Errorf("dialect %s does not support RETURNING clause", u.conn.Dialect.Name())
 	}
@@ -120,11 +189,11 @@ func (u *UpdateBuilder) All(ctx context.Context) (Results, error) {
 	query, args := u.Build()
 	rows, err := u.conn.Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, rowidError(err, u.tableName)
 	}
 	defer rows.Close()
 
-	return scanRows(rows)
+	return scanConverted(rows, columnConverters(u.schema, u.tableName, u.goTypes))
 }
 
 // One executes the update and returns the first affected row (requires RETURNING).
@@ -0,0 +1,35 @@
+package query
+
+import (
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/validate"
+)
+
+// validateRows checks rows against the validation rules of the model
+// backing tableName. Without a schema nothing is checked. The first
+// invalid row is reported as validate.Errors.
+func validateRows(sch *schema.Schema, tableName string, rows ...map[string]interface{}) error {
+	model := findModelByTable(sch, tableName)
+	if model == nil {
+		return nil
+	}
+	for _, row := range rows {
+		if err := validate.Values(model, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rows returns every row the insert writes, including the update of an
+// upsert.
+func (i *InsertBuilder) rows() []map[string]interface{} {
+	rows := i.batchData
+	if rows == nil {
+		rows = []map[string]interface{}{i.data}
+	}
+	if i.onConflict != nil && i.onConflict.doUpdate != nil {
+		rows = append(rows[:len(rows):len(rows)], i.onConflict.doUpdate)
+	}
+	return rows
+}
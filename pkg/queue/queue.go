@@ -0,0 +1,489 @@
+// Package queue runs background jobs stored in a table of the application's
+// database. Jobs are enqueued in the same database, and in the same
+// transaction, as the data they act on; workers claim them with
+// SELECT ... FOR UPDATE SKIP LOCKED on PostgreSQL and MySQL, and with a
+// conditional UPDATE on SQLite and SQL Server. Failed jobs are retried with
+// backoff until they run out of attempts.
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nexus-db/nexus/internal/dbtime"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+)
+
+// DefaultTable is the table jobs are stored in unless Options.Table is set.
+const DefaultTable = "nexus_jobs"
+
+// Status is the state of a job.
+type Status string
+
+const (
+	StatusPending Status = "pending" // Waiting for its run time or a worker
+	StatusRunning Status = "running" // Claimed by a worker until its lock expires
+	StatusDone    Status = "done"    // Handled successfully
+	StatusFailed  Status = "failed"  // Out of attempts
+)
+
+// Job is a row of the jobs table.
+type Job struct {
+	ID          int64
+	Queue       string
+	Kind        string          // Selects the handler
+	Payload     json.RawMessage // JSON arguments of the handler
+	Status      Status
+	Attempts    int // Runs started, including the current one
+	MaxAttempts int
+	RunAt       time.Time // Earliest time a worker may claim the job
+	LockedBy    string
+	LockedUntil time.Time
+	LastError   string
+	CreatedAt   time.Time
+	FinishedAt  time.Time
+}
+
+// Decode unmarshals the payload of the job into v.
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Options configures a queue.
+type Options struct {
+	Table       string                          // Jobs table (default nexus_jobs)
+	Queue       string                          // Queue name, so that one table holds several queues (default "default")
+	MaxAttempts int                             // Attempts of a job unless enqueued with others (default 25)
+	LockTimeout time.Duration                   // How long a claimed job is locked before another worker may retry it (default 5m)
+	Backoff     func(attempt int) time.Duration // Delay before retrying after the given attempt (default DefaultBackoff)
+	WorkerID    string                          // Recorded in locked_by (default host:pid)
+}
+
+// DefaultOptions returns the default queue options.
+func DefaultOptions() Options {
+	return Options{
+		Table:       DefaultTable,
+		Queue:       "default",
+		MaxAttempts: 25,
+		LockTimeout: 5 * time.Minute,
+		Backoff:     DefaultBackoff,
+	}
+}
+
+// DefaultBackoff waits 2^attempt seconds between attempts, up to an hour.
+func DefaultBackoff(attempt int) time.Duration {
+	if attempt >= 12 {
+		return time.Hour
+	}
+	return time.Duration(1<<attempt) * time.Second
+}
+
+// JobOptions configures one enqueued job.
+type JobOptions struct {
+	RunAt       time.Time     // Run no earlier than this
+	Delay       time.Duration // Run no earlier than now plus this, unless RunAt is set
+	MaxAttempts int           // Overrides Options.MaxAttempts
+}
+
+// Queue enqueues and claims the jobs of one queue.
+type Queue struct {
+	conn *dialects.Connection
+	opts Options
+}
+
+// New returns a queue using the default options with any non-zero fields
+// of opts.
+func New(conn *dialects.Connection, opts Options) *Queue {
+	def := DefaultOptions()
+	if opts.Table == "" {
+		opts.Table = def.Table
+	}
+	if opts.Queue == "" {
+		opts.Queue = def.Queue
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = def.MaxAttempts
+	}
+	if opts.LockTimeout <= 0 {
+		opts.LockTimeout = def.LockTimeout
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = def.Backoff
+	}
+	if opts.WorkerID == "" {
+		host, _ := os.Hostname()
+		opts.WorkerID = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	return &Queue{conn: conn, opts: opts}
+}
+
+// Name returns the name of the queue.
+func (q *Queue) Name() string {
+	return q.opts.Queue
+}
+
+// Define adds the jobs model to s under the name Job, so that migrations
+// create the table. Init creates it without migrations.
+func Define(s *schema.Schema, table string) *schema.Model {
+	s.Model("Job", func(m *schema.Model) {
+		m.BigInt("id").PrimaryKey().AutoInc()
+		m.String("queue").Size(100)
+		m.String("kind").Size(100)
+		m.Text("payload")
+		m.String("status").Size(20).Default(string(StatusPending))
+		m.Int("attempts").Default(0)
+		m.Int("max_attempts")
+		m.DateTime("run_at")
+		m.String("locked_by").Null()
+		m.DateTime("locked_until").Null()
+		m.Text("last_error").Null()
+		m.DateTime("created_at")
+		m.DateTime("finished_at").Null()
+		m.Index("idx_"+table+"_claim", "queue", "status", "run_at")
+		m.Map(table)
+	})
+	return s.Models["Job"]
+}
+
+// Init creates the jobs table and its index if they don't exist.
+func (q *Queue) Init(ctx context.Context) error {
+	s := schema.NewSchema()
+	model := Define(s, q.opts.Table)
+	dialect := q.conn.Dialect
+	if _, err := q.conn.Exec(ctx, dialect.CreateTableSQL(model)); err != nil {
+		return fmt.Errorf("creating table %s: %w", q.opts.Table, err)
+	}
+	for _, idx := range model.Indexes {
+		_, err := q.conn.Exec(ctx, dialect.CreateIndexSQL(model.Table(), idx))
+		// MySQL has no CREATE INDEX IF NOT EXISTS
+		if err != nil && !strings.Contains(err.Error(), "Duplicate key name") {
+			return fmt.Errorf("creating index %s: %w", idx.Name, err)
+		}
+	}
+	return nil
+}
+
+// execer is what enqueue writes through: a connection or a transaction.
+type execer interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Enqueue adds a job of the given kind to run as soon as a worker is free.
+// The payload is marshaled to JSON.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}) error {
+	return q.enqueue(ctx, q.conn, kind, payload, JobOptions{})
+}
+
+// EnqueueWithOptions adds a job with the given options, such as a run time
+// for scheduled jobs.
+func (q *Queue) EnqueueWithOptions(ctx context.Context, kind string, payload interface{}, opts JobOptions) error {
+	return q.enqueue(ctx, q.conn, kind, payload, opts)
+}
+
+// EnqueueTx adds a job within tx, so that the job only exists if tx
+// commits.
+func (q *Queue) EnqueueTx(ctx context.Context, tx *dialects.Tx, kind string, payload interface{}, opts JobOptions) error {
+	return q.enqueue(ctx, tx, kind, payload, opts)
+}
+
+func (q *Queue) enqueue(ctx context.Context, e execer, kind string, payload interface{}, opts JobOptions) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding payload of %s job: %w", kind, err)
+	}
+	now := dbtime.Now()
+	runAt := opts.RunAt.UTC()
+	if opts.RunAt.IsZero() {
+		runAt = now.Add(opts.Delay)
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.opts.MaxAttempts
+	}
+
+	st := q.statement()
+	query := fmt.Sprintf("INSERT INTO %s (queue, kind, payload, status, attempts, max_attempts, run_at, created_at) VALUES (%s, %s, %s, %s, 0, %s, %s, %s)",
+		st.table(), st.arg(q.opts.Queue), st.arg(kind), st.arg(string(data)), st.arg(string(StatusPending)),
+		st.arg(maxAttempts), st.arg(runAt), st.arg(now))
+	_, err = e.Exec(ctx, query, st.args...)
+	return err
+}
+
+// Dequeue claims the next due job of the queue for the queue's worker ID,
+// or returns nil if none is due. Jobs whose lock expired are claimed again
+// if they have attempts left, and marked failed otherwise. The job must be
+// finished with Complete or Fail.
+func (q *Queue) Dequeue(ctx context.Context) (*Job, error) {
+	if err := q.failExhausted(ctx); err != nil {
+		return nil, err
+	}
+	switch q.conn.Dialect.Name() {
+	case "postgres":
+		return q.dequeueReturning(ctx)
+	case "mysql":
+		return q.dequeueLocked(ctx)
+	default:
+		return q.dequeueConditional(ctx)
+	}
+}
+
+// claimable adds the condition of a job the queue may claim at now: a
+// pending one, or one whose worker's lock expired with attempts left.
+func (q *Queue) claimable(st *statement, now time.Time) string {
+	return fmt.Sprintf("queue = %s AND run_at <= %s AND (status = %s OR (status = %s AND locked_until < %s AND attempts < max_attempts))",
+		st.arg(q.opts.Queue), st.arg(now), st.arg(string(StatusPending)), st.arg(string(StatusRunning)), st.arg(now))
+}
+
+// failExhausted marks failed the jobs whose lock expired on their last
+// attempt, such as one that crashed its worker every time.
+func (q *Queue) failExhausted(ctx context.Context) error {
+	now := dbtime.Now()
+	st := q.statement()
+	query := fmt.Sprintf("UPDATE %s SET status = %s, locked_by = NULL, locked_until = NULL, last_error = %s, finished_at = %s WHERE queue = %s AND status = %s AND locked_until < %s AND attempts >= max_attempts",
+		st.table(), st.arg(string(StatusFailed)), st.arg("lock expired on the last attempt"), st.arg(now),
+		st.arg(q.opts.Queue), st.arg(string(StatusRunning)), st.arg(now))
+	_, err := q.conn.Exec(ctx, query, st.args...)
+	return err
+}
+
+// claim adds the assignments that lock a job to the worker.
+func (q *Queue) claim(st *statement, now time.Time) string {
+	return fmt.Sprintf("status = %s, locked_by = %s, locked_until = %s, attempts = attempts + 1",
+		st.arg(string(StatusRunning)), st.arg(q.opts.WorkerID), st.arg(now.Add(q.opts.LockTimeout)))
+}
+
+// dequeueReturning claims a job in one statement on PostgreSQL.
+func (q *Queue) dequeueReturning(ctx context.Context) (*Job, error) {
+	now := dbtime.Now()
+	st := q.statement()
+	set := q.claim(st, now)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = (SELECT id FROM %s WHERE %s ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING %s",
+		st.table(), set, st.table(), q.claimable(st, now), jobColumns)
+	job, err := scanJob(q.conn.QueryRow(ctx, query, st.args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// dequeueLocked locks the next job with SKIP LOCKED and claims it in a
+// transaction on MySQL, which has no UPDATE ... RETURNING.
+func (q *Queue) dequeueLocked(ctx context.Context) (*Job, error) {
+	var job *Job
+	err := q.transaction(ctx, func(tx *dialects.Tx) error {
+		now := dbtime.Now()
+		st := q.statement()
+		query := fmt.Sprintf("SELECT id FROM %s WHERE %s ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED",
+			st.table(), q.claimable(st, now))
+		var id int64
+		if err := tx.QueryRow(ctx, query, st.args...).Scan(&id); err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		if _, err := q.update(ctx, tx, id, now); err != nil {
+			return err
+		}
+		var err error
+		job, err = q.get(ctx, tx, id)
+		return err
+	})
+	return job, err
+}
+
+// dequeueConditional claims a job with an UPDATE that only matches while
+// the job is still claimable, retrying with the next job when another
+// worker claimed it first. SQLite serializes writes, so the UPDATE is the
+// lock.
+func (q *Queue) dequeueConditional(ctx context.Context) (*Job, error) {
+	dialect := q.conn.Dialect
+	for {
+		now := dbtime.Now()
+		st := q.statement()
+		query := fmt.Sprintf("SELECT id FROM %s WHERE %s ORDER BY run_at, id", st.table(), q.claimable(st, now)) +
+			dialects.LimitClause(dialect, 1, 0, true)
+		var id int64
+		if err := q.conn.QueryRow(ctx, query, st.args...).Scan(&id); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, nil
+			}
+			return nil, err
+		}
+		claimed, err := q.update(ctx, q.conn, id, now)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return q.get(ctx, q.conn, id)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// update claims the job id if it is still claimable at now.
+func (q *Queue) update(ctx context.Context, e execer, id int64, now time.Time) (bool, error) {
+	st := q.statement()
+	set := q.claim(st, now)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = %s AND %s", st.table(), set, st.arg(id), q.claimable(st, now))
+	result, err := e.Exec(ctx, query, st.args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// rowQuerier is what get reads through: a connection or a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// get reads the job id.
+func (q *Queue) get(ctx context.Context, r rowQuerier, id int64) (*Job, error) {
+	st := q.statement()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = %s", jobColumns, st.table(), st.arg(id))
+	return scanJob(r.QueryRow(ctx, query, st.args...))
+}
+
+// Get returns the job id, or nil if it doesn't exist.
+func (q *Queue) Get(ctx context.Context, id int64) (*Job, error) {
+	job, err := q.get(ctx, q.conn, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// Complete marks a claimed job done.
+func (q *Queue) Complete(ctx context.Context, job *Job) error {
+	now := dbtime.Now()
+	st := q.statement()
+	query := fmt.Sprintf("UPDATE %s SET status = %s, locked_by = NULL, locked_until = NULL, finished_at = %s WHERE id = %s AND locked_by = %s",
+		st.table(), st.arg(string(StatusDone)), st.arg(now), st.arg(job.ID), st.arg(q.opts.WorkerID))
+	return q.finish(ctx, job, query, st.args, StatusDone)
+}
+
+// Fail records cause on a claimed job and schedules a retry after the
+// backoff, or marks the job failed when it has no attempts left.
+func (q *Queue) Fail(ctx context.Context, job *Job, cause error) error {
+	now := dbtime.Now()
+	st := q.statement()
+	status, finished, runAt := StatusPending, interface{}(nil), now.Add(q.opts.Backoff(job.Attempts))
+	if job.Attempts >= job.MaxAttempts {
+		status, finished, runAt = StatusFailed, now, job.RunAt
+	}
+	query := fmt.Sprintf("UPDATE %s SET status = %s, locked_by = NULL, locked_until = NULL, last_error = %s, run_at = %s, finished_at = %s WHERE id = %s AND locked_by = %s",
+		st.table(), st.arg(string(status)), st.arg(cause.Error()), st.arg(runAt), st.arg(finished), st.arg(job.ID), st.arg(q.opts.WorkerID))
+	return q.finish(ctx, job, query, st.args, status)
+}
+
+// finish runs the update of Complete or Fail, which matches nothing when
+// the lock expired and another worker claimed the job.
+func (q *Queue) finish(ctx context.Context, job *Job, query string, args []interface{}, status Status) error {
+	result, err := q.conn.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("job %d is no longer locked by %s", job.ID, q.opts.WorkerID)
+	}
+	job.Status = status
+	job.LockedBy = ""
+	return nil
+}
+
+// Prune deletes the done and failed jobs of the queue that finished before
+// the given time, and returns how many it deleted.
+func (q *Queue) Prune(ctx context.Context, before time.Time) (int64, error) {
+	st := q.statement()
+	query := fmt.Sprintf("DELETE FROM %s WHERE queue = %s AND status IN (%s, %s) AND finished_at < %s",
+		st.table(), st.arg(q.opts.Queue), st.arg(string(StatusDone)), st.arg(string(StatusFailed)), st.arg(before.UTC()))
+	result, err := q.conn.Exec(ctx, query, st.args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Counts returns the number of jobs of the queue in each status.
+func (q *Queue) Counts(ctx context.Context) (map[Status]int64, error) {
+	st := q.statement()
+	query := fmt.Sprintf("SELECT status, COUNT(*) FROM %s WHERE queue = %s GROUP BY status", st.table(), st.arg(q.opts.Queue))
+	rows, err := q.conn.Query(ctx, query, st.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[Status]int64)
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[Status(status)] = n
+	}
+	return counts, rows.Err()
+}
+
+// transaction runs fn in a transaction, like query.Transaction.
+func (q *Queue) transaction(ctx context.Context, fn func(tx *dialects.Tx) error) error {
+	tx, err := q.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// statement collects the arguments of a query with the placeholders of the
+// dialect.
+type statement struct {
+	q    *Queue
+	args []interface{}
+}
+
+func (q *Queue) statement() *statement {
+	return &statement{q: q}
+}
+
+func (s *statement) arg(v interface{}) string {
+	s.args = append(s.args, v)
+	return s.q.conn.Dialect.Placeholder(len(s.args))
+}
+
+func (s *statement) table() string {
+	return s.q.conn.Dialect.Quote(s.q.opts.Table)
+}
+
+// jobColumns are the columns scanJob reads, in order.
+const jobColumns = "id, queue, kind, payload, status, attempts, max_attempts, run_at, locked_by, locked_until, last_error, created_at, finished_at"
+
+func scanJob(row *sql.Row) (*Job, error) {
+	var job Job
+	var payload, status string
+	var lockedBy, lastError sql.NullString
+	var runAt, lockedUntil, createdAt, finishedAt dbtime.Time
+	err := row.Scan(&job.ID, &job.Queue, &job.Kind, &payload, &status, &job.Attempts, &job.MaxAttempts,
+		&runAt, &lockedBy, &lockedUntil, &lastError, &createdAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = json.RawMessage(payload)
+	job.Status = Status(status)
+	job.RunAt, job.LockedUntil, job.CreatedAt, job.FinishedAt = runAt.Time, lockedUntil.Time, createdAt.Time, finishedAt.Time
+	job.LockedBy, job.LastError = lockedBy.String, lastError.String
+	return &job, nil
+}
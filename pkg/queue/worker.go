@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// Handler runs a job. Returning an error retries the job after the backoff
+// of the queue, until it runs out of attempts.
+type Handler func(ctx context.Context, job *Job) error
+
+// WorkerOptions configures a worker.
+type WorkerOptions struct {
+	Concurrency  int             // Jobs run at once (default 1)
+	PollInterval time.Duration   // Wait between polls when the queue is empty (default 1s)
+	Logger       nexuslog.Logger // Logs failed jobs (default discards)
+}
+
+// Worker runs the jobs of a queue with the handlers registered for their
+// kinds.
+type Worker struct {
+	queue    *Queue
+	opts     WorkerOptions
+	handlers map[string]Handler
+}
+
+// NewWorker returns a worker for q.
+func NewWorker(q *Queue, opts WorkerOptions) *Worker {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = nexuslog.Nop()
+	}
+	return &Worker{queue: q, opts: opts, handlers: make(map[string]Handler)}
+}
+
+// Handle registers the handler of jobs of the given kind.
+func (w *Worker) Handle(kind string, h Handler) {
+	w.handlers[kind] = h
+}
+
+// Run claims and runs jobs until ctx is canceled, then waits for the
+// running jobs to finish. Database errors are logged and retried at the
+// next poll.
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// loop runs jobs until ctx is canceled, sleeping when the queue is empty.
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		ran, err := w.RunNext(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			w.opts.Logger.Error("job queue poll failed", nexuslog.F("queue", w.queue.Name()), nexuslog.Err(err))
+		}
+		if ran && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.opts.PollInterval):
+		}
+	}
+}
+
+// RunNext claims the next due job and runs it, and reports whether there
+// was one. A failing handler is not an error of RunNext; the job is
+// retried or marked failed.
+func (w *Worker) RunNext(ctx context.Context) (bool, error) {
+	job, err := w.queue.Dequeue(ctx)
+	if err != nil || job == nil {
+		return false, err
+	}
+	// Finish the job even if ctx is canceled while the handler runs
+	finishCtx := context.WithoutCancel(ctx)
+	if err := w.run(ctx, job); err != nil {
+		w.opts.Logger.Warn("job failed",
+			nexuslog.F("queue", job.Queue), nexuslog.F("kind", job.Kind), nexuslog.F("id", job.ID),
+			nexuslog.F("attempt", job.Attempts), nexuslog.Err(err))
+		return true, w.queue.Fail(finishCtx, job, err)
+	}
+	return true, w.queue.Complete(finishCtx, job)
+}
+
+// run calls the handler of the job, turning a panic into an error.
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	h, ok := w.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for job kind %s", job.Kind)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return h(ctx, job)
+}
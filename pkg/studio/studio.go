@@ -0,0 +1,34 @@
+// Package studio embeds Nexus Studio, the database browser, into an
+// application. Mount the handler under the application's own router,
+// behind its authentication middleware:
+//
+//	admin := studio.Handler(studio.Config{
+//		Connection: conn,
+//		ReadOnly:   true,
+//		Tables:     []string{"users", "orders"},
+//	})
+//	mux.Handle("/admin/db/", requireAdmin(http.StripPrefix("/admin/db", admin)))
+//
+// The studio has no authentication of its own; whoever can reach the
+// handler can browse the database and, unless ReadOnly is set, change it.
+package studio
+
+import (
+	"net/http"
+
+	"github.com/nexus-db/nexus/internal/studio"
+)
+
+// Config configures an embedded studio. Port and Host are only used by
+// the standalone server and are ignored by Handler.
+type Config = studio.Config
+
+// DefaultQueryTimeout is the statement timeout of the query editor when
+// Config.QueryTimeout is zero.
+const DefaultQueryTimeout = studio.DefaultQueryTimeout
+
+// Handler returns the studio's web UI and API as an http.Handler. It
+// serves paths from the root, so strip the prefix it is mounted under.
+func Handler(cfg Config) http.Handler {
+	return studio.Handler(cfg)
+}
@@ -0,0 +1,207 @@
+// Package validate checks values against the validation rules of schema
+// fields (@email, @min, @max, @regex). The checks return a message, or ""
+// if the value is valid, and are shared by the query builders and
+// generated code so both report the same messages.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+// Errors maps field names to what is wrong with their values.
+type Errors map[string]string
+
+// Error lists the invalid fields in name order.
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field + " " + e[field]
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Add records message for field. Empty messages are ignored and the first
+// message of a field is kept.
+func (e Errors) Add(field, message string) {
+	if message == "" {
+		return
+	}
+	if _, ok := e[field]; !ok {
+		e[field] = message
+	}
+}
+
+// Err returns e, or nil if there are no errors.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Email checks that v is an email address such as user@example.com.
+func Email(v interface{}) string {
+	s, ok := text(v)
+	if !ok {
+		return ""
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s || !strings.Contains(s[strings.LastIndex(s, "@")+1:], ".") {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+// Min checks that a number is at least min, or a string has at least min
+// characters.
+func Min(v interface{}, min float64) string {
+	if s, ok := text(v); ok {
+		if float64(utf8.RuneCountInString(s)) < min {
+			return "must be at least " + formatNumber(min) + " characters"
+		}
+		return ""
+	}
+	if n, ok := number(v); ok && n < min {
+		return "must be at least " + formatNumber(min)
+	}
+	return ""
+}
+
+// Max checks that a number is at most max, or a string has at most max
+// characters.
+func Max(v interface{}, max float64) string {
+	if s, ok := text(v); ok {
+		if float64(utf8.RuneCountInString(s)) > max {
+			return "must be at most " + formatNumber(max) + " characters"
+		}
+		return ""
+	}
+	if n, ok := number(v); ok && n > max {
+		return "must be at most " + formatNumber(max)
+	}
+	return ""
+}
+
+// Match checks that a string matches re.
+func Match(v interface{}, re *regexp.Regexp) string {
+	if s, ok := text(v); ok && !re.MatchString(s) {
+		return "must match " + re.String()
+	}
+	return ""
+}
+
+// Field checks v against the validation rules of f. Nil values pass;
+// whether a column may be null is up to the database.
+func Field(f *schema.Field, v interface{}) string {
+	checks := []string{}
+	if f.IsEmail {
+		checks = append(checks, Email(v))
+	}
+	if f.MinValue != nil {
+		checks = append(checks, Min(v, *f.MinValue))
+	}
+	if f.MaxValue != nil {
+		checks = append(checks, Max(v, *f.MaxValue))
+	}
+	if f.Pattern != "" {
+		re, err := compile(f.Pattern)
+		if err != nil {
+			return err.Error()
+		}
+		checks = append(checks, Match(v, re))
+	}
+	for _, message := range checks {
+		if message != "" {
+			return message
+		}
+	}
+	return ""
+}
+
+// Values checks the columns present in values against the rules of model.
+// Columns that are not fields of the model are ignored.
+func Values(model *schema.Model, values map[string]interface{}) error {
+	errs := Errors{}
+	for column, v := range values {
+		if f, ok := model.Fields[column]; ok {
+			errs.Add(column, Field(f, v))
+		}
+	}
+	return errs.Err()
+}
+
+// HasRules reports whether f has validation rules.
+func HasRules(f *schema.Field) bool {
+	return f.IsEmail || f.MinValue != nil || f.MaxValue != nil || f.Pattern != ""
+}
+
+var patterns sync.Map // string -> *regexp.Regexp
+
+// compile compiles a pattern once.
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// text returns string values, dereferencing pointers.
+func text(v interface{}) (string, bool) {
+	rv := indirect(v)
+	if rv.IsValid() && rv.Kind() == reflect.String {
+		return rv.String(), true
+	}
+	return "", false
+}
+
+// number returns numeric values as float64, dereferencing pointers.
+func number(v interface{}) (float64, bool) {
+	rv := indirect(v)
+	switch {
+	case !rv.IsValid():
+		return 0, false
+	case rv.CanInt():
+		return float64(rv.Int()), true
+	case rv.CanUint():
+		return float64(rv.Uint()), true
+	case rv.CanFloat():
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func indirect(v interface{}) reflect.Value {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
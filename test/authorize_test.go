@@ -0,0 +1,257 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	nxerr "github.com/nexus-db/nexus/pkg/errors"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// readOnlyAuthorizer allows reads on every model except those listed in hidden
+// and denies all writes.
+type readOnlyAuthorizer struct {
+	hidden map[string]bool
+	calls  []string
+}
+
+func (a *readOnlyAuthorizer) CanRead(ctx context.Context, model string, op query.Operation) error {
+	a.calls = append(a.calls, model+":"+string(op))
+	if a.hidden[model] {
+		return errors.New("model is hidden")
+	}
+	return nil
+}
+
+func (a *readOnlyAuthorizer) CanWrite(ctx context.Context, model string, op query.Operation) error {
+	a.calls = append(a.calls, model+":"+string(op))
+	return errors.New("read-only")
+}
+
+func TestAuthorizer_AllowsReads(t *testing.T) {
+	conn, s := setupCascadeDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	query.New(conn, "users").Insert(map[string]interface{}{"name": "Alice"}).Exec(ctx)
+
+	auth := &readOnlyAuthorizer{}
+	users := query.NewWithSchema(conn, "users", s).WithAuthorizer(auth)
+
+	results, err := users.Select().All(ctx)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected 1 user, got %d", len(results))
+	}
+
+	if len(auth.calls) != 1 || auth.calls[0] != "User:select" {
+		t.Errorf("Expected authorizer to see User:select, got %v", auth.calls)
+	}
+}
+
+func TestAuthorizer_DeniesWrites(t *testing.T) {
+	conn, s := setupCascadeDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	users := query.NewWithSchema(conn, "users", s).WithAuthorizer(&readOnlyAuthorizer{})
+
+	_, err := users.Insert(map[string]interface{}{"name": "Mallory"}).Exec(ctx)
+	if err == nil {
+		t.Fatal("Expected insert to be denied")
+	}
+
+	var nerr *nxerr.NexusError
+	if !errors.As(err, &nerr) || nerr.Code != nxerr.ErrQueryUnauthorized {
+		t.Errorf("Expected ErrQueryUnauthorized, got %v", err)
+	}
+
+	if _, err := users.Update(map[string]interface{}{"name": "x"}).Exec(ctx); err == nil {
+		t.Error("Expected update to be denied")
+	}
+	if _, err := users.Delete().Exec(ctx); err == nil {
+		t.Error("Expected delete to be denied")
+	}
+
+	// Nothing should have been written
+	count, _ := query.New(conn, "users").Select().Count(ctx)
+	if count != 0 {
+		t.Errorf("Expected 0 users, got %d", count)
+	}
+}
+
+func TestAuthorizer_ChecksIncludes(t *testing.T) {
+	conn, s := setupCascadeDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	query.New(conn, "users").Insert(map[string]interface{}{"name": "Alice"}).Exec(ctx)
+
+	auth := &readOnlyAuthorizer{hidden: map[string]bool{"Post": true}}
+	users := query.NewWithSchema(conn, "users", s).WithAuthorizer(auth)
+
+	_, err := users.Select().Include("Post").All(ctx)
+	if err == nil {
+		t.Fatal("Expected include of hidden model to be denied")
+	}
+	if !strings.Contains(err.Error(), "Post") {
+		t.Errorf("Expected error to mention Post, got %v", err)
+	}
+}
+
+// unauthorized reports whether err is an ErrQueryUnauthorized error.
+func unauthorized(err error) bool {
+	var nerr *nxerr.NexusError
+	return errors.As(err, &nerr) && nerr.Code == nxerr.ErrQueryUnauthorized
+}
+
+// writeDenier allows everything but writes to the models in denied.
+type writeDenier struct {
+	denied map[string]bool
+}
+
+func (a *writeDenier) CanRead(ctx context.Context, model string, op query.Operation) error {
+	return nil
+}
+
+func (a *writeDenier) CanWrite(ctx context.Context, model string, op query.Operation) error {
+	if a.denied[model+":"+string(op)] {
+		return errors.New("denied")
+	}
+	return nil
+}
+
+func TestAuthorizer_ChecksCascades(t *testing.T) {
+	conn, s := setupCascadeDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	query.New(conn, "users").Insert(map[string]interface{}{"name": "Alice"}).Exec(ctx)
+	query.New(conn, "posts").Insert(map[string]interface{}{"title": "Hello", "user_id": 1}).Exec(ctx)
+
+	auth := &writeDenier{denied: map[string]bool{"Post:delete": true}}
+	_, err := query.NewWithSchema(conn, "users", s).WithAuthorizer(auth).Delete().Where(query.Eq("id", 1)).Cascade().Exec(ctx)
+	if !unauthorized(err) || !strings.Contains(err.Error(), "Post") {
+		t.Fatalf("Expected the cascaded delete of posts to be denied, got %v", err)
+	}
+	for _, table := range []string{"users", "posts"} {
+		if n, _ := query.New(conn, table).Select().Count(ctx); n != 1 {
+			t.Errorf("Expected %s left alone, got %d rows", table, n)
+		}
+	}
+}
+
+func TestAuthorizer_ChecksJunctionTable(t *testing.T) {
+	conn, s := setupManyToManyDB(t)
+	defer conn.Close()
+	conn.DB.SetMaxOpenConns(1)
+	ctx := context.Background()
+	query.New(conn, "users").Insert(map[string]interface{}{"name": "Ada"}).Exec(ctx)
+	query.New(conn, "tags").Insert(map[string]interface{}{"name": "go"}).Exec(ctx)
+
+	auth := &writeDenier{denied: map[string]bool{"user_tags:insert": true}}
+	tags := query.NewWithSchema(conn, "users", s).WithAuthorizer(auth).Relation("Tag")
+	if _, err := tags.Attach(ctx, 1, 1); !unauthorized(err) {
+		t.Errorf("Expected Attach to be denied, got %v", err)
+	}
+	if _, err := tags.Sync(ctx, 1, 1); !unauthorized(err) {
+		t.Errorf("Expected Sync to be denied, got %v", err)
+	}
+	if _, err := tags.Detach(ctx, 1, 1); err != nil {
+		t.Errorf("Expected Detach to be allowed, got %v", err)
+	}
+	if n, _ := query.New(conn, "user_tags").Select().Count(ctx); n != 0 {
+		t.Errorf("Expected no links, got %d", n)
+	}
+}
+
+func TestAuthorizer_ChecksLazyRelations(t *testing.T) {
+	conn, s := setupCascadeDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	query.New(conn, "users").Insert(map[string]interface{}{"name": "Alice"}).Exec(ctx)
+	query.New(conn, "posts").Insert(map[string]interface{}{"title": "Hello", "user_id": 1}).Exec(ctx)
+
+	auth := &readOnlyAuthorizer{hidden: map[string]bool{"Post": true}}
+	user, err := query.NewWithSchema(conn, "users", s).WithAuthorizer(auth).Select().OneLazy(ctx)
+	if err != nil {
+		t.Fatalf("OneLazy failed: %v", err)
+	}
+	if _, err := user.GetRelation(ctx, "Post"); !unauthorized(err) {
+		t.Errorf("Expected loading hidden posts to be denied, got %v", err)
+	}
+	if user.IsLoaded("Post") {
+		t.Error("Expected nothing cached for a denied relation")
+	}
+}
+
+func TestAuthorizer_ChecksLazyJunctionTable(t *testing.T) {
+	conn, s := setupManyToManyDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+	query.New(conn, "users").Insert(map[string]interface{}{"name": "Ada"}).Exec(ctx)
+
+	auth := &readOnlyAuthorizer{hidden: map[string]bool{"user_tags": true}}
+	user, err := query.NewWithSchema(conn, "users", s).WithAuthorizer(auth).Select().OneLazy(ctx)
+	if err != nil {
+		t.Fatalf("OneLazy failed: %v", err)
+	}
+	if _, err := user.GetRelation(ctx, "Tag"); !unauthorized(err) {
+		t.Errorf("Expected reading the junction table to be denied, got %v", err)
+	}
+}
+
+func TestAuthorizer_ChecksSetOperands(t *testing.T) {
+	conn, s := setupCascadeDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	auth := &readOnlyAuthorizer{hidden: map[string]bool{"Post": true}}
+	names := query.NewWithSchema(conn, "users", s).WithAuthorizer(auth).Select("name")
+	titles := query.NewWithSchema(conn, "posts", s).Select("title")
+
+	union := names.Union(titles)
+	if _, err := union.All(ctx); !unauthorized(err) || !strings.Contains(err.Error(), "Post") {
+		t.Errorf("Expected the posts operand to be denied, got %v", err)
+	}
+	if _, err := union.Count(ctx); !unauthorized(err) {
+		t.Errorf("Expected Count of the union to be denied, got %v", err)
+	}
+
+	// A nested set operation is checked too
+	nested := query.NewWithSchema(conn, "users", s).WithAuthorizer(auth).Select("name").
+		Union(query.New(conn, "users").Select("name").Intersect(titles))
+	if _, err := nested.All(ctx); !unauthorized(err) {
+		t.Errorf("Expected the nested posts operand to be denied, got %v", err)
+	}
+}
+
+func TestAuthorizer_ChecksCTEsAndDerivedTables(t *testing.T) {
+	conn, s := setupCascadeDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	auth := &readOnlyAuthorizer{hidden: map[string]bool{"Post": true}}
+	posts := query.NewWithSchema(conn, "posts", s).WithAuthorizer(auth).Select()
+
+	if _, err := query.With(conn, "recent", posts).Select().From("recent").All(ctx); !unauthorized(err) {
+		t.Errorf("Expected the CTE over posts to be denied, got %v", err)
+	}
+	if _, err := query.FromSubquery(conn, posts, "p").All(ctx); !unauthorized(err) {
+		t.Errorf("Expected the derived table over posts to be denied, got %v", err)
+	}
+
+	// The main SELECT of a CTE query reading a table directly is checked
+	users := query.NewWithSchema(conn, "users", s).WithAuthorizer(auth).Select()
+	if _, err := query.With(conn, "u", users).Select().From("posts").All(ctx); !unauthorized(err) {
+		t.Errorf("Expected reading posts beside the CTE to be denied, got %v", err)
+	}
+	if _, err := query.With(conn, "u", users).Select().From("u").All(ctx); err != nil {
+		t.Errorf("Expected the CTE over users to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func setupBatchDB(t *testing.T, rows int) *dialects.Connection {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(db, sqlite.New())
+	ctx := context.Background()
+	if _, err := conn.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, status TEXT NOT NULL, owner INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= rows; i++ {
+		if _, err := conn.Exec(ctx, `INSERT INTO items (id, status, owner) VALUES (?, 'new', ?)`, i, i%2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return conn
+}
+
+func batchIDs(n int) []interface{} {
+	ids := make([]interface{}, n)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	return ids
+}
+
+func TestUpdateManyChunks(t *testing.T) {
+	conn := setupBatchDB(t, 2500)
+	ctx := context.Background()
+	profiler := query.NewProfiler(query.DefaultProfilerOptions())
+	profiler.Start()
+
+	// 2500 IDs take three statements within SQLite's 999 parameters
+	n, err := query.New(conn, "items").WithProfiler(profiler).
+		UpdateMany(ctx, batchIDs(2500), map[string]interface{}{"status": "archived"}, query.BatchOptions{})
+	if err != nil || n != 2500 {
+		t.Fatalf("Expected 2500 rows updated, got %d (%v)", n, err)
+	}
+	if got := profiler.Report().TotalQueries; got != 3 {
+		t.Errorf("Expected 3 statements, got %d", got)
+	}
+
+	// Where restricts every chunk
+	n, err = query.New(conn, "items").UpdateMany(ctx, batchIDs(10), map[string]interface{}{"status": "owned"},
+		query.BatchOptions{ChunkSize: 3, Where: []query.Condition{query.Eq("owner", 1)}})
+	if err != nil || n != 5 {
+		t.Fatalf("Expected 5 rows updated, got %d (%v)", n, err)
+	}
+
+	if n, err := query.New(conn, "items").UpdateMany(ctx, nil, map[string]interface{}{"status": "x"}, query.BatchOptions{}); err != nil || n != 0 {
+		t.Errorf("Expected no rows for no IDs, got %d (%v)", n, err)
+	}
+}
+
+func TestDeleteManyTransaction(t *testing.T) {
+	conn := setupBatchDB(t, 20)
+	ctx := context.Background()
+
+	n, err := query.New(conn, "items").DeleteMany(ctx, batchIDs(8), query.BatchOptions{ChunkSize: 3, Transaction: true})
+	if err != nil || n != 8 {
+		t.Fatalf("Expected 8 rows deleted, got %d (%v)", n, err)
+	}
+
+	// A failing chunk rolls back the chunks before it
+	if _, err := conn.Exec(ctx, `CREATE TRIGGER lock_item BEFORE DELETE ON items WHEN OLD.id = 11 BEGIN SELECT RAISE(ABORT, 'locked'); END`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := query.New(conn, "items").DeleteMany(ctx, batchIDs(12)[8:], query.BatchOptions{ChunkSize: 2, Transaction: true}); err == nil {
+		t.Fatal("Expected the trigger to fail the second chunk")
+	}
+	var count int
+	conn.DB.QueryRow(`SELECT COUNT(*) FROM items`).Scan(&count)
+	if count != 12 {
+		t.Errorf("Expected 12 rows left, got %d", count)
+	}
+}
+
+func TestMaxParams(t *testing.T) {
+	for _, tt := range []struct {
+		dialect dialects.Dialect
+		want    int
+	}{
+		{sqlite.New(), 999},
+		{mssql.New(), 2100},
+		{postgres.New(), 65535},
+	} {
+		if got := query.MaxParams(tt.dialect); got != tt.want {
+			t.Errorf("MaxParams(%s) = %d, want %d", tt.dialect.Name(), got, tt.want)
+		}
+	}
+}
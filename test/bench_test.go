@@ -0,0 +1,129 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/bench"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// setupBenchDB opens a file database, so every worker's connection sees
+// the same tables.
+func setupBenchDB(t *testing.T) *dialects.Connection {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "bench.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+	t.Cleanup(func() { conn.Close() })
+
+	ctx := context.Background()
+	if _, err := conn.Exec(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	for i := 1; i <= 10; i++ {
+		conn.Exec(ctx, `INSERT INTO users (id, name) VALUES (?, ?)`, i, "user")
+	}
+	return conn
+}
+
+func TestBenchWorkloadSpec(t *testing.T) {
+	conn := setupBenchDB(t)
+	path := filepath.Join(t.TempDir(), "workload.yaml")
+	os.WriteFile(path, []byte(`# Read-heavy mix
+- name: user by id
+  sql: SELECT * FROM users WHERE id = ?
+  args: [3]
+  weight: 3
+- name: all users
+  sql: "SELECT * FROM users"
+- name: rename
+  sql: UPDATE users SET name = 'x' WHERE id = 1
+`), 0o644)
+
+	w, err := bench.LoadWorkload(path)
+	if err != nil {
+		t.Fatalf("LoadWorkload failed: %v", err)
+	}
+	if len(w.Queries) != 3 || w.Queries[0].Weight != 3 || w.Queries[0].Args[0] != int64(3) {
+		t.Fatalf("Unexpected workload %+v", w.Queries)
+	}
+
+	opts := bench.DefaultOptions()
+	opts.Iterations = 200
+	report, err := bench.Run(context.Background(), conn, w, opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Total != 200 || report.Errors != 0 || report.Skipped != 1 {
+		t.Errorf("Expected 200 statements, no errors and the write skipped, got %d, %d, %d",
+			report.Total, report.Errors, report.Skipped)
+	}
+	if len(report.Patterns) != 2 {
+		t.Fatalf("Expected 2 patterns, got %+v", report.Patterns)
+	}
+	for _, p := range report.Patterns {
+		if p.P50 > p.P95 || p.P95 > p.P99 || p.P99 > p.Max || p.Throughput <= 0 {
+			t.Errorf("Inconsistent percentiles for %s: %+v", p.Pattern, p)
+		}
+	}
+	if !strings.Contains(report.String(), "user by id") {
+		t.Errorf("Expected the report to name the patterns:\n%s", report)
+	}
+
+	var name string
+	conn.QueryRow(context.Background(), `SELECT name FROM users WHERE id = 1`).Scan(&name)
+	if name != "user" {
+		t.Errorf("Expected the skipped write not to run, got name %q", name)
+	}
+}
+
+func TestBenchReplayCapture(t *testing.T) {
+	conn := setupBenchDB(t)
+	ctx := context.Background()
+
+	profiler := query.NewProfiler(query.DefaultProfilerOptions())
+	profiler.Start()
+	users := query.New(conn, "users").WithProfiler(profiler)
+	for id := 1; id <= 5; id++ {
+		users.Select().Where(query.Eq("id", id)).All(ctx)
+	}
+	users.Select().Where(query.Eq("id", "missing'")).All(ctx)
+	profiler.Stop()
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := profiler.WriteCapture(f); err != nil {
+		t.Fatalf("WriteCapture failed: %v", err)
+	}
+	f.Close()
+
+	w, err := bench.LoadWorkload(path)
+	if err != nil {
+		t.Fatalf("LoadWorkload failed: %v", err)
+	}
+	if !w.Sequential || len(w.Queries) != 6 || w.Queries[0].Args[0] != int64(1) || w.Queries[0].Baseline <= 0 {
+		t.Fatalf("Unexpected workload %+v", w.Queries)
+	}
+
+	report, err := bench.Run(ctx, conn, w, bench.Options{Concurrency: 2, Duration: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(report.Patterns) != 1 || report.Patterns[0].Baseline <= 0 || report.Total == 0 {
+		t.Errorf("Expected one pattern compared to its captured baseline, got %+v", report.Patterns)
+	}
+}
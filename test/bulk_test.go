@@ -0,0 +1,212 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/bulk"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func TestBulk_LoadSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, active INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+	ctx := context.Background()
+
+	var values [][]interface{}
+	for i := 1; i <= 250; i++ {
+		values = append(values, []interface{}{i, "user@example.com", i%2 == 0})
+	}
+	opts := bulk.DefaultOptions()
+	opts.BatchSize = 100
+	n, err := bulk.LoadWithOptions(ctx, conn, "users", bulk.NewRows([]string{"id", "email", "active"}, values), opts)
+	if err != nil || n != 250 {
+		t.Fatalf("Expected 250 rows, got %d, %v", n, err)
+	}
+	var count, active int
+	db.QueryRow(`SELECT COUNT(*), SUM(active) FROM users`).Scan(&count, &active)
+	if count != 250 || active != 125 {
+		t.Errorf("Expected 250 rows with 125 active, got %d and %d", count, active)
+	}
+
+	// A failed load inserts nothing
+	bad := [][]interface{}{{1000, "a", true}, {1, "duplicate", true}}
+	if _, err := bulk.Load(ctx, conn, "users", bulk.NewRows([]string{"id", "email", "active"}, bad)); err == nil || !strings.Contains(err.Error(), "users rows 1-2") {
+		t.Fatalf("Expected a duplicate key error, got %v", err)
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count)
+	if count != 250 {
+		t.Errorf("Expected the failed load to roll back, got %d rows", count)
+	}
+
+	tx, _ := conn.Begin(ctx)
+	defer tx.Rollback()
+	w, err := bulk.NewWriter(ctx, tx, "users", []string{"id"}, bulk.DefaultOptions())
+	if err != nil || w.Method() != bulk.MethodInsert {
+		t.Fatalf("Expected inserts on SQLite, got %v, %v", w, err)
+	}
+	if _, err := bulk.NewWriter(ctx, tx, "users", []string{"id"}, bulk.Options{Method: bulk.MethodCopy}); err == nil {
+		t.Error("Expected COPY to fail on SQLite")
+	}
+	if _, err := bulk.ParseMethod("fast"); err == nil {
+		t.Error("Expected an unknown method to fail")
+	}
+}
+
+// recorder is a database/sql driver that records what bulk sends.
+type recorder struct {
+	mu      sync.Mutex
+	execs   []string
+	copied  [][]driver.Value
+	loaded  string
+	readers map[string]func() io.Reader
+}
+
+var bulkRecorder = &recorder{readers: make(map[string]func() io.Reader)}
+
+func init() {
+	sql.Register("bulkrecorder", bulkRecorder)
+}
+
+func (r *recorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.execs, r.copied, r.loaded = nil, nil, ""
+}
+
+func (r *recorder) Open(string) (driver.Conn, error) { return recorderConn{r}, nil }
+
+type recorderConn struct{ r *recorder }
+
+func (c recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return recorderStmt{r: c.r, query: query}, nil
+}
+func (c recorderConn) Close() error              { return nil }
+func (c recorderConn) Begin() (driver.Tx, error) { return recorderTx{}, nil }
+
+type recorderTx struct{}
+
+func (recorderTx) Commit() error   { return nil }
+func (recorderTx) Rollback() error { return nil }
+
+type recorderStmt struct {
+	r     *recorder
+	query string
+}
+
+func (s recorderStmt) Close() error  { return nil }
+func (s recorderStmt) NumInput() int { return -1 }
+
+func (s recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	r := s.r
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.execs = append(r.execs, s.query)
+
+	switch {
+	case strings.HasPrefix(s.query, "COPY"):
+		if len(args) == 0 {
+			return driver.RowsAffected(len(r.copied)), nil
+		}
+		r.copied = append(r.copied, args)
+	case strings.HasPrefix(s.query, "LOAD DATA"):
+		name := strings.TrimPrefix(strings.Split(s.query, "'")[1], "Reader::")
+		handler, ok := r.readers[name]
+		if !ok {
+			return nil, errors.New("no reader " + name)
+		}
+		data, _ := io.ReadAll(handler())
+		r.loaded += string(data)
+		return driver.RowsAffected(strings.Count(string(data), "\n")), nil
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s recorderStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestBulk_Copy(t *testing.T) {
+	db, err := sql.Open("bulkrecorder", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	bulkRecorder.reset()
+
+	conn := dialects.NewConnection(db, postgres.New())
+	rows := bulk.NewRows([]string{"id", "email"}, [][]interface{}{{1, "a@example.com"}, {2, nil}})
+	n, err := bulk.LoadWithOptions(context.Background(), conn, "users", rows, bulk.Options{Method: bulk.MethodCopy})
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 rows, got %d, %v", n, err)
+	}
+	if bulkRecorder.execs[0] != `COPY "users" ("id", "email") FROM STDIN` {
+		t.Errorf("Unexpected statement %q", bulkRecorder.execs[0])
+	}
+	if len(bulkRecorder.copied) != 2 || bulkRecorder.copied[1][1] != nil {
+		t.Errorf("Unexpected copied rows %v", bulkRecorder.copied)
+	}
+}
+
+func TestBulk_LoadData(t *testing.T) {
+	db, err := sql.Open("bulkrecorder", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	bulkRecorder.reset()
+
+	conn := dialects.NewConnection(db, mysql.New())
+	ctx := context.Background()
+	rows := bulk.NewRows([]string{"id", "bio", "active"}, [][]interface{}{
+		{1, "tab\there\nand a \\ slash", true},
+		{2, nil, false},
+	})
+	if _, err := bulk.LoadWithOptions(ctx, conn, "users", rows, bulk.Options{Method: bulk.MethodLoadData}); err == nil {
+		t.Fatal("Expected LOAD DATA to need the reader handlers")
+	}
+
+	bulk.UseMySQLReaders(func(name string, handler func() io.Reader) {
+		bulkRecorder.readers[name] = handler
+	}, func(name string) {
+		delete(bulkRecorder.readers, name)
+	})
+	defer bulk.UseMySQLReaders(nil, nil)
+
+	rows = bulk.NewRows(rows.Columns(), [][]interface{}{
+		{1, "tab\there\nand a \\ slash", true},
+		{2, nil, false},
+	})
+	n, err := bulk.Load(ctx, conn, "users", rows)
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 rows, got %d, %v", n, err)
+	}
+	if !strings.Contains(bulkRecorder.execs[0], "INTO TABLE `users`") || !strings.HasSuffix(bulkRecorder.execs[0], "(`id`, `bio`, `active`)") {
+		t.Errorf("Unexpected statement %q", bulkRecorder.execs[0])
+	}
+	if want := "1\ttab\\there\\nand a \\\\ slash\t1\n2\t\\N\t0\n"; bulkRecorder.loaded != want {
+		t.Errorf("Expected %q, got %q", want, bulkRecorder.loaded)
+	}
+	if len(bulkRecorder.readers) != 0 {
+		t.Error("Expected the reader to be deregistered")
+	}
+}
@@ -249,3 +249,26 @@ func TestCascadeRestrict(t *testing.T) {
 		t.Error("Expected error due to restrict, got nil")
 	}
 }
+
+func TestCascadeDeleteMappedChild(t *testing.T) {
+	conn, s := setupCascadeDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	// Post lives in a table not named after it
+	if _, err := conn.Exec(ctx, `ALTER TABLE posts RENAME TO articles`); err != nil {
+		t.Fatal(err)
+	}
+	s.Models["Post"].Map("articles")
+
+	query.New(conn, "users").Insert(map[string]interface{}{"name": "Dana"}).Exec(ctx)
+	articles := query.New(conn, "articles")
+	articles.Insert(map[string]interface{}{"title": "Mapped", "user_id": 1}).Exec(ctx)
+
+	if _, err := query.NewWithSchema(conn, "users", s).Delete().Where(query.Eq("id", 1)).Cascade().Exec(ctx); err != nil {
+		t.Fatalf("Cascade delete failed: %v", err)
+	}
+	if count, _ := articles.Select().Count(ctx); count != 0 {
+		t.Errorf("Expected the mapped child rows deleted, got %d", count)
+	}
+}
@@ -0,0 +1,201 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus"
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestClientOpenMigrateAndQuery(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	migrations := filepath.Join(dir, "migrations")
+	os.Mkdir(migrations, 0755)
+	content := "-- UP\nCREATE TABLE accounts (id INTEGER PRIMARY KEY, email TEXT NOT NULL);\n\n-- DOWN\nDROP TABLE accounts;\n"
+	if err := os.WriteFile(filepath.Join(migrations, "20240101_100000_accounts.sql"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sch := schema.NewSchema()
+	sch.Model("Account", func(m *schema.Model) {
+		m.Int("id").PrimaryKey()
+		m.String("email")
+	}).Models["Account"].Map("accounts")
+
+	client, err := nexus.Open(ctx, nexus.Config{
+		URL:           "sqlite:" + filepath.Join(dir, "app.db"),
+		Schema:        sch,
+		MigrationsDir: migrations,
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer client.Close()
+
+	applied, err := client.Migrate.Up(ctx)
+	if err != nil || applied != 1 {
+		t.Fatalf("Expected 1 migration applied, got %d (%v)", applied, err)
+	}
+
+	// Model resolves the table of the model
+	if _, err := client.Model("Account").Insert(map[string]interface{}{"email": "ada@example.com"}).Exec(ctx); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	rollback := errors.New("rollback")
+	err = client.Tx(ctx, func(tx *dialects.Tx) error {
+		if _, err := tx.Exec(ctx, "INSERT INTO accounts (email) VALUES ('bob@example.com')"); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("Expected the error of fn, got %v", err)
+	}
+
+	rows, err := client.Model("Account").Select().Where(query.Like("email", "%@example.com")).All(ctx)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["email"] != "ada@example.com" {
+		t.Errorf("Expected only the committed account, got %v", rows)
+	}
+
+	status, err := client.Migrate.Status(ctx)
+	if err != nil || len(status) != 1 || !status[0].Applied {
+		t.Errorf("Expected the migration applied, got %+v (%v)", status, err)
+	}
+}
+
+func TestClientMigrationsFS(t *testing.T) {
+	ctx := context.Background()
+	files := fstest.MapFS{
+		"migrations/20240101_100000_accounts.sql": {Data: []byte("-- UP\nCREATE TABLE accounts (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE accounts;\n")},
+		"migrations/README.md":                    {Data: []byte("not a migration")},
+	}
+	migrations, err := fs.Sub(files, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// MigrationsFS wins over a directory that doesn't exist on disk
+	client, err := nexus.Open(ctx, nexus.Config{
+		URL:           "sqlite:" + filepath.Join(t.TempDir(), "app.db"),
+		MigrationsDir: filepath.Join(t.TempDir(), "none"),
+		MigrationsFS:  migrations,
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer client.Close()
+
+	if applied, err := client.Migrate.Up(ctx); err != nil || applied != 1 {
+		t.Fatalf("Expected 1 migration applied from the FS, got %d (%v)", applied, err)
+	}
+	if _, err := client.Conn.Exec(ctx, "INSERT INTO accounts (id) VALUES (1)"); err != nil {
+		t.Errorf("Expected the accounts table, got %v", err)
+	}
+}
+
+func TestClientInMemoryPool(t *testing.T) {
+	ctx := context.Background()
+	client, err := nexus.Open(ctx, nexus.Config{URL: "sqlite::memory:", MigrationsDir: filepath.Join(t.TempDir(), "none")})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer client.Close()
+
+	// One connection, so the tables created are seen by every query
+	if got := client.Stats().MaxOpenConnections; got != 1 {
+		t.Errorf("Expected a single connection for an in-memory database, got %d", got)
+	}
+	if n, err := client.Migrate.Up(ctx); err != nil || n != 0 {
+		t.Errorf("Expected no migrations without a directory, got %d (%v)", n, err)
+	}
+	if _, err := client.Conn.Exec(ctx, "CREATE TABLE notes (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Model("notes").Select().All(ctx); err != nil {
+		t.Errorf("Expected the table to be visible: %v", err)
+	}
+}
+
+func TestClientOpenErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := nexus.Open(ctx, nexus.Config{URL: "unknown-database"}); err == nil {
+		t.Error("Expected an error for a URL of no known dialect")
+	}
+	if _, err := nexus.Open(ctx, nexus.Config{URL: "x", Dialect: "oracle"}); err == nil {
+		t.Error("Expected an error for an unknown dialect")
+	}
+}
+
+func TestClientAutoMigrate(t *testing.T) {
+	ctx := context.Background()
+	sch := schema.NewSchema()
+	sch.Model("Author", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("name")
+	})
+
+	disabled, err := nexus.Open(ctx, nexus.Config{URL: "sqlite::memory:", Schema: sch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disabled.Close()
+	if _, err := disabled.AutoMigrate(ctx); err == nil {
+		t.Error("Expected AutoMigrate to fail unless enabled")
+	}
+
+	client, err := nexus.Open(ctx, nexus.Config{URL: "sqlite::memory:", Schema: sch, AutoMigrate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err := client.Conn.Exec(ctx, "CREATE TABLE legacy (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.AutoMigrate(ctx)
+	if err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	if len(result.Applied) != 1 || result.Applied[0].Type != migration.ChangeCreateTable {
+		t.Errorf("Expected the table to be created, got %v", migration.DescribeChanges(result.Applied))
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Type != migration.ChangeDropTable {
+		t.Errorf("Expected the drop of legacy to be skipped, got %v", migration.DescribeChanges(result.Skipped))
+	}
+
+	// A new field is added to the existing table, keeping its rows
+	if _, err := client.Model("Author").Insert(map[string]interface{}{"name": "Ada"}).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	sch.Models["Author"].String("bio").Null()
+	result, err = client.AutoMigrate(ctx)
+	if err != nil {
+		t.Fatalf("AutoMigrate failed: %v", err)
+	}
+	if got := migration.DescribeChanges(result.Applied); len(got) != 1 || got[0] != "+ ADD COLUMN Author.bio" {
+		t.Errorf("Expected the column to be added, got %v", got)
+	}
+	rows, err := client.Model("Author").Select("name", "bio").All(ctx)
+	if err != nil || len(rows) != 1 || rows[0]["name"] != "Ada" {
+		t.Errorf("Expected the row to be kept, got %v (%v)", rows, err)
+	}
+	if _, err := client.Conn.Exec(ctx, "SELECT 1 FROM legacy"); err != nil {
+		t.Errorf("Expected legacy to be kept: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+const queriesSchema = `
+model User {
+  id    Int     @id @autoincrement
+  email String  @unique
+  name  String?
+  posts Post[]
+  @@map("users")
+}
+
+model Post {
+  id       Int    @id @autoincrement
+  title    String
+  authorId Int
+  views    Int    @default(0)
+  author   User   @relation(fields: [authorId], references: [id])
+  @@map("posts")
+}
+`
+
+const queriesSQL = `-- Queries of the blog
+
+-- name: GetUserByEmail :one
+-- GetUserByEmail finds a user by email address.
+SELECT id, email, name FROM users WHERE email = :email;
+
+-- name: CreateUser :one
+INSERT INTO users (email, name) VALUES (:email, :name) RETURNING *;
+
+-- name: ListPosts :many
+SELECT p.id, p.title, u.name AS author_name
+FROM posts p LEFT JOIN users u ON u.id = p.authorId
+WHERE p.views >= :min_views AND p.authorId IN (:author_ids)
+ORDER BY p.id LIMIT :limit;
+
+-- name: SearchPosts :many
+SELECT * FROM posts
+WHERE title LIKE :pattern AND views BETWEEN :low AND :high AND authorId = :author_id;
+
+-- name: CountPosts :one
+SELECT COUNT(*) AS total, MAX(views) AS most_views FROM posts WHERE authorId = :author_id;
+
+-- name: RenameUser :execrows
+UPDATE users SET name = :name WHERE id = :id;
+
+-- name: DeletePosts :exec
+DELETE FROM posts WHERE authorId = :author_id;
+`
+
+// generatedQueriesTest runs the generated queries against SQLite.
+const generatedQueriesTest = `package gen
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func TestGeneratedQueries(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(sqlDB, sqlite.New())
+	ctx := context.Background()
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL, name TEXT)",
+		"CREATE TABLE posts (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, authorId INTEGER NOT NULL, views INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO posts (title, authorId, views) VALUES ('Go', 1, 10), ('SQL', 1, 50), ('Orphan', 9, 70)",
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	name := "Ann"
+	created, err := CreateUser(ctx, conn, "ann@example.com", &name)
+	if err != nil || created.Id != 1 || *created.Name != "Ann" {
+		t.Fatalf("CreateUser: %+v, %v", created, err)
+	}
+	if _, err := GetUserByEmail(ctx, conn, "bob@example.com"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+
+	posts, err := ListPosts(ctx, conn, 20, []int{1, 9}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[0].Title != "SQL" || *posts[0].AuthorName != "Ann" || posts[1].AuthorName != nil {
+		t.Errorf("ListPosts: %+v", posts)
+	}
+
+	found, err := SearchPosts(ctx, conn, SearchPostsParams{Pattern: "%O%", Low: 0, High: 100, AuthorId: 9})
+	if err != nil || len(found) != 1 || found[0].Views != 70 {
+		t.Errorf("SearchPosts: %+v, %v", found, err)
+	}
+
+	n, err := RenameUser(ctx, conn, nil, created.Id)
+	if err != nil || n != 1 {
+		t.Errorf("RenameUser: %d, %v", n, err)
+	}
+	user, err := GetUserByEmail(ctx, conn, "ann@example.com")
+	if err != nil || user.Name != nil {
+		t.Errorf("Expected the name cleared, got %+v, %v", user, err)
+	}
+
+	if err := DeletePosts(ctx, conn, 1); err != nil {
+		t.Fatal(err)
+	}
+	count, err := CountPosts(ctx, conn, 1)
+	if err != nil || count.Total != 0 || count.MostViews != nil {
+		t.Errorf("CountPosts: %+v, %v", count, err)
+	}
+}
+`
+
+func TestCodegen_Queries(t *testing.T) {
+	s, err := schema.NewParser(queriesSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	queries, err := codegen.ParseQueries("blog.sql", queriesSQL)
+	if err != nil {
+		t.Fatalf("ParseQueries failed: %v", err)
+	}
+	if len(queries) != 7 || queries[0].Source != "blog.sql:3" || queries[0].Kind != codegen.QueryOne {
+		t.Fatalf("Unexpected queries: %+v", queries[0])
+	}
+	if queries[0].SQL != "SELECT id, email, name FROM users WHERE email = :email" {
+		t.Errorf("Expected the SQL without the annotation and semicolon, got %q", queries[0].SQL)
+	}
+
+	dir := generatedDir(t)
+	if err := codegen.NewGenerator(s, "gen", dir).GenerateQueries(queries); err != nil {
+		t.Fatalf("GenerateQueries failed: %v", err)
+	}
+	code, _ := os.ReadFile(filepath.Join(dir, "sql_queries.go"))
+	for _, want := range []string{
+		"// GetUserByEmail finds a user by email address.\nfunc GetUserByEmail(ctx context.Context, conn *dialects.Connection, email string) (*GetUserByEmailRow, error)",
+		"func CreateUser(ctx context.Context, conn *dialects.Connection, email string, name *string) (*CreateUserRow, error)",
+		"func ListPosts(ctx context.Context, conn *dialects.Connection, minViews int, authorIds []int, limit int) ([]ListPostsRow, error)",
+		"AuthorName *string `json:\"author_name\" db:\"author_name\"`",
+		"func SearchPosts(ctx context.Context, conn *dialects.Connection, arg SearchPostsParams) ([]SearchPostsRow, error)",
+		"MostViews *int",
+		"func RenameUser(ctx context.Context, conn *dialects.Connection, name *string, id int) (int64, error)",
+		"func DeletePosts(ctx context.Context, conn *dialects.Connection, authorId int) error",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("Expected generated code to contain %q", want)
+		}
+	}
+
+	// Expressions need a name, and :one needs rows
+	for _, bad := range []struct{ sql, err string }{
+		{"-- name: Bad :one\nSELECT COUNT(*) FROM posts;", "expression has no name"},
+		{"-- name: Bad :one\nDELETE FROM posts;", "need a SELECT or RETURNING"},
+		{"-- name: Bad :many\nSELECT p.id, u.id FROM posts p JOIN users u ON u.id = p.authorId;", "column id appears twice"},
+	} {
+		queries, err := codegen.ParseQueries("bad.sql", bad.sql)
+		if err == nil {
+			err = codegen.NewGenerator(s, "gen", t.TempDir()).GenerateQueries(queries)
+		}
+		if err == nil || !strings.Contains(err.Error(), bad.err) {
+			t.Errorf("%s: expected %q, got %v", bad.sql, bad.err, err)
+		}
+	}
+	if _, err := codegen.ParseQueries("bad.sql", "-- name: getUser :one\nSELECT 1;"); err == nil {
+		t.Error("Expected an error for an unexported query name")
+	}
+
+	if testing.Short() {
+		return
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gen_test.go"), []byte(generatedQueriesTest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(goTool, "test", "./"+filepath.ToSlash(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated code failed:\n%s", out)
+	}
+}
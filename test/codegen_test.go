@@ -0,0 +1,578 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+const codegenSchema = `
+model User {
+  id    Int    @id @autoincrement
+  name  String
+  posts Post[]
+}
+
+model Post {
+  id       Int    @id @autoincrement
+  title    String
+  authorId Int
+  author   User   @relation(fields: [authorId], references: [id])
+}
+
+model Tag {
+  id   Int    @id @autoincrement
+  name String
+}
+`
+
+// generatedTest exercises the generated package against SQLite.
+const generatedTest = `package gen
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestGeneratedRelations(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(sqlDB, sqlite.New())
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		` + "`" + `CREATE TABLE "User" (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)` + "`" + `,
+		` + "`" + `CREATE TABLE "Post" (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, authorId INTEGER NOT NULL)` + "`" + `,
+		` + "`" + `CREATE TABLE "Tag" (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)` + "`" + `,
+		` + "`" + `CREATE TABLE user_tags (user_id INTEGER, tag_id INTEGER)` + "`" + `,
+		` + "`" + `INSERT INTO "User" (name) VALUES ('Alice'), ('Bob')` + "`" + `,
+		` + "`" + `INSERT INTO "Post" (title, authorId) VALUES ('First', 1), ('Second', 1)` + "`" + `,
+		` + "`" + `INSERT INTO "Tag" (name) VALUES ('go'), ('sql')` + "`" + `,
+		` + "`" + `INSERT INTO user_tags (user_id, tag_id) VALUES (1, 1), (1, 2), (2, 2)` + "`" + `,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	db := NewDB(conn)
+
+	// Eager loading fills the relation for every record
+	users, err := db.Users().IncludePosts().IncludeTags().OrderBy("id", query.Asc).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Name != "Alice" {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+	conn.Close() // Loaded relations must not query again
+
+	posts, err := users[0].Posts(ctx)
+	if err != nil || len(posts) != 2 || posts[0].Title != "First" {
+		t.Errorf("unexpected posts for Alice: %+v, %v", posts, err)
+	}
+	if posts, err := users[1].Posts(ctx); err != nil || posts == nil || len(posts) != 0 {
+		t.Errorf("expected no posts for Bob, got %+v, %v", posts, err)
+	}
+	if tags, err := users[0].Tags(ctx); err != nil || len(tags) != 2 {
+		t.Errorf("expected two tags for Alice, got %+v, %v", tags, err)
+	}
+
+	// Records not loaded through a DB cannot fetch relations
+	if _, err := (&Post{AuthorId: 1}).Author(ctx); !errors.Is(err, ErrDetached) {
+		t.Errorf("expected ErrDetached, got %v", err)
+	}
+}
+
+func TestGeneratedLazyAccessor(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(sqlDB, sqlite.New())
+	defer conn.Close()
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		` + "`" + `CREATE TABLE "User" (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)` + "`" + `,
+		` + "`" + `CREATE TABLE "Post" (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, authorId INTEGER NOT NULL)` + "`" + `,
+		` + "`" + `INSERT INTO "User" (name) VALUES ('Alice')` + "`" + `,
+		` + "`" + `INSERT INTO "Post" (title, authorId) VALUES ('First', 1)` + "`" + `,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	db := NewDB(conn)
+
+	post, err := db.Posts().Where(query.Eq("title", "First")).First(ctx)
+	if err != nil || post == nil {
+		t.Fatalf("expected a post, got %+v, %v", post, err)
+	}
+	author, err := post.Author(ctx)
+	if err != nil || author == nil || author.Name != "Alice" {
+		t.Errorf("expected Alice as author, got %+v, %v", author, err)
+	}
+}
+`
+
+func TestCodegen_RelationAccessors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	s, err := schema.NewParser(codegenSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	// Many-to-many relations are declared in Go
+	s.Models["User"].BelongsToMany("Tag", "user_tags", "user_id", "tag_id")
+
+	dir := generatedDir(t)
+	if err := codegen.NewGenerator(s, "gen", dir).Generate(); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gen_test.go"), []byte(generatedTest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	queries, _ := os.ReadFile(filepath.Join(dir, "queries.go"))
+	for _, want := range []string{"func (q *UserQuery) IncludePosts() *UserQuery", "func (m *Post) Author(ctx context.Context) (*User, error)"} {
+		if !strings.Contains(string(queries), want) {
+			t.Errorf("Expected generated code to contain %q", want)
+		}
+	}
+
+	cmd := exec.Command(goTool, "test", "./"+filepath.ToSlash(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated code failed:\n%s", out)
+	}
+}
+
+// generatedDir creates a directory for a generated package. It lives inside
+// the module so the package can import it; testdata is ignored by ./...
+// patterns.
+func generatedDir(t *testing.T) string {
+	t.Helper()
+	if err := os.MkdirAll("testdata", 0755); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := os.MkdirTemp("testdata", "gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+		os.Remove("testdata") // Only if empty
+	})
+	return dir
+}
+
+const apiSchema = `
+enum Role {
+  ADMIN
+  MEMBER
+}
+
+model BlogPost {
+  id        Int      @id @autoincrement
+  title     String   @size(20)
+  body      Text?
+  role      Role     @default(MEMBER)
+  published Bool     @default(false)
+  meta      Json?
+  contact   String?  @email
+  rating    Int?     @min(1) @max(5)
+}
+`
+
+// generatedAPITest exercises the generated handlers against SQLite.
+const generatedAPITest = `package gen
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func TestGeneratedHandlers(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(sqlDB, sqlite.New())
+	defer conn.Close()
+	if _, err := sqlDB.Exec(` + "`" + `CREATE TABLE "BlogPost" (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, body TEXT, role TEXT NOT NULL DEFAULT 'MEMBER', published BOOLEAN NOT NULL DEFAULT 0, meta TEXT, contact TEXT, rating INTEGER)` + "`" + `); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(NewDB(conn).Handler())
+	defer srv.Close()
+
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	status, post := do("POST", "/blog-posts", ` + "`" + `{"title": "Hello"}` + "`" + `)
+	if status != http.StatusCreated || post["id"] != 1.0 || post["role"] != "MEMBER" {
+		t.Fatalf("create: %d %v", status, post)
+	}
+
+	status, body := do("POST", "/blog-posts", ` + "`" + `{"title": "This title is far too long", "role": "OWNER"}` + "`" + `)
+	fields, _ := body["fields"].(map[string]interface{})
+	if status != http.StatusUnprocessableEntity || fields["title"] == nil || fields["role"] == nil {
+		t.Errorf("expected validation errors, got %d %v", status, body)
+	}
+	status, body = do("POST", "/blog-posts", ` + "`" + `{"title": "Rated", "contact": "nobody", "rating": 9}` + "`" + `)
+	fields, _ = body["fields"].(map[string]interface{})
+	if status != http.StatusUnprocessableEntity || fields["contact"] != "must be a valid email address" || fields["rating"] != "must be at most 5" {
+		t.Errorf("expected validation rule errors, got %d %v", status, body)
+	}
+	rating := 0
+	if err := (&BlogPost{Rating: &rating}).Validate(); err == nil {
+		t.Error("expected BlogPost.Validate to reject a rating of 0")
+	}
+	if status, _ := do("POST", "/blog-posts", ` + "`" + `{}` + "`" + `); status != http.StatusUnprocessableEntity {
+		t.Errorf("expected a missing title to fail, got %d", status)
+	}
+	if status, _ := do("POST", "/blog-posts", ` + "`" + `{"title": "x", "bogus": 1}` + "`" + `); status != http.StatusBadRequest {
+		t.Errorf("expected unknown fields to fail, got %d", status)
+	}
+
+	status, post = do("PATCH", "/blog-posts/1", ` + "`" + `{"published": true, "meta": {"views": 3}}` + "`" + `)
+	if status != http.StatusOK || post["published"] != true || post["title"] != "Hello" {
+		t.Errorf("update: %d %v", status, post)
+	}
+	if status, post = do("GET", "/blog-posts/1", ""); status != http.StatusOK || post["published"] != true {
+		t.Errorf("get: %d %v", status, post)
+	}
+
+	resp, err := http.Get(srv.URL + "/blog-posts?limit=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var posts []map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&posts)
+	resp.Body.Close()
+	if len(posts) != 1 {
+		t.Errorf("list: %v", posts)
+	}
+
+	if status, _ := do("DELETE", "/blog-posts/1", ""); status != http.StatusNoContent {
+		t.Errorf("delete: %d", status)
+	}
+	if status, _ := do("GET", "/blog-posts/1", ""); status != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", status)
+	}
+	if status, _ := do("GET", "/blog-posts/abc", ""); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad id, got %d", status)
+	}
+}
+`
+
+func TestCodegen_API(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	s, err := schema.NewParser(apiSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	dir := generatedDir(t)
+	gen := codegen.NewGenerator(s, "gen", dir)
+	if err := gen.Generate(); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	if err := gen.GenerateAPI(); err != nil {
+		t.Fatalf("Failed to generate API: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "openapi.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]interface{}            `json:"paths"`
+		Components struct{ Schemas map[string]json.RawMessage } `json:"components"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Invalid openapi.json: %v", err)
+	}
+	if spec.OpenAPI != "3.0.3" || spec.Paths["/blog-posts"]["post"] == nil || spec.Paths["/blog-posts/{id}"]["delete"] == nil {
+		t.Errorf("Missing paths in openapi.json: %v", spec.Paths)
+	}
+	input := string(spec.Components.Schemas["BlogPostInput"])
+	for _, want := range []string{`"required": [`, `"title"`, `"maxLength": 20`, `"MEMBER"`, `"format": "email"`, `"maximum": 5`} {
+		if !strings.Contains(input, want) {
+			t.Errorf("Expected BlogPostInput to contain %s, got %s", want, input)
+		}
+	}
+	if strings.Contains(input, `"id"`) {
+		t.Errorf("Expected the autoincrement id to be read-only, got %s", input)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "gen_test.go"), []byte(generatedAPITest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(goTool, "test", "./"+filepath.ToSlash(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated code failed:\n%s", out)
+	}
+}
+
+// generatedGraphQLTest exercises the generated GraphQL API against SQLite.
+const generatedGraphQLTest = `package gen
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/graphql"
+)
+
+func TestGeneratedGraphQL(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(sqlDB, sqlite.New())
+	defer conn.Close()
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		` + "`" + `CREATE TABLE "User" (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)` + "`" + `,
+		` + "`" + `CREATE TABLE "Post" (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, authorId INTEGER NOT NULL)` + "`" + `,
+		` + "`" + `CREATE TABLE "Tag" (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)` + "`" + `,
+		` + "`" + `CREATE TABLE user_tags (user_id INTEGER, tag_id INTEGER)` + "`" + `,
+		` + "`" + `INSERT INTO "Tag" (name) VALUES ('go')` + "`" + `,
+		` + "`" + `INSERT INTO user_tags (user_id, tag_id) VALUES (1, 1)` + "`" + `,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	s := NewDB(conn).GraphQLSchema()
+
+	run := func(query string, vars map[string]interface{}) string {
+		t.Helper()
+		resp := s.Execute(ctx, graphql.Request{Query: query, Variables: vars})
+		if len(resp.Errors) > 0 {
+			t.Fatalf("%s: %v", query, resp.Errors[0])
+		}
+		out, _ := json.Marshal(resp.Data)
+		return string(out)
+	}
+
+	got := run(` + "`" + `mutation($name: String!) { createUser(data: {name: $name}) { id name } }` + "`" + `, map[string]interface{}{"name": "Alice"})
+	if want := ` + "`" + `{"createUser":{"id":1,"name":"Alice"}}` + "`" + `; got != want {
+		t.Errorf("create: %s", got)
+	}
+	run(` + "`" + `mutation { a: createPost(data: {title: "First", authorId: 1}) { id } b: createPost(data: {title: "Second", authorId: 1}) { id } }` + "`" + `, nil)
+
+	got = run(` + "`" + `{ users { name posts { title author { name } } tags { name } } }` + "`" + `, nil)
+	want := ` + "`" + `{"users":[{"name":"Alice","posts":[{"title":"First","author":{"name":"Alice"}},{"title":"Second","author":{"name":"Alice"}}],"tags":[{"name":"go"}]}]}` + "`" + `
+	if got != want {
+		t.Errorf("nested query:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = run(` + "`" + `{ posts(where: {title: "Second"}) { title } recent: posts(orderBy: [{field: id, direction: DESC}], limit: 1) { title } }` + "`" + `, nil)
+	if want := ` + "`" + `{"posts":[{"title":"Second"}],"recent":[{"title":"Second"}]}` + "`" + `; got != want {
+		t.Errorf("filters: %s", got)
+	}
+
+	got = run(` + "`" + `mutation { updatePost(id: 1, data: {title: "Renamed"}) { title } missing: updatePost(id: 9, data: {title: "x"}) { title } }` + "`" + `, nil)
+	if want := ` + "`" + `{"updatePost":{"title":"Renamed"},"missing":null}` + "`" + `; got != want {
+		t.Errorf("update: %s", got)
+	}
+
+	got = run(` + "`" + `mutation { deletePost(id: 1) again: deletePost(id: 1) }` + "`" + `, nil)
+	if want := ` + "`" + `{"deletePost":true,"again":false}` + "`" + `; got != want {
+		t.Errorf("delete: %s", got)
+	}
+	if got := run(` + "`" + `{ post(id: 1) { title } }` + "`" + `, nil); got != ` + "`" + `{"post":null}` + "`" + ` {
+		t.Errorf("expected the post to be gone, got %s", got)
+	}
+}
+`
+
+func TestCodegen_GraphQL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	s, err := schema.NewParser(codegenSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	s.Models["User"].BelongsToMany("Tag", "user_tags", "user_id", "tag_id")
+
+	dir := generatedDir(t)
+	gen := codegen.NewGenerator(s, "gen", dir)
+	if err := gen.Generate(); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	if err := gen.GenerateGraphQL(); err != nil {
+		t.Fatalf("Failed to generate GraphQL: %v", err)
+	}
+
+	sdl, err := os.ReadFile(filepath.Join(dir, "schema.graphql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"type User {\n  id: Int!\n  name: String!\n  posts: [Post!]!\n  tags: [Tag!]!\n}",
+		"users(where: UserWhere, orderBy: [UserOrderBy!], limit: Int, offset: Int): [User!]!",
+		"createPost(data: PostCreateInput!): Post!",
+		"input PostCreateInput {\n  title: String!\n  authorId: Int!\n}",
+	} {
+		if !strings.Contains(string(sdl), want) {
+			t.Errorf("Expected schema.graphql to contain %q:\n%s", want, sdl)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "gen_test.go"), []byte(generatedGraphQLTest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(goTool, "test", "./"+filepath.ToSlash(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated code failed:\n%s", out)
+	}
+}
+
+func TestScanStruct(t *testing.T) {
+	type row struct {
+		ID      int     `db:"id"`
+		Name    string  `db:"name"`
+		Score   float64 `db:"score"`
+		Active  bool    `db:"active"`
+		Note    *string `db:"note"`
+		Skipped string  `db:"-"`
+	}
+
+	var r row
+	err := query.ScanStruct(query.Result{
+		"id": int64(7), "name": []byte("Alice"), "score": "1.5", "active": int64(1), "note": nil, "extra": 1,
+	}, &r)
+	if err != nil {
+		t.Fatalf("ScanStruct failed: %v", err)
+	}
+	if r.ID != 7 || r.Name != "Alice" || r.Score != 1.5 || !r.Active || r.Note != nil {
+		t.Errorf("Unexpected scan result: %+v", r)
+	}
+
+	if err := query.ScanStruct(query.Result{"id": "x"}, &r); err == nil {
+		t.Error("Expected an error for a non-numeric id")
+	}
+
+	// Keys of different integer types match
+	a, _ := query.KeyValue(int64(3))
+	n := 3
+	b, _ := query.KeyValue(&n)
+	if a != b {
+		t.Errorf("Expected equal keys, got %v and %v", a, b)
+	}
+	if _, ok := query.KeyValue((*int)(nil)); ok {
+		t.Error("Expected nil pointers to have no key")
+	}
+}
+
+func TestResultGetters(t *testing.T) {
+	// Values as the drivers return them: []byte from MySQL, text timestamps
+	// and integer booleans from SQLite
+	r := query.Result{
+		"name": []byte("Alice"), "age": int32(30), "balance": []byte("12.50"), "active": int64(1),
+		"verified": "t", "created": "2024-05-01 10:30:00", "note": nil, "bad": "x",
+	}
+
+	if name, ok := r.GetString("name"); !ok || name != "Alice" {
+		t.Errorf("GetString = %q, %v", name, ok)
+	}
+	if age, ok := r.GetInt64("age"); !ok || age != 30 {
+		t.Errorf("GetInt64 = %d, %v", age, ok)
+	}
+	if balance, ok := r.GetFloat64("balance"); !ok || balance != 12.5 {
+		t.Errorf("GetFloat64 = %v, %v", balance, ok)
+	}
+	if active, ok := r.GetBool("active"); !ok || !active {
+		t.Errorf("GetBool(active) = %v, %v", active, ok)
+	}
+	if verified, ok := r.GetBool("verified"); !ok || !verified {
+		t.Errorf("GetBool(verified) = %v, %v", verified, ok)
+	}
+	if created, ok := r.GetTime("created"); !ok || !created.Equal(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("GetTime = %v, %v", created, ok)
+	}
+	if b, ok := r.GetBytes("name"); !ok || string(b) != "Alice" {
+		t.Errorf("GetBytes = %q, %v", b, ok)
+	}
+
+	// NULL, missing and unconvertible values report false
+	if s, ok := r.GetString("note"); ok || s != "" {
+		t.Errorf("Expected NULL to report false, got %q", s)
+	}
+	if _, ok := r.GetInt64("missing"); ok {
+		t.Error("Expected a missing column to report false")
+	}
+	if n, ok := r.GetInt64("bad"); ok || n != 0 {
+		t.Errorf("Expected a non-numeric value to report false, got %d", n)
+	}
+}
@@ -0,0 +1,255 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+const collationSchema = `
+enum Role {
+  ADMIN
+  MEMBER
+}
+
+model User {
+  id    Int    @id @autoincrement
+  email String @collate("utf8mb4_bin")
+  name  String @charset("latin1") @collate("latin1_swedish_ci")
+  bio   Text?
+  role  Role
+  age   Int
+
+  @@charset("utf8mb4")
+  @@collate("utf8mb4_unicode_ci")
+}
+`
+
+func TestCollation_Parse(t *testing.T) {
+	s, err := schema.NewParser(collationSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	user := s.Models["User"]
+	if user.CharacterSet != "utf8mb4" || user.Collation != "utf8mb4_unicode_ci" {
+		t.Errorf("Unexpected table defaults %q %q", user.CharacterSet, user.Collation)
+	}
+	if charset, collation := user.Fields["name"].ColumnCollation(); charset != "latin1" || collation != "latin1_swedish_ci" {
+		t.Errorf("Unexpected name collation %q %q", charset, collation)
+	}
+	if charset, collation := user.Fields["bio"].ColumnCollation(); charset != "utf8mb4" || collation != "utf8mb4_unicode_ci" {
+		t.Errorf("Expected bio to take the model's defaults, got %q %q", charset, collation)
+	}
+	if _, collation := user.Fields["age"].ColumnCollation(); collation != "" {
+		t.Errorf("Expected no collation on an Int, got %q", collation)
+	}
+
+	formatted := schema.Format(s)
+	for _, want := range []string{`@charset("latin1") @collate("latin1_swedish_ci")`, `@@charset("utf8mb4")`, `@@collate("utf8mb4_unicode_ci")`} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Formatting lost %s:\n%s", want, formatted)
+		}
+	}
+	if again, err := schema.NewParser(formatted).Parse(); err != nil || again.Models["User"].Fields["email"].Collation != "utf8mb4_bin" {
+		t.Errorf("Expected the formatted schema to round-trip, got %v", err)
+	}
+
+	if _, err := schema.NewParser("model A {\n  id Int @id\n  name String @collate(\"x y\")\n}\n").Parse(); err == nil ||
+		!strings.Contains(err.Error(), "@collate expects a quoted name") {
+		t.Errorf("Expected an invalid collation to be rejected, got %v", err)
+	}
+	built := schema.NewSchema().Model("A", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().Collate("C")
+	})
+	if err := built.Validate(); err == nil || !strings.Contains(err.Error(), "need a String, Text or Enum field") {
+		t.Errorf("Expected a collation on an Int to be rejected, got %v", err)
+	}
+}
+
+func TestCollation_DDL(t *testing.T) {
+	s, err := schema.NewParser(collationSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	user := s.Models["User"]
+
+	// MySQL columns take the table defaults unless they set their own
+	sql := mysql.New().CreateTableSQL(user)
+	for _, want := range []string{
+		"`email` VARCHAR(255) COLLATE utf8mb4_bin NOT NULL",
+		"`name` VARCHAR(255) CHARACTER SET latin1 COLLATE latin1_swedish_ci NOT NULL",
+		"`bio` TEXT,",
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("Expected %s in:\n%s", want, sql)
+		}
+	}
+	plain := schema.NewSchema().Model("Tag", func(m *schema.Model) { m.Int("id").PrimaryKey() })
+	if sql := mysql.New().CreateTableSQL(plain.Models["Tag"]); !strings.HasSuffix(sql, "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4") {
+		t.Errorf("Expected utf8mb4 by default, got:\n%s", sql)
+	}
+
+	// Elsewhere the model's collation goes on each text column
+	pgUser := schema.NewSchema().Model("User", func(m *schema.Model) {
+		m.Int("id").PrimaryKey()
+		m.String("email")
+		m.String("locale").Collate("en_US.utf8")
+		m.Collate("und-x-icu")
+	}).Models["User"]
+	sql = postgres.New().CreateTableSQL(pgUser)
+	if !strings.Contains(sql, `"email" VARCHAR(255) COLLATE "und-x-icu" NOT NULL`) ||
+		!strings.Contains(sql, `"locale" VARCHAR(255) COLLATE "en_US.utf8" NOT NULL`) || strings.Contains(sql, `"id" INTEGER COLLATE`) {
+		t.Errorf("Unexpected PostgreSQL table:\n%s", sql)
+	}
+	if sql := mssql.New().CreateTableSQL(pgUser); !strings.Contains(sql, "[email] NVARCHAR(255) COLLATE und-x-icu NOT NULL") {
+		t.Errorf("Unexpected SQL Server table:\n%s", sql)
+	}
+}
+
+func TestCollation_Diff(t *testing.T) {
+	s, err := schema.NewParser(collationSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	// As MySQL reports it: columns only name what differs from the table
+	db := migration.NewDatabaseSnapshot()
+	db.Tables["User"] = &migration.TableInfo{
+		Name:      "User",
+		Charset:   "utf8mb4",
+		Collation: "utf8mb4_0900_ai_ci",
+		Columns: map[string]*migration.ColumnInfo{
+			"id":    {Name: "id"},
+			"email": {Name: "email", Collation: "utf8mb4_bin"},
+			"name":  {Name: "name"},
+			"bio":   {Name: "bio"},
+			"role":  {Name: "role"},
+			"age":   {Name: "age"},
+		},
+	}
+
+	my := mysql.New()
+	changes := migration.DiffWithOptions(s, db, migration.DiffOptions{Dialect: my}).Changes
+	got := strings.Join(migration.DescribeChanges(changes), "\n")
+	want := strings.Join([]string{
+		"~ SET COLLATION User: CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci",
+		"~ SET COLLATION User.name: CHARACTER SET latin1 COLLATE latin1_swedish_ci",
+		"~ SET COLLATION User.bio: CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci",
+		"~ SET COLLATION User.role: CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci",
+	}, "\n")
+	if got != want {
+		t.Errorf("Unexpected changes:\n%s", got)
+	}
+
+	m, err := migration.GenerateMigrationFromDiff(my, changes, "collations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"ALTER TABLE `User` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci",
+		"ALTER TABLE `User` MODIFY COLUMN `bio` TEXT CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;",
+	} {
+		if !strings.Contains(m.UpSQL, want) {
+			t.Errorf("Expected %s in:\n%s", want, m.UpSQL)
+		}
+	}
+	if !strings.Contains(m.DownSQL, "MODIFY COLUMN `name` VARCHAR(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL") {
+		t.Errorf("Expected the old collation restored:\n%s", m.DownSQL)
+	}
+
+	// Without a dialect, or on one that can't change them, collations are not compared
+	if changes := migration.Diff(s, db).Changes; len(changes) != 0 {
+		t.Errorf("Expected no changes without a dialect, got %v", migration.DescribeChanges(changes))
+	}
+
+	// PostgreSQL reports a collation only where a column has its own
+	pg := schema.NewSchema().Model("User", func(m *schema.Model) {
+		m.Int("id").PrimaryKey()
+		m.String("email").Collate("und-x-icu")
+		m.String("name")
+	})
+	pgDB := migration.NewDatabaseSnapshot()
+	pgDB.Tables["User"] = &migration.TableInfo{Name: "User", Columns: map[string]*migration.ColumnInfo{
+		"id": {Name: "id"}, "email": {Name: "email"}, "name": {Name: "name", Collation: "C"},
+	}}
+	changes = migration.DiffWithOptions(pg, pgDB, migration.DiffOptions{Dialect: postgres.New()}).Changes
+	m, err = migration.GenerateMigrationFromDiff(postgres.New(), changes, "collations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.UpSQL != `ALTER TABLE "User" ALTER COLUMN "email" TYPE VARCHAR(255) COLLATE "und-x-icu";` ||
+		m.DownSQL != `ALTER TABLE "User" ALTER COLUMN "email" TYPE VARCHAR(255) COLLATE "default";` {
+		t.Errorf("Unexpected migration:\n%s\n%s", m.UpSQL, m.DownSQL)
+	}
+
+	// Schema history notices collations changed out of band
+	after := &migration.SchemaSnapshot{Tables: map[string]*migration.TableInfo{"User": {
+		Name: "User", Columns: map[string]*migration.ColumnInfo{"email": {Name: "email", Collation: "C"}},
+	}}}
+	before := &migration.SchemaSnapshot{Tables: map[string]*migration.TableInfo{"User": {
+		Name: "User", Columns: map[string]*migration.ColumnInfo{"email": {Name: "email"}},
+	}}}
+	if got := migration.DescribeChanges(migration.DiffSnapshots(before, after)); len(got) != 1 || got[0] != "~ MODIFY COLUMN User.email" {
+		t.Errorf("Unexpected snapshot changes %v", got)
+	}
+
+	pulled, _ := migration.SchemaFromSnapshot(db)
+	if user := pulled.Models["User"]; user.Collation != "utf8mb4_0900_ai_ci" || user.Fields["email"].Collation != "utf8mb4_bin" || user.Fields["bio"].Collation != "" {
+		t.Errorf("Unexpected pulled collations %+v", user)
+	}
+}
+
+func TestCollation_SnapshotSelfDiff(t *testing.T) {
+	s, err := schema.NewParser(collationSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	pg := schema.NewSchema().Model("User", func(m *schema.Model) {
+		m.Int("id").PrimaryKey()
+		m.String("email").Collate("und-x-icu")
+		m.String("name")
+		m.Collate("C")
+	})
+
+	for _, tc := range []struct {
+		schema  *schema.Schema
+		dialect dialects.Dialect
+	}{
+		{s, mysql.New()},
+		{pg, postgres.New()},
+	} {
+		offline := migration.NewSchemaSnapshot(tc.schema, tc.dialect).Database()
+		if changes := migration.DiffWithOptions(tc.schema, offline, migration.DiffOptions{Dialect: tc.dialect}).Changes; len(changes) != 0 {
+			t.Errorf("Expected a %s snapshot of the schema to match it, got %v", tc.dialect.Name(), migration.DescribeChanges(changes))
+		}
+	}
+}
+
+func TestCollation_SQLite(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	s := schema.NewSchema().Model("Account", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("email").Collate("NOCASE")
+	})
+	if _, err := conn.Exec(ctx, conn.Dialect.CreateTableSQL(s.Models["Account"])); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := query.New(conn, "Account")
+	if _, err := accounts.Insert(map[string]interface{}{"email": "ada@example.com"}).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := accounts.Select("id").Where(query.Eq("email", "ADA@Example.com")).All(ctx)
+	if err != nil || len(rows) != 1 {
+		t.Errorf("Expected the NOCASE column to match regardless of case, got %v %v", rows, err)
+	}
+}
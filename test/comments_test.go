@@ -0,0 +1,259 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/internal/studio"
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+const commentSchema = `
+model Customer {
+  id    Int    @id @autoincrement
+  email String @unique @comment("Where invoices are sent")
+  name  String @comment("Legal name, as on the contract")
+  notes Text?
+
+  @@map("customers")
+  @@comment("People and companies we bill")
+}
+`
+
+func TestComments_Parse(t *testing.T) {
+	s, err := schema.NewParser(commentSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	customer := s.Models["Customer"]
+	if customer.Description != "People and companies we bill" || customer.Fields["email"].Description != "Where invoices are sent" {
+		t.Errorf("Unexpected comments %q %q", customer.Description, customer.Fields["email"].Description)
+	}
+
+	formatted := schema.Format(s)
+	for _, want := range []string{`@unique @comment("Where invoices are sent")`, `@@comment("People and companies we bill")`} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Formatting lost %s:\n%s", want, formatted)
+		}
+	}
+	if again, err := schema.NewParser(formatted).Parse(); err != nil || again.Models["Customer"].Fields["name"].Description != "Legal name, as on the contract" {
+		t.Errorf("Expected the formatted schema to round-trip, got %v", err)
+	}
+
+	if _, err := schema.NewParser("model A {\n  id Int @id @comment(42)\n}\n").Parse(); err == nil ||
+		!strings.Contains(err.Error(), "@comment expects a quoted string") {
+		t.Errorf("Expected a comment that isn't a string to be rejected, got %v", err)
+	}
+}
+
+func TestComments_DDL(t *testing.T) {
+	customer := schema.NewSchema().Model("Customer", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("email").Comment("Where invoices are sent")
+		m.String("name").Comment("O'Brien's \\ name")
+		m.Map("customers").Comment("People we bill")
+	}).Models["Customer"]
+
+	got := migration.CommentStatements(postgres.New(), customer)
+	want := []string{
+		`COMMENT ON TABLE "customers" IS 'People we bill'`,
+		`COMMENT ON COLUMN "customers"."email" IS 'Where invoices are sent'`,
+		`COMMENT ON COLUMN "customers"."name" IS 'O''Brien''s \ name'`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected PostgreSQL comments:\n%s", strings.Join(got, "\n"))
+	}
+
+	// MySQL declares them in CREATE TABLE
+	if got := migration.CommentStatements(mysql.New(), customer); len(got) != 0 {
+		t.Errorf("Expected no MySQL comment statements, got %v", got)
+	}
+	sql := mysql.New().CreateTableSQL(customer)
+	for _, want := range []string{
+		"`email` VARCHAR(255) NOT NULL COMMENT 'Where invoices are sent'",
+		`COMMENT 'O''Brien''s \\ name'`,
+		"DEFAULT CHARSET=utf8mb4 COMMENT='People we bill'",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("Expected %s in:\n%s", want, sql)
+		}
+	}
+
+	ms := mssql.New().ColumnCommentSQL("customers", customer.Fields["email"])
+	for _, want := range []string{
+		"DECLARE @schema sysname = OBJECT_SCHEMA_NAME(OBJECT_ID(N'[customers]'))",
+		"minor_id = COLUMNPROPERTY(OBJECT_ID(N'[customers]'), N'email', 'ColumnId')",
+		"EXEC sys.sp_updateextendedproperty N'MS_Description', N'Where invoices are sent', N'SCHEMA', @schema, N'TABLE', N'customers', N'COLUMN', N'email'",
+		"ELSE EXEC sys.sp_addextendedproperty",
+	} {
+		if !strings.Contains(ms, want) {
+			t.Errorf("Expected %s in:\n%s", want, ms)
+		}
+	}
+	if drop := mssql.New().TableCommentSQL("customers", ""); !strings.Contains(drop, "EXEC sys.sp_dropextendedproperty N'MS_Description', N'SCHEMA', @schema, N'TABLE', N'customers'") {
+		t.Errorf("Expected an empty comment to drop the property:\n%s", drop)
+	}
+
+	if _, ok := dialects.Dialect(sqlite.New()).(dialects.Commenter); ok {
+		t.Error("Expected SQLite not to store comments")
+	}
+}
+
+func TestComments_Diff(t *testing.T) {
+	s, err := schema.NewParser(commentSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	db := migration.NewDatabaseSnapshot()
+	db.Tables["customers"] = &migration.TableInfo{
+		Name:    "customers",
+		Comment: "Customers",
+		Columns: map[string]*migration.ColumnInfo{
+			"id":    {Name: "id"},
+			"email": {Name: "email", Comment: "Where invoices are sent"},
+			"notes": {Name: "notes", Comment: "Free text"},
+		},
+	}
+
+	pg := postgres.New()
+	changes := migration.DiffWithOptions(s, db, migration.DiffOptions{Dialect: pg}).Changes
+	got := strings.Join(migration.DescribeChanges(changes), "\n")
+	want := strings.Join([]string{
+		"+ ADD COLUMN customers.name",
+		`~ SET COMMENT customers: "People and companies we bill"`,
+		"~ SET COMMENT customers.notes: none",
+	}, "\n")
+	if got != want {
+		t.Errorf("Unexpected changes:\n%s", got)
+	}
+
+	m, err := migration.GenerateMigrationFromDiff(pg, changes, "comments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`COMMENT ON COLUMN "customers"."name" IS 'Legal name, as on the contract'`,
+		`COMMENT ON TABLE "customers" IS 'People and companies we bill'`,
+		`COMMENT ON COLUMN "customers"."notes" IS NULL`,
+	} {
+		if !strings.Contains(m.UpSQL, want) {
+			t.Errorf("Expected %s in:\n%s", want, m.UpSQL)
+		}
+	}
+	for _, want := range []string{`COMMENT ON TABLE "customers" IS 'Customers'`, `COMMENT ON COLUMN "customers"."notes" IS 'Free text'`} {
+		if !strings.Contains(m.DownSQL, want) {
+			t.Errorf("Expected %s in:\n%s", want, m.DownSQL)
+		}
+	}
+
+	// MySQL redefines the column, keeping the rest of its definition
+	db.Tables["customers"].Columns["name"] = &migration.ColumnInfo{Name: "name"}
+	changes = migration.DiffWithOptions(s, db, migration.DiffOptions{Dialect: mysql.New()}).Changes
+	m, err = migration.GenerateMigrationFromDiff(mysql.New(), changes, "comments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(m.UpSQL, "ALTER TABLE `customers` MODIFY COLUMN `name` VARCHAR(255) NOT NULL COMMENT 'Legal name, as on the contract'") ||
+		!strings.Contains(m.UpSQL, "ALTER TABLE `customers` COMMENT = 'People and companies we bill'") {
+		t.Errorf("Unexpected MySQL migration:\n%s", m.UpSQL)
+	}
+
+	// SQLite has none to compare, and a schema snapshot matches its schema
+	if changes := migration.DiffWithOptions(s, db, migration.DiffOptions{Dialect: sqlite.New()}).Changes; len(changes) != 0 {
+		t.Errorf("Expected no changes on SQLite, got %v", migration.DescribeChanges(changes))
+	}
+	offline := migration.NewSchemaSnapshot(s, pg).Database()
+	if changes := migration.DiffWithOptions(s, offline, migration.DiffOptions{Dialect: pg}).Changes; len(changes) != 0 {
+		t.Errorf("Expected a snapshot of the schema to match it, got %v", migration.DescribeChanges(changes))
+	}
+
+	// Schema history notices comments changed out of band
+	before := migration.NewSchemaSnapshot(s, pg)
+	after := migration.NewSchemaSnapshot(s, pg)
+	after.Tables["customers"].Comment = ""
+	after.Tables["customers"].Columns["email"] = &migration.ColumnInfo{Name: "email", Type: "VARCHAR(255)", IsUnique: true, Position: 1, Comment: "Billing address"}
+	got = strings.Join(migration.DescribeChanges(migration.DiffSnapshots(before, after)), "\n")
+	if got != "~ SET COMMENT customers: none\n~ SET COMMENT customers.email: \"Billing address\"" {
+		t.Errorf("Unexpected snapshot changes:\n%s", got)
+	}
+
+	pulled, _ := migration.SchemaFromSnapshot(db)
+	if c := pulled.Models["customers"]; c.Description != "Customers" || c.Fields["notes"].Description != "Free text" {
+		t.Errorf("Unexpected pulled comments %q %q", c.Description, c.Fields["notes"].Description)
+	}
+}
+
+func TestComments_Codegen(t *testing.T) {
+	s, err := schema.NewParser(commentSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	s.Models["Customer"].Fields["notes"].Comment("Internal notes.\nNever shown to the customer.")
+
+	dir := t.TempDir()
+	if err := codegen.NewGenerator(s, "gen", dir).Generate(); err != nil {
+		t.Fatal(err)
+	}
+	models, err := os.ReadFile(filepath.Join(dir, "models.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Customer represents a row in the Customer table.\n//\n// People and companies we bill\ntype Customer struct {",
+		"\t// Where invoices are sent\n\tEmail string",
+		"\t// Internal notes.\n\t// Never shown to the customer.\n\tNotes *string",
+	} {
+		if !strings.Contains(string(models), want) {
+			t.Errorf("Expected %q in:\n%s", want, models)
+		}
+	}
+
+	ts := codegen.NewGenerator(s, "gen", dir).TypeScript()
+	if !strings.Contains(ts, "/** People and companies we bill */\nexport interface Customer {") ||
+		!strings.Contains(ts, "  /** Internal notes. Never shown to the customer. */\n  notes: string | null;") {
+		t.Errorf("Unexpected TypeScript:\n%s", ts)
+	}
+}
+
+func TestComments_Studio(t *testing.T) {
+	conn := lockConn(t)
+	s, err := schema.NewParser(commentSchema).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(context.Background(), conn.Dialect.CreateTableSQL(s.Models["Customer"])); err != nil {
+		t.Fatal(err)
+	}
+	h := studio.NewServer(studio.Config{Connection: conn, Schema: s}).Handler()
+
+	// SQLite stores no comments, so those of the schema are shown
+	code, resp := studioRequest(t, h, http.MethodGet, "/api/tables/customers", "")
+	if code != http.StatusOK || resp["comment"] != "People and companies we bill" {
+		t.Fatalf("Unexpected table %d %v", code, resp)
+	}
+	comments := map[string]interface{}{}
+	for _, col := range resp["columns"].([]interface{}) {
+		col := col.(map[string]interface{})
+		comments[col["name"].(string)] = col["comment"]
+	}
+	if comments["email"] != "Where invoices are sent" || comments["notes"] != nil {
+		t.Errorf("Unexpected column comments %v", comments)
+	}
+
+	code, resp = studioRequest(t, h, http.MethodGet, "/api/schema", "")
+	models, _ := resp["models"].([]interface{})
+	if code != http.StatusOK || len(models) != 1 || models[0].(map[string]interface{})["comment"] != "People and companies we bill" {
+		t.Errorf("Unexpected schema %d %v", code, resp)
+	}
+}
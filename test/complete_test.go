@@ -0,0 +1,67 @@
+package test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/internal/cli"
+)
+
+func TestCompletions(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("NEXUS_ENV", "")
+	t.Setenv("NEXUS_DATABASE_URL", "")
+
+	files := map[string]string{
+		"nexus.json": `{
+  "database": {"dialect": "sqlite", "url": "file:./app.db"},
+  "databases": {"replica": {"dialect": "sqlite", "url": "file:./replica.db"}},
+  "env": {"staging": {}, "production": {}}
+}`,
+		"migrations/20240101_120000_create_users.sql": "-- +migrate Up\n",
+		"migrations/20240301_090000_add_posts.sql":    "-- +migrate Up\n",
+		"migrations/README.md":                        "",
+		"seeds/dev/users.sql":                         "",
+		"seeds/test/users.sql":                        "",
+		"seeds/base.sql":                              "",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "app.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER); CREATE TABLE posts (id INTEGER); CREATE TABLE _nexus_migrations (id TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"migrations", cli.CompleteMigrations(""), []string{"20240101_120000\tcreate_users", "20240301_090000\tadd_posts"}},
+		{"migrations by prefix", cli.CompleteMigrations("202403"), []string{"20240301_090000\tadd_posts"}},
+		{"seed environments", cli.CompleteSeedEnvs(""), []string{"dev", "test"}},
+		{"tables", cli.CompleteTables(""), []string{"posts", "users"}},
+		{"environments", cli.CompleteEnvironments(""), []string{"production", "staging"}},
+		{"databases", cli.CompleteDatabases("r"), []string{"replica"}},
+	} {
+		if !reflect.DeepEqual(tc.got, tc.want) {
+			t.Errorf("Expected %s %q, got %q", tc.name, tc.want, tc.got)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func init() {
+	// A SQLite driver with a REGEXP function, as applications register one
+	sql.Register("sqlite3_regexp", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("regexp", regexp.MatchString, true)
+		},
+	})
+}
+
+func TestTupleConditions_SQL(t *testing.T) {
+	in := query.InTuples([]string{"user_id", "role"}, [][]interface{}{{1, "admin"}, {2, "member"}})
+	after := query.TupleGte([]string{"created_at", "id"}, "2024-01-01", 42)
+
+	tests := []struct {
+		dialect dialects.Dialect
+		want    string
+		args    []interface{}
+	}{
+		{postgres.New(),
+			`SELECT * FROM "memberships" WHERE ("user_id", "role") IN (($1, $2), ($3, $4)) AND ("created_at", "id") >= ($5, $6)`,
+			[]interface{}{1, "admin", 2, "member", "2024-01-01", 42}},
+		{mysql.New(),
+			"SELECT * FROM `memberships` WHERE ((`user_id` = ? AND `role` = ?) OR (`user_id` = ? AND `role` = ?))" +
+				" AND ((`created_at` > ?) OR (`created_at` = ? AND `id` >= ?))",
+			[]interface{}{1, "admin", 2, "member", "2024-01-01", "2024-01-01", 42}},
+		{mssql.New(),
+			"SELECT * FROM [memberships] WHERE (([user_id] = @p1 AND [role] = @p2) OR ([user_id] = @p3 AND [role] = @p4))" +
+				" AND (([created_at] > @p5) OR ([created_at] = @p6 AND [id] >= @p7))",
+			[]interface{}{1, "admin", 2, "member", "2024-01-01", "2024-01-01", 42}},
+	}
+	for _, tt := range tests {
+		conn := dialects.NewConnection(nil, tt.dialect)
+		sql, args := query.New(conn, "memberships").Select().Where(in, after).Build()
+		if sql != tt.want || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: got\n%s %v\nwant\n%s %v", tt.dialect.Name(), sql, args, tt.want, tt.args)
+		}
+
+		// The SQL cache binds the same arguments
+		cache := query.NewSQLCache(10)
+		for range 2 {
+			cachedSQL, cachedArgs := query.New(conn, "memberships").WithSQLCache(cache).Select().Where(in, after).Build()
+			if cachedSQL != sql || !reflect.DeepEqual(cachedArgs, args) {
+				t.Errorf("%s: cached %s %v", tt.dialect.Name(), cachedSQL, cachedArgs)
+			}
+		}
+	}
+
+	conn := dialects.NewConnection(nil, postgres.New())
+	if sql, _ := query.New(conn, "memberships").Select().Where(query.InTuples([]string{"a", "b"}, nil)).Build(); sql != `SELECT * FROM "memberships" WHERE 1 = 0` {
+		t.Errorf("Expected no rows to match nothing, got %s", sql)
+	}
+}
+
+func TestTupleConditions_SQLite(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	if _, err := conn.Exec(ctx, `CREATE TABLE events (day TEXT, seq INTEGER, name TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(ctx, `INSERT INTO events VALUES ('mon', 1, 'a'), ('mon', 2, 'b'), ('tue', 1, 'c'), ('tue', 2, 'd')`); err != nil {
+		t.Fatal(err)
+	}
+	names := func(conds ...query.Condition) []string {
+		rows, err := query.New(conn, "events").Select("name").Where(conds...).OrderBy("name", query.Asc).All(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range rows {
+			name, _ := r.GetString("name")
+			got = append(got, name)
+		}
+		return got
+	}
+
+	if got := names(query.InTuples([]string{"day", "seq"}, [][]interface{}{{"mon", 2}, {"tue", 1}})); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("InTuples matched %v", got)
+	}
+	if got := names(query.TupleGt([]string{"day", "seq"}, "mon", 1)); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+		t.Errorf("TupleGt matched %v", got)
+	}
+	if got := names(query.TupleLte([]string{"day", "seq"}, "tue", 1)); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("TupleLte matched %v", got)
+	}
+}
+
+func TestMatchConditions_SQL(t *testing.T) {
+	conds := []query.Condition{
+		query.Between("age", 18, 30),
+		query.ILike("name", "ada%"),
+		query.Regexp("email", "^[a-z]+@"),
+	}
+	tests := []struct {
+		dialect dialects.Dialect
+		want    string
+	}{
+		{postgres.New(), `SELECT * FROM "users" WHERE "age" BETWEEN $1 AND $2 AND "name" ILIKE $3 AND "email" ~ $4`},
+		{mysql.New(), "SELECT * FROM `users` WHERE `age` BETWEEN ? AND ? AND LOWER(`name`) LIKE LOWER(?) AND REGEXP_LIKE(`email`, ?, 'c')"},
+		{mssql.New(), "SELECT * FROM [users] WHERE [age] BETWEEN @p1 AND @p2 AND LOWER([name]) LIKE LOWER(@p3) AND REGEXP_LIKE([email], @p4)"},
+		{sqlite.New(), `SELECT * FROM "users" WHERE "age" BETWEEN ? AND ? AND LOWER("name") LIKE LOWER(?) AND "email" REGEXP ?`},
+	}
+	wantArgs := []interface{}{18, 30, "ada%", "^[a-z]+@"}
+	for _, tt := range tests {
+		conn := dialects.NewConnection(nil, tt.dialect)
+		sql, args := query.New(conn, "users").Select().Where(conds...).Build()
+		if sql != tt.want || !reflect.DeepEqual(args, wantArgs) {
+			t.Errorf("%s: got\n%s %v", tt.dialect.Name(), sql, args)
+		}
+
+		cache := query.NewSQLCache(10)
+		for range 2 {
+			cachedSQL, cachedArgs := query.New(conn, "users").WithSQLCache(cache).Select().Where(conds...).Build()
+			if cachedSQL != sql || !reflect.DeepEqual(cachedArgs, args) {
+				t.Errorf("%s: cached %s %v", tt.dialect.Name(), cachedSQL, cachedArgs)
+			}
+		}
+	}
+}
+
+func TestMatchConditions_SQLite(t *testing.T) {
+	ctx := context.Background()
+	setup := func(conn *dialects.Connection) {
+		if _, err := conn.Exec(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)`); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Exec(ctx, `INSERT INTO users (name, age) VALUES ('Ada', 36), ('grace', 45), ('Alan', 41)`); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conn := lockConn(t)
+	setup(conn)
+	users := query.New(conn, "users")
+	if n, err := users.Select().Where(query.Between("age", 36, 41)).Count(ctx); err != nil || n != 2 {
+		t.Errorf("Between matched %d, %v", n, err)
+	}
+	if n, err := users.Select().Where(query.ILike("name", "GRACE")).Count(ctx); err != nil || n != 1 {
+		t.Errorf("ILike matched %d, %v", n, err)
+	}
+
+	// Without a REGEXP function the query fails up front
+	_, err := users.Select().Where(query.Regexp("name", "^A")).All(ctx)
+	if !errors.Is(err, query.ErrNoRegexp) {
+		t.Errorf("Expected ErrNoRegexp, got %v", err)
+	}
+	inSubquery := query.New(conn, "users").Select("id").Where(query.Regexp("name", "^A"))
+	if _, err := users.Select().WhereIn("id", inSubquery).All(ctx); !errors.Is(err, query.ErrNoRegexp) {
+		t.Errorf("Expected ErrNoRegexp for a Regexp in a subquery, got %v", err)
+	}
+	if _, err := users.Select("age").GroupBy("age").Having(query.Regexp("age", "^3")).All(ctx); !errors.Is(err, query.ErrNoRegexp) {
+		t.Errorf("Expected ErrNoRegexp for a Regexp in HAVING, got %v", err)
+	}
+
+	// SQL Server has no regular expressions before 2025
+	mssqlUsers := query.New(dialects.NewConnection(conn.DB, mssql.New()), "users")
+	if _, err := mssqlUsers.Select().WhereExists(inSubquery).All(ctx); !errors.Is(err, query.ErrRegexpUnsupported) {
+		t.Errorf("Expected ErrRegexpUnsupported on SQL Server, got %v", err)
+	}
+
+	db, err := sql.Open("sqlite3_regexp", filepath.Join(t.TempDir(), "regexp.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withRegexp := dialects.NewConnection(db, sqlite.New())
+	defer withRegexp.Close()
+	setup(withRegexp)
+	rows, err := query.New(withRegexp, "users").Select("name").Where(query.Regexp("name", "^A")).OrderBy("name", query.Asc).All(ctx)
+	if err != nil || len(rows) != 2 || rows[0]["name"] != "Ada" {
+		t.Errorf("Regexp matched %v, %v", rows, err)
+	}
+	// Matches are case-sensitive
+	if n, err := query.New(withRegexp, "users").Select().Where(query.Regexp("name", "^a")).Count(ctx); err != nil || n != 0 {
+		t.Errorf("Expected no case-insensitive match, got %d, %v", n, err)
+	}
+}
+
+func TestHavingConditions(t *testing.T) {
+	pg := dialects.NewConnection(nil, postgres.New())
+	having := []query.Condition{query.CountGt("*", 5), query.SumGte("amount", 100), query.Expr("MAX(amount) < ? OR MIN(note) = '?'", 50)}
+	q := func(conn *dialects.Connection) *query.SelectBuilder {
+		return query.New(conn, "orders").Select("customer_id").
+			Where(query.Expr("status IN (?, ?)", "paid", "shipped")).GroupBy("customer_id").Having(having...)
+	}
+
+	sql, args := q(pg).Build()
+	want := `SELECT "customer_id" FROM "orders" WHERE (status IN ($1, $2)) GROUP BY "customer_id"` +
+		` HAVING COUNT(*) > $3 AND SUM("amount") >= $4 AND (MAX(amount) < $5 OR MIN(note) = '?')`
+	if sql != want || !reflect.DeepEqual(args, []interface{}{"paid", "shipped", 5, 100, 50}) {
+		t.Errorf("Unexpected query:\n%s %v", sql, args)
+	}
+
+	cache := query.NewSQLCache(10)
+	for range 2 {
+		cachedSQL, cachedArgs := q(pg).WithSQLCache(cache).Build()
+		if cachedSQL != sql || !reflect.DeepEqual(cachedArgs, args) {
+			t.Errorf("Cached %s %v", cachedSQL, cachedArgs)
+		}
+	}
+	// Expressions with different SQL are different shapes
+	q(pg).WithSQLCache(cache).Having(query.Expr("AVG(amount) > ?", 1)).Build()
+	if stats := cache.Stats(); stats.Size != 2 {
+		t.Errorf("Expected 2 shapes, got %+v", stats)
+	}
+
+	// Comments and PostgreSQL's jsonb operators hold no placeholders
+	sql, args = query.New(pg, "docs").Select("id").
+		Where(query.Expr("data ?| array['a'] AND data ?& array['b'] AND data ?? 'c' /* why? */ AND id = ? -- one?\n", 7)).Build()
+	want = `SELECT "id" FROM "docs" WHERE (data ?| array['a'] AND data ?& array['b'] AND data ? 'c' /* why? */ AND id = $1 -- one?` + "\n)"
+	if sql != want || !reflect.DeepEqual(args, []interface{}{7}) {
+		t.Errorf("Unexpected query:\n%s %v", sql, args)
+	}
+	if sql, _ := query.New(pg, "users").Select("id").Where(query.Expr("name = ?||'%'", "a")).Build(); !strings.Contains(sql, "name = $1||'%'") {
+		t.Errorf("Expected a placeholder before ||, got %s", sql)
+	}
+
+	for _, bad := range []func(){
+		func() { query.Aggregate("COUNT(*)); DROP TABLE orders; --", "id", ">", 1) },
+		func() { query.Aggregate("SUM", "amount", "> 0 OR 1 =", 1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected Aggregate to panic on a function or operator it doesn't know")
+				}
+			}()
+			bad()
+		}()
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Expr to panic on missing arguments")
+		}
+	}()
+	query.Expr("amount > ? AND amount < ?", 1)
+}
+
+func TestHavingConditions_SQLite(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	if _, err := conn.Exec(ctx, `CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER, amount INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(ctx, `INSERT INTO orders (customer_id, amount) VALUES (1, 50), (1, 70), (2, 30), (3, 10), (3, 20), (3, 90)`); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := query.New(conn, "orders").Select("customer_id").GroupBy("customer_id").
+		Having(query.CountGte("*", 2), query.Aggregate("max", "amount", ">", 60)).OrderBy("customer_id", query.Asc).All(ctx)
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("Expected customers 1 and 3, got %v %v", rows, err)
+	}
+	if id, _ := rows[1].GetInt64("customer_id"); id != 3 {
+		t.Errorf("Unexpected customers %v", rows)
+	}
+	n, err := query.New(conn, "orders").Select().Where(query.Expr("amount * ? > ?", 2, 100)).Count(ctx)
+	if err != nil || n != 2 {
+		t.Errorf("Expected 2 orders, got %d %v", n, err)
+	}
+}
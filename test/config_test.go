@@ -0,0 +1,151 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/internal/cli"
+)
+
+const environmentsConfig = `{
+  "database": {"dialect": "postgres", "url": "${DATABASE_URL:-postgres://localhost/dev}"},
+  "schema": {"path": "schema.nexus"},
+  "databases": {
+    "analytics": {"dialect": "postgres", "url": "${ANALYTICS_URL}"},
+    "local": {"dialect": "sqlite", "url": "file:./dev.db"}
+  },
+  "env": {
+    "production": {
+      "database": {"url": "${DATABASE_URL}"},
+      "logging": {"level": "warn"}
+    }
+  }
+}`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "nexus.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadConfig(t *testing.T, path, environment, db string) (*cli.Config, error) {
+	t.Helper()
+	cli.SelectConfig(environment, db)
+	t.Cleanup(func() { cli.SelectConfig("", "") })
+	return cli.LoadConfigFile(path)
+}
+
+func TestConfigInterpolation(t *testing.T) {
+	path := writeConfig(t, environmentsConfig)
+	t.Setenv("NEXUS_ENV", "")
+
+	t.Setenv("DATABASE_URL", "")
+	config, err := loadConfig(t, path, "", "")
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if config.Database.URL != "postgres://localhost/dev" {
+		t.Errorf("Expected the default URL, got %q", config.Database.URL)
+	}
+
+	t.Setenv("DATABASE_URL", "postgres://db.internal/app")
+	config, err = loadConfig(t, path, "", "")
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if config.Database.URL != "postgres://db.internal/app" {
+		t.Errorf("Expected the URL of $DATABASE_URL, got %q", config.Database.URL)
+	}
+}
+
+func TestConfigEnvironment(t *testing.T) {
+	path := writeConfig(t, environmentsConfig)
+	t.Setenv("DATABASE_URL", "postgres://prod/app")
+	t.Setenv("NEXUS_ENV", "production")
+
+	config, err := loadConfig(t, path, "", "")
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if config.Database.Dialect != "postgres" || config.Database.URL != "postgres://prod/app" {
+		t.Errorf("Expected the production block merged over the database, got %+v", config.Database)
+	}
+	if config.Logging.Level != "warn" || config.Schema.Path != "schema.nexus" {
+		t.Errorf("Expected the block merged over the rest of the config, got %+v", config)
+	}
+
+	if _, err := loadConfig(t, path, "staging", ""); err == nil || !strings.Contains(err.Error(), "defines production") {
+		t.Errorf("Expected an unknown environment error, got %v", err)
+	}
+}
+
+func TestConfigSelectDatabase(t *testing.T) {
+	path := writeConfig(t, environmentsConfig)
+	t.Setenv("NEXUS_ENV", "")
+	t.Setenv("ANALYTICS_URL", "")
+
+	// Unset variables only fail for the databases connected to
+	config, err := loadConfig(t, path, "", "local")
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if config.Database.Dialect != "sqlite" || config.Database.URL != "file:./dev.db" {
+		t.Errorf("Expected the local database, got %+v", config.Database)
+	}
+
+	if _, err := loadConfig(t, path, "", "missing"); err == nil || !strings.Contains(err.Error(), "defines analytics, local") {
+		t.Errorf("Expected an unknown database error, got %v", err)
+	}
+}
+
+func TestConfigDatabaseURLOverride(t *testing.T) {
+	path := writeConfig(t, environmentsConfig)
+	t.Setenv("NEXUS_ENV", "production")
+	t.Setenv("DATABASE_URL", "")
+
+	// The override stands in for the database of the config, unset
+	// variables and all, taking its dialect from the URL
+	t.Setenv("NEXUS_DATABASE_URL", "sqlite:./ci.db")
+	config, err := loadConfig(t, path, "", "")
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if config.Database.Dialect != "sqlite" || config.Database.URL != "./ci.db" {
+		t.Errorf("Expected the database of $NEXUS_DATABASE_URL, got %+v", config.Database)
+	}
+
+	// URLs that don't tell their dialect keep the configured one
+	t.Setenv("NEXUS_DATABASE_URL", "host=ci user=app dbname=app")
+	config, err = loadConfig(t, path, "", "")
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if config.Database.Dialect != "postgres" || config.Database.URL != "host=ci user=app dbname=app" {
+		t.Errorf("Expected the configured dialect with the URL, got %+v", config.Database)
+	}
+
+	// A database selected with --db wins
+	config, err = loadConfig(t, path, "", "local")
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if config.Database.URL != "file:./dev.db" {
+		t.Errorf("Expected the selected database, got %+v", config.Database)
+	}
+}
+
+func TestConfigUnsetVariable(t *testing.T) {
+	path := writeConfig(t, `{"database": {"dialect": "sqlite", "url": "file:./dev.db"}, "logging": {"level": "${NEXUS_TEST_LOG_LEVEL}"}}`)
+	t.Setenv("NEXUS_ENV", "")
+	t.Setenv("NEXUS_TEST_LOG_LEVEL", "")
+
+	_, err := loadConfig(t, path, "", "")
+	if err == nil || err.Error() != "logging.level: environment variable NEXUS_TEST_LOG_LEVEL is not set" {
+		t.Errorf("Expected an unset variable error, got %v", err)
+	}
+}
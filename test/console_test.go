@@ -0,0 +1,100 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/internal/cli"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func TestConsoleShell(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "console.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	conn := dialects.NewConnection(db, sqlite.New())
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE, age INTEGER)`,
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id) ON DELETE CASCADE)`,
+		`INSERT INTO users (email, age) VALUES ('ada@example.com', 36), ('bob@example.com', 17), ('cy@test.org', NULL)`,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sch := schema.NewSchema()
+	sch.Model("User", func(m *schema.Model) {
+		m.Int("id").PrimaryKey()
+		m.String("email")
+	}).Models["User"].Map("users")
+
+	var out bytes.Buffer
+	sh := cli.NewShell(conn, sch, &out)
+	history := filepath.Join(t.TempDir(), "history")
+	sh.LoadHistory(history)
+	script := strings.Join([]string{
+		`SELECT id, email`,
+		`FROM users WHERE id = 1;`,
+		`User.select(email).where(age >= 18, email like "%@example.com").orderBy(id desc)`,
+		`users.where(age is null).count()`,
+		`users.where(id in (1, 2)).sql()`,
+		`\d users`,
+		`\d posts`,
+		`\d`,
+		`UPDATE users SET age = 18 WHERE id = 2;`,
+		`!!`,
+		`users.where(nope ~ 1)`,
+		`\q`,
+		`SELECT 'not reached';`,
+	}, "\n")
+	if err := sh.Run(ctx, strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+
+	for _, want := range []string{
+		// Multi-line SQL, as a table
+		" id | email\n----+-----------------\n 1  | ada@example.com\n(1 row)\n",
+		// Expressions on a model, a count and the SQL
+		" email\n-----------------\n ada@example.com\n(1 row)\n",
+		" count\n-------\n 1\n(1 row)\n",
+		`SELECT * FROM "users" WHERE "id" IN (?, ?)`,
+		// Introspection
+		`Table "users"`,
+		" email  | TEXT    | not null |         | UNIQUE",
+		"    (user_id) -> users(id) ON DELETE CASCADE",
+		" posts |\n users | User\n(2 rows)\n",
+		// Statements without rows, and the last one run again
+		"OK, 1 row(s) affected\nUPDATE users SET age = 18 WHERE id = 2;\nOK, 1 row(s) affected\n",
+		`ERROR: invalid condition "nope ~ 1"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "not reached") {
+		t.Error(`Expected \q to end the session`)
+	}
+
+	// The history outlives the session
+	next := cli.NewShell(conn, sch, &out)
+	next.LoadHistory(history)
+	out.Reset()
+	if err := next.Exec(ctx, `\history`); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "   1  SELECT id, email\n      FROM users WHERE id = 1;") {
+		t.Errorf("Expected the earlier commands in the history, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,229 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+const defaultsSchema = `
+model Post {
+  id        Int      @id @autoincrement
+  token     String   @default(gen_random_uuid())
+  status    String   @default("draft")
+  title     String   @default("it's")
+  score     Float    @default(random())
+  meta      Json     @default("{}")
+  published Boolean  @default(false)
+  createdAt DateTime @default(current_timestamp())
+}
+`
+
+func TestDefaults_Parse(t *testing.T) {
+	s, err := schema.NewParser(defaultsSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	post := s.Models["Post"]
+	for name, want := range map[string]string{
+		"token": schema.DefaultExprUUID, "score": schema.DefaultExprRandom, "createdAt": schema.DefaultExprNow,
+	} {
+		if got := post.Fields[name].DefaultExpr; got != want {
+			t.Errorf("Expected %s to default to %s, got %q", name, want, got)
+		}
+	}
+
+	formatted := schema.Format(s)
+	for _, want := range []string{"@default(uuid())", "@default(random())", "@default(now())", `@default("{}")`} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Expected %s in:\n%s", want, formatted)
+		}
+	}
+	if expr, ok := schema.PortableDefaultExpr("SYSDATETIME"); !ok || expr != schema.DefaultExprNow {
+		t.Errorf("Expected SYSDATETIME to be now(), got %q", expr)
+	}
+}
+
+func TestDefaults_DDL(t *testing.T) {
+	s, err := schema.NewParser(defaultsSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	post := s.Models["Post"]
+
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"postgres", postgres.New().CreateTableSQL(post), []string{
+			`"token" VARCHAR(255) NOT NULL DEFAULT gen_random_uuid()`,
+			`"title" VARCHAR(255) NOT NULL DEFAULT 'it''s'`,
+			`DEFAULT random()`,
+			`"meta" JSONB NOT NULL DEFAULT '{}'`,
+			`DEFAULT NOW()`,
+		}},
+		{"mysql", mysql.New().CreateTableSQL(post), []string{
+			"DEFAULT (UUID())",
+			"DEFAULT (RAND())",
+			"`meta` JSON NOT NULL DEFAULT ('{}')",
+			"DEFAULT CURRENT_TIMESTAMP",
+		}},
+		{"mssql", mssql.New().CreateTableSQL(post), []string{
+			"DEFAULT NEWID()",
+			"DEFAULT N'it''s'",
+			"DEFAULT RAND()",
+			"DEFAULT SYSDATETIME()",
+		}},
+		{"sqlite", sqlite.New().CreateTableSQL(post), []string{
+			"DEFAULT (random() / 18446744073709551616.0 + 0.5)",
+			"DEFAULT 0",
+			"DEFAULT CURRENT_TIMESTAMP",
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.sql, want) {
+				t.Errorf("%s: expected %s in:\n%s", tt.name, want, tt.sql)
+			}
+		}
+		if strings.Contains(tt.sql, "DEFAULT NOW()") && tt.name != "postgres" {
+			t.Errorf("%s: NOW() was not translated:\n%s", tt.name, tt.sql)
+		}
+	}
+}
+
+func TestDefaults_Diff(t *testing.T) {
+	s, err := schema.NewParser(defaultsSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	// As PostgreSQL reports them, with the title default changed by hand
+	// and one added to id
+	pgDB := migration.NewDatabaseSnapshot()
+	pgDB.Tables["Post"] = &migration.TableInfo{Name: "Post", Columns: map[string]*migration.ColumnInfo{
+		"id":        {Name: "id", Default: `nextval('"Post_id_seq"'::regclass)`, AutoInc: true},
+		"token":     {Name: "token", Default: "gen_random_uuid()"},
+		"status":    {Name: "status", Default: "'draft'::character varying"},
+		"title":     {Name: "title", Default: "'untitled'::character varying"},
+		"score":     {Name: "score", Default: "random()"},
+		"meta":      {Name: "meta", Default: "'{}'::jsonb"},
+		"published": {Name: "published", Default: "false"},
+		"createdAt": {Name: "createdAt", Default: "CURRENT_TIMESTAMP"},
+	}}
+	pg := postgres.New()
+	changes := migration.DiffWithOptions(s, pgDB, migration.DiffOptions{Dialect: pg}).Changes
+	if got := migration.DescribeChanges(changes); len(got) != 1 || got[0] != `~ SET DEFAULT Post.title: "it's"` {
+		t.Fatalf("Unexpected changes %v", got)
+	}
+	m, err := migration.GenerateMigrationFromDiff(pg, changes, "defaults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.UpSQL != `ALTER TABLE "Post" ALTER COLUMN "title" SET DEFAULT 'it''s';` ||
+		m.DownSQL != `ALTER TABLE "Post" ALTER COLUMN "title" SET DEFAULT 'untitled';` {
+		t.Errorf("Unexpected migration:\n%s\n%s", m.UpSQL, m.DownSQL)
+	}
+
+	// As SQL Server reports them, with a default dropped from the schema
+	msDB := migration.NewDatabaseSnapshot()
+	msDB.Tables["Post"] = &migration.TableInfo{Name: "Post", Columns: map[string]*migration.ColumnInfo{
+		"id":        {Name: "id", AutoInc: true},
+		"token":     {Name: "token", Default: "(newid())"},
+		"status":    {Name: "status", Default: "(N'draft')"},
+		"title":     {Name: "title", Default: "(N'it''s')"},
+		"score":     {Name: "score", Default: "(rand())"},
+		"meta":      {Name: "meta", Default: "(N'{}')"},
+		"published": {Name: "published", Default: "((0))"},
+		"createdAt": {Name: "createdAt", Default: "(getdate())"},
+	}}
+	s.Models["Post"].Fields["status"].DefaultValue = nil
+	ms := mssql.New()
+	changes = migration.DiffWithOptions(s, msDB, migration.DiffOptions{Dialect: ms}).Changes
+	if got := migration.DescribeChanges(changes); len(got) != 1 || got[0] != "~ SET DEFAULT Post.status: none" {
+		t.Fatalf("Unexpected changes %v", got)
+	}
+	m, err = migration.GenerateMigrationFromDiff(ms, changes, "defaults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(m.UpSQL, "ADD DEFAULT") || !strings.Contains(m.DownSQL, "ALTER TABLE [Post] ADD DEFAULT N'draft' FOR [status]") {
+		t.Errorf("Unexpected migration:\n%s\n%s", m.UpSQL, m.DownSQL)
+	}
+	if n := len(migration.SplitStatements(m.DownSQL)); n != 1 {
+		t.Errorf("Expected the default change to be one batch, got %d statements", n)
+	}
+
+	// Without a dialect that can change them, defaults are not compared
+	if changes := migration.Diff(s, msDB).Changes; len(changes) != 0 {
+		t.Errorf("Expected no changes without a dialect, got %v", migration.DescribeChanges(changes))
+	}
+
+	// Nor do snapshots differ by spelling
+	before := &migration.SchemaSnapshot{Tables: map[string]*migration.TableInfo{"Post": {
+		Name: "Post", Columns: map[string]*migration.ColumnInfo{
+			"createdAt": {Name: "createdAt", Default: "now()"},
+			"status":    {Name: "status", Default: "'draft'::character varying"},
+		},
+	}}}
+	after := &migration.SchemaSnapshot{Tables: map[string]*migration.TableInfo{"Post": {
+		Name: "Post", Columns: map[string]*migration.ColumnInfo{
+			"createdAt": {Name: "createdAt", Default: "CURRENT_TIMESTAMP"},
+			"status":    {Name: "status", Default: "'draft'::text"},
+		},
+	}}}
+	if got := migration.DescribeChanges(migration.DiffSnapshots(before, after)); len(got) != 0 {
+		t.Errorf("Expected equivalent defaults to match, got %v", got)
+	}
+}
+
+func TestDefaults_SQLite(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	s, err := schema.NewParser(defaultsSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if _, err := conn.Exec(ctx, conn.Dialect.CreateTableSQL(s.Models["Post"])); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := query.New(conn, "Post")
+	if _, err := posts.Insert(map[string]interface{}{"id": 1}).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := posts.Select("token", "title", "score", "meta", "createdAt").All(ctx)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("Expected the inserted post, got %v %v", rows, err)
+	}
+	row := rows[0]
+	if token, _ := row["token"].(string); len(token) != 36 {
+		t.Errorf("Expected a UUID, got %v", row["token"])
+	}
+	if score, ok := row["score"].(float64); !ok || score < 0 || score >= 1 {
+		t.Errorf("Expected a random number from 0 up to 1, got %v", row["score"])
+	}
+	if row["title"] != "it's" || row["meta"] != "{}" || row["createdAt"] == nil {
+		t.Errorf("Unexpected defaults %v", row)
+	}
+
+	// The defaults are pulled back as the portable expressions
+	snapshot, err := migration.IntrospectDatabase(ctx, conn.DB, sqlite.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulled, _ := migration.SchemaFromSnapshot(snapshot)
+	post := pulled.Models["Post"]
+	if post.Fields["createdAt"].DefaultExpr != schema.DefaultExprNow || post.Fields["status"].DefaultValue != "draft" {
+		t.Errorf("Unexpected pulled defaults %+v %+v", post.Fields["createdAt"], post.Fields["status"])
+	}
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/internal/studio"
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+const docsSchema = `
+enum Role {
+  ADMIN
+  MEMBER
+}
+
+model User {
+  id      Int      @id @autoincrement
+  email   String   @unique @comment("Login | contact address")
+  role    Role     @default(MEMBER)
+  created DateTime @default(now())
+  posts   Post[]
+
+  @@map("users")
+  @@comment("Everyone who can sign in")
+}
+
+model Post {
+  id        Int    @id @autoincrement
+  author_id Int
+  editor_id Int?
+  title     String
+  author    User   @relation(fields: [author_id], references: [id], onDelete: Cascade)
+
+  @@index([editor_id, title])
+}
+`
+
+func TestDocs_Markdown(t *testing.T) {
+	s, err := schema.NewParser(docsSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	first, err := migration.ParseMigrationFile("20240105_093000_create_users.sql", "-- nexus:\n--   author: ann\n--   description: Users and posts\n-- UP\nCREATE TABLE users (id INT);\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := migration.ParseMigrationFile("20240210_120000_add_role.sql", "ALTER TABLE users ADD role TEXT;\n")
+
+	dir := t.TempDir()
+	gen := codegen.NewGenerator(s, "gen", dir)
+	opts := codegen.DocsOptions{Diagram: studio.SchemaMermaid(s), Migrations: []*migration.Migration{second, first}}
+	if err := gen.GenerateDocs(opts); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := os.ReadFile(filepath.Join(dir, "schema.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Database schema\n",
+		"  - [User](#user)\n  - [Post](#post)\n",
+		"```mermaid\nerDiagram\n    users {\n        Int id PK\n",
+		"### User\n\nTable `users`\n\nEveryone who can sign in\n",
+		"| `email` | String | no |  | UK | Login \\| contact address |",
+		"| `role` | [Role](#role) | no | `MEMBER` |  |  |",
+		"| `created` | DateTime | no | `now()` |  |  |",
+		"| `id` | Int | no | `autoincrement()` | PK |  |",
+		"| `author` | belongs to | [User](#user) | author_id → users.id | Cascade |",
+		"| `uq_users_email` | email | yes |",
+		"### Role\n\n- `ADMIN`\n- `MEMBER`\n",
+		"| `Post.author_id` | `users` | **not indexed** |",
+		"| `20240105_093000` | 2024-01-05 09:30 | create users | ann | Users and posts |\n| `20240210_120000` | 2024-02-10 12:00 | add role |  |  |",
+	} {
+		if !strings.Contains(string(doc), want) {
+			t.Errorf("Expected %q in:\n%s", want, doc)
+		}
+	}
+
+	// An index leading with the foreign key covers it
+	s.Models["Post"].Index("idx_posts_author", "author_id", "title")
+	doc2, err := gen.Docs(codegen.DocsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(doc2, "| `Post.author_id` | `users` | `idx_posts_author` |") {
+		t.Errorf("Expected the foreign key to be covered:\n%s", doc2)
+	}
+	if strings.Contains(doc2, "mermaid") || strings.Contains(doc2, "Migration history") {
+		t.Errorf("Expected no diagram or history without them:\n%s", doc2)
+	}
+}
+
+func TestDocs_HTML(t *testing.T) {
+	s, err := schema.NewParser(docsSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	s.Models["User"].Comment("Sign-in <b>accounts</b>")
+
+	dir := t.TempDir()
+	gen := codegen.NewGenerator(s, "gen", dir)
+	if err := gen.GenerateDocs(codegen.DocsOptions{Format: "html", Title: "Blog", Diagram: studio.SchemaMermaid(s)}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := os.ReadFile(filepath.Join(dir, "schema.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>Blog</title>",
+		`<pre class="mermaid">` + "\nerDiagram\n",
+		`<h3 id="user">User</h3>`,
+		"<p>Sign-in &lt;b&gt;accounts&lt;/b&gt;</p>",
+		`<td><a href="#role">Role</a></td>`,
+		`<span class="missing">not indexed</span>`,
+	} {
+		if !strings.Contains(string(doc), want) {
+			t.Errorf("Expected %q in:\n%s", want, doc)
+		}
+	}
+
+	if _, err := gen.Docs(codegen.DocsOptions{Format: "pdf"}); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/core/doctor"
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func findings(report *doctor.Report, check string) []doctor.Finding {
+	var found []doctor.Finding
+	for _, f := range report.Findings {
+		if f.Check == check {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+func TestDoctor(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "doctor.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	conn := dialects.NewConnection(db, sqlite.New())
+
+	for _, stmt := range []string{
+		`CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER REFERENCES customers(id))`,
+		`CREATE TABLE lines (order_id INTEGER REFERENCES orders(id), sku TEXT)`,
+		`CREATE INDEX idx_lines_order ON lines (order_id, sku)`,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Before any migration ran, they are all pending
+	writeMigrations(t, dir, map[string]string{"20240101_100000_users.sql": "users"})
+	engine := migration.NewEngine(conn)
+	engine.LoadFromDir(dir)
+	opts := doctor.DefaultOptions()
+	opts.Engine = engine
+	report := doctor.Run(ctx, conn, opts)
+	if f := findings(report, doctor.CheckMigrations); len(f) != 1 || !strings.Contains(f[0].Message, "1 pending") {
+		t.Errorf("Expected 1 pending migration, got %+v", f)
+	}
+	if f := findings(report, doctor.CheckPrimaryKeys); len(f) != 1 || f[0].Table != "lines" {
+		t.Errorf("Expected lines to lack a primary key, got %+v", f)
+	}
+	if f := findings(report, doctor.CheckForeignKeys); len(f) != 1 || f[0].Table != "orders" ||
+		!strings.Contains(f[0].Suggestion, `CREATE INDEX "idx_orders_customer_id"`) {
+		t.Errorf("Expected only orders.customer_id to lack an index, got %+v", f)
+	}
+	if report.ExitCode() != 1 || report.Stats == nil || report.Stats.Bytes == 0 {
+		t.Errorf("Expected warnings and database stats, got exit %d, stats %+v", report.ExitCode(), report.Stats)
+	}
+
+	// An applied migration edited afterwards is critical, and reported first
+	engine.Init(ctx)
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	edited := "-- UP\nCREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);\n"
+	if err := os.WriteFile(filepath.Join(dir, "20240101_100000_users.sql"), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	engine = migration.NewEngine(conn)
+	engine.LoadFromDir(dir)
+	opts.Engine = engine
+	report = doctor.Run(ctx, conn, opts)
+	if len(findings(report, doctor.CheckMigrations)) != 0 {
+		t.Errorf("Expected no pending migrations, got %+v", findings(report, doctor.CheckMigrations))
+	}
+	if f := report.Findings[0]; f.Check != doctor.CheckDrift || f.Severity != doctor.SeverityCritical {
+		t.Errorf("Expected the drift first, got %+v", report.Findings)
+	}
+	if report.ExitCode() != 2 {
+		t.Errorf("Expected exit code 2, got %d", report.ExitCode())
+	}
+
+	// A database that is down stops the checks
+	db.Close()
+	report = doctor.Run(ctx, conn, doctor.DefaultOptions())
+	if len(report.Findings) != 1 || report.Findings[0].Check != doctor.CheckConnectivity || report.ExitCode() != 2 {
+		t.Errorf("Expected only the connectivity failure, got %+v", report.Findings)
+	}
+}
+
+func TestDoctorSQLiteBloat(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "bloat.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	conn := dialects.NewConnection(db, sqlite.New())
+	if _, err := conn.Exec(ctx, `CREATE TABLE blobs (id INTEGER PRIMARY KEY, data BLOB)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if _, err := conn.Exec(ctx, `INSERT INTO blobs (data) VALUES (zeroblob(8192))`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := conn.Exec(ctx, `DELETE FROM blobs`); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := doctor.DefaultOptions()
+	opts.BloatMinBytes = 1
+	report := doctor.Run(ctx, conn, opts)
+	if f := findings(report, doctor.CheckBloat); len(f) != 1 || f[0].Suggestion != "VACUUM" {
+		t.Errorf("Expected the free pages to be reported, got %+v (stats %+v)", report.Findings, report.Stats)
+	}
+}
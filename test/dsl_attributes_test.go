@@ -0,0 +1,230 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+)
+
+const attributesSchema = `
+enum Role {
+  ADMIN
+  USER // trailing comments are fine
+}
+
+enum Status { DRAFT PUBLISHED ARCHIVED }
+
+model Account {
+  id        Int    @id @autoincrement
+  tenant_id Int
+  email     String
+  role      Role   @default(USER)
+  status    Status?
+
+  @@unique([tenant_id, email])
+  @@index([role, status], name: "account_role_status")
+  @@map("accounts")
+}
+`
+
+func TestDSLAttributes_Parse(t *testing.T) {
+	s, err := schema.NewParser(attributesSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	enums := s.GetEnums()
+	if len(enums) != 2 || enums[0].Name != "Role" || enums[1].Name != "Status" {
+		t.Fatalf("Expected enums Role, Status; got %+v", enums)
+	}
+	if strings.Join(enums[1].Values, ",") != "DRAFT,PUBLISHED,ARCHIVED" {
+		t.Errorf("Unexpected Status values: %v", enums[1].Values)
+	}
+
+	account := s.Models["Account"]
+	if account.Table() != "accounts" {
+		t.Errorf("Expected table accounts, got %s", account.Table())
+	}
+
+	role := account.Fields["role"]
+	if role == nil || role.Type != schema.FieldTypeEnum || role.Enum != s.Enums["Role"] {
+		t.Fatalf("Expected role to be a Role enum field, got %+v", role)
+	}
+	if role.DefaultValue != "USER" {
+		t.Errorf("Expected default USER, got %v", role.DefaultValue)
+	}
+	if !account.Fields["status"].Nullable {
+		t.Error("Expected status to be nullable")
+	}
+
+	if len(account.Indexes) != 2 {
+		t.Fatalf("Expected 2 indexes, got %d", len(account.Indexes))
+	}
+	if idx := account.Indexes[0]; !idx.Unique || idx.Name != "uq_accounts_tenant_id_email" {
+		t.Errorf("Unexpected unique index: %+v", idx)
+	}
+	if idx := account.Indexes[1]; idx.Unique || idx.Name != "account_role_status" {
+		t.Errorf("Unexpected index: %+v", idx)
+	}
+
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected schema to validate: %v", err)
+	}
+}
+
+func TestDSLAttributes_DDL(t *testing.T) {
+	s, err := schema.NewParser(attributesSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	account := s.Models["Account"]
+
+	pgSQL := postgres.New().CreateTableSQL(account)
+	for _, want := range []string{`CREATE TABLE IF NOT EXISTS "accounts"`, `"role" "Role" NOT NULL DEFAULT 'USER'`, `"status" "Status"`} {
+		if !strings.Contains(pgSQL, want) {
+			t.Errorf("Expected postgres DDL to contain %q:\n%s", want, pgSQL)
+		}
+	}
+	enumSQL := postgres.New().CreateEnumTypeSQL(s.Enums["Role"])
+	if !strings.Contains(enumSQL, `CREATE TYPE "Role" AS ENUM ('ADMIN', 'USER')`) {
+		t.Errorf("Unexpected enum DDL:\n%s", enumSQL)
+	}
+
+	mysqlSQL := mysql.New().CreateTableSQL(account)
+	if !strings.Contains(mysqlSQL, "ENUM('DRAFT', 'PUBLISHED', 'ARCHIVED')") {
+		t.Errorf("Expected inline MySQL enum:\n%s", mysqlSQL)
+	}
+
+	// Postgres migrations create the enum types before the table
+	diff := migration.Diff(s, migration.NewDatabaseSnapshot())
+	m, err := migration.GenerateMigrationFromDiff(postgres.New(), diff.Changes, "accounts")
+	if err != nil {
+		t.Fatalf("Failed to generate migration: %v", err)
+	}
+	roleAt := strings.Index(m.UpSQL, `CREATE TYPE "Role"`)
+	tableAt := strings.Index(m.UpSQL, `CREATE TABLE IF NOT EXISTS "accounts"`)
+	if roleAt < 0 || tableAt < 0 || roleAt > tableAt {
+		t.Errorf("Expected enum type before table:\n%s", m.UpSQL)
+	}
+}
+
+func TestDSLAttributes_SQLiteMigration(t *testing.T) {
+	db, dialect := setupDiffTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	s, err := schema.NewParser(attributesSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	diff := migration.Diff(s, migration.NewDatabaseSnapshot())
+	m, err := migration.GenerateMigrationFromDiff(dialect, diff.Changes, "accounts")
+	if err != nil {
+		t.Fatalf("Failed to generate migration: %v", err)
+	}
+	if _, err := db.Exec(m.UpSQL); err != nil {
+		t.Fatalf("Failed to apply migration: %v\n%s", err, m.UpSQL)
+	}
+
+	if _, err := db.Exec(`INSERT INTO accounts (tenant_id, email) VALUES (1, 'a@example.com')`); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	var role string
+	if err := db.QueryRow(`SELECT role FROM accounts`).Scan(&role); err != nil || role != "USER" {
+		t.Errorf("Expected default role USER, got %q (%v)", role, err)
+	}
+	if _, err := db.Exec(`INSERT INTO accounts (tenant_id, email, role) VALUES (1, 'b@example.com', 'ROOT')`); err == nil {
+		t.Error("Expected CHECK constraint to reject an unknown enum value")
+	}
+	if _, err := db.Exec(`INSERT INTO accounts (tenant_id, email) VALUES (1, 'a@example.com')`); err == nil {
+		t.Error("Expected @@unique to reject a duplicate")
+	}
+
+	// The mapped table and its indexes match the schema
+	snapshot, err := migration.IntrospectDatabase(ctx, db, dialect)
+	if err != nil {
+		t.Fatalf("Failed to introspect: %v", err)
+	}
+	if _, ok := snapshot.Tables["accounts"].Indexes["account_role_status"]; !ok {
+		t.Errorf("Expected index account_role_status, got %v", snapshot.Tables["accounts"].Indexes)
+	}
+	if diff := migration.Diff(s, snapshot); diff.HasChanges() {
+		t.Errorf("Expected no diff after migrating, got %v", migration.DescribeChanges(diff.Changes))
+	}
+}
+
+func TestDSLAttributes_FormatRoundTrip(t *testing.T) {
+	s, err := schema.NewParser(attributesSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	formatted := schema.Format(s)
+	for _, want := range []string{
+		"enum Role {\n  ADMIN\n  USER\n}",
+		"role      Role    @default(\"USER\")",
+		"@@unique([tenant_id, email])",
+		`@@index([role, status], name: "account_role_status")`,
+		`@@map("accounts")`,
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Expected formatted schema to contain %q:\n%s", want, formatted)
+		}
+	}
+
+	if _, err := schema.NewParser(formatted).Parse(); err != nil {
+		t.Errorf("Failed to reparse formatted schema: %v\n%s", err, formatted)
+	}
+}
+
+func TestDSLAttributes_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "default outside enum",
+			source: "enum Role { ADMIN USER }\nmodel A {\n  id Int @id\n  role Role @default(OWNER)\n}",
+			want:   "is not a value of enum Role",
+		},
+		{
+			name:   "index on unknown field",
+			source: "model A {\n  id Int @id\n  email String\n  @@index([emial])\n}",
+			want:   "Did you mean 'email'?",
+		},
+		{
+			name:   "unknown model attribute",
+			source: "model A {\n  id Int @id\n  @@indx([id])\n}",
+			want:   "Did you mean 'index'?",
+		},
+		{
+			name:   "duplicate enum value",
+			source: "enum Role { ADMIN ADMIN }",
+			want:   "Duplicate value 'ADMIN'",
+		},
+		{
+			name:   "unclosed enum",
+			source: "enum Role {\n  ADMIN\n",
+			want:   "missing its closing brace",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := schema.NewParser(c.source).Parse()
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), c.want) {
+				t.Errorf("Expected error to contain %q, got:\n%s", c.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+func parseErrors(t *testing.T, source string) []schema.Diagnostic {
+	t.Helper()
+	_, err := schema.NewParser(source).Parse()
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	var parseErr *schema.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a *schema.ParseError, got %T", err)
+	}
+	return schema.Diagnostics(err)
+}
+
+func TestDSLDiagnostics_Positions(t *testing.T) {
+	diags := parseErrors(t, `model User {
+  id    Int     @id
+  email Strng   @unique
+  age   Int     @sise(3)
+}
+`)
+	if len(diags) != 2 {
+		t.Fatalf("Expected 2 diagnostics, got %+v", diags)
+	}
+
+	// Spans cover the offending token exactly
+	typ := diags[0]
+	if typ.Line != 3 || typ.Column != 9 || typ.EndLine != 3 || typ.EndColumn != 14 {
+		t.Errorf("Unexpected span for unknown type: %+v", typ)
+	}
+	if typ.Code != "SCHEMA_UNKNOWN_TYPE" || typ.Suggestion != "Did you mean 'String'?" {
+		t.Errorf("Unexpected unknown type diagnostic: %+v", typ)
+	}
+
+	mod := diags[1]
+	if mod.Line != 4 || mod.Column != 17 || mod.EndColumn != 25 {
+		t.Errorf("Unexpected span for unknown modifier: %+v", mod)
+	}
+	if mod.Suggestion != "Did you mean 'size'?" {
+		t.Errorf("Expected a suggestion for @sise, got %q", mod.Suggestion)
+	}
+}
+
+func TestDSLDiagnostics_Recovery(t *testing.T) {
+	source := `model User {
+  id    Int    @id
+  name  String @default("x"
+  bio   Text   @unknown
+  "bad"
+  email String @unique
+}
+
+model Post {
+  id Int @id
+`
+	diags := parseErrors(t, source)
+
+	var lines []int
+	for _, d := range diags {
+		lines = append(lines, d.Line)
+	}
+	if len(lines) != 4 || lines[0] != 3 || lines[1] != 4 || lines[2] != 5 || lines[3] != 9 {
+		t.Fatalf("Expected errors on lines 3, 4, 5 and 9, got %+v", diags)
+	}
+	if !strings.Contains(diags[3].Message, "Model 'Post' is missing its closing brace") {
+		t.Errorf("Unexpected last diagnostic: %+v", diags[3])
+	}
+
+	// Parsing continues after a broken line; a field keeps its name and type
+	// even if one of its attributes is broken
+	f, err := schema.NewParser(source).ParseAST()
+	if err == nil {
+		t.Fatal("Expected syntax errors")
+	}
+	user, ok := f.Lookup("User").(*schema.ModelDecl)
+	if !ok {
+		t.Fatal("Expected model User in the syntax tree")
+	}
+	var names []string
+	for _, field := range user.Fields {
+		names = append(names, field.Name.Name)
+	}
+	if strings.Join(names, ",") != "id,name,bio,email" {
+		t.Errorf("Expected fields id, name, bio, email; got %v", names)
+	}
+	if f.Lookup("Post") == nil {
+		t.Error("Expected the unclosed model Post in the syntax tree")
+	}
+}
+
+func TestDSLDiagnostics_JSON(t *testing.T) {
+	_, err := schema.NewParser("model A {\n  id Int @id\n  tags String[]\n}\n").Parse()
+	out, jsonErr := schema.DiagnosticsJSON(err)
+	if jsonErr != nil {
+		t.Fatalf("Failed to encode diagnostics: %v", jsonErr)
+	}
+
+	var diags []map[string]any
+	if err := json.Unmarshal(out, &diags); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, out)
+	}
+	if len(diags) != 1 {
+		t.Fatalf("Expected 1 diagnostic, got %s", out)
+	}
+	d := diags[0]
+	if d["severity"] != "error" || d["code"] != "SCHEMA_UNKNOWN_TYPE" || d["line"] != float64(3) || d["column"] != float64(8) {
+		t.Errorf("Unexpected diagnostic: %s", out)
+	}
+
+	// Valid schemas produce an empty array, not null
+	out, _ = schema.DiagnosticsJSON(nil)
+	if strings.TrimSpace(string(out)) != "[]" {
+		t.Errorf("Expected [], got %s", out)
+	}
+}
+
+func TestDSLDiagnostics_FileLocation(t *testing.T) {
+	dir := t.TempDir()
+	writeSchemaFile(t, dir, "a.nexus", "model User {\n  id Int @id\n}\n")
+	writeSchemaFile(t, dir, "b.nexus", "\n\nmodel User {\n  id Int @id\n}\n")
+
+	_, err := schema.ParseFile(dir)
+	diags := schema.Diagnostics(err)
+	if len(diags) != 1 {
+		t.Fatalf("Expected 1 diagnostic, got %+v", diags)
+	}
+	if d := diags[0]; !strings.HasSuffix(d.File, "b.nexus") || d.Line != 3 || d.Column != 7 {
+		t.Errorf("Expected duplicate reported at b.nexus:3:7, got %+v", d)
+	}
+}
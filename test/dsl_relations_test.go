@@ -0,0 +1,266 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+func findRelationByName(m *schema.Model, name string) *schema.Relation {
+	for _, rel := range m.Relations {
+		if rel.Name == name {
+			return rel
+		}
+	}
+	return nil
+}
+
+func TestDSLRelations_ExplicitAndBackSide(t *testing.T) {
+	s, err := schema.NewParser(`
+model User {
+  id      Int      @id @autoincrement
+  email   String   @unique
+  posts   Post[]
+  profile Profile?
+}
+
+model Post {
+  id        Int  @id @autoincrement
+  writer_id Int
+  author    User @relation(fields: [writer_id], references: [id], onDelete: Cascade, onUpdate: SetNull)
+}
+
+model Profile {
+  id      Int @id @autoincrement
+  user_id Int @unique
+  user    User
+}
+`).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	post := s.Models["Post"]
+	if _, ok := post.Fields["author"]; ok {
+		t.Error("Relation fields should not become columns")
+	}
+
+	author := findRelationByName(post, "author")
+	if author == nil || author.Type != schema.RelationBelongsTo {
+		t.Fatalf("Expected BelongsTo relation 'author', got %+v", author)
+	}
+	if author.TargetModel != "User" || author.ForeignKey != "writer_id" || author.ReferenceKey != "id" {
+		t.Errorf("Unexpected author relation: %+v", author)
+	}
+	if author.OnDeleteAction != schema.Cascade || author.OnUpdateAction != schema.SetNull {
+		t.Errorf("Expected Cascade/SetNull, got %v/%v", author.OnDeleteAction, author.OnUpdateAction)
+	}
+	if post.Fields["writer_id"].References != "User" {
+		t.Error("Expected writer_id to reference User")
+	}
+
+	// The back side takes its keys and cascade rules from the owning side
+	user := s.Models["User"]
+	posts := findRelationByName(user, "posts")
+	if posts == nil || posts.Type != schema.RelationHasMany {
+		t.Fatalf("Expected HasMany relation 'posts', got %+v", posts)
+	}
+	if posts.ForeignKey != "writer_id" || posts.OnDeleteAction != schema.Cascade {
+		t.Errorf("Unexpected posts relation: %+v", posts)
+	}
+
+	// Singular relation without @relation uses the <name>_id convention
+	profile := findRelationByName(user, "profile")
+	if profile == nil || profile.Type != schema.RelationHasOne || profile.ForeignKey != "user_id" {
+		t.Errorf("Expected HasOne relation 'profile' via user_id, got %+v", profile)
+	}
+	if rel := findRelationByName(s.Models["Profile"], "user"); rel == nil || rel.Type != schema.RelationBelongsTo {
+		t.Errorf("Expected BelongsTo relation 'user', got %+v", rel)
+	}
+
+	// Exactly one HasMany from User to Post: no duplicate implicit inverse
+	if n := len(user.GetHasMany()); n != 1 {
+		t.Errorf("Expected 1 HasMany on User, got %d", n)
+	}
+}
+
+func TestDSLRelations_ImplicitInverse(t *testing.T) {
+	s, err := schema.NewParser(`
+model Team {
+  id Int @id @autoincrement
+}
+
+model Player {
+  id      Int   @id @autoincrement
+  team_id Int?
+  team    Team? @relation(fields: [team_id], references: [id], onDelete: SetNull)
+}
+`).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	// Cascades run from the parent, so the parent gets a HasMany even if undeclared
+	hasMany := s.Models["Team"].GetHasMany()
+	if len(hasMany) != 1 || hasMany[0].TargetModel != "Player" || hasMany[0].OnDeleteAction != schema.SetNull {
+		t.Fatalf("Expected implicit HasMany Player with SetNull, got %+v", hasMany)
+	}
+	if hasMany[0].Name != "" {
+		t.Errorf("Implicit relation should be unnamed, got %q", hasMany[0].Name)
+	}
+}
+
+func TestDSLRelations_NamedRelations(t *testing.T) {
+	s, err := schema.NewParser(`
+model User {
+  id       Int    @id @autoincrement
+  written  Post[] @relation("Written")
+  reviewed Post[] @relation("Reviewed")
+}
+
+model Post {
+  id          Int   @id @autoincrement
+  author_id   Int
+  reviewer_id Int?
+  author      User  @relation("Written", fields: [author_id], references: [id])
+  reviewer    User? @relation(name: "Reviewed", fields: [reviewer_id], references: [id])
+}
+`).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	user := s.Models["User"]
+	if rel := findRelationByName(user, "written"); rel == nil || rel.ForeignKey != "author_id" {
+		t.Errorf("Expected 'written' via author_id, got %+v", rel)
+	}
+	if rel := findRelationByName(user, "reviewed"); rel == nil || rel.ForeignKey != "reviewer_id" {
+		t.Errorf("Expected 'reviewed' via reviewer_id, got %+v", rel)
+	}
+}
+
+func TestDSLRelations_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name: "unknown foreign key field",
+			source: `
+model User {
+  id Int @id
+}
+model Post {
+  id        Int  @id
+  author_id Int
+  author    User @relation(fields: [autor_id], references: [id])
+}`,
+			want: "Did you mean 'author_id'?",
+		},
+		{
+			name: "unknown action",
+			source: `
+model User {
+  id Int @id
+}
+model Post {
+  id        Int  @id
+  author_id Int
+  author    User @relation(fields: [author_id], references: [id], onDelete: Destroy)
+}`,
+			want: "unknown referential action 'Destroy'",
+		},
+		{
+			name: "missing back reference",
+			source: `
+model User {
+  id    Int    @id
+  posts Post[]
+}
+model Post {
+  id Int @id
+}`,
+			want: "No foreign key on 'Post' points back to 'User'",
+		},
+		{
+			name: "ambiguous back reference",
+			source: `
+model User {
+  id    Int    @id
+  posts Post[]
+}
+model Post {
+  id          Int  @id
+  author_id   Int
+  reviewer_id Int
+  author      User @relation(fields: [author_id], references: [id])
+  reviewer    User @relation(fields: [reviewer_id], references: [id])
+}`,
+			want: "is ambiguous",
+		},
+		{
+			name: "column modifier on relation",
+			source: `
+model User {
+  id Int @id
+}
+model Post {
+  id      Int  @id
+  user_id Int
+  user    User @unique
+}`,
+			want: "not allowed on relation field 'user'",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := schema.NewParser(c.source).Parse()
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), c.want) {
+				t.Errorf("Expected error to contain %q, got:\n%s", c.want, err)
+			}
+		})
+	}
+}
+
+func TestDSLRelations_FormatRoundTrip(t *testing.T) {
+	source := `
+model User {
+  id    Int    @id @autoincrement
+  posts Post[]
+}
+
+model Post {
+  id        Int  @id @autoincrement
+  author_id Int
+  author    User @relation(fields: [author_id], references: [id], onDelete: Cascade)
+}
+`
+	s, err := schema.NewParser(source).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	formatted := schema.Format(s)
+	for _, want := range []string{
+		"posts Post[]",
+		"author    User @relation(fields: [author_id], references: [id], onDelete: Cascade)",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Expected formatted schema to contain %q:\n%s", want, formatted)
+		}
+	}
+
+	reparsed, err := schema.NewParser(formatted).Parse()
+	if err != nil {
+		t.Fatalf("Failed to reparse formatted schema: %v\n%s", err, formatted)
+	}
+	if rel := findRelationByName(reparsed.Models["User"], "posts"); rel == nil || rel.OnDeleteAction != schema.Cascade {
+		t.Errorf("Expected posts relation to survive formatting, got %+v", rel)
+	}
+}
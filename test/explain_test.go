@@ -156,6 +156,42 @@ func TestExplainWithIndex(t *testing.T) {
 	}
 }
 
+func TestExplainPlanNodes(t *testing.T) {
+	conn := setupExplainTestDB(t)
+	defer conn.Close()
+
+	ctx := context.Background()
+
+	plan, err := query.NewRawQuery(conn, "SELECT * FROM users WHERE active = ? ORDER BY created_at", 1).Explain(ctx)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if len(plan.Nodes) == 0 {
+		t.Fatalf("Expected plan nodes, got none for:\n%s", plan.Raw)
+	}
+
+	var scan *query.PlanNode
+	for _, node := range plan.Nodes {
+		if node.ScanType == "sequential_scan" {
+			scan = node
+		}
+	}
+	if scan == nil {
+		t.Fatalf("Expected a sequential scan node, got %+v", plan.Nodes)
+	}
+	if len(scan.Warnings) == 0 {
+		t.Error("Expected the full scan to carry a warning")
+	}
+
+	plan, err = query.NewRawQuery(conn, "SELECT id FROM users WHERE name = 'User 1'").Explain(ctx)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if len(plan.Nodes) != 1 || plan.Nodes[0].Index != "idx_users_name" {
+		t.Errorf("Expected one node using idx_users_name, got %+v", plan.Nodes)
+	}
+}
+
 func TestAnalyze(t *testing.T) {
 	conn := setupExplainTestDB(t)
 	defer conn.Close()
@@ -0,0 +1,239 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/factory"
+)
+
+const factorySchema = `
+enum Role {
+  ADMIN
+  MEMBER
+}
+
+model User {
+  id         Int      @id @autoincrement
+  email      String   @unique @email
+  first_name String
+  age        Int      @min(21) @max(30)
+  role       Role
+  active     Bool     @default(true)
+  posts      Post[]
+}
+
+model Post {
+  id        Int      @id @autoincrement
+  title     String   @size(12)
+  body      Text?
+  author_id Int
+  author    User     @relation(fields: [author_id], references: [id])
+  comments  Comment[]
+}
+
+model Comment {
+  id      Int    @id @autoincrement
+  message String
+  post_id Int
+  post    Post   @relation(fields: [post_id], references: [id])
+}
+`
+
+const factoryTables = `
+CREATE TABLE "User" (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE, first_name TEXT NOT NULL, age INTEGER NOT NULL, role TEXT NOT NULL, active BOOLEAN NOT NULL DEFAULT 1);
+CREATE TABLE "Post" (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, body TEXT, author_id INTEGER NOT NULL REFERENCES "User"(id));
+CREATE TABLE "Comment" (id INTEGER PRIMARY KEY AUTOINCREMENT, message TEXT NOT NULL, post_id INTEGER NOT NULL REFERENCES "Post"(id));
+`
+
+func TestFactory_Build(t *testing.T) {
+	f, err := factory.Parse(factorySchema)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	user, err := f.Build("User")
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if email, _ := user["email"].(string); !strings.HasSuffix(email, "1@example.com") {
+		t.Errorf("Expected a unique email address, got %v", user["email"])
+	}
+	if age, _ := user["age"].(int64); age < 21 || age > 30 {
+		t.Errorf("Expected age within @min and @max, got %v", user["age"])
+	}
+	if user["role"] != "ADMIN" && user["role"] != "MEMBER" {
+		t.Errorf("Expected an enum value, got %v", user["role"])
+	}
+	for _, column := range []string{"id", "active"} {
+		if _, ok := user[column]; ok {
+			t.Errorf("Expected %s to be left to the database, got %v", column, user[column])
+		}
+	}
+
+	post, _ := f.Build("Post", map[string]interface{}{"body": nil})
+	if title, _ := post["title"].(string); len(title) == 0 || len(title) > 12 {
+		t.Errorf("Expected a title within @size, got %q", title)
+	}
+	if _, ok := post["author_id"]; ok || post["body"] != nil {
+		t.Errorf("Expected no foreign key and the body override, got %v", post)
+	}
+
+	if _, err := f.Build("Post", map[string]interface{}{"titel": "x"}); err == nil || !strings.Contains(err.Error(), `no field or relation "titel"`) {
+		t.Errorf("Expected unknown overrides to fail, got %v", err)
+	}
+	if _, err := f.Build("Nope"); err == nil {
+		t.Error("Expected unknown models to fail")
+	}
+
+	// The same seed gives the same data
+	a, _ := f.Seed(7).Build("User")
+	b, _ := f.Seed(7).Build("User")
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Expected reproducible data, got %v and %v", a, b)
+	}
+}
+
+func TestFactory_CreateGraph(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(db, sqlite.New())
+	defer conn.Close()
+	ctx := context.Background()
+	if _, err := db.Exec(factoryTables); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	s, err := schema.NewParser(factorySchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	f := factory.New(s)
+
+	// A comment needs a post, which needs an author
+	comment, err := f.Create(ctx, conn, "Comment", map[string]interface{}{
+		"post": map[string]interface{}{"title": "Hello"},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	var title string
+	var authors int
+	db.QueryRow(`SELECT title FROM "Post" WHERE id = ?`, comment["post_id"]).Scan(&title)
+	db.QueryRow(`SELECT COUNT(*) FROM "User"`).Scan(&authors)
+	if title != "Hello" || authors != 1 {
+		t.Errorf("Expected the parents to be created, got title %q and %d users", title, authors)
+	}
+
+	// Children are created after their parent
+	user, err := f.Create(ctx, conn, "User", map[string]interface{}{
+		"first_name": "Ada",
+		"posts":      []map[string]interface{}{{"comments": 2}, {"title": "Second"}},
+	})
+	if err != nil {
+		t.Fatalf("Create with children failed: %v", err)
+	}
+	if user["first_name"] != "Ada" || user["active"] == nil {
+		t.Errorf("Expected the stored row with defaults, got %v", user)
+	}
+	var posts, comments int
+	db.QueryRow(`SELECT COUNT(*) FROM "Post" WHERE author_id = ?`, user["id"]).Scan(&posts)
+	db.QueryRow(`SELECT COUNT(*) FROM "Comment" c JOIN "Post" p ON p.id = c.post_id WHERE p.author_id = ?`, user["id"]).Scan(&comments)
+	if posts != 2 || comments != 2 {
+		t.Errorf("Expected 2 posts and 2 comments, got %d and %d", posts, comments)
+	}
+
+	many, err := f.CreateMany(ctx, conn, "User", 3)
+	if err != nil || len(many) != 3 {
+		t.Fatalf("CreateMany failed: %v", err)
+	}
+	if _, err := f.Create(ctx, conn, "User", map[string]interface{}{"posts": "two"}); err == nil {
+		t.Error("Expected invalid child overrides to fail")
+	}
+}
+
+// generatedFactoryTest uses the generated factories against SQLite.
+const generatedFactoryTest = `package factory
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func TestGeneratedFactories(t *testing.T) {
+	if email, _ := User()["email"].(string); !strings.Contains(email, "@example.com") {
+		t.Errorf("unexpected email %q", email)
+	}
+	if Post(map[string]interface{}{"title": "Hi"})["title"] != "Hi" {
+		t.Error("expected the override")
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(db, sqlite.New())
+	defer conn.Close()
+	if _, err := db.Exec(` + "`" + factoryTables + "`" + `); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CreateComments(context.Background(), conn, 2); err != nil {
+		t.Fatal(err)
+	}
+	var users int
+	db.QueryRow(` + "`" + `SELECT COUNT(*) FROM "User"` + "`" + `).Scan(&users)
+	if users != 2 {
+		t.Errorf("expected an author per comment, got %d", users)
+	}
+}
+`
+
+func TestCodegen_Factories(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	s, err := schema.NewParser(factorySchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	dir := generatedDir(t)
+	if err := codegen.NewGenerator(s, "gen", dir).GenerateFactories(); err != nil {
+		t.Fatalf("Failed to generate factories: %v", err)
+	}
+
+	pkg := filepath.Join(dir, "factory")
+	if err := os.WriteFile(filepath.Join(pkg, "factory_test.go"), []byte(generatedFactoryTest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(goTool, "test", "./"+filepath.ToSlash(pkg))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated code failed:\n%s", out)
+	}
+}
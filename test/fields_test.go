@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	nxerr "github.com/nexus-db/nexus/pkg/errors"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestParseFields_SparseWithInclude(t *testing.T) {
+	conn, s := setupEagerLoadingDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	query.New(conn, "users").Insert(map[string]interface{}{"name": "Alice", "email": "alice@example.com"}).Exec(ctx)
+	posts := query.New(conn, "posts")
+	posts.Insert(map[string]interface{}{"title": "First", "content": "a", "user_id": 1}).Exec(ctx)
+	posts.Insert(map[string]interface{}{"title": "Second", "content": "b", "user_id": 1}).Exec(ctx)
+
+	plan, err := query.ParseFields(s, "User", "name, posts(title)")
+	if err != nil {
+		t.Fatalf("ParseFields failed: %v", err)
+	}
+
+	if len(plan.Fields) != 1 || plan.Fields[0] != "name" {
+		t.Errorf("Expected fields [name], got %v", plan.Fields)
+	}
+	if len(plan.Includes) != 1 || plan.Includes[0].Relation.TargetModel != "Post" {
+		t.Fatalf("Expected Post include, got %+v", plan.Includes)
+	}
+
+	users := query.NewWithSchema(conn, "users", s)
+	results, err := plan.Select(users).All(ctx)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	results = plan.Project(results)
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 user, got %d", len(results))
+	}
+	user := results[0]
+	if _, ok := user["id"]; ok {
+		t.Error("Expected join column id to be projected away")
+	}
+	if user["name"] != "Alice" {
+		t.Errorf("Expected name Alice, got %v", user["name"])
+	}
+
+	userPosts, ok := user["Post"].(query.Results)
+	if !ok || len(userPosts) != 2 {
+		t.Fatalf("Expected 2 posts, got %v", user["Post"])
+	}
+	for _, p := range userPosts {
+		if len(p) != 1 || p["title"] == nil {
+			t.Errorf("Expected only title on post, got %v", p)
+		}
+	}
+}
+
+func TestParseFields_UnknownField(t *testing.T) {
+	_, s := setupEagerLoadingDB(t)
+
+	_, err := query.ParseFields(s, "User", "id,emial")
+	if err == nil {
+		t.Fatal("Expected error for unknown field")
+	}
+
+	var nerr *nxerr.NexusError
+	if !errors.As(err, &nerr) || nerr.Code != nxerr.ErrQueryInvalidFields {
+		t.Fatalf("Expected ErrQueryInvalidFields, got %v", err)
+	}
+	if nerr.Suggestion != "Did you mean 'email'?" {
+		t.Errorf("Expected suggestion for email, got %q", nerr.Suggestion)
+	}
+	if nerr.Column != 4 {
+		t.Errorf("Expected column 4, got %d", nerr.Column)
+	}
+}
+
+func TestParseFields_SyntaxErrors(t *testing.T) {
+	_, s := setupEagerLoadingDB(t)
+
+	cases := []string{
+		"",
+		"id,",
+		"posts(title",
+		"id name",
+		"posts(title,user(name))",
+	}
+	for _, expr := range cases {
+		if _, err := query.ParseFields(s, "User", expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+func TestParseFields_NamedRelationsToOneModel(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(db, sqlite.New())
+	ctx := context.Background()
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT, author_id INTEGER, reviewer_id INTEGER)`,
+		`INSERT INTO users (id, name) VALUES (1, 'Ada'), (2, 'Grace')`,
+		`INSERT INTO posts (id, title, author_id, reviewer_id) VALUES (1, 'Notes', 1, 2)`,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := namedRelationsSchema()
+
+	plan, err := query.ParseFields(s, "Post", "title,reviewer(name)")
+	if err != nil {
+		t.Fatalf("ParseFields failed: %v", err)
+	}
+	if len(plan.Includes) != 1 || plan.Includes[0].Relation.Name != "Reviewer" {
+		t.Fatalf("Expected the Reviewer relation, got %+v", plan.Includes)
+	}
+	results, err := plan.Select(query.NewWithSchema(conn, "posts", s)).All(ctx)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	results = plan.Project(results)
+	reviewer, _ := results[0]["Reviewer"].(query.Result)
+	if len(results[0]) != 2 || len(reviewer) != 1 || reviewer["name"] != "Grace" {
+		t.Errorf("Expected Grace as the reviewer under Reviewer, got %v", results[0])
+	}
+
+	// The target model alone doesn't tell the two relations apart
+	_, err = query.ParseFields(s, "Post", "title,user(name)")
+	var nerr *nxerr.NexusError
+	if !errors.As(err, &nerr) || nerr.Code != nxerr.ErrQueryInvalidFields || !strings.Contains(err.Error(), "Author, Reviewer") {
+		t.Errorf("Expected user to be ambiguous, got %v", err)
+	}
+}
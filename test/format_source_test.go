@@ -0,0 +1,93 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+func TestFormatSource_Canonical(t *testing.T) {
+	source := `// Blog schema
+import 'users.nexus'
+
+
+enum Status { DRAFT PUBLISHED // visible
+  ARCHIVED
+}
+model Post {   // posts table
+  id Int @autoincrement @id
+  title   String @size(100) @default('Untitled')    // shown in lists
+
+
+  // Author link
+  authorId Int
+  author User @relation(references:[id],fields:[authorId], onDelete:Cascade)
+  @@map("posts")
+  @@index([authorId] , name:"post_author")
+}
+`
+	want := `// Blog schema
+import "users.nexus"
+
+enum Status {
+  DRAFT
+  PUBLISHED // visible
+  ARCHIVED
+}
+
+model Post { // posts table
+  id       Int    @id @autoincrement
+  title    String @default("Untitled") @size(100) // shown in lists
+
+  // Author link
+  authorId Int
+  author   User   @relation(fields: [authorId], references: [id], onDelete: Cascade)
+
+  @@index([authorId], name: "post_author")
+  @@map("posts")
+}
+`
+	got, err := schema.FormatSource(source)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if got != want {
+		t.Errorf("Unexpected formatting:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Formatting is idempotent
+	again, err := schema.FormatSource(got)
+	if err != nil || again != got {
+		t.Errorf("Formatting formatted source changed it:\n%s", again)
+	}
+}
+
+func TestFormatSource_KeepsMeaning(t *testing.T) {
+	formatted, err := schema.FormatSource(attributesSchema)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if !strings.Contains(formatted, "USER // trailing comments are fine") {
+		t.Errorf("Expected the trailing comment to be kept:\n%s", formatted)
+	}
+
+	before, err := schema.NewParser(attributesSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	after, err := schema.NewParser(formatted).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse formatted source: %v\n%s", err, formatted)
+	}
+	if schema.Format(before) != schema.Format(after) {
+		t.Errorf("Formatting changed the schema:\n%s", formatted)
+	}
+}
+
+func TestFormatSource_SyntaxError(t *testing.T) {
+	_, err := schema.FormatSource("model User {\n  id Int @id\n")
+	if err == nil || !strings.Contains(err.Error(), "missing its closing brace") {
+		t.Errorf("Expected a syntax error, got %v", err)
+	}
+}
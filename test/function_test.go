@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func functionSchema() *schema.Schema {
+	s := schema.NewSchema().Model("Post", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("title")
+	})
+	s.Function("slugify", []schema.FunctionArg{schema.Param("title", "text")}, "text",
+		"SELECT lower(regexp_replace(title, '[^a-zA-Z0-9]+', '-', 'g'))").Pure()
+	s.Procedure("archive_posts", []schema.FunctionArg{schema.Param("before", "int")},
+		"DELETE FROM \"Post\" WHERE id < before")
+	return s
+}
+
+func TestFunction_PostgresSQL(t *testing.T) {
+	s := functionSchema()
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Expected a valid schema: %v", err)
+	}
+	d := postgres.New()
+
+	want := "CREATE OR REPLACE FUNCTION \"slugify\"(title text) RETURNS text LANGUAGE sql IMMUTABLE AS $nexus$\n" +
+		"SELECT lower(regexp_replace(title, '[^a-zA-Z0-9]+', '-', 'g'))\n$nexus$"
+	if got := d.CreateFunctionSQL(s.Functions["slugify"]); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+	if got := d.DropFunctionSQL(s.Functions["archive_posts"]); got != `DROP PROCEDURE IF EXISTS "archive_posts"(int)` {
+		t.Errorf("Unexpected drop %q", got)
+	}
+
+	// Generated migrations split into one statement per function
+	m, err := migration.GenerateMigrationFromDiff(d, migration.DiffWithOptions(s, migration.NewDatabaseSnapshot(), migration.DiffOptions{Dialect: d}).Changes, "init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements := migration.SplitStatements(m.UpSQL)
+	if len(statements) != 3 || !strings.HasPrefix(statements[2], "CREATE OR REPLACE PROCEDURE") {
+		t.Errorf("Expected table, function and procedure, got %q", statements)
+	}
+
+	bad := schema.NewSchema()
+	bad.Function("broken", nil, "", "SELECT 1")
+	if err := bad.Validate(); err == nil || !strings.Contains(err.Error(), "no return type") {
+		t.Errorf("Expected a missing return type error, got %v", err)
+	}
+}
+
+func TestFunction_Diff(t *testing.T) {
+	s := functionSchema()
+	d := postgres.New()
+	opts := migration.DiffOptions{Dialect: d}
+
+	// The database as PostgreSQL reports it matches the schema
+	current := migration.SnapshotFromSchema(s, d)
+	current.Functions["archive_posts"].Args = "before integer"
+	if diff := migration.DiffWithOptions(s, current, opts); diff.HasChanges() {
+		t.Fatalf("Expected no changes, got %v", migration.DescribeChanges(diff.Changes))
+	}
+
+	// A new body is replaced in place, a new signature is dropped first
+	current.Functions["slugify"].Body = "SELECT lower(title)"
+	current.Functions["archive_posts"].Args = "before bigint"
+	current.Functions["legacy"] = &migration.FunctionInfo{Name: "legacy", Returns: "integer", Body: "SELECT 1", Language: "sql"}
+
+	diff := migration.DiffWithOptions(s, current, opts)
+	got := strings.Join(migration.DescribeChanges(diff.Changes), "\n")
+	want := "~ REPLACE FUNCTION slugify\n~ REPLACE PROCEDURE archive_posts\n- DROP FUNCTION legacy"
+	if got != want {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	m, err := migration.GenerateMigrationFromDiff(d, diff.Changes, "functions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := migration.SplitStatements(m.UpSQL)
+	if len(up) != 4 || up[1] != `DROP PROCEDURE IF EXISTS "archive_posts"(bigint)` || up[3] != `DROP FUNCTION IF EXISTS "legacy"()` {
+		t.Errorf("Unexpected up statements %q", up)
+	}
+	if !strings.Contains(m.DownSQL, "SELECT lower(title)") || !strings.Contains(m.DownSQL, `CREATE OR REPLACE FUNCTION "legacy"() RETURNS integer`) {
+		t.Errorf("Expected the old functions in:\n%s", m.DownSQL)
+	}
+
+	// Functions survive the snapshot file
+	path := filepath.Join(t.TempDir(), ".nexus.lock")
+	if err := migration.SaveSnapshot(path, migration.NewSchemaSnapshot(s, d)); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := migration.LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := migration.DiffWithOptions(s, saved.Database(), opts); diff.HasChanges() {
+		t.Errorf("Expected no changes against the snapshot, got %v", migration.DescribeChanges(diff.Changes))
+	}
+
+	// Dialects without stored functions ignore them
+	if diff := migration.DiffWithOptions(s, migration.SnapshotFromSchema(s, sqlite.New()), migration.DiffOptions{Dialect: sqlite.New()}); diff.HasChanges() {
+		t.Errorf("Expected SQLite to skip functions, got %v", migration.DescribeChanges(diff.Changes))
+	}
+}
+
+func TestFunction_CallFunction(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+	ctx := context.Background()
+	query.New(conn, "posts").Insert(map[string]interface{}{"id": 1, "title": "Hello World"}).Exec(ctx)
+
+	row, err := query.New(conn, "posts").Select("id").
+		SelectFunc(query.CallFunction("upper", query.CallFunction("substr", query.Col("title"), 1, 5)).As("head"), query.CallFunction("lower", query.Col("posts.title"))).
+		Where(query.Eq("id", 1)).
+		One(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row["head"] != "HELLO" || row["lower"] != "hello world" {
+		t.Errorf("Unexpected row %v", row)
+	}
+
+	pg := dialects.NewConnection(nil, postgres.New())
+	sqlStr, args := query.New(pg, "posts").Select("id").
+		SelectFunc(query.CallFunction("slugify", query.Col("title"), "-").As("slug")).
+		Where(query.Eq("id", 7)).
+		Build()
+	if want := `SELECT "id", slugify("title", $1) AS "slug" FROM "posts" WHERE "id" = $2`; sqlStr != want || len(args) != 2 || args[0] != "-" {
+		t.Errorf("Expected %s, got %s %v", want, sqlStr, args)
+	}
+}
@@ -0,0 +1,164 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// sealed stands for an encrypted type: stored with a prefix, read without.
+type sealed string
+
+func init() {
+	query.RegisterConverter("example.com/crypto.Sealed", query.Converter{
+		Scan: func(src interface{}) (interface{}, error) {
+			s, ok := src.(string)
+			if !ok || !strings.HasPrefix(s, "sealed:") {
+				return nil, fmt.Errorf("not sealed: %v", src)
+			}
+			return sealed(strings.TrimPrefix(s, "sealed:")), nil
+		},
+		Value: func(v interface{}) (driver.Value, error) {
+			switch v := v.(type) {
+			case sealed:
+				return "sealed:" + string(v), nil
+			case string:
+				return "sealed:" + v, nil
+			}
+			return nil, fmt.Errorf("cannot seal %T", v)
+		},
+	})
+}
+
+const goTypeSchema = `
+model Account {
+  id     Int     @id @autoincrement
+  secret String  @gotype("example.com/crypto.Sealed")
+  note   String? @gotype("database/sql.NullString")
+}
+`
+
+func TestGoType_Parse(t *testing.T) {
+	s, err := schema.NewParser(goTypeSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if got := s.Models["Account"].Fields["secret"].GoTypeName; got != "example.com/crypto.Sealed" {
+		t.Errorf("Unexpected Go type %q", got)
+	}
+	if formatted := schema.Format(s); !strings.Contains(formatted, `String  @gotype("example.com/crypto.Sealed")`) {
+		t.Errorf("Formatting lost the Go type:\n%s", formatted)
+	}
+
+	_, err = schema.NewParser("model A {\n  id Int @id\n  price String @gotype(\"decimal Decimal\")\n}\n").Parse()
+	if err == nil || !strings.Contains(err.Error(), "@gotype expects a quoted Go type") {
+		t.Errorf("Expected an invalid Go type to be rejected, got %v", err)
+	}
+	for _, name := range []string{"uuid.UUID", "github.com/shopspring/decimal.Decimal", "gopkg.in/yaml.v3.Node", "MyEnum"} {
+		if !schema.ValidGoTypeName(name) {
+			t.Errorf("Expected %s to be a valid Go type", name)
+		}
+	}
+	if query.RegisteredConverters()[0] != "example.com/crypto.Sealed" {
+		t.Errorf("Unexpected converters %v", query.RegisteredConverters())
+	}
+}
+
+func TestGoType_Query(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	s, err := schema.NewParser(goTypeSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if _, err := conn.Exec(ctx, conn.Dialect.CreateTableSQL(s.Models["Account"])); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := query.NewWithSchema(conn, "Account", s)
+	if _, err := accounts.Insert(map[string]interface{}{"secret": sealed("s3cret")}).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := accounts.Update(map[string]interface{}{"note": "hi"}).Where(query.Eq("id", 1)).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The database holds what the converter wrote
+	raw, err := query.New(conn, "Account").Select("secret").One(ctx)
+	if err != nil || raw["secret"] != "sealed:s3cret" {
+		t.Fatalf("Expected the sealed value stored, got %v %v", raw, err)
+	}
+
+	row, err := accounts.Select().One(ctx)
+	if err != nil || row["secret"] != sealed("s3cret") {
+		t.Fatalf("Expected the converted value, got %#v %v", row, err)
+	}
+	var account struct {
+		Secret sealed         `db:"secret"`
+		Note   sql.NullString `db:"note"`
+	}
+	if err := query.ScanStruct(row, &account); err != nil || account.Secret != "s3cret" || account.Note.String != "hi" {
+		t.Errorf("Unexpected struct %+v %v", account, err)
+	}
+
+	// Builders without a schema name the Go types of columns
+	it, err := query.New(conn, "Account").WithGoTypes(map[string]string{"secret": "example.com/crypto.Sealed"}).Select("secret").Iter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	if !it.Next() || it.Result()["secret"] != sealed("s3cret") {
+		t.Errorf("Expected the iterator to convert, got %v %v", it.Result(), it.Err())
+	}
+
+	_, err = accounts.Insert(map[string]interface{}{"secret": 42}).Exec(ctx)
+	if err == nil || !strings.Contains(err.Error(), "converting column secret: cannot seal int") {
+		t.Errorf("Expected the conversion error, got %v", err)
+	}
+}
+
+func TestGoType_Codegen(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	s := schema.NewSchema().Model("Payment", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("reference").Null().GoType("database/sql.NullString")
+		m.BigInt("timeout").GoType("time.Duration")
+	})
+	dir := generatedDir(t)
+	if err := codegen.NewGenerator(s, "gen", dir).Generate(); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	models, _ := os.ReadFile(filepath.Join(dir, "models.go"))
+	queries, _ := os.ReadFile(filepath.Join(dir, "queries.go"))
+	for _, want := range []string{`"database/sql"`, "Reference *sql.NullString", "Timeout   time.Duration"} {
+		if !strings.Contains(string(models), want) {
+			t.Errorf("Expected %q in models.go:\n%s", want, models)
+		}
+	}
+	if !strings.Contains(string(queries), `"reference": "database/sql.NullString",`) {
+		t.Errorf("Expected the Go types passed to the builder:\n%s", queries)
+	}
+
+	cmd := exec.Command(goTool, "vet", "./"+filepath.ToSlash(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated code failed:\n%s", out)
+	}
+}
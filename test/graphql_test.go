@@ -0,0 +1,233 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/graphql"
+)
+
+const graphqlSDL = `
+"A blog author."
+type Author {
+  id: Int!
+  name: String!
+  role: Role!
+  posts(limit: Int = 10): [Post!]!
+}
+
+type Post {
+  title: String!
+  meta: JSON
+}
+
+enum Role {
+  ADMIN
+  MEMBER
+}
+
+input AuthorInput {
+  name: String!
+  role: Role = MEMBER
+}
+
+type Query {
+  authors(role: Role): [Author!]!
+  author(id: Int!): Author
+}
+
+type Mutation {
+  createAuthor(data: AuthorInput!): Author!
+}
+`
+
+type gqlAuthor struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type gqlPost struct {
+	Title string
+	Meta  json.RawMessage
+}
+
+// newGraphQLSchema returns a schema over fixed data and counts how often
+// the posts batch resolver runs.
+func newGraphQLSchema(t *testing.T, batches *int) *graphql.Schema {
+	t.Helper()
+	s, err := graphql.ParseSchema(graphqlSDL)
+	if err != nil {
+		t.Fatalf("Failed to parse SDL: %v", err)
+	}
+
+	authors := []*gqlAuthor{{1, "Alice", "ADMIN"}, {2, "Bob", "MEMBER"}}
+	posts := map[int][]*gqlPost{
+		1: {{Title: "First", Meta: json.RawMessage(`{"views":3}`)}, {Title: "Second"}},
+	}
+
+	s.Resolve("Query", "authors", func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+		var out []*gqlAuthor
+		for _, a := range authors {
+			if role, ok := args["role"]; !ok || role == a.Role {
+				out = append(out, a)
+			}
+		}
+		return out, nil
+	})
+	s.Resolve("Query", "author", func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+		for _, a := range authors {
+			if a.ID == args["id"] {
+				return a, nil
+			}
+		}
+		return (*gqlAuthor)(nil), nil
+	})
+	s.Batch("Author", "posts", func(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+		*batches++
+		out := make([]interface{}, len(sources))
+		for i, source := range sources {
+			list := posts[source.(*gqlAuthor).ID]
+			if limit := args["limit"].(int); len(list) > limit {
+				list = list[:limit]
+			}
+			out[i] = list
+		}
+		return out, nil
+	})
+	s.Resolve("Mutation", "createAuthor", func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+		data := args["data"].(map[string]interface{})
+		a := &gqlAuthor{ID: len(authors) + 1, Name: data["name"].(string), Role: data["role"].(string)}
+		authors = append(authors, a)
+		return a, nil
+	})
+	return s
+}
+
+func executeJSON(t *testing.T, s *graphql.Schema, req graphql.Request) string {
+	t.Helper()
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s.Execute(context.Background(), req)); err != nil {
+		t.Fatalf("Failed to encode response: %v", err)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func TestGraphQL_Execute(t *testing.T) {
+	batches := 0
+	s := newGraphQLSchema(t, &batches)
+
+	got := executeJSON(t, s, graphql.Request{Query: `
+		query Authors {
+			authors { __typename name ...Posts }
+			bob: author(id: 2) { name role posts { title } }
+			nobody: author(id: 9) { name }
+		}
+		fragment Posts on Author { posts(limit: 1) { title meta } }
+	`})
+	want := `{"data":{"authors":[` +
+		`{"__typename":"Author","name":"Alice","posts":[{"title":"First","meta":{"views":3}}]},` +
+		`{"__typename":"Author","name":"Bob","posts":[]}],` +
+		`"bob":{"name":"Bob","role":"MEMBER","posts":[]},"nobody":null}}`
+	if got != want {
+		t.Errorf("Unexpected response:\n%s\nwant:\n%s", got, want)
+	}
+	// One batch for the authors list, one for bob
+	if batches != 2 {
+		t.Errorf("Expected 2 batch calls, got %d", batches)
+	}
+}
+
+func TestGraphQL_VariablesAndMutations(t *testing.T) {
+	s := newGraphQLSchema(t, new(int))
+
+	got := executeJSON(t, s, graphql.Request{
+		Query:     `mutation Add($name: String!) { createAuthor(data: {name: $name}) { id name role } }`,
+		Variables: map[string]interface{}{"name": "Carol"},
+	})
+	if want := `{"data":{"createAuthor":{"id":3,"name":"Carol","role":"MEMBER"}}}`; got != want {
+		t.Errorf("Unexpected response:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = executeJSON(t, s, graphql.Request{
+		Query:     `query($role: Role, $withPosts: Boolean!) { authors(role: $role) { name posts @include(if: $withPosts) { title } } }`,
+		Variables: map[string]interface{}{"role": "ADMIN", "withPosts": false},
+	})
+	if want := `{"data":{"authors":[{"name":"Alice"}]}}`; got != want {
+		t.Errorf("Unexpected response:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGraphQL_Errors(t *testing.T) {
+	s := newGraphQLSchema(t, new(int))
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ authors { name }`, `Syntax Error: Expected a name, found <EOF>.`},
+		{`{ authors { age } }`, `Cannot query field \"age\" on type \"Author\".`},
+		{`{ author { name } }`, `Argument author(id:) of type Int! is required`},
+		{`{ authors(role: OWNER) { name } }`, `Enum Role has no value \"OWNER\"`},
+		{`{ authors }`, `must have a selection of subfields`},
+		{`query($id: Int!) { author(id: $id) { name } }`, `Variable $id of type Int! is required`},
+	}
+	for _, tt := range tests {
+		got := executeJSON(t, s, graphql.Request{Query: tt.query})
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %s", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestGraphQL_SchemaString(t *testing.T) {
+	s := newGraphQLSchema(t, new(int))
+	sdl := s.String()
+	for _, want := range []string{"\"A blog author.\"\ntype Author {", "posts(limit: Int = 10): [Post!]!", "role: Role = MEMBER"} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("Expected SDL to contain %q:\n%s", want, sdl)
+		}
+	}
+
+	again, err := graphql.ParseSchema(sdl)
+	if err != nil {
+		t.Fatalf("Failed to parse printed SDL: %v", err)
+	}
+	if again.String() != sdl {
+		t.Errorf("Printing parsed SDL changed it:\n%s", again.String())
+	}
+}
+
+func TestGraphQL_Handler(t *testing.T) {
+	srv := httptest.NewServer(graphql.Handler(newGraphQLSchema(t, new(int))))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"query": "query($id: Int!) { author(id: $id) { name } }", "variables": {"id": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Data struct{ Author struct{ Name string } }
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || body.Data.Author.Name != "Alice" {
+		t.Errorf("Unexpected POST response: %d %+v", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(srv.URL + "?query=" + url.QueryEscape(`mutation { createAuthor(data: {name: "X"}) { id } }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected mutations over GET to be rejected, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func TestValidateIdentifier(t *testing.T) {
+	for _, name := range []string{"users", "user posts", `odd"name`, "naïve", strings.Repeat("a", dialects.MaxIdentifierLength)} {
+		if err := dialects.ValidateIdentifier(name); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "nul\x00name", "new\nline", "\xff", strings.Repeat("a", dialects.MaxIdentifierLength+1)} {
+		if err := dialects.ValidateIdentifier(name); !errors.Is(err, dialects.ErrInvalidIdentifier) {
+			t.Errorf("Expected %q to be invalid, got %v", name, err)
+		}
+	}
+
+	set := dialects.NewIdentifierSet([]string{"users"})
+	if _, err := set.Quote(sqlite.New(), "Users"); !errors.Is(err, dialects.ErrUnknownIdentifier) {
+		t.Errorf("Expected an unknown table to be rejected, got %v", err)
+	}
+	if got, err := set.Quote(sqlite.New(), "users"); err != nil || got != `"users"` {
+		t.Errorf("Expected a quoted known table, got %q, %v", got, err)
+	}
+}
+
+// unquote reverses Quote for a dialect quoting with open and close,
+// failing if an unescaped closing quote appears inside the name.
+func unquote(t *testing.T, quoted string, open, close byte) string {
+	t.Helper()
+	if len(quoted) < 2 || quoted[0] != open || quoted[len(quoted)-1] != close {
+		t.Fatalf("%q is not quoted with %c%c", quoted, open, close)
+	}
+	inner := quoted[1 : len(quoted)-1]
+	var b strings.Builder
+	for i := 0; i < len(inner); i++ {
+		if inner[i] == close {
+			if i+1 >= len(inner) || inner[i+1] != close {
+				t.Fatalf("%q has an unescaped %c", quoted, close)
+			}
+			i++
+		}
+		b.WriteByte(inner[i])
+	}
+	return b.String()
+}
+
+func FuzzQuoteIdentifier(f *testing.F) {
+	for _, seed := range []string{"users", `a"b`, "a`b", "a]b", `"; DROP TABLE users; --`, "x\"\"y", "] OR 1=1 --", "naïve", "app.users", `"".\"000000000000000\"`} {
+		f.Add(seed)
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		f.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	f.Fuzz(func(t *testing.T, name string) {
+		for _, q := range []struct {
+			dialect     dialects.Dialect
+			open, close byte
+		}{
+			{sqlite.New(), '"', '"'},
+			{postgres.New(), '"', '"'},
+			{mysql.New(), '`', '`'},
+			{mssql.New(), '[', ']'},
+		} {
+			if got := unquote(t, q.dialect.Quote(name), q.open, q.close); got != name {
+				t.Errorf("%s: Quote(%q) unquotes to %q", q.dialect.Name(), name, got)
+			}
+		}
+
+		quoted, err := dialects.QuoteIdentifier(sqlite.New(), name)
+		if err != nil {
+			return
+		}
+		// A valid name round trips through SQLite as a single identifier
+		ctx := context.Background()
+		if _, err := db.ExecContext(ctx, "CREATE TABLE "+quoted+" (x INTEGER)"); err != nil {
+			// SQLite reserves names starting with sqlite_
+			if strings.HasPrefix(strings.ToLower(name), "sqlite_") {
+				return
+			}
+			t.Fatalf("creating table %q: %v", name, err)
+		}
+		defer db.ExecContext(ctx, "DROP TABLE "+quoted)
+		var got string
+		if err := db.QueryRowContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table'").Scan(&got); err != nil || got != name {
+			t.Errorf("Expected a table named %q, got %q, %v", name, got, err)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestJoinMutations_SQL(t *testing.T) {
+	tests := []struct {
+		dialect        dialects.Dialect
+		delete, update string
+	}{
+		{postgres.New(),
+			`DELETE FROM "sessions" USING "users" WHERE (users.id = sessions.user_id) AND "users"."banned" = $1 RETURNING "sessions".*`,
+			`UPDATE "orders" SET "status" = $1 FROM "users" WHERE (users.id = orders.user_id) AND "users"."banned" = $2`},
+		{mysql.New(),
+			"DELETE `sessions` FROM `sessions`, `users` WHERE (users.id = sessions.user_id) AND `users`.`banned` = ?",
+			"UPDATE `orders`, `users` SET `orders`.`status` = ? WHERE (users.id = orders.user_id) AND `users`.`banned` = ?"},
+		{mssql.New(),
+			"DELETE [sessions] FROM [sessions], [users] WHERE (users.id = sessions.user_id) AND [users].[banned] = @p1",
+			"UPDATE [orders] SET [status] = @p1 FROM [orders], [users] WHERE (users.id = orders.user_id) AND [users].[banned] = @p2"},
+		{sqlite.New(),
+			`DELETE FROM "sessions" WHERE rowid IN (SELECT "sessions".rowid FROM "sessions", "users" WHERE (users.id = sessions.user_id) AND "users"."banned" = ?) RETURNING "sessions".*`,
+			`UPDATE "orders" SET "status" = ? WHERE rowid IN (SELECT "orders".rowid FROM "orders", "users" WHERE (users.id = orders.user_id) AND "users"."banned" = ?)`},
+	}
+	for _, tt := range tests {
+		conn := dialects.NewConnection(nil, tt.dialect)
+		sql, args := query.New(conn, "sessions").Delete().Join("users", "users.id = sessions.user_id").
+			Where(query.Eq("users.banned", true)).Returning("*").Build()
+		if sql != tt.delete || !reflect.DeepEqual(args, []interface{}{true}) {
+			t.Errorf("%s delete:\n%s %v", tt.dialect.Name(), sql, args)
+		}
+		sql, args = query.New(conn, "orders").Update(map[string]interface{}{"status": "held"}).Join("users", "users.id = orders.user_id").
+			Where(query.Eq("users.banned", true)).Build()
+		if sql != tt.update || !reflect.DeepEqual(args, []interface{}{"held", true}) {
+			t.Errorf("%s update:\n%s %v", tt.dialect.Name(), sql, args)
+		}
+	}
+}
+
+func TestJoinMutations_SQLite(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, banned BOOLEAN)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, status TEXT)`,
+		`INSERT INTO users VALUES (1, 0), (2, 1)`,
+		`INSERT INTO orders VALUES (1, 1, 'open'), (2, 2, 'open'), (3, 2, 'open')`,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	orders := query.New(conn, "orders")
+
+	n, err := orders.Update(map[string]interface{}{"status": "held"}).Join("users", "users.id = orders.user_id").
+		Where(query.Eq("users.banned", true)).Exec(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 orders held, got %d %v", n, err)
+	}
+	if held, _ := orders.Select().Where(query.Eq("status", "held")).Count(ctx); held != 2 {
+		t.Errorf("Expected the orders of the banned user held, got %d", held)
+	}
+
+	n, err = orders.Delete().Join("users", "users.id = orders.user_id").Where(query.Eq("users.banned", false)).Exec(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 order deleted, got %d %v", n, err)
+	}
+	if left, _ := orders.Select().Count(ctx); left != 2 {
+		t.Errorf("Expected 2 orders left, got %d", left)
+	}
+}
+
+func TestJoinMutations_WithoutRowid(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, banned BOOLEAN)`,
+		`CREATE TABLE memberships (user_id INTEGER, team_id INTEGER, role TEXT, PRIMARY KEY (user_id, team_id)) WITHOUT ROWID`,
+		`INSERT INTO users VALUES (1, 0), (2, 1)`,
+		`INSERT INTO memberships VALUES (1, 1, 'member'), (2, 1, 'member'), (2, 2, 'owner')`,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := schema.NewSchema()
+	s.Model("Membership", func(m *schema.Model) {
+		m.Int("user_id").PrimaryKey()
+		m.Int("team_id").PrimaryKey()
+		m.String("role")
+		m.Map("memberships")
+	})
+
+	// Rows are matched by the primary key of the model
+	memberships := query.NewWithSchema(conn, "memberships", s)
+	update := memberships.Update(map[string]interface{}{"role": "suspended"}).Join("users", "users.id = memberships.user_id").
+		Where(query.Eq("users.banned", true))
+	if sql, _ := update.Build(); !strings.Contains(sql, `WHERE ("user_id", "team_id") IN (SELECT "memberships"."user_id", "memberships"."team_id" FROM`) {
+		t.Errorf("Expected the rows matched by primary key, got %s", sql)
+	}
+	if n, err := update.Exec(ctx); err != nil || n != 2 {
+		t.Fatalf("Expected 2 memberships suspended, got %d %v", n, err)
+	}
+	n, err := memberships.Delete().Join("users", "users.id = memberships.user_id").Where(query.Eq("users.banned", false)).Exec(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 membership deleted, got %d %v", n, err)
+	}
+
+	// Without a schema there is no key to match by
+	_, err = query.New(conn, "memberships").Delete().Join("users", "users.id = memberships.user_id").Where(query.Eq("users.banned", true)).Exec(ctx)
+	if err == nil || !strings.Contains(err.Error(), "has no rowid") {
+		t.Errorf("Expected a clear error without a schema, got %v", err)
+	}
+}
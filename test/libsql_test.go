@@ -0,0 +1,128 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func TestLibSQL_Dialect(t *testing.T) {
+	d := sqlite.NewLibSQL()
+	if d.Name() != "sqlite" || d.DriverName() != "libsql" {
+		t.Errorf("Unexpected name %q and driver %q", d.Name(), d.DriverName())
+	}
+	if sqlite.New().DriverName() != "sqlite3" {
+		t.Errorf("Expected sqlite3 driver, got %q", sqlite.New().DriverName())
+	}
+
+	s, err := schema.NewParser(`
+model User {
+  id    Int    @id @autoincrement
+  email String @unique
+}
+`).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if got, want := d.CreateTableSQL(s.Models["User"]), sqlite.New().CreateTableSQL(s.Models["User"]); got != want {
+		t.Errorf("Expected the SQLite DDL:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestLibSQL_DSN(t *testing.T) {
+	tests := []struct {
+		url, token, want string
+	}{
+		{"libsql://app-org.turso.io", "tok", "libsql://app-org.turso.io?authToken=tok"},
+		{"libsql://app-org.turso.io?authToken=mine", "tok", "libsql://app-org.turso.io?authToken=mine"},
+		{"https://app-org.turso.io", "", "https://app-org.turso.io"},
+		{"file:./local.db", "tok", "file:./local.db"},
+	}
+	for _, tt := range tests {
+		got, err := sqlite.LibSQLDSN(tt.url, tt.token)
+		if err != nil || got != tt.want {
+			t.Errorf("LibSQLDSN(%q, %q) = %q, %v; want %q", tt.url, tt.token, got, err, tt.want)
+		}
+	}
+
+	if _, err := sqlite.LibSQLDSN("postgres://localhost/app", ""); err == nil {
+		t.Error("Expected an unsupported scheme to fail")
+	}
+}
+
+// dsnConnector opens a fixed DSN, standing in for go-libsql's replica
+// connector.
+type dsnConnector struct {
+	dsn string
+	drv driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.drv.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.drv }
+
+func TestLibSQL_EmbeddedReplica(t *testing.T) {
+	opts := sqlite.LibSQLOptions{
+		URL:          "libsql://app-org.turso.io",
+		AuthToken:    "tok",
+		ReplicaPath:  filepath.Join(t.TempDir(), "replica.db"),
+		SyncInterval: time.Minute,
+	}
+
+	sqlite.RegisterReplicaConnector(nil)
+	if _, err := sqlite.OpenLibSQL(opts); err == nil || !strings.Contains(err.Error(), "RegisterReplicaConnector") {
+		t.Fatalf("Expected a missing connector error, got %v", err)
+	}
+
+	probe, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer probe.Close()
+
+	var gotURL, gotToken string
+	var gotInterval time.Duration
+	sqlite.RegisterReplicaConnector(func(path, primaryURL, authToken string, every time.Duration) (driver.Connector, error) {
+		gotURL, gotToken, gotInterval = primaryURL, authToken, every
+		return dsnConnector{dsn: path, drv: probe.Driver()}, nil
+	})
+	defer sqlite.RegisterReplicaConnector(nil)
+
+	db, err := sqlite.OpenLibSQL(opts)
+	if err != nil {
+		t.Fatalf("Failed to open replica: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE t (id INTEGER)`); err != nil {
+		t.Fatalf("Failed to use replica: %v", err)
+	}
+	if gotURL != opts.URL || gotToken != "tok" || gotInterval != time.Minute {
+		t.Errorf("Unexpected connector arguments %q, %q, %v", gotURL, gotToken, gotInterval)
+	}
+}
+
+func TestLibSQL_Lint(t *testing.T) {
+	up := `ALTER TABLE users ALTER COLUMN age TO age BIGINT NOT NULL;`
+	rules := lintRules(t, up, "turso")
+	issues := rules[migration.RuleTypeChange]
+	if len(issues) != 1 || !strings.Contains(issues[0].Suggestion, "backfill") {
+		t.Errorf("Expected libSQL type change advice, got %+v", rules)
+	}
+	if len(rules[migration.RuleRename]) != 0 {
+		t.Errorf("Expected no rename, got %+v", rules[migration.RuleRename])
+	}
+
+	notNull := lintRules(t, `ALTER TABLE users ADD COLUMN age INTEGER NOT NULL;`, "libsql")[migration.RuleNotNullColumn]
+	if len(notNull) != 1 || notNull[0].Severity != migration.SeverityError {
+		t.Errorf("Expected an error on libSQL, got %+v", notNull)
+	}
+}
@@ -0,0 +1,290 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func lockConn(t *testing.T) *dialects.Connection {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "lock.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// testLocker checks that a backend keeps two processes apart, waits,
+// renews its lease and lets expired locks be taken over.
+func testLocker(t *testing.T, newLocker func() migration.Locker) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	a := migration.NewEngine(conn).WithLocker(newLocker())
+	b := migration.NewEngine(conn).WithLocker(newLocker())
+	optsA := migration.LockOptions{Identifier: "deploy-a", LockTTL: 300 * time.Millisecond}
+	optsB := migration.LockOptions{Identifier: "deploy-b", LockTTL: 300 * time.Millisecond}
+
+	if info, err := a.GetLockInfo(ctx); err != nil || info != nil {
+		t.Fatalf("Expected a free lock, got %v, %v", info, err)
+	}
+	if err := a.AcquireLock(ctx, optsA); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	info, err := b.GetLockInfo(ctx)
+	if err != nil || info == nil {
+		t.Fatalf("Expected the lock to be held, got %v, %v", info, err)
+	}
+	host, _ := os.Hostname()
+	if info.LockedBy != "deploy-a" || info.Host != host || info.PID != os.Getpid() || info.LockedAt.IsZero() {
+		t.Errorf("Unexpected holder %+v", info)
+	}
+
+	err = b.AcquireLock(ctx, optsB)
+	if err == nil || !strings.Contains(err.Error(), "locked by deploy-a") {
+		t.Fatalf("Expected the second process to be refused, got %v", err)
+	}
+
+	// The lease outlives its TTL while renewed
+	time.Sleep(500 * time.Millisecond)
+	if locked, _ := b.IsLocked(ctx); !locked {
+		t.Fatal("Expected the renewed lock to still be held")
+	}
+
+	// Waiting succeeds once the holder releases
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		a.ReleaseLock(ctx)
+	}()
+	optsB.Timeout = 2 * time.Second
+	if err := b.AcquireLock(ctx, optsB); err != nil {
+		t.Fatalf("Expected to get the lock after waiting, got %v", err)
+	}
+	b.ReleaseLock(ctx)
+	if locked, _ := a.IsLocked(ctx); locked {
+		t.Error("Expected the lock to be free after release")
+	}
+}
+
+func TestMigrationLock_Table(t *testing.T) {
+	var conn *dialects.Connection
+	testLocker(t, func() migration.Locker {
+		if conn == nil {
+			conn = lockConn(t)
+		}
+		return migration.NewTableLocker(conn, migration.LockTable)
+	})
+
+	// An expired lease is taken over, and a lock table of older versions
+	// is upgraded
+	ctx := context.Background()
+	conn = lockConn(t)
+	conn.Exec(ctx, `CREATE TABLE _nexus_migration_lock (id INTEGER PRIMARY KEY, locked_at TIMESTAMP NOT NULL,
+		locked_by TEXT NOT NULL, expires_at TIMESTAMP NOT NULL)`)
+	conn.Exec(ctx, `INSERT INTO _nexus_migration_lock VALUES (1, ?, 'old', ?)`, time.Now(), time.Now().Add(time.Hour))
+	locker := migration.NewTableLocker(conn, migration.LockTable)
+	stale := &migration.LockInfo{LockedBy: "crashed", Host: "db1", PID: 1, LockedAt: time.Now()}
+	if ok, _, err := locker.TryAcquire(ctx, stale, time.Millisecond); !ok || err != nil {
+		t.Fatalf("Expected to lock after upgrading the table, got %v, %v", ok, err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	engine := migration.NewEngine(conn).WithLocker(locker)
+	if err := engine.AcquireLock(ctx, migration.DefaultLockOptions()); err != nil {
+		t.Fatalf("Expected to take over the expired lock, got %v", err)
+	}
+	engine.ReleaseLock(ctx)
+}
+
+func TestMigrationLock_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrate.lock")
+	testLocker(t, func() migration.Locker { return migration.NewFileLocker(path) })
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock file to be removed, got %v", err)
+	}
+
+	// A lock file of a process that died is taken over once expired
+	os.WriteFile(path, []byte(`{"locked_by":"crashed","expires_at":"2000-01-01T00:00:00Z"}`), 0644)
+	engine := migration.NewEngine(lockConn(t)).WithLocker(migration.NewFileLocker(path))
+	if err := engine.AcquireLock(context.Background(), migration.DefaultLockOptions()); err != nil {
+		t.Fatalf("Expected to take over the expired lock file, got %v", err)
+	}
+	engine.ReleaseLock(context.Background())
+
+	// Processes racing to break an expired lock: exactly one wins, and the
+	// others see it as the holder rather than break its fresh lock
+	for round := 0; round < 20; round++ {
+		os.WriteFile(path, []byte(`{"locked_by":"crashed","expires_at":"2000-01-01T00:00:00Z"}`), 0644)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var winners []string
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				holder := &migration.LockInfo{LockedBy: fmt.Sprintf("worker-%d", i), PID: i}
+				ok, _, err := migration.NewFileLocker(path).TryAcquire(context.Background(), holder, time.Minute)
+				if err != nil {
+					t.Errorf("TryAcquire failed: %v", err)
+				}
+				if ok {
+					mu.Lock()
+					winners = append(winners, holder.LockedBy)
+					mu.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+		info, _ := migration.NewFileLocker(path).Info(context.Background())
+		if len(winners) != 1 || info == nil || info.LockedBy != winners[0] {
+			t.Fatalf("Expected a single holder, got %v with the lock file of %+v", winners, info)
+		}
+		os.Remove(path)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".migrate.lock.*")); len(leftovers) != 0 {
+		t.Errorf("Expected no files left aside, got %v", leftovers)
+	}
+}
+
+func TestMigrationLock_Redis(t *testing.T) {
+	addr := fakeRedis(t)
+	testLocker(t, func() migration.Locker {
+		l, err := migration.NewRedisLocker("redis://"+addr+"/0", "")
+		if err != nil {
+			t.Fatalf("NewRedisLocker failed: %v", err)
+		}
+		return l
+	})
+	if _, err := migration.NewRedisLocker("http://localhost", ""); err == nil {
+		t.Error("Expected a non-redis URL to fail")
+	}
+}
+
+func TestMigrationLock_DefaultBackend(t *testing.T) {
+	engine := migration.NewEngine(lockConn(t))
+	if name := engine.Locker().Name(); name != "lock table" {
+		t.Errorf("Expected SQLite to use the lock table, got %q", name)
+	}
+	if _, err := migration.NewAdvisoryLocker(lockConn(t), migration.LockTable); err == nil {
+		t.Error("Expected advisory locks to need PostgreSQL or MySQL")
+	}
+}
+
+// fakeRedis serves the commands the Redis lock uses, with expiring keys.
+func fakeRedis(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	values := make(map[string]string)
+	expires := make(map[string]time.Time)
+	get := func(key string) (string, bool) {
+		if at, ok := expires[key]; ok && time.Now().After(at) {
+			delete(values, key)
+			delete(expires, key)
+		}
+		v, ok := values[key]
+		return v, ok
+	}
+	set := func(key, value, px string) {
+		ms, _ := strconv.Atoi(px)
+		values[key] = value
+		expires[key] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	handle := func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch strings.ToUpper(args[0]) {
+		case "SELECT":
+			return "+OK\r\n"
+		case "GET":
+			if v, ok := get(args[1]); ok {
+				return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			}
+			return "$-1\r\n"
+		case "SET": // SET key value NX PX ms
+			if _, ok := get(args[1]); ok {
+				return "$-1\r\n"
+			}
+			set(args[1], args[2], args[5])
+			return "+OK\r\n"
+		case "DEL":
+			delete(values, args[1])
+			return ":1\r\n"
+		case "EVAL": // EVAL script 1 key current [new ms]
+			if v, ok := get(args[3]); !ok || v != args[4] {
+				if strings.Contains(args[1], "'del'") {
+					return ":0\r\n"
+				}
+				return "$-1\r\n"
+			}
+			if strings.Contains(args[1], "'del'") {
+				delete(values, args[3])
+				return ":1\r\n"
+			}
+			set(args[3], args[5], args[6])
+			return "+OK\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+					io.WriteString(conn, handle(args))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
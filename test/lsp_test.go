@@ -0,0 +1,243 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nexus-db/nexus/internal/lsp"
+)
+
+// lspClient drives a language server over in-memory pipes.
+type lspClient struct {
+	t      *testing.T
+	in     io.WriteCloser
+	out    *bufio.Reader
+	nextID int
+}
+
+func startLSP(t *testing.T, schemaPath string) *lspClient {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+
+	server := lsp.NewServer(lsp.Config{In: serverIn, Out: serverOut, SchemaPath: schemaPath})
+	go func() {
+		server.Run()
+		serverOut.Close()
+	}()
+
+	c := &lspClient{t: t, in: clientOut, out: bufio.NewReader(clientIn)}
+	t.Cleanup(func() { clientOut.Close() })
+	c.call("initialize", map[string]any{"rootUri": "file:///"}, nil)
+	return c
+}
+
+func (c *lspClient) send(msg map[string]any) {
+	c.t.Helper()
+	msg["jsonrpc"] = "2.0"
+	body, _ := json.Marshal(msg)
+	if _, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatalf("Failed to write message: %v", err)
+	}
+}
+
+type lspMessage struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *lspClient) read() lspMessage {
+	c.t.Helper()
+	done := make(chan lspMessage, 1)
+	go func() {
+		header, err := textproto.NewReader(c.out).ReadMIMEHeader()
+		if err != nil {
+			close(done)
+			return
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		io.ReadFull(c.out, body)
+		var msg lspMessage
+		json.Unmarshal(body, &msg)
+		done <- msg
+	}()
+
+	select {
+	case msg, ok := <-done:
+		if !ok {
+			c.t.Fatal("Server closed the connection")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("Timed out waiting for the server")
+	}
+	return lspMessage{}
+}
+
+// call sends a request and decodes its result, skipping notifications.
+func (c *lspClient) call(method string, params any, result any) {
+	c.t.Helper()
+	c.nextID++
+	c.send(map[string]any{"id": c.nextID, "method": method, "params": params})
+	for {
+		msg := c.read()
+		if msg.ID == nil || *msg.ID != c.nextID {
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s failed: %s", method, msg.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("Decoding %s result: %v", method, err)
+			}
+		}
+		return
+	}
+}
+
+// diagnostics waits for the next publishDiagnostics notification.
+func (c *lspClient) diagnostics() lsp.PublishDiagnosticsParams {
+	c.t.Helper()
+	for {
+		msg := c.read()
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params lsp.PublishDiagnosticsParams
+		json.Unmarshal(msg.Params, &params)
+		return params
+	}
+}
+
+func (c *lspClient) open(uri, text string) {
+	c.send(map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "nexus", "version": 1, "text": text},
+	}})
+}
+
+func (c *lspClient) change(uri, text string) {
+	c.send(map[string]any{"method": "textDocument/didChange", "params": map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": 2},
+		"contentChanges": []map[string]any{{"text": text}},
+	}})
+}
+
+func positionParams(uri string, line, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+	}
+}
+
+func fileURI(path string) string {
+	return "file://" + filepath.ToSlash(path)
+}
+
+func TestLSP_Diagnostics(t *testing.T) {
+	dir := t.TempDir()
+	uri := fileURI(filepath.Join(dir, "schema.nexus"))
+	c := startLSP(t, "")
+
+	c.open(uri, "model User {\n  id   Int @id\n  name Strng\n}\n")
+	params := c.diagnostics()
+	if params.URI != uri || len(params.Diagnostics) != 1 {
+		t.Fatalf("Expected one diagnostic for %s, got %+v", uri, params)
+	}
+	d := params.Diagnostics[0]
+	if d.Code != "SCHEMA_UNKNOWN_TYPE" || d.Range.Start.Line != 2 || d.Range.Start.Character != 7 || d.Range.End.Character != 12 {
+		t.Errorf("Unexpected diagnostic: %+v", d)
+	}
+	if !strings.Contains(d.Message, "Did you mean 'String'?") {
+		t.Errorf("Expected the suggestion in the message, got %q", d.Message)
+	}
+
+	// Fixing the document clears its diagnostics
+	c.change(uri, "model User {\n  id   Int @id\n  name String\n}\n")
+	if params := c.diagnostics(); len(params.Diagnostics) != 0 {
+		t.Errorf("Expected diagnostics to be cleared, got %+v", params.Diagnostics)
+	}
+}
+
+func TestLSP_DefinitionAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	userPath := writeSchemaFile(t, dir, "user.nexus", "model User {\n  id    Int    @id\n  posts Post[]\n}\n")
+	postSrc := "model Post {\n  id       Int  @id\n  authorId Int\n  author   User @relation(fields: [authorId], references: [id])\n}\n"
+	postPath := writeSchemaFile(t, dir, "post.nexus", postSrc)
+	postURI := fileURI(postPath)
+
+	c := startLSP(t, dir)
+	c.open(postURI, postSrc)
+	if params := c.diagnostics(); len(params.Diagnostics) != 0 {
+		t.Fatalf("Expected a valid schema, got %+v", params)
+	}
+
+	var loc lsp.Location
+	c.call("textDocument/definition", positionParams(postURI, 3, 12), &loc)
+	if loc.URI != fileURI(userPath) || loc.Range.Start.Line != 0 || loc.Range.Start.Character != 6 {
+		t.Errorf("Expected the User model in user.nexus, got %+v", loc)
+	}
+
+	// fields: refers to this model, references: to the target model
+	c.call("textDocument/definition", positionParams(postURI, 3, 37), &loc)
+	if loc.URI != postURI || loc.Range.Start.Line != 2 {
+		t.Errorf("Expected authorId in post.nexus, got %+v", loc)
+	}
+	c.call("textDocument/definition", positionParams(postURI, 3, 59), &loc)
+	if loc.URI != fileURI(userPath) || loc.Range.Start.Line != 1 {
+		t.Errorf("Expected User.id in user.nexus, got %+v", loc)
+	}
+}
+
+func TestLSP_CompletionAndHover(t *testing.T) {
+	dir := t.TempDir()
+	uri := fileURI(filepath.Join(dir, "schema.nexus"))
+	src := "enum Role {\n  ADMIN\n  USER\n}\n\nmodel User {\n  id   Int  @id\n  role Role @default(\n  name \n}\n"
+	c := startLSP(t, "")
+	c.open(uri, src)
+	c.diagnostics()
+
+	labels := func(line, character int) []string {
+		var items []lsp.CompletionItem
+		c.call("textDocument/completion", positionParams(uri, line, character), &items)
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Label)
+		}
+		return names
+	}
+
+	if got := strings.Join(labels(7, 21), ","); got != "ADMIN,USER" {
+		t.Errorf("Expected enum values for @default, got %s", got)
+	}
+	if got := strings.Join(labels(8, 7), ","); !strings.Contains(got, "DateTime") || !strings.Contains(got, "Role") {
+		t.Errorf("Expected types, models and enums, got %s", got)
+	}
+	if got := strings.Join(labels(6, 13), ","); !strings.Contains(got, "unique") || !strings.Contains(got, "relation") {
+		t.Errorf("Expected field attributes, got %s", got)
+	}
+
+	var hover lsp.Hover
+	c.call("textDocument/hover", positionParams(uri, 7, 8), &hover)
+	if !strings.Contains(hover.Contents.Value, "ADMIN") {
+		t.Errorf("Expected the Role enum in the hover, got %q", hover.Contents.Value)
+	}
+	c.call("textDocument/hover", positionParams(uri, 6, 14), &hover)
+	if !strings.Contains(hover.Contents.Value, "primary key") {
+		t.Errorf("Expected docs for @id, got %q", hover.Contents.Value)
+	}
+}
@@ -240,3 +240,61 @@ func TestBelongsToManyNoRelations(t *testing.T) {
 		t.Errorf("Expected 0 tags, got %d", len(tagResults))
 	}
 }
+
+func TestBelongsToManyAttachDetachSync(t *testing.T) {
+	conn, s := setupManyToManyDB(t)
+	defer conn.Close()
+	conn.DB.SetMaxOpenConns(1) // Transactions see the in-memory tables
+	ctx := context.Background()
+	for _, name := range []string{"go", "sql", "orm", "db"} {
+		query.New(conn, "tags").Insert(map[string]interface{}{"name": name}).Exec(ctx)
+	}
+	query.New(conn, "users").Insert(map[string]interface{}{"name": "Ada"}).Exec(ctx)
+	tags := query.NewWithSchema(conn, "users", s).Relation("Tag")
+
+	linked := func() []int64 {
+		rows, err := query.New(conn, "user_tags").Select("tag_id").Where(query.Eq("user_id", 1)).OrderBy("tag_id", query.Asc).All(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int64, len(rows))
+		for i, row := range rows {
+			ids[i] = row["tag_id"].(int64)
+		}
+		return ids
+	}
+
+	// Links that exist are skipped
+	if n, err := tags.Attach(ctx, 1, 1, 2); err != nil || n != 2 {
+		t.Fatalf("Expected 2 links added, got %d (%v)", n, err)
+	}
+	if n, err := tags.Attach(ctx, 1, 2, 3, 3); err != nil || n != 1 {
+		t.Fatalf("Expected 1 link added, got %d (%v)", n, err)
+	}
+	if got := linked(); len(got) != 3 {
+		t.Errorf("Expected tags 1-3, got %v", got)
+	}
+
+	if n, err := tags.Detach(ctx, 1, 1, 4); err != nil || n != 1 {
+		t.Fatalf("Expected 1 link removed, got %d (%v)", n, err)
+	}
+
+	result, err := tags.Sync(ctx, 1, int64(3), 4)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(result.Attached) != 1 || len(result.Detached) != 1 {
+		t.Errorf("Expected tag 4 attached and tag 2 detached, got %+v", result)
+	}
+	if got := linked(); len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("Expected tags 3 and 4, got %v", got)
+	}
+
+	if _, err := tags.Sync(ctx, 1); err != nil || len(linked()) != 0 {
+		t.Errorf("Expected Sync with no IDs to remove every link (%v)", err)
+	}
+
+	if _, err := query.NewWithSchema(conn, "users", s).Relation("Post").Attach(ctx, 1, 1); err == nil {
+		t.Error("Expected an error for an unknown relation")
+	}
+}
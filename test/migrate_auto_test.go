@@ -0,0 +1,88 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/migrate"
+)
+
+func TestMigrateAuto(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	files := fstest.MapFS{
+		"20240101_100000_users.sql": {Data: []byte("-- UP\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE users;\n")},
+		"20240201_100000_posts.sql": {Data: []byte("-- UP\nCREATE TABLE posts (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE posts;\n")},
+	}
+
+	// Another replica holds the lock: Auto waits for it
+	other := migration.NewEngine(conn)
+	if err := other.AcquireLock(ctx, migration.LockOptions{Identifier: "replica-a"}); err != nil {
+		t.Fatal(err)
+	}
+	var phases []migrate.Phase
+	var holder string
+	done := make(chan error)
+	var result *migrate.Result
+	go func() {
+		var err error
+		result, err = migrate.Auto(ctx, conn, files, migrate.Options{
+			Identifier:  "replica-b",
+			LockTimeout: 5 * time.Second,
+			Progress: func(e migrate.Event) {
+				phases = append(phases, e.Phase)
+				if e.Phase == migrate.PhaseWaiting && e.Holder != nil {
+					holder = e.Holder.LockedBy
+				}
+				if e.Phase == migrate.PhaseApplied && e.Total != 2 {
+					t.Errorf("Expected 2 pending migrations, got %d", e.Total)
+				}
+			},
+		})
+		done <- err
+	}()
+	time.Sleep(200 * time.Millisecond)
+	other.ReleaseLock(ctx)
+	if err := <-done; err != nil {
+		t.Fatalf("Auto failed: %v", err)
+	}
+	want := []migrate.Phase{migrate.PhaseWaiting, migrate.PhaseLocked, migrate.PhasePending, migrate.PhaseApplied, migrate.PhaseApplied, migrate.PhaseDone}
+	if len(phases) != len(want) {
+		t.Fatalf("Expected phases %v, got %v", want, phases)
+	}
+	for i := range want {
+		if phases[i] != want[i] {
+			t.Fatalf("Expected phases %v, got %v", want, phases)
+		}
+	}
+	if holder != "replica-a" || len(result.Applied) != 2 || result.Applied[0].Name != "users" {
+		t.Errorf("Unexpected holder %q or result %+v", holder, result)
+	}
+	if locked, _ := other.IsLocked(ctx); locked {
+		t.Error("Expected Auto to release the lock")
+	}
+
+	// A later start has nothing to apply
+	result, err := migrate.Auto(ctx, conn, files, migrate.Options{})
+	if err != nil || len(result.Applied) != 0 {
+		t.Fatalf("Expected nothing applied, got %+v (%v)", result, err)
+	}
+
+	// An edited migration is reported, and refused with FailOnDrift
+	files["20240101_100000_users.sql"] = &fstest.MapFile{Data: []byte("-- UP\nCREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);\n")}
+	result, err = migrate.Auto(ctx, conn, files, migrate.Options{})
+	if err != nil || len(result.Drift) != 1 || result.Drift[0].ID != "20240101_100000" {
+		t.Errorf("Expected the drift to be reported, got %+v (%v)", result, err)
+	}
+	files["20240301_100000_tags.sql"] = &fstest.MapFile{Data: []byte("-- UP\nCREATE TABLE tags (id INTEGER PRIMARY KEY);\n")}
+	if _, err := migrate.Auto(ctx, conn, files, migrate.Options{FailOnDrift: true}); !errors.Is(err, migrate.ErrDrift) {
+		t.Errorf("Expected ErrDrift, got %v", err)
+	}
+	if _, err := conn.Exec(ctx, "SELECT id FROM tags"); err == nil {
+		t.Error("Expected no migration applied on drift")
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	if _, err := conn.Exec(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, email_lower TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 25; i++ {
+		lower := "NULL"
+		if i%5 == 0 {
+			lower = "'done'"
+		}
+		conn.Exec(ctx, fmt.Sprintf("INSERT INTO users (id, email, email_lower) VALUES (%d, 'User%d@Example.com', %s)", i*2, i, lower))
+	}
+
+	spec := migration.BackfillSpec{
+		Table:     "users",
+		Set:       "email_lower = LOWER(email)",
+		Where:     "email_lower IS NULL",
+		BatchSize: 8,
+	}
+	// Stop after the first batch, as if the process was killed
+	cancelCtx, cancel := context.WithCancel(ctx)
+	var batches []migration.BackfillProgress
+	spec.Progress = func(p migration.BackfillProgress) {
+		batches = append(batches, p)
+		cancel()
+	}
+	n, err := migration.Backfill(cancelCtx, conn, spec)
+	if err != context.Canceled || n != 8 {
+		t.Fatalf("Expected the first batch before the cancel, got %d rows and %v", n, err)
+	}
+	if batches[0].LastKey != 18 || batches[0].Total != 8 {
+		t.Errorf("Unexpected progress: %+v", batches[0])
+	}
+
+	// Running it again resumes after the checkpoint
+	batches, spec.Progress = nil, func(p migration.BackfillProgress) { batches = append(batches, p) }
+	n, err = migration.Backfill(ctx, conn, spec)
+	if err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	if n != 12 || len(batches) != 2 || batches[1].Total != 20 || batches[1].LastKey != 48 {
+		t.Errorf("Expected the other 12 rows in 2 batches, got %d rows and %+v", n, batches)
+	}
+	var remaining, kept int
+	conn.QueryRow(ctx, "SELECT COUNT(*) FROM users WHERE email_lower IS NULL OR email_lower <> LOWER(email)").Scan(&remaining)
+	conn.QueryRow(ctx, "SELECT COUNT(*) FROM users WHERE email_lower = 'done'").Scan(&kept)
+	if remaining != 5 || kept != 5 {
+		t.Errorf("Expected only rows matching Where updated, got %d left and %d kept", remaining, kept)
+	}
+
+	// A finished backfill does nothing until it is reset
+	conn.Exec(ctx, "UPDATE users SET email_lower = NULL WHERE id = 2")
+	if n, err := migration.Backfill(ctx, conn, spec); n != 0 || err != nil {
+		t.Errorf("Expected a finished backfill to do nothing, got %d rows and %v", n, err)
+	}
+	if err := migration.ResetBackfill(ctx, conn, "users"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := migration.Backfill(ctx, conn, spec); n != 1 || err != nil {
+		t.Errorf("Expected the reset backfill to update the new row, got %d rows and %v", n, err)
+	}
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+func TestMigrationEnvironments(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"20240101_100000_users.sql":    "-- UP\nCREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);\n\n-- DOWN\nDROP TABLE users;\n",
+		"20240102_100000_fixtures.sql": "-- nexus: { env: [development, test] }\n-- UP\nINSERT INTO users (name) VALUES ('dev');\n\n-- DOWN\nDELETE FROM users;\n",
+		"20240103_100000_backfill.sql": "-- nexus:\n--   env: production\n-- UP\nUPDATE users SET name = 'prod';\n",
+		"20240104_100000_posts.sql":    "-- UP\nCREATE TABLE posts (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE posts;\n",
+	})
+
+	conn := lockConn(t)
+	engine := migration.NewEngine(conn)
+	engine.Init(ctx)
+	if err := engine.LoadFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := engine.Migrations()[1].Environments; !reflect.DeepEqual(got, []string{"development", "test"}) {
+		t.Errorf("Expected the environments of the header, got %q", got)
+	}
+
+	// Without an environment, no gated migration runs
+	count, err := engine.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected the 2 ungated migrations applied, got %d", count)
+	}
+	status, _ := engine.Status(ctx)
+	for i, want := range []bool{false, true, true, false} {
+		if status[i].Excluded != want || status[i].Applied == want {
+			t.Errorf("Unexpected status of %s: %+v", status[i].ID, status[i])
+		}
+	}
+
+	// Gated migrations older than applied ones are not out of order elsewhere
+	if older, _, _ := engine.OutOfOrder(ctx); len(older) != 0 {
+		t.Errorf("Expected no out-of-order migrations, got %d", len(older))
+	}
+
+	engine.WithEnvironment("Test")
+	count, err = engine.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected the fixtures applied in test, got %d migrations", count)
+	}
+	var name string
+	conn.QueryRow(ctx, "SELECT name FROM users").Scan(&name)
+	if name != "dev" {
+		t.Errorf("Expected the fixture row without the production backfill, got %q", name)
+	}
+
+	// Gated migrations can't be squashed into one that runs everywhere
+	_, err = migration.SquashMigrations(engine.Migrations(), migration.SquashOptions{})
+	if err == nil || !strings.Contains(err.Error(), "runs only in development, test") {
+		t.Errorf("Expected squashing to refuse the gated migration, got %v", err)
+	}
+
+	// Misspelled environments never run, so validation catches them
+	m := &migration.Migration{ID: "20240105_100000", UpSQL: "SELECT 1;", Environments: []string{"prodution"}}
+	opts := migration.DefaultValidateOptions()
+	opts.Environments = []string{"development", "test", "production"}
+	result := migration.ValidateWithOptions(m, opts)
+	if result.Valid || len(result.Issues) != 1 || !strings.Contains(result.Issues[0].Message, "prodution is not a known environment") {
+		t.Errorf("Expected an unknown environment error, got %+v", result.Issues)
+	}
+}
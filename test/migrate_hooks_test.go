@@ -0,0 +1,120 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+func TestMigrationHooks_RunAroundMigrations(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeMigrations(t, dir, map[string]string{
+		"20240101_100000_users.sql": "users",
+		"20240201_100000_posts.sql": "posts",
+		"20240301_100000_tags.sql":  "tags",
+	})
+
+	var events []string
+	record := func(ctx context.Context, e migration.HookEvent) error {
+		var names []string
+		for _, m := range e.Migrations {
+			names = append(names, m.Name)
+		}
+		events = append(events, string(e.Stage)+" "+e.Direction+" "+strings.Join(names, ","))
+		return nil
+	}
+	migration.RegisterHook("test-record", record)
+	hook, err := migration.ParseHook("go:test-record", nil)
+	if err != nil {
+		t.Fatalf("ParseHook failed: %v", err)
+	}
+	if _, err := migration.ParseHook("go:missing", nil); err == nil {
+		t.Error("Expected an unregistered hook to fail")
+	}
+
+	engine := migration.NewEngine(lockConn(t)).WithHooks(migration.Hooks{
+		BeforeMigrate: []migration.Hook{hook},
+		AfterMigrate:  []migration.Hook{hook},
+	})
+	engine.Init(ctx)
+	engine.LoadFromDir(dir)
+
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatalf("Second Up failed: %v", err)
+	}
+	if _, err := engine.DownN(ctx, 2); err != nil {
+		t.Fatalf("DownN failed: %v", err)
+	}
+
+	want := []string{
+		"before_migrate up users,posts,tags",
+		"after_migrate up users,posts,tags",
+		"before_migrate down tags,posts",
+		"after_migrate down tags,posts",
+	}
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected hook events:\n%s", strings.Join(events, "\n"))
+	}
+}
+
+func TestMigrationHooks_BeforeHookFailureStops(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeMigrations(t, dir, map[string]string{"20240101_100000_users.sql": "users"})
+
+	engine := migration.NewEngine(lockConn(t)).WithHooks(migration.Hooks{
+		BeforeMigrate: []migration.Hook{func(ctx context.Context, e migration.HookEvent) error {
+			return errors.New("backup failed")
+		}},
+	})
+	engine.Init(ctx)
+	engine.LoadFromDir(dir)
+
+	if _, err := engine.Up(ctx); err == nil || !strings.Contains(err.Error(), "before_migrate hook: backup failed") {
+		t.Fatalf("Expected the failing hook to stop Up, got %v", err)
+	}
+	if pending, _ := engine.Pending(ctx); len(pending) != 1 {
+		t.Errorf("Expected nothing to be applied, got %d pending", len(pending))
+	}
+}
+
+func TestMigrationHooks_ShellCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeMigrations(t, dir, map[string]string{"20240101_100000_users.sql": "users"})
+	out := filepath.Join(t.TempDir(), "hook.txt")
+
+	hook, err := migration.ParseHook(`echo "$NEXUS_HOOK $NEXUS_DIRECTION $NEXUS_MIGRATIONS $APP" >> `+out, []string{"APP=shop"})
+	if err != nil {
+		t.Fatalf("ParseHook failed: %v", err)
+	}
+	engine := migration.NewEngine(lockConn(t)).WithHooks(migration.Hooks{AfterMigrate: []migration.Hook{hook}})
+	engine.Init(ctx)
+	engine.LoadFromDir(dir)
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(out)
+	if got := strings.TrimSpace(string(data)); got != "after_migrate up 20240101_100000_users shop" {
+		t.Errorf("Unexpected hook output %q", got)
+	}
+
+	failing := migration.ShellHook("exit 3", nil)
+	if err := failing(ctx, migration.HookEvent{}); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Expected the failing command to return its status, got %v", err)
+	}
+}
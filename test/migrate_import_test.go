@@ -0,0 +1,173 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestImportMigrations_Goose(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"00001_create_users.sql": `-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (id INTEGER PRIMARY KEY);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE users;
+`,
+		"00002_Add-Index.sql": "-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX CONCURRENTLY users_id ON users (id);\n-- +goose Down\nDROP INDEX users_id;\n",
+		"00003_backfill.go":   "package migrations\n",
+	})
+
+	result, err := migration.ImportMigrations(dir, migration.FormatGoose)
+	if err != nil {
+		t.Fatalf("ImportMigrations failed: %v", err)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "00003_backfill.go: Go migrations can't be converted" {
+		t.Errorf("Expected the Go migration to be skipped, got %q", result.Skipped)
+	}
+	if len(result.Migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(result.Migrations))
+	}
+	users, index := result.Migrations[0], result.Migrations[1]
+	if users.ID != "19700101_000001" || users.Name != "create_users" || users.Version != "1" {
+		t.Errorf("Unexpected migration: %+v", users)
+	}
+	if users.UpSQL != "CREATE TABLE users (id INTEGER PRIMARY KEY);" || users.DownSQL != "DROP TABLE users;" {
+		t.Errorf("Expected the goose directives dropped, got %q and %q", users.UpSQL, users.DownSQL)
+	}
+	if users.Description != "Imported from goose 00001_create_users.sql" {
+		t.Errorf("Expected the origin in the description, got %q", users.Description)
+	}
+	if index.ID != "19700101_000002" || index.Name != "add_index" || !index.NoTransaction {
+		t.Errorf("Unexpected migration: %+v", index)
+	}
+
+	// The file written reads back the same, checksum and all
+	out := t.TempDir()
+	if err := migration.SaveMigration(out, index.Migration); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(filepath.Join(out, "19700101_000002_add_index.sql"))
+	saved, err := migration.ParseMigrationFile("19700101_000002_add_index.sql", string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Checksum != index.Checksum || !saved.NoTransaction || saved.Description != index.Description {
+		t.Errorf("Expected the saved file to match the import, got %+v", saved)
+	}
+}
+
+func TestImportMigrations_GolangMigrate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"1700000000_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY);\n",
+		"1700000000_create_users.down.sql": "DROP TABLE users;\n",
+		"1700000100_add_name.up.sql":       "ALTER TABLE users ADD COLUMN name TEXT;\n",
+		"1700000200_orphan.down.sql":       "SELECT 1;\n",
+	})
+
+	result, err := migration.ImportMigrations(dir, migration.FormatGolangMigrate)
+	if err != nil {
+		t.Fatalf("ImportMigrations failed: %v", err)
+	}
+	if len(result.Migrations) != 2 || len(result.Skipped) != 1 {
+		t.Fatalf("Expected 2 migrations and 1 skipped, got %d and %q", len(result.Migrations), result.Skipped)
+	}
+	// Unix timestamps keep their time
+	users := result.Migrations[0]
+	if users.ID != "20231114_221320" || users.DownSQL != "DROP TABLE users;" || users.Version != "1700000000" {
+		t.Errorf("Unexpected migration: %+v", users)
+	}
+	if result.Migrations[1].DownSQL != "" {
+		t.Errorf("Expected no DOWN SQL without a down file, got %q", result.Migrations[1].DownSQL)
+	}
+}
+
+func TestImportMigrations_PrismaBackfill(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"20240101120000_init/migration.sql":    "-- CreateTable\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n",
+		"20240102120000_posts/migration.sql":   "CREATE TABLE posts (id INTEGER PRIMARY KEY);\n",
+		"20240103120000_pending/migration.sql": "CREATE TABLE tags (id INTEGER PRIMARY KEY);\n",
+		"migration_lock.toml":                  "provider = \"sqlite\"\n",
+	})
+
+	result, err := migration.ImportMigrations(dir, migration.FormatPrisma)
+	if err != nil {
+		t.Fatalf("ImportMigrations failed: %v", err)
+	}
+	if len(result.Migrations) != 3 || result.Migrations[0].ID != "20240101_120000" || result.Migrations[0].Version != "20240101120000_init" {
+		t.Fatalf("Unexpected migrations: %+v", result.Migrations)
+	}
+
+	conn := lockConn(t)
+	for _, stmt := range []string{
+		`CREATE TABLE _prisma_migrations (id TEXT PRIMARY KEY, migration_name TEXT NOT NULL, finished_at TIMESTAMP, rolled_back_at TIMESTAMP)`,
+		`INSERT INTO _prisma_migrations VALUES ('1', '20240101120000_init', CURRENT_TIMESTAMP, NULL)`,
+		`INSERT INTO _prisma_migrations VALUES ('2', '20240102120000_posts', CURRENT_TIMESTAMP, NULL)`,
+		`INSERT INTO _prisma_migrations VALUES ('3', '20240103120000_pending', NULL, NULL)`,
+		`CREATE TABLE users (id INTEGER PRIMARY KEY)`,
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY)`,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	applied, err := migration.AppliedImports(ctx, conn, migration.FormatPrisma, result.Migrations)
+	if err != nil {
+		t.Fatalf("AppliedImports failed: %v", err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("Expected 2 applied migrations, got %d", len(applied))
+	}
+
+	engine := migration.NewEngine(conn)
+	engine.Init(ctx)
+	for _, m := range result.Migrations {
+		engine.Add(m.Migration)
+	}
+	for i := 0; i < 2; i++ {
+		n, err := engine.Record(ctx, []*migration.Migration{applied[0].Migration, applied[1].Migration})
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		if want := []int{2, 0}[i]; n != want {
+			t.Errorf("Expected %d recorded migrations, got %d", want, n)
+		}
+	}
+
+	// Only the pending migration runs; the checksums match the history
+	count, err := engine.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 migration applied, got %d", count)
+	}
+	status, _ := engine.Status(ctx)
+	for _, s := range status {
+		if !s.Applied {
+			t.Errorf("Expected %s to be applied", s.ID)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+func lintRules(t *testing.T, up, dialect string) map[string][]migration.ValidationIssue {
+	t.Helper()
+	opts := migration.DefaultValidateOptions()
+	opts.Dialect = dialect
+	result := migration.ValidateWithOptions(&migration.Migration{ID: "20240101_100000", UpSQL: up}, opts)
+	rules := make(map[string][]migration.ValidationIssue)
+	for _, issue := range result.Issues {
+		if issue.Rule != "" {
+			rules[issue.Rule] = append(rules[issue.Rule], issue)
+		}
+	}
+	return rules
+}
+
+func TestMigrationLint_ZeroDowntimeRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		up      string
+		want    []string
+	}{
+		{"not null without default", "postgres", `ALTER TABLE users ADD COLUMN age INTEGER NOT NULL;`, []string{migration.RuleNotNullColumn}},
+		{"not null with default", "postgres", `ALTER TABLE users ADD COLUMN age INTEGER NOT NULL DEFAULT 0;`, nil},
+		{"nullable column", "postgres", `ALTER TABLE users ADD COLUMN age INTEGER;`, nil},
+		{"constraint", "postgres", `ALTER TABLE users ADD CONSTRAINT users_email UNIQUE (email);`, nil},
+		{"set not null", "postgres", `ALTER TABLE users ALTER COLUMN email SET NOT NULL;`, []string{migration.RuleSetNotNull}},
+		{"postgres type change", "postgres", `ALTER TABLE "users" ALTER COLUMN "age" TYPE BIGINT;`, []string{migration.RuleTypeChange}},
+		{"mysql modify", "mysql", "ALTER TABLE `users` MODIFY COLUMN `age` BIGINT NOT NULL DEFAULT 0, ADD COLUMN bio TEXT;", []string{migration.RuleTypeChange}},
+		{"mysql change and rename", "mysql", "ALTER TABLE users CHANGE COLUMN name full_name VARCHAR(255);", []string{migration.RuleRename, migration.RuleTypeChange}},
+		{"blocking index", "postgres", `CREATE INDEX idx_users_email ON users (email);`, []string{migration.RuleBlockingIndex}},
+		{"concurrent index", "postgres", `CREATE INDEX CONCURRENTLY idx_users_email ON users (email);`, nil},
+		{"mysql index", "mysql", `CREATE INDEX idx_users_email ON users (email);`, nil},
+		{"rename column", "sqlite", `ALTER TABLE users RENAME COLUMN name TO full_name;`, []string{migration.RuleRename}},
+		{"rename table", "postgres", `ALTER TABLE users RENAME TO accounts;`, []string{migration.RuleRename}},
+		{"mysql rename table", "mysql", `RENAME TABLE users TO accounts;`, []string{migration.RuleRename}},
+		{"new table", "postgres", `CREATE TABLE posts (id INTEGER PRIMARY KEY);
+ALTER TABLE posts ADD COLUMN title TEXT NOT NULL;
+CREATE INDEX idx_posts_title ON posts (title);`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := lintRules(t, tt.up, tt.dialect)
+			var got []string
+			for rule := range rules {
+				got = append(got, rule)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected rules %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMigrationLint_DialectAdvice(t *testing.T) {
+	up := `ALTER TABLE users ADD COLUMN age INTEGER NOT NULL;`
+
+	sqlite := lintRules(t, up, "sqlite")[migration.RuleNotNullColumn]
+	if len(sqlite) != 1 || sqlite[0].Severity != migration.SeverityError {
+		t.Errorf("Expected an error on SQLite, got %+v", sqlite)
+	}
+	postgres := lintRules(t, up, "postgres")[migration.RuleNotNullColumn]
+	if len(postgres) != 1 || postgres[0].Severity != migration.SeverityWarning || !strings.Contains(postgres[0].Suggestion, "backfill") {
+		t.Errorf("Expected a warning with backfill advice on PostgreSQL, got %+v", postgres)
+	}
+
+	typeChange := lintRules(t, "ALTER TABLE users MODIFY age BIGINT;", "mysql")[migration.RuleTypeChange]
+	if len(typeChange) != 1 || !strings.Contains(typeChange[0].Suggestion, "ALGORITHM=INPLACE") {
+		t.Errorf("Expected MySQL advice, got %+v", typeChange)
+	}
+}
+
+func TestMigrationLint_FailOn(t *testing.T) {
+	m := &migration.Migration{ID: "20240101_100000", UpSQL: `ALTER TABLE users RENAME COLUMN name TO full_name;`}
+	result := migration.Validate(m)
+	if !result.Valid || result.Fails(migration.SeverityError) {
+		t.Errorf("Expected warnings only, got %+v", result.Issues)
+	}
+	if !result.Fails(migration.SeverityWarning) {
+		t.Error("Expected --fail-on warning to fail")
+	}
+
+	opts := migration.DefaultValidateOptions()
+	opts.ZeroDowntime = false
+	if result := migration.ValidateWithOptions(m, opts); len(result.Issues) != 0 {
+		t.Errorf("Expected no issues without the zero-downtime rules, got %+v", result.Issues)
+	}
+
+	if sev, err := migration.ParseSeverity("Warning"); err != nil || sev != migration.SeverityWarning {
+		t.Errorf("Unexpected severity %v, %v", sev, err)
+	}
+	if _, err := migration.ParseSeverity("info"); err == nil {
+		t.Error("Expected an unknown severity to fail")
+	}
+}
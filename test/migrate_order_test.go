@@ -0,0 +1,141 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+func writeMigrations(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, table := range files {
+		content := "-- UP\nCREATE TABLE " + table + " (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE " + table + ";\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func migrationFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestMigrationOrder_DetectAndRepair(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	conn := lockConn(t)
+
+	// main applied two migrations
+	writeMigrations(t, dir, map[string]string{
+		"20240101_100000_users.sql": "users",
+		"20240301_100000_posts.sql": "posts",
+	})
+	engine := migration.NewEngine(conn)
+	engine.Init(ctx)
+	if err := engine.LoadFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	// A branch created before posts is merged
+	writeMigrations(t, dir, map[string]string{"20240201_100000_tags.sql": "tags"})
+	engine = migration.NewEngine(conn).WithStrictOrder(true)
+	engine.LoadFromDir(dir)
+
+	older, latest, err := engine.OutOfOrder(ctx)
+	if err != nil || len(older) != 1 || older[0].Name != "tags" || latest != "20240301_100000" {
+		t.Fatalf("Expected tags to be out of order after posts, got %v %q %v", older, latest, err)
+	}
+	status, _ := engine.Status(ctx)
+	for _, s := range status {
+		if s.OutOfOrder != (s.Name == "tags") {
+			t.Errorf("Unexpected out-of-order flag on %s: %v", s.Name, s.OutOfOrder)
+		}
+	}
+
+	var orderErr *migration.OutOfOrderError
+	if _, err := engine.Up(ctx); !errors.As(err, &orderErr) || !strings.Contains(err.Error(), "migrate repair") {
+		t.Fatalf("Expected strict Up to fail with an OutOfOrderError, got %v", err)
+	}
+
+	plan, err := engine.PlanRepair(ctx)
+	if err != nil {
+		t.Fatalf("PlanRepair failed: %v", err)
+	}
+	if len(plan.Renumber) != 1 || plan.Renumber[0].OldID != "20240201_100000" || plan.Renumber[0].NewID <= "20240301_100000" {
+		t.Fatalf("Expected tags to be renumbered after posts, got %+v", plan.Renumber)
+	}
+	if err := engine.Repair(ctx, dir, plan); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	files := migrationFiles(t, dir)
+	if len(files) != 3 || files[2] != plan.Renumber[0].NewID+"_tags.sql" {
+		t.Errorf("Expected the tags file to be renamed last, got %v", files)
+	}
+
+	engine = migration.NewEngine(conn).WithStrictOrder(true)
+	engine.LoadFromDir(dir)
+	if n, err := engine.Up(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected strict Up to apply the renumbered migration, got %d, %v", n, err)
+	}
+	if plan, _ := engine.PlanRepair(ctx); !plan.Empty() {
+		t.Errorf("Expected nothing left to repair, got %+v", plan)
+	}
+}
+
+func TestMigrationOrder_RelinkRenamedFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	conn := lockConn(t)
+
+	writeMigrations(t, dir, map[string]string{
+		"20240101_100000_users.sql": "users",
+		"20240301_100000_posts.sql": "posts",
+	})
+	engine := migration.NewEngine(conn)
+	engine.Init(ctx)
+	engine.LoadFromDir(dir)
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	// A teammate renumbered an applied migration; another was deleted
+	os.Rename(filepath.Join(dir, "20240301_100000_posts.sql"), filepath.Join(dir, "20240305_090000_posts.sql"))
+	os.Remove(filepath.Join(dir, "20240101_100000_users.sql"))
+
+	engine = migration.NewEngine(conn)
+	engine.LoadFromDir(dir)
+	plan, err := engine.PlanRepair(ctx)
+	if err != nil {
+		t.Fatalf("PlanRepair failed: %v", err)
+	}
+	if len(plan.Relink) != 1 || plan.Relink[0].OldID != "20240301_100000" || plan.Relink[0].NewID != "20240305_090000" {
+		t.Errorf("Expected posts to be relinked, got %+v", plan.Relink)
+	}
+	if len(plan.Orphaned) != 1 || plan.Orphaned[0].Name != "users" || len(plan.Renumber) != 0 {
+		t.Errorf("Expected users to be orphaned and nothing renumbered, got %+v", plan)
+	}
+	if err := engine.Repair(ctx, dir, plan); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+
+	pending, _ := engine.Pending(ctx)
+	if len(pending) != 0 {
+		t.Errorf("Expected the renamed migration to count as applied, got %d pending", len(pending))
+	}
+}
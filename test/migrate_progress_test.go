@@ -0,0 +1,102 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+func TestMigrationProgress_ReportsStatements(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	content := `-- UP
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+INSERT INTO users (name) VALUES ('a; b'), ('c');
+UPDATE users SET name = 'x';
+
+-- DOWN
+DROP TABLE users;
+`
+	if err := os.WriteFile(filepath.Join(dir, "20240101_100000_users.sql"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []migration.StatementEvent
+	engine := migration.NewEngine(lockConn(t)).WithProgress(func(e migration.StatementEvent) {
+		events = append(events, e)
+	})
+	engine.Init(ctx)
+	engine.LoadFromDir(dir)
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 statement events, got %d", len(events))
+	}
+	for i, want := range []int64{-1, 2, 2} {
+		e := events[i]
+		if e.Index != i+1 || e.Total != 3 || e.Direction != "up" || e.Migration.Name != "users" {
+			t.Errorf("Unexpected event %d: %+v", i, e)
+		}
+		if e.RowsAffected != want {
+			t.Errorf("Expected statement %d to affect %d rows, got %d", i+1, want, e.RowsAffected)
+		}
+	}
+
+	status, _ := engine.Status(ctx)
+	if len(status) != 1 || !status[0].Applied || status[0].Duration <= 0 {
+		t.Errorf("Expected the applied migration to have a duration, got %+v", status)
+	}
+
+	events = nil
+	if err := engine.Down(ctx); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Direction != "down" || events[0].SQL != "DROP TABLE users" {
+		t.Errorf("Unexpected rollback events: %+v", events)
+	}
+}
+
+func TestMigrationProgress_UpgradesHistoryTable(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+
+	// A history table from before execution times were recorded
+	_, err := conn.Exec(ctx, `CREATE TABLE _nexus_migrations (
+		id INTEGER PRIMARY KEY,
+		migration_id TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Exec(ctx, "INSERT INTO _nexus_migrations (migration_id, name, checksum) VALUES ('20240101_100000', 'users', 'x')")
+
+	dir := t.TempDir()
+	writeMigrations(t, dir, map[string]string{
+		"20240101_100000_users.sql": "users",
+		"20240201_100000_posts.sql": "posts",
+	})
+	engine := migration.NewEngine(conn)
+	if err := engine.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	engine.LoadFromDir(dir)
+	if n, err := engine.Up(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected Up to apply posts, got %d, %v", n, err)
+	}
+
+	status, err := engine.Status(ctx)
+	if err != nil || len(status) != 2 {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status[0].Duration != 0 || status[1].Duration <= 0 {
+		t.Errorf("Expected only the new migration to have a duration, got %v and %v", status[0].Duration, status[1].Duration)
+	}
+}
@@ -0,0 +1,245 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+)
+
+func TestSplitStatements_Dialects(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		dialect string
+		sql     string
+		want    []string
+	}{
+		{
+			name:    "postgres DO block",
+			dialect: "postgres",
+			sql: `DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'mood') THEN
+    CREATE TYPE mood AS ENUM ('sad', 'ok');
+  END IF;
+END
+$$;
+SELECT 1;`,
+			want: []string{"DO $$\nBEGIN\n  IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'mood') THEN\n    CREATE TYPE mood AS ENUM ('sad', 'ok');\n  END IF;\nEND\n$$", "SELECT 1"},
+		},
+		{
+			name:    "postgres escape string",
+			dialect: "postgres",
+			sql:     `INSERT INTO t VALUES (E'it\'s; here'); INSERT INTO t VALUES ('a\'); SELECT 2`,
+			want:    []string{`INSERT INTO t VALUES (E'it\'s; here')`, `INSERT INTO t VALUES ('a\')`, "SELECT 2"},
+		},
+		{
+			name:    "mysql DELIMITER",
+			dialect: "mysql",
+			sql: `CREATE TABLE t (n INT);
+DELIMITER $$
+CREATE TRIGGER t_bi BEFORE INSERT ON t FOR EACH ROW
+BEGIN
+  IF NEW.n < 0 THEN SET NEW.n = 0; END IF;
+END$$
+DELIMITER ;
+INSERT INTO t VALUES (1);`,
+			want: []string{
+				"CREATE TABLE t (n INT)",
+				"CREATE TRIGGER t_bi BEFORE INSERT ON t FOR EACH ROW\nBEGIN\n  IF NEW.n < 0 THEN SET NEW.n = 0; END IF;\nEND",
+				"INSERT INTO t VALUES (1)",
+			},
+		},
+		{
+			name:    "mysql escapes and comments",
+			dialect: "mysql",
+			sql:     "INSERT INTO t VALUES ('it\\'s; here'); # comment; here\nSELECT 1",
+			want:    []string{`INSERT INTO t VALUES ('it\'s; here')`, "SELECT 1"},
+		},
+		{
+			name: "procedure without DELIMITER",
+			sql: `CREATE PROCEDURE grade(IN n INT)
+BEGIN
+  SELECT CASE WHEN n > 5 THEN 'high' ELSE 'low' END;
+  WHILE n > 0 DO SET n = n - 1; END WHILE;
+END;
+SELECT 1;`,
+			want: []string{"CREATE PROCEDURE grade(IN n INT)\nBEGIN\n  SELECT CASE WHEN n > 5 THEN 'high' ELSE 'low' END;\n  WHILE n > 0 DO SET n = n - 1; END WHILE;\nEND", "SELECT 1"},
+		},
+		{
+			name: "COPY FROM stdin",
+			sql:  "COPY public.users (id, name) FROM stdin;\n1\tann; -- not a comment\n\\.\nSELECT 1;",
+			want: []string{"COPY public.users (id, name) FROM stdin;\n1\tann; -- not a comment\n\\.", "SELECT 1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := migration.SplitDialectStatements(tc.sql, tc.dialect); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseMigrationFile_Sections(t *testing.T) {
+	m, err := migration.ParseMigrationFile("20240101_100000_downloads.sql", "-- UP\nCREATE TABLE downloads (id INT); -- DOWNLOADS table\n-- DOWNLOADS\n\n-- DOWN\nDROP TABLE downloads;\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.UpSQL != "CREATE TABLE downloads (id INT); -- DOWNLOADS table\n-- DOWNLOADS" || m.DownSQL != "DROP TABLE downloads;" {
+		t.Errorf("Expected the sections split at the -- DOWN line, got %q and %q", m.UpSQL, m.DownSQL)
+	}
+
+	m, err = migration.ParseMigrationFile("20240101_100000_t.sql", "-- UP\nCREATE TABLE t (id INT);\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.UpSQL != "CREATE TABLE t (id INT);" || m.DownSQL != "" {
+		t.Errorf("Expected only an UP section, got %q and %q", m.UpSQL, m.DownSQL)
+	}
+}
+
+func TestParseMigrationFile_Header(t *testing.T) {
+	plain, err := migration.ParseMigrationFile("20240101_100000_users.sql", "-- UP\nCREATE INDEX CONCURRENTLY users_email ON users (email);\n\n-- DOWN\nDROP INDEX users_email;\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    migration.Migration
+	}{
+		{
+			name:    "inline",
+			content: "-- nexus: { transaction: false, author: ann, description: \"Index users, by email\" }\n" + "-- UP\nCREATE INDEX CONCURRENTLY users_email ON users (email);\n\n-- DOWN\nDROP INDEX users_email;\n",
+			want:    migration.Migration{Author: "ann", Description: "Index users, by email", NoTransaction: true},
+		},
+		{
+			name:    "lines",
+			content: "-- nexus:\n--   author: 'ann'\n--   description: Index users\n\n-- up\nCREATE INDEX CONCURRENTLY users_email ON users (email);\n\n-- down\nDROP INDEX users_email;\n",
+			want:    migration.Migration{Author: "ann", Description: "Index users"},
+		},
+		{
+			name:    "goose",
+			content: "-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX CONCURRENTLY users_email ON users (email);\n\n-- +goose Down\nDROP INDEX users_email;\n",
+			want:    migration.Migration{NoTransaction: true},
+		},
+		{
+			name:    "sql-migrate",
+			content: "-- +migrate Up notransaction\nCREATE INDEX CONCURRENTLY users_email ON users (email);\n\n-- +migrate Down\nDROP INDEX users_email;\n",
+			want:    migration.Migration{NoTransaction: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := migration.ParseMigrationFile("20240101_100000_users.sql", tc.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Author != tc.want.Author || m.Description != tc.want.Description || m.NoTransaction != tc.want.NoTransaction {
+				t.Errorf("Expected %+v, got %+v", tc.want, m)
+			}
+			// The header and markers are not part of the UP SQL
+			if m.UpSQL != plain.UpSQL || m.DownSQL != plain.DownSQL || m.Checksum != plain.Checksum {
+				t.Errorf("Expected the sections of the plain file, got %q and %q", m.UpSQL, m.DownSQL)
+			}
+		})
+	}
+
+	if _, err := migration.ParseMigrationFile("20240101_100000_users.sql", "-- nexus: { transation: false }\n-- UP\nSELECT 1;\n"); err == nil || !strings.Contains(err.Error(), `unknown key "transation"`) {
+		t.Errorf("Expected an unknown key error, got %v", err)
+	}
+}
+
+func TestMigration_RunsInTransaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	files := map[string]string{
+		"20240101_100000_users.sql":  "-- UP\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE users;\n",
+		"20240102_100000_broken.sql": "-- UP\nCREATE TABLE posts (id INTEGER PRIMARY KEY);\nINSERT INTO missing VALUES (1);\n\n-- DOWN\nDROP TABLE posts;\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conn := lockConn(t)
+	engine := migration.NewEngine(conn)
+	engine.Init(ctx)
+	engine.LoadFromDir(dir)
+	if _, err := engine.Up(ctx); err == nil {
+		t.Fatal("Expected the broken migration to fail")
+	}
+	if _, err := conn.Exec(ctx, "SELECT id FROM posts"); err == nil {
+		t.Error("Expected the table of the failed migration to be rolled back")
+	}
+
+	// Without a transaction, what ran before the failure stays
+	os.WriteFile(filepath.Join(dir, "20240102_100000_broken.sql"), []byte("-- nexus: { transaction: false }\n"+files["20240102_100000_broken.sql"]), 0644)
+	engine = migration.NewEngine(conn)
+	engine.LoadFromDir(dir)
+	if _, err := engine.Up(ctx); err == nil {
+		t.Fatal("Expected the broken migration to fail")
+	}
+	if _, err := conn.Exec(ctx, "SELECT id FROM posts"); err != nil {
+		t.Errorf("Expected the table created outside a transaction to stay, got %v", err)
+	}
+
+	status, _ := engine.Status(ctx)
+	if len(status) != 2 || !status[0].Applied || status[1].Applied || !status[1].NoTransaction {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
+
+func TestMigration_StatementsRunIndividually(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	content := `-- UP
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, note TEXT);
+CREATE TRIGGER users_name AFTER INSERT ON users
+BEGIN
+  UPDATE users SET name = CASE WHEN NEW.name IS NULL THEN 'anon' ELSE NEW.name END WHERE id = NEW.id;
+END;
+COPY users (id, name, note) FROM stdin;
+1	ann	a\ttab
+2	\N	\N
+\.
+
+-- DOWN
+DROP TABLE users;
+`
+	if err := os.WriteFile(filepath.Join(dir, "20240101_100000_users.sql"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := lockConn(t)
+	engine := migration.NewEngine(conn)
+	engine.Init(ctx)
+	if err := engine.LoadFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	rows, err := conn.Query(ctx, "SELECT name, COALESCE(note, '-') FROM users ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var name, note string
+		if err := rows.Scan(&name, &note); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, name+"/"+note)
+	}
+	if want := []string{"ann/a\ttab", "anon/-"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the copied rows through the trigger %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestMSSQL_CreateTable(t *testing.T) {
+	s, err := schema.NewParser(`
+model User {
+  id        Int      @id @autoincrement
+  email     String   @unique
+  active    Bool     @default(true)
+  createdAt DateTime @default(now())
+}
+`).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	d := mssql.New()
+	sql := d.CreateTableSQL(s.Models["User"])
+	for _, want := range []string{
+		"IF OBJECT_ID(N'User', N'U') IS NULL\nCREATE TABLE [User]",
+		"[id] INT IDENTITY(1,1) PRIMARY KEY",
+		"[email] NVARCHAR(255) NOT NULL UNIQUE",
+		"[active] BIT NOT NULL DEFAULT 1",
+		"DEFAULT SYSDATETIME()",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("Expected DDL to contain %q:\n%s", want, sql)
+		}
+	}
+
+	if got := d.Quote("odd]name"); got != "[odd]]name]" {
+		t.Errorf("Unexpected quoting: %s", got)
+	}
+	if got := d.Placeholder(2); got != "@p2" {
+		t.Errorf("Unexpected placeholder: %s", got)
+	}
+}
+
+func TestMSSQL_Paging(t *testing.T) {
+	d := mssql.New()
+	if got := dialects.LimitClause(d, 10, 20, false); got != " ORDER BY (SELECT NULL) OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY" {
+		t.Errorf("Unexpected unordered clause: %q", got)
+	}
+	if got := dialects.LimitClause(d, 10, 0, true); got != " OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY" {
+		t.Errorf("Unexpected ordered clause: %q", got)
+	}
+
+	conn := dialects.NewConnection(nil, d)
+	sql, _ := query.New(conn, "users").Select("id").OrderBy("id", query.Desc).Limit(5).Offset(10).Build()
+	if !strings.HasSuffix(sql, "ORDER BY [id] DESC OFFSET 10 ROWS FETCH NEXT 5 ROWS ONLY") {
+		t.Errorf("Unexpected select: %s", sql)
+	}
+	if strings.Contains(sql, "LIMIT") {
+		t.Errorf("Expected no LIMIT on SQL Server: %s", sql)
+	}
+}
+
+func TestMSSQL_Upsert(t *testing.T) {
+	conn := dialects.NewConnection(nil, mssql.New())
+	sql, args := query.New(conn, "users").
+		Insert(map[string]interface{}{"email": "a@example.com", "name": "A"}).
+		OnConflictDoUpdate([]string{"email"}, map[string]interface{}{"name": "A"}).
+		Build()
+
+	for _, want := range []string{
+		"MERGE INTO [users] WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2)) AS source ([email], [name])",
+		"ON target.[email] = source.[email]",
+		"WHEN MATCHED THEN UPDATE SET [name] = @p3",
+		"WHEN NOT MATCHED THEN INSERT ([email], [name]) VALUES (source.[email], source.[name]);",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("Expected upsert to contain %q:\n%s", want, sql)
+		}
+	}
+	if len(args) != 3 {
+		t.Errorf("Expected 3 args, got %v", args)
+	}
+}
+
+func TestMSSQL_FieldTypeFromSQL(t *testing.T) {
+	tests := []struct {
+		sqlType string
+		want    schema.FieldType
+	}{
+		{"BIT", schema.FieldTypeBool},
+		{"UNIQUEIDENTIFIER", schema.FieldTypeUUID},
+		{"NVARCHAR(MAX)", schema.FieldTypeText},
+		{"NVARCHAR(100)", schema.FieldTypeString},
+		{"DATETIME2", schema.FieldTypeDateTime},
+	}
+	for _, tt := range tests {
+		got, _, _, _, known := migration.FieldTypeFromSQL(tt.sqlType)
+		if !known || got != tt.want {
+			t.Errorf("FieldTypeFromSQL(%q) = %v, %v; want %v", tt.sqlType, got, known, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+func writeSchemaFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestParseFile_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeSchemaFile(t, dir, "users.nexus", `
+model User {
+  id    Int    @id @autoincrement
+  email String @unique
+}
+`)
+	writeSchemaFile(t, dir, "billing.nexus", `
+model Invoice {
+  id      Int  @id @autoincrement
+  user_id Int
+  owner   User @relation(fields: [user_id], references: [id])
+}
+`)
+
+	s, err := schema.ParseFile(dir)
+	if err != nil {
+		t.Fatalf("Failed to parse schema directory: %v", err)
+	}
+
+	models := s.GetModels()
+	if len(models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(models))
+	}
+	// Files load in name order
+	if models[0].Name != "Invoice" || models[1].Name != "User" {
+		t.Errorf("Expected Invoice, User; got %s, %s", models[0].Name, models[1].Name)
+	}
+}
+
+func TestParseFile_Imports(t *testing.T) {
+	dir := t.TempDir()
+	main := writeSchemaFile(t, dir, "schema.nexus", `
+import "domains/users.nexus"
+include "domains/inventory/*.nexus"
+
+model Order {
+  id      Int @id @autoincrement
+  user_id Int
+}
+`)
+	writeSchemaFile(t, dir, "domains/users.nexus", `
+// Imports resolve relative to this file, and repeated imports load once
+import "../schema.nexus"
+
+model User {
+  id Int @id @autoincrement
+  orders Order[]
+}
+`)
+	writeSchemaFile(t, dir, "domains/inventory/product.nexus", `
+model Product {
+  id Int @id @autoincrement
+}
+`)
+	writeSchemaFile(t, dir, "domains/inventory/stock.nexus", `
+model Stock {
+  id         Int @id @autoincrement
+  product_id Int
+}
+`)
+
+	s, err := schema.ParseFile(main)
+	if err != nil {
+		t.Fatalf("Failed to parse schema with imports: %v", err)
+	}
+
+	for _, name := range []string{"Order", "User", "Product", "Stock"} {
+		if _, ok := s.Models[name]; !ok {
+			t.Errorf("Expected model %s to be loaded", name)
+		}
+	}
+	if len(s.GetModels()) != 4 {
+		t.Errorf("Expected 4 models, got %d", len(s.GetModels()))
+	}
+}
+
+func TestParseFile_Errors(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "duplicate model",
+			files: map[string]string{
+				"a.nexus": "model User {\n  id Int @id\n}\n",
+				"b.nexus": "model User {\n  id Int @id\n}\n",
+			},
+			want: []string{"already declared in", "a.nexus"},
+		},
+		{
+			name: "missing import",
+			files: map[string]string{
+				"a.nexus": "import \"missing.nexus\"\n",
+			},
+			want: []string{"missing.nexus"},
+		},
+		{
+			name: "unknown model reference",
+			files: map[string]string{
+				"a.nexus": "model User {\n  id Int @id\n}\n",
+				"b.nexus": "model Post {\n  id Int @id\n  author Usr\n}\n",
+			},
+			want: []string{"Post.author", "Did you mean 'User'?"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range c.files {
+				writeSchemaFile(t, dir, name, content)
+			}
+
+			_, err := schema.ParseFile(dir)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			for _, want := range c.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error to contain %q, got:\n%s", want, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// namedRelationsSchema has two relations from Post to User and a
+// self-referential Category.
+func namedRelationsSchema() *schema.Schema {
+	s := schema.NewSchema()
+	s.Model("User", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("name")
+		m.HasMany("Post", "author_id").As("Authored")
+		m.HasMany("Post", "reviewer_id").As("Reviewed")
+	})
+	s.Model("Post", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("title")
+		m.Int("author_id")
+		m.Int("reviewer_id").Null()
+		m.BelongsTo("User", "author_id").As("Author")
+		m.BelongsTo("User", "reviewer_id").As("Reviewer")
+	})
+	s.Model("Category", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("name")
+		m.Int("parent_id").Null()
+		m.BelongsTo("Category", "parent_id").As("Parent")
+		m.HasMany("Category", "parent_id").As("Children")
+	}).Models["Category"].Map("categories")
+	return s
+}
+
+func TestNamedRelationsEagerLoad(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(db, sqlite.New())
+	ctx := context.Background()
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT, author_id INTEGER, reviewer_id INTEGER)`,
+		`CREATE TABLE categories (id INTEGER PRIMARY KEY, name TEXT, parent_id INTEGER)`,
+		`INSERT INTO users (id, name) VALUES (1, 'Ada'), (2, 'Grace')`,
+		`INSERT INTO posts (id, title, author_id, reviewer_id) VALUES (1, 'Notes', 1, 2), (2, 'Compilers', 2, NULL)`,
+		`INSERT INTO categories (id, name, parent_id) VALUES (1, 'Science', NULL), (2, 'Physics', 1), (3, 'Biology', 1)`,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := namedRelationsSchema()
+
+	posts, err := query.NewWithSchema(conn, "posts", s).Select().Include("Author", "Reviewer").OrderBy("id", query.Asc).All(ctx)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	author, _ := posts[0]["Author"].(query.Result)
+	reviewer, _ := posts[0]["Reviewer"].(query.Result)
+	if author["name"] != "Ada" || reviewer["name"] != "Grace" {
+		t.Errorf("Expected Ada as author and Grace as reviewer, got %v and %v", author, reviewer)
+	}
+	if _, ok := posts[1]["Reviewer"]; ok {
+		t.Errorf("Expected no reviewer for the second post, got %v", posts[1]["Reviewer"])
+	}
+
+	users, err := query.NewWithSchema(conn, "users", s).Select().Include("Reviewed").OrderBy("id", query.Asc).All(ctx)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if reviewed := users[1]["Reviewed"].(query.Results); len(reviewed) != 1 || reviewed[0]["title"] != "Notes" {
+		t.Errorf("Expected Grace to have reviewed Notes, got %v", reviewed)
+	}
+
+	// Self-referential relations use the mapped table
+	categories, err := query.NewWithSchema(conn, "categories", s).Select().Include("Children").Where(query.IsNull("parent_id")).All(ctx)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if children := categories[0]["Children"].(query.Results); len(children) != 2 {
+		t.Errorf("Expected 2 children of Science, got %v", children)
+	}
+
+	physics := query.NewLazyResult(query.Result{"id": int64(2), "parent_id": int64(1)}, conn, s, "categories")
+	parent, err := physics.GetRelation(ctx, "Parent")
+	if err != nil || parent == nil || parent.(*query.LazyResult).Get("name") != "Science" {
+		t.Errorf("Expected Science as parent, got %v (%v)", parent, err)
+	}
+}
+
+func TestCodegen_NamedRelations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	dir := generatedDir(t)
+	if err := codegen.NewGenerator(namedRelationsSchema(), "gen", dir).Generate(); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	queries, _ := os.ReadFile(filepath.Join(dir, "queries.go"))
+	for _, want := range []string{
+		"func (m *Post) Author(ctx context.Context) (*User, error)",
+		"func (m *Post) Reviewer(ctx context.Context) (*User, error)",
+		"func (m *Category) Parent(ctx context.Context) (*Category, error)",
+		"func (m *Category) Children(ctx context.Context) ([]*Category, error)",
+		"func (q *UserQuery) IncludeReviewed() *UserQuery",
+	} {
+		if !strings.Contains(string(queries), want) {
+			t.Errorf("Expected generated code to contain %q", want)
+		}
+	}
+
+	cmd := exec.Command(goTool, "vet", "./"+filepath.ToSlash(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Generated code failed:\n%s", out)
+	}
+}
+
+func TestDescendantsAndAncestors(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(db, sqlite.New())
+	ctx := context.Background()
+	for _, stmt := range []string{
+		`CREATE TABLE categories (id INTEGER PRIMARY KEY, name TEXT, parent_id INTEGER)`,
+		`INSERT INTO categories VALUES (1, 'Science', NULL), (2, 'Physics', 1), (3, 'Optics', 2), (4, 'Lasers', 3), (5, 'Art', NULL)`,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	categories := query.NewWithSchema(conn, "categories", namedRelationsSchema())
+
+	names := func(q *query.CTESelectBuilder, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := q.OrderBy("id", query.Asc).All(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, row := range rows {
+			names = append(names, row["name"].(string))
+		}
+		return names
+	}
+
+	if got := names(categories.Descendants("Parent", 1)); strings.Join(got, ",") != "Physics,Optics,Lasers" {
+		t.Errorf("Expected the descendants of Science, got %v", got)
+	}
+	if got := names(categories.Descendants("Children", 3)); strings.Join(got, ",") != "Lasers" {
+		t.Errorf("Expected the descendants of Optics, got %v", got)
+	}
+	if got := names(categories.Ancestors("Parent", 4)); strings.Join(got, ",") != "Science,Physics,Optics" {
+		t.Errorf("Expected the ancestors of Lasers, got %v", got)
+	}
+	if got := names(categories.Ancestors("Parent", 5)); len(got) != 0 {
+		t.Errorf("Expected no ancestors of a root, got %v", got)
+	}
+
+	posts := query.NewWithSchema(conn, "posts", namedRelationsSchema())
+	if _, err := posts.Descendants("Author", 1); err == nil {
+		t.Error("Expected an error for a relation to another model")
+	}
+}
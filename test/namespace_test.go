@@ -0,0 +1,212 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/internal/studio"
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+const namespaceSchema = `
+model User {
+  id    Int    @id @autoincrement
+  email String @unique
+}
+
+model Invoice {
+  id      Int    @id @autoincrement
+  user_id Int
+  number  String
+
+  @@index([user_id])
+  @@schema("billing")
+  @@map("invoices")
+}
+
+model Payment {
+  id         Int @id @autoincrement
+  invoice_id Int
+
+  @@schema("billing")
+}
+`
+
+func TestNamespace_Parse(t *testing.T) {
+	s, err := schema.NewParser(namespaceSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	invoice := s.Models["Invoice"]
+	if invoice.SchemaName != "billing" || invoice.Table() != "billing.invoices" || s.Models["User"].Table() != "User" {
+		t.Fatalf("Unexpected tables %q and %q", invoice.Table(), s.Models["User"].Table())
+	}
+	if invoice.Indexes[0].Name != "idx_invoices_user_id" {
+		t.Errorf("Expected the index named after the bare table, got %s", invoice.Indexes[0].Name)
+	}
+	if ns, table := schema.SplitTable("billing.invoices"); ns != "billing" || table != "invoices" {
+		t.Errorf("Unexpected split %q %q", ns, table)
+	}
+
+	formatted := schema.Format(s)
+	if !strings.Contains(formatted, `@@schema("billing")`) || strings.Contains(formatted, "name:") {
+		t.Errorf("Formatting lost the schema:\n%s", formatted)
+	}
+	again, err := schema.NewParser(formatted).Parse()
+	if err != nil || again.Models["Payment"].Table() != "billing.Payment" {
+		t.Errorf("Expected the formatted schema to round-trip, got %v", err)
+	}
+
+	built := schema.NewSchema().Model("Ledger", func(m *schema.Model) {
+		m.Int("id").PrimaryKey()
+		m.Schema("accounting").Map("ledgers")
+	})
+	if table := built.Models["Ledger"].Table(); table != "accounting.ledgers" {
+		t.Errorf("Expected accounting.ledgers, got %s", table)
+	}
+
+	_, err = schema.NewParser("model A {\n  id Int @id\n  @@schema(\"a.b\")\n}\n").Parse()
+	if err == nil || !strings.Contains(err.Error(), "@@schema expects a quoted schema name") {
+		t.Errorf("Expected a qualified schema name to be rejected, got %v", err)
+	}
+}
+
+func TestNamespace_DDL(t *testing.T) {
+	s, err := schema.NewParser(namespaceSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	invoice := s.Models["Invoice"]
+
+	pg := postgres.New()
+	if sql := pg.CreateTableSQL(invoice); !strings.HasPrefix(sql, `CREATE TABLE IF NOT EXISTS "billing"."invoices" (`) {
+		t.Errorf("Expected a qualified table, got:\n%s", sql)
+	}
+	if sql := pg.CreateIndexSQL(invoice.Table(), invoice.Indexes[0]); sql != `CREATE INDEX IF NOT EXISTS "idx_invoices_user_id" ON "billing"."invoices" ("user_id")` {
+		t.Errorf("Unexpected index: %s", sql)
+	}
+	if sql := pg.DropIndexSQL(invoice.Table(), "idx_invoices_user_id"); sql != `DROP INDEX IF EXISTS "billing"."idx_invoices_user_id"` {
+		t.Errorf("Expected the index qualified with its table's schema, got %s", sql)
+	}
+	if got := mysql.New().QuoteQualified("billing", "invoices"); got != "`billing`.`invoices`" {
+		t.Errorf("Unexpected MySQL identifier %s", got)
+	}
+	if got := mysql.New().Quote("billing.invoices"); got != "`billing.invoices`" {
+		t.Errorf("Expected a dot to stay part of the identifier, got %s", got)
+	}
+	if got := mssql.New().CreateSchemaSQL("billing"); got != "IF SCHEMA_ID(N'billing') IS NULL EXEC(N'CREATE SCHEMA [billing]')" {
+		t.Errorf("Unexpected SQL Server schema: %s", got)
+	}
+
+	// Queries on the model's table are qualified too
+	sql, _ := query.NewWithSchema(dialects.NewConnection(nil, pg), invoice.Table(), s).Select("id").Where(query.Eq("user_id", 1)).Build()
+	if !strings.Contains(sql, `FROM "billing"."invoices"`) {
+		t.Errorf("Expected a qualified query, got %s", sql)
+	}
+
+	// The generated client places the builder in the schema
+	dir := t.TempDir()
+	if err := codegen.NewGenerator(s, "db", dir).Generate(); err != nil {
+		t.Fatal(err)
+	}
+	queries, _ := os.ReadFile(filepath.Join(dir, "queries.go"))
+	if !strings.Contains(string(queries), `query.New(db.conn, "invoices").InSchema("billing")`) {
+		t.Errorf("Expected the Invoice query in the billing schema:\n%s", queries)
+	}
+
+	// The schema is created once, before its first table
+	m, err := migration.GenerateMigrationFromDiff(pg, migration.Diff(s, migration.NewDatabaseSnapshot()).Changes, "billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(m.UpSQL, `CREATE SCHEMA IF NOT EXISTS "billing"`) != 1 ||
+		strings.Index(m.UpSQL, "CREATE SCHEMA") > strings.Index(m.UpSQL, `"billing"."invoices"`) {
+		t.Errorf("Expected the billing schema created first:\n%s", m.UpSQL)
+	}
+}
+
+func TestNamespace_Diff(t *testing.T) {
+	s, err := schema.NewParser(namespaceSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	db := migration.NewDatabaseSnapshot()
+	for _, name := range []string{"User", "billing.invoices", "billing.refunds", "audit.events"} {
+		db.Tables[name] = &migration.TableInfo{Name: name, Columns: map[string]*migration.ColumnInfo{}}
+	}
+	for _, model := range s.GetModels() {
+		if table, ok := db.Tables[model.Table()]; ok {
+			for _, f := range model.GetFields() {
+				table.Columns[f.Name] = &migration.ColumnInfo{Name: f.Name}
+			}
+			table.Indexes = map[string]*migration.IndexInfo{}
+			for _, idx := range model.Indexes {
+				table.Indexes[idx.Name] = &migration.IndexInfo{Name: idx.Name, Columns: idx.Fields}
+			}
+		}
+	}
+
+	// Schemas the schema file doesn't use are left alone
+	got := strings.Join(migration.DescribeChanges(migration.Diff(s, db).Changes), "\n")
+	if got != "+ CREATE TABLE billing.Payment\n- DROP TABLE billing.refunds" {
+		t.Errorf("Unexpected changes:\n%s", got)
+	}
+
+	pulled, _ := migration.SchemaFromSnapshot(db)
+	if m := pulled.Models["invoices"]; m == nil || m.SchemaName != "billing" || m.Table() != "billing.invoices" {
+		t.Errorf("Expected the billing tables pulled with @@schema, got %+v", pulled.Models)
+	}
+}
+
+func TestNamespace_SQLite(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	conn.DB.SetMaxOpenConns(1) // Attached databases are per connection
+	if _, err := conn.Exec(ctx, `ATTACH DATABASE ':memory:' AS billing`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(ctx, `CREATE TABLE billing.invoices (id INTEGER PRIMARY KEY, number TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+
+	invoices := query.New(conn, "invoices").InSchema("billing")
+	if _, err := invoices.Insert(map[string]interface{}{"number": "A-1"}).Exec(ctx); err != nil {
+		t.Fatalf("Insert into a qualified table failed: %v", err)
+	}
+	rows, err := invoices.Select("number").All(ctx)
+	if err != nil || len(rows) != 1 || rows[0]["number"] != "A-1" {
+		t.Errorf("Expected the inserted invoice, got %v %v", rows, err)
+	}
+
+	// A dot in a plain table name is part of the name
+	if _, err := conn.Exec(ctx, `CREATE TABLE "app.users" (id INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := query.New(conn, "app.users").Insert(map[string]interface{}{"id": 1}).Exec(ctx); err != nil {
+		t.Errorf("Insert into a dotted table failed: %v", err)
+	}
+	conn.Exec(ctx, `DROP TABLE "app.users"`)
+
+	// The studio groups tables by schema; SQLite lists those of main only
+	conn.Exec(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY)`)
+	h := studio.NewServer(studio.Config{Connection: conn}).Handler()
+	code, resp := studioRequest(t, h, http.MethodGet, "/api/tables", "")
+	groups, _ := resp["schemas"].([]interface{})
+	if code != http.StatusOK || len(groups) != 1 {
+		t.Fatalf("Unexpected tables: %d %v", code, resp)
+	}
+	if group := groups[0].(map[string]interface{}); group["schema"] != "" || len(group["tables"].([]interface{})) != 1 {
+		t.Errorf("Expected users in the default schema, got %v", group)
+	}
+}
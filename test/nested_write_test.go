@@ -0,0 +1,168 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func setupNestedDB(t *testing.T) (*dialects.Connection, *schema.Schema) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	conn := dialects.NewConnection(db, sqlite.New())
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)`,
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, user_id INTEGER NOT NULL)`,
+		`CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)`,
+		`CREATE TABLE user_tags (user_id INTEGER, tag_id INTEGER, PRIMARY KEY (user_id, tag_id))`,
+		`INSERT INTO tags (name) VALUES ('go'), ('sql'), ('orm')`,
+	} {
+		if _, err := conn.Exec(context.Background(), stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := schema.NewSchema()
+	s.Model("User", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("name")
+		m.HasMany("Post", "user_id")
+		m.BelongsToMany("Tag", "user_tags", "user_id", "tag_id")
+	})
+	s.Model("Post", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("title")
+		m.Int("user_id")
+		m.BelongsTo("User", "user_id")
+	})
+	s.Model("Tag", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("name")
+	})
+	return conn, s
+}
+
+func countRows(t *testing.T, conn *dialects.Connection, query string) int {
+	t.Helper()
+	var n int
+	if err := conn.DB.QueryRow(query).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestInsertWithRelations(t *testing.T) {
+	conn, s := setupNestedDB(t)
+	ctx := context.Background()
+	users := query.NewWithSchema(conn, "users", s)
+
+	user, err := users.InsertWithRelations(ctx, map[string]interface{}{
+		"name": "Ada",
+		"Posts": []map[string]interface{}{
+			{"title": "Notes"},
+			{"title": "Engines"},
+		},
+		"Tag": map[string]interface{}{
+			"connect": []int{1, 2},
+			"create":  []interface{}{map[string]interface{}{"name": "math"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InsertWithRelations failed: %v", err)
+	}
+	if user["name"] != "Ada" || user["id"] == nil {
+		t.Errorf("Expected the inserted user, got %v", user)
+	}
+	if posts, ok := user["Posts"].(query.Results); !ok || len(posts) != 2 || posts[0]["user_id"] != user["id"] {
+		t.Errorf("Expected 2 posts of the user, got %v", user["Posts"])
+	}
+	if got := countRows(t, conn, `SELECT COUNT(*) FROM user_tags WHERE user_id = 1`); got != 3 {
+		t.Errorf("Expected 3 tags linked, got %d", got)
+	}
+
+	// A belongs-to parent is created first and wired to the child
+	post, err := query.NewWithSchema(conn, "posts", s).InsertWithRelations(ctx, map[string]interface{}{
+		"title": "Compilers",
+		"User":  map[string]interface{}{"name": "Grace"},
+	})
+	if err != nil {
+		t.Fatalf("InsertWithRelations failed: %v", err)
+	}
+	if post["user_id"] != int64(2) {
+		t.Errorf("Expected the post to belong to the new user, got %v", post)
+	}
+
+	// A failing child rolls back the whole write
+	_, err = users.InsertWithRelations(ctx, map[string]interface{}{
+		"name":  "Bob",
+		"Posts": []map[string]interface{}{{"title": nil}},
+	})
+	if err == nil {
+		t.Fatal("Expected the NOT NULL violation to fail the insert")
+	}
+	if got := countRows(t, conn, `SELECT COUNT(*) FROM users`); got != 2 {
+		t.Errorf("Expected the user to be rolled back, got %d users", got)
+	}
+}
+
+func TestUpdateWithRelations(t *testing.T) {
+	conn, s := setupNestedDB(t)
+	ctx := context.Background()
+	users := query.NewWithSchema(conn, "users", s)
+	for _, name := range []string{"Ada", "Grace"} {
+		if _, err := users.InsertWithRelations(ctx, map[string]interface{}{
+			"name":  name,
+			"Posts": []map[string]interface{}{{"title": name + " 1"}, {"title": name + " 2"}},
+			"Tag":   []interface{}{1, 2},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := users.UpdateWithRelations(ctx, 1, map[string]interface{}{
+		"name": "Ada Lovelace",
+		"Posts": map[string]interface{}{
+			"update": []map[string]interface{}{{"id": 1, "title": "Edited"}},
+			"delete": []interface{}{2, 3}, // Post 3 is Grace's and is kept
+			"create": map[string]interface{}{"title": "New"},
+		},
+		"Tag": map[string]interface{}{"set": []interface{}{2, 3}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateWithRelations failed: %v", err)
+	}
+
+	rows, _ := query.New(conn, "posts").Select("id", "title").Where(query.Eq("user_id", 1)).OrderBy("id", query.Asc).All(ctx)
+	if len(rows) != 2 || rows[0]["title"] != "Edited" || rows[1]["title"] != "New" {
+		t.Errorf("Expected the edited and the new post, got %v", rows)
+	}
+	if got := countRows(t, conn, `SELECT COUNT(*) FROM posts WHERE id = 3`); got != 1 {
+		t.Error("Expected another user's post to be kept")
+	}
+	if got := countRows(t, conn, `SELECT COUNT(*) FROM user_tags WHERE user_id = 1 AND tag_id IN (2, 3)`); got != 2 {
+		t.Errorf("Expected tags 2 and 3, got %d", got)
+	}
+	if got := countRows(t, conn, `SELECT COUNT(*) FROM user_tags WHERE user_id = 1`); got != 2 {
+		t.Errorf("Expected tag 1 to be unlinked, got %d links", got)
+	}
+
+	// Updating another user's post through this user fails
+	err = users.UpdateWithRelations(ctx, 1, map[string]interface{}{
+		"Posts": map[string]interface{}{"update": []map[string]interface{}{{"id": 4, "title": "Stolen"}}},
+	})
+	if err == nil {
+		t.Error("Expected an error updating a post of another user")
+	}
+}
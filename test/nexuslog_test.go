@@ -0,0 +1,195 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/seed"
+	"github.com/nexus-db/nexus/pkg/nexuslog"
+)
+
+// recordingLogger keeps the entries logged to it as "level msg k=v".
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (r *recordingLogger) add(level, msg string, fields []nexuslog.Field) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := level + " " + msg
+	for _, f := range fields {
+		entry += fmt.Sprintf(" %s=%v", f.Key, f.Value)
+	}
+	r.entries = append(r.entries, entry)
+}
+
+func (r *recordingLogger) Debug(msg string, fields ...nexuslog.Field) { r.add("debug", msg, fields) }
+func (r *recordingLogger) Info(msg string, fields ...nexuslog.Field)  { r.add("info", msg, fields) }
+func (r *recordingLogger) Warn(msg string, fields ...nexuslog.Field)  { r.add("warn", msg, fields) }
+func (r *recordingLogger) Error(msg string, fields ...nexuslog.Field) { r.add("error", msg, fields) }
+
+func (r *recordingLogger) has(prefix string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if strings.HasPrefix(e, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNexuslogText(t *testing.T) {
+	var buf bytes.Buffer
+	log := nexuslog.NewText(&buf)
+	log.Info("Change detected", nexuslog.F("file", "schema.nexus"))
+	log.Warn("Watcher error", nexuslog.Err(errors.New("too many files")))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+	if !regexp.MustCompile(`^\[\d\d:\d\d:\d\d\] Change detected file=schema\.nexus$`).MatchString(lines[0]) {
+		t.Errorf("Unexpected info line: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `] ⚠ Watcher error error="too many files"`) {
+		t.Errorf("Unexpected warn line: %q", lines[1])
+	}
+}
+
+func TestNexuslogLevels(t *testing.T) {
+	levels, err := nexuslog.ParseLevels("warn", map[string]string{"migrate": "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nexuslog.ParseLevels("loud", nil); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+
+	rec := &recordingLogger{}
+	migrate := levels.For(rec, nexuslog.SubsystemMigrate)
+	studio := levels.For(rec, nexuslog.SubsystemStudio)
+	migrate.Debug("Running migration")
+	studio.Info("Listening")
+	studio.Warn("Slow request")
+
+	want := []string{
+		"debug Running migration subsystem=migrate",
+		"warn Slow request subsystem=studio",
+	}
+	if strings.Join(rec.entries, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected %q, got %q", want, rec.entries)
+	}
+}
+
+func TestNexuslogContext(t *testing.T) {
+	rec := &recordingLogger{}
+	ctx := nexuslog.NewContext(context.Background(), nexuslog.With(rec, nexuslog.F("request", 7)))
+	nexuslog.FromContext(ctx).Info("Handled")
+	nexuslog.FromContext(context.Background()).Info("Dropped")
+
+	if len(rec.entries) != 1 || rec.entries[0] != "info Handled request=7" {
+		t.Errorf("Unexpected entries: %q", rec.entries)
+	}
+}
+
+func TestNexuslogSlog(t *testing.T) {
+	var buf bytes.Buffer
+	log := nexuslog.Slog(slog.New(slog.NewJSONHandler(&buf, nil)))
+	log.Error("Seed failed", nexuslog.F("seed", "users"), nexuslog.Err(errors.New("boom")))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", buf.String(), err)
+	}
+	if entry["level"] != "ERROR" || entry["msg"] != "Seed failed" || entry["seed"] != "users" || entry["error"] != "boom" {
+		t.Errorf("Unexpected entry: %v", entry)
+	}
+}
+
+// sugared records calls the way *zap.SugaredLogger receives them.
+type sugared struct {
+	calls []string
+}
+
+func (s *sugared) record(level, msg string, kv []interface{}) {
+	s.calls = append(s.calls, fmt.Sprint(level, " ", msg, " ", kv))
+}
+
+func (s *sugared) Debugw(msg string, kv ...interface{}) { s.record("debug", msg, kv) }
+func (s *sugared) Infow(msg string, kv ...interface{})  { s.record("info", msg, kv) }
+func (s *sugared) Warnw(msg string, kv ...interface{})  { s.record("warn", msg, kv) }
+func (s *sugared) Errorw(msg string, kv ...interface{}) { s.record("error", msg, kv) }
+
+func TestNexuslogZap(t *testing.T) {
+	s := &sugared{}
+	nexuslog.Zap(s).Warn("Lock renewal failed", nexuslog.F("backend", "file"), nexuslog.F("attempt", 2))
+
+	if len(s.calls) != 1 || s.calls[0] != "warn Lock renewal failed [backend file attempt 2]" {
+		t.Errorf("Unexpected calls: %q", s.calls)
+	}
+}
+
+func TestNexuslogMigrationEngine(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	content := "-- UP\nCREATE TABLE logged (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE logged;\n"
+	if err := os.WriteFile(filepath.Join(dir, "20240101_100000_logged.sql"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &recordingLogger{}
+	engine := migration.NewEngine(lockConn(t)).WithLogger(rec)
+	engine.Init(ctx)
+	engine.LoadFromDir(dir)
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if err := engine.Down(ctx); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+
+	if !rec.has("info Applied migration migration=20240101_100000 name=logged") {
+		t.Errorf("Expected the applied migration to be logged, got %q", rec.entries)
+	}
+	if !rec.has("info Rolled back migration migration=20240101_100000") {
+		t.Errorf("Expected the rollback to be logged, got %q", rec.entries)
+	}
+}
+
+func TestNexuslogSeedEngineFromContext(t *testing.T) {
+	conn := setupSeedTestDB(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "001_ok.sql"), []byte("INSERT INTO users (email) VALUES ('a@example.com');"), 0644)
+	os.WriteFile(filepath.Join(dir, "002_bad.sql"), []byte("INSERT INTO missing (x) VALUES (1);"), 0644)
+
+	rec := &recordingLogger{}
+	ctx := nexuslog.NewContext(context.Background(), rec)
+	engine := seed.NewEngine(conn)
+	engine.Init(ctx)
+	if err := engine.LoadFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Run(ctx, ""); err == nil {
+		t.Fatal("Expected the bad seed to fail")
+	}
+
+	if !rec.has("info Applied seed seed=ok") {
+		t.Errorf("Expected the applied seed to be logged, got %q", rec.entries)
+	}
+	if !rec.has("error Seed failed seed=bad") {
+		t.Errorf("Expected the failed seed to be logged, got %q", rec.entries)
+	}
+}
@@ -0,0 +1,193 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+const numericSchema = `
+model Product {
+  id       Int      @id @autoincrement
+  stock    SmallInt @unsigned
+  priority TinyInt
+  level    TinyInt  @unsigned
+  views    BigInt   @unsigned
+  price    Money
+  fee      Money    @precision(10,2)
+}
+`
+
+func TestNumericTypes_Parse(t *testing.T) {
+	s, err := schema.NewParser(numericSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	product := s.Models["Product"]
+	if f := product.Fields["stock"]; f.Type != schema.FieldTypeSmallInt || !f.IsUnsigned {
+		t.Errorf("Unexpected stock field %+v", f)
+	}
+	if f := product.Fields["price"]; f.Type != schema.FieldTypeMoney || f.Precision != 19 || f.Scale != 4 {
+		t.Errorf("Expected Money to default to 19,4, got %+v", f)
+	}
+	if f := product.Fields["fee"]; f.Precision != 10 || f.Scale != 2 {
+		t.Errorf("Expected @precision to change Money, got %+v", f)
+	}
+	if lo, hi := product.Fields["level"].IntegerRange(); lo != 0 || hi != 255 {
+		t.Errorf("Unexpected unsigned TinyInt range %d..%d", lo, hi)
+	}
+
+	formatted := schema.Format(s)
+	for _, want := range []string{"SmallInt @unsigned", "price    Money\n", "Money    @precision(10,2)"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("Expected %q in:\n%s", want, formatted)
+		}
+	}
+
+	built := schema.NewSchema().Model("A", func(m *schema.Model) {
+		m.Int("id").PrimaryKey()
+		m.Money("total").Unsigned()
+	})
+	if err := built.Validate(); err == nil || !strings.Contains(err.Error(), "@unsigned on field \"total\"") {
+		t.Errorf("Expected @unsigned on Money to be rejected, got %v", err)
+	}
+}
+
+func TestNumericTypes_DDL(t *testing.T) {
+	s, err := schema.NewParser(numericSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	product := s.Models["Product"]
+
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"postgres", postgres.New().CreateTableSQL(product), []string{
+			`"stock" SMALLINT NOT NULL CHECK ("stock" >= 0)`,
+			`"priority" SMALLINT NOT NULL,`,
+			`"views" BIGINT NOT NULL CHECK ("views" >= 0)`,
+			`"price" NUMERIC(19,4) NOT NULL`,
+			`"fee" NUMERIC(10,2) NOT NULL`,
+		}},
+		{"mysql", mysql.New().CreateTableSQL(product), []string{
+			"`stock` SMALLINT UNSIGNED NOT NULL,",
+			"`priority` TINYINT NOT NULL,",
+			"`level` TINYINT UNSIGNED NOT NULL,",
+			"`price` DECIMAL(19,4) NOT NULL",
+		}},
+		{"mssql", mssql.New().CreateTableSQL(product), []string{
+			"[stock] SMALLINT NOT NULL CHECK ([stock] >= 0)",
+			"[priority] SMALLINT NOT NULL,",
+			"[level] TINYINT NOT NULL,",
+			"[price] MONEY NOT NULL",
+			"[fee] DECIMAL(10,2) NOT NULL",
+		}},
+		{"sqlite", sqlite.New().CreateTableSQL(product), []string{
+			`"stock" SMALLINT NOT NULL CHECK ("stock" >= 0)`,
+			`"priority" TINYINT NOT NULL`,
+			`"price" NUMERIC(19,4) NOT NULL`,
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.sql, want) {
+				t.Errorf("%s: expected %s in:\n%s", tt.name, want, tt.sql)
+			}
+		}
+	}
+
+	if got := mssql.New().TypeMapping(&schema.Field{Type: schema.FieldTypeMoney, Precision: 10, Scale: 4}); got != "SMALLMONEY" {
+		t.Errorf("Expected SMALLMONEY, got %s", got)
+	}
+}
+
+func TestNumericTypes_FieldTypeFromSQL(t *testing.T) {
+	cases := []struct {
+		sqlType string
+		want    schema.FieldType
+	}{
+		{"smallint", schema.FieldTypeSmallInt},
+		{"int2", schema.FieldTypeSmallInt},
+		{"smallint(5) unsigned", schema.FieldTypeSmallInt},
+		{"tinyint", schema.FieldTypeTinyInt},
+		{"tinyint unsigned", schema.FieldTypeTinyInt},
+		{"TINYINT(1)", schema.FieldTypeBool},
+		{"money", schema.FieldTypeMoney},
+		{"numeric(19,4)", schema.FieldTypeMoney},
+		{"numeric(19,2)", schema.FieldTypeDecimal},
+		{"int unsigned", schema.FieldTypeInt},
+	}
+	for _, c := range cases {
+		if got, _, _, _, known := migration.FieldTypeFromSQL(c.sqlType); !known || got != c.want {
+			t.Errorf("FieldTypeFromSQL(%q) = %s, want %s", c.sqlType, got, c.want)
+		}
+	}
+	if _, _, precision, scale, _ := migration.FieldTypeFromSQL("SMALLMONEY"); precision != 10 || scale != 4 {
+		t.Errorf("Expected SMALLMONEY to be Money(10,4), got %d,%d", precision, scale)
+	}
+
+	// MySQL reports unsigned columns, PostgreSQL a check
+	db := migration.NewDatabaseSnapshot()
+	db.Tables["items"] = &migration.TableInfo{
+		Name: "items",
+		Columns: map[string]*migration.ColumnInfo{
+			"id":    {Name: "id", Type: "int unsigned", IsPrimaryKey: true, Unsigned: true},
+			"stock": {Name: "stock", Type: "smallint", Position: 1},
+		},
+		Checks: map[string]*migration.CheckInfo{
+			"items_stock_check": {Name: "items_stock_check", Expression: "CHECK ((stock >= 0))"},
+		},
+	}
+	pulled, warnings := migration.SchemaFromSnapshot(db)
+	items := pulled.Models["items"]
+	if len(warnings) != 0 || !items.Fields["id"].IsUnsigned || !items.Fields["stock"].IsUnsigned {
+		t.Errorf("Expected unsigned fields without warnings, got %v %+v", warnings, items.Fields)
+	}
+}
+
+func TestNumericTypes_SQLite(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	s, err := schema.NewParser(numericSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if _, err := conn.Exec(ctx, conn.Dialect.CreateTableSQL(s.Models["Product"])); err != nil {
+		t.Fatal(err)
+	}
+
+	products := query.New(conn, "Product")
+	row := map[string]interface{}{"stock": -1, "priority": 1, "level": 1, "views": 1, "price": "9.99", "fee": "0.5"}
+	if _, err := products.Insert(row).Exec(ctx); err == nil {
+		t.Error("Expected a negative unsigned value to be rejected")
+	}
+
+	// Introspection maps the columns back to the same types
+	snapshot, err := migration.IntrospectDatabase(ctx, conn.DB, sqlite.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulled, _ := migration.SchemaFromSnapshot(snapshot)
+	product := pulled.Models["Product"]
+	for name, want := range map[string]schema.FieldType{
+		"stock": schema.FieldTypeSmallInt, "priority": schema.FieldTypeTinyInt, "price": schema.FieldTypeMoney,
+	} {
+		if f := product.Fields[name]; f == nil || f.Type != want {
+			t.Errorf("Expected %s to be pulled as %s, got %+v", name, want, f)
+		}
+	}
+	if !product.Fields["stock"].IsUnsigned {
+		t.Error("Expected the check of stock to be pulled as @unsigned")
+	}
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/outbox"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestOutboxRelay(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "outbox.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	conn := dialects.NewConnection(db, sqlite.New())
+	ctx := context.Background()
+	if _, err := conn.Exec(ctx, `CREATE TABLE orders (id INTEGER PRIMARY KEY, total INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	box := outbox.New(conn, outbox.Options{})
+	if err := box.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	for id := 1; id <= 3; id++ {
+		err := query.Transaction(ctx, conn, func(tx *dialects.Tx) error {
+			if _, err := tx.Exec(ctx, `INSERT INTO orders (id, total) VALUES (?, ?)`, id, id*10); err != nil {
+				return err
+			}
+			return outbox.Emit(ctx, tx, "order.created", map[string]int{"id": id})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An event of a rolled back transaction is never published
+	_ = query.Transaction(ctx, conn, func(tx *dialects.Tx) error {
+		if err := outbox.Emit(ctx, tx, "order.created", map[string]int{"id": 4}); err != nil {
+			return err
+		}
+		return errors.New("payment declined")
+	})
+	if n, _ := box.Pending(ctx); n != 3 {
+		t.Fatalf("Expected 3 pending events, got %d", n)
+	}
+
+	var delivered []int
+	broker := outbox.PublisherFunc(func(ctx context.Context, event *outbox.Event) error {
+		var order map[string]int
+		if err := event.Decode(&order); err != nil {
+			return err
+		}
+		if order["id"] == 2 && event.Attempts == 0 {
+			return errors.New("broker unavailable")
+		}
+		delivered = append(delivered, order["id"])
+		return nil
+	})
+	relay := outbox.NewRelay(box, broker, outbox.RelayOptions{BatchSize: 2})
+
+	// Delivery stops at the failed event so that order is kept
+	n, err := relay.Flush(ctx)
+	if err == nil || n != 1 {
+		t.Fatalf("Expected 1 event delivered before the failure, got %d (%v)", n, err)
+	}
+	n, err = relay.Flush(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("Expected the 2 remaining events delivered, got %d (%v)", n, err)
+	}
+	if len(delivered) != 3 || delivered[0] != 1 || delivered[1] != 2 || delivered[2] != 3 {
+		t.Errorf("Expected events 1, 2 and 3 in order, got %v", delivered)
+	}
+	if n, _ := box.Pending(ctx); n != 0 {
+		t.Errorf("Expected no pending events, got %d", n)
+	}
+
+	if n, err := box.Prune(ctx, time.Now().Add(time.Minute)); err != nil || n != 3 {
+		t.Errorf("Expected 3 events pruned, got %d (%v)", n, err)
+	}
+}
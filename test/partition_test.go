@@ -0,0 +1,202 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mysql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+const partitionSchema = `
+model Event {
+  id         Int      @id @autoincrement
+  created_at DateTime @default(now())
+  payload    String
+
+  @@partition(range: [created_at], interval: monthly)
+}
+
+model Session {
+  id        Int @id
+  tenant_id Int
+
+  @@partition(hash: [tenant_id], partitions: 4)
+}
+`
+
+func TestPartition_Parse(t *testing.T) {
+	s, err := schema.NewParser(partitionSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Expected a valid schema: %v", err)
+	}
+	if p := s.Models["Event"].Partition; p == nil || p.Strategy != schema.Range || p.Interval != schema.Monthly {
+		t.Fatalf("Unexpected partitioning %+v", p)
+	}
+	if p := s.Models["Session"].Partition; p == nil || p.Strategy != schema.Hash || p.Count != 4 {
+		t.Fatalf("Unexpected partitioning %+v", p)
+	}
+
+	formatted := schema.Format(s)
+	if !strings.Contains(formatted, "@@partition(range: [created_at], interval: monthly)") ||
+		!strings.Contains(formatted, "@@partition(hash: [tenant_id], partitions: 4)") {
+		t.Errorf("Formatting lost partitioning:\n%s", formatted)
+	}
+
+	bad := schema.NewSchema().Model("Log", func(m *schema.Model) {
+		m.Int("id").PrimaryKey()
+		m.Int("day")
+		m.String("code").Unique()
+		m.PartitionBy(schema.Range, "day").Monthly()
+	})
+	err = bad.Validate()
+	if err == nil || !strings.Contains(err.Error(), "must be a DateTime") || !strings.Contains(err.Error(), `unique field "code"`) {
+		t.Errorf("Expected type and unique key errors, got %v", err)
+	}
+}
+
+func TestPartition_DDL(t *testing.T) {
+	s, err := schema.NewParser(partitionSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	event, session := s.Models["Event"], s.Models["Session"]
+
+	pg := postgres.New()
+	sql := pg.CreateTableSQL(event)
+	if !strings.Contains(sql, `PRIMARY KEY ("id", "created_at")`) || !strings.HasSuffix(sql, `) PARTITION BY RANGE ("created_at")`) ||
+		strings.Contains(sql, "SERIAL PRIMARY KEY") {
+		t.Errorf("Unexpected PostgreSQL DDL:\n%s", sql)
+	}
+	if got := migration.PartitionStatements(pg, event); len(got) != 1 || got[0] != `CREATE TABLE IF NOT EXISTS "Event_default" PARTITION OF "Event" DEFAULT` {
+		t.Errorf("Unexpected initial partitions %q", got)
+	}
+	hash := migration.PartitionStatements(pg, session)
+	if len(hash) != 4 || hash[3] != `CREATE TABLE IF NOT EXISTS "Session_p3" PARTITION OF "Session" FOR VALUES WITH (MODULUS 4, REMAINDER 3)` {
+		t.Errorf("Unexpected hash partitions %q", hash)
+	}
+
+	oct := event.Partition.RangePartitions(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), 0)[0]
+	if got := pg.AddPartitionSQL(event, oct); got != `CREATE TABLE IF NOT EXISTS "Event_2026_10" PARTITION OF "Event" FOR VALUES FROM ('2026-10-01 00:00:00+00') TO ('2026-11-01 00:00:00+00')` {
+		t.Errorf("Unexpected partition %s", got)
+	}
+
+	my := mysql.New()
+	if sql := my.CreateTableSQL(event); !strings.HasSuffix(sql, "PARTITION BY RANGE COLUMNS(`created_at`) (PARTITION p_max VALUES LESS THAN (MAXVALUE))") {
+		t.Errorf("Unexpected MySQL DDL:\n%s", sql)
+	}
+	if sql := my.CreateTableSQL(session); !strings.HasSuffix(sql, "PARTITION BY KEY(`tenant_id`) PARTITIONS 4") || !strings.Contains(sql, "PRIMARY KEY (`id`, `tenant_id`)") {
+		t.Errorf("Unexpected MySQL DDL:\n%s", sql)
+	}
+	if got := my.AddPartitionSQL(event, oct); got != "ALTER TABLE `Event` REORGANIZE PARTITION p_max INTO (PARTITION p2026_10 VALUES LESS THAN ('2026-11-01 00:00:00'), PARTITION p_max VALUES LESS THAN (MAXVALUE))" {
+		t.Errorf("Unexpected partition %s", got)
+	}
+
+	// Other dialects ignore partitioning
+	if got := migration.PartitionStatements(sqlite.New(), event); got != nil {
+		t.Errorf("Expected no partitions on SQLite, got %q", got)
+	}
+}
+
+func TestPartition_RangePartitions(t *testing.T) {
+	p := &schema.Partitioning{Strategy: schema.Range, Columns: []string{"created_at"}, Interval: schema.Monthly}
+	parts := p.RangePartitions(time.Date(2026, 11, 30, 23, 0, 0, 0, time.UTC), 3)
+	var suffixes []string
+	for _, part := range parts {
+		suffixes = append(suffixes, part.Suffix)
+	}
+	if got := strings.Join(suffixes, " "); got != "2026_11 2026_12 2027_01 2027_02" {
+		t.Errorf("Unexpected partitions %s", got)
+	}
+	if !parts[1].To.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected bound %v", parts[1].To)
+	}
+
+	p.Interval = schema.Daily
+	if parts := p.RangePartitions(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), 1); parts[1].Suffix != "2027_01_01" {
+		t.Errorf("Unexpected daily partitions %+v", parts)
+	}
+}
+
+// tablePartitions is SQLite standing in for a dialect with partitions,
+// which it keeps as tables named after their parent.
+type tablePartitions struct {
+	*sqlite.Dialect
+}
+
+func (d tablePartitions) InitialPartitionsSQL(model *schema.Model) []string { return nil }
+
+func (d tablePartitions) AddPartitionSQL(model *schema.Model, p schema.Partition) string {
+	return fmt.Sprintf("CREATE TABLE %s (id INTEGER)", d.Quote(d.PartitionName(model, p)))
+}
+
+func (d tablePartitions) PartitionName(model *schema.Model, p schema.Partition) string {
+	return model.Table() + "_" + p.Suffix
+}
+
+func (d tablePartitions) IntrospectPartitions(ctx context.Context, db *sql.DB, tableName string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name LIKE ? ORDER BY name`, tableName+"_%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func TestPartition_Ensure(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE Event_default (id INTEGER); CREATE TABLE Event_2026_10 (id INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	s, err := schema.NewParser(partitionSchema).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn := dialects.NewConnection(db, tablePartitions{sqlite.New()})
+	ctx := context.Background()
+	opts := migration.DefaultPartitionOptions()
+	opts.Now = time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	opts.DryRun = true
+
+	planned, err := migration.EnsurePartitions(ctx, conn, s, opts)
+	if err != nil || len(planned) != 3 || planned[0].Partition != "Event_2026_11" {
+		t.Fatalf("Expected 3 missing partitions, got %+v, %v", planned, err)
+	}
+
+	opts.DryRun = false
+	if created, err := migration.EnsurePartitions(ctx, conn, s, opts); err != nil || len(created) != 3 {
+		t.Fatalf("Expected 3 partitions, got %+v, %v", created, err)
+	}
+	if created, err := migration.EnsurePartitions(ctx, conn, s, opts); err != nil || len(created) != 0 {
+		t.Errorf("Expected nothing left to create, got %+v, %v", created, err)
+	}
+
+	if _, err := migration.EnsurePartitions(ctx, dialects.NewConnection(db, sqlite.New()), s, opts); err == nil {
+		t.Error("Expected SQLite to have no partitions")
+	}
+}
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/internal/studio"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/core/transfer"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// piiDB returns a database with two customers of sampleSchema, each with
+// an order.
+func piiDB(t *testing.T) (*dialects.Connection, *schema.Schema) {
+	t.Helper()
+	s, err := schema.NewParser(sampleSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	// A file, as the studio introspects tables on a second connection
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "pii.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+	t.Cleanup(func() { conn.Close() })
+	ctx := context.Background()
+	if _, err := transfer.CreateTables(ctx, conn, s, transfer.Options{}); err != nil {
+		t.Fatalf("CreateTables failed: %v", err)
+	}
+	for _, stmt := range []string{
+		`INSERT INTO "Customer" (name, email, phone) VALUES ('Ada Lovelace', 'ada@corp.com', '+44 20 7946 0000'), ('Grace Hopper', 'grace@corp.com', NULL)`,
+		`INSERT INTO "Order" (total, note, customer_id) VALUES (10, 'fragile', 1)`,
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return conn, s
+}
+
+func TestQuery_MaskedResults(t *testing.T) {
+	conn, s := piiDB(t)
+	ctx := context.Background()
+
+	customers, err := query.NewWithSchema(conn, "Customer", s).Masked().Select().OrderBy("id", query.Asc).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada := customers[0]
+	if ada["name"] == "Ada Lovelace" || ada["phone"] == "+44 20 7946 0000" || ada["phone"] == nil {
+		t.Errorf("Expected @pii fields to be masked, got %v", ada)
+	}
+	if ada["email"] != "ada@corp.com" || ada["id"] != int64(1) {
+		t.Errorf("Expected other fields to be kept, got %v", ada)
+	}
+	if customers[1]["phone"] != nil {
+		t.Errorf("Expected NULL to stay NULL, got %v", customers[1]["phone"])
+	}
+
+	// Loaded relations are masked too, and the same value masks the same way
+	orders := query.MaskedResults(s, "Order", query.Results{{
+		"id": int64(1), "note": "fragile", "Customer": query.Result{"id": int64(1), "name": "Ada Lovelace"},
+	}})
+	if customer := orders[0]["Customer"].(query.Result); customer["name"] != ada["name"] || orders[0]["note"] != "fragile" {
+		t.Errorf("Expected the loaded customer to be masked like the query, got %v", orders[0])
+	}
+
+	it, err := query.NewWithSchema(conn, "Customer", s).Masked().Select().Iter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	for it.Next() {
+		if name := it.Result()["name"]; name == "Ada Lovelace" || name == "Grace Hopper" {
+			t.Errorf("Expected iterated rows to be masked, got %v", name)
+		}
+	}
+
+	// Without Masked, results are as stored
+	raw, _ := query.NewWithSchema(conn, "Customer", s).Select().Where(query.Eq("id", 1)).One(ctx)
+	if raw["name"] != "Ada Lovelace" {
+		t.Errorf("Expected unmasked results by default, got %v", raw["name"])
+	}
+	if masked := query.MaskedResults(s, "Customer", query.Results{raw}); masked[0]["name"] == "Ada Lovelace" || raw["name"] != "Ada Lovelace" {
+		t.Errorf("Expected MaskedResults to mask a copy, got %v and %v", masked[0]["name"], raw["name"])
+	}
+}
+
+func TestTransfer_DumpMasked(t *testing.T) {
+	conn, s := piiDB(t)
+	masked, err := transfer.MaskedColumns(s, transfer.SampleOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := transfer.DumpJSON(context.Background(), conn, s, &out, transfer.Options{Masked: masked}); err != nil {
+		t.Fatalf("DumpJSON failed: %v", err)
+	}
+	dump := out.String()
+	if strings.Contains(dump, "Ada Lovelace") || strings.Contains(dump, "7946") {
+		t.Errorf("Expected @pii fields to be masked in the dump:\n%s", dump)
+	}
+	if !strings.Contains(dump, "ada@corp.com") || !strings.Contains(dump, "fragile") {
+		t.Errorf("Expected other columns to be dumped as they are:\n%s", dump)
+	}
+}
+
+func TestStudio_MasksPII(t *testing.T) {
+	conn, s := piiDB(t)
+
+	h := studio.NewServer(studio.Config{Connection: conn, Schema: s}).Handler()
+	_, resp := studioRequest(t, h, http.MethodGet, "/api/tables/Customer/data", "")
+	row := resp["data"].([]interface{})[0].(map[string]interface{})
+	if row["name"] == "Ada Lovelace" || row["email"] != "ada@corp.com" {
+		t.Errorf("Expected the table browser to mask @pii fields, got %v", row)
+	}
+	_, resp = studioRequest(t, h, http.MethodPost, "/api/query", `{"query": "SELECT name AS name, email FROM \"Customer\""}`)
+	row = resp["data"].([]interface{})[0].(map[string]interface{})
+	if row["name"] == "Ada Lovelace" {
+		t.Errorf("Expected the query editor to mask @pii columns, got %v", row)
+	}
+
+	h = studio.NewServer(studio.Config{Connection: conn, Schema: s, ShowPII: true}).Handler()
+	_, resp = studioRequest(t, h, http.MethodGet, "/api/tables/Customer/data", "")
+	if row := resp["data"].([]interface{})[0].(map[string]interface{}); row["name"] != "Ada Lovelace" {
+		t.Errorf("Expected ShowPII to show @pii fields, got %v", row)
+	}
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/nexustest"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// recordingT records the failures of assertions instead of failing.
+type recordingT struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingT) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+func TestNexustest_PlanAssertions(t *testing.T) {
+	conn := setupExplainTestDB(t)
+	defer conn.Close()
+	users := query.New(conn, "users")
+
+	byName := users.Select("id").Where(query.Eq("name", "User 1"))
+	nexustest.AssertUsesIndex(t, byName, "idx_users_name")
+	nexustest.AssertNoSeqScan(t, byName)
+
+	// A query scanning the table fails both
+	byActive := users.Select().Where(query.Eq("active", 1))
+	rec := &recordingT{TB: t}
+	nexustest.AssertUsesIndex(rec, byActive, "idx_users_name")
+	nexustest.AssertNoSeqScan(rec, query.NewRawQuery(conn, "SELECT * FROM users WHERE active = ?", 1))
+	if len(rec.failures) != 2 ||
+		!strings.Contains(rec.failures[0], "index idx_users_name is not used") ||
+		!strings.Contains(rec.failures[1], "full table scan: SCAN users") {
+		t.Errorf("Expected both assertions to fail, got %q", rec.failures)
+	}
+}
+
+func TestCheckPlans(t *testing.T) {
+	conn := setupExplainTestDB(t)
+	defer conn.Close()
+
+	path := filepath.Join(t.TempDir(), "plans.yaml")
+	os.WriteFile(path, []byte(`# Tracked queries
+- name: user by name
+  sql: SELECT id FROM users WHERE name = ?
+  args: ["User 1"]
+  uses_index: idx_users_name
+  no_seq_scan: true
+
+- sql: "SELECT * FROM users WHERE active = ?"
+  args: [1]
+  uses_index: [idx_users_name, idx_users_active]
+`), 0644)
+	tracked, err := query.LoadPlanChecks(path)
+	if err != nil {
+		t.Fatalf("LoadPlanChecks failed: %v", err)
+	}
+	if len(tracked) != 2 || tracked[1].Name != "SELECT * FROM users WHERE active = ?" || tracked[1].Args[0] != int64(1) {
+		t.Fatalf("Unexpected tracked queries: %+v", tracked)
+	}
+
+	results, err := query.CheckPlans(context.Background(), conn, tracked)
+	if err != nil {
+		t.Fatalf("CheckPlans failed: %v", err)
+	}
+	if len(results[0].Problems) != 0 {
+		t.Errorf("Expected the indexed query to pass, got %q", results[0].Problems)
+	}
+	want := []string{"index idx_users_name is not used", "index idx_users_active is not used"}
+	if strings.Join(results[1].Problems, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %q, got %q", want, results[1].Problems)
+	}
+
+	os.WriteFile(path, []byte("- sql: SELECT 1\n"), 0644)
+	if _, err := query.LoadPlanChecks(path); err == nil || !strings.Contains(err.Error(), "nothing to check") {
+		t.Errorf("Expected a query without checks to be rejected, got %v", err)
+	}
+}
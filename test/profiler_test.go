@@ -3,7 +3,11 @@ package test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -393,3 +397,368 @@ func TestProfilerErrorTracking(t *testing.T) {
 		t.Errorf("Expected 1 error, got %d", report.ErrorCount)
 	}
 }
+
+func TestProfilerHandler(t *testing.T) {
+	profiler := query.NewProfiler(query.DefaultProfilerOptions())
+	server := httptest.NewServer(http.StripPrefix("/debug/profiler", query.ProfilerHandler(profiler)))
+	defer server.Close()
+
+	call := func(method, path string) (int, map[string]interface{}) {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+"/debug/profiler"+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	if code, _ := call(http.MethodGet, "/start"); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET /start to be rejected, got %d", code)
+	}
+	if code, _ := call(http.MethodPost, "/start?duration=soon"); code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid duration to be rejected, got %d", code)
+	}
+
+	code, body := call(http.MethodPost, "/start?duration=100ms")
+	if code != http.StatusOK || body["enabled"] != true || body["stopsAt"] == nil {
+		t.Fatalf("Expected a timed session, got %d %v", code, body)
+	}
+	profile := profiler.StartQuery("SELECT * FROM users WHERE email = ?", []interface{}{"secret@example.com"})
+	profile.Duration = time.Second
+	profile.IsSlow = true
+	profiler.Record(profile)
+
+	_, body = call(http.MethodGet, "/report")
+	report := body["report"].(map[string]interface{})
+	if report["totalQueries"] != float64(1) || len(report["slowQueries"].([]interface{})) != 1 {
+		t.Errorf("Expected the recorded query in the report, got %v", report)
+	}
+	data, _ := json.Marshal(body)
+	if strings.Contains(string(data), "secret@example.com") {
+		t.Errorf("Expected query arguments to be left out, got %s", data)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for profiler.IsEnabled() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if profiler.IsEnabled() {
+		t.Fatal("Expected the timed session to stop")
+	}
+
+	resp, err := http.Get(server.URL + "/debug/profiler/report?format=text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(text), "Total Queries:    1") {
+		t.Errorf("Expected a text report, got %q", text)
+	}
+
+	// A stopped session can be restarted and stopped by hand
+	call(http.MethodPost, "/start")
+	if _, body = call(http.MethodPost, "/stop"); body["enabled"] != false || body["stopsAt"] != nil {
+		t.Errorf("Expected the session to stop, got %v", body)
+	}
+	if code, _ := call(http.MethodPost, "/unknown"); code != http.StatusNotFound {
+		t.Errorf("Expected an unknown action to be not found, got %d", code)
+	}
+}
+
+func TestProfilerNPlusOneRelationFixes(t *testing.T) {
+	conn, s := setupEagerLoadingDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	opts := query.DefaultProfilerOptions()
+	opts.NPlusOneThreshold = 3
+	opts.Schema = s
+	profiler := query.NewProfiler(opts)
+	profiler.Start()
+
+	// Posts loaded one user at a time
+	for id := 1; id <= 3; id++ {
+		query.New(conn, "posts").WithProfiler(profiler).Select().Where(query.Eq("user_id", id)).All(ctx)
+	}
+	// Authors loaded one post at a time, by hand
+	for id := 1; id <= 3; id++ {
+		profiler.EndQuery(profiler.StartQuery("SELECT * FROM users WHERE id = ? LIMIT 1", []interface{}{id}), nil)
+	}
+	profiler.Stop()
+
+	fixes := make(map[string]query.NPlusOneFix)
+	for _, w := range profiler.Report().NPlusOneWarnings {
+		for _, fix := range w.Fixes {
+			fixes[fix.Model+"."+fix.Relation] = fix
+		}
+	}
+	if len(fixes) != 2 {
+		t.Fatalf("Expected fixes for User.Post and Post.User, got %v", fixes)
+	}
+
+	hasMany := fixes["User.Post"]
+	if hasMany.Include != `query.New(conn, "users").Select().WithSchema(schema).Include("Post")` {
+		t.Errorf("Unexpected include fix %q", hasMany.Include)
+	}
+	if hasMany.BatchLoad != `query.New(conn, "posts").Select().Where(query.In("user_id", userIDs...))` {
+		t.Errorf("Unexpected batch load fix %q", hasMany.BatchLoad)
+	}
+	belongsTo := fixes["Post.User"]
+	if belongsTo.Include != `query.New(conn, "posts").Select().WithSchema(schema).Include("User")` {
+		t.Errorf("Unexpected include fix %q", belongsTo.Include)
+	}
+
+	report := profiler.Report().String()
+	if !strings.Contains(report, `fix: query.New(conn, "users").Select().WithSchema(schema).Include("Post")`) {
+		t.Errorf("Expected the text report to show the fix, got:\n%s", report)
+	}
+}
+
+func TestProfilerReportFormats(t *testing.T) {
+	profiler := query.NewProfiler(query.DefaultProfilerOptions())
+	profiler.Start()
+	for i := 0; i < 6; i++ {
+		profiler.EndQuery(profiler.StartQuery("SELECT * FROM users WHERE id = ?", []interface{}{i}), nil)
+	}
+	slow := profiler.StartQuery("SELECT * FROM posts WHERE title = '<b>|</b>'", nil)
+	slow.Duration = time.Second
+	slow.IsSlow = true
+	profiler.Record(slow)
+	profiler.Stop()
+	report := profiler.Report()
+
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded["totalQueries"] != float64(7) {
+		t.Errorf("Expected the report as JSON, got %s (%v)", data, err)
+	}
+
+	md := report.Markdown()
+	for _, want := range []string{"| Total Queries | 7 |", "### 🐢 Slowest Queries", "| 1 | 1s | ` SELECT * FROM posts WHERE title = '<b>\\|</b>' ` |", "- **6x** ` SELECT * FROM users WHERE id = ? `"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected Markdown to contain %q, got:\n%s", want, md)
+		}
+	}
+
+	page, err := report.HTML()
+	if err != nil {
+		t.Fatalf("HTML failed: %v", err)
+	}
+	html := string(page)
+	if !strings.HasPrefix(html, "<!DOCTYPE html>") || strings.Count(html, "<rect") != 9 || strings.Contains(html, "<script") {
+		t.Errorf("Expected a self-contained page with a bar per query and pattern, got:\n%s", html)
+	}
+	if strings.Contains(html, "<b>|</b>") || !strings.Contains(html, "&lt;b&gt;|&lt;/b&gt;") {
+		t.Error("Expected the SQL to be escaped in the HTML report")
+	}
+
+	for path, want := range map[string]string{"r.json": "json", "r.HTML": "html", "r.md": "markdown", "r.txt": "text"} {
+		if got := query.ReportFormatForPath(path); got != want {
+			t.Errorf("ReportFormatForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if _, err := report.Format("pdf"); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
+
+func TestProfileMiddleware(t *testing.T) {
+	conn := lockConn(t)
+	ctx := context.Background()
+	if _, err := conn.Exec(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	profiler := query.NewProfiler(query.DefaultProfilerOptions())
+	conn.Use(profiler.Hook())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// Loads each row by itself, an N+1
+		for i := 0; i < 6; i++ {
+			query.New(conn, "users").Select().Where(query.Eq("id", i)).All(r.Context())
+		}
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		query.NewRawQuery(conn, "SELECT 1").Exec(r.Context())
+	})
+	server := httptest.NewServer(query.ProfileMiddleware(profiler)(mux))
+	defer server.Close()
+
+	// Requests are summarized even while no session is started
+	resp, err := http.Get(server.URL + "/users/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get(query.QueryCountHeader); got != "6" {
+		t.Errorf("Expected 6 queries, got %q", got)
+	}
+	if resp.Header.Get(query.NPlusOneHeader) != "1" || resp.Header.Get(query.QueryTimeHeader) == "" ||
+		!strings.HasPrefix(resp.Header.Get("Server-Timing"), "db;dur=") {
+		t.Errorf("Expected the summary headers, got %v", resp.Header)
+	}
+	if profiler.Report().TotalQueries != 0 {
+		t.Error("Expected nothing recorded without a session")
+	}
+
+	profiler.Start()
+	for _, path := range []string{"/users/1", "/users/2", "/health"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	profiler.Stop()
+
+	report := profiler.Report()
+	if report.TotalQueries != 13 || len(report.Endpoints) != 2 {
+		t.Fatalf("Expected 13 queries of 2 endpoints, got %d and %+v", report.TotalQueries, report.Endpoints)
+	}
+	// Endpoints are ordered by database time, which varies between runs
+	users, health := report.Endpoints[0], report.Endpoints[1]
+	if users.Endpoint != "GET /users/{id}" {
+		users, health = health, users
+	}
+	if users.Endpoint != "GET /users/{id}" || users.Requests != 2 || users.MaxQueries != 6 ||
+		users.NPlusOneRequests != 2 || len(users.NPlusOne) != 1 || users.NPlusOne[0].Count != 6 {
+		t.Errorf("Unexpected endpoint: %+v", users)
+	}
+	if health.Endpoint != "GET /health" || health.Queries != 1 {
+		t.Errorf("Unexpected endpoint: %+v", health)
+	}
+	tagged := report.TopByDuration[0].Tags
+	if len(tagged) != 2 || tagged[0] != "method:GET" || !strings.HasPrefix(tagged[1], "route:/") {
+		t.Errorf("Expected queries tagged with the route, got %q", tagged)
+	}
+	if !strings.HasPrefix(report.TopByDuration[0].CallerInfo, "test/profiler_test.go:") {
+		t.Errorf("Expected the caller outside Nexus, got %q", report.TopByDuration[0].CallerInfo)
+	}
+	if !strings.Contains(report.String(), "GET /users/{id} [2 requests, 6.0 queries avg, max 6") {
+		t.Errorf("Expected the endpoints in the report, got:\n%s", report.String())
+	}
+}
+
+func TestProfilerContextTags(t *testing.T) {
+	conn := setupProfilerTestDB(t)
+	defer conn.Close()
+	profiler := query.NewProfiler(query.DefaultProfilerOptions())
+	profiler.Start()
+	users := query.New(conn, "users").WithProfiler(profiler)
+
+	ctx := query.WithTags(context.Background(), "handler:/users")
+	for _, tenant := range []string{"1", "2", "2"} {
+		tenantCtx := query.WithTags(ctx, "tenant:"+tenant)
+		users.Select().Where(query.Eq("active", 1)).All(tenantCtx)
+	}
+	profiler.Tag("phase:cleanup")
+	users.Delete().Where(query.Eq("id", 1)).Exec(context.Background())
+	profiler.Stop()
+
+	if got := query.TagsFromContext(ctx); len(got) != 1 {
+		t.Errorf("Expected the parent context unchanged, got %q", got)
+	}
+
+	tenant2 := profiler.ReportTagged("handler:/users", "tenant:2")
+	if tenant2.TotalQueries != 2 {
+		t.Errorf("Expected 2 queries of tenant 2, got %d", tenant2.TotalQueries)
+	}
+	if cleanup := profiler.ReportTagged("phase:cleanup"); cleanup.TotalQueries != 1 ||
+		!cleanup.TopByDuration[0].HasTag("phase:cleanup") || len(cleanup.TopByDuration[0].Tags) != 1 {
+		t.Errorf("Expected the delete tagged by the profiler, got %+v", cleanup.TopByDuration)
+	}
+
+	byTenant := profiler.ReportsByTag("tenant")
+	if len(byTenant) != 2 || byTenant["1"].TotalQueries != 1 || byTenant["2"].TotalQueries != 2 {
+		t.Errorf("Expected a report per tenant, got %v", byTenant)
+	}
+	if profiler.Report().TotalQueries != 4 {
+		t.Errorf("Expected all queries in the full report, got %d", profiler.Report().TotalQueries)
+	}
+
+	server := httptest.NewServer(query.ProfilerHandler(profiler))
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/report?tag=tenant:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Report struct {
+			TotalQueries int `json:"totalQueries"`
+		} `json:"report"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Report.TotalQueries != 1 {
+		t.Errorf("Expected the report filtered by tag, got %+v (%v)", body, err)
+	}
+}
+
+func TestProfilerPoolStats(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	opts := query.DefaultProfilerOptions()
+	opts.Pool = db
+	opts.PoolSampleInterval = 5 * time.Millisecond
+	profiler := query.NewProfiler(opts)
+	profiler.Start()
+
+	// Hold the only connection so a statement has to wait for it
+	ctx := context.Background()
+	held, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := db.ExecContext(ctx, "SELECT 1")
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	held.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	profiler.Stop()
+
+	pool := profiler.Report().Pool
+	if pool == nil {
+		t.Fatal("Expected pool statistics in the report")
+	}
+	if pool.MaxOpen != 1 || pool.PeakInUse != 1 || pool.SaturatedSamples == 0 || len(pool.Samples) < 3 {
+		t.Errorf("Unexpected pool report: %+v", pool)
+	}
+	if pool.Waits != 1 || pool.WaitDuration < 40*time.Millisecond {
+		t.Errorf("Expected one long wait, got %d in %s", pool.Waits, pool.WaitDuration)
+	}
+	samples := len(pool.Samples)
+	time.Sleep(20 * time.Millisecond)
+	if len(profiler.Report().Pool.Samples) != samples {
+		t.Error("Expected sampling to stop with the session")
+	}
+
+	report := profiler.Report()
+	found := false
+	for _, s := range report.Suggestions {
+		found = found || strings.Contains(s, "1 statements waited for a free connection")
+	}
+	if !found || !strings.Contains(report.String(), "Peak in use:    1 of 1") {
+		t.Errorf("Expected the pool in the report, got:\n%s", report.String())
+	}
+	if page, _ := report.HTML(); !strings.Contains(string(page), "<polyline") {
+		t.Error("Expected a chart of the pool in the HTML report")
+	}
+}
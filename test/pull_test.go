@@ -0,0 +1,214 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func TestPull_RoundTrip(t *testing.T) {
+	db, dialect := setupDiffTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	_, err := db.Exec(`
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email VARCHAR(120) NOT NULL UNIQUE,
+			name TEXT,
+			active BOOLEAN NOT NULL DEFAULT 1,
+			score REAL DEFAULT 0.5,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	snapshot, err := migration.IntrospectDatabase(ctx, db, dialect)
+	if err != nil {
+		t.Fatalf("Failed to introspect: %v", err)
+	}
+
+	pulled, warnings := migration.SchemaFromSnapshot(snapshot)
+	if len(warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+
+	source := schema.Format(pulled)
+	for _, want := range []string{
+		"model users {",
+		"@id @autoincrement",
+		"email      String",
+		"@size(120)",
+		"name       Text?",
+		"@default(true)",
+		"@default(now())",
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("Expected formatted schema to contain %q:\n%s", want, source)
+		}
+	}
+
+	// The generated DSL parses and matches the database it came from
+	reparsed, err := schema.NewParser(source).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse pulled schema: %v\n%s", err, source)
+	}
+	if diff := migration.Diff(reparsed, snapshot); diff.HasChanges() {
+		t.Errorf("Expected no diff, got %v", migration.DescribeChanges(diff.Changes))
+	}
+
+	// Column order is preserved
+	fields := reparsed.Models["users"].GetFields()
+	if fields[0].Name != "id" || fields[len(fields)-1].Name != "created_at" {
+		t.Errorf("Expected column order to be preserved, got %s..%s", fields[0].Name, fields[len(fields)-1].Name)
+	}
+}
+
+func TestPull_RoundTripIndexes(t *testing.T) {
+	// Index columns are read on a second connection, which must see the same database
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "pull.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dialect := sqlite.New()
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		`CREATE TABLE posts (id INTEGER PRIMARY KEY AUTOINCREMENT, author TEXT NOT NULL, slug TEXT NOT NULL, created_at DATETIME)`,
+		`CREATE INDEX idx_posts_author_created_at ON posts (author, created_at)`,
+		`CREATE UNIQUE INDEX posts_slug_per_author ON posts (author, slug)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot, err := migration.IntrospectDatabase(ctx, db, dialect)
+	if err != nil {
+		t.Fatalf("Failed to introspect: %v", err)
+	}
+	pulled, warnings := migration.SchemaFromSnapshot(snapshot)
+	if len(warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+
+	source := schema.Format(pulled)
+	for _, want := range []string{
+		"@@index([author, created_at])",
+		`@@unique([author, slug], name: "posts_slug_per_author")`,
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("Expected formatted schema to contain %q:\n%s", want, source)
+		}
+	}
+
+	reparsed, err := schema.NewParser(source).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse pulled schema: %v\n%s", err, source)
+	}
+	if diff := migration.DiffWithOptions(reparsed, snapshot, migration.DiffOptions{Dialect: dialect}); diff.HasChanges() {
+		t.Errorf("Expected no diff, got %v", migration.DescribeChanges(diff.Changes))
+	}
+}
+
+func TestFieldTypeFromSQL(t *testing.T) {
+	cases := []struct {
+		sqlType string
+		want    schema.FieldType
+	}{
+		{"INTEGER", schema.FieldTypeInt},
+		{"bigint", schema.FieldTypeBigInt},
+		{"character varying(50)", schema.FieldTypeString},
+		{"TINYINT(1)", schema.FieldTypeBool},
+		{"NUMERIC(12,4)", schema.FieldTypeDecimal},
+		{"timestamp with time zone", schema.FieldTypeDateTime},
+		{"time without time zone", schema.FieldTypeTime},
+		{"jsonb", schema.FieldTypeJSON},
+		{"bytea", schema.FieldTypeBytes},
+		{"CHAR(36)", schema.FieldTypeUUID},
+	}
+
+	for _, c := range cases {
+		got, _, _, _, known := migration.FieldTypeFromSQL(c.sqlType)
+		if !known || got != c.want {
+			t.Errorf("FieldTypeFromSQL(%q) = %s (known=%v), want %s", c.sqlType, got, known, c.want)
+		}
+	}
+
+	_, _, precision, scale, _ := migration.FieldTypeFromSQL("NUMERIC(12,4)")
+	if precision != 12 || scale != 4 {
+		t.Errorf("Expected precision 12,4, got %d,%d", precision, scale)
+	}
+}
+
+func TestIntrospect_ForeignKeysAndChecks(t *testing.T) {
+	db, dialect := setupDiffTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	_, err := db.Exec(`
+		CREATE TABLE authors (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL
+		);
+		CREATE TABLE books (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			writer INTEGER REFERENCES authors(id) ON DELETE CASCADE,
+			price REAL,
+			CONSTRAINT positive_price CHECK (price > 0 AND (price < 1000))
+		);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	snapshot, err := migration.IntrospectDatabase(ctx, db, dialect)
+	if err != nil {
+		t.Fatalf("Failed to introspect: %v", err)
+	}
+
+	books := snapshot.Tables["books"]
+	if len(books.ForeignKeys) != 1 {
+		t.Fatalf("Expected 1 foreign key, got %d", len(books.ForeignKeys))
+	}
+	for _, fk := range books.ForeignKeys {
+		if fk.RefTable != "authors" || fk.Columns[0] != "writer" || fk.RefColumns[0] != "id" {
+			t.Errorf("Unexpected foreign key: %+v", fk)
+		}
+		if fk.OnDelete != "CASCADE" {
+			t.Errorf("Expected ON DELETE CASCADE, got %s", fk.OnDelete)
+		}
+	}
+
+	check, ok := books.Checks["positive_price"]
+	if !ok {
+		t.Fatalf("Expected positive_price check, got %v", books.Checks)
+	}
+	if check.Expression != "price > 0 AND (price < 1000)" {
+		t.Errorf("Unexpected check expression: %q", check.Expression)
+	}
+
+	// Pull maps the non-conventional FK column to a relation with its cascade rule
+	pulled, _ := migration.SchemaFromSnapshot(snapshot)
+	var found bool
+	for _, rel := range pulled.Models["books"].GetBelongsTo() {
+		if rel.TargetModel == "authors" && rel.ForeignKey == "writer" {
+			found = true
+			if rel.OnDeleteAction != schema.Cascade {
+				t.Errorf("Expected cascade on delete, got %v", rel.OnDeleteAction)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected books BelongsTo authors via writer")
+	}
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/queue"
+)
+
+func setupQueueDB(t *testing.T) *dialects.Connection {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "jobs.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return dialects.NewConnection(db, sqlite.New())
+}
+
+func TestQueueRetriesAndSchedules(t *testing.T) {
+	conn := setupQueueDB(t)
+	ctx := context.Background()
+	q := queue.New(conn, queue.Options{MaxAttempts: 2, Backoff: func(int) time.Duration { return 0 }})
+	for i := 0; i < 2; i++ {
+		if err := q.Init(ctx); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+	}
+
+	if err := q.Enqueue(ctx, "email", map[string]string{"to": "ada@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueueWithOptions(ctx, "report", nil, queue.JobOptions{Delay: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := q.Dequeue(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("Expected a job, got %v (%v)", job, err)
+	}
+	var payload map[string]string
+	if err := job.Decode(&payload); err != nil || job.Kind != "email" || payload["to"] != "ada@example.com" {
+		t.Errorf("Expected the email job, got %+v", job)
+	}
+	if job.Status != queue.StatusRunning || job.Attempts != 1 {
+		t.Errorf("Expected a running first attempt, got %s attempt %d", job.Status, job.Attempts)
+	}
+	if next, _ := q.Dequeue(ctx); next != nil {
+		t.Fatalf("Expected the scheduled job to wait, got %+v", next)
+	}
+
+	// A failure is retried until the job runs out of attempts
+	if err := q.Fail(ctx, job, errors.New("smtp down")); err != nil {
+		t.Fatal(err)
+	}
+	job, _ = q.Dequeue(ctx)
+	if job == nil || job.Attempts != 2 || job.LastError != "smtp down" {
+		t.Fatalf("Expected the second attempt, got %+v", job)
+	}
+	if err := q.Fail(ctx, job, errors.New("smtp down")); err != nil {
+		t.Fatal(err)
+	}
+	if job, _ = q.Get(ctx, job.ID); job.Status != queue.StatusFailed || job.FinishedAt.IsZero() {
+		t.Errorf("Expected the job to fail, got %+v", job)
+	}
+
+	// Finished jobs are pruned
+	if n, err := q.Prune(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Errorf("Expected 1 job pruned, got %d (%v)", n, err)
+	}
+	counts, _ := q.Counts(ctx)
+	if counts[queue.StatusPending] != 1 || len(counts) != 1 {
+		t.Errorf("Expected only the scheduled job, got %v", counts)
+	}
+}
+
+func TestQueueExpiredLock(t *testing.T) {
+	conn := setupQueueDB(t)
+	ctx := context.Background()
+	first := queue.New(conn, queue.Options{WorkerID: "first", LockTimeout: time.Millisecond})
+	second := queue.New(conn, queue.Options{WorkerID: "second"})
+	if err := first.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Enqueue(ctx, "resize", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, _ := first.Dequeue(ctx)
+	time.Sleep(5 * time.Millisecond)
+	job, err := second.Dequeue(ctx)
+	if err != nil || job == nil || job.ID != stale.ID || job.Attempts != 2 {
+		t.Fatalf("Expected the expired job to be claimed again, got %+v (%v)", job, err)
+	}
+	if err := first.Complete(ctx, stale); err == nil {
+		t.Error("Expected the first worker to have lost the job")
+	}
+	if err := second.Complete(ctx, job); err != nil {
+		t.Errorf("Complete failed: %v", err)
+	}
+
+	// A job that keeps crashing its worker fails once out of attempts
+	crashing := queue.New(conn, queue.Options{WorkerID: "crashing", LockTimeout: time.Millisecond, MaxAttempts: 2})
+	if err := crashing.Enqueue(ctx, "segfault", nil); err != nil {
+		t.Fatal(err)
+	}
+	for attempt := 1; attempt <= 2; attempt++ {
+		job, err := crashing.Dequeue(ctx)
+		if err != nil || job == nil || job.Attempts != attempt {
+			t.Fatalf("Expected attempt %d, got %+v (%v)", attempt, job, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if job, err := crashing.Dequeue(ctx); err != nil || job != nil {
+		t.Fatalf("Expected no attempt past the last, got %+v (%v)", job, err)
+	}
+	counts, _ := crashing.Counts(ctx)
+	if counts[queue.StatusFailed] != 1 || counts[queue.StatusRunning] != 0 {
+		t.Errorf("Expected the crashing job failed, got %v", counts)
+	}
+}
+
+func TestQueueWorker(t *testing.T) {
+	conn := setupQueueDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := queue.New(conn, queue.Options{Backoff: func(int) time.Duration { return 0 }})
+	if err := q.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	const jobs = 20
+	for i := 0; i < jobs; i++ {
+		if err := q.Enqueue(ctx, "count", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	seen := make(map[int]int)
+	flaky := true
+	w := queue.NewWorker(q, queue.WorkerOptions{Concurrency: 4, PollInterval: 10 * time.Millisecond})
+	w.Handle("count", func(ctx context.Context, job *queue.Job) error {
+		var n int
+		if err := job.Decode(&n); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if n == 7 && flaky {
+			flaky = false
+			panic("flaky")
+		}
+		seen[n]++
+		if len(seen) == jobs {
+			cancel()
+		}
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Worker did not finish the jobs")
+	}
+
+	for n, times := range seen {
+		if times != 1 {
+			t.Errorf("Expected job %d to run once, ran %d times", n, times)
+		}
+	}
+	counts, _ := q.Counts(context.Background())
+	if counts[queue.StatusDone] != jobs {
+		t.Errorf("Expected %d jobs done, got %v", jobs, counts)
+	}
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestNamedQuery_Bind(t *testing.T) {
+	conn := dialects.NewConnection(nil, postgres.New())
+	q, err := query.NewNamedQuery(conn,
+		"SELECT data::jsonb, ':skip' FROM users -- :skip\nWHERE email = :email AND (role IN (:roles) OR :email = 'x') /* :skip */",
+		map[string]interface{}{"email": "ann@example.com", "roles": []string{"admin", "owner"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, args := q.Build()
+	want := "SELECT data::jsonb, ':skip' FROM users -- :skip\nWHERE email = $1 AND (role IN ($2, $3) OR $4 = 'x') /* :skip */"
+	if sql != want {
+		t.Errorf("Expected %q, got %q", want, sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"ann@example.com", "admin", "owner", "ann@example.com"}) {
+		t.Errorf("Unexpected arguments: %v", args)
+	}
+
+	// Struct fields match by db tag, or by name in any case
+	params := struct {
+		Email string `db:"mail"`
+		Name  string
+		Data  []byte
+	}{Email: "ann@example.com", Name: "Ann", Data: []byte("{}")}
+	q, err = query.NewNamedQuery(conn, "UPDATE users SET name = :name, data = :Data WHERE email = :mail", &params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, args := q.Build(); !reflect.DeepEqual(args, []interface{}{"Ann", []byte("{}"), "ann@example.com"}) {
+		t.Errorf("Unexpected arguments: %v", args)
+	}
+
+	for _, bad := range []struct {
+		sql    string
+		params interface{}
+		err    string
+	}{
+		{"SELECT :missing", params, "no value for parameter :missing"},
+		{"SELECT * FROM users WHERE id IN (:ids)", map[string]interface{}{"ids": []int{}}, ":ids is an empty list"},
+		{"SELECT :a", 42, "must be a map or a struct"},
+	} {
+		if _, err := query.NewNamedQuery(conn, bad.sql, bad.params); err == nil || !strings.Contains(err.Error(), bad.err) {
+			t.Errorf("%s: expected %q, got %v", bad.sql, bad.err, err)
+		}
+	}
+}
+
+func TestRawQuery_ScanStructs(t *testing.T) {
+	ctx := context.Background()
+	conn := setupV2TestDB(t)
+	conn.Exec(ctx, "CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT, active INTEGER)")
+	conn.Exec(ctx, "INSERT INTO people (name, active) VALUES ('Alice', 1), ('Bob', 0), ('Carol', 1)")
+
+	type user struct {
+		ID      int64 `db:"id"`
+		Name    string
+		Active  bool `db:"active"`
+		Created time.Time
+	}
+	q, err := query.NewNamedQuery(conn, "SELECT id, name AS Name, active FROM people WHERE active = :active ORDER BY id", map[string]interface{}{"active": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var users []user
+	if err := q.ScanAll(ctx, &users); err != nil {
+		t.Fatalf("ScanAll failed: %v", err)
+	}
+	if len(users) != 2 || users[0].Name != "Alice" || users[1].ID != 3 || !users[1].Active {
+		t.Errorf("Unexpected users: %+v", users)
+	}
+
+	var ptrs []*user
+	if err := query.NewRawQuery(conn, "SELECT id, name AS Name FROM people").ScanAll(ctx, &ptrs); err != nil || len(ptrs) != 3 {
+		t.Errorf("Expected 3 users, got %d and %v", len(ptrs), err)
+	}
+
+	var one user
+	if err := query.NewRawQuery(conn, "SELECT id, name AS Name FROM people WHERE id = ?", 2).ScanOne(ctx, &one); err != nil || one.Name != "Bob" {
+		t.Errorf("Expected Bob, got %+v and %v", one, err)
+	}
+	if err := query.NewRawQuery(conn, "SELECT id FROM people WHERE id = 99").ScanOne(ctx, &one); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+	if err := query.NewRawQuery(conn, "SELECT id FROM people").ScanAll(ctx, &one); err == nil {
+		t.Error("Expected an error scanning into a struct instead of a slice")
+	}
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+const rlsSchema = `
+model Document {
+  id        Int    @id @autoincrement
+  tenant_id Int
+  title     String
+
+  @@rls(force: true)
+  @@policy("tenant_isolation", "tenant_id = current_setting('app.tenant')::int")
+  @@policy("writers", "tenant_id > 0", for: INSERT, to: [app_writer])
+}
+`
+
+func TestRLS_Parse(t *testing.T) {
+	s, err := schema.NewParser(rlsSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	m := s.Models["Document"]
+	if !m.RowSecurity || !m.ForceRowSecurity || len(m.Policies) != 2 {
+		t.Fatalf("Unexpected row security %v, %v, %+v", m.RowSecurity, m.ForceRowSecurity, m.Policies)
+	}
+	if p := m.Policies[1]; p.Command != schema.PolicyInsert || len(p.Roles) != 1 || p.Roles[0] != "app_writer" {
+		t.Errorf("Unexpected policy %+v", p)
+	}
+
+	// Formatting keeps the attributes
+	again, err := schema.NewParser(schema.Format(s)).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse formatted schema: %v", err)
+	}
+	if got := again.Models["Document"]; !got.ForceRowSecurity || len(got.Policies) != 2 || got.Policies[0].Using != m.Policies[0].Using {
+		t.Errorf("Formatting lost row security: %+v", got.Policies)
+	}
+
+	if _, err := schema.NewParser(`
+model Document {
+  id Int @id
+  @@policy("readers", "true", for: SELECT, check: "true")
+}
+`).Parse(); err == nil {
+		t.Error("Expected a SELECT policy with a check to fail")
+	}
+}
+
+func TestRLS_PostgresSQL(t *testing.T) {
+	s := schema.NewSchema().Model("Document", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.Int("tenant_id")
+		m.EnableRLS().Policy("tenant_isolation", "tenant_id = current_setting('app.tenant')::int")
+		m.Policy("writers", "tenant_id > 0").For("insert").To("app_writer", "public")
+	})
+	m := s.Models["Document"]
+
+	statements := migration.RowSecurityStatements(postgres.New(), m)
+	want := []string{
+		`ALTER TABLE "Document" ENABLE ROW LEVEL SECURITY, NO FORCE ROW LEVEL SECURITY`,
+		`CREATE POLICY "tenant_isolation" ON "Document" FOR ALL USING (tenant_id = current_setting('app.tenant')::int)`,
+		`CREATE POLICY "writers" ON "Document" FOR INSERT TO "app_writer", PUBLIC WITH CHECK (tenant_id > 0)`,
+	}
+	if strings.Join(statements, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(statements, "\n"))
+	}
+	if got := migration.RowSecurityStatements(sqlite.New(), m); got != nil {
+		t.Errorf("Expected no row security on SQLite, got %v", got)
+	}
+}
+
+func TestRLS_Diff(t *testing.T) {
+	s, err := schema.NewParser(rlsSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	dialect := postgres.New()
+	opts := migration.DiffOptions{Dialect: dialect}
+
+	// New tables get their policies with CREATE TABLE
+	created := migration.DiffWithOptions(s, migration.NewDatabaseSnapshot(), opts)
+	m, err := migration.GenerateMigrationFromDiff(dialect, created.Changes, "documents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(m.UpSQL, "FORCE ROW LEVEL SECURITY") || !strings.Contains(m.UpSQL, `CREATE POLICY "writers"`) {
+		t.Errorf("Expected policies in:\n%s", m.UpSQL)
+	}
+
+	// The database as PostgreSQL reports it matches the schema
+	current := migration.SnapshotFromSchema(s, dialect)
+	rls := current.Tables["Document"].RowSecurity
+	rls.Policies["tenant_isolation"].Using = "(tenant_id = (current_setting('app.tenant'::text))::integer)"
+	if diff := migration.DiffWithOptions(s, current, opts); diff.HasChanges() {
+		t.Fatalf("Expected no changes, got %v", migration.DescribeChanges(diff.Changes))
+	}
+
+	// Changed, removed and extra policies
+	rls.Forced = false
+	rls.Policies["tenant_isolation"].Using = "tenant_id = 1"
+	delete(rls.Policies, "writers")
+	rls.Policies["legacy"] = &migration.PolicyInfo{Name: "legacy", Command: "SELECT", Using: "true"}
+
+	diff := migration.DiffWithOptions(s, current, opts)
+	got := strings.Join(migration.DescribeChanges(diff.Changes), "\n")
+	for _, want := range []string{
+		"~ ENABLE, FORCE ROW LEVEL SECURITY Document",
+		"~ REPLACE POLICY Document.tenant_isolation",
+		"+ CREATE POLICY Document.writers",
+		"- DROP POLICY Document.legacy",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+
+	m, err = migration.GenerateMigrationFromDiff(dialect, diff.Changes, "policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(m.UpSQL, `DROP POLICY IF EXISTS "legacy" ON "Document"`) ||
+		!strings.Contains(m.DownSQL, `CREATE POLICY "legacy" ON "Document" FOR SELECT USING (true)`) ||
+		!strings.Contains(m.DownSQL, `ENABLE ROW LEVEL SECURITY, NO FORCE ROW LEVEL SECURITY`) {
+		t.Errorf("Unexpected migration:\n%s\n--\n%s", m.UpSQL, m.DownSQL)
+	}
+
+	// Without a row security dialect nothing is compared
+	if diff := migration.Diff(s, current); diff.HasChanges() {
+		t.Errorf("Expected Diff to skip row security, got %v", migration.DescribeChanges(diff.Changes))
+	}
+}
+
+func TestRLS_SetLocal(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	conn := dialects.NewConnection(db, sqlite.New())
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := tx.SetLocal(context.Background(), "app.tenant", 42); err == nil {
+		t.Error("Expected SetLocal to need PostgreSQL")
+	}
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestSafetyRequiresWhere(t *testing.T) {
+	conn := setupBatchDB(t, 10)
+	conn.SetSafety(dialects.Safety{RequireWhere: true})
+	ctx := context.Background()
+	items := query.New(conn, "items")
+
+	if _, err := items.Update(map[string]interface{}{"status": "done"}).Exec(ctx); !errors.Is(err, dialects.ErrUnsafeStatement) {
+		t.Errorf("Expected an update without WHERE to be refused, got %v", err)
+	}
+	if _, err := conn.Exec(ctx, "  -- comment\n delete from items"); err == nil {
+		t.Error("Expected a raw delete without WHERE to be refused")
+	}
+	if _, err := conn.Exec(ctx, "UPDATE items SET status = 'where'"); err == nil {
+		t.Error("Expected WHERE inside a string not to count")
+	}
+	if _, err := conn.Exec(ctx, "UPDATE items SET status = (SELECT status FROM items WHERE id = 1)"); !errors.Is(err, dialects.ErrUnsafeStatement) {
+		t.Errorf("Expected WHERE in a subquery not to count, got %v", err)
+	}
+	if _, err := conn.Exec(ctx, `UPDATE items SET "where" = 1`); !errors.Is(err, dialects.ErrUnsafeStatement) {
+		t.Errorf("Expected WHERE as a quoted identifier not to count, got %v", err)
+	}
+	if _, err := conn.Exec(ctx, "WITH old AS (SELECT id FROM items WHERE owner = 1) DELETE FROM items"); !errors.Is(err, dialects.ErrUnsafeStatement) {
+		t.Errorf("Expected a DELETE after a CTE to be refused, got %v", err)
+	}
+	if _, err := conn.Exec(ctx, "WITH RECURSIVE ids(id) AS (SELECT 1) UPDATE items SET status = 'x'"); !errors.Is(err, dialects.ErrUnsafeStatement) {
+		t.Errorf("Expected an UPDATE after a CTE to be refused, got %v", err)
+	}
+	var id int64
+	if err := conn.QueryRow(ctx, "DELETE FROM items RETURNING id").Scan(&id); !errors.Is(err, dialects.ErrUnsafeStatement) {
+		t.Errorf("Expected QueryRow to refuse a DELETE without WHERE, got %v", err)
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM items"); !errors.Is(err, dialects.ErrUnsafeStatement) {
+		t.Errorf("Expected the transaction to inherit the guards, got %v", err)
+	}
+	if err := tx.QueryRow(ctx, "UPDATE items SET status = 'x' RETURNING id").Scan(&id); !errors.Is(err, dialects.ErrUnsafeStatement) {
+		t.Errorf("Expected the transaction's QueryRow to refuse an UPDATE without WHERE, got %v", err)
+	}
+	tx.Rollback()
+	if n, err := items.Select().Count(ctx); err != nil || n != 10 {
+		t.Errorf("Expected no refused statement to run, got %d rows (%v)", n, err)
+	}
+
+	if _, err := conn.Exec(ctx, "WITH one AS (SELECT 1 AS id) UPDATE items SET status = 'new' WHERE id IN (SELECT id FROM one)"); err != nil {
+		t.Errorf("Expected an UPDATE with a WHERE after a CTE to run, got %v", err)
+	}
+	if err := conn.QueryRow(ctx, "UPDATE items SET status = 'new' WHERE id = 2 RETURNING id").Scan(&id); err != nil || id != 2 {
+		t.Errorf("Expected QueryRow to run an UPDATE with WHERE, got %d (%v)", id, err)
+	}
+
+	if n, err := items.Update(map[string]interface{}{"status": "done"}).Where(query.Eq("id", 1)).Exec(ctx); err != nil || n != 1 {
+		t.Errorf("Expected 1 row updated, got %d (%v)", n, err)
+	}
+	if n, err := items.Update(map[string]interface{}{"status": "old"}).AllowFullTable().Exec(ctx); err != nil || n != 10 {
+		t.Errorf("Expected the allowed update of 10 rows, got %d (%v)", n, err)
+	}
+	if n, err := items.Delete().AllowFullTable().Exec(ctx); err != nil || n != 10 {
+		t.Errorf("Expected the allowed delete of 10 rows, got %d (%v)", n, err)
+	}
+}
+
+func TestSafetyMaxRows(t *testing.T) {
+	conn := setupBatchDB(t, 10)
+	conn.SetSafety(dialects.Safety{MaxRows: 3})
+	ctx := context.Background()
+	items := query.New(conn, "items")
+	count := func() int64 {
+		n, err := items.Select().Where(query.Eq("status", "new")).Count(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if _, err := items.Update(map[string]interface{}{"status": "done"}).Where(query.Eq("owner", 1)).Exec(ctx); !errors.Is(err, dialects.ErrUnsafeStatement) {
+		t.Errorf("Expected an update of 5 rows to be refused, got %v", err)
+	}
+	if n := count(); n != 10 {
+		t.Errorf("Expected the refused update to be rolled back, %d rows left new", n)
+	}
+	if n, err := items.Update(map[string]interface{}{"status": "done"}).Where(query.Lt("id", 4)).Exec(ctx); err != nil || n != 3 {
+		t.Errorf("Expected 3 rows updated, got %d (%v)", n, err)
+	}
+	if _, err := conn.Exec(dialects.AllowFullTable(ctx), "UPDATE items SET status = 'done' WHERE owner = 1"); err != nil {
+		t.Errorf("Expected the allowed update to run, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/internal/studio"
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func TestSchemaHistory(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	conn := dialects.NewConnection(db, sqlite.New())
+
+	files := map[string]string{
+		"20240101_100000_users.sql": "-- UP\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE users;\n",
+		"20240201_100000_email.sql": "-- UP\nALTER TABLE users ADD COLUMN email TEXT;\nCREATE INDEX idx_users_email ON users (email);\n\n-- DOWN\nDROP INDEX idx_users_email;\nALTER TABLE users DROP COLUMN email;\n",
+		"20240301_100000_posts.sql": "-- UP\nCREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER);\n\n-- DOWN\nDROP TABLE posts;\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engine := migration.NewEngine(conn)
+	if err := engine.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	engine.LoadFromDir(dir)
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := engine.SchemaAt(ctx, "20240101_100000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Tables) != 1 || len(first.Tables["users"].Columns) != 1 {
+		t.Errorf("Expected only users(id) after the first migration, got %+v", first.Tables)
+	}
+	second, err := engine.SchemaAt(ctx, "20240201_100000_email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(migration.DescribeChanges(migration.DiffSnapshots(first, second)), "\n")
+	if got != "+ ADD COLUMN users.email\n+ ADD INDEX users.idx_users_email" {
+		t.Errorf("Unexpected changes from the first to the second migration:\n%s", got)
+	}
+
+	// Rolling back forgets the schema of the migration
+	if err := engine.Down(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.SchemaAt(ctx, "20240301_100000"); !errors.Is(err, migration.ErrNoSchemaHistory) {
+		t.Errorf("Expected no schema for the rolled back migration, got %v", err)
+	}
+	if versions, _ := engine.SchemaHistory(ctx); len(versions) != 2 {
+		t.Errorf("Expected 2 recorded schemas, got %v", versions)
+	}
+
+	h := studio.NewServer(studio.Config{Connection: conn, Migrations: engine}).Handler()
+	code, resp := studioRequest(t, h, http.MethodGet, "/api/migrations/history", "")
+	if versions, _ := resp["versions"].([]interface{}); code != http.StatusOK || len(versions) != 2 {
+		t.Fatalf("Unexpected schema history: %d %v", code, resp)
+	}
+	code, resp = studioRequest(t, h, http.MethodGet, "/api/migrations/20240101_100000/schema?diff=current", "")
+	if changes, _ := resp["changes"].([]interface{}); code != http.StatusOK || len(changes) != 2 || resp["schema"] == nil {
+		t.Errorf("Unexpected schema with diff: %d %v", code, resp)
+	}
+	if code, _ := studioRequest(t, h, http.MethodGet, "/api/migrations/20240301_100000/schema", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a migration without a schema, got %d", code)
+	}
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+const verifySchema = `
+model User {
+  id    Int    @id @autoincrement
+  email String
+
+  @@index([email])
+  @@map("users")
+}
+`
+
+func TestEngine_Verify(t *testing.T) {
+	ctx := context.Background()
+	conn := lockConn(t)
+	s, err := schema.NewParser(verifySchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	dir := t.TempDir()
+	m, err := migration.GenerateMigrationFromDiff(conn.Dialect, migration.Diff(s, &migration.DatabaseSnapshot{}).Changes, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "-- UP\n" + m.UpSQL + "\n\n-- DOWN\n" + m.DownSQL + "\n"
+	os.WriteFile(filepath.Join(dir, "20240101_100000_users.sql"), []byte(content), 0644)
+
+	engine := migration.NewEngine(conn)
+	if err := engine.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	engine.LoadFromDir(dir)
+
+	// Mismatches are expected while migrations are pending
+	result, err := engine.Verify(ctx, s)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(result.Pending) != 1 || len(result.Mismatches) == 0 || result.Drifted() {
+		t.Errorf("Expected a pending migration and no drift, got %+v", result)
+	}
+
+	if _, err := engine.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	result, err = engine.Verify(ctx, s)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if result.Drifted() || result.Baseline != "20240101_100000" {
+		t.Fatalf("Expected no drift after migrating, got %+v (%q)", result, migration.DescribeChanges(result.Mismatches))
+	}
+
+	// Changes made by hand
+	conn.DB.ExecContext(ctx, "ALTER TABLE users ADD COLUMN nickname TEXT")
+	conn.DB.ExecContext(ctx, "CREATE INDEX idx_users_nickname ON users (nickname)")
+	result, err = engine.Verify(ctx, s)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !result.Drifted() {
+		t.Fatal("Expected drift after changing the database by hand")
+	}
+	got := strings.Join(migration.DescribeChanges(result.OutOfBand), "\n")
+	if got != "+ ADD COLUMN users.nickname\n+ ADD INDEX users.idx_users_nickname" {
+		t.Errorf("Unexpected out-of-band changes:\n%s", got)
+	}
+	got = strings.Join(migration.DescribeChanges(result.Mismatches), "\n")
+	if !strings.Contains(got, "- DROP COLUMN users.nickname") || !strings.Contains(got, "- DROP INDEX users.idx_users_nickname") {
+		t.Errorf("Expected the changes to match the schema file, got:\n%s", got)
+	}
+}
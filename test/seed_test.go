@@ -3,12 +3,16 @@ package test
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/nexus-db/nexus/pkg/core/schema"
 	"github.com/nexus-db/nexus/pkg/core/seed"
 	"github.com/nexus-db/nexus/pkg/dialects"
 	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
@@ -237,6 +241,31 @@ func TestSeed_EnvironmentFilter(t *testing.T) {
 	}
 }
 
+func TestSeed_LoadFromFS(t *testing.T) {
+	conn := setupSeedTestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	files := fstest.MapFS{
+		"001_main.sql":     {Data: []byte(`INSERT INTO users (email, name) VALUES ('main@example.com', 'Main');`)},
+		"dev/001_dev.sql":  {Data: []byte(`INSERT INTO users (email, name) VALUES ('dev@example.com', 'Dev');`)},
+		"test/001_fix.sql": {Data: []byte(`INSERT INTO users (email, name) VALUES ('test@example.com', 'Test');`)},
+	}
+	engine := seed.NewEngine(conn)
+	engine.Init(ctx)
+	if err := engine.LoadFromFS(files); err != nil {
+		t.Fatalf("LoadFromFS failed: %v", err)
+	}
+
+	applied, err := engine.Run(ctx, "dev")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if applied != 2 {
+		t.Errorf("Expected 2 seeds (main + dev), got %d", applied)
+	}
+}
+
 func TestSeed_OrderPreservation(t *testing.T) {
 	conn := setupSeedTestDB(t)
 	defer conn.Close()
@@ -271,3 +300,388 @@ func TestSeed_OrderPreservation(t *testing.T) {
 		}
 	}
 }
+
+func TestSeed_GoSeedsAndDependencies(t *testing.T) {
+	conn := setupSeedTestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	// Numbered first, but needs the Go seed below
+	profiles := `-- depends: admins
+INSERT INTO users (email, name) SELECT 'profile-' || email, 'Profile' FROM users WHERE name = 'Admin';
+`
+	if err := os.WriteFile(filepath.Join(dir, "001_profiles.sql"), []byte(profiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "002_roles.sql"), []byte(`INSERT INTO users (email) VALUES ('roles@example.com');`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := seed.NewEngine(conn)
+	engine.Init(ctx)
+	if err := engine.LoadFromDir(dir); err != nil {
+		t.Fatalf("Failed to load seeds: %v", err)
+	}
+	var ran []string
+	engine.Register("admins", []string{"roles"}, func(ctx context.Context, tx *dialects.Tx) error {
+		ran = append(ran, "admins")
+		_, err := tx.Exec(ctx, "INSERT INTO users (email, name) VALUES ('admin@example.com', 'Admin')")
+		return err
+	})
+
+	applied, err := engine.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if applied != 3 || len(ran) != 1 {
+		t.Fatalf("Expected 3 seeds with the Go seed once, got %d and %v", applied, ran)
+	}
+
+	rows, err := conn.Query(ctx, "SELECT email FROM users ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var emails []string
+	for rows.Next() {
+		var email string
+		rows.Scan(&email)
+		emails = append(emails, email)
+	}
+	want := []string{"roles@example.com", "admin@example.com", "profile-admin@example.com"}
+	if strings.Join(emails, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected dependency order %v, got %v", want, emails)
+	}
+
+	// Applied Go seeds are tracked like SQL seeds
+	if applied, _ := engine.Run(ctx, ""); applied != 0 || len(ran) != 1 {
+		t.Errorf("Expected nothing to run again, got %d", applied)
+	}
+}
+
+func TestSeed_GoSeedTransaction(t *testing.T) {
+	conn := setupSeedTestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	engine := seed.NewEngine(conn)
+	engine.Init(ctx)
+	engine.Register("broken", nil, func(ctx context.Context, tx *dialects.Tx) error {
+		if _, err := tx.Exec(ctx, "INSERT INTO users (email) VALUES ('partial@example.com')"); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	})
+
+	if _, err := engine.Run(ctx, ""); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("Expected the seed error, got %v", err)
+	}
+	var count int
+	conn.QueryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected the failed seed to be rolled back, got %d rows", count)
+	}
+	status, _ := engine.Status(ctx)
+	if len(status) != 1 || status[0].Applied {
+		t.Errorf("Expected the failed seed to stay pending, got %+v", status)
+	}
+}
+
+func TestSeed_DependencyErrors(t *testing.T) {
+	conn := setupSeedTestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+	noop := func(ctx context.Context, tx *dialects.Tx) error { return nil }
+
+	engine := seed.NewEngine(conn)
+	engine.Init(ctx)
+	engine.Register("a", []string{"b"}, noop)
+	engine.Register("b", []string{"a"}, noop)
+	if _, err := engine.Run(ctx, ""); err == nil || !strings.Contains(err.Error(), "cycle: a, b") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+
+	engine = seed.NewEngine(conn)
+	engine.Register("c", []string{"missing"}, noop)
+	if _, err := engine.Run(ctx, ""); err == nil || !strings.Contains(err.Error(), `depends on "missing"`) {
+		t.Errorf("Expected a missing dependency error, got %v", err)
+	}
+}
+
+const dataSeedSchema = `
+model Account {
+  id        Int      @id @autoincrement
+  email     String   @unique
+  name      String?
+  age       Int?
+  active    Bool     @default(true)
+  joined_at DateTime?
+  @@map("accounts")
+}
+
+model Tag {
+  slug  String @id
+  label String
+}
+`
+
+func TestSeed_DataFiles(t *testing.T) {
+	conn := setupSeedTestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+	if _, err := conn.Exec(ctx, `CREATE TABLE accounts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT NOT NULL UNIQUE,
+		name TEXT,
+		age INTEGER,
+		active BOOLEAN NOT NULL DEFAULT 1,
+		joined_at TIMESTAMP
+	);
+	CREATE TABLE "Tag" (slug TEXT PRIMARY KEY, label TEXT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	s, err := schema.NewParser(dataSeedSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "dev"), 0755)
+	files := map[string]string{
+		"001_accounts.csv": "email,name,age,active\nada@example.com,Ada,36,yes\ngrace@example.com,Grace,,false\n",
+		"002_tags.json":    `[{"slug": "go", "label": "Go"}, {"slug": "sql", "label": "SQL"}]`,
+		"dev/003_accounts.yaml": `# Updates Ada, adds Alan and Linus
+- email: ada@example.com
+  name: "Ada Lovelace"
+- email: alan@example.com
+  active: false
+- email: linus@example.com
+  age: 21
+  joined_at: 2024-03-01
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	engine := seed.NewEngine(conn).WithSchema(s)
+	engine.Init(ctx)
+	if err := engine.LoadFromDir(dir); err != nil {
+		t.Fatalf("Failed to load seeds: %v", err)
+	}
+	applied, err := engine.Run(ctx, "dev")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if applied != 3 {
+		t.Fatalf("Expected 3 data seeds, got %d", applied)
+	}
+
+	var label string
+	conn.QueryRow(ctx, `SELECT label FROM "Tag" WHERE slug = 'sql'`).Scan(&label)
+	if label != "SQL" {
+		t.Errorf("Expected the JSON seed to insert tags, got %q", label)
+	}
+
+	var count int
+	conn.QueryRow(ctx, "SELECT COUNT(*) FROM accounts").Scan(&count)
+	if count != 4 {
+		t.Errorf("Expected the YAML seed to update Ada instead of adding her, got %d rows", count)
+	}
+	var name string
+	var age sql.NullInt64
+	var active bool
+	conn.QueryRow(ctx, "SELECT name, age, active FROM accounts WHERE email = 'ada@example.com'").Scan(&name, &age, &active)
+	if name != "Ada Lovelace" || age.Int64 != 36 || !active {
+		t.Errorf("Expected the converted and updated row, got %q %v %v", name, age, active)
+	}
+	conn.QueryRow(ctx, "SELECT age, active FROM accounts WHERE email = 'grace@example.com'").Scan(&age, &active)
+	if age.Valid || active {
+		t.Errorf("Expected an empty cell to stay NULL and false to convert, got %v %v", age, active)
+	}
+	var joined sql.NullTime
+	conn.QueryRow(ctx, "SELECT joined_at FROM accounts WHERE email = 'linus@example.com'").Scan(&joined)
+	if !joined.Valid || joined.Time.Year() != 2024 {
+		t.Errorf("Expected the date to be converted, got %v", joined)
+	}
+
+	// Re-running upserts rather than duplicating
+	if _, err := engine.Reset(ctx, "dev"); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	conn.QueryRow(ctx, "SELECT COUNT(*) FROM accounts").Scan(&count)
+	if count != 4 {
+		t.Errorf("Expected reseeding to keep 4 rows, got %d", count)
+	}
+}
+
+func TestSeed_DataFileErrors(t *testing.T) {
+	conn := setupSeedTestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+	s, _ := schema.NewParser(dataSeedSchema).Parse()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "accounts.csv"), []byte("email,nickname\nada@example.com,ada\n"), 0644)
+
+	engine := seed.NewEngine(conn)
+	engine.Init(ctx)
+	engine.LoadFromDir(dir)
+	if _, err := engine.Run(ctx, ""); err == nil || !strings.Contains(err.Error(), "needs a schema") {
+		t.Errorf("Expected a missing schema error, got %v", err)
+	}
+
+	engine = seed.NewEngine(conn).WithSchema(s)
+	engine.LoadFromDir(dir)
+	if _, err := engine.Run(ctx, ""); err == nil || !strings.Contains(err.Error(), `row 1: accounts has no column "nickname"`) {
+		t.Errorf("Expected an unknown column error, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "accounts.csv"), []byte("email,age\nada@example.com,old\n"), 0644)
+	engine = seed.NewEngine(conn).WithSchema(s)
+	engine.LoadFromDir(dir)
+	if _, err := engine.Run(ctx, ""); err == nil || !strings.Contains(err.Error(), "column age") {
+		t.Errorf("Expected a conversion error, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "accounts.yaml"), []byte("- email: [a, b]\n"), 0644)
+	if err := seed.NewEngine(conn).LoadFromDir(dir); err == nil || !strings.Contains(err.Error(), "accounts.yaml") {
+		t.Errorf("Expected a YAML parse error, got %v", err)
+	}
+}
+
+func TestSeed_ChangePolicies(t *testing.T) {
+	conn := setupSeedTestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "001_users.sql")
+	os.WriteFile(file, []byte("INSERT INTO users (email) VALUES ('a@example.com');"), 0644)
+	load := func(policy seed.DriftPolicy) *seed.Engine {
+		engine := seed.NewEngine(conn).WithDriftPolicy(policy)
+		engine.Init(ctx)
+		if err := engine.LoadFromDir(dir); err != nil {
+			t.Fatalf("Failed to load seeds: %v", err)
+		}
+		return engine
+	}
+	if _, err := load(seed.DriftWarn).Run(ctx, ""); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	os.WriteFile(file, []byte("INSERT INTO users (email) VALUES ('b@example.com');"), 0644)
+
+	// warn: reported, not re-run
+	engine := load(seed.DriftWarn)
+	changed, err := engine.Changed(ctx, "")
+	if err != nil || len(changed) != 1 || changed[0].Name != "users" {
+		t.Fatalf("Expected the edited seed to be reported, got %v, %v", changed, err)
+	}
+	status, _ := engine.Status(ctx)
+	if len(status) != 1 || !status[0].Changed {
+		t.Errorf("Expected the status to flag the change, got %+v", status)
+	}
+	if applied, err := engine.Run(ctx, ""); applied != 0 || err != nil {
+		t.Errorf("Expected nothing to run, got %d, %v", applied, err)
+	}
+
+	// error: the run fails
+	if _, err := load(seed.DriftError).Run(ctx, ""); err == nil || !strings.Contains(err.Error(), "changed since they were applied: users") {
+		t.Errorf("Expected a change error, got %v", err)
+	}
+
+	// rerun-on-change: runs again and records the new checksum
+	engine = load(seed.DriftRerun)
+	if applied, err := engine.Run(ctx, ""); applied != 1 || err != nil {
+		t.Fatalf("Expected the seed to re-run, got %d, %v", applied, err)
+	}
+	var count int
+	conn.QueryRow(ctx, "SELECT COUNT(*) FROM users WHERE email = 'b@example.com'").Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected the edited seed to run, got %d rows", count)
+	}
+	if changed, _ := engine.Changed(ctx, ""); len(changed) != 0 {
+		t.Errorf("Expected no changes after the re-run, got %v", changed)
+	}
+	if applied, _ := engine.Run(ctx, ""); applied != 0 {
+		t.Errorf("Expected nothing to re-run, got %d", applied)
+	}
+
+	if _, err := seed.ParseDriftPolicy("sometimes"); err == nil {
+		t.Error("Expected an unknown policy to fail")
+	}
+	if p, _ := seed.ParseDriftPolicy(""); p != seed.DriftWarn {
+		t.Errorf("Expected warn by default, got %q", p)
+	}
+}
+
+func TestSeed_FailureReport(t *testing.T) {
+	conn := setupSeedTestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"001_users.sql": `-- description: semicolons in strings and comments; are fine
+INSERT INTO users (email, name) VALUES ('a@example.com', 'a;b'); /* ; */
+INSERT INTO users (email) VALUES ('b@example.com');`,
+		"002_broken.sql": `INSERT INTO users (email) VALUES ('c@example.com');
+INSERT INTO missing (x) VALUES (1);`,
+		"003_after_broken.sql": "-- depends: broken\nINSERT INTO users (email) VALUES ('d@example.com');",
+		"004_independent.sql":  "INSERT INTO users (email) VALUES ('e@example.com');",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	load := func(cont bool) *seed.Engine {
+		engine := seed.NewEngine(conn).WithContinueOnError(cont)
+		engine.Init(ctx)
+		if err := engine.LoadFromDir(dir); err != nil {
+			t.Fatalf("Failed to load seeds: %v", err)
+		}
+		return engine
+	}
+	names := func(seeds []*seed.Seed) string {
+		var out []string
+		for _, s := range seeds {
+			out = append(out, s.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	// By default the run stops at the failure
+	report, err := load(false).RunReport(ctx, "")
+	if err == nil || !strings.Contains(err.Error(), "applying seed broken: statement 2:") {
+		t.Fatalf("Expected the failing statement in the error, got %v", err)
+	}
+	if names(report.Applied) != "users" || names(report.Skipped) != "after_broken,independent" {
+		t.Errorf("Expected users applied and the rest skipped, got %q and %q", names(report.Applied), names(report.Skipped))
+	}
+	if len(report.Failed) != 1 || report.Failed[0].Statement != "INSERT INTO missing (x) VALUES (1)" {
+		t.Fatalf("Expected the failing statement, got %+v", report.Failed)
+	}
+	var name string
+	conn.QueryRow(ctx, "SELECT name FROM users WHERE email = 'a@example.com'").Scan(&name)
+	if name != "a;b" {
+		t.Errorf("Expected the quoted semicolon to survive, got %q", name)
+	}
+	var count int
+	conn.QueryRow(ctx, "SELECT COUNT(*) FROM users WHERE email = 'c@example.com'").Scan(&count)
+	if count != 0 {
+		t.Error("Expected the failed seed to be rolled back")
+	}
+
+	// --continue runs what does not depend on the failure
+	report, err = load(true).RunReport(ctx, "")
+	if err == nil {
+		t.Fatal("Expected the failure to be returned")
+	}
+	if names(report.Applied) != "independent" || names(report.Skipped) != "after_broken" || len(report.Failed) != 1 {
+		t.Errorf("Expected only the independent seed to run, got %q, %q and %d failures",
+			names(report.Applied), names(report.Skipped), len(report.Failed))
+	}
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestSlowQueryLog(t *testing.T) {
+	conn := setupProfilerTestDB(t)
+	defer conn.Close()
+
+	var mu sync.Mutex
+	var logged []query.SlowQuery
+	conn.Use(query.NewSlowQueryLog(query.SlowQueryLogOptions{
+		Sink: query.SlowQuerySinkFunc(func(q query.SlowQuery) error {
+			mu.Lock()
+			defer mu.Unlock()
+			logged = append(logged, q)
+			return nil
+		}),
+	}).Hook())
+
+	ctx := context.Background()
+	if _, err := query.New(conn, "users").Select().Where(query.Eq("name", "secret")).All(ctx); err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	tx.Exec(ctx, "SELECT * FROM missing")
+	tx.Rollback()
+
+	if len(logged) != 2 {
+		t.Fatalf("Expected 2 logged queries, got %d", len(logged))
+	}
+	if len(logged[0].Args) != 1 || logged[0].Args[0] != "<string>" {
+		t.Errorf("Expected redacted args, got %v", logged[0].Args)
+	}
+	if logged[1].Error == "" {
+		t.Error("Expected the failed query to be logged with its error")
+	}
+}
+
+func TestSlowQueryLogThresholdAndSampling(t *testing.T) {
+	var buf bytes.Buffer
+	log := query.NewSlowQueryLog(query.SlowQueryLogOptions{
+		Threshold: 50 * time.Millisecond,
+		LogArgs:   true,
+		Sink:      query.NewJSONLinesSink(&buf),
+	})
+	log.Observe(dialects.QueryEvent{Query: "SELECT 1", Duration: 10 * time.Millisecond})
+	log.Observe(dialects.QueryEvent{Query: "SELECT 2", Args: []interface{}{42}, Duration: 75 * time.Millisecond})
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single JSON line, got %q: %v", buf.String(), err)
+	}
+	if entry["query"] != "SELECT 2" || entry["durationMs"] != 75.0 {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+	if args, _ := entry["args"].([]interface{}); len(args) != 1 || args[0] != 42.0 {
+		t.Errorf("Expected args to be logged, got %v", entry["args"])
+	}
+
+	count := 0
+	sampled := query.NewSlowQueryLog(query.SlowQueryLogOptions{
+		SampleRate: 0.2,
+		Sink: query.SlowQuerySinkFunc(func(query.SlowQuery) error {
+			count++
+			return nil
+		}),
+	})
+	for i := 0; i < 1000; i++ {
+		sampled.Observe(dialects.QueryEvent{Query: "SELECT 1"})
+	}
+	if count < 100 || count > 300 {
+		t.Errorf("Expected about 200 of 1000 queries sampled, got %d", count)
+	}
+}
+
+func TestRotatingFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slow.log")
+	sink, err := query.NewRotatingFileSink(path, 200, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFileSink failed: %v", err)
+	}
+	defer sink.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := sink.Write(query.SlowQuery{Query: "SELECT * FROM users WHERE id = ?", Duration: time.Second}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("Expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("Expected %s to stay under 200 bytes, got %d", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only 2 backups, got %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Errorf("Expected JSON lines, got %q", scanner.Text())
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+)
+
+func snapshotTestSchema() *schema.Schema {
+	s := schema.NewSchema()
+	s.Model("User", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("email").Unique()
+		m.String("name").Null()
+		m.Index("idx_user_name", "name")
+	})
+	return s
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	dialect := sqlite.New()
+	path := filepath.Join(t.TempDir(), ".nexus.lock")
+
+	snap := migration.NewSchemaSnapshot(snapshotTestSchema(), dialect)
+	if err := migration.SaveSnapshot(path, snap); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	loaded, err := migration.LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot failed: %v", err)
+	}
+
+	if loaded.Version != migration.SnapshotVersion {
+		t.Errorf("Expected version %d, got %d", migration.SnapshotVersion, loaded.Version)
+	}
+	if loaded.Dialect != "sqlite" {
+		t.Errorf("Expected dialect sqlite, got %s", loaded.Dialect)
+	}
+
+	user, ok := loaded.Tables["User"]
+	if !ok {
+		t.Fatal("Expected User table in snapshot")
+	}
+	if len(user.Columns) != 3 {
+		t.Errorf("Expected 3 columns, got %d", len(user.Columns))
+	}
+	if !user.Columns["id"].IsPrimaryKey {
+		t.Error("Expected id to be primary key")
+	}
+	if _, ok := user.Indexes["idx_user_name"]; !ok {
+		t.Error("Expected idx_user_name in snapshot")
+	}
+}
+
+func TestSnapshot_OfflineDiff(t *testing.T) {
+	dialect := sqlite.New()
+	snap := migration.NewSchemaSnapshot(snapshotTestSchema(), dialect)
+
+	// Unchanged schema produces no diff
+	if diff := migration.Diff(snapshotTestSchema(), snap.Database()); diff.HasChanges() {
+		t.Fatalf("Expected no changes, got %v", migration.DescribeChanges(diff.Changes))
+	}
+
+	// Adding a column is detected without a database
+	changed := snapshotTestSchema()
+	changed.Models["User"].Int("age").Null()
+
+	diff := migration.Diff(changed, snap.Database())
+	if len(diff.Changes) != 1 {
+		t.Fatalf("Expected 1 change, got %v", migration.DescribeChanges(diff.Changes))
+	}
+	if diff.Changes[0].Type != migration.ChangeAddColumn || diff.Changes[0].ColumnName != "age" {
+		t.Errorf("Expected ADD COLUMN age, got %s %s", diff.Changes[0].Type, diff.Changes[0].ColumnName)
+	}
+}
+
+func TestSnapshot_RejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".nexus.lock")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "dialect": "sqlite", "tables": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migration.LoadSnapshot(path); err == nil {
+		t.Error("Expected error for unsupported snapshot version")
+	}
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+func TestSQLCacheMatchesRenderedSQL(t *testing.T) {
+	conn := dialects.NewConnection(nil, postgres.New())
+	cache := query.NewSQLCache(10)
+
+	shapes := []func(b *query.Builder, v int) *query.SelectBuilder{
+		func(b *query.Builder, v int) *query.SelectBuilder {
+			return b.Select("id", "name").Where(query.Eq("id", v))
+		},
+		func(b *query.Builder, v int) *query.SelectBuilder {
+			return b.Select().Where(query.In("id", v, v+1), query.IsNull("deleted_at"), query.Gt("age", v)).
+				GroupBy("age").Having(query.Gt("COUNT(*)", v)).OrderBy("age", query.Desc).Limit(10)
+		},
+		func(b *query.Builder, v int) *query.SelectBuilder {
+			return b.Select().Where(query.In("id", v, v+1, v+2))
+		},
+	}
+	for round := 1; round <= 3; round++ {
+		for i, shape := range shapes {
+			wantSQL, wantArgs := shape(query.New(conn, "users"), round).Build()
+			gotSQL, gotArgs := shape(query.New(conn, "users").WithSQLCache(cache), round).Build()
+			if gotSQL != wantSQL || !reflect.DeepEqual(gotArgs, wantArgs) {
+				t.Errorf("shape %d round %d: cached %q %v, rendered %q %v", i, round, gotSQL, gotArgs, wantSQL, wantArgs)
+			}
+		}
+	}
+
+	// The IN lists of different lengths are different shapes
+	if stats := cache.Stats(); stats.Size != 3 || stats.Misses != 3 || stats.Hits != 6 {
+		t.Errorf("Expected 3 shapes missed once and hit twice, got %+v", stats)
+	}
+
+	// Subqueries are rendered every time
+	sub := query.New(conn, "posts").Select("user_id")
+	query.New(conn, "users").WithSQLCache(cache).Select().WhereIn("id", sub).Build()
+	if stats := cache.Stats(); stats.Size != 3 || stats.Hits+stats.Misses != 9 {
+		t.Errorf("Expected subqueries to bypass the cache, got %+v", stats)
+	}
+}
+
+func TestSQLCacheWithStmtCacheAndProfiler(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+	defer conn.Close()
+	ctx := context.Background()
+	conn.Exec(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`)
+	conn.Exec(ctx, `INSERT INTO users (id, name) VALUES (1, 'Ada'), (2, 'Grace')`)
+
+	stmts := query.NewStmtCacheWithStats(db, 10)
+	cache := query.NewSQLCache(10).WithStmtCache(stmts.StmtCache)
+	profiler := query.NewProfiler(query.DefaultProfilerOptions())
+	profiler.Start()
+
+	users := query.New(conn, "users").WithSQLCache(cache).WithProfiler(profiler)
+	for _, id := range []int{1, 2, 1} {
+		rows, err := users.Select("name").Where(query.Eq("id", id)).All(ctx)
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		if len(rows) != 1 {
+			t.Fatalf("Expected one row for id %d, got %v", id, rows)
+		}
+	}
+	profiler.Stop()
+
+	if stmts.Size() != 1 {
+		t.Errorf("Expected one prepared statement, got %d", stmts.Size())
+	}
+	report := profiler.Report()
+	if report.SQLCacheHits != 2 || report.SQLCacheMisses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss in the report, got %d and %d", report.SQLCacheHits, report.SQLCacheMisses)
+	}
+	if !strings.Contains(report.String(), "SQL Cache:        2 hits, 1 misses") {
+		t.Errorf("Expected the text report to show the cache, got:\n%s", report)
+	}
+}
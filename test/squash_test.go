@@ -1,9 +1,16 @@
 package test
 
 import (
+	"context"
+	"database/sql"
+	"path/filepath"
 	"testing"
 
+	_ "github.com/mattn/go-sqlite3"
+
 	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
 )
 
 func TestSquash_BasicMerge(t *testing.T) {
@@ -247,8 +254,8 @@ func TestSquash_PreservesOrder(t *testing.T) {
 		{
 			ID:      "20231202_100000",
 			Name:    "second",
-			UpSQL:   "ALTER TABLE users ADD COLUMN name TEXT",
-			DownSQL: "ALTER TABLE users DROP COLUMN name",
+			UpSQL:   "CREATE INDEX idx_users_id ON users(id)",
+			DownSQL: "DROP INDEX idx_users_id",
 		},
 	}
 
@@ -260,16 +267,157 @@ func TestSquash_PreservesOrder(t *testing.T) {
 		t.Fatalf("Squash failed: %v", err)
 	}
 
-	// CREATE TABLE should come before ALTER TABLE
+	// CREATE TABLE should come before CREATE INDEX
 	createIdx := indexOfSubstr(result.Migration.UpSQL, "CREATE TABLE")
-	alterIdx := indexOfSubstr(result.Migration.UpSQL, "ALTER TABLE")
+	indexIdx := indexOfSubstr(result.Migration.UpSQL, "CREATE INDEX")
 
-	if createIdx == -1 || alterIdx == -1 {
+	if createIdx == -1 || indexIdx == -1 {
 		t.Fatal("Both statements should be present")
 	}
 
-	if createIdx > alterIdx {
-		t.Error("CREATE TABLE should come before ALTER TABLE")
+	if createIdx > indexIdx {
+		t.Error("CREATE TABLE should come before CREATE INDEX")
+	}
+}
+
+func TestSquash_MergeColumnsIntoCreateTable(t *testing.T) {
+	migrations := []*migration.Migration{
+		{
+			ID:      "20231201_100000",
+			Name:    "create_users",
+			UpSQL:   "CREATE TABLE IF NOT EXISTS \"users\" (\n  \"id\" INTEGER PRIMARY KEY,\n  \"nickname\" TEXT,\n  \"legacy\" TEXT\n)",
+			DownSQL: "DROP TABLE \"users\"",
+		},
+		{
+			ID:      "20231202_100000",
+			Name:    "add_email",
+			UpSQL:   "ALTER TABLE \"users\" ADD COLUMN \"email\" TEXT NOT NULL DEFAULT '';\nCREATE INDEX idx_users_nickname ON users(nickname)",
+			DownSQL: "DROP INDEX idx_users_nickname;\nALTER TABLE \"users\" DROP COLUMN \"email\"",
+		},
+		{
+			ID:      "20231203_100000",
+			Name:    "cleanup",
+			UpSQL:   "ALTER TABLE users DROP COLUMN legacy;\nALTER TABLE users DROP COLUMN nickname",
+			DownSQL: "ALTER TABLE users ADD COLUMN nickname TEXT;\nALTER TABLE users ADD COLUMN legacy TEXT",
+		},
+	}
+
+	result, err := migration.SquashMigrations(migrations, migration.SquashOptions{OutputName: "users"})
+	if err != nil {
+		t.Fatalf("Squash failed: %v", err)
+	}
+
+	// email joins the table and legacy leaves it; nickname stays, as the
+	// index refers to it
+	wantUp := "CREATE TABLE IF NOT EXISTS \"users\" (\n  \"id\" INTEGER PRIMARY KEY,\n  \"nickname\" TEXT,\n  \"email\" TEXT NOT NULL DEFAULT ''\n);\n\n" +
+		"CREATE INDEX idx_users_nickname ON users(nickname);\n\n" +
+		"ALTER TABLE users DROP COLUMN nickname;"
+	if result.Migration.UpSQL != wantUp {
+		t.Errorf("Expected UpSQL:\n%s\ngot:\n%s", wantUp, result.Migration.UpSQL)
+	}
+	if result.MergedCount != 2 {
+		t.Errorf("Expected 2 merged statements, got %d", result.MergedCount)
+	}
+
+	// Changes to the table before it is dropped are left out of DOWN
+	if containsSubstr(result.Migration.DownSQL, "ALTER TABLE") || !containsSubstr(result.Migration.DownSQL, "DROP TABLE \"users\"") {
+		t.Errorf("Expected DownSQL to drop the table only, got:\n%s", result.Migration.DownSQL)
+	}
+}
+
+func TestSquash_MergeAlterTable(t *testing.T) {
+	migrations := []*migration.Migration{
+		{
+			ID:      "20231201_100000",
+			Name:    "add_bio",
+			UpSQL:   "ALTER TABLE users ADD COLUMN bio TEXT",
+			DownSQL: "ALTER TABLE users DROP COLUMN bio",
+		},
+		{
+			ID:      "20231202_100000",
+			Name:    "add_avatar",
+			UpSQL:   "ALTER TABLE users ADD COLUMN avatar TEXT;\nALTER TABLE users RENAME COLUMN name TO full_name",
+			DownSQL: "ALTER TABLE users RENAME COLUMN full_name TO name;\nALTER TABLE users DROP COLUMN avatar",
+		},
+	}
+
+	result, err := migration.SquashMigrations(migrations, migration.SquashOptions{OutputName: "profile", Dialect: "postgres"})
+	if err != nil {
+		t.Fatalf("Squash failed: %v", err)
+	}
+	wantUp := "ALTER TABLE users ADD COLUMN bio TEXT, ADD COLUMN avatar TEXT;\n\nALTER TABLE users RENAME COLUMN name TO full_name;"
+	if result.Migration.UpSQL != wantUp {
+		t.Errorf("Expected UpSQL:\n%s\ngot:\n%s", wantUp, result.Migration.UpSQL)
+	}
+	wantDown := "ALTER TABLE users RENAME COLUMN full_name TO name;\n\nALTER TABLE users DROP COLUMN avatar, DROP COLUMN bio;"
+	if result.Migration.DownSQL != wantDown {
+		t.Errorf("Expected DownSQL:\n%s\ngot:\n%s", wantDown, result.Migration.DownSQL)
+	}
+
+	// SQLite takes one change per ALTER TABLE
+	result, err = migration.SquashMigrations(migrations, migration.SquashOptions{OutputName: "profile", Dialect: "sqlite"})
+	if err != nil {
+		t.Fatalf("Squash failed: %v", err)
+	}
+	if result.MergedCount != 0 {
+		t.Errorf("Expected no merged statements for SQLite, got %d:\n%s", result.MergedCount, result.Migration.UpSQL)
+	}
+}
+
+func TestSquash_Verify(t *testing.T) {
+	ctx := context.Background()
+	migrations := []*migration.Migration{
+		{
+			ID:      "20231201_100000",
+			Name:    "create_accounts",
+			UpSQL:   "CREATE TABLE accounts (id INTEGER PRIMARY KEY)",
+			DownSQL: "DROP TABLE accounts",
+		},
+		{
+			ID:      "20231202_100000",
+			Name:    "create_users",
+			UpSQL:   "CREATE TABLE users (\n  id INTEGER PRIMARY KEY,\n  legacy TEXT\n)",
+			DownSQL: "DROP TABLE users",
+		},
+		{
+			ID:      "20231203_100000",
+			Name:    "add_email",
+			UpSQL:   "ALTER TABLE users ADD COLUMN email TEXT;\nALTER TABLE users DROP COLUMN legacy;\nCREATE INDEX idx_users_email ON users(email)",
+			DownSQL: "DROP INDEX idx_users_email;\nALTER TABLE users ADD COLUMN legacy TEXT;\nALTER TABLE users DROP COLUMN email",
+		},
+	}
+	open := func() *dialects.Connection {
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "verify.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return dialects.NewConnection(db, sqlite.New())
+	}
+
+	// The older migration is applied to both databases first
+	result, err := migration.SquashMigrations(migrations, migration.SquashOptions{FromID: "20231202", OutputName: "users"})
+	if err != nil {
+		t.Fatalf("Squash failed: %v", err)
+	}
+	verification, err := migration.VerifySquash(ctx, open(), open(), migrations, result)
+	if err != nil {
+		t.Fatalf("VerifySquash failed: %v", err)
+	}
+	if !verification.Equivalent() {
+		t.Errorf("Expected the squash to be equivalent, got up %v, down %v",
+			migration.DescribeChanges(verification.Up), migration.DescribeChanges(verification.Down))
+	}
+
+	// A squash that lost a column and an index is caught
+	result.Migration.UpSQL = "CREATE TABLE users (id INTEGER PRIMARY KEY)"
+	result.Migration.DownSQL = "DROP TABLE users"
+	verification, err = migration.VerifySquash(ctx, open(), open(), migrations, result)
+	if err != nil {
+		t.Fatalf("VerifySquash failed: %v", err)
+	}
+	if len(verification.Up) != 2 {
+		t.Errorf("Expected the missing column and index, got %v", migration.DescribeChanges(verification.Up))
 	}
 }
 
@@ -285,3 +433,36 @@ func indexOfSubstr(s, substr string) int {
 	}
 	return -1
 }
+
+func TestSquash_KeepsBodiesWhole(t *testing.T) {
+	fn := `CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+  NEW.updated_at := now(); -- stamp the row
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql`
+	migrations := []*migration.Migration{
+		{
+			ID:      "20231201_100000",
+			Name:    "create_users",
+			UpSQL:   "CREATE TABLE users (id INTEGER PRIMARY KEY, note TEXT DEFAULT 'a;b', updated_at TIMESTAMP);",
+			DownSQL: "DROP TABLE users;",
+		},
+		{
+			ID:      "20231202_100000",
+			Name:    "touch_users",
+			UpSQL:   fn + ";\n",
+			DownSQL: "DROP FUNCTION touch();",
+		},
+	}
+
+	result, err := migration.SquashMigrations(migrations, migration.SquashOptions{Dialect: "postgres"})
+	if err != nil {
+		t.Fatalf("Squash failed: %v", err)
+	}
+	want := "CREATE TABLE users (id INTEGER PRIMARY KEY, note TEXT DEFAULT 'a;b', updated_at TIMESTAMP);\n\n" +
+		fn + ";"
+	if result.Migration.UpSQL != want {
+		t.Errorf("Expected the function body kept whole, got:\n%s", result.Migration.UpSQL)
+	}
+}
@@ -0,0 +1,661 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nexus-db/nexus/internal/studio"
+	"github.com/nexus-db/nexus/pkg/core/migration"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+	embedded "github.com/nexus-db/nexus/pkg/studio"
+)
+
+func studioRequest(t *testing.T, h http.Handler, method, path, body string) (int, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: invalid response %q", method, path, rec.Body.String())
+	}
+	return rec.Code, resp
+}
+
+func TestStudio_EditTableData(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, bio TEXT);
+		CREATE TABLE tags (name TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	h := studio.NewServer(studio.Config{Connection: dialects.NewConnection(db, sqlite.New())}).Handler()
+
+	code, resp := studioRequest(t, h, http.MethodPost, "/api/tables/users/data", `{"values": {"id": 1, "name": "Ada"}}`)
+	if code != http.StatusOK || resp["rowsAffected"] != float64(1) {
+		t.Fatalf("Insert failed: %d %v", code, resp)
+	}
+
+	// Values are bound as parameters, not spliced into the SQL
+	code, resp = studioRequest(t, h, http.MethodPut, "/api/tables/users/data", `{"key": {"id": 1}, "values": {"name": "O'Brien", "bio": null}}`)
+	if code != http.StatusOK {
+		t.Fatalf("Update failed: %d %v", code, resp)
+	}
+	var name string
+	if err := db.QueryRow(`SELECT name FROM users WHERE id = 1`).Scan(&name); err != nil || name != "O'Brien" {
+		t.Errorf("Expected the name to be updated, got %q, %v", name, err)
+	}
+
+	code, resp = studioRequest(t, h, http.MethodGet, "/api/tables/users", "")
+	if keys, _ := resp["primaryKey"].([]interface{}); code != http.StatusOK || len(keys) != 1 || keys[0] != "id" {
+		t.Errorf("Expected the primary key in the table schema, got %d %v", code, resp)
+	}
+
+	for _, tc := range []struct {
+		method, table, body string
+		status              int
+	}{
+		{http.MethodPut, "users", `{"key": {"name": "Ada"}, "values": {"bio": "x"}}`, http.StatusBadRequest},
+		{http.MethodPut, "users", `{"key": {"id": 1, "name": "Ada"}, "values": {"bio": "x"}}`, http.StatusBadRequest},
+		{http.MethodPut, "users", `{"key": {"id": 1}, "values": {"email": "x"}}`, http.StatusBadRequest},
+		{http.MethodPut, "users", `{"key": {"id": 2}, "values": {"bio": "x"}}`, http.StatusNotFound},
+		{http.MethodDelete, "tags", `{"key": {"name": "go"}}`, http.StatusBadRequest},
+		{http.MethodDelete, "sqlite_master", `{"key": {"name": "users"}}`, http.StatusNotFound},
+		{http.MethodPost, "users", `{"values": {"bio": "missing name"}}`, http.StatusBadRequest},
+	} {
+		if code, resp := studioRequest(t, h, tc.method, "/api/tables/"+tc.table+"/data", tc.body); code != tc.status {
+			t.Errorf("%s %s %s: expected %d, got %d %v", tc.method, tc.table, tc.body, tc.status, code, resp)
+		}
+	}
+
+	code, resp = studioRequest(t, h, http.MethodDelete, "/api/tables/users/data", `{"key": {"id": 1}}`)
+	if code != http.StatusOK || resp["rowsAffected"] != float64(1) {
+		t.Fatalf("Delete failed: %d %v", code, resp)
+	}
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected no users left, got %d", count)
+	}
+}
+
+func TestStudio_ListTableDataSortFilterSearch(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, age INTEGER, active BOOLEAN);
+		INSERT INTO users VALUES (1, 'Ada', 36, 1), (2, 'Grace', 45, 0), (3, 'Alan', 41, 1), (4, 'Linus', 36, 1)`); err != nil {
+		t.Fatal(err)
+	}
+	h := studio.NewServer(studio.Config{Connection: dialects.NewConnection(db, sqlite.New())}).Handler()
+
+	names := func(resp map[string]interface{}) []string {
+		var out []string
+		rows, _ := resp["data"].([]interface{})
+		for _, row := range rows {
+			out = append(out, row.(map[string]interface{})["name"].(string))
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  string
+		total float64
+	}{
+		{"sort=age&dir=desc&limit=2", "Grace,Alan", 4},
+		{"sort=name", "Ada,Alan,Grace,Linus", 4},
+		{"filter[age]=36&sort=id&dir=desc", "Linus,Ada", 2},
+		{"filter[active]=false", "Grace", 1},
+		{"search=A&filter[active]=true&sort=id", "Ada,Alan", 2},
+		{"search=' OR 1=1 --", "", 0},
+	} {
+		code, resp := studioRequest(t, h, http.MethodGet, "/api/tables/users/data?"+strings.ReplaceAll(tc.query, " ", "+"), "")
+		if got := strings.Join(names(resp), ","); code != http.StatusOK || got != tc.want || resp["total"] != tc.total {
+			t.Errorf("%s: expected %s (total %v), got %d %s %v", tc.query, tc.want, tc.total, code, got, resp)
+		}
+	}
+
+	for _, q := range []string{"sort=email", "sort=name%20DESC", "sort=name&dir=sideways", "filter[email]=x", "filter[age]=old"} {
+		if code, resp := studioRequest(t, h, http.MethodGet, "/api/tables/users/data?"+q, ""); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %v", q, code, resp)
+		}
+	}
+	if code, _ := studioRequest(t, h, http.MethodGet, "/api/tables/missing/data", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing table, got %d", code)
+	}
+}
+
+func TestStudio_TableNameInjection(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	h := studio.NewServer(studio.Config{Connection: dialects.NewConnection(db, sqlite.New())}).Handler()
+
+	if code, resp := studioRequest(t, h, http.MethodGet, "/api/tables/users", ""); code != http.StatusOK || len(resp["columns"].([]interface{})) != 2 {
+		t.Fatalf("Expected the users schema, got %d %v", code, resp)
+	}
+	for _, name := range []string{
+		`users%22%3B%20DROP%20TABLE%20users%3B%20--`,
+		`users)%3B%20DROP%20TABLE%20users%3B%20--`,
+		`sqlite_master`,
+	} {
+		for _, path := range []string{"/api/tables/" + name, "/api/tables/" + name + "/data"} {
+			if code, resp := studioRequest(t, h, http.MethodGet, path, ""); code != http.StatusNotFound {
+				t.Errorf("Expected %s to be not found, got %d %v", path, code, resp)
+			}
+		}
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		t.Errorf("Expected the users table to survive: %v", err)
+	}
+}
+
+func TestStudio_QueryEditor(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	store := filepath.Join(t.TempDir(), "studio.json")
+	h := studio.NewServer(studio.Config{
+		Connection:     dialects.NewConnection(db, sqlite.New()),
+		QueryStoreFile: store,
+	}).Handler()
+
+	body := `{"query": "CREATE TABLE t (id INTEGER, name TEXT); INSERT INTO t VALUES (1, 'a;b'), (2, NULL); SELECT * FROM t ORDER BY id"}`
+	code, resp := studioRequest(t, h, http.MethodPost, "/api/query", body)
+	results, _ := resp["results"].([]interface{})
+	if code != http.StatusOK || len(results) != 3 || resp["rowsAffected"] != float64(2) {
+		t.Fatalf("Expected three statement results, got %d %v", code, resp)
+	}
+	if insert := results[1].(map[string]interface{}); insert["rowsAffected"] != float64(2) {
+		t.Errorf("Expected the insert to affect 2 rows, got %v", insert)
+	}
+
+	// Execution stops at the first failing statement
+	_, resp = studioRequest(t, h, http.MethodPost, "/api/query", `{"query": "DELETE FROM t WHERE id = 2; SELECT * FROM missing; DELETE FROM t"}`)
+	if results, _ := resp["results"].([]interface{}); len(results) != 2 || resp["error"] == nil {
+		t.Errorf("Expected the query to stop at the failing statement, got %v", resp)
+	}
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM t`).Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected one row left, got %d", count)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(`{"query": "SELECT id, name FROM t", "format": "csv"}`)))
+	if got := rec.Body.String(); rec.Code != http.StatusOK || got != "id,name\n1,a;b\n" || !strings.Contains(rec.Header().Get("Content-Disposition"), "query.csv") {
+		t.Errorf("Unexpected CSV export: %d %q", rec.Code, got)
+	}
+
+	code, resp = studioRequest(t, h, http.MethodGet, "/api/query/history", "")
+	history, _ := resp["history"].([]interface{})
+	if code != http.StatusOK || len(history) != 3 {
+		t.Fatalf("Expected three history entries, got %d %v", code, resp)
+	}
+	if newest := history[0].(map[string]interface{}); newest["query"] != "SELECT id, name FROM t" {
+		t.Errorf("Expected the newest entry first, got %v", newest)
+	}
+	if failed := history[1].(map[string]interface{}); failed["error"] == nil {
+		t.Errorf("Expected the failed query to record its error, got %v", failed)
+	}
+
+	if code, resp := studioRequest(t, h, http.MethodPost, "/api/query/saved", `{"name": "all", "query": "SELECT * FROM t"}`); code != http.StatusOK {
+		t.Fatalf("Save failed: %d %v", code, resp)
+	}
+	studioRequest(t, h, http.MethodPost, "/api/query/saved", `{"name": "all", "query": "SELECT id FROM t"}`)
+
+	// History and saved queries survive a restart
+	h = studio.NewServer(studio.Config{
+		Connection:     dialects.NewConnection(db, sqlite.New()),
+		QueryStoreFile: store,
+	}).Handler()
+	_, resp = studioRequest(t, h, http.MethodGet, "/api/query/saved", "")
+	saved, _ := resp["queries"].([]interface{})
+	if len(saved) != 1 || saved[0].(map[string]interface{})["query"] != "SELECT id FROM t" {
+		t.Errorf("Expected the saved query to be replaced and persisted, got %v", resp)
+	}
+	if code, _ := studioRequest(t, h, http.MethodDelete, "/api/query/saved/all", ""); code != http.StatusOK {
+		t.Errorf("Expected the saved query to be deleted, got %d", code)
+	}
+	if code, _ := studioRequest(t, h, http.MethodDelete, "/api/query/saved/all", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting a missing saved query, got %d", code)
+	}
+}
+
+func TestStudio_Explain(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, active INTEGER);
+		CREATE INDEX idx_users_email ON users(email)`); err != nil {
+		t.Fatal(err)
+	}
+	h := studio.NewServer(studio.Config{Connection: dialects.NewConnection(db, sqlite.New())}).Handler()
+
+	code, resp := studioRequest(t, h, http.MethodPost, "/api/explain", `{"query": "SELECT * FROM users WHERE active = 1"}`)
+	nodes, _ := resp["nodes"].([]interface{})
+	if code != http.StatusOK || len(nodes) != 1 {
+		t.Fatalf("Expected a one node plan, got %d %v", code, resp)
+	}
+	node := nodes[0].(map[string]interface{})
+	if node["scanType"] != "sequential_scan" || node["problem"] != true {
+		t.Errorf("Expected a flagged full scan, got %v", node)
+	}
+
+	_, resp = studioRequest(t, h, http.MethodPost, "/api/explain", `{"query": "SELECT id FROM users WHERE email = 'a'"}`)
+	nodes, _ = resp["nodes"].([]interface{})
+	if len(nodes) != 1 || nodes[0].(map[string]interface{})["index"] != "idx_users_email" || nodes[0].(map[string]interface{})["problem"] != false {
+		t.Errorf("Expected an index search, got %v", resp)
+	}
+
+	for _, body := range []string{
+		`{"query": ""}`,
+		`{"query": "SELECT 1; SELECT 2"}`,
+		`{"query": "DELETE FROM users", "analyze": true}`,
+		`{"query": "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", "analyze": true}`,
+		`{"query": "SELECT * INTO archive FROM users", "analyze": true}`,
+	} {
+		if code, resp := studioRequest(t, h, http.MethodPost, "/api/explain", body); code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d %v", body, code, resp)
+		} else if msg, _ := resp["error"].(string); strings.Contains(body, "analyze") && !strings.Contains(msg, "ANALYZE") {
+			t.Errorf("Expected %s to be refused before it runs, got %v", body, resp)
+		}
+	}
+
+	// ANALYZE runs the statement, which a read-only studio never does
+	readOnly := studio.NewServer(studio.Config{Connection: dialects.NewConnection(db, sqlite.New()), ReadOnly: true}).Handler()
+	if code, resp := studioRequest(t, readOnly, http.MethodPost, "/api/explain", `{"query": "SELECT * FROM users", "analyze": true}`); code != http.StatusForbidden {
+		t.Errorf("Expected ANALYZE to be refused in read-only mode, got %d %v", code, resp)
+	}
+	if code, resp := studioRequest(t, readOnly, http.MethodPost, "/api/explain", `{"query": "SELECT * FROM users"}`); code != http.StatusOK {
+		t.Errorf("Expected a plain EXPLAIN in read-only mode, got %d %v", code, resp)
+	}
+}
+
+func TestStudio_SchemaGraph(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, author_id INTEGER REFERENCES users(id))`); err != nil {
+		t.Fatal(err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+
+	sch, err := schema.NewParser(codegenSchema).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := studio.NewServer(studio.Config{Connection: conn, Schema: sch}).Handler()
+
+	code, resp := studioRequest(t, h, http.MethodGet, "/api/schema/graph", "")
+	nodes, _ := resp["nodes"].([]interface{})
+	edges, _ := resp["edges"].([]interface{})
+	if code != http.StatusOK || resp["source"] != "schema" || len(nodes) != 3 {
+		t.Fatalf("Expected a node per model, got %d %v", code, resp)
+	}
+	// Post.author and User.posts are the same foreign key
+	if len(edges) != 1 {
+		t.Fatalf("Expected one edge, got %v", edges)
+	}
+	edge := edges[0].(map[string]interface{})
+	if edge["from"] != "Post" || edge["to"] != "User" || edge["cardinality"] != "N:1" || edge["columns"].([]interface{})[0] != "authorId" {
+		t.Errorf("Unexpected edge %v", edge)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/schema/graph?format=mermaid", nil))
+	if got := rec.Body.String(); !strings.HasPrefix(got, "erDiagram\n") || !strings.Contains(got, "Int authorId FK") || !strings.Contains(got, `Post }o--|| User : "author"`) {
+		t.Errorf("Unexpected Mermaid diagram:\n%s", got)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/schema/graph?format=dot", nil))
+	if got := rec.Body.String(); !strings.HasPrefix(got, "digraph schema {") || !strings.Contains(got, `"Post" -> "User" [label="authorId N:1"];`) {
+		t.Errorf("Unexpected DOT graph:\n%s", got)
+	}
+
+	// Without a schema the graph comes from the database's foreign keys
+	h = studio.NewServer(studio.Config{Connection: conn}).Handler()
+	code, resp = studioRequest(t, h, http.MethodGet, "/api/schema/graph", "")
+	edges, _ = resp["edges"].([]interface{})
+	if code != http.StatusOK || resp["source"] != "database" || len(edges) != 1 {
+		t.Fatalf("Expected the introspected foreign key, got %d %v", code, resp)
+	}
+	if edge := edges[0].(map[string]interface{}); edge["from"] != "posts" || edge["to"] != "users" {
+		t.Errorf("Unexpected edge %v", edge)
+	}
+	if code, _ := studioRequest(t, h, http.MethodGet, "/api/schema/graph?source=schema", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a schema, got %d", code)
+	}
+}
+
+func TestStudio_MigrationManagement(t *testing.T) {
+	// Introspecting the database for a diff needs more than one connection
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "studio.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	conn := dialects.NewConnection(db, sqlite.New())
+
+	initSQL := "-- UP\nCREATE TABLE notes (id INTEGER PRIMARY KEY);\n\n-- DOWN\nDROP TABLE notes;\n"
+	if err := os.WriteFile(filepath.Join(dir, "20200101_000000_init.sql"), []byte(initSQL), 0644); err != nil {
+		t.Fatal(err)
+	}
+	engine := migration.NewEngine(conn)
+	if err := engine.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.LoadFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := schema.NewParser(codegenSchema).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := studio.NewServer(studio.Config{Connection: conn, Schema: sch, Migrations: engine, MigrationsDir: dir}).Handler()
+
+	if code, resp := studioRequest(t, h, http.MethodPost, "/api/migrations", `{"action": "up"}`); code != http.StatusOK {
+		t.Fatalf("Up failed: %d %v", code, resp)
+	}
+
+	code, resp := studioRequest(t, h, http.MethodGet, "/api/migrations/20200101_000000", "")
+	if code != http.StatusOK || resp["applied"] != true || !strings.Contains(resp["upSQL"].(string), "CREATE TABLE notes") {
+		t.Fatalf("Unexpected migration details: %d %v", code, resp)
+	}
+
+	// Previewing writes nothing
+	code, resp = studioRequest(t, h, http.MethodPost, "/api/migrations/diff", `{"name": "add_models"}`)
+	if changes, _ := resp["changes"].([]interface{}); code != http.StatusOK || len(changes) == 0 || resp["saved"] != false {
+		t.Fatalf("Unexpected diff preview: %d %v", code, resp)
+	}
+	if !strings.Contains(resp["upSQL"].(string), "DROP TABLE") {
+		t.Errorf("Expected the diff to drop the notes table, got %v", resp["upSQL"])
+	}
+	if issues, _ := resp["issues"].([]interface{}); len(issues) == 0 {
+		t.Errorf("Expected validation warnings for dropping a table, got %v", resp)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("Expected a preview not to write a migration, got %d files", len(files))
+	}
+
+	if code, _ := studioRequest(t, h, http.MethodPost, "/api/migrations/diff", `{"name": "../escape", "save": true}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsafe migration name, got %d", code)
+	}
+	code, resp = studioRequest(t, h, http.MethodPost, "/api/migrations/diff", `{"name": "add_models", "save": true}`)
+	if code != http.StatusOK || resp["saved"] != true {
+		t.Fatalf("Saving the diff failed: %d %v", code, resp)
+	}
+	if _, err := os.Stat(filepath.Join(dir, resp["file"].(string))); err != nil {
+		t.Errorf("Expected the migration file to be written: %v", err)
+	}
+
+	_, resp = studioRequest(t, h, http.MethodGet, "/api/migrations", "")
+	if migrations, _ := resp["migrations"].([]interface{}); len(migrations) != 2 || migrations[1].(map[string]interface{})["applied"] != false {
+		t.Fatalf("Expected the saved migration to be pending, got %v", resp)
+	}
+	_, resp = studioRequest(t, h, http.MethodGet, "/api/migrations/validate", "")
+	if results, _ := resp["results"].([]interface{}); len(results) != 2 {
+		t.Errorf("Expected both migrations validated, got %v", resp)
+	}
+
+	studioRequest(t, h, http.MethodPost, "/api/migrations", `{"action": "up"}`)
+	code, resp = studioRequest(t, h, http.MethodPost, "/api/migrations", `{"action": "downTo", "target": "20200101_000000"}`)
+	if code != http.StatusOK || resp["rolledBack"] != float64(1) {
+		t.Fatalf("DownTo failed: %d %v", code, resp)
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'User'`).Scan(&n)
+	if n != 0 {
+		t.Errorf("Expected the User table to be rolled back")
+	}
+	if code, _ := studioRequest(t, h, http.MethodGet, "/api/migrations/missing", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown migration, got %d", code)
+	}
+}
+
+func TestStudio_ProfilerStream(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+	profiler := query.NewProfiler(query.DefaultProfilerOptions())
+	srv := httptest.NewServer(studio.NewServer(studio.Config{Connection: conn, Profiler: profiler}).Handler())
+	defer srv.Close()
+
+	if code, _ := studioRequest(t, srv.Config.Handler, http.MethodPost, "/api/profiler", `{"action": "start"}`); code != http.StatusOK {
+		t.Fatalf("Starting the profiler failed: %d", code)
+	}
+
+	// Handshake by hand: the studio only needs net/http for WebSockets
+	ws, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(ws, "GET /api/profiler/stream HTTP/1.1\r\nHost: studio\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(ws)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected handshake response: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	users := query.New(conn, "users").WithProfiler(profiler)
+	if _, err := users.Select().Where(query.Eq("id", 1)).All(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		t.Fatal(err)
+	}
+	if head[0] != 0x81 || head[1]&0x80 != 0 {
+		t.Fatalf("Expected an unmasked text frame, got % x", head)
+	}
+	payload := make([]byte, head[1]&0x7F)
+	if len(payload) == 126 {
+		ext := make([]byte, 2)
+		io.ReadFull(r, ext)
+		payload = make([]byte, int(ext[0])<<8|int(ext[1]))
+	}
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	var msg struct {
+		Type  string                 `json:"type"`
+		Query map[string]interface{} `json:"query"`
+	}
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		t.Fatalf("Invalid message %q: %v", payload, err)
+	}
+	if msg.Type != "query" || !strings.Contains(msg.Query["sql"].(string), "FROM \"users\"") {
+		t.Errorf("Unexpected message %v", msg)
+	}
+	if _, ok := msg.Query["args"]; ok {
+		t.Errorf("Expected the query arguments left out, got %v", msg.Query)
+	}
+
+	code, report := studioRequest(t, srv.Config.Handler, http.MethodGet, "/api/profiler", "")
+	if r, _ := report["report"].(map[string]interface{}); code != http.StatusOK || r["totalQueries"] != float64(1) {
+		t.Errorf("Expected the report to count the query, got %d %v", code, report)
+	}
+
+	// Pages of other origins can't open the stream
+	handshake := func(origin string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://studio/api/profiler/stream", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		rec := httptest.NewRecorder()
+		studio.NewServer(studio.Config{Connection: conn, Profiler: profiler, AllowedOrigins: []string{"https://admin.example.com"}}).Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := handshake("https://evil.example.com"); code != http.StatusForbidden {
+		t.Errorf("Expected a cross-origin handshake rejected, got %d", code)
+	}
+	// Past the origin check, the handshake fails for its missing key
+	for _, origin := range []string{"http://studio", "https://admin.example.com"} {
+		if code := handshake(origin); code != http.StatusBadRequest {
+			t.Errorf("Expected origin %s allowed, got %d", origin, code)
+		}
+	}
+
+	noProfiler := studio.NewServer(studio.Config{Connection: conn}).Handler()
+	if code, _ := studioRequest(t, noProfiler, http.MethodGet, "/api/profiler", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a profiler, got %d", code)
+	}
+}
+
+func TestStudio_EmbeddedHandler(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE secrets (id INTEGER PRIMARY KEY, token TEXT);
+		INSERT INTO users VALUES (1, 'a')`); err != nil {
+		t.Fatal(err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+
+	mux := http.NewServeMux()
+	mux.Handle("/admin/db/", http.StripPrefix("/admin/db", embedded.Handler(embedded.Config{
+		Connection: conn,
+		ReadOnly:   true,
+	})))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/db/api/tables", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected tables without CORS headers, got %d %v", rec.Code, rec.Header())
+	}
+
+	if code, resp := studioRequest(t, mux, http.MethodPost, "/admin/db/api/query", `{"query": "SELECT * FROM users"}`); code != http.StatusOK || resp["error"] != nil {
+		t.Errorf("Expected queries to run when read-only, got %d %v", code, resp)
+	}
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodPost, "/admin/db/api/query", `{"query": "SELECT 1; DELETE FROM users"}`},
+		{http.MethodPost, "/admin/db/api/query", `{"query": "WITH d AS (DELETE FROM users RETURNING id) SELECT * FROM d"}`},
+		{http.MethodPost, "/admin/db/api/query", `{"query": "PRAGMA journal_mode = OFF"}`},
+		{http.MethodPut, "/admin/db/api/tables/users/data", `{"key": {"id": 1}, "values": {"name": "b"}}`},
+		{http.MethodDelete, "/admin/db/api/tables/users/data", `{"key": {"id": 1}}`},
+	} {
+		if code, resp := studioRequest(t, mux, req.method, req.path, req.body); code != http.StatusForbidden {
+			t.Errorf("Expected %s %s %s to be forbidden, got %d %v", req.method, req.path, req.body, code, resp)
+		}
+	}
+	var name string
+	db.QueryRow(`SELECT name FROM users WHERE id = 1`).Scan(&name)
+	if name != "a" {
+		t.Errorf("Expected the row to be unchanged, got %q", name)
+	}
+
+	h := embedded.Handler(embedded.Config{Connection: conn, Tables: []string{"users"}})
+	if _, resp := studioRequest(t, h, http.MethodGet, "/api/tables", ""); len(resp["tables"].([]interface{})) != 1 {
+		t.Errorf("Expected only the users table, got %v", resp)
+	}
+	if code, _ := studioRequest(t, h, http.MethodGet, "/api/tables/secrets/data", ""); code != http.StatusNotFound {
+		t.Errorf("Expected a hidden table to be not found, got %d", code)
+	}
+	if code, _ := studioRequest(t, h, http.MethodPost, "/api/query", `{"query": "SELECT * FROM secrets"}`); code != http.StatusForbidden {
+		t.Errorf("Expected SQL to be disabled with restricted tables, got %d", code)
+	}
+	if code, resp := studioRequest(t, h, http.MethodGet, "/api/info", ""); code != http.StatusOK || resp["rawSQL"] != false || resp["readOnly"] != false {
+		t.Errorf("Unexpected info: %v", resp)
+	}
+}
+
+func TestStudio_Pool(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(2)
+	conn := dialects.NewConnection(db, sqlite.New())
+	opts := query.DefaultProfilerOptions()
+	opts.Pool = db
+	profiler := query.NewProfiler(opts)
+	h := studio.NewServer(studio.Config{Connection: conn, Profiler: profiler}).Handler()
+
+	studioRequest(t, h, http.MethodGet, "/api/pool", "")
+	code, resp := studioRequest(t, h, http.MethodGet, "/api/pool", "")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %v", code, resp)
+	}
+	current, _ := resp["current"].(map[string]interface{})
+	if current["maxOpen"] != float64(2) || current["saturated"] != false {
+		t.Errorf("Unexpected current sample: %v", current)
+	}
+	if samples, _ := resp["samples"].([]interface{}); len(samples) != 2 {
+		t.Errorf("Expected a sample per request, got %v", resp["samples"])
+	}
+	if resp["session"] != nil {
+		t.Errorf("Expected no session samples before profiling, got %v", resp["session"])
+	}
+
+	profiler.Start()
+	profiler.Stop()
+	if _, resp = studioRequest(t, h, http.MethodGet, "/api/pool", ""); resp["session"] == nil {
+		t.Error("Expected the samples of the profiling session")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/pool", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected POST to be rejected, got %d", rec.Code)
+	}
+}
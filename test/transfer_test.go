@@ -0,0 +1,473 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/core/transfer"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+)
+
+// Comment is declared first to check that parents are dumped first.
+const transferSchema = `
+model Comment {
+  id      Int    @id @autoincrement
+  body    String
+  post_id Int
+  post    Post   @relation(fields: [post_id], references: [id])
+}
+
+model Post {
+  id        Int       @id @autoincrement
+  title     String
+  subtitle  String?
+  published Bool
+  rating    Float?
+  meta      Json?
+  cover     Bytes?
+  posted_at DateTime?
+  author_id Int
+  author    Author    @relation(fields: [author_id], references: [id])
+  comments  Comment[]
+}
+
+model Author {
+  id    Int    @id @autoincrement
+  name  String
+  posts Post[]
+}
+`
+
+const transferTables = `
+CREATE TABLE "Author" (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL);
+CREATE TABLE "Post" (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, subtitle TEXT, published BOOLEAN NOT NULL,
+  rating REAL, meta TEXT, cover BLOB, posted_at TIMESTAMP, author_id INTEGER NOT NULL REFERENCES "Author"(id));
+CREATE TABLE "Comment" (id INTEGER PRIMARY KEY AUTOINCREMENT, body TEXT NOT NULL, post_id INTEGER NOT NULL REFERENCES "Post"(id));
+`
+
+func transferDB(t *testing.T, data string) *dialects.Connection {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA foreign_keys = ON;" + transferTables + data); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func dumpRows(t *testing.T, conn *dialects.Connection, table string) query.Results {
+	t.Helper()
+	rows, err := query.New(conn, table).Select().OrderBy("id", query.Asc).All(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", table, err)
+	}
+	return rows
+}
+
+func TestQuery_Iter(t *testing.T) {
+	conn := transferDB(t, `INSERT INTO "Author" (name) VALUES ('Ada'), ('Grace'), ('Alan');`)
+	it, err := query.New(conn, "Author").Select("name").OrderBy("id", query.Desc).Iter(context.Background())
+	if err != nil {
+		t.Fatalf("Iter failed: %v", err)
+	}
+	defer it.Close()
+
+	var names []string
+	for it.Next() {
+		names = append(names, it.Result()["name"].(string))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Iteration failed: %v", err)
+	}
+	if strings.Join(names, ",") != "Alan,Grace,Ada" || !reflect.DeepEqual(it.Columns(), []string{"name"}) {
+		t.Errorf("Unexpected rows %v and columns %v", names, it.Columns())
+	}
+}
+
+func TestTransfer_TableOrder(t *testing.T) {
+	s, err := schema.NewParser(transferSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	var names []string
+	for _, m := range transfer.TableOrder(s) {
+		names = append(names, m.Name)
+	}
+	if strings.Join(names, ",") != "Author,Post,Comment" {
+		t.Errorf("Expected parents first, got %v", names)
+	}
+
+	if _, err := transfer.Models(s, transfer.Options{Tables: []string{"nope"}}); err == nil {
+		t.Error("Expected an unknown table to fail")
+	}
+}
+
+func TestTransfer_RoundTrip(t *testing.T) {
+	s, err := schema.NewParser(transferSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	src := transferDB(t, `
+INSERT INTO "Author" (name) VALUES ('Ada "Countess" Lovelace'), ('O''Brien');
+INSERT INTO "Post" (title, subtitle, published, rating, meta, cover, posted_at, author_id) VALUES
+  ('First, post', '', 1, 4.5, '{"tags":["a","b"]}', X'00FF10', '2024-03-01 10:30:00', 1),
+  ('Second
+line', NULL, 0, NULL, NULL, NULL, NULL, 2);
+INSERT INTO "Comment" (body, post_id) VALUES ('Nice; really', 1), ('\N is not null', 2);
+`)
+	ctx := context.Background()
+
+	load := func(t *testing.T, fn func(tx *dialects.Tx) error) *dialects.Connection {
+		dst := transferDB(t, "")
+		tx, err := dst.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+		if err := fn(tx); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		return dst
+	}
+	same := func(t *testing.T, dst *dialects.Connection) {
+		for _, table := range []string{"Author", "Post", "Comment"} {
+			want, got := dumpRows(t, src, table), dumpRows(t, dst, table)
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("%s differs:\nwant %v\ngot  %v", table, want, got)
+			}
+		}
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		var progress []string
+		opts := transfer.Options{BatchSize: 1, Progress: func(table string, done, total int64) {
+			if done == total {
+				progress = append(progress, table)
+			}
+		}}
+		if err := transfer.DumpJSON(ctx, src, s, &buf, opts); err != nil {
+			t.Fatalf("Dump failed: %v", err)
+		}
+		if strings.Join(progress, ",") != "Author,Post,Comment" {
+			t.Errorf("Expected progress per table, parents first, got %v", progress)
+		}
+		if !strings.Contains(buf.String(), `"meta": {"tags":["a","b"]}`) || !strings.Contains(buf.String(), `"published": true`) {
+			t.Errorf("Expected typed JSON values, got:\n%s", buf.String())
+		}
+		dst := load(t, func(tx *dialects.Tx) error {
+			n, err := transfer.LoadJSON(ctx, tx, s, &buf, opts)
+			if err == nil && n != 6 {
+				t.Errorf("Expected 6 rows, got %d", n)
+			}
+			return err
+		})
+		same(t, dst)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		files := make(map[string]*bytes.Buffer)
+		for _, m := range transfer.TableOrder(s) {
+			files[m.Name] = &bytes.Buffer{}
+			if err := transfer.DumpCSV(ctx, src, m, files[m.Name], transfer.Options{}); err != nil {
+				t.Fatalf("Dump failed: %v", err)
+			}
+		}
+		if !strings.Contains(files["Post"].String(), `\N`) {
+			t.Errorf("Expected NULL as \\N, got:\n%s", files["Post"])
+		}
+		dst := load(t, func(tx *dialects.Tx) error {
+			for _, m := range transfer.TableOrder(s) {
+				if _, err := transfer.LoadCSV(ctx, tx, m, files[m.Name], transfer.Options{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		same(t, dst)
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := transfer.DumpSQL(ctx, src, s, nil, &buf, transfer.Options{}); err != nil {
+			t.Fatalf("Dump failed: %v", err)
+		}
+		dst := load(t, func(tx *dialects.Tx) error {
+			_, err := transfer.LoadSQL(ctx, tx, &buf, transfer.Options{})
+			return err
+		})
+		same(t, dst)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		dst := transferDB(t, "")
+		tx, _ := dst.Begin(ctx)
+		defer tx.Rollback()
+		_, err := transfer.LoadJSON(ctx, tx, s, strings.NewReader(`{"Author": [{"id": 1, "nickname": "x"}]}`), transfer.Options{})
+		if err == nil || !strings.Contains(err.Error(), `no column "nickname"`) {
+			t.Errorf("Expected an unknown column error, got %v", err)
+		}
+		_, err = transfer.LoadCSV(ctx, tx, s.Models["Post"], strings.NewReader("id,published\n1,maybe\n"), transfer.Options{})
+		if err == nil || !strings.Contains(err.Error(), "column published") {
+			t.Errorf("Expected a conversion error, got %v", err)
+		}
+	})
+}
+
+func TestTransfer_FilterTables(t *testing.T) {
+	s, _ := schema.NewParser(transferSchema).Parse()
+	src := transferDB(t, `INSERT INTO "Author" (name) VALUES ('Ada');`)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dump.json")
+	f, _ := os.Create(path)
+	if err := transfer.DumpJSON(context.Background(), src, s, f, transfer.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	dst := transferDB(t, "")
+	tx, _ := dst.Begin(context.Background())
+	defer tx.Rollback()
+	f, _ = os.Open(path)
+	defer f.Close()
+	n, err := transfer.LoadJSON(context.Background(), tx, s, f, transfer.Options{Tables: []string{"Author"}})
+	if err != nil || n != 1 {
+		t.Errorf("Expected only the Author row, got %d, %v", n, err)
+	}
+}
+
+func TestTransfer_Copy(t *testing.T) {
+	s, err := schema.NewParser(transferSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	ctx := context.Background()
+	src := transferDB(t, `
+INSERT INTO "Author" (name) VALUES ('Ada'), ('Grace');
+INSERT INTO "Post" (title, published, meta, cover, posted_at, author_id) VALUES
+  ('Hello', 1, '{"a":1}', X'CAFE', '2024-03-01 10:30:00', 2);
+INSERT INTO "Comment" (body, post_id) VALUES ('Hi', 1);
+`)
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	dst := dialects.NewConnection(db, sqlite.New())
+	defer dst.Close()
+
+	created, err := transfer.CreateTables(ctx, dst, s, transfer.Options{})
+	if err != nil {
+		t.Fatalf("CreateTables failed: %v", err)
+	}
+	if strings.Join(created, ",") != "Author,Post,Comment" {
+		t.Errorf("Expected all tables to be created parents first, got %v", created)
+	}
+	if created, _ := transfer.CreateTables(ctx, dst, s, transfer.Options{}); len(created) != 0 {
+		t.Errorf("Expected existing tables to be kept, got %v", created)
+	}
+
+	var done []string
+	n, err := transfer.Copy(ctx, src, dst, s, transfer.Options{Progress: func(table string, d, total int64) {
+		if d == total {
+			done = append(done, table)
+		}
+	}})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if n != 4 || strings.Join(done, ",") != "Author,Post,Comment" {
+		t.Errorf("Expected 4 rows with progress per table, got %d and %v", n, done)
+	}
+
+	var want, got bytes.Buffer
+	transfer.DumpJSON(ctx, src, s, &want, transfer.Options{})
+	transfer.DumpJSON(ctx, dst, s, &got, transfer.Options{})
+	if want.String() != got.String() {
+		t.Errorf("Copied data differs:\nwant %s\ngot  %s", want.String(), got.String())
+	}
+
+	// Copying again conflicts on the ids and leaves the target unchanged
+	if _, err := transfer.Copy(ctx, src, dst, s, transfer.Options{}); err == nil {
+		t.Error("Expected copying into filled tables to fail")
+	}
+	var authors int
+	dst.QueryRow(ctx, `SELECT COUNT(*) FROM "Author"`).Scan(&authors)
+	if authors != 2 {
+		t.Errorf("Expected the failed copy to be rolled back, got %d authors", authors)
+	}
+}
+
+const sampleSchema = `
+model Customer {
+  id     Int     @id @autoincrement
+  name   String  @pii
+  email  String  @unique
+  phone  String? @pii("phone")
+  orders Order[]
+}
+
+model Order {
+  id          Int      @id @autoincrement
+  total       Float
+  note        String?
+  customer_id Int
+  customer    Customer @relation(fields: [customer_id], references: [id])
+  items       Item[]
+}
+
+model Item {
+  id       Int    @id @autoincrement
+  sku      String
+  order_id Int
+  order    Order  @relation(fields: [order_id], references: [id])
+}
+`
+
+func TestTransfer_SamplePIIAttribute(t *testing.T) {
+	s, err := schema.NewParser(sampleSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	customer := s.Models["Customer"]
+	if name := customer.Fields["name"]; !name.IsPII || name.PIIMask != "" {
+		t.Errorf("Expected name to be @pii with an inferred mask, got %v %q", name.IsPII, name.PIIMask)
+	}
+	if phone := customer.Fields["phone"]; !phone.IsPII || phone.PIIMask != "phone" {
+		t.Errorf("Expected phone to be masked as a phone number, got %q", phone.PIIMask)
+	}
+	if out := schema.Format(s); !strings.Contains(out, `@pii("phone")`) || !strings.Contains(out, "String  @pii") && !strings.Contains(out, "String @pii") {
+		t.Errorf("Expected @pii to be formatted:\n%s", out)
+	}
+
+	_, err = schema.NewParser(`model A {
+  id Int    @id
+  x  String @pii("secret")
+}`).Parse()
+	if err == nil || !strings.Contains(err.Error(), "@pii expects") {
+		t.Errorf("Expected an unknown mask to fail, got %v", err)
+	}
+}
+
+func TestTransfer_Sample(t *testing.T) {
+	s, err := schema.NewParser(sampleSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	ctx := context.Background()
+
+	open := func() *dialects.Connection {
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+		conn := dialects.NewConnection(db, sqlite.New())
+		t.Cleanup(func() { conn.Close() })
+		if _, err := transfer.CreateTables(ctx, conn, s, transfer.Options{}); err != nil {
+			t.Fatalf("CreateTables failed: %v", err)
+		}
+		return conn
+	}
+	src := open()
+	for c := 1; c <= 5; c++ {
+		src.Exec(ctx, `INSERT INTO "Customer" (name, email, phone) VALUES (?, ?, ?)`,
+			fmt.Sprintf("Customer %d", c), fmt.Sprintf("c%d@corp.com", c), "+44 20 7946 0000")
+		for o := 1; o <= 4; o++ {
+			src.Exec(ctx, `INSERT INTO "Order" (total, note, customer_id) VALUES (?, ?, ?)`, float64(o*10), "leave at door", c)
+		}
+	}
+	for o := 1; o <= 20; o++ {
+		src.Exec(ctx, `INSERT INTO "Item" (sku, order_id) VALUES ('SKU-1', ?), ('SKU-2', ?)`, o, o)
+	}
+
+	dst := open()
+	opts := transfer.SampleOptions{
+		Options:   transfer.Options{Tables: []string{"Item"}},
+		Rows:      3,
+		Anonymize: []string{"Order.note"},
+	}
+	n, err := transfer.Sample(ctx, src, dst, s, opts)
+	if err != nil {
+		t.Fatalf("Sample failed: %v", err)
+	}
+
+	items := dumpRows(t, dst, "Item")
+	orders := dumpRows(t, dst, "Order")
+	customers := dumpRows(t, dst, "Customer")
+	if len(items) != 3 || len(orders) == 0 || len(customers) == 0 || n != int64(len(items)+len(orders)+len(customers)) {
+		t.Fatalf("Expected 3 items with their orders and customers, got %d rows: %d items, %d orders, %d customers",
+			n, len(items), len(orders), len(customers))
+	}
+
+	// Every foreign key resolves within the sample
+	ids := func(rows query.Results) map[int64]bool {
+		set := make(map[int64]bool)
+		for _, r := range rows {
+			set[r["id"].(int64)] = true
+		}
+		return set
+	}
+	orderIDs, customerIDs := ids(orders), ids(customers)
+	for _, item := range items {
+		if !orderIDs[item["order_id"].(int64)] {
+			t.Errorf("Item %v references an order that was not sampled", item["id"])
+		}
+	}
+	for _, order := range orders {
+		if !customerIDs[order["customer_id"].(int64)] {
+			t.Errorf("Order %v references a customer that was not sampled", order["id"])
+		}
+		if order["note"] == "leave at door" {
+			t.Error("Expected the anonymized note to be masked")
+		}
+	}
+	for _, c := range customers {
+		if strings.HasPrefix(c["name"].(string), "Customer") || c["phone"] == "+44 20 7946 0000" {
+			t.Errorf("Expected @pii columns to be masked, got %v", c)
+		}
+		if !strings.HasSuffix(c["email"].(string), "@corp.com") {
+			t.Errorf("Expected email to be kept without @pii or --anonymize, got %v", c["email"])
+		}
+	}
+
+	// Clear replaces the previous sample
+	opts.Anonymize = []string{"email"}
+	opts.Clear = true
+	if _, err := transfer.Sample(ctx, src, dst, s, opts); err != nil {
+		t.Fatalf("Sample with Clear failed: %v", err)
+	}
+	if items := dumpRows(t, dst, "Item"); len(items) != 3 {
+		t.Errorf("Expected the target to hold only the new sample, got %d items", len(items))
+	}
+	for _, c := range dumpRows(t, dst, "Customer") {
+		if email := c["email"].(string); !strings.HasPrefix(email, "user-") || !strings.HasSuffix(email, "@example.com") {
+			t.Errorf("Expected a fake email, got %q", email)
+		}
+	}
+
+	for _, bad := range [][]string{{"id"}, {"Order.customer_id"}, {"nope"}} {
+		if _, err := transfer.MaskedColumns(s, transfer.SampleOptions{Anonymize: bad}); err == nil {
+			t.Errorf("Expected anonymizing %v to fail", bad)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+)
+
+const typescriptSchema = `
+enum Role {
+  ADMIN
+  MEMBER
+}
+
+model User {
+  id        Int      @id @autoincrement
+  email     String   @size(100)
+  role      Role     @default(MEMBER)
+  bio       Text?
+  createdAt DateTime @default(now())
+  posts     Post[]
+}
+
+model Post {
+  id       Int   @id @autoincrement
+  meta     Json?
+  authorId Int
+  author   User  @relation(fields: [authorId], references: [id])
+}
+`
+
+func TestTypeScript_Interfaces(t *testing.T) {
+	s, err := schema.NewParser(typescriptSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	ts := codegen.NewGenerator(s, "db", t.TempDir()).TypeScript()
+
+	for _, want := range []string{
+		"export const Role = {\n  ADMIN: \"ADMIN\",\n  MEMBER: \"MEMBER\",\n} as const;\nexport type Role = (typeof Role)[keyof typeof Role];",
+		"export interface User {\n  id: number;\n  email: string;\n  role: Role;\n  bio: string | null;\n  createdAt: string;\n  posts?: Post[];\n}",
+		"export interface Post {\n  id: number;\n  meta: unknown;\n  authorId: number;\n  author?: User | null;\n}",
+		// Defaults and nullable columns are optional on create; ids are generated
+		"export interface UserCreateInput {\n  email: string;\n  role?: Role;\n  bio?: string | null;\n  createdAt?: string;\n}",
+		"export type UserUpdateInput = Partial<UserCreateInput>;",
+	} {
+		if !strings.Contains(ts, want) {
+			t.Errorf("Expected models.ts to contain:\n%s\ngot:\n%s", want, ts)
+		}
+	}
+}
+
+func TestTypeScript_Zod(t *testing.T) {
+	s, err := schema.NewParser(typescriptSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	zod := codegen.NewGenerator(s, "db", t.TempDir()).ZodSchemas()
+
+	for _, want := range []string{
+		`import type { User, Post } from "./models";`,
+		`export const RoleSchema = z.enum(["ADMIN", "MEMBER"]);`,
+		"export const UserSchema: z.ZodType<User> = z.object({\n  id: z.number().int(),\n  email: z.string().max(100),\n  role: RoleSchema,\n  bio: z.string().nullable(),\n  createdAt: z.string().datetime({ offset: true }),\n  posts: z.array(z.lazy(() => PostSchema)).optional(),\n});",
+		"  author: z.lazy(() => UserSchema).nullable().optional(),",
+		"export const PostCreateInputSchema = z.object({\n  meta: z.unknown().optional(),\n  authorId: z.number().int(),\n}).strict();",
+	} {
+		if !strings.Contains(zod, want) {
+			t.Errorf("Expected schemas.ts to contain:\n%s\ngot:\n%s", want, zod)
+		}
+	}
+}
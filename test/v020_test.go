@@ -3,11 +3,14 @@ package test
 import (
 	"context"
 	"database/sql"
+	"reflect"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/mssql"
+	"github.com/nexus-db/nexus/pkg/dialects/postgres"
 	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
 	"github.com/nexus-db/nexus/pkg/query"
 )
@@ -175,6 +178,74 @@ func TestUnionWithOrderAndLimit(t *testing.T) {
 	}
 }
 
+func TestIntersectExcept(t *testing.T) {
+	conn := setupV2TestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+	users := query.New(conn, "users")
+	orders := query.New(conn, "orders")
+	ids := func(q *query.SetOpQuery) []int64 {
+		results, err := q.OrderBy("id", query.Asc).All(ctx)
+		if err != nil {
+			t.Fatalf("Set operation failed: %v", err)
+		}
+		var got []int64
+		for _, r := range results {
+			id, _ := r.GetInt64("id")
+			got = append(got, id)
+		}
+		return got
+	}
+
+	if got := ids(users.Select("id").Where(query.Eq("active", 1)).Intersect(orders.Select("user_id"))); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("Intersect returned %v", got)
+	}
+	if got := ids(users.Select("id").Except(orders.Select("user_id"))); !reflect.DeepEqual(got, []int64{3}) {
+		t.Errorf("Except returned %v", got)
+	}
+
+	// (Charlie UNION Alice) INTERSECT active users, left to right
+	q := users.Select("id").Where(query.Eq("name", "Charlie")).
+		Union(users.Select("id").Where(query.Eq("name", "Alice"))).
+		Intersect(users.Select("id").Where(query.Eq("active", 1)))
+	if got := ids(q); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("Expected operations to apply left to right, got %v", got)
+	}
+
+	// Operands with their own LIMIT, and nested set operations
+	newest := users.Select("id").OrderBy("id", query.Desc).Limit(1)
+	nested := users.Select("id").Where(query.Eq("name", "Bob")).Intersect(users.Select("id").Where(query.Eq("active", 1)))
+	if got := ids(newest.Union(nested)); !reflect.DeepEqual(got, []int64{2, 3}) {
+		t.Errorf("Expected the newest user and Bob, got %v", got)
+	}
+
+	_, err := users.Select("id").IntersectAll(orders.Select("user_id")).All(ctx)
+	if err == nil || err.Error() != "dialect sqlite does not support INTERSECT ALL" {
+		t.Errorf("Expected INTERSECT ALL to be rejected on SQLite, got %v", err)
+	}
+}
+
+func TestSetOperationSQL(t *testing.T) {
+	pg := dialects.NewConnection(nil, postgres.New())
+	users := query.New(pg, "users")
+	q := users.Select("id").Where(query.Eq("name", "a")).
+		Union(users.Select("id").Where(query.Eq("name", "b")).OrderBy("id", query.Asc)).
+		Intersect(users.Select("id").Where(query.Eq("active", true)).Limit(5)).
+		OrderBy("id", query.Desc).Limit(10)
+	sql, args := q.Build()
+	want := `SELECT * FROM (SELECT "id" FROM "users" WHERE "name" = $1 UNION SELECT "id" FROM "users" WHERE "name" = $2) AS "_c2"` +
+		` INTERSECT SELECT * FROM (SELECT "id" FROM "users" WHERE "active" = $3 LIMIT 5) AS "_s3" ORDER BY "id" DESC LIMIT 10`
+	if sql != want || !reflect.DeepEqual(args, []interface{}{"a", "b", true}) {
+		t.Errorf("Unexpected SQL:\n%s %v", sql, args)
+	}
+
+	ms := dialects.NewConnection(nil, mssql.New())
+	sql, _ = query.New(ms, "users").Select("id").Except(query.New(ms, "users").Select("id")).Limit(3).Build()
+	if want := "SELECT * FROM (SELECT [id] FROM [users] EXCEPT SELECT [id] FROM [users]) AS [_compound] ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 3 ROWS ONLY"; sql != want {
+		t.Errorf("Unexpected SQL Server SQL:\n%s", sql)
+	}
+}
+
 // === CTE Tests ===
 
 func TestBasicCTE(t *testing.T) {
@@ -217,6 +288,42 @@ func TestCTEWithWhere(t *testing.T) {
 	}
 }
 
+func TestRecursiveCTE(t *testing.T) {
+	conn := setupV2TestDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	// Users 1, 2, 3 by walking id + 1 from Alice
+	anchor := query.New(conn, "users").Select("id").Where(query.Eq("name", "Alice"))
+	next := query.New(conn, "users").Select("users.id").
+		Join("chain", `"users"."id" = "chain"."id" + 1`).Where(query.RawSQL(`"users"."id" <= 3`))
+	results, err := query.WithRecursive(conn, "chain", anchor, next).Select("id").From("chain").OrderBy("id", query.Asc).All(ctx)
+	if err != nil {
+		t.Fatalf("Recursive CTE failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("Expected 3 rows, got %v", results)
+	}
+}
+
+func TestRecursiveCTEDialects(t *testing.T) {
+	for _, tt := range []struct {
+		dialect dialects.Dialect
+		want    string
+	}{
+		{postgres.New(), `WITH RECURSIVE "tree" AS (SELECT * FROM "nodes" WHERE "id" = $1 UNION ALL SELECT nodes.* FROM "nodes" INNER JOIN "tree" ON nodes.parent_id = tree.id WHERE "depth" < $2) SELECT * FROM "tree" WHERE "name" = $3`},
+		{mssql.New(), `WITH [tree] AS (SELECT * FROM [nodes] WHERE [id] = @p1 UNION ALL SELECT nodes.* FROM [nodes] INNER JOIN [tree] ON nodes.parent_id = tree.id WHERE [depth] < @p2) SELECT * FROM [tree] WHERE [name] = @p3`},
+	} {
+		conn := dialects.NewConnection(nil, tt.dialect)
+		anchor := query.New(conn, "nodes").Select().Where(query.Eq("id", 1))
+		children := query.New(conn, "nodes").Select("nodes.*").Join("tree", "nodes.parent_id = tree.id").Where(query.Lt("depth", 5))
+		sql, args := query.WithRecursive(conn, "tree", anchor, children).Select().From("tree").Where(query.Eq("name", "x")).Build()
+		if sql != tt.want || len(args) != 3 {
+			t.Errorf("%s:\ngot  %s\nwant %s (%d args)", tt.dialect.Name(), sql, tt.want, len(args))
+		}
+	}
+}
+
 // === Subquery Tests ===
 
 func TestWhereInSubquery(t *testing.T) {
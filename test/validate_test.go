@@ -0,0 +1,256 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nexus-db/nexus/internal/codegen"
+	"github.com/nexus-db/nexus/pkg/core/schema"
+	"github.com/nexus-db/nexus/pkg/dialects"
+	"github.com/nexus-db/nexus/pkg/dialects/sqlite"
+	"github.com/nexus-db/nexus/pkg/query"
+	"github.com/nexus-db/nexus/pkg/validate"
+)
+
+const validationSchema = `
+model Member {
+  id    Int     @id @autoincrement
+  email String  @email @unique
+  age   Int?    @min(13) @max(120)
+  slug  String  @regex("^[a-z0-9-]+$") @min(3)
+}
+`
+
+func TestValidate_ParseAttributes(t *testing.T) {
+	s, err := schema.NewParser(validationSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	m := s.Models["Member"]
+	if !m.Fields["email"].IsEmail {
+		t.Error("Expected email to have @email")
+	}
+	age := m.Fields["age"]
+	if age.MinValue == nil || *age.MinValue != 13 || age.MaxValue == nil || *age.MaxValue != 120 {
+		t.Errorf("Unexpected age bounds: %v %v", age.MinValue, age.MaxValue)
+	}
+	if m.Fields["slug"].Pattern != "^[a-z0-9-]+$" {
+		t.Errorf("Unexpected slug pattern %q", m.Fields["slug"].Pattern)
+	}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected a valid schema, got %v", err)
+	}
+
+	// The Go API sets the same rules
+	b := schema.NewSchema()
+	b.Model("Member", func(m *schema.Model) {
+		m.Int("id").PrimaryKey().AutoInc()
+		m.String("email").Email()
+		m.Int("age").Min(13).Max(120)
+		m.String("slug").Regex("^[a-z0-9-]+$")
+	})
+	f := b.Models["Member"].Fields["age"]
+	if f.MinValue == nil || *f.MinValue != 13 || *f.MaxValue != 120 {
+		t.Errorf("Unexpected Go API bounds: %v %v", f.MinValue, f.MaxValue)
+	}
+}
+
+func TestValidate_InvalidAttributes(t *testing.T) {
+	diags := parseErrors(t, `model Member {
+  id    Int    @id
+  email String @email(true)
+  age   Int    @min("x")
+  slug  String @regex("[a-")
+}
+`)
+	if len(diags) != 3 {
+		t.Fatalf("Expected 3 diagnostics, got %+v", diags)
+	}
+	for _, d := range diags {
+		if d.Code != "SCHEMA_INVALID_MODIFIER" {
+			t.Errorf("Unexpected diagnostic: %+v", d)
+		}
+	}
+
+	s := schema.NewSchema()
+	s.Model("Member", func(m *schema.Model) {
+		m.Int("id").PrimaryKey()
+		m.Bool("active").Email()
+		m.Int("age").Min(10).Max(5)
+	})
+	err := s.Validate()
+	if err == nil {
+		t.Fatal("Expected rules on the wrong types to fail validation")
+	}
+	for _, want := range []string{"@email on field \"active\"", "@min is greater than @max"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestValidate_Format(t *testing.T) {
+	s, err := schema.NewParser(validationSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	out := schema.Format(s)
+	for _, want := range []string{"@unique @email", "@min(13) @max(120)", `@min(3) @regex("^[a-z0-9-]+$")`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in formatted schema:\n%s", want, out)
+		}
+	}
+	again, err := schema.NewParser(out).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse formatted schema: %v", err)
+	}
+	if schema.Format(again) != out {
+		t.Errorf("Formatting is not stable:\n%s", schema.Format(again))
+	}
+
+	src, err := schema.FormatSource("model Member {\n  id Int @id\n  age Int @max(120) @min(13) @default(18)\n}\n")
+	if err != nil {
+		t.Fatalf("FormatSource failed: %v", err)
+	}
+	if !strings.Contains(src, "@default(18) @min(13) @max(120)") {
+		t.Errorf("Expected validation attributes after @default:\n%s", src)
+	}
+}
+
+func TestValidate_Checks(t *testing.T) {
+	age := 12
+	tests := []struct {
+		got, want string
+	}{
+		{validate.Email("ada@example.com"), ""},
+		{validate.Email("ada"), "must be a valid email address"},
+		{validate.Email("Ada <ada@example.com>"), "must be a valid email address"},
+		{validate.Min(&age, 13), "must be at least 13"},
+		{validate.Min((*int)(nil), 13), ""},
+		{validate.Max(2.5, 2), "must be at most 2"},
+		{validate.Min("ab", 3), "must be at least 3 characters"},
+		{validate.Max("héllo", 5), ""},
+		{validate.Match("Bad Slug", regexp.MustCompile("^[a-z-]+$")), "must match ^[a-z-]+$"},
+	}
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%d: got %q, want %q", i, tt.got, tt.want)
+		}
+	}
+
+	errs := validate.Errors{}
+	errs.Add("email", "")
+	if errs.Err() != nil {
+		t.Error("Expected no error without messages")
+	}
+	errs.Add("slug", "must match x")
+	errs.Add("age", "must be at least 13")
+	errs.Add("age", "ignored")
+	if got := errs.Error(); got != "validation failed: age must be at least 13; slug must match x" {
+		t.Errorf("Unexpected message %q", got)
+	}
+}
+
+func TestValidate_Builders(t *testing.T) {
+	s, err := schema.NewParser(validationSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	conn := dialects.NewConnection(db, sqlite.New())
+	defer conn.Close()
+	ctx := context.Background()
+	if _, err := conn.Exec(ctx, `CREATE TABLE "Member" (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT, age INTEGER, slug TEXT)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	members := query.NewWithSchema(conn, "Member", s)
+
+	_, err = members.Insert(map[string]interface{}{"email": "nope", "age": 9, "slug": "ok-slug"}).Exec(ctx)
+	var invalid validate.Errors
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected validate.Errors, got %v", err)
+	}
+	if invalid["email"] == "" || invalid["age"] == "" || invalid["slug"] != "" {
+		t.Errorf("Unexpected errors: %v", invalid)
+	}
+
+	// Every row of a batch is checked
+	_, err = members.Insert(map[string]interface{}{"email": "a@example.com", "slug": "first"}).
+		Values(map[string]interface{}{"email": "b@example.com", "slug": "Second!"}).Exec(ctx)
+	if !errors.As(err, &invalid) || invalid["slug"] == "" {
+		t.Errorf("Expected the second row to fail, got %v", err)
+	}
+
+	if _, err := members.Insert(map[string]interface{}{"email": "ada@example.com", "age": nil, "slug": "ada"}).Exec(ctx); err != nil {
+		t.Fatalf("Expected a valid insert, got %v", err)
+	}
+	_, err = members.Update(map[string]interface{}{"age": 200}).Where(query.Eq("id", 1)).Exec(ctx)
+	if !errors.As(err, &invalid) || invalid["age"] != "must be at most 120" {
+		t.Errorf("Expected the update to fail, got %v", err)
+	}
+
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM "Member"`).Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected only the valid row to be written, got %d", count)
+	}
+
+	// Without a schema there are no rules to check
+	if _, err := query.New(conn, "Member").Insert(map[string]interface{}{"email": "nope", "slug": "x"}).Exec(ctx); err != nil {
+		t.Errorf("Expected no validation without a schema, got %v", err)
+	}
+}
+
+func TestCodegen_ValidationImports(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	withPattern, err := schema.NewParser(validationSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	withoutPattern, err := schema.NewParser(queriesSchema).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	for _, tc := range []struct {
+		schema *schema.Schema
+		regexp bool
+	}{
+		{withPattern, true},
+		{withoutPattern, false},
+	} {
+		dir := generatedDir(t)
+		if err := codegen.NewGenerator(tc.schema, "gen", dir).Generate(); err != nil {
+			t.Fatalf("Failed to generate: %v", err)
+		}
+		queries, _ := os.ReadFile(filepath.Join(dir, "queries.go"))
+		if got := strings.Contains(string(queries), `"regexp"`); got != tc.regexp {
+			t.Errorf("Expected regexp imported %v, got %v:\n%s", tc.regexp, got, queries)
+		}
+		if strings.Contains(string(queries), "var _ =") {
+			t.Errorf("Expected no blank package-level vars:\n%s", queries)
+		}
+
+		cmd := exec.Command(goTool, "vet", "./"+filepath.ToSlash(dir))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Generated code failed:\n%s", out)
+		}
+	}
+}
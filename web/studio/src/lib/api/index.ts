@@ -1,10 +1,14 @@
 // API client for Nexus Studio
 
-const API_BASE = '/api';
+import { base } from '$app/paths';
+
+// The studio may be mounted under a prefix; build with STUDIO_BASE_PATH set to it
+const API_BASE = `${base}/api`;
 
 export interface TableInfo {
     name: string;
     columns?: ColumnInfo[];
+    primaryKey?: string[];
 }
 
 export interface ColumnInfo {
@@ -15,12 +19,35 @@ export interface ColumnInfo {
     default?: string;
 }
 
+export interface StatementResult {
+    statement: string;
+    data?: Record<string, unknown>[];
+    columns?: string[];
+    rowsAffected: number;
+    duration: number;
+    error?: string;
+}
+
 export interface QueryResult {
     data?: Record<string, unknown>[];
     columns?: string[];
     rowsAffected?: number;
     duration?: number;
     error?: string;
+    results?: StatementResult[];
+}
+
+export interface HistoryEntry {
+    query: string;
+    executedAt: string;
+    duration: number;
+    error?: string;
+}
+
+export interface SavedQuery {
+    name: string;
+    query: string;
+    updatedAt: string;
 }
 
 export interface TableDataResult {
@@ -32,9 +59,42 @@ export interface TableDataResult {
     pages: number;
 }
 
+export interface GraphField {
+    name: string;
+    type: string;
+    nullable?: boolean;
+    primaryKey?: boolean;
+    unique?: boolean;
+    foreignKey?: boolean;
+}
+
+export interface GraphNode {
+    id: string;
+    model?: string;
+    fields: GraphField[];
+}
+
+export interface GraphEdge {
+    from: string;
+    to: string;
+    cardinality: 'N:1' | '1:1' | 'N:M';
+    name?: string;
+    columns?: string[];
+    refColumns?: string[];
+    through?: string;
+}
+
+export interface SchemaGraph {
+    source: 'schema' | 'database';
+    nodes: GraphNode[];
+    edges: GraphEdge[];
+}
+
 export interface DbInfo {
     dialect: string;
     version: string;
+    readOnly: boolean; // Row edits, migrations and writing SQL are disabled
+    rawSQL: boolean; // The query editor is available
 }
 
 export interface MigrationInfo {
@@ -42,6 +102,37 @@ export interface MigrationInfo {
     name: string;
     applied: boolean;
     appliedAt?: string;
+    outOfOrder?: boolean;
+    errors?: number;
+    warnings?: number;
+}
+
+export interface ValidationIssue {
+    severity: 'error' | 'warning';
+    message: string;
+    line?: number;
+    suggestion?: string;
+    rule?: string;
+}
+
+export interface MigrationDetails extends MigrationInfo {
+    upSQL: string;
+    downSQL: string;
+    checksum: string;
+    issues: ValidationIssue[];
+}
+
+export interface MigrationDiff {
+    id?: string;
+    name?: string;
+    changes: string[];
+    upSQL?: string;
+    downSQL?: string;
+    issues?: ValidationIssue[];
+    saved?: boolean;
+    file?: string;
+    message?: string;
+    error?: string;
 }
 
 // Fetch all tables
@@ -57,49 +148,181 @@ export async function getTableSchema(name: string): Promise<TableInfo> {
     return res.json();
 }
 
-// Fetch table data with pagination
+export interface TableDataOptions {
+    sort?: string;
+    dir?: 'asc' | 'desc';
+    filter?: Record<string, string>;
+    search?: string;
+}
+
+// Fetch table data with pagination, sorting, filters and search
 export async function getTableData(
     name: string,
     page = 1,
-    limit = 50
+    limit = 50,
+    options: TableDataOptions = {}
 ): Promise<TableDataResult> {
-    const res = await fetch(`${API_BASE}/tables/${name}/data?page=${page}&limit=${limit}`);
+    const params = new URLSearchParams({ page: String(page), limit: String(limit) });
+    if (options.sort) {
+        params.set('sort', options.sort);
+        if (options.dir) params.set('dir', options.dir);
+    }
+    for (const [col, value] of Object.entries(options.filter ?? {})) {
+        params.set(`filter[${col}]`, value);
+    }
+    if (options.search) params.set('search', options.search);
+    const res = await fetch(`${API_BASE}/tables/${encodeURIComponent(name)}/data?${params}`);
     return res.json();
 }
 
+// Fetch the entity-relationship graph of the schema
+export async function getSchemaGraph(): Promise<SchemaGraph> {
+    const res = await fetch(`${API_BASE}/schema/graph`);
+    return res.json();
+}
+
+// Fetch the schema graph as a Mermaid or DOT diagram
+export async function exportSchemaGraph(format: 'mermaid' | 'dot'): Promise<string> {
+    const res = await fetch(`${API_BASE}/schema/graph?format=${format}`);
+    return res.text();
+}
+
 // Fetch a single record by ID
 export async function getRecordById(table: string, id: number | string): Promise<Record<string, unknown> | null> {
-    const query = `SELECT * FROM ${table} WHERE id = ${id} LIMIT 1`;
-    const result = await executeQuery(query);
-    if (result.data && result.data.length > 0) {
-        return result.data[0];
-    }
-    return null;
+    const result = await getTableData(table, 1, 1, { filter: { id: String(id) } });
+    return result.data?.[0] ?? null;
 }
 
-// Execute SQL query
-export async function executeQuery(query: string): Promise<QueryResult> {
+// Execute SQL query, one or more statements separated by semicolons.
+// timeout bounds each statement in milliseconds.
+export async function executeQuery(query: string, timeout?: number): Promise<QueryResult> {
     const res = await fetch(`${API_BASE}/query`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ query })
+        body: JSON.stringify({ query, timeout })
     });
     return res.json();
 }
 
-// Insert a record
-export async function insertRecord(table: string, data: Record<string, unknown>): Promise<QueryResult> {
-    const columns = Object.keys(data).filter(k => data[k] !== '' && data[k] !== null);
-    const values = columns.map(k => {
-        const v = data[k];
-        if (typeof v === 'string') return `'${v.replace(/'/g, "''")}'`;
-        if (typeof v === 'boolean') return v ? '1' : '0';
-        if (v === null) return 'NULL';
-        return String(v);
+export interface PlanNode {
+    detail: string;
+    scanType: string;
+    index: string;
+    warnings: string[];
+    problem: boolean;
+    properties?: Record<string, string>;
+    children: PlanNode[];
+}
+
+export interface QueryPlan {
+    query: string;
+    dialect: string;
+    raw: string;
+    analyzed: boolean;
+    scanTypes: string[];
+    usedIndexes: string[];
+    warnings: string[];
+    nodes: PlanNode[];
+    error?: string;
+}
+
+// Explain a single statement; analyze runs it for actual timings (SELECT only)
+export async function explainQuery(query: string, analyze = false): Promise<QueryPlan> {
+    const res = await fetch(`${API_BASE}/explain`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ query, analyze })
+    });
+    return res.json();
+}
+
+// Export the last result set of a query as CSV or JSON
+export async function exportQuery(query: string, format: 'csv' | 'json'): Promise<Blob> {
+    const res = await fetch(`${API_BASE}/query`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ query, format })
     });
+    if (!res.ok) {
+        const data = await res.json();
+        throw new Error(data.error || res.statusText);
+    }
+    return res.blob();
+}
+
+// Get the query history, newest first
+export async function getQueryHistory(): Promise<HistoryEntry[]> {
+    const res = await fetch(`${API_BASE}/query/history`);
+    const data = await res.json();
+    return data.history || [];
+}
+
+// Clear the query history
+export async function clearQueryHistory(): Promise<void> {
+    await fetch(`${API_BASE}/query/history`, { method: 'DELETE' });
+}
+
+// Get the saved queries
+export async function getSavedQueries(): Promise<SavedQuery[]> {
+    const res = await fetch(`${API_BASE}/query/saved`);
+    const data = await res.json();
+    return data.queries || [];
+}
+
+// Save a query under a name, replacing any with the same name
+export async function saveQuery(name: string, query: string): Promise<SavedQuery> {
+    const res = await fetch(`${API_BASE}/query/saved`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name, query })
+    });
+    return res.json();
+}
+
+// Delete a saved query
+export async function deleteSavedQuery(name: string): Promise<void> {
+    await fetch(`${API_BASE}/query/saved/${encodeURIComponent(name)}`, { method: 'DELETE' });
+}
+
+// Send a row edit to the table data endpoint
+async function editRecord(
+    method: 'POST' | 'PUT' | 'DELETE',
+    table: string,
+    body: { key?: Record<string, unknown>; values?: Record<string, unknown> }
+): Promise<QueryResult> {
+    const res = await fetch(`${API_BASE}/tables/${encodeURIComponent(table)}/data`, {
+        method,
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body)
+    });
+    return res.json();
+}
+
+// Insert a record, leaving empty fields to their defaults
+export async function insertRecord(table: string, data: Record<string, unknown>): Promise<QueryResult> {
+    const values: Record<string, unknown> = {};
+    for (const [k, v] of Object.entries(data)) {
+        if (v !== '' && v !== null) values[k] = v;
+    }
+    return editRecord('POST', table, { values });
+}
+
+// Update the record with the given primary key, setting empty fields to NULL
+export async function updateRecord(
+    table: string,
+    key: Record<string, unknown>,
+    data: Record<string, unknown>
+): Promise<QueryResult> {
+    const values: Record<string, unknown> = {};
+    for (const [k, v] of Object.entries(data)) {
+        values[k] = v === '' ? null : v;
+    }
+    return editRecord('PUT', table, { key, values });
+}
 
-    const query = `INSERT INTO ${table} (${columns.join(', ')}) VALUES (${values.join(', ')})`;
-    return executeQuery(query);
+// Delete the record with the given primary key
+export async function deleteRecord(table: string, key: Record<string, unknown>): Promise<QueryResult> {
+    return editRecord('DELETE', table, { key });
 }
 
 // Get database info
@@ -115,9 +338,88 @@ export async function getMigrations(): Promise<MigrationInfo[]> {
     return data.migrations || [];
 }
 
-// Run migration action
-export async function runMigration(action: 'up' | 'down'): Promise<{ message?: string; error?: string }> {
+// Run migration action; "downTo" rolls back to target, keeping it applied
+export async function runMigration(
+    action: 'up' | 'down' | 'downTo',
+    target?: string
+): Promise<{ message?: string; error?: string }> {
     const res = await fetch(`${API_BASE}/migrations`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ action, target })
+    });
+    return res.json();
+}
+
+// Get a migration's SQL and validation issues
+export async function getMigration(id: string): Promise<MigrationDetails> {
+    const res = await fetch(`${API_BASE}/migrations/${encodeURIComponent(id)}`);
+    return res.json();
+}
+
+// Validate every migration
+export async function validateMigrations(): Promise<{
+    valid: boolean;
+    results: { id: string; valid: boolean; issues: ValidationIssue[] }[];
+}> {
+    const res = await fetch(`${API_BASE}/migrations/validate`);
+    return res.json();
+}
+
+// Diff the schema against the database, saving the migration if save is set
+export async function diffMigration(name: string, save = false): Promise<MigrationDiff> {
+    const res = await fetch(`${API_BASE}/migrations/diff`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name, save })
+    });
+    return res.json();
+}
+
+export interface QueryProfile {
+    sql: string;
+    args?: unknown[];
+    duration: number;
+    rowsAffected: number;
+    rowsReturned: number;
+    startTime: string;
+    slow: boolean;
+    caller?: string;
+    error?: string;
+    tags?: string[];
+}
+
+export interface ProfileReport {
+    sessionId: string;
+    totalQueries: number;
+    totalDuration: number;
+    averageDuration: number;
+    sessionDuration: number;
+    errorCount: number;
+    sqlCacheHits: number;
+    sqlCacheMisses: number;
+    slowQueries: QueryProfile[];
+    topByDuration: QueryProfile[];
+    topByFrequency: { pattern: string; count: number; totalDuration: number; avgDuration: number }[];
+    nPlusOneWarnings: {
+        pattern: string;
+        count: number;
+        examples: string[];
+        callers: string[];
+        fixes: { model: string; relation: string; include: string; batchLoad: string }[];
+    }[];
+    suggestions: string[];
+}
+
+// Get the attached profiler's report
+export async function getProfilerReport(): Promise<{ enabled: boolean; report: ProfileReport; error?: string }> {
+    const res = await fetch(`${API_BASE}/profiler`);
+    return res.json();
+}
+
+// Start, stop or reset the attached profiler
+export async function controlProfiler(action: 'start' | 'stop' | 'reset'): Promise<{ enabled: boolean }> {
+    const res = await fetch(`${API_BASE}/profiler`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ action })
@@ -125,6 +427,17 @@ export async function runMigration(action: 'up' | 'down'): Promise<{ message?: s
     return res.json();
 }
 
+// Stream queries as the profiler records them; returns a function that stops the stream
+export function streamProfiler(onQuery: (q: QueryProfile) => void): () => void {
+    const proto = location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const ws = new WebSocket(`${proto}//${location.host}${API_BASE}/profiler/stream`);
+    ws.onmessage = (e) => {
+        const msg = JSON.parse(e.data);
+        if (msg.type === 'query') onQuery(msg.query);
+    };
+    return () => ws.close();
+}
+
 // Helper to detect if a column is likely a foreign key
 // Returns the table name if found, null otherwise
 export function isForeignKey(colName: string, availableTables: string[]): string | null {
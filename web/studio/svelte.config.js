@@ -12,6 +12,10 @@ const config = {
             precompress: false,
             strict: true
         }),
+        paths: {
+            // Prefix the studio is mounted under when embedded in an application
+            base: process.env.STUDIO_BASE_PATH ?? ''
+        },
         alias: {
             '$lib': './src/lib',
             '$lib/*': './src/lib/*'